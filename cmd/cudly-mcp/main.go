@@ -9,12 +9,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	gosdk "github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/database"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
 	cudlymcp "github.com/LeanerCloud/CUDly/mcp"
+	"github.com/LeanerCloud/CUDly/mcp/tools"
+	pkgcommon "github.com/LeanerCloud/CUDly/pkg/common"
 	_ "github.com/LeanerCloud/CUDly/providers/aws"
 	_ "github.com/LeanerCloud/CUDly/providers/azure"
 	_ "github.com/LeanerCloud/CUDly/providers/gcp"
@@ -25,14 +32,72 @@ import (
 //	go build -ldflags "-X main.version=1.2.3" ./cmd/cudly-mcp
 var version = "dev"
 
+// databaseEnvVars are the DB_* variables (shared with the deployed
+// service) any of which marks the server as configured with the CUDly
+// database.
+var databaseEnvVars = []string{"DB_HOST", "DB_PASSWORD", "DB_PASSWORD_SECRET"}
+
+// databaseConfigured reports whether any of databaseEnvVars is set.
+func databaseConfigured() bool {
+	for _, name := range databaseEnvVars {
+		if strings.TrimSpace(os.Getenv(name)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// enableCommitmentBudgets wires the commitment budget guard whenever the
+// CUDly database is configured. It fails rather than starting unguarded:
+// a server pointed at the database must not silently spend past its
+// budgets. Without the database the guard stays unset and the server
+// refuses real purchases (see tools.SetBudgetReserver). The returned close
+// func releases the DB connection.
+func enableCommitmentBudgets(ctx context.Context) (func(), error) {
+	if !databaseConfigured() {
+		log.Printf("cudly-mcp: CUDly database not configured (%s); real purchases are disabled because the commitment budget cannot be enforced",
+			strings.Join(databaseEnvVars, ", "))
+		return func() {}, nil
+	}
+	db, err := database.OpenFromEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("the CUDly database is configured but unreachable, refusing to start without the commitment budget: %w", err)
+	}
+	store := config.NewPostgresStore(db)
+	tools.SetBudgetReserver(purchase.NewBudgetReserver(store))
+	tools.SetCloudAccountLookup(cloudAccountLookup(store))
+	return db.Close, nil
+}
+
+// cloudAccountLookup resolves a provider-side account to its CUDly cloud
+// account through store, so MCP purchases count only against the
+// commitment budgets whose account group they land in.
+func cloudAccountLookup(store config.StoreInterface) tools.CloudAccountLookup {
+	return func(ctx context.Context, providerType pkgcommon.ProviderType, externalID string) (string, error) {
+		acct, err := store.GetCloudAccountByExternalID(ctx, string(providerType), externalID)
+		if err != nil || acct == nil {
+			return "", err
+		}
+		return acct.ID, nil
+	}
+}
+
 func main() {
+	ctx := context.Background()
+	closeDB, err := enableCommitmentBudgets(ctx)
+	if err != nil {
+		log.Fatalf("cudly-mcp: %v", err)
+	}
+	defer closeDB()
+
 	server, err := cudlymcp.NewServer(version)
 	if err != nil {
 		log.Fatalf("cudly-mcp: failed to build server: %v", err)
 	}
 
-	if err := server.Run(context.Background(), &gosdk.StdioTransport{}); err != nil {
+	if err := server.Run(ctx, &gosdk.StdioTransport{}); err != nil {
 		log.Printf("cudly-mcp: server exited with error: %v", err)
+		closeDB()
 		os.Exit(1)
 	}
 }
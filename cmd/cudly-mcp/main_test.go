@@ -13,6 +13,8 @@ import (
 
 	cudlymcp "github.com/LeanerCloud/CUDly/mcp"
 	"github.com/LeanerCloud/CUDly/mcp/tools"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
 )
 
 // isolateFromAmbientAWS points the AWS SDK at deliberately nonexistent
@@ -65,6 +67,10 @@ func TestRealPurchasePastProviderRegistration(t *testing.T) {
 	// must clear the operator-side EnvEnableRealPurchases gate too, or every
 	// assertion below would instead observe the gate's own refusal.
 	t.Setenv(tools.EnvEnableRealPurchases, "1")
+	// Likewise the commitment budget gate, which refuses real purchases
+	// when no reserver is installed.
+	tools.SetBudgetReserver(admitAllReserver{})
+	t.Cleanup(func() { tools.SetBudgetReserver(nil) })
 
 	server, err := cudlymcp.NewServer("test-regression")
 	require.NoError(t, err)
@@ -114,3 +120,40 @@ func TestRealPurchasePastProviderRegistration(t *testing.T) {
 	assert.Contains(t, text, "AWS is not configured",
 		"expected a credentials/config-shaped failure once past registration: got %q", text)
 }
+
+// admitAllReserver reserves every request.
+type admitAllReserver struct{}
+
+func (admitAllReserver) Reserve(context.Context, budget.Request) (string, error) { return "res", nil }
+
+func (admitAllReserver) Commit(context.Context, string, float64, float64) error { return nil }
+
+func (admitAllReserver) Release(context.Context, string, string) error { return nil }
+
+func TestEnableCommitmentBudgets_WithoutDatabaseLeavesGuardUnset(t *testing.T) {
+	for _, name := range databaseEnvVars {
+		t.Setenv(name, "")
+	}
+	require.False(t, databaseConfigured())
+	closeDB, err := enableCommitmentBudgets(context.Background())
+	require.NoError(t, err)
+	closeDB()
+
+	// With no reserver installed the purchase tools refuse real purchases.
+	t.Setenv(tools.EnvEnableRealPurchases, "1")
+	t.Setenv("AWS_PROFILE", "budget-gate-test")
+	_, err = tools.ExecutePurchase(context.Background(), tools.PurchaseRequest{
+		Region: "us-east-1", Confirm: true, CredentialScope: "budget-gate-test",
+		Recommendation: common.Recommendation{Provider: common.ProviderAWS, Service: common.ServiceEC2, Count: 1, Term: "1yr"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commitment budget cannot be enforced")
+}
+
+func TestDatabaseConfigured(t *testing.T) {
+	for _, name := range databaseEnvVars {
+		t.Setenv(name, "")
+	}
+	t.Setenv("DB_PASSWORD_SECRET", "arn:aws:secretsmanager:us-east-1:123456789012:secret:cudly-db")
+	assert.True(t, databaseConfigured())
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/spf13/cobra"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// LadderBacktestOptions holds configuration for the ladder-backtest command.
+type LadderBacktestOptions struct {
+	ConfigFile    string
+	SeriesFile    string
+	CERegion      string
+	Profile       string
+	Term          string
+	PaymentOption string
+	OutputJSON    string
+	Discounts     []string
+	CEDays        int
+}
+
+var ladderBacktestOpts = LadderBacktestOptions{}
+
+var ladderBacktestCmd = &cobra.Command{
+	Use:   "ladder-backtest",
+	Short: "Replay a historical on-demand series through a ladder config",
+	Long: `Simulate how a commitment ladder config would have behaved over a historical
+daily on-demand series: tranche firings, expiries, buffer reshapes, realised
+coverage, waste, spend, and the expiry distribution. Nothing is purchased.
+
+The config file is a ladder config as returned by GET /api/ladder/configs.
+The series comes from a CSV (date,usd_per_hour) or JSON fixture:
+  cudly ladder-backtest --config ladder.json --series ondemand.csv
+
+or is fetched once from AWS Cost Explorer before the replay starts:
+  cudly ladder-backtest --config ladder.json --ce-region us-east-1 --ce-days 180
+
+A config with a service (rds, elasticache, opensearch) replays through that
+service's single RI layer, and --ce-region fetches that service's series.`,
+	RunE: runLadderBacktest,
+}
+
+func init() {
+	rootCmd.AddCommand(ladderBacktestCmd)
+
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.ConfigFile, "config", "", "Path to the ladder config JSON file (required)")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.SeriesFile, "series", "", "Path to a daily on-demand series fixture (.csv or .json)")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.CERegion, "ce-region", "", "Fetch the on-demand series (EC2, or the config's service) for this region from AWS Cost Explorer instead of --series")
+	ladderBacktestCmd.Flags().IntVar(&ladderBacktestOpts.CEDays, "ce-days", 180, "Days of Cost Explorer history to fetch with --ce-region")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.Profile, "profile", "", "AWS profile to use with --ce-region")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.Term, "term", string(ladder.Term1Year), "Commitment term (1yr, 3yr)")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.PaymentOption, "payment", string(ladder.PaymentNoUpfront), "Payment option (all-upfront, partial-upfront, no-upfront)")
+	ladderBacktestCmd.Flags().StringSliceVar(&ladderBacktestOpts.Discounts, "discount", nil, "Per-layer discount vs on-demand as layer=pct (e.g. compute-sp=28); repeatable")
+	ladderBacktestCmd.Flags().StringVar(&ladderBacktestOpts.OutputJSON, "output-json", "", "Write the full result, including the daily timeline, to this JSON file")
+	_ = ladderBacktestCmd.MarkFlagRequired("config")
+}
+
+func runLadderBacktest(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	opts := ladderBacktestOpts
+
+	if (opts.SeriesFile == "") == (opts.CERegion == "") {
+		return fmt.Errorf("exactly one of --series or --ce-region is required")
+	}
+	input, err := buildLadderBacktestInput(opts)
+	if err != nil {
+		return err
+	}
+	if opts.CERegion != "" {
+		input.Series, err = fetchCostExplorerSeries(ctx, opts, input.Config.Scope.Service)
+	} else {
+		input.Series, err = loadSeriesFile(opts.SeriesFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := ladder.Backtest(input)
+	if err != nil {
+		return fmt.Errorf("backtest: %w", err)
+	}
+	printBacktestResult(cmd.OutOrStdout(), result)
+
+	if opts.OutputJSON != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+		if err := os.WriteFile(opts.OutputJSON, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", opts.OutputJSON, err)
+		}
+	}
+	return nil
+}
+
+// buildLadderBacktestInput loads and validates everything except the series.
+func buildLadderBacktestInput(opts LadderBacktestOptions) (*ladder.BacktestInput, error) {
+	data, err := os.ReadFile(opts.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var dbCfg config.LadderConfigDB
+	if err := json.Unmarshal(data, &dbCfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := dbCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	provider := common.ProviderType(dbCfg.Provider)
+	// The scope only labels the replay; no cloud call is made against it.
+	accountID := dbCfg.CloudAccountID
+	if accountID == "" {
+		accountID = "backtest"
+	}
+	engineCfg, err := dbCfg.EngineConfig(provider, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	layers, err := ladder.ScopeLayers(engineCfg.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	term, err := ladder.ParseTerm(opts.Term)
+	if err != nil {
+		return nil, fmt.Errorf("--term: %w", err)
+	}
+	payment, err := ladder.ParsePaymentOption(opts.PaymentOption)
+	if err != nil {
+		return nil, fmt.Errorf("--payment: %w", err)
+	}
+	discounts, err := parseLayerDiscounts(opts.Discounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ladder.BacktestInput{
+		Config:        engineCfg,
+		Layers:        layers,
+		Term:          term,
+		PaymentOption: payment,
+		DiscountPct:   discounts,
+	}, nil
+}
+
+// parseLayerDiscounts parses repeated layer=pct flags.
+func parseLayerDiscounts(flags []string) (map[ladder.LayerType]float64, error) {
+	out := make(map[ladder.LayerType]float64, len(flags))
+	for _, f := range flags {
+		name, pct, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("--discount %q: expected layer=pct", f)
+		}
+		layer, err := ladder.ParseLayerType(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("--discount %q: %w", f, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil {
+			return nil, fmt.Errorf("--discount %q: %w", f, err)
+		}
+		out[layer] = v
+	}
+	return out, nil
+}
+
+// loadSeriesFile parses a series fixture by extension (.csv or .json).
+func loadSeriesFile(path string) ([]ladder.DailyPoint, error) {
+	f, err := os.Open(path) // #nosec G304 -- operator-supplied fixture path on the local CLI
+	if err != nil {
+		return nil, fmt.Errorf("failed to open series: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ladder.ParseDailySeriesCSV(f)
+	case ".json":
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read series: %w", err)
+		}
+		return ladder.ParseDailySeriesJSON(data)
+	default:
+		return nil, fmt.Errorf("series file %s: unsupported extension (want .csv or .json)", path)
+	}
+}
+
+// fetchCostExplorerSeries fetches the on-demand series once, before the
+// replay; the replay loop itself makes no cloud calls. service selects a
+// reserved-capacity service's series; empty is the EC2 series.
+func fetchCostExplorerSeries(ctx context.Context, opts LadderBacktestOptions, service common.ServiceType) ([]ladder.DailyPoint, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	loadOpts = append(loadOpts, awsconfig.WithRegion("us-east-1"))
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, awsconfig.WithSharedConfigProfile(opts.Profile))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := recommendations.NewClient(&awsCfg)
+	var costs []recommendations.DailyCost
+	if service != "" {
+		costs, err = client.GetServiceOnDemandSeries(ctx, service, opts.CERegion, opts.CEDays)
+	} else {
+		costs, err = client.GetOnDemandSeries(ctx, opts.CERegion, opts.CEDays)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch on-demand series: %w", err)
+	}
+	points := make([]ladder.DailyPoint, len(costs))
+	for i, c := range costs {
+		points[i] = ladder.DailyPoint{Date: c.Date, USDPerHour: c.USDPerHour}
+	}
+	return points, nil
+}
+
+// printBacktestResult writes a human-readable summary of r to w.
+func printBacktestResult(w io.Writer, r *ladder.BacktestResult) {
+	fmt.Fprintf(w, "Ladder backtest %s .. %s (%d days, %d runs)\n",
+		r.StartDate.Format("2006-01-02"), r.EndDate.Format("2006-01-02"), r.Days, r.Runs)
+	fmt.Fprintf(w, "  Coverage:     %.1f%%\n", r.CoveragePct)
+	if r.UtilizationPct != nil {
+		fmt.Fprintf(w, "  Utilization:  %.1f%%\n", *r.UtilizationPct)
+	} else {
+		fmt.Fprintf(w, "  Utilization:  n/a (nothing committed)\n")
+	}
+	fmt.Fprintf(w, "  Spend:        $%.2f (on-demand only: $%.2f, savings: $%.2f)\n", r.SpendUSD, r.OnDemandOnlySpendUSD, r.SavingsUSD)
+	fmt.Fprintf(w, "  Waste:        $%.2f\n", r.WasteUSD)
+	fmt.Fprintf(w, "  Purchases:    %d buy-now, %d tranches scheduled, %d fired, %d pending ($%.4f/h)\n",
+		r.Purchases, r.TranchesScheduled, r.TrancheFirings, r.PendingTranches, r.PendingUSDPerHour)
+	fmt.Fprintf(w, "  Expiries:     %d   Reshapes: %d   Holds: %d\n", r.Expiries, r.Reshapes, r.Holds)
+	fmt.Fprintf(w, "  Committed at end: $%.4f/h\n", r.EndCommittedUSDPerHour)
+	if len(r.ExpiryDistribution) > 0 {
+		fmt.Fprintln(w, "  Expiry distribution:")
+		for _, b := range r.ExpiryDistribution {
+			fmt.Fprintf(w, "    %s  $%.4f/h  (%d)\n", b.Month, b.USDPerHour, b.Count)
+		}
+	}
+}
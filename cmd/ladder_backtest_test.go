@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+const backtestConfigJSON = `{
+  "cloud_account_id": "acct-1", "provider": "aws", "enabled": true,
+  "mode": "email_approval", "cadence": "daily",
+  "ramp_schedule": {"steps": [{"after_days": 0, "fraction": 1}]},
+  "target_coverage": 100, "buffer_fraction": 0.1, "baseline_percentile": 5,
+  "lookback_days": 30, "max_actions_per_run": 10, "buffer_utilization_threshold": 90
+}`
+
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestLadderBacktest_EndToEndFromFixtures runs the command's building blocks
+// against a config file and a CSV fixture, with no cloud access.
+func TestLadderBacktest_EndToEndFromFixtures(t *testing.T) {
+	var csv strings.Builder
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 45; i++ {
+		fmt.Fprintf(&csv, "%s,10\n", start.AddDate(0, 0, i).Format("2006-01-02"))
+	}
+
+	opts := LadderBacktestOptions{
+		ConfigFile:    writeTempFile(t, "ladder.json", backtestConfigJSON),
+		Term:          "1yr",
+		PaymentOption: "no-upfront",
+		Discounts:     []string{"compute-sp=28", "convertible-ri = 30"},
+	}
+	input, err := buildLadderBacktestInput(opts)
+	require.NoError(t, err)
+	assert.InDelta(t, 30, input.DiscountPct[ladder.LayerConvertibleRI], 1e-9)
+	require.Len(t, input.Layers, 3)
+
+	input.Series, err = loadSeriesFile(writeTempFile(t, "series.csv", csv.String()))
+	require.NoError(t, err)
+
+	result, err := ladder.Backtest(input)
+	require.NoError(t, err)
+	assert.Equal(t, 15, result.Days)
+
+	var out bytes.Buffer
+	printBacktestResult(&out, result)
+	assert.Contains(t, out.String(), "Coverage:")
+	assert.Contains(t, out.String(), "Expiry distribution:")
+}
+
+func TestBuildLadderBacktestInput_ServiceConfigUsesServiceLayer(t *testing.T) {
+	cfg := strings.Replace(backtestConfigJSON, `"buffer_fraction": 0.1`, `"service": "elasticache", "buffer_fraction": 0`, 1)
+	opts := LadderBacktestOptions{
+		ConfigFile:    writeTempFile(t, "ladder.json", cfg),
+		Term:          "1yr",
+		PaymentOption: "no-upfront",
+	}
+	input, err := buildLadderBacktestInput(opts)
+	require.NoError(t, err)
+	require.Len(t, input.Layers, 1)
+	assert.Equal(t, ladder.LayerElastiCacheRI, input.Layers[0].Type)
+}
+
+func TestParseLayerDiscounts_RejectsMalformed(t *testing.T) {
+	for _, f := range []string{"compute-sp", "nope=10", "compute-sp=abc"} {
+		_, err := parseLayerDiscounts([]string{f})
+		assert.Error(t, err, f)
+	}
+}
+
+func TestLoadSeriesFile_RejectsUnknownExtension(t *testing.T) {
+	_, err := loadSeriesFile(writeTempFile(t, "series.txt", "2026-01-01,1\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported extension")
+}
@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/pkg/scorer"
 	_ "github.com/LeanerCloud/CUDly/providers/aws"
 	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/dynamodb"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/memorydb"
@@ -63,6 +66,13 @@ type Config struct {
 	CoverageLookbackDays   int
 	MinCount               int
 	MinSavingsPct          float64
+	DiscountRatePct        float64
+	UsageDropPct           float64
+	MinNPV                 float64
+	MinIRRPct              float64
+	MaxDiscountedPayback   float64
+	MaxWorstCaseLoss       float64
+	SortBy                 string
 	OverrideCount          int32
 	MaxInstances           int32
 	IncludeExtendedSupport bool
@@ -87,7 +97,8 @@ var rootCmd = &cobra.Command{
 	Use:   "ri-helper",
 	Short: "AWS Reserved Instance purchase tool based on Cost Explorer recommendations",
 	Long: `A tool that fetches Reserved Instance recommendations from AWS Cost Explorer
-for multiple services (RDS, ElastiCache, EC2, OpenSearch, Redshift, MemoryDB) and
+for multiple services (RDS, ElastiCache, EC2, OpenSearch, Redshift, MemoryDB,
+DynamoDB) and
 purchases them based on specified coverage percentage. Supports multiple regions.`,
 	PreRunE: validateFlags,
 	Run:     runTool,
@@ -97,7 +108,7 @@ func init() {
 	// Note: We still bind to package-level variables here for cobra's flag system
 	// These will be copied into a ToolConfig in runTool
 	rootCmd.Flags().StringSliceVarP(&toolCfg.Regions, "regions", "r", []string{}, "AWS regions (comma-separated or multiple flags). If empty, auto-discovers regions from recommendations")
-	rootCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to process (rds, elasticache, ec2, opensearch, redshift, memorydb, savingsplans)")
+	rootCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to process (rds, elasticache, ec2, opensearch, redshift, memorydb, dynamodb, savingsplans)")
 	rootCmd.Flags().BoolVar(&toolCfg.AllServices, "all-services", false, "Process all supported services")
 	rootCmd.Flags().Float64VarP(&toolCfg.Coverage, "coverage", "c", 80.0, "Percentage of recommendations to purchase (0-100)")
 	rootCmd.Flags().Float64VarP(&toolCfg.TargetCoverage, "target-coverage", "u", 0,
@@ -137,6 +148,15 @@ func init() {
 	rootCmd.Flags().Float64Var(&toolCfg.MinSavingsPct, "min-savings-pct", 0, "Minimum savings percentage to include a recommendation (0 = no filter)")
 	rootCmd.Flags().IntVar(&toolCfg.MaxBreakEvenMonths, "max-break-even-months", 0, "Maximum break-even period in months (0 = no filter)")
 	rootCmd.Flags().IntVar(&toolCfg.MinCount, "min-count", 0, "Minimum instance count to include a recommendation (0 = no filter)")
+
+	// Financial model flags (pkg/finance)
+	rootCmd.Flags().Float64Var(&toolCfg.DiscountRatePct, "discount-rate", finance.DefaultDiscountRatePct, "Annual cost of capital in percent used for NPV, IRR and discounted payback")
+	rootCmd.Flags().Float64Var(&toolCfg.UsageDropPct, "usage-drop-pct", finance.DefaultUsageDropPct, "Usage drop in percent assumed by the worst-case loss")
+	rootCmd.Flags().StringVar(&toolCfg.SortBy, "sort-by", scorer.SortSavingsPct, "Ranking key for recommendations (savings-pct, npv, irr, discounted-payback)")
+	rootCmd.Flags().Float64Var(&toolCfg.MinNPV, "min-npv", 0, "Minimum net present value to include a recommendation (0 = no filter)")
+	rootCmd.Flags().Float64Var(&toolCfg.MinIRRPct, "min-irr", 0, "Minimum annualised IRR in percent to include a recommendation (0 = no filter)")
+	rootCmd.Flags().Float64Var(&toolCfg.MaxDiscountedPayback, "max-discounted-payback-months", 0, "Maximum discounted payback in months (0 = no filter)")
+	rootCmd.Flags().Float64Var(&toolCfg.MaxWorstCaseLoss, "max-worst-case-loss", 0, "Maximum loss if usage drops by --usage-drop-pct (0 = no filter)")
 	rootCmd.Flags().IntVar(&toolCfg.CoverageLookbackDays, "coverage-lookback-days", 30,
 		"Number of calendar days of historical demand fed to GetReservationCoverage "+
 			"when computing the existing-RI coverage map for --target-coverage sizing. "+
@@ -195,6 +215,7 @@ func parseServices(serviceNames []string) []common.ServiceType {
 		"elasticsearch":             common.ServiceOpenSearch, // Legacy alias maps to OpenSearch
 		"redshift":                  common.ServiceRedshift,
 		"memorydb":                  common.ServiceMemoryDB,
+		"dynamodb":                  common.ServiceDynamoDB,
 		"savingsplans-compute":      common.ServiceSavingsPlansCompute,
 		"savingsplans-ec2instance":  common.ServiceSavingsPlansEC2Instance,
 		"savingsplans-sagemaker":    common.ServiceSavingsPlansSageMaker,
@@ -232,6 +253,7 @@ func getAllServices() []common.ServiceType {
 		common.ServiceOpenSearch,
 		common.ServiceRedshift,
 		common.ServiceMemoryDB,
+		common.ServiceDynamoDB,
 		common.ServiceSavingsPlansCompute,
 		common.ServiceSavingsPlansEC2Instance,
 		common.ServiceSavingsPlansSageMaker,
@@ -254,6 +276,8 @@ func createServiceClient(service common.ServiceType, cfg aws.Config) provider.Se
 		return redshift.NewClient(cfg)
 	case common.ServiceMemoryDB:
 		return memorydb.NewClient(cfg)
+	case common.ServiceDynamoDB:
+		return dynamodb.NewClient(cfg)
 	case common.ServiceSavingsPlansCompute,
 		common.ServiceSavingsPlansEC2Instance,
 		common.ServiceSavingsPlansSageMaker,
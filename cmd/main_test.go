@@ -49,7 +49,7 @@ func TestParseServices(t *testing.T) {
 		},
 		{
 			name:  "All supported services",
-			input: []string{"rds", "elasticache", "ec2", "opensearch", "redshift", "memorydb"},
+			input: []string{"rds", "elasticache", "ec2", "opensearch", "redshift", "memorydb", "dynamodb"},
 			expected: []common.ServiceType{
 				common.ServiceRDS,
 				common.ServiceElastiCache,
@@ -57,6 +57,7 @@ func TestParseServices(t *testing.T) {
 				common.ServiceOpenSearch,
 				common.ServiceRedshift,
 				common.ServiceMemoryDB,
+				common.ServiceDynamoDB,
 			},
 		},
 		{
@@ -86,6 +87,7 @@ func TestGetAllServices(t *testing.T) {
 		common.ServiceOpenSearch,
 		common.ServiceRedshift,
 		common.ServiceMemoryDB,
+		common.ServiceDynamoDB,
 		common.ServiceSavingsPlansCompute,
 		common.ServiceSavingsPlansEC2Instance,
 		common.ServiceSavingsPlansSageMaker,
@@ -11,6 +11,7 @@ import (
 
 	"github.com/LeanerCloud/CUDly/internal/reporter"
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
 	"github.com/LeanerCloud/CUDly/pkg/scorer"
 	awsprovider "github.com/LeanerCloud/CUDly/providers/aws"
@@ -172,7 +173,7 @@ func runPurchaseAndReport(ctx context.Context, awsCfg aws.Config, scoredResult s
 func writeReportAndSummary(passed []common.Recommendation, allResults []common.PurchaseResult, isDryRun bool, cfg Config, drops *common.DropSummary) {
 	serviceStats := buildServiceStats(passed, allResults)
 	finalCSVOutput := generateCSVFilename(isDryRun, cfg)
-	if err := writeMultiServiceCSVReport(allResults, finalCSVOutput); err != nil {
+	if err := writeMultiServiceCSVReport(allResults, finalCSVOutput, cfg.financeParams()); err != nil {
 		log.Printf("Warning: Failed to write CSV output: %v", err)
 	} else {
 		AppLogger.Printf("\n📋 CSV report written to: %s\n", finalCSVOutput)
@@ -208,16 +209,11 @@ func loadAWSConfig(ctx context.Context, cfg Config) (aws.Config, error) {
 //
 // The cap runs between scoring and rendering so the table, the confirmation
 // prompt and the purchase loop all describe the same post-cap set, and so the
-// instances that survive are the highest-savings ones run-wide (scorer.Score
-// sorts Passed by savings percentage descending).
+// instances that survive are the best-ranked ones run-wide (scorer.Score
+// sorts Passed by --sort-by, savings percentage descending by default).
 func scoreLimitAndDisplay(recs []common.Recommendation, cfg Config, drops *common.DropSummary) scorer.ScoredResult {
-	scorerCfg := scorer.Config{
-		MinSavingsPct:      cfg.MinSavingsPct,
-		MaxBreakEvenMonths: cfg.MaxBreakEvenMonths,
-		MinCount:           cfg.MinCount,
-	}
-	result := scorer.Score(recs, scorerCfg)
-	result.Passed = applyGlobalInstanceLimit(result.Passed, cfg, rankBySavingsPercentage, drops)
+	result := scorer.Score(recs, cfg.scorerConfig())
+	result.Passed = applyGlobalInstanceLimit(result.Passed, cfg, rankingRuleFor(cfg.SortBy), drops)
 	fmt.Print(reporter.RenderTable(result))
 	fmt.Print(reporter.RenderExcluded(result))
 	fmt.Print(reporter.RenderSummary(result))
@@ -239,8 +235,49 @@ const (
 	// savings percentage, so sortBySavingsPerInstance derives the rate from
 	// the EstimatedSavings and Count columns it does carry.
 	rankBySavingsPerInstance rankingRule = "highest savings-per-instance rows first (a CSV row carries no savings percentage)"
+	// rankByNPV, rankByIRR and rankByDiscountedPayback are the
+	// recommendation-driven path under --sort-by npv / irr /
+	// discounted-payback: scorer.Score sorts on the pkg/finance figures.
+	rankByNPV               rankingRule = "highest net-present-value recommendations first"
+	rankByIRR               rankingRule = "highest internal-rate-of-return recommendations first"
+	rankByDiscountedPayback rankingRule = "shortest discounted-payback recommendations first"
 )
 
+// rankingRuleFor maps --sort-by to the rule scorer.Score sorted by.
+func rankingRuleFor(sortBy string) rankingRule {
+	switch sortBy {
+	case scorer.SortNPV:
+		return rankByNPV
+	case scorer.SortIRR:
+		return rankByIRR
+	case scorer.SortDiscountedPayback:
+		return rankByDiscountedPayback
+	default:
+		return rankBySavingsPercentage
+	}
+}
+
+// scorerConfig builds the scorer thresholds, sort key and financial
+// assumptions from the CLI flags.
+func (c Config) scorerConfig() scorer.Config {
+	return scorer.Config{
+		MinSavingsPct:              c.MinSavingsPct,
+		MaxBreakEvenMonths:         c.MaxBreakEvenMonths,
+		MinCount:                   c.MinCount,
+		MinNPV:                     c.MinNPV,
+		MinIRRPct:                  c.MinIRRPct,
+		MaxDiscountedPaybackMonths: c.MaxDiscountedPayback,
+		MaxWorstCaseLoss:           c.MaxWorstCaseLoss,
+		SortBy:                     c.SortBy,
+		Finance:                    c.financeParams(),
+	}
+}
+
+// financeParams returns the --discount-rate / --usage-drop-pct assumptions.
+func (c Config) financeParams() finance.Params {
+	return finance.Params{DiscountRatePct: c.DiscountRatePct, UsageDropPct: c.UsageDropPct}
+}
+
 // capBinds reports whether --max-instances will actually remove instances from
 // recs, rather than being unset or already satisfied.
 //
@@ -576,7 +613,7 @@ func runToolFromCSV(ctx context.Context, cfg Config) error {
 	finalCSVOutput := generateCSVFilename(isDryRun, cfg)
 
 	// Write CSV report
-	if err := writeMultiServiceCSVReport(allResults, finalCSVOutput); err != nil {
+	if err := writeMultiServiceCSVReport(allResults, finalCSVOutput, cfg.financeParams()); err != nil {
 		log.Printf("Warning: Failed to write CSV output: %v", err)
 	} else {
 		AppLogger.Printf("\n📋 CSV report written to: %s\n", finalCSVOutput)
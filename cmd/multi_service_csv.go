@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
 )
 
@@ -189,8 +190,9 @@ func parseCSVFloat(record []string, colIdx map[string]int, fieldName string, tar
 	return nil
 }
 
-// writeMultiServiceCSVReport writes purchase results to a CSV file.
-func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string) error {
+// writeMultiServiceCSVReport writes purchase results to a CSV file. The
+// financial columns evaluate each row under params (see csvFinanceCells).
+func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string, params finance.Params) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -220,7 +222,9 @@ func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string
 	// are NOT emitted because under under-buy sizing both land at ~100% on
 	// every row, which adds noise without information; the underlying fields
 	// stay on the Recommendation struct for internal use (SP no-signal
-	// guard, etc.).
+	// guard, etc.). NPV, IRR, DiscountedPaybackMonths and WorstCaseLoss are
+	// the pkg/finance view of the row at --discount-rate and
+	// --usage-drop-pct.
 	header := []string{
 		"Service", "Region", "ResourceType", "Family", "Engine", "Deployment",
 		"Instances", "CoveredInstances",
@@ -229,6 +233,7 @@ func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string
 		"UpfrontPayment", "RecurringMonthlyCost", "EstimatedSavings",
 		"CommitmentID", "Success", "Error", "Timestamp",
 		"ExistingCoverage", "ProjectedCoverage",
+		"NPV", "IRR", "DiscountedPaybackMonths", "WorstCaseLoss",
 	}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
@@ -279,6 +284,7 @@ func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string
 			formatExistingCoverage(rec),
 			formatPercentOrBlank(rec.ProjectedCoverage),
 		}
+		row = append(row, csvFinanceCells(rec, params)...)
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write CSV row: %w", err)
 		}
@@ -290,7 +296,7 @@ func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string
 	// the Service column for easy spotting; columns that don't aggregate
 	// meaningfully (per-rec identifiers, timestamps, %) stay blank.
 	if len(sorted) > 0 {
-		totalRow := buildTotalRow(sorted)
+		totalRow := buildTotalRow(sorted, params)
 		if err := writer.Write(totalRow); err != nil {
 			return fmt.Errorf("failed to write CSV total row: %w", err)
 		}
@@ -303,13 +309,20 @@ func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string
 // returns a row aligned to the same header order as writeCSVRowsOrdered.
 // Non-summable cells (per-rec identifiers, percentages, timestamps) are
 // blank; the "TOTAL" label lands in Service so the row reads as a
-// summary at first glance.
-func buildTotalRow(results []common.PurchaseResult) []string {
+// summary at first glance. NPV and WorstCaseLoss sum over the rows that
+// could be evaluated; IRR and payback do not aggregate.
+func buildTotalRow(results []common.PurchaseResult, params finance.Params) []string {
 	var totalCount int
 	var totalNU, totalUpfront, totalRecurring, totalSavings float64
-	hasRecurring := false
+	var totalNPV, totalWorstCaseLoss float64
+	hasRecurring, hasFinance := false, false
 	for i := range results {
 		r := results[i]
+		if ev, err := finance.Evaluate(r.Recommendation, params); err == nil {
+			totalNPV += ev.NPV
+			totalWorstCaseLoss += ev.WorstCaseLoss
+			hasFinance = true
+		}
 		totalCount += r.Recommendation.Count
 		totalNU += float64(r.Recommendation.Count) * recommendations.RDSInstanceNUFromType(r.Recommendation.ResourceType)
 		totalUpfront += r.Recommendation.CommitmentCost
@@ -327,6 +340,11 @@ func buildTotalRow(results []common.PurchaseResult) []string {
 	if totalNU > 0 {
 		nuCell = fmt.Sprintf("%g", totalNU)
 	}
+	npvCell, lossCell := "", ""
+	if hasFinance {
+		npvCell = fmt.Sprintf("%.2f", totalNPV)
+		lossCell = fmt.Sprintf("%.2f", totalWorstCaseLoss)
+	}
 	return []string{
 		"TOTAL", "", "", "", "", "", // Service through Deployment
 		"", "", // Instances, CoveredInstances
@@ -335,7 +353,27 @@ func buildTotalRow(results []common.PurchaseResult) []string {
 		fmt.Sprintf("%.2f", totalUpfront), recurringCell, fmt.Sprintf("%.2f", totalSavings),
 		"", "", "", "", // CommitmentID, Success, Error, Timestamp
 		"", "", // ExistingCoverage, ProjectedCoverage
+		npvCell, "", "", lossCell, // NPV, IRR, DiscountedPaybackMonths, WorstCaseLoss
+	}
+}
+
+// csvFinanceCells returns the NPV, IRR, DiscountedPaybackMonths and
+// WorstCaseLoss cells for rec. All four are blank when rec cannot be
+// evaluated (e.g. a Savings Plan row with no term); IRR is blank without
+// an upfront outlay and the payback when the row never pays back.
+func csvFinanceCells(rec common.Recommendation, params finance.Params) []string {
+	ev, err := finance.Evaluate(rec, params)
+	if err != nil {
+		return []string{"", "", "", ""}
+	}
+	irrCell, paybackCell := "", ""
+	if ev.IRRPct != nil {
+		irrCell = fmt.Sprintf("%.1f", *ev.IRRPct)
+	}
+	if ev.DiscountedPaybackMonths != nil {
+		paybackCell = fmt.Sprintf("%.1f", *ev.DiscountedPaybackMonths)
 	}
+	return []string{fmt.Sprintf("%.2f", ev.NPV), irrCell, paybackCell, fmt.Sprintf("%.2f", ev.WorstCaseLoss)}
 }
 
 // formatIntOrBlank renders an int as its decimal string when non-zero, ""
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -177,7 +178,7 @@ func TestWriteMultiServiceCSVReport(t *testing.T) {
 			tmpDir := t.TempDir()
 			filepath := tmpDir + "/" + tt.filename
 
-			err := writeMultiServiceCSVReport(tt.results, filepath)
+			err := writeMultiServiceCSVReport(tt.results, filepath, finance.DefaultParams())
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -239,7 +240,7 @@ func TestWriteMultiServiceCSVReport_CoverageColumn(t *testing.T) {
 		},
 	}
 
-	err := writeMultiServiceCSVReport(results, filepath)
+	err := writeMultiServiceCSVReport(results, filepath, finance.DefaultParams())
 	require.NoError(t, err)
 
 	content, err := os.ReadFile(filepath)
@@ -343,7 +344,7 @@ func TestWriteMultiServiceCSVReport_SortAndTotal(t *testing.T) {
 		{Recommendation: common.Recommendation{Service: "rds", ResourceType: "db.r6g.2xlarge", Count: 2, CommitmentCost: 20000, EstimatedSavings: 1500}},
 		{Recommendation: common.Recommendation{Service: "rds", ResourceType: "db.t4g.medium", Count: 4, CommitmentCost: 1000, EstimatedSavings: 80}},
 	}
-	require.NoError(t, writeMultiServiceCSVReport(results, fp))
+	require.NoError(t, writeMultiServiceCSVReport(results, fp, finance.DefaultParams()))
 	content, err := os.ReadFile(fp)
 	require.NoError(t, err)
 
@@ -851,3 +852,34 @@ func TestLoadRecommendationsFromCSV_FileErrors(t *testing.T) {
 		})
 	}
 }
+
+// TestWriteMultiServiceCSVReport_FinanceColumns checks the pkg/finance
+// columns: a 1yr row repaying 1100 upfront with 100/month at a 0% discount
+// rate pays back in month 11 and, with usage halved, loses 500; the TOTAL
+// row sums NPV and WorstCaseLoss only.
+func TestWriteMultiServiceCSVReport_FinanceColumns(t *testing.T) {
+	fp := t.TempDir() + "/finance.csv"
+	recurring := 0.0
+	results := []common.PurchaseResult{
+		{Recommendation: common.Recommendation{
+			Service: common.ServiceEC2, ResourceType: "m5.large", Count: 1, Term: "1yr", PaymentOption: "all-upfront",
+			CommitmentCost: 1100, OnDemandCost: 100, RecurringMonthlyCost: &recurring,
+		}},
+		{Recommendation: common.Recommendation{Service: common.ServiceEC2, ResourceType: "c5.large", Count: 1}},
+	}
+	require.NoError(t, writeMultiServiceCSVReport(results, fp, finance.Params{UsageDropPct: 50}))
+	content, err := os.ReadFile(fp)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	header := rows[0]
+	require.Equal(t, []string{"NPV", "IRR", "DiscountedPaybackMonths", "WorstCaseLoss"}, header[len(header)-4:])
+	for _, row := range rows {
+		require.Len(t, row, len(header), "every row must align with the header")
+	}
+	assert.Equal(t, []string{"100.00", "17.7", "11.0", "500.00"}, rows[1][len(header)-4:])
+	assert.Equal(t, []string{"", "", "", ""}, rows[2][len(header)-4:], "a row with no term cannot be evaluated")
+	assert.Equal(t, []string{"100.00", "", "", "500.00"}, rows[3][len(header)-4:])
+}
@@ -45,6 +45,8 @@ func getServiceDisplayName(service common.ServiceType) string {
 		return "Redshift"
 	case common.ServiceMemoryDB:
 		return "MemoryDB"
+	case common.ServiceDynamoDB:
+		return "DynamoDB"
 	}
 	if name, ok := savingsPlanDisplayName(service); ok {
 		return name
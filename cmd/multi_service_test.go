@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/LeanerCloud/CUDly/pkg/scorer"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
@@ -1116,7 +1117,7 @@ func TestWriteMultiServiceCSVReport_EmptyResults(t *testing.T) {
 	tmpFile := "/tmp/test_empty_results.csv"
 	defer os.Remove(tmpFile)
 
-	err := writeMultiServiceCSVReport([]common.PurchaseResult{}, tmpFile)
+	err := writeMultiServiceCSVReport([]common.PurchaseResult{}, tmpFile, finance.DefaultParams())
 	assert.NoError(t, err)
 
 	// File should not be created for empty results
@@ -1147,7 +1148,7 @@ func TestWriteMultiServiceCSVReport_Success(t *testing.T) {
 		},
 	}
 
-	err := writeMultiServiceCSVReport(results, tmpFile)
+	err := writeMultiServiceCSVReport(results, tmpFile, finance.DefaultParams())
 	assert.NoError(t, err)
 
 	// Verify file was created and has content
@@ -1179,7 +1180,7 @@ func TestWriteMultiServiceCSVReport_WithError(t *testing.T) {
 		},
 	}
 
-	err := writeMultiServiceCSVReport(results, tmpFile)
+	err := writeMultiServiceCSVReport(results, tmpFile, finance.DefaultParams())
 	assert.NoError(t, err)
 
 	// Verify error is included in CSV
@@ -1206,7 +1207,7 @@ func TestWriteMultiServiceCSVReport_InvalidPath(t *testing.T) {
 		},
 	}
 
-	err := writeMultiServiceCSVReport(results, invalidPath)
+	err := writeMultiServiceCSVReport(results, invalidPath, finance.DefaultParams())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create CSV file")
 }
@@ -1242,7 +1243,7 @@ func TestWriteMultiServiceCSVReport_MultipleResults(t *testing.T) {
 		},
 	}
 
-	err := writeMultiServiceCSVReport(results, tmpFile)
+	err := writeMultiServiceCSVReport(results, tmpFile, finance.DefaultParams())
 	assert.NoError(t, err)
 
 	// Verify both results are in CSV
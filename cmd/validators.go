@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/scorer"
 	"github.com/spf13/cobra"
 )
 
@@ -37,6 +38,22 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := validateFinanceFlags(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateFinanceFlags validates --sort-by, --discount-rate and
+// --usage-drop-pct, and refuses negative financial thresholds.
+func validateFinanceFlags() error {
+	if err := toolCfg.scorerConfig().Validate(); err != nil {
+		return err
+	}
+	if toolCfg.MinIRRPct < 0 || toolCfg.MaxDiscountedPayback < 0 || toolCfg.MaxWorstCaseLoss < 0 {
+		return fmt.Errorf("--min-irr, --max-discounted-payback-months and --max-worst-case-loss must be 0 (no filter) or positive")
+	}
 	return nil
 }
 
@@ -196,6 +213,16 @@ func validateCSVModeFilterFlags() error {
 		return fmt.Errorf("--max-break-even-months cannot be applied to --input-csv runs: a recommendations CSV carries no break-even column, so the threshold would be silently ignored (see #1819). Remove --max-break-even-months, or filter the CSV before passing it in")
 	}
 
+	// The CSV path ranks and filters in scoreAndLimitCSVRecs, which never
+	// evaluates the financial keys.
+	if toolCfg.MinNPV != 0 || toolCfg.MinIRRPct > 0 || toolCfg.MaxDiscountedPayback > 0 || toolCfg.MaxWorstCaseLoss > 0 {
+		return fmt.Errorf("--min-npv, --min-irr, --max-discounted-payback-months and --max-worst-case-loss cannot be applied to --input-csv runs: the CSV path does not evaluate the financial filters. Remove them, or filter the CSV before passing it in")
+	}
+
+	if toolCfg.SortBy != "" && toolCfg.SortBy != scorer.SortSavingsPct {
+		return fmt.Errorf("--sort-by %s cannot be applied to --input-csv runs: CSV rows are ranked by savings per instance", toolCfg.SortBy)
+	}
+
 	return nil
 }
 
@@ -636,6 +636,8 @@ func TestValidateCSVModeFilterFlags(t *testing.T) {
 		errSubstr          string
 		minSavingsPct      float64
 		maxBreakEvenMonths int
+		minNPV             float64
+		sortBy             string
 	}{
 		{
 			name:          "min-savings-pct refused in CSV mode",
@@ -649,6 +651,18 @@ func TestValidateCSVModeFilterFlags(t *testing.T) {
 			maxBreakEvenMonths: 12,
 			errSubstr:          "--max-break-even-months cannot be applied to --input-csv",
 		},
+		{
+			name:      "financial filters refused in CSV mode",
+			csvInput:  "recs.csv",
+			minNPV:    100,
+			errSubstr: "cannot be applied to --input-csv runs: the CSV path does not evaluate",
+		},
+		{
+			name:      "financial sort key refused in CSV mode",
+			csvInput:  "recs.csv",
+			sortBy:    "npv",
+			errSubstr: "--sort-by npv cannot be applied to --input-csv",
+		},
 		{
 			// Both flags default to 0, so an ordinary CSV run is unaffected.
 			name:     "CSV mode without the thresholds is accepted",
@@ -669,6 +683,8 @@ func TestValidateCSVModeFilterFlags(t *testing.T) {
 			toolCfg.CSVInput = tt.csvInput
 			toolCfg.MinSavingsPct = tt.minSavingsPct
 			toolCfg.MaxBreakEvenMonths = tt.maxBreakEvenMonths
+			toolCfg.MinNPV = tt.minNPV
+			toolCfg.SortBy = tt.sortBy
 
 			err := validateCSVModeFilterFlags()
 			if tt.errSubstr == "" {
@@ -686,3 +702,38 @@ func TestValidateCSVModeFilterFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateFinanceFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*Config)
+		errSubstr string
+	}{
+		{name: "defaults accepted", mutate: func(*Config) {}},
+		{name: "npv sort accepted", mutate: func(c *Config) { c.SortBy = "npv" }},
+		{name: "unknown sort key rejected", mutate: func(c *Config) { c.SortBy = "roi" }, errSubstr: "invalid sort key"},
+		{name: "discount rate above 100 rejected", mutate: func(c *Config) { c.DiscountRatePct = 120 }, errSubstr: "discount rate"},
+		{name: "negative usage drop rejected", mutate: func(c *Config) { c.UsageDropPct = -5 }, errSubstr: "usage drop"},
+		{name: "negative worst-case cap rejected", mutate: func(c *Config) { c.MaxWorstCaseLoss = -1 }, errSubstr: "--max-worst-case-loss"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origCfg := toolCfg
+			defer func() { toolCfg = origCfg }()
+			toolCfg.SortBy = ""
+			toolCfg.DiscountRatePct = 8
+			toolCfg.UsageDropPct = 30
+			tt.mutate(&toolCfg)
+			err := validateFinanceFlags()
+			if tt.errSubstr == "" {
+				if err != nil {
+					t.Errorf("validateFinanceFlags() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("validateFinanceFlags() error = %v, want substring %q", err, tt.errSubstr)
+			}
+		})
+	}
+}
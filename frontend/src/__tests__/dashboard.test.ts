@@ -46,7 +46,7 @@ jest.mock('../recommendations', () => ({
   triggerAutoRefreshIfStale: jest.fn(() => Promise.resolve()),
 }));
 
-import { loadDashboard } from '../dashboard';
+import { loadDashboard, budgetTile } from '../dashboard';
 import { Chart } from 'chart.js';
 
 // Mock the api module. `getSavingsAnalytics` is re-exported from
@@ -2002,3 +2002,32 @@ describe('Dashboard Module', () => {
     });
   });
 });
+
+describe('budgetTile', () => {
+  const usage = {
+    budget: { id: 'b1', name: 'org', period: 'quarter' as const, max_upfront_usd: 1000, max_hourly_usd: 2, enabled: true },
+    period_start: '2026-10-01T00:00:00Z',
+    period_end: '2027-01-01T00:00:00Z',
+    used_upfront_usd: 400,
+    used_hourly_usd: 0.5,
+    remaining_upfront_usd: 600,
+    remaining_hourly_usd: 1.5,
+  };
+
+  it('leads with the upfront remainder and names the hourly one', () => {
+    const tile = budgetTile(usage);
+    expect(tile.title).toBe('Budget: org');
+    expect(tile.value).toBe('$600');
+    expect(tile.detail).toBe('of $1000 upfront left this quarter; $1.5/h of $2/h left');
+  });
+
+  it('shows the hourly remainder when upfront is uncapped', () => {
+    const tile = budgetTile({
+      ...usage,
+      budget: { ...usage.budget, period: 'month', max_upfront_usd: undefined },
+      remaining_upfront_usd: undefined,
+    });
+    expect(tile.value).toBe('$1.5/h');
+    expect(tile.detail).toBe('of $2/h new commitment left this month');
+  });
+});
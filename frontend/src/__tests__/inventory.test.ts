@@ -138,6 +138,7 @@ function makeCommitment(overrides: Partial<api.InventoryCommitment> = {}): api.I
     monthly_cost: 240.5,
     estimated_savings: 80.0,
     status: 'active',
+    source: 'cudly',
     ...overrides,
   };
 }
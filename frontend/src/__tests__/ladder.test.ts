@@ -125,6 +125,20 @@ describe('ladder.ts', () => {
     });
   });
 
+  describe('renderConfigTable mode labels', () => {
+    test.each([
+      ['email_approval', 'Email Approval'],
+      ['auto_approve', 'Auto Approve'],
+      ['shadow', 'Shadow'],
+    ] as const)('%s renders as %s', async (mode, label) => {
+      await renderSection();
+      renderConfigTable([baseConfig({ mode })]);
+
+      const cells = document.querySelectorAll('#ladder-configs-table-container tbody tr td');
+      expect(cells[3]?.textContent).toBe(label);
+    });
+  });
+
   describe('saveLadderConfig max_hourly guard', () => {
     // Fill the modal form with a valid baseline so save reaches the store,
     // then override max-hourly per case.
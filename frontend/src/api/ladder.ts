@@ -36,8 +36,10 @@ export interface LadderConfig {
   id?: string;
   cloud_account_id: string;
   provider: string;
+  /** Empty/absent = the compute ladder; rds, elasticache or opensearch = that service's RI ladder (AWS only) */
+  service?: string;
   enabled: boolean;
-  mode: 'email_approval' | 'auto_approve';
+  mode: 'email_approval' | 'auto_approve' | 'shadow';
   cadence: 'daily' | 'weekly';
   target_coverage: number;
   buffer_fraction: number;
@@ -63,7 +65,7 @@ export async function getLadderConfigs(): Promise<LadderConfig[]> {
 
 /**
  * Upsert (insert or update) a per-account ladder configuration.
- * The upsert key is (cloud_account_id, provider).
+ * The upsert key is (cloud_account_id, provider, service).
  * Requires update:config permission.
  */
 export async function upsertLadderConfig(cfg: LadderConfig): Promise<LadderConfig> {
@@ -18,6 +18,7 @@ export async function getRecommendations(filters: RecommendationFilters = {}): P
   // min_savings_pct: percentage floor (mirrors the CLI --min-savings-pct semantics)
   if (filters.minSavingsPct) params.set('min_savings_pct', String(filters.minSavingsPct));
   if (filters.account_ids && filters.account_ids.length > 0) params.set('account_ids', filters.account_ids.join(','));
+  if (filters.source) params.set('source', filters.source);
 
   const queryString = params.toString();
   return apiRequest<Recommendation[]>(`/recommendations${queryString ? '?' + queryString : ''}`);
@@ -94,7 +94,31 @@ export interface DashboardSummary {
   current_coverage: number;
   target_coverage: number;
   ytd_savings: number;
+  // RI recommendations a Savings Plan recommendation already covers, and
+  // the monthly savings at risk if both are bought.
+  overlapping_count?: number;
+  overlap_savings_at_risk?: number;
   by_service: Record<string, { potential_savings: number; current_savings: number }>;
+  // Enabled commitment budgets' use and remainder in their current period.
+  commitment_budgets?: CommitmentBudgetUsage[];
+}
+
+export interface CommitmentBudgetUsage {
+  budget: {
+    id: string;
+    name: string;
+    period: 'month' | 'quarter';
+    account_group_id?: string;
+    max_upfront_usd?: number;
+    max_hourly_usd?: number;
+    enabled: boolean;
+  };
+  period_start: string;
+  period_end: string;
+  used_upfront_usd: number;
+  used_hourly_usd: number;
+  remaining_upfront_usd?: number;
+  remaining_hourly_usd?: number;
 }
 
 export interface UpcomingPurchase {
@@ -161,6 +185,15 @@ export interface Recommendation {
   // oldest-to-newest). Absent/null when the provider did not populate it
   // (non-AWS providers or pre-#239 cached rows).
   usage_history?: number[] | null;
+  // Share (0-100) of this RI's usage that Savings Plan recommendations in
+  // overlap_with already cover, and the count left once it is removed.
+  // Absent when nothing overlaps.
+  overlap_covered_pct?: number;
+  overlap_adjusted_count?: number;
+  overlap_with?: string[];
+  // Engine that produced the rec: the provider recommendation API ('vendor')
+  // or CUDly's native recommender ('native').
+  source?: 'vendor' | 'native';
   // vcpu / memory_gb surface the compute size of the recommended instance
   // type so the Capacity column can render "<vcpu> vCPU / <memory_gb> GB"
   // (#219). Emitted top-level by the backend (buildRecommendationsResponse
@@ -194,6 +227,8 @@ export interface RecommendationFilters {
    */
   minSavingsPct?: number;
   account_ids?: string[];
+  /** Keep only recommendations from this engine; absent means both. */
+  source?: 'vendor' | 'native';
 }
 
 // PlanFilters are the query parameters accepted by the GET /api/plans endpoint.
@@ -216,6 +251,12 @@ export interface Plan {
   name: string;
   enabled: boolean;
   auto_purchase: boolean;
+  // shadow_mode plans record what they would have bought instead of buying
+  // it; see GET /api/shadow-purchases/report.
+  shadow_mode?: boolean;
+  // allow_double_coverage lets the plan buy usage an RI / Savings Plan it
+  // or another plan buys already covers.
+  allow_double_coverage?: boolean;
   notification_days_before: number;
   services: Record<string, ServiceConfig>;
   ramp_schedule: PlanRampSchedule;
@@ -251,6 +292,8 @@ export interface CreatePlanRequest {
   name: string;
   enabled: boolean;
   auto_purchase: boolean;
+  shadow_mode?: boolean;
+  allow_double_coverage?: boolean;
   notification_days_before: number;
   services: Record<string, ServiceConfig>;
   ramp_schedule: PlanRampSchedule;
@@ -326,6 +369,19 @@ export interface Config {
   // Must be 7, 30, or 60 (AWS LookbackPeriodInDays enum). Default: 7.
   // GCP CUD Recommender has no equivalent parameter; applies to AWS only.
   recommendations_lookback_days?: number;
+  // Where recommendations come from: provider APIs ("vendor", default),
+  // the native engine computed from usage and offering prices ("native"),
+  // or vendor plus native recs for pools the vendor missed ("both").
+  recommendation_source?: 'vendor' | 'native' | 'both';
+  // Native engine knobs: usage lookback (1–365 days, default 30), the
+  // uncovered-demand percentile commitments are sized to (default 10), and
+  // the maximum break-even in months (0 = no cap).
+  native_rec_lookback_days?: number;
+  native_rec_percentile?: number;
+  native_rec_max_break_even_months?: number;
+  // Annual cost of capital (percent) used for recommendation NPV, IRR and
+  // discounted payback. Unset means the default (8%).
+  finance_discount_rate_pct?: number;
   // Global kill-switch for the commitment-laddering feature (issue #1336).
   // Default false. When true, per-account LadderConfig.enabled settings
   // determine whether the engine runs for that account.
@@ -876,6 +932,8 @@ export interface InventoryCommitment {
    * soon" sub-state can land without a breaking API change.
    */
   status: string;
+  /** "cudly" for commitments CUDly purchased; "external" for ones the inventory sync found that were bought elsewhere. */
+  source: 'cudly' | 'external';
 }
 
 // Coverage breakdown types (issue #754)
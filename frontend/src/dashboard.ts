@@ -6,7 +6,7 @@ import { Chart, registerables } from 'chart.js';
 import * as api from './api';
 import * as state from './state';
 import { formatCurrency, getDateParts, providerBadgeClass } from './utils';
-import type { DashboardSummary, UpcomingPurchase, ServiceSavings, LocalRecommendation } from './types';
+import type { DashboardSummary, UpcomingPurchase, ServiceSavings, LocalRecommendation, CommitmentBudgetUsage } from './types';
 import type { SavingsDataPoint } from './api';
 import { showToast } from './toast';
 import { confirmDialog } from './confirmDialog';
@@ -293,13 +293,7 @@ function renderDashboardSummary(data: DashboardSummary, recs: readonly LocalReco
   // XSS constraint. The values are all backend-sourced numbers/strings,
   // but the safe-by-default pattern is cheap and removes the question.
   while (summary.firstChild) summary.removeChild(summary.firstChild);
-  const tiles: ReadonlyArray<{
-    kpi: string;
-    title: string;
-    value: string;
-    valueSavings?: boolean;
-    detail: string;
-  }> = [
+  const tiles: KPITile[] = [
     { kpi: 'savings',     title: 'Potential Monthly Savings', value: savingsDisplay, valueSavings: true,
       detail: `${data.total_recommendations ?? '--'} recommendations` },
     { kpi: 'commitments', title: 'Active Commitments', value: data.active_commitments != null ? String(data.active_commitments) : '--',
@@ -307,6 +301,7 @@ function renderDashboardSummary(data: DashboardSummary, recs: readonly LocalReco
     { kpi: 'coverage',    title: 'Current Coverage', value: coverageValue, detail: coverageDetail },
     { kpi: 'ytd',         title: 'YTD Savings', value: formatCurrency(data.ytd_savings), valueSavings: true,
       detail: 'From commitment purchases' },
+    ...(data.commitment_budgets ?? []).map(budgetTile),
   ];
   for (const t of tiles) {
     const card = document.createElement('div');
@@ -333,6 +328,41 @@ function renderDashboardSummary(data: DashboardSummary, recs: readonly LocalReco
   }
 }
 
+interface KPITile {
+  kpi: string;
+  title: string;
+  value: string;
+  valueSavings?: boolean;
+  detail: string;
+}
+
+/**
+ * Build the KPI tile for one commitment budget: what is left of its upfront
+ * cap this period (or of its hourly cap when upfront is uncapped), with the
+ * other limit in the detail line. Pure helper — no DOM access.
+ */
+export function budgetTile(u: CommitmentBudgetUsage): KPITile {
+  const period = u.budget.period === 'quarter' ? 'this quarter' : 'this month';
+  const hourlyLeft = u.remaining_hourly_usd != null
+    ? `${formatCurrency(u.remaining_hourly_usd, '$', 4)}/h of ${formatCurrency(u.budget.max_hourly_usd, '$', 4)}/h left`
+    : '';
+  if (u.remaining_upfront_usd != null) {
+    const upfront = `of ${formatCurrency(u.budget.max_upfront_usd)} upfront left ${period}`;
+    return {
+      kpi: `budget-${u.budget.id}`,
+      title: `Budget: ${u.budget.name}`,
+      value: formatCurrency(u.remaining_upfront_usd),
+      detail: hourlyLeft ? `${upfront}; ${hourlyLeft}` : upfront,
+    };
+  }
+  return {
+    kpi: `budget-${u.budget.id}`,
+    title: `Budget: ${u.budget.name}`,
+    value: `${formatCurrency(u.remaining_hourly_usd, '$', 4)}/h`,
+    detail: `of ${formatCurrency(u.budget.max_hourly_usd, '$', 4)}/h new commitment left ${period}`,
+  };
+}
+
 /**
  * Build a small SVG polyline path string from a series of numeric values,
  * normalized into a width × height viewport. Returns the points string for
@@ -160,6 +160,7 @@ function renderLadderingSection(globalEnabled: boolean): string {
           <select id="ladder-cfg-mode">
             <option value="email_approval">Email Approval</option>
             <option value="auto_approve">Auto Approve</option>
+            <option value="shadow">Shadow (record only, never buy)</option>
           </select>
         </div>
 
@@ -239,6 +240,16 @@ function renderLadderingSection(globalEnabled: boolean): string {
 `;
 }
 
+// ladderModeLabel is the table label of a config's approval mode. Shadow
+// configs plan as usual but only record what they would have bought.
+function ladderModeLabel(mode: api.LadderConfig['mode']): string {
+  switch (mode) {
+    case 'email_approval': return 'Email Approval';
+    case 'shadow': return 'Shadow';
+    default: return 'Auto Approve';
+  }
+}
+
 // Exported for unit testing (mirrors the apikeys.ts convention of exposing
 // render/action helpers so tests can drive them directly).
 export function renderConfigTable(configs: api.LadderConfig[]): void {
@@ -256,7 +267,7 @@ export function renderConfigTable(configs: api.LadderConfig[]): void {
       <td>${escapeHtml(cfg.cloud_account_id)}</td>
       <td>${escapeHtml(cfg.provider.toUpperCase())}</td>
       <td>${cfg.enabled ? 'Yes' : 'No'}</td>
-      <td>${escapeHtml(cfg.mode === 'email_approval' ? 'Email Approval' : 'Auto Approve')}</td>
+      <td>${escapeHtml(ladderModeLabel(cfg.mode))}</td>
       <td>${escapeHtml(cfg.cadence === 'daily' ? 'Daily' : 'Weekly')}</td>
       <td>${cfg.target_coverage.toFixed(1)}%</td>
       <td>${cfg.updated_at ? escapeHtml(formatDate(cfg.updated_at)) : 'N/A'}</td>
@@ -840,6 +840,8 @@ interface BackendPlan {
   name: string;
   enabled: boolean;
   auto_purchase: boolean;
+  shadow_mode?: boolean;
+  allow_double_coverage?: boolean;
   notification_days_before: number;
   services?: Record<string, {
     provider: string;
@@ -1269,6 +1271,10 @@ async function editPlan(planId: string): Promise<boolean> {
     // blank so the user sees the field is missing (H-4).
     (document.getElementById('plan-coverage') as HTMLInputElement).value = info.coverage !== null ? String(info.coverage) : '';
     (document.getElementById('plan-auto-purchase') as HTMLInputElement).checked = backendPlan.auto_purchase;
+    const shadowInput = document.getElementById('plan-shadow-mode') as HTMLInputElement | null;
+    if (shadowInput) shadowInput.checked = backendPlan.shadow_mode ?? false;
+    const doubleCoverageInput = document.getElementById('plan-allow-double-coverage') as HTMLInputElement | null;
+    if (doubleCoverageInput) doubleCoverageInput.checked = backendPlan.allow_double_coverage ?? false;
     (document.getElementById('plan-notify-days') as HTMLInputElement).value = String(backendPlan.notification_days_before || 3);
     (document.getElementById('plan-enabled') as HTMLInputElement).checked = backendPlan.enabled;
 
@@ -1368,6 +1374,8 @@ export async function savePlan(e: Event): Promise<void> {
     target_coverage: rawCoverage,
     ramp_schedule: rampSchedule,
     auto_purchase: (document.getElementById('plan-auto-purchase') as HTMLInputElement).checked,
+    shadow_mode: (document.getElementById('plan-shadow-mode') as HTMLInputElement | null)?.checked ?? false,
+    allow_double_coverage: (document.getElementById('plan-allow-double-coverage') as HTMLInputElement | null)?.checked ?? false,
     notification_days_before: rawNotifyDays,
     enabled: (document.getElementById('plan-enabled') as HTMLInputElement).checked
   };
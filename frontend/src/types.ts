@@ -29,7 +29,30 @@ export interface DashboardSummary {
   current_coverage?: number;
   target_coverage?: number;
   ytd_savings?: number;
+  overlapping_count?: number;
+  overlap_savings_at_risk?: number;
   by_service?: Record<string, ServiceSavings>;
+  commitment_budgets?: CommitmentBudgetUsage[];
+}
+
+// An enabled commitment budget's standing in its current period.
+// remaining_* are absent when that limit is not capped.
+export interface CommitmentBudgetUsage {
+  budget: {
+    id: string;
+    name: string;
+    period: 'month' | 'quarter';
+    account_group_id?: string;
+    max_upfront_usd?: number;
+    max_hourly_usd?: number;
+    enabled: boolean;
+  };
+  period_start: string;
+  period_end: string;
+  used_upfront_usd: number;
+  used_hourly_usd: number;
+  remaining_upfront_usd?: number;
+  remaining_hourly_usd?: number;
 }
 
 export interface ServiceSavings {
@@ -123,6 +146,14 @@ export interface LocalRecommendation {
   // null or absent means the collector did not populate it (non-AWS providers
   // or pre-#239 cached rows); the cell renders "—" in that case.
   usage_history?: number[] | null;
+  // Savings Plan overlap annotation: share (0-100) of this RI's usage the
+  // plans in overlap_with already cover, and the suggested reduced count.
+  overlap_covered_pct?: number;
+  overlap_adjusted_count?: number;
+  overlap_with?: string[];
+  // Engine that produced the rec: the provider recommendation API ('vendor')
+  // or CUDly's native recommender ('native').
+  source?: 'vendor' | 'native';
   // ComputeDetails fields surfaced by PR #810/#816/#833.
   // null = provider catalogue did not return a value (renders as "—", not "0").
   // Absent on non-compute recs (RDS, savings plans, etc.).
@@ -135,6 +166,8 @@ export interface RecommendationsSummary {
   total_monthly_savings?: number;
   total_upfront_cost?: number;
   avg_payback_months?: number;
+  overlapping_count?: number;
+  overlap_savings_at_risk?: number;
 }
 
 // Plans types
@@ -177,6 +210,8 @@ export interface SavePlanData {
   target_coverage: number;
   ramp_schedule: string;
   auto_purchase: boolean;
+  shadow_mode: boolean;
+  allow_double_coverage: boolean;
   notification_days_before: number;
   enabled: boolean;
   custom_step_percent?: number;
@@ -386,6 +421,19 @@ export interface GlobalConfig {
   recommendations_cache_stale_hours?: number;
   // AWS Cost Explorer lookback window (days). One of 7, 30, or 60. Default: 7.
   recommendations_lookback_days?: number;
+  // Where recommendations come from: provider APIs ("vendor", default),
+  // the native engine computed from usage and offering prices ("native"),
+  // or vendor plus native recs for pools the vendor missed ("both").
+  recommendation_source?: 'vendor' | 'native' | 'both';
+  // Native engine knobs: usage lookback (1–365 days, default 30), the
+  // uncovered-demand percentile commitments are sized to (default 10), and
+  // the maximum break-even in months (0 = no cap).
+  native_rec_lookback_days?: number;
+  native_rec_percentile?: number;
+  native_rec_max_break_even_months?: number;
+  // Annual cost of capital (percent) used for recommendation NPV, IRR and
+  // discounted payback. Unset means the default (8%).
+  finance_discount_rate_pct?: number;
   // Global kill-switch for the commitment-laddering feature (issue #1333 phase 3).
   // When false (the default) no laddering engine runs fire, regardless of
   // per-account LadderConfig settings. Set to true to allow per-account
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"github.com/LeanerCloud/CUDly/internal/config"
@@ -151,7 +152,7 @@ func aggregatePurchases(ctx context.Context, purchases []config.PurchaseHistoryR
 		}
 
 		// Skip expired commitments (outside their term window).
-		expiryTime := p.Timestamp.Add(time.Duration(p.Term*HoursPerYear) * time.Hour)
+		expiryTime := purchaseExpiry(p)
 		if now.After(expiryTime) {
 			continue
 		}
@@ -179,7 +180,7 @@ func aggregatePurchases(ctx context.Context, purchases []config.PurchaseHistoryR
 		agg.savings += p.EstimatedSavings
 		// Upfront commitment amortized to a monthly run-rate over the term.
 		// Term > 0 guaranteed above.
-		agg.commitment += p.UpfrontCost / (float64(p.Term) * MonthsPerYear)
+		agg.commitment += p.UpfrontCost / amortizationMonths(p, expiryTime)
 		// H2: real covered usage from the recurring monthly cost when present.
 		// Nil MonthlyCost (e.g. AWS all-upfront) contributes nothing and leaves
 		// usage unknown rather than implicitly $0.
@@ -193,6 +194,28 @@ func aggregatePurchases(ctx context.Context, purchases []config.PurchaseHistoryR
 	return serviceMap, activePurchases, skippedBadTerm, nil
 }
 
+// purchaseExpiry returns when a purchase's commitment ends: TermEnd when set
+// (a marketplace RI bought with the seller's remaining term), else Timestamp
+// plus Term years.
+func purchaseExpiry(p config.PurchaseHistoryRecord) time.Time {
+	if p.TermEnd != nil {
+		return *p.TermEnd
+	}
+	return p.Timestamp.Add(time.Duration(p.Term*HoursPerYear) * time.Hour)
+}
+
+// amortizationMonths is the number of months an upfront cost is spread over:
+// Term*MonthsPerYear, or the actual span up to TermEnd for a partial-term
+// purchase so a few-month marketplace RI is not amortized over a full year.
+// The span is floored at one month to keep the division bounded.
+func amortizationMonths(p config.PurchaseHistoryRecord, expiry time.Time) float64 {
+	if p.TermEnd == nil {
+		return float64(p.Term) * MonthsPerYear
+	}
+	months := expiry.Sub(p.Timestamp).Hours() / (HoursPerYear / MonthsPerYear)
+	return math.Max(months, 1)
+}
+
 // commitmentTypeFor maps a service to its commitment_type. SavingsPlans is the
 // only Savings Plan service today; everything else is a Reserved Instance.
 func commitmentTypeFor(service string) string {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/LeanerCloud/CUDly/internal/config"
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
@@ -286,6 +287,9 @@ func (m *mockConfigStore) ListPendingExecutionIDsForAccount(ctx context.Context,
 func (m *mockConfigStore) TransitionExecutionStatus(ctx context.Context, executionID string, fromStatuses []string, toStatus string, actor *string) (*config.PurchaseExecution, error) {
 	return nil, nil
 }
+func (m *mockConfigStore) DeferExecutionAtomic(ctx context.Context, executionID string, fromStatuses []string, until time.Time) (*config.PurchaseExecution, error) {
+	return nil, nil
+}
 
 func (m *mockConfigStore) SetCancelledBy(_ context.Context, _ string, _ string) error {
 	return nil
@@ -343,6 +347,224 @@ func (m *mockConfigStore) CancelPendingExchangesByOrigin(_ context.Context, _ co
 func (m *mockConfigStore) GetStaleProcessingExchanges(ctx context.Context, olderThan time.Duration) ([]config.RIExchangeRecord, error) {
 	return nil, nil
 }
+func (m *mockConfigStore) SaveRIModificationRecord(_ context.Context, _ *config.RIModificationRecord) error {
+	return nil
+}
+func (m *mockConfigStore) GetRIModificationRecord(_ context.Context, _ string) (*config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStore) GetRIModificationHistory(_ context.Context, _ time.Time, _ int) ([]config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStore) TransitionRIModificationStatus(_ context.Context, _, _, _ string, _ *string) (*config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStore) CompleteRIModification(_ context.Context, _, _, _ string) error {
+	return nil
+}
+func (m *mockConfigStore) FailRIModification(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) SaveAzureRefund(_ context.Context, _ *config.AzureRefundRecord) error {
+	return nil
+}
+
+func (m *mockConfigStore) SumAzureRefunds(_ context.Context, _, _ string, _ time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockConfigStore) UpsertCommitmentInventory(_ context.Context, _ string, _ []config.CommitmentInventoryRecord, _ []string) error {
+	return nil
+}
+
+func (m *mockConfigStore) GetActiveCommitmentInventory(_ context.Context, _ time.Time, _ []string, _ map[string][]string) ([]config.CommitmentInventoryRecord, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) CreateCommitmentRenewal(_ context.Context, _ *config.CommitmentRenewal) error {
+	return nil
+}
+
+func (m *mockConfigStore) ListCommitmentRenewals(_ context.Context, _ config.CommitmentRenewalFilter) ([]config.CommitmentRenewal, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) UpdateCommitmentRenewalStatus(_ context.Context, _, _ string, _ *string) error {
+	return nil
+}
+
+func (m *mockConfigStore) CreatePurchasePolicy(_ context.Context, _ *config.PurchasePolicy) error {
+	return nil
+}
+
+func (m *mockConfigStore) GetLatestPurchasePolicy(_ context.Context) (*config.PurchasePolicy, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) GetPurchasePolicy(_ context.Context, _ int) (*config.PurchasePolicy, error) {
+	return nil, config.ErrNotFound
+}
+
+func (m *mockConfigStore) ListPurchasePolicies(_ context.Context, _ int) ([]config.PurchasePolicy, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) RecordPolicyEvaluation(_ context.Context, _ *config.PolicyEvaluation) error {
+	return nil
+}
+
+func (m *mockConfigStore) ListPolicyEvaluations(_ context.Context, _ string) ([]config.PolicyEvaluation, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) RecordExecutionApproval(_ context.Context, _ *config.ExecutionApproval) error {
+	return nil
+}
+
+func (m *mockConfigStore) ListExecutionApprovals(_ context.Context, _ string) ([]config.ExecutionApproval, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) ListAccountGroups(_ context.Context) ([]config.AccountGroup, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) CreateAccountGroup(_ context.Context, _ *config.AccountGroup) error {
+	return nil
+}
+
+func (m *mockConfigStore) UpdateAccountGroup(_ context.Context, _ *config.AccountGroup) error {
+	return nil
+}
+
+func (m *mockConfigStore) DeleteAccountGroup(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) ListApprovalChains(_ context.Context) ([]config.ApprovalChain, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) CreateApprovalChain(_ context.Context, _ *config.ApprovalChain) error {
+	return nil
+}
+
+func (m *mockConfigStore) UpdateApprovalChain(_ context.Context, _ *config.ApprovalChain) error {
+	return nil
+}
+
+func (m *mockConfigStore) DeleteApprovalChain(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) StartExecutionApprovalChain(_ context.Context, _ *config.ExecutionApprovalChain) (bool, error) {
+	return false, nil
+}
+
+func (m *mockConfigStore) GetExecutionApprovalChain(_ context.Context, _ string) (*config.ExecutionApprovalChain, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) AdvanceExecutionApprovalChain(_ context.Context, _ string, _ int) (bool, error) {
+	return false, nil
+}
+
+func (m *mockConfigStore) RecordApprovalStageDecision(_ context.Context, _ *config.ApprovalStageDecision) error {
+	return nil
+}
+
+func (m *mockConfigStore) ListApprovalStageDecisions(_ context.Context, _ string) ([]config.ApprovalStageDecision, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) ListCommitmentBudgets(_ context.Context) ([]config.CommitmentBudget, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) CreateCommitmentBudget(_ context.Context, _ *config.CommitmentBudget) error {
+	return nil
+}
+
+func (m *mockConfigStore) UpdateCommitmentBudget(_ context.Context, _ *config.CommitmentBudget) error {
+	return nil
+}
+
+func (m *mockConfigStore) DeleteCommitmentBudget(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) ReserveCommitmentBudget(_ context.Context, _ *config.BudgetReservation) error {
+	return nil
+}
+
+func (m *mockConfigStore) CommitBudgetReservation(_ context.Context, _ string, _, _ float64) error {
+	return nil
+}
+
+func (m *mockConfigStore) ReleaseBudgetReservation(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) CreditCommitmentBudget(_ context.Context, _ *config.BudgetReservation) error {
+	return nil
+}
+
+func (m *mockConfigStore) GetCommitmentBudgetUsage(_ context.Context, _ time.Time) ([]config.CommitmentBudgetUsage, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) ListFreezeWindows(_ context.Context) ([]config.FreezeWindow, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) CreateFreezeWindow(_ context.Context, _ *config.FreezeWindow) error {
+	return nil
+}
+
+func (m *mockConfigStore) UpdateFreezeWindow(_ context.Context, _ *config.FreezeWindow) error {
+	return nil
+}
+
+func (m *mockConfigStore) DeleteFreezeWindow(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStore) RecordFreezeOverride(_ context.Context, _ *config.FreezeOverride) error {
+	return nil
+}
+
+func (m *mockConfigStore) GetLatestFreezeOverride(_ context.Context, _ string) (*config.FreezeOverride, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) ListFreezeOverrides(_ context.Context, _ int) ([]config.FreezeOverride, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) RecordPlanShadowPurchases(_ context.Context, _ string, _ []config.ShadowPurchase) error {
+	return nil
+}
+
+func (m *mockConfigStore) SaveShadowLadderRun(_ context.Context, run *config.LadderRunDB, _ []config.ShadowPurchase) (*config.LadderRunDB, error) {
+	return run, nil
+}
+
+func (m *mockConfigStore) GetShadowLadderCommitUSDHr(_ context.Context, _ string, _ time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockConfigStore) ListShadowPurchases(_ context.Context, _ config.ShadowPurchaseFilter) ([]config.ShadowPurchase, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) ListShadowPurchasesToEvaluate(_ context.Context, _ time.Time) ([]config.ShadowPurchase, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStore) SaveShadowEvaluation(_ context.Context, _ *config.ShadowPurchase) error {
+	return nil
+}
 
 func (m *mockConfigStore) CreateCloudAccount(ctx context.Context, account *config.CloudAccount) error {
 	return nil
@@ -440,6 +662,21 @@ func (m *mockConfigStore) GetRIUtilizationCache(_ context.Context, _ string, _ i
 func (m *mockConfigStore) UpsertRIUtilizationCache(_ context.Context, _ string, _ int, _ []byte, _ time.Time) error {
 	return nil
 }
+func (m *mockConfigStore) CURObjectETags(_ context.Context, _ string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (m *mockConfigStore) ReplaceCURObject(_ context.Context, _, _, _ string, _ []cur.PoolHour, _ []cur.CommitmentHour) error {
+	return nil
+}
+func (m *mockConfigStore) DeleteCURObjects(_ context.Context, _ string, _ []string) error {
+	return nil
+}
+func (m *mockConfigStore) CURPoolHours(_ context.Context, _ cur.Query) ([]cur.PoolHour, error) {
+	return nil, nil
+}
+func (m *mockConfigStore) CURCommitmentHours(_ context.Context, _ cur.Query) ([]cur.CommitmentHour, error) {
+	return nil, nil
+}
 func (m *mockConfigStore) UpsertNotificationMute(_ context.Context, _, _, _ string) error {
 	return nil
 }
@@ -554,6 +791,28 @@ func TestCollectorCollect(t *testing.T) {
 		assert.Empty(t, store.savedSnapshots)
 	})
 
+	t.Run("honours TermEnd of partial-term marketplace purchases", func(t *testing.T) {
+		store := &mockAnalyticsStore{}
+		// Both bought 3 months ago with a nominal 1-year term. The first
+		// marketplace RI ran out a month ago; the second runs 6 months in
+		// total, so its upfront cost is amortized over 6 months, not 12.
+		ended := activeRecord("aws", "ec2", "us-east-1", 1, 100, 600)
+		endedAt := ended.Timestamp.AddDate(0, 2, 0)
+		ended.TermEnd = &endedAt
+		live := activeRecord("aws", "ec2", "us-west-2", 1, 100, 600)
+		liveUntil := live.Timestamp.Add(6 * (HoursPerYear / MonthsPerYear) * time.Hour)
+		live.TermEnd = &liveUntil
+		cfgStore := &mockConfigStore{
+			getActivePurchaseHistoryFunc: func(ctx context.Context, asOf time.Time, accountIDs []string, externalIDsByProvider map[string][]string) ([]config.PurchaseHistoryRecord, error) {
+				return []config.PurchaseHistoryRecord{ended, live}, nil
+			},
+		}
+		require.NoError(t, newTestCollector(t, store, cfgStore).Collect(context.Background()))
+		require.Len(t, store.savedSnapshots, 1, "the ended marketplace RI is skipped")
+		assert.Equal(t, "us-west-2", store.savedSnapshots[0].Region)
+		assert.InDelta(t, 100.0, store.savedSnapshots[0].TotalCommitment, 1e-6)
+	})
+
 	t.Run("processes active purchases and creates snapshots", func(t *testing.T) {
 		store := &mockAnalyticsStore{}
 		cfgStore := &mockConfigStore{
@@ -784,7 +1043,7 @@ func (m *mockConfigStore) WithTx(_ context.Context, fn func(tx pgx.Tx) error) er
 func (m *mockConfigStore) GetLadderConfigs(_ context.Context) ([]config.LadderConfigDB, error) {
 	return nil, nil
 }
-func (m *mockConfigStore) GetLadderConfig(_ context.Context, _, _ string) (*config.LadderConfigDB, error) {
+func (m *mockConfigStore) GetLadderConfig(_ context.Context, _, _, _ string) (*config.LadderConfigDB, error) {
 	return nil, nil
 }
 func (m *mockConfigStore) UpsertLadderConfig(_ context.Context, cfg *config.LadderConfigDB) (*config.LadderConfigDB, error) {
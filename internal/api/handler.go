@@ -91,6 +91,21 @@ type Handler struct {
 	// awsprovider.NewEC2ClientDirect.
 	marketplaceEC2Factory func(aws.Config) marketplaceEC2Client
 
+	// Optional marketplace-offerings EC2 client factory injected by tests.
+	// When nil (the production default), searchMarketplaceOfferings uses
+	// awsprovider.NewEC2ClientDirect.
+	marketplaceOfferingsEC2Factory func(aws.Config) marketplaceOfferingsEC2Client
+
+	// Optional RI modification EC2 client factory injected by tests. When
+	// nil (the production default), buildRIModificationEC2Client uses
+	// awsprovider.NewEC2ClientDirect.
+	riModificationEC2Factory func(aws.Config) riModificationEC2Client
+
+	// Optional GCP commitments client factory injected by tests. When nil
+	// (the production default), buildGCPCommitmentsClient resolves the
+	// account's GCP credential and builds a computeengine client.
+	gcpCommitmentsFactory func(projectID, region string) gcpCommitmentsClient
+
 	// Optional account-resolver injection point used by the reshape
 	// handler integration test. When nil (the production default), the
 	// handler calls h.resolveAWSCloudAccountID which in turn invokes
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+)
+
+// Account groups and approval chains: the tiered sign-off chains purchases
+// go through before they are approved (see internal/purchase
+// approval_chain.go). Reads take view:config; writes take update:config.
+
+// AccountGroupRequest is the body of POST /api/account-groups and
+// PUT /api/account-groups/{id}.
+type AccountGroupRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	AccountIDs  []string `json:"account_ids"`
+}
+
+// ApprovalChainRequest is the body of POST /api/approval-chains and
+// PUT /api/approval-chains/{id}. Enabled defaults to true.
+type ApprovalChainRequest struct {
+	Name           string                 `json:"name"`
+	Priority       int                    `json:"priority"`
+	Enabled        *bool                  `json:"enabled"`
+	Provider       string                 `json:"provider"`
+	AccountGroupID *string                `json:"account_group_id"`
+	AmountBasis    string                 `json:"amount_basis"`
+	Stages         []config.ApprovalStage `json:"stages"`
+}
+
+// ApprovalChainView is an execution's approval chain as the purchase
+// details and policy-evaluations responses show it.
+type ApprovalChainView struct {
+	ChainName    string              `json:"chain_name"`
+	Amount       float64             `json:"amount"`
+	CurrentStage int                 `json:"current_stage"`
+	Completed    bool                `json:"completed"`
+	StartedAt    time.Time           `json:"started_at"`
+	CompletedAt  *time.Time          `json:"completed_at,omitempty"`
+	Stages       []ApprovalStageView `json:"stages"`
+}
+
+// ApprovalStageView is one stage of an ApprovalChainView. Status is
+// "signed", "awaiting" (the stage approvers are asked to sign now) or
+// "queued".
+type ApprovalStageView struct {
+	Name      string                         `json:"name"`
+	Approvers []string                       `json:"approvers"`
+	Quorum    int                            `json:"quorum"`
+	MinAmount float64                        `json:"min_amount,omitempty"`
+	Status    string                         `json:"status"`
+	Decisions []config.ApprovalStageDecision `json:"decisions"`
+}
+
+// listAccountGroups handles GET /api/account-groups.
+func (h *Handler) listAccountGroups(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	groups, err := h.config.ListAccountGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account groups: %w", err)
+	}
+	if groups == nil {
+		groups = []config.AccountGroup{}
+	}
+	return map[string]any{"account_groups": groups}, nil
+}
+
+// createAccountGroup handles POST /api/account-groups.
+func (h *Handler) createAccountGroup(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	g, err := parseAccountGroupRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if saveErr := h.config.CreateAccountGroup(ctx, g); saveErr != nil {
+		return nil, fmt.Errorf("failed to create account group: %w", saveErr)
+	}
+	return g, nil
+}
+
+// updateAccountGroup handles PUT /api/account-groups/{id}.
+func (h *Handler) updateAccountGroup(ctx context.Context, req *events.LambdaFunctionURLRequest, groupID string) (any, error) {
+	if err := validateUUID(groupID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	g, err := parseAccountGroupRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = groupID
+	if saveErr := h.config.UpdateAccountGroup(ctx, g); saveErr != nil {
+		return nil, approvalConfigError("account group", saveErr)
+	}
+	return g, nil
+}
+
+// deleteAccountGroup handles DELETE /api/account-groups/{id}. A group an
+// approval chain still names cannot be deleted.
+func (h *Handler) deleteAccountGroup(ctx context.Context, req *events.LambdaFunctionURLRequest, groupID string) (any, error) {
+	if err := validateUUID(groupID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	if err := h.config.DeleteAccountGroup(ctx, groupID); err != nil {
+		return nil, approvalConfigError("account group", err)
+	}
+	return map[string]string{"status": "account group deleted"}, nil
+}
+
+// listApprovalChains handles GET /api/approval-chains.
+func (h *Handler) listApprovalChains(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	chains, err := h.config.ListApprovalChains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval chains: %w", err)
+	}
+	if chains == nil {
+		chains = []config.ApprovalChain{}
+	}
+	return map[string]any{"approval_chains": chains}, nil
+}
+
+// createApprovalChain handles POST /api/approval-chains.
+func (h *Handler) createApprovalChain(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	c, err := parseApprovalChainRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if saveErr := h.config.CreateApprovalChain(ctx, c); saveErr != nil {
+		return nil, fmt.Errorf("failed to create approval chain: %w", saveErr)
+	}
+	return c, nil
+}
+
+// updateApprovalChain handles PUT /api/approval-chains/{id}. Executions
+// whose chain already started keep the stages they started with.
+func (h *Handler) updateApprovalChain(ctx context.Context, req *events.LambdaFunctionURLRequest, chainID string) (any, error) {
+	if err := validateUUID(chainID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	c, err := parseApprovalChainRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = chainID
+	if saveErr := h.config.UpdateApprovalChain(ctx, c); saveErr != nil {
+		return nil, approvalConfigError("approval chain", saveErr)
+	}
+	return c, nil
+}
+
+// deleteApprovalChain handles DELETE /api/approval-chains/{id}.
+func (h *Handler) deleteApprovalChain(ctx context.Context, req *events.LambdaFunctionURLRequest, chainID string) (any, error) {
+	if err := validateUUID(chainID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	if err := h.config.DeleteApprovalChain(ctx, chainID); err != nil {
+		return nil, approvalConfigError("approval chain", err)
+	}
+	return map[string]string{"status": "approval chain deleted"}, nil
+}
+
+// approvalConfigError maps a missing account group or approval chain to a
+// 404; other errors are wrapped with kind.
+func approvalConfigError(kind string, err error) error {
+	if errors.Is(err, config.ErrNotFound) {
+		return NewClientError(404, kind+" not found")
+	}
+	return fmt.Errorf("%s: %w", kind, err)
+}
+
+// parseAccountGroupRequest decodes and validates an account group body.
+func parseAccountGroupRequest(body string) (*config.AccountGroup, error) {
+	var r AccountGroupRequest
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		return nil, NewClientError(400, "name is required")
+	}
+	ids := make([]string, 0, len(r.AccountIDs))
+	for _, id := range r.AccountIDs {
+		if err := validateUUID(id); err != nil {
+			return nil, NewClientError(400, fmt.Sprintf("account_ids: %q is not a valid account ID", id))
+		}
+		ids = append(ids, id)
+	}
+	return &config.AccountGroup{Name: name, Description: strings.TrimSpace(r.Description), AccountIDs: ids}, nil
+}
+
+// parseApprovalChainRequest decodes and validates an approval chain body.
+// Approver emails are lower-cased, matching how sign-offs are recorded.
+func parseApprovalChainRequest(body string) (*config.ApprovalChain, error) {
+	var r ApprovalChainRequest
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	switch r.Provider {
+	case "", "aws", "azure", "gcp":
+	default:
+		return nil, NewClientError(400, "provider must be aws, azure, gcp or empty")
+	}
+	if r.AccountGroupID != nil {
+		if err := validateUUID(*r.AccountGroupID); err != nil {
+			return nil, NewClientError(400, "account_group_id is not a valid ID")
+		}
+	}
+	for i := range r.Stages {
+		st := &r.Stages[i]
+		st.Name = strings.TrimSpace(st.Name)
+		for j, addr := range st.Approvers {
+			st.Approvers[j] = strings.ToLower(strings.TrimSpace(addr))
+		}
+	}
+	c := &config.ApprovalChain{
+		Name:           strings.TrimSpace(r.Name),
+		Priority:       r.Priority,
+		Enabled:        r.Enabled == nil || *r.Enabled,
+		Provider:       r.Provider,
+		AccountGroupID: r.AccountGroupID,
+		AmountBasis:    r.AmountBasis,
+		Stages:         r.Stages,
+	}
+	if err := c.Validate(); err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+	return c, nil
+}
+
+// loadApprovalChainView returns executionID's approval chain with each
+// stage's sign-offs, or nil when the execution has none.
+func (h *Handler) loadApprovalChainView(ctx context.Context, executionID string) (*ApprovalChainView, error) {
+	run, err := h.config.GetExecutionApprovalChain(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval chain: %w", err)
+	}
+	if run == nil {
+		return nil, nil
+	}
+	decisions, err := h.config.ListApprovalStageDecisions(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval stage decisions: %w", err)
+	}
+	return buildApprovalChainView(run, decisions), nil
+}
+
+// buildApprovalChainView assembles the view of run from its decisions.
+func buildApprovalChainView(run *config.ExecutionApprovalChain, decisions []config.ApprovalStageDecision) *ApprovalChainView {
+	view := &ApprovalChainView{
+		ChainName:    run.ChainName,
+		Amount:       run.Amount,
+		CurrentStage: run.CurrentStage,
+		Completed:    run.Completed(),
+		StartedAt:    run.StartedAt,
+		CompletedAt:  run.CompletedAt,
+		Stages:       make([]ApprovalStageView, len(run.Stages)),
+	}
+	for i, st := range run.Stages {
+		status := "queued"
+		switch {
+		case i < run.CurrentStage:
+			status = "signed"
+		case i == run.CurrentStage:
+			status = "awaiting"
+		}
+		view.Stages[i] = ApprovalStageView{
+			Name: st.Name, Approvers: st.Approvers, Quorum: st.Quorum, MinAmount: st.MinAmount,
+			Status: status, Decisions: []config.ApprovalStageDecision{},
+		}
+	}
+	for _, d := range decisions {
+		if d.StageIndex >= 0 && d.StageIndex < len(view.Stages) {
+			view.Stages[d.StageIndex].Decisions = append(view.Stages[d.StageIndex].Decisions, d)
+		}
+	}
+	return view
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+)
+
+const approvalChainID = "22222222-2222-2222-2222-222222222222"
+
+func chainRun(current int) *config.ExecutionApprovalChain {
+	return &config.ExecutionApprovalChain{
+		ExecutionID: policyExecID,
+		ChainName:   "procurement",
+		Amount:      150000,
+		Stages: []config.ApprovalStage{
+			{Name: "Team lead", Approvers: []string{"lead@example.com"}, Quorum: 1},
+			{Name: "FinOps", Approvers: []string{"finops1@example.com", "finops2@example.com"}, Quorum: 1, MinAmount: 10000},
+			{Name: "Finance director", Approvers: []string{"admin@test.com"}, Quorum: 1, MinAmount: 100000},
+		},
+		CurrentStage: current,
+	}
+}
+
+func TestCreateApprovalChain(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("CreateApprovalChain", mock.Anything, mock.MatchedBy(func(c *config.ApprovalChain) bool {
+		return c.Name == "procurement" && c.Enabled && c.AmountBasis == config.AmountBasisUpfront &&
+			len(c.Stages) == 2 && c.Stages[1].Approvers[0] == "finops@example.com"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*config.ApprovalChain).ID = approvalChainID
+	}).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"name":" procurement ","amount_basis":"upfront","stages":[
+		{"name":"Team lead","approvers":["lead@example.com"],"quorum":1},
+		{"name":"FinOps","approvers":[" FinOps@Example.com "],"quorum":1,"min_amount":10000}
+	]}`
+	got, err := h.createApprovalChain(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, approvalChainID, got.(*config.ApprovalChain).ID)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestCreateApprovalChain_RejectsInvalidChains(t *testing.T) {
+	tests := []struct{ name, body, want string }{
+		{"bad body", `{`, "invalid request body"},
+		{"no stages", `{"name":"c","amount_basis":"upfront","stages":[]}`, "at least one stage"},
+		{"bad basis", `{"name":"c","amount_basis":"monthly","stages":[{"name":"s","approvers":["a@x.com"],"quorum":1}]}`, "amount_basis"},
+		{"unreachable quorum", `{"name":"c","amount_basis":"upfront","stages":[{"name":"s","approvers":["a@x.com"],"quorum":2}]}`, "quorum"},
+		{"bad provider", `{"name":"c","provider":"oracle","amount_basis":"upfront","stages":[{"name":"s","approvers":["a@x.com"],"quorum":1}]}`, "provider"},
+		{"bad group", `{"name":"c","account_group_id":"nope","amount_basis":"upfront","stages":[{"name":"s","approvers":["a@x.com"],"quorum":1}]}`, "account_group_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, cfgStore, _ := newPolicyHandler()
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.createApprovalChain(context.Background(), req)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, 400, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+			cfgStore.AssertNotCalled(t, "CreateApprovalChain", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateApprovalChain_NotFound(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("UpdateApprovalChain", mock.Anything, mock.Anything).
+		Return(fmt.Errorf("approval chain %s: %w", approvalChainID, config.ErrNotFound))
+
+	req := marketplaceReq()
+	req.Body = `{"name":"c","amount_basis":"total_commitment","stages":[{"name":"s","approvers":["a@x.com"],"quorum":1}]}`
+	_, err := h.updateApprovalChain(context.Background(), req, approvalChainID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 404, ce.code)
+}
+
+func TestApprovalChains_RequireUpdateConfig(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "viewer", Email: "viewer@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionView, Resource: auth.ResourceConfig}})
+	h := &Handler{config: cfgStore, auth: authSvc}
+
+	_, err := h.deleteApprovalChain(context.Background(), marketplaceReq(), approvalChainID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 403, ce.code)
+	cfgStore.AssertNotCalled(t, "DeleteApprovalChain", mock.Anything, mock.Anything)
+}
+
+func TestAccountGroups(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("CreateAccountGroup", mock.Anything, mock.MatchedBy(func(g *config.AccountGroup) bool {
+		return g.Name == "prod" && len(g.AccountIDs) == 1
+	})).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = fmt.Sprintf(`{"name":"prod","account_ids":[%q]}`, policyExecID)
+	_, err := h.createAccountGroup(context.Background(), req)
+	require.NoError(t, err)
+
+	req.Body = `{"name":"prod","account_ids":["not-an-id"]}`
+	_, err = h.createAccountGroup(context.Background(), req)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 400, ce.code)
+
+	cfgStore.On("DeleteAccountGroup", mock.Anything, approvalChainID).
+		Return(fmt.Errorf("account group %s: %w", approvalChainID, config.ErrNotFound))
+	_, err = h.deleteAccountGroup(context.Background(), marketplaceReq(), approvalChainID)
+	ce, ok = IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 404, ce.code)
+}
+
+func TestBuildApprovalChainView(t *testing.T) {
+	view := buildApprovalChainView(chainRun(1), []config.ApprovalStageDecision{
+		{ExecutionID: policyExecID, StageIndex: 0, ApproverEmail: "lead@example.com", DecidedAt: time.Now()},
+	})
+	require.Len(t, view.Stages, 3)
+	assert.Equal(t, []string{"signed", "awaiting", "queued"},
+		[]string{view.Stages[0].Status, view.Stages[1].Status, view.Stages[2].Status})
+	require.Len(t, view.Stages[0].Decisions, 1)
+	assert.Empty(t, view.Stages[1].Decisions)
+	assert.False(t, view.Completed)
+	assert.True(t, buildApprovalChainView(chainRun(3), nil).Completed)
+}
+
+func TestSendApprovalRequest_ChainEmailsTheFirstStage(t *testing.T) {
+	pm := &MockPurchaseManager{}
+	pm.On("StartApprovalChain", mock.Anything, policyExecID).Return(chainRun(0), nil)
+	// No email notifier: the chain sent the stage email, so the plain
+	// approval email must not be attempted.
+	h := &Handler{config: &MockConfigStore{}, purchase: pm}
+
+	sent, reason, recipient := h.sendApprovalRequest(context.Background(), marketplaceReq(),
+		&config.PurchaseExecution{ExecutionID: policyExecID}, nil, 150000, 0)
+	assert.True(t, sent)
+	assert.Empty(t, reason)
+	assert.Equal(t, "lead@example.com", recipient)
+}
+
+func TestSendApprovalRequest_ReportsChainEmailFailure(t *testing.T) {
+	pm := &MockPurchaseManager{}
+	pm.On("StartApprovalChain", mock.Anything, policyExecID).Return(chainRun(0),
+		&purchase.ApprovalStageEmailError{ExecutionID: policyExecID, Stage: "1 of 3 (Team lead)", Err: fmt.Errorf("SES throttled")})
+	// The chain started, so the plain approval email must not be tried:
+	// with no notifier it would report a different reason.
+	h := &Handler{config: &MockConfigStore{}, purchase: pm}
+
+	sent, reason, recipient := h.sendApprovalRequest(context.Background(), marketplaceReq(),
+		&config.PurchaseExecution{ExecutionID: policyExecID}, nil, 150000, 0)
+	assert.False(t, sent)
+	assert.Contains(t, reason, "SES throttled")
+	assert.Equal(t, "lead@example.com", recipient)
+}
+
+func TestPolicyApprovalResponse_ReportsNextStageEmailFailure(t *testing.T) {
+	resp, handled, err := policyApprovalResponse(&purchase.ApprovalStagePendingError{ExecutionID: policyExecID,
+		Stage: "Finance", StageNumber: 2, Stages: 3, Need: 1, EmailErr: fmt.Errorf("SES throttled")})
+	require.NoError(t, err)
+	require.True(t, handled)
+	body := resp.(map[string]any)
+	assert.Equal(t, false, body["email_sent"])
+	assert.Equal(t, "SES throttled", body["email_reason"])
+}
+
+func TestSendApprovalRequest_FallsBackToPlainEmail(t *testing.T) {
+	pm := &MockPurchaseManager{}
+	pm.On("StartApprovalChain", mock.Anything, policyExecID).Return(nil, fmt.Errorf("db down"))
+	h := &Handler{config: &MockConfigStore{}, purchase: pm}
+
+	sent, reason, _ := h.sendApprovalRequest(context.Background(), marketplaceReq(),
+		&config.PurchaseExecution{ExecutionID: policyExecID}, nil, 0, 0)
+	assert.False(t, sent)
+	assert.Contains(t, reason, "email notifier not configured")
+}
+
+func TestAuthorizeApprovalAction_AcceptsActiveStageApprover(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("GetExecutionApprovalChain", mock.Anything, policyExecID).Return(chainRun(2), nil)
+
+	actor, err := h.authorizeApprovalAction(context.Background(), marketplaceReq(), &config.PurchaseExecution{ExecutionID: policyExecID})
+	require.NoError(t, err)
+	assert.Equal(t, "admin@test.com", actor)
+}
+
+func TestAnnotateApprovalChain(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("GetExecutionApprovalChain", mock.Anything, policyExecID).Return(chainRun(1), nil)
+	cfgStore.On("ListApprovalStageDecisions", mock.Anything, policyExecID).Return([]config.ApprovalStageDecision{
+		{ExecutionID: policyExecID, StageIndex: 0, ApproverEmail: "lead@example.com"},
+	}, nil)
+
+	row := config.PurchaseHistoryRecord{PurchaseID: policyExecID, Approver: "notify@example.com"}
+	h.annotateApprovalChain(context.Background(), &row)
+	assert.Equal(t, "finops1@example.com, finops2@example.com", row.Approver)
+	assert.Equal(t, "Team lead signed by lead@example.com; awaiting FinOps (stage 2 of 3): 0 of 1 sign-offs", row.StatusDescription)
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+)
+
+// Commitment budgets: the organisation-wide caps on upfront spend and new
+// hourly commitment every purchase path reserves against before calling
+// the cloud (see pkg/budget). Reads take view:config; writes take
+// update:config.
+
+// CommitmentBudgetRequest is the body of POST /api/commitment-budgets and
+// PUT /api/commitment-budgets/{id}. Enabled defaults to true.
+type CommitmentBudgetRequest struct {
+	Name           string   `json:"name"`
+	Period         string   `json:"period"`
+	AccountGroupID *string  `json:"account_group_id"`
+	MaxUpfrontUSD  *float64 `json:"max_upfront_usd"`
+	MaxHourlyUSD   *float64 `json:"max_hourly_usd"`
+	Enabled        *bool    `json:"enabled"`
+}
+
+// ReleaseBudgetReservationRequest is the body of
+// POST /api/commitment-budgets/reservations/{id}/release.
+type ReleaseBudgetReservationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// listCommitmentBudgets handles GET /api/commitment-budgets.
+func (h *Handler) listCommitmentBudgets(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	budgets, err := h.config.ListCommitmentBudgets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commitment budgets: %w", err)
+	}
+	if budgets == nil {
+		budgets = []config.CommitmentBudget{}
+	}
+	return map[string]any{"commitment_budgets": budgets}, nil
+}
+
+// getCommitmentBudgetUsage handles GET /api/commitment-budgets/usage: every
+// enabled budget's use and remainder in the current period.
+func (h *Handler) getCommitmentBudgetUsage(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	usage, err := h.commitmentBudgetUsage(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"usage": usage}, nil
+}
+
+// commitmentBudgetUsage returns every enabled budget's standing at at,
+// never nil.
+func (h *Handler) commitmentBudgetUsage(ctx context.Context, at time.Time) ([]config.CommitmentBudgetUsage, error) {
+	usage, err := h.config.GetCommitmentBudgetUsage(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commitment budget usage: %w", err)
+	}
+	if usage == nil {
+		usage = []config.CommitmentBudgetUsage{}
+	}
+	return usage, nil
+}
+
+// createCommitmentBudget handles POST /api/commitment-budgets.
+func (h *Handler) createCommitmentBudget(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	b, err := parseCommitmentBudgetRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if saveErr := h.config.CreateCommitmentBudget(ctx, b); saveErr != nil {
+		return nil, fmt.Errorf("failed to create commitment budget: %w", saveErr)
+	}
+	return b, nil
+}
+
+// updateCommitmentBudget handles PUT /api/commitment-budgets/{id}. Amounts
+// already reserved or committed stay counted under the new caps.
+func (h *Handler) updateCommitmentBudget(ctx context.Context, req *events.LambdaFunctionURLRequest, budgetID string) (any, error) {
+	if err := validateUUID(budgetID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	b, err := parseCommitmentBudgetRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.ID = budgetID
+	if saveErr := h.config.UpdateCommitmentBudget(ctx, b); saveErr != nil {
+		return nil, approvalConfigError("commitment budget", saveErr)
+	}
+	return b, nil
+}
+
+// deleteCommitmentBudget handles DELETE /api/commitment-budgets/{id}.
+func (h *Handler) deleteCommitmentBudget(ctx context.Context, req *events.LambdaFunctionURLRequest, budgetID string) (any, error) {
+	if err := validateUUID(budgetID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	if err := h.config.DeleteCommitmentBudget(ctx, budgetID); err != nil {
+		return nil, approvalConfigError("commitment budget", err)
+	}
+	return map[string]string{"status": "commitment budget deleted"}, nil
+}
+
+// releaseBudgetReservation handles
+// POST /api/commitment-budgets/reservations/{id}/release: an operator
+// gives back a reservation a purchase path could not settle (its purchase
+// failed but the release did not land), or one for a commitment returned
+// outside CUDly. Releasing a released reservation is a no-op.
+func (h *Handler) releaseBudgetReservation(ctx context.Context, req *events.LambdaFunctionURLRequest, reservationID string) (any, error) {
+	if err := validateUUID(reservationID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	var body ReleaseBudgetReservationRequest
+	if strings.TrimSpace(req.Body) != "" {
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return nil, NewClientError(400, "invalid request body")
+		}
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" {
+		return nil, NewClientError(400, "reason is required")
+	}
+	if err := h.config.ReleaseBudgetReservation(ctx, reservationID, reason); err != nil {
+		return nil, approvalConfigError("budget reservation", err)
+	}
+	return map[string]string{"status": "budget reservation released"}, nil
+}
+
+// parseCommitmentBudgetRequest decodes and validates a commitment budget
+// body.
+func parseCommitmentBudgetRequest(body string) (*config.CommitmentBudget, error) {
+	var r CommitmentBudgetRequest
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if r.AccountGroupID != nil {
+		if err := validateUUID(*r.AccountGroupID); err != nil {
+			return nil, NewClientError(400, "account_group_id is not a valid ID")
+		}
+	}
+	b := &config.CommitmentBudget{
+		Name:           strings.TrimSpace(r.Name),
+		Period:         r.Period,
+		AccountGroupID: r.AccountGroupID,
+		MaxUpfrontUSD:  r.MaxUpfrontUSD,
+		MaxHourlyUSD:   r.MaxHourlyUSD,
+		Enabled:        r.Enabled == nil || *r.Enabled,
+	}
+	if err := b.Validate(); err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+	return b, nil
+}
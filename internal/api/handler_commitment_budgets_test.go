@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+)
+
+const commitmentBudgetID = "33333333-3333-3333-3333-333333333333"
+
+func TestCreateCommitmentBudget(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("CreateCommitmentBudget", mock.Anything, mock.MatchedBy(func(b *config.CommitmentBudget) bool {
+		return b.Name == "org" && b.Period == "quarter" && b.Enabled &&
+			b.MaxUpfrontUSD != nil && *b.MaxUpfrontUSD == 50000 && b.MaxHourlyUSD == nil
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*config.CommitmentBudget).ID = commitmentBudgetID
+	}).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"name":" org ","period":"quarter","max_upfront_usd":50000}`
+	got, err := h.createCommitmentBudget(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, commitmentBudgetID, got.(*config.CommitmentBudget).ID)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestCreateCommitmentBudget_RejectsInvalidBudgets(t *testing.T) {
+	tests := []struct{ name, body, want string }{
+		{"bad body", `{`, "invalid request body"},
+		{"no name", `{"period":"month","max_upfront_usd":1}`, "name"},
+		{"bad period", `{"name":"b","period":"year","max_upfront_usd":1}`, "period"},
+		{"no cap", `{"name":"b","period":"month"}`, "at least one"},
+		{"negative cap", `{"name":"b","period":"month","max_hourly_usd":-1}`, "max_hourly_usd"},
+		{"bad group", `{"name":"b","period":"month","max_upfront_usd":1,"account_group_id":"nope"}`, "account_group_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, cfgStore, _ := newPolicyHandler()
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.createCommitmentBudget(context.Background(), req)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, 400, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+			cfgStore.AssertNotCalled(t, "CreateCommitmentBudget", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateCommitmentBudget_NotFound(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("UpdateCommitmentBudget", mock.Anything, mock.Anything).
+		Return(fmt.Errorf("commitment budget %s: %w", commitmentBudgetID, config.ErrNotFound))
+
+	req := marketplaceReq()
+	req.Body = `{"name":"org","period":"month","max_hourly_usd":5}`
+	_, err := h.updateCommitmentBudget(context.Background(), req, commitmentBudgetID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 404, ce.code)
+}
+
+func TestCommitmentBudgets_RequireUpdateConfig(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "viewer", Email: "viewer@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionView, Resource: auth.ResourceConfig}})
+	h := &Handler{config: cfgStore, auth: authSvc}
+
+	_, err := h.deleteCommitmentBudget(context.Background(), marketplaceReq(), commitmentBudgetID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 403, ce.code)
+	cfgStore.AssertNotCalled(t, "DeleteCommitmentBudget", mock.Anything, mock.Anything)
+}
+
+func TestReleaseBudgetReservation(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("ReleaseBudgetReservation", mock.Anything, commitmentBudgetID, "returned via support case").Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"reason":" returned via support case "}`
+	_, err := h.releaseBudgetReservation(context.Background(), req, commitmentBudgetID)
+	require.NoError(t, err)
+	cfgStore.AssertExpectations(t)
+
+	req.Body = ``
+	_, err = h.releaseBudgetReservation(context.Background(), req, commitmentBudgetID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 400, ce.code)
+}
+
+func TestGetCommitmentBudgetUsage(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	remaining := 600.0
+	usage := []config.CommitmentBudgetUsage{{
+		Budget:              config.CommitmentBudget{ID: commitmentBudgetID, Name: "org", Period: "month"},
+		UsedUpfrontUSD:      400,
+		RemainingUpfrontUSD: &remaining,
+	}}
+	cfgStore.On("GetCommitmentBudgetUsage", mock.Anything, mock.AnythingOfType("time.Time")).Return(usage, nil)
+
+	got, err := h.getCommitmentBudgetUsage(context.Background(), marketplaceReq())
+	require.NoError(t, err)
+	assert.Equal(t, usage, got.(map[string]any)["usage"])
+}
+
+func TestPolicyApprovalResponse_BudgetExceeded(t *testing.T) {
+	exceeded := &budget.ExceededError{
+		Budget: "org", Period: budget.PeriodMonth, PeriodStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Limit: budget.LimitUpfront, Cap: 1000, Used: 900, Requested: 500,
+	}
+	_, handled, err := policyApprovalResponse(fmt.Errorf("purchase[e]: %w", exceeded))
+	require.True(t, handled)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 409, ce.code)
+	assert.Contains(t, err.Error(), "remaining $100.00")
+}
@@ -79,6 +79,15 @@ func (h *Handler) getDashboardSummary(ctx context.Context, req *events.LambdaFun
 		byService[svc] = entry
 	}
 
+	overlapping, atRisk := summarizeOverlap(recommendations)
+
+	// Best-effort: a budget lookup failure must not blank the dashboard.
+	budgets, budgetErr := h.commitmentBudgetUsage(ctx, time.Now())
+	if budgetErr != nil {
+		logging.Warnf("dashboard: %v", budgetErr)
+		budgets = []config.CommitmentBudgetUsage{}
+	}
+
 	return &DashboardSummaryResponse{
 		PotentialMonthlySavings: totalSavings,
 		TotalRecommendations:    len(recommendations),
@@ -88,6 +97,9 @@ func (h *Handler) getDashboardSummary(ctx context.Context, req *events.LambdaFun
 		TargetCoverage:          targetCoverage,
 		YTDSavings:              ytdSavings,
 		ByService:               byService,
+		OverlappingCount:        overlapping,
+		OverlapSavingsAtRisk:    atRisk,
+		CommitmentBudgets:       budgets,
 	}, nil
 }
 
@@ -559,8 +571,12 @@ func (h *Handler) getDeploymentInfo(ctx context.Context, _ *events.LambdaFunctio
 // so a future tweak to "what counts as expired" lands in exactly one
 // place). One year is approximated as 365 days — matches the original
 // dashboard arithmetic verbatim; leap-year precision isn't material for
-// a multi-year RI/SP/CUD term.
+// a multi-year RI/SP/CUD term. A set TermEnd (a marketplace RI bought
+// with the seller's remaining term) is returned as-is.
 func commitmentExpiry(p config.PurchaseHistoryRecord) time.Time {
+	if p.TermEnd != nil {
+		return *p.TermEnd
+	}
 	termDuration := time.Duration(p.Term) * 365 * 24 * time.Hour
 	return p.Timestamp.Add(termDuration)
 }
@@ -627,6 +643,9 @@ func aggregateActiveCommitmentsPerService(purchases []config.PurchaseHistoryReco
 //     row cap, so older-but-still-active 1y/3y commitments cannot be silently
 //     dropped the way a newest-first LIMIT 1000 page dropped them (issue #1140);
 //     the result is bounded by the number of live commitments.
+//   - externally purchased commitments from the synced inventory are merged in
+//     (appendExternalCommitments) before the provider filter, so the KPIs count
+//     every live commitment, not only the ones CUDly bought.
 //   - provider: when non-empty, only purchases for that provider are returned,
 //     mirroring fetchCommitmentRecords' in-memory provider filter so KPIs match
 //     the provider chip selection.
@@ -640,6 +659,7 @@ func (h *Handler) fetchCommitmentPurchases(ctx context.Context, asOf time.Time,
 		logging.Errorf("dashboard: failed to fetch commitment purchases; KPIs will be zeroed: %v", err)
 		return nil, false
 	}
+	purchases = h.appendExternalCommitments(ctx, asOf, purchases, accountUUIDs, accountExternalIDsByProvider)
 
 	if provider != "" {
 		filtered := purchases[:0]
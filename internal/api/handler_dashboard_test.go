@@ -854,6 +854,23 @@ func TestHandler_calculateCommitmentMetrics(t *testing.T) {
 		assert.Empty(t, savingsByService)
 	})
 
+	t.Run("externally purchased commitments count as active", func(t *testing.T) {
+		mockStore := new(MockConfigStore)
+		scope := map[string][]string{"aws": {"account-123"}}
+		end := time.Now().AddDate(1, 0, 0)
+		mockStore.On("GetActivePurchaseHistory", ctx, mock.AnythingOfType("time.Time"), []string(nil), scope).Return([]config.PurchaseHistoryRecord{}, nil)
+		mockStore.On("GetActiveCommitmentInventory", ctx, mock.AnythingOfType("time.Time"), []string(nil), scope).Return([]config.CommitmentInventoryRecord{
+			{Provider: "aws", AccountID: "account-123", CommitmentID: "ri-console", Service: "ec2", EndDate: &end, Source: config.CommitmentSourceExternal},
+		}, nil)
+
+		handler := &Handler{config: mockStore}
+
+		activeCommitments, committedMonthly, _, _ := handler.calculateCommitmentMetrics(ctx, "", nil, scope)
+
+		assert.Equal(t, 1, activeCommitments)
+		assert.Equal(t, 0.0, committedMonthly, "an external commitment has no recorded savings")
+	})
+
 	t.Run("purchase history error returns zeros", func(t *testing.T) {
 		mockStore := new(MockConfigStore)
 		mockStore.On("GetActivePurchaseHistory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string{"": {"account-123"}}).Return(nil, errors.New("db error"))
@@ -1605,6 +1622,21 @@ func TestIsActiveCommitment_RevokedReturnsFalse(t *testing.T) {
 		"revoked commitment must not be active even when its term has not expired")
 }
 
+// TestIsActiveCommitment_TermEndOverridesTerm covers marketplace RIs bought
+// with a partial remaining term: TermEnd, not Timestamp + Term years, decides
+// when they stop being active.
+func TestIsActiveCommitment_TermEndOverridesTerm(t *testing.T) {
+	now := time.Now()
+	ended := now.AddDate(0, -1, 0)
+	p := config.PurchaseHistoryRecord{
+		Timestamp: now.AddDate(0, -3, 0),
+		Term:      1,
+		TermEnd:   &ended,
+	}
+	assert.Equal(t, ended, commitmentExpiry(p))
+	assert.False(t, isActiveCommitment(p, now), "a marketplace RI past its TermEnd is expired despite Term")
+}
+
 // TestHandler_calculateCommitmentMetrics_RevokedExcluded is the defect-#2
 // regression guard for the dashboard KPI path. A revoked purchase returned by
 // GetActivePurchaseHistory (e.g., from a DB snapshot before the SQL fix was
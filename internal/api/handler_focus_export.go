@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/analytics"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/focus"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FOCUS export datasets selectable with ?dataset=.
+const (
+	focusDatasetPurchases   = "purchases"
+	focusDatasetCommitments = "commitments"
+	focusDatasetSavings     = "savings"
+)
+
+// exportFOCUS handles GET /api/export/focus?dataset=purchases|commitments|savings.
+//
+// Renders CUDly's own data as a FOCUS 1.x CSV so FinOps tooling that speaks
+// FOCUS can ingest it next to provider billing data:
+//   - purchases: one Purchase row per completed commitment purchase, billed
+//     at its upfront cost, filtered like /api/history (provider, account_id,
+//     account_ids, start, end);
+//   - commitments: one Recurring fee row per active commitment for the
+//     current month, filtered like /api/inventory/commitments;
+//   - savings: one committed Usage row per month, account, service, region
+//     and commitment type from the savings snapshots, filtered like
+//     /api/analytics/trends. Snapshots are hourly run-rates, so only the
+//     latest snapshot of each month is exported.
+//
+// Auth: `view:purchases`, the same gate as the endpoints each dataset
+// mirrors, with the session's allowed_accounts applied the same way.
+func (h *Handler) exportFOCUS(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	session, err := h.requirePermission(ctx, req, "view", "purchases")
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []focus.Row
+	switch dataset := params["dataset"]; dataset {
+	case focusDatasetPurchases:
+		rows, err = h.focusPurchaseRows(ctx, session, params)
+	case focusDatasetCommitments:
+		rows, err = h.focusCommitmentRows(ctx, session, params, time.Now())
+	case focusDatasetSavings:
+		rows, err = h.focusSavingsRows(ctx, session, params)
+	default:
+		return nil, NewClientError(400, fmt.Sprintf("dataset must be one of %s, %s, %s", focusDatasetPurchases, focusDatasetCommitments, focusDatasetSavings))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := focus.WriteCSV(&buf, rows); err != nil {
+		return nil, fmt.Errorf("failed to render FOCUS export: %w", err)
+	}
+	return &rawResponse{contentType: "text/csv; charset=utf-8", body: buf.String()}, nil
+}
+
+func (h *Handler) focusPurchaseRows(ctx context.Context, session *Session, params map[string]string) ([]focus.Row, error) {
+	filters, err := parseHistoryFilters(params)
+	if err != nil {
+		return nil, err
+	}
+	h.resolveHistoryAccountFilter(ctx, &filters)
+	purchases, err := h.fetchPurchaseHistory(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	purchases, err = h.filterPurchaseHistoryByAllowedAccounts(ctx, session, purchases)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(purchases, func(i, j int) bool { return purchases[i].Timestamp.Before(purchases[j].Timestamp) })
+
+	rows := make([]focus.Row, 0, len(purchases))
+	for _rvc := range purchases {
+		p := purchases[_rvc]
+		// A revoked (returned) commitment was refunded; exporting its
+		// purchase without the matching credit would overstate spend.
+		if p.RevokedAt != nil {
+			continue
+		}
+		rows = append(rows, focus.PurchaseRow(focusCommitment(p)))
+	}
+	return rows, nil
+}
+
+func (h *Handler) focusCommitmentRows(ctx context.Context, session *Session, params map[string]string, now time.Time) ([]focus.Row, error) {
+	purchases, err := h.fetchCommitmentRecords(ctx, now, session, params)
+	if err != nil {
+		return nil, err
+	}
+	purchases, err = h.filterPurchaseHistoryByAllowedAccounts(ctx, session, purchases)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]focus.Row, 0, len(purchases))
+	for _rvc := range purchases {
+		p := purchases[_rvc]
+		if !isActiveCommitment(p, now) {
+			continue
+		}
+		if row, ok := focus.InventoryRow(focusCommitment(p), now); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (h *Handler) focusSavingsRows(ctx context.Context, session *Session, params map[string]string) ([]focus.Row, error) {
+	if h.analyticsSnapshots == nil {
+		// Mirror getAnalyticsTrends: 503 = feature intentionally unavailable.
+		return nil, NewClientError(503, "analytics snapshots not configured")
+	}
+	accountID := params["account_id"]
+	if err := h.validateAnalyticsAccountScope(ctx, session, accountID); err != nil {
+		return nil, err
+	}
+	start, end, err := parseDateRange(params["start"], params["end"])
+	if err != nil {
+		return nil, err
+	}
+	accountUUIDs, accountExternalIDsByProvider := h.resolveSingleAccountFilterIDs(ctx, accountID)
+
+	snapshots, err := h.analyticsSnapshots.QuerySavings(ctx, analytics.QueryRequest{
+		StartDate:                    start,
+		EndDate:                      end,
+		AccountUUIDs:                 accountUUIDs,
+		AccountExternalIDsByProvider: accountExternalIDsByProvider,
+		Provider:                     params["provider"],
+		Service:                      params["service"],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query savings snapshots: %w", err)
+	}
+
+	latest := latestSnapshotPerMonth(snapshots)
+	rows := make([]focus.Row, 0, len(latest))
+	for _rvc := range latest {
+		s := latest[_rvc]
+		rows = append(rows, focus.SavingsRow(focus.SavingsRunRate{
+			Provider:         common.ProviderType(s.Provider),
+			AccountID:        s.AccountID,
+			Service:          common.ServiceType(s.Service),
+			Region:           s.Region,
+			CommitmentType:   s.CommitmentType,
+			Timestamp:        s.Timestamp,
+			AmortizedUpfront: s.TotalCommitment,
+			Recurring:        s.TotalUsage,
+			Savings:          s.TotalSavings,
+		}))
+	}
+	return rows, nil
+}
+
+// latestSnapshotPerMonth keeps the newest snapshot of each calendar month
+// per account, service, region and commitment type, ordered by month.
+// Summing every hourly run-rate of a month would multiply it by the number
+// of collections.
+func latestSnapshotPerMonth(snapshots []analytics.SavingsSnapshot) []analytics.SavingsSnapshot {
+	type key struct {
+		month                                              time.Time
+		provider, account, service, region, commitmentType string
+	}
+	byKey := map[key]int{}
+	var out []analytics.SavingsSnapshot
+	for _rvc := range snapshots {
+		s := snapshots[_rvc]
+		t := s.Timestamp.UTC()
+		k := key{time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), s.Provider, s.AccountID, s.Service, s.Region, s.CommitmentType}
+		if i, ok := byKey[k]; ok {
+			if s.Timestamp.After(out[i].Timestamp) {
+				out[i] = s
+			}
+			continue
+		}
+		byKey[k] = len(out)
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// focusCommitment maps a purchase_history row onto the pkg/focus commitment
+// model. PurchaseID is the provider's commitment ID.
+func focusCommitment(p config.PurchaseHistoryRecord) focus.Commitment {
+	return focus.Commitment{
+		Provider:                common.ProviderType(p.Provider),
+		AccountID:               p.AccountID,
+		ID:                      p.PurchaseID,
+		Service:                 common.ServiceType(p.Service),
+		Region:                  p.Region,
+		ResourceType:            p.ResourceType,
+		Count:                   p.Count,
+		TermYears:               p.Term,
+		PaymentOption:           p.Payment,
+		Start:                   p.Timestamp,
+		End:                     commitmentExpiry(p),
+		UpfrontCost:             p.UpfrontCost,
+		MonthlyCost:             p.MonthlyCost,
+		EstimatedMonthlySavings: p.EstimatedSavings,
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/analytics"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/focus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFOCUSExport decodes an exportFOCUS response into header-keyed rows.
+func parseFOCUSExport(t *testing.T, result any) []map[string]string {
+	t.Helper()
+	raw, ok := result.(*rawResponse)
+	require.True(t, ok, "FOCUS export must be a raw CSV response")
+	assert.Equal(t, "text/csv; charset=utf-8", raw.contentType)
+	records, err := csv.NewReader(strings.NewReader(raw.body)).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	assert.Equal(t, focus.Columns, records[0])
+	var out []map[string]string
+	for _, rec := range records[1:] {
+		row := map[string]string{}
+		for i, col := range records[0] {
+			row[col] = rec[i]
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func TestHandler_exportFOCUS_Purchases(t *testing.T) {
+	ctx := context.Background()
+	revoked := time.Now()
+	mockStore := new(MockConfigStore)
+	mockStore.On("GetAllPurchaseHistory", ctx, config.DefaultListLimit).Return([]config.PurchaseHistoryRecord{
+		{AccountID: "111111111111", PurchaseID: "ri-1", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 2, Term: 1, Payment: "all-upfront", UpfrontCost: 1000,
+			Timestamp: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{AccountID: "111111111111", PurchaseID: "ri-2", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			Term: 1, UpfrontCost: 500, Timestamp: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), RevokedAt: &revoked},
+	}, nil)
+
+	mockAuth, req := adminInventoryReq(ctx)
+	handler := &Handler{auth: mockAuth, config: mockStore}
+
+	result, err := handler.exportFOCUS(ctx, req, map[string]string{"dataset": "purchases"})
+	require.NoError(t, err)
+	rows := parseFOCUSExport(t, result)
+	require.Len(t, rows, 1, "revoked purchases are not exported")
+	assert.Equal(t, focus.ChargePurchase, rows[0][focus.ColChargeCategory])
+	assert.Equal(t, "ri-1", rows[0][focus.ColCommitmentDiscountID])
+	assert.Equal(t, "1000", rows[0][focus.ColBilledCost])
+	assert.Equal(t, "AWS", rows[0][focus.ColProviderName])
+}
+
+func TestHandler_exportFOCUS_Commitments(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	mockStore.On("GetActivePurchaseHistory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string(nil)).Return([]config.PurchaseHistoryRecord{
+		{AccountID: "sub-1", PurchaseID: "r1", Provider: "azure", Service: "compute", Region: "eastus",
+			Count: 1, Term: 3, Timestamp: time.Now().AddDate(0, -2, 0), MonthlyCost: float64Ptr(40)},
+		{AccountID: "sub-1", PurchaseID: "r0", Provider: "azure", Service: "compute", Region: "eastus",
+			Count: 1, Term: 1, Timestamp: time.Now().AddDate(-2, 0, 0), MonthlyCost: float64Ptr(40)},
+	}, nil)
+
+	mockAuth, req := adminInventoryReq(ctx)
+	handler := &Handler{auth: mockAuth, config: mockStore}
+
+	result, err := handler.exportFOCUS(ctx, req, map[string]string{"dataset": "commitments"})
+	require.NoError(t, err)
+	rows := parseFOCUSExport(t, result)
+	require.Len(t, rows, 1, "expired commitments are not exported")
+	assert.Equal(t, "r1", rows[0][focus.ColCommitmentDiscountID])
+	assert.Equal(t, focus.FrequencyRecurring, rows[0][focus.ColChargeFrequency])
+	assert.Equal(t, "Microsoft", rows[0][focus.ColProviderName])
+}
+
+func TestHandler_exportFOCUS_SavingsKeepsLatestSnapshotPerMonth(t *testing.T) {
+	ctx := context.Background()
+	recurring := 50.0
+	snap := func(ts time.Time, savings float64) analytics.SavingsSnapshot {
+		return analytics.SavingsSnapshot{Timestamp: ts, AccountID: "111111111111", Provider: "aws", Service: "ec2",
+			Region: "us-east-1", CommitmentType: "RI", TotalCommitment: 100, TotalUsage: &recurring, TotalSavings: savings}
+	}
+	mockSnap := new(MockAnalyticsSnapshotStore)
+	mockSnap.On("QuerySavings", ctx, mock.AnythingOfType("analytics.QueryRequest")).Return([]analytics.SavingsSnapshot{
+		snap(time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC), 30),
+		snap(time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC), 40),
+		snap(time.Date(2026, 4, 1, 1, 0, 0, 0, time.UTC), 45),
+	}, nil)
+
+	mockAuth, req := adminAnalyticsReq(ctx)
+	handler := &Handler{auth: mockAuth, analyticsSnapshots: mockSnap, config: new(MockConfigStore)}
+
+	result, err := handler.exportFOCUS(ctx, req, map[string]string{"dataset": "savings", "start": "2026-03-01", "end": "2026-04-30"})
+	require.NoError(t, err)
+	rows := parseFOCUSExport(t, result)
+	require.Len(t, rows, 2, "one row per month")
+	assert.Equal(t, "190", rows[0][focus.ColListCost], "March uses its latest snapshot")
+	assert.Equal(t, "150", rows[0][focus.ColEffectiveCost])
+	assert.Equal(t, "195", rows[1][focus.ColListCost])
+}
+
+func TestHandler_exportFOCUS_RejectsUnknownDataset(t *testing.T) {
+	ctx := context.Background()
+	mockAuth, req := adminInventoryReq(ctx)
+	handler := &Handler{auth: mockAuth, config: new(MockConfigStore)}
+
+	_, err := handler.exportFOCUS(ctx, req, map[string]string{"dataset": "invoices"})
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 400, ce.code)
+}
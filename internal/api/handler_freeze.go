@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// Change-freeze windows: the blackout calendar during which scheduled
+// purchases and ladder tranches are deferred or skipped (see pkg/freeze),
+// and manual purchases need an administrator's override. Reads take
+// view:config; writes take update:config; overrides take admin.
+
+// freezeOverrideListLimit bounds GET /api/freeze-windows/overrides.
+const freezeOverrideListLimit = 100
+
+// FreezeWindowRequest is the body of POST /api/freeze-windows and
+// PUT /api/freeze-windows/{id}. Recurrence defaults to "none", Action to
+// "defer" and Enabled to true.
+type FreezeWindowRequest struct {
+	Name       string    `json:"name"`
+	Reason     string    `json:"reason"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Recurrence string    `json:"recurrence"`
+	Provider   string    `json:"provider"`
+	AccountIDs []string  `json:"account_ids"`
+	Action     string    `json:"action"`
+	Enabled    *bool     `json:"enabled"`
+}
+
+// FreezeOverrideRequest is the body of
+// POST /api/purchases/{id}/freeze-override.
+type FreezeOverrideRequest struct {
+	Reason string `json:"reason"`
+}
+
+// listFreezeWindows handles GET /api/freeze-windows.
+func (h *Handler) listFreezeWindows(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	windows, err := h.config.ListFreezeWindows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list freeze windows: %w", err)
+	}
+	if windows == nil {
+		windows = []config.FreezeWindow{}
+	}
+	return map[string]any{"freeze_windows": windows}, nil
+}
+
+// createFreezeWindow handles POST /api/freeze-windows.
+func (h *Handler) createFreezeWindow(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	w, err := parseFreezeWindowRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if saveErr := h.config.CreateFreezeWindow(ctx, w); saveErr != nil {
+		return nil, fmt.Errorf("failed to create freeze window: %w", saveErr)
+	}
+	return w, nil
+}
+
+// updateFreezeWindow handles PUT /api/freeze-windows/{id}. Executions
+// already deferred by the window keep their new time; the next sweep
+// re-checks them against the updated calendar.
+func (h *Handler) updateFreezeWindow(ctx context.Context, req *events.LambdaFunctionURLRequest, windowID string) (any, error) {
+	if err := validateUUID(windowID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	w, err := parseFreezeWindowRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	w.ID = windowID
+	if saveErr := h.config.UpdateFreezeWindow(ctx, w); saveErr != nil {
+		return nil, approvalConfigError("freeze window", saveErr)
+	}
+	return w, nil
+}
+
+// deleteFreezeWindow handles DELETE /api/freeze-windows/{id}.
+func (h *Handler) deleteFreezeWindow(ctx context.Context, req *events.LambdaFunctionURLRequest, windowID string) (any, error) {
+	if err := validateUUID(windowID); err != nil {
+		return nil, err
+	}
+	if _, err := h.requirePermission(ctx, req, "update", "config"); err != nil {
+		return nil, err
+	}
+	if err := h.config.DeleteFreezeWindow(ctx, windowID); err != nil {
+		return nil, approvalConfigError("freeze window", err)
+	}
+	return map[string]string{"status": "freeze window deleted"}, nil
+}
+
+// listFreezeOverrides handles GET /api/freeze-windows/overrides: the audit
+// trail of administrators overriding a freeze, newest first.
+func (h *Handler) listFreezeOverrides(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	overrides, err := h.config.ListFreezeOverrides(ctx, freezeOverrideListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list freeze overrides: %w", err)
+	}
+	if overrides == nil {
+		overrides = []config.FreezeOverride{}
+	}
+	return map[string]any{"overrides": overrides}, nil
+}
+
+// overridePurchaseFreeze handles POST /api/purchases/{id}/freeze-override:
+// an administrator lifts the change freeze in force over one execution,
+// with a reason, so it can be approved or executed now. The override is
+// recorded for audit and lasts for the window occurrence now in force. A
+// scheduled execution the freeze deferred is brought forward so the next
+// sweep buys it.
+func (h *Handler) overridePurchaseFreeze(ctx context.Context, req *events.LambdaFunctionURLRequest, executionID string) (any, error) {
+	if err := validateUUID(executionID); err != nil {
+		return nil, err
+	}
+	session, err := h.requireAdmin(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var body FreezeOverrideRequest
+	if strings.TrimSpace(req.Body) != "" {
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return nil, NewClientError(400, "invalid request body")
+		}
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" {
+		return nil, NewClientError(400, "reason is required")
+	}
+
+	exec, err := h.config.GetExecutionByID(ctx, executionID)
+	if errors.Is(err, config.ErrNotFound) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution: %w", err)
+	}
+	now := time.Now()
+	d, err := purchase.FreezeInForce(ctx, h.config, exec, now)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, NewClientError(409, fmt.Sprintf("no change freeze is in force over execution %s", executionID))
+	}
+
+	o := &config.FreezeOverride{
+		ExecutionID: executionID,
+		WindowName:  d.Window.Name,
+		Actor:       fourEyesActorIdentity(session),
+		ActorUserID: validUUIDPtrOrNil(&session.UserID),
+		Reason:      reason,
+	}
+	if d.Window.ID != "" {
+		windowID := d.Window.ID
+		o.WindowID = &windowID
+	}
+	if err := h.config.RecordFreezeOverride(ctx, o); err != nil {
+		return nil, fmt.Errorf("failed to record freeze override: %w", err)
+	}
+	// PII policy: log execution and window only, not user identifiers.
+	logging.Infof("purchase[%s]: change freeze %q overridden", executionID, d.Window.Name)
+
+	// A row the freeze deferred is stamped at exactly the first allowed
+	// time; one scheduled for any other reason keeps its revoke window.
+	if exec.Status == "scheduled" && exec.ScheduledExecutionAt != nil && exec.ScheduledExecutionAt.Equal(d.Until) {
+		exec.ScheduledExecutionAt = &now
+		if saveErr := h.config.SavePurchaseExecution(ctx, exec); saveErr != nil {
+			logging.Errorf("purchase[%s]: failed to bring the deferred purchase forward: %v", executionID, saveErr)
+		}
+	}
+	return o, nil
+}
+
+// parseFreezeWindowRequest decodes and validates a freeze window body.
+func parseFreezeWindowRequest(body string) (*config.FreezeWindow, error) {
+	var r FreezeWindowRequest
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	for _, id := range r.AccountIDs {
+		if err := validateUUID(id); err != nil {
+			return nil, NewClientError(400, fmt.Sprintf("account_ids: %q is not a valid ID", id))
+		}
+	}
+	w := &config.FreezeWindow{
+		Name:       strings.TrimSpace(r.Name),
+		Reason:     strings.TrimSpace(r.Reason),
+		StartsAt:   r.StartsAt,
+		EndsAt:     r.EndsAt,
+		Recurrence: r.Recurrence,
+		Provider:   r.Provider,
+		AccountIDs: r.AccountIDs,
+		Action:     r.Action,
+		Enabled:    r.Enabled == nil || *r.Enabled,
+	}
+	if w.Recurrence == "" {
+		w.Recurrence = string(freeze.RecurrenceNone)
+	}
+	if w.Action == "" {
+		w.Action = string(freeze.ActionDefer)
+	}
+	if err := w.Validate(); err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+	return w, nil
+}
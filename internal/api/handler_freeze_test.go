@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
+)
+
+const (
+	freezeWindowID    = "44444444-4444-4444-4444-444444444444"
+	freezeExecutionID = "55555555-5555-5555-5555-555555555555"
+)
+
+// freezeWindowInForce returns an enabled window in force now, ending in a
+// day.
+func freezeWindowInForce() config.FreezeWindow {
+	now := time.Now().UTC().Truncate(time.Second)
+	return config.FreezeWindow{
+		ID: freezeWindowID, Name: "fy-close", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour),
+		Recurrence: "none", Action: "defer", Enabled: true,
+	}
+}
+
+func TestCreateFreezeWindow(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("CreateFreezeWindow", mock.Anything, mock.MatchedBy(func(w *config.FreezeWindow) bool {
+		return w.Name == "fy-close" && w.Recurrence == "none" && w.Action == "defer" && w.Enabled &&
+			w.Provider == "aws" && w.EndsAt.Sub(w.StartsAt) == 14*24*time.Hour
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*config.FreezeWindow).ID = freezeWindowID
+	}).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"name":" fy-close ","starts_at":"2026-12-20T00:00:00Z","ends_at":"2027-01-03T00:00:00Z","provider":"aws"}`
+	got, err := h.createFreezeWindow(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, freezeWindowID, got.(*config.FreezeWindow).ID)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestCreateFreezeWindow_RejectsInvalidWindows(t *testing.T) {
+	const span = `"starts_at":"2026-12-20T00:00:00Z","ends_at":"2027-01-03T00:00:00Z"`
+	tests := []struct{ name, body, want string }{
+		{"bad body", `{`, "invalid request body"},
+		{"no name", `{` + span + `}`, "name"},
+		{"end before start", `{"name":"w","starts_at":"2027-01-03T00:00:00Z","ends_at":"2026-12-20T00:00:00Z"}`, "end"},
+		{"bad recurrence", `{"name":"w",` + span + `,"recurrence":"daily"}`, "recurrence"},
+		{"bad action", `{"name":"w",` + span + `,"action":"cancel"}`, "action"},
+		{"bad provider", `{"name":"w",` + span + `,"provider":"oracle"}`, "provider"},
+		{"bad account", `{"name":"w",` + span + `,"account_ids":["nope"]}`, "account_ids"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, cfgStore, _ := newPolicyHandler()
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.createFreezeWindow(context.Background(), req)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, 400, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+			cfgStore.AssertNotCalled(t, "CreateFreezeWindow", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateFreezeWindow_NotFound(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("UpdateFreezeWindow", mock.Anything, mock.Anything).
+		Return(fmt.Errorf("freeze window %s: %w", freezeWindowID, config.ErrNotFound))
+
+	req := marketplaceReq()
+	req.Body = `{"name":"fy-close","starts_at":"2026-12-20T00:00:00Z","ends_at":"2027-01-03T00:00:00Z"}`
+	_, err := h.updateFreezeWindow(context.Background(), req, freezeWindowID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 404, ce.code)
+}
+
+func TestOverridePurchaseFreeze(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	window := freezeWindowInForce()
+	deferredTo := window.EndsAt
+	exec := &config.PurchaseExecution{
+		ExecutionID: freezeExecutionID, Status: "scheduled", ScheduledExecutionAt: &deferredTo,
+		Recommendations: []config.RecommendationRecord{{Provider: "aws", Selected: true}},
+	}
+	cfgStore.On("GetExecutionByID", mock.Anything, freezeExecutionID).Return(exec, nil)
+	cfgStore.On("ListFreezeWindows", mock.Anything).Return([]config.FreezeWindow{window}, nil)
+	cfgStore.On("RecordFreezeOverride", mock.Anything, mock.MatchedBy(func(o *config.FreezeOverride) bool {
+		return o.ExecutionID == freezeExecutionID && o.WindowID != nil && *o.WindowID == freezeWindowID &&
+			o.WindowName == "fy-close" && o.Actor == "admin@test.com" && o.ActorUserID != nil &&
+			o.Reason == "board approved the renewal"
+	})).Return(nil)
+	cfgStore.On("SavePurchaseExecution", mock.Anything, mock.MatchedBy(func(e *config.PurchaseExecution) bool {
+		return e.ScheduledExecutionAt != nil && e.ScheduledExecutionAt.Before(window.EndsAt)
+	})).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"reason":" board approved the renewal "}`
+	got, err := h.overridePurchaseFreeze(context.Background(), req, freezeExecutionID)
+	require.NoError(t, err)
+	assert.Equal(t, "fy-close", got.(*config.FreezeOverride).WindowName)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestOverridePurchaseFreeze_Refusals(t *testing.T) {
+	t.Run("a reason is required", func(t *testing.T) {
+		h, cfgStore, _ := newPolicyHandler()
+		_, err := h.overridePurchaseFreeze(context.Background(), marketplaceReq(), freezeExecutionID)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "expected a ClientError, got: %v", err)
+		assert.Equal(t, 400, ce.code)
+		cfgStore.AssertNumberOfCalls(t, "RecordFreezeOverride", 0)
+	})
+
+	t.Run("no freeze in force", func(t *testing.T) {
+		h, cfgStore, _ := newPolicyHandler()
+		cfgStore.On("GetExecutionByID", mock.Anything, freezeExecutionID).
+			Return(&config.PurchaseExecution{ExecutionID: freezeExecutionID, Status: "pending"}, nil)
+		req := marketplaceReq()
+		req.Body = `{"reason":"urgent"}`
+		_, err := h.overridePurchaseFreeze(context.Background(), req, freezeExecutionID)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "expected a ClientError, got: %v", err)
+		assert.Equal(t, 409, ce.code)
+		cfgStore.AssertNumberOfCalls(t, "RecordFreezeOverride", 0)
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+		authSvc.On("ValidateSession", mock.Anything, "test-token").
+			Return(&Session{UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", Email: "ops@test.com"}, nil)
+		authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionUpdate, Resource: auth.ResourceConfig}})
+		h := &Handler{config: cfgStore, auth: authSvc}
+		req := marketplaceReq()
+		req.Body = `{"reason":"urgent"}`
+		_, err := h.overridePurchaseFreeze(context.Background(), req, freezeExecutionID)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "expected a ClientError, got: %v", err)
+		assert.Equal(t, 403, ce.code)
+		cfgStore.AssertNumberOfCalls(t, "RecordFreezeOverride", 0)
+	})
+}
+
+func TestPolicyApprovalResponse_Frozen(t *testing.T) {
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	frozen := &freeze.FrozenError{Decision: freeze.Decision{
+		Window: freeze.Window{Name: "fy-close"}, Start: start, End: start.AddDate(0, 0, 14), Until: start.AddDate(0, 0, 14),
+	}}
+	_, handled, err := policyApprovalResponse(fmt.Errorf("change freeze check: %w", frozen))
+	require.True(t, handled)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 409, ce.code)
+	assert.Contains(t, err.Error(), `"fy-close"`)
+}
+
+func TestApproveWithDelay_FreezeNeedsOverride(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	pm := &MockPurchaseManager{}
+	w := freezeWindowInForce()
+	d := freeze.Decision{Window: w.Window(), Start: w.StartsAt, End: w.EndsAt, Until: w.EndsAt}
+	pm.On("CheckManualFreeze", mock.Anything, freezeExecutionID).Return(&freeze.FrozenError{Decision: d})
+	h := &Handler{config: cfgStore, purchase: pm}
+
+	_, err := h.approveWithDelay(context.Background(), &config.PurchaseExecution{ExecutionID: freezeExecutionID, Status: "pending"}, 48*time.Hour, "lead@example.com", nil)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 409, ce.code)
+	assert.Contains(t, err.Error(), `"fy-close"`)
+	pm.AssertNotCalled(t, "EnforceApprovalPolicy", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	cfgStore.AssertNotCalled(t, "TransitionExecutionStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/api/option"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/credentials"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+// gcpCommitmentsClient is the narrow slice of the GCP compute client the
+// commitment management handlers need. *computeengine.ComputeEngineClient
+// implements it.
+type gcpCommitmentsClient interface {
+	GetResourceCommitments(ctx context.Context) ([]computeengine.ResourceCommitmentInfo, error)
+	SetAutoRenew(ctx context.Context, name string, autoRenew bool) error
+	MergeCommitments(ctx context.Context, names []string, idempotencyToken string) (string, error)
+	ApplyAutoRenewPolicy(ctx context.Context, policy computeengine.AutoRenewPolicy, now time.Time, dryRun bool) ([]computeengine.AutoRenewDecision, error)
+}
+
+// maxMergeSourceCommitments caps how many commitments one merge request may
+// name.
+const maxMergeSourceCommitments = 50
+
+// GCPCommitment is one resource-based CUD in GET /api/gcp/commitments.
+type GCPCommitment struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Plan      string    `json:"plan"`
+	Status    string    `json:"status"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	VCPUs     int64     `json:"vcpus"`
+	MemoryMB  int64     `json:"memory_mb"`
+	AutoRenew bool      `json:"auto_renew"`
+}
+
+// GCPCommitmentsResponse is the response for GET /api/gcp/commitments.
+// MergeableGroups lists, by name, the sets of commitments that
+// POST /api/gcp/commitments/merge would accept together.
+type GCPCommitmentsResponse struct {
+	Region          string          `json:"region"`
+	Commitments     []GCPCommitment `json:"commitments"`
+	MergeableGroups [][]string      `json:"mergeable_groups"`
+}
+
+// GCPAutoRenewRequest is the body for
+// PUT /api/gcp/commitments/{name}/auto-renew.
+type GCPAutoRenewRequest struct {
+	AccountID string `json:"account_id"`
+	Region    string `json:"region"`
+	AutoRenew *bool  `json:"auto_renew"`
+}
+
+// GCPMergeRequest is the body for POST /api/gcp/commitments/merge.
+type GCPMergeRequest struct {
+	AccountID   string   `json:"account_id"`
+	Region      string   `json:"region"`
+	Commitments []string `json:"commitments"`
+}
+
+// GCPMergeResponse is the response for POST /api/gcp/commitments/merge.
+type GCPMergeResponse struct {
+	Commitment string   `json:"commitment"`
+	MergedFrom []string `json:"merged_from"`
+}
+
+// GCPAutoRenewPolicyRequest is the body for
+// POST /api/gcp/commitments/auto-renew-policy. DryRun defaults to true so a
+// bare request only previews the decisions.
+type GCPAutoRenewPolicyRequest struct {
+	AccountID      string  `json:"account_id"`
+	Region         string  `json:"region"`
+	HorizonDays    int     `json:"horizon_days,omitempty"`
+	MinCoveragePct float64 `json:"min_coverage_pct,omitempty"`
+	DryRun         *bool   `json:"dry_run,omitempty"`
+}
+
+// GCPAutoRenewPolicyResponse is the response for
+// POST /api/gcp/commitments/auto-renew-policy.
+type GCPAutoRenewPolicyResponse struct {
+	Region    string                            `json:"region"`
+	DryRun    bool                              `json:"dry_run"`
+	Decisions []computeengine.AutoRenewDecision `json:"decisions"`
+}
+
+// buildGCPCommitmentsClient honors the injected factory when set, otherwise
+// resolves the account's GCP credential the way the ladder does and builds a
+// compute client for region.
+func (h *Handler) buildGCPCommitmentsClient(ctx context.Context, account *config.CloudAccount, region string) (gcpCommitmentsClient, error) {
+	if h.gcpCommitmentsFactory != nil {
+		return h.gcpCommitmentsFactory(account.GCPProjectID, region), nil
+	}
+	ts, err := credentials.ResolveGCPTokenSourceWithOpts(ctx, account, h.credStore, credentials.GCPResolveOptions{
+		Signer:    h.signer,
+		IssuerURL: h.issuerURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp: resolve credentials for project %q: %w", account.GCPProjectID, err)
+	}
+	var opts []option.ClientOption
+	if ts != nil {
+		opts = append(opts, option.WithTokenSource(ts))
+	}
+	return computeengine.NewClient(ctx, account.GCPProjectID, region, opts...)
+}
+
+// resolveGCPCommitmentsClient checks the session may use accountID, that it
+// is a GCP project, and returns a compute client for region.
+func (h *Handler) resolveGCPCommitmentsClient(ctx context.Context, session *Session, accountID, region string) (gcpCommitmentsClient, error) {
+	if accountID == "" {
+		return nil, NewClientError(400, "account_id is required")
+	}
+	if region == "" {
+		return nil, NewClientError(400, "region is required")
+	}
+	account, err := h.requireAccountAccess(ctx, session, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Provider != "gcp" || account.GCPProjectID == "" {
+		return nil, NewClientError(400, "account_id must name a GCP account with a project ID")
+	}
+	return h.buildGCPCommitmentsClient(ctx, account, region)
+}
+
+// listGCPCommitments returns the region's resource-based CUDs with their
+// auto-renew setting and the groups that could be merged.
+//
+// GET /api/gcp/commitments?account_id=&region=.
+func (h *Handler) listGCPCommitments(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "view", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	region := req.QueryStringParameters["region"]
+	client, err := h.resolveGCPCommitmentsClient(ctx, session, req.QueryStringParameters["account_id"], region)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := client.GetResourceCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GCP commitments: %w", err)
+	}
+	resp := &GCPCommitmentsResponse{
+		Region:          region,
+		Commitments:     make([]GCPCommitment, 0, len(infos)),
+		MergeableGroups: [][]string{},
+	}
+	for _, info := range infos {
+		resp.Commitments = append(resp.Commitments, GCPCommitment{
+			Name: info.Name, Type: info.Type, Plan: info.Plan, Status: info.Status,
+			Start: info.Start, End: info.End, VCPUs: info.VCPUs, MemoryMB: info.MemoryMB,
+			AutoRenew: info.AutoRenew,
+		})
+	}
+	for _, group := range computeengine.MergeableGroups(infos) {
+		names := make([]string, 0, len(group))
+		for _, info := range group {
+			names = append(names, info.Name)
+		}
+		resp.MergeableGroups = append(resp.MergeableGroups, names)
+	}
+	return resp, nil
+}
+
+// setGCPCommitmentAutoRenew turns auto-renew on or off for one commitment.
+// Turning it on commits the account to another term's spend, so it takes
+// the execute:purchases verb like any other purchase.
+//
+// PUT /api/gcp/commitments/{name}/auto-renew.
+func (h *Handler) setGCPCommitmentAutoRenew(ctx context.Context, req *events.LambdaFunctionURLRequest, name string) (any, error) {
+	session, err := h.requirePermission(ctx, req, "execute", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, NewClientError(400, "invalid commitment name")
+	}
+	var body GCPAutoRenewRequest
+	if decodeErr := json.Unmarshal([]byte(req.Body), &body); decodeErr != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if body.AutoRenew == nil {
+		return nil, NewClientError(400, "auto_renew is required")
+	}
+	client, err := h.resolveGCPCommitmentsClient(ctx, session, body.AccountID, body.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	if setErr := client.SetAutoRenew(ctx, name, *body.AutoRenew); setErr != nil {
+		return nil, fmt.Errorf("failed to set auto-renew: %w", setErr)
+	}
+	return map[string]any{"commitment": name, "auto_renew": *body.AutoRenew}, nil
+}
+
+// mergeGCPCommitments merges commitments of one type and plan into a single
+// commitment.
+//
+// POST /api/gcp/commitments/merge.
+func (h *Handler) mergeGCPCommitments(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "execute", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	var body GCPMergeRequest
+	if decodeErr := json.Unmarshal([]byte(req.Body), &body); decodeErr != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if len(body.Commitments) < 2 || len(body.Commitments) > maxMergeSourceCommitments {
+		return nil, NewClientError(400, fmt.Sprintf("commitments must name between 2 and %d commitments", maxMergeSourceCommitments))
+	}
+	client, err := h.resolveGCPCommitmentsClient(ctx, session, body.AccountID, body.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := client.MergeCommitments(ctx, body.Commitments, gcpMergeIdempotencyToken(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge commitments: %w", err)
+	}
+	return &GCPMergeResponse{Commitment: merged, MergedFrom: body.Commitments}, nil
+}
+
+// gcpMergeIdempotencyToken derives the merge's idempotency token from what
+// it merges, so a retried request (the same account, region and sources in
+// any order) reuses the merged commitment's name and request ID instead of
+// creating a second one.
+func gcpMergeIdempotencyToken(body GCPMergeRequest) string {
+	names := append([]string(nil), body.Commitments...)
+	sort.Strings(names)
+	sum := sha256.Sum256([]byte(body.AccountID + "/" + body.Region + "/" + strings.Join(names, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// applyGCPAutoRenewPolicy runs the auto-renew policy over a region's
+// commitments, previewing the decisions by default and writing them when
+// dry_run is false.
+//
+// POST /api/gcp/commitments/auto-renew-policy.
+func (h *Handler) applyGCPAutoRenewPolicy(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "execute", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	var body GCPAutoRenewPolicyRequest
+	if decodeErr := json.Unmarshal([]byte(req.Body), &body); decodeErr != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if body.HorizonDays < 0 {
+		return nil, NewClientError(400, "horizon_days must not be negative")
+	}
+	policy := computeengine.AutoRenewPolicy{
+		Horizon:        time.Duration(body.HorizonDays) * 24 * time.Hour,
+		MinCoveragePct: body.MinCoveragePct,
+	}
+	if validateErr := policy.Validate(); validateErr != nil {
+		return nil, NewClientError(400, validateErr.Error())
+	}
+	dryRun := body.DryRun == nil || *body.DryRun
+	client, err := h.resolveGCPCommitmentsClient(ctx, session, body.AccountID, body.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := client.ApplyAutoRenewPolicy(ctx, policy, time.Now(), dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply auto-renew policy: %w", err)
+	}
+	return &GCPAutoRenewPolicyResponse{Region: body.Region, DryRun: dryRun, Decisions: decisions}, nil
+}
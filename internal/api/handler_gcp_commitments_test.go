@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+const gcpAccountID = "33333333-3333-3333-3333-333333333333"
+
+// stubGCPCommitments implements gcpCommitmentsClient and records its calls.
+type stubGCPCommitments struct {
+	infos     []computeengine.ResourceCommitmentInfo
+	decisions []computeengine.AutoRenewDecision
+	setErr    error
+
+	projectID, region string
+	setCalls          map[string]bool
+	mergeNames        []string
+	mergeToken        string
+	policy            computeengine.AutoRenewPolicy
+	dryRun            *bool
+}
+
+func (s *stubGCPCommitments) GetResourceCommitments(context.Context) ([]computeengine.ResourceCommitmentInfo, error) {
+	return s.infos, nil
+}
+
+func (s *stubGCPCommitments) SetAutoRenew(_ context.Context, name string, autoRenew bool) error {
+	if s.setErr != nil {
+		return s.setErr
+	}
+	if s.setCalls == nil {
+		s.setCalls = make(map[string]bool)
+	}
+	s.setCalls[name] = autoRenew
+	return nil
+}
+
+func (s *stubGCPCommitments) MergeCommitments(_ context.Context, names []string, token string) (string, error) {
+	s.mergeNames, s.mergeToken = names, token
+	return "cud-merged", nil
+}
+
+func (s *stubGCPCommitments) ApplyAutoRenewPolicy(_ context.Context, policy computeengine.AutoRenewPolicy, _ time.Time, dryRun bool) ([]computeengine.AutoRenewDecision, error) {
+	s.policy, s.dryRun = policy, &dryRun
+	return s.decisions, nil
+}
+
+func newGCPCommitmentsHandler(cfgStore *MockConfigStore, authSvc *MockAuthService, stub *stubGCPCommitments) *Handler {
+	return &Handler{
+		config: cfgStore,
+		auth:   authSvc,
+		gcpCommitmentsFactory: func(projectID, region string) gcpCommitmentsClient {
+			stub.projectID, stub.region = projectID, region
+			return stub
+		},
+	}
+}
+
+// gcpPurchaser holds view:purchases and execute:purchases, the two verbs
+// the GCP commitment handlers check.
+func gcpPurchaser(authSvc *MockAuthService) {
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "buyer", Email: "buyer@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{
+		{Action: auth.ActionView, Resource: auth.ResourcePurchases},
+		{Action: auth.ActionExecute, Resource: auth.ResourcePurchases},
+	})
+}
+
+func gcpAccount(cfgStore *MockConfigStore) {
+	cfgStore.On("GetCloudAccount", mock.Anything, gcpAccountID).
+		Return(&config.CloudAccount{ID: gcpAccountID, Name: "gcp-prod", Provider: "gcp", GCPProjectID: "prod-project"}, nil)
+}
+
+func TestListGCPCommitments(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	gcpPurchaser(authSvc)
+	gcpAccount(cfgStore)
+	end := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub := &stubGCPCommitments{infos: []computeengine.ResourceCommitmentInfo{
+		{Name: "cud-a", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", Status: "active", End: end, VCPUs: 8, AutoRenew: true},
+		{Name: "cud-b", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", Status: "active", End: end, VCPUs: 4},
+	}}
+	h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+	req := marketplaceReq()
+	req.QueryStringParameters = map[string]string{"account_id": gcpAccountID, "region": "us-central1"}
+	got, err := h.listGCPCommitments(context.Background(), req)
+	require.NoError(t, err)
+
+	resp := got.(*GCPCommitmentsResponse)
+	assert.Equal(t, "prod-project", stub.projectID)
+	assert.Equal(t, "us-central1", stub.region)
+	require.Len(t, resp.Commitments, 2)
+	assert.True(t, resp.Commitments[0].AutoRenew)
+	assert.Equal(t, [][]string{{"cud-a", "cud-b"}}, resp.MergeableGroups)
+}
+
+func TestSetGCPCommitmentAutoRenew(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	gcpPurchaser(authSvc)
+	gcpAccount(cfgStore)
+	stub := &stubGCPCommitments{}
+	h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+	req := marketplaceReq()
+	req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","auto_renew":false}`
+	_, err := h.setGCPCommitmentAutoRenew(context.Background(), req, "cud-a")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"cud-a": false}, stub.setCalls)
+}
+
+func TestSetGCPCommitmentAutoRenew_Errors(t *testing.T) {
+	tests := []struct {
+		name, commitment, body, want string
+		code                         int
+	}{
+		{"missing auto_renew", "cud-a", `{"account_id":"` + gcpAccountID + `","region":"us-central1"}`, "auto_renew is required", 400},
+		{"missing region", "cud-a", `{"account_id":"` + gcpAccountID + `","auto_renew":true}`, "region is required", 400},
+		{"bad name", "a/b", `{"account_id":"` + gcpAccountID + `","region":"us-central1","auto_renew":true}`, "invalid commitment name", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+			gcpPurchaser(authSvc)
+			gcpAccount(cfgStore)
+			stub := &stubGCPCommitments{}
+			h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.setGCPCommitmentAutoRenew(context.Background(), req, tt.commitment)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, tt.code, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.Empty(t, stub.setCalls)
+		})
+	}
+
+	t.Run("non-GCP account", func(t *testing.T) {
+		cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+		gcpPurchaser(authSvc)
+		cfgStore.On("GetCloudAccount", mock.Anything, gcpAccountID).
+			Return(&config.CloudAccount{ID: gcpAccountID, Provider: "aws"}, nil)
+		h := newGCPCommitmentsHandler(cfgStore, authSvc, &stubGCPCommitments{})
+
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","auto_renew":true}`
+		_, err := h.setGCPCommitmentAutoRenew(context.Background(), req, "cud-a")
+		assert.ErrorContains(t, err, "must name a GCP account")
+	})
+
+	t.Run("view-only caller denied", func(t *testing.T) {
+		cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+		authSvc.On("ValidateSession", mock.Anything, "test-token").Return(&Session{UserID: "viewer"}, nil)
+		authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionView, Resource: auth.ResourcePurchases}})
+		stub := &stubGCPCommitments{}
+		h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","auto_renew":true}`
+		_, err := h.setGCPCommitmentAutoRenew(context.Background(), req, "cud-a")
+		require.Error(t, err)
+		assert.Empty(t, stub.setCalls)
+	})
+
+	t.Run("GCP failure", func(t *testing.T) {
+		cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+		gcpPurchaser(authSvc)
+		gcpAccount(cfgStore)
+		h := newGCPCommitmentsHandler(cfgStore, authSvc, &stubGCPCommitments{setErr: errors.New("denied")})
+
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","auto_renew":true}`
+		_, err := h.setGCPCommitmentAutoRenew(context.Background(), req, "cud-a")
+		assert.ErrorContains(t, err, "failed to set auto-renew")
+	})
+}
+
+func TestMergeGCPCommitments(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	gcpPurchaser(authSvc)
+	gcpAccount(cfgStore)
+	stub := &stubGCPCommitments{}
+	h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+	req := marketplaceReq()
+	req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","commitments":["cud-b","cud-a"]}`
+	got, err := h.mergeGCPCommitments(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "cud-merged", got.(*GCPMergeResponse).Commitment)
+	assert.Equal(t, []string{"cud-b", "cud-a"}, stub.mergeNames)
+
+	// The token ignores source order, so a retry with the list reordered
+	// converges on the same merged commitment.
+	reordered := gcpMergeIdempotencyToken(GCPMergeRequest{AccountID: gcpAccountID, Region: "us-central1", Commitments: []string{"cud-a", "cud-b"}})
+	assert.Equal(t, reordered, stub.mergeToken)
+	assert.Len(t, stub.mergeToken, 64)
+
+	t.Run("one source", func(t *testing.T) {
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","commitments":["cud-a"]}`
+		_, err := h.mergeGCPCommitments(context.Background(), req)
+		ce, ok := IsClientError(err)
+		require.True(t, ok)
+		assert.Equal(t, 400, ce.code)
+	})
+}
+
+func TestApplyGCPAutoRenewPolicy(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	gcpPurchaser(authSvc)
+	gcpAccount(cfgStore)
+	stub := &stubGCPCommitments{decisions: []computeengine.AutoRenewDecision{{Name: "cud-a", AutoRenew: true}}}
+	h := newGCPCommitmentsHandler(cfgStore, authSvc, stub)
+
+	t.Run("defaults to dry run", func(t *testing.T) {
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","horizon_days":60}`
+		got, err := h.applyGCPAutoRenewPolicy(context.Background(), req)
+		require.NoError(t, err)
+		resp := got.(*GCPAutoRenewPolicyResponse)
+		assert.True(t, resp.DryRun)
+		require.NotNil(t, stub.dryRun)
+		assert.True(t, *stub.dryRun)
+		assert.Equal(t, 60*24*time.Hour, stub.policy.Horizon)
+		require.Len(t, resp.Decisions, 1)
+	})
+
+	t.Run("applies when dry_run is false", func(t *testing.T) {
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","min_coverage_pct":75,"dry_run":false}`
+		_, err := h.applyGCPAutoRenewPolicy(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, *stub.dryRun)
+		assert.Equal(t, 75.0, stub.policy.MinCoveragePct)
+	})
+
+	t.Run("invalid coverage", func(t *testing.T) {
+		req := marketplaceReq()
+		req.Body = `{"account_id":"` + gcpAccountID + `","region":"us-central1","min_coverage_pct":150}`
+		_, err := h.applyGCPAutoRenewPolicy(context.Background(), req)
+		ce, ok := IsClientError(err)
+		require.True(t, ok)
+		assert.Equal(t, 400, ce.code)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/LeanerCloud/CUDly/internal/config"
@@ -182,11 +183,44 @@ func (h *Handler) fetchExecutionsAsHistory(ctx context.Context, filters historyF
 		if exec.CreatedByUserID != nil {
 			createdByEmail = userEmailCache[*exec.CreatedByUserID]
 		}
-		out = append(out, executionToHistoryRow(exec, approver, createdByEmail))
+		row := executionToHistoryRow(exec, approver, createdByEmail)
+		if exec.Status == "pending" || exec.Status == "notified" {
+			h.annotateApprovalChain(ctx, &row)
+		}
+		out = append(out, row)
 	}
 	return out, staleExecs
 }
 
+// annotateApprovalChain points a pending row going through an approval
+// chain at the approvers of the stage awaiting sign-off, and describes the
+// stages signed so far and the one in progress, e.g. "Team lead signed by
+// lead@example.com; awaiting FinOps (stage 2 of 3): 0 of 1 sign-offs". A
+// lookup failure is logged and leaves the row as it is.
+func (h *Handler) annotateApprovalChain(ctx context.Context, row *config.PurchaseHistoryRecord) {
+	chain, err := h.loadApprovalChainView(ctx, row.PurchaseID)
+	if err != nil {
+		logging.Warnf("history: %v", err)
+		return
+	}
+	if chain == nil || chain.Completed {
+		return
+	}
+	parts := make([]string, 0, chain.CurrentStage+1)
+	for _, st := range chain.Stages[:chain.CurrentStage] {
+		signers := make([]string, 0, len(st.Decisions))
+		for _, d := range st.Decisions {
+			signers = append(signers, d.ApproverEmail)
+		}
+		parts = append(parts, fmt.Sprintf("%s signed by %s", st.Name, strings.Join(signers, ", ")))
+	}
+	active := chain.Stages[chain.CurrentStage]
+	parts = append(parts, fmt.Sprintf("awaiting %s (stage %d of %d): %d of %d sign-offs",
+		active.Name, chain.CurrentStage+1, len(chain.Stages), len(active.Decisions), active.Quorum))
+	row.Approver = strings.Join(active.Approvers, ", ")
+	row.StatusDescription = strings.Join(parts, "; ")
+}
+
 // isStaleExecution reports whether the execution is a pending/notified
 // approval older than approvalExpiryWindow that should be transitioned to
 // "expired". Extracted so both fetchExecutionsAsHistory and the expire
@@ -3,11 +3,13 @@ package api
 
 import (
 	"context"
+	"math"
 	"sort"
 	"time"
 
 	"github.com/LeanerCloud/CUDly/internal/analytics"
 	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -123,6 +125,7 @@ func (h *Handler) fetchCommitmentRecords(ctx context.Context, asOf time.Time, se
 	if err != nil {
 		return nil, err
 	}
+	rows = h.appendExternalCommitments(ctx, asOf, rows, uuids, externalIDsByProvider)
 
 	// Apply provider filter in-memory. An absent or empty param means
 	// "all providers". Case-sensitive match — providers are always
@@ -141,6 +144,71 @@ func (h *Handler) fetchCommitmentRecords(ctx context.Context, asOf time.Time, se
 	return rows, nil
 }
 
+// appendExternalCommitments merges the synced commitments inventory into the
+// active purchase_history rows: every inventory row marked external, whose
+// commitment ID is not already one of the purchases, is appended as a
+// synthesized PurchaseHistoryRecord so commitments bought outside CUDly count
+// in the inventory, coverage and dashboard views. The inventory is read with
+// the same account scope as the purchases. A read failure is logged and the
+// purchases are returned unchanged: the inventory is a best-effort overlay
+// and must never hide CUDly's own commitments.
+func (h *Handler) appendExternalCommitments(ctx context.Context, asOf time.Time, purchases []config.PurchaseHistoryRecord, accountUUIDs []string, accountExternalIDsByProvider map[string][]string) []config.PurchaseHistoryRecord {
+	inventory, err := h.config.GetActiveCommitmentInventory(ctx, asOf, accountUUIDs, accountExternalIDsByProvider)
+	if err != nil {
+		logging.Errorf("inventory: failed to read commitments inventory; showing CUDly purchases only: %v", err)
+		return purchases
+	}
+
+	known := make(map[string]bool, len(purchases))
+	for _rvc := range purchases {
+		known[purchases[_rvc].Provider+"/"+purchases[_rvc].PurchaseID] = true
+	}
+	for _rvc := range inventory {
+		r := inventory[_rvc]
+		if r.Source != config.CommitmentSourceExternal || known[r.Provider+"/"+r.CommitmentID] {
+			continue
+		}
+		purchases = append(purchases, externalCommitmentRecord(r))
+	}
+	return purchases
+}
+
+// externalCommitmentRecord maps an externally purchased inventory row to the
+// PurchaseHistoryRecord shape the commitment views aggregate. The provider
+// reports an amortized hourly cost, so MonthlyCost is that rate over 730
+// hours with no separate upfront; a zero rate means the provider did not
+// report one and leaves MonthlyCost nil. EstimatedSavings stays zero because
+// nothing records what the commitment was bought to save.
+func externalCommitmentRecord(r config.CommitmentInventoryRecord) config.PurchaseHistoryRecord {
+	rec := config.PurchaseHistoryRecord{
+		AccountID:      r.AccountID,
+		PurchaseID:     r.CommitmentID,
+		Timestamp:      r.SyncedAt,
+		Provider:       r.Provider,
+		Service:        r.Service,
+		Region:         r.Region,
+		ResourceType:   r.ResourceType,
+		Count:          r.Count,
+		Term:           1,
+		CloudAccountID: r.CloudAccountID,
+		Source:         config.CommitmentSourceExternal,
+		TermEnd:        r.EndDate,
+	}
+	if r.StartDate != nil {
+		rec.Timestamp = *r.StartDate
+	}
+	if r.EndDate != nil {
+		if years := int(math.Round(r.EndDate.Sub(rec.Timestamp).Hours() / (365 * 24))); years > 1 {
+			rec.Term = years
+		}
+	}
+	if r.HourlyCost > 0 {
+		monthly := r.HourlyCost * 730
+		rec.MonthlyCost = &monthly
+	}
+	return rec
+}
+
 // buildInventoryCommitment maps a PurchaseHistoryRecord to the
 // response-layer InventoryCommitment. The ID is namespaced by account so
 // the JSON payload is globally unique without a DB schema change —
@@ -163,7 +231,18 @@ func buildInventoryCommitment(p config.PurchaseHistoryRecord, accountName string
 		MonthlyCost:      p.MonthlyCost,
 		EstimatedSavings: p.EstimatedSavings,
 		Status:           "active",
+		Source:           commitmentSource(p),
+	}
+}
+
+// commitmentSource reports who bought a commitment: "external" for rows
+// merged in from the commitments inventory, "cudly" for everything read from
+// purchase_history.
+func commitmentSource(p config.PurchaseHistoryRecord) string {
+	if p.Source == config.CommitmentSourceExternal {
+		return config.CommitmentSourceExternal
 	}
+	return config.CommitmentSourceCUDly
 }
 
 // getCoverageBreakdown handles GET /api/inventory/coverage.
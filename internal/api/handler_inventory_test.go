@@ -226,6 +226,92 @@ func TestHandler_listActiveCommitments_ProviderFilter(t *testing.T) {
 	assert.Equal(t, "p-aws", splitPurchaseID(resp.Commitments[0].ID))
 }
 
+// TestHandler_listActiveCommitments_MergesExternalInventory verifies that
+// commitments the inventory sync found outside CUDly are listed next to the
+// purchase_history rows: external rows are appended and tagged, rows the
+// store already attributed to CUDly or that duplicate a purchase are not
+// listed twice, and the provider filter applies to the merged set.
+func TestHandler_listActiveCommitments_MergesExternalInventory(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+
+	now := time.Now()
+	start := now.AddDate(-1, 0, 0)
+	end := now.AddDate(2, 0, 0)
+	purchases := []config.PurchaseHistoryRecord{
+		{AccountID: "acc-1", PurchaseID: "p-cudly", Provider: "aws", Service: "ec2", Timestamp: now.AddDate(0, -1, 0), Term: 1, Count: 1},
+	}
+	inventory := []config.CommitmentInventoryRecord{
+		{Provider: "aws", AccountID: "acc-1", CommitmentID: "ri-console", Service: "ec2", Region: "us-east-1", ResourceType: "m5.large", Count: 4, StartDate: &start, EndDate: &end, HourlyCost: 0.5, Source: config.CommitmentSourceExternal},
+		{Provider: "aws", AccountID: "acc-1", CommitmentID: "p-cudly", Service: "ec2", EndDate: &end, Source: config.CommitmentSourceExternal},
+		{Provider: "aws", AccountID: "acc-1", CommitmentID: "ri-cudly", Service: "ec2", EndDate: &end, Source: config.CommitmentSourceCUDly},
+		{Provider: "azure", AccountID: "sub-1", CommitmentID: "res-1", Service: "compute", EndDate: &end, Source: config.CommitmentSourceExternal},
+	}
+
+	mockStore.On("GetActivePurchaseHistory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string(nil)).Return(purchases, nil)
+	mockStore.On("GetActiveCommitmentInventory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string(nil)).Return(inventory, nil)
+	mockStore.ListCloudAccountsFn = func(_ context.Context, _ config.CloudAccountFilter) ([]config.CloudAccount, error) {
+		return []config.CloudAccount{}, nil
+	}
+
+	mockAuth, req := adminInventoryReq(ctx)
+	handler := &Handler{auth: mockAuth, config: mockStore}
+
+	result, err := handler.listActiveCommitments(ctx, req, map[string]string{"provider": "aws"})
+	require.NoError(t, err)
+
+	resp := result.(InventoryCommitmentsResponse)
+	require.Len(t, resp.Commitments, 2, "the CUDly purchase plus one external commitment")
+	bySource := map[string]InventoryCommitment{}
+	for _, c := range resp.Commitments {
+		bySource[c.Source] = c
+	}
+	assert.Equal(t, "p-cudly", splitPurchaseID(bySource[config.CommitmentSourceCUDly].ID))
+
+	ext := bySource[config.CommitmentSourceExternal]
+	assert.Equal(t, "ri-console", splitPurchaseID(ext.ID))
+	assert.Equal(t, 4, ext.Count)
+	assert.Equal(t, 3, ext.TermYears)
+	assert.True(t, ext.EndDate.Equal(end))
+	require.NotNil(t, ext.MonthlyCost)
+	assert.InDelta(t, 365.0, *ext.MonthlyCost, 1e-9, "0.5/h amortized over 730 hours")
+}
+
+// TestHandler_listActiveCommitments_InventoryErrorIsNonFatal verifies a
+// failed inventory read still returns the purchase_history rows.
+func TestHandler_listActiveCommitments_InventoryErrorIsNonFatal(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+
+	purchases := []config.PurchaseHistoryRecord{
+		{AccountID: "acc-1", PurchaseID: "p-1", Provider: "aws", Service: "ec2", Timestamp: time.Now().AddDate(0, -1, 0), Term: 1, Count: 1},
+	}
+	mockStore.On("GetActivePurchaseHistory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string(nil)).Return(purchases, nil)
+	mockStore.On("GetActiveCommitmentInventory", ctx, mock.AnythingOfType("time.Time"), []string(nil), map[string][]string(nil)).Return(nil, fmt.Errorf("relation does not exist"))
+	mockStore.ListCloudAccountsFn = func(_ context.Context, _ config.CloudAccountFilter) ([]config.CloudAccount, error) {
+		return []config.CloudAccount{}, nil
+	}
+
+	mockAuth, req := adminInventoryReq(ctx)
+	handler := &Handler{auth: mockAuth, config: mockStore}
+
+	result, err := handler.listActiveCommitments(ctx, req, map[string]string{})
+	require.NoError(t, err)
+	resp := result.(InventoryCommitmentsResponse)
+	require.Len(t, resp.Commitments, 1)
+	assert.Equal(t, config.CommitmentSourceCUDly, resp.Commitments[0].Source)
+}
+
+func TestExternalCommitmentRecord_UnknownCostAndDates(t *testing.T) {
+	synced := time.Date(2026, 9, 30, 6, 0, 0, 0, time.UTC)
+	rec := externalCommitmentRecord(config.CommitmentInventoryRecord{Provider: "gcp", AccountID: "proj-1", CommitmentID: "cud-1", SyncedAt: synced})
+
+	assert.Nil(t, rec.MonthlyCost, "a zero hourly rate is unreported, not free")
+	assert.Equal(t, synced, rec.Timestamp, "falls back to the sync time without a start date")
+	assert.Equal(t, 1, rec.Term)
+	assert.Equal(t, config.CommitmentSourceExternal, rec.Source)
+}
+
 // TestHandler_listActiveCommitments_SortedByExpiry verifies soonest-expiring
 // is first — the dashboard framing is "what do I need to renew next?", so
 // surfacing the imminent end_date on top keeps the UI's order intuitive
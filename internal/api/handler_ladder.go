@@ -168,13 +168,19 @@ func (h *Handler) requireLadderAccountAccess(ctx context.Context, session *Sessi
 // to the default, the forbidden silent-fallback pattern on a money-adjacent
 // config path (feedback_no_silent_fallbacks). buffer_fraction's valid range is
 // [0, 1), so an explicit 0 there is a legitimate "no buffer" choice and is
-// likewise passed through untouched.
+// likewise passed through untouched. An absent buffer_fraction on a
+// service-scoped config (service set) defaults to 0 rather than
+// DefaultLadderBufferFraction: a reserved-capacity ladder has no buffer layer,
+// so the only valid value is 0.
 func applyLadderConfigNumericDefaults(cfg *config.LadderConfigDB, present map[string]json.RawMessage) {
 	if _, ok := present["target_coverage"]; !ok {
 		cfg.TargetCoverage = config.DefaultLadderTargetCoverage
 	}
 	if _, ok := present["buffer_fraction"]; !ok {
 		cfg.BufferFraction = config.DefaultLadderBufferFraction
+		if cfg.Service != "" {
+			cfg.BufferFraction = 0
+		}
 	}
 	if _, ok := present["baseline_percentile"]; !ok {
 		cfg.BaselinePercentile = config.DefaultLadderBaselinePercentile
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ladderBacktestRequest is the body of POST /api/ladder/backtest.
+//
+// The config under test is either the saved ladder config for
+// (cloud_account_id, provider, service) or, when config is present, an inline
+// LadderConfigDB-shaped draft (same shape and defaults as PUT
+// /api/ladder/configs) so a config can be evaluated before it is saved.
+// Exactly one of series and series_csv carries the daily on-demand series.
+// term and payment_option default to the global default_term and
+// default_payment, the values a live ladder run would use.
+type ladderBacktestRequest struct {
+	DiscountPct    map[string]float64      `json:"discount_pct,omitempty"`
+	Config         json.RawMessage         `json:"config,omitempty"`
+	Series         []pkgladder.SeriesPoint `json:"series,omitempty"`
+	CloudAccountID string                  `json:"cloud_account_id"`
+	Provider       string                  `json:"provider"`
+	Service        string                  `json:"service,omitempty"`
+	SeriesCSV      string                  `json:"series_csv,omitempty"`
+	Term           string                  `json:"term,omitempty"`
+	PaymentOption  string                  `json:"payment_option,omitempty"`
+}
+
+// backtestLadderConfig replays a historical daily on-demand series through
+// the ladder engine (pkg/ladder.Backtest) and returns the simulated coverage,
+// waste, spend, and expiry distribution. Requires view:config permission and
+// access to the target account; the replay makes no cloud calls and persists
+// nothing, so it is safe to run with LadderExecutionEnabled off.
+func (h *Handler) backtestLadderConfig(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, permErr := h.requirePermission(ctx, req, "view", "config")
+	if permErr != nil {
+		return nil, permErr
+	}
+
+	var body ladderBacktestRequest
+	dec := json.NewDecoder(bytes.NewReader([]byte(req.Body)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("invalid request body: %s", err))
+	}
+	if body.CloudAccountID == "" {
+		return nil, NewClientError(400, "cloud_account_id is required")
+	}
+	if body.Provider == "" {
+		return nil, NewClientError(400, "provider is required")
+	}
+
+	account, err := h.requireAccountAccess(ctx, session, body.CloudAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Provider != body.Provider {
+		return nil, NewClientError(400, fmt.Sprintf("provider %q does not match cloud account provider %q", body.Provider, account.Provider))
+	}
+
+	input, err := h.buildLadderBacktestInput(ctx, &body, account)
+	if err != nil {
+		return nil, err
+	}
+	result, err := pkgladder.Backtest(input)
+	if err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("backtest: %s", err))
+	}
+	return result, nil
+}
+
+// buildLadderBacktestInput resolves the config, series, term, payment option,
+// and discounts of a backtest request into a pkg/ladder BacktestInput. Every
+// malformed field is a 400.
+func (h *Handler) buildLadderBacktestInput(ctx context.Context, body *ladderBacktestRequest, account *config.CloudAccount) (*pkgladder.BacktestInput, error) {
+	dbCfg, err := h.resolveBacktestLadderConfig(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	provider := common.ProviderType(body.Provider)
+	// The scope only labels the replay (no cloud call is made), so the CUDly
+	// account ID stands in for the provider-native one.
+	engineCfg, err := dbCfg.EngineConfig(provider, account.ID)
+	if err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("config: %s", err))
+	}
+	layers, err := pkgladder.ScopeLayers(engineCfg.Scope)
+	if err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+
+	series, err := parseBacktestSeries(body)
+	if err != nil {
+		return nil, err
+	}
+	term, payment, err := h.resolveBacktestPurchaseOptions(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	discounts := make(map[pkgladder.LayerType]float64, len(body.DiscountPct))
+	for k, v := range body.DiscountPct {
+		layer, err := pkgladder.ParseLayerType(k)
+		if err != nil {
+			return nil, NewClientError(400, fmt.Sprintf("discount_pct: %s", err))
+		}
+		discounts[layer] = v
+	}
+
+	return &pkgladder.BacktestInput{
+		Config:        engineCfg,
+		Layers:        layers,
+		Series:        series,
+		Term:          term,
+		PaymentOption: payment,
+		DiscountPct:   discounts,
+	}, nil
+}
+
+// resolveBacktestLadderConfig returns the inline draft config when the
+// request carries one, otherwise the saved config for the account. The draft
+// gets the same unknown-field rejection, numeric defaults, and validation as
+// upsertLadderConfig, so a draft that backtests cleanly also saves cleanly.
+func (h *Handler) resolveBacktestLadderConfig(ctx context.Context, body *ladderBacktestRequest) (*config.LadderConfigDB, error) {
+	if len(body.Config) == 0 {
+		saved, err := h.config.GetLadderConfig(ctx, body.CloudAccountID, body.Provider, body.Service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ladder config: %w", err)
+		}
+		if saved == nil {
+			return nil, NewClientError(404, "no ladder config for this account; save one or pass an inline config")
+		}
+		return saved, nil
+	}
+
+	var cfg config.LadderConfigDB
+	dec := json.NewDecoder(bytes.NewReader(body.Config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("invalid config: %s", err))
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body.Config, &present); err != nil {
+		return nil, NewClientError(400, "invalid config")
+	}
+	cfg.CloudAccountID = body.CloudAccountID
+	cfg.Provider = body.Provider
+	cfg.Service = body.Service
+	applyLadderConfigNumericDefaults(&cfg, present)
+	if err := cfg.Validate(); err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("validation error: %s", err))
+	}
+	return &cfg, nil
+}
+
+// parseBacktestSeries decodes whichever of series / series_csv the request
+// carries; exactly one is required.
+func parseBacktestSeries(body *ladderBacktestRequest) ([]pkgladder.DailyPoint, error) {
+	hasJSON, hasCSV := len(body.Series) > 0, body.SeriesCSV != ""
+	if hasJSON == hasCSV {
+		return nil, NewClientError(400, "exactly one of series or series_csv is required")
+	}
+	var (
+		points []pkgladder.DailyPoint
+		err    error
+	)
+	if hasJSON {
+		points, err = pkgladder.DailyPointsFromSeries(body.Series)
+	} else {
+		points, err = pkgladder.ParseDailySeriesCSV(strings.NewReader(body.SeriesCSV))
+	}
+	if err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+	return points, nil
+}
+
+// resolveBacktestPurchaseOptions parses the requested term and payment
+// option, falling back to the global defaults a live ladder run uses.
+func (h *Handler) resolveBacktestPurchaseOptions(ctx context.Context, body *ladderBacktestRequest) (pkgladder.Term, pkgladder.PaymentOption, error) {
+	termStr, paymentStr := body.Term, body.PaymentOption
+	if termStr == "" || paymentStr == "" {
+		global, err := h.config.GetGlobalConfig(ctx)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get global config: %w", err)
+		}
+		if termStr == "" {
+			termStr = fmt.Sprintf("%dyr", global.DefaultTerm)
+		}
+		if paymentStr == "" {
+			paymentStr = global.DefaultPayment
+		}
+	}
+	term, err := pkgladder.ParseTerm(termStr)
+	if err != nil {
+		return "", "", NewClientError(400, fmt.Sprintf("term: %s", err))
+	}
+	payment, err := pkgladder.ParsePaymentOption(paymentStr)
+	if err != nil {
+		return "", "", NewClientError(400, fmt.Sprintf("payment_option: %s", err))
+	}
+	return term, payment, nil
+}
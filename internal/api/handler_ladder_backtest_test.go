@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// backtestSeriesPoints returns n consecutive days of flat usage from
+// 2026-01-01.
+func backtestSeriesPoints(n int) []pkgladder.SeriesPoint {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]pkgladder.SeriesPoint, n)
+	for i := range out {
+		out[i] = pkgladder.SeriesPoint{Date: start.AddDate(0, 0, i).Format("2006-01-02"), USDPerHour: 10}
+	}
+	return out
+}
+
+// backtestBody marshals a backtest request body.
+func backtestBody(t *testing.T, fields map[string]any) string {
+	t.Helper()
+	b, err := json.Marshal(fields)
+	require.NoError(t, err)
+	return string(b)
+}
+
+const backtestDraftConfig = `{"mode":"email_approval","cadence":"daily",` + ladderValidRamp + `}`
+
+func TestBacktestLadderConfig_InlineConfigReplays(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore, _ := newLadderHandler(t)
+	mockStore.On("GetCloudAccount", ctx, "acct-1").Return(&config.CloudAccount{ID: "acct-1", Provider: "aws"}, nil)
+
+	body := backtestBody(t, map[string]any{
+		"cloud_account_id": "acct-1",
+		"provider":         "aws",
+		"config":           json.RawMessage(backtestDraftConfig),
+		"series":           backtestSeriesPoints(40),
+		"term":             "1yr",
+		"payment_option":   "no-upfront",
+		"discount_pct":     map[string]float64{"compute-sp": 25},
+	})
+	result, err := handler.backtestLadderConfig(ctx, ladderReq(body))
+	require.NoError(t, err)
+
+	res, ok := result.(*pkgladder.BacktestResult)
+	require.True(t, ok, "got %T", result)
+	assert.Equal(t, 10, res.Days, "30 lookback days of warm-up, 10 replayed")
+	assert.Equal(t, 10, res.Runs)
+	assert.Positive(t, res.SavingsUSD)
+	mockStore.AssertNotCalled(t, "GetLadderConfig", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "UpsertLadderConfig", mock.Anything, mock.Anything)
+}
+
+// TestBacktestLadderConfig_ServiceDraftUsesServiceLayer pins that a service
+// draft replays through the service's single RI layer: the discount keyed on
+// rds-ri is accepted and produces savings.
+func TestBacktestLadderConfig_ServiceDraftUsesServiceLayer(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore, _ := newLadderHandler(t)
+	mockStore.On("GetCloudAccount", ctx, "acct-1").Return(&config.CloudAccount{ID: "acct-1", Provider: "aws"}, nil)
+
+	body := backtestBody(t, map[string]any{
+		"cloud_account_id": "acct-1",
+		"provider":         "aws",
+		"service":          "rds",
+		"config":           json.RawMessage(backtestDraftConfig),
+		"series":           backtestSeriesPoints(40),
+		"term":             "1yr",
+		"payment_option":   "no-upfront",
+		"discount_pct":     map[string]float64{"rds-ri": 35},
+	})
+	result, err := handler.backtestLadderConfig(ctx, ladderReq(body))
+	require.NoError(t, err)
+	res := result.(*pkgladder.BacktestResult)
+	assert.Positive(t, res.SavingsUSD)
+	assert.Positive(t, res.Purchases)
+}
+
+func TestBacktestLadderConfig_SavedConfigCSVAndGlobalDefaults(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore, _ := newLadderHandler(t)
+	mockStore.On("GetCloudAccount", ctx, "acct-1").Return(&config.CloudAccount{ID: "acct-1", Provider: "aws"}, nil)
+	mockStore.On("GetLadderConfig", ctx, "acct-1", "aws", "").Return(&config.LadderConfigDB{
+		CloudAccountID:             "acct-1",
+		Provider:                   "aws",
+		Mode:                       "email_approval",
+		Cadence:                    "weekly",
+		RampSchedule:               json.RawMessage(`{"steps":[{"after_days":0,"fraction":1}]}`),
+		TargetCoverage:             config.DefaultLadderTargetCoverage,
+		BufferFraction:             config.DefaultLadderBufferFraction,
+		BaselinePercentile:         config.DefaultLadderBaselinePercentile,
+		LookbackDays:               config.DefaultLadderLookbackDays,
+		BufferUtilizationThreshold: config.DefaultLadderBufferUtilThreshold,
+		MaxActionsPerRun:           config.DefaultLadderMaxActionsPerRun,
+	}, nil)
+	mockStore.On("GetGlobalConfig", ctx).Return(&config.GlobalConfig{DefaultTerm: 3, DefaultPayment: "all-upfront"}, nil)
+
+	var csv strings.Builder
+	csv.WriteString("date,usd_per_hour\n")
+	for _, p := range backtestSeriesPoints(44) {
+		fmt.Fprintf(&csv, "%s,%g\n", p.Date, p.USDPerHour)
+	}
+	body := backtestBody(t, map[string]any{"cloud_account_id": "acct-1", "provider": "aws", "series_csv": csv.String()})
+
+	result, err := handler.backtestLadderConfig(ctx, ladderReq(body))
+	require.NoError(t, err)
+	res := result.(*pkgladder.BacktestResult)
+	assert.Equal(t, 14, res.Days)
+	assert.Equal(t, 2, res.Runs, "weekly cadence over 14 replayed days")
+	require.NotEmpty(t, res.ExpiryDistribution)
+	assert.Equal(t, "2029-01", res.ExpiryDistribution[0].Month, "3yr default term from global config")
+}
+
+func TestBacktestLadderConfig_RejectsBadRequests(t *testing.T) {
+	series := backtestSeriesPoints(40)
+	cases := []struct {
+		name   string
+		fields map[string]any
+		code   int
+	}{
+		{"no series", map[string]any{"config": json.RawMessage(backtestDraftConfig)}, 400},
+		{"both series", map[string]any{"config": json.RawMessage(backtestDraftConfig), "series": series, "series_csv": "2026-01-01,1\n"}, 400},
+		{"bad draft", map[string]any{"config": json.RawMessage(`{"mode":"yolo","cadence":"daily",` + ladderValidRamp + `}`), "series": series}, 400},
+		{"unknown draft field", map[string]any{"config": json.RawMessage(`{"max_hourly_commit_per_rn":1,"mode":"email_approval","cadence":"daily",` + ladderValidRamp + `}`), "series": series}, 400},
+		{"bad discount layer", map[string]any{"config": json.RawMessage(backtestDraftConfig), "series": series, "term": "1yr", "payment_option": "no-upfront", "discount_pct": map[string]float64{"nope": 10}}, 400},
+		{"short series", map[string]any{"config": json.RawMessage(backtestDraftConfig), "series": series[:20], "term": "1yr", "payment_option": "no-upfront"}, 400},
+		{"bad term", map[string]any{"config": json.RawMessage(backtestDraftConfig), "series": series, "term": "2yr", "payment_option": "no-upfront"}, 400},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			handler, mockStore, _ := newLadderHandler(t)
+			mockStore.On("GetCloudAccount", ctx, "acct-1").Return(&config.CloudAccount{ID: "acct-1", Provider: "aws"}, nil)
+
+			tc.fields["cloud_account_id"] = "acct-1"
+			tc.fields["provider"] = "aws"
+			_, err := handler.backtestLadderConfig(ctx, ladderReq(backtestBody(t, tc.fields)))
+			require.Error(t, err)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected ClientError, got %T: %v", err, err)
+			assert.Equal(t, tc.code, ce.code)
+		})
+	}
+}
+
+func TestBacktestLadderConfig_NoSavedConfigIs404(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore, _ := newLadderHandler(t)
+	mockStore.On("GetCloudAccount", ctx, "acct-1").Return(&config.CloudAccount{ID: "acct-1", Provider: "aws"}, nil)
+	mockStore.On("GetLadderConfig", ctx, "acct-1", "aws", "").Return(nil, nil)
+
+	body := backtestBody(t, map[string]any{"cloud_account_id": "acct-1", "provider": "aws", "series": backtestSeriesPoints(40)})
+	_, err := handler.backtestLadderConfig(ctx, ladderReq(body))
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected ClientError, got %T: %v", err, err)
+	assert.Equal(t, 404, ce.code)
+}
+
+// TestBacktestLadderConfig_OutOfScopeAccountRefused pins that the backtest
+// reads a saved config only for accounts the caller may see: the saved row
+// carries the account's spend cap and ramp schedule.
+func TestBacktestLadderConfig_OutOfScopeAccountRefused(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := ladderScopedHandler(t)
+
+	body := backtestBody(t, map[string]any{"cloud_account_id": scopedOutAccount, "provider": "aws", "series": backtestSeriesPoints(40)})
+	_, err := handler.backtestLadderConfig(ctx, ladderScopedReq(body))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errNotFound)
+	mockStore.AssertNotCalled(t, "GetLadderConfig", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -116,6 +116,27 @@ func TestUpsertLadderConfig_MultiStepRampAccepted(t *testing.T) {
 		string((*captured).RampSchedule))
 }
 
+// TestUpsertLadderConfig_ServiceLadderDefaultsToNoBuffer asserts that a
+// service-scoped config without buffer_fraction defaults to 0 (the RDS layer
+// has no buffer role) and that an explicit buffer on it is a 400.
+func TestUpsertLadderConfig_ServiceLadderDefaultsToNoBuffer(t *testing.T) {
+	ctx := context.Background()
+	handler, _, captured := newLadderHandler(t)
+
+	body := `{"cloud_account_id":"acct-1","provider":"aws","service":"rds","mode":"email_approval","cadence":"daily",` + ladderValidRamp + `}`
+	_, err := handler.upsertLadderConfig(ctx, ladderReq(body))
+	require.NoError(t, err)
+	require.NotNil(t, *captured)
+	assert.Equal(t, "rds", (*captured).Service)
+	assert.Zero(t, (*captured).BufferFraction)
+
+	body = `{"cloud_account_id":"acct-1","provider":"aws","service":"rds","buffer_fraction":0.1,"mode":"email_approval","cadence":"daily",` + ladderValidRamp + `}`
+	_, err = handler.upsertLadderConfig(ctx, ladderReq(body))
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected ClientError, got %T: %v", err, err)
+	assert.Equal(t, 400, ce.code)
+}
+
 // TestUpsertLadderConfig_UnknownFieldRejected is F5: a typo'd key must be
 // rejected with 400 (DisallowUnknownFields), not silently dropped -- a mistyped
 // max_hourly_commit_per_run would otherwise decode to nil = no spend cap.
@@ -149,7 +149,7 @@ func (h *Handler) marketplaceList(ctx context.Context, req *events.LambdaFunctio
 	if row.Term <= 0 {
 		return nil, fmt.Errorf("purchase has invalid term %d (expected 1 or 3 years); cannot compute marketplace pricing", row.Term)
 	}
-	termMonths := row.Term * 12
+	termMonths := purchaseTermMonths(row)
 
 	// Compute actual remaining months from the purchase timestamp and total
 	// term so the default price schedule reflects real remaining value
@@ -441,6 +441,21 @@ func (h *Handler) authorizeAllowedAccount(ctx context.Context, session *Session,
 	return NewClientError(403, "permission denied: purchase is in a cloud account not covered by your session's allowed accounts")
 }
 
+// purchaseTermMonths returns a purchase's total term in months: Term*12, or
+// the span up to TermEnd (rounded up, at least 1) for an RI that was itself
+// bought on the marketplace with a partial remaining term. Callers reject
+// Term <= 0 first.
+func purchaseTermMonths(row *config.PurchaseHistoryRecord) int {
+	if row.TermEnd == nil {
+		return row.Term * 12
+	}
+	months := int(math.Ceil(row.TermEnd.Sub(row.Timestamp).Hours() / (24 * 30.4375)))
+	if months < 1 {
+		return 1
+	}
+	return months
+}
+
 // computeRemainingMonths returns the number of whole months remaining on an RI
 // given its purchase timestamp and total term in months. The result is floored
 // at 1 so defensive callers always get a positive value.
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	awsprovider "github.com/LeanerCloud/CUDly/providers/aws"
+	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+)
+
+// maxMarketplaceRemainingMonths bounds the remaining-term query parameters.
+// Marketplace listings are resold 1- or 3-year RIs, so nothing has more than
+// 36 months left.
+const maxMarketplaceRemainingMonths = 36
+
+// marketplaceOfferingsEC2Client is the narrow EC2 interface that
+// searchMarketplaceOfferings needs. Satisfied by *ec2svc.Client; tests inject
+// a stub via Handler.marketplaceOfferingsEC2Factory.
+type marketplaceOfferingsEC2Client interface {
+	SearchMarketplaceOfferings(ctx context.Context, params ec2svc.SearchMarketplaceOfferingsParams) ([]ec2svc.MarketplaceOffering, error)
+}
+
+// buildMarketplaceOfferingsEC2Client honors the injected factory when set,
+// falling back to the direct AWS SDK constructor otherwise.
+func (h *Handler) buildMarketplaceOfferingsEC2Client(cfg aws.Config) marketplaceOfferingsEC2Client {
+	if h.marketplaceOfferingsEC2Factory != nil {
+		return h.marketplaceOfferingsEC2Factory(cfg)
+	}
+	return awsprovider.NewEC2ClientDirect(cfg)
+}
+
+// MarketplaceOfferingsResponse is the response for
+// GET /api/ri-marketplace/offerings.
+type MarketplaceOfferingsResponse struct {
+	Offerings []ec2svc.MarketplaceOffering `json:"offerings"`
+}
+
+// searchMarketplaceOfferings lists third-party Reserved Instance Marketplace
+// listings for one instance type, cheapest effective hourly rate first.
+// Buying one goes through the normal execute flow: the chosen listing's
+// offering_id is sent as details.marketplace_offering_id on an EC2
+// recommendation, with upfront_cost set to the approved total, so the usual
+// dry-run, approval and spend guardrails apply.
+//
+// GET /api/ri-marketplace/offerings?instance_type=<type>&region=<region>
+// &platform=<product description>&tenancy=<tenancy>
+// &min_remaining_months=<n>&max_remaining_months=<n>.
+func (h *Handler) searchMarketplaceOfferings(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "purchases"); err != nil {
+		return nil, err
+	}
+
+	params, err := marketplaceOfferingsParams(req.QueryStringParameters)
+	if err != nil {
+		return nil, err
+	}
+	region := req.QueryStringParameters["region"]
+	if err = validateRegion(region); err != nil {
+		return nil, err
+	}
+
+	cfg, err := h.loadAWSConfigWithRegion(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	offerings, err := h.buildMarketplaceOfferingsEC2Client(cfg).SearchMarketplaceOfferings(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search marketplace offerings: %w", err)
+	}
+	if offerings == nil {
+		offerings = []ec2svc.MarketplaceOffering{}
+	}
+	return &MarketplaceOfferingsResponse{Offerings: offerings}, nil
+}
+
+// marketplaceOfferingsParams parses the search query parameters. Remaining
+// months are converted to seconds at OneYearSeconds/12 per month, the unit
+// DescribeReservedInstancesOfferings filters Duration on.
+func marketplaceOfferingsParams(q map[string]string) (ec2svc.SearchMarketplaceOfferingsParams, error) {
+	params := ec2svc.SearchMarketplaceOfferingsParams{
+		InstanceType:       q["instance_type"],
+		ProductDescription: q["platform"],
+		Tenancy:            q["tenancy"],
+	}
+	if params.InstanceType == "" {
+		return params, NewClientError(400, "instance_type is required")
+	}
+	minMonths, err := remainingMonthsParam(q, "min_remaining_months")
+	if err != nil {
+		return params, err
+	}
+	maxMonths, err := remainingMonthsParam(q, "max_remaining_months")
+	if err != nil {
+		return params, err
+	}
+	if maxMonths > 0 && minMonths > maxMonths {
+		return params, NewClientError(400, "min_remaining_months must not exceed max_remaining_months")
+	}
+	params.MinDuration = int64(minMonths) * ec2svc.OneYearSeconds / 12
+	params.MaxDuration = int64(maxMonths) * ec2svc.OneYearSeconds / 12
+	return params, nil
+}
+
+// remainingMonthsParam reads an optional remaining-months query parameter,
+// returning 0 when it is absent.
+func remainingMonthsParam(q map[string]string, name string) (int, error) {
+	raw := q[name]
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > maxMarketplaceRemainingMonths {
+		return 0, NewClientError(400, fmt.Sprintf("%s must be an integer between 1 and %d", name, maxMarketplaceRemainingMonths))
+	}
+	return n, nil
+}
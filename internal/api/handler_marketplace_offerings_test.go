@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+)
+
+// stubMarketplaceOfferingsEC2 records the search parameters it was called
+// with and returns a fixed result.
+type stubMarketplaceOfferingsEC2 struct {
+	err       error
+	offerings []ec2svc.MarketplaceOffering
+	got       *ec2svc.SearchMarketplaceOfferingsParams
+}
+
+func (s *stubMarketplaceOfferingsEC2) SearchMarketplaceOfferings(_ context.Context, params ec2svc.SearchMarketplaceOfferingsParams) ([]ec2svc.MarketplaceOffering, error) {
+	s.got = &params
+	return s.offerings, s.err
+}
+
+func marketplaceOfferingsHandler(stub *stubMarketplaceOfferingsEC2) *Handler {
+	h := &Handler{
+		auth:                           &mockAuthForExchange{},
+		marketplaceOfferingsEC2Factory: func(_ aws.Config) marketplaceOfferingsEC2Client { return stub },
+	}
+	h.awsCfgOnce.Do(func() { h.awsCfg = aws.Config{Region: "us-east-1"} })
+	return h
+}
+
+func marketplaceOfferingsRequest(query map[string]string) *events.LambdaFunctionURLRequest {
+	return &events.LambdaFunctionURLRequest{
+		Headers:               map[string]string{"authorization": "Bearer test-token"},
+		QueryStringParameters: query,
+	}
+}
+
+func TestSearchMarketplaceOfferings_RequiresPermission(t *testing.T) {
+	h := &Handler{}
+	_, err := h.searchMarketplaceOfferings(context.Background(), &events.LambdaFunctionURLRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication")
+}
+
+func TestSearchMarketplaceOfferings_HappyPath(t *testing.T) {
+	stub := &stubMarketplaceOfferingsEC2{
+		offerings: []ec2svc.MarketplaceOffering{
+			{OfferingID: "4b2293b4-5fbc-4017-9c75-d5a9d3aa8c91", InstanceType: "m5.large", Duration: 7884000, EffectiveHourlyRate: 0.05},
+		},
+	}
+	h := marketplaceOfferingsHandler(stub)
+
+	res, err := h.searchMarketplaceOfferings(context.Background(), marketplaceOfferingsRequest(map[string]string{
+		"instance_type":        "m5.large",
+		"platform":             "Windows",
+		"min_remaining_months": "2",
+		"max_remaining_months": "6",
+	}))
+	require.NoError(t, err)
+	resp, ok := res.(*MarketplaceOfferingsResponse)
+	require.True(t, ok)
+	require.Len(t, resp.Offerings, 1)
+	assert.Equal(t, "4b2293b4-5fbc-4017-9c75-d5a9d3aa8c91", resp.Offerings[0].OfferingID)
+
+	require.NotNil(t, stub.got)
+	assert.Equal(t, "m5.large", stub.got.InstanceType)
+	assert.Equal(t, "Windows", stub.got.ProductDescription)
+	assert.Equal(t, int64(2*ec2svc.OneYearSeconds/12), stub.got.MinDuration)
+	assert.Equal(t, int64(ec2svc.OneYearSeconds/2), stub.got.MaxDuration)
+}
+
+func TestSearchMarketplaceOfferings_EmptyResultIsArray(t *testing.T) {
+	h := marketplaceOfferingsHandler(&stubMarketplaceOfferingsEC2{})
+	res, err := h.searchMarketplaceOfferings(context.Background(), marketplaceOfferingsRequest(map[string]string{"instance_type": "m5.large"}))
+	require.NoError(t, err)
+	resp := res.(*MarketplaceOfferingsResponse)
+	assert.NotNil(t, resp.Offerings, "an empty search must serialize as [] rather than null")
+}
+
+func TestSearchMarketplaceOfferings_BadRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   map[string]string
+		wantErr string
+	}{
+		{"missing instance type", map[string]string{}, "instance_type is required"},
+		{"non-numeric months", map[string]string{"instance_type": "m5.large", "max_remaining_months": "six"}, "max_remaining_months must be an integer"},
+		{"months out of range", map[string]string{"instance_type": "m5.large", "min_remaining_months": "37"}, "between 1 and 36"},
+		{"min above max", map[string]string{"instance_type": "m5.large", "min_remaining_months": "9", "max_remaining_months": "3"}, "must not exceed"},
+		{"bad region", map[string]string{"instance_type": "m5.large", "region": "US EAST"}, "invalid region"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubMarketplaceOfferingsEC2{}
+			_, err := marketplaceOfferingsHandler(stub).searchMarketplaceOfferings(context.Background(), marketplaceOfferingsRequest(tt.query))
+			require.Error(t, err)
+			ce, ok := IsClientError(err)
+			require.True(t, ok)
+			assert.Equal(t, 400, ce.code)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Nil(t, stub.got, "AWS must not be queried for an invalid request")
+		})
+	}
+}
+
+func TestSearchMarketplaceOfferings_AWSError(t *testing.T) {
+	h := marketplaceOfferingsHandler(&stubMarketplaceOfferingsEC2{err: errors.New("throttled")})
+	_, err := h.searchMarketplaceOfferings(context.Background(), marketplaceOfferingsRequest(map[string]string{"instance_type": "m5.large"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "throttled")
+}
@@ -661,6 +661,18 @@ func TestComputeRemainingMonths(t *testing.T) {
 	assert.Equal(t, 1, computeRemainingMonths(old, 36), "expired RI should floor to 1")
 }
 
+func TestPurchaseTermMonths(t *testing.T) {
+	bought := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 36, purchaseTermMonths(&config.PurchaseHistoryRecord{Timestamp: bought, Term: 3}))
+
+	// A marketplace RI bought with ~4.5 months left rounds up to 5.
+	end := bought.Add(137 * 24 * time.Hour)
+	assert.Equal(t, 5, purchaseTermMonths(&config.PurchaseHistoryRecord{Timestamp: bought, Term: 1, TermEnd: &end}))
+
+	// A TermEnd at or before the purchase floors to 1.
+	assert.Equal(t, 1, purchaseTermMonths(&config.PurchaseHistoryRecord{Timestamp: bought, Term: 1, TermEnd: &bought}))
+}
+
 // TestMarketplaceList_TermYearsConvertedToMonths is the end-to-end regression
 // test for the #808 follow-up money bug. purchase_history.term is stored in
 // years (1 or 3); the handler was passing it unchanged to computeRemainingMonths
@@ -137,17 +137,29 @@ func (h *Handler) createPlan(ctx context.Context, httpReq *events.LambdaFunction
 	}
 
 	plan := req.toPurchasePlan()
+	if err := h.storeNewPlan(ctx, plan, req.TargetAccounts); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
 
-	// Validate the plan
+// storeNewPlan validates plan, creates it and assigns it to accountIDs,
+// rolling the plan row back if the accounts cannot be assigned. It is the
+// shared tail of createPlan and the plan path of purchasePortfolio.
+func (h *Handler) storeNewPlan(ctx context.Context, plan *config.PurchasePlan, accountIDs []string) error {
 	if err := plan.Validate(); err != nil {
-		return nil, NewClientError(400, fmt.Sprintf("validation error: %s", err))
+		return NewClientError(400, fmt.Sprintf("validation error: %s", err))
+	}
+
+	if err := h.checkAutoPurchaseDoubleCoverage(ctx, plan, accountIDs); err != nil {
+		return err
 	}
 
 	if err := h.config.CreatePurchasePlan(ctx, plan); err != nil {
-		return nil, mapCreatePlanStorageError(err,
+		return mapCreatePlanStorageError(err,
 			"plan not found", "failed to create plan",
-			"createPlan: CreatePurchasePlan failed (provider=%s service=%s accounts=%d): %v",
-			req.Provider, req.Service, len(req.TargetAccounts), err)
+			"createPlan: CreatePurchasePlan failed (services=%d accounts=%d): %v",
+			len(plan.Services), len(accountIDs), err)
 	}
 
 	// Provider-match validation + plan_accounts insert. SetPlanAccounts is
@@ -161,20 +173,19 @@ func (h *Handler) createPlan(ctx context.Context, httpReq *events.LambdaFunction
 		}
 	}
 
-	if err := h.validatePlanAccountProviders(ctx, plan.ID, req.TargetAccounts); err != nil {
+	if err := h.validatePlanAccountProviders(ctx, plan.ID, accountIDs); err != nil {
 		rollbackPlan()
-		return nil, err
+		return err
 	}
 
-	if err := h.config.SetPlanAccounts(ctx, plan.ID, req.TargetAccounts); err != nil {
+	if err := h.config.SetPlanAccounts(ctx, plan.ID, accountIDs); err != nil {
 		rollbackPlan()
-		return nil, mapCreatePlanStorageError(err,
+		return mapCreatePlanStorageError(err,
 			"account not found", "failed to assign accounts to plan",
 			"createPlan: SetPlanAccounts failed (plan=%s accounts=%d): %v",
-			plan.ID, len(req.TargetAccounts), err)
+			plan.ID, len(accountIDs), err)
 	}
-
-	return plan, nil
+	return nil
 }
 
 // validateTargetAccounts rejects a missing/empty target_accounts payload and
@@ -285,6 +296,10 @@ func (h *Handler) updatePlan(ctx context.Context, httpReq *events.LambdaFunction
 		return nil, NewClientError(400, fmt.Sprintf("validation error: %s", err))
 	}
 
+	if err := h.checkSavedPlanDoubleCoverage(ctx, plan); err != nil {
+		return nil, err
+	}
+
 	if err := h.config.UpdatePurchasePlan(ctx, plan); err != nil {
 		return nil, err
 	}
@@ -292,6 +307,19 @@ func (h *Handler) updatePlan(ctx context.Context, httpReq *events.LambdaFunction
 	return plan, nil
 }
 
+// checkSavedPlanDoubleCoverage is checkAutoPurchaseDoubleCoverage for an
+// existing plan, on the accounts it is already assigned.
+func (h *Handler) checkSavedPlanDoubleCoverage(ctx context.Context, plan *config.PurchasePlan) error {
+	if !plan.Enabled || !plan.AutoPurchase || plan.ShadowMode || plan.AllowDoubleCoverage {
+		return nil
+	}
+	accountIDs, err := h.planAccountIDs(ctx, plan.ID)
+	if err != nil {
+		return err
+	}
+	return h.checkAutoPurchaseDoubleCoverage(ctx, plan, accountIDs)
+}
+
 func (h *Handler) deletePlan(ctx context.Context, req *events.LambdaFunctionURLRequest, planID string) (any, error) {
 	// Validate UUID format to prevent injection attacks
 	if err := validateUUID(planID); err != nil {
@@ -344,6 +372,16 @@ func (h *Handler) createPlannedPurchases(ctx context.Context, httpReq *events.La
 		return nil, err
 	}
 
+	if !req.AllowDoubleCoverage && !plan.AllowDoubleCoverage {
+		accountIDs, err := h.planAccountIDs(ctx, planID)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.checkPlanDoubleCoverage(ctx, plan, accountIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	// Atomic write: per-row execution inserts and the plan's
 	// next_execution_date bump commit together, or roll back together.
 	// The previous implementation called SavePurchaseExecution outside
@@ -488,6 +526,7 @@ type PatchPlanRequest struct {
 	Enabled                *bool   `json:"enabled,omitempty"`
 	AutoPurchase           *bool   `json:"auto_purchase,omitempty"`
 	NotificationDaysBefore *int    `json:"notification_days_before,omitempty"`
+	AllowDoubleCoverage    *bool   `json:"allow_double_coverage,omitempty"`
 }
 
 // applyPatchFields applies validated partial-update fields to a plan.
@@ -507,6 +546,9 @@ func applyPatchFields(plan *config.PurchasePlan, req PatchPlanRequest) error {
 	if req.AutoPurchase != nil {
 		plan.AutoPurchase = *req.AutoPurchase
 	}
+	if req.AllowDoubleCoverage != nil {
+		plan.AllowDoubleCoverage = *req.AllowDoubleCoverage
+	}
 	if req.NotificationDaysBefore != nil {
 		if *req.NotificationDaysBefore < 0 || *req.NotificationDaysBefore > 30 {
 			return NewClientError(400, "notification_days_before must be between 0 and 30")
@@ -562,6 +604,10 @@ func (h *Handler) patchPlan(ctx context.Context, httpReq *events.LambdaFunctionU
 		return nil, NewClientError(400, fmt.Sprintf("validation error: %s", err))
 	}
 
+	if err := h.checkSavedPlanDoubleCoverage(ctx, plan); err != nil {
+		return nil, err
+	}
+
 	if err := h.config.UpdatePurchasePlan(ctx, plan); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/portfolio"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// PortfolioItem is the optimizer's verdict on one cached recommendation.
+type PortfolioItem struct {
+	Recommendation   config.RecommendationRecord `json:"recommendation"`
+	Chosen           bool                        `json:"chosen"`
+	Quantity         float64                     `json:"quantity"`
+	MonthlySavings   float64                     `json:"monthly_savings"`
+	Upfront          float64                     `json:"upfront"`
+	HourlyCommitment float64                     `json:"hourly_commitment"`
+	AtRiskHourly     float64                     `json:"at_risk_hourly"`
+	Volatility       float64                     `json:"volatility"`
+	Reason           string                      `json:"reason"`
+}
+
+// PortfolioResponse is the GET /api/recommendations/optimize payload.
+// Recommendations holds the chosen items sized to the chosen quantity, in
+// the shape POST /api/purchases/execute accepts, so the portfolio can be
+// submitted as-is.
+type PortfolioResponse struct {
+	Recommendations  []config.RecommendationRecord `json:"recommendations"`
+	Items            []PortfolioItem               `json:"items"`
+	MonthlySavings   float64                       `json:"monthly_savings"`
+	Upfront          float64                       `json:"upfront"`
+	HourlyCommitment float64                       `json:"hourly_commitment"`
+	AtRiskHourly     float64                       `json:"at_risk_hourly"`
+	Optimal          bool                          `json:"optimal"`
+}
+
+// PortfolioPurchaseRequest is the optional body of
+// POST /api/recommendations/optimize. Without PlanID or Plan the portfolio
+// is submitted to POST /api/purchases/execute; with either it is scheduled
+// on a purchase plan instead.
+type PortfolioPurchaseRequest struct {
+	// ExecuteMode is passed through to POST /api/purchases/execute. It
+	// does not apply to a portfolio scheduled on a plan.
+	ExecuteMode string `json:"execute_mode,omitempty"`
+	// PlanID schedules the portfolio on this existing plan, adding the
+	// portfolio's provider/service pairs the plan does not have yet.
+	PlanID string `json:"plan_id,omitempty"`
+	// Plan creates a plan, as POST /api/plans does, and schedules the
+	// portfolio on it. Its Provider, Service, Term and Payment are ignored:
+	// the plan's services are the portfolio's.
+	Plan *PlanRequest `json:"plan,omitempty"`
+}
+
+// PortfolioPurchaseResponse is the POST /api/recommendations/optimize
+// payload: the portfolio, and the POST /api/purchases/execute response for
+// the purchase it was submitted as or, when it was scheduled on a plan,
+// the plan and its new pending execution.
+type PortfolioPurchaseResponse struct {
+	Portfolio *PortfolioResponse   `json:"portfolio"`
+	Plan      *config.PurchasePlan `json:"plan,omitempty"`
+	Purchase  any                  `json:"purchase"`
+}
+
+// optimizePortfolio handles GET /api/recommendations/optimize.
+//
+// Runs pkg/portfolio over the cached recommendations the caller can see
+// (the same filters, enabled-provider and allowed_accounts scoping as
+// GET /api/recommendations) and returns the SP/RI mix that maximises
+// monthly savings under max_upfront, max_hourly_commitment and
+// max_at_risk_hourly (absent = unlimited), with a reason per item.
+// default_volatility applies to pools without usage history. Pools whose
+// current coverage the collector recorded are bounded at their uncovered
+// usage (portfolio.CoverageDemand).
+func (h *Handler) optimizePortfolio(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (*PortfolioResponse, error) {
+	session, err := h.requirePermission(ctx, req, "view", "recommendations")
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseRecommendationFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parsePortfolioConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := h.scheduler.ListRecommendations(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	records, err = h.filterRecommendationsByEnabledProviders(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	records, err = h.filterRecommendationsByAllowedAccounts(ctx, session, records)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]common.Recommendation, len(records))
+	for i := range records {
+		recs[i] = recommendationFromRecord(records[i])
+	}
+	cfg.Demand = portfolio.CoverageDemand(recs)
+	result, err := portfolio.Optimize(recs, cfg)
+	if err != nil {
+		return nil, NewClientError(400, err.Error())
+	}
+
+	resp := &PortfolioResponse{
+		Recommendations:  make([]config.RecommendationRecord, 0, len(result.Selected)),
+		Items:            make([]PortfolioItem, len(records)),
+		MonthlySavings:   result.MonthlySavings,
+		Upfront:          result.Upfront,
+		HourlyCommitment: result.HourlyCommitment,
+		AtRiskHourly:     result.AtRiskHourly,
+		Optimal:          result.Optimal,
+	}
+	selected := 0
+	for i := range result.Decisions {
+		d := result.Decisions[i]
+		resp.Items[i] = PortfolioItem{
+			Recommendation:   records[i],
+			Chosen:           d.Chosen,
+			Quantity:         d.Quantity,
+			MonthlySavings:   d.MonthlySavings,
+			Upfront:          d.Upfront,
+			HourlyCommitment: d.HourlyCommitment,
+			AtRiskHourly:     d.AtRiskHourly,
+			Volatility:       d.Volatility,
+			Reason:           d.Reason,
+		}
+		if d.Chosen {
+			resp.Recommendations = append(resp.Recommendations, recordFromScaled(records[i], result.Selected[selected]))
+			selected++
+		}
+	}
+	return resp, nil
+}
+
+// purchasePortfolio handles POST /api/recommendations/optimize: it builds
+// the portfolio GET /api/recommendations/optimize returns, from the same
+// query parameters, and submits its sized recommendations to
+// POST /api/purchases/execute, so the purchase passes the same permission,
+// scope, budget and approval gates as a hand-picked one. With plan_id or
+// plan in the body the portfolio is scheduled on a purchase plan instead;
+// see schedulePortfolio.
+func (h *Handler) purchasePortfolio(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (*PortfolioPurchaseResponse, error) {
+	var body PortfolioPurchaseRequest
+	if strings.TrimSpace(req.Body) != "" {
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return nil, NewClientError(400, "invalid request body")
+		}
+	}
+	onPlan := body.PlanID != "" || body.Plan != nil
+	if body.PlanID != "" && body.Plan != nil {
+		return nil, NewClientError(400, "set plan_id or plan, not both")
+	}
+	if onPlan && body.ExecuteMode != "" {
+		return nil, NewClientError(400, "execute_mode does not apply to a portfolio scheduled on a plan")
+	}
+	resp, err := h.optimizePortfolio(ctx, req, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Recommendations) == 0 {
+		return nil, NewClientError(400, "the optimizer chose nothing to buy")
+	}
+	if onPlan {
+		return h.schedulePortfolio(ctx, req, &body, resp)
+	}
+	execBody, err := json.Marshal(ExecutePurchaseRequest{Recommendations: resp.Recommendations, ExecuteMode: body.ExecuteMode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode portfolio purchase: %w", err)
+	}
+	execReq := *req
+	execReq.Body = string(execBody)
+	execReq.IsBase64Encoded = false
+	purchase, err := h.executePurchase(ctx, &execReq)
+	if err != nil {
+		return nil, err
+	}
+	return &PortfolioPurchaseResponse{Portfolio: resp, Purchase: purchase}, nil
+}
+
+// schedulePortfolio is the plan path of purchasePortfolio. It creates the
+// plan body.Plan describes, or loads body.PlanID and adds the portfolio's
+// services it lacks, then schedules the sized recommendations as the plan's
+// next pending execution, which goes through the plan's approval flow like
+// any scheduled purchase. Creating a plan needs create:plans, changing one
+// update:plans, and scheduling the execution update:purchases, as for
+// POST /api/plans/{id}/purchases.
+func (h *Handler) schedulePortfolio(ctx context.Context, req *events.LambdaFunctionURLRequest, body *PortfolioPurchaseRequest, resp *PortfolioResponse) (*PortfolioPurchaseResponse, error) {
+	session, err := h.requirePermission(ctx, req, "update", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	totalUpfront, totalSavings, err := validateAndTotalRecommendations(resp.Recommendations)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan *config.PurchasePlan
+	if body.Plan != nil {
+		plan, err = h.createPortfolioPlan(ctx, req, body.Plan, resp.Recommendations)
+	} else {
+		plan, err = h.addPortfolioToPlan(ctx, req, body.PlanID, resp.Recommendations)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	approvalToken, err := common.GenerateApprovalToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate approval token: %w", err)
+	}
+	scheduled := time.Now()
+	if plan.NextExecutionDate != nil && plan.NextExecutionDate.After(scheduled) {
+		scheduled = *plan.NextExecutionDate
+	}
+	execution := &config.PurchaseExecution{
+		PlanID:           plan.ID,
+		ExecutionID:      uuid.New().String(),
+		Status:           "pending",
+		StepNumber:       plan.RampSchedule.CurrentStep + 1,
+		ScheduledDate:    scheduled,
+		ApprovalToken:    approvalToken,
+		Recommendations:  resp.Recommendations,
+		TotalUpfrontCost: totalUpfront,
+		EstimatedSavings: totalSavings,
+		Source:           common.PurchaseSourceWeb,
+		CreatedByUserID:  resolveCreatorUserID(session),
+	}
+	if err := h.config.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := h.config.SavePurchaseExecutionTx(ctx, tx, execution); err != nil {
+			return fmt.Errorf("failed to save execution: %w", err)
+		}
+		return h.updatePlanNextExecutionDateTx(ctx, tx, plan, scheduled)
+	}); err != nil {
+		return nil, err
+	}
+
+	return &PortfolioPurchaseResponse{Portfolio: resp, Plan: plan, Purchase: map[string]any{
+		"execution_id":         execution.ExecutionID,
+		"plan_id":              plan.ID,
+		"status":               execution.Status,
+		"scheduled_date":       execution.ScheduledDate,
+		"recommendation_count": len(execution.Recommendations),
+		"total_upfront_cost":   execution.TotalUpfrontCost,
+		"estimated_savings":    execution.EstimatedSavings,
+	}}, nil
+}
+
+// createPortfolioPlan creates the plan spec describes, as POST /api/plans
+// does, with one service per provider/service pair in recs.
+func (h *Handler) createPortfolioPlan(ctx context.Context, req *events.LambdaFunctionURLRequest, spec *PlanRequest, recs []config.RecommendationRecord) (*config.PurchasePlan, error) {
+	if _, err := h.requirePermission(ctx, req, "create", "plans"); err != nil {
+		return nil, err
+	}
+	if err := validateTargetAccounts(spec.TargetAccounts); err != nil {
+		return nil, err
+	}
+	plan := spec.toPurchasePlan()
+	plan.Services = portfolioServices(*spec, recs)
+	if err := h.storeNewPlan(ctx, plan, spec.TargetAccounts); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// addPortfolioToPlan loads planID and adds a service for each
+// provider/service pair in recs the plan does not have yet. Services the
+// plan already has keep their configuration. A portfolio for a provider
+// the plan does not target is refused: the plan's accounts cannot buy it.
+func (h *Handler) addPortfolioToPlan(ctx context.Context, req *events.LambdaFunctionURLRequest, planID string, recs []config.RecommendationRecord) (*config.PurchasePlan, error) {
+	if err := validateUUID(planID); err != nil {
+		return nil, err
+	}
+	session, err := h.requirePermission(ctx, req, "update", "plans")
+	if err != nil {
+		return nil, err
+	}
+	if err := h.requirePlanAccess(ctx, session, planID); err != nil {
+		return nil, err
+	}
+	plan, err := h.getPlanForPurchaseCreation(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := config.DerivePlanProviders(plan)
+	added := false
+	for key, svc := range portfolioServices(PlanRequest{}, recs) {
+		if _, ok := plan.Services[key]; ok {
+			continue
+		}
+		if len(providers) > 0 && !slices.Contains(providers, svc.Provider) {
+			return nil, NewClientError(400, fmt.Sprintf("plan %s buys %s commitments; the portfolio includes %s",
+				plan.Name, strings.Join(providers, ", "), svc.Provider))
+		}
+		if plan.Services == nil {
+			plan.Services = map[string]config.ServiceConfig{}
+		}
+		plan.Services[key] = svc
+		added = true
+	}
+	if !added {
+		return plan, nil
+	}
+	plan.UpdatedAt = time.Now()
+	if err := plan.Validate(); err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("validation error: %s", err))
+	}
+	if err := h.checkSavedPlanDoubleCoverage(ctx, plan); err != nil {
+		return nil, err
+	}
+	if err := h.config.UpdatePurchasePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// portfolioServices is the plan service configuration for recs: one
+// service per provider/service pair, with the term and payment of the
+// pair's first recommendation and spec's coverage (80% when unset).
+func portfolioServices(spec PlanRequest, recs []config.RecommendationRecord) map[string]config.ServiceConfig {
+	services := map[string]config.ServiceConfig{}
+	for i := range recs {
+		spec.Provider, spec.Service = recs[i].Provider, recs[i].Service
+		spec.Term, spec.Payment = recs[i].Term, recs[i].Payment
+		for key, svc := range spec.buildServiceConfig() {
+			if _, ok := services[key]; !ok {
+				services[key] = svc
+			}
+		}
+	}
+	return services
+}
+
+func parsePortfolioConfig(params map[string]string) (portfolio.Config, error) {
+	var cfg portfolio.Config
+	for name, dst := range map[string]*float64{
+		"max_upfront":           &cfg.MaxUpfront,
+		"max_hourly_commitment": &cfg.MaxHourlyCommitment,
+		"max_at_risk_hourly":    &cfg.MaxAtRiskHourly,
+		"default_volatility":    &cfg.DefaultVolatility,
+	} {
+		v, err := parseMinSavingsParam(params[name], name)
+		if err != nil {
+			return portfolio.Config{}, err
+		}
+		*dst = v
+	}
+	if cfg.DefaultVolatility > 1 {
+		return portfolio.Config{}, NewClientError(400, "default_volatility must be between 0 and 1")
+	}
+	return cfg, nil
+}
+
+// recommendationFromRecord rebuilds the common.Recommendation the
+// optimizer works on from a cached record. The account is the external
+// account segment of the record ID (see scheduler.convertRecommendations),
+// which is what groups overlapping commitments; Details is decoded so EC2
+// Instance Savings Plans keep their instance family, and the pool's
+// coverage is carried over when the collector recorded it.
+func recommendationFromRecord(r config.RecommendationRecord) common.Recommendation {
+	rec := common.Recommendation{
+		Provider:             common.ProviderType(r.Provider),
+		Service:              common.ServiceType(r.Service),
+		Region:               r.Region,
+		ResourceType:         r.ResourceType,
+		Count:                r.Count,
+		CommitmentType:       common.CommitmentReservedInstance,
+		Term:                 fmt.Sprintf("%dyr", r.Term),
+		PaymentOption:        r.Payment,
+		CommitmentCost:       r.UpfrontCost,
+		RecurringMonthlyCost: r.MonthlyCost,
+		EstimatedSavings:     r.Savings,
+		UsageHistory:         r.UsageHistory,
+
+		AverageInstancesUsedPerHour: r.AverageInstancesUsedPerHour,
+	}
+	if r.ExistingCoveragePct != nil {
+		rec.ExistingCoveragePct = *r.ExistingCoveragePct
+		rec.ExistingCoverageKnown = true
+	}
+	if parts := strings.Split(r.ID, "|"); len(parts) > 1 && parts[1] != "" {
+		rec.Account = parts[1]
+	} else if r.CloudAccountID != nil {
+		rec.Account = *r.CloudAccountID
+	}
+	if common.IsSavingsPlan(rec.Service) {
+		rec.CommitmentType = common.CommitmentSavingsPlan
+	}
+	if r.OnDemandCost != nil {
+		rec.OnDemandCost = *r.OnDemandCost
+	}
+	if details, err := common.DecodeServiceDetailsFor(r.Service, r.Details); err == nil {
+		rec.Details = details
+	}
+	return rec
+}
+
+// recordFromScaled copies the optimizer's sizing of rec back onto the
+// cached record: Count and every money field, plus the Savings Plan's
+// hourly commitment inside Details.
+func recordFromScaled(r config.RecommendationRecord, rec common.Recommendation) config.RecommendationRecord {
+	r.Count = rec.Count
+	r.UpfrontCost = rec.CommitmentCost
+	r.MonthlyCost = rec.RecurringMonthlyCost
+	r.Savings = rec.EstimatedSavings
+	if r.OnDemandCost != nil {
+		onDemand := rec.OnDemandCost
+		r.OnDemandCost = &onDemand
+	}
+	if _, ok := rec.Details.(*common.SavingsPlanDetails); ok {
+		if raw, err := common.MarshalServiceDetails(rec.Details); err == nil {
+			r.Details = raw
+		}
+	}
+	return r
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_optimizePortfolio_DropsOverlapAndSizesToBudget(t *testing.T) {
+	ctx := context.Background()
+	riMonthly, spMonthly := 280.0, 750.0
+	riOnDemand, spOnDemand := 400.0, 400.0
+	spDetails, err := common.MarshalServiceDetails(&common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: spMonthly / 730})
+	require.NoError(t, err)
+	records := []config.RecommendationRecord{
+		{ID: "aws|111111111111|ec2|us-east-1|m5.large||3|all-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 3, Payment: "all-upfront", UpfrontCost: 7200, MonthlyCost: new(float64),
+			Savings: 200, OnDemandCost: &riOnDemand},
+		{ID: "aws|111111111111|ec2|us-east-1|m5.large||1|no-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "no-upfront", MonthlyCost: &riMonthly,
+			Savings: 120, OnDemandCost: &riOnDemand},
+		{ID: "aws|111111111111|savings-plans-compute|||1|no-upfront", Provider: "aws", Service: "savings-plans-compute",
+			Count: 1, Term: 1, Payment: "no-upfront", MonthlyCost: &spMonthly, Savings: 90, OnDemandCost: &spOnDemand,
+			Details: spDetails},
+	}
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return(records, nil)
+	handler := &Handler{scheduler: mockScheduler, apiKey: "test-key"}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}}
+
+	resp, err := handler.optimizePortfolio(ctx, req, map[string]string{"max_upfront": "3600"})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 3)
+	assert.True(t, resp.Optimal)
+
+	// Two 3yr units fit the budget; the 1yr variant covers the rest of
+	// the pool; the Compute SP would cover the same usage again.
+	assert.Equal(t, 2.0, resp.Items[0].Quantity)
+	assert.Contains(t, resp.Items[0].Reason, "upfront budget 3600.00 is spent")
+	assert.Equal(t, 2.0, resp.Items[1].Quantity)
+	assert.False(t, resp.Items[2].Chosen)
+	assert.Contains(t, resp.Items[2].Reason, "compute usage of account 111111111111 is already covered")
+
+	require.Len(t, resp.Recommendations, 2)
+	threeYear := resp.Recommendations[0]
+	assert.Equal(t, records[0].ID, threeYear.ID)
+	assert.Equal(t, 2, threeYear.Count)
+	assert.InDelta(t, 3600, threeYear.UpfrontCost, 1e-9)
+	assert.InDelta(t, 100, threeYear.Savings, 1e-9)
+	require.NotNil(t, threeYear.OnDemandCost)
+	assert.InDelta(t, 200, *threeYear.OnDemandCost, 1e-9)
+	assert.InDelta(t, 3600, resp.Upfront, 1e-9)
+}
+
+func TestHandler_optimizePortfolio_ScalesSavingsPlanDetails(t *testing.T) {
+	ctx := context.Background()
+	riMonthly, spMonthly := 280.0, 750.0
+	riOnDemand, spOnDemand := 400.0, 1000.0
+	spDetails, err := common.MarshalServiceDetails(&common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1})
+	require.NoError(t, err)
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{
+		{ID: "aws|1|ec2|us-east-1|m5.large||1|no-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "no-upfront", MonthlyCost: &riMonthly, Savings: 120, OnDemandCost: &riOnDemand},
+		{ID: "aws|1|savings-plans-compute|||1|no-upfront", Provider: "aws", Service: "savings-plans-compute",
+			Count: 1, Term: 1, Payment: "no-upfront", MonthlyCost: &spMonthly, Savings: 250, OnDemandCost: &spOnDemand, Details: spDetails},
+	}, nil)
+	handler := &Handler{scheduler: mockScheduler, apiKey: "test-key"}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}}
+
+	resp, err := handler.optimizePortfolio(ctx, req, map[string]string{})
+	require.NoError(t, err)
+	require.Len(t, resp.Recommendations, 2)
+	sp := resp.Recommendations[1]
+	assert.InDelta(t, 150, sp.Savings, 1e-9)
+	var details common.SavingsPlanDetails
+	require.NoError(t, json.Unmarshal(sp.Details, &details))
+	assert.InDelta(t, 0.6, details.HourlyCommitment, 1e-9)
+}
+
+func TestHandler_optimizePortfolio_RejectsBadLimits(t *testing.T) {
+	ctx := context.Background()
+	handler := &Handler{scheduler: new(MockScheduler), apiKey: "test-key"}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}}
+
+	for _, params := range []map[string]string{
+		{"max_upfront": "-1"},
+		{"max_hourly_commitment": "abc"},
+		{"default_volatility": "1.5"},
+	} {
+		_, err := handler.optimizePortfolio(ctx, req, params)
+		require.Error(t, err, "%v", params)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "%v", params)
+		assert.Equal(t, 400, ce.code)
+	}
+}
+
+func TestHandler_optimizePortfolio_SizesCoveredPoolToUncoveredUsage(t *testing.T) {
+	ctx := context.Background()
+	riMonthly, riOnDemand, covered := 280.0, 400.0, 50.0
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{
+		{ID: "aws|1|ec2|us-east-1|m5.large||1|no-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "no-upfront", MonthlyCost: &riMonthly, Savings: 120,
+			OnDemandCost: &riOnDemand, AverageInstancesUsedPerHour: 4, ExistingCoveragePct: &covered},
+	}, nil)
+	handler := &Handler{scheduler: mockScheduler, apiKey: "test-key"}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}}
+
+	// Half of the 4 instances the pool runs are already reserved: the
+	// recommendation for 4 is cut to the 2 uncovered ones.
+	resp, err := handler.optimizePortfolio(ctx, req, map[string]string{})
+	require.NoError(t, err)
+	require.Len(t, resp.Recommendations, 1)
+	assert.Equal(t, 2, resp.Recommendations[0].Count)
+	assert.InDelta(t, 60, resp.Recommendations[0].Savings, 1e-9)
+}
+
+func TestHandler_purchasePortfolio_SubmitsChosenRecommendations(t *testing.T) {
+	ctx := context.Background()
+	riMonthly, riOnDemand := 280.0, 400.0
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{
+		{ID: "aws|1|ec2|us-east-1|m5.large||1|all-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "all-upfront", UpfrontCost: 3600, MonthlyCost: &riMonthly,
+			Savings: 120, OnDemandCost: &riOnDemand},
+	}, nil)
+	mockStore := new(MockConfigStore)
+	mockAuth := new(MockAuthService)
+	mockAuth.On("ValidateSession", ctx, "admin-token").Return(&Session{
+		UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", Email: "admin@example.com",
+	}, nil)
+	mockAuth.grantAdminPurchaser()
+	var saved *config.PurchaseExecution
+	mockStore.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*config.PurchaseExecution) }).Return(nil)
+	mockStore.On("GetGlobalConfig", ctx).Return(&config.GlobalConfig{}, nil)
+	mockStore.On("GetPendingExecutions", ctx).Return([]config.PurchaseExecution{}, nil)
+	handler := &Handler{scheduler: mockScheduler, config: mockStore, auth: mockAuth}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"Authorization": "Bearer admin-token"}}
+
+	resp, err := handler.purchasePortfolio(ctx, req, map[string]string{"max_upfront": "1800"})
+	require.NoError(t, err)
+	require.Len(t, resp.Portfolio.Recommendations, 1)
+	require.NotNil(t, saved)
+	require.Len(t, saved.Recommendations, 1)
+	assert.Equal(t, 2, saved.Recommendations[0].Count)
+	assert.InDelta(t, 1800, saved.TotalUpfrontCost, 1e-9)
+	purchase := resp.Purchase.(map[string]interface{})
+	assert.Equal(t, saved.ExecutionID, purchase["execution_id"])
+}
+
+func TestHandler_purchasePortfolio_RejectsEmptyPortfolio(t *testing.T) {
+	ctx := context.Background()
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{}, nil)
+	handler := &Handler{scheduler: mockScheduler, apiKey: "test-key"}
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}}
+
+	_, err := handler.purchasePortfolio(ctx, req, map[string]string{})
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, 400, ce.code)
+}
+
+func TestHandler_purchasePortfolio_CreatesPlan(t *testing.T) {
+	ctx := context.Background()
+	riMonthly, riOnDemand := 280.0, 400.0
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{
+		{ID: "aws|1|ec2|us-east-1|m5.large||1|all-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "all-upfront", UpfrontCost: 3600, MonthlyCost: &riMonthly,
+			Savings: 120, OnDemandCost: &riOnDemand},
+	}, nil)
+	targetAccountID := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	mockStore := new(MockConfigStore)
+	mockStore.GetCloudAccountFn = func(_ context.Context, id string) (*config.CloudAccount, error) {
+		return &config.CloudAccount{ID: id, Name: "test-aws", Provider: "aws"}, nil
+	}
+	var plan *config.PurchasePlan
+	mockStore.On("CreatePurchasePlan", ctx, mock.AnythingOfType("*config.PurchasePlan")).
+		Run(func(args mock.Arguments) {
+			plan = args.Get(1).(*config.PurchasePlan)
+			plan.ID = "cccccccc-cccc-cccc-cccc-cccccccccccc"
+		}).Return(nil)
+	mockStore.On("SetPlanAccounts", ctx, "cccccccc-cccc-cccc-cccc-cccccccccccc", []string{targetAccountID}).Return(nil)
+	var saved *config.PurchaseExecution
+	mockStore.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*config.PurchaseExecution) }).Return(nil)
+	mockAuth := new(MockAuthService)
+	mockAuth.On("ValidateSession", ctx, "admin-token").Return(&Session{
+		UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", Email: "admin@example.com",
+	}, nil)
+	mockAuth.grantAdmin()
+	handler := &Handler{scheduler: mockScheduler, config: mockStore, auth: mockAuth}
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"plan": {"name": "Portfolio", "enabled": true, "target_accounts": ["` + targetAccountID + `"]}}`,
+	}
+
+	resp, err := handler.purchasePortfolio(ctx, req, map[string]string{"max_upfront": "1800"})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	assert.Same(t, plan, resp.Plan)
+	require.Contains(t, plan.Services, "aws/ec2")
+	svc := plan.Services["aws/ec2"]
+	assert.Equal(t, 1, svc.Term)
+	assert.Equal(t, "all-upfront", svc.Payment)
+
+	// The portfolio is the plan's next execution, awaiting approval rather
+	// than bought now.
+	require.NotNil(t, saved)
+	assert.Equal(t, plan.ID, saved.PlanID)
+	assert.Equal(t, "pending", saved.Status)
+	require.Len(t, saved.Recommendations, 1)
+	assert.Equal(t, 2, saved.Recommendations[0].Count)
+	assert.InDelta(t, 1800, saved.TotalUpfrontCost, 1e-9)
+	require.NotNil(t, saved.CreatedByUserID)
+	purchase := resp.Purchase.(map[string]any)
+	assert.Equal(t, saved.ExecutionID, purchase["execution_id"])
+}
+
+func TestHandler_purchasePortfolio_AddsToExistingPlan(t *testing.T) {
+	ctx := context.Background()
+	planID := "cccccccc-cccc-cccc-cccc-cccccccccccc"
+	riMonthly, riOnDemand := 280.0, 400.0
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("ListRecommendations", ctx, mock.Anything).Return([]config.RecommendationRecord{
+		{ID: "aws|1|ec2|us-east-1|m5.large||1|no-upfront", Provider: "aws", Service: "ec2", Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: 1, Payment: "no-upfront", MonthlyCost: &riMonthly,
+			Savings: 120, OnDemandCost: &riOnDemand},
+		{ID: "aws|1|rds|us-east-1|db.r5.large|mysql|3|no-upfront", Provider: "aws", Service: "rds", Region: "us-east-1",
+			ResourceType: "db.r5.large", Engine: "mysql", Count: 1, Term: 3, Payment: "no-upfront", MonthlyCost: &riMonthly,
+			Savings: 100, OnDemandCost: &riOnDemand},
+	}, nil)
+	rds := config.ServiceConfig{Provider: "aws", Service: "rds", Enabled: true, Term: 1, Payment: "all-upfront", Coverage: 50}
+	existing := &config.PurchasePlan{
+		ID: planID, Name: "Existing", Enabled: true,
+		Services:     map[string]config.ServiceConfig{"aws/rds": rds},
+		RampSchedule: config.PresetRampSchedules["immediate"],
+	}
+	mockStore := new(MockConfigStore)
+	mockStore.On("GetPurchasePlan", ctx, planID).Return(existing, nil)
+	var updated *config.PurchasePlan
+	mockStore.On("UpdatePurchasePlan", ctx, mock.AnythingOfType("*config.PurchasePlan")).
+		Run(func(args mock.Arguments) { updated = args.Get(1).(*config.PurchasePlan) }).Return(nil)
+	var saved *config.PurchaseExecution
+	mockStore.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*config.PurchaseExecution) }).Return(nil)
+	mockAuth := new(MockAuthService)
+	mockAuth.On("ValidateSession", ctx, "admin-token").Return(&Session{
+		UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", Email: "admin@example.com",
+	}, nil)
+	mockAuth.grantAdmin()
+	handler := &Handler{scheduler: mockScheduler, config: mockStore, auth: mockAuth}
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"plan_id": "` + planID + `"}`,
+	}
+
+	resp, err := handler.purchasePortfolio(ctx, req, map[string]string{})
+	require.NoError(t, err)
+	require.NotNil(t, updated)
+	require.Contains(t, updated.Services, "aws/ec2")
+	assert.Equal(t, "no-upfront", updated.Services["aws/ec2"].Payment)
+	assert.Equal(t, rds, updated.Services["aws/rds"], "a service the plan already has keeps its configuration")
+	require.NotNil(t, saved)
+	assert.Equal(t, planID, saved.PlanID)
+	assert.Len(t, saved.Recommendations, 2)
+	assert.Equal(t, planID, resp.Plan.ID)
+}
+
+func TestHandler_purchasePortfolio_RejectsConflictingPlanOptions(t *testing.T) {
+	ctx := context.Background()
+	handler := &Handler{apiKey: "test-key"}
+	for _, body := range []string{
+		`{"plan_id": "cccccccc-cccc-cccc-cccc-cccccccccccc", "plan": {"name": "New"}}`,
+		`{"plan_id": "cccccccc-cccc-cccc-cccc-cccccccccccc", "execute_mode": "direct"}`,
+	} {
+		req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"x-api-key": "test-key"}, Body: body}
+		_, err := handler.purchasePortfolio(ctx, req, map[string]string{})
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "%s: %v", body, err)
+		assert.Equal(t, 400, ce.code, body)
+	}
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+// defaultPolicyVersionsLimit caps GET /api/purchase-policy/versions when the
+// caller does not pass ?limit=.
+const defaultPolicyVersionsLimit = 50
+
+// PurchasePolicyResponse is the response for GET /api/purchase-policy.
+// Policy is null until the first version is saved.
+type PurchasePolicyResponse struct {
+	Policy *config.PurchasePolicy `json:"policy"`
+}
+
+// PurchasePolicyVersionsResponse is the response for
+// GET /api/purchase-policy/versions, newest first.
+type PurchasePolicyVersionsResponse struct {
+	Versions []config.PurchasePolicy `json:"versions"`
+}
+
+// UpdatePurchasePolicyRequest is the body for PUT /api/purchase-policy.
+// Saving an empty rules list turns the gate off.
+type UpdatePurchasePolicyRequest struct {
+	Rules   []policy.Rule `json:"rules"`
+	Comment string        `json:"comment,omitempty"`
+}
+
+// PurchasePolicyDryRunRequest is the body for
+// POST /api/purchase-policy/dry-run. Rules defaults to the policy in force.
+// Exactly one of ExecutionID and Input names what to evaluate; Stage
+// defaults to "approval" and applies to ExecutionID only.
+type PurchasePolicyDryRunRequest struct {
+	Rules       []policy.Rule `json:"rules,omitempty"`
+	ExecutionID string        `json:"execution_id,omitempty"`
+	Stage       string        `json:"stage,omitempty"`
+	Input       *policy.Input `json:"input,omitempty"`
+}
+
+// PurchasePolicyDryRunResponse is the response for
+// POST /api/purchase-policy/dry-run. PolicyVersion is 0 when the request
+// supplied its own rules.
+type PurchasePolicyDryRunResponse struct {
+	PolicyVersion int           `json:"policy_version"`
+	Input         policy.Input  `json:"input"`
+	Result        policy.Result `json:"result"`
+}
+
+// PolicyEvaluationsResponse is the response for
+// GET /api/purchases/{id}/policy-evaluations.
+type PolicyEvaluationsResponse struct {
+	Evaluations []config.PolicyEvaluation  `json:"evaluations"`
+	Approvals   []config.ExecutionApproval `json:"approvals"`
+	// ApprovalChain is the execution's approval chain with each stage's
+	// sign-offs, omitted when no chain applies.
+	ApprovalChain *ApprovalChainView `json:"approval_chain,omitempty"`
+}
+
+// getPurchasePolicy returns the policy in force.
+//
+// GET /api/purchase-policy.
+func (h *Handler) getPurchasePolicy(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	p, err := h.config.GetLatestPurchasePolicy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase policy: %w", err)
+	}
+	return &PurchasePolicyResponse{Policy: p}, nil
+}
+
+// listPurchasePolicyVersions returns the saved versions, newest first.
+//
+// GET /api/purchase-policy/versions?limit=.
+func (h *Handler) listPurchasePolicyVersions(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	limit := defaultPolicyVersionsLimit
+	if raw := req.QueryStringParameters["limit"]; raw != "" {
+		n, parseErr := strconv.Atoi(raw)
+		if parseErr != nil || n < 1 {
+			return nil, NewClientError(400, "limit must be a positive integer")
+		}
+		limit = n
+	}
+	versions, err := h.config.ListPurchasePolicies(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchase policies: %w", err)
+	}
+	return &PurchasePolicyVersionsResponse{Versions: versions}, nil
+}
+
+// updatePurchasePolicy saves the rules as a new policy version, which is in
+// force from the next evaluation. Rules that do not compile are rejected
+// whole.
+//
+// PUT /api/purchase-policy.
+func (h *Handler) updatePurchasePolicy(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "update", "config")
+	if err != nil {
+		return nil, err
+	}
+	var body UpdatePurchasePolicyRequest
+	if decodeErr := json.Unmarshal([]byte(req.Body), &body); decodeErr != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if _, compileErr := policy.Compile(body.Rules); compileErr != nil {
+		return nil, NewClientError(400, compileErr.Error())
+	}
+
+	p := &config.PurchasePolicy{
+		Rules:     body.Rules,
+		Comment:   strings.TrimSpace(body.Comment),
+		CreatedBy: session.Email,
+	}
+	if saveErr := h.config.CreatePurchasePolicy(ctx, p); saveErr != nil {
+		return nil, fmt.Errorf("failed to save purchase policy: %w", saveErr)
+	}
+	return &PurchasePolicyResponse{Policy: p}, nil
+}
+
+// dryRunPurchasePolicy evaluates rules, or the policy in force, against an
+// existing execution or a hand-written input without recording anything,
+// so an admin can see what a policy change would do before saving it.
+//
+// POST /api/purchase-policy/dry-run.
+func (h *Handler) dryRunPurchasePolicy(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "update", "config")
+	if err != nil {
+		return nil, err
+	}
+	var body PurchasePolicyDryRunRequest
+	if decodeErr := json.Unmarshal([]byte(req.Body), &body); decodeErr != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if (body.ExecutionID == "") == (body.Input == nil) {
+		return nil, NewClientError(400, "exactly one of execution_id and input is required")
+	}
+
+	rules, version, err := h.dryRunRules(ctx, body.Rules)
+	if err != nil {
+		return nil, err
+	}
+	engine, compileErr := policy.Compile(rules)
+	if compileErr != nil {
+		return nil, NewClientError(400, compileErr.Error())
+	}
+	in, err := h.dryRunInput(ctx, session, body)
+	if err != nil {
+		return nil, err
+	}
+	return &PurchasePolicyDryRunResponse{PolicyVersion: version, Input: in, Result: engine.Evaluate(ctx, in)}, nil
+}
+
+// dryRunRules returns the rules a dry run evaluates: the request's own, or
+// the policy in force with its version.
+func (h *Handler) dryRunRules(ctx context.Context, rules []policy.Rule) ([]policy.Rule, int, error) {
+	if rules != nil {
+		return rules, 0, nil
+	}
+	p, err := h.config.GetLatestPurchasePolicy(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get purchase policy: %w", err)
+	}
+	if p == nil {
+		return nil, 0, nil
+	}
+	return p.Rules, p.Version, nil
+}
+
+// dryRunInput builds the input a dry run evaluates. An execution is
+// described exactly as the gate would describe it, with the caller as the
+// acting user.
+func (h *Handler) dryRunInput(ctx context.Context, session *Session, body PurchasePolicyDryRunRequest) (policy.Input, error) {
+	if body.Input != nil {
+		return *body.Input, nil
+	}
+	stage := body.Stage
+	if stage == "" {
+		stage = policy.StageApproval
+	}
+	if stage != policy.StageApproval && stage != policy.StageExecution {
+		return policy.Input{}, NewClientError(400, "stage must be approval or execution")
+	}
+	if uuidErr := validateUUID(body.ExecutionID); uuidErr != nil {
+		return policy.Input{}, uuidErr
+	}
+	execution, err := h.config.GetExecutionByID(ctx, body.ExecutionID)
+	if errors.Is(err, config.ErrNotFound) {
+		return policy.Input{}, NewClientError(404, "execution not found")
+	}
+	if err != nil {
+		return policy.Input{}, fmt.Errorf("failed to get execution: %w", err)
+	}
+	if accessErr := h.requirePlanAccess(ctx, session, execution.PlanID); accessErr != nil {
+		return policy.Input{}, accessErr
+	}
+	actor := policy.Actor{UserID: session.UserID, Email: session.Email, Kind: policy.ActorUser}
+	return purchase.BuildPolicyInput(ctx, h.config, execution, stage, actor)
+}
+
+// getPurchasePolicyEvaluations returns the policy evaluations recorded
+// against an execution and the approver signatures it has collected, for
+// the History detail view.
+//
+// GET /api/purchases/{id}/policy-evaluations.
+func (h *Handler) getPurchasePolicyEvaluations(ctx context.Context, req *events.LambdaFunctionURLRequest, executionID string) (any, error) {
+	if err := validateUUID(executionID); err != nil {
+		return nil, err
+	}
+	session, err := h.requirePermission(ctx, req, "view", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	execution, err := h.config.GetExecutionByID(ctx, executionID)
+	if errors.Is(err, config.ErrNotFound) {
+		return nil, NewClientError(404, "execution not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution: %w", err)
+	}
+	if accessErr := h.requirePlanAccess(ctx, session, execution.PlanID); accessErr != nil {
+		return nil, accessErr
+	}
+
+	evals, err := h.config.ListPolicyEvaluations(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy evaluations: %w", err)
+	}
+	approvals, err := h.config.ListExecutionApprovals(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list execution approvals: %w", err)
+	}
+	chain, err := h.loadApprovalChainView(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyEvaluationsResponse{Evaluations: evals, Approvals: approvals, ApprovalChain: chain}, nil
+}
+
+// policyApprovalResponse maps the purchase policy's outcomes on an approval
+// path. An approval held for more approvers, or for the next stage of an
+// approval chain, succeeds with an "awaiting_approvals" status so the
+// approver is not shown an error; a policy or approval chain refusal is a
+// 403, a purchase the commitment budget refuses is a 409 naming the
+// budget and what remains of it, and one a change freeze holds back is a
+// 409 naming the freeze and when it ends. handled is false for any other error,
+// which the caller maps as before.
+func policyApprovalResponse(err error) (resp any, handled bool, respErr error) {
+	var pending *purchase.ApprovalsPendingError
+	if errors.As(err, &pending) {
+		return map[string]any{
+			"status":             "awaiting_approvals",
+			"approvals":          pending.Have,
+			"required_approvals": pending.Need,
+			"message":            pending.Error(),
+		}, true, nil
+	}
+	var stagePending *purchase.ApprovalStagePendingError
+	if errors.As(err, &stagePending) {
+		resp := map[string]any{
+			"status":             "awaiting_approvals",
+			"stage":              stagePending.Stage,
+			"stage_number":       stagePending.StageNumber,
+			"stages":             stagePending.Stages,
+			"approvals":          stagePending.Have,
+			"required_approvals": stagePending.Need,
+			"message":            stagePending.Error(),
+		}
+		if stagePending.EmailErr != nil {
+			resp["email_sent"] = false
+			resp["email_reason"] = stagePending.EmailErr.Error()
+		}
+		return resp, true, nil
+	}
+	var denied *purchase.PolicyDeniedError
+	if errors.As(err, &denied) {
+		return nil, true, NewClientError(403, denied.Error())
+	}
+	var chainErr *purchase.ApprovalChainError
+	if errors.As(err, &chainErr) {
+		return nil, true, NewClientError(403, chainErr.Error())
+	}
+	var exceeded *budget.ExceededError
+	if errors.As(err, &exceeded) {
+		return nil, true, NewClientError(409, exceeded.Error())
+	}
+	var frozen *freeze.FrozenError
+	if errors.As(err, &frozen) {
+		return nil, true, NewClientError(409, frozen.Error())
+	}
+	return nil, false, nil
+}
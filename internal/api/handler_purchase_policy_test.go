@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+const policyExecID = "11111111-1111-1111-1111-111111111111"
+
+func newPolicyHandler() (*Handler, *MockConfigStore, *MockAuthService) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", Email: "admin@test.com"}, nil)
+	authSvc.grantAdmin()
+	return &Handler{config: cfgStore, auth: authSvc}, cfgStore, authSvc
+}
+
+func TestGetPurchasePolicy(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	p := &config.PurchasePolicy{Version: 3, Rules: []policy.Rule{{ID: "a", Expression: "true", Effect: policy.EffectWarn}}}
+	cfgStore.On("GetLatestPurchasePolicy", mock.Anything).Return(p, nil)
+
+	got, err := h.getPurchasePolicy(context.Background(), marketplaceReq())
+	require.NoError(t, err)
+	assert.Equal(t, p, got.(*PurchasePolicyResponse).Policy)
+}
+
+func TestUpdatePurchasePolicy(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("CreatePurchasePolicy", mock.Anything, mock.MatchedBy(func(p *config.PurchasePolicy) bool {
+		return len(p.Rules) == 1 && p.CreatedBy == "admin@test.com" && p.Comment == "cap RDS"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*config.PurchasePolicy).Version = 4
+	}).Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"rules":[{"id":"big-rds","expression":"recommendations.filter(r, r.service == \"rds\").map(r, r.commitment).sum() > 50000","effect":"require_approvals","approvals":2}],"comment":" cap RDS "}`
+	got, err := h.updatePurchasePolicy(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 4, got.(*PurchasePolicyResponse).Policy.Version)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestUpdatePurchasePolicy_RejectsRulesThatDoNotCompile(t *testing.T) {
+	tests := []struct{ name, body, want string }{
+		{"syntax error", `{"rules":[{"id":"a","expression":"execution.","effect":"deny"}]}`, "rule a"},
+		{"non-bool", `{"rules":[{"id":"a","expression":"1 + 1","effect":"deny"}]}`, "must evaluate to a bool"},
+		{"approvals on deny", `{"rules":[{"id":"a","expression":"true","effect":"deny","approvals":2}]}`, "rule a"},
+		{"bad body", `{`, "invalid request body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, cfgStore, _ := newPolicyHandler()
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.updatePurchasePolicy(context.Background(), req)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, 400, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+			cfgStore.AssertNotCalled(t, "CreatePurchasePolicy", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdatePurchasePolicy_RequiresUpdateConfig(t *testing.T) {
+	cfgStore, authSvc := &MockConfigStore{}, &MockAuthService{}
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "viewer", Email: "viewer@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionView, Resource: auth.ResourceConfig}})
+	h := &Handler{config: cfgStore, auth: authSvc}
+
+	req := marketplaceReq()
+	req.Body = `{"rules":[]}`
+	_, err := h.updatePurchasePolicy(context.Background(), req)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 403, ce.code)
+	cfgStore.AssertNotCalled(t, "CreatePurchasePolicy", mock.Anything, mock.Anything)
+}
+
+func TestDryRunPurchasePolicy_InlineInput(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+
+	req := marketplaceReq()
+	req.Body = `{
+		"rules":[{"id":"no-sandbox-3yr","expression":"accounts.exists(a, a.name.contains(\"sandbox\")) && recommendations.exists(r, r.term == 3)","effect":"deny","message":"no 3-year in sandbox"}],
+		"input":{"stage":"approval","accounts":[{"id":"a1","name":"sandbox-1"}],"recommendations":[{"term":3,"payment":"all-upfront"}]}
+	}`
+	got, err := h.dryRunPurchasePolicy(context.Background(), req)
+	require.NoError(t, err)
+
+	resp := got.(*PurchasePolicyDryRunResponse)
+	assert.Equal(t, 0, resp.PolicyVersion)
+	assert.Equal(t, policy.OutcomeDeny, resp.Result.Outcome)
+	assert.Equal(t, "no-sandbox-3yr: no 3-year in sandbox", resp.Result.DenyReason())
+	cfgStore.AssertNotCalled(t, "RecordPolicyEvaluation", mock.Anything, mock.Anything)
+}
+
+func TestDryRunPurchasePolicy_ExecutionAgainstPolicyInForce(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("GetLatestPurchasePolicy", mock.Anything).Return(&config.PurchasePolicy{
+		Version: 5,
+		Rules:   []policy.Rule{{ID: "big", Expression: "execution.total_upfront_cost > 1000", Effect: policy.EffectRequireApprovals, Approvals: 3}},
+	}, nil)
+	cfgStore.On("GetExecutionByID", mock.Anything, policyExecID).Return(&config.PurchaseExecution{
+		ExecutionID: policyExecID, TotalUpfrontCost: 2500,
+	}, nil)
+
+	req := marketplaceReq()
+	req.Body = fmt.Sprintf(`{"execution_id":%q,"stage":"execution"}`, policyExecID)
+	got, err := h.dryRunPurchasePolicy(context.Background(), req)
+	require.NoError(t, err)
+
+	resp := got.(*PurchasePolicyDryRunResponse)
+	assert.Equal(t, 5, resp.PolicyVersion)
+	assert.Equal(t, policy.StageExecution, resp.Input.Stage)
+	assert.Equal(t, "admin@test.com", resp.Input.Actor.Email)
+	assert.Equal(t, policy.OutcomeRequireApprovals, resp.Result.Outcome)
+	assert.Equal(t, 3, resp.Result.RequiredApprovals)
+}
+
+func TestDryRunPurchasePolicy_Errors(t *testing.T) {
+	tests := []struct {
+		name, body, want string
+		code             int
+	}{
+		{"neither target", `{"rules":[]}`, "exactly one of execution_id and input", 400},
+		{"both targets", fmt.Sprintf(`{"execution_id":%q,"input":{}}`, policyExecID), "exactly one of execution_id and input", 400},
+		{"bad stage", fmt.Sprintf(`{"rules":[],"execution_id":%q,"stage":"later"}`, policyExecID), "stage must be", 400},
+		{"bad rules", `{"rules":[{"id":"a","expression":"(","effect":"deny"}],"input":{}}`, "rule a", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newPolicyHandler()
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.dryRunPurchasePolicy(context.Background(), req)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, tt.code, ce.code)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestGetPurchasePolicyEvaluations(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("GetExecutionByID", mock.Anything, policyExecID).Return(&config.PurchaseExecution{ExecutionID: policyExecID}, nil)
+	cfgStore.On("ListPolicyEvaluations", mock.Anything, policyExecID).Return([]config.PolicyEvaluation{
+		{ExecutionID: policyExecID, PolicyVersion: 2, Stage: "approval", Outcome: "require_approvals", RequiredApprovals: 2},
+	}, nil)
+	cfgStore.On("ListExecutionApprovals", mock.Anything, policyExecID).Return([]config.ExecutionApproval{
+		{ExecutionID: policyExecID, Approver: "lead@example.com", ApproverEmail: "lead@example.com"},
+	}, nil)
+
+	got, err := h.getPurchasePolicyEvaluations(context.Background(), marketplaceReq(), policyExecID)
+	require.NoError(t, err)
+	resp := got.(*PolicyEvaluationsResponse)
+	require.Len(t, resp.Evaluations, 1)
+	require.Len(t, resp.Approvals, 1)
+
+	_, err = h.getPurchasePolicyEvaluations(context.Background(), marketplaceReq(), "not-a-uuid")
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 400, ce.code)
+}
+
+func TestPolicyApprovalResponse(t *testing.T) {
+	resp, handled, err := policyApprovalResponse(fmt.Errorf("approve: %w", &purchase.ApprovalsPendingError{ExecutionID: policyExecID, Have: 1, Need: 2}))
+	require.True(t, handled)
+	require.NoError(t, err)
+	body := resp.(map[string]any)
+	assert.Equal(t, "awaiting_approvals", body["status"])
+	assert.Equal(t, 1, body["approvals"])
+	assert.Equal(t, 2, body["required_approvals"])
+
+	_, handled, err = policyApprovalResponse(&purchase.PolicyDeniedError{ExecutionID: policyExecID, Stage: "approval", PolicyVersion: 3, Reason: "no"})
+	require.True(t, handled)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 403, ce.code)
+
+	resp, handled, err = policyApprovalResponse(&purchase.ApprovalStagePendingError{ExecutionID: policyExecID, Stage: "FinOps", StageNumber: 2, Stages: 3, Have: 0, Need: 1})
+	require.True(t, handled)
+	require.NoError(t, err)
+	body = resp.(map[string]any)
+	assert.Equal(t, "awaiting_approvals", body["status"])
+	assert.Equal(t, "FinOps", body["stage"])
+	assert.Equal(t, 2, body["stage_number"])
+
+	_, handled, err = policyApprovalResponse(&purchase.ApprovalChainError{ExecutionID: policyExecID, Stage: "FinOps", Reason: "not an approver"})
+	require.True(t, handled)
+	ce, ok = IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 403, ce.code)
+
+	_, handled, _ = policyApprovalResponse(errors.New("cannot transition"))
+	assert.False(t, handled)
+}
+
+func TestApproveWithDelay_PolicyHoldsForMoreApprovers(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	pm := &MockPurchaseManager{}
+	pm.On("EnforceApprovalPolicy", mock.Anything, policyExecID, "lead@example.com", (*string)(nil)).
+		Return(&purchase.ApprovalsPendingError{ExecutionID: policyExecID, Have: 1, Need: 2})
+	h := &Handler{config: cfgStore, purchase: pm}
+
+	got, err := h.approveWithDelay(context.Background(), &config.PurchaseExecution{ExecutionID: policyExecID}, 0, "lead@example.com", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "awaiting_approvals", got.(map[string]any)["status"])
+	cfgStore.AssertNotCalled(t, "TransitionExecutionStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
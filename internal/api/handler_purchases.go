@@ -617,6 +617,9 @@ func (h *Handler) approveViaToken(ctx context.Context, req *events.LambdaFunctio
 	// has already happened, so the response surfaces "completed"
 	// instead of the transient "approved" the old no-op flow returned.
 	if err := h.purchase.ApproveExecution(ctx, execution.ExecutionID, token, actor); err != nil {
+		if resp, handled, respErr := policyApprovalResponse(err); handled {
+			return resp, respErr
+		}
 		return nil, err
 	}
 	// Re-fetch the execution to pick up the fresh revocation token written by
@@ -714,6 +717,9 @@ func (h *Handler) approvePurchaseViaSession(ctx context.Context, req *events.Lam
 	}
 
 	if err := h.purchase.ApproveAndExecute(ctx, execution.ExecutionID, fourEyesActorIdentity(session), actor); err != nil {
+		if resp, handled, respErr := policyApprovalResponse(err); handled {
+			return resp, respErr
+		}
 		// ApproveAndExecute returns either a transition error (the row
 		// drifted out of pending/notified between our check and the UPDATE
 		// -- race with cancel/expire) or an execution error (AWS API failed,
@@ -785,6 +791,23 @@ func (h *Handler) authorizeSessionApprove(ctx context.Context, session *Session,
 // Revoking a status=scheduled execution (via the revoke handler or the
 // History "Revoke" button) transitions it to "canceled" at zero cloud cost.
 func (h *Handler) approveWithDelay(ctx context.Context, execution *config.PurchaseExecution, delay time.Duration, actor string, transitionedBy *string) (any, error) {
+	// Scheduling skips ApproveAndExecute, so run its change freeze gate
+	// here: a manual approval inside a freeze needs an administrator's
+	// override rather than being deferred to the end of the window.
+	if err := h.purchase.CheckManualFreeze(ctx, execution.ExecutionID); err != nil {
+		if _, handled, respErr := policyApprovalResponse(err); handled {
+			return nil, respErr
+		}
+		return nil, fmt.Errorf("change freeze check failed: %w", err)
+	}
+	// Likewise its purchase policy gate; the fire-time executor re-checks
+	// the policy before buying.
+	if err := h.purchase.EnforceApprovalPolicy(ctx, execution.ExecutionID, actor, transitionedBy); err != nil {
+		if resp, handled, respErr := policyApprovalResponse(err); handled {
+			return resp, respErr
+		}
+		return nil, fmt.Errorf("purchase policy check failed: %w", err)
+	}
 	updated, err := h.scheduleApprovedExecution(ctx, execution, delay, actor, transitionedBy)
 	if err != nil {
 		if errors.Is(err, config.ErrExecutionNotInExpectedStatus) {
@@ -1641,7 +1664,7 @@ func (h *Handler) retryPurchase(ctx context.Context, req *events.LambdaFunctionU
 	// `failed` so the user sees the reason in History; the linkage on
 	// the original row is unaffected (it points at the failed-again
 	// successor, which is exactly the audit trail we want).
-	emailSent, emailReason, recipient := h.sendPurchaseApprovalEmail(ctx, req, newExecution, failedExec.Recommendations, totalUpfront, totalSavings)
+	emailSent, emailReason, recipient := h.sendApprovalRequest(ctx, req, newExecution, failedExec.Recommendations, totalUpfront, totalSavings)
 	status := h.finalizePurchaseStatus(ctx, newExecution, emailSent, emailReason)
 
 	resp := map[string]any{
@@ -2095,7 +2118,16 @@ func (h *Handler) getPurchaseDetails(ctx context.Context, req *events.LambdaFunc
 		}
 	}
 
-	return buildPurchaseDetailsResponse(execution, planName), nil
+	response := buildPurchaseDetailsResponse(execution, planName)
+	// The approval chain's stages and sign-offs, when one applies. A lookup
+	// failure is logged and the details still render.
+	chain, err := h.loadApprovalChainView(ctx, executionID)
+	if err != nil {
+		logging.Warnf("purchase[%s]: %v", executionID, err)
+	} else if chain != nil {
+		response["approval_chain"] = chain
+	}
+	return response, nil
 }
 
 // ExecutePurchaseRequest represents the request to execute purchases.
@@ -2309,6 +2341,9 @@ func validateExecutePurchaseRecommendations(recs []config.RecommendationRecord)
 		if err != nil {
 			return nil, err
 		}
+		if err = validateMarketplaceRecommendation(&recs[i], i); err != nil {
+			return nil, err
+		}
 		if adjustment != nil {
 			adjustments = append(adjustments, *adjustment)
 		}
@@ -2316,6 +2351,35 @@ func validateExecutePurchaseRecommendations(recs []config.RecommendationRecord)
 	return adjustments, nil
 }
 
+// validateMarketplaceRecommendation checks a rec that names an AWS Reserved
+// Instance Marketplace offering (ComputeDetails.MarketplaceOfferingID). Such a
+// rec buys that exact listing, so the offering ID must be well-formed, only
+// the AWS EC2 client can honour it, and a positive upfront cost is required:
+// the EC2 client sends it as the order's LimitPrice and refuses the purchase
+// without one. Recs without a marketplace offering pass through unchanged.
+func validateMarketplaceRecommendation(rec *config.RecommendationRecord, idx int) error {
+	if len(rec.Details) == 0 {
+		return nil
+	}
+	details, err := common.DecodeServiceDetailsFor(rec.Service, rec.Details)
+	if err != nil {
+		return NewClientError(400, fmt.Sprintf("recommendation %d has invalid details: %v", idx, err))
+	}
+	compute, ok := details.(*common.ComputeDetails)
+	if !ok || compute.MarketplaceOfferingID == "" {
+		return nil
+	}
+	switch {
+	case rec.Provider != string(common.ProviderAWS):
+		return NewClientError(400, fmt.Sprintf("recommendation %d: marketplace offerings are only supported for AWS EC2, got provider %s", idx, rec.Provider))
+	case !offeringIDPattern.MatchString(compute.MarketplaceOfferingID):
+		return NewClientError(400, fmt.Sprintf("recommendation %d has invalid marketplace_offering_id %q", idx, compute.MarketplaceOfferingID))
+	case rec.UpfrontCost <= 0:
+		return NewClientError(400, fmt.Sprintf("recommendation %d: a marketplace purchase needs a positive upfront_cost to bound the order price", idx))
+	}
+	return nil
+}
+
 // finalizePurchaseStatus flips an execution's stored status to "failed" if
 // the approval email couldn't send, and returns the status string the API
 // response should carry. Returns the original "pending" when email_sent is
@@ -2660,7 +2724,7 @@ func (h *Handler) executePurchase(ctx context.Context, req *events.LambdaFunctio
 	// best-effort and never blocks the response body; the returned
 	// email_sent / email_reason fields let the UI tell the user whether they
 	// should wait for an inbox or cancel/retry manually.
-	emailSent, emailReason, recipient := h.sendPurchaseApprovalEmail(ctx, req, execution, execReq.Recommendations, totalUpfront, totalSavings)
+	emailSent, emailReason, recipient := h.sendApprovalRequest(ctx, req, execution, execReq.Recommendations, totalUpfront, totalSavings)
 	status := h.finalizePurchaseStatus(ctx, execution, emailSent, emailReason)
 
 	return withPaymentAdjustments(buildApprovalPendingResponse(executionID, status, len(execReq.Recommendations), totalUpfront, totalSavings, emailSent, emailReason, recipient), paymentAdjustments), nil
@@ -2765,6 +2829,9 @@ func (h *Handler) directExecutePurchase(ctx context.Context, req *events.LambdaF
 	// transitioned_by (FK-safe via validUUIDPtrOrNil) so the audit trail
 	// records who flipped the row to "approved".
 	if err := h.purchase.ApproveAndExecute(ctx, executionID, fourEyesActorIdentity(session), validUUIDPtrOrNil(&session.UserID)); err != nil {
+		if resp, handled, respErr := policyApprovalResponse(err); handled {
+			return resp, respErr
+		}
 		logging.Errorf("purchase[%s]: directExecutePurchase failed after %s: %v",
 			executionID, time.Since(t0), err)
 		return nil, NewClientError(409, fmt.Sprintf("execution %s could not be direct-executed: %v", executionID, err))
@@ -2814,6 +2881,34 @@ func approvalResponseRecipient(globalNotify, to string) string {
 	return to
 }
 
+// sendApprovalRequest asks for approval of a newly created execution. When
+// an approval chain applies, the purchase manager starts it and emails the
+// first stage's approvers with that stage's own token, and the recipient is
+// the stage's first approver; otherwise the plain approval email goes out
+// via sendPurchaseApprovalEmail. A chain that started but could not email
+// its first stage reports the send error exactly as a failed plain email
+// does. A chain that fails to start is logged and falls back to the plain
+// email so the purchase is never left without an approval request.
+func (h *Handler) sendApprovalRequest(ctx context.Context, req *events.LambdaFunctionURLRequest, execution *config.PurchaseExecution, recs []config.RecommendationRecord, totalUpfront, totalSavings float64) (bool, string, string) { //nolint:gocritic // unnamedResult: mirrors sendPurchaseApprovalEmail
+	if h.purchase != nil {
+		run, err := h.purchase.StartApprovalChain(ctx, execution.ExecutionID)
+		var emailErr *purchase.ApprovalStageEmailError
+		switch {
+		case errors.As(err, &emailErr):
+			recipient := ""
+			if run != nil {
+				recipient = run.Stages[run.CurrentStage].Approvers[0]
+			}
+			return false, emailErr.Error(), recipient
+		case err != nil:
+			logging.Errorf("purchase[%s]: failed to start approval chain, sending the plain approval email: %v", execution.ExecutionID, err)
+		case run != nil && !run.Completed():
+			return true, "", run.Stages[run.CurrentStage].Approvers[0]
+		}
+	}
+	return h.sendPurchaseApprovalEmail(ctx, req, execution, recs, totalUpfront, totalSavings)
+}
+
 // sendPurchaseApprovalEmail sends an approval-request email for a newly created
 // execution and returns a structured outcome:
 //   - (true, "", recipient) on successful send
@@ -3289,8 +3384,18 @@ func (h *Handler) authorizeApprovalAction(ctx context.Context, req *events.Lambd
 		return "", NewClientError(401, "sign in with the account's contact email to approve or cancel this purchase")
 	}
 
+	// Approvers of the approval-chain stage awaiting sign-off act on the
+	// purchase alongside the account contacts.
+	stageApprover, err := purchase.IsActiveStageApprover(ctx, h.config, execution.ExecutionID, actor)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve approvers: %w", err)
+	}
+	if stageApprover {
+		return actor, nil
+	}
+
 	globalNotify := ""
-	if cfg, err := h.config.GetGlobalConfig(ctx); err == nil && cfg != nil && cfg.NotificationEmail != nil {
+	if cfg, cfgErr := h.config.GetGlobalConfig(ctx); cfgErr == nil && cfg != nil && cfg.NotificationEmail != nil {
 		globalNotify = *cfg.NotificationEmail
 	}
 	_, _, approvers, err := h.resolveApprovalRecipients(ctx, execution.Recommendations, globalNotify)
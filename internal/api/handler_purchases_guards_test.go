@@ -159,6 +159,52 @@ func TestValidatePurchaseRecommendation(t *testing.T) {
 	}
 }
 
+func TestValidateMarketplaceRecommendation(t *testing.T) {
+	t.Parallel()
+	const offerID = "11111111-2222-3333-4444-555555555555"
+	marketplace := func(f func(r *config.RecommendationRecord)) config.RecommendationRecord {
+		r := validRec()
+		r.UpfrontCost = 250
+		r.Details = []byte(`{"marketplace_offering_id":"` + offerID + `"}`)
+		if f != nil {
+			f(&r)
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		rec     config.RecommendationRecord
+		wantErr string
+	}{
+		{"no details", validRec(), ""},
+		{"ordinary compute details", func() config.RecommendationRecord {
+			r := validRec()
+			r.Details = []byte(`{"platform":"Linux/UNIX"}`)
+			return r
+		}(), ""},
+		{"valid marketplace rec", marketplace(nil), ""},
+		{"non-AWS provider", marketplace(func(r *config.RecommendationRecord) { r.Provider = "azure" }), "only supported for AWS EC2"},
+		{"malformed offering id", marketplace(func(r *config.RecommendationRecord) {
+			r.Details = []byte(`{"marketplace_offering_id":"not-an-offering"}`)
+		}), "invalid marketplace_offering_id"},
+		{"no upfront cost", marketplace(func(r *config.RecommendationRecord) { r.UpfrontCost = 0 }), "positive upfront_cost"},
+		{"undecodable details", marketplace(func(r *config.RecommendationRecord) { r.Details = []byte(`[`) }), "invalid details"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := tt.rec
+			err := validateMarketplaceRecommendation(&rec, 0)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 // TestValidatePurchaseRecommendation_ErrorMessage verifies that a mismatched
 // (provider, payment-option) pair produces a 400 error whose message names the
 // provider and lists the valid options, matching the plan-validator shape
@@ -6,9 +6,13 @@ package api
 //
 // Per-provider support:
 //
-//   - Azure reservations: return via armreservations.ReturnClient within a 7-day
-//     window. The button is shown in the History UI for Azure rows inside the
-//     window. Requires CalculateRefund first (to get the session ID) then Return.
+//   - Azure reservations: return via the providers/azure/reservations client
+//     within a 7-day window. The button is shown in the History UI for Azure
+//     rows inside the window. Requires CalculateRefund first (to get the
+//     session ID) then Return. Returns count against Azure's rolling 12-month
+//     refund limit per billing profile; every refund CUDly issues is recorded
+//     in azure_refund_ledger and a return that would exceed the limit is
+//     rejected with 422 before Azure is asked to commit it.
 //
 //   - AWS EC2 RIs / Savings Plans: AWS does not expose a direct cancel API for
 //     purchased RIs. Revocation requires an AWS Support case
@@ -32,7 +36,9 @@ import (
 	armreservations "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
 	"github.com/LeanerCloud/CUDly/internal/auth"
 	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
+	azurereservations "github.com/LeanerCloud/CUDly/providers/azure/reservations"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/jackc/pgx/v5"
 )
@@ -57,10 +63,37 @@ const azureRefundSafetyMargin = 1 * time.Hour
 
 // revokeQuoteResult is the JSON body returned by
 // GET /api/purchases/revoke/calculate/{id}.
+//
+// The refund-limit fields describe the billing profile's rolling 12-month
+// Azure refund limit and are omitted when it cannot be determined (see
+// azureRefundLimitStatus). PolicyErrors is non-empty when Azure's refund
+// policy would reject the return; POST /revoke then fails with 422.
 type revokeQuoteResult struct {
-	RefundCurrency string  `json:"refund_currency"`
-	QuotedAt       string  `json:"quoted_at"`
-	RefundAmount   float64 `json:"refund_amount"`
+	RefundCurrency       string   `json:"refund_currency"`
+	QuotedAt             string   `json:"quoted_at"`
+	RefundAmount         float64  `json:"refund_amount"`
+	RefundLimit          *float64 `json:"refund_limit,omitempty"`
+	RefundsConsumed      *float64 `json:"refunds_consumed,omitempty"`
+	RefundLimitRemaining *float64 `json:"refund_limit_remaining,omitempty"`
+	PolicyErrors         []string `json:"policy_errors,omitempty"`
+}
+
+// azureRefundLimitWindowYears is the length of the rolling window Azure
+// applies its refund limit over.
+const azureRefundLimitWindowYears = 1
+
+// azureRefundLimitStatus is the billing profile's position against Azure's
+// rolling 12-month refund limit, in the quote's currency. Consumed is the
+// larger of CUDly's own ledger total and the total Azure reported, since
+// refunds issued outside CUDly only show up in the latter. Limit and
+// Remaining are nil when neither Azure nor DefaultRefundLimitUSD gives a
+// limit in that currency, in which case only Azure's own policy check applies.
+type azureRefundLimitStatus struct {
+	BillingProfile string
+	Currency       string
+	Consumed       float64
+	Limit          *float64
+	Remaining      *float64
 }
 
 // revokeConfirmBody is the JSON body expected on
@@ -412,9 +445,10 @@ func (h *Handler) checkRevokeOwnAccountAccess(ctx context.Context, userID string
 //
 // This is the first step of the two-step quote-then-confirm revoke UX
 // (issue #290 Finding #4). No state is mutated; the result is used by the
-// frontend to populate revokeConfirmBody.ExpectedRefundAmount.
+// frontend to populate revokeConfirmBody.ExpectedRefundAmount and to warn
+// before a return that the refund limit or Azure's refund policy would block.
 func (h *Handler) calculateAzureRevoke(ctx context.Context, req *events.LambdaFunctionURLRequest, purchaseID string) (any, error) {
-	_, orderID, reservationID, count, err := h.validateAzureRevokeRequest(ctx, req, purchaseID)
+	record, orderID, reservationID, count, err := h.validateAzureRevokeRequest(ctx, req, purchaseID)
 	if err != nil {
 		return nil, err
 	}
@@ -425,28 +459,37 @@ func (h *Handler) calculateAzureRevoke(ctx context.Context, req *events.LambdaFu
 	}
 
 	quantity := int32(count) // #nosec G115 -- Azure reservation count bounded by API limits (<<math.MaxInt32) //nolint:gosec
-	calcResp, err := calcClient.Post(ctx, orderID, armreservations.CalculateRefundRequest{
-		Properties: &armreservations.CalculateRefundRequestProperties{
-			ReservationToReturn: &armreservations.ReservationToReturn{
-				ReservationID: &reservationID,
-				Quantity:      &quantity,
-			},
-			Scope: toPtr("Reservation"),
-		},
-	}, nil)
+	quote, err := azurereservations.NewClientWithAPIs(calcClient, nil).CalculateRefund(ctx, azurereservations.ReturnTarget{
+		ReservationOrderID: orderID,
+		ReservationID:      reservationID,
+		Quantity:           quantity,
+	})
 	if err != nil {
 		if isAzureClientError(err) {
 			return nil, NewClientError(400, fmt.Sprintf("Azure refund calculation rejected: %v", err))
 		}
-		return nil, fmt.Errorf("revoke/calculate: CalculateRefund failed: %w", err)
+		return nil, fmt.Errorf("revoke/calculate: %w", err)
 	}
 
-	refundAmount, refundCurrency := extractAzureRefundQuote(calcResp)
-	return &revokeQuoteResult{
-		RefundAmount:   refundAmount,
-		RefundCurrency: refundCurrency,
+	result := &revokeQuoteResult{
+		RefundCurrency: quote.Currency,
 		QuotedAt:       time.Now().UTC().Format(time.RFC3339),
-	}, nil
+		PolicyErrors:   quote.PolicyErrors,
+	}
+	if quote.Amount != nil {
+		result.RefundAmount = *quote.Amount
+	}
+	// The limit fields are informational here: POST /revoke re-checks them
+	// against a fresh quote, so a ledger read failure only drops them.
+	limit, err := h.azureRefundLimit(ctx, record, quote)
+	if err != nil {
+		logging.Warnf("revoke/calculate: refund limit for %s unavailable: %v", purchaseID, err)
+		return result, nil
+	}
+	result.RefundsConsumed = toPtr(limit.Consumed)
+	result.RefundLimit = limit.Limit
+	result.RefundLimitRemaining = limit.Remaining
+	return result, nil
 }
 
 // validateAzureRevokeRequest runs the shared preflight for the Azure
@@ -520,23 +563,6 @@ func azureRevokeWindowAndIDs(record *config.PurchaseHistoryRecord) (string, stri
 	return orderID, reservationID, nil
 }
 
-// extractAzureRefundQuote pulls the refund amount and currency out of a
-// CalculateRefund response, guarding every nil pointer in the chain. Returns
-// zero values when the response carries no billing-refund amount.
-func extractAzureRefundQuote(resp armreservations.CalculateRefundClientPostResponse) (float64, string) { //nolint:gocritic // unnamedResult: return names would conflict with body locals
-	var refundAmount float64
-	var refundCurrency string
-	if resp.Properties != nil && resp.Properties.BillingRefundAmount != nil {
-		if resp.Properties.BillingRefundAmount.Amount != nil {
-			refundAmount = *resp.Properties.BillingRefundAmount.Amount
-		}
-		if resp.Properties.BillingRefundAmount.CurrencyCode != nil {
-			refundCurrency = *resp.Properties.BillingRefundAmount.CurrencyCode
-		}
-	}
-	return refundAmount, refundCurrency
-}
-
 // revokeAzurePurchase handles Azure reservation returns via the Azure
 // Reservations API (CalculateRefund + Return). The reservation order ID and
 // reservation ID are parsed from the purchase_id ARM resource path stored at
@@ -646,9 +672,15 @@ func (h *Handler) callAzureReturn(
 		return nil, NewClientError(422, "cannot determine Azure reservation ID from purchase record; contact Azure Support to request a refund")
 	}
 
+	client := azurereservations.NewClientWithAPIs(calcClient, returnClient)
+	target := azurereservations.ReturnTarget{
+		ReservationOrderID: orderID,
+		ReservationID:      reservationID,
+		Quantity:           int32(record.Count), // #nosec G115 -- Azure reservation count validated at purchase; bounded by API limits (<<math.MaxInt32) //nolint:gosec
+	}
+
 	// Step 1: CalculateRefund -> sessionID + quoted amount (TOCTOU check).
-	quantity := int32(record.Count) // #nosec G115 -- Azure reservation count validated at purchase; bounded by API limits (<<math.MaxInt32) //nolint:gosec
-	sessionID, calcRefundAmount, calcRefundCurrency, err := h.azureCalculateRefund(ctx, calcClient, orderID, reservationID, quantity)
+	quote, err := h.azureCalculateRefund(ctx, client, target)
 	if err != nil {
 		return nil, err
 	}
@@ -658,15 +690,21 @@ func (h *Handler) callAzureReturn(
 	// CalculateRefund response within epsilon. A mismatch means Azure's refund
 	// quote changed between the user's confirmation and the actual call (e.g.
 	// partial return already submitted, time-based fee tier changed).
-	if expectedRefundAmount != nil && calcRefundAmount != nil {
-		if math.Abs(*expectedRefundAmount-*calcRefundAmount) > revokeQuoteEpsilon {
+	if expectedRefundAmount != nil && quote.Amount != nil {
+		if math.Abs(*expectedRefundAmount-*quote.Amount) > revokeQuoteEpsilon {
 			return nil, NewClientError(422, fmt.Sprintf(
 				"refund amount diverged: you confirmed %.2f but Azure now quotes %.2f %s; re-confirm to proceed",
-				*expectedRefundAmount, *calcRefundAmount, calcRefundCurrency,
+				*expectedRefundAmount, *quote.Amount, quote.Currency,
 			))
 		}
 	}
 
+	// Rolling refund-limit check: fail closed before anything is committed.
+	limit, err := h.checkAzureRefundLimit(ctx, record, quote)
+	if err != nil {
+		return nil, err
+	}
+
 	// Partial-success guard (issue #290 Finding #6): flip the in-flight flag
 	// BEFORE calling Azure Return so that if the subsequent MarkPurchaseRevoked
 	// write fails, the row is visible to the finalize_revocations sweep rather
@@ -677,62 +715,132 @@ func (h *Handler) callAzureReturn(
 	}
 
 	// Step 2: Return (post the actual refund request).
-	_, err = returnClient.Post(ctx, orderID, armreservations.RefundRequest{
-		Properties: &armreservations.RefundRequestProperties{
-			ReservationToReturn: &armreservations.ReservationToReturn{
-				ReservationID: &reservationID,
-				Quantity:      &quantity,
-			},
-			SessionID:    &sessionID,
-			ReturnReason: toPtr("Revoked via CUDly within free-cancel window"),
-			Scope:        toPtr("Reservation"),
-		},
-	}, nil)
+	refund, err := client.Return(ctx, target, quote.SessionID, "Revoked via CUDly within free-cancel window")
 	if err != nil {
 		return nil, h.handleAzureReturnError(ctx, record, err)
 	}
 
-	return h.persistAzureRevocation(ctx, record, calcRefundAmount, calcRefundCurrency)
+	h.recordAzureRefund(ctx, record, limit, quote, refund)
+	return h.persistAzureRevocation(ctx, record, quote.Amount, quote.Currency)
 }
 
-// azureCalculateRefund runs the CalculateRefund step and parses out the session
-// ID (required by Return) and the quoted refund amount/currency (for the TOCTOU
+// azureCalculateRefund runs the CalculateRefund step, which yields the session
+// ID required by Return and the quoted refund amount/currency (for the TOCTOU
 // check). Errors are classified into 400 (client) vs 500 (transient).
-func (h *Handler) azureCalculateRefund(ctx context.Context, calcClient azureCalculateRefundClient, orderID, reservationID string, quantity int32) (string, *float64, string, error) { //nolint:gocritic // unnamedResult: return names would conflict with body locals
-	calcResp, err := calcClient.Post(ctx, orderID, armreservations.CalculateRefundRequest{
-		Properties: &armreservations.CalculateRefundRequestProperties{
-			ReservationToReturn: &armreservations.ReservationToReturn{
-				ReservationID: &reservationID,
-				Quantity:      &quantity,
-			},
-			Scope: toPtr("Reservation"),
-		},
-	}, nil)
+func (h *Handler) azureCalculateRefund(ctx context.Context, client *azurereservations.Client, target azurereservations.ReturnTarget) (*azurereservations.RefundQuote, error) {
+	quote, err := client.CalculateRefund(ctx, target)
 	if err != nil {
 		if isAzureClientError(err) {
-			return "", nil, "", NewClientError(400, fmt.Sprintf("Azure refund calculation rejected: %v", err))
+			return nil, NewClientError(400, fmt.Sprintf("Azure refund calculation rejected: %v", err))
 		}
-		return "", nil, "", fmt.Errorf("revoke azure: CalculateRefund failed: %w", err)
+		return nil, fmt.Errorf("revoke azure: %w", err)
+	}
+	if quote.SessionID == "" {
+		return nil, fmt.Errorf("revoke azure: CalculateRefund returned no session ID for %s", target.ReservationOrderID)
 	}
+	return quote, nil
+}
 
-	var sessionID string
-	var calcRefundAmount *float64
-	var calcRefundCurrency string
-	if calcResp.Properties != nil {
-		if calcResp.Properties.SessionID != nil {
-			sessionID = *calcResp.Properties.SessionID
-		}
-		if calcResp.Properties.BillingRefundAmount != nil {
-			if calcResp.Properties.BillingRefundAmount.Amount != nil {
-				v := *calcResp.Properties.BillingRefundAmount.Amount
-				calcRefundAmount = &v
-			}
-			if calcResp.Properties.BillingRefundAmount.CurrencyCode != nil {
-				calcRefundCurrency = *calcResp.Properties.BillingRefundAmount.CurrencyCode
-			}
+// checkAzureRefundLimit rejects the return with 422 when Azure's refund
+// policy already blocks it, or when the quoted refund would take the billing
+// profile past its rolling 12-month refund limit. It fails closed: a ledger
+// read error aborts the revoke with a 500 rather than refunding unchecked.
+func (h *Handler) checkAzureRefundLimit(ctx context.Context, record *config.PurchaseHistoryRecord, quote *azurereservations.RefundQuote) (*azureRefundLimitStatus, error) {
+	if len(quote.PolicyErrors) > 0 {
+		return nil, NewClientError(422, fmt.Sprintf("Azure refund policy rejects this return: %s", strings.Join(quote.PolicyErrors, "; ")))
+	}
+	limit, err := h.azureRefundLimit(ctx, record, quote)
+	if err != nil {
+		return nil, fmt.Errorf("revoke azure: %w", err)
+	}
+	if quote.Amount == nil || limit.Remaining == nil {
+		return limit, nil
+	}
+	if *quote.Amount > *limit.Remaining+revokeQuoteEpsilon {
+		return nil, NewClientError(422, fmt.Sprintf(
+			"refund of %.2f %s exceeds the remaining Azure refund limit: %.2f of %.2f %s already refunded in the last 12 months",
+			*quote.Amount, limit.Currency, limit.Consumed, *limit.Limit, limit.Currency,
+		))
+	}
+	return limit, nil
+}
+
+// azureRefundLimit computes the billing profile's position against Azure's
+// rolling refund limit in the quote's currency. Ledger refunds in other
+// currencies are not converted, so they do not count.
+func (h *Handler) azureRefundLimit(ctx context.Context, record *config.PurchaseHistoryRecord, quote *azurereservations.RefundQuote) (*azureRefundLimitStatus, error) {
+	status := &azureRefundLimitStatus{
+		BillingProfile: h.azureBillingProfile(ctx, record),
+		Currency:       quote.Currency,
+	}
+	since := time.Now().UTC().AddDate(-azureRefundLimitWindowYears, 0, 0)
+	consumed, err := h.config.SumAzureRefunds(ctx, status.BillingProfile, status.Currency, since)
+	if err != nil {
+		return nil, fmt.Errorf("read refund ledger: %w", err)
+	}
+	status.Consumed = consumed
+
+	azureInCurrency := quote.LimitCurrency == "" || strings.EqualFold(quote.LimitCurrency, quote.Currency)
+	if azureInCurrency && quote.ConsumedRefundsTotal != nil && *quote.ConsumedRefundsTotal > status.Consumed {
+		status.Consumed = *quote.ConsumedRefundsTotal
+	}
+	switch {
+	case azureInCurrency && quote.MaxRefundLimit != nil:
+		status.Limit = toPtr(*quote.MaxRefundLimit)
+	case quote.Currency == "" || strings.EqualFold(quote.Currency, "USD"):
+		status.Limit = toPtr(azurereservations.DefaultRefundLimitUSD)
+	}
+	if status.Limit != nil {
+		status.Remaining = toPtr(math.Max(*status.Limit-status.Consumed, 0))
+	}
+	return status, nil
+}
+
+// azureBillingProfile returns the key refunds are tracked under in
+// azure_refund_ledger: the Azure tenant of the purchase's registered cloud
+// account, or the purchase's account (subscription) ID when the purchase is
+// not attributed to a registered account or the account has no tenant.
+func (h *Handler) azureBillingProfile(ctx context.Context, record *config.PurchaseHistoryRecord) string {
+	if record.CloudAccountID == nil || *record.CloudAccountID == "" {
+		return record.AccountID
+	}
+	account, err := h.config.GetCloudAccount(ctx, *record.CloudAccountID)
+	if err != nil {
+		logging.Warnf("revoke azure: load cloud account %s for refund ledger (falling back to account ID): %v", *record.CloudAccountID, err)
+		return record.AccountID
+	}
+	if account == nil || account.AzureTenantID == "" {
+		return record.AccountID
+	}
+	return account.AzureTenantID
+}
+
+// recordAzureRefund adds an issued refund to azure_refund_ledger. Azure has
+// already committed the refund, so a failed write is logged rather than
+// surfaced; Azure's own ConsumedRefundsTotal still covers it on the next
+// limit check.
+func (h *Handler) recordAzureRefund(ctx context.Context, record *config.PurchaseHistoryRecord, limit *azureRefundLimitStatus, quote *azurereservations.RefundQuote, refund *azurereservations.RefundResult) {
+	amount, currency := quote.Amount, quote.Currency
+	if refund.Amount != nil {
+		amount = refund.Amount
+		if refund.Currency != "" {
+			currency = refund.Currency
 		}
 	}
-	return sessionID, calcRefundAmount, calcRefundCurrency, nil
+	if amount == nil {
+		logging.Warnf("revoke azure: no refund amount reported for %s; not recorded in refund ledger", record.PurchaseID)
+		return
+	}
+	err := h.config.SaveAzureRefund(ctx, &config.AzureRefundRecord{
+		BillingProfile: limit.BillingProfile,
+		PurchaseID:     record.PurchaseID,
+		Amount:         *amount,
+		Currency:       currency,
+		RefundedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		logging.Errorf("revoke azure: SaveAzureRefund failed for %s (Azure already returned): %v", record.PurchaseID, err)
+	}
 }
 
 // handleAzureReturnError clears the in-flight flag (no refund was issued) and
@@ -793,6 +901,13 @@ func (h *Handler) persistAzureRevocation(ctx context.Context, record *config.Pur
 	// PII policy: log execution and account IDs only, not user identifiers.
 	logging.Infof("revoke azure: purchase_id=%s account_id=%s revoked_via=direct-api", record.PurchaseID, record.AccountID)
 
+	// The returned reservation no longer counts against the commitment
+	// budget. Best-effort: the refund has happened either way, and a
+	// missed credit only leaves the budget more conservative.
+	if err := purchase.CreditRevokedPurchase(ctx, h.config, record); err != nil {
+		logging.Errorf("revoke azure: %v", err)
+	}
+
 	return &revokePurchaseResult{
 		Status:     "revoked",
 		RevokedAt:  now.Format(time.RFC3339),
@@ -881,5 +996,5 @@ func isAzureWindowEdgeError(err error) bool {
 }
 
 // toPtr returns a pointer to its argument. Generic helper used by the Azure
-// revocation call-site to construct ARM struct fields without temp variables.
+// revocation path to fill optional fields without temp variables.
 func toPtr[T any](v T) *T { return &v }
@@ -1405,6 +1405,10 @@ func TestCalculateAzureRevoke_Success(t *testing.T) {
 	require.True(t, ok)
 	assert.InDelta(t, 75.25, quote.RefundAmount, 0.0001)
 	assert.Equal(t, "USD", quote.RefundCurrency)
+	// No refunds recorded and no limit reported by Azure: the USD default applies.
+	require.NotNil(t, quote.RefundLimitRemaining)
+	assert.InDelta(t, 50000.0, *quote.RefundLimitRemaining, 0.0001)
+	assert.Empty(t, quote.PolicyErrors)
 	assert.Equal(t, 1, credentialCalls)
 	assert.Equal(t, 1, calculateClientCalls)
 	assert.Zero(t, returnClientCalls, "calculate must not construct an unused Return client")
@@ -1589,3 +1593,144 @@ func TestRevokePurchase_ConcurrentScheduledRevoke_OneWinsOneGets410(t *testing.T
 		assert.Equal(t, 410, ce.code, "second concurrent revoke must return 410")
 	})
 }
+
+// --- Azure rolling refund limit ---
+
+// refundPolicyResp builds a CalculateRefund response quoting amount USD with
+// the given refund-policy limit, consumed total, and policy error messages.
+func refundPolicyResp(amount, limit, consumed float64, policyErrors ...string) armreservations.CalculateRefundClientPostResponse {
+	usd := "USD"
+	policy := &armreservations.RefundPolicyResultProperty{
+		MaxRefundLimit:       &armreservations.Price{Amount: &limit, CurrencyCode: &usd},
+		ConsumedRefundsTotal: &armreservations.Price{Amount: &consumed, CurrencyCode: &usd},
+	}
+	for _, msg := range policyErrors {
+		policy.PolicyErrors = append(policy.PolicyErrors, &armreservations.RefundPolicyError{Message: toPtr(msg)})
+	}
+	return armreservations.CalculateRefundClientPostResponse{
+		CalculateRefundResponse: armreservations.CalculateRefundResponse{
+			Properties: &armreservations.RefundResponseProperties{
+				SessionID:           toPtr("s-limit"),
+				BillingRefundAmount: &armreservations.Price{Amount: &amount, CurrencyCode: &usd},
+				PolicyResult:        &armreservations.RefundPolicyResult{Properties: policy},
+			},
+		},
+	}
+}
+
+// TestCallAzureReturn_RefundLimitExceeded verifies a return Azure reports as
+// past the billing profile's rolling limit is rejected with 422 before the
+// in-flight flag is flipped or Return is called.
+func TestCallAzureReturn_RefundLimitExceeded(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+	calcClient := &stubCalcRefundClient{resp: refundPolicyResp(3000, 50000, 48000)}
+	returnClient := &stubReturnClient{}
+
+	h := &Handler{config: mockStore}
+	_, err := h.callAzureReturn(ctx, calcClient, returnClient, armReservationRecord(), "order-abc", "res-xyz", nil)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 422, ce.code)
+	assert.Contains(t, ce.message, "exceeds the remaining Azure refund limit")
+	assert.Zero(t, returnClient.calls)
+	mockStore.AssertNotCalled(t, "FlipPurchaseRevocationInFlight", mock.Anything, mock.Anything)
+}
+
+// TestCallAzureReturn_RefundLimitFromLedger verifies the ledger total counts
+// against the default USD limit when Azure reports no refund policy.
+func TestCallAzureReturn_RefundLimitFromLedger(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+	r := armReservationRecord()
+	mockStore.On("SumAzureRefunds", ctx, r.AccountID, "USD", mock.AnythingOfType("time.Time")).Return(49000.0, nil)
+	calcClient := &stubCalcRefundClientWithAmount{amount: 2000, currency: "USD", sessID: "s-ledger"}
+	returnClient := &stubReturnClient{}
+
+	h := &Handler{config: mockStore}
+	_, err := h.callAzureReturn(ctx, calcClient, returnClient, r, "order-abc", "res-xyz", nil)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 422, ce.code)
+	assert.Contains(t, ce.message, "49000.00 of 50000.00 USD")
+	assert.Zero(t, returnClient.calls)
+}
+
+// TestCallAzureReturn_RefundPolicyErrors verifies a quote carrying Azure
+// refund-policy errors is rejected with 422 even when the amount fits.
+func TestCallAzureReturn_RefundPolicyErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+	calcClient := &stubCalcRefundClient{resp: refundPolicyResp(100, 50000, 0, "Reservation is not eligible for refund")}
+	returnClient := &stubReturnClient{}
+
+	h := &Handler{config: mockStore}
+	_, err := h.callAzureReturn(ctx, calcClient, returnClient, armReservationRecord(), "order-abc", "res-xyz", nil)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 422, ce.code)
+	assert.Contains(t, ce.message, "not eligible for refund")
+	assert.Zero(t, returnClient.calls)
+}
+
+// TestCallAzureReturn_LedgerReadFailureFailsClosed verifies the revoke is
+// aborted with a server error when the refund ledger cannot be read.
+func TestCallAzureReturn_LedgerReadFailureFailsClosed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+	r := armReservationRecord()
+	mockStore.On("SumAzureRefunds", ctx, r.AccountID, "USD", mock.AnythingOfType("time.Time")).Return(0.0, errors.New("db down"))
+	calcClient := &stubCalcRefundClientWithAmount{amount: 10, currency: "USD", sessID: "s-db"}
+	returnClient := &stubReturnClient{}
+
+	h := &Handler{config: mockStore}
+	_, err := h.callAzureReturn(ctx, calcClient, returnClient, r, "order-abc", "res-xyz", nil)
+	require.Error(t, err)
+	_, isClientErr := IsClientError(err)
+	assert.False(t, isClientErr)
+	assert.Zero(t, returnClient.calls)
+}
+
+// TestCallAzureReturn_RecordsRefundInLedger verifies a successful return is
+// recorded in the ledger under the registered cloud account's tenant.
+func TestCallAzureReturn_RecordsRefundInLedger(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+	r := armReservationRecord()
+	r.CloudAccountID = toPtr("acct-uuid")
+	mockStore.On("GetCloudAccount", ctx, "acct-uuid").Return(&config.CloudAccount{ID: "acct-uuid", Provider: "azure", AzureTenantID: "tenant-1"}, nil)
+	mockStore.On("SumAzureRefunds", ctx, "tenant-1", "EUR", mock.AnythingOfType("time.Time")).Return(100.0, nil)
+	mockStore.On("SaveAzureRefund", ctx, mock.MatchedBy(func(rec *config.AzureRefundRecord) bool {
+		return rec.BillingProfile == "tenant-1" && rec.PurchaseID == r.PurchaseID && rec.Amount == 42.50 && rec.Currency == "EUR"
+	})).Return(nil).Once()
+	mockStore.On("MarkPurchaseRevoked", ctx, r.PurchaseID, mock.AnythingOfType("time.Time"), "direct-api", "", mock.Anything, mock.Anything).Return(nil)
+
+	calcClient := &stubCalcRefundClientWithAmount{amount: 42.50, currency: "EUR", sessID: "s-ledger"}
+	returnClient := &stubReturnClient{}
+
+	h := &Handler{config: mockStore}
+	result, err := h.callAzureReturn(ctx, calcClient, returnClient, r, "order-abc", "res-xyz", nil)
+	require.NoError(t, err)
+	m, ok := result.(*revokePurchaseResult)
+	require.True(t, ok)
+	assert.Equal(t, "revoked", m.Status)
+	assert.Equal(t, 1, returnClient.calls)
+}
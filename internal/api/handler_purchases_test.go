@@ -4690,7 +4690,10 @@ func TestApproveWithDelay_CASLostMaps409(t *testing.T) {
 	mockConfig.On("TransitionExecutionStatus", ctx, execID, []string{"pending", "notified"}, "scheduled", mock.Anything).
 		Return(nil, concurrentCancelErr)
 
-	handler := &Handler{config: mockConfig}
+	mockPurchase := new(MockPurchaseManager)
+	mockPurchase.On("EnforceApprovalPolicy", ctx, execID, "actor@example.com", (*string)(nil)).Return(nil)
+
+	handler := &Handler{config: mockConfig, purchase: mockPurchase}
 
 	_, err := handler.approveWithDelay(ctx, exec, 48*time.Hour, "actor@example.com", nil)
 	require.Error(t, err)
@@ -36,6 +36,9 @@ import (
 // min_savings_pct: effective savings percentage floor (0-100 scale).
 // Both are optional; absent or "0" means no floor. Fractions are rejected (a
 // user typing "30%" expects a percentage, not $30.5).
+//
+// source: "vendor" or "native" keeps only the recs that engine produced
+// (see config.RecommendationRecord.Source); absent means both.
 func parseRecommendationFilter(params map[string]string) (config.RecommendationFilter, error) {
 	// Validate input parameters to prevent injection attacks.
 	if err := validateProvider(params["provider"]); err != nil {
@@ -68,6 +71,12 @@ func parseRecommendationFilter(params map[string]string) (config.RecommendationF
 	if minSavingsPct < 0 || minSavingsPct > 100 {
 		return config.RecommendationFilter{}, NewClientError(400, "min_savings_pct must be between 0 and 100")
 	}
+	switch params["source"] {
+	case "", config.RecommendationSourceVendor, config.RecommendationSourceNative:
+	default:
+		return config.RecommendationFilter{}, NewClientError(400, fmt.Sprintf("source must be %q or %q",
+			config.RecommendationSourceVendor, config.RecommendationSourceNative))
+	}
 
 	return config.RecommendationFilter{
 		Provider:      params["provider"],
@@ -76,6 +85,7 @@ func parseRecommendationFilter(params map[string]string) (config.RecommendationF
 		AccountIDs:    accountIDs,
 		MinSavingsUSD: minSavingsUSD,
 		MinSavingsPct: minSavingsPct,
+		Source:        params["source"],
 	}, nil
 }
 
@@ -226,14 +236,17 @@ func buildRecommendationsResponse(recommendations []config.RecommendationRecord)
 	if totalSavings > 0 {
 		avgPayback = totalUpfront / totalSavings
 	}
+	overlapping, atRisk := summarizeOverlap(recommendations)
 
 	return &RecommendationsResponse{
 		Recommendations: recommendations,
 		Summary: RecommendationsSummary{
-			TotalCount:          len(recommendations),
-			TotalMonthlySavings: totalSavings,
-			TotalUpfrontCost:    totalUpfront,
-			AvgPaybackMonths:    avgPayback,
+			TotalCount:           len(recommendations),
+			TotalMonthlySavings:  totalSavings,
+			TotalUpfrontCost:     totalUpfront,
+			AvgPaybackMonths:     avgPayback,
+			OverlappingCount:     overlapping,
+			OverlapSavingsAtRisk: atRisk,
 		},
 		Regions: regions,
 	}
@@ -311,6 +324,11 @@ func (h *Handler) getRecommendationsFreshness(ctx context.Context, req *events.L
 // than a 501) keeps the drawer functional today and means the day the
 // collector starts populating it, the frontend automatically picks it
 // up. The empty-slice case is documented in known_issues/28.
+//
+// financials evaluates the recommendation at GlobalConfig's
+// finance_discount_rate_pct and the optional usage_drop_pct query
+// parameter; ?variants=true adds the same view for every cached
+// term/payment variant of the pool.
 func (h *Handler) getRecommendationDetail(ctx context.Context, req *events.LambdaFunctionURLRequest, id string) (*RecommendationDetailResponse, error) {
 	// Authn/permission gate runs first so an unauthenticated caller
 	// can't probe id-shape validation to learn anything about the
@@ -350,10 +368,21 @@ func (h *Handler) getRecommendationDetail(ctx context.Context, req *events.Lambd
 		return nil, errNotFound
 	}
 
+	params, err := h.financeParams(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := h.buildRecommendationDetail(ctx, &visible[0])
 	if len(hiddenBy) > 0 {
 		resp.HiddenBy = hiddenBy
 	}
+	resp.Financials = recommendationFinancials(&visible[0], params)
+	if req.QueryStringParameters["variants"] == "true" {
+		if resp.FinancialVariants, err = h.variantFinancials(ctx, &visible[0], params); err != nil {
+			return nil, err
+		}
+	}
 	return resp, nil
 }
 
@@ -90,6 +90,34 @@ func TestGetRecommendations_PushdownFilters(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("source param reaches the filter", func(t *testing.T) {
+		mockScheduler := new(MockScheduler)
+		t.Cleanup(func() { mockScheduler.AssertExpectations(t) })
+		mockScheduler.On(
+			"ListRecommendations", ctx,
+			config.RecommendationFilter{Source: config.RecommendationSourceNative},
+		).Return([]config.RecommendationRecord{}, nil)
+
+		handler := &Handler{scheduler: mockScheduler, apiKey: "test-key"}
+		req := &events.LambdaFunctionURLRequest{
+			Headers: map[string]string{"x-api-key": "test-key"},
+		}
+		_, err := handler.getRecommendations(ctx, req, map[string]string{"source": "native"})
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown source returns 400", func(t *testing.T) {
+		handler := &Handler{apiKey: "test-key"}
+		req := &events.LambdaFunctionURLRequest{
+			Headers: map[string]string{"x-api-key": "test-key"},
+		}
+		_, err := handler.getRecommendations(ctx, req, map[string]string{"source": "both"})
+		require.Error(t, err)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "expected ClientError, got %T", err)
+		assert.Equal(t, 400, ce.code)
+	})
+
 	t.Run("invalid service slug returns 400", func(t *testing.T) {
 		handler := &Handler{apiKey: "test-key"}
 		req := &events.LambdaFunctionURLRequest{
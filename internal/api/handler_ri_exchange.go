@@ -2285,6 +2285,14 @@ func (h *Handler) sessionHasApproveRight(ctx context.Context, session *Session)
 // "approving a purchase action on a different resource type" per the issue spec,
 // which prefers reusing the existing verbs to keep the matrix small.
 func (h *Handler) authorizeSessionApproveRIExchange(ctx context.Context, session *Session, record *config.RIExchangeRecord) error {
+	return h.authorizeSessionApproveCreatedBy(ctx, session, record.CreatedByUserID, "exchange")
+}
+
+// authorizeSessionApproveCreatedBy applies the approve-any / approve-own
+// matrix on ResourcePurchases to a pending record created by createdBy
+// (nil for system-created records, which approve-own never matches). kind
+// names the record in the denial message ("exchange", "modification").
+func (h *Handler) authorizeSessionApproveCreatedBy(ctx context.Context, session *Session, createdBy *string, kind string) error {
 	// Stateless admin API key: full access, no user row. Administrators-group
 	// users pass via the approve-any HasPermissionAPI check below.
 	if session.UserID == apiKeyAdminUserID {
@@ -2310,9 +2318,9 @@ func (h *Handler) authorizeSessionApproveRIExchange(ctx context.Context, session
 		return NewClientError(403, "permission denied: requires approve-any or approve-own on purchases")
 	}
 
-	// approve-own: only allow if the session user created this exchange.
-	if record.CreatedByUserID == nil || *record.CreatedByUserID != session.UserID {
-		return NewClientError(403, "permission denied: cannot approve another user's pending exchange")
+	// approve-own: only allow if the session user created this record.
+	if createdBy == nil || *createdBy != session.UserID {
+		return NewClientError(403, fmt.Sprintf("permission denied: cannot approve another user's pending %s", kind))
 	}
 
 	return nil
@@ -2427,6 +2435,7 @@ func checkCapsAndComputeHeadroom(dailySpendStr, paymentDue string, cfg *config.G
 }
 
 // executeApprovedExchange checks caps and executes the exchange after approval.
+// Azure records are executed by executeApprovedAzureExchange.
 func (h *Handler) executeApprovedExchange(ctx context.Context, id string, record *config.RIExchangeRecord) (any, error) {
 	dailySpendStr, err := h.config.GetRIExchangeDailySpend(ctx, time.Now())
 	if err != nil {
@@ -2451,6 +2460,12 @@ func (h *Handler) executeApprovedExchange(ctx context.Context, id string, record
 		return h.failExchange(ctx, id, reason)
 	}
 
+	// Azure records (ri_exchange_reshape, migration 000105) carry a target
+	// SKU rather than an EC2 offering ID; the same caps apply to both.
+	if record.Provider == string(common.ProviderAzure) {
+		return h.executeApprovedAzureExchange(ctx, id, record, effectiveCap)
+	}
+
 	execFn := exchange.ExecuteExchange
 	if h.executeExchangeFn != nil {
 		execFn = h.executeExchangeFn
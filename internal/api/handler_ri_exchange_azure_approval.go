@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+	azurecompute "github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// executeApprovedAzureExchange executes an approved Azure exchange created by
+// the ri_exchange_reshape task (record.Provider == "azure"). effectiveCap is
+// the smaller of the per-exchange cap and today's remaining daily headroom,
+// already computed by executeApprovedExchange.
+//
+// The pending record stores the target, not an Azure session: sessions
+// expire within minutes and approval can come hours later. Like
+// executeAzureExchange, this re-lists the source reservation, re-quotes the
+// exchange with CalculateExchange, checks the fresh quote against every
+// guardrail in checkAzureExchangeMoneyGuardrails and only then executes the
+// session that fresh call returned.
+//
+// Every refusal goes through failExchange, matching the AWS approval path.
+func (h *Handler) executeApprovedAzureExchange(ctx context.Context, id string, record *config.RIExchangeRecord, effectiveCap *big.Rat) (any, error) {
+	client, err := h.buildAzureExchangeClient(ctx, record.AccountID)
+	if err != nil {
+		logging.Errorf("azure exchange %s: client construction failed: %v", id, err)
+		return h.failExchange(ctx, id, "failed to build Azure exchange client")
+	}
+	if client == nil {
+		return h.failExchange(ctx, id, fmt.Sprintf("no Azure cloud account is registered for subscription %q", record.AccountID))
+	}
+
+	source, reason := findApprovedAzureSource(ctx, client, record)
+	if reason != "" {
+		return h.failExchange(ctx, id, reason)
+	}
+	target := azurecompute.ExchangeTarget{
+		SKU:      record.TargetInstanceType,
+		Location: record.Region,
+		// The task only picks targets on the source's own term.
+		Term:           armreservations.ReservationTerm(source.Term),
+		Quantity:       int32(record.TargetCount), // #nosec G115 -- target quantity stored from an Azure recommendation already bounded to math.MaxInt32
+		BillingScopeID: azureBillingScopeID(record.AccountID),
+	}
+
+	preview, _, err := client.CalculateExchange(ctx, []azurecompute.ExchangeableReservation{*source}, []azurecompute.ExchangeTarget{target})
+	if err != nil {
+		logging.Errorf("azure exchange %s: re-quote failed: %v", id, err)
+		return h.failExchange(ctx, id, fmt.Sprintf("failed to price the exchange before execution: %v", err))
+	}
+	if err = checkAzureExchangeMoneyGuardrails(preview, effectiveCap, azureMaxPurchaseAmountCurrency); err != nil {
+		return h.failExchange(ctx, id, err.Error())
+	}
+
+	result, err := client.ExecuteExchange(ctx, preview.SessionID)
+	if err != nil {
+		logging.Errorf("azure exchange %s: execution failed: %v", id, err)
+		return h.failExchange(ctx, id, fmt.Sprintf(
+			"exchange execution failed and may already have been submitted to Azure; verify the reservation state in the Azure portal: %v", err))
+	}
+
+	accepted := azureAcceptedPaymentDue(result, preview)
+	if completeErr := h.retryCompleteWithPayment(ctx, id, result.SessionID, accepted); completeErr != nil {
+		logging.Errorf("all ledger write attempts failed for Azure exchange %s after money moved: %v", id, completeErr)
+		return nil, fmt.Errorf("exchange executed (session=%s) but ledger update failed: %w",
+			result.SessionID, completeErr)
+	}
+
+	logging.Infof("azure ri-exchange %s executed after approval: subscription=%s session=%s status=%s",
+		id, record.AccountID, result.SessionID, result.Status)
+	return map[string]any{"status": "completed", "exchange_id": result.SessionID}, nil
+}
+
+// findApprovedAzureSource re-lists the subscription's reservations and
+// returns the record's single source reservation, or a non-empty reason when
+// it can no longer be exchanged as approved: gone, no longer billed to the
+// subscription, or resized since the record was created (the approved quote
+// priced returning SourceCount instances).
+func findApprovedAzureSource(ctx context.Context, client azureExchangeClient, record *config.RIExchangeRecord) (*azurecompute.ExchangeableReservation, string) {
+	if len(record.SourceRIIDs) != 1 {
+		return nil, fmt.Sprintf("Azure exchange record must have exactly one source reservation, has %d", len(record.SourceRIIDs))
+	}
+	owned, err := client.ListExchangeableReservations(ctx)
+	if err != nil {
+		logging.Errorf("azure exchange source lookup failed: %v", err)
+		return nil, "could not list the subscription's reservations to verify the source"
+	}
+	scope := azureBillingScopeID(record.AccountID)
+	for i := range owned {
+		r := &owned[i]
+		if !strings.EqualFold(r.ReservationID, record.SourceRIIDs[0]) {
+			continue
+		}
+		if !strings.EqualFold(r.BillingScopeID, scope) {
+			break
+		}
+		if int(r.Quantity) != record.SourceCount {
+			return nil, fmt.Sprintf("source reservation quantity changed from %d to %d since the exchange was proposed", record.SourceCount, r.Quantity)
+		}
+		return r, ""
+	}
+	return nil, fmt.Sprintf("source reservation is no longer exchangeable or no longer billed to subscription %q", record.AccountID)
+}
+
+// azureAcceptedPaymentDue returns the payment_due to record for an executed
+// Azure exchange: what Azure committed to when it reported it, else the
+// guardrail-checked quote. A refund (negative net payable) spends nothing and
+// is recorded as "0" (payment_due is CHECK >= 0).
+func azureAcceptedPaymentDue(result *azurecompute.ExchangeResult, preview *azurecompute.ExchangePreview) string {
+	net := *preview.NetPayable
+	if result.NetPayable != nil && strings.EqualFold(result.NetPayableCurrency, azureMaxPurchaseAmountCurrency) {
+		net = *result.NetPayable
+	}
+	if net <= 0 {
+		return "0"
+	}
+	return new(big.Rat).SetFloat64(net).FloatString(6)
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	azurecompute "github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const azureApprovalSourceID = "/providers/Microsoft.Capacity/reservationOrders/order-1/reservations/res-1"
+
+// azureApprovalRecord is a pending Azure record as the ri_exchange_reshape
+// task stores it: two Standard_D2s_v3 instances into one Standard_D4s_v3.
+func azureApprovalRecord(id string) *config.RIExchangeRecord {
+	return &config.RIExchangeRecord{
+		ID:                 id,
+		AccountID:          "sub-1",
+		Region:             "eastus",
+		SourceRIIDs:        []string{azureApprovalSourceID},
+		SourceInstanceType: "Standard_D2s_v3",
+		SourceCount:        2,
+		TargetInstanceType: "Standard_D4s_v3",
+		TargetCount:        1,
+		PaymentDue:         "25.000000",
+		Provider:           "azure",
+	}
+}
+
+func azureApprovalSource() azurecompute.ExchangeableReservation {
+	return azurecompute.ExchangeableReservation{
+		ReservationID:  azureApprovalSourceID,
+		BillingScopeID: "/subscriptions/sub-1",
+		SKU:            "Standard_D2s_v3",
+		Region:         "eastus",
+		Term:           "P1Y",
+		Quantity:       2,
+	}
+}
+
+func azureApprovalStore(t *testing.T) *MockConfigStore {
+	mockStore := new(MockConfigStore)
+	t.Cleanup(func() { mockStore.AssertExpectations(t) })
+	mockStore.On("GetRIExchangeDailySpend", mock.Anything, mock.Anything).Return("0", nil)
+	mockStore.On("GetGlobalConfig", mock.Anything).Return(&config.GlobalConfig{
+		RIExchangeMaxDailyUSD:       500,
+		RIExchangeMaxPerExchangeUSD: 100,
+	}, nil)
+	return mockStore
+}
+
+func TestExecuteApprovedExchange_Azure_RequotesAndExecutes(t *testing.T) {
+	ctx := context.Background()
+	const id = "550e8400-e29b-41d4-a716-000000000105"
+
+	mockStore := azureApprovalStore(t)
+	opsClient := new(mockAzureExchangeOpsClient)
+	t.Cleanup(func() { opsClient.AssertExpectations(t) })
+	opsClient.On("ListExchangeableReservations", ctx).Return([]azurecompute.ExchangeableReservation{azureApprovalSource()}, nil)
+
+	wantTarget := azurecompute.ExchangeTarget{
+		SKU: "Standard_D4s_v3", Location: "eastus", Term: "P1Y", Quantity: 1, BillingScopeID: "/subscriptions/sub-1",
+	}
+	netPayable, accepted := 30.0, 31.25
+	opsClient.On("CalculateExchange", ctx, []azurecompute.ExchangeableReservation{azureApprovalSource()}, []azurecompute.ExchangeTarget{wantTarget}).
+		Return(&azurecompute.ExchangePreview{SessionID: "fresh-session", NetPayable: &netPayable, NetPayableCurrency: "USD"}, nil, nil)
+	opsClient.On("ExecuteExchange", ctx, "fresh-session").
+		Return(&azurecompute.ExchangeResult{SessionID: "fresh-session", NetPayable: &accepted, NetPayableCurrency: "USD", Status: "Succeeded"}, nil)
+	mockStore.On("CompleteRIExchangeWithPayment", ctx, id, "fresh-session", "31.250000").Return(nil)
+
+	var builtFor string
+	h := &Handler{
+		config: mockStore,
+		azureExchangeFactory: func(sub string) azureExchangeClient {
+			builtFor = sub
+			return opsClient
+		},
+	}
+
+	resp, err := h.executeApprovedExchange(ctx, id, azureApprovalRecord(id))
+	require.NoError(t, err)
+	assert.Equal(t, "sub-1", builtFor)
+	respMap, ok := resp.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "completed", respMap["status"])
+	assert.Equal(t, "fresh-session", respMap["exchange_id"])
+}
+
+func TestExecuteApprovedExchange_Azure_SourceQuantityChanged(t *testing.T) {
+	ctx := context.Background()
+	const id = "550e8400-e29b-41d4-a716-000000000106"
+
+	mockStore := azureApprovalStore(t)
+	opsClient := new(mockAzureExchangeOpsClient)
+	t.Cleanup(func() { opsClient.AssertExpectations(t) })
+	resized := azureApprovalSource()
+	resized.Quantity = 1
+	opsClient.On("ListExchangeableReservations", ctx).Return([]azurecompute.ExchangeableReservation{resized}, nil)
+	mockStore.On("FailRIExchange", ctx, id, mock.MatchedBy(func(reason string) bool {
+		return assert.Contains(t, reason, "quantity changed from 2 to 1")
+	})).Return(nil)
+
+	h := &Handler{config: mockStore, azureExchangeFactory: func(string) azureExchangeClient { return opsClient }}
+
+	resp, err := h.executeApprovedExchange(ctx, id, azureApprovalRecord(id))
+	require.NoError(t, err)
+	assert.Equal(t, "failed", resp.(map[string]any)["status"])
+	opsClient.AssertNotCalled(t, "CalculateExchange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecuteApprovedExchange_Azure_SourceBilledElsewhere(t *testing.T) {
+	ctx := context.Background()
+	const id = "550e8400-e29b-41d4-a716-000000000107"
+
+	mockStore := azureApprovalStore(t)
+	opsClient := new(mockAzureExchangeOpsClient)
+	t.Cleanup(func() { opsClient.AssertExpectations(t) })
+	moved := azureApprovalSource()
+	moved.BillingScopeID = "/subscriptions/sub-2"
+	opsClient.On("ListExchangeableReservations", ctx).Return([]azurecompute.ExchangeableReservation{moved}, nil)
+	mockStore.On("FailRIExchange", ctx, id, mock.MatchedBy(func(reason string) bool {
+		return assert.Contains(t, reason, "no longer billed to subscription")
+	})).Return(nil)
+
+	h := &Handler{config: mockStore, azureExchangeFactory: func(string) azureExchangeClient { return opsClient }}
+
+	resp, err := h.executeApprovedExchange(ctx, id, azureApprovalRecord(id))
+	require.NoError(t, err)
+	assert.Equal(t, "failed", resp.(map[string]any)["status"])
+}
+
+// TestExecuteApprovedExchange_Azure_RequoteAboveCap verifies the fresh quote
+// is checked against the effective cap: a price that rose past the cap
+// between proposal and approval is refused, never executed.
+func TestExecuteApprovedExchange_Azure_RequoteAboveCap(t *testing.T) {
+	ctx := context.Background()
+	const id = "550e8400-e29b-41d4-a716-000000000108"
+
+	mockStore := azureApprovalStore(t)
+	opsClient := new(mockAzureExchangeOpsClient)
+	t.Cleanup(func() { opsClient.AssertExpectations(t) })
+	opsClient.On("ListExchangeableReservations", ctx).Return([]azurecompute.ExchangeableReservation{azureApprovalSource()}, nil)
+	netPayable := 150.0
+	opsClient.On("CalculateExchange", ctx, mock.Anything, mock.Anything).
+		Return(&azurecompute.ExchangePreview{SessionID: "fresh-session", NetPayable: &netPayable, NetPayableCurrency: "USD"}, nil, nil)
+	mockStore.On("FailRIExchange", ctx, id, mock.MatchedBy(func(reason string) bool {
+		return assert.Contains(t, reason, "exceeds max_payment_due 100.00")
+	})).Return(nil)
+
+	h := &Handler{config: mockStore, azureExchangeFactory: func(string) azureExchangeClient { return opsClient }}
+
+	resp, err := h.executeApprovedExchange(ctx, id, azureApprovalRecord(id))
+	require.NoError(t, err)
+	assert.Equal(t, "failed", resp.(map[string]any)["status"])
+	opsClient.AssertNotCalled(t, "ExecuteExchange", mock.Anything, mock.Anything)
+}
+
+func TestAzureAcceptedPaymentDue(t *testing.T) {
+	quoted, refund, accepted := 20.0, -5.0, 22.5
+	preview := &azurecompute.ExchangePreview{NetPayable: &quoted, NetPayableCurrency: "USD"}
+
+	assert.Equal(t, "22.500000", azureAcceptedPaymentDue(&azurecompute.ExchangeResult{NetPayable: &accepted, NetPayableCurrency: "USD"}, preview))
+	assert.Equal(t, "20.000000", azureAcceptedPaymentDue(&azurecompute.ExchangeResult{}, preview), "falls back to the quote")
+	assert.Equal(t, "20.000000", azureAcceptedPaymentDue(&azurecompute.ExchangeResult{NetPayable: &accepted, NetPayableCurrency: "EUR"}, preview), "ignores non-USD results")
+	assert.Equal(t, "0", azureAcceptedPaymentDue(&azurecompute.ExchangeResult{NetPayable: &refund, NetPayableCurrency: "USD"}, preview), "refunds spend nothing")
+}
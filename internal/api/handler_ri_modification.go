@@ -0,0 +1,425 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/exchange"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+	awsprovider "github.com/LeanerCloud/CUDly/providers/aws"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+)
+
+// riModificationEC2Client is the narrow slice of the EC2 client the RI
+// modification handlers need. The concrete *ec2svc.Client returned by
+// awsprovider.NewEC2ClientDirect implements it.
+type riModificationEC2Client interface {
+	ListModifiableReservedInstances(ctx context.Context) ([]exchange.ModifiableRI, error)
+	ListRunningInstanceUsage(ctx context.Context) ([]exchange.InstanceUsage, error)
+	ModifyReservedInstances(ctx context.Context, req ec2svc.ModifyReservedInstancesRequest) (string, error)
+}
+
+// buildRIModificationEC2Client honors the injected factory when set,
+// falling back to the direct AWS SDK constructor otherwise.
+func (h *Handler) buildRIModificationEC2Client(cfg aws.Config) riModificationEC2Client {
+	if h.riModificationEC2Factory != nil {
+		return h.riModificationEC2Factory(cfg)
+	}
+	return awsprovider.NewEC2ClientDirect(cfg)
+}
+
+// maxModificationSourceRIs caps how many RIs one modification request may
+// name; AWS accepts a handful per call and the planner never groups more.
+const maxModificationSourceRIs = 100
+
+// RIModificationRecommendationsResponse is the response for
+// GET /api/ri-exchange/modification-recommendations.
+type RIModificationRecommendationsResponse struct {
+	Region          string                      `json:"region"`
+	Recommendations []exchange.ModificationPlan `json:"recommendations"`
+}
+
+// RIModificationRequestBody is the request body for
+// POST /api/ri-exchange/modifications.
+type RIModificationRequestBody struct {
+	Region  string                        `json:"region,omitempty"`
+	Reason  string                        `json:"reason,omitempty"`
+	RIIDs   []string                      `json:"ri_ids"`
+	Targets []exchange.ModificationTarget `json:"targets"`
+}
+
+// RIModificationHistoryResponse is the response for
+// GET /api/ri-exchange/modifications.
+type RIModificationHistoryResponse struct {
+	Records []config.RIModificationRecord `json:"records"`
+}
+
+// getRIModificationRecommendations returns the free ModifyReservedInstances
+// calls (split, merge, AZ move, regional scope) that would raise the
+// utilization of the region's underutilized RIs, matched against the
+// instances running right now.
+//
+// GET /api/ri-exchange/modification-recommendations?region=&threshold=&lookback_days=.
+func (h *Handler) getRIModificationRecommendations(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "view", "purchases")
+	if err != nil {
+		return nil, err
+	}
+	empty := &RIModificationRecommendationsResponse{Recommendations: []exchange.ModificationPlan{}}
+	if inScope, sErr := h.reshapeCloudAccountInScope(ctx, session); sErr != nil {
+		return nil, sErr
+	} else if !inScope {
+		return empty, nil
+	}
+
+	p, err := parseReshapeParams(req.QueryStringParameters)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := h.loadAWSConfigWithRegion(ctx, p.region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if p.region == "" {
+		p.region = cfg.Region
+	}
+	empty.Region = p.region
+
+	ec2Client := h.buildRIModificationEC2Client(cfg)
+	ris, err := ec2Client.ListModifiableReservedInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved instances: %w", err)
+	}
+	if len(ris) == 0 {
+		return empty, nil
+	}
+	usage, err := ec2Client.ListRunningInstanceUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running instances: %w", err)
+	}
+
+	recsAdapter := h.buildReshapeRecsClient(cfg)
+	fetch := func(fetchCtx context.Context, days int) ([]recommendations.RIUtilization, error) {
+		return recsAdapter.GetRIUtilization(fetchCtx, days, p.region)
+	}
+	utilData, err := h.getRIUtilizationCache().getOrFetch(ctx, p.region, p.lookbackDays, riUtilizationCacheTTL, riUtilizationCacheStaleTTL, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RI utilization: %w", err)
+	}
+	utilInfos := make([]exchange.UtilizationInfo, 0, len(utilData))
+	for _, u := range utilData {
+		utilInfos = append(utilInfos, exchange.UtilizationInfo{RIID: u.ReservedInstanceID, UtilizationPercent: u.UtilizationPercent})
+	}
+
+	plans := exchange.PlanModifications(ris, usage, utilInfos, p.threshold)
+	if plans == nil {
+		plans = []exchange.ModificationPlan{}
+	}
+	return &RIModificationRecommendationsResponse{Region: p.region, Recommendations: plans}, nil
+}
+
+// createRIModification validates a modification against the RIs' live
+// state and records it as pending. Nothing reaches AWS until an approver
+// calls approveRIModification.
+//
+// POST /api/ri-exchange/modifications.
+func (h *Handler) createRIModification(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "execute", "ri-exchange")
+	if err != nil {
+		return nil, err
+	}
+
+	var body RIModificationRequestBody
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		return nil, NewClientError(400, "invalid request body")
+	}
+	if err := validateRIModificationBody(body); err != nil {
+		return nil, err
+	}
+
+	cfg, err := h.loadAWSConfigWithRegion(ctx, body.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	region := body.Region
+	if region == "" {
+		region = cfg.Region
+	}
+
+	// Same constraint shape as executeExchange: modifications act on the
+	// deployment's own AWS account, EC2 only, in one region. They are free,
+	// so there is no amount to cap.
+	cloudAccountID, err := h.resolveReshapeCloudAccountID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve cloud account scope: %w", err)
+	}
+	constraintAccount := cloudAccountID
+	if constraintAccount == "" {
+		constraintAccount = unattributedAccountConstraint
+	}
+	err = h.requirePermissionConstraints(ctx, session, "ri-exchange", []auth.PermissionConstraints{{
+		AccountIDs: []string{constraintAccount},
+		Providers:  []string{string(common.ProviderAWS)},
+		Services:   []string{string(common.ServiceEC2)},
+		Regions:    []string{region},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := h.loadModificationSources(ctx, h.buildRIModificationEC2Client(cfg), body.RIIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := exchange.ValidateModification(region, sources, body.Targets); err != nil {
+		return nil, NewClientError(400, fmt.Sprintf("invalid modification: %v", err))
+	}
+
+	record := &config.RIModificationRecord{
+		CloudAccountID:  cloudAccountID,
+		Region:          region,
+		SourceRIIDs:     body.RIIDs,
+		Sources:         sourceConfigurations(sources),
+		Targets:         toRIModificationConfigurations(body.Targets),
+		Status:          "pending",
+		Reason:          body.Reason,
+		CreatedByUserID: resolveCreatorUserID(session),
+	}
+	if err := h.config.SaveRIModificationRecord(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to save modification: %w", err)
+	}
+	return record, nil
+}
+
+// validateRIModificationBody checks the request shape; the AWS rules are
+// checked by exchange.ValidateModification against the live RIs.
+func validateRIModificationBody(body RIModificationRequestBody) error {
+	if len(body.RIIDs) == 0 {
+		return NewClientError(400, "ri_ids is required")
+	}
+	if len(body.RIIDs) > maxModificationSourceRIs {
+		return NewClientError(400, fmt.Sprintf("ri_ids must name at most %d reserved instances", maxModificationSourceRIs))
+	}
+	seen := make(map[string]bool, len(body.RIIDs))
+	for _, id := range body.RIIDs {
+		if id == "" || seen[id] {
+			return NewClientError(400, "ri_ids must be non-empty and unique")
+		}
+		seen[id] = true
+	}
+	if len(body.Targets) == 0 {
+		return NewClientError(400, "targets is required")
+	}
+	if len(body.Reason) > 1000 {
+		return NewClientError(400, "reason must be at most 1000 characters")
+	}
+	return nil
+}
+
+// loadModificationSources returns the active RIs named by ids, in ids
+// order, or a 409 naming the first one that is no longer active.
+func (h *Handler) loadModificationSources(ctx context.Context, client riModificationEC2Client, ids []string) ([]exchange.ModifiableRI, error) {
+	ris, err := client.ListModifiableReservedInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved instances: %w", err)
+	}
+	byID := make(map[string]exchange.ModifiableRI, len(ris))
+	for _, ri := range ris {
+		byID[ri.ID] = ri
+	}
+	sources := make([]exchange.ModifiableRI, 0, len(ids))
+	for _, id := range ids {
+		ri, ok := byID[id]
+		if !ok {
+			return nil, NewClientError(409, fmt.Sprintf("reserved instance %s is not active in this region", id))
+		}
+		sources = append(sources, ri)
+	}
+	return sources, nil
+}
+
+// getRIModificationHistory returns the last year of modification records
+// visible to the session's account scope.
+//
+// GET /api/ri-exchange/modifications.
+func (h *Handler) getRIModificationHistory(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	session, err := h.requirePermission(ctx, req, "view", "purchases")
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := h.config.GetRIModificationHistory(ctx, time.Now().AddDate(-1, 0, 0), 500)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load modification history: %w", err)
+	}
+
+	allowed, err := h.getAccountScope(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed accounts: %w", err)
+	}
+	if !allowed.AllowsAll() {
+		nameByID := h.resolveAccountNamesByID(ctx)
+		filtered := records[:0]
+		for i := range records {
+			if allowed.Allows(records[i].CloudAccountID, nameByID[records[i].CloudAccountID]) {
+				filtered = append(filtered, records[i])
+			}
+		}
+		records = filtered
+	}
+	if records == nil {
+		records = []config.RIModificationRecord{}
+	}
+	return &RIModificationHistoryResponse{Records: records}, nil
+}
+
+// approveRIModification approves a pending modification and submits it to
+// AWS. The approve-any / approve-own matrix on purchases applies, as for
+// RI exchanges. The modification is re-validated against the RIs' live
+// state first (they may have expired or been modified since submission),
+// and the record ID is sent as the ClientToken so AWS never applies it
+// twice. "completed" means AWS accepted the request; AWS fulfils it
+// asynchronously under the returned modification ID.
+//
+// POST /api/ri-exchange/modifications/{id}/approve.
+func (h *Handler) approveRIModification(ctx context.Context, req *events.LambdaFunctionURLRequest, id string) (any, error) {
+	session, record, err := h.fetchAndAuthorizeRIModification(ctx, req, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.config.TransitionRIModificationStatus(ctx, id, "pending", "processing", resolveCreatorUserID(session)); err != nil {
+		return nil, NewClientError(409, fmt.Sprintf("modification %s cannot be approved: %v", id, err))
+	}
+
+	cfg, err := h.loadAWSConfigWithRegion(ctx, record.Region)
+	if err != nil {
+		return nil, h.failRIModification(ctx, id, fmt.Errorf("failed to load AWS config: %w", err))
+	}
+	client := h.buildRIModificationEC2Client(cfg)
+	sources, err := h.loadModificationSources(ctx, client, record.SourceRIIDs)
+	if err != nil {
+		return nil, h.failRIModification(ctx, id, err)
+	}
+	targets := toModificationTargets(record.Targets)
+	if err := exchange.ValidateModification(record.Region, sources, targets); err != nil {
+		return nil, h.failRIModification(ctx, id, NewClientError(409, fmt.Sprintf("modification is no longer valid: %v", err)))
+	}
+
+	modificationID, err := client.ModifyReservedInstances(ctx, ec2svc.ModifyReservedInstancesRequest{
+		ReservedInstancesIDs: record.SourceRIIDs,
+		Targets:              targets,
+		ClientToken:          record.ID,
+	})
+	if err != nil {
+		logging.Errorf("RI modification %s failed: %v", id, err)
+		if failErr := h.config.FailRIModification(ctx, id, err.Error()); failErr != nil {
+			logging.Errorf("failed to mark RI modification %s failed: %v", id, failErr)
+		}
+		return nil, mapAWSExchangeError("modification failed", err)
+	}
+
+	if err := h.config.CompleteRIModification(ctx, id, modificationID, session.Email); err != nil {
+		return nil, fmt.Errorf("modification %s was submitted to AWS as %s but could not be recorded: %w", id, modificationID, err)
+	}
+	return map[string]string{"status": "completed", "modification_id": modificationID}, nil
+}
+
+// rejectRIModification cancels a pending modification. The same rights
+// that allow approving it allow rejecting it.
+//
+// POST /api/ri-exchange/modifications/{id}/reject.
+func (h *Handler) rejectRIModification(ctx context.Context, req *events.LambdaFunctionURLRequest, id string) (any, error) {
+	session, _, err := h.fetchAndAuthorizeRIModification(ctx, req, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.config.TransitionRIModificationStatus(ctx, id, "pending", config.StatusCanceled, resolveCreatorUserID(session)); err != nil {
+		return nil, NewClientError(409, fmt.Sprintf("modification %s cannot be rejected: %v", id, err))
+	}
+	return map[string]string{"status": config.StatusCanceled}, nil
+}
+
+// fetchAndAuthorizeRIModification resolves the session, loads the pending
+// record and checks the session may act on it: the record's account must
+// be in the session's scope, and approve-any / approve-own must allow it.
+func (h *Handler) fetchAndAuthorizeRIModification(ctx context.Context, req *events.LambdaFunctionURLRequest, id string) (*Session, *config.RIModificationRecord, error) {
+	session, err := h.requireSession(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateUUID(id); err != nil {
+		return nil, nil, err
+	}
+
+	record, err := h.config.GetRIModificationRecord(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up modification record: %w", err)
+	}
+	if record == nil {
+		return nil, nil, NewClientError(404, "modification record not found")
+	}
+
+	allowed, err := h.getAccountScope(ctx, session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get allowed accounts: %w", err)
+	}
+	if !allowed.AllowsAll() && !allowed.Allows(record.CloudAccountID, h.resolveAccountNamesByID(ctx)[record.CloudAccountID]) {
+		return nil, nil, NewClientError(404, "modification record not found")
+	}
+
+	if record.Status != "pending" {
+		return nil, nil, NewClientError(409, fmt.Sprintf("modification %s is not pending (status=%s)", id, record.Status))
+	}
+	if err := h.authorizeSessionApproveCreatedBy(ctx, session, record.CreatedByUserID, "modification"); err != nil {
+		return nil, nil, err
+	}
+	return session, record, nil
+}
+
+// failRIModification marks the processing record failed with cause's
+// message and returns cause for the caller to surface.
+func (h *Handler) failRIModification(ctx context.Context, id string, cause error) error {
+	if err := h.config.FailRIModification(ctx, id, cause.Error()); err != nil {
+		logging.Errorf("failed to mark RI modification %s failed: %v", id, err)
+	}
+	return cause
+}
+
+// sourceConfigurations records the sources' configurations as they are.
+func sourceConfigurations(sources []exchange.ModifiableRI) []config.RIModificationConfiguration {
+	out := make([]config.RIModificationConfiguration, 0, len(sources))
+	for _, ri := range sources {
+		c := config.RIModificationConfiguration{InstanceType: ri.InstanceType, InstanceCount: ri.InstanceCount, Scope: ri.Scope}
+		if ri.Scope != exchange.ScopeRegion {
+			c.AvailabilityZone = ri.AvailabilityZone
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func toRIModificationConfigurations(targets []exchange.ModificationTarget) []config.RIModificationConfiguration {
+	out := make([]config.RIModificationConfiguration, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, config.RIModificationConfiguration(t))
+	}
+	return out
+}
+
+func toModificationTargets(configs []config.RIModificationConfiguration) []exchange.ModificationTarget {
+	out := make([]exchange.ModificationTarget, 0, len(configs))
+	for _, c := range configs {
+		out = append(out, exchange.ModificationTarget(c))
+	}
+	return out
+}
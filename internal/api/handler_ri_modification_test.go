@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/auth"
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/exchange"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+)
+
+const validModificationID = "22222222-2222-2222-2222-222222222222"
+
+// stubRIModificationEC2 implements riModificationEC2Client and records the
+// last ModifyReservedInstances request.
+type stubRIModificationEC2 struct {
+	ris      []exchange.ModifiableRI
+	usage    []exchange.InstanceUsage
+	modifyFn func(req ec2svc.ModifyReservedInstancesRequest) (string, error)
+
+	lastModifyReq   ec2svc.ModifyReservedInstancesRequest
+	modifyCallCount int
+}
+
+func (s *stubRIModificationEC2) ListModifiableReservedInstances(_ context.Context) ([]exchange.ModifiableRI, error) {
+	return s.ris, nil
+}
+
+func (s *stubRIModificationEC2) ListRunningInstanceUsage(_ context.Context) ([]exchange.InstanceUsage, error) {
+	return s.usage, nil
+}
+
+func (s *stubRIModificationEC2) ModifyReservedInstances(_ context.Context, req ec2svc.ModifyReservedInstancesRequest) (string, error) {
+	s.modifyCallCount++
+	s.lastModifyReq = req
+	if s.modifyFn != nil {
+		return s.modifyFn(req)
+	}
+	return "rimod-default", nil
+}
+
+func newRIModificationHandler(cfgStore *MockConfigStore, authSvc *MockAuthService, ec2 *stubRIModificationEC2) *Handler {
+	h := &Handler{
+		config: cfgStore,
+		auth:   authSvc,
+		riModificationEC2Factory: func(_ aws.Config) riModificationEC2Client {
+			return ec2
+		},
+		reshapeAccountResolver: func(_ context.Context) (string, error) { return "acct-1", nil },
+	}
+	h.awsCfgOnce.Do(func() { h.awsCfg = aws.Config{Region: "us-east-1"} })
+	return h
+}
+
+// modificationApprover models an admin who is also in the Purchaser group
+// and holds the execute:ri-exchange verb carved out of admin:*.
+func modificationApprover(authSvc *MockAuthService) {
+	authSvc.On("ValidateSession", mock.Anything, "test-token").
+		Return(&Session{UserID: "admin", Email: "admin@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{
+		{Action: auth.ActionAdmin, Resource: auth.ResourceAll},
+		{Action: auth.ActionExecute, Resource: auth.ResourceRIExchange},
+		{Action: auth.ActionApproveAny, Resource: auth.ResourcePurchases},
+	})
+}
+
+func zonalXLarge(id string) exchange.ModifiableRI {
+	return exchange.ModifiableRI{
+		ID:                 id,
+		InstanceType:       "m5.xlarge",
+		AvailabilityZone:   "us-east-1a",
+		Scope:              exchange.ScopeAvailabilityZone,
+		ProductDescription: "Linux/UNIX",
+		InstanceTenancy:    "default",
+		OfferingClass:      "standard",
+		End:                time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		InstanceCount:      1,
+	}
+}
+
+func pendingModification(createdBy string) *config.RIModificationRecord {
+	return &config.RIModificationRecord{
+		ID:             validModificationID,
+		CloudAccountID: "acct-1",
+		Region:         "us-east-1",
+		SourceRIIDs:    []string{"ri-1"},
+		Targets: []config.RIModificationConfiguration{
+			{InstanceType: "m5.large", AvailabilityZone: "us-east-1b", Scope: exchange.ScopeAvailabilityZone, InstanceCount: 2},
+		},
+		Status:          "pending",
+		CreatedByUserID: &createdBy,
+	}
+}
+
+func TestGetRIModificationRecommendations(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	ec2 := &stubRIModificationEC2{
+		ris: []exchange.ModifiableRI{zonalXLarge("ri-1")},
+		usage: []exchange.InstanceUsage{
+			{InstanceType: "m5.large", AvailabilityZone: "us-east-1b", ProductDescription: "Linux/UNIX", InstanceTenancy: "default", Count: 2},
+		},
+	}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+	h.reshapeRecsFactory = func(_ aws.Config) reshapeRecsClient {
+		return &fakeReshapeRecsStub{utilization: []recommendations.RIUtilization{
+			{ReservedInstanceID: "ri-1", UtilizationPercent: 0},
+		}}
+	}
+
+	resp, err := h.getRIModificationRecommendations(context.Background(), marketplaceReq())
+	require.NoError(t, err)
+	out := resp.(*RIModificationRecommendationsResponse)
+	assert.Equal(t, "us-east-1", out.Region)
+	require.Len(t, out.Recommendations, 1)
+	plan := out.Recommendations[0]
+	assert.Equal(t, []string{"ri-1"}, plan.SourceRIIDs)
+	assert.Equal(t, []exchange.ModificationTarget{
+		{InstanceType: "m5.large", AvailabilityZone: "us-east-1b", Scope: exchange.ScopeAvailabilityZone, InstanceCount: 2},
+	}, plan.Targets)
+	assert.InDelta(t, 8.0, plan.ProjectedCoveredUnits, 1e-9)
+}
+
+func TestGetRIModificationRecommendations_NoRIs(t *testing.T) {
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	h := newRIModificationHandler(&MockConfigStore{}, authSvc, &stubRIModificationEC2{})
+
+	resp, err := h.getRIModificationRecommendations(context.Background(), marketplaceReq())
+	require.NoError(t, err)
+	assert.Empty(t, resp.(*RIModificationRecommendationsResponse).Recommendations)
+}
+
+func TestCreateRIModification_SavesPendingRecord(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	ec2 := &stubRIModificationEC2{ris: []exchange.ModifiableRI{zonalXLarge("ri-1")}}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+
+	var saved *config.RIModificationRecord
+	cfgStore.On("SaveRIModificationRecord", mock.Anything, mock.AnythingOfType("*config.RIModificationRecord")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*config.RIModificationRecord) }).
+		Return(nil)
+
+	req := marketplaceReq()
+	req.Body = `{"ri_ids":["ri-1"],"reason":"rebalance","targets":[{"instance_type":"m5.large","availability_zone":"us-east-1b","scope":"Availability Zone","instance_count":2}]}`
+	_, err := h.createRIModification(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NotNil(t, saved)
+	assert.Equal(t, "pending", saved.Status)
+	assert.Equal(t, "acct-1", saved.CloudAccountID)
+	assert.Equal(t, "us-east-1", saved.Region)
+	assert.Equal(t, []config.RIModificationConfiguration{
+		{InstanceType: "m5.xlarge", AvailabilityZone: "us-east-1a", Scope: exchange.ScopeAvailabilityZone, InstanceCount: 1},
+	}, saved.Sources)
+	assert.Equal(t, 0, ec2.modifyCallCount, "nothing reaches AWS before approval")
+}
+
+func TestCreateRIModification_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		message string
+	}{
+		{"no ri_ids", `{"targets":[{"instance_type":"m5.large","scope":"Region","instance_count":2}]}`, 400, "ri_ids is required"},
+		{"duplicate ri_ids", `{"ri_ids":["ri-1","ri-1"],"targets":[{"instance_type":"m5.large","scope":"Region","instance_count":2}]}`, 400, "unique"},
+		{"no targets", `{"ri_ids":["ri-1"]}`, 400, "targets is required"},
+		{"footprint mismatch", `{"ri_ids":["ri-1"],"targets":[{"instance_type":"m5.large","scope":"Region","instance_count":1}]}`, 400, "conserve the footprint"},
+		{"inactive RI", `{"ri_ids":["ri-gone"],"targets":[{"instance_type":"m5.large","scope":"Region","instance_count":2}]}`, 409, "ri-gone is not active"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgStore := &MockConfigStore{}
+			authSvc := &MockAuthService{}
+			modificationApprover(authSvc)
+			h := newRIModificationHandler(cfgStore, authSvc, &stubRIModificationEC2{ris: []exchange.ModifiableRI{zonalXLarge("ri-1")}})
+
+			req := marketplaceReq()
+			req.Body = tt.body
+			_, err := h.createRIModification(context.Background(), req)
+			require.Error(t, err)
+			ce, ok := IsClientError(err)
+			require.True(t, ok, "expected a ClientError, got: %v", err)
+			assert.Equal(t, tt.code, ce.code)
+			assert.Contains(t, err.Error(), tt.message)
+			cfgStore.AssertNotCalled(t, "SaveRIModificationRecord", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestApproveRIModification(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	ec2 := &stubRIModificationEC2{
+		ris: []exchange.ModifiableRI{zonalXLarge("ri-1")},
+		modifyFn: func(_ ec2svc.ModifyReservedInstancesRequest) (string, error) {
+			return "rimod-123", nil
+		},
+	}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+
+	cfgStore.On("GetRIModificationRecord", mock.Anything, validModificationID).Return(pendingModification("creator"), nil)
+	cfgStore.On("TransitionRIModificationStatus", mock.Anything, validModificationID, "pending", "processing", mock.Anything).
+		Return(&config.RIModificationRecord{ID: validModificationID, Status: "processing"}, nil)
+	cfgStore.On("CompleteRIModification", mock.Anything, validModificationID, "rimod-123", "admin@test.com").Return(nil)
+
+	resp, err := h.approveRIModification(context.Background(), marketplaceReq(), validModificationID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "completed", "modification_id": "rimod-123"}, resp)
+	assert.Equal(t, validModificationID, ec2.lastModifyReq.ClientToken, "the record ID makes the AWS call idempotent")
+	assert.Equal(t, []string{"ri-1"}, ec2.lastModifyReq.ReservedInstancesIDs)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestApproveRIModification_ApproveOwnOtherUserDenied(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	authSvc.On("ValidateSession", mock.Anything, "test-token").Return(&Session{UserID: "owner", Email: "owner@test.com"}, nil)
+	authSvc.grantPermissions([]auth.Permission{{Action: auth.ActionApproveOwn, Resource: auth.ResourcePurchases}})
+	ec2 := &stubRIModificationEC2{ris: []exchange.ModifiableRI{zonalXLarge("ri-1")}}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+
+	cfgStore.On("GetRIModificationRecord", mock.Anything, validModificationID).Return(pendingModification("someone-else"), nil)
+
+	_, err := h.approveRIModification(context.Background(), marketplaceReq(), validModificationID)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 403, ce.code)
+	assert.Contains(t, err.Error(), "cannot approve another user's pending modification")
+	cfgStore.AssertNotCalled(t, "TransitionRIModificationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, 0, ec2.modifyCallCount)
+}
+
+func TestApproveRIModification_SourceNoLongerActive(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	ec2 := &stubRIModificationEC2{}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+
+	cfgStore.On("GetRIModificationRecord", mock.Anything, validModificationID).Return(pendingModification("creator"), nil)
+	cfgStore.On("TransitionRIModificationStatus", mock.Anything, validModificationID, "pending", "processing", mock.Anything).
+		Return(&config.RIModificationRecord{ID: validModificationID, Status: "processing"}, nil)
+	cfgStore.On("FailRIModification", mock.Anything, validModificationID, mock.AnythingOfType("string")).Return(nil)
+
+	_, err := h.approveRIModification(context.Background(), marketplaceReq(), validModificationID)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 409, ce.code)
+	assert.Equal(t, 0, ec2.modifyCallCount)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestApproveRIModification_AWSFailureMarksFailed(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	ec2 := &stubRIModificationEC2{
+		ris: []exchange.ModifiableRI{zonalXLarge("ri-1")},
+		modifyFn: func(_ ec2svc.ModifyReservedInstancesRequest) (string, error) {
+			return "", errors.New("boom")
+		},
+	}
+	h := newRIModificationHandler(cfgStore, authSvc, ec2)
+
+	cfgStore.On("GetRIModificationRecord", mock.Anything, validModificationID).Return(pendingModification("creator"), nil)
+	cfgStore.On("TransitionRIModificationStatus", mock.Anything, validModificationID, "pending", "processing", mock.Anything).
+		Return(&config.RIModificationRecord{ID: validModificationID, Status: "processing"}, nil)
+	cfgStore.On("FailRIModification", mock.Anything, validModificationID, "boom").Return(nil)
+
+	_, err := h.approveRIModification(context.Background(), marketplaceReq(), validModificationID)
+	require.Error(t, err)
+	cfgStore.AssertExpectations(t)
+	cfgStore.AssertNotCalled(t, "CompleteRIModification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRejectRIModification(t *testing.T) {
+	cfgStore := &MockConfigStore{}
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	h := newRIModificationHandler(cfgStore, authSvc, &stubRIModificationEC2{})
+
+	cfgStore.On("GetRIModificationRecord", mock.Anything, validModificationID).Return(pendingModification("creator"), nil)
+	cfgStore.On("TransitionRIModificationStatus", mock.Anything, validModificationID, "pending", config.StatusCanceled, mock.Anything).
+		Return(&config.RIModificationRecord{ID: validModificationID, Status: config.StatusCanceled}, nil)
+
+	resp, err := h.rejectRIModification(context.Background(), marketplaceReq(), validModificationID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": config.StatusCanceled}, resp)
+	cfgStore.AssertExpectations(t)
+}
+
+func TestApproveRIModification_NotFound(t *testing.T) {
+	authSvc := &MockAuthService{}
+	modificationApprover(authSvc)
+	h := newRIModificationHandler(&MockConfigStore{}, authSvc, &stubRIModificationEC2{})
+
+	_, err := h.approveRIModification(context.Background(), &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"authorization": "Bearer test-token"},
+	}, validModificationID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "expected a ClientError, got: %v", err)
+	assert.Equal(t, 404, ce.code)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/shadow"
+)
+
+// Shadow purchases: what shadow-mode purchase plans and ladder configs
+// would have bought, with the running evaluation of each against the usage
+// that followed (see pkg/shadow). Both routes take view:config.
+
+// shadowPurchaseListLimit bounds GET /api/shadow-purchases. The report
+// reads every matching purchase.
+const shadowPurchaseListLimit = 500
+
+// ShadowReportResponse is the body of GET /api/shadow-purchases/report:
+// the matching purchases rolled up into a go/no-go verdict against the
+// criteria it was decided with. Failed counts the purchases whose last
+// evaluation recorded an error, which the totals leave out.
+type ShadowReportResponse struct {
+	MinDays           int            `json:"min_days"`
+	MinUtilizationPct float64        `json:"min_utilization_pct"`
+	Failed            int            `json:"failed"`
+	Summary           shadow.Summary `json:"summary"`
+}
+
+// listShadowPurchases handles
+// GET /api/shadow-purchases?source=&plan_id=&ladder_config_id=, newest
+// purchase first.
+func (h *Handler) listShadowPurchases(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	filter, err := parseShadowPurchaseFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = shadowPurchaseListLimit
+	ps, err := h.config.ListShadowPurchases(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow purchases: %w", err)
+	}
+	if ps == nil {
+		ps = []config.ShadowPurchase{}
+	}
+	return map[string]any{"shadow_purchases": ps}, nil
+}
+
+// getShadowReport handles GET /api/shadow-purchases/report, which takes the
+// filters of listShadowPurchases plus min_days and min_utilization_pct to
+// override shadow.DefaultCriteria.
+func (h *Handler) getShadowReport(ctx context.Context, req *events.LambdaFunctionURLRequest) (any, error) {
+	if _, err := h.requirePermission(ctx, req, "view", "config"); err != nil {
+		return nil, err
+	}
+	filter, err := parseShadowPurchaseFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := parseShadowCriteria(req)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := h.config.ListShadowPurchases(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow purchases: %w", err)
+	}
+	resp := &ShadowReportResponse{MinDays: criteria.MinDays, MinUtilizationPct: criteria.MinUtilizationPct}
+	evals := make([]shadow.Evaluation, 0, len(ps))
+	for i := range ps {
+		if ps[i].EvaluationError != "" {
+			resp.Failed++
+			continue
+		}
+		evals = append(evals, ps[i].Evaluation)
+	}
+	resp.Summary = shadow.Summarize(evals, criteria)
+	return resp, nil
+}
+
+// parseShadowPurchaseFilter reads the source, plan_id and ladder_config_id
+// query parameters.
+func parseShadowPurchaseFilter(req *events.LambdaFunctionURLRequest) (config.ShadowPurchaseFilter, error) {
+	q := req.QueryStringParameters
+	filter := config.ShadowPurchaseFilter{Source: q["source"], PlanID: q["plan_id"], LadderConfigID: q["ladder_config_id"]}
+	switch filter.Source {
+	case "", config.ShadowSourcePlan, config.ShadowSourceLadder:
+	default:
+		return filter, NewClientError(400, fmt.Sprintf("source must be %q or %q", config.ShadowSourcePlan, config.ShadowSourceLadder))
+	}
+	for _, id := range []string{filter.PlanID, filter.LadderConfigID} {
+		if id == "" {
+			continue
+		}
+		if err := validateUUID(id); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// parseShadowCriteria reads min_days and min_utilization_pct over
+// shadow.DefaultCriteria.
+func parseShadowCriteria(req *events.LambdaFunctionURLRequest) (shadow.Criteria, error) {
+	criteria := shadow.DefaultCriteria
+	if raw := req.QueryStringParameters["min_days"]; raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return criteria, NewClientError(400, "min_days must be a non-negative integer")
+		}
+		criteria.MinDays = n
+	}
+	if raw := req.QueryStringParameters["min_utilization_pct"]; raw != "" {
+		pct, err := parseMinSavingsParam(raw, "min_utilization_pct")
+		if err != nil {
+			return criteria, err
+		}
+		if pct > 100 {
+			return criteria, NewClientError(400, "min_utilization_pct must be at most 100")
+		}
+		criteria.MinUtilizationPct = pct
+	}
+	return criteria, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/shadow"
+)
+
+const shadowPlanID = "66666666-6666-6666-6666-666666666666"
+
+func shadowReq(query map[string]string) *events.LambdaFunctionURLRequest {
+	req := marketplaceReq()
+	req.QueryStringParameters = query
+	return req
+}
+
+func TestListShadowPurchases(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("ListShadowPurchases", mock.Anything, config.ShadowPurchaseFilter{
+		Source: config.ShadowSourcePlan, PlanID: shadowPlanID, Limit: shadowPurchaseListLimit,
+	}).Return([]config.ShadowPurchase{{ID: "p1", Source: config.ShadowSourcePlan}}, nil)
+
+	got, err := h.listShadowPurchases(context.Background(), shadowReq(map[string]string{"source": "plan", "plan_id": shadowPlanID}))
+	require.NoError(t, err)
+	assert.Len(t, got.(map[string]any)["shadow_purchases"], 1)
+}
+
+func TestListShadowPurchases_RejectsBadFilters(t *testing.T) {
+	for name, query := range map[string]map[string]string{
+		"unknown source":   {"source": "manual"},
+		"malformed plan":   {"plan_id": "not-a-uuid"},
+		"malformed ladder": {"ladder_config_id": "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h, cfgStore, _ := newPolicyHandler()
+			_, err := h.listShadowPurchases(context.Background(), shadowReq(query))
+			require.Error(t, err)
+			_, isClient := IsClientError(err)
+			assert.True(t, isClient)
+			cfgStore.AssertNumberOfCalls(t, "ListShadowPurchases", 0)
+		})
+	}
+}
+
+func TestGetShadowReport(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	good := shadow.Evaluation{CoveredUSD: 900, CommittedUSD: 1000, CostUSD: 600, SavingsUSD: 300, Days: 40}
+	cfgStore.On("ListShadowPurchases", mock.Anything, config.ShadowPurchaseFilter{Source: config.ShadowSourceLadder}).
+		Return([]config.ShadowPurchase{
+			{ID: "p1", Evaluation: good},
+			{ID: "p2", EvaluationError: "no on-demand usage"},
+		}, nil)
+
+	got, err := h.getShadowReport(context.Background(), shadowReq(map[string]string{"source": "ladder"}))
+	require.NoError(t, err)
+	report := got.(*ShadowReportResponse)
+	assert.Equal(t, shadow.DefaultCriteria.MinDays, report.MinDays)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Summary.Purchases, "a failed purchase is left out of the totals")
+	assert.Equal(t, shadow.VerdictGo, report.Summary.Verdict)
+}
+
+func TestGetShadowReport_Criteria(t *testing.T) {
+	h, cfgStore, _ := newPolicyHandler()
+	cfgStore.On("ListShadowPurchases", mock.Anything, mock.Anything).
+		Return([]config.ShadowPurchase{{ID: "p1", Evaluation: shadow.Evaluation{
+			CoveredUSD: 900, CommittedUSD: 1000, CostUSD: 600, SavingsUSD: 300, Days: 40,
+		}}}, nil)
+
+	got, err := h.getShadowReport(context.Background(), shadowReq(map[string]string{"min_days": "60", "min_utilization_pct": "95"}))
+	require.NoError(t, err)
+	report := got.(*ShadowReportResponse)
+	assert.Equal(t, 60, report.MinDays)
+	assert.InDelta(t, 95, report.MinUtilizationPct, 1e-9)
+	assert.Equal(t, shadow.VerdictInsufficientData, report.Summary.Verdict)
+
+	for _, query := range []map[string]string{
+		{"min_days": "-1"},
+		{"min_days": "many"},
+		{"min_utilization_pct": "101"},
+		{"min_utilization_pct": "NaN"},
+	} {
+		_, err := h.getShadowReport(context.Background(), shadowReq(query))
+		require.Error(t, err, query)
+		_, isClient := IsClientError(err)
+		assert.True(t, isClient, query)
+	}
+}
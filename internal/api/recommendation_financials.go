@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// VariantFinancials is the financial evaluation of one cached term/payment
+// variant of a recommendation.
+type VariantFinancials struct {
+	ID string `json:"id"`
+	finance.Evaluation
+}
+
+// financeParams resolves the financial assumptions for a request: the
+// discount rate from GlobalConfig (finance.DefaultDiscountRatePct when
+// unset) and the usage drop from the usage_drop_pct query parameter
+// (finance.DefaultUsageDropPct when absent).
+func (h *Handler) financeParams(ctx context.Context, req *events.LambdaFunctionURLRequest) (finance.Params, error) {
+	params := finance.DefaultParams()
+	if raw := strings.TrimSpace(req.QueryStringParameters["usage_drop_pct"]); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return params, NewClientError(400, "usage_drop_pct must be a number")
+		}
+		params.UsageDropPct = v
+	}
+	globalCfg, err := h.config.GetGlobalConfig(ctx)
+	if err != nil {
+		return params, fmt.Errorf("failed to get global config: %w", err)
+	}
+	if globalCfg != nil && globalCfg.FinanceDiscountRatePct != nil {
+		params.DiscountRatePct = *globalCfg.FinanceDiscountRatePct
+	}
+	if err := params.Validate(); err != nil {
+		return params, NewClientError(400, err.Error())
+	}
+	return params, nil
+}
+
+// recommendationFinancials evaluates rec under params. It returns nil when
+// the record cannot be evaluated (e.g. it carries no term), so the drawer
+// still renders without the financials section.
+func recommendationFinancials(rec *config.RecommendationRecord, params finance.Params) *finance.Evaluation {
+	ev, err := finance.Evaluate(recommendationFromRecord(*rec), params)
+	if err != nil {
+		return nil
+	}
+	return &ev
+}
+
+// variantFinancials evaluates every cached recommendation that differs from
+// rec only in term and payment option, rec itself included, so the drawer
+// can compare e.g. 1yr no-upfront against 3yr all-upfront at the
+// configured cost of capital. Siblings share the ID up to the engine
+// segment (see scheduler.convertRecommendations for the layout).
+func (h *Handler) variantFinancials(ctx context.Context, rec *config.RecommendationRecord, params finance.Params) ([]VariantFinancials, error) {
+	prefix, ok := variantPrefix(rec.ID)
+	if !ok {
+		return nil, nil
+	}
+	recs, err := h.scheduler.ListRecommendations(ctx, config.RecommendationFilter{
+		Provider: rec.Provider, Service: rec.Service, Region: rec.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	var out []VariantFinancials
+	for i := range recs {
+		if !strings.HasPrefix(recs[i].ID, prefix) {
+			continue
+		}
+		if ev := recommendationFinancials(&recs[i], params); ev != nil {
+			out = append(out, VariantFinancials{ID: recs[i].ID, Evaluation: *ev})
+		}
+	}
+	return out, nil
+}
+
+// variantPrefix returns the record ID up to and including the engine
+// segment's trailing separator.
+func variantPrefix(id string) (string, bool) {
+	parts := strings.Split(id, "|")
+	if len(parts) != 8 {
+		return "", false
+	}
+	return strings.Join(parts[:6], "|") + "|", true
+}
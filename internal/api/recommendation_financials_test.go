@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func financialsFixture(ctx context.Context, rate *float64) (*Handler, *MockScheduler, config.RecommendationRecord) {
+	monthly, noMonthly, onDemand := 0.0, 82.0, 100.0
+	rec := config.RecommendationRecord{
+		ID: "aws|111111111111|ec2|us-east-1|m5.large||3|all-upfront", Provider: "aws", Service: "ec2",
+		Region: "us-east-1", ResourceType: "m5.large", Count: 1, Term: 3, Payment: "all-upfront",
+		UpfrontCost: 2700, MonthlyCost: &monthly, OnDemandCost: &onDemand, Savings: 25,
+	}
+	sibling := config.RecommendationRecord{
+		ID: "aws|111111111111|ec2|us-east-1|m5.large||3|no-upfront", Provider: "aws", Service: "ec2",
+		Region: "us-east-1", ResourceType: "m5.large", Count: 1, Term: 3, Payment: "no-upfront",
+		MonthlyCost: &noMonthly, OnDemandCost: &onDemand, Savings: 18,
+	}
+	other := config.RecommendationRecord{
+		ID: "aws|111111111111|ec2|us-east-1|c5.large||3|no-upfront", Provider: "aws", Service: "ec2",
+		Region: "us-east-1", ResourceType: "c5.large", Term: 3, Payment: "no-upfront", Savings: 10,
+	}
+
+	mockScheduler := new(MockScheduler)
+	mockScheduler.On("GetRecommendationByID", ctx, rec.ID).Return(&rec, ([]string)(nil), nil)
+	mockScheduler.On("ListRecommendations", ctx, config.RecommendationFilter{Provider: "aws", Service: "ec2", Region: "us-east-1"}).
+		Return([]config.RecommendationRecord{rec, sibling, other}, nil)
+	mockStore := new(MockConfigStore)
+	mockStore.On("GetRecommendationsFreshness", ctx).Return(nil, errors.New("db down"))
+	mockStore.On("GetGlobalConfig", ctx).Return(&config.GlobalConfig{FinanceDiscountRatePct: rate}, nil)
+
+	return &Handler{scheduler: mockScheduler, config: mockStore, apiKey: "test-key"}, mockScheduler, rec
+}
+
+func TestHandler_getRecommendationDetail_Financials(t *testing.T) {
+	ctx := context.Background()
+	handler, mockScheduler, rec := financialsFixture(ctx, nil)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers:               map[string]string{"x-api-key": "test-key"},
+		QueryStringParameters: map[string]string{"usage_drop_pct": "50"},
+	}
+	got, err := handler.getRecommendationDetail(ctx, req, rec.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Financials)
+	assert.Equal(t, 8.0, got.Financials.DiscountRatePct, "unset rate falls back to the default")
+	assert.Equal(t, 50.0, got.Financials.UsageDropPct)
+	assert.InDelta(t, 100, got.Financials.MonthlyBenefit, 1e-9)
+	assert.NotNil(t, got.Financials.IRRPct)
+	assert.Empty(t, got.FinancialVariants, "variants are opt-in")
+	mockScheduler.AssertNumberOfCalls(t, "ListRecommendations", 0)
+}
+
+func TestHandler_getRecommendationDetail_FinancialVariants(t *testing.T) {
+	ctx := context.Background()
+	rate := 30.0
+	handler, _, rec := financialsFixture(ctx, &rate)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers:               map[string]string{"x-api-key": "test-key"},
+		QueryStringParameters: map[string]string{"variants": "true"},
+	}
+	got, err := handler.getRecommendationDetail(ctx, req, rec.ID)
+	require.NoError(t, err)
+	require.Len(t, got.FinancialVariants, 2, "the c5.large pool is not a variant")
+	byPayment := map[string]VariantFinancials{}
+	for _, v := range got.FinancialVariants {
+		assert.Equal(t, 30.0, v.DiscountRatePct)
+		byPayment[v.PaymentOption] = v
+	}
+	// At a 30% cost of capital keeping the cash beats paying 2700 upfront.
+	assert.Greater(t, byPayment["no-upfront"].NPV, byPayment["all-upfront"].NPV)
+}
+
+func TestHandler_getRecommendationDetail_RejectsBadUsageDrop(t *testing.T) {
+	ctx := context.Background()
+	handler, _, rec := financialsFixture(ctx, nil)
+
+	for _, raw := range []string{"abc", "150"} {
+		req := &events.LambdaFunctionURLRequest{
+			Headers:               map[string]string{"x-api-key": "test-key"},
+			QueryStringParameters: map[string]string{"usage_drop_pct": raw},
+		}
+		_, err := handler.getRecommendationDetail(ctx, req, rec.ID)
+		ce, ok := IsClientError(err)
+		require.True(t, ok, "usage_drop_pct=%s: %v", raw, err)
+		assert.Equal(t, 400, ce.code)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+)
+
+// summarizeOverlap counts the RI recommendations whose usage a Savings Plan
+// recommendation already covers (the scheduler's Overlap* annotation) and
+// the monthly savings at risk if both are bought: each one's savings times
+// its covered share.
+func summarizeOverlap(recs []config.RecommendationRecord) (count int, savingsAtRisk float64) {
+	for i := range recs {
+		if pct := recs[i].OverlapCoveredPct; pct != nil {
+			count++
+			savingsAtRisk += recs[i].Savings * *pct / 100
+		}
+	}
+	return count, savingsAtRisk
+}
+
+// checkPlanDoubleCoverage refuses a plan that would buy one side of an
+// RI / Savings Plan overlap while the plan itself, or another enabled plan
+// sharing the recommendation's account, buys the other side. What each
+// plan buys is matched on the cached recommendations for accountIDs (the
+// plan's accounts) through its services' term, payment and filters (see
+// purchase.PlanBuys). Returns a 409 naming the overlapping recommendations.
+func (h *Handler) checkPlanDoubleCoverage(ctx context.Context, plan *config.PurchasePlan, accountIDs []string) error {
+	if len(accountIDs) == 0 || h.scheduler == nil {
+		return nil
+	}
+	recs, err := h.scheduler.ListRecommendations(ctx, config.RecommendationFilter{AccountIDs: accountIDs})
+	if err != nil {
+		return fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	conflicts, err := purchase.DoubleCoverage(ctx, h.config, plan, accountIDs, recs, func(rec *config.RecommendationRecord) bool {
+		return purchase.PlanBuys(plan, rec)
+	})
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return NewClientError(409, fmt.Sprintf("plan would schedule double coverage: %s; set allow_double_coverage to override",
+		strings.Join(conflicts, "; ")))
+}
+
+// checkAutoPurchaseDoubleCoverage runs checkPlanDoubleCoverage for a plan
+// being saved enabled with auto-purchase on accountIDs, unless the plan
+// allows double coverage: its executions would otherwise be refused only
+// when they fire.
+func (h *Handler) checkAutoPurchaseDoubleCoverage(ctx context.Context, plan *config.PurchasePlan, accountIDs []string) error {
+	if !plan.Enabled || !plan.AutoPurchase || plan.ShadowMode || plan.AllowDoubleCoverage {
+		return nil
+	}
+	return h.checkPlanDoubleCoverage(ctx, plan, accountIDs)
+}
+
+// planAccountIDs returns the IDs of planID's accounts.
+func (h *Handler) planAccountIDs(ctx context.Context, planID string) ([]string, error) {
+	accounts, err := h.config.GetPlanAccounts(ctx, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan accounts: %w", err)
+	}
+	ids := make([]string, len(accounts))
+	for i := range accounts {
+		ids[i] = accounts[i].ID
+	}
+	return ids, nil
+}
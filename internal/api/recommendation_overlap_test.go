@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const overlapPlanID = "11111111-1111-1111-1111-111111111111"
+
+// overlapFixture wires a plan buying EC2 RIs on one account, a second
+// enabled plan buying Compute Savings Plans on the same account, and a
+// cached EC2 recommendation the scheduler marked as 50% covered by the SP.
+func overlapFixture(ctx context.Context) (*Handler, *MockConfigStore) {
+	mockStore := new(MockConfigStore)
+	mockAuth := new(MockAuthService)
+	mockScheduler := new(MockScheduler)
+
+	ec2Plan := &config.PurchasePlan{
+		ID: overlapPlanID, Name: "EC2", Enabled: true,
+		Services:     map[string]config.ServiceConfig{"aws/ec2": {Provider: "aws", Service: "ec2", Enabled: true}},
+		RampSchedule: config.RampSchedule{StepIntervalDays: 7},
+	}
+	spPlan := config.PurchasePlan{
+		ID: "22222222-2222-2222-2222-222222222222", Name: "SP", Enabled: true,
+		Services: map[string]config.ServiceConfig{
+			"aws/savings-plans-compute": {Provider: "aws", Service: "savings-plans-compute", Enabled: true},
+		},
+	}
+	account := config.CloudAccount{ID: "33333333-3333-3333-3333-333333333333", Provider: "aws", ExternalID: "111111111111"}
+	pct, adjusted := 50.0, 2
+
+	mockAuth.On("ValidateSession", ctx, "admin-token").Return(&Session{UserID: "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"}, nil)
+	mockAuth.grantAdmin()
+	mockStore.On("GetPurchasePlan", ctx, overlapPlanID).Return(ec2Plan, nil)
+	mockStore.On("GetPlanAccounts", ctx, overlapPlanID).Return([]config.CloudAccount{account}, nil)
+	mockStore.On("ListPurchasePlans", ctx, config.PurchasePlanFilter{AccountIDs: []string{account.ID}}).
+		Return([]config.PurchasePlan{*ec2Plan, spPlan}, nil)
+	mockScheduler.On("ListRecommendations", ctx, config.RecommendationFilter{AccountIDs: []string{account.ID}}).
+		Return([]config.RecommendationRecord{{
+			ID: "aws|111111111111|ec2|us-east-1|m5.large||1|no-upfront", Provider: "aws", Service: "ec2",
+			Region: "us-east-1", ResourceType: "m5.large", Count: 4, Term: 1, Payment: "no-upfront", Savings: 120,
+			OverlapCoveredPct: &pct, OverlapAdjustedCount: &adjusted,
+			OverlapWith: []string{"aws|111111111111|savings-plans-compute|||1|no-upfront"},
+		}, {
+			ID: "aws|111111111111|savings-plans-compute|||1|no-upfront", Provider: "aws",
+			Service: "savings-plans-compute", Count: 1, Term: 1, Payment: "no-upfront", Savings: 50,
+		}}, nil)
+
+	return &Handler{config: mockStore, auth: mockAuth, scheduler: mockScheduler}, mockStore
+}
+
+func TestHandler_createPlannedPurchases_RefusesDoubleCoverage(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := overlapFixture(ctx)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"count": 1, "start_date": "2024-12-01"}`,
+	}
+	_, err := handler.createPlannedPurchases(ctx, req, overlapPlanID)
+	require.Error(t, err)
+	ce, ok := IsClientError(err)
+	require.True(t, ok)
+	assert.Equal(t, 409, ce.code)
+	assert.Contains(t, err.Error(), "ec2 m5.large us-east-1 overlaps savings-plans-compute (50% of its usage covered)")
+	mockStore.AssertNotCalled(t, "SavePurchaseExecution", mock.Anything, mock.Anything)
+}
+
+func TestHandler_createPlannedPurchases_AllowDoubleCoverageOverrides(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := overlapFixture(ctx)
+	mockStore.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).Return(nil).Once()
+	mockStore.On("UpdatePurchasePlan", ctx, mock.AnythingOfType("*config.PurchasePlan")).Return(nil)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"count": 1, "start_date": "2024-12-01", "allow_double_coverage": true}`,
+	}
+	result, err := handler.createPlannedPurchases(ctx, req, overlapPlanID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	mockStore.AssertNotCalled(t, "GetPlanAccounts", mock.Anything, mock.Anything)
+}
+
+func TestHandler_createPlannedPurchases_MatchesPlanFilters(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := overlapFixture(ctx)
+	// The EC2 plan only buys in eu-west-1, so it never buys the us-east-1
+	// recommendation the Savings Plan covers.
+	plan := &config.PurchasePlan{
+		ID: overlapPlanID, Name: "EC2", Enabled: true,
+		Services: map[string]config.ServiceConfig{"aws/ec2": {
+			Provider: "aws", Service: "ec2", Enabled: true, IncludeRegions: []string{"eu-west-1"},
+		}},
+		RampSchedule: config.RampSchedule{StepIntervalDays: 7},
+	}
+	mockStore.ExpectedCalls = nil
+	mockStore.On("GetPurchasePlan", ctx, overlapPlanID).Return(plan, nil)
+	mockStore.On("GetPlanAccounts", ctx, overlapPlanID).
+		Return([]config.CloudAccount{{ID: "33333333-3333-3333-3333-333333333333", Provider: "aws"}}, nil)
+	mockStore.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).Return(nil).Once()
+	mockStore.On("UpdatePurchasePlan", ctx, mock.AnythingOfType("*config.PurchasePlan")).Return(nil)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"count": 1, "start_date": "2024-12-01"}`,
+	}
+	result, err := handler.createPlannedPurchases(ctx, req, overlapPlanID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	mockStore.AssertNotCalled(t, "ListPurchasePlans", mock.Anything, mock.Anything)
+}
+
+func TestHandler_createPlan_RefusesAutoPurchaseDoubleCoverage(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := overlapFixture(ctx)
+
+	body := `{"name": "EC2 auto", "provider": "aws", "service": "ec2", "enabled": true, "auto_purchase": true,
+		"term": 1, "payment": "no-upfront", "target_coverage": 80, "ramp_schedule": "immediate",
+		"target_accounts": ["33333333-3333-3333-3333-333333333333"]}`
+	req := &events.LambdaFunctionURLRequest{Headers: map[string]string{"Authorization": "Bearer admin-token"}, Body: body}
+	_, err := handler.createPlan(ctx, req)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, 409, ce.code)
+	assert.Contains(t, err.Error(), "ec2 m5.large us-east-1 overlaps savings-plans-compute")
+	mockStore.AssertNotCalled(t, "CreatePurchasePlan", mock.Anything, mock.Anything)
+
+	// The same plan saved with the override is created.
+	var created *config.PurchasePlan
+	mockStore.On("CreatePurchasePlan", ctx, mock.AnythingOfType("*config.PurchasePlan")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*config.PurchasePlan) }).Return(nil)
+	mockStore.GetPurchasePlanFn = func(context.Context, string) (*config.PurchasePlan, error) { return created, nil }
+	mockStore.On("GetCloudAccount", ctx, "33333333-3333-3333-3333-333333333333").
+		Return(&config.CloudAccount{ID: "33333333-3333-3333-3333-333333333333", Provider: "aws"}, nil)
+	mockStore.On("SetPlanAccounts", ctx, mock.Anything, []string{"33333333-3333-3333-3333-333333333333"}).Return(nil)
+	req.Body = strings.Replace(body, `"auto_purchase": true,`, `"auto_purchase": true, "allow_double_coverage": true,`, 1)
+	result, err := handler.createPlan(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.(*config.PurchasePlan).AllowDoubleCoverage)
+}
+
+func TestHandler_patchPlan_RefusesEnablingAutoPurchaseWithDoubleCoverage(t *testing.T) {
+	ctx := context.Background()
+	handler, mockStore := overlapFixture(ctx)
+
+	req := &events.LambdaFunctionURLRequest{
+		Headers: map[string]string{"Authorization": "Bearer admin-token"},
+		Body:    `{"auto_purchase": true}`,
+	}
+	_, err := handler.patchPlan(ctx, req, overlapPlanID)
+	ce, ok := IsClientError(err)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, 409, ce.code)
+	mockStore.AssertNotCalled(t, "UpdatePurchasePlan", mock.Anything, mock.Anything)
+}
+
+func TestSummarizeOverlap(t *testing.T) {
+	half, full := 50.0, 100.0
+	resp := buildRecommendationsResponse([]config.RecommendationRecord{
+		{Savings: 120, OverlapCoveredPct: &half},
+		{Savings: 30, OverlapCoveredPct: &full},
+		{Savings: 500},
+	})
+	assert.Equal(t, 2, resp.Summary.OverlappingCount)
+	assert.InDelta(t, 90, resp.Summary.OverlapSavingsAtRisk, 1e-9)
+}
@@ -124,6 +124,11 @@ func (r *Router) registerRoutes() {
 		// permission grants).
 		{ExactPath: "/api/recommendations", Method: "GET", Handler: r.getRecommendationsHandler, Auth: AuthUser},
 		{ExactPath: "/api/recommendations/freshness", Method: "GET", Handler: r.getRecommendationsFreshnessHandler, Auth: AuthUser},
+		// SP/RI portfolio optimizer over the same cached, scoped
+		// recommendations (pkg/portfolio). Read-only: the chosen items
+		// come back ready for POST /api/purchases/execute.
+		{ExactPath: "/api/recommendations/optimize", Method: "GET", Handler: r.optimizePortfolioHandler, Auth: AuthUser},
+		{ExactPath: "/api/recommendations/optimize", Method: "POST", Handler: r.purchasePortfolioHandler, Auth: AuthUser},
 		// AuthUser: any signed-in user can trigger refresh; the handler
 		// then enforces requirePermission(view, recommendations) so
 		// users without that permission are rejected at the handler
@@ -203,6 +208,14 @@ func (r *Router) registerRoutes() {
 		{PathPrefix: "/api/purchases/planned/", PathSuffix: "/run", Method: "POST", Handler: r.runPlannedPurchaseHandler, Auth: AuthUser},
 		{PathPrefix: "/api/purchases/planned/", Method: "DELETE", Handler: r.deletePlannedPurchaseHandler, Auth: AuthUser},
 
+		// Purchase policy evaluations and approver signatures for the History
+		// detail view; view:purchases and plan scope checked in the handler.
+		{PathPrefix: "/api/purchases/", PathSuffix: "/policy-evaluations", Method: "GET", Handler: r.getPurchasePolicyEvaluationsHandler, Auth: AuthUser},
+
+		// Administrator override of a change freeze for one execution; the
+		// handler enforces admin and records the override for audit.
+		{PathPrefix: "/api/purchases/", PathSuffix: "/freeze-override", Method: "POST", Handler: r.overridePurchaseFreezeHandler, Auth: AuthUser},
+
 		// Generic purchase details (must come after more specific routes)
 		// — read-only; AuthUser so the history detail view works for everyone.
 		{PathPrefix: "/api/purchases/", Method: "GET", Handler: r.getPurchaseDetailsHandler, Auth: AuthUser},
@@ -297,6 +310,10 @@ func (r *Router) registerRoutes() {
 		// sub-tab (issue #754). AuthUser + allowed_accounts filter applied
 		// inside the handler, matching commitments endpoint precedent.
 		{ExactPath: "/api/inventory/coverage", Method: "GET", Handler: r.getCoverageBreakdownHandler, Auth: AuthUser},
+		// FOCUS 1.x CSV export of purchases, active commitments or savings
+		// snapshots (?dataset=). Same gate and allowed_accounts filtering
+		// as the endpoints each dataset mirrors.
+		{ExactPath: "/api/export/focus", Method: "GET", Handler: r.exportFOCUSHandler, Auth: AuthUser},
 
 		// RI Exchange endpoints — GETs are AuthUser (Convertible RIs,
 		// Reshape Recommendations, Exchange History pages all need this).
@@ -308,6 +325,7 @@ func (r *Router) registerRoutes() {
 		{ExactPath: "/api/ri-exchange/azure-instances/exchange", Method: "POST", Handler: r.executeAzureExchangeHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/instances", Method: "GET", Handler: r.listConvertibleRIsHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/target-offerings", Method: "GET", Handler: r.listTargetOfferingsHandler, Auth: AuthUser},
+		{ExactPath: "/api/ri-marketplace/offerings", Method: "GET", Handler: r.searchMarketplaceOfferingsHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/utilization", Method: "GET", Handler: r.getRIUtilizationHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/reshape-recommendations", Method: "GET", Handler: r.getReshapeRecommendationsHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/quote", Method: "POST", Handler: r.getExchangeQuoteHandler, Auth: AuthUser},
@@ -315,15 +333,65 @@ func (r *Router) registerRoutes() {
 		{ExactPath: "/api/ri-exchange/config", Method: "GET", Handler: r.getRIExchangeConfigHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/config", Method: "PUT", Handler: r.updateRIExchangeConfigHandler, Auth: AuthUser},
 		{ExactPath: "/api/ri-exchange/history", Method: "GET", Handler: r.getRIExchangeHistoryHandler, Auth: AuthUser},
+		{ExactPath: "/api/ri-exchange/modification-recommendations", Method: "GET", Handler: r.getRIModificationRecommendationsHandler, Auth: AuthUser},
+		{ExactPath: "/api/ri-exchange/modifications", Method: "GET", Handler: r.getRIModificationHistoryHandler, Auth: AuthUser},
+		{ExactPath: "/api/ri-exchange/modifications", Method: "POST", Handler: r.createRIModificationHandler, Auth: AuthUser},
+		{PathPrefix: "/api/ri-exchange/modifications/", PathSuffix: "/approve", Method: "POST", Handler: r.approveRIModificationHandler, Auth: AuthUser},
+		{PathPrefix: "/api/ri-exchange/modifications/", PathSuffix: "/reject", Method: "POST", Handler: r.rejectRIModificationHandler, Auth: AuthUser},
 		{PathPrefix: "/api/ri-exchange/approve/", Method: "POST", Handler: r.approveRIExchangeHandler, Auth: AuthPublic},
 		{PathPrefix: "/api/ri-exchange/reject/", Method: "POST", Handler: r.rejectRIExchangeHandler, Auth: AuthPublic},
 
+		// GCP resource-based CUD management: list with auto-renew state, toggle
+		// auto-renew, merge same-type commitments and run the auto-renew
+		// policy. view:purchases / execute:purchases are checked inside the
+		// handlers, together with the account_id scope.
+		{ExactPath: "/api/gcp/commitments", Method: "GET", Handler: r.listGCPCommitmentsHandler, Auth: AuthUser},
+		{ExactPath: "/api/gcp/commitments/merge", Method: "POST", Handler: r.mergeGCPCommitmentsHandler, Auth: AuthUser},
+		{ExactPath: "/api/gcp/commitments/auto-renew-policy", Method: "POST", Handler: r.applyGCPAutoRenewPolicyHandler, Auth: AuthUser},
+		{PathPrefix: "/api/gcp/commitments/", PathSuffix: "/auto-renew", Method: "PUT", Handler: r.setGCPCommitmentAutoRenewHandler, Auth: AuthUser},
+
+		// Purchase policy (CEL rules gating approval and execution). Reads take
+		// view:config; saving a version and dry runs take update:config,
+		// checked inside the handlers.
+		{ExactPath: "/api/purchase-policy", Method: "GET", Handler: r.getPurchasePolicyHandler, Auth: AuthUser},
+		{ExactPath: "/api/purchase-policy", Method: "PUT", Handler: r.updatePurchasePolicyHandler, Auth: AuthUser},
+		{ExactPath: "/api/purchase-policy/versions", Method: "GET", Handler: r.listPurchasePolicyVersionsHandler, Auth: AuthUser},
+		{ExactPath: "/api/purchase-policy/dry-run", Method: "POST", Handler: r.dryRunPurchasePolicyHandler, Auth: AuthUser},
+
+		// Account groups and approval chains (tiered sign-off before a
+		// purchase is approved). Reads take view:config; writes take
+		// update:config, checked inside the handlers.
+		{ExactPath: "/api/account-groups", Method: "GET", Handler: r.listAccountGroupsHandler, Auth: AuthUser},
+		{ExactPath: "/api/account-groups", Method: "POST", Handler: r.createAccountGroupHandler, Auth: AuthUser},
+		{PathPrefix: "/api/account-groups/", Method: "PUT", Handler: r.updateAccountGroupHandler, Auth: AuthUser},
+		{PathPrefix: "/api/account-groups/", Method: "DELETE", Handler: r.deleteAccountGroupHandler, Auth: AuthUser},
+		{ExactPath: "/api/commitment-budgets", Method: "GET", Handler: r.listCommitmentBudgetsHandler, Auth: AuthUser},
+		{ExactPath: "/api/commitment-budgets", Method: "POST", Handler: r.createCommitmentBudgetHandler, Auth: AuthUser},
+		{ExactPath: "/api/commitment-budgets/usage", Method: "GET", Handler: r.getCommitmentBudgetUsageHandler, Auth: AuthUser},
+		{PathPrefix: "/api/commitment-budgets/reservations/", PathSuffix: "/release", Method: "POST", Handler: r.releaseBudgetReservationHandler, Auth: AuthUser},
+		{PathPrefix: "/api/commitment-budgets/", Method: "PUT", Handler: r.updateCommitmentBudgetHandler, Auth: AuthUser},
+		{PathPrefix: "/api/commitment-budgets/", Method: "DELETE", Handler: r.deleteCommitmentBudgetHandler, Auth: AuthUser},
+		{ExactPath: "/api/freeze-windows", Method: "GET", Handler: r.listFreezeWindowsHandler, Auth: AuthUser},
+		{ExactPath: "/api/freeze-windows", Method: "POST", Handler: r.createFreezeWindowHandler, Auth: AuthUser},
+		{ExactPath: "/api/freeze-windows/overrides", Method: "GET", Handler: r.listFreezeOverridesHandler, Auth: AuthUser},
+		{PathPrefix: "/api/freeze-windows/", Method: "PUT", Handler: r.updateFreezeWindowHandler, Auth: AuthUser},
+		{PathPrefix: "/api/freeze-windows/", Method: "DELETE", Handler: r.deleteFreezeWindowHandler, Auth: AuthUser},
+		{ExactPath: "/api/shadow-purchases", Method: "GET", Handler: r.listShadowPurchasesHandler, Auth: AuthUser},
+		{ExactPath: "/api/shadow-purchases/report", Method: "GET", Handler: r.getShadowReportHandler, Auth: AuthUser},
+		{ExactPath: "/api/approval-chains", Method: "GET", Handler: r.listApprovalChainsHandler, Auth: AuthUser},
+		{ExactPath: "/api/approval-chains", Method: "POST", Handler: r.createApprovalChainHandler, Auth: AuthUser},
+		{PathPrefix: "/api/approval-chains/", Method: "PUT", Handler: r.updateApprovalChainHandler, Auth: AuthUser},
+		{PathPrefix: "/api/approval-chains/", Method: "DELETE", Handler: r.deleteApprovalChainHandler, Auth: AuthUser},
+
 		// Commitment Laddering endpoints (flag-gated default-off, issue #1336).
 		// GET returns all per-account ladder configs; PUT inserts or updates one.
 		// Both routes require update:config / view:config (checked inside the
 		// handler), consistent with the RI Exchange config precedent.
 		{ExactPath: "/api/ladder/configs", Method: "GET", Handler: r.getLadderConfigsHandler, Auth: AuthUser},
 		{ExactPath: "/api/ladder/configs", Method: "PUT", Handler: r.upsertLadderConfigHandler, Auth: AuthUser},
+		// POST replays a daily on-demand series through a saved or draft config
+		// (view:config, checked inside the handler); no cloud calls, no writes.
+		{ExactPath: "/api/ladder/backtest", Method: "POST", Handler: r.backtestLadderConfigHandler, Auth: AuthUser},
 
 		// Notification one-click unsubscribe (RFC 8058). AuthPublic: the signed
 		// token in the query string is the credential (mirrors approve/cancel).
@@ -512,6 +580,14 @@ func (r *Router) getRecommendationsFreshnessHandler(ctx context.Context, req *ev
 	return r.h.getRecommendationsFreshness(ctx, req)
 }
 
+func (r *Router) optimizePortfolioHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.optimizePortfolio(ctx, req, req.QueryStringParameters)
+}
+
+func (r *Router) purchasePortfolioHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.purchasePortfolio(ctx, req, req.QueryStringParameters)
+}
+
 func (r *Router) getRecommendationDetailHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
 	return r.h.getRecommendationDetail(ctx, req, params["id"])
 }
@@ -819,6 +895,10 @@ func (r *Router) listInventoryCommitmentsHandler(ctx context.Context, req *event
 	return r.h.listActiveCommitments(ctx, req, req.QueryStringParameters)
 }
 
+func (r *Router) exportFOCUSHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.exportFOCUS(ctx, req, req.QueryStringParameters)
+}
+
 func (r *Router) getCoverageBreakdownHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
 	return r.h.getCoverageBreakdown(ctx, req, req.QueryStringParameters)
 }
@@ -843,6 +923,10 @@ func (r *Router) listTargetOfferingsHandler(ctx context.Context, req *events.Lam
 	return r.h.listTargetOfferings(ctx, req)
 }
 
+func (r *Router) searchMarketplaceOfferingsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.searchMarketplaceOfferings(ctx, req)
+}
+
 func (r *Router) getRIUtilizationHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
 	return r.h.getRIUtilization(ctx, req)
 }
@@ -871,6 +955,44 @@ func (r *Router) getRIExchangeHistoryHandler(ctx context.Context, req *events.La
 	return r.h.getRIExchangeHistory(ctx, req)
 }
 
+func (r *Router) getRIModificationRecommendationsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.getRIModificationRecommendations(ctx, req)
+}
+
+func (r *Router) getRIModificationHistoryHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.getRIModificationHistory(ctx, req)
+}
+
+func (r *Router) createRIModificationHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.createRIModification(ctx, req)
+}
+
+func (r *Router) approveRIModificationHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.approveRIModification(ctx, req, params["id"])
+}
+
+func (r *Router) rejectRIModificationHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.rejectRIModification(ctx, req, params["id"])
+}
+
+// GCP commitment management route wrappers.
+
+func (r *Router) listGCPCommitmentsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listGCPCommitments(ctx, req)
+}
+
+func (r *Router) mergeGCPCommitmentsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.mergeGCPCommitments(ctx, req)
+}
+
+func (r *Router) applyGCPAutoRenewPolicyHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.applyGCPAutoRenewPolicy(ctx, req)
+}
+
+func (r *Router) setGCPCommitmentAutoRenewHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.setGCPCommitmentAutoRenew(ctx, req, params["id"])
+}
+
 func (r *Router) approveRIExchangeHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
 	if err := r.h.checkRateLimit(ctx, req, "approve_cancel_public"); err != nil {
 		return nil, err
@@ -1001,6 +1123,118 @@ func (r *Router) upsertLadderConfigHandler(ctx context.Context, req *events.Lamb
 	return r.h.upsertLadderConfig(ctx, req)
 }
 
+func (r *Router) backtestLadderConfigHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.backtestLadderConfig(ctx, req)
+}
+
 func (r *Router) unsubscribeHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
 	return r.h.unsubscribeHandler(ctx, req, params)
 }
+
+func (r *Router) getPurchasePolicyHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.getPurchasePolicy(ctx, req)
+}
+
+func (r *Router) updatePurchasePolicyHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.updatePurchasePolicy(ctx, req)
+}
+
+func (r *Router) listPurchasePolicyVersionsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listPurchasePolicyVersions(ctx, req)
+}
+
+func (r *Router) dryRunPurchasePolicyHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.dryRunPurchasePolicy(ctx, req)
+}
+
+func (r *Router) getPurchasePolicyEvaluationsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.getPurchasePolicyEvaluations(ctx, req, params["id"])
+}
+
+func (r *Router) listAccountGroupsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listAccountGroups(ctx, req)
+}
+
+func (r *Router) createAccountGroupHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.createAccountGroup(ctx, req)
+}
+
+func (r *Router) updateAccountGroupHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.updateAccountGroup(ctx, req, params["id"])
+}
+
+func (r *Router) deleteAccountGroupHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.deleteAccountGroup(ctx, req, params["id"])
+}
+
+func (r *Router) listCommitmentBudgetsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listCommitmentBudgets(ctx, req)
+}
+
+func (r *Router) createCommitmentBudgetHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.createCommitmentBudget(ctx, req)
+}
+
+func (r *Router) getCommitmentBudgetUsageHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.getCommitmentBudgetUsage(ctx, req)
+}
+
+func (r *Router) releaseBudgetReservationHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.releaseBudgetReservation(ctx, req, params["id"])
+}
+
+func (r *Router) updateCommitmentBudgetHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.updateCommitmentBudget(ctx, req, params["id"])
+}
+
+func (r *Router) deleteCommitmentBudgetHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.deleteCommitmentBudget(ctx, req, params["id"])
+}
+
+func (r *Router) listFreezeWindowsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listFreezeWindows(ctx, req)
+}
+
+func (r *Router) createFreezeWindowHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.createFreezeWindow(ctx, req)
+}
+
+func (r *Router) listFreezeOverridesHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listFreezeOverrides(ctx, req)
+}
+
+func (r *Router) updateFreezeWindowHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.updateFreezeWindow(ctx, req, params["id"])
+}
+
+func (r *Router) deleteFreezeWindowHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.deleteFreezeWindow(ctx, req, params["id"])
+}
+
+func (r *Router) listShadowPurchasesHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listShadowPurchases(ctx, req)
+}
+
+func (r *Router) getShadowReportHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.getShadowReport(ctx, req)
+}
+
+func (r *Router) overridePurchaseFreezeHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.overridePurchaseFreeze(ctx, req, params["id"])
+}
+
+func (r *Router) listApprovalChainsHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.listApprovalChains(ctx, req)
+}
+
+func (r *Router) createApprovalChainHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, _ map[string]string) (any, error) {
+	return r.h.createApprovalChain(ctx, req)
+}
+
+func (r *Router) updateApprovalChainHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.updateApprovalChain(ctx, req, params["id"])
+}
+
+func (r *Router) deleteApprovalChainHandler(ctx context.Context, req *events.LambdaFunctionURLRequest, params map[string]string) (any, error) {
+	return r.h.deleteApprovalChain(ctx, req, params["id"])
+}
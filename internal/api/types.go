@@ -13,6 +13,7 @@ import (
 	"github.com/LeanerCloud/CUDly/internal/email"
 	"github.com/LeanerCloud/CUDly/internal/oidc"
 	"github.com/LeanerCloud/CUDly/internal/scheduler"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 )
 
 // RateLimiterInterface defines the interface for rate limiting implementations
@@ -137,6 +138,19 @@ type BreakdownValue struct {
 type PurchaseManagerInterface interface {
 	ApproveExecution(ctx context.Context, execID, token, actor string) error
 	ApproveAndExecute(ctx context.Context, execID, actor string, transitionedBy *string) error
+	// EnforceApprovalPolicy runs the purchase policy's approval-stage
+	// check on its own, for approval paths that do not go through
+	// ApproveAndExecute (the pre-fire delay).
+	EnforceApprovalPolicy(ctx context.Context, execID, actor string, actorUserID *string) error
+	// CheckManualFreeze returns a *freeze.FrozenError when a change
+	// freeze holds the execution back and no administrator overrode it,
+	// for the pre-fire delay, which schedules without ApproveAndExecute.
+	CheckManualFreeze(ctx context.Context, execID string) error
+	// StartApprovalChain starts the approval chain an execution requires
+	// and emails its first stage; nil when no chain applies. A started
+	// chain whose first stage could not be emailed comes with a
+	// *purchase.ApprovalStageEmailError.
+	StartApprovalChain(ctx context.Context, execID string) (*config.ExecutionApprovalChain, error)
 	CancelExecution(ctx context.Context, execID, token, actor string) error
 }
 
@@ -412,6 +426,11 @@ type RecommendationsSummary struct {
 	TotalMonthlySavings float64 `json:"total_monthly_savings"`
 	TotalUpfrontCost    float64 `json:"total_upfront_cost"`
 	AvgPaybackMonths    float64 `json:"avg_payback_months"`
+	// OverlappingCount and OverlapSavingsAtRisk summarise the RI
+	// recommendations whose usage a Savings Plan recommendation already
+	// covers (see summarizeOverlap).
+	OverlappingCount     int     `json:"overlapping_count"`
+	OverlapSavingsAtRisk float64 `json:"overlap_savings_at_risk"`
 }
 
 // RecommendationsResponse holds the recommendations response.
@@ -458,6 +477,13 @@ type RecommendationDetailResponse struct {
 	// renders a "hidden by your override" banner when this field is present.
 	// Absent (null) means the rec is fully visible.
 	HiddenBy []string `json:"hidden_by,omitempty"`
+	// Financials is the NPV / IRR / discounted payback / worst-case view of
+	// the recommendation at the configured discount rate (pkg/finance).
+	// Absent when the record cannot be evaluated.
+	Financials *finance.Evaluation `json:"financials,omitempty"`
+	// FinancialVariants evaluates the cached term/payment variants of the
+	// same pool, the recommendation included. Only set with ?variants=true.
+	FinancialVariants []VariantFinancials `json:"financial_variants,omitempty"`
 }
 
 // PlansResponse holds the purchase plans response.
@@ -602,6 +628,12 @@ type DashboardSummaryResponse struct {
 	CurrentCoverage         float64                   `json:"current_coverage"`
 	TargetCoverage          float64                   `json:"target_coverage"`
 	YTDSavings              float64                   `json:"ytd_savings"`
+	OverlappingCount        int                       `json:"overlapping_count"`
+	OverlapSavingsAtRisk    float64                   `json:"overlap_savings_at_risk"`
+	// CommitmentBudgets is every enabled commitment budget's use and
+	// remainder in its current period. Budgets are organisation-wide, so
+	// the account filter does not narrow them.
+	CommitmentBudgets []config.CommitmentBudgetUsage `json:"commitment_budgets"`
 }
 
 // ServiceSavings holds savings data for a service.
@@ -643,6 +675,10 @@ type InventoryCommitment struct {
 	UpfrontCost      float64  `json:"upfront_cost"`
 	EstimatedSavings float64  `json:"estimated_savings"`
 	Count            int      `json:"count"`
+	// Source is "cudly" for commitments CUDly purchased and "external" for
+	// ones the inventory sync found that were bought elsewhere (console,
+	// Terraform, another tool).
+	Source string `json:"source"`
 }
 
 // InventoryCommitmentsResponse is the envelope returned by
@@ -772,6 +808,8 @@ type PlanRequest struct {
 	CustomIntervalDays     int      `json:"custom_interval_days,omitempty"`
 	AutoPurchase           bool     `json:"auto_purchase"`
 	Enabled                bool     `json:"enabled"`
+	ShadowMode             bool     `json:"shadow_mode"`
+	AllowDoubleCoverage    bool     `json:"allow_double_coverage,omitempty"`
 }
 
 // toPurchasePlan converts a PlanRequest to a config.PurchasePlan.
@@ -781,6 +819,8 @@ func (r *PlanRequest) toPurchasePlan() *config.PurchasePlan {
 		Name:                   r.Name,
 		Enabled:                r.Enabled,
 		AutoPurchase:           r.AutoPurchase,
+		ShadowMode:             r.ShadowMode,
+		AllowDoubleCoverage:    r.AllowDoubleCoverage,
 		NotificationDaysBefore: r.NotificationDaysBefore,
 		CreatedAt:              now,
 		UpdatedAt:              now,
@@ -888,6 +928,9 @@ func (r *PlanRequest) calculateNextExecutionDate(now time.Time, schedule config.
 type CreatePlannedPurchasesRequest struct {
 	StartDate string `json:"start_date"`
 	Count     int    `json:"count"`
+	// AllowDoubleCoverage overrides the refusal to schedule a plan that
+	// would buy both an RI and a Savings Plan for the same usage.
+	AllowDoubleCoverage bool `json:"allow_double_coverage,omitempty"`
 }
 
 // CreatePlannedPurchasesResponse represents the response after creating planned purchases.
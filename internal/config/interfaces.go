@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
 )
 
@@ -125,6 +126,10 @@ type StoreInterface interface {
 	// When non-nil the actor is stamped onto transitioned_by + transitioned_at; when nil,
 	// transitioned_by is set to NULL and transitioned_at is still set to NOW() for ordering.
 	TransitionExecutionStatus(ctx context.Context, executionID string, fromStatuses []string, toStatus string, actor *string) (*PurchaseExecution, error)
+	// DeferExecutionAtomic moves an execution from one of fromStatuses to
+	// "scheduled" and sets ScheduledExecutionAt to until in one CAS write,
+	// failing like TransitionExecutionStatus when it cannot.
+	DeferExecutionAtomic(ctx context.Context, executionID string, fromStatuses []string, until time.Time) (*PurchaseExecution, error)
 	// SetCancelledBy stamps canceled_by and the legacy cancelled_by column on
 	// an execution without overwriting any other columns. Used after
 	// TransitionExecutionStatus to fold the actor attribution into the same
@@ -323,6 +328,189 @@ type StoreInterface interface {
 	CancelPendingExchangesByOrigin(ctx context.Context, origin common.ExchangeOrigin) (int64, error)
 	GetStaleProcessingExchanges(ctx context.Context, olderThan time.Duration) ([]RIExchangeRecord, error)
 
+	// EC2 RI modifications (ri_modification_history, migration 000103).
+	SaveRIModificationRecord(ctx context.Context, record *RIModificationRecord) error
+	GetRIModificationRecord(ctx context.Context, id string) (*RIModificationRecord, error)
+	GetRIModificationHistory(ctx context.Context, since time.Time, limit int) ([]RIModificationRecord, error)
+	// TransitionRIModificationStatus atomically moves a modification from
+	// fromStatus to toStatus, stamping actor (nil for system paths) as
+	// transitioned_by. Returns an error naming the current status when the
+	// record is not in fromStatus.
+	TransitionRIModificationStatus(ctx context.Context, id string, fromStatus string, toStatus string, actor *string) (*RIModificationRecord, error)
+	// CompleteRIModification marks a modification completed with the AWS
+	// ReservedInstancesModificationId and the approver's email.
+	CompleteRIModification(ctx context.Context, id string, modificationID string, approvedBy string) error
+	FailRIModification(ctx context.Context, id string, errorMsg string) error
+
+	// Azure reservation refunds (azure_refund_ledger, migration 000104).
+	// SaveAzureRefund is idempotent per purchase_id.
+	SaveAzureRefund(ctx context.Context, record *AzureRefundRecord) error
+	// SumAzureRefunds totals the refunds recorded for billingProfile in
+	// currency since the given time.
+	SumAzureRefunds(ctx context.Context, billingProfile string, currency string, since time.Time) (float64, error)
+
+	// Commitment inventory (commitments_inventory, migration 000107).
+	// UpsertCommitmentInventory writes one account's synced commitments and
+	// deletes that account's unseen rows whose sync scope is in
+	// listedScopes.
+	UpsertCommitmentInventory(ctx context.Context, cloudAccountID string, records []CommitmentInventoryRecord, listedScopes []string) error
+	// GetActiveCommitmentInventory returns the synced commitments still live
+	// as of asOf, scoped like GetActivePurchaseHistory.
+	GetActiveCommitmentInventory(ctx context.Context, asOf time.Time, accountIDs []string, externalIDsByProvider map[string][]string) ([]CommitmentInventoryRecord, error)
+
+	// Commitment renewals (commitment_renewals, migration 000108).
+	// CreateCommitmentRenewal inserts a renewal and sets its ID and
+	// timestamps.
+	CreateCommitmentRenewal(ctx context.Context, renewal *CommitmentRenewal) error
+	// ListCommitmentRenewals returns renewals soonest source expiry first.
+	ListCommitmentRenewals(ctx context.Context, filter CommitmentRenewalFilter) ([]CommitmentRenewal, error)
+	// UpdateCommitmentRenewalStatus moves a proposed renewal to status,
+	// recording the replacement commitment when known. Returns an error
+	// wrapping ErrNotFound when no proposed renewal has that ID.
+	UpdateCommitmentRenewalStatus(ctx context.Context, id, status string, replacementCommitmentID *string) error
+
+	// Purchase policy (purchase_policies, purchase_policy_evaluations and
+	// purchase_execution_approvals, migration 000109).
+	// CreatePurchasePolicy saves p as the next version and sets its Version
+	// and CreatedAt.
+	CreatePurchasePolicy(ctx context.Context, p *PurchasePolicy) error
+	// GetLatestPurchasePolicy returns the version in force, or nil, nil
+	// when no policy was ever saved.
+	GetLatestPurchasePolicy(ctx context.Context) (*PurchasePolicy, error)
+	// GetPurchasePolicy returns one version, or an error wrapping
+	// ErrNotFound.
+	GetPurchasePolicy(ctx context.Context, version int) (*PurchasePolicy, error)
+	// ListPurchasePolicies returns versions newest first.
+	ListPurchasePolicies(ctx context.Context, limit int) ([]PurchasePolicy, error)
+	// RecordPolicyEvaluation inserts an evaluation and sets its ID and
+	// EvaluatedAt.
+	RecordPolicyEvaluation(ctx context.Context, eval *PolicyEvaluation) error
+	// ListPolicyEvaluations returns an execution's evaluations oldest first.
+	ListPolicyEvaluations(ctx context.Context, executionID string) ([]PolicyEvaluation, error)
+	// RecordExecutionApproval adds an approver's signature. Signing twice
+	// is a no-op.
+	RecordExecutionApproval(ctx context.Context, approval *ExecutionApproval) error
+	// ListExecutionApprovals returns an execution's signatures oldest first.
+	ListExecutionApprovals(ctx context.Context, executionID string) ([]ExecutionApproval, error)
+
+	// Account groups (account_groups, migration 000110).
+	// ListAccountGroups returns every group ordered by name.
+	ListAccountGroups(ctx context.Context) ([]AccountGroup, error)
+	// CreateAccountGroup inserts g and sets its ID and timestamps.
+	CreateAccountGroup(ctx context.Context, g *AccountGroup) error
+	// UpdateAccountGroup rewrites g's name, description and accounts.
+	// Returns an error wrapping ErrNotFound when no group has g.ID.
+	UpdateAccountGroup(ctx context.Context, g *AccountGroup) error
+	// DeleteAccountGroup removes a group. Returns an error wrapping
+	// ErrNotFound when no group has that ID.
+	DeleteAccountGroup(ctx context.Context, id string) error
+
+	// Approval chains (approval_chains, purchase_approval_chain_runs and
+	// purchase_approval_stage_decisions, migration 000110).
+	// ListApprovalChains returns every chain by priority, then name.
+	ListApprovalChains(ctx context.Context) ([]ApprovalChain, error)
+	// CreateApprovalChain inserts c and sets its ID and timestamps.
+	CreateApprovalChain(ctx context.Context, c *ApprovalChain) error
+	// UpdateApprovalChain rewrites c. Returns an error wrapping ErrNotFound
+	// when no chain has c.ID.
+	UpdateApprovalChain(ctx context.Context, c *ApprovalChain) error
+	// DeleteApprovalChain removes a chain; runs already started keep their
+	// snapshot. Returns an error wrapping ErrNotFound when no chain has
+	// that ID.
+	DeleteApprovalChain(ctx context.Context, id string) error
+	// StartExecutionApprovalChain records the chain an execution goes
+	// through and sets its StartedAt. It returns false, leaving run
+	// untouched, when the execution already has one.
+	StartExecutionApprovalChain(ctx context.Context, run *ExecutionApprovalChain) (bool, error)
+	// GetExecutionApprovalChain returns an execution's chain, or nil, nil
+	// when it has none.
+	GetExecutionApprovalChain(ctx context.Context, executionID string) (*ExecutionApprovalChain, error)
+	// AdvanceExecutionApprovalChain moves an execution's chain from stage
+	// from to the next one, stamping completed_at past the last stage. It
+	// returns false when the chain is no longer at stage from.
+	AdvanceExecutionApprovalChain(ctx context.Context, executionID string, from int) (bool, error)
+	// RecordApprovalStageDecision adds an approver's sign-off on a stage.
+	// Signing twice is a no-op.
+	RecordApprovalStageDecision(ctx context.Context, d *ApprovalStageDecision) error
+	// ListApprovalStageDecisions returns an execution's sign-offs by stage,
+	// oldest first.
+	ListApprovalStageDecisions(ctx context.Context, executionID string) ([]ApprovalStageDecision, error)
+
+	// Commitment budgets (commitment_budgets and budget_reservations,
+	// migration 000111).
+	// ListCommitmentBudgets returns every budget ordered by name.
+	ListCommitmentBudgets(ctx context.Context) ([]CommitmentBudget, error)
+	// CreateCommitmentBudget inserts b and sets its ID and timestamps.
+	CreateCommitmentBudget(ctx context.Context, b *CommitmentBudget) error
+	// UpdateCommitmentBudget rewrites b. Returns an error wrapping
+	// ErrNotFound when no budget has b.ID.
+	UpdateCommitmentBudget(ctx context.Context, b *CommitmentBudget) error
+	// DeleteCommitmentBudget removes a budget. Returns an error wrapping
+	// ErrNotFound when no budget has that ID.
+	DeleteCommitmentBudget(ctx context.Context, id string) error
+	// ReserveCommitmentBudget checks r against every enabled budget that
+	// applies to it and records it, atomically with respect to other
+	// reservations, setting its ID, Status and ReservedAt. It returns a
+	// *budget.ExceededError, reserving nothing, when a cap would be
+	// exceeded. A live reservation with the same Source and Reference is
+	// returned in r as is.
+	ReserveCommitmentBudget(ctx context.Context, r *BudgetReservation) error
+	// CommitBudgetReservation settles a reservation at the amounts bought.
+	// Returns an error wrapping ErrNotFound when no unreleased reservation
+	// has that ID.
+	CommitBudgetReservation(ctx context.Context, id string, upfrontUSD, hourlyUSD float64) error
+	// ReleaseBudgetReservation gives a reservation back. Releasing twice
+	// is a no-op.
+	ReleaseBudgetReservation(ctx context.Context, id, reason string) error
+	// CreditCommitmentBudget records a committed credit, r with negative
+	// amounts, for a purchase revoked after it committed. Crediting the
+	// same Source and Reference twice is a no-op.
+	CreditCommitmentBudget(ctx context.Context, r *BudgetReservation) error
+	// GetCommitmentBudgetUsage returns every enabled budget's usage in the
+	// period containing at.
+	GetCommitmentBudgetUsage(ctx context.Context, at time.Time) ([]CommitmentBudgetUsage, error)
+
+	// Change-freeze windows (freeze_windows and freeze_overrides,
+	// migration 000112).
+	// ListFreezeWindows returns every window ordered by start.
+	ListFreezeWindows(ctx context.Context) ([]FreezeWindow, error)
+	// CreateFreezeWindow inserts w and sets its ID and timestamps.
+	CreateFreezeWindow(ctx context.Context, w *FreezeWindow) error
+	// UpdateFreezeWindow rewrites w. Returns an error wrapping ErrNotFound
+	// when no window has w.ID.
+	UpdateFreezeWindow(ctx context.Context, w *FreezeWindow) error
+	// DeleteFreezeWindow removes a window. Returns an error wrapping
+	// ErrNotFound when no window has that ID.
+	DeleteFreezeWindow(ctx context.Context, id string) error
+	// RecordFreezeOverride records an administrator's override, setting its
+	// ID and CreatedAt.
+	RecordFreezeOverride(ctx context.Context, o *FreezeOverride) error
+	// GetLatestFreezeOverride returns an execution's most recent override,
+	// or nil, nil when it has none.
+	GetLatestFreezeOverride(ctx context.Context, executionID string) (*FreezeOverride, error)
+	// ListFreezeOverrides returns the most recent overrides, newest first.
+	ListFreezeOverrides(ctx context.Context, limit int) ([]FreezeOverride, error)
+
+	// Shadow purchases (shadow_purchases, migration 000113).
+	// RecordPlanShadowPurchases replaces an execution's shadow purchases
+	// with ps in one transaction, setting their IDs and CreatedAt.
+	RecordPlanShadowPurchases(ctx context.Context, executionID string, ps []ShadowPurchase) error
+	// SaveShadowLadderRun inserts a shadow-mode ladder run and its shadow
+	// purchases in one transaction and returns the persisted run.
+	SaveShadowLadderRun(ctx context.Context, run *LadderRunDB, ps []ShadowPurchase) (*LadderRunDB, error)
+	// GetShadowLadderCommitUSDHr returns the hourly commitment of a ladder
+	// config's shadow purchases whose term has not ended at at.
+	GetShadowLadderCommitUSDHr(ctx context.Context, configID string, at time.Time) (float64, error)
+	// ListShadowPurchases returns the shadow purchases matching filter,
+	// most recent purchase first.
+	ListShadowPurchases(ctx context.Context, filter ShadowPurchaseFilter) ([]ShadowPurchase, error)
+	// ListShadowPurchasesToEvaluate returns the shadow purchases with
+	// unscored days before at, oldest purchase first.
+	ListShadowPurchasesToEvaluate(ctx context.Context, at time.Time) ([]ShadowPurchase, error)
+	// SaveShadowEvaluation stores a shadow purchase's evaluation. Returns
+	// an error wrapping ErrNotFound when no purchase has p.ID.
+	SaveShadowEvaluation(ctx context.Context, p *ShadowPurchase) error
+
 	// Cloud accounts
 	CreateCloudAccount(ctx context.Context, account *CloudAccount) error
 	GetCloudAccount(ctx context.Context, id string) (*CloudAccount, error)
@@ -398,6 +586,18 @@ type StoreInterface interface {
 	GetRIUtilizationCache(ctx context.Context, region string, lookbackDays int) (*RIUtilizationCacheEntry, error)
 	UpsertRIUtilizationCache(ctx context.Context, region string, lookbackDays int, payload []byte, fetchedAt time.Time) error
 
+	// CUR usage (migration 000101). The ingestion job records each export
+	// object under its source location with the ETag it was read at;
+	// ReplaceCURObject swaps in an object's hourly aggregates atomically and
+	// DeleteCURObjects prunes objects that left the export. CURPoolHours and
+	// CURCommitmentHours sum the aggregates across objects for the Cost
+	// Explorer replacements in providers/aws/recommendations.
+	CURObjectETags(ctx context.Context, source string) (map[string]string, error)
+	ReplaceCURObject(ctx context.Context, source, key, etag string, pools []cur.PoolHour, commitments []cur.CommitmentHour) error
+	DeleteCURObjects(ctx context.Context, source string, keys []string) error
+	CURPoolHours(ctx context.Context, q cur.Query) ([]cur.PoolHour, error)
+	CURCommitmentHours(ctx context.Context, q cur.Query) ([]cur.CommitmentHour, error)
+
 	// Account registrations (self-service enrollment via federation IaC)
 	CreateAccountRegistration(ctx context.Context, reg *AccountRegistration) error
 	GetAccountRegistration(ctx context.Context, id string) (*AccountRegistration, error)
@@ -441,14 +641,16 @@ type StoreInterface interface {
 	// supported — fn must not call WithTx recursively.
 	WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error
 
-	// Ladder configuration (per-account, per-provider).
+	// Ladder configuration (per-account, per-provider, per-service).
 	// GetLadderConfigs returns all rows, newest first.
-	// GetLadderConfig returns the single row for (cloudAccountID, provider),
-	// or (nil, nil) when no row exists.
-	// UpsertLadderConfig inserts or updates via the UNIQUE(cloud_account_id, provider)
-	// constraint and returns the persisted row with all DB-stamped fields populated.
+	// GetLadderConfig returns the single row for (cloudAccountID, provider,
+	// service), or (nil, nil) when no row exists; service is "" for the
+	// compute ladder.
+	// UpsertLadderConfig inserts or updates via the
+	// UNIQUE(cloud_account_id, provider, service) constraint and returns the
+	// persisted row with all DB-stamped fields populated.
 	GetLadderConfigs(ctx context.Context) ([]LadderConfigDB, error)
-	GetLadderConfig(ctx context.Context, cloudAccountID, provider string) (*LadderConfigDB, error)
+	GetLadderConfig(ctx context.Context, cloudAccountID, provider, service string) (*LadderConfigDB, error)
 	UpsertLadderConfig(ctx context.Context, cfg *LadderConfigDB) (*LadderConfigDB, error)
 
 	// Ladder run/tranche persistence (migration 000080/000081, PR-2).
@@ -0,0 +1,72 @@
+package config
+
+// Admits reports whether rec passes c's engine, region, resource-type and
+// min-count filters. Enabled is not consulted: callers decide what a
+// disabled config means for them (hidden on the dashboard, not bought by a
+// plan).
+func (c *ServiceConfig) Admits(rec *RecommendationRecord) bool {
+	return c.AdmitsEngine(rec.Engine) &&
+		c.AdmitsRegion(rec.Region) &&
+		c.AdmitsType(rec.ResourceType) &&
+		c.AdmitsCount(rec.Count)
+}
+
+// AdmitsEngine applies the engine include/exclude rule with a "lax for
+// engine-less services" carve-out.
+//
+// Some recommendation services (Savings Plans, Compute) carry no engine
+// field. Strictly applying an IncludeEngines list would silently drop every
+// such rec the moment a user added an engine include for a different service
+// on the same (account, provider). The carve-out: when engine == "" we skip
+// the engine check entirely and let the region / type filters decide.
+func (c *ServiceConfig) AdmitsEngine(engine string) bool {
+	if engine == "" {
+		return true
+	}
+	return inListRule(engine, c.IncludeEngines, c.ExcludeEngines)
+}
+
+// AdmitsRegion applies the region include/exclude rule.
+func (c *ServiceConfig) AdmitsRegion(region string) bool {
+	return inListRule(region, c.IncludeRegions, c.ExcludeRegions)
+}
+
+// AdmitsType applies the resource-type include/exclude rule.
+func (c *ServiceConfig) AdmitsType(resourceType string) bool {
+	return inListRule(resourceType, c.IncludeTypes, c.ExcludeTypes)
+}
+
+// AdmitsCount implements the read-time half of the GUI/CLI --min-count
+// filter: a rec is dropped when its count is below MinCount. A MinCount of
+// 0 (the default) disables the filter, matching the CLI flag's
+// 0-no-floor semantics. count is the persisted RecommendationRecord.Count
+// (already grace-suppression-adjusted by the time this runs, so the floor
+// applies to the net count the user would actually see).
+func (c *ServiceConfig) AdmitsCount(count int) bool {
+	if c.MinCount <= 0 {
+		return true
+	}
+	return count >= c.MinCount
+}
+
+// inListRule returns true when value is allowed by an include/exclude pair:
+//   - empty include list = allow anything not on the exclude list
+//   - non-empty include list = value must be on it AND not on exclude
+//
+// Order matters: exclude wins over include when the same value appears on both.
+func inListRule(value string, include, exclude []string) bool {
+	for _, e := range exclude {
+		if e == value {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, i := range include {
+		if i == value {
+			return true
+		}
+	}
+	return false
+}
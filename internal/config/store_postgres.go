@@ -83,7 +83,10 @@ func getGlobalConfigFrom(ctx context.Context, q globalConfigExecutor) (*GlobalCo
 		       COALESCE(laddering_enabled, false),
 		       COALESCE(ladder_execution_enabled, false),
 		       offering_class,
-		       require_different_approver
+		       require_different_approver,
+		       recommendation_source, native_rec_lookback_days,
+		       native_rec_percentile, native_rec_max_break_even_months,
+		       finance_discount_rate_pct
 		FROM global_config
 		WHERE id = 1
 	`
@@ -117,6 +120,11 @@ func getGlobalConfigFrom(ctx context.Context, q globalConfigExecutor) (*GlobalCo
 		&config.LadderExecutionEnabled,
 		&config.OfferingClass,
 		&config.RequireDifferentApprover,
+		&config.RecommendationSource,
+		&config.NativeRecLookbackDays,
+		&config.NativeRecPercentile,
+		&config.NativeRecMaxBreakEvenMonths,
+		&config.FinanceDiscountRatePct,
 	)
 
 	if err != nil {
@@ -140,6 +148,9 @@ func getGlobalConfigFrom(ctx context.Context, q globalConfigExecutor) (*GlobalCo
 				RecommendationsLookbackDays:    DefaultRecommendationsLookbackDays,
 				PurchaseDelayHours:             DefaultPurchaseDelayHours,
 				OfferingClass:                  "convertible",
+				RecommendationSource:           RecommendationSourceVendor,
+				NativeRecLookbackDays:          DefaultNativeRecLookbackDays,
+				NativeRecPercentile:            DefaultNativeRecPercentile,
 			}, nil
 		}
 		return nil, fmt.Errorf("failed to get global config: %w", err)
@@ -217,8 +228,11 @@ func saveGlobalConfigWith(ctx context.Context, q globalConfigExecutor, config *G
 			grace_period_days,
 			recommendations_cache_stale_hours, recommendations_lookback_days,
 			purchase_delay_hours, laddering_enabled, ladder_execution_enabled, offering_class,
-			require_different_approver
-		) VALUES (1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+			require_different_approver,
+			recommendation_source, native_rec_lookback_days,
+			native_rec_percentile, native_rec_max_break_even_months,
+			finance_discount_rate_pct
+		) VALUES (1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
 		ON CONFLICT (id) DO UPDATE SET
 			enabled_providers = $1,
 			notification_email = $2,
@@ -244,6 +258,11 @@ func saveGlobalConfigWith(ctx context.Context, q globalConfigExecutor, config *G
 			ladder_execution_enabled = $22,
 			offering_class = $23,
 			require_different_approver = $24,
+			recommendation_source = $25,
+			native_rec_lookback_days = $26,
+			native_rec_percentile = $27,
+			native_rec_max_break_even_months = $28,
+			finance_discount_rate_pct = $29,
 			updated_at = NOW()
 	`
 
@@ -287,6 +306,21 @@ func saveGlobalConfigWith(ctx context.Context, q globalConfigExecutor, config *G
 		offeringClass = "convertible"
 	}
 
+	// Zero native settings mean "use the default"; store the resolved value
+	// so the row matches the ErrNoRows defaults.
+	recommendationSource := config.RecommendationSource
+	if recommendationSource == "" {
+		recommendationSource = RecommendationSourceVendor
+	}
+	nativeRecLookbackDays := config.NativeRecLookbackDays
+	if nativeRecLookbackDays == 0 {
+		nativeRecLookbackDays = DefaultNativeRecLookbackDays
+	}
+	nativeRecPercentile := config.NativeRecPercentile
+	if nativeRecPercentile == 0 {
+		nativeRecPercentile = DefaultNativeRecPercentile
+	}
+
 	_, err := q.Exec(ctx, query,
 		config.EnabledProviders,
 		config.NotificationEmail,
@@ -312,6 +346,11 @@ func saveGlobalConfigWith(ctx context.Context, q globalConfigExecutor, config *G
 		config.LadderExecutionEnabled,
 		offeringClass,
 		config.RequireDifferentApprover,
+		recommendationSource,
+		nativeRecLookbackDays,
+		nativeRecPercentile,
+		config.NativeRecMaxBreakEvenMonths,
+		config.FinanceDiscountRatePct,
 	)
 
 	if err != nil {
@@ -514,8 +553,9 @@ func (s *PostgresStore) CreatePurchasePlan(ctx context.Context, plan *PurchasePl
 		INSERT INTO purchase_plans (
 			id, name, enabled, auto_purchase, notification_days_before,
 			services, ramp_schedule, created_at, updated_at,
-			next_execution_date, last_execution_date, last_notification_sent
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			next_execution_date, last_execution_date, last_notification_sent,
+			shadow_mode, allow_double_coverage
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err = s.db.Exec(ctx, query,
@@ -531,6 +571,8 @@ func (s *PostgresStore) CreatePurchasePlan(ctx context.Context, plan *PurchasePl
 		plan.NextExecutionDate,
 		plan.LastExecutionDate,
 		plan.LastNotificationSent,
+		plan.ShadowMode,
+		plan.AllowDoubleCoverage,
 	)
 
 	if err != nil {
@@ -543,7 +585,8 @@ func (s *PostgresStore) CreatePurchasePlan(ctx context.Context, plan *PurchasePl
 const purchasePlanSelectCols = `
 	SELECT id, name, enabled, auto_purchase, notification_days_before,
 	       services, ramp_schedule, created_at, updated_at,
-	       next_execution_date, last_execution_date, last_notification_sent
+	       next_execution_date, last_execution_date, last_notification_sent,
+	       shadow_mode, allow_double_coverage
 	FROM purchase_plans`
 
 // scanPurchasePlanRow deserialises one purchase_plans row returned by QueryRow
@@ -567,6 +610,8 @@ func scanPurchasePlanRow(row pgx.Row) (*PurchasePlan, error) {
 		&nextExecDate,
 		&lastExecDate,
 		&lastNotifSent,
+		&plan.ShadowMode,
+		&plan.AllowDoubleCoverage,
 	)
 	if err != nil {
 		return nil, err
@@ -726,7 +771,9 @@ func (s *PostgresStore) UpdatePurchasePlanTx(ctx context.Context, tx pgx.Tx, pla
 			updated_at = $8,
 			next_execution_date = $9,
 			last_execution_date = $10,
-			last_notification_sent = $11
+			last_notification_sent = $11,
+			shadow_mode = $12,
+			allow_double_coverage = $13
 		WHERE id = $1
 	`
 
@@ -742,6 +789,8 @@ func (s *PostgresStore) UpdatePurchasePlanTx(ctx context.Context, tx pgx.Tx, pla
 		plan.NextExecutionDate,
 		plan.LastExecutionDate,
 		plan.LastNotificationSent,
+		plan.ShadowMode,
+		plan.AllowDoubleCoverage,
 	)
 
 	if err != nil {
@@ -793,7 +842,7 @@ func buildListPlansQuery(accountIDs []string) (query string, args []any) {
 			SELECT id, name, enabled, auto_purchase, notification_days_before,
 			       services, ramp_schedule, created_at, updated_at,
 			       next_execution_date, last_execution_date, last_notification_sent,
-			       false AS unassigned
+			       shadow_mode, allow_double_coverage, false AS unassigned
 			FROM purchase_plans
 			ORDER BY created_at DESC
 		`, nil
@@ -808,7 +857,7 @@ func buildListPlansQuery(accountIDs []string) (query string, args []any) {
 		SELECT DISTINCT pp.id, pp.name, pp.enabled, pp.auto_purchase, pp.notification_days_before,
 		       pp.services, pp.ramp_schedule, pp.created_at, pp.updated_at,
 		       pp.next_execution_date, pp.last_execution_date, pp.last_notification_sent,
-		       (NOT EXISTS (SELECT 1 FROM plan_accounts WHERE plan_id = pp.id)) AS unassigned
+		       pp.shadow_mode, pp.allow_double_coverage, (NOT EXISTS (SELECT 1 FROM plan_accounts WHERE plan_id = pp.id)) AS unassigned
 		FROM purchase_plans pp
 		LEFT JOIN plan_accounts pa ON pa.plan_id = pp.id
 		WHERE pa.account_id IN (%s)
@@ -851,6 +900,8 @@ func (s *PostgresStore) ListPurchasePlans(ctx context.Context, filter PurchasePl
 			&nextExecDate,
 			&lastExecDate,
 			&lastNotifSent,
+			&plan.ShadowMode,
+			&plan.AllowDoubleCoverage,
 			&plan.Unassigned,
 		)
 		if err != nil {
@@ -1036,7 +1087,44 @@ func (s *PostgresStore) TransitionExecutionStatus(ctx context.Context, execution
 		SET status = $2, updated_at = NOW(),
 		    transitioned_by = $4, transitioned_at = NOW()
 		WHERE execution_id = $1 AND status = ANY($3)
-		RETURNING plan_id, execution_id, status, step_number, scheduled_date,
+		RETURNING ` + transitionedExecutionCols
+
+	records, err := s.queryExecutions(ctx, query, executionID, toStatus, fromStatuses, actor)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, s.transitionMissError(ctx, executionID, toStatus)
+	}
+	return &records[0], nil
+}
+
+// DeferExecutionAtomic moves an execution from one of fromStatuses to
+// 'scheduled' with scheduled_execution_at = until in a single CAS UPDATE, so
+// the row is never left scheduled without a fire time. It fails like
+// TransitionExecutionStatus when the execution is gone or not in an allowed
+// status.
+func (s *PostgresStore) DeferExecutionAtomic(ctx context.Context, executionID string, fromStatuses []string, until time.Time) (*PurchaseExecution, error) {
+	query := `
+		UPDATE purchase_executions
+		SET status = 'scheduled', scheduled_execution_at = $3, updated_at = NOW(),
+		    transitioned_by = NULL, transitioned_at = NOW()
+		WHERE execution_id = $1 AND status = ANY($2)
+		RETURNING ` + transitionedExecutionCols
+
+	records, err := s.queryExecutions(ctx, query, executionID, fromStatuses, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, s.transitionMissError(ctx, executionID, "scheduled")
+	}
+	return &records[0], nil
+}
+
+// transitionedExecutionCols is the RETURNING list of the execution CAS
+// updates, in queryExecutions' scan order.
+const transitionedExecutionCols = `plan_id, execution_id, status, step_number, scheduled_date,
 		          notification_sent, approval_token, recommendations,
 		          total_upfront_cost, estimated_savings, completed_at, error, expires_at,
 		          cloud_account_id, source, approved_by, cancelled_by, capacity_percent,
@@ -1046,31 +1134,24 @@ func (s *PostgresStore) TransitionExecutionStatus(ctx context.Context, execution
 		          idempotency_key, scheduled_execution_at
 	`
 
-	records, err := s.queryExecutions(ctx, query, executionID, toStatus, fromStatuses, actor)
-	if err != nil {
-		return nil, err
+// transitionMissError explains an execution CAS update that matched no row.
+func (s *PostgresStore) transitionMissError(ctx context.Context, executionID, toStatus string) error {
+	existing, existErr := s.GetExecutionByID(ctx, executionID)
+	if errors.Is(existErr, ErrNotFound) {
+		// Wrap ErrNotFound so callers (e.g. the purchase reaper) can
+		// use errors.Is to distinguish "row vanished mid-flight" — a
+		// legitimate CAS race-loss — from a hard DB error.
+		return fmt.Errorf("%w: execution %s", ErrNotFound, executionID)
 	}
-
-	if len(records) == 0 {
-		existing, existErr := s.GetExecutionByID(ctx, executionID)
-		if errors.Is(existErr, ErrNotFound) {
-			// Wrap ErrNotFound so callers (e.g. the purchase reaper) can
-			// use errors.Is to distinguish "row vanished mid-flight" — a
-			// legitimate CAS race-loss — from a hard DB error.
-			return nil, fmt.Errorf("%w: execution %s", ErrNotFound, executionID)
-		}
-		if existErr != nil {
-			// A hard DB error during the probe must NOT read as a benign
-			// race-loss: propagate it so callers see the outage.
-			return nil, fmt.Errorf("transition %s: probe after zero-row CAS failed: %w", executionID, existErr)
-		}
-		// Wrap ErrExecutionNotInExpectedStatus so callers can use
-		// errors.Is to recognize CAS rejection (status changed between
-		// SELECT and UPDATE) as race-lost rather than a real error.
-		return nil, fmt.Errorf("%w: execution %s cannot transition from %q to %q", ErrExecutionNotInExpectedStatus, executionID, existing.Status, toStatus)
+	if existErr != nil {
+		// A hard DB error during the probe must NOT read as a benign
+		// race-loss: propagate it so callers see the outage.
+		return fmt.Errorf("transition %s: probe after zero-row CAS failed: %w", executionID, existErr)
 	}
-
-	return &records[0], nil
+	// Wrap ErrExecutionNotInExpectedStatus so callers can use
+	// errors.Is to recognize CAS rejection (status changed between
+	// SELECT and UPDATE) as race-lost rather than a real error.
+	return fmt.Errorf("%w: execution %s cannot transition from %q to %q", ErrExecutionNotInExpectedStatus, executionID, existing.Status, toStatus)
 }
 
 // SetCancelledBy stamps both the canceled_by and legacy cancelled_by columns
@@ -1862,8 +1943,8 @@ func (s *PostgresStore) SavePurchaseHistory(ctx context.Context, record *Purchas
 			account_id, purchase_id, timestamp, provider, service, region,
 			resource_type, count, term, payment, upfront_cost, monthly_cost,
 			estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
-			source, revocation_window_closes_at, offering_class
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+			source, revocation_window_closes_at, offering_class, term_end
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 	`
 
 	_, err := s.db.Exec(ctx, query,
@@ -1887,6 +1968,7 @@ func (s *PostgresStore) SavePurchaseHistory(ctx context.Context, record *Purchas
 		record.Source,
 		record.RevocationWindowClosesAt,
 		nullStringFromString(record.OfferingClass),
+		record.TermEnd,
 	)
 
 	if err != nil {
@@ -1960,7 +2042,7 @@ func (s *PostgresStore) GetPurchaseHistory(ctx context.Context, accountID string
 		       resource_type, count, term, payment, upfront_cost, monthly_cost,
 		       estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 		       revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-		       offering_class, listing_id, listing_state
+		       offering_class, listing_id, listing_state, term_end
 		FROM purchase_history
 		WHERE account_id = $1
 		ORDER BY timestamp DESC
@@ -1977,7 +2059,7 @@ func (s *PostgresStore) GetAllPurchaseHistory(ctx context.Context, limit int) ([
 		       resource_type, count, term, payment, upfront_cost, monthly_cost,
 		       estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 		       revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-		       offering_class, listing_id, listing_state
+		       offering_class, listing_id, listing_state, term_end
 		FROM purchase_history
 		ORDER BY timestamp DESC
 		LIMIT $1
@@ -2003,10 +2085,13 @@ func (s *PostgresStore) GetAllPurchaseHistory(ctx context.Context, limit int) ([
 // (expiry >= asOf): a commitment expiring exactly at asOf is still active,
 // matching the API layer's isActiveCommitment (!now.After(expiry)) so the SQL
 // result set and the Go-side active checks share one boundary definition.
+// A non-NULL term_end (marketplace purchases with a partial remaining term,
+// migration 000106) overrides the derived expiry, again matching
+// commitmentExpiry.
 func (s *PostgresStore) GetActivePurchaseHistory(ctx context.Context, asOf time.Time, accountIDs []string, externalIDsByProvider map[string][]string) ([]PurchaseHistoryRecord, error) {
 	conds := []string{
 		"term > 0",
-		"timestamp + make_interval(hours => term * 8760) >= $1",
+		"COALESCE(term_end, timestamp + make_interval(hours => term * 8760)) >= $1",
 		"revoked_at IS NULL",
 	}
 	args := []any{asOf}
@@ -2022,7 +2107,7 @@ func (s *PostgresStore) GetActivePurchaseHistory(ctx context.Context, asOf time.
 		       resource_type, count, term, payment, upfront_cost, monthly_cost,
 		       estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 		       revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-		       offering_class, listing_id, listing_state
+		       offering_class, listing_id, listing_state, term_end
 		FROM purchase_history
 		WHERE %s
 		ORDER BY timestamp DESC
@@ -2160,7 +2245,7 @@ func (s *PostgresStore) GetPurchaseHistoryFiltered(
 		       resource_type, count, term, payment, upfront_cost, monthly_cost,
 		       estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 		       revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-		       offering_class, listing_id, listing_state
+		       offering_class, listing_id, listing_state, term_end
 		FROM purchase_history%s
 		ORDER BY timestamp DESC
 		LIMIT $%d
@@ -2176,11 +2261,11 @@ func (s *PostgresStore) GetPurchaseHistoryFiltered(
 //	resource_type, count, term, payment, upfront_cost, monthly_cost,
 //	estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 //	revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-//	offering_class, listing_id, listing_state
+//	offering_class, listing_id, listing_state, term_end
 //
 // The revocation columns were added in migration 000057; the marketplace
 // listing columns (offering_class, listing_id, listing_state) in the #292
-// migration. Queries must include them explicitly so the Scan targets stay
+// migration; term_end in migration 000106. Queries must include them explicitly so the Scan targets stay
 // in sync.
 func (s *PostgresStore) queryPurchaseHistory(ctx context.Context, query string, args ...any) ([]PurchaseHistoryRecord, error) {
 	rows, err := s.db.Query(ctx, query, args...)
@@ -2224,6 +2309,7 @@ type purchaseHistoryNullables struct {
 	offeringClass            sql.NullString
 	listingID                sql.NullString
 	listingState             sql.NullString
+	termEnd                  *time.Time
 }
 
 // applyTo reconciles the nullable columns into record. monthly_cost is left as
@@ -2261,6 +2347,7 @@ func (n *purchaseHistoryNullables) applyTo(record *PurchaseHistoryRecord) {
 	if n.listingState.Valid {
 		record.ListingState = n.listingState.String
 	}
+	record.TermEnd = n.termEnd
 }
 
 func scanPurchaseHistoryRow(rows pgx.Rows) (PurchaseHistoryRecord, error) {
@@ -2292,6 +2379,7 @@ func scanPurchaseHistoryRow(rows pgx.Rows) (PurchaseHistoryRecord, error) {
 		&n.offeringClass,
 		&n.listingID,
 		&n.listingState,
+		&n.termEnd,
 	); err != nil {
 		return PurchaseHistoryRecord{}, fmt.Errorf("failed to scan purchase history: %w", err)
 	}
@@ -2314,7 +2402,7 @@ func (s *PostgresStore) GetPurchaseHistoryByPurchaseID(ctx context.Context, purc
 		       resource_type, count, term, payment, upfront_cost, monthly_cost,
 		       estimated_savings, plan_id, plan_name, ramp_step, cloud_account_id,
 		       revocation_window_closes_at, revoked_at, revoked_via, support_case_id,
-		       revocation_in_flight, offering_class, listing_id, listing_state
+		       revocation_in_flight, offering_class, listing_id, listing_state, term_end
 		FROM purchase_history
 		WHERE purchase_id = $1
 		LIMIT 1
@@ -2361,6 +2449,7 @@ func (s *PostgresStore) GetPurchaseHistoryByPurchaseID(ctx context.Context, purc
 		&n.offeringClass,
 		&n.listingID,
 		&n.listingState,
+		&n.termEnd,
 	); err != nil {
 		return nil, fmt.Errorf("GetPurchaseHistoryByPurchaseID scan: %w", err)
 	}
@@ -2592,14 +2681,21 @@ func (s *PostgresStore) SaveRIExchangeRecord(ctx context.Context, record *RIExch
 		paymentDue = "0"
 	}
 
+	// Records written before Azure exchanges existed never set Provider;
+	// they are all AWS, matching the column default (migration 000105).
+	provider := record.Provider
+	if provider == "" {
+		provider = "aws"
+	}
+
 	query := `
 		INSERT INTO ri_exchange_history (
 			id, account_id, exchange_id, region, source_ri_ids,
 			source_instance_type, source_count, target_offering_id,
 			target_instance_type, target_count, payment_due,
 			status, approval_token, error, mode, completed_at, expires_at,
-			created_at, updated_at, created_by_user_id, ladder_run_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+			created_at, updated_at, created_by_user_id, ladder_run_id, provider
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 
 	_, err := s.db.Exec(ctx, query,
@@ -2624,6 +2720,7 @@ func (s *PostgresStore) SaveRIExchangeRecord(ctx context.Context, record *RIExch
 		record.UpdatedAt,
 		record.CreatedByUserID,
 		record.LadderRunID,
+		provider,
 	)
 
 	if err != nil {
@@ -2641,7 +2738,7 @@ func (s *PostgresStore) GetRIExchangeRecord(ctx context.Context, id string) (*RI
 		       target_instance_type, target_count, payment_due::text,
 		       status, approval_token, error, mode,
 		       created_at, updated_at, completed_at, expires_at,
-		       created_by_user_id, approved_by, ladder_run_id
+		       created_by_user_id, approved_by, ladder_run_id, provider
 		FROM ri_exchange_history
 		WHERE id = $1
 	`
@@ -2666,7 +2763,7 @@ func (s *PostgresStore) GetRIExchangeRecordByToken(ctx context.Context, token st
 		       target_instance_type, target_count, payment_due::text,
 		       status, approval_token, error, mode,
 		       created_at, updated_at, completed_at, expires_at,
-		       created_by_user_id, approved_by, ladder_run_id
+		       created_by_user_id, approved_by, ladder_run_id, provider
 		FROM ri_exchange_history
 		WHERE approval_token = $1
 	`
@@ -2691,7 +2788,7 @@ func (s *PostgresStore) GetRIExchangeHistory(ctx context.Context, since time.Tim
 		       target_instance_type, target_count, payment_due::text,
 		       status, approval_token, error, mode,
 		       created_at, updated_at, completed_at, expires_at,
-		       created_by_user_id, approved_by, ladder_run_id
+		       created_by_user_id, approved_by, ladder_run_id, provider
 		FROM ri_exchange_history
 		WHERE created_at >= $1
 		ORDER BY created_at DESC
@@ -2716,7 +2813,7 @@ func (s *PostgresStore) TransitionRIExchangeStatus(ctx context.Context, id, from
 		          target_instance_type, target_count, payment_due::text,
 		          status, approval_token, error, mode,
 		          created_at, updated_at, completed_at, expires_at,
-		          created_by_user_id, approved_by, ladder_run_id
+		          created_by_user_id, approved_by, ladder_run_id, provider
 	`
 
 	records, err := s.queryRIExchangeRecords(ctx, query, id, fromStatus, toStatus, actor)
@@ -2936,7 +3033,7 @@ func (s *PostgresStore) GetStaleProcessingExchanges(ctx context.Context, olderTh
 		       target_instance_type, target_count, payment_due::text,
 		       status, approval_token, error, mode,
 		       created_at, updated_at, completed_at, expires_at,
-		       created_by_user_id, approved_by, ladder_run_id
+		       created_by_user_id, approved_by, ladder_run_id, provider
 		FROM ri_exchange_history
 		WHERE status = 'processing' AND updated_at < NOW() - $1::interval
 	`
@@ -2982,6 +3079,7 @@ func (s *PostgresStore) queryRIExchangeRecords(ctx context.Context, query string
 			&createdByUserID,
 			&approvedBy,
 			&ladderRunID,
+			&record.Provider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan ri exchange record: %w", err)
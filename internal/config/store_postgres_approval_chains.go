@@ -0,0 +1,314 @@
+package config
+
+// store_postgres_approval_chains.go -- account groups and tiered approval
+// chains (migration 000110): the configured chains, the chain each
+// execution goes through and the sign-offs its stages collect.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListAccountGroups returns every account group ordered by name.
+func (s *PostgresStore) ListAccountGroups(ctx context.Context) ([]AccountGroup, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, description, account_ids, created_at, updated_at
+		FROM account_groups
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]AccountGroup, 0)
+	for rows.Next() {
+		var g AccountGroup
+		if scanErr := rows.Scan(&g.ID, &g.Name, &g.Description, &g.AccountIDs, &g.CreatedAt, &g.UpdatedAt); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan account group: %w", scanErr)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// CreateAccountGroup inserts g, filling in its ID and timestamps.
+func (s *PostgresStore) CreateAccountGroup(ctx context.Context, g *AccountGroup) error {
+	if g == nil {
+		return fmt.Errorf("account group must not be nil")
+	}
+	accountIDs := g.AccountIDs
+	if accountIDs == nil {
+		accountIDs = []string{}
+	}
+	const q = `
+		INSERT INTO account_groups (name, description, account_ids)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	if err := s.db.QueryRow(ctx, q, g.Name, g.Description, accountIDs).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create account group %q: %w", g.Name, err)
+	}
+	return nil
+}
+
+// UpdateAccountGroup rewrites g's name, description and accounts and
+// refreshes its UpdatedAt.
+func (s *PostgresStore) UpdateAccountGroup(ctx context.Context, g *AccountGroup) error {
+	if g == nil {
+		return fmt.Errorf("account group must not be nil")
+	}
+	accountIDs := g.AccountIDs
+	if accountIDs == nil {
+		accountIDs = []string{}
+	}
+	const q = `
+		UPDATE account_groups
+		SET name = $2, description = $3, account_ids = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := s.db.QueryRow(ctx, q, g.ID, g.Name, g.Description, accountIDs).Scan(&g.CreatedAt, &g.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("account group %s: %w", g.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update account group %s: %w", g.ID, err)
+	}
+	return nil
+}
+
+// DeleteAccountGroup removes a group. A group still named by an approval
+// chain cannot be deleted: the foreign key refuses it.
+func (s *PostgresStore) DeleteAccountGroup(ctx context.Context, id string) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM account_groups WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete account group %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("account group %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// marshalStages encodes stages for a JSONB column, writing [] for nil.
+func marshalStages(stages []ApprovalStage) ([]byte, error) {
+	if stages == nil {
+		stages = []ApprovalStage{}
+	}
+	b, err := json.Marshal(stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal approval stages: %w", err)
+	}
+	return b, nil
+}
+
+// ListApprovalChains returns every chain by priority, then name.
+func (s *PostgresStore) ListApprovalChains(ctx context.Context) ([]ApprovalChain, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, priority, enabled, provider, account_group_id, amount_basis,
+		       stages, created_at, updated_at
+		FROM approval_chains
+		ORDER BY priority ASC, name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval chains: %w", err)
+	}
+	defer rows.Close()
+
+	chains := make([]ApprovalChain, 0)
+	for rows.Next() {
+		var c ApprovalChain
+		var stages []byte
+		if scanErr := rows.Scan(
+			&c.ID, &c.Name, &c.Priority, &c.Enabled, &c.Provider, &c.AccountGroupID, &c.AmountBasis,
+			&stages, &c.CreatedAt, &c.UpdatedAt,
+		); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan approval chain: %w", scanErr)
+		}
+		if decodeErr := json.Unmarshal(stages, &c.Stages); decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode stages of approval chain %s: %w", c.ID, decodeErr)
+		}
+		chains = append(chains, c)
+	}
+	return chains, rows.Err()
+}
+
+// CreateApprovalChain inserts c, filling in its ID and timestamps.
+func (s *PostgresStore) CreateApprovalChain(ctx context.Context, c *ApprovalChain) error {
+	if c == nil {
+		return fmt.Errorf("approval chain must not be nil")
+	}
+	stages, err := marshalStages(c.Stages)
+	if err != nil {
+		return err
+	}
+	const q = `
+		INSERT INTO approval_chains (name, priority, enabled, provider, account_group_id, amount_basis, stages)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	if scanErr := s.db.QueryRow(ctx, q,
+		c.Name, c.Priority, c.Enabled, c.Provider, c.AccountGroupID, c.AmountBasis, stages,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt); scanErr != nil {
+		return fmt.Errorf("failed to create approval chain %q: %w", c.Name, scanErr)
+	}
+	return nil
+}
+
+// UpdateApprovalChain rewrites c and refreshes its UpdatedAt. Executions
+// already going through the chain keep the stages they started with.
+func (s *PostgresStore) UpdateApprovalChain(ctx context.Context, c *ApprovalChain) error {
+	if c == nil {
+		return fmt.Errorf("approval chain must not be nil")
+	}
+	stages, err := marshalStages(c.Stages)
+	if err != nil {
+		return err
+	}
+	const q = `
+		UPDATE approval_chains
+		SET name = $2, priority = $3, enabled = $4, provider = $5, account_group_id = $6,
+		    amount_basis = $7, stages = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	scanErr := s.db.QueryRow(ctx, q,
+		c.ID, c.Name, c.Priority, c.Enabled, c.Provider, c.AccountGroupID, c.AmountBasis, stages,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(scanErr, pgx.ErrNoRows) {
+		return fmt.Errorf("approval chain %s: %w", c.ID, ErrNotFound)
+	}
+	if scanErr != nil {
+		return fmt.Errorf("failed to update approval chain %s: %w", c.ID, scanErr)
+	}
+	return nil
+}
+
+// DeleteApprovalChain removes a chain. Runs it started keep their stage
+// snapshot and lose only the chain_id link.
+func (s *PostgresStore) DeleteApprovalChain(ctx context.Context, id string) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM approval_chains WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval chain %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("approval chain %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// StartExecutionApprovalChain inserts run unless the execution already has
+// a chain, in which case it returns false: two approvers racing to start
+// the chain start it once.
+func (s *PostgresStore) StartExecutionApprovalChain(ctx context.Context, run *ExecutionApprovalChain) (bool, error) {
+	if run == nil {
+		return false, fmt.Errorf("approval chain run must not be nil")
+	}
+	stages, err := marshalStages(run.Stages)
+	if err != nil {
+		return false, err
+	}
+	const q = `
+		INSERT INTO purchase_approval_chain_runs (execution_id, chain_id, chain_name, amount, stages, current_stage)
+		VALUES ($1, $2, $3, $4, $5, 0)
+		ON CONFLICT (execution_id) DO NOTHING
+		RETURNING started_at
+	`
+	scanErr := s.db.QueryRow(ctx, q, run.ExecutionID, run.ChainID, run.ChainName, run.Amount, stages).Scan(&run.StartedAt)
+	if errors.Is(scanErr, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if scanErr != nil {
+		return false, fmt.Errorf("failed to start approval chain for execution %s: %w", run.ExecutionID, scanErr)
+	}
+	run.CurrentStage = 0
+	return true, nil
+}
+
+// GetExecutionApprovalChain returns an execution's chain, or nil, nil when
+// it has none.
+func (s *PostgresStore) GetExecutionApprovalChain(ctx context.Context, executionID string) (*ExecutionApprovalChain, error) {
+	var run ExecutionApprovalChain
+	var stages []byte
+	err := s.db.QueryRow(ctx, `
+		SELECT execution_id, chain_id, chain_name, amount, stages, current_stage, started_at, completed_at
+		FROM purchase_approval_chain_runs
+		WHERE execution_id = $1
+	`, executionID).Scan(
+		&run.ExecutionID, &run.ChainID, &run.ChainName, &run.Amount, &stages, &run.CurrentStage,
+		&run.StartedAt, &run.CompletedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval chain of execution %s: %w", executionID, err)
+	}
+	if decodeErr := json.Unmarshal(stages, &run.Stages); decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode approval chain stages of execution %s: %w", executionID, decodeErr)
+	}
+	return &run, nil
+}
+
+// AdvanceExecutionApprovalChain moves the chain from stage from to from+1
+// with a compare-and-set on current_stage, stamping completed_at when that
+// is past the last stage.
+func (s *PostgresStore) AdvanceExecutionApprovalChain(ctx context.Context, executionID string, from int) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE purchase_approval_chain_runs
+		SET current_stage = current_stage + 1,
+		    completed_at = CASE WHEN current_stage + 1 >= jsonb_array_length(stages) THEN NOW() ELSE completed_at END
+		WHERE execution_id = $1 AND current_stage = $2
+	`, executionID, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance approval chain of execution %s: %w", executionID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RecordApprovalStageDecision inserts d; a second sign-off by the same
+// approver on the same stage is ignored and keeps the first DecidedAt.
+func (s *PostgresStore) RecordApprovalStageDecision(ctx context.Context, d *ApprovalStageDecision) error {
+	if d == nil {
+		return fmt.Errorf("decision must not be nil")
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO purchase_approval_stage_decisions (execution_id, stage_index, approver_email, approver_user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (execution_id, stage_index, approver_email) DO NOTHING
+	`, d.ExecutionID, d.StageIndex, d.ApproverEmail, d.ApproverUserID)
+	if err != nil {
+		return fmt.Errorf("failed to record stage %d sign-off on execution %s: %w", d.StageIndex, d.ExecutionID, err)
+	}
+	return nil
+}
+
+// ListApprovalStageDecisions returns an execution's sign-offs by stage,
+// oldest first.
+func (s *PostgresStore) ListApprovalStageDecisions(ctx context.Context, executionID string) ([]ApprovalStageDecision, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT execution_id, stage_index, approver_email, approver_user_id, decided_at
+		FROM purchase_approval_stage_decisions
+		WHERE execution_id = $1
+		ORDER BY stage_index ASC, decided_at ASC, approver_email ASC
+	`, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval stage decisions: %w", err)
+	}
+	defer rows.Close()
+
+	decisions := make([]ApprovalStageDecision, 0)
+	for rows.Next() {
+		var d ApprovalStageDecision
+		if scanErr := rows.Scan(&d.ExecutionID, &d.StageIndex, &d.ApproverEmail, &d.ApproverUserID, &d.DecidedAt); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan approval stage decision: %w", scanErr)
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
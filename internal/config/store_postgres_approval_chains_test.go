@@ -0,0 +1,200 @@
+package config
+
+// store_postgres_approval_chains_test.go -- pgxmock tests for account
+// groups and approval chains (migration 000110).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGXMock_CreateApprovalChain(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	group := "33333333-3333-3333-3333-333333333333"
+	c := &ApprovalChain{
+		Name:           "procurement",
+		Enabled:        true,
+		Provider:       "aws",
+		AccountGroupID: &group,
+		AmountBasis:    AmountBasisUpfront,
+		Stages:         []ApprovalStage{{Name: "Team lead", Approvers: []string{"lead@example.com"}, Quorum: 1}},
+	}
+	created := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO approval_chains[\s\S]*RETURNING id, created_at, updated_at`).
+		WithArgs("procurement", 0, true, "aws", &group, AmountBasisUpfront,
+			[]byte(`[{"name":"Team lead","approvers":["lead@example.com"],"quorum":1}]`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("chain-1", created, created))
+
+	require.NoError(t, store.CreateApprovalChain(context.Background(), c))
+	assert.Equal(t, "chain-1", c.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorContains(t, store.CreateApprovalChain(context.Background(), nil), "approval chain must not be nil")
+}
+
+func TestPGXMock_ListApprovalChains(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	cols := []string{"id", "name", "priority", "enabled", "provider", "account_group_id", "amount_basis", "stages", "created_at", "updated_at"}
+	mock.ExpectQuery(`FROM approval_chains\s+ORDER BY priority ASC, name ASC`).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow("chain-1", "procurement", 0, true, "", (*string)(nil), AmountBasisTotalCommitment,
+				[]byte(`[{"name":"FinOps","approvers":["finops@example.com"],"quorum":1,"min_amount":10000}]`), time.Now(), time.Now()))
+
+	chains, err := store.ListApprovalChains(context.Background())
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	require.Len(t, chains[0].Stages, 1)
+	assert.Equal(t, 10000.0, chains[0].Stages[0].MinAmount)
+	assert.Nil(t, chains[0].AccountGroupID)
+}
+
+func TestPGXMock_UpdateApprovalChain_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectQuery(`UPDATE approval_chains`).
+		WithArgs("chain-1", pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+
+	err := store.UpdateApprovalChain(context.Background(), &ApprovalChain{ID: "chain-1"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPGXMock_DeleteAccountGroup(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectExec(`DELETE FROM account_groups WHERE id = \$1`).WithArgs("group-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`DELETE FROM account_groups`).WithArgs("group-2").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	require.NoError(t, store.DeleteAccountGroup(context.Background(), "group-1"))
+	assert.ErrorIs(t, store.DeleteAccountGroup(context.Background(), "group-2"), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CreateAccountGroup_WritesEmptyAccountList(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectQuery(`INSERT INTO account_groups`).WithArgs("prod", "", []string{}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("group-1", time.Now(), time.Now()))
+
+	require.NoError(t, store.CreateAccountGroup(context.Background(), &AccountGroup{Name: "prod"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_StartExecutionApprovalChain(t *testing.T) {
+	run := func() *ExecutionApprovalChain {
+		return &ExecutionApprovalChain{
+			ExecutionID: "exec-1",
+			ChainName:   "procurement",
+			Amount:      25000,
+			Stages:      []ApprovalStage{{Name: "Team lead", Approvers: []string{"lead@example.com"}, Quorum: 1}},
+		}
+	}
+	stages := []byte(`[{"name":"Team lead","approvers":["lead@example.com"],"quorum":1}]`)
+
+	t.Run("started", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		started := time.Now()
+		mock.ExpectQuery(`INSERT INTO purchase_approval_chain_runs[\s\S]*ON CONFLICT \(execution_id\) DO NOTHING`).
+			WithArgs("exec-1", (*string)(nil), "procurement", 25000.0, stages).
+			WillReturnRows(pgxmock.NewRows([]string{"started_at"}).AddRow(started))
+
+		r := run()
+		ok, err := store.StartExecutionApprovalChain(context.Background(), r)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, started, r.StartedAt)
+	})
+
+	t.Run("already started", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`INSERT INTO purchase_approval_chain_runs`).
+			WithArgs("exec-1", (*string)(nil), "procurement", 25000.0, stages).
+			WillReturnRows(pgxmock.NewRows([]string{"started_at"}))
+
+		ok, err := store.StartExecutionApprovalChain(context.Background(), run())
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestPGXMock_GetExecutionApprovalChain(t *testing.T) {
+	cols := []string{"execution_id", "chain_id", "chain_name", "amount", "stages", "current_stage", "started_at", "completed_at"}
+
+	t.Run("found", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		chainID := "chain-1"
+		mock.ExpectQuery(`FROM purchase_approval_chain_runs\s+WHERE execution_id = \$1`).WithArgs("exec-1").
+			WillReturnRows(pgxmock.NewRows(cols).AddRow("exec-1", &chainID, "procurement", 25000.0,
+				[]byte(`[{"name":"Team lead","approvers":["lead@example.com"],"quorum":1}]`), 1, time.Now(), (*time.Time)(nil)))
+
+		run, err := store.GetExecutionApprovalChain(context.Background(), "exec-1")
+		require.NoError(t, err)
+		require.NotNil(t, run)
+		assert.True(t, run.Completed())
+	})
+
+	t.Run("none", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`FROM purchase_approval_chain_runs`).WithArgs("exec-1").WillReturnRows(pgxmock.NewRows(cols))
+
+		run, err := store.GetExecutionApprovalChain(context.Background(), "exec-1")
+		require.NoError(t, err)
+		assert.Nil(t, run)
+	})
+}
+
+func TestPGXMock_AdvanceExecutionApprovalChain(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectExec(`UPDATE purchase_approval_chain_runs[\s\S]*WHERE execution_id = \$1 AND current_stage = \$2`).
+		WithArgs("exec-1", 0).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE purchase_approval_chain_runs`).
+		WithArgs("exec-1", 0).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectExec(`UPDATE purchase_approval_chain_runs`).
+		WithArgs("exec-1", 1).WillReturnError(errors.New("boom"))
+
+	ok, err := store.AdvanceExecutionApprovalChain(context.Background(), "exec-1", 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.AdvanceExecutionApprovalChain(context.Background(), "exec-1", 0)
+	require.NoError(t, err)
+	assert.False(t, ok, "a concurrent sign-off already moved the chain on")
+	_, err = store.AdvanceExecutionApprovalChain(context.Background(), "exec-1", 1)
+	assert.ErrorContains(t, err, "failed to advance approval chain")
+}
+
+func TestPGXMock_ApprovalStageDecisions(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectExec(`INSERT INTO purchase_approval_stage_decisions[\s\S]*ON CONFLICT`).
+		WithArgs("exec-1", 1, "finops@example.com", (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectQuery(`FROM purchase_approval_stage_decisions[\s\S]*ORDER BY stage_index ASC`).WithArgs("exec-1").
+		WillReturnRows(pgxmock.NewRows([]string{"execution_id", "stage_index", "approver_email", "approver_user_id", "decided_at"}).
+			AddRow("exec-1", 0, "lead@example.com", (*string)(nil), time.Now()).
+			AddRow("exec-1", 1, "finops@example.com", (*string)(nil), time.Now()))
+
+	require.NoError(t, store.RecordApprovalStageDecision(context.Background(),
+		&ApprovalStageDecision{ExecutionID: "exec-1", StageIndex: 1, ApproverEmail: "finops@example.com"}))
+	decisions, err := store.ListApprovalStageDecisions(context.Background(), "exec-1")
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, 1, decisions[1].StageIndex)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ==========================================
+// AZURE REFUND LEDGER STORE METHODS
+// ==========================================
+
+// SaveAzureRefund records an issued Azure reservation refund. A second
+// write for the same purchase_id is a no-op, so retrying after a partial
+// failure never double-counts a refund against the limit.
+func (s *PostgresStore) SaveAzureRefund(ctx context.Context, record *AzureRefundRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.New().String()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO azure_refund_ledger (
+			id, billing_profile, purchase_id, amount, currency, refunded_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (purchase_id) DO NOTHING
+	`
+	_, err := s.db.Exec(ctx, query,
+		record.ID,
+		record.BillingProfile,
+		record.PurchaseID,
+		record.Amount,
+		record.Currency,
+		record.RefundedAt,
+		record.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save azure refund: %w", err)
+	}
+	return nil
+}
+
+// SumAzureRefunds totals the refunds recorded for billingProfile in
+// currency with refunded_at at or after since. Refunds in other currencies
+// are excluded rather than converted.
+func (s *PostgresStore) SumAzureRefunds(ctx context.Context, billingProfile, currency string, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)::float8
+		FROM azure_refund_ledger
+		WHERE billing_profile = $1 AND currency = $2 AND refunded_at >= $3
+	`
+	var total float64
+	if err := s.db.QueryRow(ctx, query, billingProfile, currency, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum azure refunds: %w", err)
+	}
+	return total, nil
+}
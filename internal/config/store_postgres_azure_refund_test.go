@@ -0,0 +1,58 @@
+package config
+
+// store_postgres_azure_refund_test.go -- pgxmock tests for the Azure refund
+// ledger (migration 000104).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGXMock_SaveAzureRefund(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	refundedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	record := &AzureRefundRecord{
+		BillingProfile: "tenant-1",
+		PurchaseID:     "/providers/Microsoft.Capacity/reservationOrders/o1/reservations/r1",
+		Amount:         1200,
+		Currency:       "USD",
+		RefundedAt:     refundedAt,
+	}
+	mock.ExpectExec(`INSERT INTO azure_refund_ledger[\s\S]*ON CONFLICT \(purchase_id\) DO NOTHING`).
+		WithArgs(pgxmock.AnyArg(), "tenant-1", record.PurchaseID, 1200.0, "USD", refundedAt, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, store.SaveAzureRefund(context.Background(), record))
+	assert.NotEmpty(t, record.ID)
+	assert.False(t, record.CreatedAt.IsZero())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_SumAzureRefunds(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	since := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SUM\(amount\)[\s\S]*FROM azure_refund_ledger`).
+		WithArgs("tenant-1", "USD", since).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(4250.5))
+
+	total, err := store.SumAzureRefunds(context.Background(), "tenant-1", "USD", since)
+	require.NoError(t, err)
+	assert.Equal(t, 4250.5, total)
+
+	mock.ExpectQuery(`FROM azure_refund_ledger`).
+		WithArgs("tenant-1", "USD", since).
+		WillReturnError(errors.New("db down"))
+	_, err = store.SumAzureRefunds(context.Background(), "tenant-1", "USD", since)
+	assert.ErrorContains(t, err, "failed to sum azure refunds")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,357 @@
+package config
+
+// store_postgres_budgets.go -- the organisation-wide commitment budget
+// (migration 000111): the configured caps and the reservation ledger every
+// purchase path checks them against.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+)
+
+// budgetLockKey is the advisory-lock key that serializes reservations
+// against the commitment budgets, so two purchases firing at once cannot
+// both fit under the same remaining budget. Derived like
+// globalConfigLockKey.
+var budgetLockKey = func() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("cudly:commitment_budgets:reserve"))
+	return int64(h.Sum64())
+}()
+
+// budgetQuerier is the read side shared by the pool and a transaction.
+type budgetQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const commitmentBudgetCols = `id, name, period, account_group_id, max_upfront_usd, max_hourly_usd,
+		       enabled, created_at, updated_at`
+
+func scanCommitmentBudget(row pgx.Row, b *CommitmentBudget, extra ...any) error {
+	dest := append([]any{
+		&b.ID, &b.Name, &b.Period, &b.AccountGroupID, &b.MaxUpfrontUSD, &b.MaxHourlyUSD,
+		&b.Enabled, &b.CreatedAt, &b.UpdatedAt,
+	}, extra...)
+	return row.Scan(dest...)
+}
+
+// ListCommitmentBudgets returns every budget ordered by name.
+func (s *PostgresStore) ListCommitmentBudgets(ctx context.Context) ([]CommitmentBudget, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+commitmentBudgetCols+` FROM commitment_budgets ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commitment budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := make([]CommitmentBudget, 0)
+	for rows.Next() {
+		var b CommitmentBudget
+		if scanErr := scanCommitmentBudget(rows, &b); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan commitment budget: %w", scanErr)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// CreateCommitmentBudget inserts b, filling in its ID and timestamps.
+func (s *PostgresStore) CreateCommitmentBudget(ctx context.Context, b *CommitmentBudget) error {
+	if b == nil {
+		return fmt.Errorf("commitment budget must not be nil")
+	}
+	const q = `
+		INSERT INTO commitment_budgets (name, period, account_group_id, max_upfront_usd, max_hourly_usd, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	if err := s.db.QueryRow(ctx, q, b.Name, b.Period, b.AccountGroupID, b.MaxUpfrontUSD, b.MaxHourlyUSD, b.Enabled).
+		Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create commitment budget %q: %w", b.Name, err)
+	}
+	return nil
+}
+
+// UpdateCommitmentBudget rewrites b and refreshes its UpdatedAt.
+func (s *PostgresStore) UpdateCommitmentBudget(ctx context.Context, b *CommitmentBudget) error {
+	if b == nil {
+		return fmt.Errorf("commitment budget must not be nil")
+	}
+	const q = `
+		UPDATE commitment_budgets
+		SET name = $2, period = $3, account_group_id = $4, max_upfront_usd = $5, max_hourly_usd = $6,
+		    enabled = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := s.db.QueryRow(ctx, q, b.ID, b.Name, b.Period, b.AccountGroupID, b.MaxUpfrontUSD, b.MaxHourlyUSD, b.Enabled).
+		Scan(&b.CreatedAt, &b.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("commitment budget %s: %w", b.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update commitment budget %s: %w", b.ID, err)
+	}
+	return nil
+}
+
+// DeleteCommitmentBudget removes a budget. Its reservations stay in the
+// ledger and keep counting against any other budget they fall under.
+func (s *PostgresStore) DeleteCommitmentBudget(ctx context.Context, id string) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM commitment_budgets WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete commitment budget %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("commitment budget %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// scopedBudget is an enabled budget with its account group's accounts.
+type scopedBudget struct {
+	CommitmentBudget
+	groupAccounts []string
+}
+
+// appliesTo reports whether a reservation landing in accountIDs counts
+// against b: an unscoped budget covers everything, a scoped one
+// reservations landing in one of its group's accounts and those whose
+// accounts are unknown, which cannot be shown to fall outside the group.
+func (b *scopedBudget) appliesTo(accountIDs []string) bool {
+	if b.AccountGroupID == nil || len(accountIDs) == 0 {
+		return true
+	}
+	for _, a := range accountIDs {
+		for _, g := range b.groupAccounts {
+			if a == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadEnabledBudgets returns every enabled budget with its group's
+// accounts, ordered by name.
+func loadEnabledBudgets(ctx context.Context, q budgetQuerier) ([]scopedBudget, error) {
+	rows, err := q.Query(ctx, `
+		SELECT b.id, b.name, b.period, b.account_group_id, b.max_upfront_usd, b.max_hourly_usd,
+		       b.enabled, b.created_at, b.updated_at, COALESCE(g.account_ids, '{}')
+		FROM commitment_budgets b
+		LEFT JOIN account_groups g ON g.id = b.account_group_id
+		WHERE b.enabled
+		ORDER BY b.name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commitment budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []scopedBudget
+	for rows.Next() {
+		var b scopedBudget
+		if scanErr := scanCommitmentBudget(rows, &b.CommitmentBudget, &b.groupAccounts); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan commitment budget: %w", scanErr)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// budgetUsage sums the unreleased reservations b counts in the period
+// [start, end); see appliesTo.
+func budgetUsage(ctx context.Context, q budgetQuerier, b *scopedBudget, start, end time.Time) (budget.Usage, error) {
+	var u budget.Usage
+	err := q.QueryRow(ctx, `
+		SELECT COALESCE(SUM(upfront_usd), 0)::float8, COALESCE(SUM(hourly_usd), 0)::float8
+		FROM budget_reservations
+		WHERE status <> 'released' AND reserved_at >= $1 AND reserved_at < $2
+		  AND ($3::boolean OR account_ids && $4::uuid[] OR cardinality(account_ids) = 0)
+	`, start, end, b.AccountGroupID == nil, b.groupAccounts).Scan(&u.UpfrontUSD, &u.HourlyUSD)
+	if err != nil {
+		return budget.Usage{}, fmt.Errorf("failed to sum usage of commitment budget %q: %w", b.Name, err)
+	}
+	return u, nil
+}
+
+const budgetReservationCols = `id, source, reference, provider, account_ids, upfront_usd, hourly_usd,
+		       status, reserved_at, settled_at, release_reason`
+
+func scanBudgetReservation(row pgx.Row, r *BudgetReservation) error {
+	return row.Scan(&r.ID, &r.Source, &r.Reference, &r.Provider, &r.AccountIDs, &r.UpfrontUSD, &r.HourlyUSD,
+		&r.Status, &r.ReservedAt, &r.SettledAt, &r.ReleaseReason)
+}
+
+// ReserveCommitmentBudget checks r against every enabled budget that
+// applies to it and records it. The advisory lock serializes reservers, so
+// the usage read and the insert are atomic with respect to each other. A
+// released reservation with the same Source and Reference is reused and
+// re-checked; a live one is returned as is.
+func (s *PostgresStore) ReserveCommitmentBudget(ctx context.Context, r *BudgetReservation) error {
+	if r == nil {
+		return fmt.Errorf("budget reservation must not be nil")
+	}
+	accountIDs := r.AccountIDs
+	if accountIDs == nil {
+		accountIDs = []string{}
+	}
+	return s.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", budgetLockKey); err != nil {
+			return fmt.Errorf("failed to acquire commitment budget lock: %w", err)
+		}
+
+		var existing BudgetReservation
+		err := scanBudgetReservation(tx.QueryRow(ctx, `SELECT `+budgetReservationCols+`
+			FROM budget_reservations WHERE source = $1 AND reference = $2`, r.Source, r.Reference), &existing)
+		switch {
+		case err == nil && existing.Status != BudgetReservationReleased:
+			*r = existing
+			return nil
+		case err != nil && !errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("failed to look up budget reservation %s/%s: %w", r.Source, r.Reference, err)
+		}
+
+		now := time.Now().UTC()
+		if err := checkBudgets(ctx, tx, r, accountIDs, now); err != nil {
+			return err
+		}
+
+		const q = `
+			INSERT INTO budget_reservations (source, reference, provider, account_ids, upfront_usd, hourly_usd, status, reserved_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'reserved', $7)
+			ON CONFLICT (source, reference) DO UPDATE
+			SET provider = EXCLUDED.provider, account_ids = EXCLUDED.account_ids,
+			    upfront_usd = EXCLUDED.upfront_usd, hourly_usd = EXCLUDED.hourly_usd,
+			    status = 'reserved', reserved_at = EXCLUDED.reserved_at, settled_at = NULL, release_reason = ''
+			RETURNING id
+		`
+		if err := tx.QueryRow(ctx, q, r.Source, r.Reference, r.Provider, accountIDs, r.UpfrontUSD, r.HourlyUSD, now).Scan(&r.ID); err != nil {
+			return fmt.Errorf("failed to record budget reservation %s/%s: %w", r.Source, r.Reference, err)
+		}
+		r.Status = BudgetReservationReserved
+		r.ReservedAt = now
+		r.SettledAt = nil
+		r.ReleaseReason = ""
+		return nil
+	})
+}
+
+// checkBudgets returns a *budget.ExceededError when r would take any
+// enabled budget that applies to it past a cap in the period containing
+// now.
+func checkBudgets(ctx context.Context, q budgetQuerier, r *BudgetReservation, accountIDs []string, now time.Time) error {
+	budgets, err := loadEnabledBudgets(ctx, q)
+	if err != nil {
+		return err
+	}
+	req := budget.Request{Source: r.Source, Reference: r.Reference, UpfrontUSD: r.UpfrontUSD, HourlyUSD: r.HourlyUSD}
+	for i := range budgets {
+		b := &budgets[i]
+		if !b.appliesTo(accountIDs) {
+			continue
+		}
+		start, end := budget.Window(budget.Period(b.Period), now)
+		used, err := budgetUsage(ctx, q, b, start, end)
+		if err != nil {
+			return err
+		}
+		if err := budget.Check(b.Cap(), used, req, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommitBudgetReservation settles a reservation at the amounts bought.
+func (s *PostgresStore) CommitBudgetReservation(ctx context.Context, id string, upfrontUSD, hourlyUSD float64) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE budget_reservations
+		SET status = 'committed', upfront_usd = $2, hourly_usd = $3, settled_at = NOW()
+		WHERE id = $1 AND status <> 'released'
+	`, id, upfrontUSD, hourlyUSD)
+	if err != nil {
+		return fmt.Errorf("failed to commit budget reservation %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("budget reservation %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ReleaseBudgetReservation gives a reservation back.
+func (s *PostgresStore) ReleaseBudgetReservation(ctx context.Context, id, reason string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE budget_reservations
+		SET status = 'released', release_reason = $2, settled_at = NOW()
+		WHERE id = $1 AND status <> 'released'
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to release budget reservation %s: %w", id, err)
+	}
+	return nil
+}
+
+// CreditCommitmentBudget records r, a credit for a revoked purchase, as a
+// committed ledger entry. r.ReservedAt should be the purchase's own time
+// so the credit lands in the period the purchase counted against.
+func (s *PostgresStore) CreditCommitmentBudget(ctx context.Context, r *BudgetReservation) error {
+	if r == nil {
+		return fmt.Errorf("budget credit must not be nil")
+	}
+	accountIDs := r.AccountIDs
+	if accountIDs == nil {
+		accountIDs = []string{}
+	}
+	if r.ReservedAt.IsZero() {
+		r.ReservedAt = time.Now().UTC()
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO budget_reservations (source, reference, provider, account_ids, upfront_usd, hourly_usd, status, reserved_at, settled_at, release_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, 'committed', $7, NOW(), $8)
+		ON CONFLICT (source, reference) DO NOTHING
+	`, r.Source, r.Reference, r.Provider, accountIDs, r.UpfrontUSD, r.HourlyUSD, r.ReservedAt, r.ReleaseReason)
+	if err != nil {
+		return fmt.Errorf("failed to record budget credit %s/%s: %w", r.Source, r.Reference, err)
+	}
+	return nil
+}
+
+// GetCommitmentBudgetUsage returns every enabled budget's usage in the
+// period containing at.
+func (s *PostgresStore) GetCommitmentBudgetUsage(ctx context.Context, at time.Time) ([]CommitmentBudgetUsage, error) {
+	budgets, err := loadEnabledBudgets(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]CommitmentBudgetUsage, 0, len(budgets))
+	for i := range budgets {
+		b := &budgets[i]
+		start, end := budget.Window(budget.Period(b.Period), at)
+		used, usageErr := budgetUsage(ctx, s.db, b, start, end)
+		if usageErr != nil {
+			return nil, usageErr
+		}
+		u := CommitmentBudgetUsage{
+			Budget: b.CommitmentBudget, PeriodStart: start, PeriodEnd: end,
+			UsedUpfrontUSD: used.UpfrontUSD, UsedHourlyUSD: used.HourlyUSD,
+		}
+		if b.MaxUpfrontUSD != nil {
+			remaining := budget.Remaining(*b.MaxUpfrontUSD, used.UpfrontUSD)
+			u.RemainingUpfrontUSD = &remaining
+		}
+		if b.MaxHourlyUSD != nil {
+			remaining := budget.Remaining(*b.MaxHourlyUSD, used.HourlyUSD)
+			u.RemainingHourlyUSD = &remaining
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
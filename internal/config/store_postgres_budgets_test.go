@@ -0,0 +1,205 @@
+package config
+
+// store_postgres_budgets_test.go -- pgxmock tests for commitment budgets and
+// the reservation ledger (migration 000111).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+)
+
+var enabledBudgetCols = []string{
+	"id", "name", "period", "account_group_id", "max_upfront_usd", "max_hourly_usd",
+	"enabled", "created_at", "updated_at", "account_ids",
+}
+
+// expectBudgetCheck queues the reservation transaction up to and including
+// the usage sum of a single unscoped monthly budget capped at maxUpfront.
+func expectBudgetCheck(mock pgxmock.PgxPoolIface, maxUpfront, usedUpfront float64) {
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(budgetLockKey).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(`FROM budget_reservations WHERE source = \$1 AND reference = \$2`).
+		WithArgs(budget.SourceExecution, "exec-1").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`FROM commitment_budgets b`).
+		WillReturnRows(pgxmock.NewRows(enabledBudgetCols).
+			AddRow("budget-1", "org", "month", (*string)(nil), &maxUpfront, (*float64)(nil), true, now, now, []string{}))
+	mock.ExpectQuery(`SUM\(upfront_usd\)`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), true, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"upfront", "hourly"}).AddRow(usedUpfront, 0.0))
+}
+
+func TestPGXMock_ReserveCommitmentBudget(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	expectBudgetCheck(mock, 1000, 400)
+	mock.ExpectQuery(`INSERT INTO budget_reservations[\s\S]*RETURNING id`).
+		WithArgs(budget.SourceExecution, "exec-1", "aws", []string{}, 500.0, 0.0, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow("res-1"))
+	mock.ExpectCommit()
+
+	r := &BudgetReservation{Source: budget.SourceExecution, Reference: "exec-1", Provider: "aws", UpfrontUSD: 500}
+	require.NoError(t, store.ReserveCommitmentBudget(context.Background(), r))
+	assert.Equal(t, "res-1", r.ID)
+	assert.Equal(t, BudgetReservationReserved, r.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ReserveCommitmentBudget_Exceeded(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	expectBudgetCheck(mock, 1000, 900)
+	mock.ExpectRollback()
+
+	r := &BudgetReservation{Source: budget.SourceExecution, Reference: "exec-1", Provider: "aws", UpfrontUSD: 500}
+	err := store.ReserveCommitmentBudget(context.Background(), r)
+	var exceeded *budget.ExceededError
+	require.True(t, errors.As(err, &exceeded), "expected an ExceededError, got: %v", err)
+	assert.Equal(t, "org", exceeded.Budget)
+	assert.Equal(t, 100.0, exceeded.Cap-exceeded.Used)
+	assert.Empty(t, r.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectScopedBudgetCheck queues the reservation transaction of a ladder
+// purchase up to and including the usage sum of a single monthly budget
+// capped at $1000 upfront, scoped to a group holding acct-1 and acct-2.
+func expectScopedBudgetCheck(mock pgxmock.PgxPoolIface, usedUpfront float64) {
+	now := time.Now()
+	group, maxUpfront := "group-1", 1000.0
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(budgetLockKey).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(`FROM budget_reservations WHERE source = \$1 AND reference = \$2`).
+		WithArgs(budget.SourceLadder, "tok-1").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`FROM commitment_budgets b`).
+		WillReturnRows(pgxmock.NewRows(enabledBudgetCols).
+			AddRow("budget-1", "prod", "month", &group, &maxUpfront, (*float64)(nil), true, now, now, []string{"acct-1", "acct-2"}))
+	mock.ExpectQuery(`SUM\(upfront_usd\)[\s\S]*cardinality\(account_ids\) = 0`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), false, []string{"acct-1", "acct-2"}).
+		WillReturnRows(pgxmock.NewRows([]string{"upfront", "hourly"}).AddRow(usedUpfront, 0.0))
+}
+
+func TestPGXMock_ReserveCommitmentBudget_GroupScopedRefusesLadderPurchase(t *testing.T) {
+	for name, accountIDs := range map[string][]string{
+		"in the group": {"acct-1"},
+		// An unattributed purchase cannot be shown to fall outside it.
+		"unattributed": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mock := newMock(t)
+			store := storeWith(mock)
+
+			expectScopedBudgetCheck(mock, 900)
+			mock.ExpectRollback()
+
+			r := &BudgetReservation{Source: budget.SourceLadder, Reference: "tok-1", Provider: "aws", AccountIDs: accountIDs, UpfrontUSD: 500}
+			err := store.ReserveCommitmentBudget(context.Background(), r)
+			var exceeded *budget.ExceededError
+			require.ErrorAs(t, err, &exceeded)
+			assert.Equal(t, "prod", exceeded.Budget)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPGXMock_ReserveCommitmentBudget_GroupScopedSkipsOtherAccounts(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	now := time.Now()
+	group, maxUpfront := "group-1", 1000.0
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(budgetLockKey).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(`FROM budget_reservations WHERE source = \$1 AND reference = \$2`).
+		WithArgs(budget.SourceLadder, "tok-1").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`FROM commitment_budgets b`).
+		WillReturnRows(pgxmock.NewRows(enabledBudgetCols).
+			AddRow("budget-1", "prod", "month", &group, &maxUpfront, (*float64)(nil), true, now, now, []string{"acct-1"}))
+	mock.ExpectQuery(`INSERT INTO budget_reservations[\s\S]*RETURNING id`).
+		WithArgs(budget.SourceLadder, "tok-1", "aws", []string{"acct-dev"}, 5000.0, 0.0, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow("res-1"))
+	mock.ExpectCommit()
+
+	r := &BudgetReservation{Source: budget.SourceLadder, Reference: "tok-1", Provider: "aws", AccountIDs: []string{"acct-dev"}, UpfrontUSD: 5000}
+	require.NoError(t, store.ReserveCommitmentBudget(context.Background(), r))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ReserveCommitmentBudget_ReusesLiveReservation(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	reservedAt := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(budgetLockKey).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(`FROM budget_reservations WHERE source = \$1 AND reference = \$2`).
+		WithArgs(budget.SourceExecution, "exec-1").
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "source", "reference", "provider", "account_ids", "upfront_usd", "hourly_usd",
+			"status", "reserved_at", "settled_at", "release_reason",
+		}).AddRow("res-1", budget.SourceExecution, "exec-1", "aws", []string{}, 500.0, 0.0,
+			BudgetReservationCommitted, reservedAt, &reservedAt, ""))
+	mock.ExpectCommit()
+
+	r := &BudgetReservation{Source: budget.SourceExecution, Reference: "exec-1", Provider: "aws", UpfrontUSD: 500}
+	require.NoError(t, store.ReserveCommitmentBudget(context.Background(), r))
+	assert.Equal(t, "res-1", r.ID)
+	assert.Equal(t, BudgetReservationCommitted, r.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CommitBudgetReservation_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectExec(`UPDATE budget_reservations[\s\S]*SET status = 'committed'`).
+		WithArgs("res-1", 500.0, 0.0).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err := store.CommitBudgetReservation(context.Background(), "res-1", 500, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_DeleteCommitmentBudget_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectExec(`DELETE FROM commitment_budgets WHERE id = \$1`).
+		WithArgs("budget-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	assert.ErrorIs(t, store.DeleteCommitmentBudget(context.Background(), "budget-1"), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CreditCommitmentBudget(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	purchasedAt := time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`INSERT INTO budget_reservations[\s\S]*ON CONFLICT \(source, reference\) DO NOTHING`).
+		WithArgs(budget.SourceRevocation, "purchase-1", "azure", []string{}, -1200.0, 0.0, purchasedAt, "revoked").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, store.CreditCommitmentBudget(context.Background(), &BudgetReservation{
+		Source: budget.SourceRevocation, Reference: "purchase-1", Provider: "azure",
+		UpfrontUSD: -1200, ReservedAt: purchasedAt, ReleaseReason: "revoked",
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -47,6 +47,7 @@ var purchasePlanRowCols = []string{
 	"id", "name", "enabled", "auto_purchase", "notification_days_before",
 	"services", "ramp_schedule", "created_at", "updated_at",
 	"next_execution_date", "last_execution_date", "last_notification_sent",
+	"shadow_mode", "allow_double_coverage",
 }
 
 // rampStepArg is a pgxmock.Argument that unmarshals the ramp_schedule JSONB
@@ -90,7 +91,7 @@ func (a afterArg) Match(v interface{}) bool {
 	return ts.After(a.notBefore)
 }
 
-const purchasePlanUpdateArgs = 11
+const purchasePlanUpdateArgs = 13
 
 // completeStepUpdateArgs builds the WithArgs matcher list for the UPDATE issued
 // by CompletePlanStep, asserting the persisted CurrentStep at $7, a refreshed
@@ -117,6 +118,7 @@ func rampPlanRows(t *testing.T, planID string, ramp RampSchedule, now, stale tim
 		planID, "Ramp Plan", true, true, 3,
 		svcJSON, rampJSON, now, stale,
 		nextExec, sql.NullTime{Valid: false}, sql.NullTime{Valid: false},
+		false, false,
 	)
 }
 
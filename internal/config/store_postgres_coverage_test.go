@@ -570,10 +570,11 @@ func TestSaveGlobalConfig_OfferingClassBindsAt23(t *testing.T) {
 		OfferingClass:       "standard",
 	}
 
-	// Expect exactly 24 args; pgxmock validates arg count and types.
+	// Expect exactly 29 args; pgxmock validates arg count and types.
 	// The 21st arg is laddering_enabled; the 22nd is ladder_execution_enabled;
 	// the 23rd arg must be "standard" (offering_class); the 24th is
-	// require_different_approver (issue #1005).
+	// require_different_approver (issue #1005); the last four are the
+	// native recommendation settings, then the finance discount rate.
 	// If the real query regresses to a different arg count, pgxmock
 	// will return an unexpected-call error and the test will fail.
 	mock.ExpectExec(`INSERT INTO global_config`).
@@ -602,11 +603,16 @@ func TestSaveGlobalConfig_OfferingClassBindsAt23(t *testing.T) {
 			pgxmock.AnyArg(), // $22 ladder_execution_enabled
 			"standard",       // $23 offering_class -- the field this test guards
 			pgxmock.AnyArg(), // $24 require_different_approver
+			pgxmock.AnyArg(), // $25 recommendation_source
+			pgxmock.AnyArg(), // $26 native_rec_lookback_days
+			pgxmock.AnyArg(), // $27 native_rec_percentile
+			pgxmock.AnyArg(), // $28 native_rec_max_break_even_months
+			pgxmock.AnyArg(), // $29 finance_discount_rate_pct
 		).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = store.SaveGlobalConfig(ctx, cfg)
-	require.NoError(t, err, "SaveGlobalConfig must succeed when the DB accepts all 24 args")
+	require.NoError(t, err, "SaveGlobalConfig must succeed when the DB accepts all 29 args")
 
 	require.NoError(t, mock.ExpectationsWereMet(),
 		"offering_class must be bound as the 23rd argument to SaveGlobalConfig")
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+// ==========================================
+// CUR USAGE STORE METHODS
+// ==========================================
+
+// CURObjectETags returns the ETag each export object under source was last
+// ingested at, keyed by object key. Returns an empty map when nothing has
+// been ingested.
+func (s *PostgresStore) CURObjectETags(ctx context.Context, source string) (map[string]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT object_key, etag
+		  FROM cur_ingested_objects
+		 WHERE source = $1
+	`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cur_ingested_objects: %w", err)
+	}
+	defer rows.Close()
+
+	etags := make(map[string]string)
+	for rows.Next() {
+		var key, etag string
+		if err := rows.Scan(&key, &etag); err != nil {
+			return nil, fmt.Errorf("failed to scan cur_ingested_objects row: %w", err)
+		}
+		etags[key] = etag
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cur_ingested_objects rows: %w", err)
+	}
+	return etags, nil
+}
+
+// ReplaceCURObject records one ingested export object and its aggregates in
+// a single transaction. Any earlier ingestion of the same (source, key) is
+// deleted first (its aggregate rows cascade), so a rewritten object replaces
+// its contribution rather than adding to it.
+func (s *PostgresStore) ReplaceCURObject(ctx context.Context, source, key, etag string, pools []cur.PoolHour, commitments []cur.CommitmentHour) error {
+	return s.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM cur_ingested_objects WHERE source = $1 AND object_key = $2
+		`, source, key); err != nil {
+			return fmt.Errorf("failed to delete previous cur object %s: %w", key, err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO cur_ingested_objects (source, object_key, etag, ingested_at)
+			VALUES ($1, $2, $3, NOW())
+		`, source, key, etag); err != nil {
+			return fmt.Errorf("failed to insert cur object %s: %w", key, err)
+		}
+
+		if len(pools) > 0 {
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"cur_pool_usage_hourly"}, curPoolColumns,
+				pgx.CopyFromSlice(len(pools), func(i int) ([]any, error) {
+					p := pools[i]
+					return []any{
+						source, key, p.AccountID, p.ProductCode, p.Region, p.ResourceType,
+						p.Platform, p.DeploymentOption, p.Hour, p.UsageHours, p.OnDemandHours,
+						p.ReservedHours, p.SavingsPlanHours, p.OnDemandCost,
+					}, nil
+				})); err != nil {
+				return fmt.Errorf("failed to copy cur pool hours for %s: %w", key, err)
+			}
+		}
+		if len(commitments) > 0 {
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"cur_commitment_coverage_hourly"}, curCommitmentColumns,
+				pgx.CopyFromSlice(len(commitments), func(i int) ([]any, error) {
+					c := commitments[i]
+					return []any{
+						source, key, c.AccountID, c.CommitmentARN, c.CommitmentType, c.ProductCode,
+						c.Region, c.ResourceType, c.Hour, c.CoveredHours, c.UnusedHours, c.EffectiveCost,
+					}, nil
+				})); err != nil {
+				return fmt.Errorf("failed to copy cur commitment hours for %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteCURObjects removes the given objects under source and, by cascade,
+// their aggregates. Used to prune objects that disappeared from the export
+// location (a re-delivered billing period replaces its files).
+func (s *PostgresStore) DeleteCURObjects(ctx context.Context, source string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := s.db.Exec(ctx, `
+		DELETE FROM cur_ingested_objects WHERE source = $1 AND object_key = ANY($2)
+	`, source, keys); err != nil {
+		return fmt.Errorf("failed to delete cur objects: %w", err)
+	}
+	return nil
+}
+
+var curPoolColumns = []string{
+	"source", "object_key", "account_id", "product_code", "region", "resource_type",
+	"platform", "deployment_option", "hour", "usage_hours", "on_demand_hours",
+	"reserved_hours", "savings_plan_hours", "on_demand_cost",
+}
+
+var curCommitmentColumns = []string{
+	"source", "object_key", "account_id", "commitment_arn", "commitment_type", "product_code",
+	"region", "resource_type", "hour", "covered_hours", "unused_hours", "effective_cost",
+}
+
+// CURPoolHours returns the hourly pool usage in q's window summed across
+// sources and objects, ordered by hour. Empty ProductCodes / Regions match
+// everything. It backs cur.Reader.PoolHours.
+func (s *PostgresStore) CURPoolHours(ctx context.Context, q cur.Query) ([]cur.PoolHour, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT account_id, product_code, region, resource_type, platform, deployment_option, hour,
+		       SUM(usage_hours), SUM(on_demand_hours), SUM(reserved_hours),
+		       SUM(savings_plan_hours), SUM(on_demand_cost)
+		  FROM cur_pool_usage_hourly
+		 WHERE hour >= $1 AND hour < $2
+		   AND (cardinality($3::text[]) = 0 OR product_code = ANY($3))
+		   AND (cardinality($4::text[]) = 0 OR region = ANY($4))
+		 GROUP BY account_id, product_code, region, resource_type, platform, deployment_option, hour
+		 ORDER BY hour, account_id, product_code, region, resource_type, platform, deployment_option
+	`, q.From, q.To, nonNilStrings(q.ProductCodes), nonNilStrings(q.Regions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cur_pool_usage_hourly: %w", err)
+	}
+	defer rows.Close()
+
+	var out []cur.PoolHour
+	for rows.Next() {
+		var p cur.PoolHour
+		if err := rows.Scan(&p.AccountID, &p.ProductCode, &p.Region, &p.ResourceType, &p.Platform,
+			&p.DeploymentOption, &p.Hour, &p.UsageHours, &p.OnDemandHours, &p.ReservedHours,
+			&p.SavingsPlanHours, &p.OnDemandCost); err != nil {
+			return nil, fmt.Errorf("failed to scan cur_pool_usage_hourly row: %w", err)
+		}
+		p.Hour = p.Hour.UTC()
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cur_pool_usage_hourly rows: %w", err)
+	}
+	return out, nil
+}
+
+// CURCommitmentHours returns the hourly commitment coverage in q's window
+// summed across sources and objects, ordered by hour. It backs
+// cur.Reader.CommitmentHours.
+func (s *PostgresStore) CURCommitmentHours(ctx context.Context, q cur.Query) ([]cur.CommitmentHour, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT account_id, commitment_arn, commitment_type, product_code, region, resource_type, hour,
+		       SUM(covered_hours), SUM(unused_hours), SUM(effective_cost)
+		  FROM cur_commitment_coverage_hourly
+		 WHERE hour >= $1 AND hour < $2
+		   AND (cardinality($3::text[]) = 0 OR product_code = ANY($3))
+		   AND (cardinality($4::text[]) = 0 OR region = ANY($4))
+		 GROUP BY account_id, commitment_arn, commitment_type, product_code, region, resource_type, hour
+		 ORDER BY hour, account_id, commitment_arn, product_code, region, resource_type
+	`, q.From, q.To, nonNilStrings(q.ProductCodes), nonNilStrings(q.Regions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cur_commitment_coverage_hourly: %w", err)
+	}
+	defer rows.Close()
+
+	var out []cur.CommitmentHour
+	for rows.Next() {
+		var c cur.CommitmentHour
+		if err := rows.Scan(&c.AccountID, &c.CommitmentARN, &c.CommitmentType, &c.ProductCode,
+			&c.Region, &c.ResourceType, &c.Hour, &c.CoveredHours, &c.UnusedHours,
+			&c.EffectiveCost); err != nil {
+			return nil, fmt.Errorf("failed to scan cur_commitment_coverage_hourly row: %w", err)
+		}
+		c.Hour = c.Hour.UTC()
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cur_commitment_coverage_hourly rows: %w", err)
+	}
+	return out, nil
+}
+
+// nonNilStrings returns s, or an empty slice for nil, so pgx encodes an
+// empty text[] (which the cardinality guard matches) instead of NULL.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package config
+
+// store_postgres_cur_test.go -- pgxmock tests for the CUR usage store
+// methods (migration 000101): object replacement is one transaction that
+// deletes the previous ingestion before copying the new aggregates, and the
+// readers pass empty (never NULL) filter arrays so the cardinality guard
+// matches everything.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+func TestPGXMock_ReplaceCURObject_DeletesThenCopies(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	ctx := context.Background()
+	hour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	pools := []cur.PoolHour{{
+		PoolKey:    cur.PoolKey{AccountID: "1", ProductCode: cur.ProductEC2, Region: "us-east-1", ResourceType: "m5.large"},
+		Hour:       hour,
+		UsageHours: 2, OnDemandHours: 2, OnDemandCost: 0.192,
+	}}
+	commitments := []cur.CommitmentHour{{
+		CommitmentKey: cur.CommitmentKey{AccountID: "1", CommitmentARN: "arn:ri", CommitmentType: cur.CommitmentReservation, ProductCode: cur.ProductEC2, Region: "us-east-1", ResourceType: "m5.large"},
+		Hour:          hour,
+		CoveredHours:  1,
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM cur_ingested_objects WHERE source = \$1 AND object_key = \$2`).
+		WithArgs("s3://bucket/cur", "data/part-0.csv.gz").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO cur_ingested_objects`).
+		WithArgs("s3://bucket/cur", "data/part-0.csv.gz", "etag-2").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCopyFrom([]string{"cur_pool_usage_hourly"}, curPoolColumns).WillReturnResult(1)
+	mock.ExpectCopyFrom([]string{"cur_commitment_coverage_hourly"}, curCommitmentColumns).WillReturnResult(1)
+	mock.ExpectCommit()
+
+	require.NoError(t, store.ReplaceCURObject(ctx, "s3://bucket/cur", "data/part-0.csv.gz", "etag-2", pools, commitments))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CURObjectETags(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`SELECT object_key, etag\s+FROM cur_ingested_objects`).
+		WithArgs("/data/cur").
+		WillReturnRows(pgxmock.NewRows([]string{"object_key", "etag"}).
+			AddRow("a.csv", "e1").
+			AddRow("b.csv.gz", "e2"))
+
+	got, err := store.CURObjectETags(context.Background(), "/data/cur")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.csv": "e1", "b.csv.gz": "e2"}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_DeleteCURObjects_NoKeysIsNoop(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	require.NoError(t, store.DeleteCURObjects(context.Background(), "/data/cur", nil))
+	require.NoError(t, mock.ExpectationsWereMet(), "an empty prune must not touch the database")
+}
+
+func TestPGXMock_CURPoolHours_EmptyFiltersAreArrays(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(`FROM cur_pool_usage_hourly[\s\S]*GROUP BY`).
+		WithArgs(from, to, []string{}, []string{"us-east-1"}).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"account_id", "product_code", "region", "resource_type", "platform", "deployment_option", "hour",
+			"usage_hours", "on_demand_hours", "reserved_hours", "savings_plan_hours", "on_demand_cost",
+		}).AddRow("1", cur.ProductRDS, "us-east-1", "db.r5.large", "PostgreSQL", "Multi-AZ", from.Add(time.Hour),
+			3.0, 1.0, 2.0, 0.0, 0.5))
+
+	got, err := store.CURPoolHours(context.Background(), cur.Query{Regions: []string{"us-east-1"}, From: from, To: to})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Multi-AZ", got[0].DeploymentOption)
+	assert.Equal(t, 2.0, got[0].ReservedHours)
+	assert.Equal(t, from.Add(time.Hour), got[0].Hour)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CURCommitmentHours(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(`FROM cur_commitment_coverage_hourly[\s\S]*GROUP BY`).
+		WithArgs(from, to, []string{cur.ProductEC2}, []string{}).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"account_id", "commitment_arn", "commitment_type", "product_code", "region", "resource_type", "hour",
+			"covered_hours", "unused_hours", "effective_cost",
+		}).AddRow("1", "arn:ri", cur.CommitmentReservation, cur.ProductEC2, "us-east-1", "m5.large", from, 1.0, 0.25, 0.06))
+
+	got, err := store.CURCommitmentHours(context.Background(), cur.Query{ProductCodes: []string{cur.ProductEC2}, From: from, To: to})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "arn:ri", got[0].CommitmentARN)
+	assert.Equal(t, 0.25, got[0].UnusedHours)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,167 @@
+package config
+
+// store_postgres_freeze.go -- change-freeze calendars (migration 000112):
+// the configured blackout windows and the audit trail of administrators
+// overriding them for a single execution.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const freezeWindowCols = `id, name, reason, starts_at, ends_at, recurrence, provider, account_ids,
+		       action, enabled, created_at, updated_at`
+
+func scanFreezeWindow(row pgx.Row, w *FreezeWindow) error {
+	return row.Scan(&w.ID, &w.Name, &w.Reason, &w.StartsAt, &w.EndsAt, &w.Recurrence, &w.Provider, &w.AccountIDs,
+		&w.Action, &w.Enabled, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// freezeAccountIDs returns w's account IDs for an insert, never nil: the
+// column is NOT NULL.
+func freezeAccountIDs(w *FreezeWindow) []string {
+	if w.AccountIDs == nil {
+		return []string{}
+	}
+	return w.AccountIDs
+}
+
+// ListFreezeWindows returns every window ordered by start.
+func (s *PostgresStore) ListFreezeWindows(ctx context.Context) ([]FreezeWindow, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+freezeWindowCols+` FROM freeze_windows ORDER BY starts_at ASC, name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query freeze windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := make([]FreezeWindow, 0)
+	for rows.Next() {
+		var w FreezeWindow
+		if scanErr := scanFreezeWindow(rows, &w); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan freeze window: %w", scanErr)
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// CreateFreezeWindow inserts w, filling in its ID and timestamps.
+func (s *PostgresStore) CreateFreezeWindow(ctx context.Context, w *FreezeWindow) error {
+	if w == nil {
+		return fmt.Errorf("freeze window must not be nil")
+	}
+	const q = `
+		INSERT INTO freeze_windows (name, reason, starts_at, ends_at, recurrence, provider, account_ids, action, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	if err := s.db.QueryRow(ctx, q, w.Name, w.Reason, w.StartsAt, w.EndsAt, w.Recurrence, w.Provider,
+		freezeAccountIDs(w), w.Action, w.Enabled).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create freeze window %q: %w", w.Name, err)
+	}
+	return nil
+}
+
+// UpdateFreezeWindow rewrites w and refreshes its UpdatedAt.
+func (s *PostgresStore) UpdateFreezeWindow(ctx context.Context, w *FreezeWindow) error {
+	if w == nil {
+		return fmt.Errorf("freeze window must not be nil")
+	}
+	const q = `
+		UPDATE freeze_windows
+		SET name = $2, reason = $3, starts_at = $4, ends_at = $5, recurrence = $6, provider = $7,
+		    account_ids = $8, action = $9, enabled = $10, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := s.db.QueryRow(ctx, q, w.ID, w.Name, w.Reason, w.StartsAt, w.EndsAt, w.Recurrence, w.Provider,
+		freezeAccountIDs(w), w.Action, w.Enabled).Scan(&w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("freeze window %s: %w", w.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update freeze window %s: %w", w.ID, err)
+	}
+	return nil
+}
+
+// DeleteFreezeWindow removes a window. Overrides recorded against it keep
+// its name but lose the reference.
+func (s *PostgresStore) DeleteFreezeWindow(ctx context.Context, id string) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM freeze_windows WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete freeze window %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("freeze window %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+const freezeOverrideCols = `id, execution_id, window_id, window_name, actor, actor_user_id, reason, created_at`
+
+func scanFreezeOverride(row pgx.Row, o *FreezeOverride) error {
+	return row.Scan(&o.ID, &o.ExecutionID, &o.WindowID, &o.WindowName, &o.Actor, &o.ActorUserID, &o.Reason, &o.CreatedAt)
+}
+
+// RecordFreezeOverride records o, filling in its ID and CreatedAt.
+func (s *PostgresStore) RecordFreezeOverride(ctx context.Context, o *FreezeOverride) error {
+	if o == nil {
+		return fmt.Errorf("freeze override must not be nil")
+	}
+	const q = `
+		INSERT INTO freeze_overrides (execution_id, window_id, window_name, actor, actor_user_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	if err := s.db.QueryRow(ctx, q, o.ExecutionID, o.WindowID, o.WindowName, o.Actor, o.ActorUserID, o.Reason).
+		Scan(&o.ID, &o.CreatedAt); err != nil {
+		return fmt.Errorf("failed to record freeze override for execution %s: %w", o.ExecutionID, err)
+	}
+	return nil
+}
+
+// GetLatestFreezeOverride returns an execution's most recent override, or
+// nil when it has none.
+func (s *PostgresStore) GetLatestFreezeOverride(ctx context.Context, executionID string) (*FreezeOverride, error) {
+	var o FreezeOverride
+	err := scanFreezeOverride(s.db.QueryRow(ctx, `
+		SELECT `+freezeOverrideCols+` FROM freeze_overrides
+		WHERE execution_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, executionID), &o)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get freeze override for execution %s: %w", executionID, err)
+	}
+	return &o, nil
+}
+
+// ListFreezeOverrides returns the most recent overrides, newest first.
+func (s *PostgresStore) ListFreezeOverrides(ctx context.Context, limit int) ([]FreezeOverride, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+freezeOverrideCols+` FROM freeze_overrides
+		ORDER BY created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query freeze overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make([]FreezeOverride, 0)
+	for rows.Next() {
+		var o FreezeOverride
+		if scanErr := scanFreezeOverride(rows, &o); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan freeze override: %w", scanErr)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
@@ -0,0 +1,124 @@
+package config
+
+// store_postgres_freeze_test.go -- pgxmock tests for change-freeze windows
+// and their override audit trail (migration 000112).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var freezeWindowColNames = []string{
+	"id", "name", "reason", "starts_at", "ends_at", "recurrence", "provider", "account_ids",
+	"action", "enabled", "created_at", "updated_at",
+}
+
+var freezeOverrideColNames = []string{
+	"id", "execution_id", "window_id", "window_name", "actor", "actor_user_id", "reason", "created_at",
+}
+
+func TestPGXMock_ListFreezeWindows(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM freeze_windows ORDER BY starts_at`).
+		WillReturnRows(pgxmock.NewRows(freezeWindowColNames).
+			AddRow("win-1", "fy-close", "year-end close", start, start.AddDate(0, 0, 14), "yearly", "", []string{"acct-1"},
+				"defer", true, start, start))
+
+	windows, err := store.ListFreezeWindows(context.Background())
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, "fy-close", windows[0].Name)
+	assert.Equal(t, []string{"acct-1"}, windows[0].AccountIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CreateFreezeWindow(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 14)
+	mock.ExpectQuery(`INSERT INTO freeze_windows`).
+		WithArgs("fy-close", "", start, end, "none", "aws", []string{}, "skip", true).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("win-1", start, start))
+
+	w := &FreezeWindow{Name: "fy-close", StartsAt: start, EndsAt: end, Recurrence: "none", Provider: "aws", Action: "skip", Enabled: true}
+	require.NoError(t, store.CreateFreezeWindow(context.Background(), w))
+	assert.Equal(t, "win-1", w.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_UpdateFreezeWindow_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`UPDATE freeze_windows`).
+		WithArgs("win-1", "fy-close", "", pgxmock.AnyArg(), pgxmock.AnyArg(), "none", "", []string{}, "defer", true).
+		WillReturnError(pgx.ErrNoRows)
+
+	err := store.UpdateFreezeWindow(context.Background(), &FreezeWindow{ID: "win-1", Name: "fy-close", Recurrence: "none", Action: "defer", Enabled: true})
+	assert.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got: %v", err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_DeleteFreezeWindow_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectExec(`DELETE FROM freeze_windows`).WithArgs("win-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := store.DeleteFreezeWindow(context.Background(), "win-1")
+	assert.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got: %v", err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_RecordFreezeOverride(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	now := time.Now()
+	windowID := "win-1"
+	mock.ExpectQuery(`INSERT INTO freeze_overrides`).
+		WithArgs("exec-1", &windowID, "fy-close", "admin@example.com", (*string)(nil), "board approved").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow("ovr-1", now))
+
+	o := &FreezeOverride{ExecutionID: "exec-1", WindowID: &windowID, WindowName: "fy-close", Actor: "admin@example.com", Reason: "board approved"}
+	require.NoError(t, store.RecordFreezeOverride(context.Background(), o))
+	assert.Equal(t, "ovr-1", o.ID)
+	assert.Equal(t, now, o.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_GetLatestFreezeOverride(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM freeze_overrides[\s\S]*WHERE execution_id = \$1`).WithArgs("exec-1").
+		WillReturnRows(pgxmock.NewRows(freezeOverrideColNames).
+			AddRow("ovr-1", "exec-1", (*string)(nil), "fy-close", "admin@example.com", (*string)(nil), "board approved", now))
+	mock.ExpectQuery(`FROM freeze_overrides[\s\S]*WHERE execution_id = \$1`).WithArgs("exec-2").
+		WillReturnError(pgx.ErrNoRows)
+
+	o, err := store.GetLatestFreezeOverride(context.Background(), "exec-1")
+	require.NoError(t, err)
+	require.NotNil(t, o)
+	assert.Equal(t, "fy-close", o.WindowName)
+	assert.Nil(t, o.WindowID)
+
+	o, err = store.GetLatestFreezeOverride(context.Background(), "exec-2")
+	require.NoError(t, err)
+	assert.Nil(t, o)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
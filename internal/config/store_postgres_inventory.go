@@ -0,0 +1,141 @@
+package config
+
+// store_postgres_inventory.go -- the commitments_inventory table (migration
+// 000107): provider-side commitments synced by the inventory_sync task,
+// including ones bought outside CUDly.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// inactiveCommitmentStates are the lowercased provider states of a
+// commitment that no longer covers usage. GetActiveCommitmentInventory
+// excludes them alongside the end_date filter; GetExistingCommitments
+// mostly lists live commitments already, so this is defense in depth.
+var inactiveCommitmentStates = []string{"retired", "expired", "cancelled", "canceled", "payment-failed"}
+
+// UpsertCommitmentInventory writes one account's synced commitments in a
+// single transaction, keyed by (provider, account_id, commitment_id). Every
+// row written is stamped with the transaction's NOW() as synced_at, and its
+// source is derived in SQL: 'cudly' when a purchase_history row of the same
+// provider carries the commitment ID as its purchase_id, 'external'
+// otherwise.
+//
+// Rows of cloudAccountID that this sync did not touch are then deleted when
+// their sync_scope is in listedScopes: that listing succeeded and no longer
+// returns them (expired, returned, sold). Rows whose scope failed to list,
+// or was not attempted, are kept, so a flaky region never empties the
+// inventory.
+func (s *PostgresStore) UpsertCommitmentInventory(ctx context.Context, cloudAccountID string, records []CommitmentInventoryRecord, listedScopes []string) error {
+	if cloudAccountID == "" {
+		return fmt.Errorf("cloud account ID is required")
+	}
+	return s.WithTx(ctx, func(tx pgx.Tx) error {
+		for i := range records {
+			if err := upsertCommitmentInventoryRow(ctx, tx, cloudAccountID, &records[i]); err != nil {
+				return err
+			}
+		}
+		if len(listedScopes) == 0 {
+			return nil
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM commitments_inventory
+			 WHERE cloud_account_id = $1 AND synced_at < NOW() AND sync_scope = ANY($2)
+		`, cloudAccountID, listedScopes); err != nil {
+			return fmt.Errorf("failed to evict stale commitment inventory: %w", err)
+		}
+		return nil
+	})
+}
+
+// upsertCommitmentInventoryRow writes a single inventory row inside the
+// caller's transaction. See UpsertCommitmentInventory.
+func upsertCommitmentInventoryRow(ctx context.Context, tx pgx.Tx, cloudAccountID string, r *CommitmentInventoryRecord) error {
+	const q = `
+		INSERT INTO commitments_inventory (
+			provider, account_id, cloud_account_id, commitment_id, commitment_type,
+			service, region, resource_type, engine, count, start_date, end_date,
+			state, hourly_cost, sync_scope, source, synced_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			CASE WHEN EXISTS (
+				SELECT 1 FROM purchase_history
+				 WHERE provider = $1 AND purchase_id = $4
+			) THEN 'cudly' ELSE 'external' END,
+			NOW()
+		)
+		ON CONFLICT (provider, account_id, commitment_id) DO UPDATE SET
+			cloud_account_id = EXCLUDED.cloud_account_id,
+			commitment_type  = EXCLUDED.commitment_type,
+			service          = EXCLUDED.service,
+			region           = EXCLUDED.region,
+			resource_type    = EXCLUDED.resource_type,
+			engine           = EXCLUDED.engine,
+			count            = EXCLUDED.count,
+			start_date       = EXCLUDED.start_date,
+			end_date         = EXCLUDED.end_date,
+			state            = EXCLUDED.state,
+			hourly_cost      = EXCLUDED.hourly_cost,
+			sync_scope       = EXCLUDED.sync_scope,
+			source           = EXCLUDED.source,
+			synced_at        = EXCLUDED.synced_at
+	`
+	if _, err := tx.Exec(ctx, q,
+		r.Provider, r.AccountID, cloudAccountID, r.CommitmentID, r.CommitmentType,
+		r.Service, r.Region, r.ResourceType, r.Engine, r.Count, r.StartDate, r.EndDate,
+		strings.ToLower(r.State), r.HourlyCost, r.SyncScope,
+	); err != nil {
+		return fmt.Errorf("failed to upsert commitment %s: %w", r.CommitmentID, err)
+	}
+	return nil
+}
+
+// GetActiveCommitmentInventory returns the synced commitments that are
+// still live as of asOf: end_date unset or not yet passed, and a state other
+// than inactiveCommitmentStates. Both sources are returned; callers merging
+// with purchase_history keep only the external rows. The account scope is
+// the same dual-column predicate GetActivePurchaseHistory applies, so
+// empty inputs mean all accounts.
+func (s *PostgresStore) GetActiveCommitmentInventory(ctx context.Context, asOf time.Time, accountIDs []string, externalIDsByProvider map[string][]string) ([]CommitmentInventoryRecord, error) {
+	conds := []string{
+		"(end_date IS NULL OR end_date >= $1)",
+		"state <> ALL($2)",
+	}
+	args := []any{asOf, inactiveCommitmentStates}
+	conds, args = appendAccountPredicate(conds, args, accountIDs, externalIDsByProvider)
+
+	query := fmt.Sprintf(`
+		SELECT provider, account_id, cloud_account_id, commitment_id, commitment_type,
+		       service, region, resource_type, engine, count, start_date, end_date,
+		       state, hourly_cost::float8, source, sync_scope, synced_at
+		FROM commitments_inventory
+		WHERE %s
+		ORDER BY end_date ASC NULLS LAST
+	`, strings.Join(conds, " AND "))
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commitment inventory: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]CommitmentInventoryRecord, 0)
+	for rows.Next() {
+		var r CommitmentInventoryRecord
+		if scanErr := rows.Scan(
+			&r.Provider, &r.AccountID, &r.CloudAccountID, &r.CommitmentID, &r.CommitmentType,
+			&r.Service, &r.Region, &r.ResourceType, &r.Engine, &r.Count, &r.StartDate, &r.EndDate,
+			&r.State, &r.HourlyCost, &r.Source, &r.SyncScope, &r.SyncedAt,
+		); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan commitment inventory: %w", scanErr)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,141 @@
+package config
+
+// store_postgres_inventory_test.go -- pgxmock tests for the commitment
+// inventory (migration 000107).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inventoryCloudAccountID = "11111111-1111-1111-1111-111111111111"
+
+func inventoryRecord(id string) CommitmentInventoryRecord {
+	end := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	return CommitmentInventoryRecord{
+		Provider:       "aws",
+		AccountID:      "123456789012",
+		CommitmentID:   id,
+		CommitmentType: "reserved-instance",
+		Service:        "ec2",
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+		Count:          2,
+		EndDate:        &end,
+		State:          "Active",
+		HourlyCost:     0.12,
+		SyncScope:      "compute/us-east-1",
+	}
+}
+
+// inventoryUpsertArgs is the argument list UpsertCommitmentInventory binds
+// for rec.
+func inventoryUpsertArgs(rec CommitmentInventoryRecord) []any {
+	return []any{"aws", "123456789012", inventoryCloudAccountID, rec.CommitmentID, "reserved-instance",
+		"ec2", "us-east-1", "m5.large", "", 2, rec.StartDate, rec.EndDate, "active", 0.12, "compute/us-east-1"}
+}
+
+func TestPGXMock_UpsertCommitmentInventory(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	rec := inventoryRecord("ri-1")
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO commitments_inventory[\s\S]*FROM purchase_history[\s\S]*ON CONFLICT \(provider, account_id, commitment_id\)`).
+		WithArgs(inventoryUpsertArgs(rec)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`DELETE FROM commitments_inventory[\s\S]*synced_at < NOW\(\) AND sync_scope = ANY\(\$2\)`).
+		WithArgs(inventoryCloudAccountID, []string{"compute/us-east-1", "compute/us-west-2"}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mock.ExpectCommit()
+
+	require.NoError(t, store.UpsertCommitmentInventory(context.Background(), inventoryCloudAccountID, []CommitmentInventoryRecord{rec}, []string{"compute/us-east-1", "compute/us-west-2"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_UpsertCommitmentInventory_NothingListedKeepsRows(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO commitments_inventory`).
+		WithArgs(inventoryUpsertArgs(inventoryRecord("ri-1"))...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, store.UpsertCommitmentInventory(context.Background(), inventoryCloudAccountID, []CommitmentInventoryRecord{inventoryRecord("ri-1")}, nil))
+	require.NoError(t, mock.ExpectationsWereMet(), "no successfully listed scope means nothing is evicted")
+}
+
+func TestPGXMock_UpsertCommitmentInventory_Errors(t *testing.T) {
+	t.Run("missing account", func(t *testing.T) {
+		store := storeWith(newMock(t))
+		err := store.UpsertCommitmentInventory(context.Background(), "", nil, nil)
+		assert.ErrorContains(t, err, "cloud account ID is required")
+	})
+
+	t.Run("insert failure rolls back", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO commitments_inventory`).
+			WithArgs(inventoryUpsertArgs(inventoryRecord("ri-1"))...).
+			WillReturnError(errors.New("db down"))
+		mock.ExpectRollback()
+
+		err := store.UpsertCommitmentInventory(context.Background(), inventoryCloudAccountID, []CommitmentInventoryRecord{inventoryRecord("ri-1")}, nil)
+		assert.ErrorContains(t, err, "failed to upsert commitment ri-1")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPGXMock_GetActiveCommitmentInventory(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	asOf := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	synced := time.Date(2026, 9, 30, 6, 0, 0, 0, time.UTC)
+	cloudAccountID := inventoryCloudAccountID
+
+	mock.ExpectQuery(`FROM commitments_inventory[\s\S]*end_date IS NULL OR end_date >= \$1[\s\S]*state <> ALL\(\$2\)[\s\S]*\(provider = \$3 AND account_id = ANY\(\$4\)\)`).
+		WithArgs(asOf, inactiveCommitmentStates, "aws", []string{"123456789012"}).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"provider", "account_id", "cloud_account_id", "commitment_id", "commitment_type",
+			"service", "region", "resource_type", "engine", "count", "start_date", "end_date",
+			"state", "hourly_cost", "source", "sync_scope", "synced_at",
+		}).AddRow(
+			"aws", "123456789012", &cloudAccountID, "sp-1", "savings-plan",
+			"savingsplans", "us-east-1", "Compute", "", 1, &start, &end,
+			"active", 1.5, CommitmentSourceExternal, "savingsplans-compute/us-east-1", synced,
+		))
+
+	recs, err := store.GetActiveCommitmentInventory(context.Background(), asOf, nil, map[string][]string{"aws": {"123456789012"}})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "sp-1", recs[0].CommitmentID)
+	assert.Equal(t, CommitmentSourceExternal, recs[0].Source)
+	assert.Equal(t, 1.5, recs[0].HourlyCost)
+	require.NotNil(t, recs[0].EndDate)
+	assert.Equal(t, end, *recs[0].EndDate)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_GetActiveCommitmentInventory_QueryError(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`FROM commitments_inventory`).
+		WithArgs(pgxmock.AnyArg(), inactiveCommitmentStates).
+		WillReturnError(errors.New("db down"))
+	_, err := store.GetActiveCommitmentInventory(context.Background(), time.Now(), nil, nil)
+	assert.ErrorContains(t, err, "failed to query commitment inventory")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -21,7 +21,7 @@ import (
 // Returns an empty slice (not nil) when no rows exist.
 func (s *PostgresStore) GetLadderConfigs(ctx context.Context) ([]LadderConfigDB, error) {
 	query := `
-		SELECT id, cloud_account_id, provider, enabled, mode, cadence,
+		SELECT id, cloud_account_id, provider, service, enabled, mode, cadence,
 		       target_coverage, buffer_fraction, baseline_percentile,
 		       lookback_days, buffer_utilization_threshold,
 		       max_hourly_commit_per_run, max_actions_per_run,
@@ -53,30 +53,31 @@ func (s *PostgresStore) GetLadderConfigs(ctx context.Context) ([]LadderConfigDB,
 }
 
 // GetLadderConfig returns the ladder_config row for the given
-// (cloud_account_id, provider) pair. Returns (nil, nil) when no row exists.
-func (s *PostgresStore) GetLadderConfig(ctx context.Context, cloudAccountID, provider string) (*LadderConfigDB, error) {
+// (cloud_account_id, provider, service) triple; service is "" for the compute
+// ladder. Returns (nil, nil) when no row exists.
+func (s *PostgresStore) GetLadderConfig(ctx context.Context, cloudAccountID, provider, service string) (*LadderConfigDB, error) {
 	query := `
-		SELECT id, cloud_account_id, provider, enabled, mode, cadence,
+		SELECT id, cloud_account_id, provider, service, enabled, mode, cadence,
 		       target_coverage, buffer_fraction, baseline_percentile,
 		       lookback_days, buffer_utilization_threshold,
 		       max_hourly_commit_per_run, max_actions_per_run,
 		       ramp_schedule, created_at, updated_at
 		FROM ladder_configs
-		WHERE cloud_account_id = $1 AND provider = $2
+		WHERE cloud_account_id = $1 AND provider = $2 AND service = $3
 	`
-	row := s.db.QueryRow(ctx, query, cloudAccountID, provider)
+	row := s.db.QueryRow(ctx, query, cloudAccountID, provider, service)
 	cfg, err := scanLadderConfig(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to get ladder_config for account=%s provider=%s: %w", cloudAccountID, provider, err)
+		return nil, fmt.Errorf("failed to get ladder_config for account=%s provider=%s service=%q: %w", cloudAccountID, provider, service, err)
 	}
 	return &cfg, nil
 }
 
 // UpsertLadderConfig inserts or updates the per-account ladder configuration.
-// The upsert key is (cloud_account_id, provider). If ID is empty a new UUID
+// The upsert key is (cloud_account_id, provider, service). If ID is empty a new UUID
 // is generated; existing rows retain their original id and created_at.
 //
 // Validate() must be called by the API handler before this method; the store
@@ -97,9 +98,9 @@ func (s *PostgresStore) UpsertLadderConfig(ctx context.Context, cfg *LadderConfi
 			target_coverage, buffer_fraction, baseline_percentile,
 			lookback_days, buffer_utilization_threshold,
 			max_hourly_commit_per_run, max_actions_per_run,
-			ramp_schedule, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
-		ON CONFLICT (cloud_account_id, provider) DO UPDATE SET
+			ramp_schedule, service, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW(), NOW())
+		ON CONFLICT (cloud_account_id, provider, service) DO UPDATE SET
 			enabled                      = $4,
 			mode                         = $5,
 			cadence                      = $6,
@@ -112,7 +113,7 @@ func (s *PostgresStore) UpsertLadderConfig(ctx context.Context, cfg *LadderConfi
 			max_actions_per_run          = $13,
 			ramp_schedule                = $14,
 			updated_at                   = NOW()
-		RETURNING id, cloud_account_id, provider, enabled, mode, cadence,
+		RETURNING id, cloud_account_id, provider, service, enabled, mode, cadence,
 		          target_coverage, buffer_fraction, baseline_percentile,
 		          lookback_days, buffer_utilization_threshold,
 		          max_hourly_commit_per_run, max_actions_per_run,
@@ -134,11 +135,12 @@ func (s *PostgresStore) UpsertLadderConfig(ctx context.Context, cfg *LadderConfi
 		cfg.MaxHourlyCommitPerRun,
 		cfg.MaxActionsPerRun,
 		rampJSON,
+		cfg.Service,
 	)
 	result, err := scanLadderConfig(row)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upsert ladder_config for account=%s provider=%s: %w",
-			cfg.CloudAccountID, cfg.Provider, err)
+		return nil, fmt.Errorf("failed to upsert ladder_config for account=%s provider=%s service=%q: %w",
+			cfg.CloudAccountID, cfg.Provider, cfg.Service, err)
 	}
 	return &result, nil
 }
@@ -146,7 +148,7 @@ func (s *PostgresStore) UpsertLadderConfig(ctx context.Context, cfg *LadderConfi
 // scanLadderConfig scans a single ladder_configs row from either a pgx.Row
 // or pgx.Rows. Both types satisfy the scannable interface (declared in
 // store_postgres_registrations.go). This helper exists to avoid duplicating
-// the 16-column scan logic.
+// the 17-column scan logic.
 func scanLadderConfig(row scannable) (LadderConfigDB, error) {
 	var cfg LadderConfigDB
 	var rampJSON []byte
@@ -156,6 +158,7 @@ func scanLadderConfig(row scannable) (LadderConfigDB, error) {
 		&cfg.ID,
 		&cfg.CloudAccountID,
 		&cfg.Provider,
+		&cfg.Service,
 		&cfg.Enabled,
 		&cfg.Mode,
 		&cfg.Cadence,
@@ -514,3 +514,41 @@ func TestPostgresStore_SaveLadderRunWithTranches_AppendOnly(t *testing.T) {
 	require.NotNil(t, inFlight)
 	assert.InDelta(t, 5.5, *inFlight, 1e-6, "in-flight must sum both live scheduled generations (3.0 + 2.5)")
 }
+
+// TestPostgresStore_LadderConfig_PerServiceRows pins migration 000099: the
+// compute ladder and a service ladder for the same account are separate rows,
+// and GetLadderConfig selects by service.
+func TestPostgresStore_LadderConfig_PerServiceRows(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+	store := setupLadderStore(ctx, t)
+
+	computeID := seedLadderConfig(ctx, t, store)
+	compute, err := store.GetLadderConfigs(ctx)
+	require.NoError(t, err)
+	require.Len(t, compute, 1)
+	acctID := compute[0].CloudAccountID
+
+	rds := compute[0]
+	rds.ID = ""
+	rds.Service = "rds"
+	rds.BufferFraction = 0
+	saved, err := store.UpsertLadderConfig(ctx, &rds)
+	require.NoError(t, err)
+	assert.NotEqual(t, computeID, saved.ID, "a service ladder must not overwrite the compute row")
+
+	got, err := store.GetLadderConfig(ctx, acctID, "aws", "rds")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "rds", got.Service)
+	assert.Zero(t, got.BufferFraction)
+
+	got, err = store.GetLadderConfig(ctx, acctID, "aws", "")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, computeID, got.ID)
+
+	got, err = store.GetLadderConfig(ctx, acctID, "aws", "elasticache")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -64,6 +64,9 @@ func TestPGXMock_GetGlobalConfig_Success(t *testing.T) {
 		"ladder_execution_enabled",
 		"offering_class",
 		"require_different_approver",
+		"recommendation_source", "native_rec_lookback_days",
+		"native_rec_percentile", "native_rec_max_break_even_months",
+		"finance_discount_rate_pct",
 	}
 	rows := pgxmock.NewRows(cols).AddRow(
 		[]string{"aws"}, strPtr("ops@example.com"), true,
@@ -78,6 +81,8 @@ func TestPGXMock_GetGlobalConfig_Success(t *testing.T) {
 		false,
 		"convertible",
 		false,
+		"vendor", 30, 10.0, 0.0,
+		(*float64)(nil),
 	)
 	mock.ExpectQuery("SELECT").WillReturnRows(rows)
 
@@ -90,6 +95,10 @@ func TestPGXMock_GetGlobalConfig_Success(t *testing.T) {
 	assert.Equal(t, 7, cfg.RecommendationsLookbackDays)
 	assert.Equal(t, "convertible", cfg.OfferingClass)
 	assert.False(t, cfg.RequireDifferentApprover)
+	assert.Equal(t, RecommendationSourceVendor, cfg.RecommendationSource)
+	assert.Equal(t, 30, cfg.NativeRecLookbackDays)
+	assert.Equal(t, 10.0, cfg.NativeRecPercentile)
+	assert.Nil(t, cfg.FinanceDiscountRatePct, "NULL means the finance default rate")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -124,6 +133,9 @@ func TestPGXMock_GetGlobalConfig_GracePeriodDays(t *testing.T) {
 		"ladder_execution_enabled",
 		"offering_class",
 		"require_different_approver",
+		"recommendation_source", "native_rec_lookback_days",
+		"native_rec_percentile", "native_rec_max_break_even_months",
+		"finance_discount_rate_pct",
 	}
 	baseRow := func(graceJSON string) []any {
 		return []any{
@@ -139,6 +151,8 @@ func TestPGXMock_GetGlobalConfig_GracePeriodDays(t *testing.T) {
 			false,
 			"convertible",
 			false,
+			"vendor", 30, 10.0, 0.0,
+			(*float64)(nil),
 		}
 	}
 
@@ -199,6 +213,9 @@ var globalConfigCols = []string{
 	"ladder_execution_enabled",
 	"offering_class",
 	"require_different_approver",
+	"recommendation_source", "native_rec_lookback_days",
+	"native_rec_percentile", "native_rec_max_break_even_months",
+	"finance_discount_rate_pct",
 }
 
 // TestPGXMock_UpdateGlobalConfigAtomic_LockedReadModifyWrite proves the F2
@@ -226,10 +243,12 @@ func TestPGXMock_UpdateGlobalConfigAtomic_LockedReadModifyWrite(t *testing.T) {
 		"{}",
 		24, 7,
 		48,
-		false,         // laddering_enabled = false
-		false,         // ladder_execution_enabled = false
-		"convertible", // offering_class
-		false,         // require_different_approver
+		false,                   // laddering_enabled = false
+		false,                   // ladder_execution_enabled = false
+		"convertible",           // offering_class
+		false,                   // require_different_approver
+		"vendor", 30, 10.0, 0.0, // native recommendation settings
+		(*float64)(nil), // finance_discount_rate_pct
 	)
 
 	// Strict order: the SELECT and the UPSERT must sit between the same
@@ -238,7 +257,7 @@ func TestPGXMock_UpdateGlobalConfigAtomic_LockedReadModifyWrite(t *testing.T) {
 	mock.ExpectExec("pg_advisory_xact_lock").WithArgs(pgxmock.AnyArg()).
 		WillReturnResult(pgxmock.NewResult("SELECT", 1))
 	mock.ExpectQuery("FROM global_config").WillReturnRows(seeded)
-	mock.ExpectExec("INSERT INTO global_config").WithArgs(anyArgsCfg(24)...).
+	mock.ExpectExec("INSERT INTO global_config").WithArgs(anyArgsCfg(29)...).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectCommit()
 
@@ -287,6 +306,8 @@ func TestPGXMock_UpdateGlobalConfigAtomic_ApplyErrorRollsBack(t *testing.T) {
 		false,
 		"convertible",
 		false,
+		"vendor", 30, 10.0, 0.0,
+		(*float64)(nil),
 	)
 
 	mock.ExpectBegin()
@@ -383,11 +404,13 @@ func TestPGXMock_GetPurchasePlan_Success(t *testing.T) {
 		"id", "name", "enabled", "auto_purchase", "notification_days_before",
 		"services", "ramp_schedule", "created_at", "updated_at",
 		"next_execution_date", "last_execution_date", "last_notification_sent",
+		"shadow_mode", "allow_double_coverage",
 	}
 	rows := pgxmock.NewRows(cols).AddRow(
 		"plan-id", "My Plan", true, false, 3,
 		svcJSON, rampJSON, now, now,
 		sql.NullTime{Valid: false}, sql.NullTime{Valid: false}, sql.NullTime{Valid: false},
+		false, false,
 	)
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(rows)
 
@@ -410,6 +433,7 @@ func TestPGXMock_GetPurchasePlan_WithAllTimestamps(t *testing.T) {
 		"id", "name", "enabled", "auto_purchase", "notification_days_before",
 		"services", "ramp_schedule", "created_at", "updated_at",
 		"next_execution_date", "last_execution_date", "last_notification_sent",
+		"shadow_mode", "allow_double_coverage",
 	}
 	rows := pgxmock.NewRows(cols).AddRow(
 		"plan-id", "My Plan", true, false, 3,
@@ -417,6 +441,7 @@ func TestPGXMock_GetPurchasePlan_WithAllTimestamps(t *testing.T) {
 		sql.NullTime{Valid: true, Time: now},
 		sql.NullTime{Valid: true, Time: now},
 		sql.NullTime{Valid: true, Time: now},
+		true, true,
 	)
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(rows)
 
@@ -425,6 +450,8 @@ func TestPGXMock_GetPurchasePlan_WithAllTimestamps(t *testing.T) {
 	require.NotNil(t, plan.NextExecutionDate)
 	require.NotNil(t, plan.LastExecutionDate)
 	require.NotNil(t, plan.LastNotificationSent)
+	assert.True(t, plan.ShadowMode)
+	assert.True(t, plan.AllowDoubleCoverage)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -442,13 +469,13 @@ func TestPGXMock_ListPurchasePlans_Success(t *testing.T) {
 		"id", "name", "enabled", "auto_purchase", "notification_days_before",
 		"services", "ramp_schedule", "created_at", "updated_at",
 		"next_execution_date", "last_execution_date", "last_notification_sent",
-		"unassigned",
+		"shadow_mode", "allow_double_coverage", "unassigned",
 	}
 	rows := pgxmock.NewRows(cols).
 		AddRow("p1", "Plan 1", true, false, 3, svcJSON, rampJSON, now, now,
-			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false).
+			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false, false, false).
 		AddRow("p2", "Plan 2", false, true, 7, svcJSON, rampJSON, now, now,
-			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false)
+			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false, false, false)
 	mock.ExpectQuery("SELECT").WillReturnRows(rows)
 
 	plans, err := store.ListPurchasePlans(ctx, PurchasePlanFilter{})
@@ -488,15 +515,15 @@ func TestPGXMock_ListPurchasePlans_UnassignedIncluded(t *testing.T) {
 		"id", "name", "enabled", "auto_purchase", "notification_days_before",
 		"services", "ramp_schedule", "created_at", "updated_at",
 		"next_execution_date", "last_execution_date", "last_notification_sent",
-		"unassigned",
+		"shadow_mode", "allow_double_coverage", "unassigned",
 	}
 	// The query returns two rows: one assigned (unassigned=false) and one
 	// legacy zero-account plan (unassigned=true).
 	rows := pgxmock.NewRows(cols).
 		AddRow("assigned-id", "Assigned Plan", true, false, 3, svcJSON, rampJSON, now, now,
-			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false).
+			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false, false, false).
 		AddRow("legacy-id", "Legacy Plan", true, false, 3, svcJSON, rampJSON, now, now,
-			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, true)
+			sql.NullTime{}, sql.NullTime{}, sql.NullTime{}, false, false, true)
 	mock.ExpectQuery("SELECT").WithArgs("acc-uuid").WillReturnRows(rows)
 
 	plans, err := store.ListPurchasePlans(ctx, PurchasePlanFilter{AccountIDs: []string{"acc-uuid"}})
@@ -529,7 +556,7 @@ func TestPGXMock_UpdatePurchasePlan_NotFound(t *testing.T) {
 	// frame; the inner Exec returns 0 rows-affected, which the store
 	// surfaces as a "not found" error after the rollback.
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").WithArgs(anyArgsCfg(11)...).
+	mock.ExpectExec("UPDATE").WithArgs(anyArgsCfg(13)...).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 	mock.ExpectRollback()
 
@@ -817,6 +844,7 @@ func TestPGXMock_GetPurchaseHistory_Success(t *testing.T) {
 	ctx := context.Background()
 
 	now := time.Now().Truncate(time.Second)
+	termEnd := now.Add(90 * 24 * time.Hour)
 	cols := []string{
 		"account_id", "purchase_id", "timestamp", "provider", "service", "region",
 		"resource_type", "count", "term", "payment", "upfront_cost", "monthly_cost",
@@ -825,6 +853,8 @@ func TestPGXMock_GetPurchaseHistory_Success(t *testing.T) {
 		"revocation_window_closes_at", "revoked_at", "revoked_via", "support_case_id",
 		// marketplace columns (issue #292)
 		"offering_class", "listing_id", "listing_state",
+		// partial-term end (migration 000106)
+		"term_end",
 	}
 	rows := pgxmock.NewRows(cols).
 		AddRow("acc-1", "pur-1", now, "aws", "ec2", "us-east-1",
@@ -836,12 +866,14 @@ func TestPGXMock_GetPurchaseHistory_Success(t *testing.T) {
 			nil, nil, sql.NullString{}, sql.NullString{},
 			// marketplace columns (issue #292)
 			sql.NullString{Valid: true, String: "standard"},
-			sql.NullString{}, sql.NullString{}).
+			sql.NullString{}, sql.NullString{},
+			// partial-term end (migration 000106)
+			&termEnd).
 		AddRow("acc-1", "pur-2", now, "aws", "rds", "us-west-2",
 			"db.t3.medium", 1, 3, "all-upfront", 200.0, 0.0, 100.0,
 			sql.NullString{}, sql.NullString{}, 0, sql.NullString{},
 			nil, nil, sql.NullString{}, sql.NullString{},
-			sql.NullString{}, sql.NullString{}, sql.NullString{})
+			sql.NullString{}, sql.NullString{}, sql.NullString{}, nil)
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(rows)
 
 	records, err := store.GetPurchaseHistory(ctx, "acc-1", 10)
@@ -849,6 +881,9 @@ func TestPGXMock_GetPurchaseHistory_Success(t *testing.T) {
 	assert.Len(t, records, 2)
 	assert.Equal(t, "plan-1", records[0].PlanID)
 	assert.Equal(t, "", records[1].PlanID) // null → empty
+	require.NotNil(t, records[0].TermEnd)
+	assert.True(t, termEnd.Equal(*records[0].TermEnd))
+	assert.Nil(t, records[1].TermEnd) // null → full term
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -876,6 +911,8 @@ func TestPGXMock_GetPurchaseHistoryByPurchaseID_Success(t *testing.T) {
 		"revocation_in_flight",
 		// marketplace columns (issue #292)
 		"offering_class", "listing_id", "listing_state",
+		// partial-term end (migration 000106)
+		"term_end",
 	}
 	// monthly_cost scans straight into the *float64 r.MonthlyCost (not through
 	// the nullables struct), so the mock value must be a *float64.
@@ -893,7 +930,9 @@ func TestPGXMock_GetPurchaseHistoryByPurchaseID_Success(t *testing.T) {
 			// marketplace columns (issue #292)
 			sql.NullString{Valid: true, String: "standard"},
 			sql.NullString{Valid: true, String: "listing-1"},
-			sql.NullString{Valid: true, String: "active"})
+			sql.NullString{Valid: true, String: "active"},
+			// partial-term end (migration 000106)
+			nil)
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(rows)
 
 	record, err := store.GetPurchaseHistoryByPurchaseID(ctx, "pur-1")
@@ -935,13 +974,13 @@ func TestPGXMock_GetPurchaseHistoryByPurchaseID_NotFound(t *testing.T) {
 // the order GetPurchaseHistoryFiltered scans them. Keep in sync with
 // queryPurchaseHistory in store_postgres.go (issue #290 added the 4 revocation
 // columns at positions 18-21; issue #292 added the 3 marketplace columns at
-// positions 22-24).
+// positions 22-24; migration 000106 added term_end at position 25).
 var purchaseHistoryCols = []string{
 	"account_id", "purchase_id", "timestamp", "provider", "service", "region",
 	"resource_type", "count", "term", "payment", "upfront_cost", "monthly_cost",
 	"estimated_savings", "plan_id", "plan_name", "ramp_step", "cloud_account_id",
 	"revocation_window_closes_at", "revoked_at", "revoked_via", "support_case_id",
-	"offering_class", "listing_id", "listing_state",
+	"offering_class", "listing_id", "listing_state", "term_end",
 }
 
 // purchaseHistoryRow builds a single AddRow tuple matching purchaseHistoryCols.
@@ -954,6 +993,8 @@ func purchaseHistoryRow(now time.Time, provider, acct string) []interface{} {
 		nil, nil, sql.NullString{}, sql.NullString{},
 		// marketplace columns (issue #292): all null for unlisted rows
 		sql.NullString{}, sql.NullString{}, sql.NullString{},
+		// term_end (migration 000106): null for full-term commitments
+		nil,
 	}
 }
 
@@ -1116,7 +1157,7 @@ func TestPGXMock_GetActivePurchaseHistory_Unscoped(t *testing.T) {
 	now := time.Now().Truncate(time.Second)
 	rows := pgxmock.NewRows(purchaseHistoryCols).AddRow(purchaseHistoryRow(now, "aws", "acct-1")...)
 	mock.ExpectQuery(
-		`SELECT account_id, purchase_id, .*revocation_window_closes_at, revoked_at, revoked_via, support_case_id, offering_class, listing_id, listing_state FROM purchase_history WHERE term > 0 AND timestamp \+ make_interval\(hours => term \* 8760\) >= \$1 AND revoked_at IS NULL ORDER BY timestamp DESC$`,
+		`SELECT account_id, purchase_id, .*revocation_window_closes_at, revoked_at, revoked_via, support_case_id, offering_class, listing_id, listing_state, term_end FROM purchase_history WHERE term > 0 AND COALESCE\(term_end, timestamp \+ make_interval\(hours => term \* 8760\)\) >= \$1 AND revoked_at IS NULL ORDER BY timestamp DESC$`,
 	).WithArgs(now).WillReturnRows(rows)
 
 	records, err := store.GetActivePurchaseHistory(ctx, now, nil, nil)
@@ -1140,7 +1181,7 @@ func TestPGXMock_GetActivePurchaseHistory_AccountScoped(t *testing.T) {
 	now := time.Now().Truncate(time.Second)
 	rows := pgxmock.NewRows(purchaseHistoryCols).AddRow(purchaseHistoryRow(now, "aws", "111122223333")...)
 	mock.ExpectQuery(
-		`FROM purchase_history WHERE term > 0 AND timestamp \+ make_interval\(hours => term \* 8760\) >= \$1 AND revoked_at IS NULL AND \(cloud_account_id = ANY\(\$2\) OR \(provider = \$3 AND account_id = ANY\(\$4\)\)\) ORDER BY timestamp DESC$`,
+		`FROM purchase_history WHERE term > 0 AND COALESCE\(term_end, timestamp \+ make_interval\(hours => term \* 8760\)\) >= \$1 AND revoked_at IS NULL AND \(cloud_account_id = ANY\(\$2\) OR \(provider = \$3 AND account_id = ANY\(\$4\)\)\) ORDER BY timestamp DESC$`,
 	).WithArgs(now, []string{"acct-uuid-1"}, "aws", []string{"111122223333"}).WillReturnRows(rows)
 
 	records, err := store.GetActivePurchaseHistory(ctx, now,
@@ -1221,6 +1262,7 @@ func riExchangeRow(now time.Time) []interface{} {
 		now, now, sql.NullTime{}, sql.NullTime{},
 		sql.NullString{}, sql.NullString{}, // created_by_user_id, approved_by
 		sql.NullString{}, // ladder_run_id (NULL for standalone)
+		"aws",
 	}
 }
 
@@ -1230,7 +1272,7 @@ var riExchangeCols = []string{
 	"target_instance_type", "target_count", "payment_due",
 	"status", "approval_token", "error", "mode",
 	"created_at", "updated_at", "completed_at", "expires_at",
-	"created_by_user_id", "approved_by", "ladder_run_id",
+	"created_by_user_id", "approved_by", "ladder_run_id", "provider",
 }
 
 func TestPGXMock_GetRIExchangeRecord_Success(t *testing.T) {
@@ -1266,6 +1308,7 @@ func TestPGXMock_GetRIExchangeRecord_WithTimestamps(t *testing.T) {
 		now, now, sql.NullTime{Valid: true, Time: now}, sql.NullTime{Valid: true, Time: now},
 		sql.NullString{}, sql.NullString{}, // created_by_user_id, approved_by
 		sql.NullString{}, // ladder_run_id
+		"azure",
 	}
 	rows := pgxmock.NewRows(riExchangeCols).AddRow(row...)
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(rows)
@@ -1275,6 +1318,7 @@ func TestPGXMock_GetRIExchangeRecord_WithTimestamps(t *testing.T) {
 	assert.NotNil(t, rec.CompletedAt)
 	assert.NotNil(t, rec.ExpiresAt)
 	assert.Equal(t, "some error", rec.Error)
+	assert.Equal(t, "azure", rec.Provider)
 }
 
 func TestPGXMock_GetRIExchangeRecordByToken_Success(t *testing.T) {
@@ -2157,9 +2201,10 @@ func TestPGXMock_SaveRIExchangeRecord_InsertColumnAlignment(t *testing.T) {
 	store := storeWith(mock)
 	ctx := context.Background()
 
-	// 21 columns/placeholders: original 20 + ladder_run_id ($21, issue #1348).
-	// A column/placeholder count drift makes WithArgs(anyArgsCfg(21)) fail.
-	mock.ExpectExec("INSERT INTO ri_exchange_history").WithArgs(anyArgsCfg(21)...).
+	// 22 columns/placeholders: original 20 + ladder_run_id ($21, issue #1348)
+	// + provider ($22, migration 000105).
+	// A column/placeholder count drift makes WithArgs(anyArgsCfg(22)) fail.
+	mock.ExpectExec("INSERT INTO ri_exchange_history").WithArgs(anyArgsCfg(22)...).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	ladderRunID := "run-123"
@@ -2198,9 +2243,9 @@ func TestPGXMock_SavePurchaseHistory_Success(t *testing.T) {
 	store := storeWith(mock)
 	ctx := context.Background()
 
-	// 20 columns: original 18 + revocation_window_closes_at (issue #290)
-	// + offering_class (issue #292).
-	mock.ExpectExec("INSERT INTO purchase_history").WithArgs(anyArgsCfg(20)...).
+	// 21 columns: original 18 + revocation_window_closes_at (issue #290)
+	// + offering_class (issue #292) + term_end (migration 000106).
+	mock.ExpectExec("INSERT INTO purchase_history").WithArgs(anyArgsCfg(21)...).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err := store.SavePurchaseHistory(ctx, &PurchaseHistoryRecord{
@@ -2614,6 +2659,71 @@ func TestPGXMock_TransitionExecutionStatus_ProbeHardErrorNotMappedToNotFound(t *
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+// ─── DeferExecutionAtomic ─────────────────────────────────────────────────────
+
+// TestPGXMock_DeferExecutionAtomic writes the status and the fire time in one
+// CAS UPDATE, and a CAS miss reads as ErrExecutionNotInExpectedStatus like
+// TransitionExecutionStatus.
+func TestPGXMock_DeferExecutionAtomic(t *testing.T) {
+	ctx := context.Background()
+	recsJSON, _ := json.Marshal([]RecommendationRecord{})
+	now := time.Now().Truncate(time.Second)
+	until := now.Add(24 * time.Hour)
+	cols := []string{
+		"plan_id", "execution_id", "status", "step_number", "scheduled_date",
+		"notification_sent", "approval_token", "recommendations",
+		"total_upfront_cost", "estimated_savings", "completed_at", "error", "expires_at",
+		"cloud_account_id", "source", "approved_by", "cancelled_by", "capacity_percent",
+		"created_by_user_id", "retry_execution_id", "retry_attempt_n",
+		"approval_token_expires_at",
+		"executed_by_user_id", "executed_at", "pre_approval_skip_reason",
+		"idempotency_key", "scheduled_execution_at",
+	}
+	row := func(status string, scheduledAt sql.NullTime) *pgxmock.Rows {
+		return pgxmock.NewRows(cols).AddRow(
+			"plan-1", "exec-1", status, 1, now,
+			sql.NullTime{}, "tok-123", recsJSON,
+			100.0, 200.0, sql.NullTime{}, "", sql.NullTime{},
+			nil, "", nil, nil, 100,
+			nil, nil, 0,
+			sql.NullTime{},
+			nil, sql.NullTime{}, nil,
+			"idem-key",
+			scheduledAt,
+		)
+	}
+
+	t.Run("sets status and fire time together", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`SET status = 'scheduled', scheduled_execution_at = \$3`).
+			WithArgs("exec-1", []string{"pending"}, until).
+			WillReturnRows(row("scheduled", sql.NullTime{Time: until, Valid: true}))
+
+		exec, err := store.DeferExecutionAtomic(ctx, "exec-1", []string{"pending"}, until)
+		require.NoError(t, err)
+		assert.Equal(t, "scheduled", exec.Status)
+		require.NotNil(t, exec.ScheduledExecutionAt)
+		assert.True(t, exec.ScheduledExecutionAt.Equal(until))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CAS miss is not in expected status", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`UPDATE purchase_executions`).
+			WithArgs("exec-1", []string{"pending"}, until).
+			WillReturnRows(pgxmock.NewRows(cols))
+		mock.ExpectQuery(`SELECT plan_id, execution_id, status`).
+			WithArgs("exec-1").
+			WillReturnRows(row("canceled", sql.NullTime{}))
+
+		_, err := store.DeferExecutionAtomic(ctx, "exec-1", []string{"pending"}, until)
+		assert.ErrorIs(t, err, ErrExecutionNotInExpectedStatus)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 // ─── F2 regression: GetExecutionByPlanAndDate zero-rows wraps ErrNotFound ────
 
 // TestPGXMock_GetExecutionByPlanAndDate_NotFoundWrapsErrNotFound is the
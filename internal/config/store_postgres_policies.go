@@ -0,0 +1,210 @@
+package config
+
+// store_postgres_policies.go -- the purchase policy gate (migration 000109):
+// versioned CEL policies, the evaluations recorded against executions, and
+// the approver signatures a require_approvals rule collects.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+// CreatePurchasePolicy inserts p as version MAX(version)+1, filling in its
+// Version and CreatedAt. A concurrent save of the same version loses on the
+// primary key and errors rather than overwriting.
+func (s *PostgresStore) CreatePurchasePolicy(ctx context.Context, p *PurchasePolicy) error {
+	if p == nil {
+		return fmt.Errorf("policy must not be nil")
+	}
+	rules := p.Rules
+	if rules == nil {
+		rules = []policy.Rule{}
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy rules: %w", err)
+	}
+	const q = `
+		INSERT INTO purchase_policies (version, rules, comment, created_by)
+		SELECT COALESCE(MAX(version), 0) + 1, $1, $2, $3 FROM purchase_policies
+		RETURNING version, created_at
+	`
+	if err := s.db.QueryRow(ctx, q, rulesJSON, p.Comment, p.CreatedBy).Scan(&p.Version, &p.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save purchase policy: %w", err)
+	}
+	return nil
+}
+
+const purchasePolicyColumns = `version, rules, comment, created_by, created_at`
+
+// scanPurchasePolicy decodes one purchase_policies row.
+func scanPurchasePolicy(row pgx.Row) (*PurchasePolicy, error) {
+	var p PurchasePolicy
+	var rules []byte
+	if err := row.Scan(&p.Version, &rules, &p.Comment, &p.CreatedBy, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rules, &p.Rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rules of policy version %d: %w", p.Version, err)
+	}
+	return &p, nil
+}
+
+// GetLatestPurchasePolicy returns the highest version, or nil, nil when the
+// table is empty.
+func (s *PostgresStore) GetLatestPurchasePolicy(ctx context.Context) (*PurchasePolicy, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+purchasePolicyColumns+` FROM purchase_policies ORDER BY version DESC LIMIT 1`)
+	p, err := scanPurchasePolicy(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase policy: %w", err)
+	}
+	return p, nil
+}
+
+// GetPurchasePolicy returns one version.
+func (s *PostgresStore) GetPurchasePolicy(ctx context.Context, version int) (*PurchasePolicy, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+purchasePolicyColumns+` FROM purchase_policies WHERE version = $1`, version)
+	p, err := scanPurchasePolicy(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("purchase policy version %d: %w", version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase policy version %d: %w", version, err)
+	}
+	return p, nil
+}
+
+// ListPurchasePolicies returns up to limit versions, newest first. limit 0
+// means no limit.
+func (s *PostgresStore) ListPurchasePolicies(ctx context.Context, limit int) ([]PurchasePolicy, error) {
+	query := `SELECT ` + purchasePolicyColumns + ` FROM purchase_policies ORDER BY version DESC`
+	var args []any
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query purchase policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make([]PurchasePolicy, 0)
+	for rows.Next() {
+		p, scanErr := scanPurchasePolicy(rows)
+		if scanErr != nil {
+			return nil, fmt.Errorf("failed to scan purchase policy: %w", scanErr)
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+// RecordPolicyEvaluation inserts eval, filling in its ID and EvaluatedAt.
+func (s *PostgresStore) RecordPolicyEvaluation(ctx context.Context, eval *PolicyEvaluation) error {
+	if eval == nil {
+		return fmt.Errorf("evaluation must not be nil")
+	}
+	decisions := eval.Decisions
+	if decisions == nil {
+		decisions = []policy.Decision{}
+	}
+	decisionsJSON, err := json.Marshal(decisions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy decisions: %w", err)
+	}
+	const q = `
+		INSERT INTO purchase_policy_evaluations (
+			execution_id, policy_version, stage, outcome, required_approvals, decisions, actor
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, evaluated_at
+	`
+	if err := s.db.QueryRow(ctx, q,
+		eval.ExecutionID, eval.PolicyVersion, eval.Stage, eval.Outcome, eval.RequiredApprovals, decisionsJSON, eval.Actor,
+	).Scan(&eval.ID, &eval.EvaluatedAt); err != nil {
+		return fmt.Errorf("failed to record policy evaluation for execution %s: %w", eval.ExecutionID, err)
+	}
+	return nil
+}
+
+// ListPolicyEvaluations returns an execution's evaluations, oldest first.
+func (s *PostgresStore) ListPolicyEvaluations(ctx context.Context, executionID string) ([]PolicyEvaluation, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, execution_id, policy_version, stage, outcome, required_approvals,
+		       decisions, actor, evaluated_at
+		FROM purchase_policy_evaluations
+		WHERE execution_id = $1
+		ORDER BY evaluated_at ASC, id ASC
+	`, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policy evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	evals := make([]PolicyEvaluation, 0)
+	for rows.Next() {
+		var e PolicyEvaluation
+		var decisions []byte
+		if scanErr := rows.Scan(
+			&e.ID, &e.ExecutionID, &e.PolicyVersion, &e.Stage, &e.Outcome, &e.RequiredApprovals,
+			&decisions, &e.Actor, &e.EvaluatedAt,
+		); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan policy evaluation: %w", scanErr)
+		}
+		if decodeErr := json.Unmarshal(decisions, &e.Decisions); decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode policy evaluation %s: %w", e.ID, decodeErr)
+		}
+		evals = append(evals, e)
+	}
+	return evals, rows.Err()
+}
+
+// RecordExecutionApproval inserts approval; a second signature by the same
+// approver is ignored and keeps the first ApprovedAt.
+func (s *PostgresStore) RecordExecutionApproval(ctx context.Context, approval *ExecutionApproval) error {
+	if approval == nil {
+		return fmt.Errorf("approval must not be nil")
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO purchase_execution_approvals (execution_id, approver, approver_email, approver_user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (execution_id, approver) DO NOTHING
+	`, approval.ExecutionID, approval.Approver, approval.ApproverEmail, approval.ApproverUserID)
+	if err != nil {
+		return fmt.Errorf("failed to record approval of execution %s: %w", approval.ExecutionID, err)
+	}
+	return nil
+}
+
+// ListExecutionApprovals returns an execution's signatures, oldest first.
+func (s *PostgresStore) ListExecutionApprovals(ctx context.Context, executionID string) ([]ExecutionApproval, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT execution_id, approver, approver_email, approver_user_id, approved_at
+		FROM purchase_execution_approvals
+		WHERE execution_id = $1
+		ORDER BY approved_at ASC, approver ASC
+	`, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := make([]ExecutionApproval, 0)
+	for rows.Next() {
+		var a ExecutionApproval
+		if scanErr := rows.Scan(&a.ExecutionID, &a.Approver, &a.ApproverEmail, &a.ApproverUserID, &a.ApprovedAt); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan execution approval: %w", scanErr)
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, rows.Err()
+}
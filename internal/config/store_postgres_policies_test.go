@@ -0,0 +1,156 @@
+package config
+
+// store_postgres_policies_test.go -- pgxmock tests for the purchase policy
+// gate (migration 000109).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+func TestPGXMock_CreatePurchasePolicy(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	created := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	p := &PurchasePolicy{
+		Rules:     []policy.Rule{{ID: "no-sandbox-3yr", Expression: "true", Effect: policy.EffectDeny}},
+		Comment:   "block sandbox",
+		CreatedBy: "admin@example.com",
+	}
+	mock.ExpectQuery(`INSERT INTO purchase_policies[\s\S]*COALESCE\(MAX\(version\), 0\) \+ 1[\s\S]*RETURNING version, created_at`).
+		WithArgs([]byte(`[{"id":"no-sandbox-3yr","expression":"true","effect":"deny"}]`), "block sandbox", "admin@example.com").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "created_at"}).AddRow(3, created))
+
+	require.NoError(t, store.CreatePurchasePolicy(context.Background(), p))
+	assert.Equal(t, 3, p.Version)
+	assert.Equal(t, created, p.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CreatePurchasePolicy_EmptyRulesDisableTheGate(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectQuery(`INSERT INTO purchase_policies`).
+		WithArgs([]byte(`[]`), "", "").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "created_at"}).AddRow(4, time.Now()))
+
+	require.NoError(t, store.CreatePurchasePolicy(context.Background(), &PurchasePolicy{}))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorContains(t, store.CreatePurchasePolicy(context.Background(), nil), "policy must not be nil")
+}
+
+func TestPGXMock_GetLatestPurchasePolicy(t *testing.T) {
+	cols := []string{"version", "rules", "comment", "created_by", "created_at"}
+
+	t.Run("found", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`FROM purchase_policies ORDER BY version DESC LIMIT 1`).
+			WillReturnRows(pgxmock.NewRows(cols).AddRow(2, []byte(`[{"id":"a","expression":"true","effect":"warn"}]`), "", "admin", time.Now()))
+
+		p, err := store.GetLatestPurchasePolicy(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, p)
+		assert.Equal(t, 2, p.Version)
+		require.Len(t, p.Rules, 1)
+		assert.Equal(t, policy.EffectWarn, p.Rules[0].Effect)
+	})
+
+	t.Run("none saved", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`FROM purchase_policies`).WillReturnRows(pgxmock.NewRows(cols))
+
+		p, err := store.GetLatestPurchasePolicy(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, p)
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`FROM purchase_policies`).WillReturnError(errors.New("boom"))
+
+		_, err := store.GetLatestPurchasePolicy(context.Background())
+		assert.ErrorContains(t, err, "failed to get purchase policy")
+	})
+}
+
+func TestPGXMock_GetPurchasePolicy_NotFound(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	mock.ExpectQuery(`FROM purchase_policies WHERE version = \$1`).WithArgs(9).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "rules", "comment", "created_by", "created_at"}))
+
+	_, err := store.GetPurchasePolicy(context.Background(), 9)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPGXMock_RecordPolicyEvaluation(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	execID := "22222222-2222-2222-2222-222222222222"
+	eval := &PolicyEvaluation{
+		ExecutionID:       execID,
+		PolicyVersion:     3,
+		Stage:             policy.StageApproval,
+		Outcome:           string(policy.OutcomeRequireApprovals),
+		RequiredApprovals: 2,
+		Decisions:         []policy.Decision{{RuleID: "large-rds", Effect: policy.EffectRequireApprovals, Approvals: 2}},
+		Actor:             "lead@example.com",
+	}
+	evaluated := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO purchase_policy_evaluations[\s\S]*RETURNING id, evaluated_at`).
+		WithArgs(execID, 3, "approval", "require_approvals", 2,
+			[]byte(`[{"rule_id":"large-rds","effect":"require_approvals","approvals":2}]`), "lead@example.com").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "evaluated_at"}).AddRow("eval-1", evaluated))
+
+	require.NoError(t, store.RecordPolicyEvaluation(context.Background(), eval))
+	assert.Equal(t, "eval-1", eval.ID)
+	assert.Equal(t, evaluated, eval.EvaluatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ListPolicyEvaluations(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	execID := "22222222-2222-2222-2222-222222222222"
+	mock.ExpectQuery(`FROM purchase_policy_evaluations[\s\S]*WHERE execution_id = \$1`).WithArgs(execID).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "execution_id", "policy_version", "stage", "outcome", "required_approvals", "decisions", "actor", "evaluated_at",
+		}).AddRow("eval-1", execID, 3, "execution", "deny", 0, []byte(`[{"rule_id":"a","effect":"deny"}]`), "", time.Now()))
+
+	evals, err := store.ListPolicyEvaluations(context.Background(), execID)
+	require.NoError(t, err)
+	require.Len(t, evals, 1)
+	assert.Equal(t, "deny", evals[0].Outcome)
+	require.Len(t, evals[0].Decisions, 1)
+	assert.Equal(t, "a", evals[0].Decisions[0].RuleID)
+}
+
+func TestPGXMock_RecordExecutionApproval_IgnoresRepeatSignature(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	execID := "22222222-2222-2222-2222-222222222222"
+	mock.ExpectExec(`INSERT INTO purchase_execution_approvals[\s\S]*ON CONFLICT \(execution_id, approver\) DO NOTHING`).
+		WithArgs(execID, "lead@example.com", "lead@example.com", (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	require.NoError(t, store.RecordExecutionApproval(context.Background(), &ExecutionApproval{
+		ExecutionID: execID, Approver: "lead@example.com", ApproverEmail: "lead@example.com",
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorContains(t, store.RecordExecutionApproval(context.Background(), nil), "approval must not be nil")
+}
@@ -167,33 +167,38 @@ func insertRecommendationsBatched(ctx context.Context, tx pgx.Tx, collectedAt ti
 // recommendationsBatchSize rows. Splits the VALUES placeholder list into
 // (collected_at, cloud_account_id, provider, service, region,
 // resource_type, engine, payload, upfront_cost, monthly_savings, term,
-// payment_option) — 12 columns per row (id defaulted via
-// gen_random_uuid() so we send 12 args, no $n for id).
+// payment_option, source) — 13 columns per row (id defaulted via
+// gen_random_uuid() so we send 13 args, no $n for id).
 //
 // term + payment_option were added as part of the natural-key
 // broadening (migration 000032) so per-rec ON CONFLICT can store
 // every Azure term × payment variant per SKU instead of collapsing
 // onto the highest-savings one.
 // engine was added to distinguish MySQL vs Postgres RDS at the same SKU.
+// source (migration 000114) records which engine produced the rec; an
+// unstamped rec is stored as a vendor one.
 func insertRecommendationsBatch(ctx context.Context, tx pgx.Tx, collectedAt time.Time, recs []RecommendationRecord, onConflict bool) error {
 	if len(recs) == 0 {
 		return nil
 	}
 
-	const colsPerRow = 12
+	const colsPerRow = 13
 	args := make([]any, 0, len(recs)*colsPerRow)
 	placeholders := make([]string, 0, len(recs))
 
 	for i := range recs {
 		rec := recs[i]
+		if rec.Source == "" {
+			rec.Source = RecommendationSourceVendor
+		}
 		payload, err := json.Marshal(rec)
 		if err != nil {
 			return fmt.Errorf("failed to marshal recommendation %d: %w", i, err)
 		}
 		base := i * colsPerRow
 		placeholders = append(placeholders, fmt.Sprintf(
-			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11, base+12,
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11, base+12, base+13,
 		))
 		args = append(args,
 			collectedAt,        // collected_at
@@ -208,6 +213,7 @@ func insertRecommendationsBatch(ctx context.Context, tx pgx.Tx, collectedAt time
 			rec.Savings,        // monthly_savings
 			rec.Term,           // term
 			rec.Payment,        // payment_option
+			rec.Source,         // source
 		)
 	}
 
@@ -215,7 +221,7 @@ func insertRecommendationsBatch(ctx context.Context, tx pgx.Tx, collectedAt time
 		INSERT INTO recommendations
 		    (collected_at, cloud_account_id, provider, service, region,
 		     resource_type, engine, payload, upfront_cost, monthly_savings,
-		     term, payment_option)
+		     term, payment_option, source)
 		VALUES %s
 	`, strings.Join(placeholders, ","))
 
@@ -227,7 +233,8 @@ func insertRecommendationsBatch(ctx context.Context, tx pgx.Tx, collectedAt time
 			    upfront_cost    = EXCLUDED.upfront_cost,
 			    monthly_savings = EXCLUDED.monthly_savings,
 			    collected_at    = EXCLUDED.collected_at,
-			    cloud_account_id = EXCLUDED.cloud_account_id
+			    cloud_account_id = EXCLUDED.cloud_account_id,
+			    source          = EXCLUDED.source
 		`
 	}
 
@@ -288,6 +295,9 @@ func buildRecommendationFilter(filter RecommendationFilter) (whereClause string,
 	if filter.ID != "" {
 		add("payload->>'id' = $%d", filter.ID)
 	}
+	if filter.Source != "" {
+		add("source = $%d", filter.Source)
+	}
 	if len(conds) == 0 {
 		return "", nil
 	}
@@ -371,7 +381,7 @@ func recOnDemandBaseline(rec *RecommendationRecord) (float64, bool) {
 // baseline lives inside the JSONB payload (not a native column).
 func (s *PostgresStore) ListStoredRecommendations(ctx context.Context, filter RecommendationFilter) ([]RecommendationRecord, error) {
 	whereClause, args := buildRecommendationFilter(filter)
-	rows, err := s.db.Query(ctx, `SELECT payload FROM recommendations`+whereClause, args...)
+	rows, err := s.db.Query(ctx, `SELECT payload, source FROM recommendations`+whereClause, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recommendations: %w", err)
 	}
@@ -380,13 +390,17 @@ func (s *PostgresStore) ListStoredRecommendations(ctx context.Context, filter Re
 	var out []RecommendationRecord
 	for rows.Next() {
 		var payload []byte
-		if err := rows.Scan(&payload); err != nil {
+		var source string
+		if err := rows.Scan(&payload, &source); err != nil {
 			return nil, fmt.Errorf("failed to scan payload: %w", err)
 		}
 		var rec RecommendationRecord
 		if err := json.Unmarshal(payload, &rec); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
 		}
+		// The column, not the payload, is authoritative: rows collected
+		// before migration 000114 carry no source in their payload.
+		rec.Source = source
 		// Apply the in-process percentage floor. Recs where the percentage
 		// cannot be computed (missing on-demand baseline) pass through -- we
 		// only drop a rec when pct is computable AND below the threshold.
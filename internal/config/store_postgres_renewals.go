@@ -0,0 +1,108 @@
+package config
+
+// store_postgres_renewals.go -- the commitment_renewals table (migration
+// 000108): lineage from expiring commitments to the purchases drafted by the
+// renewal_plan task to replace them.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CreateCommitmentRenewal inserts renewal, filling in its ID, CreatedAt and
+// UpdatedAt. A second renewal for the same (provider, account_id,
+// source_commitment_id, source_end_date) violates the table's unique key
+// and errors; callers check ListCommitmentRenewals first.
+func (s *PostgresStore) CreateCommitmentRenewal(ctx context.Context, renewal *CommitmentRenewal) error {
+	if renewal == nil {
+		return fmt.Errorf("renewal must not be nil")
+	}
+	proposal := renewal.Proposal
+	if len(proposal) == 0 {
+		proposal = []byte("{}")
+	}
+	const q = `
+		INSERT INTO commitment_renewals (
+			provider, account_id, cloud_account_id, source_commitment_id, source_end_date,
+			action, utilization_pct, reason, proposal, execution_id, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+	if err := s.db.QueryRow(ctx, q,
+		renewal.Provider, renewal.AccountID, renewal.CloudAccountID, renewal.SourceCommitmentID, renewal.SourceEndDate,
+		renewal.Action, renewal.UtilizationPct, renewal.Reason, proposal, renewal.ExecutionID, renewal.Status,
+	).Scan(&renewal.ID, &renewal.CreatedAt, &renewal.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create renewal for commitment %s: %w", renewal.SourceCommitmentID, err)
+	}
+	return nil
+}
+
+// ListCommitmentRenewals returns the renewals matching filter, soonest
+// source expiry first.
+func (s *PostgresStore) ListCommitmentRenewals(ctx context.Context, filter CommitmentRenewalFilter) ([]CommitmentRenewal, error) {
+	conds := []string{"TRUE"}
+	var args []any
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		conds = append(conds, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+	if filter.SourceEndAfter != nil {
+		args = append(args, *filter.SourceEndAfter)
+		conds = append(conds, fmt.Sprintf("source_end_date >= $%d", len(args)))
+	}
+	query := fmt.Sprintf(`
+		SELECT id, provider, account_id, cloud_account_id, source_commitment_id, source_end_date,
+		       action, utilization_pct::float8, reason, proposal, execution_id,
+		       replacement_commitment_id, status, created_at, updated_at
+		FROM commitment_renewals
+		WHERE %s
+		ORDER BY source_end_date ASC, id ASC
+	`, strings.Join(conds, " AND "))
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commitment renewals: %w", err)
+	}
+	defer rows.Close()
+
+	renewals := make([]CommitmentRenewal, 0)
+	for rows.Next() {
+		var r CommitmentRenewal
+		var proposal []byte
+		if scanErr := rows.Scan(
+			&r.ID, &r.Provider, &r.AccountID, &r.CloudAccountID, &r.SourceCommitmentID, &r.SourceEndDate,
+			&r.Action, &r.UtilizationPct, &r.Reason, &proposal, &r.ExecutionID,
+			&r.ReplacementCommitmentID, &r.Status, &r.CreatedAt, &r.UpdatedAt,
+		); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan commitment renewal: %w", scanErr)
+		}
+		r.Proposal = proposal
+		renewals = append(renewals, r)
+	}
+	return renewals, rows.Err()
+}
+
+// UpdateCommitmentRenewalStatus moves a proposed renewal to status. The
+// update is a compare-and-set on status = 'proposed', so renewed, declined
+// and let_expire rows are final.
+func (s *PostgresStore) UpdateCommitmentRenewalStatus(ctx context.Context, id, status string, replacementCommitmentID *string) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE commitment_renewals
+		   SET status = $2,
+		       replacement_commitment_id = COALESCE($3, replacement_commitment_id),
+		       updated_at = NOW()
+		 WHERE id = $1 AND status = 'proposed'
+	`, id, status, replacementCommitmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update renewal %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("proposed renewal %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package config
+
+// store_postgres_renewals_test.go -- pgxmock tests for the commitment
+// renewal lineage (migration 000108).
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGXMock_CreateCommitmentRenewal(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	end := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	execID := "22222222-2222-2222-2222-222222222222"
+	r := &CommitmentRenewal{
+		Provider:           "aws",
+		AccountID:          "123456789012",
+		SourceCommitmentID: "ri-1",
+		SourceEndDate:      end,
+		Action:             "resize",
+		UtilizationPct:     f64Ptr(60),
+		Reason:             "under-used",
+		ExecutionID:        &execID,
+		Status:             RenewalStatusProposed,
+	}
+	mock.ExpectQuery(`INSERT INTO commitment_renewals[\s\S]*RETURNING id, created_at, updated_at`).
+		WithArgs("aws", "123456789012", (*string)(nil), "ri-1", end,
+			"resize", r.UtilizationPct, "under-used", json.RawMessage("{}"), &execID, RenewalStatusProposed).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("ren-1", created, created))
+
+	require.NoError(t, store.CreateCommitmentRenewal(context.Background(), r))
+	assert.Equal(t, "ren-1", r.ID)
+	assert.Equal(t, created, r.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CreateCommitmentRenewal_Errors(t *testing.T) {
+	t.Run("nil renewal", func(t *testing.T) {
+		store := storeWith(newMock(t))
+		assert.ErrorContains(t, store.CreateCommitmentRenewal(context.Background(), nil), "renewal must not be nil")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectQuery(`INSERT INTO commitment_renewals`).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), "ri-1", pgxmock.AnyArg(), pgxmock.AnyArg(),
+				pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(errors.New("duplicate key"))
+		err := store.CreateCommitmentRenewal(context.Background(), &CommitmentRenewal{SourceCommitmentID: "ri-1"})
+		assert.ErrorContains(t, err, "failed to create renewal for commitment ri-1")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPGXMock_ListCommitmentRenewals(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	execID := "22222222-2222-2222-2222-222222222222"
+	mock.ExpectQuery(`FROM commitment_renewals[\s\S]*status = ANY\(\$1\) AND source_end_date >= \$2[\s\S]*LIMIT \$3`).
+		WithArgs([]string{RenewalStatusProposed}, since, 50).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "provider", "account_id", "cloud_account_id", "source_commitment_id", "source_end_date",
+			"action", "utilization_pct", "reason", "proposal", "execution_id",
+			"replacement_commitment_id", "status", "created_at", "updated_at",
+		}).AddRow(
+			"ren-1", "aws", "123456789012", (*string)(nil), "ri-1", end,
+			"like_for_like", (*float64)(nil), "utilization unknown", []byte(`{"action":"like_for_like"}`), &execID,
+			(*string)(nil), RenewalStatusProposed, since, since,
+		))
+
+	got, err := store.ListCommitmentRenewals(context.Background(), CommitmentRenewalFilter{
+		Statuses:       []string{RenewalStatusProposed},
+		SourceEndAfter: &since,
+		Limit:          50,
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "ri-1", got[0].SourceCommitmentID)
+	assert.Nil(t, got[0].UtilizationPct)
+	require.NotNil(t, got[0].ExecutionID)
+	assert.Equal(t, execID, *got[0].ExecutionID)
+	assert.JSONEq(t, `{"action":"like_for_like"}`, string(got[0].Proposal))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ListCommitmentRenewals_QueryError(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`FROM commitment_renewals\s+WHERE TRUE\s+ORDER BY`).WillReturnError(errors.New("db down"))
+	_, err := store.ListCommitmentRenewals(context.Background(), CommitmentRenewalFilter{})
+	assert.ErrorContains(t, err, "failed to query commitment renewals")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_UpdateCommitmentRenewalStatus(t *testing.T) {
+	t.Run("proposed row updated", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		replacement := "sp-new"
+		mock.ExpectExec(`UPDATE commitment_renewals[\s\S]*WHERE id = \$1 AND status = 'proposed'`).
+			WithArgs("ren-1", RenewalStatusRenewed, &replacement).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		require.NoError(t, store.UpdateCommitmentRenewalStatus(context.Background(), "ren-1", RenewalStatusRenewed, &replacement))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not proposed", func(t *testing.T) {
+		mock := newMock(t)
+		store := storeWith(mock)
+		mock.ExpectExec(`UPDATE commitment_renewals`).
+			WithArgs("ren-1", RenewalStatusDeclined, (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		err := store.UpdateCommitmentRenewalStatus(context.Background(), "ren-1", RenewalStatusDeclined, nil)
+		assert.ErrorIs(t, err, ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
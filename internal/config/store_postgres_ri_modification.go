@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ==========================================
+// RI MODIFICATION STORE METHODS
+// ==========================================
+
+// riModificationColumns is the column list every ri_modification_history
+// read scans, in scanRIModificationRecords order.
+const riModificationColumns = `id, cloud_account_id, region, source_ri_ids, sources, targets,
+		       modification_id, status, reason, error, created_by_user_id, approved_by,
+		       transitioned_by, transitioned_at, created_at, updated_at, completed_at`
+
+// SaveRIModificationRecord inserts a new RI modification record.
+func (s *PostgresStore) SaveRIModificationRecord(ctx context.Context, record *RIModificationRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	record.UpdatedAt = now
+
+	sourcesJSON, err := json.Marshal(record.Sources)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sources: %w", err)
+	}
+	targetsJSON, err := json.Marshal(record.Targets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal targets: %w", err)
+	}
+
+	query := `
+		INSERT INTO ri_modification_history (
+			id, cloud_account_id, region, source_ri_ids, sources, targets,
+			modification_id, status, reason, error, created_by_user_id,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err = s.db.Exec(ctx, query,
+		record.ID,
+		record.CloudAccountID,
+		record.Region,
+		record.SourceRIIDs,
+		sourcesJSON,
+		targetsJSON,
+		record.ModificationID,
+		record.Status,
+		record.Reason,
+		nullStringFromString(record.Error),
+		record.CreatedByUserID,
+		record.CreatedAt,
+		record.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save ri modification record: %w", err)
+	}
+	return nil
+}
+
+// GetRIModificationRecord retrieves an RI modification record by ID.
+// Returns (nil, nil) when no record exists.
+func (s *PostgresStore) GetRIModificationRecord(ctx context.Context, id string) (*RIModificationRecord, error) {
+	records, err := s.queryRIModificationRecords(ctx, `
+		SELECT `+riModificationColumns+`
+		FROM ri_modification_history
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// GetRIModificationHistory returns modification records created since
+// since, newest first.
+func (s *PostgresStore) GetRIModificationHistory(ctx context.Context, since time.Time, limit int) ([]RIModificationRecord, error) {
+	return s.queryRIModificationRecords(ctx, `
+		SELECT `+riModificationColumns+`
+		FROM ri_modification_history
+		WHERE created_at >= $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, since, limit)
+}
+
+// TransitionRIModificationStatus atomically transitions an RI modification
+// record status with a single UPDATE...WHERE...RETURNING, diagnosing the
+// failure only when no row matched.
+func (s *PostgresStore) TransitionRIModificationStatus(ctx context.Context, id, fromStatus, toStatus string, actor *string) (*RIModificationRecord, error) {
+	records, err := s.queryRIModificationRecords(ctx, `
+		UPDATE ri_modification_history
+		SET status = $3, updated_at = NOW(),
+		    transitioned_by = $4, transitioned_at = NOW()
+		WHERE id = $1 AND status = $2
+		RETURNING `+riModificationColumns, id, fromStatus, toStatus, actor)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 {
+		return &records[0], nil
+	}
+
+	var current string
+	err = s.db.QueryRow(ctx, `SELECT status FROM ri_modification_history WHERE id = $1`, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("ri modification record not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to diagnose transition failure: %w", err)
+	}
+	return nil, fmt.Errorf("ri modification status transition failed: expected status %q but current status is %q", fromStatus, current)
+}
+
+// CompleteRIModification marks an RI modification as completed.
+func (s *PostgresStore) CompleteRIModification(ctx context.Context, id, modificationID, approvedBy string) error {
+	query := `
+		UPDATE ri_modification_history
+		SET status = 'completed', modification_id = $2, approved_by = $3,
+		    completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := s.db.Exec(ctx, query, id, modificationID, nullStringFromString(approvedBy))
+	if err != nil {
+		return fmt.Errorf("failed to complete ri modification: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("ri modification record not found: %s", id)
+	}
+	return nil
+}
+
+// FailRIModification marks an RI modification as failed.
+func (s *PostgresStore) FailRIModification(ctx context.Context, id, errorMsg string) error {
+	query := `
+		UPDATE ri_modification_history
+		SET status = 'failed', error = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := s.db.Exec(ctx, query, id, errorMsg)
+	if err != nil {
+		return fmt.Errorf("failed to fail ri modification: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("ri modification record not found: %s", id)
+	}
+	return nil
+}
+
+// queryRIModificationRecords queries and scans RI modification records.
+func (s *PostgresStore) queryRIModificationRecords(ctx context.Context, query string, args ...any) ([]RIModificationRecord, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ri modification records: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]RIModificationRecord, 0)
+	for rows.Next() {
+		var record RIModificationRecord
+		var sourcesJSON, targetsJSON []byte
+		var errStr, createdByUserID, approvedBy, transitionedBy sql.NullString
+		var transitionedAt, completedAt sql.NullTime
+
+		err := rows.Scan(
+			&record.ID,
+			&record.CloudAccountID,
+			&record.Region,
+			&record.SourceRIIDs,
+			&sourcesJSON,
+			&targetsJSON,
+			&record.ModificationID,
+			&record.Status,
+			&record.Reason,
+			&errStr,
+			&createdByUserID,
+			&approvedBy,
+			&transitionedBy,
+			&transitionedAt,
+			&record.CreatedAt,
+			&record.UpdatedAt,
+			&completedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ri modification record: %w", err)
+		}
+		if err := json.Unmarshal(sourcesJSON, &record.Sources); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sources: %w", err)
+		}
+		if err := json.Unmarshal(targetsJSON, &record.Targets); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal targets: %w", err)
+		}
+
+		record.Error = errStr.String
+		record.CreatedByUserID = nullPtrFromNullString(createdByUserID)
+		record.ApprovedBy = nullPtrFromNullString(approvedBy)
+		record.TransitionedBy = nullPtrFromNullString(transitionedBy)
+		if transitionedAt.Valid {
+			record.TransitionedAt = &transitionedAt.Time
+		}
+		if completedAt.Valid {
+			record.CompletedAt = &completedAt.Time
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ri modification records: %w", err)
+	}
+	return records, nil
+}
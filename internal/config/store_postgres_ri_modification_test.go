@@ -0,0 +1,148 @@
+package config
+
+// store_postgres_ri_modification_test.go -- pgxmock tests for the RI
+// modification store methods (migration 000103): configurations round-trip
+// through JSONB, transitions stamp the actor and explain a status mismatch,
+// and completion records the approver.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var riModificationTestColumns = []string{
+	"id", "cloud_account_id", "region", "source_ri_ids", "sources", "targets",
+	"modification_id", "status", "reason", "error", "created_by_user_id", "approved_by",
+	"transitioned_by", "transitioned_at", "created_at", "updated_at", "completed_at",
+}
+
+func riModificationRow(rows *pgxmock.Rows, id, status string, actor *string) *pgxmock.Rows {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	// The nullable text columns scan into sql.NullString, which takes a
+	// string or nil, not a *string.
+	var transitionedBy any
+	if actor != nil {
+		transitionedBy = *actor
+	}
+	return rows.AddRow(
+		id, "acct-uuid", "us-east-1", []string{"ri-1"},
+		[]byte(`[{"instance_type":"m5.xlarge","availability_zone":"us-east-1a","scope":"Availability Zone","instance_count":1}]`),
+		[]byte(`[{"instance_type":"m5.large","availability_zone":"us-east-1a","scope":"Availability Zone","instance_count":2}]`),
+		"", status, "split to match running larges", nil, "user-1", nil,
+		transitionedBy, nil, now, now, nil,
+	)
+}
+
+func TestPGXMock_SaveRIModificationRecord(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	record := &RIModificationRecord{
+		CloudAccountID: "acct-uuid",
+		Region:         "us-east-1",
+		SourceRIIDs:    []string{"ri-1"},
+		Sources:        []RIModificationConfiguration{{InstanceType: "m5.xlarge", InstanceCount: 1, AvailabilityZone: "us-east-1a", Scope: "Availability Zone"}},
+		Targets:        []RIModificationConfiguration{{InstanceType: "m5.large", InstanceCount: 2, AvailabilityZone: "us-east-1a", Scope: "Availability Zone"}},
+		Status:         "pending",
+	}
+	mock.ExpectExec(`INSERT INTO ri_modification_history`).
+		WithArgs(pgxmock.AnyArg(), "acct-uuid", "us-east-1", []string{"ri-1"},
+			[]byte(`[{"instance_type":"m5.xlarge","availability_zone":"us-east-1a","scope":"Availability Zone","instance_count":1}]`),
+			[]byte(`[{"instance_type":"m5.large","availability_zone":"us-east-1a","scope":"Availability Zone","instance_count":2}]`),
+			"", "pending", "", pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, store.SaveRIModificationRecord(context.Background(), record))
+	assert.NotEmpty(t, record.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_GetRIModificationRecord(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`FROM ri_modification_history\s+WHERE id = \$1`).
+		WithArgs("mod-1").
+		WillReturnRows(riModificationRow(pgxmock.NewRows(riModificationTestColumns), "mod-1", "pending", nil))
+
+	record, err := store.GetRIModificationRecord(context.Background(), "mod-1")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "pending", record.Status)
+	require.Len(t, record.Targets, 1)
+	assert.Equal(t, int32(2), record.Targets[0].InstanceCount)
+	assert.Equal(t, "user-1", *record.CreatedByUserID)
+	assert.Nil(t, record.ApprovedBy)
+
+	mock.ExpectQuery(`FROM ri_modification_history\s+WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnRows(pgxmock.NewRows(riModificationTestColumns))
+	record, err = store.GetRIModificationRecord(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_TransitionRIModificationStatus(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+	actor := strPtr("user-2")
+
+	mock.ExpectQuery(`UPDATE ri_modification_history\s+SET status = \$3`).
+		WithArgs("mod-1", "pending", "processing", actor).
+		WillReturnRows(riModificationRow(pgxmock.NewRows(riModificationTestColumns), "mod-1", "processing", actor))
+
+	record, err := store.TransitionRIModificationStatus(context.Background(), "mod-1", "pending", "processing", actor)
+	require.NoError(t, err)
+	assert.Equal(t, "processing", record.Status)
+	assert.Equal(t, "user-2", *record.TransitionedBy)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_TransitionRIModificationStatus_Diagnoses(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`UPDATE ri_modification_history`).
+		WithArgs("mod-1", "pending", "processing", (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows(riModificationTestColumns))
+	mock.ExpectQuery(`SELECT status FROM ri_modification_history`).
+		WithArgs("mod-1").
+		WillReturnRows(pgxmock.NewRows([]string{"status"}).AddRow("completed"))
+
+	_, err := store.TransitionRIModificationStatus(context.Background(), "mod-1", "pending", "processing", nil)
+	assert.ErrorContains(t, err, `current status is "completed"`)
+
+	mock.ExpectQuery(`UPDATE ri_modification_history`).
+		WithArgs("gone", "pending", "processing", (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows(riModificationTestColumns))
+	mock.ExpectQuery(`SELECT status FROM ri_modification_history`).
+		WithArgs("gone").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.TransitionRIModificationStatus(context.Background(), "gone", "pending", "processing", nil)
+	assert.ErrorContains(t, err, "not found")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_CompleteAndFailRIModification(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectExec(`SET status = 'completed', modification_id = \$2, approved_by = \$3`).
+		WithArgs("mod-1", "rimod-abc", nullStringFromString("alice@example.com")).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	require.NoError(t, store.CompleteRIModification(context.Background(), "mod-1", "rimod-abc", "alice@example.com"))
+
+	mock.ExpectExec(`SET status = 'failed', error = \$2`).
+		WithArgs("mod-2", "boom").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	assert.ErrorContains(t, store.FailRIModification(context.Background(), "mod-2", "boom"), "not found")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -230,3 +230,9 @@ func TestBuildRecommendationFilter_MinSavingsUSD(t *testing.T) {
 		assert.Equal(t, float64(50), args[0])
 	})
 }
+
+func TestBuildRecommendationFilter_Source(t *testing.T) {
+	clause, args := buildRecommendationFilter(RecommendationFilter{Provider: "aws", Source: RecommendationSourceNative})
+	assert.Equal(t, " WHERE provider = $1 AND source = $2", clause)
+	assert.Equal(t, []any{"aws", RecommendationSourceNative}, args)
+}
@@ -0,0 +1,215 @@
+package config
+
+// store_postgres_shadow.go -- shadow mode (migration 000113): the purchases
+// shadow-mode plans and ladder configs would have made, and their running
+// evaluation against later usage.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const shadowPurchaseCols = `id, source, plan_id, execution_id, ladder_config_id, ladder_run_id, cloud_account_id,
+		       provider, service, region, resource_type, count, term_years, payment,
+		       upfront_cost, commit_usd_per_hour, cost_usd_per_hour, estimated_savings, purchase_at,
+		       evaluated_at, evaluated_through, evaluated_days, covered_usd, committed_usd, cost_usd,
+		       savings_usd, waste_usd, utilization_pct, evaluation_error, created_at`
+
+func scanShadowPurchase(row pgx.Row, p *ShadowPurchase) error {
+	var covered, committed, cost, savings, waste *float64
+	err := row.Scan(&p.ID, &p.Source, &p.PlanID, &p.ExecutionID, &p.LadderConfigID, &p.LadderRunID, &p.CloudAccountID,
+		&p.Provider, &p.Service, &p.Region, &p.ResourceType, &p.Count, &p.TermYears, &p.Payment,
+		&p.UpfrontCost, &p.CommitUSDPerHour, &p.CostUSDPerHour, &p.EstimatedSavings, &p.PurchaseAt,
+		&p.EvaluatedAt, &p.EvaluatedThrough, &p.Evaluation.Days, &covered, &committed, &cost,
+		&savings, &waste, &p.Evaluation.UtilizationPct, &p.EvaluationError, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		src *float64
+		dst *float64
+	}{
+		{covered, &p.Evaluation.CoveredUSD},
+		{committed, &p.Evaluation.CommittedUSD},
+		{cost, &p.Evaluation.CostUSD},
+		{savings, &p.Evaluation.SavingsUSD},
+		{waste, &p.Evaluation.WasteUSD},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	return nil
+}
+
+const shadowPurchaseInsert = `
+	INSERT INTO shadow_purchases (
+		id, source, plan_id, execution_id, ladder_config_id, ladder_run_id, cloud_account_id,
+		provider, service, region, resource_type, count, term_years, payment,
+		upfront_cost, commit_usd_per_hour, cost_usd_per_hour, estimated_savings, purchase_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	RETURNING created_at
+`
+
+// insertShadowPurchasesTx inserts ps inside tx, filling in missing IDs and
+// each row's CreatedAt.
+func insertShadowPurchasesTx(ctx context.Context, tx pgx.Tx, ps []ShadowPurchase) error {
+	for i := range ps {
+		p := &ps[i]
+		if p.ID == "" {
+			p.ID = uuid.New().String()
+		}
+		err := tx.QueryRow(ctx, shadowPurchaseInsert,
+			p.ID, p.Source, p.PlanID, p.ExecutionID, p.LadderConfigID, p.LadderRunID, p.CloudAccountID,
+			p.Provider, p.Service, p.Region, p.ResourceType, p.Count, p.TermYears, p.Payment,
+			p.UpfrontCost, p.CommitUSDPerHour, p.CostUSDPerHour, p.EstimatedSavings, p.PurchaseAt,
+		).Scan(&p.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert shadow purchase %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// RecordPlanShadowPurchases replaces the shadow purchases recorded for
+// executionID with ps in one transaction, so an execution that is re-driven
+// after a failure records its purchases once.
+func (s *PostgresStore) RecordPlanShadowPurchases(ctx context.Context, executionID string, ps []ShadowPurchase) error {
+	return s.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM shadow_purchases WHERE execution_id = $1`, executionID); err != nil {
+			return fmt.Errorf("failed to clear shadow purchases for execution %s: %w", executionID, err)
+		}
+		return insertShadowPurchasesTx(ctx, tx, ps)
+	})
+}
+
+// SaveShadowLadderRun inserts the ladder_runs row of a shadow-mode run and
+// the shadow purchases standing in for its tranches in one transaction, the
+// shadow counterpart of SaveLadderRunWithTranches. If run.ID is empty a
+// fresh UUID is generated. Returns the persisted run row.
+func (s *PostgresStore) SaveShadowLadderRun(ctx context.Context, run *LadderRunDB, ps []ShadowPurchase) (*LadderRunDB, error) {
+	if run.ID == "" {
+		run.ID = uuid.New().String()
+	}
+	var result LadderRunDB
+	err := s.WithTx(ctx, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, ladderRunInsertQuery, ladderRunInsertArgs(run, ladderRunPlanJSON(run))...)
+		scanned, err := scanLadderRun(row)
+		if err != nil {
+			return fmt.Errorf("failed to insert ladder_run id=%s: %w", run.ID, err)
+		}
+		if err := insertShadowPurchasesTx(ctx, tx, ps); err != nil {
+			return err
+		}
+		result = scanned
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetShadowLadderCommitUSDHr returns the hourly commitment of configID's
+// shadow purchases whose term has not ended at at, including those not yet
+// due. A shadow-mode ladder nets it out of the gap the way a live ladder
+// nets its scheduled tranches and its provider's active commitments, which
+// shadow purchases never become.
+func (s *PostgresStore) GetShadowLadderCommitUSDHr(ctx context.Context, configID string, at time.Time) (float64, error) {
+	var total float64
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(commit_usd_per_hour), 0)
+		FROM shadow_purchases
+		WHERE ladder_config_id = $1
+		  AND purchase_at + make_interval(years => term_years) > $2
+	`, configID, at).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("GetShadowLadderCommitUSDHr config_id=%s: %w", configID, err)
+	}
+	return total, nil
+}
+
+// ListShadowPurchases returns the shadow purchases matching filter, most
+// recent purchase first.
+func (s *PostgresStore) ListShadowPurchases(ctx context.Context, filter ShadowPurchaseFilter) ([]ShadowPurchase, error) {
+	var where []string
+	var args []any
+	for _, f := range []struct{ col, val string }{
+		{"source", filter.Source},
+		{"plan_id", filter.PlanID},
+		{"ladder_config_id", filter.LadderConfigID},
+	} {
+		if f.val != "" {
+			args = append(args, f.val)
+			where = append(where, fmt.Sprintf("%s = $%d", f.col, len(args)))
+		}
+	}
+	q := `SELECT ` + shadowPurchaseCols + ` FROM shadow_purchases`
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	q += ` ORDER BY purchase_at DESC, id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		q += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return s.queryShadowPurchases(ctx, q, args...)
+}
+
+// ListShadowPurchasesToEvaluate returns the shadow purchases made before at
+// whose days up to at, or to the end of their term, have not all been
+// scored yet, oldest purchase first: the order Evaluate stacks them in.
+func (s *PostgresStore) ListShadowPurchasesToEvaluate(ctx context.Context, at time.Time) ([]ShadowPurchase, error) {
+	return s.queryShadowPurchases(ctx, `SELECT `+shadowPurchaseCols+` FROM shadow_purchases
+		WHERE purchase_at < $1
+		  AND COALESCE(evaluated_through, purchase_at) < LEAST(purchase_at + make_interval(years => term_years), $1)
+		ORDER BY purchase_at ASC, created_at ASC, id`, at)
+}
+
+func (s *PostgresStore) queryShadowPurchases(ctx context.Context, q string, args ...any) ([]ShadowPurchase, error) {
+	rows, err := s.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadow purchases: %w", err)
+	}
+	defer rows.Close()
+
+	ps := make([]ShadowPurchase, 0)
+	for rows.Next() {
+		var p ShadowPurchase
+		if scanErr := scanShadowPurchase(rows, &p); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan shadow purchase: %w", scanErr)
+		}
+		ps = append(ps, p)
+	}
+	return ps, rows.Err()
+}
+
+// SaveShadowEvaluation stores p's evaluation: EvaluatedAt,
+// EvaluatedThrough, Evaluation and EvaluationError.
+func (s *PostgresStore) SaveShadowEvaluation(ctx context.Context, p *ShadowPurchase) error {
+	if p == nil {
+		return fmt.Errorf("shadow purchase must not be nil")
+	}
+	e := &p.Evaluation
+	tag, err := s.db.Exec(ctx, `
+		UPDATE shadow_purchases
+		SET evaluated_at = $2, evaluated_through = $3, evaluated_days = $4, covered_usd = $5,
+		    committed_usd = $6, cost_usd = $7, savings_usd = $8, waste_usd = $9,
+		    utilization_pct = $10, evaluation_error = $11
+		WHERE id = $1
+	`, p.ID, p.EvaluatedAt, p.EvaluatedThrough, e.Days, e.CoveredUSD,
+		e.CommittedUSD, e.CostUSD, e.SavingsUSD, e.WasteUSD,
+		e.UtilizationPct, p.EvaluationError)
+	if err != nil {
+		return fmt.Errorf("failed to save the evaluation of shadow purchase %s: %w", p.ID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shadow purchase %s: %w", p.ID, ErrNotFound)
+	}
+	return nil
+}
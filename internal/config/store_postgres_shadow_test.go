@@ -0,0 +1,158 @@
+package config
+
+// store_postgres_shadow_test.go -- pgxmock tests for shadow purchases
+// (migration 000113).
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var shadowPurchaseColNames = []string{
+	"id", "source", "plan_id", "execution_id", "ladder_config_id", "ladder_run_id", "cloud_account_id",
+	"provider", "service", "region", "resource_type", "count", "term_years", "payment",
+	"upfront_cost", "commit_usd_per_hour", "cost_usd_per_hour", "estimated_savings", "purchase_at",
+	"evaluated_at", "evaluated_through", "evaluated_days", "covered_usd", "committed_usd", "cost_usd",
+	"savings_usd", "waste_usd", "utilization_pct", "evaluation_error", "created_at",
+}
+
+func TestPGXMock_RecordPlanShadowPurchases(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	now := time.Now()
+	planID, execID := "plan-1", "exec-1"
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM shadow_purchases WHERE execution_id = \$1`).WithArgs(execID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectQuery(`INSERT INTO shadow_purchases`).
+		WithArgs(pgxmock.AnyArg(), ShadowSourcePlan, &planID, &execID, (*string)(nil), (*string)(nil), (*string)(nil),
+			"aws", "ec2", "us-east-1", "m5.large", 2, 1, "no-upfront",
+			0.0, 0.2, 0.14, 43.8, now).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(now))
+	mock.ExpectCommit()
+
+	ps := []ShadowPurchase{{
+		Source: ShadowSourcePlan, PlanID: &planID, ExecutionID: &execID,
+		Provider: "aws", Service: "ec2", Region: "us-east-1", ResourceType: "m5.large", Count: 2, TermYears: 1, Payment: "no-upfront",
+		CommitUSDPerHour: 0.2, CostUSDPerHour: 0.14, EstimatedSavings: 43.8, PurchaseAt: now,
+	}}
+	require.NoError(t, store.RecordPlanShadowPurchases(context.Background(), execID, ps))
+	assert.NotEmpty(t, ps[0].ID)
+	assert.Equal(t, now, ps[0].CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_RecordPlanShadowPurchases_RollsBackOnInsertFailure(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM shadow_purchases`).WithArgs("exec-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectQuery(`INSERT INTO shadow_purchases`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("check constraint violated"))
+	mock.ExpectRollback()
+
+	err := store.RecordPlanShadowPurchases(context.Background(), "exec-1", []ShadowPurchase{{Source: ShadowSourcePlan}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to insert shadow purchase")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_GetShadowLadderCommitUSDHr(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	at := time.Now()
+	mock.ExpectQuery(`SUM\(commit_usd_per_hour\)[\s\S]*ladder_config_id = \$1`).WithArgs("cfg-1", at).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(3.5))
+
+	got, err := store.GetShadowLadderCommitUSDHr(context.Background(), "cfg-1", at)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.5, got, 1e-9)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ListShadowPurchases_Filters(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	mock.ExpectQuery(`FROM shadow_purchases WHERE source = \$1 AND ladder_config_id = \$2 ORDER BY purchase_at DESC, id LIMIT \$3`).
+		WithArgs(ShadowSourceLadder, "cfg-1", 10).
+		WillReturnRows(pgxmock.NewRows(shadowPurchaseColNames))
+
+	ps, err := store.ListShadowPurchases(context.Background(), ShadowPurchaseFilter{
+		Source: ShadowSourceLadder, LadderConfigID: "cfg-1", Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, ps)
+	assert.Empty(t, ps)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_ListShadowPurchasesToEvaluate(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	bought := at.AddDate(0, -1, 0)
+	through := at.AddDate(0, 0, -10)
+	cfgID := "cfg-1"
+	f := func(v float64) *float64 { return &v }
+	mock.ExpectQuery(`FROM shadow_purchases\s+WHERE purchase_at < \$1`).WithArgs(at).
+		WillReturnRows(pgxmock.NewRows(shadowPurchaseColNames).
+			AddRow("sp-1", ShadowSourceLadder, (*string)(nil), (*string)(nil), &cfgID, (*string)(nil), (*string)(nil),
+				"aws", "", "us-east-1", "", 1, 3, "no-upfront",
+				0.0, 2.0, 1.4, 0.0, bought,
+				&through, &through, 21, f(907.2), f(1008), f(705.6),
+				f(201.6), f(70.56), f(90), "", bought).
+			AddRow("sp-2", ShadowSourceLadder, (*string)(nil), (*string)(nil), &cfgID, (*string)(nil), (*string)(nil),
+				"aws", "", "us-east-1", "", 1, 3, "no-upfront",
+				0.0, 1.0, 0.7, 0.0, at.AddDate(0, 0, -3),
+				(*time.Time)(nil), (*time.Time)(nil), 0, (*float64)(nil), (*float64)(nil), (*float64)(nil),
+				(*float64)(nil), (*float64)(nil), (*float64)(nil), "", bought))
+
+	ps, err := store.ListShadowPurchasesToEvaluate(context.Background(), at)
+	require.NoError(t, err)
+	require.Len(t, ps, 2)
+	assert.Equal(t, 21, ps[0].Evaluation.Days)
+	assert.InDelta(t, 201.6, ps[0].Evaluation.SavingsUSD, 1e-9)
+	require.NotNil(t, ps[0].Evaluation.UtilizationPct)
+	assert.Equal(t, through, *ps[0].EvaluatedThrough)
+	assert.Nil(t, ps[1].EvaluatedThrough)
+	assert.Nil(t, ps[1].Evaluation.UtilizationPct, "an unevaluated purchase has no utilization, not 0%")
+	assert.Zero(t, ps[1].Evaluation.CoveredUSD)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPGXMock_SaveShadowEvaluation(t *testing.T) {
+	mock := newMock(t)
+	store := storeWith(mock)
+
+	now := time.Now()
+	pct := 75.0
+	p := &ShadowPurchase{ID: "sp-1", EvaluatedAt: &now, EvaluatedThrough: &now}
+	p.Evaluation.Days, p.Evaluation.CoveredUSD, p.Evaluation.UtilizationPct = 2, 36, &pct
+	mock.ExpectExec(`UPDATE shadow_purchases`).
+		WithArgs("sp-1", &now, &now, 2, 36.0, 0.0, 0.0, 0.0, 0.0, &pct, "").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE shadow_purchases`).
+		WithArgs("sp-2", pgxmock.AnyArg(), pgxmock.AnyArg(), 0, 0.0, 0.0, 0.0, 0.0, 0.0, pgxmock.AnyArg(), "no usage").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	require.NoError(t, store.SaveShadowEvaluation(context.Background(), p))
+	err := store.SaveShadowEvaluation(context.Background(), &ShadowPurchase{ID: "sp-2", EvaluationError: "no usage"})
+	assert.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got: %v", err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
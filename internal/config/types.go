@@ -3,10 +3,17 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+	"github.com/LeanerCloud/CUDly/pkg/shadow"
 )
 
 // GlobalConfig represents the global CUDly configuration.
@@ -56,6 +63,35 @@ type GlobalConfig struct {
 	// Default: 7.
 	RecommendationsLookbackDays int `json:"recommendations_lookback_days" db:"recommendations_lookback_days"`
 
+	// RecommendationSource selects where recommendations come from:
+	// "vendor" (the provider recommendation APIs), "native" (pkg/recommender
+	// computed from usage, coverage and offering prices) or "both" (vendor
+	// recs plus native recs for pools the vendor did not cover). Empty means
+	// "vendor". Only AWS has a native source today: Azure and GCP always
+	// use vendor recs whatever this says, and their rows are stored with
+	// RecommendationRecord.Source "vendor" accordingly.
+	RecommendationSource string `json:"recommendation_source" db:"recommendation_source"`
+
+	// NativeRecLookbackDays, NativeRecPercentile and
+	// NativeRecMaxBreakEvenMonths tune the native engine (see
+	// recommender.Config). Unlike RecommendationsLookbackDays the lookback
+	// is free-form in [1, MaxNativeRecLookbackDays]. The percentile picks
+	// the uncovered-demand level commitments are sized to; it is a
+	// percentile of each pool's own hourly usage only when usage comes from
+	// CUR (USAGE_DATA_SOURCE=cur). From Cost Explorer, pool demand is
+	// approximated from the region's daily on-demand spend (see
+	// recommendations.NativeSource). A break-even cap of 0 means no cap.
+	// Zero lookback and percentile mean "use the default".
+	NativeRecLookbackDays       int     `json:"native_rec_lookback_days" db:"native_rec_lookback_days"`
+	NativeRecPercentile         float64 `json:"native_rec_percentile" db:"native_rec_percentile"`
+	NativeRecMaxBreakEvenMonths float64 `json:"native_rec_max_break_even_months" db:"native_rec_max_break_even_months"`
+
+	// FinanceDiscountRatePct is the annual cost of capital (percent) the
+	// recommendation financials (pkg/finance NPV, IRR and discounted
+	// payback) discount cash flows at. nil means
+	// finance.DefaultDiscountRatePct; 0 is a valid rate.
+	FinanceDiscountRatePct *float64 `json:"finance_discount_rate_pct,omitempty" db:"finance_discount_rate_pct"`
+
 	// PurchaseDelayHours is the Gmail-style pre-fire delay (issue #291 wave-2).
 	// When > 0, approving a purchase defers the actual cloud SDK call by this
 	// many hours. The user receives a "scheduled, revoke before X" email
@@ -123,6 +159,24 @@ const DefaultRecommendationsLookbackDays = 7
 // LookbackPeriodInDays enum values. Other values are rejected.
 var ValidRecommendationsLookbackDays = []int{7, 30, 60}
 
+// Recommendation sources accepted by GlobalConfig.RecommendationSource.
+const (
+	RecommendationSourceVendor = "vendor"
+	RecommendationSourceNative = "native"
+	RecommendationSourceBoth   = "both"
+)
+
+// DefaultNativeRecLookbackDays is the native engine's default usage window.
+const DefaultNativeRecLookbackDays = 30
+
+// MaxNativeRecLookbackDays caps the native lookback at Cost Explorer's
+// roughly one-year history.
+const MaxNativeRecLookbackDays = 365
+
+// DefaultNativeRecPercentile sizes native commitments to the 10th
+// percentile of uncovered demand, close to the trough.
+const DefaultNativeRecPercentile = 10.0
+
 // DefaultPurchaseDelayHours is the default Gmail-style pre-fire delay.
 // 48 hours gives most users a working-day window to spot and cancel
 // an approval they didn't intend.
@@ -211,6 +265,16 @@ type PurchasePlan struct {
 	NextExecutionDate      *time.Time               `json:"next_execution_date,omitempty" dynamodbav:"next_execution_date,omitempty"`
 	LastExecutionDate      *time.Time               `json:"last_execution_date,omitempty" dynamodbav:"last_execution_date,omitempty"`
 	LastNotificationSent   *time.Time               `json:"last_notification_sent,omitempty" dynamodbav:"last_notification_sent,omitempty"`
+	// ShadowMode runs the plan's selection and sizing as usual but records
+	// what it would have bought as shadow purchases instead of calling the
+	// provider, so the plan can be scored against later usage before it is
+	// trusted with real money.
+	ShadowMode bool `json:"shadow_mode,omitempty" dynamodbav:"shadow_mode,omitempty"`
+	// AllowDoubleCoverage lets the plan be saved with auto-purchase, and
+	// its executions run, while it would buy one side of an RI / Savings
+	// Plan overlap whose other side it or another enabled plan on the same
+	// account also buys.
+	AllowDoubleCoverage bool `json:"allow_double_coverage,omitempty" dynamodbav:"allow_double_coverage,omitempty"`
 	// Unassigned is true when the plan has zero rows in plan_accounts.
 	// This can happen for legacy plans created before target_accounts was
 	// required (issue #743). Such plans are invisible when an account filter
@@ -522,6 +586,12 @@ type RecommendationRecord struct {
 	PurchaseID        string   `json:"purchase_id,omitempty" dynamodbav:"purchase_id,omitempty"`
 	Error             string   `json:"error,omitempty" dynamodbav:"error,omitempty"`
 	CloudAccountID    *string  `json:"cloud_account_id,omitempty" dynamodbav:"cloud_account_id,omitempty"`
+	// Source is the engine that produced the rec: RecommendationSourceVendor
+	// (the provider recommendation API) or RecommendationSourceNative
+	// (pkg/recommender). Stamped by the scheduler at collection time and
+	// persisted in the recommendations.source column, which reads back as
+	// "vendor" for rows collected before it existed.
+	Source string `json:"source,omitempty" dynamodbav:"source,omitempty"`
 	// SuppressedCount is the cumulative count already committed against
 	// this recommendation's 6-tuple (account, provider, service, region,
 	// resource_type, engine) within the active grace window. The
@@ -547,6 +617,34 @@ type RecommendationRecord struct {
 	// recommendations JSONB payload — no DDL change needed (closes #239
 	// Part 1 for AWS).
 	UsageHistory []float64 `json:"usage_history,omitempty" dynamodbav:"usage_history,omitempty"`
+	// OverlapCoveredPct is the share (0-100) of this RI recommendation's
+	// on-demand usage that Savings Plan recommendations from the same
+	// collection already cover (pkg/portfolio.AnalyzeOverlap); buying both
+	// leaves the second purchase under-utilised. nil when nothing overlaps.
+	// OverlapAdjustedCount is the count left once the covered share is
+	// removed, and OverlapWith lists the overlapping Savings Plan record
+	// IDs. Populated by the scheduler at collection time and stored inside
+	// the recommendations JSONB payload — no DDL change needed.
+	OverlapCoveredPct    *float64 `json:"overlap_covered_pct,omitempty" dynamodbav:"overlap_covered_pct,omitempty"`
+	OverlapAdjustedCount *int     `json:"overlap_adjusted_count,omitempty" dynamodbav:"overlap_adjusted_count,omitempty"`
+	OverlapWith          []string `json:"overlap_with,omitempty" dynamodbav:"overlap_with,omitempty"`
+	// AverageInstancesUsedPerHour is the pool's usage in instances per hour
+	// and ExistingCoveragePct the share (0-100) of it existing commitments
+	// already cover, as the collector saw them (AWS Cost Explorer coverage,
+	// see common.Recommendation). ExistingCoveragePct is nil when no
+	// coverage was found for the pool. The portfolio optimizer bounds each
+	// pool at its uncovered usage with them. Stored inside the
+	// recommendations JSONB payload — no DDL change needed.
+	AverageInstancesUsedPerHour float64  `json:"average_instances_used_per_hour,omitempty" dynamodbav:"average_instances_used_per_hour,omitempty"`
+	ExistingCoveragePct         *float64 `json:"existing_coverage_pct,omitempty" dynamodbav:"existing_coverage_pct,omitempty"`
+	// StartAt, when set, is when the commitment should take effect: a
+	// renewal drafted by the renewal_plan task starts at the expiry of the
+	// commitment it replaces. It is passed to the provider as
+	// PurchaseOptions.StartTime, and an approval before StartAt defers the
+	// execution until then unless every selected rec can be queued (see
+	// purchase.deferUntil). nil means buy immediately. Stored inside the
+	// execution's recommendations JSONB — no DDL change needed.
+	StartAt *time.Time `json:"start_at,omitempty" dynamodbav:"start_at,omitempty"`
 	// VCPU and MemoryGB surface the compute size of the recommended
 	// instance type so the frontend's Capacity column can render
 	// "<vcpu> vCPU / <memory> GB" without parsing the opaque Details blob
@@ -605,6 +703,7 @@ type RecommendationFilter struct {
 	MinSavingsUSD float64  // 0 = no floor on monthly savings dollar amount
 	MinSavingsPct float64  // 0 = no floor on savings percentage (0–100 scale)
 	ID            string   // "" = all ids; non-empty = exact match on the id column
+	Source        string   // "" = all sources; "vendor" / "native" = exact match on the source column
 }
 
 // PurchasePlanFilter parameterises ListPurchasePlans. Zero-value means "no
@@ -864,6 +963,13 @@ type PurchaseHistoryRecord struct {
 	// ListingState* constants). Empty when not listed. Persisted in
 	// purchase_history via migration 000087.
 	ListingState string `json:"listing_state,omitempty" dynamodbav:"listing_state,omitempty"`
+
+	// TermEnd is the commitment's actual end when it does not run a whole
+	// Term of years from Timestamp: Reserved Instances bought on the AWS
+	// Reserved Instance Marketplace run for the seller's remaining term. Nil
+	// means the end is Timestamp + Term years. Persisted in purchase_history
+	// via migration 000106.
+	TermEnd *time.Time `json:"term_end,omitempty" dynamodbav:"term_end,omitempty"`
 }
 
 // AWS EC2 ReservedInstancesListing status values, mirroring the
@@ -896,6 +1002,12 @@ type RIExchangeRecord struct {
 	ApprovalToken      string   `json:"approval_token,omitempty"`
 	Error              string   `json:"error,omitempty"`
 	Mode               string   `json:"mode"`
+	// Provider is the cloud the exchange belongs to: "aws" (the default, and
+	// every record before migration 000105) or "azure". Azure records carry
+	// the subscription ID in AccountID, the target VM SKU in
+	// TargetInstanceType, the target location in Region and no
+	// TargetOfferingID. An empty Provider is stored as "aws".
+	Provider string `json:"provider"`
 	// CreatedByUserID is the UUID of the session user who submitted the exchange
 	// (populated for dashboard-initiated exchanges; nil for automated or legacy
 	// email-link-initiated ones). Exposed to the frontend so the Approve button
@@ -924,6 +1036,515 @@ type RIExchangeRecord struct {
 	CloudAccountID *string    `json:"cloud_account_id,omitempty"`
 }
 
+// RIModificationConfiguration is one EC2 ReservedInstancesConfiguration of
+// an RI modification: an instance type and count, placed in an Availability
+// Zone or regionally. Mirrors pkg/exchange.ModificationTarget.
+type RIModificationConfiguration struct {
+	InstanceType     string `json:"instance_type"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	Scope            string `json:"scope"`
+	InstanceCount    int32  `json:"instance_count"`
+}
+
+// RIModificationRecord represents a record in the ri_modification_history
+// table: one ModifyReservedInstances request and its approval lifecycle.
+// Sources are the source RIs' configurations at submission time, so the
+// record stays readable after AWS retires them.
+type RIModificationRecord struct {
+	ID             string                        `json:"id"`
+	CloudAccountID string                        `json:"cloud_account_id"`
+	Region         string                        `json:"region"`
+	SourceRIIDs    []string                      `json:"source_ri_ids"`
+	Sources        []RIModificationConfiguration `json:"sources"`
+	Targets        []RIModificationConfiguration `json:"targets"`
+	ModificationID string                        `json:"modification_id"`
+	Status         string                        `json:"status"`
+	Reason         string                        `json:"reason,omitempty"`
+	Error          string                        `json:"error,omitempty"`
+	// CreatedByUserID is the UUID of the session user who submitted the
+	// modification; approve-own only lets that user approve it.
+	CreatedByUserID *string `json:"created_by_user_id,omitempty"`
+	// ApprovedBy is the email of the session user who approved it.
+	ApprovedBy     *string    `json:"approved_by,omitempty"`
+	TransitionedBy *string    `json:"transitioned_by,omitempty"`
+	TransitionedAt *time.Time `json:"transitioned_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// AzureRefundRecord represents a row in the azure_refund_ledger table: one
+// Azure reservation refund CUDly issued, counted against the billing
+// profile's rolling 12-month refund limit.
+type AzureRefundRecord struct {
+	ID             string    `json:"id"`
+	BillingProfile string    `json:"billing_profile"`
+	PurchaseID     string    `json:"purchase_id"`
+	Amount         float64   `json:"amount"`
+	Currency       string    `json:"currency"`
+	RefundedAt     time.Time `json:"refunded_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Commitment inventory sources (commitments_inventory.source).
+const (
+	// CommitmentSourceCUDly marks a commitment CUDly bought: its ID matches
+	// a purchase_history purchase_id.
+	CommitmentSourceCUDly = "cudly"
+	// CommitmentSourceExternal marks a commitment bought outside CUDly, e.g.
+	// by hand in the cloud console.
+	CommitmentSourceExternal = "external"
+)
+
+// CommitmentInventoryRecord represents a row in the commitments_inventory
+// table: one commitment as the provider reported it on the last
+// inventory_sync run. AccountID is the provider's external account ID and
+// CloudAccountID the registered cloud_accounts UUID it was synced under.
+// HourlyCost is the amortized hourly cost of the whole commitment, 0 when
+// the provider does not report pricing. EndDate is nil when the provider
+// returned none. SyncScope is the "<service>/<region>" listing the
+// commitment was last seen in; see UpsertCommitmentInventory.
+type CommitmentInventoryRecord struct {
+	Provider       string     `json:"provider"`
+	AccountID      string     `json:"account_id"`
+	CloudAccountID *string    `json:"cloud_account_id,omitempty"`
+	CommitmentID   string     `json:"commitment_id"`
+	CommitmentType string     `json:"commitment_type"`
+	Service        string     `json:"service"`
+	Region         string     `json:"region"`
+	ResourceType   string     `json:"resource_type"`
+	Engine         string     `json:"engine,omitempty"`
+	Count          int        `json:"count"`
+	StartDate      *time.Time `json:"start_date,omitempty"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	State          string     `json:"state"`
+	HourlyCost     float64    `json:"hourly_cost"`
+	Source         string     `json:"source"`
+	SyncScope      string     `json:"sync_scope"`
+	SyncedAt       time.Time  `json:"synced_at"`
+}
+
+// Commitment renewal statuses (commitment_renewals.status).
+const (
+	// RenewalStatusProposed is a drafted replacement awaiting approval.
+	RenewalStatusProposed = "proposed"
+	// RenewalStatusRenewed is a replacement that was bought.
+	RenewalStatusRenewed = "renewed"
+	// RenewalStatusDeclined is a replacement whose execution was canceled,
+	// failed or expired.
+	RenewalStatusDeclined = "declined"
+	// RenewalStatusLetExpire is a commitment the planner chose not to renew.
+	RenewalStatusLetExpire = "let_expire"
+)
+
+// CommitmentRenewal represents a row in the commitment_renewals table: the
+// lineage from an expiring commitment to the purchase execution drafted to
+// replace it and, once that completes, the replacement commitment.
+// Action is a renewal.Action; Proposal is the planner's renewal.Proposal as
+// JSON. ExecutionID is nil for let_expire rows.
+type CommitmentRenewal struct {
+	ID                      string          `json:"id"`
+	Provider                string          `json:"provider"`
+	AccountID               string          `json:"account_id"`
+	CloudAccountID          *string         `json:"cloud_account_id,omitempty"`
+	SourceCommitmentID      string          `json:"source_commitment_id"`
+	SourceEndDate           time.Time       `json:"source_end_date"`
+	Action                  string          `json:"action"`
+	UtilizationPct          *float64        `json:"utilization_pct,omitempty"`
+	Reason                  string          `json:"reason"`
+	Proposal                json.RawMessage `json:"proposal"`
+	ExecutionID             *string         `json:"execution_id,omitempty"`
+	ReplacementCommitmentID *string         `json:"replacement_commitment_id,omitempty"`
+	Status                  string          `json:"status"`
+	CreatedAt               time.Time       `json:"created_at"`
+	UpdatedAt               time.Time       `json:"updated_at"`
+}
+
+// CommitmentRenewalFilter narrows ListCommitmentRenewals. Empty fields
+// match everything; Limit 0 means no limit.
+type CommitmentRenewalFilter struct {
+	Statuses []string
+	// SourceEndAfter keeps renewals of commitments expiring at or after it.
+	SourceEndAfter *time.Time
+	Limit          int
+}
+
+// PurchasePolicy is one version of the purchase policy (purchase_policies,
+// migration 000109). Versions are append-only: saving a policy creates the
+// next version, and the highest version is the one in force. A version
+// with no rules turns the gate off.
+type PurchasePolicy struct {
+	Version   int           `json:"version"`
+	Rules     []policy.Rule `json:"rules"`
+	Comment   string        `json:"comment,omitempty"`
+	CreatedBy string        `json:"created_by,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// PolicyEvaluation records one evaluation of the purchase policy against
+// an execution (purchase_policy_evaluations, migration 000109). Stage is
+// policy.StageApproval or policy.StageExecution; Actor is the approver's
+// email, or empty for scheduler-driven executions.
+type PolicyEvaluation struct {
+	ID                string            `json:"id"`
+	ExecutionID       string            `json:"execution_id"`
+	PolicyVersion     int               `json:"policy_version"`
+	Stage             string            `json:"stage"`
+	Outcome           string            `json:"outcome"`
+	RequiredApprovals int               `json:"required_approvals,omitempty"`
+	Decisions         []policy.Decision `json:"decisions"`
+	Actor             string            `json:"actor,omitempty"`
+	EvaluatedAt       time.Time         `json:"evaluated_at"`
+}
+
+// ExecutionApproval is one approver's signature on an execution
+// (purchase_execution_approvals, migration 000109), collected while a
+// require_approvals policy rule holds the execution for more approvers.
+// Approver is the user UUID when known, otherwise the lower-cased email.
+type ExecutionApproval struct {
+	ExecutionID    string    `json:"execution_id"`
+	Approver       string    `json:"approver"`
+	ApproverEmail  string    `json:"approver_email,omitempty"`
+	ApproverUserID *string   `json:"approver_user_id,omitempty"`
+	ApprovedAt     time.Time `json:"approved_at"`
+}
+
+// AccountGroup is a named set of cloud accounts (account_groups, migration
+// 000110) that configuration such as approval chains can be keyed by.
+type AccountGroup struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	AccountIDs  []string  `json:"account_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HasAccount reports whether accountID is a member of g.
+func (g *AccountGroup) HasAccount(accountID string) bool {
+	for _, id := range g.AccountIDs {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// Approval chain amount bases: which value of an execution a stage's
+// MinAmount is compared against.
+const (
+	// AmountBasisUpfront compares against the execution's upfront cost.
+	AmountBasisUpfront = "upfront"
+	// AmountBasisTotalCommitment compares against the upfront cost plus
+	// every recurring monthly charge over the commitment's term.
+	AmountBasisTotalCommitment = "total_commitment"
+)
+
+// ApprovalChain is an ordered, tiered sign-off chain (approval_chains,
+// migration 000110). It applies to an execution when it is enabled, its
+// Provider (empty = any) matches every selected recommendation and every
+// account the execution targets is in AccountGroupID (nil = any). Among
+// matching chains the lowest Priority wins.
+type ApprovalChain struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Priority       int             `json:"priority"`
+	Enabled        bool            `json:"enabled"`
+	Provider       string          `json:"provider,omitempty"`
+	AccountGroupID *string         `json:"account_group_id,omitempty"`
+	AmountBasis    string          `json:"amount_basis"`
+	Stages         []ApprovalStage `json:"stages"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// ApprovalStage is one sign-off step of an ApprovalChain. The stage is
+// required when the execution's amount is at least MinAmount, and is
+// complete once Quorum of its Approvers (emails) have signed.
+type ApprovalStage struct {
+	Name      string   `json:"name"`
+	Approvers []string `json:"approvers"`
+	Quorum    int      `json:"quorum"`
+	MinAmount float64  `json:"min_amount,omitempty"`
+}
+
+// Validate checks c is well formed: a name, a known amount basis and at
+// least one stage, each with approvers and a quorum it can reach.
+func (c *ApprovalChain) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if c.AmountBasis != AmountBasisUpfront && c.AmountBasis != AmountBasisTotalCommitment {
+		return fmt.Errorf("amount_basis must be %q or %q", AmountBasisUpfront, AmountBasisTotalCommitment)
+	}
+	if len(c.Stages) == 0 {
+		return fmt.Errorf("at least one stage is required")
+	}
+	for i, st := range c.Stages {
+		if strings.TrimSpace(st.Name) == "" {
+			return fmt.Errorf("stage %d: name is required", i+1)
+		}
+		if len(st.Approvers) == 0 {
+			return fmt.Errorf("stage %s: at least one approver is required", st.Name)
+		}
+		if st.Quorum < 1 || st.Quorum > len(st.Approvers) {
+			return fmt.Errorf("stage %s: quorum must be between 1 and the number of approvers (%d)", st.Name, len(st.Approvers))
+		}
+		if st.MinAmount < 0 {
+			return fmt.Errorf("stage %s: min_amount must not be negative", st.Name)
+		}
+	}
+	return nil
+}
+
+// ExecutionApprovalChain is the chain an execution is going through
+// (purchase_approval_chain_runs, migration 000110). Stages is the snapshot
+// of the stages its amount requires, taken when the chain started;
+// CurrentStage indexes the stage awaiting sign-off and equals len(Stages)
+// once every stage has signed.
+type ExecutionApprovalChain struct {
+	ExecutionID  string          `json:"execution_id"`
+	ChainID      *string         `json:"chain_id,omitempty"`
+	ChainName    string          `json:"chain_name"`
+	Amount       float64         `json:"amount"`
+	Stages       []ApprovalStage `json:"stages"`
+	CurrentStage int             `json:"current_stage"`
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Completed reports whether every stage has signed.
+func (r *ExecutionApprovalChain) Completed() bool {
+	return r.CurrentStage >= len(r.Stages)
+}
+
+// ApprovalStageDecision is one approver's sign-off on a stage of an
+// execution's approval chain (purchase_approval_stage_decisions, migration
+// 000110). ApproverEmail is lower-cased.
+type ApprovalStageDecision struct {
+	ExecutionID    string    `json:"execution_id"`
+	StageIndex     int       `json:"stage_index"`
+	ApproverEmail  string    `json:"approver_email"`
+	ApproverUserID *string   `json:"approver_user_id,omitempty"`
+	DecidedAt      time.Time `json:"decided_at"`
+}
+
+// CommitmentBudget caps the upfront spend and the new hourly commitment
+// CUDly may take on per calendar Period (commitment_budgets, migration
+// 000111). AccountGroupID scopes it to purchases landing in that group's
+// accounts; nil applies it to every purchase. A nil cap leaves that limit
+// uncapped.
+type CommitmentBudget struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Period         string    `json:"period"`
+	AccountGroupID *string   `json:"account_group_id,omitempty"`
+	MaxUpfrontUSD  *float64  `json:"max_upfront_usd,omitempty"`
+	MaxHourlyUSD   *float64  `json:"max_hourly_usd,omitempty"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Validate checks b is well formed: a name, a known period and at least
+// one non-negative cap.
+func (b *CommitmentBudget) Validate() error {
+	if strings.TrimSpace(b.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !budget.Period(b.Period).Valid() {
+		return fmt.Errorf("period must be %q or %q", budget.PeriodMonth, budget.PeriodQuarter)
+	}
+	if b.MaxUpfrontUSD == nil && b.MaxHourlyUSD == nil {
+		return fmt.Errorf("at least one of max_upfront_usd and max_hourly_usd is required")
+	}
+	if !validBudgetCap(b.MaxUpfrontUSD) {
+		return fmt.Errorf("max_upfront_usd must be a non-negative number")
+	}
+	if !validBudgetCap(b.MaxHourlyUSD) {
+		return fmt.Errorf("max_hourly_usd must be a non-negative number")
+	}
+	return nil
+}
+
+// validBudgetCap reports whether v is unset or a finite, non-negative cap.
+func validBudgetCap(v *float64) bool {
+	return v == nil || (*v >= 0 && !math.IsInf(*v, 0))
+}
+
+// Cap returns b's limits for budget.Check.
+func (b *CommitmentBudget) Cap() budget.Cap {
+	return budget.Cap{Name: b.Name, Period: budget.Period(b.Period), MaxUpfrontUSD: b.MaxUpfrontUSD, MaxHourlyUSD: b.MaxHourlyUSD}
+}
+
+// Budget reservation statuses.
+const (
+	// BudgetReservationReserved holds budget for a purchase in flight.
+	BudgetReservationReserved = "reserved"
+	// BudgetReservationCommitted counts a purchase that happened.
+	BudgetReservationCommitted = "committed"
+	// BudgetReservationReleased gave the budget back.
+	BudgetReservationReleased = "released"
+)
+
+// BudgetReservation is one entry of the commitment budget ledger
+// (budget_reservations, migration 000111): budget reserved by a purchase
+// path before it called the cloud. Source and Reference identify the
+// purchase (budget.Source*); AccountIDs are CUDly cloud account UUIDs.
+type BudgetReservation struct {
+	ID            string     `json:"id"`
+	Source        string     `json:"source"`
+	Reference     string     `json:"reference"`
+	Provider      string     `json:"provider,omitempty"`
+	AccountIDs    []string   `json:"account_ids"`
+	UpfrontUSD    float64    `json:"upfront_usd"`
+	HourlyUSD     float64    `json:"hourly_usd"`
+	Status        string     `json:"status"`
+	ReservedAt    time.Time  `json:"reserved_at"`
+	SettledAt     *time.Time `json:"settled_at,omitempty"`
+	ReleaseReason string     `json:"release_reason,omitempty"`
+}
+
+// CommitmentBudgetUsage is a budget's standing in the period containing
+// the time it was computed for. Remaining* are nil for an uncapped limit.
+type CommitmentBudgetUsage struct {
+	Budget              CommitmentBudget `json:"budget"`
+	PeriodStart         time.Time        `json:"period_start"`
+	PeriodEnd           time.Time        `json:"period_end"`
+	UsedUpfrontUSD      float64          `json:"used_upfront_usd"`
+	UsedHourlyUSD       float64          `json:"used_hourly_usd"`
+	RemainingUpfrontUSD *float64         `json:"remaining_upfront_usd,omitempty"`
+	RemainingHourlyUSD  *float64         `json:"remaining_hourly_usd,omitempty"`
+}
+
+// FreezeWindow is a change-freeze (blackout) window during which scheduled
+// purchases and ladder tranches are deferred or skipped (freeze_windows,
+// migration 000112). Provider and AccountIDs scope it; empty applies it to
+// every purchase. AccountIDs are CUDly cloud account UUIDs.
+type FreezeWindow struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Reason     string    `json:"reason,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Recurrence string    `json:"recurrence"`
+	Provider   string    `json:"provider,omitempty"`
+	AccountIDs []string  `json:"account_ids"`
+	Action     string    `json:"action"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Validate checks w is well formed (see freeze.Window.Validate) and that
+// its provider, when set, is a known one.
+func (w *FreezeWindow) Validate() error {
+	if w.Provider != "" && !isValidProvider(w.Provider) {
+		return fmt.Errorf("invalid provider: %s (valid: %s)", w.Provider, strings.Join(ValidProviders, ", "))
+	}
+	win := w.Window()
+	return win.Validate()
+}
+
+// Window returns w for the freeze calendar.
+func (w *FreezeWindow) Window() freeze.Window {
+	return freeze.Window{
+		ID:         w.ID,
+		Name:       w.Name,
+		Start:      w.StartsAt,
+		End:        w.EndsAt,
+		Recurrence: freeze.Recurrence(w.Recurrence),
+		Provider:   w.Provider,
+		AccountIDs: w.AccountIDs,
+		Action:     freeze.Action(w.Action),
+	}
+}
+
+// FreezeOverride is an administrator's audited override letting one
+// execution be purchased during a change freeze (freeze_overrides,
+// migration 000112). WindowID is nil once the window has been deleted;
+// WindowName keeps the audit trail readable.
+type FreezeOverride struct {
+	ID          string    `json:"id"`
+	ExecutionID string    `json:"execution_id"`
+	WindowID    *string   `json:"window_id,omitempty"`
+	WindowName  string    `json:"window_name"`
+	Actor       string    `json:"actor"`
+	ActorUserID *string   `json:"actor_user_id,omitempty"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Shadow purchase sources.
+const (
+	// ShadowSourcePlan is a purchase a shadow-mode purchase plan's
+	// execution would have made.
+	ShadowSourcePlan = "plan"
+	// ShadowSourceLadder is a tranche a shadow-mode ladder config would
+	// have scheduled.
+	ShadowSourceLadder = "ladder"
+)
+
+// ShadowPurchase is one purchase a shadow-mode plan or ladder config would
+// have made (shadow_purchases, migration 000113), with its latest
+// evaluation against the usage that followed. CommitUSDPerHour is the
+// on-demand spend per hour it would cover at full utilization and
+// CostUSDPerHour what it would cost per hour (see pkg/shadow.Purchase).
+// EstimatedSavings is the monthly saving the recommendation promised.
+// Evaluation accumulates run by run: EvaluatedThrough is the end of the
+// last UTC day scored. EvaluatedAt and EvaluatedThrough are nil, and
+// Evaluation empty, until the first evaluation.
+type ShadowPurchase struct {
+	ID               string            `json:"id"`
+	Source           string            `json:"source"`
+	PlanID           *string           `json:"plan_id,omitempty"`
+	ExecutionID      *string           `json:"execution_id,omitempty"`
+	LadderConfigID   *string           `json:"ladder_config_id,omitempty"`
+	LadderRunID      *string           `json:"ladder_run_id,omitempty"`
+	CloudAccountID   *string           `json:"cloud_account_id,omitempty"`
+	Provider         string            `json:"provider"`
+	Service          string            `json:"service"`
+	Region           string            `json:"region"`
+	ResourceType     string            `json:"resource_type"`
+	Count            int               `json:"count"`
+	TermYears        int               `json:"term_years"`
+	Payment          string            `json:"payment"`
+	UpfrontCost      float64           `json:"upfront_cost"`
+	CommitUSDPerHour float64           `json:"commit_usd_per_hour"`
+	CostUSDPerHour   float64           `json:"cost_usd_per_hour"`
+	EstimatedSavings float64           `json:"estimated_savings"`
+	PurchaseAt       time.Time         `json:"purchase_at"`
+	EvaluatedAt      *time.Time        `json:"evaluated_at,omitempty"`
+	EvaluatedThrough *time.Time        `json:"evaluated_through,omitempty"`
+	Evaluation       shadow.Evaluation `json:"evaluation"`
+	EvaluationError  string            `json:"evaluation_error,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// TermEnd is when the purchase's term would have ended.
+func (p *ShadowPurchase) TermEnd() time.Time {
+	return p.PurchaseAt.AddDate(p.TermYears, 0, 0)
+}
+
+// Purchase returns p for pkg/shadow.
+func (p *ShadowPurchase) Purchase() shadow.Purchase {
+	return shadow.Purchase{
+		Start:            p.PurchaseAt,
+		End:              p.TermEnd(),
+		CommitUSDPerHour: p.CommitUSDPerHour,
+		CostUSDPerHour:   p.CostUSDPerHour,
+	}
+}
+
+// ShadowPurchaseFilter narrows ListShadowPurchases. Empty fields match
+// every row.
+type ShadowPurchaseFilter struct {
+	Source         string
+	PlanID         string
+	LadderConfigID string
+	Limit          int
+}
+
 // ConfigSetting represents a configuration setting for the defaults system.
 type ConfigSetting struct { //nolint:revive // exported: doc comment style intentional
 	Key         string    `json:"key"`
@@ -1090,12 +1711,15 @@ const DefaultLadderMaxActionsPerRun = 10
 const MaxLadderActionsPerRun = 50
 
 // LadderConfigDB is the DB-persistence mirror of pkg/ladder.LadderConfig.
-// It stores one per-account, per-provider ladder configuration row and is
-// used by the store layer (GetLadderConfig / UpsertLadderConfig) and the API
-// handler. Mode and Cadence are plain strings whose valid values are defined
-// by pkg/ladder (ModeEmailApproval, ModeAutoApprove, CadenceDaily,
-// CadenceWeekly). Validation calls pkg/ladder's Parse* functions so the
-// internal/config package never redefines those constants.
+// It stores one per-account, per-provider, per-service ladder configuration
+// row and is used by the store layer (GetLadderConfig / UpsertLadderConfig)
+// and the API handler. Service is empty for the provider's compute ladder and
+// names an AWS reserved-capacity service (rds, elasticache, opensearch) for
+// that service's single-layer ladder. Mode and Cadence are plain strings
+// whose valid values are defined by pkg/ladder (ModeEmailApproval,
+// ModeAutoApprove, ModeShadow, CadenceDaily, CadenceWeekly). Validation calls
+// pkg/ladder's Parse* functions so the internal/config package never
+// redefines those constants.
 //
 // MaxHourlyCommitPerRun is a pointer because nil means "no cap" (distinct from
 // 0, which would cap all spending). All numeric money fields follow the project
@@ -1112,9 +1736,10 @@ type LadderConfigDB struct {
 	MaxHourlyCommitPerRun      *float64        `json:"max_hourly_commit_per_run,omitempty"`
 	CloudAccountID             string          `json:"cloud_account_id"`
 	Provider                   string          `json:"provider"`
-	Mode                       string          `json:"mode"`    // ladder.ModeEmailApproval | ladder.ModeAutoApprove
+	Mode                       string          `json:"mode"`    // ladder.ModeEmailApproval | ladder.ModeAutoApprove | ladder.ModeShadow
 	Cadence                    string          `json:"cadence"` // ladder.CadenceDaily | ladder.CadenceWeekly
 	ID                         string          `json:"id"`
+	Service                    string          `json:"service,omitempty"`
 	RampSchedule               json.RawMessage `json:"ramp_schedule"`
 	BufferUtilizationThreshold float64         `json:"buffer_utilization_threshold"`
 	LookbackDays               int             `json:"lookback_days"`
@@ -1127,6 +1752,44 @@ type LadderConfigDB struct {
 	Enabled            bool    `json:"enabled"`
 }
 
+// EngineConfig converts the row to a pkg/ladder LadderConfig scoped to
+// (provider, accountID, Service). It parses typed enums at the boundary and
+// fails loud on any unknown value. accountID is the provider-native account identifier
+// (AWS account, Azure subscription, GCP project), not CloudAccountID.
+func (c *LadderConfigDB) EngineConfig(provider common.ProviderType, accountID string) (ladder.LadderConfig, error) {
+	mode, err := ladder.ParseLadderMode(c.Mode)
+	if err != nil {
+		return ladder.LadderConfig{}, fmt.Errorf("mode: %w", err)
+	}
+	cadence, err := ladder.ParseLadderCadence(c.Cadence)
+	if err != nil {
+		return ladder.LadderConfig{}, fmt.Errorf("cadence: %w", err)
+	}
+
+	var ramp ladder.RampSchedule
+	if err := json.Unmarshal(c.RampSchedule, &ramp); err != nil {
+		return ladder.LadderConfig{}, fmt.Errorf("ramp_schedule: %w", err)
+	}
+
+	return ladder.LadderConfig{
+		Scope: ladder.Scope{
+			Provider:  provider,
+			AccountID: accountID,
+			Service:   common.ServiceType(c.Service),
+		},
+		Mode:                          mode,
+		Cadence:                       cadence,
+		Ramp:                          ramp,
+		TargetCoveragePct:             c.TargetCoverage,
+		BufferFraction:                c.BufferFraction,
+		BaselinePercentile:            c.BaselinePercentile,
+		LookbackDays:                  c.LookbackDays,
+		MaxActionsPerRun:              c.MaxActionsPerRun,
+		BufferUtilizationThresholdPct: c.BufferUtilizationThreshold,
+		MaxHourlyCommitPerRun:         c.MaxHourlyCommitPerRun,
+	}, nil
+}
+
 // LadderRunDB mirrors the ladder_runs table (migration 000080).
 // Monetary snapshot columns are *float64 (nullable, NEVER 0-coerced:
 // NULL means "not computed", not "$0"). Field order minimizes GC
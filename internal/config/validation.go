@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
 )
 
@@ -325,9 +326,44 @@ func (c *GlobalConfig) validateRecommendationsFields() error {
 	if err := c.validateRecommendationsLookbackDays(); err != nil {
 		return err
 	}
+	if err := c.validateNativeRecommendations(); err != nil {
+		return err
+	}
+	if err := c.validateFinanceDiscountRate(); err != nil {
+		return err
+	}
 	return c.validatePurchaseDelayHours()
 }
 
+// validateNativeRecommendations validates the recommendation source and the
+// native engine knobs. Zero lookback and percentile mean "use the default".
+func (c *GlobalConfig) validateNativeRecommendations() error {
+	switch c.RecommendationSource {
+	case "", RecommendationSourceVendor, RecommendationSourceNative, RecommendationSourceBoth:
+	default:
+		return fmt.Errorf("invalid recommendation_source: %q (valid: vendor, native, both)", c.RecommendationSource)
+	}
+	if c.NativeRecLookbackDays < 0 || c.NativeRecLookbackDays > MaxNativeRecLookbackDays {
+		return fmt.Errorf("native_rec_lookback_days must be between 0 and %d, got: %d", MaxNativeRecLookbackDays, c.NativeRecLookbackDays)
+	}
+	if math.IsNaN(c.NativeRecPercentile) || c.NativeRecPercentile < 0 || c.NativeRecPercentile > 100 {
+		return fmt.Errorf("native_rec_percentile must be between 0 and 100, got: %v", c.NativeRecPercentile)
+	}
+	if math.IsNaN(c.NativeRecMaxBreakEvenMonths) || c.NativeRecMaxBreakEvenMonths < 0 || c.NativeRecMaxBreakEvenMonths > 120 {
+		return fmt.Errorf("native_rec_max_break_even_months must be between 0 and 120, got: %v (0 = no cap)", c.NativeRecMaxBreakEvenMonths)
+	}
+	return nil
+}
+
+// validateFinanceDiscountRate validates the recommendation financials'
+// discount rate. nil means the default.
+func (c *GlobalConfig) validateFinanceDiscountRate() error {
+	if r := c.FinanceDiscountRatePct; r != nil && (math.IsNaN(*r) || *r < 0 || *r > 100) {
+		return fmt.Errorf("finance_discount_rate_pct must be between 0 and 100, got: %v", *r)
+	}
+	return nil
+}
+
 // validatePurchaseDelayHours validates the Gmail-style pre-fire delay
 // (issue #291 wave-2). Valid range: [0, MaxPurchaseDelayHours]. 0 means
 // immediate-execute (backward compat).
@@ -681,6 +717,9 @@ func (c *LadderConfigDB) Validate() error {
 	if err := validateLadderProvider(c.Provider); err != nil {
 		return err
 	}
+	if err := c.validateLadderService(); err != nil {
+		return err
+	}
 	if _, err := ladder.ParseLadderMode(c.Mode); err != nil {
 		return fmt.Errorf("mode: %w", err)
 	}
@@ -706,6 +745,26 @@ func validateLadderProvider(provider string) error {
 	return fmt.Errorf("provider %q is not valid (allowed: %s)", provider, strings.Join(ValidProviders, ", "))
 }
 
+// validateLadderService checks the optional service scope. A service ladder
+// is AWS-only, must name a service with a reserved-capacity layer, and has
+// no buffer layer, so its buffer_fraction must be 0 (the engine would
+// otherwise fail every run with "no buffer layer").
+func (c *LadderConfigDB) validateLadderService() error {
+	if c.Service == "" {
+		return nil
+	}
+	if c.Provider != "aws" {
+		return fmt.Errorf("service %q: service-scoped ladders are only supported on aws", c.Service)
+	}
+	if _, err := ladder.ReservedCapacityLayerType(common.ServiceType(c.Service)); err != nil {
+		return fmt.Errorf("service: %w", err)
+	}
+	if c.BufferFraction != 0 {
+		return fmt.Errorf("buffer_fraction %g must be 0 for a %s ladder (no buffer layer)", c.BufferFraction, c.Service)
+	}
+	return nil
+}
+
 // validateLadderBaselineBounds checks the coverage target, buffer fraction,
 // and baseline measurement parameters. Uses the same NaN-hostile invariants
 // as pkg/ladder.LadderConfig.validateBaselineBounds (mirrored here so
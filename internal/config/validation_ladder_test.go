@@ -172,3 +172,39 @@ func TestLadderConfigDB_Validate_EnumsAndProvider(t *testing.T) {
 		})
 	}
 }
+
+// TestLadderConfigDB_Validate_Service covers the optional service scope: AWS
+// reserved-capacity services only, and no buffer (the single RI layer has no
+// buffer role).
+func TestLadderConfigDB_Validate_Service(t *testing.T) {
+	cases := []struct {
+		mutate  func(c *LadderConfigDB)
+		name    string
+		wantErr bool
+	}{
+		{name: "rds without buffer", mutate: func(c *LadderConfigDB) { c.Service, c.BufferFraction = "rds", 0 }, wantErr: false},
+		{name: "opensearch without buffer", mutate: func(c *LadderConfigDB) { c.Service, c.BufferFraction = "opensearch", 0 }, wantErr: false},
+		{name: "rds with buffer", mutate: func(c *LadderConfigDB) { c.Service = "rds" }, wantErr: true},
+		{name: "service without reserved-capacity layer", mutate: func(c *LadderConfigDB) { c.Service, c.BufferFraction = "redshift", 0 }, wantErr: true},
+		{name: "service on azure", mutate: func(c *LadderConfigDB) { c.Provider, c.Service, c.BufferFraction = "azure", "rds", 0 }, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validLadderConfigDB()
+			tc.mutate(&c)
+			err := c.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	c := validLadderConfigDB()
+	c.Service, c.BufferFraction = "elasticache", 0
+	engineCfg, err := c.EngineConfig("aws", "123456789012")
+	require.NoError(t, err)
+	assert.Equal(t, "elasticache", string(engineCfg.Scope.Service))
+	assert.NoError(t, engineCfg.Validate())
+}
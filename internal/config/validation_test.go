@@ -263,6 +263,70 @@ func TestGlobalConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "native recommendation settings are valid",
+			config: GlobalConfig{
+				DefaultTerm:                 3,
+				RecommendationSource:        RecommendationSourceBoth,
+				NativeRecLookbackDays:       90,
+				NativeRecPercentile:         25,
+				NativeRecMaxBreakEvenMonths: 12,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown recommendation_source is rejected",
+			config: GlobalConfig{
+				DefaultTerm:          3,
+				RecommendationSource: "aws",
+			},
+			wantErr: true,
+			errMsg:  "invalid recommendation_source",
+		},
+		{
+			name: "native lookback above a year is rejected",
+			config: GlobalConfig{
+				DefaultTerm:           3,
+				NativeRecLookbackDays: 400,
+			},
+			wantErr: true,
+			errMsg:  "native_rec_lookback_days",
+		},
+		{
+			name: "native percentile above 100 is rejected",
+			config: GlobalConfig{
+				DefaultTerm:         3,
+				NativeRecPercentile: 101,
+			},
+			wantErr: true,
+			errMsg:  "native_rec_percentile",
+		},
+		{
+			name: "negative native break-even cap is rejected",
+			config: GlobalConfig{
+				DefaultTerm:                 3,
+				NativeRecMaxBreakEvenMonths: -1,
+			},
+			wantErr: true,
+			errMsg:  "native_rec_max_break_even_months",
+		},
+		{
+			name: "zero finance discount rate is valid",
+			config: GlobalConfig{
+				DefaultTerm:            3,
+				FinanceDiscountRatePct: float64Ptr(0),
+			},
+			wantErr: false,
+		},
+		{
+			name: "finance discount rate above 100 is rejected",
+			config: GlobalConfig{
+				DefaultTerm:            3,
+				FinanceDiscountRatePct: float64Ptr(150),
+			},
+			wantErr: true,
+			errMsg:  "finance_discount_rate_pct",
+		},
 		// Issue #694: OfferingClass validation on PUT
 		{
 			name: "offering_class empty is valid (defaults to convertible)",
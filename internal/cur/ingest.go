@@ -0,0 +1,195 @@
+package cur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	pkgcur "github.com/LeanerCloud/CUDly/pkg/cur"
+	"github.com/LeanerCloud/CUDly/pkg/focus"
+)
+
+// Format is an export format the Ingester can decode into pkg/cur line
+// items.
+type Format struct {
+	Name string
+	// Supported reports whether Decode reads an object with this key.
+	Supported func(key string) bool
+	Decode    func(key string, r io.Reader, fn func(pkgcur.LineItem) error) error
+}
+
+// FormatCUR reads AWS CUR 2.0 and legacy CUR exports.
+var FormatCUR = Format{Name: "cur", Supported: pkgcur.Supported, Decode: pkgcur.Decode}
+
+// FormatFOCUS reads FOCUS 1.x datasets from any provider.
+var FormatFOCUS = Format{Name: "focus", Supported: focus.Supported, Decode: focus.Decode}
+
+// Store is the persistence the Ingester needs. internal/config's
+// PostgresStore implements it.
+type Store interface {
+	CURObjectETags(ctx context.Context, source string) (map[string]string, error)
+	ReplaceCURObject(ctx context.Context, source, key, etag string, pools []pkgcur.PoolHour, commitments []pkgcur.CommitmentHour) error
+	DeleteCURObjects(ctx context.Context, source string, keys []string) error
+}
+
+// Result summarises one ingestion run.
+type Result struct {
+	Listed    int `json:"listed"`
+	Ingested  int `json:"ingested"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+	Pruned    int `json:"pruned"`
+	// Skipped counts listed data objects the format has no decoder for.
+	// Manifests and other export metadata are not counted.
+	Skipped int `json:"skipped"`
+	// LineItems counts rows decoded from ingested objects; UsedLineItems
+	// the ones the aggregator consumed (instance-hour usage of a pool).
+	LineItems     int `json:"line_items"`
+	UsedLineItems int `json:"used_line_items"`
+}
+
+// Ingester copies a Source's exports into a Store.
+type Ingester struct {
+	source Source
+	format Format
+	store  Store
+}
+
+// NewIngester wires an Ingester that decodes source's objects as format.
+func NewIngester(source Source, format Format, store Store) (*Ingester, error) {
+	if source == nil {
+		return nil, fmt.Errorf("NewIngester: source must not be nil")
+	}
+	if format.Supported == nil || format.Decode == nil {
+		return nil, fmt.Errorf("NewIngester: format %q must set Supported and Decode", format.Name)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("NewIngester: store must not be nil")
+	}
+	return &Ingester{source: source, format: format, store: store}, nil
+}
+
+// Run ingests every object that is new or whose ETag changed since it was
+// last ingested, then prunes stored objects that are no longer listed
+// (CUR rewrites a billing period under new file names). Each object is
+// replaced atomically, so a failure leaves earlier objects ingested: the
+// failing objects are reported together in the returned error and retried
+// on the next run. An empty listing prunes nothing, so a mistyped prefix
+// cannot wipe the store. A data object no decoder can read is counted in
+// Skipped and reported in the error too, so an export in an unregistered
+// format fails loudly instead of ingesting nothing.
+func (in *Ingester) Run(ctx context.Context) (Result, error) {
+	location := in.source.Location()
+	all, err := in.source.List(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	objects := all[:0:0]
+	var unreadable []string
+	for _, obj := range all {
+		switch {
+		case in.format.Supported(obj.Key):
+			objects = append(objects, obj)
+		case !isExportMetadata(obj.Key):
+			unreadable = append(unreadable, obj.Key)
+		}
+	}
+	ingested, err := in.store.CURObjectETags(ctx, location)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Listed: len(objects), Skipped: len(unreadable)}
+	var errs []error
+	if len(unreadable) > 0 {
+		errs = append(errs, fmt.Errorf("%d objects have no %s decoder (first: %s)", len(unreadable), in.format.Name, unreadable[0]))
+	}
+	listed := make(map[string]struct{}, len(objects))
+	for _, obj := range objects {
+		listed[obj.Key] = struct{}{}
+		if etag, ok := ingested[obj.Key]; ok && etag == obj.ETag {
+			res.Unchanged++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		lines, used, err := in.ingestObject(ctx, location, obj)
+		if err != nil {
+			res.Failed++
+			errs = append(errs, fmt.Errorf("%s: %w", obj.Key, err))
+			continue
+		}
+		res.Ingested++
+		res.LineItems += lines
+		res.UsedLineItems += used
+	}
+
+	if len(objects) > 0 {
+		var gone []string
+		for key := range ingested {
+			if _, ok := listed[key]; !ok {
+				gone = append(gone, key)
+			}
+		}
+		if err := in.store.DeleteCURObjects(ctx, location, gone); err != nil {
+			errs = append(errs, fmt.Errorf("pruning: %w", err))
+		} else {
+			res.Pruned = len(gone)
+		}
+	} else if len(ingested) > 0 {
+		log.Printf("cur_ingest: %s listed no objects; keeping %d previously ingested", location, len(ingested))
+	}
+	return res, errors.Join(errs...)
+}
+
+// exportMetadataExts are the non-data files CUR, Data Exports and FOCUS
+// exports write next to their data: manifests, Athena and Redshift setup
+// files, and checksums.
+var exportMetadataExts = map[string]bool{
+	".json": true, ".yml": true, ".yaml": true, ".sql": true, ".txt": true, ".crc": true,
+}
+
+// isExportMetadata reports whether key is export metadata rather than data.
+// Directory markers and files starting with "_" or "." (such as _SUCCESS)
+// are metadata too.
+func isExportMetadata(key string) bool {
+	if strings.HasSuffix(key, "/") {
+		return true
+	}
+	base := strings.ToLower(path.Base(key))
+	if strings.HasPrefix(base, "_") || strings.HasPrefix(base, ".") {
+		return true
+	}
+	return exportMetadataExts[path.Ext(base)]
+}
+
+// ingestObject decodes and aggregates one object and replaces its rows.
+func (in *Ingester) ingestObject(ctx context.Context, location string, obj Object) (lines, used int, err error) {
+	rc, err := in.source.Open(ctx, obj.Key)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rc.Close()
+
+	agg := pkgcur.NewAggregator()
+	err = in.format.Decode(obj.Key, rc, func(li pkgcur.LineItem) error {
+		lines++
+		ok, err := agg.Add(li)
+		if ok {
+			used++
+		}
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := in.store.ReplaceCURObject(ctx, location, obj.Key, obj.ETag, agg.PoolHours(), agg.CommitmentHours()); err != nil {
+		return 0, 0, err
+	}
+	return lines, used, nil
+}
@@ -0,0 +1,209 @@
+package cur
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgcur "github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+const exportCSV = `line_item_usage_start_date,line_item_usage_end_date,line_item_usage_account_id,line_item_line_item_type,line_item_product_code,line_item_usage_type,pricing_unit,product_region_code,product_instance_type,line_item_usage_amount,line_item_unblended_cost,reservation_reservation_a_r_n,reservation_effective_cost
+2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,111111111111,Usage,AmazonEC2,USE1-BoxUsage:m5.large,Hrs,us-east-1,m5.large,2,0.192,,
+2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,111111111111,DiscountedUsage,AmazonEC2,USE1-BoxUsage:m5.large,Hrs,us-east-1,m5.large,1,0,arn:aws:ec2:us-east-1:111111111111:reserved-instances/ri-1,0.06
+2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,111111111111,Usage,AmazonS3,USE1-TimedStorage-ByteHrs,GB-Mo,us-east-1,,10,0.23,,
+`
+
+// fakeStore keeps replaced objects in memory.
+type fakeStore struct {
+	etags      map[string]string
+	pools      map[string][]pkgcur.PoolHour
+	deleted    []string
+	replaceErr error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{etags: map[string]string{}, pools: map[string][]pkgcur.PoolHour{}}
+}
+
+func (f *fakeStore) CURObjectETags(_ context.Context, _ string) (map[string]string, error) {
+	out := make(map[string]string, len(f.etags))
+	for k, v := range f.etags {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (f *fakeStore) ReplaceCURObject(_ context.Context, _, key, etag string, pools []pkgcur.PoolHour, _ []pkgcur.CommitmentHour) error {
+	if f.replaceErr != nil {
+		return f.replaceErr
+	}
+	f.etags[key] = etag
+	f.pools[key] = pools
+	return nil
+}
+
+func (f *fakeStore) DeleteCURObjects(_ context.Context, _ string, keys []string) error {
+	for _, k := range keys {
+		delete(f.etags, k)
+		delete(f.pools, k)
+	}
+	f.deleted = append(f.deleted, keys...)
+	return nil
+}
+
+func writeExport(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestIngester_IngestsNewObjectsOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "BILLING_PERIOD=2026-03/part-0.csv", exportCSV)
+	writeExport(t, dir, "BILLING_PERIOD=2026-03/manifest.json", `{}`)
+	src, err := NewLocalSource(dir)
+	require.NoError(t, err)
+	store := newFakeStore()
+	in, err := NewIngester(src, FormatCUR, store)
+	require.NoError(t, err)
+
+	res, err := in.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Result{Listed: 1, Ingested: 1, LineItems: 3, UsedLineItems: 2}, res)
+
+	pools := store.pools["BILLING_PERIOD=2026-03/part-0.csv"]
+	require.Len(t, pools, 1)
+	assert.Equal(t, 3.0, pools[0].UsageHours)
+	assert.Equal(t, 1.0, pools[0].ReservedHours)
+
+	res, err = in.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Result{Listed: 1, Unchanged: 1}, res, "an unchanged object is not re-read")
+}
+
+func TestIngester_ReingestsRewrittenAndPrunesRemoved(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "a.csv", exportCSV)
+	writeExport(t, dir, "b.csv", exportCSV)
+	src, err := NewLocalSource(dir)
+	require.NoError(t, err)
+	store := newFakeStore()
+	in, err := NewIngester(src, FormatCUR, store)
+	require.NoError(t, err)
+	_, err = in.Run(context.Background())
+	require.NoError(t, err)
+
+	// CUR redelivers the period: a.csv is rewritten, b.csv disappears.
+	writeExport(t, dir, "a.csv", exportCSV+"2026-03-01T11:00:00Z,2026-03-01T12:00:00Z,111111111111,Usage,AmazonEC2,USE1-BoxUsage:m5.large,Hrs,us-east-1,m5.large,1,0.096,,\n")
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "a.csv"), future, future))
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.csv")))
+
+	res, err := in.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Ingested)
+	assert.Equal(t, 1, res.Pruned)
+	assert.Equal(t, []string{"b.csv"}, store.deleted)
+	assert.Len(t, store.pools["a.csv"], 2)
+}
+
+func TestIngester_EmptyListingPrunesNothing(t *testing.T) {
+	src, err := NewLocalSource(t.TempDir())
+	require.NoError(t, err)
+	store := newFakeStore()
+	store.etags["old.csv"] = "e1"
+	in, err := NewIngester(src, FormatCUR, store)
+	require.NoError(t, err)
+
+	res, err := in.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, res.Pruned)
+	assert.Empty(t, store.deleted)
+}
+
+func TestIngester_FailuresAreReportedAndRetried(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "bad.csv", "line_item_usage_start_date\nnot-a-date\n")
+	writeExport(t, dir, "good.csv", exportCSV)
+	src, err := NewLocalSource(dir)
+	require.NoError(t, err)
+	store := newFakeStore()
+	in, err := NewIngester(src, FormatCUR, store)
+	require.NoError(t, err)
+
+	res, err := in.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.csv")
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, 1, res.Ingested, "one bad object must not block the others")
+	_, recorded := store.etags["bad.csv"]
+	assert.False(t, recorded, "a failed object stays unrecorded so the next run retries it")
+
+	store.replaceErr = errors.New("db down")
+	writeExport(t, dir, "bad.csv", exportCSV)
+	_, err = in.Run(context.Background())
+	require.ErrorContains(t, err, "db down")
+}
+
+func TestIngester_ReportsObjectsWithoutADecoder(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "data/part-0.csv", exportCSV)
+	writeExport(t, dir, "data/part-1.orc", "orc")
+	writeExport(t, dir, "data/_SUCCESS", "")
+	writeExport(t, dir, "metadata/Manifest.json", `{}`)
+	src, err := NewLocalSource(dir)
+	require.NoError(t, err)
+	store := newFakeStore()
+	in, err := NewIngester(src, FormatCUR, store)
+	require.NoError(t, err)
+
+	res, err := in.Run(context.Background())
+	require.ErrorContains(t, err, "data/part-1.orc")
+	assert.Equal(t, 1, res.Skipped, "metadata files are not counted")
+	assert.Equal(t, 1, res.Ingested, "an unreadable object must not block the others")
+}
+
+func TestNewIngester_RejectsNil(t *testing.T) {
+	_, err := NewIngester(nil, FormatCUR, newFakeStore())
+	assert.ErrorContains(t, err, "source must not be nil")
+	src, err := NewLocalSource(t.TempDir())
+	require.NoError(t, err)
+	_, err = NewIngester(src, FormatCUR, nil)
+	assert.ErrorContains(t, err, "store must not be nil")
+}
+
+func TestLocalSource_RejectsEscapingKeys(t *testing.T) {
+	src, err := NewLocalSource(t.TempDir())
+	require.NoError(t, err)
+	_, err = src.Open(context.Background(), "../etc/passwd")
+	assert.ErrorContains(t, err, "invalid object key")
+}
+
+func TestIngester_FOCUSFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "focus/part-0.csv", `SubAccountId,ChargePeriodStart,ChargePeriodEnd,ChargeCategory,PricingCategory,ServiceName,RegionId,SkuPriceDetails,ConsumedQuantity,ConsumedUnit,BilledCost
+proj-1,2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,Usage,Standard,Compute Engine,europe-west1,"{""MachineType"":""n2-standard-4""}",2,Hours,0.39
+`)
+	writeExport(t, dir, "focus/manifest.json", `{}`)
+	src, err := NewLocalSource(dir)
+	require.NoError(t, err)
+	store := newFakeStore()
+	in, err := NewIngester(src, FormatFOCUS, store)
+	require.NoError(t, err)
+
+	res, err := in.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Result{Listed: 1, Ingested: 1, LineItems: 1, UsedLineItems: 1}, res)
+	pools := store.pools["focus/part-0.csv"]
+	require.Len(t, pools, 1)
+	assert.Equal(t, "n2-standard-4", pools[0].ResourceType)
+	assert.Equal(t, "Compute Engine", pools[0].ProductCode)
+}
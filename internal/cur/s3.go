@@ -0,0 +1,203 @@
+package cur
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body, which every S3Source
+// request (GET) signs.
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// maxListPages caps the ListObjectsV2 continuation loop; at 1000 keys per
+// page that is far beyond any export prefix.
+const maxListPages = 1000
+
+// S3Source reads exports from an S3-compatible bucket with ListObjectsV2
+// and GetObject, signed with SigV4. Requests are path-style
+// (endpoint/bucket/key), which AWS S3 and every common S3-compatible store
+// accept. It talks HTTP directly so no S3 SDK is needed.
+type S3Source struct {
+	location    string
+	bucket      string
+	prefix      string
+	endpoint    *url.URL
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	client      *http.Client
+}
+
+// NewS3Source returns an S3Source for an "s3://bucket/prefix" location.
+func NewS3Source(location string, cfg S3Config) (*S3Source, error) {
+	rest, ok := strings.CutPrefix(location, "s3://")
+	if !ok {
+		return nil, fmt.Errorf("NewS3Source: location %q must start with s3://", location)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("NewS3Source: location %q has no bucket", location)
+	}
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("NewS3Source: credentials must not be nil")
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("NewS3Source: region must not be empty")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("NewS3Source: invalid endpoint %q", endpoint)
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &S3Source{
+		location:    location,
+		bucket:      bucket,
+		prefix:      prefix,
+		endpoint:    u,
+		region:      cfg.Region,
+		credentials: cfg.Credentials,
+		// S3 signs the path as sent rather than double-encoding it.
+		signer: v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+		client: client,
+	}, nil
+}
+
+// Location returns the s3:// location the source was built from.
+func (s *S3Source) Location() string { return s.location }
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// List pages through ListObjectsV2 under the prefix and returns every
+// object.
+func (s *S3Source) List(ctx context.Context) ([]Object, error) {
+	var out []Object
+	token := ""
+	for page := 1; ; page++ {
+		if page > maxListPages {
+			return nil, fmt.Errorf("listing %s: exceeded %d pages", s.location, maxListPages)
+		}
+		q := url.Values{"list-type": {"2"}}
+		if s.prefix != "" {
+			q.Set("prefix", s.prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		body, err := s.get(ctx, "", q)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", s.location, err)
+		}
+		var res listBucketResult
+		err = xml.NewDecoder(body).Decode(&res)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: decoding ListObjectsV2 response: %w", s.location, err)
+		}
+		for _, c := range res.Contents {
+			out = append(out, Object{Key: c.Key, ETag: strings.Trim(c.ETag, `"`), Size: c.Size})
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			break
+		}
+		token = res.NextContinuationToken
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Open issues a GetObject for key.
+func (s *S3Source) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := s.get(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return body, nil
+}
+
+// get signs and sends a GET for key (empty for the bucket itself) and
+// returns the body of a 2xx response. Other statuses are errors carrying
+// the start of S3's XML error document.
+func (s *S3Source) get(ctx context.Context, key string, query url.Values) (io.ReadCloser, error) {
+	u := *s.endpoint
+	rawPath := strings.TrimRight(u.EscapedPath(), "/") + "/" + s3EscapePath(s.bucket)
+	if key != "" {
+		rawPath += "/" + s3EscapePath(key)
+	}
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	u.Path, u.RawPath = path, rawPath
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving credentials: %w", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err := s.signer.SignHTTP(ctx, creds, req, emptyPayloadHash, "s3", s.region, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp.Body, nil
+}
+
+// s3EscapePath percent-encodes every byte of p except unreserved
+// characters and '/', as SigV4's canonical URI requires. CUR 2.0 keys carry
+// '=' (BILLING_PERIOD=2026-03), which url.PathEscape leaves alone.
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
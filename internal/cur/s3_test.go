@@ -0,0 +1,98 @@
+package cur
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticCreds() aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+	})
+}
+
+// fakeS3 serves two ListObjectsV2 pages and one object, path-style.
+func fakeS3(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"), "request must be SigV4-signed")
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+		switch {
+		case r.URL.Path == "/cur-bucket" && r.URL.Query().Get("continuation-token") == "":
+			assert.Equal(t, "exports/", r.URL.Query().Get("prefix"))
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>true</IsTruncated><NextContinuationToken>p2</NextContinuationToken>
+<Contents><Key>exports/BILLING_PERIOD=2026-03/part-1.csv.gz</Key><ETag>"e1"</ETag><Size>10</Size></Contents>
+<Contents><Key>exports/BILLING_PERIOD=2026-03/manifest.json</Key><ETag>"m"</ETag><Size>2</Size></Contents>
+</ListBucketResult>`)
+		case r.URL.Path == "/cur-bucket":
+			assert.Equal(t, "p2", r.URL.Query().Get("continuation-token"))
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>exports/BILLING_PERIOD=2026-03/part-0.csv.gz</Key><ETag>"e0"</ETag><Size>10</Size></Contents>
+</ListBucketResult>`)
+		case r.URL.EscapedPath() == "/cur-bucket/exports/BILLING_PERIOD%3D2026-03/part-0.csv.gz":
+			fmt.Fprint(w, "payload")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+		}
+	}))
+}
+
+func TestS3Source_ListPages(t *testing.T) {
+	srv := fakeS3(t)
+	defer srv.Close()
+	src, err := NewS3Source("s3://cur-bucket/exports/", S3Config{Endpoint: srv.URL, Region: "eu-west-1", Credentials: staticCreds()})
+	require.NoError(t, err)
+
+	objs, err := src.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Object{
+		{Key: "exports/BILLING_PERIOD=2026-03/manifest.json", ETag: "m", Size: 2},
+		{Key: "exports/BILLING_PERIOD=2026-03/part-0.csv.gz", ETag: "e0", Size: 10},
+		{Key: "exports/BILLING_PERIOD=2026-03/part-1.csv.gz", ETag: "e1", Size: 10},
+	}, objs)
+}
+
+func TestS3Source_OpenEscapesKeyAndReportsErrors(t *testing.T) {
+	srv := fakeS3(t)
+	defer srv.Close()
+	src, err := NewS3Source("s3://cur-bucket/exports/", S3Config{Endpoint: srv.URL, Region: "eu-west-1", Credentials: staticCreds()})
+	require.NoError(t, err)
+
+	rc, err := src.Open(context.Background(), "exports/BILLING_PERIOD=2026-03/part-0.csv.gz")
+	require.NoError(t, err)
+	body, err := io.ReadAll(rc)
+	require.NoError(t, rc.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+
+	_, err = src.Open(context.Background(), "exports/missing.csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 404")
+	assert.Contains(t, err.Error(), "NoSuchKey")
+}
+
+func TestNewSource_PicksBackend(t *testing.T) {
+	src, err := NewSource("s3://bucket/prefix", S3Config{Region: "us-east-1", Credentials: staticCreds()})
+	require.NoError(t, err)
+	assert.IsType(t, &S3Source{}, src)
+	assert.Equal(t, "s3://bucket/prefix", src.Location())
+
+	src, err = NewSource(t.TempDir(), S3Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalSource{}, src)
+
+	_, err = NewS3Source("s3://bucket", S3Config{Region: "us-east-1"})
+	assert.ErrorContains(t, err, "credentials must not be nil")
+	_, err = NewS3Source("s3:///prefix", S3Config{Region: "us-east-1", Credentials: staticCreds()})
+	assert.ErrorContains(t, err, "no bucket")
+}
@@ -0,0 +1,138 @@
+// Package cur ingests billing exports into Postgres: AWS Cost and Usage
+// Report exports and FOCUS datasets from any provider. A Source lists and
+// opens export objects (a local directory or an S3-compatible bucket); the
+// Ingester decodes each new or rewritten object with its Format,
+// aggregates it into hourly pool usage and commitment coverage with
+// pkg/cur, and replaces that object's rows in the store. The aggregates
+// are read back through cur.Reader by the Cost Explorer replacements in
+// providers/aws/recommendations.
+package cur
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Object is one export file. ETag changes whenever the object's content
+// does, which is how the Ingester detects rewritten objects.
+type Object struct {
+	Key  string
+	ETag string
+	Size int64
+}
+
+// Source is a location billing exports are delivered to.
+type Source interface {
+	// Location identifies the source in the store ("s3://bucket/prefix" or
+	// an absolute directory), so two sources never share object rows.
+	Location() string
+	// List returns every object under the source, ordered by key. The
+	// Ingester skips the ones its Format cannot decode (manifests, ...).
+	List(ctx context.Context) ([]Object, error)
+	// Open streams one object's raw (possibly gzip-compressed) bytes.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// S3Config carries what an S3Source needs beyond its location. Endpoint
+// defaults to AWS S3 in Region; set it for MinIO, Ceph and other
+// S3-compatible stores.
+type S3Config struct {
+	Endpoint    string
+	Region      string
+	Credentials aws.CredentialsProvider
+	HTTPClient  *http.Client
+}
+
+// NewSource returns an S3Source for an "s3://bucket/prefix" location and a
+// LocalSource for anything else.
+func NewSource(location string, s3 S3Config) (Source, error) {
+	if strings.HasPrefix(location, "s3://") {
+		return NewS3Source(location, s3)
+	}
+	return NewLocalSource(location)
+}
+
+// LocalSource reads exports from a directory tree, e.g. a mounted bucket or
+// a manual download. ETags are derived from size and modification time.
+type LocalSource struct {
+	root string
+}
+
+// NewLocalSource returns a LocalSource rooted at dir, which must exist.
+func NewLocalSource(dir string) (*LocalSource, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("NewLocalSource: dir must not be empty")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("NewLocalSource: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("NewLocalSource: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("NewLocalSource: %s is not a directory", abs)
+	}
+	return &LocalSource{root: abs}, nil
+}
+
+// Location returns the absolute root directory.
+func (s *LocalSource) Location() string { return s.root }
+
+// List walks the root and returns every file, keyed by its slash-separated
+// path relative to the root.
+func (s *LocalSource) List(ctx context.Context) ([]Object, error) {
+	var out []Object
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		out = append(out, Object{
+			Key:  filepath.ToSlash(rel),
+			ETag: fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()),
+			Size: info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", s.root, err)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Open opens the file at key. Keys that escape the root are rejected.
+func (s *LocalSource) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	if !fs.ValidPath(key) {
+		return nil, fmt.Errorf("invalid object key %q", key)
+	}
+	f, err := os.Open(filepath.Join(s.root, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", key, err)
+	}
+	return f, nil
+}
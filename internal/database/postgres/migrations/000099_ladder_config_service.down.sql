@@ -0,0 +1,18 @@
+-- 000099 down: restore the (cloud_account_id, provider) key.
+--
+-- Service-scoped rows cannot be represented under the old key, so they are
+-- deleted first; the compute rows (service = '') survive unchanged.
+
+DELETE FROM ladder_configs WHERE service <> '';
+
+ALTER TABLE ladder_configs
+    DROP CONSTRAINT IF EXISTS ladder_configs_cloud_account_id_provider_service_key;
+
+ALTER TABLE ladder_configs
+    DROP CONSTRAINT IF EXISTS ladder_configs_cloud_account_id_provider_key;
+
+ALTER TABLE ladder_configs
+    ADD CONSTRAINT ladder_configs_cloud_account_id_provider_key
+    UNIQUE (cloud_account_id, provider);
+
+ALTER TABLE ladder_configs DROP COLUMN IF EXISTS service;
@@ -0,0 +1,25 @@
+-- Migration 000099: per-service ladder configs.
+--
+-- A ladder_configs row is now scoped to (cloud_account_id, provider, service).
+-- service = '' is the compute ladder every existing row already describes;
+-- 'rds', 'elasticache', and 'opensearch' are the AWS reserved-capacity
+-- ladders, each with its own ramp and caps. The original
+-- UNIQUE(cloud_account_id, provider) would allow only one of them per
+-- account, so it is replaced by a three-column key.
+--
+-- Idempotent: ADD COLUMN IF NOT EXISTS, DROP CONSTRAINT IF EXISTS for both
+-- the old and the new key, then ADD the new key, so a re-run after a partial
+-- failure converges on the same schema.
+
+ALTER TABLE ladder_configs
+    ADD COLUMN IF NOT EXISTS service TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE ladder_configs
+    DROP CONSTRAINT IF EXISTS ladder_configs_cloud_account_id_provider_key;
+
+ALTER TABLE ladder_configs
+    DROP CONSTRAINT IF EXISTS ladder_configs_cloud_account_id_provider_service_key;
+
+ALTER TABLE ladder_configs
+    ADD CONSTRAINT ladder_configs_cloud_account_id_provider_service_key
+    UNIQUE (cloud_account_id, provider, service);
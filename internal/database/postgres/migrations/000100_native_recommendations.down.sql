@@ -0,0 +1,5 @@
+ALTER TABLE global_config
+    DROP COLUMN IF EXISTS native_rec_max_break_even_months,
+    DROP COLUMN IF EXISTS native_rec_percentile,
+    DROP COLUMN IF EXISTS native_rec_lookback_days,
+    DROP COLUMN IF EXISTS recommendation_source;
@@ -0,0 +1,18 @@
+-- Migration 000100: native recommendation engine settings.
+--
+-- recommendation_source selects provider-API recommendations ('vendor'),
+-- recommendations computed by pkg/recommender ('native'), or both. The
+-- native_rec_* columns tune the engine: usage lookback in days, the
+-- uncovered-demand percentile commitments are sized to, and the maximum
+-- break-even in months (0 = no cap). Defaults keep existing deployments on
+-- vendor recommendations.
+
+ALTER TABLE global_config
+    ADD COLUMN IF NOT EXISTS recommendation_source TEXT NOT NULL DEFAULT 'vendor'
+        CHECK (recommendation_source IN ('vendor', 'native', 'both')),
+    ADD COLUMN IF NOT EXISTS native_rec_lookback_days INTEGER NOT NULL DEFAULT 30
+        CHECK (native_rec_lookback_days BETWEEN 1 AND 365),
+    ADD COLUMN IF NOT EXISTS native_rec_percentile DOUBLE PRECISION NOT NULL DEFAULT 10
+        CHECK (native_rec_percentile > 0 AND native_rec_percentile <= 100),
+    ADD COLUMN IF NOT EXISTS native_rec_max_break_even_months DOUBLE PRECISION NOT NULL DEFAULT 0
+        CHECK (native_rec_max_break_even_months >= 0 AND native_rec_max_break_even_months <= 120);
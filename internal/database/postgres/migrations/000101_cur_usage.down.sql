@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cur_commitment_coverage_hourly;
+DROP TABLE IF EXISTS cur_pool_usage_hourly;
+DROP TABLE IF EXISTS cur_ingested_objects;
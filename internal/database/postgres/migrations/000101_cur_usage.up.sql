@@ -0,0 +1,69 @@
+-- Migration 000101: CUR ingestion tables.
+--
+-- cur_ingested_objects records every Cost and Usage Report export object
+-- (keyed by the configured source location and the object key) with the
+-- ETag it was ingested at, so the ingestion job skips unchanged objects and
+-- re-ingests rewritten ones. The two aggregate tables hold what each object
+-- contributed: hourly usage per commitment pool and hourly coverage per
+-- commitment. Rows cascade from their object, so replacing or pruning an
+-- object replaces or removes its contribution; readers sum across objects.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS cur_ingested_objects (
+    source          TEXT        NOT NULL,
+    object_key      TEXT        NOT NULL,
+    etag            TEXT        NOT NULL,
+    ingested_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (source, object_key)
+);
+
+CREATE TABLE IF NOT EXISTS cur_pool_usage_hourly (
+    source              TEXT             NOT NULL,
+    object_key          TEXT             NOT NULL,
+    account_id          TEXT             NOT NULL,
+    product_code        TEXT             NOT NULL,
+    region              TEXT             NOT NULL,
+    resource_type       TEXT             NOT NULL,
+    platform            TEXT             NOT NULL,
+    deployment_option   TEXT             NOT NULL,
+    hour                TIMESTAMPTZ      NOT NULL,
+    usage_hours         DOUBLE PRECISION NOT NULL,
+    on_demand_hours     DOUBLE PRECISION NOT NULL,
+    reserved_hours      DOUBLE PRECISION NOT NULL,
+    savings_plan_hours  DOUBLE PRECISION NOT NULL,
+    on_demand_cost      DOUBLE PRECISION NOT NULL,
+
+    PRIMARY KEY (source, object_key, hour, account_id, product_code, region,
+                 resource_type, platform, deployment_option),
+    FOREIGN KEY (source, object_key)
+        REFERENCES cur_ingested_objects(source, object_key) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cur_pool_usage_hourly_lookup
+    ON cur_pool_usage_hourly(product_code, region, hour);
+
+CREATE TABLE IF NOT EXISTS cur_commitment_coverage_hourly (
+    source              TEXT             NOT NULL,
+    object_key          TEXT             NOT NULL,
+    account_id          TEXT             NOT NULL,
+    commitment_arn      TEXT             NOT NULL,
+    commitment_type     TEXT             NOT NULL
+                                         CHECK (commitment_type IN ('reservation', 'savings_plan')),
+    product_code        TEXT             NOT NULL,
+    region              TEXT             NOT NULL,
+    resource_type       TEXT             NOT NULL,
+    hour                TIMESTAMPTZ      NOT NULL,
+    covered_hours       DOUBLE PRECISION NOT NULL,
+    unused_hours        DOUBLE PRECISION NOT NULL,
+    effective_cost      DOUBLE PRECISION NOT NULL,
+
+    PRIMARY KEY (source, object_key, hour, account_id, commitment_arn,
+                 product_code, region, resource_type),
+    FOREIGN KEY (source, object_key)
+        REFERENCES cur_ingested_objects(source, object_key) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cur_commitment_coverage_hourly_lookup
+    ON cur_commitment_coverage_hourly(product_code, region, hour);
@@ -0,0 +1,2 @@
+ALTER TABLE global_config
+    DROP COLUMN IF EXISTS finance_discount_rate_pct;
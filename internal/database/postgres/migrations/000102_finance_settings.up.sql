@@ -0,0 +1,10 @@
+-- Migration 000102: recommendation financials settings.
+--
+-- finance_discount_rate_pct is the annual cost of capital (percent) the
+-- recommendation NPV, IRR and discounted payback figures discount cash
+-- flows at. NULL means the built-in default (pkg/finance), so existing
+-- deployments need no backfill; 0 is a valid rate.
+
+ALTER TABLE global_config
+    ADD COLUMN IF NOT EXISTS finance_discount_rate_pct DOUBLE PRECISION
+        CHECK (finance_discount_rate_pct >= 0 AND finance_discount_rate_pct <= 100);
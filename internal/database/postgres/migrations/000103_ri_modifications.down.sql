@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ri_modification_history;
@@ -0,0 +1,42 @@
+-- Migration 000103: EC2 Reserved Instance modification history.
+--
+-- ri_modification_history records every ModifyReservedInstances request
+-- submitted through /api/ri-exchange/modifications: the source RIs and
+-- their configurations as they were at submission, the target
+-- configurations, and the approval lifecycle. Rows are created pending and
+-- only reach AWS once approved, so sources/targets are the audit record of
+-- what was asked for and modification_id links the row to AWS's
+-- ReservedInstancesModificationId once submitted.
+--
+-- cloud_account_id is the registered cloud account the running deployment
+-- resolves to ('' when unattributed), matching ri_exchange_history's
+-- account scoping. The actor columns mirror ri_exchange_history
+-- (migrations 000054 and 000077).
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS ri_modification_history (
+    id                  UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    cloud_account_id    TEXT         NOT NULL DEFAULT '',
+    region              VARCHAR(50)  NOT NULL,
+    source_ri_ids       TEXT[]       NOT NULL,
+    sources             JSONB        NOT NULL,
+    targets             JSONB        NOT NULL,
+    modification_id     VARCHAR(100) NOT NULL DEFAULT '',
+    status              VARCHAR(20)  NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'canceled')),
+    reason              TEXT         NOT NULL DEFAULT '',
+    error               TEXT,
+    created_by_user_id  UUID         REFERENCES users(id) ON DELETE SET NULL,
+    approved_by         TEXT,
+    transitioned_by     UUID         REFERENCES users(id) ON DELETE SET NULL,
+    transitioned_at     TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_ri_modification_history_created
+    ON ri_modification_history(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ri_modification_history_status
+    ON ri_modification_history(status);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS azure_refund_ledger;
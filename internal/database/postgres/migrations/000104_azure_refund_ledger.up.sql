@@ -0,0 +1,27 @@
+-- Migration 000104: Azure reservation refund ledger.
+--
+-- Azure caps reservation refunds at a rolling 12-month amount per billing
+-- profile (or EA enrollment). azure_refund_ledger records every refund
+-- CUDly issued through /api/purchases/{id}/revoke so the revoke handler can
+-- check a new return against the limit even when CalculateRefund does not
+-- report the consumed total.
+--
+-- billing_profile is the key the limit is tracked under: the Azure tenant
+-- of the purchase's registered cloud account, falling back to the
+-- purchase's account (subscription) ID for unregistered purchases.
+-- purchase_id is unique so a retried ledger write is a no-op.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS azure_refund_ledger (
+    id              UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    billing_profile TEXT           NOT NULL,
+    purchase_id     TEXT           NOT NULL UNIQUE,
+    amount          NUMERIC(14, 4) NOT NULL CHECK (amount >= 0),
+    currency        VARCHAR(3)     NOT NULL DEFAULT '',
+    refunded_at     TIMESTAMPTZ    NOT NULL,
+    created_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_azure_refund_ledger_profile_refunded
+    ON azure_refund_ledger(billing_profile, refunded_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE ri_exchange_history
+    DROP CONSTRAINT IF EXISTS ri_exchange_history_provider_check;
+ALTER TABLE ri_exchange_history
+    DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,27 @@
+-- Migration 000105: record which cloud an RI exchange belongs to.
+--
+-- ri_exchange_history was AWS-only: every row's target_offering_id is an EC2
+-- Convertible RI offering ID and the approval path executes it through the
+-- EC2 exchange API. The ri_exchange_reshape task now also reshapes Azure VM
+-- reservations, whose rows carry an Azure subscription ID in account_id, the
+-- target VM SKU in target_instance_type, the target location in region and
+-- no offering ID. provider tells the approval path which API to execute a
+-- pending row against.
+--
+-- Existing rows are all AWS, so the column defaults to 'aws'.
+--
+-- Idempotent: ADD COLUMN IF NOT EXISTS and a guarded constraint.
+
+ALTER TABLE ri_exchange_history
+    ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'aws';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'ri_exchange_history_provider_check'
+    ) THEN
+        ALTER TABLE ri_exchange_history
+            ADD CONSTRAINT ri_exchange_history_provider_check
+            CHECK (provider IN ('aws', 'azure'));
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+-- Revert migration 000106
+ALTER TABLE purchase_history
+    DROP COLUMN IF EXISTS term_end;
@@ -0,0 +1,15 @@
+-- Migration 000106: record the real end of commitments bought with a
+-- partial term.
+--
+-- Expiry tracking derives a commitment's end as timestamp + term years. That
+-- holds for every commitment bought from a provider, but not for a Reserved
+-- Instance bought on the AWS Reserved Instance Marketplace: it runs for
+-- whatever term the seller had left, often a few months. term_end stores that
+-- end explicitly; consumers use it when set and fall back to
+-- timestamp + term otherwise.
+--
+-- Nullable and added with IF NOT EXISTS so existing rows keep the derived end
+-- and the migration is idempotent on re-apply.
+
+ALTER TABLE purchase_history
+    ADD COLUMN IF NOT EXISTS term_end TIMESTAMPTZ;
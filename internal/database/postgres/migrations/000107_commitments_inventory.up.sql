@@ -0,0 +1,53 @@
+-- Migration 000107: provider-side commitment inventory.
+--
+-- The inventory and dashboard handlers were built from purchase_history, so
+-- RIs, Savings Plans and CUDs bought by hand in the cloud consoles never
+-- showed up. The inventory_sync task lists every enabled account's existing
+-- commitments through ServiceClient.GetExistingCommitments and upserts them
+-- here, one row per (provider, account_id, commitment_id).
+--
+-- source records whether CUDly bought the commitment ('cudly': its ID
+-- matches a purchase_history.purchase_id) or it was bought elsewhere
+-- ('external'). The read path merges only the external rows into the
+-- purchase_history view, so CUDly purchases keep their richer cost and
+-- savings data and are never counted twice.
+--
+-- sync_scope is the "<service>/<region>" listing the row was last seen in.
+-- A sync only evicts rows it did not see whose scope listed successfully,
+-- so a region that failed to list (or a service not offered there) never
+-- drops that region's commitments.
+--
+-- hourly_cost is the provider-reported amortized hourly cost of the whole
+-- commitment; 0 when the provider does not report pricing. end_date is NULL
+-- when the provider returned no end date.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS commitments_inventory (
+    id               UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider         TEXT        NOT NULL,
+    account_id       TEXT        NOT NULL,
+    cloud_account_id UUID        REFERENCES cloud_accounts(id) ON DELETE CASCADE,
+    commitment_id    TEXT        NOT NULL,
+    commitment_type  TEXT        NOT NULL DEFAULT '',
+    service          TEXT        NOT NULL DEFAULT '',
+    region           TEXT        NOT NULL DEFAULT '',
+    resource_type    TEXT        NOT NULL DEFAULT '',
+    engine           TEXT        NOT NULL DEFAULT '',
+    count            INTEGER     NOT NULL DEFAULT 0,
+    start_date       TIMESTAMPTZ,
+    end_date         TIMESTAMPTZ,
+    state            TEXT        NOT NULL DEFAULT '',
+    hourly_cost      NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    source           TEXT        NOT NULL DEFAULT 'external'
+                                 CHECK (source IN ('cudly', 'external')),
+    sync_scope       TEXT        NOT NULL DEFAULT '',
+    synced_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, account_id, commitment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commitments_inventory_cloud_account
+    ON commitments_inventory(cloud_account_id, synced_at);
+
+CREATE INDEX IF NOT EXISTS idx_commitments_inventory_end_date
+    ON commitments_inventory(end_date);
@@ -0,0 +1,58 @@
+-- Migration 000108: commitment renewal lineage.
+--
+-- The renewal_plan task walks commitments_inventory for commitments expiring
+-- within the lookahead window and proposes a replacement for each:
+-- like-for-like, resized to measured utilization, converted to a Compute
+-- Savings Plan, or let expire. A proposal that buys something is drafted as a
+-- pending, plan-less purchase_executions row scheduled at the expiry and sent
+-- for approval; this table records one row per expiring commitment linking
+-- the old commitment to that execution and, once it completes, to the
+-- commitment it bought.
+--
+-- The natural key is (provider, account_id, source_commitment_id,
+-- source_end_date): a commitment is planned for once per term, and a later
+-- run skips it. source_end_date is part of the key so an extended
+-- commitment (same ID, new end date) is planned for again.
+--
+-- status moves proposed -> renewed (the execution completed;
+-- replacement_commitment_id is set) or proposed -> declined (the execution
+-- was canceled, failed or expired). let_expire rows are final on insert.
+--
+-- execution_id references purchase_executions(execution_id), the business
+-- key, like ladder_tranches (migration 000081). ON DELETE SET NULL keeps the
+-- lineage if the execution is cleaned up.
+--
+-- proposal is the planner's full proposal (source commitment, replacement,
+-- reason) as JSON, kept for audit and display.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS commitment_renewals (
+    id                        UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider                  TEXT        NOT NULL,
+    account_id                TEXT        NOT NULL,
+    cloud_account_id          UUID        REFERENCES cloud_accounts(id) ON DELETE SET NULL,
+    source_commitment_id      TEXT        NOT NULL,
+    source_end_date           TIMESTAMPTZ NOT NULL,
+    action                    TEXT        NOT NULL
+                                          CHECK (action IN ('like_for_like', 'resize', 'convert_to_savings_plan', 'let_expire')),
+    utilization_pct           NUMERIC(6, 2),
+    reason                    TEXT        NOT NULL DEFAULT '',
+    proposal                  JSONB       NOT NULL DEFAULT '{}',
+    execution_id              UUID        REFERENCES purchase_executions(execution_id) ON DELETE SET NULL,
+    replacement_commitment_id TEXT,
+    status                    TEXT        NOT NULL
+                                          CHECK (status IN ('proposed', 'renewed', 'declined', 'let_expire')),
+    created_at                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, account_id, source_commitment_id, source_end_date)
+);
+
+-- Partial index for the reconcile pass, which only reads open proposals.
+CREATE INDEX IF NOT EXISTS idx_commitment_renewals_proposed
+    ON commitment_renewals(source_end_date)
+    WHERE status = 'proposed';
+
+CREATE INDEX IF NOT EXISTS idx_commitment_renewals_execution
+    ON commitment_renewals(execution_id)
+    WHERE execution_id IS NOT NULL;
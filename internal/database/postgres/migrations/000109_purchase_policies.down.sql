@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS purchase_execution_approvals;
+DROP TABLE IF EXISTS purchase_policy_evaluations;
+DROP TABLE IF EXISTS purchase_policies;
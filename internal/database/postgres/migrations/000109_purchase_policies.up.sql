@@ -0,0 +1,53 @@
+-- Migration 000109: policy-as-code gate for purchases.
+--
+-- purchase_policies holds the admin-authored CEL rules (pkg/policy) as
+-- append-only versions. Saving a policy inserts version MAX+1; the highest
+-- version is in force, and a version with an empty rules array turns the
+-- gate off. Two concurrent saves race on the primary key and one fails,
+-- so an edit never silently overwrites another.
+--
+-- purchase_policy_evaluations records every evaluation of the policy in
+-- force against an execution, at approval and again just before the cloud
+-- is called, so History can show which rules fired and under which
+-- version. execution_id references purchase_executions(execution_id), the
+-- business key, like commitment_renewals (migration 000108); evaluations
+-- go with the execution when it is deleted.
+--
+-- purchase_execution_approvals collects approver signatures while a
+-- require_approvals rule holds an execution for more approvers. approver
+-- is the user UUID when the approver signed in, otherwise the lower-cased
+-- email, so the same person cannot sign twice under two spellings.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS purchase_policies (
+    version    INTEGER     PRIMARY KEY CHECK (version > 0),
+    rules      JSONB       NOT NULL DEFAULT '[]',
+    comment    TEXT        NOT NULL DEFAULT '',
+    created_by TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_policy_evaluations (
+    id                 UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    execution_id       UUID        NOT NULL REFERENCES purchase_executions(execution_id) ON DELETE CASCADE,
+    policy_version     INTEGER     NOT NULL REFERENCES purchase_policies(version),
+    stage              TEXT        NOT NULL CHECK (stage IN ('approval', 'execution')),
+    outcome            TEXT        NOT NULL CHECK (outcome IN ('allow', 'warn', 'require_approvals', 'deny')),
+    required_approvals INTEGER     NOT NULL DEFAULT 0,
+    decisions          JSONB       NOT NULL DEFAULT '[]',
+    actor              TEXT        NOT NULL DEFAULT '',
+    evaluated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_policy_evaluations_execution
+    ON purchase_policy_evaluations(execution_id, evaluated_at);
+
+CREATE TABLE IF NOT EXISTS purchase_execution_approvals (
+    execution_id     UUID        NOT NULL REFERENCES purchase_executions(execution_id) ON DELETE CASCADE,
+    approver         TEXT        NOT NULL,
+    approver_email   TEXT        NOT NULL DEFAULT '',
+    approver_user_id UUID        REFERENCES users(id) ON DELETE SET NULL,
+    approved_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (execution_id, approver)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS purchase_approval_stage_decisions;
+DROP TABLE IF EXISTS purchase_approval_chain_runs;
+DROP TABLE IF EXISTS approval_chains;
+DROP TABLE IF EXISTS account_groups;
@@ -0,0 +1,74 @@
+-- Migration 000110: tiered approval chains.
+--
+-- account_groups names sets of cloud accounts ("production", "sandboxes")
+-- so configuration can be keyed by a group instead of by listing accounts.
+-- account_ids is a plain UUID array rather than a join table: groups are
+-- small, edited as a whole from the Settings page, and read whole. An
+-- account deleted from cloud_accounts simply stops matching.
+--
+-- approval_chains holds the admin-configured sign-off chains. A chain
+-- applies to an execution when it is enabled, its provider (empty = any)
+-- matches every selected recommendation and every account the execution
+-- targets is in its account group (NULL = any). The first matching chain
+-- by priority, then name, wins. stages is an ordered JSON array of
+-- {name, approvers, quorum, min_amount}; a stage is required when the
+-- execution's amount, its upfront cost or its total commitment value per
+-- amount_basis, is at least min_amount.
+--
+-- purchase_approval_chain_runs records the chain an execution is going
+-- through: a snapshot of the required stages taken when the chain starts,
+-- so editing a chain never changes an approval already in flight, and the
+-- index of the stage awaiting sign-off. current_stage equal to the number
+-- of stages means every stage has signed. Advancing is a compare-and-set on
+-- current_stage so two approvers meeting quorum at once advance it once.
+--
+-- purchase_approval_stage_decisions holds each approver's sign-off on a
+-- stage, keyed by lower-cased email: stage approvers are configured by
+-- email, and the same person cannot sign a stage twice.
+--
+-- execution_id references purchase_executions(execution_id), the business
+-- key, like purchase_policy_evaluations (migration 000109).
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS account_groups (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        TEXT        NOT NULL UNIQUE,
+    description TEXT        NOT NULL DEFAULT '',
+    account_ids UUID[]      NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS approval_chains (
+    id               UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    name             TEXT        NOT NULL UNIQUE,
+    priority         INTEGER     NOT NULL DEFAULT 0,
+    enabled          BOOLEAN     NOT NULL DEFAULT TRUE,
+    provider         TEXT        NOT NULL DEFAULT '',
+    account_group_id UUID        REFERENCES account_groups(id) ON DELETE RESTRICT,
+    amount_basis     TEXT        NOT NULL DEFAULT 'upfront' CHECK (amount_basis IN ('upfront', 'total_commitment')),
+    stages           JSONB       NOT NULL DEFAULT '[]',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_approval_chain_runs (
+    execution_id  UUID             PRIMARY KEY REFERENCES purchase_executions(execution_id) ON DELETE CASCADE,
+    chain_id      UUID             REFERENCES approval_chains(id) ON DELETE SET NULL,
+    chain_name    TEXT             NOT NULL,
+    amount        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stages        JSONB            NOT NULL DEFAULT '[]',
+    current_stage INTEGER          NOT NULL DEFAULT 0 CHECK (current_stage >= 0),
+    started_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    completed_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS purchase_approval_stage_decisions (
+    execution_id     UUID        NOT NULL REFERENCES purchase_approval_chain_runs(execution_id) ON DELETE CASCADE,
+    stage_index      INTEGER     NOT NULL,
+    approver_email   TEXT        NOT NULL,
+    approver_user_id UUID        REFERENCES users(id) ON DELETE SET NULL,
+    decided_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (execution_id, stage_index, approver_email)
+);
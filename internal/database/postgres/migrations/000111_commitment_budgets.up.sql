@@ -0,0 +1,58 @@
+-- Migration 000111: organisation-wide commitment budget.
+--
+-- commitment_budgets holds the admin-configured caps on how much CUDly may
+-- commit per calendar month or quarter: max_upfront_usd caps the upfront
+-- payments, max_hourly_usd the new hourly commitment (a Savings Plan's
+-- hourly commitment, or the upfront payment spread over the term plus the
+-- recurring monthly charge for everything else). NULL leaves that limit
+-- uncapped. account_group_id scopes a budget to purchases landing in the
+-- group's accounts (migration 000110); NULL applies it to every purchase.
+--
+-- budget_reservations is the ledger the caps are checked against. Every
+-- purchase path (the purchase manager, ladder layer purchases and the MCP
+-- tools) reserves its amounts here before the cloud is called, inside a
+-- transaction holding an advisory lock, so two purchases firing at once
+-- cannot both fit under the same remaining budget. A reservation is
+-- committed at the amounts actually bought or released when the purchase
+-- fails; a period's usage is every reservation reserved in it that has not
+-- been released. (source, reference) is unique, so a retried purchase
+-- reuses its reservation instead of counting twice.
+--
+-- A purchase revoked or returned after it committed is credited back with
+-- a source = 'revocation' row carrying negative amounts and the purchase's
+-- own timestamp, so the credit lands in the period the purchase counted
+-- against. account_ids are CUDly cloud account UUIDs; a reservation with
+-- none (ambient credentials) only counts against unscoped budgets.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS commitment_budgets (
+    id               UUID             PRIMARY KEY DEFAULT gen_random_uuid(),
+    name             TEXT             NOT NULL UNIQUE,
+    period           TEXT             NOT NULL CHECK (period IN ('month', 'quarter')),
+    account_group_id UUID             REFERENCES account_groups(id) ON DELETE RESTRICT,
+    max_upfront_usd  DOUBLE PRECISION CHECK (max_upfront_usd >= 0),
+    max_hourly_usd   DOUBLE PRECISION CHECK (max_hourly_usd >= 0),
+    enabled          BOOLEAN          NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS budget_reservations (
+    id             UUID             PRIMARY KEY DEFAULT gen_random_uuid(),
+    source         TEXT             NOT NULL,
+    reference      TEXT             NOT NULL,
+    provider       TEXT             NOT NULL DEFAULT '',
+    account_ids    UUID[]           NOT NULL DEFAULT '{}',
+    upfront_usd    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    hourly_usd     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    status         TEXT             NOT NULL DEFAULT 'reserved' CHECK (status IN ('reserved', 'committed', 'released')),
+    reserved_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    settled_at     TIMESTAMPTZ,
+    release_reason TEXT             NOT NULL DEFAULT '',
+    UNIQUE (source, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_reservations_reserved_at
+    ON budget_reservations (reserved_at)
+    WHERE status <> 'released';
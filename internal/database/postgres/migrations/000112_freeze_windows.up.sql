@@ -0,0 +1,46 @@
+-- Migration 000112: change-freeze (blackout) calendars.
+--
+-- freeze_windows holds the admin-configured windows, such as a fiscal
+-- year-end close, a reorganisation or a migration, during which CUDly must
+-- not buy commitments. [starts_at, ends_at) bounds the first occurrence;
+-- recurrence repeats it weekly, monthly or yearly. provider and account_ids
+-- (CUDly cloud account UUIDs) scope a window; empty applies it to every
+-- purchase. action says what happens to a scheduled purchase or ladder
+-- tranche coming due inside the window: 'defer' holds it until no window
+-- is in force, 'skip' cancels it.
+--
+-- freeze_overrides is the audit trail of administrators letting a single
+-- execution be purchased during a freeze. window_name is copied so the
+-- trail stays readable after the window is deleted.
+--
+-- Idempotent: CREATE ... IF NOT EXISTS throughout.
+
+CREATE TABLE IF NOT EXISTS freeze_windows (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        TEXT        NOT NULL UNIQUE,
+    reason      TEXT        NOT NULL DEFAULT '',
+    starts_at   TIMESTAMPTZ NOT NULL,
+    ends_at     TIMESTAMPTZ NOT NULL,
+    recurrence  TEXT        NOT NULL DEFAULT 'none' CHECK (recurrence IN ('none', 'weekly', 'monthly', 'yearly')),
+    provider    TEXT        NOT NULL DEFAULT '',
+    account_ids UUID[]      NOT NULL DEFAULT '{}',
+    action      TEXT        NOT NULL DEFAULT 'defer' CHECK (action IN ('defer', 'skip')),
+    enabled     BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS freeze_overrides (
+    id            UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    execution_id  UUID        NOT NULL REFERENCES purchase_executions(execution_id) ON DELETE CASCADE,
+    window_id     UUID        REFERENCES freeze_windows(id) ON DELETE SET NULL,
+    window_name   TEXT        NOT NULL,
+    actor         TEXT        NOT NULL,
+    actor_user_id UUID        REFERENCES users(id) ON DELETE SET NULL,
+    reason        TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_freeze_overrides_execution
+    ON freeze_overrides (execution_id, created_at DESC);
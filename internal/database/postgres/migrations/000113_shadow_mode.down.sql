@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shadow_purchases;
+ALTER TABLE purchase_plans DROP COLUMN IF EXISTS shadow_mode;
@@ -0,0 +1,70 @@
+-- Migration 000113: shadow mode.
+--
+-- A purchase plan with shadow_mode = TRUE, or a ladder config with
+-- mode = 'shadow', runs its selection and sizing as usual but never calls
+-- the cloud: each purchase it would have made is recorded in
+-- shadow_purchases instead. The shadow_evaluate task later replays every
+-- row against the on-demand usage that followed and stores the savings or
+-- waste it would have produced, so a configuration can be judged on
+-- evidence before it is allowed to spend. ladder_configs.mode has no CHECK
+-- constraint, so the new mode needs no DDL there.
+--
+-- commit_usd_per_hour is the on-demand spend per hour the commitment would
+-- cover at full utilization, in the unit of the usage series;
+-- cost_usd_per_hour is what it would cost per hour, upfront payment
+-- amortised over the term plus any recurring charge. Evaluation is
+-- incremental: evaluated_through is the end of the last UTC day scored, and
+-- each run scores only the days after it and adds them to the evaluated_*
+-- and *_usd totals, so the evidence keeps growing until the term ends even
+-- though the usage source only reaches back a year. The totals are NULL
+-- until the first evaluation. evaluation_error records why the latest run
+-- could not score a row (no usage source for its service, a usage read
+-- failure) and is cleared by the next successful one.
+--
+-- The source references are SET NULL on delete so the evidence outlives
+-- the plan, execution or ladder config that produced it.
+--
+-- Idempotent: ADD COLUMN IF NOT EXISTS and CREATE ... IF NOT EXISTS.
+
+ALTER TABLE purchase_plans
+    ADD COLUMN IF NOT EXISTS shadow_mode BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS shadow_purchases (
+    id                  UUID             PRIMARY KEY DEFAULT gen_random_uuid(),
+    source              TEXT             NOT NULL CHECK (source IN ('plan', 'ladder')),
+    plan_id             UUID             REFERENCES purchase_plans(id) ON DELETE SET NULL,
+    execution_id        UUID             REFERENCES purchase_executions(execution_id) ON DELETE SET NULL,
+    ladder_config_id    UUID             REFERENCES ladder_configs(id) ON DELETE SET NULL,
+    ladder_run_id       UUID             REFERENCES ladder_runs(id) ON DELETE SET NULL,
+    cloud_account_id    UUID             REFERENCES cloud_accounts(id) ON DELETE SET NULL,
+    provider            TEXT             NOT NULL,
+    service             TEXT             NOT NULL DEFAULT '',
+    region              TEXT             NOT NULL DEFAULT '',
+    resource_type       TEXT             NOT NULL DEFAULT '',
+    count               INTEGER          NOT NULL DEFAULT 0,
+    term_years          INTEGER          NOT NULL CHECK (term_years > 0),
+    payment             TEXT             NOT NULL DEFAULT '',
+    upfront_cost        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    commit_usd_per_hour DOUBLE PRECISION NOT NULL CHECK (commit_usd_per_hour > 0),
+    cost_usd_per_hour   DOUBLE PRECISION NOT NULL CHECK (cost_usd_per_hour >= 0),
+    estimated_savings   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    purchase_at         TIMESTAMPTZ      NOT NULL,
+    evaluated_at        TIMESTAMPTZ,
+    evaluated_through   TIMESTAMPTZ,
+    evaluated_days      INTEGER          NOT NULL DEFAULT 0,
+    covered_usd         DOUBLE PRECISION,
+    committed_usd       DOUBLE PRECISION,
+    cost_usd            DOUBLE PRECISION,
+    savings_usd         DOUBLE PRECISION,
+    waste_usd           DOUBLE PRECISION,
+    utilization_pct     DOUBLE PRECISION,
+    evaluation_error    TEXT             NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shadow_purchases_plan
+    ON shadow_purchases (plan_id, purchase_at);
+CREATE INDEX IF NOT EXISTS idx_shadow_purchases_ladder_config
+    ON shadow_purchases (ladder_config_id, purchase_at);
+CREATE INDEX IF NOT EXISTS idx_shadow_purchases_execution
+    ON shadow_purchases (execution_id);
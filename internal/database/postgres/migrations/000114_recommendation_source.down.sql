@@ -0,0 +1,2 @@
+ALTER TABLE recommendations
+    DROP COLUMN IF EXISTS source;
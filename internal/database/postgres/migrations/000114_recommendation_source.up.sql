@@ -0,0 +1,12 @@
+-- Migration 000114: recommendation source.
+--
+-- source records which engine produced a recommendation row: the provider
+-- recommendation API ('vendor') or pkg/recommender ('native'). With
+-- global_config.recommendation_source = 'both' the two are merged into one
+-- table, and the column is what lets the API filter them apart so native
+-- output can be audited against vendor output. Rows collected before this
+-- migration all came from the vendor APIs.
+
+ALTER TABLE recommendations
+    ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'vendor'
+        CHECK (source IN ('vendor', 'native'));
@@ -0,0 +1 @@
+ALTER TABLE purchase_plans DROP COLUMN IF EXISTS allow_double_coverage;
@@ -0,0 +1,12 @@
+-- Migration 000115: per-plan double-coverage override.
+--
+-- Saving a plan with auto-purchase, scheduling its purchases and running
+-- its executions are refused while the plan would buy one side of an
+-- RI / Savings Plan overlap whose other side it, or another enabled plan
+-- on the same account, also buys. allow_double_coverage = TRUE lifts that
+-- refusal for the plan.
+--
+-- Idempotent: ADD COLUMN IF NOT EXISTS.
+
+ALTER TABLE purchase_plans
+    ADD COLUMN IF NOT EXISTS allow_double_coverage BOOLEAN NOT NULL DEFAULT FALSE;
@@ -275,6 +275,32 @@ func TestRenderPurchaseApprovalRequestEmail_NoAuthorizedApprovers(t *testing.T)
 	assert.NotContains(t, body, "Only the inbox(es)")
 }
 
+// TestPurchaseApprovalRequest_ApprovalStage pins the approval-chain stage
+// in the subject and both bodies, and its absence outside a chain.
+func TestPurchaseApprovalRequest_ApprovalStage(t *testing.T) {
+	data := NotificationData{
+		DashboardURL:    "https://dashboard.example.com",
+		ApprovalToken:   "tok",
+		ExecutionID:     "exec-xyz",
+		Recommendations: []RecommendationSummary{{Service: "ec2", Count: 1}},
+		ApprovalStage:   "FinOps (stage 2 of 3)",
+	}
+	assert.Equal(t, "CUDly - Purchase Approval Required (1 commitment(s)) - FinOps (stage 2 of 3)", purchaseApprovalSubject(data))
+
+	body, err := RenderPurchaseApprovalRequestEmail(data)
+	require.NoError(t, err)
+	assert.Contains(t, body, "Approval stage: FinOps (stage 2 of 3)")
+	html, err := RenderPurchaseApprovalRequestEmailHTML(data)
+	require.NoError(t, err)
+	assert.Contains(t, html, "FinOps (stage 2 of 3)")
+
+	data.ApprovalStage = ""
+	assert.Equal(t, "CUDly - Purchase Approval Required (1 commitment(s))", purchaseApprovalSubject(data))
+	body, err = RenderPurchaseApprovalRequestEmail(data)
+	require.NoError(t, err)
+	assert.NotContains(t, body, "Approval stage")
+}
+
 // TestRenderRegistrationReceivedEmail_AdminApprovers pins the new
 // "authorized reviewer(s)" block on the registration notification
 // template: when AdminApprovers is populated the body lists each admin
@@ -593,6 +593,10 @@ type NotificationData struct {
 	// the purchase). Used in the post-execution notification body.
 	// Empty omits the field.
 	ExecutedBy string
+	// ApprovalStage names the approval-chain stage an approval request is
+	// for, e.g. "FinOps (stage 2 of 3)". Rendered in the approval request
+	// subject and body; empty for executions outside an approval chain.
+	ApprovalStage string
 }
 
 // RecommendationSummary is a simplified recommendation for email display.
@@ -542,7 +542,7 @@ func (s *SMTPSender) SendPurchaseApprovalRequest(ctx context.Context, data Notif
 		return nil
 	}
 
-	subject := fmt.Sprintf("CUDly - Purchase Approval Required (%d commitment(s))", len(data.Recommendations))
+	subject := purchaseApprovalSubject(data)
 
 	textBody, err := RenderPurchaseApprovalRequestEmail(data)
 	if err != nil {
@@ -585,7 +585,10 @@ const purchaseApprovalRequestTemplate = `CUDly - Purchase Approval Required
 ====================================
 
 A direct purchase of {{len .Recommendations}} commitment(s) has been submitted and requires approval.
-{{if .AuthorizedApprovers}}
+{{if .ApprovalStage}}
+Approval stage: {{.ApprovalStage}}
+Stages sign off in order; the purchase moves on once this stage does.
+{{end}}{{if .AuthorizedApprovers}}
 Authorized approver(s):
 {{range .AuthorizedApprovers}}  - {{.}}
 {{end}}
@@ -677,7 +680,8 @@ const purchaseApprovalRequestHTMLTemplate = `<!DOCTYPE html>
 <tr><td style="padding:32px 32px 16px 32px;">
 <h1 style="margin:0;font-size:22px;color:#0f172a;">Purchase Approval Required</h1>
 <p style="margin:8px 0 0 0;color:#475569;font-size:14px;">A direct purchase of <strong>{{len .Recommendations}}</strong> commitment(s) has been submitted and requires approval.</p>
-</td></tr>
+{{if .ApprovalStage}}<p style="margin:8px 0 0 0;color:#475569;font-size:14px;">Approval stage: <strong>{{.ApprovalStage}}</strong>. Stages sign off in order; the purchase moves on once this stage does.</p>
+{{end}}</td></tr>
 
 {{if .AuthorizedApprovers}}
 <tr><td style="padding:0 32px 16px 32px;">
@@ -831,8 +835,17 @@ func (s *Sender) SendPurchaseApprovalRequest(ctx context.Context, data Notificat
 	}
 	extraHeaders := addListUnsubscribeHeaders(unsubHdr, postHdr)
 
+	return sendPurchaseApprovalRequestWithCC(ctx, s, data.RecipientEmail, filteredCC, purchaseApprovalSubject(data), data, extraHeaders)
+}
+
+// purchaseApprovalSubject is the approval request subject shared by the SES
+// and SMTP senders, naming the approval-chain stage when there is one.
+func purchaseApprovalSubject(data NotificationData) string {
 	subject := fmt.Sprintf("CUDly - Purchase Approval Required (%d commitment(s))", len(data.Recommendations))
-	return sendPurchaseApprovalRequestWithCC(ctx, s, data.RecipientEmail, filteredCC, subject, data, extraHeaders)
+	if data.ApprovalStage != "" {
+		subject += " - " + data.ApprovalStage
+	}
+	return subject
 }
 
 // sendPurchaseApprovalRequestWithCC is the low-level send helper that accepts
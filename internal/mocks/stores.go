@@ -8,6 +8,7 @@ import (
 	"github.com/LeanerCloud/CUDly/internal/auth"
 	"github.com/LeanerCloud/CUDly/internal/config"
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/mock"
@@ -260,6 +261,20 @@ func (m *MockConfigStore) TransitionExecutionStatus(ctx context.Context, executi
 	return v, args.Error(1)
 }
 
+// DeferExecutionAtomic mocks the DeferExecutionAtomic operation.
+func (m *MockConfigStore) DeferExecutionAtomic(ctx context.Context, executionID string, fromStatuses []string, until time.Time) (*config.PurchaseExecution, error) {
+	m.record("DeferExecutionAtomic", ctx, executionID, fromStatuses, until)
+	args := m.Called(ctx, executionID, fromStatuses, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.PurchaseExecution)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.PurchaseExecution, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
 // SetCancelledBy mocks the SetCancelledBy targeted-update operation.
 func (m *MockConfigStore) SetCancelledBy(ctx context.Context, executionID, cancelledBy string) error {
 	m.record("SetCancelledBy", ctx, executionID, cancelledBy)
@@ -1235,6 +1250,78 @@ func (m *MockConfigStore) UpsertRIUtilizationCache(ctx context.Context, region s
 	return args.Error(0)
 }
 
+// CURObjectETags mocks the CURObjectETags operation. Returns an empty map
+// when no expectation is registered.
+func (m *MockConfigStore) CURObjectETags(ctx context.Context, source string) (map[string]string, error) {
+	m.record("CURObjectETags", ctx, source)
+	if !isExpected(&m.Mock, "CURObjectETags") {
+		return map[string]string{}, nil
+	}
+	args := m.Called(ctx, source)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(map[string]string)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected map[string]string, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+func (m *MockConfigStore) ReplaceCURObject(ctx context.Context, source, key, etag string, pools []cur.PoolHour, commitments []cur.CommitmentHour) error {
+	m.record("ReplaceCURObject", ctx, source, key, etag, pools, commitments)
+	if !isExpected(&m.Mock, "ReplaceCURObject") {
+		return nil
+	}
+	args := m.Called(ctx, source, key, etag, pools, commitments)
+	return args.Error(0)
+}
+
+func (m *MockConfigStore) DeleteCURObjects(ctx context.Context, source string, keys []string) error {
+	m.record("DeleteCURObjects", ctx, source, keys)
+	if !isExpected(&m.Mock, "DeleteCURObjects") {
+		return nil
+	}
+	args := m.Called(ctx, source, keys)
+	return args.Error(0)
+}
+
+// CURPoolHours mocks the CURPoolHours operation. Returns no rows when no
+// expectation is registered.
+func (m *MockConfigStore) CURPoolHours(ctx context.Context, q cur.Query) ([]cur.PoolHour, error) {
+	m.record("CURPoolHours", ctx, q)
+	if !isExpected(&m.Mock, "CURPoolHours") {
+		return nil, nil
+	}
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]cur.PoolHour)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []cur.PoolHour, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CURCommitmentHours mocks the CURCommitmentHours operation. Returns no
+// rows when no expectation is registered.
+func (m *MockConfigStore) CURCommitmentHours(ctx context.Context, q cur.Query) ([]cur.CommitmentHour, error) {
+	m.record("CURCommitmentHours", ctx, q)
+	if !isExpected(&m.Mock, "CURCommitmentHours") {
+		return nil, nil
+	}
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]cur.CommitmentHour)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []cur.CommitmentHour, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
 func (m *MockConfigStore) CreateAccountRegistration(ctx context.Context, reg *config.AccountRegistration) error {
 	m.record("CreateAccountRegistration", ctx, reg)
 	args := m.Called(ctx, reg)
@@ -1535,12 +1622,12 @@ func (m *MockConfigStore) GetLadderConfigs(ctx context.Context) ([]config.Ladder
 
 // GetLadderConfig mocks the GetLadderConfig operation.
 // Returns (nil, nil) when no expectation is registered.
-func (m *MockConfigStore) GetLadderConfig(ctx context.Context, cloudAccountID, provider string) (*config.LadderConfigDB, error) {
-	m.record("GetLadderConfig", ctx, cloudAccountID, provider)
+func (m *MockConfigStore) GetLadderConfig(ctx context.Context, cloudAccountID, provider, service string) (*config.LadderConfigDB, error) {
+	m.record("GetLadderConfig", ctx, cloudAccountID, provider, service)
 	if !isExpected(&m.Mock, "GetLadderConfig") {
 		return nil, nil
 	}
-	args := m.Called(ctx, cloudAccountID, provider)
+	args := m.Called(ctx, cloudAccountID, provider, service)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -1707,6 +1794,751 @@ func (m *MockConfigStore) IsNotificationMuted(ctx context.Context, recipientEmai
 	return args.Bool(0), args.Error(1)
 }
 
+// SaveRIModificationRecord mocks the SaveRIModificationRecord operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) SaveRIModificationRecord(ctx context.Context, record *config.RIModificationRecord) error {
+	m.record("SaveRIModificationRecord", ctx, record)
+	if !isExpected(&m.Mock, "SaveRIModificationRecord") {
+		return nil
+	}
+	return m.Called(ctx, record).Error(0)
+}
+
+// GetRIModificationRecord mocks the GetRIModificationRecord operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) GetRIModificationRecord(ctx context.Context, id string) (*config.RIModificationRecord, error) {
+	m.record("GetRIModificationRecord", ctx, id)
+	if !isExpected(&m.Mock, "GetRIModificationRecord") {
+		return nil, nil
+	}
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.RIModificationRecord)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.RIModificationRecord, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// GetRIModificationHistory mocks the GetRIModificationHistory operation.
+// Returns an empty slice when no expectation is registered.
+func (m *MockConfigStore) GetRIModificationHistory(ctx context.Context, since time.Time, limit int) ([]config.RIModificationRecord, error) {
+	m.record("GetRIModificationHistory", ctx, since, limit)
+	if !isExpected(&m.Mock, "GetRIModificationHistory") {
+		return []config.RIModificationRecord{}, nil
+	}
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.RIModificationRecord)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.RIModificationRecord, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// TransitionRIModificationStatus mocks the TransitionRIModificationStatus
+// operation. Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) TransitionRIModificationStatus(ctx context.Context, id, fromStatus, toStatus string, actor *string) (*config.RIModificationRecord, error) {
+	m.record("TransitionRIModificationStatus", ctx, id, fromStatus, toStatus, actor)
+	if !isExpected(&m.Mock, "TransitionRIModificationStatus") {
+		return nil, nil
+	}
+	args := m.Called(ctx, id, fromStatus, toStatus, actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.RIModificationRecord)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.RIModificationRecord, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CompleteRIModification mocks the CompleteRIModification operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CompleteRIModification(ctx context.Context, id, modificationID, approvedBy string) error {
+	m.record("CompleteRIModification", ctx, id, modificationID, approvedBy)
+	if !isExpected(&m.Mock, "CompleteRIModification") {
+		return nil
+	}
+	return m.Called(ctx, id, modificationID, approvedBy).Error(0)
+}
+
+// FailRIModification mocks the FailRIModification operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) FailRIModification(ctx context.Context, id, errorMsg string) error {
+	m.record("FailRIModification", ctx, id, errorMsg)
+	if !isExpected(&m.Mock, "FailRIModification") {
+		return nil
+	}
+	return m.Called(ctx, id, errorMsg).Error(0)
+}
+
+// SaveAzureRefund mocks the SaveAzureRefund operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) SaveAzureRefund(ctx context.Context, record *config.AzureRefundRecord) error {
+	m.record("SaveAzureRefund", ctx, record)
+	if !isExpected(&m.Mock, "SaveAzureRefund") {
+		return nil
+	}
+	return m.Called(ctx, record).Error(0)
+}
+
+// SumAzureRefunds mocks the SumAzureRefunds operation.
+// Returns (0, nil) when no expectation is registered.
+func (m *MockConfigStore) SumAzureRefunds(ctx context.Context, billingProfile, currency string, since time.Time) (float64, error) {
+	m.record("SumAzureRefunds", ctx, billingProfile, currency, since)
+	if !isExpected(&m.Mock, "SumAzureRefunds") {
+		return 0, nil
+	}
+	args := m.Called(ctx, billingProfile, currency, since)
+	v, ok := args.Get(0).(float64)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected float64, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// UpsertCommitmentInventory mocks the UpsertCommitmentInventory operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) UpsertCommitmentInventory(ctx context.Context, cloudAccountID string, records []config.CommitmentInventoryRecord, listedScopes []string) error {
+	m.record("UpsertCommitmentInventory", ctx, cloudAccountID, records, listedScopes)
+	if !isExpected(&m.Mock, "UpsertCommitmentInventory") {
+		return nil
+	}
+	return m.Called(ctx, cloudAccountID, records, listedScopes).Error(0)
+}
+
+// GetActiveCommitmentInventory mocks the GetActiveCommitmentInventory operation.
+// Returns (nil, nil) when no expectation is registered, so handlers that merge
+// the inventory into purchase history see an empty inventory by default.
+func (m *MockConfigStore) GetActiveCommitmentInventory(ctx context.Context, asOf time.Time, accountIDs []string, externalIDsByProvider map[string][]string) ([]config.CommitmentInventoryRecord, error) {
+	m.record("GetActiveCommitmentInventory", ctx, asOf, accountIDs, externalIDsByProvider)
+	if !isExpected(&m.Mock, "GetActiveCommitmentInventory") {
+		return nil, nil
+	}
+	args := m.Called(ctx, asOf, accountIDs, externalIDsByProvider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.CommitmentInventoryRecord)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.CommitmentInventoryRecord, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CreateCommitmentRenewal mocks the CreateCommitmentRenewal operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CreateCommitmentRenewal(ctx context.Context, renewal *config.CommitmentRenewal) error {
+	m.record("CreateCommitmentRenewal", ctx, renewal)
+	if !isExpected(&m.Mock, "CreateCommitmentRenewal") {
+		return nil
+	}
+	return m.Called(ctx, renewal).Error(0)
+}
+
+// ListCommitmentRenewals mocks the ListCommitmentRenewals operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListCommitmentRenewals(ctx context.Context, filter config.CommitmentRenewalFilter) ([]config.CommitmentRenewal, error) {
+	m.record("ListCommitmentRenewals", ctx, filter)
+	if !isExpected(&m.Mock, "ListCommitmentRenewals") {
+		return nil, nil
+	}
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.CommitmentRenewal)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.CommitmentRenewal, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// UpdateCommitmentRenewalStatus mocks the UpdateCommitmentRenewalStatus
+// operation. Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) UpdateCommitmentRenewalStatus(ctx context.Context, id, status string, replacementCommitmentID *string) error {
+	m.record("UpdateCommitmentRenewalStatus", ctx, id, status, replacementCommitmentID)
+	if !isExpected(&m.Mock, "UpdateCommitmentRenewalStatus") {
+		return nil
+	}
+	return m.Called(ctx, id, status, replacementCommitmentID).Error(0)
+}
+
+// CreatePurchasePolicy mocks the CreatePurchasePolicy operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) CreatePurchasePolicy(ctx context.Context, p *config.PurchasePolicy) error {
+	m.record("CreatePurchasePolicy", ctx, p)
+	if !isExpected(&m.Mock, "CreatePurchasePolicy") {
+		return nil
+	}
+	return m.Called(ctx, p).Error(0)
+}
+
+// GetLatestPurchasePolicy mocks the GetLatestPurchasePolicy operation.
+// Returns (nil, nil), no policy, when no expectation is registered.
+func (m *MockConfigStore) GetLatestPurchasePolicy(ctx context.Context) (*config.PurchasePolicy, error) {
+	m.record("GetLatestPurchasePolicy", ctx)
+	if !isExpected(&m.Mock, "GetLatestPurchasePolicy") {
+		return nil, nil
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.PurchasePolicy)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.PurchasePolicy, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// GetPurchasePolicy mocks the GetPurchasePolicy operation. Returns
+// ErrNotFound when no expectation is registered.
+func (m *MockConfigStore) GetPurchasePolicy(ctx context.Context, version int) (*config.PurchasePolicy, error) {
+	m.record("GetPurchasePolicy", ctx, version)
+	if !isExpected(&m.Mock, "GetPurchasePolicy") {
+		return nil, config.ErrNotFound
+	}
+	args := m.Called(ctx, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.PurchasePolicy)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.PurchasePolicy, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListPurchasePolicies mocks the ListPurchasePolicies operation. Returns
+// (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListPurchasePolicies(ctx context.Context, limit int) ([]config.PurchasePolicy, error) {
+	m.record("ListPurchasePolicies", ctx, limit)
+	if !isExpected(&m.Mock, "ListPurchasePolicies") {
+		return nil, nil
+	}
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.PurchasePolicy)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.PurchasePolicy, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// RecordPolicyEvaluation mocks the RecordPolicyEvaluation operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) RecordPolicyEvaluation(ctx context.Context, eval *config.PolicyEvaluation) error {
+	m.record("RecordPolicyEvaluation", ctx, eval)
+	if !isExpected(&m.Mock, "RecordPolicyEvaluation") {
+		return nil
+	}
+	return m.Called(ctx, eval).Error(0)
+}
+
+// ListPolicyEvaluations mocks the ListPolicyEvaluations operation. Returns
+// (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListPolicyEvaluations(ctx context.Context, executionID string) ([]config.PolicyEvaluation, error) {
+	m.record("ListPolicyEvaluations", ctx, executionID)
+	if !isExpected(&m.Mock, "ListPolicyEvaluations") {
+		return nil, nil
+	}
+	args := m.Called(ctx, executionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.PolicyEvaluation)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.PolicyEvaluation, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// RecordExecutionApproval mocks the RecordExecutionApproval operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) RecordExecutionApproval(ctx context.Context, approval *config.ExecutionApproval) error {
+	m.record("RecordExecutionApproval", ctx, approval)
+	if !isExpected(&m.Mock, "RecordExecutionApproval") {
+		return nil
+	}
+	return m.Called(ctx, approval).Error(0)
+}
+
+// ListExecutionApprovals mocks the ListExecutionApprovals operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListExecutionApprovals(ctx context.Context, executionID string) ([]config.ExecutionApproval, error) {
+	m.record("ListExecutionApprovals", ctx, executionID)
+	if !isExpected(&m.Mock, "ListExecutionApprovals") {
+		return nil, nil
+	}
+	args := m.Called(ctx, executionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.ExecutionApproval)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.ExecutionApproval, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListAccountGroups mocks the ListAccountGroups operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListAccountGroups(ctx context.Context) ([]config.AccountGroup, error) {
+	m.record("ListAccountGroups", ctx)
+	if !isExpected(&m.Mock, "ListAccountGroups") {
+		return nil, nil
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.AccountGroup)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.AccountGroup, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CreateAccountGroup mocks the CreateAccountGroup operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) CreateAccountGroup(ctx context.Context, g *config.AccountGroup) error {
+	m.record("CreateAccountGroup", ctx, g)
+	if !isExpected(&m.Mock, "CreateAccountGroup") {
+		return nil
+	}
+	return m.Called(ctx, g).Error(0)
+}
+
+// UpdateAccountGroup mocks the UpdateAccountGroup operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) UpdateAccountGroup(ctx context.Context, g *config.AccountGroup) error {
+	m.record("UpdateAccountGroup", ctx, g)
+	if !isExpected(&m.Mock, "UpdateAccountGroup") {
+		return nil
+	}
+	return m.Called(ctx, g).Error(0)
+}
+
+// DeleteAccountGroup mocks the DeleteAccountGroup operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) DeleteAccountGroup(ctx context.Context, id string) error {
+	m.record("DeleteAccountGroup", ctx, id)
+	if !isExpected(&m.Mock, "DeleteAccountGroup") {
+		return nil
+	}
+	return m.Called(ctx, id).Error(0)
+}
+
+// ListApprovalChains mocks the ListApprovalChains operation.
+// Returns (nil, nil), no chains, when no expectation is registered.
+func (m *MockConfigStore) ListApprovalChains(ctx context.Context) ([]config.ApprovalChain, error) {
+	m.record("ListApprovalChains", ctx)
+	if !isExpected(&m.Mock, "ListApprovalChains") {
+		return nil, nil
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.ApprovalChain)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.ApprovalChain, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CreateApprovalChain mocks the CreateApprovalChain operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) CreateApprovalChain(ctx context.Context, c *config.ApprovalChain) error {
+	m.record("CreateApprovalChain", ctx, c)
+	if !isExpected(&m.Mock, "CreateApprovalChain") {
+		return nil
+	}
+	return m.Called(ctx, c).Error(0)
+}
+
+// UpdateApprovalChain mocks the UpdateApprovalChain operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) UpdateApprovalChain(ctx context.Context, c *config.ApprovalChain) error {
+	m.record("UpdateApprovalChain", ctx, c)
+	if !isExpected(&m.Mock, "UpdateApprovalChain") {
+		return nil
+	}
+	return m.Called(ctx, c).Error(0)
+}
+
+// DeleteApprovalChain mocks the DeleteApprovalChain operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) DeleteApprovalChain(ctx context.Context, id string) error {
+	m.record("DeleteApprovalChain", ctx, id)
+	if !isExpected(&m.Mock, "DeleteApprovalChain") {
+		return nil
+	}
+	return m.Called(ctx, id).Error(0)
+}
+
+// StartExecutionApprovalChain mocks the StartExecutionApprovalChain operation.
+// Defaults to (true, nil), the chain started, when no expectation is registered.
+func (m *MockConfigStore) StartExecutionApprovalChain(ctx context.Context, run *config.ExecutionApprovalChain) (bool, error) {
+	m.record("StartExecutionApprovalChain", ctx, run)
+	if !isExpected(&m.Mock, "StartExecutionApprovalChain") {
+		return true, nil
+	}
+	args := m.Called(ctx, run)
+	return args.Bool(0), args.Error(1)
+}
+
+// GetExecutionApprovalChain mocks the GetExecutionApprovalChain operation.
+// Returns (nil, nil), no chain, when no expectation is registered.
+func (m *MockConfigStore) GetExecutionApprovalChain(ctx context.Context, executionID string) (*config.ExecutionApprovalChain, error) {
+	m.record("GetExecutionApprovalChain", ctx, executionID)
+	if !isExpected(&m.Mock, "GetExecutionApprovalChain") {
+		return nil, nil
+	}
+	args := m.Called(ctx, executionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.ExecutionApprovalChain)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.ExecutionApprovalChain, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// AdvanceExecutionApprovalChain mocks the AdvanceExecutionApprovalChain operation.
+// Defaults to (true, nil), the chain advanced, when no expectation is registered.
+func (m *MockConfigStore) AdvanceExecutionApprovalChain(ctx context.Context, executionID string, from int) (bool, error) {
+	m.record("AdvanceExecutionApprovalChain", ctx, executionID, from)
+	if !isExpected(&m.Mock, "AdvanceExecutionApprovalChain") {
+		return true, nil
+	}
+	args := m.Called(ctx, executionID, from)
+	return args.Bool(0), args.Error(1)
+}
+
+// RecordApprovalStageDecision mocks the RecordApprovalStageDecision operation. Defaults
+// to nil when no expectation is registered.
+func (m *MockConfigStore) RecordApprovalStageDecision(ctx context.Context, d *config.ApprovalStageDecision) error {
+	m.record("RecordApprovalStageDecision", ctx, d)
+	if !isExpected(&m.Mock, "RecordApprovalStageDecision") {
+		return nil
+	}
+	return m.Called(ctx, d).Error(0)
+}
+
+// ListApprovalStageDecisions mocks the ListApprovalStageDecisions operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListApprovalStageDecisions(ctx context.Context, executionID string) ([]config.ApprovalStageDecision, error) {
+	m.record("ListApprovalStageDecisions", ctx, executionID)
+	if !isExpected(&m.Mock, "ListApprovalStageDecisions") {
+		return nil, nil
+	}
+	args := m.Called(ctx, executionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.ApprovalStageDecision)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.ApprovalStageDecision, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListCommitmentBudgets mocks the ListCommitmentBudgets operation.
+// Returns (nil, nil), no budgets, when no expectation is registered.
+func (m *MockConfigStore) ListCommitmentBudgets(ctx context.Context) ([]config.CommitmentBudget, error) {
+	m.record("ListCommitmentBudgets", ctx)
+	if !isExpected(&m.Mock, "ListCommitmentBudgets") {
+		return nil, nil
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.CommitmentBudget)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.CommitmentBudget, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CreateCommitmentBudget mocks the CreateCommitmentBudget operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CreateCommitmentBudget(ctx context.Context, b *config.CommitmentBudget) error {
+	m.record("CreateCommitmentBudget", ctx, b)
+	if !isExpected(&m.Mock, "CreateCommitmentBudget") {
+		return nil
+	}
+	return m.Called(ctx, b).Error(0)
+}
+
+// UpdateCommitmentBudget mocks the UpdateCommitmentBudget operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) UpdateCommitmentBudget(ctx context.Context, b *config.CommitmentBudget) error {
+	m.record("UpdateCommitmentBudget", ctx, b)
+	if !isExpected(&m.Mock, "UpdateCommitmentBudget") {
+		return nil
+	}
+	return m.Called(ctx, b).Error(0)
+}
+
+// DeleteCommitmentBudget mocks the DeleteCommitmentBudget operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) DeleteCommitmentBudget(ctx context.Context, id string) error {
+	m.record("DeleteCommitmentBudget", ctx, id)
+	if !isExpected(&m.Mock, "DeleteCommitmentBudget") {
+		return nil
+	}
+	return m.Called(ctx, id).Error(0)
+}
+
+// ReserveCommitmentBudget mocks the ReserveCommitmentBudget operation.
+// Defaults to nil, no budget refusing, when no expectation is registered.
+func (m *MockConfigStore) ReserveCommitmentBudget(ctx context.Context, r *config.BudgetReservation) error {
+	m.record("ReserveCommitmentBudget", ctx, r)
+	if !isExpected(&m.Mock, "ReserveCommitmentBudget") {
+		return nil
+	}
+	return m.Called(ctx, r).Error(0)
+}
+
+// CommitBudgetReservation mocks the CommitBudgetReservation operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CommitBudgetReservation(ctx context.Context, id string, upfrontUSD, hourlyUSD float64) error {
+	m.record("CommitBudgetReservation", ctx, id, upfrontUSD, hourlyUSD)
+	if !isExpected(&m.Mock, "CommitBudgetReservation") {
+		return nil
+	}
+	return m.Called(ctx, id, upfrontUSD, hourlyUSD).Error(0)
+}
+
+// ReleaseBudgetReservation mocks the ReleaseBudgetReservation operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) ReleaseBudgetReservation(ctx context.Context, id, reason string) error {
+	m.record("ReleaseBudgetReservation", ctx, id, reason)
+	if !isExpected(&m.Mock, "ReleaseBudgetReservation") {
+		return nil
+	}
+	return m.Called(ctx, id, reason).Error(0)
+}
+
+// CreditCommitmentBudget mocks the CreditCommitmentBudget operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CreditCommitmentBudget(ctx context.Context, r *config.BudgetReservation) error {
+	m.record("CreditCommitmentBudget", ctx, r)
+	if !isExpected(&m.Mock, "CreditCommitmentBudget") {
+		return nil
+	}
+	return m.Called(ctx, r).Error(0)
+}
+
+// GetCommitmentBudgetUsage mocks the GetCommitmentBudgetUsage operation.
+// Returns (nil, nil), no budgets, when no expectation is registered.
+func (m *MockConfigStore) GetCommitmentBudgetUsage(ctx context.Context, at time.Time) ([]config.CommitmentBudgetUsage, error) {
+	m.record("GetCommitmentBudgetUsage", ctx, at)
+	if !isExpected(&m.Mock, "GetCommitmentBudgetUsage") {
+		return nil, nil
+	}
+	args := m.Called(ctx, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.CommitmentBudgetUsage)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.CommitmentBudgetUsage, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListFreezeWindows mocks the ListFreezeWindows operation.
+// Returns (nil, nil), no freeze in force, when no expectation is registered.
+func (m *MockConfigStore) ListFreezeWindows(ctx context.Context) ([]config.FreezeWindow, error) {
+	m.record("ListFreezeWindows", ctx)
+	if !isExpected(&m.Mock, "ListFreezeWindows") {
+		return nil, nil
+	}
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.FreezeWindow)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.FreezeWindow, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// CreateFreezeWindow mocks the CreateFreezeWindow operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) CreateFreezeWindow(ctx context.Context, w *config.FreezeWindow) error {
+	m.record("CreateFreezeWindow", ctx, w)
+	if !isExpected(&m.Mock, "CreateFreezeWindow") {
+		return nil
+	}
+	return m.Called(ctx, w).Error(0)
+}
+
+// UpdateFreezeWindow mocks the UpdateFreezeWindow operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) UpdateFreezeWindow(ctx context.Context, w *config.FreezeWindow) error {
+	m.record("UpdateFreezeWindow", ctx, w)
+	if !isExpected(&m.Mock, "UpdateFreezeWindow") {
+		return nil
+	}
+	return m.Called(ctx, w).Error(0)
+}
+
+// DeleteFreezeWindow mocks the DeleteFreezeWindow operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) DeleteFreezeWindow(ctx context.Context, id string) error {
+	m.record("DeleteFreezeWindow", ctx, id)
+	if !isExpected(&m.Mock, "DeleteFreezeWindow") {
+		return nil
+	}
+	return m.Called(ctx, id).Error(0)
+}
+
+// RecordFreezeOverride mocks the RecordFreezeOverride operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) RecordFreezeOverride(ctx context.Context, o *config.FreezeOverride) error {
+	m.record("RecordFreezeOverride", ctx, o)
+	if !isExpected(&m.Mock, "RecordFreezeOverride") {
+		return nil
+	}
+	return m.Called(ctx, o).Error(0)
+}
+
+// GetLatestFreezeOverride mocks the GetLatestFreezeOverride operation.
+// Returns (nil, nil), no override, when no expectation is registered.
+func (m *MockConfigStore) GetLatestFreezeOverride(ctx context.Context, executionID string) (*config.FreezeOverride, error) {
+	m.record("GetLatestFreezeOverride", ctx, executionID)
+	if !isExpected(&m.Mock, "GetLatestFreezeOverride") {
+		return nil, nil
+	}
+	args := m.Called(ctx, executionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.FreezeOverride)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.FreezeOverride, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListFreezeOverrides mocks the ListFreezeOverrides operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListFreezeOverrides(ctx context.Context, limit int) ([]config.FreezeOverride, error) {
+	m.record("ListFreezeOverrides", ctx, limit)
+	if !isExpected(&m.Mock, "ListFreezeOverrides") {
+		return nil, nil
+	}
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.FreezeOverride)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.FreezeOverride, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// RecordPlanShadowPurchases mocks the RecordPlanShadowPurchases operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) RecordPlanShadowPurchases(ctx context.Context, executionID string, ps []config.ShadowPurchase) error {
+	m.record("RecordPlanShadowPurchases", ctx, executionID, ps)
+	if !isExpected(&m.Mock, "RecordPlanShadowPurchases") {
+		return nil
+	}
+	return m.Called(ctx, executionID, ps).Error(0)
+}
+
+// SaveShadowLadderRun mocks the SaveShadowLadderRun operation.
+// Returns (run, nil) when no expectation is registered.
+func (m *MockConfigStore) SaveShadowLadderRun(ctx context.Context, run *config.LadderRunDB, ps []config.ShadowPurchase) (*config.LadderRunDB, error) {
+	m.record("SaveShadowLadderRun", ctx, run, ps)
+	if !isExpected(&m.Mock, "SaveShadowLadderRun") {
+		return run, nil
+	}
+	args := m.Called(ctx, run, ps)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).(*config.LadderRunDB)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected *config.LadderRunDB, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// GetShadowLadderCommitUSDHr mocks the GetShadowLadderCommitUSDHr operation.
+// Returns (0, nil) when no expectation is registered.
+func (m *MockConfigStore) GetShadowLadderCommitUSDHr(ctx context.Context, configID string, at time.Time) (float64, error) {
+	m.record("GetShadowLadderCommitUSDHr", ctx, configID, at)
+	if !isExpected(&m.Mock, "GetShadowLadderCommitUSDHr") {
+		return 0, nil
+	}
+	args := m.Called(ctx, configID, at)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// ListShadowPurchases mocks the ListShadowPurchases operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListShadowPurchases(ctx context.Context, filter config.ShadowPurchaseFilter) ([]config.ShadowPurchase, error) {
+	m.record("ListShadowPurchases", ctx, filter)
+	if !isExpected(&m.Mock, "ListShadowPurchases") {
+		return nil, nil
+	}
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.ShadowPurchase)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.ShadowPurchase, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// ListShadowPurchasesToEvaluate mocks the ListShadowPurchasesToEvaluate operation.
+// Returns (nil, nil) when no expectation is registered.
+func (m *MockConfigStore) ListShadowPurchasesToEvaluate(ctx context.Context, at time.Time) ([]config.ShadowPurchase, error) {
+	m.record("ListShadowPurchasesToEvaluate", ctx, at)
+	if !isExpected(&m.Mock, "ListShadowPurchasesToEvaluate") {
+		return nil, nil
+	}
+	args := m.Called(ctx, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	v, ok := args.Get(0).([]config.ShadowPurchase)
+	if !ok {
+		panic(fmt.Sprintf("mock: expected []config.ShadowPurchase, got %T", args.Get(0)))
+	}
+	return v, args.Error(1)
+}
+
+// SaveShadowEvaluation mocks the SaveShadowEvaluation operation.
+// Defaults to nil when no expectation is registered.
+func (m *MockConfigStore) SaveShadowEvaluation(ctx context.Context, p *config.ShadowPurchase) error {
+	m.record("SaveShadowEvaluation", ctx, p)
+	if !isExpected(&m.Mock, "SaveShadowEvaluation") {
+		return nil
+	}
+	return m.Called(ctx, p).Error(0)
+}
+
 // isExpected reports whether mock has any .On() expectation for method.
 func isExpected(m *mock.Mock, method string) bool {
 	for _, call := range m.ExpectedCalls {
@@ -0,0 +1,527 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/email"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// ApprovalStagePendingError is returned by the approval paths while an
+// execution's approval chain waits on a stage. Any signature the approver
+// gave has been recorded; the execution stays pending.
+type ApprovalStagePendingError struct {
+	ExecutionID string
+	Stage       string
+	// StageNumber is 1-based; Stages is the number of stages the
+	// execution's amount requires.
+	StageNumber, Stages int
+	Have, Need          int
+	// EmailErr is why the stage's approvers could not be emailed when the
+	// previous stage's sign-off activated it; nil when they were.
+	EmailErr error
+}
+
+// Error implements the error interface.
+func (e *ApprovalStagePendingError) Error() string {
+	return fmt.Sprintf("execution %s is awaiting approval stage %q (%d of %d): %d of %d sign-offs",
+		e.ExecutionID, e.Stage, e.StageNumber, e.Stages, e.Have, e.Need)
+}
+
+// ApprovalStageEmailError is returned when an approval chain stage was
+// activated, its token minted and saved, but its approvers could not be
+// emailed. The chain stands; the approvers can still sign from the
+// dashboard.
+type ApprovalStageEmailError struct {
+	ExecutionID string
+	Stage       string
+	Err         error
+}
+
+// Error implements the error interface.
+func (e *ApprovalStageEmailError) Error() string {
+	return fmt.Sprintf("failed to email the approvers of approval stage %s of execution %s: %v", e.Stage, e.ExecutionID, e.Err)
+}
+
+// Unwrap returns the send error.
+func (e *ApprovalStageEmailError) Unwrap() error { return e.Err }
+
+// stageEmailFailed reports whether err only says a stage's approvers were
+// not emailed, which leaves the chain started.
+func stageEmailFailed(err error) bool {
+	var emailErr *ApprovalStageEmailError
+	return errors.As(err, &emailErr)
+}
+
+// ApprovalChainError is returned when an execution's approval chain
+// refuses an action: the approver is not on the stage awaiting sign-off,
+// or the purchase is about to run before every stage has signed.
+type ApprovalChainError struct {
+	ExecutionID string
+	Stage       string
+	Reason      string
+}
+
+// Error implements the error interface.
+func (e *ApprovalChainError) Error() string {
+	return fmt.Sprintf("approval chain refused execution %s at stage %q: %s", e.ExecutionID, e.Stage, e.Reason)
+}
+
+// approvalHeld reports whether err only says an approval was recorded and
+// the execution waits for more approvers, which is not a failure.
+func approvalHeld(err error) bool {
+	var pending *ApprovalsPendingError
+	var stagePending *ApprovalStagePendingError
+	return errors.As(err, &pending) || errors.As(err, &stagePending)
+}
+
+// IsActiveStageApprover reports whether actor is an approver of the stage
+// awaiting sign-off in executionID's approval chain. The approve and cancel
+// authorization checks accept stage approvers alongside the account
+// contacts, who otherwise are the only ones allowed to act on a purchase.
+// Exported for the HTTP layer's check.
+func IsActiveStageApprover(ctx context.Context, store config.StoreInterface, executionID, actor string) (bool, error) {
+	run, err := store.GetExecutionApprovalChain(ctx, executionID)
+	if err != nil {
+		return false, fmt.Errorf("approval chain: %w", err)
+	}
+	if run == nil || run.Completed() {
+		return false, nil
+	}
+	return isStageApprover(run.Stages[run.CurrentStage], strings.ToLower(strings.TrimSpace(actor))), nil
+}
+
+// stageLabel names a chain's stage for emails and errors, e.g.
+// "FinOps (stage 2 of 3)".
+func stageLabel(run *config.ExecutionApprovalChain, index int) string {
+	return fmt.Sprintf("%s (stage %d of %d)", run.Stages[index].Name, index+1, len(run.Stages))
+}
+
+// chainAmount is the value of exec a chain's stage thresholds are compared
+// against: the upfront cost of its selected recommendations or, for
+// config.AmountBasisTotalCommitment, that plus every recurring monthly
+// charge over each commitment's term (Term is in years).
+func chainAmount(exec *config.PurchaseExecution, basis string) float64 {
+	var amount float64
+	for _, i := range selectedIndices(exec.Recommendations) {
+		rec := exec.Recommendations[i]
+		amount += rec.UpfrontCost
+		if basis == config.AmountBasisTotalCommitment && rec.Term > 0 && rec.MonthlyCost != nil {
+			amount += *rec.MonthlyCost * float64(rec.Term*12)
+		}
+	}
+	return amount
+}
+
+// requiredStages returns chain's stages that amount requires, in order.
+func requiredStages(chain *config.ApprovalChain, amount float64) []config.ApprovalStage {
+	var stages []config.ApprovalStage
+	for _, st := range chain.Stages {
+		if amount >= st.MinAmount {
+			stages = append(stages, st)
+		}
+	}
+	return stages
+}
+
+// chainMatches reports whether chain applies to an execution buying from
+// providers in the given accounts. Every selected recommendation must be
+// for the chain's provider and every account must be in its group.
+func chainMatches(chain *config.ApprovalChain, providers, accountIDs []string, groups map[string]*config.AccountGroup) bool {
+	if chain.Provider != "" {
+		for _, p := range providers {
+			if p != chain.Provider {
+				return false
+			}
+		}
+	}
+	if chain.AccountGroupID == nil {
+		return true
+	}
+	group := groups[*chain.AccountGroupID]
+	if group == nil || len(accountIDs) == 0 {
+		return false
+	}
+	for _, id := range accountIDs {
+		if !group.HasAccount(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// executionProviders returns the provider of each selected recommendation,
+// an empty provider meaning AWS like everywhere else.
+func executionProviders(exec *config.PurchaseExecution) []string {
+	idx := selectedIndices(exec.Recommendations)
+	providers := make([]string, 0, len(idx))
+	for _, i := range idx {
+		p := exec.Recommendations[i].Provider
+		if p == "" {
+			p = "aws"
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// enabledApprovalChains returns the enabled chains in priority order.
+func (m *Manager) enabledApprovalChains(ctx context.Context) ([]config.ApprovalChain, error) {
+	chains, err := m.config.ListApprovalChains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("approval chain: failed to list chains: %w", err)
+	}
+	enabled := make([]config.ApprovalChain, 0, len(chains))
+	for i := range chains {
+		if chains[i].Enabled {
+			enabled = append(enabled, chains[i])
+		}
+	}
+	return enabled, nil
+}
+
+// selectApprovalChain returns the run exec must go through: the first of
+// chains that matches it, with the stages its amount requires. It returns
+// nil when no chain matches or the matching chain requires no stage at
+// this amount.
+func (m *Manager) selectApprovalChain(ctx context.Context, exec *config.PurchaseExecution, chains []config.ApprovalChain) (*config.ExecutionApprovalChain, error) {
+	groups, err := m.accountGroupsByID(ctx, chains)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := resolvePolicyAccounts(ctx, m.config, exec)
+	if err != nil {
+		return nil, fmt.Errorf("approval chain: %w", err)
+	}
+	accountIDs := make([]string, 0, len(accounts))
+	for _, a := range accounts {
+		accountIDs = append(accountIDs, a.ID)
+	}
+	providers := executionProviders(exec)
+
+	for i := range chains {
+		chain := &chains[i]
+		if !chainMatches(chain, providers, accountIDs, groups) {
+			continue
+		}
+		amount := chainAmount(exec, chain.AmountBasis)
+		stages := requiredStages(chain, amount)
+		if len(stages) == 0 {
+			return nil, nil
+		}
+		chainID := chain.ID
+		return &config.ExecutionApprovalChain{
+			ExecutionID: exec.ExecutionID,
+			ChainID:     &chainID,
+			ChainName:   chain.Name,
+			Amount:      amount,
+			Stages:      stages,
+		}, nil
+	}
+	return nil, nil
+}
+
+// accountGroupsByID loads the account groups, keyed by ID, when any of
+// chains is scoped to one.
+func (m *Manager) accountGroupsByID(ctx context.Context, chains []config.ApprovalChain) (map[string]*config.AccountGroup, error) {
+	scoped := false
+	for i := range chains {
+		scoped = scoped || chains[i].AccountGroupID != nil
+	}
+	if !scoped {
+		return nil, nil
+	}
+	groups, err := m.config.ListAccountGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("approval chain: failed to list account groups: %w", err)
+	}
+	byID := make(map[string]*config.AccountGroup, len(groups))
+	for i := range groups {
+		byID[groups[i].ID] = &groups[i]
+	}
+	return byID, nil
+}
+
+// StartApprovalChain starts the approval chain executionID requires, minting
+// the first stage's token and emailing its approvers. It returns the chain
+// the execution is going through, already started or not, or nil when no
+// chain applies. Callers that email an approval request call it first and
+// skip their own email when it returns a chain; an execution whose chain
+// was not started up front starts it at its first approval attempt. A chain
+// started here whose first stage could not be emailed is returned with an
+// *ApprovalStageEmailError.
+func (m *Manager) StartApprovalChain(ctx context.Context, executionID string) (*config.ExecutionApprovalChain, error) {
+	run, _, err := m.loadApprovalChain(ctx, executionID)
+	return run, err
+}
+
+// loadApprovalChain returns executionID's chain and the execution, starting
+// the chain when one applies and none has started. It returns a nil chain
+// when none applies; the execution is then only loaded when chains are
+// configured. A chain started here whose first stage could not be emailed
+// comes with an *ApprovalStageEmailError.
+func (m *Manager) loadApprovalChain(ctx context.Context, executionID string) (*config.ExecutionApprovalChain, *config.PurchaseExecution, error) {
+	run, err := m.config.GetExecutionApprovalChain(ctx, executionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("approval chain: %w", err)
+	}
+	var chains []config.ApprovalChain
+	if run == nil {
+		if chains, err = m.enabledApprovalChains(ctx); err != nil || len(chains) == 0 {
+			return nil, nil, err
+		}
+	}
+	exec, err := m.config.GetExecutionByID(ctx, executionID)
+	if errors.Is(err, config.ErrNotFound) {
+		// Nothing to gate; the caller's status transition reports the
+		// missing row.
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("approval chain: failed to load execution: %w", err)
+	}
+	if run != nil {
+		return run, exec, nil
+	}
+	run, err = m.startApprovalChain(ctx, exec, chains)
+	return run, exec, err
+}
+
+// startApprovalChain records the chain exec goes through and activates its
+// first stage. When a concurrent caller started it first, that chain is
+// returned instead and nothing is emailed twice.
+func (m *Manager) startApprovalChain(ctx context.Context, exec *config.PurchaseExecution, chains []config.ApprovalChain) (*config.ExecutionApprovalChain, error) {
+	run, err := m.selectApprovalChain(ctx, exec, chains)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	started, err := m.config.StartExecutionApprovalChain(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("approval chain: %w", err)
+	}
+	if !started {
+		existing, getErr := m.config.GetExecutionApprovalChain(ctx, exec.ExecutionID)
+		if getErr != nil {
+			return nil, fmt.Errorf("approval chain: %w", getErr)
+		}
+		return existing, nil
+	}
+	logging.Infof("purchase[%s]: approval chain %q started: %d stage(s) for $%.2f",
+		exec.ExecutionID, run.ChainName, len(run.Stages), run.Amount)
+	if activateErr := m.activateApprovalStage(ctx, exec, run, 0); activateErr != nil {
+		if stageEmailFailed(activateErr) {
+			return run, activateErr
+		}
+		return nil, activateErr
+	}
+	return run, nil
+}
+
+// activateApprovalStage mints a token for the stage at index, replacing the
+// previous stage's so only the active stage's link approves, and emails
+// the stage's approvers. A failed email returns an *ApprovalStageEmailError
+// after the token is saved: the stage is active either way, and its
+// approvers can still sign from the dashboard.
+func (m *Manager) activateApprovalStage(ctx context.Context, exec *config.PurchaseExecution, run *config.ExecutionApprovalChain, index int) error {
+	tok, err := common.GenerateApprovalToken()
+	if err != nil {
+		return fmt.Errorf("approval chain: failed to generate stage token: %w", err)
+	}
+	expiry := time.Now().Add(config.ApprovalTokenTTL)
+	exec.ApprovalToken = tok
+	exec.ApprovalTokenExpiresAt = &expiry
+	if saveErr := m.config.SavePurchaseExecution(ctx, exec); saveErr != nil {
+		return fmt.Errorf("approval chain: failed to save stage token: %w", saveErr)
+	}
+
+	stage := run.Stages[index]
+	data := email.NotificationData{
+		DashboardURL:        m.dashboardURL,
+		ApprovalToken:       tok,
+		ExecutionID:         exec.ExecutionID,
+		PlanID:              exec.PlanID,
+		TotalUpfrontCost:    exec.TotalUpfrontCost,
+		TotalSavings:        exec.EstimatedSavings,
+		RecipientEmail:      stage.Approvers[0],
+		CCEmails:            stage.Approvers[1:],
+		AuthorizedApprovers: stage.Approvers,
+		ApprovalStage:       stageLabel(run, index),
+	}
+	for _, i := range selectedIndices(exec.Recommendations) {
+		rec := exec.Recommendations[i]
+		data.Recommendations = append(data.Recommendations, email.RecommendationSummary{
+			Service:        rec.Service,
+			ResourceType:   rec.ResourceType,
+			Engine:         rec.Engine,
+			Region:         rec.Region,
+			Count:          rec.Count,
+			MonthlySavings: rec.Savings,
+			Term:           rec.Term,
+			Payment:        rec.Payment,
+			UpfrontCost:    rec.UpfrontCost,
+		})
+	}
+	if sendErr := m.email.SendPurchaseApprovalRequest(ctx, data); sendErr != nil {
+		logging.Errorf("purchase[%s]: failed to email approval stage %s: %v", exec.ExecutionID, data.ApprovalStage, sendErr)
+		return &ApprovalStageEmailError{ExecutionID: exec.ExecutionID, Stage: data.ApprovalStage, Err: sendErr}
+	}
+	return nil
+}
+
+// enforceApprovalChain signs the stage of executionID's approval chain
+// awaiting sign-off on actor's behalf. It returns an *ApprovalChainError
+// when actor is not one of that stage's approvers, and an
+// *ApprovalStagePendingError while the stage is short of its quorum or
+// once it completes with further stages to go, whose approvers it mints a
+// token for and emails. It returns nil when no chain applies or the last
+// stage has signed.
+func (m *Manager) enforceApprovalChain(ctx context.Context, executionID, actor string, actorUserID *string) error {
+	run, exec, err := m.loadApprovalChain(ctx, executionID)
+	if err != nil && !stageEmailFailed(err) {
+		return err
+	}
+	// A chain started by this attempt whose first stage could not be
+	// emailed goes ahead: the approver acting now signs it.
+	if run == nil || run.Completed() {
+		return nil
+	}
+	index := run.CurrentStage
+	stage := run.Stages[index]
+	approver := strings.ToLower(strings.TrimSpace(actor))
+	if !isStageApprover(stage, approver) {
+		return &ApprovalChainError{ExecutionID: executionID, Stage: stage.Name,
+			Reason: "the approver is not on this stage; it awaits " + strings.Join(stage.Approvers, ", ")}
+	}
+	if recErr := m.config.RecordApprovalStageDecision(ctx, &config.ApprovalStageDecision{
+		ExecutionID: executionID, StageIndex: index, ApproverEmail: approver, ApproverUserID: actorUserID,
+	}); recErr != nil {
+		return fmt.Errorf("approval chain: %w", recErr)
+	}
+
+	have, err := m.stageSignOffs(ctx, executionID, index)
+	if err != nil {
+		return err
+	}
+	if have < stage.Quorum {
+		logging.Infof("purchase[%s]: approval stage %s: %d of %d sign-offs", executionID, stageLabel(run, index), have, stage.Quorum)
+		return &ApprovalStagePendingError{ExecutionID: executionID, Stage: stage.Name,
+			StageNumber: index + 1, Stages: len(run.Stages), Have: have, Need: stage.Quorum}
+	}
+	return m.completeApprovalStage(ctx, exec, run, index)
+}
+
+// completeApprovalStage moves the chain past the stage at index, which has
+// met its quorum. Past the last stage it returns nil so the approval goes
+// ahead; otherwise it activates the next stage and returns an
+// *ApprovalStagePendingError for it.
+func (m *Manager) completeApprovalStage(ctx context.Context, exec *config.PurchaseExecution, run *config.ExecutionApprovalChain, index int) error {
+	advanced, err := m.config.AdvanceExecutionApprovalChain(ctx, exec.ExecutionID, index)
+	if err != nil {
+		return fmt.Errorf("approval chain: %w", err)
+	}
+	if !advanced {
+		// A concurrent sign-off completed this stage first and owns what
+		// happens next, including running the purchase after the last one.
+		return fmt.Errorf("approval chain of execution %s moved past stage %q concurrently; reload the purchase", exec.ExecutionID, run.Stages[index].Name)
+	}
+	next := index + 1
+	if next >= len(run.Stages) {
+		logging.Infof("purchase[%s]: approval chain %q complete", exec.ExecutionID, run.ChainName)
+		return nil
+	}
+	activateErr := m.activateApprovalStage(ctx, exec, run, next)
+	if activateErr != nil && !stageEmailFailed(activateErr) {
+		return activateErr
+	}
+	logging.Infof("purchase[%s]: approval chain moved on to %s", exec.ExecutionID, stageLabel(run, next))
+	return &ApprovalStagePendingError{ExecutionID: exec.ExecutionID, Stage: run.Stages[next].Name,
+		StageNumber: next + 1, Stages: len(run.Stages), Need: run.Stages[next].Quorum, EmailErr: activateErr}
+}
+
+// stageSignOffs counts the sign-offs recorded on the stage at index.
+func (m *Manager) stageSignOffs(ctx context.Context, executionID string, index int) (int, error) {
+	decisions, err := m.config.ListApprovalStageDecisions(ctx, executionID)
+	if err != nil {
+		return 0, fmt.Errorf("approval chain: %w", err)
+	}
+	have := 0
+	for _, d := range decisions {
+		if d.StageIndex == index {
+			have++
+		}
+	}
+	return have, nil
+}
+
+// isStageApprover reports whether the lower-cased email is one of stage's
+// approvers.
+func isStageApprover(stage config.ApprovalStage, email string) bool {
+	if email == "" {
+		return false
+	}
+	for _, a := range stage.Approvers {
+		if strings.EqualFold(strings.TrimSpace(a), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdForApprovalChain reports whether the scheduler must leave exec
+// pending because an approval chain applies that has not finished. A chain
+// that applies but has not started is started here, emailing its first
+// stage; the final sign-off then runs the purchase through
+// ApproveAndExecute.
+func (m *Manager) holdForApprovalChain(ctx context.Context, exec *config.PurchaseExecution) (bool, error) {
+	run, _, err := m.loadApprovalChain(ctx, exec.ExecutionID)
+	if err != nil && !stageEmailFailed(err) {
+		return false, err
+	}
+	if run == nil || run.Completed() {
+		return false, nil
+	}
+	logging.Infof("purchase[%s]: held for approval chain %q at %s", exec.ExecutionID, run.ChainName, stageLabel(run, run.CurrentStage))
+	return true, nil
+}
+
+// enforceApprovalChainComplete is the executor-side check that an
+// execution with an approval chain only runs once every stage has signed.
+// An execution a chain applies to but that never started one, such as one
+// created before the chain was configured or a redriven one, is refused
+// rather than read as signed off.
+func (m *Manager) enforceApprovalChainComplete(ctx context.Context, exec *config.PurchaseExecution) error {
+	run, err := m.config.GetExecutionApprovalChain(ctx, exec.ExecutionID)
+	if err != nil {
+		return fmt.Errorf("approval chain: %w", err)
+	}
+	if run == nil {
+		return m.refuseUnstartedApprovalChain(ctx, exec)
+	}
+	if run.Completed() {
+		return nil
+	}
+	return &ApprovalChainError{ExecutionID: exec.ExecutionID, Stage: run.Stages[run.CurrentStage].Name,
+		Reason: fmt.Sprintf("%s has not signed off", stageLabel(run, run.CurrentStage))}
+}
+
+// refuseUnstartedApprovalChain returns an *ApprovalChainError when an
+// approval chain applies to exec, which has none recorded, and nil when
+// none applies.
+func (m *Manager) refuseUnstartedApprovalChain(ctx context.Context, exec *config.PurchaseExecution) error {
+	chains, err := m.enabledApprovalChains(ctx)
+	if err != nil || len(chains) == 0 {
+		return err
+	}
+	run, err := m.selectApprovalChain(ctx, exec, chains)
+	if err != nil || run == nil {
+		return err
+	}
+	return &ApprovalChainError{ExecutionID: exec.ExecutionID, Stage: run.Stages[0].Name,
+		Reason: fmt.Sprintf("approval chain %q applies but was never started, so %s has not signed off", run.ChainName, stageLabel(run, 0))}
+}
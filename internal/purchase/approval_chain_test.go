@@ -0,0 +1,307 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/email"
+)
+
+// tieredChain is the procurement chain from the feature request: a team
+// lead signs everything, FinOps from $10k and the finance director from
+// $100k.
+func tieredChain() config.ApprovalChain {
+	return config.ApprovalChain{
+		ID:          "chain-1",
+		Name:        "procurement",
+		Enabled:     true,
+		AmountBasis: config.AmountBasisUpfront,
+		Stages: []config.ApprovalStage{
+			{Name: "Team lead", Approvers: []string{"lead@example.com"}, Quorum: 1},
+			{Name: "FinOps", Approvers: []string{"finops-a@example.com", "finops-b@example.com"}, Quorum: 2, MinAmount: 10000},
+			{Name: "Finance director", Approvers: []string{"director@example.com"}, Quorum: 1, MinAmount: 100000},
+		},
+	}
+}
+
+func chainExec(upfront float64) *config.PurchaseExecution {
+	monthly := 100.0
+	account := "acct-prod"
+	return &config.PurchaseExecution{
+		ExecutionID:      "exec-chain",
+		Status:           "pending",
+		TotalUpfrontCost: upfront,
+		CloudAccountID:   &account,
+		Recommendations: []config.RecommendationRecord{
+			{Provider: "aws", Service: "rds", Term: 1, Count: 1, UpfrontCost: upfront, MonthlyCost: &monthly, Selected: true},
+		},
+	}
+}
+
+// runAt is exec-chain's run of the tiered chain at a $50k amount,
+// awaiting the stage at index.
+func runAt(index int) *config.ExecutionApprovalChain {
+	chain := tieredChain()
+	return &config.ExecutionApprovalChain{
+		ExecutionID: "exec-chain", ChainName: chain.Name, Amount: 50000,
+		Stages: chain.Stages[:2], CurrentStage: index,
+	}
+}
+
+func TestChainAmountAndRequiredStages(t *testing.T) {
+	exec := chainExec(20000)
+	assert.Equal(t, 20000.0, chainAmount(exec, config.AmountBasisUpfront))
+	assert.Equal(t, 21200.0, chainAmount(exec, config.AmountBasisTotalCommitment), "plus 12 months at $100")
+
+	chain := tieredChain()
+	tests := []struct {
+		amount float64
+		want   []string
+	}{
+		{5000, []string{"Team lead"}},
+		{10000, []string{"Team lead", "FinOps"}},
+		{250000, []string{"Team lead", "FinOps", "Finance director"}},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, st := range requiredStages(&chain, tt.amount) {
+			names = append(names, st.Name)
+		}
+		assert.Equal(t, tt.want, names, "amount %.0f", tt.amount)
+	}
+}
+
+func TestChainMatches(t *testing.T) {
+	group := "group-prod"
+	groups := map[string]*config.AccountGroup{group: {ID: group, AccountIDs: []string{"acct-prod", "acct-prod-2"}}}
+	tests := []struct {
+		name       string
+		chain      config.ApprovalChain
+		providers  []string
+		accountIDs []string
+		want       bool
+	}{
+		{"unscoped chain", config.ApprovalChain{}, []string{"gcp"}, nil, true},
+		{"provider matches", config.ApprovalChain{Provider: "aws"}, []string{"aws", "aws"}, nil, true},
+		{"mixed providers", config.ApprovalChain{Provider: "aws"}, []string{"aws", "azure"}, nil, false},
+		{"all accounts in group", config.ApprovalChain{AccountGroupID: &group}, nil, []string{"acct-prod", "acct-prod-2"}, true},
+		{"account outside group", config.ApprovalChain{AccountGroupID: &group}, nil, []string{"acct-prod", "acct-dev"}, false},
+		{"no accounts resolved", config.ApprovalChain{AccountGroupID: &group}, nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, chainMatches(&tt.chain, tt.providers, tt.accountIDs, groups))
+		})
+	}
+}
+
+func TestEnforceApprovalPolicy_StartsChainAndMovesToNextStage(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(nil, nil)
+	store.On("ListApprovalChains", ctx).Return([]config.ApprovalChain{
+		{Name: "disabled", Stages: tieredChain().Stages},
+		tieredChain(),
+	}, nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+	store.On("StartExecutionApprovalChain", ctx, mock.MatchedBy(func(r *config.ExecutionApprovalChain) bool {
+		return r.ChainName == "procurement" && r.Amount == 50000 && len(r.Stages) == 2
+	})).Return(true, nil)
+
+	var tokens []string
+	store.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).
+		Run(func(args mock.Arguments) {
+			tokens = append(tokens, args.Get(1).(*config.PurchaseExecution).ApprovalToken)
+		}).Return(nil)
+	sender.On("SendPurchaseApprovalRequest", ctx, mock.MatchedBy(func(d email.NotificationData) bool {
+		return d.RecipientEmail == "lead@example.com" && d.ApprovalStage == "Team lead (stage 1 of 2)"
+	})).Return(nil).Once()
+	sender.On("SendPurchaseApprovalRequest", ctx, mock.MatchedBy(func(d email.NotificationData) bool {
+		return d.RecipientEmail == "finops-a@example.com" && assert.ObjectsAreEqual([]string{"finops-b@example.com"}, d.CCEmails) &&
+			d.ApprovalStage == "FinOps (stage 2 of 2)"
+	})).Return(nil).Once()
+
+	store.On("RecordApprovalStageDecision", ctx, mock.MatchedBy(func(d *config.ApprovalStageDecision) bool {
+		return d.StageIndex == 0 && d.ApproverEmail == "lead@example.com"
+	})).Return(nil)
+	store.On("ListApprovalStageDecisions", ctx, "exec-chain").Return([]config.ApprovalStageDecision{
+		{StageIndex: 0, ApproverEmail: "lead@example.com"},
+	}, nil)
+	store.On("AdvanceExecutionApprovalChain", ctx, "exec-chain", 0).Return(true, nil)
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-chain", "Lead@Example.com", nil)
+	var pending *ApprovalStagePendingError
+	require.ErrorAs(t, err, &pending)
+	assert.Equal(t, "FinOps", pending.Stage)
+	assert.Equal(t, 2, pending.StageNumber)
+	assert.Equal(t, 2, pending.Need)
+
+	require.Len(t, tokens, 2, "each stage mints its own token")
+	assert.NotEqual(t, tokens[0], tokens[1])
+	store.AssertExpectations(t)
+	sender.AssertExpectations(t)
+}
+
+func TestStartApprovalChain_ReportsFailedStageEmail(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(nil, nil)
+	store.On("ListApprovalChains", ctx).Return([]config.ApprovalChain{tieredChain()}, nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+	store.On("StartExecutionApprovalChain", ctx, mock.Anything).Return(true, nil)
+	store.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).Return(nil)
+	sender.On("SendPurchaseApprovalRequest", ctx, mock.Anything).Return(errors.New("SES throttled"))
+
+	run, err := manager.StartApprovalChain(ctx, "exec-chain")
+	var emailErr *ApprovalStageEmailError
+	require.ErrorAs(t, err, &emailErr)
+	assert.ErrorContains(t, err, "SES throttled")
+	require.NotNil(t, run, "the chain started even though its email failed")
+	assert.Equal(t, 0, run.CurrentStage)
+	store.AssertCalled(t, "SavePurchaseExecution", ctx, mock.Anything)
+}
+
+func TestEnforceApprovalPolicy_NextStageEmailFailureIsReported(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(0), nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+	store.On("RecordApprovalStageDecision", ctx, mock.Anything).Return(nil)
+	store.On("ListApprovalStageDecisions", ctx, "exec-chain").Return([]config.ApprovalStageDecision{
+		{StageIndex: 0, ApproverEmail: "lead@example.com"},
+	}, nil)
+	store.On("AdvanceExecutionApprovalChain", ctx, "exec-chain", 0).Return(true, nil)
+	store.On("SavePurchaseExecution", ctx, mock.AnythingOfType("*config.PurchaseExecution")).Return(nil)
+	sender.On("SendPurchaseApprovalRequest", ctx, mock.Anything).Return(errors.New("SES throttled"))
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-chain", "lead@example.com", nil)
+	var pending *ApprovalStagePendingError
+	require.ErrorAs(t, err, &pending)
+	assert.Equal(t, "FinOps", pending.Stage)
+	var emailErr *ApprovalStageEmailError
+	require.ErrorAs(t, pending.EmailErr, &emailErr)
+	assert.ErrorContains(t, emailErr, "SES throttled")
+}
+
+func TestEnforceApprovalPolicy_RefusesApproverOutsideActiveStage(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(1), nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-chain", "lead@example.com", nil)
+	var refused *ApprovalChainError
+	require.ErrorAs(t, err, &refused)
+	assert.Equal(t, "FinOps", refused.Stage)
+	store.AssertNotCalled(t, "RecordApprovalStageDecision", mock.Anything, mock.Anything)
+}
+
+func TestEnforceApprovalPolicy_StageWaitsForQuorum(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(1), nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+	store.On("ListApprovalStageDecisions", ctx, "exec-chain").Return([]config.ApprovalStageDecision{
+		{StageIndex: 0, ApproverEmail: "lead@example.com"},
+		{StageIndex: 1, ApproverEmail: "finops-a@example.com"},
+	}, nil)
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-chain", "finops-a@example.com", nil)
+	var pending *ApprovalStagePendingError
+	require.ErrorAs(t, err, &pending)
+	assert.Equal(t, 1, pending.Have)
+	assert.Equal(t, 2, pending.Need)
+	store.AssertNotCalled(t, "AdvanceExecutionApprovalChain", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnforceApprovalPolicy_LastStageLetsApprovalProceed(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(1), nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+	store.On("ListApprovalStageDecisions", ctx, "exec-chain").Return([]config.ApprovalStageDecision{
+		{StageIndex: 1, ApproverEmail: "finops-a@example.com"},
+		{StageIndex: 1, ApproverEmail: "finops-b@example.com"},
+	}, nil)
+	store.On("AdvanceExecutionApprovalChain", ctx, "exec-chain", 1).Return(true, nil).Once()
+
+	require.NoError(t, manager.EnforceApprovalPolicy(ctx, "exec-chain", "finops-b@example.com", nil))
+
+	// Losing the advance to a concurrent sign-off must not also run the
+	// purchase.
+	store.On("AdvanceExecutionApprovalChain", ctx, "exec-chain", 1).Return(false, nil).Once()
+	require.ErrorContains(t, manager.EnforceApprovalPolicy(ctx, "exec-chain", "finops-b@example.com", nil), "concurrently")
+}
+
+func TestEnforceApprovalPolicy_NoMatchingChainIsANoOp(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(nil, nil)
+	azure := tieredChain()
+	azure.Provider = "azure"
+	store.On("ListApprovalChains", ctx).Return([]config.ApprovalChain{azure}, nil)
+	store.On("GetExecutionByID", ctx, "exec-chain").Return(chainExec(50000), nil)
+
+	require.NoError(t, manager.EnforceApprovalPolicy(ctx, "exec-chain", "anyone@example.com", nil))
+	store.AssertNotCalled(t, "StartExecutionApprovalChain", mock.Anything, mock.Anything)
+}
+
+func TestEnforceApprovalChainComplete(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(1), nil).Once()
+
+	err := manager.enforceApprovalChainComplete(ctx, chainExec(50000))
+	var refused *ApprovalChainError
+	require.ErrorAs(t, err, &refused)
+	assert.Contains(t, refused.Reason, "FinOps (stage 2 of 2) has not signed off")
+
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(2), nil).Once()
+	require.NoError(t, manager.enforceApprovalChainComplete(ctx, chainExec(50000)))
+}
+
+func TestEnforceApprovalChainComplete_UnstartedChainRefuses(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(nil, nil)
+	store.On("ListApprovalChains", ctx).Return([]config.ApprovalChain{tieredChain()}, nil).Once()
+
+	err := manager.enforceApprovalChainComplete(ctx, chainExec(50000))
+	var refused *ApprovalChainError
+	require.ErrorAs(t, err, &refused)
+	assert.Equal(t, "Team lead", refused.Stage)
+	assert.Contains(t, refused.Reason, "never started")
+	store.AssertNumberOfCalls(t, "StartExecutionApprovalChain", 0)
+
+	// Without a matching chain there is nothing to wait for.
+	azure := tieredChain()
+	azure.Provider = "azure"
+	store.On("ListApprovalChains", ctx).Return([]config.ApprovalChain{azure}, nil).Once()
+	require.NoError(t, manager.enforceApprovalChainComplete(ctx, chainExec(50000)))
+}
+
+func TestIsActiveStageApprover(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockConfigStore)
+	store.On("GetExecutionApprovalChain", ctx, "exec-chain").Return(runAt(1), nil)
+
+	ok, err := IsActiveStageApprover(ctx, store, "exec-chain", " FinOps-B@example.com ")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = IsActiveStageApprover(ctx, store, "exec-chain", "lead@example.com")
+	require.NoError(t, err)
+	assert.False(t, ok, "earlier stages' approvers act no longer")
+}
+
+func TestApprovalHeld(t *testing.T) {
+	assert.True(t, approvalHeld(&ApprovalsPendingError{}))
+	assert.True(t, approvalHeld(&ApprovalStagePendingError{}))
+	assert.False(t, approvalHeld(&ApprovalChainError{}))
+	assert.False(t, approvalHeld(nil))
+}
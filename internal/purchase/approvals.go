@@ -325,6 +325,23 @@ func (m *Manager) ApproveAndExecute(ctx context.Context, executionID, actor stri
 		return err
 	}
 
+	// Change freeze gate: an approval runs the purchase now, so while a
+	// freeze is in force it needs an administrator's override (recorded
+	// through the freeze override API). Checked before the purchase policy
+	// so a refused approval records no signature.
+	if err := m.CheckManualFreeze(ctx, executionID); err != nil {
+		logging.Warnf("purchase[%s]: ApproveAndExecute refused by change freeze: %v", executionID, err)
+		return err
+	}
+
+	// Purchase policy gate: a deny rule refuses the approval, and a
+	// require_approvals rule records this signature and holds the execution
+	// pending until enough distinct approvers have signed.
+	if err := m.EnforceApprovalPolicy(ctx, executionID, actor, transitionedBy); err != nil {
+		logging.Warnf("purchase[%s]: ApproveAndExecute held by purchase policy: %v", executionID, err)
+		return err
+	}
+
 	// transitionedBy carries the session user's UUID for human-initiated
 	// approvals (stamped onto transitioned_by); it is nil for token/SQS/system
 	// flows so transitioned_by = NULL on those hops. The human-readable actor
@@ -337,6 +354,12 @@ func (m *Manager) ApproveAndExecute(ctx context.Context, executionID, actor stri
 	}
 	logging.Infof("purchase[%s]: status transitioned to approved in %s", executionID, time.Since(t0))
 
+	// A renewal approved ahead of the expiry it replaces must not start
+	// early; park it for the scheduled-fire sweep instead of buying now.
+	if startAt := deferUntil(updated, time.Now()); startAt != nil {
+		return m.deferApprovedExecution(ctx, updated, *startAt, actor, transitionedBy)
+	}
+
 	if actor != "" {
 		a := actor
 		updated.ApprovedBy = &a
@@ -358,6 +381,54 @@ func (m *Manager) ApproveAndExecute(ctx context.Context, executionID, actor stri
 	return execErr
 }
 
+// deferUntil returns when an approved execution may run: the latest future
+// StartAt among its selected recs, so no commitment starts before its own
+// StartAt, or nil to run now. AWS Savings Plans are left out: they are
+// bought at approval with PurchaseOptions.StartTime and AWS queues them to
+// start then. Every other commitment starts when it is bought.
+func deferUntil(exec *config.PurchaseExecution, now time.Time) *time.Time {
+	var latest *time.Time
+	for _, i := range selectedIndices(exec.Recommendations) {
+		rec := exec.Recommendations[i]
+		if rec.StartAt == nil || !rec.StartAt.After(now) || queuesStart(rec) {
+			continue
+		}
+		if latest == nil || rec.StartAt.After(*latest) {
+			startAt := *rec.StartAt
+			latest = &startAt
+		}
+	}
+	return latest
+}
+
+// queuesStart reports whether the provider can buy rec now and start it at
+// rec.StartAt.
+func queuesStart(rec config.RecommendationRecord) bool {
+	return (rec.Provider == "" || rec.Provider == "aws") && common.IsSavingsPlan(common.ServiceType(rec.Service))
+}
+
+// deferApprovedExecution moves a just-approved execution to "scheduled" with
+// ScheduledExecutionAt = startAt, the same state the pre-fire delay path
+// leaves, so FireScheduledDelayedPurchases buys it at startAt and it can be
+// revoked at no cost until then. The approved -> scheduled hop is a CAS like
+// every other transition.
+func (m *Manager) deferApprovedExecution(ctx context.Context, exec *config.PurchaseExecution, startAt time.Time, actor string, transitionedBy *string) error {
+	scheduled, err := m.config.TransitionExecutionStatus(ctx, exec.ExecutionID, []string{"approved"}, "scheduled", transitionedBy)
+	if err != nil {
+		return fmt.Errorf("approve: defer until %s: %w", startAt.UTC().Format(time.RFC3339), err)
+	}
+	scheduled.ScheduledExecutionAt = &startAt
+	if actor != "" {
+		a := actor
+		scheduled.ApprovedBy = &a
+	}
+	if saveErr := m.config.SavePurchaseExecution(ctx, scheduled); saveErr != nil {
+		return fmt.Errorf("failed to stamp scheduled_execution_at on execution %s: %w", exec.ExecutionID, saveErr)
+	}
+	logging.Infof("purchase[%s]: approved ahead of its start; deferred to %s", exec.ExecutionID, startAt.UTC().Format(time.RFC3339))
+	return nil
+}
+
 // CancelExecution cancels a pending execution. actor carries the email of
 // the session-authenticated user who clicked cancel; verified by the
 // caller (HTTP path: authorizeApprovalAction; SQS path:
@@ -274,6 +274,57 @@ func TestManager_ApproveAndExecute_EmptyPlanID(t *testing.T) {
 	sender.AssertExpectations(t)
 }
 
+// TestManager_ApproveAndExecute_DefersUntilStartAt covers a renewal
+// approved before the expiry it replaces: the execution is parked in
+// "scheduled" at the rec's StartAt for the scheduled-fire sweep, and no
+// purchase runs now.
+func TestManager_ApproveAndExecute_DefersUntilStartAt(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+
+	startAt := time.Now().Add(10 * 24 * time.Hour).UTC().Truncate(time.Second)
+	approved := &config.PurchaseExecution{
+		ExecutionID: "exec-renewal",
+		Status:      "approved",
+		Recommendations: []config.RecommendationRecord{
+			{Provider: "aws", Service: "ec2", ResourceType: "m5.large", Count: 2, Selected: true, StartAt: &startAt},
+		},
+	}
+	scheduled := *approved
+	scheduled.Status = "scheduled"
+	store.On("TransitionExecutionStatus", ctx, "exec-renewal", approveFromStatuses, "approved", (*string)(nil)).Return(approved, nil)
+	store.On("TransitionExecutionStatus", ctx, "exec-renewal", []string{"approved"}, "scheduled", (*string)(nil)).Return(&scheduled, nil)
+	store.On("SavePurchaseExecution", ctx, mock.MatchedBy(func(e *config.PurchaseExecution) bool {
+		return e.Status == "scheduled" && e.ScheduledExecutionAt != nil && e.ScheduledExecutionAt.Equal(startAt) &&
+			e.ApprovedBy != nil && *e.ApprovedBy == "operator@example.com"
+	})).Return(nil).Once()
+
+	require.NoError(t, manager.ApproveAndExecute(ctx, "exec-renewal", "operator@example.com", nil))
+	store.AssertExpectations(t)
+	sender.AssertNumberOfCalls(t, "SendPurchaseConfirmation", 0)
+}
+
+func TestDeferUntil(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	soon, later, past := now.AddDate(0, 0, 5), now.AddDate(0, 0, 9), now.AddDate(0, 0, -1)
+	exec := func(recs ...config.RecommendationRecord) *config.PurchaseExecution {
+		return &config.PurchaseExecution{Recommendations: recs}
+	}
+
+	assert.Nil(t, deferUntil(exec(config.RecommendationRecord{Provider: "aws", Service: "ec2", Selected: true}), now), "no StartAt")
+	assert.Nil(t, deferUntil(exec(config.RecommendationRecord{Provider: "aws", Service: "ec2", Selected: true, StartAt: &past}), now), "StartAt passed")
+	assert.Nil(t, deferUntil(exec(config.RecommendationRecord{Provider: "aws", Service: "savings-plans-compute", Selected: true, StartAt: &later}), now),
+		"AWS queues a Savings Plan's start itself")
+	assert.Nil(t, deferUntil(exec(config.RecommendationRecord{Provider: "azure", Service: "compute", StartAt: &later}), now), "unselected")
+
+	got := deferUntil(exec(
+		config.RecommendationRecord{Provider: "azure", Service: "compute", Selected: true, StartAt: &soon},
+		config.RecommendationRecord{Provider: "gcp", Service: "compute", Selected: true, StartAt: &later},
+	), now)
+	require.NotNil(t, got)
+	assert.Equal(t, later, *got, "the latest start, so nothing starts early")
+}
+
 func TestManager_ApproveAndExecute_SkipsTokenCheck(t *testing.T) {
 	// Session-authed path: ApproveAndExecute is called directly without a
 	// token, after the caller has run RBAC. Verifies the entry point works
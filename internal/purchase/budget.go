@@ -0,0 +1,163 @@
+package purchase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// BudgetReserver is the budget.Reserver backed by the config store's
+// commitment budget ledger. The purchase manager reserves through it, and
+// the other purchase paths (ladder layer purchases, the MCP tools) are
+// wired with it.
+type BudgetReserver struct {
+	store config.StoreInterface
+}
+
+// NewBudgetReserver returns a BudgetReserver over store.
+func NewBudgetReserver(store config.StoreInterface) *BudgetReserver {
+	return &BudgetReserver{store: store}
+}
+
+var _ budget.Reserver = (*BudgetReserver)(nil)
+
+// Reserve implements budget.Reserver.
+func (r *BudgetReserver) Reserve(ctx context.Context, req budget.Request) (string, error) {
+	res := &config.BudgetReservation{
+		Source:     req.Source,
+		Reference:  req.Reference,
+		Provider:   req.Provider,
+		AccountIDs: req.AccountIDs,
+		UpfrontUSD: req.UpfrontUSD,
+		HourlyUSD:  req.HourlyUSD,
+	}
+	if err := r.store.ReserveCommitmentBudget(ctx, res); err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// Commit implements budget.Reserver.
+func (r *BudgetReserver) Commit(ctx context.Context, id string, upfrontUSD, hourlyUSD float64) error {
+	return r.store.CommitBudgetReservation(ctx, id, upfrontUSD, hourlyUSD)
+}
+
+// Release implements budget.Reserver.
+func (r *BudgetReserver) Release(ctx context.Context, id, reason string) error {
+	return r.store.ReleaseBudgetReservation(ctx, id, reason)
+}
+
+// budgetRequest describes the recommendations at selected, about to be
+// bought for exec. The reference is exec's lineage key, so a re-drive of
+// the same execution (or account, after a multi-account fan-out) reuses
+// its reservation rather than counting twice.
+func budgetRequest(exec *config.PurchaseExecution, selected []int) budget.Request {
+	req := budget.Request{Source: budget.SourceExecution, Reference: idempotencyLineageKey(exec)}
+	providers := make(map[string]bool)
+	accounts := make(map[string]bool)
+	addAccount := func(id *string) {
+		if id != nil && *id != "" && !accounts[*id] {
+			accounts[*id] = true
+			req.AccountIDs = append(req.AccountIDs, *id)
+		}
+	}
+	addAccount(exec.CloudAccountID)
+	for _, i := range selected {
+		rec := exec.Recommendations[i]
+		upfront, hourly := recBudgetAmounts(rec)
+		req.UpfrontUSD += upfront
+		req.HourlyUSD += hourly
+		providers[rec.Provider] = true
+		addAccount(rec.CloudAccountID)
+	}
+	if len(providers) == 1 {
+		for p := range providers {
+			req.Provider = p
+		}
+	}
+	return req
+}
+
+// recBudgetAmounts is the upfront payment and new hourly commitment of
+// buying rec.
+func recBudgetAmounts(rec config.RecommendationRecord) (upfront, hourly float64) {
+	monthly := 0.0
+	if rec.MonthlyCost != nil {
+		monthly = *rec.MonthlyCost
+	}
+	return rec.UpfrontCost, budget.HourlyCommitment(rec.UpfrontCost, monthly, rec.Term)
+}
+
+// reserveBudget reserves the commitment budget the recommendations at
+// selected need before any of them reaches the cloud and returns the
+// reservation's ID. It returns a *budget.ExceededError when a budget's cap
+// would be exceeded, in which case nothing may be bought.
+func (m *Manager) reserveBudget(ctx context.Context, exec *config.PurchaseExecution, selected []int) (string, error) {
+	id, err := NewBudgetReserver(m.config).Reserve(ctx, budgetRequest(exec, selected))
+	if err != nil {
+		return "", fmt.Errorf("purchase[%s]: %w", exec.ExecutionID, err)
+	}
+	return id, nil
+}
+
+// settleBudget commits reservation id at what exec actually bought, or
+// releases it when nothing was bought. A settle failure is logged, not
+// returned: the purchase has already happened, and the reservation stays
+// counted at the amounts reserved, which errs on the side of spending
+// less.
+func (m *Manager) settleBudget(ctx context.Context, exec *config.PurchaseExecution, id string, purchaseErrors []string) {
+	reserver := NewBudgetReserver(m.config)
+	if !anyRecPurchased(exec.Recommendations) {
+		reason := "purchase failed"
+		if len(purchaseErrors) > 0 {
+			reason = strings.Join(purchaseErrors, "; ")
+		}
+		if err := reserver.Release(ctx, id, reason); err != nil {
+			logging.Errorf("purchase[%s]: failed to release commitment budget reservation %s: %v", exec.ExecutionID, id, err)
+		}
+		return
+	}
+	var upfront, hourly float64
+	for i := range exec.Recommendations {
+		if rec := exec.Recommendations[i]; rec.Selected && rec.Purchased {
+			u, h := recBudgetAmounts(rec)
+			upfront += u
+			hourly += h
+		}
+	}
+	if err := reserver.Commit(ctx, id, upfront, hourly); err != nil {
+		logging.Errorf("purchase[%s]: failed to commit commitment budget reservation %s: %v", exec.ExecutionID, id, err)
+	}
+}
+
+// CreditRevokedPurchase gives a revoked or returned purchase's amounts
+// back to the commitment budgets. The credit is dated at the purchase so
+// it lands in the period the purchase counted against, and crediting the
+// same purchase twice is a no-op.
+func CreditRevokedPurchase(ctx context.Context, store config.StoreInterface, record *config.PurchaseHistoryRecord) error {
+	upfront, hourly := recBudgetAmounts(config.RecommendationRecord{
+		UpfrontCost: record.UpfrontCost,
+		MonthlyCost: record.MonthlyCost,
+		Term:        record.Term,
+	})
+	credit := &config.BudgetReservation{
+		Source:        budget.SourceRevocation,
+		Reference:     record.PurchaseID,
+		Provider:      record.Provider,
+		UpfrontUSD:    -upfront,
+		HourlyUSD:     -hourly,
+		ReservedAt:    record.Timestamp,
+		ReleaseReason: "purchase revoked",
+	}
+	if record.CloudAccountID != nil && *record.CloudAccountID != "" {
+		credit.AccountIDs = []string{*record.CloudAccountID}
+	}
+	if err := store.CreditCommitmentBudget(ctx, credit); err != nil {
+		return fmt.Errorf("failed to credit commitment budget for revoked purchase %s: %w", record.PurchaseID, err)
+	}
+	return nil
+}
@@ -50,6 +50,7 @@ func TestMapServiceType_AllBranches(t *testing.T) {
 		{"opensearch", common.ServiceOpenSearch},
 		{"redshift", common.ServiceRedshift},
 		{"memorydb", common.ServiceMemoryDB},
+		{"dynamodb", common.ServiceDynamoDB},
 		{"savingsplans", common.ServiceSavingsPlansAll},
 		// Issue #85: the legacy hyphenated form is still accepted as a
 		// backwards-compat alias so Lambda-scheduled purchase executions
@@ -1064,6 +1065,61 @@ func TestManager_SavePurchaseHistory_RevocationWindow(t *testing.T) {
 	}
 }
 
+// TestManager_SavePurchaseHistory_MarketplaceOffering asserts a Reserved
+// Instance Marketplace purchase records the seller's remaining term as
+// TermEnd and is stamped "standard" (only Standard RIs are listed there),
+// while an ordinary EC2 purchase keeps a nil TermEnd and "convertible".
+func TestManager_SavePurchaseHistory_MarketplaceOffering(t *testing.T) {
+	endDate := time.Now().Add(90 * 24 * time.Hour)
+	tests := []struct {
+		name      string
+		result    common.PurchaseResult
+		wantClass string
+		wantEnd   *time.Time
+	}{
+		{
+			name: "marketplace offering",
+			result: common.PurchaseResult{
+				Success: true, CommitmentID: "ri-market", EndDate: &endDate,
+				Recommendation: common.Recommendation{Details: &common.ComputeDetails{MarketplaceOfferingID: "offer-1"}},
+			},
+			wantClass: "standard",
+			wantEnd:   &endDate,
+		},
+		{
+			name: "AWS-sold offering",
+			result: common.PurchaseResult{
+				Success: true, CommitmentID: "ri-aws",
+				Recommendation: common.Recommendation{Details: &common.ComputeDetails{Platform: "Linux/UNIX"}},
+			},
+			wantClass: "convertible",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockStore := new(MockConfigStore)
+			t.Cleanup(func() { mockStore.AssertExpectations(t) })
+
+			var captured *config.PurchaseHistoryRecord
+			mockStore.On("SavePurchaseHistory", ctx, mock.AnythingOfType("*config.PurchaseHistoryRecord")).
+				Run(func(args mock.Arguments) {
+					captured = args.Get(1).(*config.PurchaseHistoryRecord)
+				}).Return(nil)
+
+			manager := &Manager{config: mockStore}
+			rec := config.RecommendationRecord{Provider: "aws", Service: "ec2", ResourceType: "m5.large", Region: "us-east-1", Count: 1, Term: 1}
+			err := manager.savePurchaseHistory(ctx, &config.PurchaseExecution{ExecutionID: "exec-mkt"},
+				&config.PurchasePlan{Name: "Direct purchase"}, rec, tc.result, "acct-1")
+			require.NoError(t, err)
+			require.NotNil(t, captured)
+			assert.Equal(t, tc.wantClass, captured.OfferingClass)
+			assert.Equal(t, tc.wantEnd, captured.TermEnd)
+		})
+	}
+}
+
 // TestManager_ExecuteSinglePurchase_DetailsByService is the regression guard
 // for issue #453. Before the fix, executeSinglePurchase assigned a value-
 // typed common.DatabaseDetails (and only when rec.Engine was non-empty);
@@ -0,0 +1,174 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// ErrDoubleCoverage is returned (wrapped) by executePurchase when a plan
+// execution would buy one side of an RI / Savings Plan overlap while the
+// other side is bought too and the plan does not allow double coverage.
+var ErrDoubleCoverage = errors.New("plan would buy double coverage")
+
+// PlanBuys reports whether plan buys rec: one of its enabled services is
+// rec's provider and service, with rec's term and payment when the service
+// pins them, and admits rec through its engine, region, resource-type and
+// min-count filters.
+func PlanBuys(plan *config.PurchasePlan, rec *config.RecommendationRecord) bool {
+	for _, svc := range plan.Services {
+		if !svc.Enabled || svc.Provider != rec.Provider || svc.Service != rec.Service {
+			continue
+		}
+		if (svc.Term > 0 && svc.Term != rec.Term) || (svc.Payment != "" && svc.Payment != rec.Payment) {
+			continue
+		}
+		if svc.Admits(rec) {
+			return true
+		}
+	}
+	return false
+}
+
+// DoubleCoverage describes each RI / Savings Plan overlap the scheduler
+// annotated on recs (the cached recommendations of accountIDs) where buys
+// takes one side while the other side is taken by buys too or bought by
+// another enabled plan on the recommendation's account. buys is what plan
+// is about to buy. The result is sorted and capped at three entries plus
+// an "and N more".
+func DoubleCoverage(ctx context.Context, store config.StoreInterface, plan *config.PurchasePlan, accountIDs []string, recs []config.RecommendationRecord, buys func(*config.RecommendationRecord) bool) ([]string, error) {
+	byID := make(map[string]*config.RecommendationRecord, len(recs))
+	for i := range recs {
+		byID[recs[i].ID] = &recs[i]
+	}
+	// Pair each overlap-annotated RI with the Savings Plans covering it,
+	// keeping only the pairs plan buys a side of, so the other plans are
+	// listed only when there is something to check them against.
+	type overlap struct{ ri, sp *config.RecommendationRecord }
+	var pairs []overlap
+	for i := range recs {
+		ri := &recs[i]
+		if ri.OverlapCoveredPct == nil {
+			continue
+		}
+		for _, spID := range ri.OverlapWith {
+			if sp := byID[spID]; sp != nil && (buys(ri) || buys(sp)) {
+				pairs = append(pairs, overlap{ri: ri, sp: sp})
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	others := map[string][]config.PurchasePlan{}
+	for _, accountID := range accountIDs {
+		plans, err := store.ListPurchasePlans(ctx, config.PurchasePlanFilter{AccountIDs: []string{accountID}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list plans: %w", err)
+		}
+		for i := range plans {
+			if plans[i].Enabled && !plans[i].ShadowMode && plans[i].ID != plan.ID {
+				others[accountID] = append(others[accountID], plans[i])
+			}
+		}
+	}
+	bought := func(rec *config.RecommendationRecord) bool {
+		if buys(rec) {
+			return true
+		}
+		for _, accountID := range recAccounts(rec, accountIDs) {
+			for i := range others[accountID] {
+				if PlanBuys(&others[accountID][i], rec) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var conflicts []string
+	var last *config.RecommendationRecord
+	for _, p := range pairs {
+		if p.ri == last {
+			continue
+		}
+		if (buys(p.ri) && bought(p.sp)) || (buys(p.sp) && bought(p.ri)) {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s %s overlaps %s (%.0f%% of its usage covered)",
+				p.ri.Service, p.ri.ResourceType, p.ri.Region, p.sp.Service, *p.ri.OverlapCoveredPct))
+			last = p.ri
+		}
+	}
+	sort.Strings(conflicts)
+	if len(conflicts) > 3 {
+		conflicts = append(conflicts[:3], fmt.Sprintf("and %d more", len(conflicts)-3))
+	}
+	return conflicts, nil
+}
+
+// recAccounts returns the accounts among accountIDs rec belongs to: its
+// own cloud account, or all of them for a recommendation collected with
+// ambient credentials.
+func recAccounts(rec *config.RecommendationRecord, accountIDs []string) []string {
+	if rec.CloudAccountID == nil {
+		return accountIDs
+	}
+	return []string{*rec.CloudAccountID}
+}
+
+// checkDoubleCoverage refuses a plan execution whose selected
+// recommendations would buy one side of an RI / Savings Plan overlap while
+// the other side is selected too or bought by another enabled plan on the
+// same account (see DoubleCoverage), unless the plan allows double
+// coverage. The overlaps come from the cached recommendations of the plan's
+// accounts, with the execution's own selection added for recommendations
+// the cache no longer holds.
+func (m *Manager) checkDoubleCoverage(ctx context.Context, exec *config.PurchaseExecution, plan *config.PurchasePlan) error {
+	selected := selectedIndices(exec.Recommendations)
+	if plan.AllowDoubleCoverage || len(selected) == 0 {
+		return nil
+	}
+	accounts, err := m.config.GetPlanAccounts(ctx, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load plan accounts for plan %s: %w", plan.ID, err)
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+	accountIDs := make([]string, len(accounts))
+	for i := range accounts {
+		accountIDs[i] = accounts[i].ID
+	}
+	recs, err := m.config.ListStoredRecommendations(ctx, config.RecommendationFilter{AccountIDs: accountIDs})
+	if err != nil {
+		return fmt.Errorf("failed to load recommendations for the double-coverage check: %w", err)
+	}
+	buying := make(map[string]bool, len(selected))
+	cached := make(map[string]bool, len(recs))
+	for i := range recs {
+		cached[recs[i].ID] = true
+	}
+	for _, i := range selected {
+		rec := exec.Recommendations[i]
+		buying[rec.ID] = true
+		if !cached[rec.ID] {
+			recs = append(recs, rec)
+		}
+	}
+	conflicts, err := DoubleCoverage(ctx, m.config, plan, accountIDs, recs, func(rec *config.RecommendationRecord) bool {
+		return buying[rec.ID]
+	})
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	logging.Warnf("purchase[%s]: plan %q would buy double coverage: %s", exec.ExecutionID, plan.Name, strings.Join(conflicts, "; "))
+	return fmt.Errorf("%w: %s; set allow_double_coverage on the plan to override", ErrDoubleCoverage, strings.Join(conflicts, "; "))
+}
@@ -0,0 +1,82 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+)
+
+const (
+	overlapRIID = "aws|111111111111|ec2|us-east-1|m5.large||1|no-upfront"
+	overlapSPID = "aws|111111111111|savings-plans-compute|||1|no-upfront"
+)
+
+// overlapRecs returns a cached EC2 recommendation the scheduler marked as
+// half covered by a cached Compute Savings Plan recommendation.
+func overlapRecs() []config.RecommendationRecord {
+	pct := 50.0
+	return []config.RecommendationRecord{
+		{ID: overlapRIID, Provider: "aws", Service: "ec2", Region: "us-east-1", ResourceType: "m5.large", Count: 4,
+			Term: 1, Payment: "no-upfront", OverlapCoveredPct: &pct, OverlapWith: []string{overlapSPID}},
+		{ID: overlapSPID, Provider: "aws", Service: "savings-plans-compute", Count: 1, Term: 1, Payment: "no-upfront"},
+	}
+}
+
+func overlapExec() *config.PurchaseExecution {
+	rec := overlapRecs()[0]
+	rec.Selected = true
+	return &config.PurchaseExecution{PlanID: "plan-1", ExecutionID: "exec-overlap", Status: "running",
+		Recommendations: []config.RecommendationRecord{rec}}
+}
+
+func TestExecutePurchase_RefusesDoubleCoverage(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	ec2Plan := &config.PurchasePlan{ID: "plan-1", Name: "EC2", Enabled: true,
+		Services: map[string]config.ServiceConfig{"aws/ec2": {Provider: "aws", Service: "ec2", Enabled: true}}}
+	spPlan := config.PurchasePlan{ID: "plan-2", Name: "SP", Enabled: true,
+		Services: map[string]config.ServiceConfig{"aws/savings-plans-compute": {Provider: "aws", Service: "savings-plans-compute", Enabled: true}}}
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(ec2Plan, nil)
+	store.On("GetPlanAccounts", ctx, "plan-1").Return([]config.CloudAccount{{ID: "acct-a", Provider: "aws"}}, nil)
+	store.On("ListStoredRecommendations", ctx, config.RecommendationFilter{AccountIDs: []string{"acct-a"}}).Return(overlapRecs(), nil)
+	store.On("ListPurchasePlans", ctx, config.PurchasePlanFilter{AccountIDs: []string{"acct-a"}}).
+		Return([]config.PurchasePlan{*ec2Plan, spPlan}, nil)
+
+	err := manager.executePurchase(ctx, overlapExec())
+	require.ErrorIs(t, err, ErrDoubleCoverage)
+	assert.Contains(t, err.Error(), "ec2 m5.large us-east-1 overlaps savings-plans-compute (50% of its usage covered)")
+	store.AssertNumberOfCalls(t, "GetGlobalConfig", 0)
+	store.AssertNumberOfCalls(t, "ReserveCommitmentBudget", 0)
+}
+
+func TestCheckDoubleCoverage_HonoursPlanFiltersAndOverride(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetPlanAccounts", ctx, "plan-1").Return([]config.CloudAccount{{ID: "acct-a", Provider: "aws"}}, nil)
+	store.On("ListStoredRecommendations", ctx, config.RecommendationFilter{AccountIDs: []string{"acct-a"}}).Return(overlapRecs(), nil)
+	ec2Plan := &config.PurchasePlan{ID: "plan-1", Enabled: true,
+		Services: map[string]config.ServiceConfig{"aws/ec2": {Provider: "aws", Service: "ec2", Enabled: true}}}
+	// The Savings Plan plan only buys 3-year plans, so nothing else buys
+	// the 1-year Savings Plan the EC2 recommendation overlaps.
+	threeYearSP := config.PurchasePlan{ID: "plan-2", Enabled: true,
+		Services: map[string]config.ServiceConfig{"aws/savings-plans-compute": {Provider: "aws", Service: "savings-plans-compute", Enabled: true, Term: 3}}}
+	store.On("ListPurchasePlans", ctx, config.PurchasePlanFilter{AccountIDs: []string{"acct-a"}}).
+		Return([]config.PurchasePlan{*ec2Plan, threeYearSP}, nil)
+
+	require.NoError(t, manager.checkDoubleCoverage(ctx, overlapExec(), ec2Plan))
+
+	// Selecting the Savings Plan in the same execution buys both sides,
+	// unless the plan allows it.
+	exec := overlapExec()
+	sp := overlapRecs()[1]
+	sp.Selected = true
+	exec.Recommendations = append(exec.Recommendations, sp)
+	require.True(t, errors.Is(manager.checkDoubleCoverage(ctx, exec, ec2Plan), ErrDoubleCoverage))
+	ec2Plan.AllowDoubleCoverage = true
+	require.NoError(t, manager.checkDoubleCoverage(ctx, exec, ec2Plan))
+}
@@ -53,6 +53,12 @@ func (m *Manager) executePurchase(ctx context.Context, exec *config.PurchaseExec
 		if plan == nil {
 			return fmt.Errorf("plan not found: %s", exec.PlanID)
 		}
+		if plan.ShadowMode {
+			return m.recordShadowPurchases(ctx, exec, plan)
+		}
+		if err := m.checkDoubleCoverage(ctx, exec, plan); err != nil {
+			return err
+		}
 
 		// Fan out across plan accounts when accounts are configured.
 		if exec.CloudAccountID == nil {
@@ -567,6 +573,13 @@ func (m *Manager) processPurchaseRecommendations(ctx context.Context, exec *conf
 	logging.Infof("purchase[%s]: dispatching %d recommendation(s) for account=%s plan=%q",
 		exec.ExecutionID, len(selected), accountID, plan.Name)
 
+	// Reserve the commitment budget before any rec reaches the cloud; a
+	// budget that would be exceeded refuses the whole batch.
+	reservationID, err := m.reserveBudget(ctx, exec, selected)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
 	// Each rec runs in its own goroutine so a multi-rec execution that
 	// spans providers (AWS RI + Azure reservation + GCP CUD) or services
 	// (EC2 + RDS + ElastiCache + OpenSearch within AWS) makes its cloud
@@ -604,6 +617,7 @@ func (m *Manager) processPurchaseRecommendations(ctx context.Context, exec *conf
 			// mutation across goroutines).
 			recOpts := opts
 			recOpts.IdempotencyToken = common.DeriveIdempotencyToken(idempotencyLineageKey(exec), i)
+			recOpts.StartTime = rec.StartAt
 			purchaseResult, err := m.executeSinglePurchase(ctx, rec, provCfg, recOpts)
 			return recPurchaseOutcome{index: i, purchase: purchaseResult, err: err}, nil
 		}, getMaxAccountParallelism())
@@ -614,6 +628,7 @@ func (m *Manager) processPurchaseRecommendations(ctx context.Context, exec *conf
 	// purchaseErrors (05-N2). Do NOT move the aggregation inside the FanOut
 	// closure or run it concurrently with the fan-out.
 	totalSavings, totalUpfront, purchaseErrors = m.aggregatePurchaseOutcomes(ctx, exec, plan, accountID, results)
+	m.settleBudget(ctx, exec, reservationID, purchaseErrors)
 	return totalSavings, totalUpfront, purchaseErrors, nil
 }
 
@@ -914,14 +929,22 @@ func (m *Manager) savePurchaseHistory(ctx context.Context, exec *config.Purchase
 		// Stamp the in-app free-cancel window so the History UI can offer the
 		// Revoke button (issue #290). Azure-only in Phase 1; nil for AWS/GCP.
 		RevocationWindowClosesAt: config.RevocationWindowClosesAtFor(rec.Provider, purchasedAt),
+		// Marketplace RIs run for the seller's remaining term, not Term
+		// years; nil for every other purchase.
+		TermEnd: result.EndDate,
 	}
 	// Stamp offering_class for AWS EC2 Reserved Instances. CUDly always
 	// purchases EC2 RIs with offering-class=Convertible (the EC2 client
 	// hard-wires OfferingClassTypeConvertible). Stamping at write time
 	// ensures the marketplace-sell guard can distinguish eligibility without
-	// an extra AWS round-trip for CUDly-purchased rows (issue #292).
+	// an extra AWS round-trip for CUDly-purchased rows (issue #292). RIs
+	// bought on the Reserved Instance Marketplace are the exception: only
+	// Standard RIs are listed there.
 	if rec.Provider == string(common.ProviderAWS) && rec.Service == string(common.ServiceEC2) {
 		historyRecord.OfferingClass = string(ec2types.OfferingClassTypeConvertible)
+		if isMarketplacePurchase(result) {
+			historyRecord.OfferingClass = string(ec2types.OfferingClassTypeStandard)
+		}
 	}
 	if err := m.config.SavePurchaseHistory(ctx, historyRecord); err != nil {
 		logging.Errorf("Failed to save history: %v", err)
@@ -930,6 +953,13 @@ func (m *Manager) savePurchaseHistory(ctx context.Context, exec *config.Purchase
 	return nil
 }
 
+// isMarketplacePurchase reports whether result bought a specific AWS
+// Reserved Instance Marketplace offering.
+func isMarketplacePurchase(result common.PurchaseResult) bool {
+	details, ok := result.Recommendation.Details.(*common.ComputeDetails)
+	return ok && details != nil && details.MarketplaceOfferingID != ""
+}
+
 func (m *Manager) sendPurchaseNotification(ctx context.Context, exec *config.PurchaseExecution, plan *config.PurchasePlan, totalSavings, totalUpfront float64) error {
 	data := m.buildPurchaseConfirmationData(exec, plan, totalSavings, totalUpfront)
 	return m.email.SendPurchaseConfirmation(ctx, data)
@@ -1157,6 +1187,7 @@ func mapServiceSlug(service string) (common.ServiceType, bool) {
 		"opensearch":  common.ServiceOpenSearch,
 		"redshift":    common.ServiceRedshift,
 		"memorydb":    common.ServiceMemoryDB,
+		"dynamodb":    common.ServiceDynamoDB,
 	}
 	svc, ok := slugs[service]
 	return svc, ok
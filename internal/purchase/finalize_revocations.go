@@ -69,6 +69,10 @@ func (m *Manager) FinalizeInFlightRevocations(ctx context.Context) (*FinalizeRes
 		} else {
 			logging.Infof("finalize_revocations: finalized in-flight revocation for purchase_id=%s", record.PurchaseID)
 			result.Finalized++
+			// Idempotent: a credit the revoke handler already wrote is kept.
+			if err := CreditRevokedPurchase(ctx, m.config, record); err != nil {
+				logging.Errorf("finalize_revocations: %v", err)
+			}
 		}
 	}
 
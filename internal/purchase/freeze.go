@@ -0,0 +1,193 @@
+package purchase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// LoadFreezeWindows returns the enabled change-freeze windows for the
+// freeze calendar.
+func LoadFreezeWindows(ctx context.Context, store config.StoreInterface) ([]freeze.Window, error) {
+	stored, err := store.ListFreezeWindows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load freeze windows: %w", err)
+	}
+	windows := make([]freeze.Window, 0, len(stored))
+	for i := range stored {
+		if stored[i].Enabled {
+			windows = append(windows, stored[i].Window())
+		}
+	}
+	return windows, nil
+}
+
+// freezeScope is where exec lands: the providers and CUDly cloud accounts
+// of its recommendations. A rec with no provider is an AWS rec, as
+// everywhere else.
+func freezeScope(exec *config.PurchaseExecution) freeze.Scope {
+	var s freeze.Scope
+	add := func(values *[]string, v string) {
+		for _, x := range *values {
+			if x == v {
+				return
+			}
+		}
+		*values = append(*values, v)
+	}
+	if exec.CloudAccountID != nil && *exec.CloudAccountID != "" {
+		add(&s.AccountIDs, *exec.CloudAccountID)
+	}
+	for i := range exec.Recommendations {
+		rec := &exec.Recommendations[i]
+		provider := rec.Provider
+		if provider == "" {
+			provider = "aws"
+		}
+		add(&s.Providers, provider)
+		if rec.CloudAccountID != nil && *rec.CloudAccountID != "" {
+			add(&s.AccountIDs, *rec.CloudAccountID)
+		}
+	}
+	return s
+}
+
+// FreezeInForce returns the change freeze in force over exec at now,
+// whether or not an administrator has overridden it, or nil when none is.
+func FreezeInForce(ctx context.Context, store config.StoreInterface, exec *config.PurchaseExecution, now time.Time) (*freeze.Decision, error) {
+	windows, err := LoadFreezeWindows(ctx, store)
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+	return freeze.Check(windows, freezeScope(exec), now), nil
+}
+
+// ActiveFreeze returns the change freeze holding exec back at now, or nil
+// when none is in force or an administrator overrode it for exec during
+// the window occurrence in force. An override from an earlier occurrence,
+// or recorded before the window now in force began, does not count.
+func ActiveFreeze(ctx context.Context, store config.StoreInterface, exec *config.PurchaseExecution, now time.Time) (*freeze.Decision, error) {
+	d, err := FreezeInForce(ctx, store, exec, now)
+	if err != nil || d == nil {
+		return d, err
+	}
+	override, err := store.GetLatestFreezeOverride(ctx, exec.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load freeze override: %w", err)
+	}
+	if override != nil && !override.CreatedAt.Before(d.Start) {
+		logging.Infof("purchase[%s]: change freeze %q overridden by %s: %s",
+			exec.ExecutionID, d.Window.Name, override.Actor, override.Reason)
+		return nil, nil
+	}
+	return d, nil
+}
+
+// CheckManualFreeze returns a *freeze.FrozenError when a change freeze
+// holds back the execution an approver is about to run or schedule now.
+// Manual purchases are never deferred: they need an administrator's
+// override.
+func (m *Manager) CheckManualFreeze(ctx context.Context, executionID string) error {
+	windows, err := LoadFreezeWindows(ctx, m.config)
+	if err != nil {
+		return fmt.Errorf("change freeze check: %w", err)
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	exec, err := m.config.GetExecutionByID(ctx, executionID)
+	if err != nil {
+		return fmt.Errorf("change freeze check: failed to load execution: %w", err)
+	}
+	d, err := ActiveFreeze(ctx, m.config, exec, time.Now())
+	if err != nil {
+		return fmt.Errorf("change freeze check: %w", err)
+	}
+	if d != nil {
+		return &freeze.FrozenError{Decision: *d}
+	}
+	return nil
+}
+
+// holdForFreeze defers or skips exec, a scheduled purchase that came due,
+// when a change freeze holds it back, and returns the freeze; nil means
+// exec may run. A deferred execution moves to "scheduled" with
+// ScheduledExecutionAt at the first allowed time, the state the pre-fire
+// delay path leaves, so FireScheduledDelayedPurchases buys it then and it
+// can be revoked until then. A skipped one is canceled. Either way the
+// notification topic is told why. Fail closed: an error holds exec back
+// too, and the caller counts it failed.
+func (m *Manager) holdForFreeze(ctx context.Context, exec *config.PurchaseExecution) (*freeze.Decision, error) {
+	d, err := ActiveFreeze(ctx, m.config, exec, time.Now())
+	if err != nil || d == nil {
+		return nil, err
+	}
+	if d.Skip() {
+		err = m.skipForFreeze(ctx, exec, d)
+	} else {
+		err = m.deferForFreeze(ctx, exec, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.notifyFreeze(ctx, exec, d)
+	return d, nil
+}
+
+// deferForFreeze parks exec until d.Until. The status and fire time are
+// written together, so a failure leaves exec as it was rather than
+// scheduled with no time to fire it.
+func (m *Manager) deferForFreeze(ctx context.Context, exec *config.PurchaseExecution, d *freeze.Decision) error {
+	until := d.Until
+	if _, err := m.config.DeferExecutionAtomic(ctx, exec.ExecutionID, []string{exec.Status}, until); err != nil {
+		return fmt.Errorf("change freeze %q: defer until %s: %w", d.Window.Name, until.UTC().Format(time.RFC3339), err)
+	}
+	logging.Infof("purchase[%s]: change freeze %q in force; deferred to %s",
+		exec.ExecutionID, d.Window.Name, until.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// skipForFreeze cancels exec, releasing its recommendations' suppressions
+// so they are offered again.
+func (m *Manager) skipForFreeze(ctx context.Context, exec *config.PurchaseExecution, d *freeze.Decision) error {
+	canceled, err := m.config.TransitionExecutionStatus(ctx, exec.ExecutionID, []string{exec.Status}, config.StatusCanceled, nil)
+	if err != nil {
+		return fmt.Errorf("change freeze %q: skip: %w", d.Window.Name, err)
+	}
+	canceled.Error = fmt.Sprintf("skipped: change freeze %q was in force", d.Window.Name)
+	if saveErr := m.config.SavePurchaseExecution(ctx, canceled); saveErr != nil {
+		logging.Errorf("AUDIT GAP: purchase[%s]: failed to record the change freeze skip: %v", exec.ExecutionID, saveErr)
+	}
+	if delErr := m.config.DeleteSuppressionsByExecution(ctx, exec.ExecutionID); delErr != nil {
+		logging.Errorf("purchase[%s]: failed to release suppressions after a change freeze skip: %v", exec.ExecutionID, delErr)
+	}
+	logging.Infof("purchase[%s]: change freeze %q in force; skipped", exec.ExecutionID, d.Window.Name)
+	return nil
+}
+
+// notifyFreeze tells the notification topic exec was deferred or skipped.
+// Best-effort: the execution has already been held back.
+func (m *Manager) notifyFreeze(ctx context.Context, exec *config.PurchaseExecution, d *freeze.Decision) {
+	var subject, outcome string
+	if d.Skip() {
+		subject = "CUDly: scheduled purchase skipped during a change freeze"
+		outcome = "It has been skipped, as the window's policy requires; its recommendations will be offered again."
+	} else {
+		subject = "CUDly: scheduled purchase deferred by a change freeze"
+		outcome = fmt.Sprintf("It has been deferred to %s, the first time no change freeze applies to it. It can be canceled until then.",
+			d.Until.UTC().Format(time.RFC1123))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Scheduled purchase %s came due during the change freeze %q (%s to %s).\n\n",
+		exec.ExecutionID, d.Window.Name, d.Start.UTC().Format(time.RFC1123), d.End.UTC().Format(time.RFC1123))
+	b.WriteString(outcome)
+	b.WriteString("\n\nAn administrator can override the freeze to buy it sooner.\n")
+	if err := m.email.SendNotification(ctx, subject, b.String()); err != nil {
+		logging.Errorf("purchase[%s]: failed to send the change freeze notification: %v", exec.ExecutionID, err)
+	}
+}
@@ -0,0 +1,190 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
+)
+
+// freezeWindowNow returns an enabled window in force now, ending in a day.
+func freezeWindowNow(action string) config.FreezeWindow {
+	now := time.Now().UTC().Truncate(time.Second)
+	return config.FreezeWindow{
+		ID: "win-1", Name: "fy-close", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour),
+		Recurrence: string(freeze.RecurrenceNone), Action: action, Enabled: true,
+	}
+}
+
+func freezeExec(id, status string) *config.PurchaseExecution {
+	return &config.PurchaseExecution{
+		PlanID: "plan-1", ExecutionID: id, Status: status,
+		Recommendations: []config.RecommendationRecord{{Provider: "aws", Service: "ec2", Selected: true}},
+	}
+}
+
+func TestFireScheduledDelayedPurchases_FreezeDefers(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+	window := freezeWindowNow(string(freeze.ActionDefer))
+
+	row := *freezeExec("exec-frozen", "scheduled")
+	store.On("GetScheduledExecutionsDue", ctx).Return([]config.PurchaseExecution{row}, nil)
+	store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+	store.On("DeferExecutionAtomic", ctx, "exec-frozen", []string{"scheduled"}, window.EndsAt).
+		Return(freezeExec("exec-frozen", "scheduled"), nil)
+	sender.On("SendNotification", ctx, "CUDly: scheduled purchase deferred by a change freeze",
+		mock.MatchedBy(func(body string) bool { return strings.Contains(body, `"fy-close"`) })).Return(nil)
+
+	result, err := manager.FireScheduledDelayedPurchases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deferred)
+	assert.Equal(t, 0, result.Fired)
+	store.AssertExpectations(t)
+	sender.AssertExpectations(t)
+	store.AssertNotCalled(t, "TransitionExecutionStatus", ctx, "exec-frozen", []string{"scheduled"}, "approved", (*string)(nil))
+}
+
+func TestProcessOneExecution_FreezeDeferFailureLeavesTheExecutionAlone(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+	window := freezeWindowNow(string(freeze.ActionDefer))
+
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(&config.PurchasePlan{ID: "plan-1", AutoPurchase: true}, nil)
+	store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+	store.On("DeferExecutionAtomic", ctx, "exec-frozen", []string{"pending"}, window.EndsAt).
+		Return(nil, errors.New("connection reset"))
+
+	result := &ProcessResult{}
+	manager.processOneExecution(ctx, *freezeExec("exec-frozen", "pending"), result)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 0, result.Deferred)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "connection reset")
+	// The status and the fire time are one write: nothing else touches the
+	// row, so it is never left scheduled without a time to fire it.
+	store.AssertNotCalled(t, "TransitionExecutionStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	store.AssertNotCalled(t, "SavePurchaseExecution", mock.Anything, mock.Anything)
+	// sender has no SendNotification expectation: a notification would fail
+	// the test.
+	sender.AssertExpectations(t)
+}
+
+func TestProcessOneExecution_FreezeSkips(t *testing.T) {
+	ctx := context.Background()
+	manager, store, sender := newApproveManager(t)
+
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(&config.PurchasePlan{ID: "plan-1", AutoPurchase: true}, nil)
+	store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{freezeWindowNow(string(freeze.ActionSkip))}, nil)
+	store.On("TransitionExecutionStatus", ctx, "exec-frozen", []string{"pending"}, config.StatusCanceled, (*string)(nil)).
+		Return(freezeExec("exec-frozen", config.StatusCanceled), nil)
+	store.On("SavePurchaseExecution", ctx, mock.MatchedBy(func(e *config.PurchaseExecution) bool {
+		return strings.Contains(e.Error, "fy-close")
+	})).Return(nil)
+	store.On("DeleteSuppressionsByExecution", ctx, "exec-frozen").Return(nil)
+	sender.On("SendNotification", ctx, "CUDly: scheduled purchase skipped during a change freeze", mock.Anything).Return(nil)
+
+	result := &ProcessResult{}
+	manager.processOneExecution(ctx, *freezeExec("exec-frozen", "pending"), result)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, 0, result.Processed)
+	store.AssertExpectations(t)
+	sender.AssertExpectations(t)
+}
+
+func TestActiveFreeze(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	exec := freezeExec("exec-1", "pending")
+
+	t.Run("a window for another provider does not apply", func(t *testing.T) {
+		store := new(MockConfigStore)
+		window := freezeWindowNow(string(freeze.ActionDefer))
+		window.Provider = "azure"
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+
+		d, err := ActiveFreeze(ctx, store, exec, now)
+		require.NoError(t, err)
+		assert.Nil(t, d)
+	})
+
+	t.Run("a disabled window does not apply", func(t *testing.T) {
+		store := new(MockConfigStore)
+		window := freezeWindowNow(string(freeze.ActionDefer))
+		window.Enabled = false
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+
+		d, err := ActiveFreeze(ctx, store, exec, now)
+		require.NoError(t, err)
+		assert.Nil(t, d)
+	})
+
+	t.Run("an override during the window lifts it", func(t *testing.T) {
+		store := new(MockConfigStore)
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{freezeWindowNow(string(freeze.ActionDefer))}, nil)
+		store.On("GetLatestFreezeOverride", ctx, "exec-1").
+			Return(&config.FreezeOverride{Actor: "admin@example.com", Reason: "board approved", CreatedAt: now}, nil)
+
+		d, err := ActiveFreeze(ctx, store, exec, now)
+		require.NoError(t, err)
+		assert.Nil(t, d)
+	})
+
+	t.Run("an override from before the window does not", func(t *testing.T) {
+		store := new(MockConfigStore)
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{freezeWindowNow(string(freeze.ActionDefer))}, nil)
+		store.On("GetLatestFreezeOverride", ctx, "exec-1").
+			Return(&config.FreezeOverride{CreatedAt: now.Add(-48 * time.Hour)}, nil)
+
+		d, err := ActiveFreeze(ctx, store, exec, now)
+		require.NoError(t, err)
+		require.NotNil(t, d)
+		assert.Equal(t, "fy-close", d.Window.Name)
+	})
+}
+
+func TestApproveAndExecute_FreezeNeedsOverride(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{freezeWindowNow(string(freeze.ActionDefer))}, nil)
+	store.On("GetExecutionByID", ctx, "exec-frozen").Return(freezeExec("exec-frozen", "pending"), nil)
+
+	err := manager.ApproveAndExecute(ctx, "exec-frozen", "a@example.com", nil)
+	var frozen *freeze.FrozenError
+	require.ErrorAs(t, err, &frozen)
+	assert.Equal(t, "fy-close", frozen.Window.Name)
+	store.AssertNumberOfCalls(t, "TransitionExecutionStatus", 0)
+}
+
+func TestCheckManualFreeze(t *testing.T) {
+	ctx := context.Background()
+	window := freezeWindowNow(string(freeze.ActionDefer))
+
+	t.Run("defer window still refuses a manual approval", func(t *testing.T) {
+		manager, store, _ := newApproveManager(t)
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+		store.On("GetExecutionByID", ctx, "exec-frozen").Return(freezeExec("exec-frozen", "pending"), nil)
+
+		var frozen *freeze.FrozenError
+		require.ErrorAs(t, manager.CheckManualFreeze(ctx, "exec-frozen"), &frozen)
+		assert.Equal(t, "fy-close", frozen.Window.Name)
+	})
+
+	t.Run("admin override lets it through", func(t *testing.T) {
+		manager, store, _ := newApproveManager(t)
+		store.On("ListFreezeWindows", ctx).Return([]config.FreezeWindow{window}, nil)
+		store.On("GetExecutionByID", ctx, "exec-frozen").Return(freezeExec("exec-frozen", "pending"), nil)
+		store.On("GetLatestFreezeOverride", ctx, "exec-frozen").
+			Return(&config.FreezeOverride{ExecutionID: "exec-frozen", Actor: "admin@example.com", Reason: "fy-close exception", CreatedAt: time.Now()}, nil)
+
+		require.NoError(t, manager.CheckManualFreeze(ctx, "exec-frozen"))
+	})
+}
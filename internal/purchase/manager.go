@@ -73,6 +73,10 @@ type ProcessResult struct {
 	Executed  int      `json:"executed"`
 	Failed    int      `json:"failed"`
 	Recovered int      `json:"recovered,omitempty"`
+	// Deferred and Skipped count due executions a change freeze pushed
+	// back to the first allowed time or canceled.
+	Deferred int `json:"deferred,omitempty"`
+	Skipped  int `json:"skipped,omitempty"`
 }
 
 // staleApprovedThreshold is how long an execution may sit in the "approved"
@@ -219,8 +223,17 @@ func isMultiAccountAckable(execErr error) bool {
 // the root row in "running" until the reaper failed it.
 func (m *Manager) executeAndFinalize(ctx context.Context, exec *config.PurchaseExecution) error {
 	// Last line of defense before money moves (issue #1718). Every executor
-	// entry point funnels through here, so one check covers all of them.
+	// entry point funnels through here, so one check covers all of them. The
+	// purchase policy is re-evaluated here for the same reason: scheduled
+	// executions reach the cloud without anyone approving them. An approval
+	// chain still short of a stage refuses too.
 	execErr := armedRedriveRefusal(exec)
+	if execErr == nil {
+		execErr = m.enforceExecutionPolicy(ctx, exec)
+	}
+	if execErr == nil {
+		execErr = m.enforceApprovalChainComplete(ctx, exec)
+	}
 	if execErr == nil {
 		execErr = m.executePurchase(ctx, exec)
 	}
@@ -607,6 +620,9 @@ func (m *Manager) RecoverStrandedApprovals(ctx context.Context) (int, error) {
 //   - web-submitted rows (Source == common.PurchaseSourceWeb, "cudly-web") must
 //     wait for the token-link approval path; the scheduler and SQS paths must
 //     never bypass that gate.
+//   - plan-less rows (no PlanID: direct purchases and the renewals drafted
+//     by the renewal_plan task) have no AutoPurchase setting to consult and
+//     always wait for an explicit approval.
 //   - All other pending/notified rows require the owning plan to have
 //     AutoPurchase=true. A plan-fetch error is propagated so the caller can
 //     fail closed rather than defaulting to "execute".
@@ -618,7 +634,7 @@ func (m *Manager) executableByScheduler(ctx context.Context, exec *config.Purcha
 	// persisted value is "cudly-web" (common.PurchaseSourceWeb), so the old
 	// literal never matched and web rows could be auto-executed without the
 	// token-link approval when AutoPurchase=true (fail-open on a money path).
-	if exec.Source == common.PurchaseSourceWeb {
+	if exec.Source == common.PurchaseSourceWeb || exec.PlanID == "" {
 		return false, nil
 	}
 	plan, err := m.config.GetPurchasePlan(ctx, exec.PlanID)
@@ -646,7 +662,22 @@ func (m *Manager) processOneExecution(ctx context.Context, exec config.PurchaseE
 		return
 	}
 	if !eligible {
-		logging.Infof("Skipping execution %s (AutoPurchase=false or source=web; requires explicit approval)", exec.ExecutionID)
+		logging.Infof("Skipping execution %s (AutoPurchase=false, no plan or source=web; requires explicit approval)", exec.ExecutionID)
+		return
+	}
+	// An approval chain that applies holds even an AutoPurchase row until
+	// its last stage signs, which runs the purchase itself.
+	held, holdErr := m.holdForApprovalChain(ctx, &exec)
+	if holdErr != nil {
+		result.Failed++
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: approval chain check failed: %v", exec.ExecutionID, holdErr))
+		return
+	}
+	if held {
+		return
+	}
+	// A change freeze in force defers or skips the row instead.
+	if m.heldByFreeze(ctx, &exec, result) {
 		return
 	}
 
@@ -682,6 +713,29 @@ func (m *Manager) processOneExecution(ctx context.Context, exec config.PurchaseE
 	result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", exec.ExecutionID, execErr))
 }
 
+// heldByFreeze defers or skips exec when a change freeze is in force over
+// it, counting it on result, and reports whether exec must not run now.
+// Fail closed: a freeze check error holds exec back and counts as failed;
+// a row another actor transitioned first is skipped like a lost claim.
+func (m *Manager) heldByFreeze(ctx context.Context, exec *config.PurchaseExecution, result *ProcessResult) bool {
+	d, err := m.holdForFreeze(ctx, exec)
+	switch {
+	case errors.Is(err, config.ErrExecutionNotInExpectedStatus):
+		return true
+	case err != nil:
+		result.Failed++
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: change freeze check failed: %v", exec.ExecutionID, err))
+		return true
+	case d == nil:
+		return false
+	case d.Skip():
+		result.Skipped++
+	default:
+		result.Deferred++
+	}
+	return true
+}
+
 // ProcessScheduledPurchases checks for and executes scheduled purchases.
 func (m *Manager) ProcessScheduledPurchases(ctx context.Context) (*ProcessResult, error) {
 	logging.Info("Processing scheduled purchases...")
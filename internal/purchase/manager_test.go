@@ -443,6 +443,31 @@ func TestManager_ProcessScheduledPurchases_WebSourceSkipped(t *testing.T) {
 		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
+// TestManager_ProcessScheduledPurchases_PlanlessSkipped verifies a due
+// plan-less row (a drafted renewal) waits for approval: there is no plan
+// to read AutoPurchase from, and GetPurchasePlan("") would fail the sweep
+// on every run.
+func TestManager_ProcessScheduledPurchases_PlanlessSkipped(t *testing.T) {
+	ctx := context.Background()
+	mockStore := new(MockConfigStore)
+
+	executions := []config.PurchaseExecution{
+		{ExecutionID: "exec-renewal", Status: "notified", ScheduledDate: time.Now().Add(-time.Hour)},
+	}
+	mockStore.On("GetStaleApprovedExecutions", ctx, mock.Anything).Return([]config.PurchaseExecution{}, nil)
+	mockStore.On("GetPendingExecutions", ctx).Return(executions, nil)
+
+	manager := &Manager{config: mockStore, email: new(MockEmailSender)}
+	result, err := manager.ProcessScheduledPurchases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Processed)
+	assert.Equal(t, 0, result.Failed, "a plan-less row is skipped, not failed")
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "TransitionExecutionStatus",
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 // TestManager_ProcessScheduledPurchases_GateCheckErrorCounted verifies that a
 // plan-fetch error during the AutoPurchase gate check counts as a failure and
 // does NOT proceed to claim+execute the row.
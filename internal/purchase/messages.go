@@ -125,6 +125,12 @@ func (m *Manager) handleExecutePurchase(ctx context.Context, msg *AsyncMessage)
 		return err
 	}
 
+	// A change freeze in force defers or skips the execution instead of
+	// buying it; the held row's message is acked.
+	if held, err := m.holdMessageForFreeze(ctx, execution); held || err != nil {
+		return err
+	}
+
 	// Atomically claim the row before touching the cloud (issue #1013). SQS
 	// delivery is at-least-once: a redelivered execute_purchase message (slow
 	// purchase whose visibility timeout expired, partial-batch replay, operator
@@ -155,6 +161,21 @@ func (m *Manager) handleExecutePurchase(ctx context.Context, msg *AsyncMessage)
 	return execErr
 }
 
+// holdMessageForFreeze runs holdForFreeze for handleExecutePurchase and
+// reports whether the execution must not run now. A row another actor
+// transitioned first is held without an error, like a lost claim; a check
+// error is returned so the message is redelivered.
+func (m *Manager) holdMessageForFreeze(ctx context.Context, exec *config.PurchaseExecution) (bool, error) {
+	d, err := m.holdForFreeze(ctx, exec)
+	if errors.Is(err, config.ErrExecutionNotInExpectedStatus) {
+		return true, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("change freeze check failed for execution %s: %w", exec.ExecutionID, err)
+	}
+	return d != nil, nil
+}
+
 // handleApproveMessage processes an approve message.
 //
 // SECURITY: closes the previous async-SQS bypass of the approver gate.
@@ -178,7 +199,13 @@ func (m *Manager) handleApproveMessage(ctx context.Context, msg *AsyncMessage) e
 	if err := m.verifyAsyncApprovalActor(ctx, msg); err != nil {
 		return err
 	}
-	return m.ApproveExecution(ctx, msg.ExecutionID, msg.Token, msg.ActorEmail)
+	err := m.ApproveExecution(ctx, msg.ExecutionID, msg.Token, msg.ActorEmail)
+	if approvalHeld(err) {
+		// The signature is recorded; redelivering would only replay it.
+		logging.Infof("purchase[%s]: async approval recorded, awaiting more approvers: %v", msg.ExecutionID, err)
+		return nil
+	}
+	return err
 }
 
 // handleCancelMessage processes a cancel message. Same hardening as
@@ -222,6 +249,9 @@ func (m *Manager) verifyAsyncApprovalActor(ctx context.Context, msg *AsyncMessag
 	if err != nil {
 		return err
 	}
+	if stageApprover, stageErr := IsActiveStageApprover(ctx, m.config, execution.ExecutionID, msg.ActorEmail); stageErr != nil || stageApprover {
+		return stageErr
+	}
 	return m.matchActorAgainstApprovers(ctx, msg.ActorEmail, execution.Recommendations)
 }
 
@@ -219,6 +219,7 @@ func TestSQSRedeliveryDoesNotDoubleExecute(t *testing.T) {
 	newPending := func() *config.PurchaseExecution {
 		return &config.PurchaseExecution{
 			ExecutionID:    "exec-dup",
+			PlanID:         "plan-x",
 			IdempotencyKey: "lineage-dup",
 			Status:         "pending",
 			Recommendations: []config.RecommendationRecord{
@@ -0,0 +1,344 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+// PolicyDeniedError is returned when the purchase policy in force blocks an
+// execution, at approval or just before the cloud is called.
+type PolicyDeniedError struct {
+	ExecutionID   string
+	Stage         string
+	PolicyVersion int
+	Reason        string
+}
+
+// Error implements the error interface.
+func (e *PolicyDeniedError) Error() string {
+	return fmt.Sprintf("purchase policy v%d denied execution %s at %s: %s", e.PolicyVersion, e.ExecutionID, e.Stage, e.Reason)
+}
+
+// ApprovalsPendingError is returned by the approval paths when a
+// require_approvals rule holds the execution for more approvers. The
+// approver's signature has been recorded; the execution stays pending.
+type ApprovalsPendingError struct {
+	ExecutionID string
+	Have, Need  int
+}
+
+// Error implements the error interface.
+func (e *ApprovalsPendingError) Error() string {
+	return fmt.Sprintf("execution %s has %d of the %d approvals the purchase policy requires; waiting for more approvers",
+		e.ExecutionID, e.Have, e.Need)
+}
+
+// BuildPolicyInput describes exec for the policy engine: its selected
+// recommendations, the accounts it targets and who is acting on it.
+// Exported so the dry-run endpoint evaluates exactly what the gate would.
+func BuildPolicyInput(ctx context.Context, store config.StoreInterface, exec *config.PurchaseExecution, stage string, actor policy.Actor) (policy.Input, error) {
+	accounts, err := resolvePolicyAccounts(ctx, store, exec)
+	if err != nil {
+		return policy.Input{}, err
+	}
+	in := policy.Input{
+		Stage: stage,
+		Execution: policy.Execution{
+			ID:               exec.ExecutionID,
+			PlanID:           exec.PlanID,
+			Source:           exec.Source,
+			Status:           exec.Status,
+			StepNumber:       exec.StepNumber,
+			TotalUpfrontCost: exec.TotalUpfrontCost,
+			EstimatedSavings: exec.EstimatedSavings,
+		},
+		Recommendations: make([]policy.Recommendation, 0, len(exec.Recommendations)),
+		Actor:           actor,
+		Accounts:        accounts,
+	}
+	for _, i := range selectedIndices(exec.Recommendations) {
+		in.Recommendations = append(in.Recommendations, policyRecommendation(exec.Recommendations[i]))
+	}
+	return in, nil
+}
+
+func policyRecommendation(rec config.RecommendationRecord) policy.Recommendation {
+	r := policy.Recommendation{
+		Provider:     rec.Provider,
+		Service:      rec.Service,
+		Region:       rec.Region,
+		ResourceType: rec.ResourceType,
+		Engine:       rec.Engine,
+		Payment:      rec.Payment,
+		Term:         rec.Term,
+		Count:        rec.Count,
+		UpfrontCost:  rec.UpfrontCost,
+		Savings:      rec.Savings,
+	}
+	if rec.MonthlyCost != nil {
+		r.MonthlyCost = *rec.MonthlyCost
+	}
+	if rec.CloudAccountID != nil {
+		r.AccountID = *rec.CloudAccountID
+	}
+	return r
+}
+
+// resolvePolicyAccounts returns the accounts exec targets: its own account
+// and those named on its recommendations or, when it names none, its
+// plan's accounts. An ID that no longer resolves is kept with only its ID
+// so rules on account IDs still see it.
+func resolvePolicyAccounts(ctx context.Context, store config.StoreInterface, exec *config.PurchaseExecution) ([]policy.Account, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id *string) {
+		if id != nil && *id != "" && !seen[*id] {
+			seen[*id] = true
+			ids = append(ids, *id)
+		}
+	}
+	add(exec.CloudAccountID)
+	for i := range exec.Recommendations {
+		add(exec.Recommendations[i].CloudAccountID)
+	}
+
+	accounts := make([]policy.Account, 0, len(ids))
+	if len(ids) == 0 && exec.PlanID != "" {
+		planAccounts, err := store.GetPlanAccounts(ctx, exec.PlanID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load plan accounts for policy evaluation: %w", err)
+		}
+		for i := range planAccounts {
+			accounts = append(accounts, policyAccount(&planAccounts[i]))
+		}
+		return accounts, nil
+	}
+	for _, id := range ids {
+		account, err := store.GetCloudAccount(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load account %s for policy evaluation: %w", id, err)
+		}
+		if account == nil {
+			accounts = append(accounts, policy.Account{ID: id})
+			continue
+		}
+		accounts = append(accounts, policyAccount(account))
+	}
+	return accounts, nil
+}
+
+func policyAccount(a *config.CloudAccount) policy.Account {
+	return policy.Account{ID: a.ID, Name: a.Name, Provider: a.Provider, ExternalID: a.ExternalID}
+}
+
+// loadPolicy returns the policy in force and its compiled rules, or nils
+// when no policy is in force or the version in force has no rules. A
+// policy that cannot be loaded or compiled is an error: a guardrail that
+// cannot be checked fails closed.
+func (m *Manager) loadPolicy(ctx context.Context) (*config.PurchasePolicy, *policy.Engine, error) {
+	p, err := m.config.GetLatestPurchasePolicy(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("purchase policy: failed to load: %w", err)
+	}
+	if p == nil || len(p.Rules) == 0 {
+		return nil, nil, nil
+	}
+	engine, err := policy.Compile(p.Rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("purchase policy v%d does not compile: %w", p.Version, err)
+	}
+	return p, engine, nil
+}
+
+// evaluatePolicy runs engine, compiled from p, against exec and records the
+// evaluation. Failing to record is an error too, so no purchase goes ahead
+// on a decision History cannot show.
+func (m *Manager) evaluatePolicy(ctx context.Context, p *config.PurchasePolicy, engine *policy.Engine, exec *config.PurchaseExecution, stage string, actor policy.Actor) (policy.Result, error) {
+	in, err := BuildPolicyInput(ctx, m.config, exec, stage, actor)
+	if err != nil {
+		return policy.Result{}, fmt.Errorf("purchase policy: %w", err)
+	}
+	res := engine.Evaluate(ctx, in)
+	if recErr := m.config.RecordPolicyEvaluation(ctx, &config.PolicyEvaluation{
+		ExecutionID:       exec.ExecutionID,
+		PolicyVersion:     p.Version,
+		Stage:             stage,
+		Outcome:           string(res.Outcome),
+		RequiredApprovals: res.RequiredApprovals,
+		Decisions:         res.Decisions,
+		Actor:             actor.Email,
+	}); recErr != nil {
+		return policy.Result{}, fmt.Errorf("purchase policy: %w", recErr)
+	}
+	logging.Infof("purchase[%s]: policy v%d at %s: %s (%d rule(s) fired)",
+		exec.ExecutionID, p.Version, stage, res.Outcome, len(res.Decisions))
+	return res, nil
+}
+
+// EnforceApprovalPolicy runs the approval-time gates for an approval of
+// executionID by actor: the purchase policy, then the execution's approval
+// chain. It returns a *PolicyDeniedError when a deny rule fires, an
+// *ApprovalsPendingError while a require_approvals rule is still short of
+// signatures, and the errors enforceApprovalChain documents while a chain
+// stage has not signed off; the approver's signature is recorded in each
+// case. ApproveAndExecute runs it itself; approval paths that do not go
+// through ApproveAndExecute (the pre-fire delay) must call it first.
+func (m *Manager) EnforceApprovalPolicy(ctx context.Context, executionID, actor string, actorUserID *string) error {
+	if err := m.enforcePurchasePolicy(ctx, executionID, actor, actorUserID); err != nil {
+		return err
+	}
+	return m.enforceApprovalChain(ctx, executionID, actor, actorUserID)
+}
+
+// enforcePurchasePolicy evaluates the purchase policy for an approval of
+// executionID by actor.
+func (m *Manager) enforcePurchasePolicy(ctx context.Context, executionID, actor string, actorUserID *string) error {
+	p, engine, err := m.loadPolicy(ctx)
+	if err != nil || p == nil {
+		return err
+	}
+	exec, err := m.config.GetExecutionByID(ctx, executionID)
+	if errors.Is(err, config.ErrNotFound) {
+		// Nothing to gate; the caller's status transition reports the
+		// missing row.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("purchase policy: failed to load execution: %w", err)
+	}
+
+	res, err := m.evaluatePolicy(ctx, p, engine, exec, policy.StageApproval, userActor(actor, actorUserID))
+	if err != nil {
+		return err
+	}
+	if res.Denied() {
+		return &PolicyDeniedError{ExecutionID: executionID, Stage: policy.StageApproval, PolicyVersion: p.Version, Reason: res.DenyReason()}
+	}
+	if res.RequiredApprovals < 2 {
+		return nil
+	}
+	return m.collectApproval(ctx, executionID, actor, actorUserID, res.RequiredApprovals)
+}
+
+// collectApproval records the actor's signature and returns an
+// *ApprovalsPendingError until need distinct approvers have signed.
+func (m *Manager) collectApproval(ctx context.Context, executionID, actor string, actorUserID *string, need int) error {
+	email := strings.ToLower(strings.TrimSpace(actor))
+	approver := email
+	if actorUserID != nil && *actorUserID != "" {
+		approver = *actorUserID
+	}
+	if approver == "" {
+		return fmt.Errorf("the purchase policy requires %d approvers but this approver could not be identified; sign in to approve", need)
+	}
+	if err := m.config.RecordExecutionApproval(ctx, &config.ExecutionApproval{
+		ExecutionID: executionID, Approver: approver, ApproverEmail: email, ApproverUserID: actorUserID,
+	}); err != nil {
+		return fmt.Errorf("purchase policy: %w", err)
+	}
+
+	have, err := m.distinctApprovers(ctx, executionID)
+	if err != nil {
+		return err
+	}
+	if have < need {
+		logging.Infof("purchase[%s]: policy approval %d of %d recorded", executionID, have, need)
+		return &ApprovalsPendingError{ExecutionID: executionID, Have: have, Need: need}
+	}
+	return nil
+}
+
+// distinctApprovers counts the people who signed executionID. Signatures
+// are keyed by user UUID on session paths and by email on token paths, so
+// two signatures are the same person when either the UUIDs or the emails
+// match.
+func (m *Manager) distinctApprovers(ctx context.Context, executionID string) (int, error) {
+	approvals, err := m.config.ListExecutionApprovals(ctx, executionID)
+	if err != nil {
+		return 0, fmt.Errorf("purchase policy: %w", err)
+	}
+	var people []config.ExecutionApproval
+	for _, a := range approvals {
+		duplicate := false
+		for _, p := range people {
+			if sameApprover(a, p) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			people = append(people, a)
+		}
+	}
+	return len(people), nil
+}
+
+func sameApprover(a, b config.ExecutionApproval) bool {
+	if a.Approver == b.Approver {
+		return true
+	}
+	if a.ApproverUserID != nil && b.ApproverUserID != nil && *a.ApproverUserID == *b.ApproverUserID {
+		return true
+	}
+	return a.ApproverEmail != "" && strings.EqualFold(a.ApproverEmail, b.ApproverEmail)
+}
+
+// enforceExecutionPolicy is the last policy check before the cloud is
+// called, on every executor path including scheduled purchases nobody
+// approved. It re-evaluates the policy in force, which may have changed
+// since approval, and refuses when a deny rule fires or a
+// require_approvals rule has fewer signatures than it needs.
+func (m *Manager) enforceExecutionPolicy(ctx context.Context, exec *config.PurchaseExecution) error {
+	p, engine, err := m.loadPolicy(ctx)
+	if err != nil || p == nil {
+		return err
+	}
+	res, err := m.evaluatePolicy(ctx, p, engine, exec, policy.StageExecution, executionActor(exec))
+	if err != nil {
+		return err
+	}
+	if res.Denied() {
+		return &PolicyDeniedError{ExecutionID: exec.ExecutionID, Stage: policy.StageExecution, PolicyVersion: p.Version, Reason: res.DenyReason()}
+	}
+	if res.RequiredApprovals < 2 {
+		return nil
+	}
+	have, err := m.distinctApprovers(ctx, exec.ExecutionID)
+	if err != nil {
+		return err
+	}
+	if have < res.RequiredApprovals {
+		return &PolicyDeniedError{
+			ExecutionID: exec.ExecutionID, Stage: policy.StageExecution, PolicyVersion: p.Version,
+			Reason: fmt.Sprintf("%d of the %d required approvals were given", have, res.RequiredApprovals),
+		}
+	}
+	return nil
+}
+
+// userActor describes a human approver.
+func userActor(email string, userID *string) policy.Actor {
+	a := policy.Actor{Email: email, Kind: policy.ActorUser}
+	if userID != nil {
+		a.UserID = *userID
+	}
+	return a
+}
+
+// executionActor describes who set exec running: the approver or
+// direct-executor when there was one, otherwise the scheduler.
+func executionActor(exec *config.PurchaseExecution) policy.Actor {
+	switch {
+	case exec.ApprovedBy != nil && *exec.ApprovedBy != "":
+		return userActor(*exec.ApprovedBy, exec.ExecutedByUserID)
+	case exec.ExecutedByUserID != nil:
+		return userActor("", exec.ExecutedByUserID)
+	}
+	return policy.Actor{Kind: policy.ActorSystem}
+}
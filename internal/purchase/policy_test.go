@@ -0,0 +1,228 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/policy"
+)
+
+func policyWith(rules ...policy.Rule) *config.PurchasePolicy {
+	return &config.PurchasePolicy{Version: 7, Rules: rules}
+}
+
+var (
+	denySandbox3yr = policy.Rule{
+		ID:         "no-sandbox-3yr",
+		Expression: `accounts.exists(a, a.name.startsWith("sandbox")) && recommendations.exists(r, r.term == 3)`,
+		Effect:     policy.EffectDeny,
+		Message:    "no 3-year commitments in sandbox accounts",
+	}
+	twoApprovers = policy.Rule{
+		ID:         "two-approvers",
+		Expression: `execution.total_upfront_cost > 1000`,
+		Effect:     policy.EffectRequireApprovals,
+		Approvals:  2,
+	}
+)
+
+func policyExec() *config.PurchaseExecution {
+	monthly := 10.0
+	account := "acct-1"
+	return &config.PurchaseExecution{
+		ExecutionID:      "exec-policy",
+		PlanID:           "plan-policy",
+		Status:           "pending",
+		TotalUpfrontCost: 5000,
+		CloudAccountID:   &account,
+		Recommendations: []config.RecommendationRecord{
+			{Provider: "aws", Service: "rds", Term: 3, Count: 1, UpfrontCost: 5000, MonthlyCost: &monthly, Selected: true},
+			{Provider: "aws", Service: "ec2", Term: 1, Count: 4, UpfrontCost: 900},
+		},
+	}
+}
+
+func TestBuildPolicyInput(t *testing.T) {
+	store := new(MockConfigStore)
+	store.GetCloudAccountFn = func(_ context.Context, id string) (*config.CloudAccount, error) {
+		if id == "acct-1" {
+			return &config.CloudAccount{ID: id, Name: "sandbox-dev", Provider: "aws", ExternalID: "111122223333"}, nil
+		}
+		return nil, nil
+	}
+	exec := policyExec()
+	gone := "acct-gone"
+	exec.Recommendations[0].CloudAccountID = &gone
+
+	in, err := BuildPolicyInput(context.Background(), store, exec, policy.StageApproval, policy.Actor{Kind: policy.ActorSystem})
+	require.NoError(t, err)
+
+	require.Len(t, in.Recommendations, 1, "only selected recommendations are evaluated")
+	assert.Equal(t, "rds", in.Recommendations[0].Service)
+	assert.Equal(t, 10.0, in.Recommendations[0].MonthlyCost)
+	assert.Equal(t, "acct-gone", in.Recommendations[0].AccountID)
+	assert.Equal(t, []policy.Account{
+		{ID: "acct-1", Name: "sandbox-dev", Provider: "aws", ExternalID: "111122223333"},
+		{ID: "acct-gone"},
+	}, in.Accounts)
+	assert.Equal(t, 5000.0, in.Execution.TotalUpfrontCost)
+}
+
+func TestBuildPolicyInput_FallsBackToPlanAccounts(t *testing.T) {
+	store := new(MockConfigStore)
+	store.GetPlanAccountsFn = func(_ context.Context, planID string) ([]config.CloudAccount, error) {
+		return []config.CloudAccount{{ID: "acct-9", Name: "prod"}}, nil
+	}
+	exec := policyExec()
+	exec.CloudAccountID = nil
+
+	in, err := BuildPolicyInput(context.Background(), store, exec, policy.StageExecution, policy.Actor{})
+	require.NoError(t, err)
+	assert.Equal(t, []policy.Account{{ID: "acct-9", Name: "prod"}}, in.Accounts)
+}
+
+func TestEnforceApprovalPolicy_NoPolicyIsANoOp(t *testing.T) {
+	manager, store, _ := newApproveManager(t)
+	// No GetExecutionByID expectation: without a policy nothing is loaded.
+	require.NoError(t, manager.EnforceApprovalPolicy(context.Background(), "exec-policy", "a@example.com", nil))
+	store.AssertNotCalled(t, "RecordPolicyEvaluation", mock.Anything, mock.Anything)
+}
+
+func TestEnforceApprovalPolicy_Deny(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.GetCloudAccountFn = func(_ context.Context, id string) (*config.CloudAccount, error) {
+		return &config.CloudAccount{ID: id, Name: "sandbox-dev"}, nil
+	}
+	store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(denySandbox3yr), nil)
+	store.On("GetExecutionByID", ctx, "exec-policy").Return(policyExec(), nil)
+	store.On("RecordPolicyEvaluation", ctx, mock.MatchedBy(func(e *config.PolicyEvaluation) bool {
+		return e.Outcome == "deny" && e.PolicyVersion == 7 && e.Stage == policy.StageApproval && e.Actor == "a@example.com"
+	})).Return(nil)
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-policy", "a@example.com", nil)
+	var denied *PolicyDeniedError
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, 7, denied.PolicyVersion)
+	assert.Contains(t, denied.Reason, "no 3-year commitments in sandbox accounts")
+	store.AssertExpectations(t)
+}
+
+func TestEnforceApprovalPolicy_RecordFailureFailsClosed(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(twoApprovers), nil)
+	store.On("GetExecutionByID", ctx, "exec-policy").Return(policyExec(), nil)
+	store.On("RecordPolicyEvaluation", ctx, mock.Anything).Return(errors.New("db down"))
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-policy", "a@example.com", nil)
+	require.ErrorContains(t, err, "db down")
+}
+
+func TestEnforceApprovalPolicy_CollectsDistinctApprovers(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(twoApprovers), nil)
+	store.On("GetExecutionByID", ctx, "exec-policy").Return(policyExec(), nil)
+	store.On("RecordPolicyEvaluation", ctx, mock.Anything).Return(nil)
+	uid := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	store.On("RecordExecutionApproval", ctx, mock.MatchedBy(func(a *config.ExecutionApproval) bool {
+		return a.Approver == uid && a.ApproverEmail == "lead@example.com"
+	})).Return(nil)
+
+	// The same person signed earlier through the email link: one approver.
+	store.On("ListExecutionApprovals", ctx, "exec-policy").Return([]config.ExecutionApproval{
+		{Approver: "lead@example.com", ApproverEmail: "lead@example.com"},
+		{Approver: uid, ApproverEmail: "lead@example.com", ApproverUserID: &uid},
+	}, nil).Once()
+	err := manager.EnforceApprovalPolicy(ctx, "exec-policy", "Lead@Example.com", &uid)
+	var pending *ApprovalsPendingError
+	require.ErrorAs(t, err, &pending)
+	assert.Equal(t, 1, pending.Have)
+	assert.Equal(t, 2, pending.Need)
+
+	// A second person has signed: the approval proceeds.
+	store.On("ListExecutionApprovals", ctx, "exec-policy").Return([]config.ExecutionApproval{
+		{Approver: "other@example.com", ApproverEmail: "other@example.com"},
+		{Approver: uid, ApproverEmail: "lead@example.com", ApproverUserID: &uid},
+	}, nil).Once()
+	require.NoError(t, manager.EnforceApprovalPolicy(ctx, "exec-policy", "Lead@Example.com", &uid))
+}
+
+func TestEnforceApprovalPolicy_AnonymousApproverRefused(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(twoApprovers), nil)
+	store.On("GetExecutionByID", ctx, "exec-policy").Return(policyExec(), nil)
+
+	err := manager.EnforceApprovalPolicy(ctx, "exec-policy", "", nil)
+	require.ErrorContains(t, err, "could not be identified")
+	store.AssertNotCalled(t, "RecordExecutionApproval", mock.Anything, mock.Anything)
+}
+
+func TestApproveAndExecute_PolicyDenyStopsBeforeTransition(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.GetCloudAccountFn = func(_ context.Context, id string) (*config.CloudAccount, error) {
+		return &config.CloudAccount{ID: id, Name: "sandbox-dev"}, nil
+	}
+	store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(denySandbox3yr), nil)
+	store.On("GetExecutionByID", ctx, "exec-policy").Return(policyExec(), nil)
+
+	err := manager.ApproveAndExecute(ctx, "exec-policy", "a@example.com", nil)
+	var denied *PolicyDeniedError
+	require.ErrorAs(t, err, &denied)
+	store.AssertNotCalled(t, "TransitionExecutionStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnforceExecutionPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("scheduled purchase short of approvals is refused", func(t *testing.T) {
+		manager, store, _ := newApproveManager(t)
+		store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(twoApprovers), nil)
+		store.On("RecordPolicyEvaluation", ctx, mock.MatchedBy(func(e *config.PolicyEvaluation) bool {
+			return e.Stage == policy.StageExecution && e.Actor == ""
+		})).Return(nil)
+		store.On("ListExecutionApprovals", ctx, "exec-policy").Return([]config.ExecutionApproval{
+			{Approver: "lead@example.com", ApproverEmail: "lead@example.com"},
+		}, nil)
+
+		err := manager.enforceExecutionPolicy(ctx, policyExec())
+		var denied *PolicyDeniedError
+		require.ErrorAs(t, err, &denied)
+		assert.Equal(t, "1 of the 2 required approvals were given", denied.Reason)
+	})
+
+	t.Run("policy that no longer compiles fails closed", func(t *testing.T) {
+		manager, store, _ := newApproveManager(t)
+		store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(policy.Rule{ID: "broken", Expression: "execution.", Effect: policy.EffectDeny}), nil)
+
+		err := manager.enforceExecutionPolicy(ctx, policyExec())
+		require.ErrorContains(t, err, "does not compile")
+	})
+
+	t.Run("empty rules turn the gate off", func(t *testing.T) {
+		manager, store, _ := newApproveManager(t)
+		store.On("GetLatestPurchasePolicy", ctx).Return(policyWith(), nil)
+
+		require.NoError(t, manager.enforceExecutionPolicy(ctx, policyExec()))
+	})
+}
+
+func TestExecutionActor(t *testing.T) {
+	approver := "lead@example.com"
+	uid := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+
+	assert.Equal(t, policy.Actor{Kind: policy.ActorSystem}, executionActor(&config.PurchaseExecution{}))
+	assert.Equal(t, policy.Actor{Email: approver, UserID: uid, Kind: policy.ActorUser},
+		executionActor(&config.PurchaseExecution{ApprovedBy: &approver, ExecutedByUserID: &uid}))
+	assert.Equal(t, policy.Actor{UserID: uid, Kind: policy.ActorUser},
+		executionActor(&config.PurchaseExecution{ExecutedByUserID: &uid}))
+}
@@ -53,9 +53,9 @@ func TestRedriveGuardReachMatchesDispatchReach(t *testing.T) {
 
 		// Every key of mapServiceSlug (execution.go).
 		"compute", "relational-db", "cache", "search", "data-warehouse",
-		"ec2", "rds", "elasticache", "opensearch", "redshift", "memorydb",
+		"ec2", "rds", "elasticache", "opensearch", "redshift", "memorydb", "dynamodb",
 
-		// The literal value of all 20 common.ServiceType constants, so a value
+		// The literal value of all 23 common.ServiceType constants, so a value
 		// that bypasses both slug maps and passes through verbatim is covered.
 		string(common.ServiceCompute), string(common.ServiceRelationalDB),
 		string(common.ServiceNoSQL), string(common.ServiceCache),
@@ -67,6 +67,8 @@ func TestRedriveGuardReachMatchesDispatchReach(t *testing.T) {
 		string(common.ServiceEC2), string(common.ServiceRDS),
 		string(common.ServiceElastiCache), string(common.ServiceOpenSearch),
 		string(common.ServiceRedshift), string(common.ServiceMemoryDB),
+		string(common.ServiceDynamoDB), string(common.ServiceBlockStorage),
+		string(common.ServiceAppService),
 
 		// The variants the finding named, plus neighboring mutations: case,
 		// separator, whitespace, and near-miss spellings.
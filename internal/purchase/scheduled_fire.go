@@ -26,11 +26,28 @@ type FireResult struct {
 	// Errored is the number of rows where the fire attempt failed for a reason
 	// other than a CAS race (DB error, provider error). Worth alerting on.
 	Errored int `json:"errored"`
+	// Deferred is the number of rows a change freeze pushed back to the
+	// first allowed time; Skipped the number it canceled.
+	Deferred int `json:"deferred,omitempty"`
+	Skipped  int `json:"skipped,omitempty"`
 }
 
+// fireOutcome is what fireOneDue did with a due row.
+type fireOutcome int
+
+const (
+	fireErrored fireOutcome = iota
+	fireFired
+	fireRaceLost
+	fireDeferred
+	fireSkipped
+)
+
 // FireScheduledDelayedPurchases runs a sweep that fires all purchase_executions
 // with status="scheduled" and scheduled_execution_at <= NOW(). For each row:
 //
+//  0. Defer or skip the row when a change freeze is in force over it (see
+//     holdForFreeze).
 //  1. Atomically transition scheduled -> approved via TransitionExecutionStatus
 //     (CAS; skips the row if the revoke handler won the race and flipped it to
 //     canceled first).
@@ -55,25 +72,33 @@ func (m *Manager) FireScheduledDelayedPurchases(ctx context.Context) (*FireResul
 	logging.Infof("FireScheduledDelayedPurchases: found %d row(s) due for execution", len(due))
 
 	for i := range due {
-		exec := &due[i]
-		if fired, raceLost := m.fireOneDue(ctx, exec); fired {
+		switch m.fireOneDue(ctx, &due[i]) {
+		case fireFired:
 			result.Fired++
-		} else if raceLost {
+		case fireRaceLost:
 			result.RaceLost++
-		} else {
+		case fireDeferred:
+			result.Deferred++
+		case fireSkipped:
+			result.Skipped++
+		default:
 			result.Errored++
 		}
 	}
 
-	logging.Infof("FireScheduledDelayedPurchases: found=%d fired=%d race_lost=%d errored=%d",
-		result.Found, result.Fired, result.RaceLost, result.Errored)
+	logging.Infof("FireScheduledDelayedPurchases: found=%d fired=%d race_lost=%d errored=%d deferred=%d skipped=%d",
+		result.Found, result.Fired, result.RaceLost, result.Errored, result.Deferred, result.Skipped)
 	return result, nil
 }
 
-// fireOneDue attempts to fire a single due scheduled execution. Returns
-// (true, false) on success, (false, true) when the CAS was lost to a
-// concurrent revoke, or (false, false) on a real error.
-func (m *Manager) fireOneDue(ctx context.Context, exec *config.PurchaseExecution) (fired, raceLost bool) {
+// fireOneDue attempts to fire a single due scheduled execution and returns
+// what it did: fired it, lost the CAS to a concurrent revoke, held it back
+// for a change freeze, or failed.
+func (m *Manager) fireOneDue(ctx context.Context, exec *config.PurchaseExecution) fireOutcome {
+	if outcome, held := m.holdDueForFreeze(ctx, exec); held {
+		return outcome
+	}
+
 	// CAS: scheduled -> approved. If this fails with ErrExecutionNotInExpectedStatus
 	// the revoke handler already transitioned the row to "canceled" — that is
 	// not an error, just a CAS race loss.
@@ -82,10 +107,10 @@ func (m *Manager) fireOneDue(ctx context.Context, exec *config.PurchaseExecution
 	if err != nil {
 		if errors.Is(err, config.ErrExecutionNotInExpectedStatus) || errors.Is(err, config.ErrNotFound) {
 			logging.Infof("fireOneDue[%s]: CAS lost (execution already transitioned by another actor)", exec.ExecutionID)
-			return false, true
+			return fireRaceLost
 		}
 		logging.Errorf("fireOneDue[%s]: TransitionExecutionStatus failed: %v", exec.ExecutionID, err)
-		return false, false
+		return fireErrored
 	}
 
 	// Stamp ApprovedBy for the audit trail before executing.
@@ -99,8 +124,29 @@ func (m *Manager) fireOneDue(ctx context.Context, exec *config.PurchaseExecution
 
 	if execErr := m.executeAndFinalize(ctx, updated); execErr != nil {
 		logging.Errorf("fireOneDue[%s]: executeAndFinalize failed: %v", exec.ExecutionID, execErr)
-		return false, false
+		return fireErrored
 	}
 
-	return true, false
+	return fireFired
+}
+
+// holdDueForFreeze runs holdForFreeze for fireOneDue. held reports whether
+// the row must not fire now and outcome what it counts as; a row another
+// actor transitioned first is a CAS race like any other. Split from
+// fireOneDue to keep it under the gocyclo threshold.
+func (m *Manager) holdDueForFreeze(ctx context.Context, exec *config.PurchaseExecution) (outcome fireOutcome, held bool) {
+	d, err := m.holdForFreeze(ctx, exec)
+	switch {
+	case errors.Is(err, config.ErrExecutionNotInExpectedStatus) || errors.Is(err, config.ErrNotFound):
+		logging.Infof("fireOneDue[%s]: CAS lost while holding for a change freeze", exec.ExecutionID)
+		return fireRaceLost, true
+	case err != nil:
+		logging.Errorf("fireOneDue[%s]: change freeze check failed: %v", exec.ExecutionID, err)
+		return fireErrored, true
+	case d == nil:
+		return fireFired, false
+	case d.Skip():
+		return fireSkipped, true
+	}
+	return fireDeferred, true
 }
@@ -0,0 +1,134 @@
+package purchase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// recordShadowPurchases is executePurchase for a shadow-mode plan: it
+// records the selected recommendations of exec as shadow purchases instead
+// of buying them. Every gate before it (auto-purchase, approvals, change
+// freezes, policies) has already applied, and the caller finalizes exec
+// and advances the plan's ramp as for a real purchase, so the shadow
+// purchases are what the plan would actually have bought. Nothing reaches
+// the cloud, the commitment budget, purchase history or the
+// recently-purchased suppressions, and no confirmation is sent.
+//
+// A root execution of a plan with accounts would fan out and buy every
+// selected recommendation in each account, so it records one shadow
+// purchase per account and recommendation, all under exec.
+func (m *Manager) recordShadowPurchases(ctx context.Context, exec *config.PurchaseExecution, plan *config.PurchasePlan) error {
+	accounts, err := m.shadowFanOutAccounts(ctx, exec)
+	if err != nil {
+		return err
+	}
+	ps := shadowPurchasesFor(exec, plan, accounts, time.Now())
+	if err := m.config.RecordPlanShadowPurchases(ctx, exec.ExecutionID, ps); err != nil {
+		return fmt.Errorf("purchase[%s]: failed to record shadow purchases: %w", exec.ExecutionID, err)
+	}
+	logging.Infof("purchase[%s]: plan %q is in shadow mode; recorded %d shadow purchase(s) across %d account(s) instead of buying",
+		exec.ExecutionID, plan.Name, len(ps), max(len(accounts), 1))
+	return nil
+}
+
+// shadowFanOutAccounts returns the plan accounts executePurchase would fan
+// exec out across, or nil when it would take the single-account path: exec
+// already carries an account (or its lineage key recovers one, as in
+// executeScopeAware) or the plan has none.
+func (m *Manager) shadowFanOutAccounts(ctx context.Context, exec *config.PurchaseExecution) ([]config.CloudAccount, error) {
+	if exec.CloudAccountID != nil {
+		return nil, nil
+	}
+	accounts, err := m.config.GetPlanAccounts(ctx, exec.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan accounts for plan %s: %w", exec.PlanID, err)
+	}
+	if len(accounts) == 0 {
+		return nil, nil
+	}
+	if err := reattachAccountScope(exec, accounts); err != nil {
+		return nil, err
+	}
+	if exec.CloudAccountID != nil {
+		return nil, nil
+	}
+	return accounts, nil
+}
+
+// shadowPurchasesFor returns the shadow purchases standing in for the
+// selected recommendations of exec, bought at now: once per account in
+// accounts, or once in the recommendation's (else exec's) account when
+// accounts is empty. A recommendation with no term or no on-demand
+// baseline to score against is left out.
+func shadowPurchasesFor(exec *config.PurchaseExecution, plan *config.PurchasePlan, accounts []config.CloudAccount, now time.Time) []config.ShadowPurchase {
+	ps := make([]config.ShadowPurchase, 0)
+	for _, i := range selectedIndices(exec.Recommendations) {
+		rec := exec.Recommendations[i]
+		upfront, _ := recBudgetAmounts(rec)
+		commit, cost := RecHourlyPricing(rec)
+		if rec.Term <= 0 || commit <= 0 {
+			logging.Warnf("purchase[%s]: not recording a shadow purchase for %s %s in %s: term %d years, on-demand %.4f USD/h",
+				exec.ExecutionID, rec.Service, rec.ResourceType, rec.Region, rec.Term, commit)
+			continue
+		}
+		provider := rec.Provider
+		if provider == "" {
+			provider = "aws"
+		}
+		planID, execID := plan.ID, exec.ExecutionID
+		p := config.ShadowPurchase{
+			Source:           config.ShadowSourcePlan,
+			PlanID:           &planID,
+			ExecutionID:      &execID,
+			Provider:         provider,
+			Service:          rec.Service,
+			Region:           rec.Region,
+			ResourceType:     rec.ResourceType,
+			Count:            rec.Count,
+			TermYears:        rec.Term,
+			Payment:          rec.Payment,
+			UpfrontCost:      upfront,
+			CommitUSDPerHour: commit,
+			CostUSDPerHour:   cost,
+			EstimatedSavings: rec.Savings,
+			PurchaseAt:       now,
+		}
+		if len(accounts) == 0 {
+			p.CloudAccountID = rec.CloudAccountID
+			if p.CloudAccountID == nil {
+				p.CloudAccountID = exec.CloudAccountID
+			}
+			ps = append(ps, p)
+			continue
+		}
+		for j := range accounts {
+			accountID := accounts[j].ID
+			p.CloudAccountID = &accountID
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// RecHourlyPricing prices rec's commitment per hour: the on-demand spend
+// it would cover at full utilization, and what it would cost, upfront
+// payment amortised over the term plus any recurring charge.
+func RecHourlyPricing(rec config.RecommendationRecord) (onDemandUSDPerHour, costUSDPerHour float64) {
+	_, cost := recBudgetAmounts(rec)
+	return recOnDemandUSDPerHour(rec, cost), cost
+}
+
+// recOnDemandUSDPerHour is the on-demand spend per hour rec's commitment
+// would cover at full utilization: the provider's on-demand baseline when
+// it reported one, otherwise costUSDPerHour plus the promised savings.
+func recOnDemandUSDPerHour(rec config.RecommendationRecord, costUSDPerHour float64) float64 {
+	if rec.OnDemandCost != nil && *rec.OnDemandCost > 0 {
+		return *rec.OnDemandCost / budget.HoursPerMonth
+	}
+	return costUSDPerHour + rec.Savings/budget.HoursPerMonth
+}
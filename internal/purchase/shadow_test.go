@@ -0,0 +1,104 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+)
+
+func shadowExec() *config.PurchaseExecution {
+	onDemand, monthly := 146.0, 73.0
+	return &config.PurchaseExecution{
+		PlanID: "plan-1", ExecutionID: "exec-shadow", Status: "running",
+		Recommendations: []config.RecommendationRecord{
+			{Provider: "aws", Service: "ec2", Region: "us-east-1", ResourceType: "m5.large", Count: 2, Term: 1,
+				Payment: "no-upfront", MonthlyCost: &monthly, OnDemandCost: &onDemand, Savings: 73, Selected: true},
+			{Provider: "aws", Service: "rds", Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, Term: 3,
+				Selected: false},
+		},
+	}
+}
+
+func TestExecutePurchase_ShadowModeRecordsInsteadOfBuying(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(&config.PurchasePlan{ID: "plan-1", Name: "trial", ShadowMode: true}, nil)
+	store.On("RecordPlanShadowPurchases", ctx, "exec-shadow", mock.MatchedBy(func(ps []config.ShadowPurchase) bool {
+		if len(ps) != 1 {
+			return false
+		}
+		p := ps[0]
+		return p.Source == config.ShadowSourcePlan && *p.PlanID == "plan-1" && *p.ExecutionID == "exec-shadow" &&
+			p.Service == "ec2" && p.TermYears == 1 && p.CommitUSDPerHour == 0.2 && p.CostUSDPerHour == 0.1
+	})).Return(nil)
+
+	require.NoError(t, manager.executePurchase(ctx, shadowExec()))
+	store.AssertExpectations(t)
+	store.AssertNumberOfCalls(t, "ReserveCommitmentBudget", 0)
+	store.AssertNumberOfCalls(t, "GetGlobalConfig", 0)
+}
+
+func TestExecutePurchase_ShadowModeRecordFailure(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(&config.PurchasePlan{ID: "plan-1", ShadowMode: true}, nil)
+	store.On("RecordPlanShadowPurchases", ctx, "exec-shadow", mock.Anything).Return(errors.New("db down"))
+
+	err := manager.executePurchase(ctx, shadowExec())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shadow purchases")
+}
+
+func TestExecutePurchase_ShadowModeRecordsPerPlanAccount(t *testing.T) {
+	ctx := context.Background()
+	manager, store, _ := newApproveManager(t)
+	store.On("GetPurchasePlan", ctx, "plan-1").Return(&config.PurchasePlan{ID: "plan-1", Name: "trial", ShadowMode: true}, nil)
+	store.On("GetPlanAccounts", ctx, "plan-1").Return([]config.CloudAccount{
+		{ID: "acct-a", Name: "prod", Provider: "aws", ExternalID: "111111111111"},
+		{ID: "acct-b", Name: "staging", Provider: "aws", ExternalID: "222222222222"},
+	}, nil)
+	var recorded []config.ShadowPurchase
+	store.On("RecordPlanShadowPurchases", ctx, "exec-shadow", mock.Anything).
+		Run(func(args mock.Arguments) { recorded = args.Get(2).([]config.ShadowPurchase) }).
+		Return(nil)
+
+	require.NoError(t, manager.executePurchase(ctx, shadowExec()))
+	require.Len(t, recorded, 2, "one shadow purchase per plan account")
+	accounts := []string{*recorded[0].CloudAccountID, *recorded[1].CloudAccountID}
+	assert.ElementsMatch(t, []string{"acct-a", "acct-b"}, accounts)
+	for _, p := range recorded {
+		assert.Equal(t, "m5.large", p.ResourceType)
+		assert.Equal(t, "exec-shadow", *p.ExecutionID)
+	}
+	store.AssertNumberOfCalls(t, "SavePurchaseExecution", 0)
+	store.AssertNumberOfCalls(t, "ReserveCommitmentBudget", 0)
+}
+
+func TestShadowPurchasesFor(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	acct := "acct-1"
+	exec := shadowExec()
+	exec.CloudAccountID = &acct
+	exec.Recommendations = append(exec.Recommendations,
+		// No on-demand baseline: cost plus the promised savings.
+		config.RecommendationRecord{Service: "elasticache", Term: 1, UpfrontCost: 876, Savings: 36.5, Selected: true},
+		// No term: nothing to score over.
+		config.RecommendationRecord{Service: "ec2", Savings: 10, Selected: true},
+	)
+
+	ps := shadowPurchasesFor(exec, &config.PurchasePlan{ID: "plan-1"}, nil, now)
+	require.Len(t, ps, 2)
+	assert.Equal(t, &acct, ps[0].CloudAccountID, "the execution's account stands in for a rec without one")
+	assert.Equal(t, now, ps[0].PurchaseAt)
+	assert.Equal(t, "aws", ps[1].Provider)
+	assert.InDelta(t, 0.1, ps[1].CostUSDPerHour, 1e-9)
+	assert.InDelta(t, 0.15, ps[1].CommitUSDPerHour, 1e-9)
+	assert.InDelta(t, 876, ps[1].UpfrontCost, 1e-9)
+}
@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/execution"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// inventoryProviders are the providers whose enabled accounts the
+// inventory sync walks.
+var inventoryProviders = []string{"aws", "azure", "gcp"}
+
+// InventorySyncResult is the outcome of one inventory_sync run.
+// IncompleteAccounts counts accounts where at least one service/region
+// listing failed; their listed commitments are still written, and only the
+// listings that succeeded evict stale rows.
+type InventorySyncResult struct {
+	Accounts           int    `json:"accounts"`
+	FailedAccounts     int    `json:"failed_accounts"`
+	IncompleteAccounts int    `json:"incomplete_accounts"`
+	Commitments        int    `json:"commitments"`
+	LastError          string `json:"last_error,omitempty"`
+}
+
+// accountInventory is what one account's sweep found: the deduplicated
+// commitments, each tagged with the scope it was listed under, and the
+// scopes whose listing succeeded.
+type accountInventory struct {
+	records      []config.CommitmentInventoryRecord
+	listedScopes []string
+	failed       int
+}
+
+// SyncCommitmentInventory lists the existing commitments of every enabled
+// cloud account through ServiceClient.GetExistingCommitments, for every
+// supported service and region, and upserts them into the
+// commitments_inventory table. That table is what lets the inventory,
+// coverage and dashboard views show RIs, Savings Plans and CUDs bought
+// outside CUDly; the store marks each row 'cudly' or 'external' by matching
+// it against purchase_history.
+//
+// Accounts are swept concurrently, bounded like fanOutPerAccount. A failed
+// account is logged and skipped so one misconfigured account never blocks
+// the rest; the run errors only when every account failed.
+func (s *Scheduler) SyncCommitmentInventory(ctx context.Context) (*InventorySyncResult, error) {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(execution.ConcurrencyFromEnv())
+
+	var mu sync.Mutex
+	result := &InventorySyncResult{}
+	for _, providerName := range inventoryProviders {
+		accounts := s.enabledAccounts(ctx, providerName)
+		for i := range accounts {
+			acct := accounts[i]
+			result.Accounts++
+			g.Go(func() error {
+				n, incomplete, err := s.syncAccountInventory(gctx, acct)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					logging.Errorf("inventory sync: %s account %s (%s): %v", acct.Provider, acct.Name, acct.ExternalID, err)
+					result.FailedAccounts++
+					result.LastError = fmt.Sprintf("account %s (%s): %v", acct.Name, acct.ExternalID, err)
+					return nil // never fail the whole group — partial is fine
+				}
+				result.Commitments += n
+				if incomplete {
+					result.IncompleteAccounts++
+				}
+				return nil
+			})
+		}
+	}
+	if waitErr := g.Wait(); waitErr != nil {
+		// Goroutines return nil to isolate per-account failures; non-nil is unexpected.
+		logging.Warnf("SyncCommitmentInventory: errgroup.Wait returned unexpected error: %v", waitErr)
+	}
+
+	if result.Accounts > 0 && result.FailedAccounts == result.Accounts {
+		return result, fmt.Errorf("inventory sync: all %d accounts failed; last error: %s", result.FailedAccounts, result.LastError)
+	}
+	return result, nil
+}
+
+// syncAccountInventory sweeps one account and writes what it found,
+// returning the number of commitments written and whether any listing
+// failed.
+func (s *Scheduler) syncAccountInventory(ctx context.Context, acct config.CloudAccount) (int, bool, error) {
+	prov, err := s.accountProvider(ctx, acct)
+	if err != nil {
+		return 0, false, err
+	}
+	inv, err := listAccountInventory(ctx, prov, acct)
+	if err != nil {
+		return 0, false, err
+	}
+	if storeErr := s.config.UpsertCommitmentInventory(ctx, acct.ID, inv.records, inv.listedScopes); storeErr != nil {
+		return 0, false, fmt.Errorf("store inventory: %w", storeErr)
+	}
+	return len(inv.records), inv.failed > 0, nil
+}
+
+// accountProvider builds the provider for a registered account with the
+// same per-account credential resolution recommendation collection uses.
+func (s *Scheduler) accountProvider(ctx context.Context, acct config.CloudAccount) (provider.Provider, error) {
+	switch acct.Provider {
+	case "aws":
+		return s.awsAccountProvider(ctx, acct)
+	case "azure":
+		return s.azureAccountProvider(ctx, acct)
+	case "gcp":
+		return s.gcpAccountProvider(ctx, acct)
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", acct.Provider)
+	}
+}
+
+// listAccountInventory calls GetExistingCommitments for every supported
+// service in every region and collects the results. A failed listing is
+// logged and skipped; the sweep errors only when every listing failed, so a
+// service that is not offered in some region does not fail the account.
+//
+// Account-wide commitments (AWS Savings Plans) come back from every region;
+// they are deduplicated by commitment ID, keeping the first scope they were
+// seen under.
+func listAccountInventory(ctx context.Context, prov provider.Provider, acct config.CloudAccount) (*accountInventory, error) {
+	regions, err := inventoryRegions(ctx, prov, acct.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &accountInventory{}
+	seen := make(map[string]bool)
+	var lastErr error
+	for _, service := range uniqueServices(prov.GetSupportedServices()) {
+		for _, region := range regions {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			scope := string(service) + "/" + region
+			commitments, listErr := listServiceCommitments(ctx, prov, service, region)
+			if listErr != nil {
+				logging.Warnf("inventory sync: %s account %s: %s: %v", acct.Provider, acct.ExternalID, scope, listErr)
+				inv.failed++
+				lastErr = listErr
+				continue
+			}
+			inv.listedScopes = append(inv.listedScopes, scope)
+			for _, c := range commitments {
+				if c.CommitmentID == "" || seen[c.CommitmentID] {
+					continue
+				}
+				seen[c.CommitmentID] = true
+				inv.records = append(inv.records, inventoryRecord(acct, c, scope))
+			}
+		}
+	}
+	if inv.failed > 0 && len(inv.listedScopes) == 0 {
+		return nil, fmt.Errorf("all %d service/region listings failed; last error: %w", inv.failed, lastErr)
+	}
+	return inv, nil
+}
+
+// inventoryRegions returns the regions to list an account's commitments in.
+// Azure service clients list reservations for the whole subscription and use
+// the region only as a label, so Azure is listed once with no region rather
+// than once per location with every reservation mislabelled.
+func inventoryRegions(ctx context.Context, prov provider.Provider, providerName string) ([]string, error) {
+	if providerName == "azure" {
+		return []string{""}, nil
+	}
+	regions, err := prov.GetRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list regions: %w", err)
+	}
+	ids := make([]string, 0, len(regions))
+	for _, r := range regions {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+// listServiceCommitments lists one service's commitments in one region.
+func listServiceCommitments(ctx context.Context, prov provider.Provider, service common.ServiceType, region string) ([]common.Commitment, error) {
+	client, err := prov.GetServiceClient(ctx, service, region)
+	if err != nil {
+		return nil, fmt.Errorf("get service client: %w", err)
+	}
+	return client.GetExistingCommitments(ctx)
+}
+
+// uniqueServices drops repeated service types, preserving order.
+func uniqueServices(services []common.ServiceType) []common.ServiceType {
+	seen := make(map[common.ServiceType]bool, len(services))
+	out := make([]common.ServiceType, 0, len(services))
+	for _, svc := range services {
+		if seen[svc] {
+			continue
+		}
+		seen[svc] = true
+		out = append(out, svc)
+	}
+	return out
+}
+
+// inventoryRecord maps a provider commitment to its inventory row. The
+// account is the registered account the sweep ran under: service clients
+// do not all populate Commitment.Account.
+func inventoryRecord(acct config.CloudAccount, c common.Commitment, scope string) config.CommitmentInventoryRecord {
+	cloudAccountID := acct.ID
+	return config.CommitmentInventoryRecord{
+		Provider:       acct.Provider,
+		AccountID:      acct.ExternalID,
+		CloudAccountID: &cloudAccountID,
+		CommitmentID:   c.CommitmentID,
+		CommitmentType: string(c.CommitmentType),
+		Service:        string(c.Service),
+		Region:         c.Region,
+		ResourceType:   c.ResourceType,
+		Engine:         c.Engine,
+		Count:          c.Count,
+		StartDate:      nonZeroTime(c.StartDate),
+		EndDate:        nonZeroTime(c.EndDate),
+		State:          c.State,
+		HourlyCost:     c.Cost,
+		SyncScope:      scope,
+	}
+}
+
+// nonZeroTime returns nil for the zero time so an unreported date is stored
+// as NULL rather than year 1.
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
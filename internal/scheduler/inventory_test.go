@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// inventoryProvider is a MockProvider with a fixed region and service list,
+// serving GetExistingCommitments from per-scope stubs.
+type inventoryProvider struct {
+	*MockProvider
+	regions  []string
+	services []common.ServiceType
+	clients  map[string]*inventoryServiceClient
+}
+
+func (p *inventoryProvider) GetRegions(context.Context) ([]common.Region, error) {
+	out := make([]common.Region, 0, len(p.regions))
+	for _, r := range p.regions {
+		out = append(out, common.Region{Provider: common.ProviderAWS, ID: r})
+	}
+	return out, nil
+}
+
+func (p *inventoryProvider) GetSupportedServices() []common.ServiceType {
+	return p.services
+}
+
+func (p *inventoryProvider) GetServiceClient(_ context.Context, service common.ServiceType, region string) (provider.ServiceClient, error) {
+	client, ok := p.clients[string(service)+"/"+region]
+	if !ok {
+		return nil, errors.New("service not available in region")
+	}
+	return client, nil
+}
+
+// inventoryServiceClient returns fixed commitments (or an error) from
+// GetExistingCommitments. The embedded nil interface panics on any other
+// ServiceClient call, which the sync must never make.
+type inventoryServiceClient struct {
+	provider.ServiceClient
+	commitments []common.Commitment
+	err         error
+}
+
+func (c *inventoryServiceClient) GetExistingCommitments(context.Context) ([]common.Commitment, error) {
+	return c.commitments, c.err
+}
+
+func inventoryTestAccount() config.CloudAccount {
+	return config.CloudAccount{ID: "acct-uuid-1", Name: "prod", Provider: "aws", ExternalID: "123456789012", Enabled: true}
+}
+
+func newInventoryProvider() *inventoryProvider {
+	end := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	sp := common.Commitment{CommitmentID: "sp-1", CommitmentType: common.CommitmentSavingsPlan, Service: common.ServiceSavingsPlansCompute, Region: "us-east-1", Count: 1, EndDate: end, State: "active", Cost: 2.5}
+	return &inventoryProvider{
+		MockProvider: &MockProvider{},
+		regions:      []string{"us-east-1", "us-west-2"},
+		services:     []common.ServiceType{common.ServiceEC2, common.ServiceSavingsPlansAll, common.ServiceEC2},
+		clients: map[string]*inventoryServiceClient{
+			"ec2/us-east-1": {commitments: []common.Commitment{
+				{CommitmentID: "ri-console", CommitmentType: common.CommitmentReservedInstance, Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 3, EndDate: end, State: "active"},
+				{CommitmentID: ""},
+			}},
+			"ec2/us-west-2":          {err: errors.New("throttled")},
+			"savingsplans/us-east-1": {commitments: []common.Commitment{sp}},
+			"savingsplans/us-west-2": {commitments: []common.Commitment{sp}},
+		},
+	}
+}
+
+func TestListAccountInventory_DedupesAndToleratesFailedListings(t *testing.T) {
+	inv, err := listAccountInventory(context.Background(), newInventoryProvider(), inventoryTestAccount())
+	require.NoError(t, err)
+
+	require.Len(t, inv.records, 2, "the empty ID is dropped and the Savings Plan is listed once")
+	ri, sp := inv.records[0], inv.records[1]
+	assert.Equal(t, "ri-console", ri.CommitmentID)
+	assert.Equal(t, "123456789012", ri.AccountID)
+	require.NotNil(t, ri.CloudAccountID)
+	assert.Equal(t, "acct-uuid-1", *ri.CloudAccountID)
+	assert.Equal(t, "ec2/us-east-1", ri.SyncScope)
+	assert.Nil(t, ri.StartDate, "an unreported start date is stored as NULL")
+	require.NotNil(t, ri.EndDate)
+
+	assert.Equal(t, "sp-1", sp.CommitmentID)
+	assert.Equal(t, 2.5, sp.HourlyCost)
+	assert.Equal(t, "savingsplans/us-east-1", sp.SyncScope, "first scope wins")
+
+	assert.Equal(t, 1, inv.failed)
+	assert.Equal(t, []string{"ec2/us-east-1", "savingsplans/us-east-1", "savingsplans/us-west-2"}, inv.listedScopes,
+		"the failed ec2/us-west-2 listing must not evict; the repeated ec2 service is listed once")
+}
+
+func TestListAccountInventory_AllListingsFailed(t *testing.T) {
+	prov := &inventoryProvider{
+		MockProvider: &MockProvider{},
+		regions:      []string{"us-east-1"},
+		services:     []common.ServiceType{common.ServiceEC2},
+	}
+	_, err := listAccountInventory(context.Background(), prov, inventoryTestAccount())
+	assert.ErrorContains(t, err, "all 1 service/region listings failed")
+}
+
+func TestInventoryRegions_AzureListsOnce(t *testing.T) {
+	regions, err := inventoryRegions(context.Background(), &MockProvider{}, "azure")
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, regions)
+}
+
+func TestSyncCommitmentInventory(t *testing.T) {
+	acct := inventoryTestAccount()
+	prov := newInventoryProvider()
+
+	mockStore := new(MockConfigStore)
+	mockStore.ListCloudAccountsFn = func(_ context.Context, filter config.CloudAccountFilter) ([]config.CloudAccount, error) {
+		if filter.Provider != nil && *filter.Provider == "aws" {
+			return []config.CloudAccount{acct}, nil
+		}
+		return nil, nil
+	}
+	mockStore.On("UpsertCommitmentInventory", mock.Anything, "acct-uuid-1",
+		mock.MatchedBy(func(recs []config.CommitmentInventoryRecord) bool { return len(recs) == 2 }),
+		[]string{"ec2/us-east-1", "savingsplans/us-east-1", "savingsplans/us-west-2"},
+	).Return(nil).Once()
+
+	mockFactory := new(MockProviderFactory)
+	mockFactory.On("CreateAndValidateProvider", mock.Anything, "aws", (*provider.ProviderConfig)(nil)).Return(prov, nil)
+
+	s := NewScheduler(SchedulerConfig{ConfigStore: mockStore, ProviderFactory: mockFactory})
+	result, err := s.SyncCommitmentInventory(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Accounts)
+	assert.Equal(t, 0, result.FailedAccounts)
+	assert.Equal(t, 1, result.IncompleteAccounts)
+	assert.Equal(t, 2, result.Commitments)
+	mockStore.AssertExpectations(t)
+}
+
+func TestSyncCommitmentInventory_AllAccountsFailed(t *testing.T) {
+	acct := inventoryTestAccount()
+	acct.Provider = "oracle"
+
+	mockStore := new(MockConfigStore)
+	mockStore.ListCloudAccountsFn = func(_ context.Context, filter config.CloudAccountFilter) ([]config.CloudAccount, error) {
+		if *filter.Provider == "aws" {
+			return []config.CloudAccount{acct}, nil
+		}
+		return nil, nil
+	}
+
+	s := NewScheduler(SchedulerConfig{ConfigStore: mockStore})
+	result, err := s.SyncCommitmentInventory(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all 1 accounts failed")
+	assert.Contains(t, result.LastError, `unsupported provider "oracle"`)
+	mockStore.AssertNotCalled(t, "UpsertCommitmentInventory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -20,8 +20,10 @@ import (
 	"github.com/LeanerCloud/CUDly/internal/purchase"
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/concurrency"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
 	azureprovider "github.com/LeanerCloud/CUDly/providers/azure"
 	gcpprovider "github.com/LeanerCloud/CUDly/providers/gcp"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
@@ -48,9 +50,13 @@ type SchedulerConfig struct { //nolint:revive // exported: doc comment style int
 	OIDCSigner      oidc.Signer
 	AssumeRoleSTS   credentials.STSClient
 	STSClient       STSClient
-	DashboardURL    string
-	OIDCIssuerURL   string
-	IsLambda        bool
+	// UsageReader, when set, serves AWS usage and coverage reads (coverage
+	// on vendor recommendations, native pools and demand) from ingested CUR
+	// data instead of Cost Explorer.
+	UsageReader   cur.Reader
+	DashboardURL  string
+	OIDCIssuerURL string
+	IsLambda      bool
 }
 
 // CollectResult holds the result of collecting recommendations.
@@ -85,6 +91,7 @@ type Scheduler struct {
 	credStore       credentials.CredentialStore
 	oidcSigner      oidc.Signer
 	assumeRoleSTS   credentials.STSClient
+	usageReader     cur.Reader
 	config          config.StoreInterface
 	dashboardURL    string
 	oidcIssuerURL   string
@@ -115,6 +122,7 @@ func NewScheduler(cfg SchedulerConfig) *Scheduler {
 		oidcSigner:      cfg.OIDCSigner,
 		oidcIssuerURL:   cfg.OIDCIssuerURL,
 		assumeRoleSTS:   cfg.AssumeRoleSTS,
+		usageReader:     cfg.UsageReader,
 		stsClient:       cfg.STSClient,
 		isLambda:        cfg.IsLambda,
 		cacheTTL:        cacheTTLFromEnv(),
@@ -640,7 +648,25 @@ func (s *Scheduler) collectAWSAmbient(ctx context.Context, globalCfg *config.Glo
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create AWS provider: %w", err)
 	}
-	return s.fetchAndConvert(ctx, prov, "aws", nil, globalCfg)
+	return s.fetchAndConvert(ctx, s.withUsageReader(prov), "aws", nil, globalCfg)
+}
+
+// usageReaderSetter is implemented by providers whose usage and coverage
+// reads can come from ingested CUR data (the AWS provider).
+type usageReaderSetter interface {
+	SetUsageReader(r cur.Reader)
+}
+
+// withUsageReader points prov's usage and coverage reads at the configured
+// CUR reader, if any and if prov supports it, and returns prov.
+func (s *Scheduler) withUsageReader(prov provider.Provider) provider.Provider {
+	if s.usageReader == nil {
+		return prov
+	}
+	if setter, ok := prov.(usageReaderSetter); ok {
+		setter.SetUsageReader(s.usageReader)
+	}
+	return prov
 }
 
 // resolveAmbientHostAccountID looks up the Lambda's own AWS account ID
@@ -750,24 +776,33 @@ func (s *Scheduler) collectGCPAmbient(ctx context.Context) ([]config.Recommendat
 }
 
 func (s *Scheduler) collectAWSForAccount(ctx context.Context, globalCfg *config.GlobalConfig, acct config.CloudAccount) ([]config.RecommendationRecord, bool, error) {
+	prov, err := s.awsAccountProvider(ctx, acct)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.fetchAndConvert(ctx, prov, "aws", &acct.ID, globalCfg)
+}
+
+// awsAccountProvider builds an AWS provider for a registered account.
+func (s *Scheduler) awsAccountProvider(ctx context.Context, acct config.CloudAccount) (provider.Provider, error) {
 	// Self-account (role_arn with no role ARN) or ambient modes use ambient credentials
 	if acct.AWSRoleARN == "" {
 		prov, err := s.providerFactory.CreateAndValidateProvider(ctx, "aws", nil)
 		if err != nil {
-			return nil, false, fmt.Errorf("create ambient provider: %w", err)
+			return nil, fmt.Errorf("create ambient provider: %w", err)
 		}
-		return s.fetchAndConvert(ctx, prov, "aws", &acct.ID, globalCfg)
+		return s.withUsageReader(prov), nil
 	}
 	awsCreds, err := credentials.ResolveAWSCredentialProvider(ctx, &acct, s.credStore, s.assumeRoleSTS)
 	if err != nil {
-		return nil, false, fmt.Errorf("resolve credentials: %w", err)
+		return nil, fmt.Errorf("resolve credentials: %w", err)
 	}
 	cfg := &provider.ProviderConfig{Name: "aws", AWSCredentialsProvider: awsCreds}
 	prov, err := s.providerFactory.CreateAndValidateProvider(ctx, "aws", cfg)
 	if err != nil {
-		return nil, false, fmt.Errorf("create provider: %w", err)
+		return nil, fmt.Errorf("create provider: %w", err)
 	}
-	return s.fetchAndConvert(ctx, prov, "aws", &acct.ID, globalCfg)
+	return s.withUsageReader(prov), nil
 }
 
 // collectAzureRecommendations fans out across all enabled Azure accounts,
@@ -807,29 +842,10 @@ func (s *Scheduler) collectAzureRecommendations(ctx context.Context, _ *config.G
 }
 
 func (s *Scheduler) collectAzureForAccount(ctx context.Context, acct config.CloudAccount) ([]config.RecommendationRecord, bool, error) {
-	// Every recommendation returned below is tagged with THIS account's UUID
-	// (see tagAccount at the end of this function), so the provider must be
-	// pinned to this account's subscription. An empty AzureSubscriptionID
-	// leaves the provider unpinned, and an unpinned provider now fans out
-	// across every subscription the credential can see -- which would file
-	// other subscriptions' recommendations under this account and expose them
-	// to anyone authorized for it. Fail loud instead; the row is misconfigured.
-	if acct.AzureSubscriptionID == "" {
-		return nil, false, fmt.Errorf("cloud account %s has no azure_subscription_id configured", acct.ID)
-	}
-
-	azCred, err := credentials.ResolveAzureTokenCredentialWithOpts(ctx, &acct, s.credStore, credentials.AzureResolveOptions{
-		Signer:    s.oidcSigner,
-		IssuerURL: s.oidcIssuerURL,
-	})
+	azProv, err := s.azureAccountProvider(ctx, acct)
 	if err != nil {
-		return nil, false, fmt.Errorf("resolve credentials: %w", err)
+		return nil, false, err
 	}
-	azProv, err := azureprovider.NewAzureProvider(&provider.ProviderConfig{Profile: acct.AzureSubscriptionID})
-	if err != nil {
-		return nil, false, fmt.Errorf("create provider: %w", err)
-	}
-	azProv.SetCredential(azCred)
 
 	recClient, err := azProv.GetRecommendationsClient(ctx)
 	if err != nil {
@@ -843,6 +859,35 @@ func (s *Scheduler) collectAzureForAccount(ctx context.Context, acct config.Clou
 	return s.tagAccount(s.convertRecommendations(recs, "azure"), acct.ID), complete, nil
 }
 
+// azureAccountProvider builds an Azure provider pinned to a registered
+// account's subscription.
+func (s *Scheduler) azureAccountProvider(ctx context.Context, acct config.CloudAccount) (*azureprovider.AzureProvider, error) {
+	// Everything read through this provider is tagged with THIS account's
+	// UUID, so the provider must be pinned to this account's subscription.
+	// An empty AzureSubscriptionID leaves the provider unpinned, and an
+	// unpinned provider now fans out across every subscription the credential
+	// can see -- which would file other subscriptions' recommendations under
+	// this account and expose them to anyone authorized for it. Fail loud
+	// instead; the row is misconfigured.
+	if acct.AzureSubscriptionID == "" {
+		return nil, fmt.Errorf("cloud account %s has no azure_subscription_id configured", acct.ID)
+	}
+
+	azCred, err := credentials.ResolveAzureTokenCredentialWithOpts(ctx, &acct, s.credStore, credentials.AzureResolveOptions{
+		Signer:    s.oidcSigner,
+		IssuerURL: s.oidcIssuerURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolve credentials: %w", err)
+	}
+	azProv, err := azureprovider.NewAzureProvider(&provider.ProviderConfig{Profile: acct.AzureSubscriptionID})
+	if err != nil {
+		return nil, fmt.Errorf("create provider: %w", err)
+	}
+	azProv.SetCredential(azCred)
+	return azProv, nil
+}
+
 // collectGCPRecommendations fans out across all enabled GCP accounts,
 // resolving per-account federated credentials via the KMS signer.
 // When no accounts are registered but GCP_PROJECT_ID is set (CUDly
@@ -880,24 +925,9 @@ func (s *Scheduler) collectGCPRecommendations(ctx context.Context, _ *config.Glo
 }
 
 func (s *Scheduler) collectGCPForAccount(ctx context.Context, acct config.CloudAccount) ([]config.RecommendationRecord, bool, error) {
-	gcpTS, err := credentials.ResolveGCPTokenSourceWithOpts(ctx, &acct, s.credStore, credentials.GCPResolveOptions{
-		Signer:    s.oidcSigner,
-		IssuerURL: s.oidcIssuerURL,
-	})
+	prov, err := s.gcpAccountProvider(ctx, acct)
 	if err != nil {
-		return nil, false, fmt.Errorf("resolve credentials: %w", err)
-	}
-
-	var prov provider.Provider
-	if gcpTS != nil {
-		prov = gcpprovider.NewProviderWithCredentials(ctx, acct.GCPProjectID, gcpTS)
-	} else {
-		// ADC mode (application_default): use ambient credentials
-		created, createErr := s.providerFactory.CreateAndValidateProvider(ctx, "gcp", nil)
-		if createErr != nil {
-			return nil, false, fmt.Errorf("create ambient GCP provider: %w", createErr)
-		}
-		prov = created
+		return nil, false, err
 	}
 
 	recClient, err := prov.GetRecommendationsClient(ctx)
@@ -912,6 +942,27 @@ func (s *Scheduler) collectGCPForAccount(ctx context.Context, acct config.CloudA
 	return s.tagAccount(s.convertRecommendations(recs, "gcp"), acct.ID), complete, nil
 }
 
+// gcpAccountProvider builds a GCP provider for a registered account's
+// project.
+func (s *Scheduler) gcpAccountProvider(ctx context.Context, acct config.CloudAccount) (provider.Provider, error) {
+	gcpTS, err := credentials.ResolveGCPTokenSourceWithOpts(ctx, &acct, s.credStore, credentials.GCPResolveOptions{
+		Signer:    s.oidcSigner,
+		IssuerURL: s.oidcIssuerURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolve credentials: %w", err)
+	}
+	if gcpTS != nil {
+		return gcpprovider.NewProviderWithCredentials(ctx, acct.GCPProjectID, gcpTS), nil
+	}
+	// ADC mode (application_default): use ambient credentials
+	prov, err := s.providerFactory.CreateAndValidateProvider(ctx, "gcp", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create ambient GCP provider: %w", err)
+	}
+	return prov, nil
+}
+
 // enabledAccounts returns all enabled cloud accounts for the given provider.
 func (s *Scheduler) enabledAccounts(ctx context.Context, providerName string) []config.CloudAccount {
 	enabled := true
@@ -988,7 +1039,78 @@ func tolerateIncompleteSweep(providerName string, err error) (complete bool, _ e
 // The bool reports whether the sweep covered everything it was asked to; see
 // tolerateIncompleteSweep for why an incomplete one must not authorize
 // stale-row eviction.
+//
+// globalCfg.RecommendationSource picks vendor recommendations, native ones
+// (provider.NativeRecommendationsSource), or both, and each record's Source
+// says which produced it. A provider without a native source (Azure and
+// GCP today) keeps vendor recommendations whatever the setting.
 func (s *Scheduler) fetchAndConvert(ctx context.Context, prov provider.Provider, providerName string, accountID *string, globalCfg *config.GlobalConfig) ([]config.RecommendationRecord, bool, error) {
+	source := config.RecommendationSourceVendor
+	if globalCfg != nil && globalCfg.RecommendationSource != "" {
+		source = globalCfg.RecommendationSource
+	}
+	native, hasNative := prov.(provider.NativeRecommendationsSource)
+	if source != config.RecommendationSourceVendor && !hasNative {
+		logging.Warnf("%s has no native recommendation source; using vendor recommendations (recommendation_source=%s)", providerName, source)
+		source = config.RecommendationSourceVendor
+	}
+
+	var result []config.RecommendationRecord
+	complete := true
+	if source != config.RecommendationSourceNative {
+		recs, vendorComplete, err := s.fetchVendorRecommendations(ctx, prov, providerName, globalCfg)
+		if err != nil {
+			return nil, false, err
+		}
+		result = s.convertRecommendations(recs, providerName)
+		complete = vendorComplete
+	}
+	if source != config.RecommendationSourceVendor {
+		nativeRecords, err := s.fetchNativeRecommendations(ctx, native, providerName, globalCfg)
+		switch {
+		case err != nil && source == config.RecommendationSourceNative:
+			return nil, false, err
+		case err != nil:
+			// Alongside vendor recommendations the native engine must not
+			// take the existing source down with it: keep the vendor rows,
+			// and report the sweep incomplete so the previous native rows
+			// are not evicted as stale.
+			logging.Warnf("%v; keeping the %d vendor recommendations (recommendation_source=%s)", err, len(result), source)
+			complete = false
+		default:
+			result = append(result, dropVendorCoveredPools(result, nativeRecords)...)
+		}
+	}
+	if accountID != nil {
+		result = s.tagAccount(result, *accountID)
+	}
+	return result, complete, nil
+}
+
+// fetchNativeRecommendations computes native recommendations and converts
+// them to records tagged with the native source.
+func (s *Scheduler) fetchNativeRecommendations(ctx context.Context, native provider.NativeRecommendationsSource, providerName string, globalCfg *config.GlobalConfig) ([]config.RecommendationRecord, error) {
+	nativeRecs, err := native.GetNativeRecommendations(ctx, nativeRecommenderConfig(globalCfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute %s native recommendations: %w", providerName, err)
+	}
+	records := s.convertRecommendations(nativeRecs, providerName)
+	for i := range records {
+		records[i].Source = config.RecommendationSourceNative
+	}
+	return records, nil
+}
+
+// coverageAnnotator is implemented by recommendation clients that can
+// attach each rec's current pool coverage (the AWS adapter).
+type coverageAnnotator interface {
+	ApplyExistingCoverage(ctx context.Context, recs []common.Recommendation, lookbackDays int) error
+}
+
+// fetchVendorRecommendations reads the provider recommendation API, retrying
+// with the configured default term/payment when the unfiltered sweep comes
+// back empty, and attaches current coverage where the client supports it.
+func (s *Scheduler) fetchVendorRecommendations(ctx context.Context, prov provider.Provider, providerName string, globalCfg *config.GlobalConfig) ([]common.Recommendation, bool, error) {
 	recClient, err := prov.GetRecommendationsClient(ctx)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to get %s recommendations client: %w", providerName, err)
@@ -1024,11 +1146,67 @@ func (s *Scheduler) fetchAndConvert(ctx context.Context, prov provider.Provider,
 		// the first sweep's missing subscriptions and re-authorize eviction.
 		complete = complete && fallbackComplete
 	}
-	result := s.convertRecommendations(recs, providerName)
-	if accountID != nil {
-		result = s.tagAccount(result, *accountID)
+	annotateExistingCoverage(ctx, recClient, recs, providerName, globalCfg)
+	return recs, complete, nil
+}
+
+// annotateExistingCoverage attaches current pool coverage to recs when
+// recClient supports it. A failure is logged, not returned: the recs are
+// still valid, and the portfolio optimizer then estimates their pools from
+// the recs alone.
+func annotateExistingCoverage(ctx context.Context, recClient provider.RecommendationsClient, recs []common.Recommendation, providerName string, globalCfg *config.GlobalConfig) {
+	annotator, ok := recClient.(coverageAnnotator)
+	if !ok || len(recs) == 0 {
+		return
+	}
+	lookbackDays := config.DefaultRecommendationsLookbackDays
+	if globalCfg != nil && globalCfg.RecommendationsLookbackDays > 0 {
+		lookbackDays = globalCfg.RecommendationsLookbackDays
+	}
+	if err := annotator.ApplyExistingCoverage(ctx, recs, lookbackDays); err != nil {
+		logging.Warnf("%s recommendations: could not read existing coverage, storing them without it: %v", providerName, err)
 	}
-	return result, complete, nil
+}
+
+// nativeRecommenderConfig maps the global settings onto the native engine's
+// config. Zero lookback and percentile fall back to the defaults the store
+// applies; the engine validates the result.
+func nativeRecommenderConfig(globalCfg *config.GlobalConfig) recommender.Config {
+	cfg := recommender.Config{
+		Term:               fmt.Sprintf("%dyr", globalCfg.DefaultTerm),
+		PaymentOption:      globalCfg.DefaultPayment,
+		LookbackDays:       globalCfg.NativeRecLookbackDays,
+		Percentile:         globalCfg.NativeRecPercentile,
+		MaxBreakEvenMonths: globalCfg.NativeRecMaxBreakEvenMonths,
+	}
+	if cfg.LookbackDays == 0 {
+		cfg.LookbackDays = config.DefaultNativeRecLookbackDays
+	}
+	if cfg.Percentile == 0 {
+		cfg.Percentile = config.DefaultNativeRecPercentile
+	}
+	return cfg
+}
+
+// dropVendorCoveredPools returns the native records whose pool (service,
+// region, SKU, engine, term, payment) has no vendor record. Vendor wins a
+// collision: its recs are per account, the native ones are pool-wide, so
+// keeping both would double-count the same demand.
+func dropVendorCoveredPools(vendor, native []config.RecommendationRecord) []config.RecommendationRecord {
+	poolKey := func(r config.RecommendationRecord) string {
+		return fmt.Sprintf("%s|%s|%s|%s|%d|%s", r.Service, r.Region, r.ResourceType, r.Engine, r.Term, r.Payment)
+	}
+	covered := make(map[string]bool, len(vendor))
+	for _, r := range vendor {
+		covered[poolKey(r)] = true
+	}
+	out := make([]config.RecommendationRecord, 0, len(native))
+	for _, r := range native {
+		if !covered[poolKey(r)] {
+			out = append(out, r)
+		}
+	}
+	return out
 }
 
 // tagAccount sets CloudAccountID on each recommendation record.
@@ -1166,13 +1344,13 @@ func overrideHiddenReasons(rec *config.RecommendationRecord, cfg *config.Service
 	if !cfg.Enabled {
 		reasons = append(reasons, "enabled=false")
 	}
-	if !engineMatches(rec.Engine, cfg) {
+	if !cfg.AdmitsEngine(rec.Engine) {
 		reasons = append(reasons, "engine")
 	}
-	if !inListRule(rec.Region, cfg.IncludeRegions, cfg.ExcludeRegions) {
+	if !cfg.AdmitsRegion(rec.Region) {
 		reasons = append(reasons, "region")
 	}
-	if !inListRule(rec.ResourceType, cfg.IncludeTypes, cfg.ExcludeTypes) {
+	if !cfg.AdmitsType(rec.ResourceType) {
 		reasons = append(reasons, "resource_type")
 	}
 	return reasons
@@ -1361,6 +1539,16 @@ func nonZeroPtr(v float64) *float64 {
 	return nil
 }
 
+// existingCoveragePct is rec's pool coverage, or nil when none was found
+// for the pool.
+func existingCoveragePct(rec common.Recommendation) *float64 {
+	if !rec.ExistingCoverageKnown {
+		return nil
+	}
+	pct := rec.ExistingCoveragePct
+	return &pct
+}
+
 // extractEngine pulls the engine string out of a polymorphic
 // common.ServiceDetails value when one is present. DatabaseDetails/
 // CacheDetails are always pointers (every producer constructs them that
@@ -1412,8 +1600,11 @@ func marshalRecDetails(rec common.Recommendation, providerName string) []byte {
 }
 
 // convertRecommendations converts common.Recommendation slice to config.RecommendationRecord slice.
+// RI records that overlap a Savings Plan record from the same batch are
+// annotated by annotateOverlap.
 func (s *Scheduler) convertRecommendations(recs []common.Recommendation, providerName string) []config.RecommendationRecord {
 	records := make([]config.RecommendationRecord, 0, len(recs))
+	sources := make([]common.Recommendation, 0, len(recs))
 
 	for _rvc := range recs {
 		rec := recs[_rvc]
@@ -1494,26 +1685,31 @@ func (s *Scheduler) convertRecommendations(recs []common.Recommendation, provide
 			rec.ResourceType, engine, term, rec.PaymentOption)
 
 		records = append(records, config.RecommendationRecord{
-			ID:                recordID,
-			Provider:          providerName,
-			Service:           string(rec.Service),
-			Region:            rec.Region,
-			ResourceType:      rec.ResourceType,
-			Engine:            engine,
-			Details:           detailsBlob, // full ServiceDetails payload (issue #453)
-			Count:             rec.Count,
-			Term:              term,
-			Payment:           rec.PaymentOption,
-			UpfrontCost:       rec.CommitmentCost,
-			MonthlyCost:       rec.RecurringMonthlyCost, // nil when provider API didn't return a monthly breakdown
-			Savings:           rec.EstimatedSavings,
-			OnDemandCost:      nonZeroPtr(rec.OnDemandCost),      // nil when provider API didn't return a baseline; frontend falls back to reconstruction (#274)
-			SavingsPercentage: nonZeroPtr(rec.SavingsPercentage), // provider-authoritative %; nil when not reported, frontend falls back to effectiveSavingsPct
-			UsageHistory:      rec.UsageHistory,                  // daily coverage pcts (nil when provider not yet wired; see #239)
-			Selected:          true,                              // Default to selected
-			Purchased:         false,
+			ID:                          recordID,
+			Provider:                    providerName,
+			Service:                     string(rec.Service),
+			Region:                      rec.Region,
+			ResourceType:                rec.ResourceType,
+			Engine:                      engine,
+			Details:                     detailsBlob, // full ServiceDetails payload (issue #453)
+			Count:                       rec.Count,
+			Term:                        term,
+			Payment:                     rec.PaymentOption,
+			UpfrontCost:                 rec.CommitmentCost,
+			MonthlyCost:                 rec.RecurringMonthlyCost, // nil when provider API didn't return a monthly breakdown
+			Savings:                     rec.EstimatedSavings,
+			OnDemandCost:                nonZeroPtr(rec.OnDemandCost),      // nil when provider API didn't return a baseline; frontend falls back to reconstruction (#274)
+			SavingsPercentage:           nonZeroPtr(rec.SavingsPercentage), // provider-authoritative %; nil when not reported, frontend falls back to effectiveSavingsPct
+			UsageHistory:                rec.UsageHistory,                  // daily coverage pcts (nil when provider not yet wired; see #239)
+			Source:                      config.RecommendationSourceVendor, // fetchAndConvert restamps native recs
+			AverageInstancesUsedPerHour: rec.AverageInstancesUsedPerHour,
+			ExistingCoveragePct:         existingCoveragePct(rec),
+			Selected:                    true, // Default to selected
+			Purchased:                   false,
 		})
+		sources = append(sources, rec)
 	}
 
+	annotateOverlap(records, sources)
 	return records
 }
@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNativeProvider adds provider.NativeRecommendationsSource to MockProvider.
+type mockNativeProvider struct {
+	*MockProvider
+	recs   []common.Recommendation
+	err    error
+	gotCfg recommender.Config
+}
+
+func (m *mockNativeProvider) GetNativeRecommendations(_ context.Context, cfg recommender.Config) ([]common.Recommendation, error) {
+	m.gotCfg = cfg
+	return m.recs, m.err
+}
+
+func ec2Rec(sku, source string) common.Recommendation {
+	return common.Recommendation{
+		Provider:             common.ProviderAWS,
+		Service:              common.ServiceEC2,
+		Region:               "us-east-1",
+		ResourceType:         sku,
+		Count:                2,
+		Term:                 "3yr",
+		PaymentOption:        "no-upfront",
+		SourceRecommendation: source,
+	}
+}
+
+func nativeGlobalConfig(source string) *config.GlobalConfig {
+	return &config.GlobalConfig{
+		DefaultTerm:          3,
+		DefaultPayment:       "no-upfront",
+		RecommendationSource: source,
+	}
+}
+
+func TestScheduler_FetchAndConvert_NativeSourceReplacesVendor(t *testing.T) {
+	prov := &mockNativeProvider{
+		MockProvider: new(MockProvider),
+		recs:         []common.Recommendation{ec2Rec("m5.large", common.RecommendationSourceNative)},
+	}
+	s := &Scheduler{config: new(MockConfigStore)}
+
+	recs, complete, err := s.fetchAndConvert(context.Background(), prov, "aws", nil, nativeGlobalConfig(config.RecommendationSourceNative))
+	require.NoError(t, err)
+	assert.True(t, complete)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "m5.large", recs[0].ResourceType)
+	assert.Equal(t, config.RecommendationSourceNative, recs[0].Source)
+	// Native mode never asks the vendor API.
+	prov.MockProvider.AssertNumberOfCalls(t, "GetRecommendationsClient", 0)
+
+	assert.Equal(t, "3yr", prov.gotCfg.Term)
+	assert.Equal(t, config.DefaultNativeRecLookbackDays, prov.gotCfg.LookbackDays)
+	assert.Equal(t, config.DefaultNativeRecPercentile, prov.gotCfg.Percentile)
+}
+
+func TestScheduler_FetchAndConvert_BothKeepsVendorOnCollision(t *testing.T) {
+	recClient := new(MockRecommendationsClient)
+	recClient.On("GetAllRecommendations", mock.Anything).
+		Return([]common.Recommendation{ec2Rec("m5.large", "")}, nil)
+	prov := &mockNativeProvider{
+		MockProvider: new(MockProvider),
+		recs: []common.Recommendation{
+			ec2Rec("m5.large", common.RecommendationSourceNative),
+			ec2Rec("c5.xlarge", common.RecommendationSourceNative),
+		},
+	}
+	prov.MockProvider.On("GetRecommendationsClient", mock.Anything).Return(recClient, nil)
+	s := &Scheduler{config: new(MockConfigStore)}
+
+	recs, complete, err := s.fetchAndConvert(context.Background(), prov, "aws", nil, nativeGlobalConfig(config.RecommendationSourceBoth))
+	require.NoError(t, err)
+	assert.True(t, complete)
+	require.Len(t, recs, 2, "the native m5.large duplicates a vendor pool and must be dropped")
+	assert.Equal(t, "m5.large", recs[0].ResourceType)
+	assert.Equal(t, config.RecommendationSourceVendor, recs[0].Source)
+	assert.Equal(t, "c5.xlarge", recs[1].ResourceType)
+	assert.Equal(t, config.RecommendationSourceNative, recs[1].Source)
+}
+
+func TestScheduler_FetchAndConvert_BothKeepsVendorWhenNativeFails(t *testing.T) {
+	recClient := new(MockRecommendationsClient)
+	recClient.On("GetAllRecommendations", mock.Anything).
+		Return([]common.Recommendation{ec2Rec("m5.large", "")}, nil)
+	prov := &mockNativeProvider{
+		MockProvider: new(MockProvider),
+		err:          errors.New("GetReservationCoverage: throttled"),
+	}
+	prov.MockProvider.On("GetRecommendationsClient", mock.Anything).Return(recClient, nil)
+	s := &Scheduler{config: new(MockConfigStore)}
+
+	recs, complete, err := s.fetchAndConvert(context.Background(), prov, "aws", nil, nativeGlobalConfig(config.RecommendationSourceBoth))
+	require.NoError(t, err, "the native engine failing must not take the vendor source down")
+	assert.False(t, complete, "stale native rows must not be evicted after a failed native pass")
+	require.Len(t, recs, 1)
+	assert.Equal(t, "m5.large", recs[0].ResourceType)
+	assert.Equal(t, config.RecommendationSourceVendor, recs[0].Source)
+}
+
+func TestScheduler_FetchAndConvert_NativeOnlyFailureIsAnError(t *testing.T) {
+	prov := &mockNativeProvider{
+		MockProvider: new(MockProvider),
+		err:          errors.New("GetReservationCoverage: throttled"),
+	}
+	s := &Scheduler{config: new(MockConfigStore)}
+
+	_, _, err := s.fetchAndConvert(context.Background(), prov, "aws", nil, nativeGlobalConfig(config.RecommendationSourceNative))
+	require.ErrorContains(t, err, "native recommendations")
+}
+
+func TestScheduler_FetchAndConvert_NativeFallsBackWithoutSource(t *testing.T) {
+	recClient := new(MockRecommendationsClient)
+	recClient.On("GetAllRecommendations", mock.Anything).
+		Return([]common.Recommendation{ec2Rec("m5.large", "")}, nil)
+	prov := new(MockProvider)
+	prov.On("GetRecommendationsClient", mock.Anything).Return(recClient, nil)
+	t.Cleanup(func() { prov.AssertExpectations(t) })
+	s := &Scheduler{config: new(MockConfigStore)}
+
+	recs, _, err := s.fetchAndConvert(context.Background(), prov, "azure", nil, nativeGlobalConfig(config.RecommendationSourceNative))
+	require.NoError(t, err)
+	require.Len(t, recs, 1, "a provider without a native source keeps vendor recommendations")
+	assert.Equal(t, config.RecommendationSourceVendor, recs[0].Source)
+}
+
+// usageReaderProvider records the CUR reader the scheduler installs.
+type usageReaderProvider struct {
+	*mockNativeProvider
+	usage cur.Reader
+}
+
+func (m *usageReaderProvider) SetUsageReader(r cur.Reader) { m.usage = r }
+
+// nopUsageReader is a cur.Reader with no data.
+type nopUsageReader struct{}
+
+func (nopUsageReader) PoolHours(context.Context, cur.Query) ([]cur.PoolHour, error) { return nil, nil }
+
+func (nopUsageReader) CommitmentHours(context.Context, cur.Query) ([]cur.CommitmentHour, error) {
+	return nil, nil
+}
+
+func TestScheduler_AWSProvidersReadUsageFromCUR(t *testing.T) {
+	prov := &usageReaderProvider{mockNativeProvider: &mockNativeProvider{MockProvider: new(MockProvider)}}
+	factory := new(MockProviderFactory)
+	factory.On("CreateAndValidateProvider", mock.Anything, "aws", mock.Anything).Return(prov, nil)
+	reader := nopUsageReader{}
+	s := &Scheduler{config: new(MockConfigStore), providerFactory: factory, usageReader: reader}
+
+	_, _, err := s.collectAWSAmbient(context.Background(), nativeGlobalConfig(config.RecommendationSourceNative))
+	require.NoError(t, err)
+	assert.Equal(t, reader, prov.usage, "the ambient provider reads usage from CUR")
+
+	prov.usage = nil
+	_, err = s.awsAccountProvider(context.Background(), config.CloudAccount{ID: "acct-1"})
+	require.NoError(t, err)
+	assert.Equal(t, reader, prov.usage, "a registered account's provider reads usage from CUR")
+
+	prov.usage = nil
+	s.usageReader = nil
+	_, err = s.awsAccountProvider(context.Background(), config.CloudAccount{ID: "acct-1"})
+	require.NoError(t, err)
+	assert.Nil(t, prov.usage, "without a reader the provider stays on Cost Explorer")
+}
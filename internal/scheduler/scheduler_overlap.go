@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/portfolio"
+)
+
+// annotateOverlap stamps the Overlap* fields on every record whose usage
+// the Savings Plan records of the same batch already cover. records[i] was
+// converted from sources[i]. AWS returns RI and Savings Plan
+// recommendations independently, against the same on-demand usage, so a
+// batch is the natural unit: one provider call for one account.
+//
+// Records are annotated, not down-sized: the provider's count stays the
+// source of truth and OverlapAdjustedCount is the suggestion. The plan
+// scheduling path (createPlannedPurchases) refuses to buy both sides.
+func annotateOverlap(records []config.RecommendationRecord, sources []common.Recommendation) {
+	for _, o := range portfolio.AnalyzeOverlap(sources) {
+		rec := &records[o.Index]
+		pct := o.CoveredPct
+		adjusted := o.AdjustedCount
+		rec.OverlapCoveredPct = &pct
+		rec.OverlapAdjustedCount = &adjusted
+		rec.OverlapWith = make([]string, len(o.CoveredBy))
+		for j, p := range o.CoveredBy {
+			rec.OverlapWith[j] = records[p].ID
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_ConvertRecommendations_AnnotatesSavingsPlanOverlap(t *testing.T) {
+	scheduler := &Scheduler{}
+	riMonthly, spMonthly := 280.0, 150.0
+	recommendations := []common.Recommendation{
+		// Dropped for its invalid term: the overlap indices must still
+		// line up with the persisted records.
+		{Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceEC2, Region: "us-east-1",
+			ResourceType: "c5.large", Count: 1, Term: "", PaymentOption: "no-upfront", OnDemandCost: 100},
+		{Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceEC2, Region: "us-east-1",
+			ResourceType: "m5.large", Count: 4, Term: "1yr", PaymentOption: "no-upfront", OnDemandCost: 400,
+			RecurringMonthlyCost: &riMonthly, EstimatedSavings: 120},
+		{Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceSavingsPlansCompute,
+			Count: 1, Term: "1yr", PaymentOption: "no-upfront", OnDemandCost: 200,
+			RecurringMonthlyCost: &spMonthly, EstimatedSavings: 50,
+			Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: spMonthly / 730}},
+	}
+
+	records := scheduler.convertRecommendations(recommendations, "aws")
+
+	require.Len(t, records, 2)
+	ri, sp := records[0], records[1]
+	require.NotNil(t, ri.OverlapCoveredPct)
+	assert.InDelta(t, 50, *ri.OverlapCoveredPct, 1e-9)
+	require.NotNil(t, ri.OverlapAdjustedCount)
+	assert.Equal(t, 2, *ri.OverlapAdjustedCount)
+	assert.Equal(t, []string{sp.ID}, ri.OverlapWith)
+	assert.Equal(t, 4, ri.Count, "the provider count is kept; the adjusted count is a suggestion")
+	assert.Nil(t, sp.OverlapCoveredPct)
+}
+
+func TestScheduler_ConvertRecommendations_KeepsExistingCoverage(t *testing.T) {
+	scheduler := &Scheduler{}
+	monthly := 280.0
+	covered := common.Recommendation{Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceEC2,
+		Region: "us-east-1", ResourceType: "m5.large", Count: 4, Term: "1yr", PaymentOption: "no-upfront",
+		OnDemandCost: 400, RecurringMonthlyCost: &monthly, EstimatedSavings: 120,
+		AverageInstancesUsedPerHour: 8, ExistingCoveragePct: 50, ExistingCoverageKnown: true}
+	unknown := covered
+	unknown.ResourceType = "c5.large"
+	unknown.ExistingCoverageKnown = false
+	unknown.ExistingCoveragePct = 0
+
+	records := scheduler.convertRecommendations([]common.Recommendation{covered, unknown}, "aws")
+
+	require.Len(t, records, 2)
+	assert.InDelta(t, 8, records[0].AverageInstancesUsedPerHour, 1e-9)
+	require.NotNil(t, records[0].ExistingCoveragePct)
+	assert.InDelta(t, 50, *records[0].ExistingCoveragePct, 1e-9)
+	assert.Nil(t, records[1].ExistingCoveragePct, "an unknown coverage must not read as 0%")
+}
@@ -66,72 +66,10 @@ func filterRecsByResolvedConfigs(
 			out = append(out, rec)
 			continue
 		}
-		if !cfg.Enabled {
-			continue
-		}
-		if !engineMatches(rec.Engine, cfg) {
-			continue
-		}
-		if !inListRule(rec.Region, cfg.IncludeRegions, cfg.ExcludeRegions) {
-			continue
-		}
-		if !inListRule(rec.ResourceType, cfg.IncludeTypes, cfg.ExcludeTypes) {
-			continue
-		}
-		if !meetsMinCount(rec.Count, cfg) {
+		if !cfg.Enabled || !cfg.Admits(&rec) {
 			continue
 		}
 		out = append(out, rec)
 	}
 	return out
 }
-
-// meetsMinCount implements the read-time half of the GUI/CLI --min-count
-// filter: a rec is dropped when its count is below cfg.MinCount. A MinCount
-// of 0 (the default) disables the filter, matching the CLI flag's
-// 0-no-floor semantics. count is the persisted RecommendationRecord.Count
-// (already grace-suppression-adjusted by the time this runs, so the floor
-// applies to the net count the user would actually see).
-func meetsMinCount(count int, cfg *config.ServiceConfig) bool {
-	if cfg.MinCount <= 0 {
-		return true
-	}
-	return count >= cfg.MinCount
-}
-
-// engineMatches applies the engine include/exclude rule with a "lax for
-// engine-less services" carve-out.
-//
-// Some recommendation services (Savings Plans, Compute) carry no engine
-// field. Strictly applying an IncludeEngines list would silently drop every
-// such rec the moment a user added an engine include for a different service
-// on the same (account, provider). The carve-out: when rec.Engine == "" we
-// skip the engine check entirely and let the region / type filters decide.
-func engineMatches(engine string, cfg *config.ServiceConfig) bool {
-	if engine == "" {
-		return true
-	}
-	return inListRule(engine, cfg.IncludeEngines, cfg.ExcludeEngines)
-}
-
-// inListRule returns true when value is allowed by an include/exclude pair:
-//   - empty include list = allow anything not on the exclude list
-//   - non-empty include list = value must be on it AND not on exclude
-//
-// Order matters: exclude wins over include when the same value appears on both.
-func inListRule(value string, include, exclude []string) bool {
-	for _, e := range exclude {
-		if e == value {
-			return false
-		}
-	}
-	if len(include) == 0 {
-		return true
-	}
-	for _, i := range include {
-		if i == value {
-			return true
-		}
-	}
-	return false
-}
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/LeanerCloud/CUDly/internal/analytics"
 	"github.com/LeanerCloud/CUDly/internal/api"
 	"github.com/LeanerCloud/CUDly/internal/auth"
@@ -29,13 +30,17 @@ import (
 	"github.com/LeanerCloud/CUDly/internal/scheduler"
 	"github.com/LeanerCloud/CUDly/internal/secrets"
 	"github.com/LeanerCloud/CUDly/internal/server/scheduledauth"
+	pkgcommon "github.com/LeanerCloud/CUDly/pkg/common"
 	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 	awsladder "github.com/LeanerCloud/CUDly/providers/aws/ladder"
+	azureladder "github.com/LeanerCloud/CUDly/providers/azure/ladder"
+	gcpladder "github.com/LeanerCloud/CUDly/providers/gcp/ladder"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/oauth2"
 )
 
 // Application holds all components of the CUDly server.
@@ -62,6 +67,49 @@ type Application struct {
 	// with a fake factory that returns a hermetic LadderCapability.
 	LadderCapabilityFactory func(ctx context.Context, region, accountID string) (pkgladder.LadderCapability, error)
 
+	// AWSReservedCapacityLadderFactory constructs the single-layer
+	// LadderCapability for an AWS reserved-capacity service (rds, elasticache,
+	// opensearch) ladder config. Defaults to
+	// awsladder.NewReservedCapacityFromAWSConfig in production; tests replace it
+	// with a fake factory.
+	AWSReservedCapacityLadderFactory func(ctx context.Context, service pkgcommon.ServiceType, region, accountID string) (pkgladder.LadderCapability, error)
+
+	// AzureLadderCapabilityFactory constructs a LadderCapability for one Azure
+	// subscription from an already-resolved token credential. Defaults to
+	// azureladder.NewFromTokenCredential in production; tests replace it with
+	// a fake factory.
+	AzureLadderCapabilityFactory func(ctx context.Context, cred azcore.TokenCredential, subscriptionID string) (pkgladder.LadderCapability, error)
+
+	// AzureLadderCredentialResolver resolves the token credential for an Azure
+	// cloud account. Nil until reinitializeAfterConnect wires it against the
+	// credential store; Azure ladder configs count as Errored while it is nil
+	// rather than falling back to ambient credentials.
+	AzureLadderCredentialResolver func(ctx context.Context, acct *config.CloudAccount) (azcore.TokenCredential, error)
+
+	// azureRIExchangeClientsFactory builds the Azure clients the
+	// ri_exchange_reshape task uses for one Azure cloud account. Nil in
+	// production -> buildAzureRIExchangeClients (which resolves credentials
+	// through AzureLadderCredentialResolver); tests inject stubs.
+	azureRIExchangeClientsFactory func(ctx context.Context, acct *config.CloudAccount) (azureRIExchangeClients, error)
+
+	// renewalUtilization measures the utilization of the commitments the
+	// renewal_plan task is about to propose replacements for, keyed by
+	// commitment ID. Nil in production -> measureRenewalUtilization (Cost
+	// Explorer for AWS, the reservation utilization API for Azure); tests
+	// inject a stub.
+	renewalUtilization func(ctx context.Context, records []config.CommitmentInventoryRecord, lookbackDays int) map[string]float64
+
+	// GCPLadderCapabilityFactory constructs a LadderCapability for one GCP
+	// project from an already-resolved token source (nil = Application
+	// Default Credentials). Defaults to gcpladder.NewFromTokenSource in
+	// production; tests replace it with a fake factory.
+	GCPLadderCapabilityFactory func(ctx context.Context, ts oauth2.TokenSource, projectID string) (pkgladder.LadderCapability, error)
+
+	// GCPLadderTokenSourceResolver resolves the token source for a GCP cloud
+	// account. Nil until reinitializeAfterConnect wires it against the
+	// credential store; GCP ladder configs count as Errored while it is nil.
+	GCPLadderTokenSourceResolver func(ctx context.Context, acct *config.CloudAccount) (oauth2.TokenSource, error)
+
 	// LadderAccountResolver resolves the Lambda's own AWS account ID and region
 	// for the single-account ladder gate (Q1). It MUST fail loud when the
 	// account cannot be determined: the account ID gates which configs run, so a
@@ -113,6 +161,8 @@ type ApplicationConfig struct {
 	DashboardBucket         string
 	APIKeySecretARN         string
 	Analytics               AnalyticsConfig
+	CUR                     CURConfig
+	Renewal                 RenewalConfig
 	NotificationDaysBefore  int
 	DefaultCoverage         float64
 	DefaultTerm             int
@@ -277,6 +327,8 @@ func LoadApplicationConfig() ApplicationConfig {
 		ScheduledTaskSecretName: os.Getenv("SCHEDULED_TASK_SECRET_NAME"),
 		IsLambda:                runtime.IsLambda(),
 		Analytics:               LoadAnalyticsConfig(),
+		CUR:                     LoadCURConfig(),
+		Renewal:                 LoadRenewalConfig(),
 	}
 }
 
@@ -533,6 +585,12 @@ func NewApplication(ctx context.Context, version string) (*Application, error) {
 	if err := cfg.Analytics.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid analytics configuration: %w", err)
 	}
+	if err := cfg.CUR.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid CUR configuration: %w", err)
+	}
+	if err := cfg.Renewal.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid renewal configuration: %w", err)
+	}
 
 	log.Printf("CUDly Server initializing, version: %s", cfg.Version)
 
@@ -568,6 +626,13 @@ func NewApplication(ctx context.Context, version string) (*Application, error) {
 		return nil, err
 	}
 	app.LadderCapabilityFactory = awsladder.NewFromAWSConfig
+	app.AWSReservedCapacityLadderFactory = awsladder.NewReservedCapacityFromAWSConfig
+	if usage := app.usageReader(); usage != nil {
+		app.LadderCapabilityFactory = awsladder.NewFromAWSConfigWithUsage(usage)
+		app.AWSReservedCapacityLadderFactory = awsladder.NewReservedCapacityFromAWSConfigWithUsage(usage)
+	}
+	app.AzureLadderCapabilityFactory = azureladder.NewFromTokenCredential
+	app.GCPLadderCapabilityFactory = gcpladder.NewFromTokenSource
 	return app, nil
 }
 
@@ -772,6 +837,21 @@ func (app *Application) reinitializeAfterConnect(ctx context.Context, dbConn *da
 	app.encKeySource = encKeySource
 	log.Println("Initialized encrypted credential store")
 
+	// Azure and GCP ladder configs resolve their account credential per run,
+	// the same way the scheduler does for Azure and GCP collection.
+	app.AzureLadderCredentialResolver = func(ctx context.Context, acct *config.CloudAccount) (azcore.TokenCredential, error) {
+		return credentials.ResolveAzureTokenCredentialWithOpts(ctx, acct, credStore, credentials.AzureResolveOptions{
+			Signer:    app.signer,
+			IssuerURL: resolveOIDCIssuerURL(app.appConfig),
+		})
+	}
+	app.GCPLadderTokenSourceResolver = func(ctx context.Context, acct *config.CloudAccount) (oauth2.TokenSource, error) {
+		return credentials.ResolveGCPTokenSourceWithOpts(ctx, acct, credStore, credentials.GCPResolveOptions{
+			Signer:    app.signer,
+			IssuerURL: resolveOIDCIssuerURL(app.appConfig),
+		})
+	}
+
 	// Re-initialize purchase manager with multi-account deps now that credStore is available.
 	// The initial manager (created before DB connect) lacks CredentialStore and AssumeRoleSTS,
 	// so the multi-account fan-out guard (m.credStore != nil) would always be false without this.
@@ -807,6 +887,7 @@ func (app *Application) reinitializeAfterConnect(ctx context.Context, dbConn *da
 		OIDCIssuerURL:   resolveOIDCIssuerURL(app.appConfig),
 		AssumeRoleSTS:   sts.NewFromConfig(awsCfg),
 		STSClient:       sts.NewFromConfig(awsCfg),
+		UsageReader:     app.usageReader(),
 		IsLambda:        app.appConfig.IsLambda,
 	})
 
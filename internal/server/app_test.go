@@ -266,6 +266,37 @@ func TestHandleCollectRecommendations_WithResults(t *testing.T) {
 	testutil.AssertTrue(t, result != nil, "Result should not be nil")
 }
 
+func TestHandleSyncCommitmentInventory(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	app := &Application{
+		Scheduler: &testutil.MockScheduler{
+			SyncCommitmentInventoryFunc: func(ctx context.Context) (*scheduler.InventorySyncResult, error) {
+				return &scheduler.InventorySyncResult{Accounts: 2, Commitments: 7}, nil
+			},
+		},
+	}
+
+	result, err := app.HandleScheduledTask(ctx, TaskSyncCommitmentInventory, ScheduledTaskParams{})
+	testutil.AssertNoError(t, err)
+	synced, ok := result.(*scheduler.InventorySyncResult)
+	testutil.AssertTrue(t, ok, "Result should be an InventorySyncResult")
+	testutil.AssertEqual(t, 7, synced.Commitments)
+}
+
+func TestHandleSyncCommitmentInventory_Error(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	app := &Application{
+		Scheduler: &testutil.MockScheduler{
+			SyncCommitmentInventoryFunc: func(ctx context.Context) (*scheduler.InventorySyncResult, error) {
+				return nil, errors.New("inventory sync: all 1 accounts failed")
+			},
+		},
+	}
+
+	_, err := app.HandleScheduledTask(ctx, TaskSyncCommitmentInventory, ScheduledTaskParams{})
+	testutil.AssertError(t, err)
+}
+
 // noopEmailSender is a minimal email.SenderInterface for unit tests.
 var _ email.SenderInterface = (*noopEmailSender)(nil)
 
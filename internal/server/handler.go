@@ -68,6 +68,29 @@ const (
 	// purchases, no emails, no reshapes, no approval tokens are issued in this
 	// plan-only phase (PR-2). Execution arrives in a later PR.
 	TaskLadderRun ScheduledTaskType = "ladder_run"
+	// TaskCURIngest ingests new and rewritten CUR 2.0 export objects from
+	// CUR_SOURCE into the CUR usage tables, which back the AWS ladder's usage
+	// reads when USAGE_DATA_SOURCE=cur. A no-op when CUR_SOURCE is unset.
+	TaskCURIngest ScheduledTaskType = "cur_ingest"
+	// TaskFOCUSIngest ingests FOCUS 1.x datasets (AWS, Azure, GCP) from
+	// FOCUS_SOURCES into the same usage tables. A no-op when FOCUS_SOURCES is
+	// unset.
+	TaskFOCUSIngest ScheduledTaskType = "focus_ingest"
+	// TaskSyncCommitmentInventory lists the existing commitments of every
+	// enabled cloud account and upserts them into commitments_inventory, so
+	// RIs, Savings Plans and CUDs bought outside CUDly show up in the
+	// inventory, coverage and dashboard views.
+	TaskSyncCommitmentInventory ScheduledTaskType = "inventory_sync"
+	// TaskPlanRenewals drafts replacement purchases, timed to start at
+	// expiry, for the commitments expiring within RENEWAL_LOOKAHEAD_DAYS,
+	// and records the lineage from each expiring commitment to its
+	// replacement in commitment_renewals.
+	TaskPlanRenewals ScheduledTaskType = "renewal_plan"
+	// TaskEvaluateShadowPurchases scores the shadow purchases recorded by
+	// shadow-mode purchase plans and ladder configs against the on-demand
+	// usage that followed them, adding each day's savings or waste to
+	// their running evaluation.
+	TaskEvaluateShadowPurchases ScheduledTaskType = "shadow_evaluate"
 )
 
 // scheduledEventActions maps a raw scheduled-event action string to its
@@ -86,6 +109,11 @@ var scheduledEventActions = map[string]ScheduledTaskType{
 	"fire_scheduled_purchases":    TaskFireScheduledPurchases,
 	"finalize_revocations":        TaskFinalizeRevocations,
 	"ladder_run":                  TaskLadderRun,
+	"cur_ingest":                  TaskCURIngest,
+	"focus_ingest":                TaskFOCUSIngest,
+	"inventory_sync":              TaskSyncCommitmentInventory,
+	"renewal_plan":                TaskPlanRenewals,
+	"shadow_evaluate":             TaskEvaluateShadowPurchases,
 }
 
 // HandleScheduledTask processes a scheduled task by type.
@@ -198,6 +226,15 @@ func (app *Application) dispatchTask(ctx context.Context, taskType ScheduledTask
 		},
 		TaskFinalizeRevocations: func(c context.Context, _ ScheduledTaskParams) (any, error) { return app.handleFinalizeRevocations(c) },
 		TaskLadderRun:           func(c context.Context, _ ScheduledTaskParams) (any, error) { return app.handleLadderRun(c) },
+		TaskCURIngest:           func(c context.Context, _ ScheduledTaskParams) (any, error) { return app.handleCURIngest(c) },
+		TaskFOCUSIngest:         func(c context.Context, _ ScheduledTaskParams) (any, error) { return app.handleFOCUSIngest(c) },
+		TaskSyncCommitmentInventory: func(c context.Context, _ ScheduledTaskParams) (any, error) {
+			return app.handleSyncCommitmentInventory(c)
+		},
+		TaskPlanRenewals: func(c context.Context, _ ScheduledTaskParams) (any, error) { return app.handlePlanRenewals(c) },
+		TaskEvaluateShadowPurchases: func(c context.Context, _ ScheduledTaskParams) (any, error) {
+			return app.handleShadowEvaluate(c)
+		},
 	}
 	handler, ok := handlers[taskType]
 	if !ok {
@@ -238,6 +275,20 @@ func (app *Application) handleCollectRecommendations(ctx context.Context, ownerT
 	return result, nil
 }
 
+// handleSyncCommitmentInventory syncs provider-side commitments into the
+// commitments inventory.
+func (app *Application) handleSyncCommitmentInventory(ctx context.Context) (*scheduler.InventorySyncResult, error) {
+	log.Println("Syncing commitment inventory...")
+	result, err := app.Scheduler.SyncCommitmentInventory(ctx)
+	if err != nil {
+		log.Printf("Failed to sync commitment inventory: %v", err)
+		return nil, err
+	}
+	log.Printf("Commitment inventory synced: %d accounts (%d failed, %d incomplete), %d commitments",
+		result.Accounts, result.FailedAccounts, result.IncompleteAccounts, result.Commitments)
+	return result, nil
+}
+
 // handleProcessScheduledPurchases processes scheduled purchases.
 func (app *Application) handleProcessScheduledPurchases(ctx context.Context) (*purchase.ProcessResult, error) {
 	log.Println("Processing scheduled purchases...")
@@ -246,7 +297,8 @@ func (app *Application) handleProcessScheduledPurchases(ctx context.Context) (*p
 		log.Printf("Failed to process scheduled purchases: %v", err)
 		return nil, err
 	}
-	log.Printf("Purchases processed: %d processed, %d executed, %d stranded-approvals recovered", result.Processed, result.Executed, result.Recovered)
+	log.Printf("Purchases processed: %d processed, %d executed, %d stranded-approvals recovered, %d deferred and %d skipped by change freezes",
+		result.Processed, result.Executed, result.Recovered, result.Deferred, result.Skipped)
 	return result, nil
 }
 
@@ -338,8 +390,8 @@ func (app *Application) handleFireScheduledPurchases(ctx context.Context) (*purc
 		log.Printf("Failed to fire scheduled purchases: %v", err)
 		return nil, err
 	}
-	log.Printf("Fire sweep complete: found=%d fired=%d race_lost=%d errored=%d",
-		result.Found, result.Fired, result.RaceLost, result.Errored)
+	log.Printf("Fire sweep complete: found=%d fired=%d race_lost=%d errored=%d deferred=%d skipped=%d",
+		result.Found, result.Fired, result.RaceLost, result.Errored, result.Deferred, result.Skipped)
 	return result, nil
 }
 
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+
+	curingest "github.com/LeanerCloud/CUDly/internal/cur"
+	pkgcur "github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+// Usage data sources selectable with USAGE_DATA_SOURCE.
+const (
+	usageDataSourceCostExplorer = "cost-explorer"
+	usageDataSourceCUR          = "cur"
+)
+
+// CURConfig holds the CUR 2.0 ingestion knobs, read from env at startup and
+// validated at the boundary (see Validate).
+type CURConfig struct {
+	// UsageDataSource selects where AWS reads RI coverage, the on-demand
+	// series and RI utilization for the ladder, shadow purchases and
+	// recommendation collection (existing coverage and the native engine):
+	// "cost-explorer" (default) or "cur" (the aggregates the cur_ingest
+	// task persists).
+	UsageDataSource string
+	// Source is the export location: "s3://bucket/prefix" or a local
+	// directory. Empty turns the cur_ingest task into a no-op.
+	Source string
+	// S3Endpoint overrides the S3 endpoint for S3-compatible stores.
+	S3Endpoint string
+	// S3Region is the bucket's region. Defaults to AWS_REGION.
+	S3Region string
+	// FOCUSSources are FOCUS 1.x dataset locations (any provider), in the
+	// same forms as Source, ingested by the focus_ingest task into the same
+	// usage tables. Empty turns that task into a no-op.
+	FOCUSSources []string
+}
+
+// LoadCURConfig reads the CUR knobs from env.
+func LoadCURConfig() CURConfig {
+	region := os.Getenv("CUR_S3_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	return CURConfig{
+		UsageDataSource: strings.ToLower(strings.TrimSpace(os.Getenv("USAGE_DATA_SOURCE"))),
+		Source:          strings.TrimSpace(os.Getenv("CUR_SOURCE")),
+		S3Endpoint:      os.Getenv("CUR_S3_ENDPOINT"),
+		S3Region:        region,
+		FOCUSSources:    splitList(os.Getenv("FOCUS_SOURCES")),
+	}
+}
+
+// splitList splits a comma-separated env value, dropping blanks.
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// UsesCUR reports whether usage reads come from ingested CUR data.
+func (c CURConfig) UsesCUR() bool {
+	return c.UsageDataSource == usageDataSourceCUR
+}
+
+// Validate rejects an unknown USAGE_DATA_SOURCE, and USAGE_DATA_SOURCE=cur
+// with neither a CUR_SOURCE nor FOCUS_SOURCES to ingest from: every usage
+// read would then fail on an empty store.
+func (c CURConfig) Validate() error {
+	switch c.UsageDataSource {
+	case "", usageDataSourceCostExplorer:
+		return nil
+	case usageDataSourceCUR:
+		if c.Source == "" && len(c.FOCUSSources) == 0 {
+			return fmt.Errorf("USAGE_DATA_SOURCE=%s requires CUR_SOURCE or FOCUS_SOURCES", usageDataSourceCUR)
+		}
+		return nil
+	default:
+		return fmt.Errorf("USAGE_DATA_SOURCE must be %q or %q, got %q", usageDataSourceCostExplorer, usageDataSourceCUR, c.UsageDataSource)
+	}
+}
+
+// handleCURIngest ingests new and rewritten CUR 2.0 export objects from
+// CUR_SOURCE into the usage tables (migration 000101).
+func (app *Application) handleCURIngest(ctx context.Context) (*curingest.Result, error) {
+	cfg := app.appConfig.CUR
+	if cfg.Source == "" {
+		log.Println("CUR ingestion not configured (CUR_SOURCE unset), skipping")
+		return &curingest.Result{}, nil
+	}
+	res, err := app.ingestUsageExports(ctx, "cur_ingest", cfg.Source, curingest.FormatCUR)
+	return &res, err
+}
+
+// handleFOCUSIngest ingests FOCUS datasets from every FOCUS_SOURCES
+// location into the usage tables. A failing location does not stop the
+// others; the results are summed and the errors joined.
+func (app *Application) handleFOCUSIngest(ctx context.Context) (*curingest.Result, error) {
+	cfg := app.appConfig.CUR
+	if len(cfg.FOCUSSources) == 0 {
+		log.Println("FOCUS ingestion not configured (FOCUS_SOURCES unset), skipping")
+		return &curingest.Result{}, nil
+	}
+	var total curingest.Result
+	var errs []error
+	for _, location := range cfg.FOCUSSources {
+		res, err := app.ingestUsageExports(ctx, "focus_ingest", location, curingest.FormatFOCUS)
+		total.Listed += res.Listed
+		total.Ingested += res.Ingested
+		total.Unchanged += res.Unchanged
+		total.Failed += res.Failed
+		total.Pruned += res.Pruned
+		total.Skipped += res.Skipped
+		total.LineItems += res.LineItems
+		total.UsedLineItems += res.UsedLineItems
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return &total, errors.Join(errs...)
+}
+
+// ingestUsageExports runs one Ingester over location with format. task
+// prefixes log lines and errors.
+func (app *Application) ingestUsageExports(ctx context.Context, task, location string, format curingest.Format) (curingest.Result, error) {
+	cfg := app.appConfig.CUR
+	var s3 curingest.S3Config
+	if strings.HasPrefix(location, "s3://") {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return curingest.Result{}, fmt.Errorf("%s: load AWS config: %w", task, err)
+		}
+		s3 = curingest.S3Config{Endpoint: cfg.S3Endpoint, Region: cfg.S3Region, Credentials: awsCfg.Credentials}
+	}
+	src, err := curingest.NewSource(location, s3)
+	if err != nil {
+		return curingest.Result{}, fmt.Errorf("%s: %w", task, err)
+	}
+	ingester, err := curingest.NewIngester(src, format, app.Config)
+	if err != nil {
+		return curingest.Result{}, fmt.Errorf("%s: %w", task, err)
+	}
+
+	res, err := ingester.Run(ctx)
+	log.Printf("%s: %s listed=%d ingested=%d unchanged=%d failed=%d pruned=%d skipped=%d line_items=%d used=%d",
+		task, src.Location(), res.Listed, res.Ingested, res.Unchanged, res.Failed, res.Pruned, res.Skipped, res.LineItems, res.UsedLineItems)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", task, err)
+	}
+	return res, nil
+}
+
+// usageReader is the reader AWS usage and coverage reads go through: the
+// ingested CUR data when USAGE_DATA_SOURCE=cur, and nil (Cost Explorer)
+// otherwise.
+func (app *Application) usageReader() pkgcur.Reader {
+	if !app.appConfig.CUR.UsesCUR() {
+		return nil
+	}
+	return curUsageReader{app: app}
+}
+
+// curUsageReader adapts the config store's CUR queries to pkg/cur.Reader.
+// The store connects lazily, so each call goes through ensureDB and reads
+// app.Config at call time rather than capturing it at wiring time.
+type curUsageReader struct {
+	app *Application
+}
+
+func (r curUsageReader) PoolHours(ctx context.Context, q pkgcur.Query) ([]pkgcur.PoolHour, error) {
+	if err := r.app.ensureDB(ctx); err != nil {
+		return nil, fmt.Errorf("database connection failed: %w", err)
+	}
+	return r.app.Config.CURPoolHours(ctx, q)
+}
+
+func (r curUsageReader) CommitmentHours(ctx context.Context, q pkgcur.Query) ([]pkgcur.CommitmentHour, error) {
+	if err := r.app.ensureDB(ctx); err != nil {
+		return nil, fmt.Errorf("database connection failed: %w", err)
+	}
+	return r.app.Config.CURCommitmentHours(ctx, q)
+}
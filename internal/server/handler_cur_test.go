@@ -0,0 +1,87 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/mocks"
+	"github.com/LeanerCloud/CUDly/internal/testutil"
+	pkgcur "github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+func TestHandleCURIngest_ParseScheduledEvent(t *testing.T) {
+	taskType, _, err := ParseScheduledEvent([]byte(`{"action":"cur_ingest"}`))
+	require.NoError(t, err)
+	assert.Equal(t, TaskCURIngest, taskType)
+}
+
+func TestHandleCURIngest_UnconfiguredIsNoop(t *testing.T) {
+	store := new(mocks.MockConfigStore)
+	app := &Application{Config: store}
+
+	res, err := app.handleCURIngest(testutil.TestContext(t))
+	require.NoError(t, err)
+	assert.Zero(t, res.Listed)
+	store.AssertNotCalled(t, "CURObjectETags", mock.Anything, mock.Anything)
+}
+
+func TestHandleCURIngest_LocalSource(t *testing.T) {
+	dir := t.TempDir()
+	csv := "line_item_usage_start_date,line_item_line_item_type,line_item_product_code,pricing_unit,product_region_code,product_instance_type,line_item_usage_amount,line_item_unblended_cost\n" +
+		"2026-03-01T10:00:00Z,Usage,AmazonEC2,Hrs,us-east-1,m5.large,2,0.192\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "part-0.csv"), []byte(csv), 0o600))
+
+	store := new(mocks.MockConfigStore)
+	store.On("CURObjectETags", mock.Anything, dir).Return(map[string]string{}, nil)
+	store.On("ReplaceCURObject", mock.Anything, dir, "part-0.csv", mock.Anything,
+		mock.MatchedBy(func(p []pkgcur.PoolHour) bool { return len(p) == 1 && p[0].UsageHours == 2 }),
+		mock.Anything).Return(nil)
+	store.On("DeleteCURObjects", mock.Anything, dir, mock.Anything).Return(nil)
+	app := &Application{Config: store, appConfig: ApplicationConfig{CUR: CURConfig{Source: dir}}}
+
+	res, err := app.handleCURIngest(testutil.TestContext(t))
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Ingested)
+	store.AssertExpectations(t)
+}
+
+func TestCURConfig_Validate(t *testing.T) {
+	assert.NoError(t, CURConfig{}.Validate())
+	assert.NoError(t, CURConfig{UsageDataSource: "cost-explorer"}.Validate())
+	assert.NoError(t, CURConfig{UsageDataSource: "cur", Source: "s3://bucket/cur"}.Validate())
+	assert.ErrorContains(t, CURConfig{UsageDataSource: "cur"}.Validate(), "requires CUR_SOURCE")
+	assert.NoError(t, CURConfig{UsageDataSource: "cur", FOCUSSources: []string{"s3://bucket/focus"}}.Validate())
+	assert.ErrorContains(t, CURConfig{UsageDataSource: "athena"}.Validate(), "USAGE_DATA_SOURCE must be")
+}
+
+func TestHandleFOCUSIngest_JoinsLocationErrors(t *testing.T) {
+	dir := t.TempDir()
+	csv := "SubAccountId,ChargePeriodStart,ChargeCategory,PricingCategory,ServiceName,RegionId,SkuId,ConsumedQuantity,ConsumedUnit\n" +
+		"sub-1,2026-03-01T10:00:00Z,Usage,Standard,Virtual Machines,eastus,Standard_D2s_v3,2,Hours\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "focus.csv"), []byte(csv), 0o600))
+	missing := filepath.Join(dir, "missing")
+
+	store := new(mocks.MockConfigStore)
+	store.On("CURObjectETags", mock.Anything, dir).Return(map[string]string{}, nil)
+	store.On("ReplaceCURObject", mock.Anything, dir, "focus.csv", mock.Anything,
+		mock.MatchedBy(func(p []pkgcur.PoolHour) bool { return len(p) == 1 && p[0].ResourceType == "Standard_D2s_v3" }),
+		mock.Anything).Return(nil)
+	store.On("DeleteCURObjects", mock.Anything, dir, mock.Anything).Return(nil)
+	app := &Application{Config: store, appConfig: ApplicationConfig{CUR: CURConfig{FOCUSSources: []string{dir, missing}}}}
+
+	res, err := app.handleFOCUSIngest(testutil.TestContext(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "focus_ingest")
+	assert.Equal(t, 1, res.Ingested)
+	store.AssertExpectations(t)
+}
+
+func TestLoadCURConfig_FOCUSSources(t *testing.T) {
+	t.Setenv("FOCUS_SOURCES", " s3://a/focus, ,/data/focus ")
+	assert.Equal(t, []string{"s3://a/focus", "/data/focus"}, LoadCURConfig().FOCUSSources)
+}
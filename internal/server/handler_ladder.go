@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,7 +15,9 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
 	pkgcommon "github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/freeze"
 	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
 )
 
@@ -35,6 +38,27 @@ const (
 // provenance value has a single definition.
 const dataSourceAWSCostExplorer = "aws-ce"
 
+// dataSourceAzureConsumption is the provenance tag for Azure ladder plans:
+// the baseline comes from Consumption usage details and layer utilization
+// from Consumption reservation summaries.
+const dataSourceAzureConsumption = "azure-consumption"
+
+// dataSourceGCPRecommender is the provenance tag for GCP ladder plans: the
+// baseline comes from the Recommender's Compute Engine CUD recommendations.
+const dataSourceGCPRecommender = "gcp-recommender"
+
+// ladderDataSource returns the provenance tag for a capability's provider.
+func ladderDataSource(provider pkgcommon.ProviderType) string {
+	switch provider {
+	case pkgcommon.ProviderAzure:
+		return dataSourceAzureConsumption
+	case pkgcommon.ProviderGCP:
+		return dataSourceGCPRecommender
+	default:
+		return dataSourceAWSCostExplorer
+	}
+}
+
 // ladderConfigOutcome is the result of processing a single ladder_config entry
 // in the handleLadderRun loop. Using a typed constant avoids bare strings on
 // the outcome path.
@@ -219,7 +243,8 @@ func (app *Application) processOneLadderConfig(
 		return outcomeSkippedDisabled
 	}
 
-	// Resolve the cloud account to get the 12-digit AWS account number (Q2).
+	// Resolve the cloud account to get the 12-digit AWS account number (Q2),
+	// the Azure subscription ID or the GCP project ID.
 	cloudAcct, err := app.Config.GetCloudAccount(ctx, dbCfg.CloudAccountID)
 	if err != nil {
 		log.Printf("ladder_run: config %s: failed to get cloud account %s: %v", dbCfg.ID, dbCfg.CloudAccountID, err)
@@ -230,10 +255,9 @@ func (app *Application) processOneLadderConfig(
 		return outcomeErrored
 	}
 
-	// Q1: skip configs that belong to a different AWS account.
-	if cloudAcct.ExternalID != ownAccountID {
-		log.Printf("ladder_run: config %s: cloud account external_id=%q != lambda account=%q: skipped (multi_account_unsupported)", dbCfg.ID, cloudAcct.ExternalID, ownAccountID)
-		return outcomeSkippedMultiAccount
+	accountID, outcome, ok := ladderTargetAccount(dbCfg, cloudAcct, ownAccountID)
+	if !ok {
+		return outcome
 	}
 
 	// Cadence self-gate: skip if a run already started within the window. Fail
@@ -251,20 +275,61 @@ func (app *Application) processOneLadderConfig(
 	}
 
 	// Build and wire the LadderCapability for this account.
-	capability, err := app.buildAndWireCapability(ctx, region, cloudAcct.ExternalID, executionEnabled)
+	// A shadow-mode config never gets the write side, whatever the global
+	// switch says.
+	capability, err := app.buildAndWireCapability(ctx, cloudAcct, dbCfg.Service, region, accountID, executionEnabled && !isShadowLadder(dbCfg))
 	if err != nil {
 		log.Printf("ladder_run: config %s: %v", dbCfg.ID, err)
 		return outcomeErrored
 	}
 
 	// Run the plan engine and persist the result.
-	if err := app.executeLadderRun(ctx, dbCfg, capability, cloudAcct.ExternalID, term, paymentOpt, now); err != nil {
+	if err := app.executeLadderRun(ctx, dbCfg, capability, accountID, term, paymentOpt, now); err != nil {
 		log.Printf("ladder_run: config %s: planning failed: %v", dbCfg.ID, err)
 		return outcomeErrored
 	}
 	return outcomePlanned
 }
 
+// ladderTargetAccount returns the account identifier the ladder plans against
+// for dbCfg: the AWS account number for "aws" configs, the subscription ID for
+// "azure" configs and the project ID for "gcp" configs. ok=false means the
+// config must not run and outcome is the result to count; when ok is true
+// outcome is unused. The AWS single-account gate (Q1) applies only to AWS:
+// Azure and GCP configs authenticate per account through the credential
+// store, so any registered subscription or project can run from this
+// deployment.
+func ladderTargetAccount(dbCfg *config.LadderConfigDB, cloudAcct *config.CloudAccount, ownAccountID string) (string, ladderConfigOutcome, bool) {
+	if cloudAcct.Provider != dbCfg.Provider {
+		log.Printf("ladder_run: config %s: provider %q does not match cloud account provider %q", dbCfg.ID, dbCfg.Provider, cloudAcct.Provider)
+		return "", outcomeErrored, false
+	}
+	switch pkgcommon.ProviderType(dbCfg.Provider) {
+	case pkgcommon.ProviderAWS:
+		// Q1: skip configs that belong to a different AWS account.
+		if cloudAcct.ExternalID != ownAccountID {
+			log.Printf("ladder_run: config %s: cloud account external_id=%q != lambda account=%q: skipped (multi_account_unsupported)", dbCfg.ID, cloudAcct.ExternalID, ownAccountID)
+			return "", outcomeSkippedMultiAccount, false
+		}
+		return cloudAcct.ExternalID, outcomePlanned, true
+	case pkgcommon.ProviderAzure:
+		if cloudAcct.AzureSubscriptionID == "" {
+			log.Printf("ladder_run: config %s: cloud account %s has no azure_subscription_id", dbCfg.ID, cloudAcct.ID)
+			return "", outcomeErrored, false
+		}
+		return cloudAcct.AzureSubscriptionID, outcomePlanned, true
+	case pkgcommon.ProviderGCP:
+		if cloudAcct.GCPProjectID == "" {
+			log.Printf("ladder_run: config %s: cloud account %s has no gcp_project_id", dbCfg.ID, cloudAcct.ID)
+			return "", outcomeErrored, false
+		}
+		return cloudAcct.GCPProjectID, outcomePlanned, true
+	default:
+		log.Printf("ladder_run: config %s: provider %q is not supported by ladder_run", dbCfg.ID, dbCfg.Provider)
+		return "", outcomeErrored, false
+	}
+}
+
 // executeLadderRun runs the planning engine for a single ladder_config and
 // persists the result as a ladder_runs row (+ ladder_tranches audit rows).
 //
@@ -295,7 +360,7 @@ func (app *Application) executeLadderRun(
 	now time.Time,
 ) error {
 	// Convert the DB config row to the engine's typed LadderConfig.
-	engineCfg, err := ladderConfigToEngine(dbCfg, accountID)
+	engineCfg, err := ladderConfigToEngine(dbCfg, capability.Provider(), accountID)
 	if err != nil {
 		return fmt.Errorf("config conversion: %w", err)
 	}
@@ -320,14 +385,10 @@ func (app *Application) executeLadderRun(
 	// again would double-subtract. Including prior-run scheduled tranches lets
 	// the engine account for en-route commitment and produce a ~zero gap (Hold)
 	// when the scheduled ramp already covers the target.
-	inFlight, err := app.Config.GetInFlightLadderCommitUSDHr(ctx, dbCfg.ID)
+	// A shadow-mode config also nets its live shadow purchases.
+	inFlight, err := app.ladderInFlightUSDHr(ctx, dbCfg, now)
 	if err != nil {
-		return fmt.Errorf("GetInFlightLadderCommitUSDHr: %w", err)
-	}
-	if inFlight == nil {
-		// The store must return a non-nil value; nil signals an impossible
-		// query state (e.g. driver bug). Treat as fail-loud.
-		return fmt.Errorf("GetInFlightLadderCommitUSDHr returned nil for config %s", dbCfg.ID)
+		return err
 	}
 
 	supportedLayers := capability.SupportedLayers()
@@ -340,7 +401,7 @@ func (app *Application) executeLadderRun(
 		LayerStates:        layerStates,
 		Layers:             supportedLayers,
 		Config:             engineCfg,
-		DataSources:        []string{dataSourceAWSCostExplorer},
+		DataSources:        []string{ladderDataSource(capability.Provider())},
 		InFlightUSDPerHour: inFlight,
 	})
 	if err != nil {
@@ -423,6 +484,14 @@ func (app *Application) persistLadderRun(
 	if err != nil {
 		return fmt.Errorf("buildTrancheDBRows: %w", err)
 	}
+	if isShadowLadder(dbCfg) {
+		// A shadow run records its buy-now purchases as well, so they take
+		// the change-freeze gate with the tranches.
+		trancheRows = append(shadowBuyNowRows(trancheResult.BuyNow, runRow.ID, &cfgID, now), trancheRows...)
+	}
+	if err := app.freezeLadderTranches(ctx, dbCfg, trancheRows); err != nil {
+		return err
+	}
 
 	// L5 APPEND-ONLY: always persist via the plain path, which INSERTS the new
 	// scheduled tranches and NEVER cancels existing ones. In-flight netting
@@ -442,7 +511,14 @@ func (app *Application) persistLadderRun(
 	// scheduled ramp is preserved uncancelled (a Hold). That over-commitment is
 	// an accepted, separately-tracked follow-up (drift-down shrink); it is
 	// bounded by the low-water clamp in Allocate and not handled here.
-	savedRun, err := app.Config.SaveLadderRunWithTranches(ctx, runRow, trancheRows)
+	//
+	// A shadow-mode config records shadow purchases instead of tranches.
+	var savedRun *config.LadderRunDB
+	if isShadowLadder(dbCfg) {
+		savedRun, err = app.saveShadowLadderRun(ctx, dbCfg, runRow, trancheRows)
+	} else {
+		savedRun, err = app.Config.SaveLadderRunWithTranches(ctx, runRow, trancheRows)
+	}
 	if err != nil {
 		return fmt.Errorf("persist ladder run: %w", err)
 	}
@@ -453,6 +529,47 @@ func (app *Application) persistLadderRun(
 	return nil
 }
 
+// freezeLadderTranches moves each tranche scheduled inside a change freeze
+// covering dbCfg to the first allowed time, or cancels it when the window
+// skips, and tells the notification topic. A cancelled tranche is still
+// persisted for the audit trail but no longer counts as in flight, so a
+// later run re-plans the gap. Fail closed: a window load error fails the
+// run rather than scheduling tranches into a freeze.
+func (app *Application) freezeLadderTranches(ctx context.Context, dbCfg *config.LadderConfigDB, rows []config.LadderTrancheDB) error {
+	windows, err := purchase.LoadFreezeWindows(ctx, app.Config)
+	if err != nil {
+		return err
+	}
+	scope := freeze.Scope{Providers: []string{dbCfg.Provider}, AccountIDs: []string{dbCfg.CloudAccountID}}
+	var held []string
+	for i := range rows {
+		row := &rows[i]
+		d := freeze.Check(windows, scope, row.ScheduledDate)
+		if d == nil {
+			continue
+		}
+		note := fmt.Sprintf("tranche %s (%s, $%.4f/hr) scheduled for %s falls in change freeze %q: ",
+			row.ID, row.LayerType, row.AmountUSDHr, row.ScheduledDate.UTC().Format(time.RFC3339), d.Window.Name)
+		if d.Skip() {
+			row.Status = pkgladder.TrancheStatusCancelled
+			note += "skipped"
+		} else {
+			row.ScheduledDate = d.Until
+			note += "deferred to " + d.Until.UTC().Format(time.RFC3339)
+		}
+		log.Printf("ladder_run: config %s: %s", dbCfg.ID, note)
+		held = append(held, note)
+	}
+	if len(held) > 0 && app.Email != nil {
+		body := fmt.Sprintf("The ladder run for config %s (%s %s) scheduled tranches inside a change freeze:\n\n%s\n",
+			dbCfg.ID, dbCfg.Provider, dbCfg.Service, strings.Join(held, "\n"))
+		if err := app.Email.SendNotification(ctx, "CUDly: ladder tranches held by a change freeze", body); err != nil {
+			log.Printf("ladder_run: config %s: failed to send the change freeze notification: %v", dbCfg.ID, err)
+		}
+	}
+	return nil
+}
+
 // ladderWithinCadenceWindow reports whether a new run should be skipped because
 // a recent run already covers the cadence window. It queries
 // LatestLadderRunStartedAt for the config and returns:
@@ -492,38 +609,10 @@ func ladderWithinCadenceWindow(ctx context.Context, store config.StoreInterface,
 }
 
 // ladderConfigToEngine converts a LadderConfigDB row to a pkg/ladder LadderConfig.
-// It parses typed enums at the boundary and fails loud on any unknown value.
-func ladderConfigToEngine(dbCfg *config.LadderConfigDB, accountID string) (pkgladder.LadderConfig, error) {
-	mode, err := pkgladder.ParseLadderMode(dbCfg.Mode)
-	if err != nil {
-		return pkgladder.LadderConfig{}, fmt.Errorf("mode: %w", err)
-	}
-	cadence, err := pkgladder.ParseLadderCadence(dbCfg.Cadence)
-	if err != nil {
-		return pkgladder.LadderConfig{}, fmt.Errorf("cadence: %w", err)
-	}
-
-	var ramp pkgladder.RampSchedule
-	if err := json.Unmarshal(dbCfg.RampSchedule, &ramp); err != nil {
-		return pkgladder.LadderConfig{}, fmt.Errorf("ramp_schedule: %w", err)
-	}
-
-	return pkgladder.LadderConfig{
-		Scope: pkgladder.Scope{
-			Provider:  pkgcommon.ProviderAWS,
-			AccountID: accountID,
-		},
-		Mode:                          mode,
-		Cadence:                       cadence,
-		Ramp:                          ramp,
-		TargetCoveragePct:             dbCfg.TargetCoverage,
-		BufferFraction:                dbCfg.BufferFraction,
-		BaselinePercentile:            dbCfg.BaselinePercentile,
-		LookbackDays:                  dbCfg.LookbackDays,
-		MaxActionsPerRun:              dbCfg.MaxActionsPerRun,
-		BufferUtilizationThresholdPct: dbCfg.BufferUtilizationThreshold,
-		MaxHourlyCommitPerRun:         dbCfg.MaxHourlyCommitPerRun,
-	}, nil
+// It parses typed enums at the boundary and fails loud on any unknown value
+// (see config.LadderConfigDB.EngineConfig).
+func ladderConfigToEngine(dbCfg *config.LadderConfigDB, provider pkgcommon.ProviderType, accountID string) (pkgladder.LadderConfig, error) {
+	return dbCfg.EngineConfig(provider, accountID)
 }
 
 // assembleLadderPlan builds a LadderPlan from the Allocate result and the
@@ -8,9 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 
 	"github.com/LeanerCloud/CUDly/internal/config"
 	"github.com/LeanerCloud/CUDly/internal/testutil"
@@ -31,10 +33,14 @@ type fakeLadderCapability struct {
 	layerStates     map[pkgladder.LayerType]pkgladder.LayerState
 	layerStatesErr  error
 	supportedLayers []pkgladder.LayerSpec
+	provider        pkgcommon.ProviderType // empty means AWS
 	t               *testing.T
 }
 
 func (f *fakeLadderCapability) Provider() pkgcommon.ProviderType {
+	if f.provider != "" {
+		return f.provider
+	}
 	return pkgcommon.ProviderAWS
 }
 
@@ -136,6 +142,13 @@ type ladderTestStore struct {
 	savedTranches         []config.LadderTrancheDB
 	saveLadderRunErr      error
 	saveLadderTranchesErr error
+
+	// Change-freeze windows returned by ListFreezeWindows.
+	freezeWindows []config.FreezeWindow
+}
+
+func (s *ladderTestStore) ListFreezeWindows(_ context.Context) ([]config.FreezeWindow, error) {
+	return s.freezeWindows, nil
 }
 
 func (s *ladderTestStore) GetGlobalConfig(_ context.Context) (*config.GlobalConfig, error) {
@@ -508,6 +521,296 @@ func TestHandleLadderRun_MultiAccountSkip_CountedAndIsolated(t *testing.T) {
 	assert.Equal(t, 0, result.SkippedCadence)
 }
 
+// ============================================================
+// processOneLadderConfig: Azure routing
+// ============================================================
+
+// validTestAzureCloudAccount returns an Azure CloudAccount for testAzureSub.
+func validTestAzureCloudAccount() *config.CloudAccount {
+	return &config.CloudAccount{
+		ID:                  "azure-acct-uuid",
+		Provider:            "azure",
+		ExternalID:          testAzureSub,
+		AzureSubscriptionID: testAzureSub,
+		Enabled:             true,
+	}
+}
+
+const testAzureSub = "00000000-0000-0000-0000-0000000000aa"
+
+// TestHandleLadderRun_AzureConfigRoutesToAzureFactory pins that an azure
+// ladder_config bypasses the AWS single-account gate, resolves the
+// subscription credential, builds the capability through the Azure factory
+// for the subscription, and plans with an Azure-scoped engine config.
+func TestHandleLadderRun_AzureConfigRoutesToAzureFactory(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	dbCfg := validTestDBConfig("cfg-azure")
+	dbCfg.CloudAccountID = "azure-acct-uuid"
+	dbCfg.Provider = "azure"
+
+	store := &ladderTestStore{
+		cloudAcctByID: map[string]*config.CloudAccount{"azure-acct-uuid": validTestAzureCloudAccount()},
+	}
+	var resolvedFor, builtFor string
+	app := &Application{
+		Config: store,
+		LadderCapabilityFactory: func(_ context.Context, _, _ string) (pkgladder.LadderCapability, error) {
+			t.Fatal("the AWS factory must not be used for an azure config")
+			return nil, nil
+		},
+		AzureLadderCredentialResolver: func(_ context.Context, acct *config.CloudAccount) (azcore.TokenCredential, error) {
+			resolvedFor = acct.ID
+			return nil, nil
+		},
+		AzureLadderCapabilityFactory: func(_ context.Context, _ azcore.TokenCredential, subscriptionID string) (pkgladder.LadderCapability, error) {
+			builtFor = subscriptionID
+			return &fakeLadderCapability{t: t, baseline: testBaseline(), provider: pkgcommon.ProviderAzure}, nil
+		},
+	}
+
+	// The Lambda's own AWS account differs from the subscription: the AWS
+	// multi-account gate must not apply.
+	result := app.runLadderConfigs(ctx, []config.LadderConfigDB{dbCfg}, "123456789012", "us-east-1", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, now, false)
+
+	assert.Equal(t, 1, result.Planned)
+	assert.Equal(t, 0, result.SkippedMultiAccount)
+	assert.Equal(t, "azure-acct-uuid", resolvedFor)
+	assert.Equal(t, testAzureSub, builtFor)
+
+	require.Len(t, store.savedRuns, 1)
+	var planDTO ladderPlanJSONDTO
+	require.NoError(t, json.Unmarshal(store.savedRuns[0].Plan, &planDTO))
+	assert.Equal(t, "azure", string(planDTO.Scope.Provider))
+	assert.Equal(t, testAzureSub, planDTO.Scope.AccountID)
+}
+
+// TestHandleLadderRun_ServiceConfigRoutesToReservedCapacityFactory pins that
+// an aws config with a service builds its capability through the
+// reserved-capacity factory and plans with a service-scoped engine config.
+func TestHandleLadderRun_ServiceConfigRoutesToReservedCapacityFactory(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	dbCfg := validTestDBConfig("cfg-rds")
+	dbCfg.Service = "rds"
+
+	store := &ladderTestStore{
+		cloudAcctByID: map[string]*config.CloudAccount{"cloud-acct-uuid": validTestCloudAccount("123456789012")},
+	}
+	zero := 0.0
+	var builtFor pkgcommon.ServiceType
+	app := &Application{
+		Config: store,
+		LadderCapabilityFactory: func(_ context.Context, _, _ string) (pkgladder.LadderCapability, error) {
+			t.Fatal("the compute factory must not be used for a service config")
+			return nil, nil
+		},
+		AWSReservedCapacityLadderFactory: func(_ context.Context, service pkgcommon.ServiceType, _, _ string) (pkgladder.LadderCapability, error) {
+			builtFor = service
+			return &fakeLadderCapability{
+				t:               t,
+				baseline:        testBaseline(),
+				supportedLayers: []pkgladder.LayerSpec{{Type: pkgladder.LayerRDSRI, Roles: []pkgladder.LayerRole{pkgladder.RoleFlex}}},
+				layerStates: map[pkgladder.LayerType]pkgladder.LayerState{
+					pkgladder.LayerRDSRI: {Layer: pkgladder.LayerRDSRI, ExistingUSDPerHour: &zero, ExpiringUSDPerHour: &zero},
+				},
+			}, nil
+		},
+	}
+
+	result := app.runLadderConfigs(ctx, []config.LadderConfigDB{dbCfg}, "123456789012", "us-east-1", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, now, false)
+
+	assert.Equal(t, 1, result.Planned)
+	assert.Equal(t, pkgcommon.ServiceRDS, builtFor)
+	require.Len(t, store.savedRuns, 1)
+	var planDTO ladderPlanJSONDTO
+	require.NoError(t, json.Unmarshal(store.savedRuns[0].Plan, &planDTO))
+	assert.Equal(t, pkgcommon.ServiceRDS, planDTO.Scope.Service)
+}
+
+func TestHandleLadderRun_AzureConfigErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		acct     func() *config.CloudAccount
+		provider string
+		resolver func(context.Context, *config.CloudAccount) (azcore.TokenCredential, error)
+	}{
+		{
+			name:     "no subscription id",
+			acct:     func() *config.CloudAccount { a := validTestAzureCloudAccount(); a.AzureSubscriptionID = ""; return a },
+			provider: "azure",
+		},
+		{
+			name:     "provider mismatch with cloud account",
+			acct:     func() *config.CloudAccount { a := validTestAzureCloudAccount(); a.Provider = "aws"; return a },
+			provider: "azure",
+		},
+		{
+			name:     "unsupported provider",
+			acct:     func() *config.CloudAccount { a := validTestAzureCloudAccount(); a.Provider = "oci"; return a },
+			provider: "oci",
+		},
+		{
+			name:     "credential resolver not wired",
+			acct:     validTestAzureCloudAccount,
+			provider: "azure",
+		},
+		{
+			name:     "credential resolution fails",
+			acct:     validTestAzureCloudAccount,
+			provider: "azure",
+			resolver: func(context.Context, *config.CloudAccount) (azcore.TokenCredential, error) {
+				return nil, errors.New("no stored credential")
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := testutil.TestContext(t)
+			dbCfg := validTestDBConfig("cfg-azure")
+			dbCfg.CloudAccountID = "azure-acct-uuid"
+			dbCfg.Provider = tc.provider
+			store := &ladderTestStore{
+				cloudAcctByID: map[string]*config.CloudAccount{"azure-acct-uuid": tc.acct()},
+			}
+			app := &Application{
+				Config:                        store,
+				AzureLadderCredentialResolver: tc.resolver,
+				AzureLadderCapabilityFactory: func(context.Context, azcore.TokenCredential, string) (pkgladder.LadderCapability, error) {
+					t.Fatal("the Azure factory must not be reached")
+					return nil, nil
+				},
+			}
+
+			result := app.runLadderConfigs(ctx, []config.LadderConfigDB{dbCfg}, "123456789012", "us-east-1", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now(), false)
+
+			assert.Equal(t, 1, result.Errored)
+			assert.Equal(t, 0, result.Planned)
+			assert.Empty(t, store.savedRuns)
+		})
+	}
+}
+
+func TestLadderDataSource(t *testing.T) {
+	assert.Equal(t, dataSourceAWSCostExplorer, ladderDataSource(pkgcommon.ProviderAWS))
+	assert.Equal(t, dataSourceAzureConsumption, ladderDataSource(pkgcommon.ProviderAzure))
+	assert.Equal(t, dataSourceGCPRecommender, ladderDataSource(pkgcommon.ProviderGCP))
+}
+
+// ============================================================
+// processOneLadderConfig: GCP routing
+// ============================================================
+
+const testGCPProject = "cudly-test-project"
+
+// validTestGCPCloudAccount returns a GCP CloudAccount for testGCPProject.
+func validTestGCPCloudAccount() *config.CloudAccount {
+	return &config.CloudAccount{
+		ID:           "gcp-acct-uuid",
+		Provider:     "gcp",
+		ExternalID:   testGCPProject,
+		GCPProjectID: testGCPProject,
+		Enabled:      true,
+	}
+}
+
+// TestHandleLadderRun_GCPConfigRoutesToGCPFactory pins that a gcp
+// ladder_config bypasses the AWS single-account gate, resolves the project's
+// token source, and builds the capability through the GCP factory for the
+// project.
+func TestHandleLadderRun_GCPConfigRoutesToGCPFactory(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	dbCfg := validTestDBConfig("cfg-gcp")
+	dbCfg.CloudAccountID = "gcp-acct-uuid"
+	dbCfg.Provider = "gcp"
+
+	store := &ladderTestStore{
+		cloudAcctByID: map[string]*config.CloudAccount{"gcp-acct-uuid": validTestGCPCloudAccount()},
+	}
+	var resolvedFor, builtFor string
+	app := &Application{
+		Config: store,
+		LadderCapabilityFactory: func(_ context.Context, _, _ string) (pkgladder.LadderCapability, error) {
+			t.Fatal("the AWS factory must not be used for a gcp config")
+			return nil, nil
+		},
+		GCPLadderTokenSourceResolver: func(_ context.Context, acct *config.CloudAccount) (oauth2.TokenSource, error) {
+			resolvedFor = acct.ID
+			return nil, nil
+		},
+		GCPLadderCapabilityFactory: func(_ context.Context, _ oauth2.TokenSource, projectID string) (pkgladder.LadderCapability, error) {
+			builtFor = projectID
+			return &fakeLadderCapability{t: t, baseline: testBaseline(), provider: pkgcommon.ProviderGCP}, nil
+		},
+	}
+
+	result := app.runLadderConfigs(ctx, []config.LadderConfigDB{dbCfg}, "123456789012", "us-east-1", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, now, false)
+
+	assert.Equal(t, 1, result.Planned)
+	assert.Equal(t, 0, result.SkippedMultiAccount)
+	assert.Equal(t, "gcp-acct-uuid", resolvedFor)
+	assert.Equal(t, testGCPProject, builtFor)
+
+	require.Len(t, store.savedRuns, 1)
+	var planDTO ladderPlanJSONDTO
+	require.NoError(t, json.Unmarshal(store.savedRuns[0].Plan, &planDTO))
+	assert.Equal(t, "gcp", string(planDTO.Scope.Provider))
+	assert.Equal(t, testGCPProject, planDTO.Scope.AccountID)
+}
+
+func TestHandleLadderRun_GCPConfigErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		acct     func() *config.CloudAccount
+		resolver func(context.Context, *config.CloudAccount) (oauth2.TokenSource, error)
+	}{
+		{
+			name: "no project id",
+			acct: func() *config.CloudAccount { a := validTestGCPCloudAccount(); a.GCPProjectID = ""; return a },
+		},
+		{
+			name: "token source resolver not wired",
+			acct: validTestGCPCloudAccount,
+		},
+		{
+			name: "credential resolution fails",
+			acct: validTestGCPCloudAccount,
+			resolver: func(context.Context, *config.CloudAccount) (oauth2.TokenSource, error) {
+				return nil, errors.New("no stored credential")
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := testutil.TestContext(t)
+			dbCfg := validTestDBConfig("cfg-gcp")
+			dbCfg.CloudAccountID = "gcp-acct-uuid"
+			dbCfg.Provider = "gcp"
+			store := &ladderTestStore{
+				cloudAcctByID: map[string]*config.CloudAccount{"gcp-acct-uuid": tc.acct()},
+			}
+			app := &Application{
+				Config:                       store,
+				GCPLadderTokenSourceResolver: tc.resolver,
+				GCPLadderCapabilityFactory: func(context.Context, oauth2.TokenSource, string) (pkgladder.LadderCapability, error) {
+					t.Fatal("the GCP factory must not be reached")
+					return nil, nil
+				},
+			}
+
+			result := app.runLadderConfigs(ctx, []config.LadderConfigDB{dbCfg}, "123456789012", "us-east-1", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now(), false)
+
+			assert.Equal(t, 1, result.Errored)
+			assert.Equal(t, 0, result.Planned)
+			assert.Empty(t, store.savedRuns)
+		})
+	}
+}
+
 // ============================================================
 // executeLadderRun: healthy single-config run
 // ============================================================
@@ -910,7 +1213,7 @@ func TestLadderConfigToEngine_BadMode(t *testing.T) {
 	dbCfg := validTestDBConfig("cfg-bad-mode")
 	dbCfg.Mode = "invalid_mode"
 
-	_, err := ladderConfigToEngine(&dbCfg, "123456789012")
+	_, err := ladderConfigToEngine(&dbCfg, pkgcommon.ProviderAWS, "123456789012")
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "mode")
@@ -920,7 +1223,7 @@ func TestLadderConfigToEngine_BadCadence(t *testing.T) {
 	dbCfg := validTestDBConfig("cfg-bad-cadence")
 	dbCfg.Cadence = "monthly"
 
-	_, err := ladderConfigToEngine(&dbCfg, "123456789012")
+	_, err := ladderConfigToEngine(&dbCfg, pkgcommon.ProviderAWS, "123456789012")
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "cadence")
@@ -930,7 +1233,7 @@ func TestLadderConfigToEngine_BadRampJSON(t *testing.T) {
 	dbCfg := validTestDBConfig("cfg-bad-ramp")
 	dbCfg.RampSchedule = []byte(`not-json`)
 
-	_, err := ladderConfigToEngine(&dbCfg, "123456789012")
+	_, err := ladderConfigToEngine(&dbCfg, pkgcommon.ProviderAWS, "123456789012")
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "ramp_schedule")
@@ -938,7 +1241,7 @@ func TestLadderConfigToEngine_BadRampJSON(t *testing.T) {
 
 func TestLadderConfigToEngine_ValidConfig(t *testing.T) {
 	dbCfg := validTestDBConfig("cfg-valid")
-	engineCfg, err := ladderConfigToEngine(&dbCfg, "123456789012")
+	engineCfg, err := ladderConfigToEngine(&dbCfg, pkgcommon.ProviderAWS, "123456789012")
 
 	require.NoError(t, err)
 	assert.Equal(t, pkgcommon.ProviderAWS, engineCfg.Scope.Provider)
@@ -1355,3 +1658,44 @@ func TestExecuteLadderRun_L5OverCommitGuard_NettingCapsAppends(t *testing.T) {
 	assert.InDelta(t, targetMinusE, store.scheduledSum(cfgID), 1e-9,
 		"after 3 constant-usage runs the scheduled commitment must equal target-E, never a multiple")
 }
+
+// ladderFreezeRecorder records the generic notifications sent.
+type ladderFreezeRecorder struct {
+	noopEmailSender
+	subjects []string
+}
+
+func (r *ladderFreezeRecorder) SendNotification(_ context.Context, subject, _ string) error {
+	r.subjects = append(r.subjects, subject)
+	return nil
+}
+
+func TestFreezeLadderTranches(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	dbCfg := validTestDBConfig("cfg-freeze")
+	store := &ladderTestStore{freezeWindows: []config.FreezeWindow{
+		{Name: "fy-close", StartsAt: start, EndsAt: start.AddDate(0, 0, 14), Recurrence: "none", Action: "defer", Enabled: true},
+		{Name: "reorg", StartsAt: start.AddDate(0, 1, 0), EndsAt: start.AddDate(0, 1, 7), Recurrence: "none", Action: "skip",
+			AccountIDs: []string{dbCfg.CloudAccountID}, Enabled: true},
+		{Name: "azure-migration", StartsAt: start.AddDate(0, 2, 0), EndsAt: start.AddDate(0, 2, 7), Recurrence: "none", Action: "skip",
+			Provider: "azure", Enabled: true},
+	}}
+	sender := &ladderFreezeRecorder{}
+	app := &Application{Config: store, Email: sender}
+
+	rows := []config.LadderTrancheDB{
+		{ID: "before", Status: pkgladder.TrancheStatusScheduled, ScheduledDate: start.AddDate(0, 0, -1)},
+		{ID: "in-close", Status: pkgladder.TrancheStatusScheduled, ScheduledDate: start.AddDate(0, 0, 3)},
+		{ID: "in-reorg", Status: pkgladder.TrancheStatusScheduled, ScheduledDate: start.AddDate(0, 1, 2)},
+		{ID: "in-azure-window", Status: pkgladder.TrancheStatusScheduled, ScheduledDate: start.AddDate(0, 2, 2)},
+	}
+	require.NoError(t, app.freezeLadderTranches(ctx, &dbCfg, rows))
+
+	assert.Equal(t, start.AddDate(0, 0, -1), rows[0].ScheduledDate)
+	assert.Equal(t, start.AddDate(0, 0, 14), rows[1].ScheduledDate, "a defer window moves the tranche to its end")
+	assert.Equal(t, pkgladder.TrancheStatusScheduled, rows[1].Status)
+	assert.Equal(t, pkgladder.TrancheStatusCancelled, rows[2].Status, "a skip window cancels the tranche")
+	assert.Equal(t, pkgladder.TrancheStatusScheduled, rows[3].Status, "an azure window does not cover an aws ladder")
+	assert.Equal(t, []string{"CUDly: ladder tranches held by a change freeze"}, sender.subjects)
+}
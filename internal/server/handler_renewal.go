@@ -0,0 +1,629 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/google/uuid"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/email"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/renewal"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// RenewalConfig holds the renewal_plan knobs, read from env at startup and
+// validated at the boundary (see Validate).
+type RenewalConfig struct {
+	renewal.Config
+	// LookbackDays is the utilization window measured before proposing a
+	// replacement.
+	LookbackDays int
+}
+
+// LoadRenewalConfig reads the renewal planner knobs from env.
+func LoadRenewalConfig() RenewalConfig {
+	def := renewal.DefaultConfig()
+	return RenewalConfig{
+		Config: renewal.Config{
+			LookaheadDays:   getEnvInt("RENEWAL_LOOKAHEAD_DAYS", def.LookaheadDays),
+			ResizeBelowPct:  getEnvFloat("RENEWAL_RESIZE_BELOW_PCT", def.ResizeBelowPct),
+			ConvertBelowPct: getEnvFloat("RENEWAL_CONVERT_BELOW_PCT", def.ConvertBelowPct),
+		},
+		LookbackDays: getEnvInt("RENEWAL_LOOKBACK_DAYS", 30),
+	}
+}
+
+// Validate rejects out-of-range planner thresholds and a non-positive
+// lookback window.
+func (c RenewalConfig) Validate() error {
+	if err := c.Config.Validate(); err != nil {
+		return err
+	}
+	if c.LookbackDays <= 0 {
+		return fmt.Errorf("RENEWAL_LOOKBACK_DAYS must be positive, got %d", c.LookbackDays)
+	}
+	return nil
+}
+
+// RenewalPlanResult is the outcome of one renewal_plan run. Expiring counts
+// the commitments inside the lookahead window; AlreadyPlanned those skipped
+// because an earlier run recorded a renewal for them. Renewed and Declined
+// count proposals from earlier runs whose executions settled this run.
+type RenewalPlanResult struct {
+	Expiring       int    `json:"expiring"`
+	Proposed       int    `json:"proposed"`
+	LetExpire      int    `json:"let_expire"`
+	AlreadyPlanned int    `json:"already_planned"`
+	Renewed        int    `json:"renewed"`
+	Declined       int    `json:"declined"`
+	Failed         int    `json:"failed"`
+	LastError      string `json:"last_error,omitempty"`
+}
+
+// renewalTerminalDeclines are the execution statuses that settle a proposed
+// renewal as declined.
+var renewalTerminalDeclines = map[string]bool{
+	"failed":                    true,
+	"expired":                   true,
+	config.StatusCanceled:       true,
+	config.LegacyStatusCanceled: true,
+}
+
+// handlePlanRenewals drafts replacements for the commitments expiring within
+// the configured lookahead. For each expiring inventory commitment it
+// measures utilization, asks renewal.Propose how to replace it, and records
+// the proposal in commitment_renewals. Every proposal that buys something
+// becomes a pending, plan-less purchase execution whose recommendation
+// starts at the source's expiry; it goes through the normal approval flow
+// and the approvers are emailed. Proposals to let a commitment lapse are
+// recorded without an execution.
+//
+// Each run first settles the proposals of earlier runs against their
+// executions, recording the replacement commitment of completed ones, so the
+// lineage from old to new commitment is complete.
+func (app *Application) handlePlanRenewals(ctx context.Context) (*RenewalPlanResult, error) {
+	log.Println("Planning commitment renewals...")
+	cfg := app.appConfig.Renewal
+	now := time.Now().UTC()
+	result := &RenewalPlanResult{}
+
+	if err := app.reconcileRenewals(ctx, now, result); err != nil {
+		return nil, err
+	}
+
+	inventory, err := app.Config.GetActiveCommitmentInventory(ctx, now, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commitment inventory: %w", err)
+	}
+	expiring := expiringInventory(inventory, now, cfg.LookaheadDays)
+	result.Expiring = len(expiring)
+
+	planned, err := app.plannedRenewalKeys(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]config.CommitmentInventoryRecord, 0, len(expiring))
+	for i := range expiring {
+		if planned[plannedRenewalKey(expiring[i].Provider, expiring[i].AccountID, expiring[i].CommitmentID, *expiring[i].EndDate)] {
+			result.AlreadyPlanned++
+			continue
+		}
+		pending = append(pending, expiring[i])
+	}
+	if len(pending) == 0 {
+		log.Printf("Commitment renewals: %d expiring, nothing new to plan", result.Expiring)
+		return result, nil
+	}
+
+	utilization := app.renewalUtilizationFor(ctx, pending, cfg.LookbackDays)
+	notifyEmail := app.renewalNotifyEmail(ctx)
+	for i := range pending {
+		rec := &pending[i]
+		var util *float64
+		if pct, ok := utilization[rec.CommitmentID]; ok {
+			util = &pct
+		}
+		proposal := renewal.Propose(inventoryCommitment(rec), util, cfg.Config)
+		if planErr := app.recordRenewal(ctx, rec, proposal, notifyEmail, result); planErr != nil {
+			log.Printf("Warning: failed to plan renewal of %s commitment %s: %v", rec.Provider, rec.CommitmentID, planErr)
+			result.Failed++
+			result.LastError = fmt.Sprintf("commitment %s: %v", rec.CommitmentID, planErr)
+		}
+	}
+
+	log.Printf("Commitment renewals: %d expiring, %d proposed, %d let expire, %d already planned, %d failed",
+		result.Expiring, result.Proposed, result.LetExpire, result.AlreadyPlanned, result.Failed)
+	if result.Failed > 0 && result.Proposed+result.LetExpire == 0 {
+		return result, fmt.Errorf("renewal plan: all %d renewals failed; last error: %s", result.Failed, result.LastError)
+	}
+	return result, nil
+}
+
+// reconcileRenewals settles proposed renewals whose executions reached a
+// terminal state: completed ones are marked renewed with the purchased
+// commitment as the replacement, canceled and failed ones declined. A
+// proposal whose approval token lapsed unapproved is expired and declined.
+func (app *Application) reconcileRenewals(ctx context.Context, now time.Time, result *RenewalPlanResult) error {
+	proposed, err := app.Config.ListCommitmentRenewals(ctx, config.CommitmentRenewalFilter{
+		Statuses: []string{config.RenewalStatusProposed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list proposed renewals: %w", err)
+	}
+	for i := range proposed {
+		r := &proposed[i]
+		if r.ExecutionID == nil {
+			continue
+		}
+		status, replacement, settleErr := app.renewalOutcome(ctx, *r.ExecutionID, now)
+		if settleErr != nil {
+			log.Printf("Warning: failed to check execution %s of renewal %s: %v", *r.ExecutionID, r.ID, settleErr)
+			continue
+		}
+		if status == "" {
+			continue
+		}
+		if updateErr := app.Config.UpdateCommitmentRenewalStatus(ctx, r.ID, status, replacement); updateErr != nil {
+			log.Printf("Warning: failed to mark renewal %s %s: %v", r.ID, status, updateErr)
+			continue
+		}
+		if status == config.RenewalStatusRenewed {
+			result.Renewed++
+		} else {
+			result.Declined++
+		}
+	}
+	return nil
+}
+
+// renewalOutcome returns the renewal status a proposal's execution settles
+// it to, and the replacement commitment ID for a completed one. An empty
+// status means the execution is still in flight.
+func (app *Application) renewalOutcome(ctx context.Context, executionID string, now time.Time) (string, *string, error) {
+	exec, err := app.Config.GetExecutionByID(ctx, executionID)
+	if errors.Is(err, config.ErrNotFound) || (err == nil && exec == nil) {
+		return config.RenewalStatusDeclined, nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	switch {
+	case exec.Status == "completed" || exec.Status == "partially_completed":
+		return config.RenewalStatusRenewed, replacementCommitmentID(exec), nil
+	case renewalTerminalDeclines[exec.Status]:
+		return config.RenewalStatusDeclined, nil, nil
+	case (exec.Status == "pending" || exec.Status == "notified") &&
+		exec.ApprovalTokenExpiresAt != nil && now.After(*exec.ApprovalTokenExpiresAt):
+		if _, transErr := app.Config.TransitionExecutionStatus(ctx, executionID, []string{"pending", "notified"}, "expired", nil); transErr != nil {
+			return "", nil, fmt.Errorf("expire unapproved execution: %w", transErr)
+		}
+		return config.RenewalStatusDeclined, nil, nil
+	}
+	return "", nil, nil
+}
+
+// replacementCommitmentID returns the provider ID of the commitment an
+// execution bought, or nil when none was recorded.
+func replacementCommitmentID(exec *config.PurchaseExecution) *string {
+	for i := range exec.Recommendations {
+		if id := exec.Recommendations[i].PurchaseID; id != "" {
+			return &id
+		}
+	}
+	return nil
+}
+
+// plannedRenewalKeys returns the keys of the commitment expiries still ahead
+// that already have a renewal recorded, in any status, so a commitment is
+// planned once per expiry; one extended to a later end date is planned
+// again.
+func (app *Application) plannedRenewalKeys(ctx context.Context, now time.Time) (map[string]bool, error) {
+	existing, err := app.Config.ListCommitmentRenewals(ctx, config.CommitmentRenewalFilter{SourceEndAfter: &now})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commitment renewals: %w", err)
+	}
+	keys := make(map[string]bool, len(existing))
+	for i := range existing {
+		r := &existing[i]
+		keys[plannedRenewalKey(r.Provider, r.AccountID, r.SourceCommitmentID, r.SourceEndDate)] = true
+	}
+	return keys, nil
+}
+
+func renewalKey(provider, accountID, commitmentID string) string {
+	return provider + "/" + accountID + "/" + commitmentID
+}
+
+// plannedRenewalKey mirrors the commitment_renewals natural key.
+func plannedRenewalKey(provider, accountID, commitmentID string, end time.Time) string {
+	return renewalKey(provider, accountID, commitmentID) + "@" + end.UTC().Format(time.RFC3339)
+}
+
+// expiringInventory returns the inventory rows whose commitments end within
+// lookaheadDays of now, soonest first.
+func expiringInventory(inventory []config.CommitmentInventoryRecord, now time.Time, lookaheadDays int) []config.CommitmentInventoryRecord {
+	commitments := make([]common.Commitment, 0, len(inventory))
+	byKey := make(map[string]config.CommitmentInventoryRecord, len(inventory))
+	for i := range inventory {
+		rec := &inventory[i]
+		commitments = append(commitments, inventoryCommitment(rec))
+		byKey[renewalKey(rec.Provider, rec.AccountID, rec.CommitmentID)] = *rec
+	}
+	expiring := renewal.Expiring(commitments, now, lookaheadDays)
+	out := make([]config.CommitmentInventoryRecord, 0, len(expiring))
+	for _, c := range expiring {
+		out = append(out, byKey[renewalKey(string(c.Provider), c.Account, c.CommitmentID)])
+	}
+	return out
+}
+
+// inventoryCommitment maps an inventory row back to the provider commitment
+// it was synced from.
+func inventoryCommitment(rec *config.CommitmentInventoryRecord) common.Commitment {
+	c := common.Commitment{
+		Provider:       common.ProviderType(rec.Provider),
+		Account:        rec.AccountID,
+		CommitmentID:   rec.CommitmentID,
+		CommitmentType: common.CommitmentType(rec.CommitmentType),
+		Service:        common.ServiceType(rec.Service),
+		Region:         rec.Region,
+		ResourceType:   rec.ResourceType,
+		Engine:         rec.Engine,
+		Count:          rec.Count,
+		State:          rec.State,
+		Cost:           rec.HourlyCost,
+	}
+	if rec.StartDate != nil {
+		c.StartDate = *rec.StartDate
+	}
+	if rec.EndDate != nil {
+		c.EndDate = *rec.EndDate
+	}
+	return c
+}
+
+// recordRenewal stores proposal. A let_expire proposal is recorded as
+// settled; any other becomes a pending purchase execution, linked from a
+// proposed renewal, and the approvers are notified.
+func (app *Application) recordRenewal(ctx context.Context, rec *config.CommitmentInventoryRecord, proposal renewal.Proposal, notifyEmail string, result *RenewalPlanResult) error {
+	proposalJSON, err := json.Marshal(proposal)
+	if err != nil {
+		return fmt.Errorf("marshal proposal: %w", err)
+	}
+	row := &config.CommitmentRenewal{
+		Provider:           rec.Provider,
+		AccountID:          rec.AccountID,
+		CloudAccountID:     rec.CloudAccountID,
+		SourceCommitmentID: rec.CommitmentID,
+		SourceEndDate:      proposal.Source.EndDate,
+		Action:             string(proposal.Action),
+		UtilizationPct:     proposal.UtilizationPct,
+		Reason:             proposal.Reason,
+		Proposal:           proposalJSON,
+		Status:             config.RenewalStatusLetExpire,
+	}
+	if proposal.Action == renewal.ActionLetExpire {
+		if createErr := app.Config.CreateCommitmentRenewal(ctx, row); createErr != nil {
+			return createErr
+		}
+		result.LetExpire++
+		return nil
+	}
+
+	exec, err := renewalExecution(rec, proposal)
+	if err != nil {
+		return err
+	}
+	if saveErr := app.Config.SavePurchaseExecution(ctx, exec); saveErr != nil {
+		return fmt.Errorf("save renewal execution: %w", saveErr)
+	}
+	row.ExecutionID = &exec.ExecutionID
+	row.Status = config.RenewalStatusProposed
+	if createErr := app.Config.CreateCommitmentRenewal(ctx, row); createErr != nil {
+		return createErr
+	}
+	result.Proposed++
+	app.sendRenewalNotification(ctx, exec, proposal, notifyEmail)
+	return nil
+}
+
+// renewalExecution builds the pending, plan-less purchase execution that
+// buys proposal's replacement at the source's expiry. The approval token
+// stays valid until ApprovalTokenTTL past the expiry, like a plan
+// execution's stays valid past its ScheduledDate.
+func renewalExecution(rec *config.CommitmentInventoryRecord, proposal renewal.Proposal) (*config.PurchaseExecution, error) {
+	r := proposal.Replacement
+	if r == nil {
+		return nil, fmt.Errorf("proposal %s has no replacement", proposal.Action)
+	}
+	var details json.RawMessage
+	if r.CommitmentType == common.CommitmentSavingsPlan {
+		raw, err := common.MarshalServiceDetails(&common.SavingsPlanDetails{PlanType: r.PlanType, HourlyCommitment: r.HourlyCommitment})
+		if err != nil {
+			return nil, err
+		}
+		details = raw
+	}
+	approvalToken, err := common.GenerateApprovalToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate approval token: %w", err)
+	}
+
+	startAt := proposal.StartAt
+	tokenExpiresAt := startAt.Add(config.ApprovalTokenTTL)
+	return &config.PurchaseExecution{
+		ExecutionID:            uuid.New().String(),
+		Status:                 "pending",
+		ScheduledDate:          startAt,
+		ApprovalToken:          approvalToken,
+		ApprovalTokenExpiresAt: &tokenExpiresAt,
+		CloudAccountID:         rec.CloudAccountID,
+		CapacityPercent:        100,
+		Recommendations: []config.RecommendationRecord{{
+			ID:             uuid.New().String(),
+			Provider:       rec.Provider,
+			Service:        string(r.Service),
+			Region:         r.Region,
+			ResourceType:   r.ResourceType,
+			Engine:         r.Engine,
+			Details:        details,
+			Count:          r.Count,
+			Term:           proposal.TermYears,
+			Payment:        renewalPayment(rec.Provider),
+			Selected:       true,
+			CloudAccountID: rec.CloudAccountID,
+			StartAt:        &startAt,
+		}},
+	}, nil
+}
+
+// renewalPayment returns the no-upfront payment option in each provider's
+// vocabulary, so renewing never requires an upfront payment the approvers
+// did not plan for.
+func renewalPayment(provider string) string {
+	if provider == string(common.ProviderAWS) {
+		return "no-upfront"
+	}
+	return "monthly"
+}
+
+// renewalNotifyEmail returns the global notification address, or "" when
+// none is configured.
+func (app *Application) renewalNotifyEmail(ctx context.Context) string {
+	globalCfg, err := app.Config.GetGlobalConfig(ctx)
+	if err != nil || globalCfg == nil || globalCfg.NotificationEmail == nil {
+		return ""
+	}
+	return *globalCfg.NotificationEmail
+}
+
+// sendRenewalNotification emails the approval request for a drafted
+// renewal. Email errors are logged but don't fail the task: the execution
+// is still listed for approval in the dashboard.
+func (app *Application) sendRenewalNotification(ctx context.Context, exec *config.PurchaseExecution, proposal renewal.Proposal, notifyEmail string) {
+	if app.Email == nil {
+		return
+	}
+	// The body embeds the approval token and must go to a specific inbox
+	// rather than the SNS broadcast topic.
+	if notifyEmail == "" {
+		log.Printf("Warning: skipping renewal notification for execution %s: no notification email configured in global settings", exec.ExecutionID)
+		return
+	}
+	data := email.NotificationData{
+		DashboardURL:      app.appConfig.DashboardURL,
+		ApprovalToken:     exec.ApprovalToken,
+		ExecutionID:       exec.ExecutionID,
+		PurchaseDate:      exec.ScheduledDate.Format("January 2, 2006"),
+		DaysUntilPurchase: int(time.Until(exec.ScheduledDate).Hours() / config.HoursPerDay),
+		PlanName:          fmt.Sprintf("Renewal of %s (%s)", proposal.Source.CommitmentID, strings.ReplaceAll(string(proposal.Action), "_", " ")),
+		RecipientEmail:    notifyEmail,
+	}
+	for i := range exec.Recommendations {
+		rec := exec.Recommendations[i]
+		data.Recommendations = append(data.Recommendations, email.RecommendationSummary{
+			Service:      rec.Service,
+			ResourceType: rec.ResourceType,
+			Engine:       rec.Engine,
+			Region:       rec.Region,
+			Payment:      rec.Payment,
+			Count:        rec.Count,
+			Term:         rec.Term,
+		})
+	}
+	if err := app.Email.SendScheduledPurchaseNotification(ctx, data); err != nil {
+		log.Printf("Warning: failed to send renewal notification for execution %s: %v", exec.ExecutionID, err)
+	}
+}
+
+// renewalUtilizationFor returns the injected measurement when one is set
+// (test path), or measures with measureRenewalUtilization.
+func (app *Application) renewalUtilizationFor(ctx context.Context, records []config.CommitmentInventoryRecord, lookbackDays int) map[string]float64 {
+	if app.renewalUtilization != nil {
+		return app.renewalUtilization(ctx, records, lookbackDays)
+	}
+	return app.measureRenewalUtilization(ctx, records, lookbackDays)
+}
+
+// measureRenewalUtilization returns the utilization percentage of each
+// commitment in records it could measure, keyed by commitment ID. A
+// commitment missing from the map has unknown utilization, which the
+// planner renews like-for-like, so a failed measurement is logged and never
+// fails the run. GCP exposes no per-commitment utilization and is never
+// measured.
+func (app *Application) measureRenewalUtilization(ctx context.Context, records []config.CommitmentInventoryRecord, lookbackDays int) map[string]float64 {
+	out := make(map[string]float64)
+	var awsRecords []config.CommitmentInventoryRecord
+	azureByAccount := make(map[string][]config.CommitmentInventoryRecord)
+	for i := range records {
+		rec := records[i]
+		if rec.Provider == string(common.ProviderAWS) {
+			awsRecords = append(awsRecords, rec)
+		} else if rec.Provider == string(common.ProviderAzure) && rec.CloudAccountID != nil {
+			azureByAccount[*rec.CloudAccountID] = append(azureByAccount[*rec.CloudAccountID], rec)
+		}
+	}
+	if len(awsRecords) > 0 {
+		app.measureAWSRenewalUtilization(ctx, awsRecords, lookbackDays, out)
+	}
+	for accountID, recs := range azureByAccount {
+		app.measureAzureRenewalUtilization(ctx, accountID, recs, lookbackDays, out)
+	}
+	return out
+}
+
+// renewalAWSUtilizationAPI is the slice of the Cost Explorer
+// recommendations client the renewal planner measures AWS utilization
+// with. Satisfied by *recommendations.Client.
+type renewalAWSUtilizationAPI interface {
+	GetRIUtilization(ctx context.Context, lookbackDays int, region string) ([]recommendations.RIUtilization, error)
+	GetServiceRIUtilization(ctx context.Context, service common.ServiceType, lookbackDays int, region string) ([]recommendations.RIUtilization, error)
+	GetSPUtilization(ctx context.Context, planType cetypes.SupportedSavingsPlansType, region string, lookbackDays int) (recommendations.SPUtilizationSummary, error)
+}
+
+// measureAWSRenewalUtilization reads AWS utilization from Cost Explorer with
+// the ambient credentials, which see every linked account's commitments
+// when CUDly runs in the payer account.
+func (app *Application) measureAWSRenewalUtilization(ctx context.Context, records []config.CommitmentInventoryRecord, lookbackDays int, out map[string]float64) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Printf("Warning: renewal utilization: failed to load AWS config: %v", err)
+		return
+	}
+	client := recommendations.NewClient(&awsCfg)
+	measureAWSReservationUtilization(ctx, client, records, lookbackDays, out)
+	measureAWSSavingsPlanUtilization(ctx, client, records, lookbackDays, out)
+}
+
+// measureAWSReservationUtilization measures reservations one by one,
+// querying each service and region once.
+func measureAWSReservationUtilization(ctx context.Context, client renewalAWSUtilizationAPI, records []config.CommitmentInventoryRecord, lookbackDays int, out map[string]float64) {
+	queried := make(map[string]bool)
+	for i := range records {
+		rec := &records[i]
+		if rec.CommitmentType == string(common.CommitmentSavingsPlan) {
+			continue
+		}
+		service, ok := renewalRIService(rec.Service)
+		scope := string(service) + "/" + rec.Region
+		if !ok || queried[scope] {
+			continue
+		}
+		queried[scope] = true
+		var utils []recommendations.RIUtilization
+		var err error
+		if service == common.ServiceEC2 {
+			utils, err = client.GetRIUtilization(ctx, lookbackDays, rec.Region)
+		} else {
+			utils, err = client.GetServiceRIUtilization(ctx, service, lookbackDays, rec.Region)
+		}
+		if err != nil {
+			log.Printf("Warning: renewal utilization: %s: %v", scope, err)
+			continue
+		}
+		for _, u := range utils {
+			out[u.ReservedInstanceID] = u.UtilizationPercent
+		}
+	}
+}
+
+// measureAWSSavingsPlanUtilization measures Savings Plans. Cost Explorer
+// reports utilization per plan type, not per plan, so every plan of a type
+// gets that type's utilization across all regions.
+func measureAWSSavingsPlanUtilization(ctx context.Context, client renewalAWSUtilizationAPI, records []config.CommitmentInventoryRecord, lookbackDays int, out map[string]float64) {
+	byType := make(map[cetypes.SupportedSavingsPlansType]*float64)
+	for i := range records {
+		rec := &records[i]
+		if rec.CommitmentType != string(common.CommitmentSavingsPlan) {
+			continue
+		}
+		planType, ok := renewalSPPlanType(rec.ResourceType)
+		if !ok {
+			continue
+		}
+		pct, queried := byType[planType]
+		if !queried {
+			summary, err := client.GetSPUtilization(ctx, planType, "", lookbackDays)
+			if err != nil {
+				log.Printf("Warning: renewal utilization: %s Savings Plans: %v", planType, err)
+			}
+			pct = summary.UtilizationPct
+			byType[planType] = pct
+		}
+		if pct != nil {
+			out[rec.CommitmentID] = *pct
+		}
+	}
+}
+
+// renewalRIService maps an inventory service to the service Cost Explorer
+// reservation utilization is queried for. The inventory stores the
+// canonical slugs the AWS provider lists under; utilization takes the
+// legacy per-service ones.
+func renewalRIService(service string) (common.ServiceType, bool) {
+	switch common.ServiceType(service) {
+	case common.ServiceEC2, common.ServiceCompute:
+		return common.ServiceEC2, true
+	case common.ServiceRDS, common.ServiceRelationalDB:
+		return common.ServiceRDS, true
+	case common.ServiceElastiCache, common.ServiceCache:
+		return common.ServiceElastiCache, true
+	case common.ServiceOpenSearch, common.ServiceSearch:
+		return common.ServiceOpenSearch, true
+	}
+	return "", false
+}
+
+// renewalSPPlanType maps a Savings Plan's inventory ResourceType (its plan
+// type as the Savings Plans API reports it) to the Cost Explorer enum.
+func renewalSPPlanType(planType string) (cetypes.SupportedSavingsPlansType, bool) {
+	switch planType {
+	case renewal.PlanTypeCompute:
+		return cetypes.SupportedSavingsPlansTypeComputeSp, true
+	case renewal.PlanTypeEC2Instance:
+		return cetypes.SupportedSavingsPlansTypeEc2InstanceSp, true
+	case "SageMaker":
+		return cetypes.SupportedSavingsPlansTypeSagemakerSp, true
+	case "Database":
+		return cetypes.SupportedSavingsPlansTypeDatabaseSp, true
+	}
+	return "", false
+}
+
+// measureAzureRenewalUtilization reads the utilization of one Azure cloud
+// account's reservations, keyed by their reservation GUID.
+func (app *Application) measureAzureRenewalUtilization(ctx context.Context, cloudAccountID string, records []config.CommitmentInventoryRecord, lookbackDays int, out map[string]float64) {
+	acct, err := app.Config.GetCloudAccount(ctx, cloudAccountID)
+	if err != nil || acct == nil {
+		log.Printf("Warning: renewal utilization: failed to load Azure cloud account %s: %v", cloudAccountID, err)
+		return
+	}
+	clients, err := app.azureRIExchangeClientsFor(ctx, acct)
+	if err != nil {
+		log.Printf("Warning: renewal utilization: Azure subscription %s: %v", acct.ExternalID, err)
+		return
+	}
+	utils, err := clients.getRIUtilization(ctx, lookbackDays)
+	if err != nil {
+		log.Printf("Warning: renewal utilization: Azure subscription %s: %v", acct.ExternalID, err)
+		return
+	}
+	byGUID := make(map[string]float64, len(utils))
+	for _, u := range utils {
+		byGUID[strings.ToLower(azureReservationGUID(u.ReservedInstanceID))] = u.UtilizationPercent
+	}
+	for i := range records {
+		if pct, ok := byGUID[strings.ToLower(azureReservationGUID(records[i].CommitmentID))]; ok {
+			out[records[i].CommitmentID] = pct
+		}
+	}
+}
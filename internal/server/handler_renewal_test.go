@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/email"
+	"github.com/LeanerCloud/CUDly/internal/mocks"
+	"github.com/LeanerCloud/CUDly/internal/testutil"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/renewal"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// renewalEmailRecorder records the scheduled-purchase notifications sent.
+type renewalEmailRecorder struct {
+	noopEmailSender
+	sent []email.NotificationData
+}
+
+func (r *renewalEmailRecorder) SendScheduledPurchaseNotification(_ context.Context, data email.NotificationData) error {
+	r.sent = append(r.sent, data)
+	return nil
+}
+
+func pctPtr(v float64) *float64 { return &v }
+
+func renewalTestApp(store *mocks.MockConfigStore, util map[string]float64) *Application {
+	return &Application{
+		Config: store,
+		appConfig: ApplicationConfig{Renewal: RenewalConfig{
+			Config:       renewal.DefaultConfig(),
+			LookbackDays: 30,
+		}},
+		renewalUtilization: func(context.Context, []config.CommitmentInventoryRecord, int) map[string]float64 {
+			return util
+		},
+	}
+}
+
+func renewalInventoryRecord(id string, end time.Time) config.CommitmentInventoryRecord {
+	start := end.AddDate(-1, 0, 0)
+	acct := "acct-uuid-1"
+	return config.CommitmentInventoryRecord{
+		Provider:       "aws",
+		AccountID:      "123456789012",
+		CloudAccountID: &acct,
+		CommitmentID:   id,
+		CommitmentType: string(common.CommitmentReservedInstance),
+		Service:        string(common.ServiceEC2),
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+		Count:          4,
+		StartDate:      &start,
+		EndDate:        &end,
+		State:          "active",
+		HourlyCost:     0.4,
+	}
+}
+
+func isProposedFilter(f config.CommitmentRenewalFilter) bool {
+	return len(f.Statuses) == 1 && f.Statuses[0] == config.RenewalStatusProposed
+}
+
+func TestHandlePlanRenewals_ProposesReplacements(t *testing.T) {
+	now := time.Now().UTC()
+	soon := now.Add(10 * 24 * time.Hour).Truncate(time.Second)
+	store := new(mocks.MockConfigStore)
+	notify := "finops@example.com"
+	store.On("GetGlobalConfig", mock.Anything).Return(&config.GlobalConfig{NotificationEmail: &notify}, nil)
+	store.On("ListCommitmentRenewals", mock.Anything, mock.MatchedBy(isProposedFilter)).Return([]config.CommitmentRenewal{}, nil)
+	store.On("ListCommitmentRenewals", mock.Anything, mock.MatchedBy(func(f config.CommitmentRenewalFilter) bool {
+		return f.SourceEndAfter != nil
+	})).Return([]config.CommitmentRenewal{{Provider: "aws", AccountID: "123456789012", SourceCommitmentID: "ri-planned", SourceEndDate: soon}}, nil)
+	store.On("GetActiveCommitmentInventory", mock.Anything, mock.Anything, []string(nil), map[string][]string(nil)).
+		Return([]config.CommitmentInventoryRecord{
+			renewalInventoryRecord("ri-busy", soon),
+			renewalInventoryRecord("ri-idle", soon),
+			renewalInventoryRecord("ri-planned", soon),
+			renewalInventoryRecord("ri-later", now.AddDate(0, 3, 0)),
+		}, nil)
+	var saved []*config.PurchaseExecution
+	store.SavePurchaseExecutionFn = func(_ context.Context, exec *config.PurchaseExecution) error {
+		saved = append(saved, exec)
+		return nil
+	}
+	var created []*config.CommitmentRenewal
+	store.On("CreateCommitmentRenewal", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(1).(*config.CommitmentRenewal))
+	}).Return(nil)
+
+	app := renewalTestApp(store, map[string]float64{"ri-busy": 95, "ri-idle": 0})
+	sender := &renewalEmailRecorder{}
+	app.Email = sender
+
+	result, err := app.HandleScheduledTask(testutil.TestContext(t), TaskPlanRenewals, ScheduledTaskParams{})
+	require.NoError(t, err)
+	planned, ok := result.(*RenewalPlanResult)
+	require.True(t, ok, "result should be a RenewalPlanResult")
+	assert.Equal(t, 3, planned.Expiring)
+	assert.Equal(t, 1, planned.AlreadyPlanned)
+	assert.Equal(t, 1, planned.Proposed)
+	assert.Equal(t, 1, planned.LetExpire)
+
+	require.Len(t, saved, 1)
+	exec := saved[0]
+	assert.Equal(t, "pending", exec.Status)
+	assert.Empty(t, exec.PlanID)
+	assert.Equal(t, soon, exec.ScheduledDate)
+	require.NotNil(t, exec.ApprovalTokenExpiresAt)
+	assert.Equal(t, soon.Add(config.ApprovalTokenTTL), *exec.ApprovalTokenExpiresAt)
+	require.Len(t, exec.Recommendations, 1)
+	rec := exec.Recommendations[0]
+	assert.Equal(t, 4, rec.Count)
+	assert.Equal(t, 1, rec.Term)
+	assert.Equal(t, "no-upfront", rec.Payment)
+	assert.True(t, rec.Selected)
+	require.NotNil(t, rec.StartAt)
+	assert.Equal(t, soon, *rec.StartAt)
+
+	require.Len(t, created, 2)
+	assert.Equal(t, "ri-busy", created[0].SourceCommitmentID)
+	assert.Equal(t, config.RenewalStatusProposed, created[0].Status)
+	require.NotNil(t, created[0].ExecutionID)
+	assert.Equal(t, exec.ExecutionID, *created[0].ExecutionID)
+	assert.Equal(t, "ri-idle", created[1].SourceCommitmentID)
+	assert.Equal(t, config.RenewalStatusLetExpire, created[1].Status)
+	assert.Nil(t, created[1].ExecutionID)
+
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, notify, sender.sent[0].RecipientEmail)
+	assert.Equal(t, exec.ApprovalToken, sender.sent[0].ApprovalToken)
+	assert.Contains(t, sender.sent[0].PlanName, "ri-busy")
+}
+
+func TestHandlePlanRenewals_ReconcilesEarlierProposals(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	done, lapsed, waiting := "exec-done", "exec-lapsed", "exec-waiting"
+	store := new(mocks.MockConfigStore)
+	store.On("ListCommitmentRenewals", mock.Anything, mock.MatchedBy(isProposedFilter)).Return([]config.CommitmentRenewal{
+		{ID: "ren-done", ExecutionID: &done},
+		{ID: "ren-lapsed", ExecutionID: &lapsed},
+		{ID: "ren-waiting", ExecutionID: &waiting},
+	}, nil)
+	store.On("ListCommitmentRenewals", mock.Anything, mock.Anything).Return([]config.CommitmentRenewal{}, nil)
+	store.On("GetExecutionByID", mock.Anything, done).Return(&config.PurchaseExecution{
+		ExecutionID:     done,
+		Status:          "completed",
+		Recommendations: []config.RecommendationRecord{{PurchaseID: "ri-new"}},
+	}, nil)
+	store.On("GetExecutionByID", mock.Anything, lapsed).Return(&config.PurchaseExecution{
+		ExecutionID: lapsed, Status: "pending", ApprovalTokenExpiresAt: &past,
+	}, nil)
+	store.On("GetExecutionByID", mock.Anything, waiting).Return(&config.PurchaseExecution{
+		ExecutionID: waiting, Status: "notified", ApprovalTokenExpiresAt: &future,
+	}, nil)
+	store.On("TransitionExecutionStatus", mock.Anything, lapsed, []string{"pending", "notified"}, "expired", (*string)(nil)).
+		Return(&config.PurchaseExecution{ExecutionID: lapsed, Status: "expired"}, nil)
+	store.On("UpdateCommitmentRenewalStatus", mock.Anything, "ren-done", config.RenewalStatusRenewed,
+		mock.MatchedBy(func(id *string) bool { return id != nil && *id == "ri-new" })).Return(nil)
+	store.On("UpdateCommitmentRenewalStatus", mock.Anything, "ren-lapsed", config.RenewalStatusDeclined, (*string)(nil)).Return(nil)
+
+	result, err := renewalTestApp(store, nil).handlePlanRenewals(testutil.TestContext(t))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Renewed)
+	assert.Equal(t, 1, result.Declined)
+	assert.Zero(t, result.Expiring)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "UpdateCommitmentRenewalStatus", mock.Anything, "ren-waiting", mock.Anything, mock.Anything)
+}
+
+func TestRenewalExecution_SavingsPlanReplacement(t *testing.T) {
+	end := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	rec := renewalInventoryRecord("ri-1", end)
+	proposal := renewal.Propose(inventoryCommitment(&rec), pctPtr(30), renewal.DefaultConfig())
+	require.Equal(t, renewal.ActionConvertToSavingsPlan, proposal.Action)
+
+	exec, err := renewalExecution(&rec, proposal)
+	require.NoError(t, err)
+	require.Len(t, exec.Recommendations, 1)
+	got := exec.Recommendations[0]
+	assert.Equal(t, string(common.ServiceSavingsPlansCompute), got.Service)
+	var details common.SavingsPlanDetails
+	require.NoError(t, json.Unmarshal(got.Details, &details))
+	assert.Equal(t, renewal.PlanTypeCompute, details.PlanType)
+	assert.InDelta(t, 0.12, details.HourlyCommitment, 1e-9)
+}
+
+type stubRenewalAWSUtilization struct {
+	spCalls []cetypes.SupportedSavingsPlansType
+	riCalls []string
+}
+
+func (s *stubRenewalAWSUtilization) GetRIUtilization(_ context.Context, _ int, region string) ([]recommendations.RIUtilization, error) {
+	s.riCalls = append(s.riCalls, "ec2/"+region)
+	return []recommendations.RIUtilization{{ReservedInstanceID: "ri-1", UtilizationPercent: 70}}, nil
+}
+
+func (s *stubRenewalAWSUtilization) GetServiceRIUtilization(_ context.Context, service common.ServiceType, _ int, region string) ([]recommendations.RIUtilization, error) {
+	s.riCalls = append(s.riCalls, string(service)+"/"+region)
+	return []recommendations.RIUtilization{{ReservedInstanceID: "db-ri-1", UtilizationPercent: 55}}, nil
+}
+
+func (s *stubRenewalAWSUtilization) GetSPUtilization(_ context.Context, planType cetypes.SupportedSavingsPlansType, _ string, _ int) (recommendations.SPUtilizationSummary, error) {
+	s.spCalls = append(s.spCalls, planType)
+	return recommendations.SPUtilizationSummary{UtilizationPct: pctPtr(88)}, nil
+}
+
+func TestMeasureAWSRenewalUtilization(t *testing.T) {
+	end := time.Now().AddDate(0, 0, 10)
+	ec2a := renewalInventoryRecord("ri-1", end)
+	ec2b := renewalInventoryRecord("ri-2", end)
+	db := renewalInventoryRecord("db-ri-1", end)
+	db.Service = string(common.ServiceRelationalDB)
+	spA := renewalInventoryRecord("sp-1", end)
+	spA.CommitmentType, spA.ResourceType = string(common.CommitmentSavingsPlan), renewal.PlanTypeCompute
+	spB := spA
+	spB.CommitmentID = "sp-2"
+	records := []config.CommitmentInventoryRecord{ec2a, ec2b, db, spA, spB}
+
+	stub := &stubRenewalAWSUtilization{}
+	out := make(map[string]float64)
+	measureAWSReservationUtilization(context.Background(), stub, records, 30, out)
+	measureAWSSavingsPlanUtilization(context.Background(), stub, records, 30, out)
+
+	assert.Equal(t, []string{"ec2/us-east-1", "rds/us-east-1"}, stub.riCalls)
+	assert.Equal(t, []cetypes.SupportedSavingsPlansType{cetypes.SupportedSavingsPlansTypeComputeSp}, stub.spCalls)
+	assert.Equal(t, map[string]float64{"ri-1": 70, "db-ri-1": 55, "sp-1": 88, "sp-2": 88}, out)
+}
+
+func TestRenewalConfig_Validate(t *testing.T) {
+	valid := RenewalConfig{Config: renewal.DefaultConfig(), LookbackDays: 30}
+	assert.NoError(t, valid.Validate())
+
+	noLookback := valid
+	noLookback.LookbackDays = 0
+	assert.ErrorContains(t, noLookback.Validate(), "RENEWAL_LOOKBACK_DAYS")
+
+	inverted := valid
+	inverted.ConvertBelowPct = 90
+	assert.ErrorContains(t, inverted.Validate(), "convert threshold")
+}
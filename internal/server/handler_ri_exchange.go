@@ -114,6 +114,10 @@ func (app *Application) executeRIExchangeReshape(ctx context.Context, cfg *confi
 		return nil, fmt.Errorf("auto exchange failed: %w", err)
 	}
 
+	// Azure VM reservations are reshaped into the same result after the AWS
+	// pass, so one notification covers both clouds.
+	app.runAzureRIExchangeReshape(ctx, cfg, result)
+
 	notifyEmail := ""
 	if cfg.NotificationEmail != nil {
 		notifyEmail = *cfg.NotificationEmail
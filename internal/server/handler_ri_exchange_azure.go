@@ -0,0 +1,462 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/exchange"
+	"github.com/LeanerCloud/CUDly/providers/azure"
+	azureladder "github.com/LeanerCloud/CUDly/providers/azure/ladder"
+	azurecompute "github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// azureExchangeLedgerAttempts is the number of times a completed Azure
+// exchange's ledger write is retried after money has moved, matching
+// pkg/exchange's maxLedgerAttempts for the AWS path.
+const azureExchangeLedgerAttempts = 3
+
+// azureRIExchangeAPI is the narrow slice of the Azure compute client the
+// Azure side of ri_exchange_reshape needs: the tenant-wide reservation
+// listing, Azure's own VM reservation recommendations (the exchange
+// targets), and the two-step exchange. Satisfied by
+// *azurecompute.ComputeClient.
+type azureRIExchangeAPI interface {
+	ListExchangeableReservations(ctx context.Context) ([]azurecompute.ExchangeableReservation, error)
+	GetRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error)
+	CalculateExchange(ctx context.Context, sources []azurecompute.ExchangeableReservation, targets []azurecompute.ExchangeTarget) (*azurecompute.ExchangePreview, []azurecompute.CompatibleOffering, error)
+	ExecuteExchange(ctx context.Context, sessionID string) (*azurecompute.ExchangeResult, error)
+}
+
+// azureRIExchangeClients holds the injectable dependencies for reshaping the
+// VM reservations of one Azure subscription. In production
+// buildAzureRIExchangeClients constructs real clients; tests set
+// Application.azureRIExchangeClientsFactory or call
+// executeAzureRIExchangeReshape directly with stubs.
+type azureRIExchangeClients struct {
+	exchange         azureRIExchangeAPI
+	getRIUtilization func(ctx context.Context, lookbackDays int) ([]common.RIUtilization, error)
+	subscriptionID   string
+}
+
+// azureReshapeRun carries the per-subscription state of one Azure reshape
+// pass: the caps from GlobalConfig and the targets already exchanged into,
+// so two under-utilized reservations are not both reshaped into the same
+// recommendation.
+type azureReshapeRun struct {
+	cfg            *config.GlobalConfig
+	clients        azureRIExchangeClients
+	result         *exchange.AutoExchangeResult
+	perExchangeCap *big.Rat
+	usedTargets    map[string]struct{}
+}
+
+// runAzureRIExchangeReshape extends the ri_exchange_reshape task to the VM
+// reservations of every enabled Azure cloud account, appending the outcomes
+// to result so one notification covers both clouds.
+//
+// It runs after the AWS pass on purpose: RunAutoExchange starts by canceling
+// every pending standalone record, Azure ones included, so the Azure pending
+// records created here survive until the next run, exactly like the AWS
+// ones.
+//
+// One subscription failing (credentials, listing, utilization) is logged and
+// does not stop the others. A ledger write that fails after an exchange
+// executed halts the whole pass: without that row GetRIExchangeDailySpend
+// under-counts today's spend and later exchanges could bypass the daily cap.
+func (app *Application) runAzureRIExchangeReshape(ctx context.Context, cfg *config.GlobalConfig, result *exchange.AutoExchangeResult) {
+	provider := string(common.ProviderAzure)
+	enabled := true
+	accounts, err := app.Config.ListCloudAccounts(ctx, config.CloudAccountFilter{Provider: &provider, Enabled: &enabled})
+	if err != nil {
+		log.Printf("Warning: failed to list Azure cloud accounts for RI exchange reshape: %v", err)
+		return
+	}
+
+	for i := range accounts {
+		acct := &accounts[i]
+		clients, buildErr := app.azureRIExchangeClientsFor(ctx, acct)
+		if buildErr != nil {
+			log.Printf("Warning: skipping Azure RI exchange reshape for subscription %s: %v", acct.ExternalID, buildErr)
+			continue
+		}
+		halt, runErr := app.executeAzureRIExchangeReshape(ctx, cfg, clients, result)
+		if runErr != nil {
+			log.Printf("Warning: Azure RI exchange reshape failed for subscription %s: %v", acct.ExternalID, runErr)
+			if isContextErr(runErr) {
+				return
+			}
+		}
+		if halt {
+			return
+		}
+	}
+}
+
+// azureRIExchangeClientsFor returns the injected factory's clients when one
+// is set (test path), or builds real ones with buildAzureRIExchangeClients.
+func (app *Application) azureRIExchangeClientsFor(ctx context.Context, acct *config.CloudAccount) (azureRIExchangeClients, error) {
+	if app.azureRIExchangeClientsFactory != nil {
+		return app.azureRIExchangeClientsFactory(ctx, acct)
+	}
+	return app.buildAzureRIExchangeClients(ctx, acct)
+}
+
+// buildAzureRIExchangeClients resolves the account's Azure credential the
+// same way Azure ladder configs do and builds the compute and
+// recommendations clients for its subscription.
+func (app *Application) buildAzureRIExchangeClients(ctx context.Context, acct *config.CloudAccount) (azureRIExchangeClients, error) {
+	if acct.ExternalID == "" {
+		return azureRIExchangeClients{}, errors.New("cloud account has no subscription ID")
+	}
+	if app.AzureLadderCredentialResolver == nil {
+		return azureRIExchangeClients{}, errors.New("AzureLadderCredentialResolver is nil (credential store not connected)")
+	}
+	cred, err := app.AzureLadderCredentialResolver(ctx, acct)
+	if err != nil {
+		return azureRIExchangeClients{}, fmt.Errorf("failed to resolve Azure credential: %w", err)
+	}
+	recs, err := azure.NewRecommendationsClientAdapter(cred, acct.ExternalID)
+	if err != nil {
+		return azureRIExchangeClients{}, err
+	}
+	return azureRIExchangeClients{
+		// Region is left empty: the reservation listing, recommendations
+		// and exchange calls are not region-scoped.
+		exchange:         azurecompute.NewClient(cred, acct.ExternalID, ""),
+		getRIUtilization: recs.GetRIUtilization,
+		subscriptionID:   acct.ExternalID,
+	}, nil
+}
+
+// executeAzureRIExchangeReshape reshapes the under-utilized VM reservations
+// billed to one subscription, mirroring exchange.RunAutoExchange for AWS.
+// For each reservation whose utilization over RIExchangeLookbackDays is
+// below RIExchangeUtilizationThreshold:
+//
+//  1. pick Azure's best recommended target on the same term
+//     (azureladder.PickExchangeTarget);
+//  2. price the swap with CalculateExchange;
+//  3. skip it when Azure reports policy errors, no net payable, a non-USD
+//     net payable (the caps are USD and no FX conversion is performed) or a
+//     net payable above RIExchangeMaxPerExchangeUSD;
+//  4. manual mode: save a pending record with an approval token; auto mode:
+//     check the daily cap against the RI exchange ledger, ExecuteExchange
+//     the priced session and record it as completed.
+//
+// Reservations without a utilization measurement are left alone: no
+// measurement is not evidence of under-use. Returns halt=true when a ledger
+// write failed after money moved, and an error when the subscription could
+// not be analyzed or ctx was canceled.
+func (app *Application) executeAzureRIExchangeReshape(ctx context.Context, cfg *config.GlobalConfig, clients azureRIExchangeClients, result *exchange.AutoExchangeResult) (bool, error) {
+	owned, err := listAzureReservationsBilledTo(ctx, clients)
+	if err != nil {
+		return false, err
+	}
+	if len(owned) == 0 {
+		return false, nil
+	}
+	utils, err := clients.getRIUtilization(ctx, cfg.RIExchangeLookbackDays)
+	if err != nil {
+		return false, fmt.Errorf("failed to get reservation utilization: %w", err)
+	}
+	candidates, err := clients.exchange.GetRecommendations(ctx, &common.RecommendationParams{Service: common.ServiceCompute})
+	if err != nil {
+		return false, fmt.Errorf("failed to get exchange targets: %w", err)
+	}
+
+	utilByGUID := make(map[string]common.RIUtilization, len(utils))
+	for i := range utils {
+		utilByGUID[strings.ToLower(utils[i].ReservedInstanceID)] = utils[i]
+	}
+
+	run := &azureReshapeRun{
+		cfg:            cfg,
+		clients:        clients,
+		result:         result,
+		perExchangeCap: new(big.Rat).SetFloat64(cfg.RIExchangeMaxPerExchangeUSD),
+		usedTargets:    make(map[string]struct{}),
+	}
+	for i := range owned {
+		r := &owned[i]
+		u, ok := utilByGUID[strings.ToLower(azureReservationGUID(r.ReservationID))]
+		if !ok || u.PurchasedHours == 0 || u.UtilizationPercent >= cfg.RIExchangeUtilizationThreshold {
+			continue
+		}
+		if halt, reshapeErr := app.reshapeAzureReservation(ctx, run, r, u.UtilizationPercent, candidates); reshapeErr != nil || halt {
+			return halt, reshapeErr
+		}
+	}
+	return false, nil
+}
+
+// listAzureReservationsBilledTo lists the tenant-wide exchangeable
+// reservations and keeps the ones billed to the subscription: an exchange
+// refunds each source to its own billing scope, so reshaping another
+// subscription's reservation would move that subscription's money.
+func listAzureReservationsBilledTo(ctx context.Context, clients azureRIExchangeClients) ([]azurecompute.ExchangeableReservation, error) {
+	all, err := clients.exchange.ListExchangeableReservations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchangeable reservations: %w", err)
+	}
+	scope := azureSubscriptionScope(clients.subscriptionID)
+	owned := make([]azurecompute.ExchangeableReservation, 0, len(all))
+	for i := range all {
+		if strings.EqualFold(all[i].BillingScopeID, scope) {
+			owned = append(owned, all[i])
+		}
+	}
+	return owned, nil
+}
+
+// reshapeAzureReservation prices and records (manual) or executes (auto) the
+// exchange for one under-utilized reservation. Quote failures and tripped
+// guardrails are skips; only context cancellation is returned as an error.
+func (app *Application) reshapeAzureReservation(ctx context.Context, run *azureReshapeRun, r *azurecompute.ExchangeableReservation, utilPct float64, candidates []common.Recommendation) (bool, error) {
+	target, key, ok := azureladder.PickExchangeTarget(candidates, r, run.usedTargets)
+	if !ok {
+		run.skip(r, "no recommended exchange target on the same term")
+		return false, nil
+	}
+	target.BillingScopeID = azureSubscriptionScope(run.clients.subscriptionID)
+
+	preview, _, err := run.clients.exchange.CalculateExchange(ctx, []azurecompute.ExchangeableReservation{*r}, []azurecompute.ExchangeTarget{target})
+	if err != nil {
+		if isContextErr(err) {
+			return false, err
+		}
+		run.skip(r, fmt.Sprintf("quote failed: %v", err))
+		return false, nil
+	}
+	if reason := azureExchangeSkipReason(preview, run.perExchangeCap, run.cfg.RIExchangeMaxPerExchangeUSD); reason != "" {
+		run.skip(r, reason)
+		return false, nil
+	}
+
+	record := newAzureExchangeRecord(run.clients.subscriptionID, r, target, azurePaymentDue(*preview.NetPayable))
+	outcome := exchange.ExchangeOutcome{
+		SourceRIID:         r.ReservationID,
+		SourceInstanceType: r.SKU,
+		TargetInstanceType: target.SKU,
+		TargetCount:        target.Quantity,
+		PaymentDue:         record.PaymentDue,
+		UtilizationPct:     utilPct,
+	}
+	run.usedTargets[key] = struct{}{}
+
+	if run.cfg.RIExchangeMode == string(exchange.ExchangeModeManual) {
+		app.saveAzurePendingExchange(ctx, run, record, outcome)
+		return false, nil
+	}
+	return app.executeAzureAutoExchange(ctx, run, record, preview.SessionID, outcome), nil
+}
+
+// azureExchangeSkipReason returns a non-empty reason when preview must not be
+// recorded or executed. perExchangeCapUSD is perExchangeCap as configured,
+// for the message.
+func azureExchangeSkipReason(preview *azurecompute.ExchangePreview, perExchangeCap *big.Rat, perExchangeCapUSD float64) string {
+	switch {
+	case preview == nil:
+		return "Azure returned no exchange quote"
+	case len(preview.PolicyErrors) > 0:
+		return "invalid exchange: " + strings.Join(preview.PolicyErrors, "; ")
+	case preview.NetPayable == nil:
+		return "Azure did not report a net payable amount"
+	case math.IsNaN(*preview.NetPayable) || math.IsInf(*preview.NetPayable, 0):
+		return fmt.Sprintf("Azure reported a non-finite net payable amount %v", *preview.NetPayable)
+	case !strings.EqualFold(preview.NetPayableCurrency, "USD"):
+		return fmt.Sprintf("net payable is in %q; RI exchange caps are USD", preview.NetPayableCurrency)
+	}
+	if new(big.Rat).SetFloat64(*preview.NetPayable).Cmp(perExchangeCap) > 0 {
+		return fmt.Sprintf("exceeds per-exchange cap: payment $%.2f > cap $%.2f", *preview.NetPayable, perExchangeCapUSD)
+	}
+	return ""
+}
+
+// saveAzurePendingExchange records a manual-mode exchange awaiting approval.
+// The approval path re-quotes the exchange before executing it, so only the
+// target, not Azure's short-lived session, is stored.
+func (app *Application) saveAzurePendingExchange(ctx context.Context, run *azureReshapeRun, record *config.RIExchangeRecord, outcome exchange.ExchangeOutcome) {
+	token, err := common.GenerateApprovalToken()
+	if err != nil {
+		outcome.Error = fmt.Sprintf("failed to generate approval token: %v", err)
+		app.saveAzureFailedExchange(ctx, run, record, exchange.ExchangeModeManual, outcome.Error)
+		run.result.Failed = append(run.result.Failed, outcome)
+		return
+	}
+	// Same 24h safety net as the AWS pending records: the next run (every
+	// 6h) cancels pending standalone records anyway.
+	expiresAt := time.Now().Add(24 * time.Hour)
+	record.Status = "pending"
+	record.Mode = string(exchange.ExchangeModeManual)
+	record.ApprovalToken = token
+	record.ExpiresAt = &expiresAt
+
+	if err = app.Config.SaveRIExchangeRecord(ctx, record); err != nil {
+		log.Printf("Warning: failed to save pending Azure exchange record for %s: %v", record.SourceRIIDs[0], err)
+		outcome.Error = fmt.Sprintf("failed to save record: %v", err)
+		run.result.Failed = append(run.result.Failed, outcome)
+		return
+	}
+	outcome.RecordID = record.ID
+	outcome.ApprovalToken = token
+	run.result.Pending = append(run.result.Pending, outcome)
+}
+
+// executeAzureAutoExchange checks the daily cap against the RI exchange
+// ledger, executes the priced session and records the result. Returns
+// halt=true when the ledger write failed after the exchange executed.
+func (app *Application) executeAzureAutoExchange(ctx context.Context, run *azureReshapeRun, record *config.RIExchangeRecord, sessionID string, outcome exchange.ExchangeOutcome) bool {
+	if reason := app.azureDailyCapReason(ctx, run.cfg.RIExchangeMaxDailyUSD, record.PaymentDue); reason != "" {
+		log.Printf("Warning: skipping Azure exchange for %s: %s", record.SourceRIIDs[0], reason)
+		outcome.Error = reason
+		app.saveAzureFailedExchange(ctx, run, record, exchange.ExchangeModeAuto, reason)
+		run.result.Failed = append(run.result.Failed, outcome)
+		return false
+	}
+
+	res, err := run.clients.exchange.ExecuteExchange(ctx, sessionID)
+	if err != nil {
+		outcome.Error = err.Error()
+		app.saveAzureFailedExchange(ctx, run, record, exchange.ExchangeModeAuto, outcome.Error)
+		run.result.Failed = append(run.result.Failed, outcome)
+		return false
+	}
+
+	now := time.Now()
+	record.ExchangeID = res.SessionID
+	record.Status = "completed"
+	record.Mode = string(exchange.ExchangeModeAuto)
+	record.CompletedAt = &now
+	if res.NetPayable != nil && strings.EqualFold(res.NetPayableCurrency, "USD") {
+		// Persist what Azure committed to, not the pre-execution quote.
+		record.PaymentDue = azurePaymentDue(*res.NetPayable)
+		outcome.PaymentDue = record.PaymentDue
+	}
+	outcome.ExchangeID = res.SessionID
+
+	if err = app.saveAzureLedgerRecord(ctx, record); err != nil {
+		log.Printf("ERROR: all %d ledger save attempts failed for Azure exchange %s after money moved: %v; halting to prevent cap bypass",
+			azureExchangeLedgerAttempts, res.SessionID, err)
+		outcome.Error = fmt.Sprintf("ledger save failed after exchange executed: %v", err)
+		run.result.Failed = append(run.result.Failed, outcome)
+		return true
+	}
+	outcome.RecordID = record.ID
+	run.result.Completed = append(run.result.Completed, outcome)
+	return false
+}
+
+// azureDailyCapReason returns a non-empty reason when paymentDue would take
+// today's RI exchange spend (AWS and Azure alike) above maxDailyUSD. Fails
+// closed when the ledger cannot be read or parsed.
+func (app *Application) azureDailyCapReason(ctx context.Context, maxDailyUSD float64, paymentDue string) string {
+	spentStr, err := app.Config.GetRIExchangeDailySpend(ctx, time.Now())
+	if err != nil {
+		return fmt.Sprintf("daily cap check failed: %v", err)
+	}
+	spent, err := exchange.ParseDecimalRat(spentStr)
+	if err != nil {
+		return fmt.Sprintf("failed to parse daily spend: %v", err)
+	}
+	due, err := exchange.ParseDecimalRat(paymentDue)
+	if err != nil {
+		return fmt.Sprintf("failed to parse payment due %q: %v", paymentDue, err)
+	}
+	if new(big.Rat).Add(spent, due).Cmp(new(big.Rat).SetFloat64(maxDailyUSD)) > 0 {
+		return fmt.Sprintf("daily cap exceeded: spent $%s + payment $%s > cap $%.2f",
+			spent.FloatString(2), due.FloatString(2), maxDailyUSD)
+	}
+	return ""
+}
+
+// saveAzureLedgerRecord saves a completed exchange record, retrying up to
+// azureExchangeLedgerAttempts times.
+func (app *Application) saveAzureLedgerRecord(ctx context.Context, record *config.RIExchangeRecord) error {
+	var err error
+	for attempt := 1; attempt <= azureExchangeLedgerAttempts; attempt++ {
+		if err = app.Config.SaveRIExchangeRecord(ctx, record); err == nil {
+			return nil
+		}
+		log.Printf("Warning: ledger save attempt %d/%d for Azure exchange %s failed: %v",
+			attempt, azureExchangeLedgerAttempts, record.ExchangeID, err)
+	}
+	return err
+}
+
+// saveAzureFailedExchange persists a failed exchange attempt for audit.
+func (app *Application) saveAzureFailedExchange(ctx context.Context, run *azureReshapeRun, record *config.RIExchangeRecord, mode exchange.ExchangeMode, errMsg string) {
+	failed := *record
+	failed.Status = "failed"
+	failed.Mode = string(mode)
+	failed.Error = errMsg
+	failed.ApprovalToken = ""
+	failed.ExpiresAt = nil
+	if err := app.Config.SaveRIExchangeRecord(ctx, &failed); err != nil {
+		log.Printf("Warning: failed to save failed Azure exchange record for %s in subscription %s: %v",
+			record.SourceRIIDs[0], run.clients.subscriptionID, err)
+	}
+}
+
+func (run *azureReshapeRun) skip(r *azurecompute.ExchangeableReservation, reason string) {
+	run.result.Skipped = append(run.result.Skipped, exchange.SkippedRecommendation{
+		SourceRIID:         r.ReservationID,
+		SourceInstanceType: r.SKU,
+		Reason:             reason,
+	})
+}
+
+// newAzureExchangeRecord builds the ri_exchange_history row for exchanging r
+// into target. See config.RIExchangeRecord.Provider for how the Azure
+// fields map onto the AWS-shaped columns.
+func newAzureExchangeRecord(subscriptionID string, r *azurecompute.ExchangeableReservation, target azurecompute.ExchangeTarget, paymentDue string) *config.RIExchangeRecord {
+	return &config.RIExchangeRecord{
+		AccountID:          subscriptionID,
+		Region:             target.Location,
+		SourceRIIDs:        []string{r.ReservationID},
+		SourceInstanceType: r.SKU,
+		SourceCount:        int(r.Quantity),
+		TargetInstanceType: target.SKU,
+		TargetCount:        int(target.Quantity),
+		PaymentDue:         paymentDue,
+		Provider:           string(common.ProviderAzure),
+	}
+}
+
+// azurePaymentDue renders an Azure net payable as the ledger's payment_due.
+// A negative net payable is a refund to the customer: it spends nothing, so
+// it is recorded as 0 (payment_due is CHECK >= 0) rather than netted against
+// the day's other exchanges.
+func azurePaymentDue(net float64) string {
+	if net <= 0 {
+		return "0"
+	}
+	return new(big.Rat).SetFloat64(net).FloatString(6)
+}
+
+// azureSubscriptionScope is the billing scope ID of a subscription.
+func azureSubscriptionScope(subscriptionID string) string {
+	return "/subscriptions/" + subscriptionID
+}
+
+// azureReservationGUID returns the last segment of a reservation's ARM ID,
+// the key Azure reservation utilization is reported under.
+func azureReservationGUID(reservationID string) string {
+	if i := strings.LastIndex(reservationID, "/"); i >= 0 {
+		return reservationID[i+1:]
+	}
+	return reservationID
+}
+
+// isContextErr reports whether err is a context cancellation or deadline
+// expiry, which aborts the run rather than counting as one failed exchange.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/testutil"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/exchange"
+	azurecompute "github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+const azureTestReservationID = "/providers/Microsoft.Capacity/reservationOrders/order-1/reservations/res-guid-1"
+
+type stubAzureRIExchangeAPI struct {
+	reservations []azurecompute.ExchangeableReservation
+	recs         []common.Recommendation
+	preview      *azurecompute.ExchangePreview
+	calcErr      error
+	result       *azurecompute.ExchangeResult
+	executeErr   error
+
+	calculated []azurecompute.ExchangeTarget
+	executed   []string
+}
+
+func (s *stubAzureRIExchangeAPI) ListExchangeableReservations(context.Context) ([]azurecompute.ExchangeableReservation, error) {
+	return s.reservations, nil
+}
+
+func (s *stubAzureRIExchangeAPI) GetRecommendations(context.Context, *common.RecommendationParams) ([]common.Recommendation, error) {
+	return s.recs, nil
+}
+
+func (s *stubAzureRIExchangeAPI) CalculateExchange(_ context.Context, _ []azurecompute.ExchangeableReservation, targets []azurecompute.ExchangeTarget) (*azurecompute.ExchangePreview, []azurecompute.CompatibleOffering, error) {
+	s.calculated = append(s.calculated, targets...)
+	return s.preview, nil, s.calcErr
+}
+
+func (s *stubAzureRIExchangeAPI) ExecuteExchange(_ context.Context, sessionID string) (*azurecompute.ExchangeResult, error) {
+	s.executed = append(s.executed, sessionID)
+	return s.result, s.executeErr
+}
+
+func azureFloat(v float64) *float64 { return &v }
+
+// newStubAzureRIExchange returns a subscription owning one 40%-utilized
+// Standard_D2s_v3 reservation (plus one billed to another subscription),
+// with Azure recommending Standard_D4s_v3 and quoting a $25 USD exchange.
+func newStubAzureRIExchange() *stubAzureRIExchangeAPI {
+	return &stubAzureRIExchangeAPI{
+		reservations: []azurecompute.ExchangeableReservation{
+			{ReservationID: azureTestReservationID, BillingScopeID: "/subscriptions/sub-1", SKU: "Standard_D2s_v3", Region: "eastus", Term: "P1Y", Quantity: 2},
+			{ReservationID: "/providers/Microsoft.Capacity/reservationOrders/order-2/reservations/res-guid-2", BillingScopeID: "/subscriptions/sub-other", SKU: "Standard_D2s_v3", Region: "eastus", Term: "P1Y", Quantity: 1},
+		},
+		recs: []common.Recommendation{
+			{ResourceType: "Standard_D4s_v3", Region: "eastus", Term: "1yr", Count: 1, EstimatedSavings: 50},
+		},
+		preview: &azurecompute.ExchangePreview{SessionID: "session-1", NetPayable: azureFloat(25), NetPayableCurrency: "USD"},
+		result:  &azurecompute.ExchangeResult{SessionID: "session-1", NetPayable: azureFloat(24.5), NetPayableCurrency: "USD", Status: "Succeeded"},
+	}
+}
+
+func azureTestClients(api *stubAzureRIExchangeAPI) azureRIExchangeClients {
+	return azureRIExchangeClients{
+		exchange: api,
+		getRIUtilization: func(context.Context, int) ([]common.RIUtilization, error) {
+			return []common.RIUtilization{
+				{ReservedInstanceID: "RES-GUID-1", UtilizationPercent: 40, PurchasedHours: 720},
+				{ReservedInstanceID: "res-guid-2", UtilizationPercent: 10, PurchasedHours: 720},
+			}, nil
+		},
+		subscriptionID: "sub-1",
+	}
+}
+
+func azureTestConfig(mode string) *config.GlobalConfig {
+	return &config.GlobalConfig{
+		RIExchangeEnabled:              true,
+		RIExchangeMode:                 mode,
+		RIExchangeUtilizationThreshold: 95.0,
+		RIExchangeMaxPerExchangeUSD:    100.0,
+		RIExchangeMaxDailyUSD:          500.0,
+		RIExchangeLookbackDays:         30,
+	}
+}
+
+func TestExecuteAzureRIExchangeReshape_ManualMode(t *testing.T) {
+	ctx := testutil.TestContext(t)
+
+	var saved []*config.RIExchangeRecord
+	app := &Application{Config: &mockConfigStoreForExchange{
+		saveRIExchangeRecordFunc: func(_ context.Context, record *config.RIExchangeRecord) error {
+			record.ID = "rec-1"
+			saved = append(saved, record)
+			return nil
+		},
+	}}
+	api := newStubAzureRIExchange()
+	result := &exchange.AutoExchangeResult{Mode: "manual"}
+
+	halt, err := app.executeAzureRIExchangeReshape(ctx, azureTestConfig("manual"), azureTestClients(api), result)
+	testutil.AssertNoError(t, err)
+	testutil.AssertFalse(t, halt, "manual mode never halts")
+
+	// Only the reservation billed to sub-1 is considered.
+	testutil.AssertEqual(t, 1, len(api.calculated))
+	testutil.AssertEqual(t, "Standard_D4s_v3", api.calculated[0].SKU)
+	testutil.AssertEqual(t, "/subscriptions/sub-1", api.calculated[0].BillingScopeID)
+	testutil.AssertEqual(t, 0, len(api.executed))
+
+	testutil.AssertEqual(t, 1, len(result.Pending))
+	testutil.AssertEqual(t, "rec-1", result.Pending[0].RecordID)
+	testutil.AssertTrue(t, result.Pending[0].ApprovalToken != "", "pending outcome carries the approval token")
+
+	testutil.AssertEqual(t, 1, len(saved))
+	rec := saved[0]
+	testutil.AssertEqual(t, "azure", rec.Provider)
+	testutil.AssertEqual(t, "pending", rec.Status)
+	testutil.AssertEqual(t, "manual", rec.Mode)
+	testutil.AssertEqual(t, "sub-1", rec.AccountID)
+	testutil.AssertEqual(t, "eastus", rec.Region)
+	testutil.AssertEqual(t, azureTestReservationID, rec.SourceRIIDs[0])
+	testutil.AssertEqual(t, 2, rec.SourceCount)
+	testutil.AssertEqual(t, "Standard_D4s_v3", rec.TargetInstanceType)
+	testutil.AssertEqual(t, "25.000000", rec.PaymentDue)
+	testutil.AssertTrue(t, rec.ExpiresAt != nil, "pending record expires")
+}
+
+func TestExecuteAzureRIExchangeReshape_AutoMode(t *testing.T) {
+	ctx := testutil.TestContext(t)
+
+	var saved []*config.RIExchangeRecord
+	app := &Application{Config: &mockConfigStoreForExchange{
+		saveRIExchangeRecordFunc: func(_ context.Context, record *config.RIExchangeRecord) error {
+			saved = append(saved, record)
+			return nil
+		},
+	}}
+	api := newStubAzureRIExchange()
+	result := &exchange.AutoExchangeResult{Mode: "auto"}
+
+	halt, err := app.executeAzureRIExchangeReshape(ctx, azureTestConfig("auto"), azureTestClients(api), result)
+	testutil.AssertNoError(t, err)
+	testutil.AssertFalse(t, halt, "successful ledger write does not halt")
+
+	testutil.AssertEqual(t, 1, len(api.executed))
+	testutil.AssertEqual(t, "session-1", api.executed[0])
+	testutil.AssertEqual(t, 1, len(result.Completed))
+	testutil.AssertEqual(t, "session-1", result.Completed[0].ExchangeID)
+
+	testutil.AssertEqual(t, 1, len(saved))
+	testutil.AssertEqual(t, "completed", saved[0].Status)
+	testutil.AssertEqual(t, "auto", saved[0].Mode)
+	testutil.AssertEqual(t, "azure", saved[0].Provider)
+	// The amount Azure committed to, not the pre-execution quote.
+	testutil.AssertEqual(t, "24.500000", saved[0].PaymentDue)
+}
+
+func TestExecuteAzureRIExchangeReshape_DailyCapFromLedger(t *testing.T) {
+	ctx := testutil.TestContext(t)
+
+	var saved []*config.RIExchangeRecord
+	app := &Application{Config: &mockConfigStoreForExchange{
+		saveRIExchangeRecordFunc: func(_ context.Context, record *config.RIExchangeRecord) error {
+			saved = append(saved, record)
+			return nil
+		},
+		// $490 already spent today (AWS or Azure) + $25 > $500 cap.
+		getDailySpendFunc: func(context.Context, time.Time) (string, error) { return "490.00", nil },
+	}}
+	api := newStubAzureRIExchange()
+	result := &exchange.AutoExchangeResult{Mode: "auto"}
+
+	halt, err := app.executeAzureRIExchangeReshape(ctx, azureTestConfig("auto"), azureTestClients(api), result)
+	testutil.AssertNoError(t, err)
+	testutil.AssertFalse(t, halt, "a cap refusal does not halt")
+
+	testutil.AssertEqual(t, 0, len(api.executed))
+	testutil.AssertEqual(t, 1, len(result.Failed))
+	testutil.AssertContains(t, result.Failed[0].Error, "daily cap exceeded")
+	testutil.AssertEqual(t, 1, len(saved))
+	testutil.AssertEqual(t, "failed", saved[0].Status)
+	testutil.AssertEqual(t, "azure", saved[0].Provider)
+}
+
+func TestExecuteAzureRIExchangeReshape_Guardrails(t *testing.T) {
+	tests := []struct {
+		name    string
+		preview *azurecompute.ExchangePreview
+		calcErr error
+		reason  string
+	}{
+		{"policy errors", &azurecompute.ExchangePreview{SessionID: "s", NetPayable: azureFloat(5), NetPayableCurrency: "USD", PolicyErrors: []string{"refund too large"}}, nil, "invalid exchange: refund too large"},
+		{"nil net payable", &azurecompute.ExchangePreview{SessionID: "s", NetPayableCurrency: "USD"}, nil, "did not report a net payable"},
+		{"non-USD", &azurecompute.ExchangePreview{SessionID: "s", NetPayable: azureFloat(5), NetPayableCurrency: "EUR"}, nil, "caps are USD"},
+		{"over per-exchange cap", &azurecompute.ExchangePreview{SessionID: "s", NetPayable: azureFloat(150), NetPayableCurrency: "USD"}, nil, "exceeds per-exchange cap"},
+		{"quote failure", nil, errors.New("boom"), "quote failed: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testutil.TestContext(t)
+			saves := 0
+			app := &Application{Config: &mockConfigStoreForExchange{
+				saveRIExchangeRecordFunc: func(context.Context, *config.RIExchangeRecord) error {
+					saves++
+					return nil
+				},
+			}}
+			api := newStubAzureRIExchange()
+			api.preview, api.calcErr = tt.preview, tt.calcErr
+			result := &exchange.AutoExchangeResult{Mode: "auto"}
+
+			_, err := app.executeAzureRIExchangeReshape(ctx, azureTestConfig("auto"), azureTestClients(api), result)
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, 0, len(api.executed))
+			testutil.AssertEqual(t, 0, saves)
+			testutil.AssertEqual(t, 1, len(result.Skipped))
+			testutil.AssertContains(t, result.Skipped[0].Reason, tt.reason)
+		})
+	}
+}
+
+func TestExecuteAzureRIExchangeReshape_LedgerFailureHalts(t *testing.T) {
+	ctx := testutil.TestContext(t)
+
+	attempts := 0
+	app := &Application{Config: &mockConfigStoreForExchange{
+		saveRIExchangeRecordFunc: func(context.Context, *config.RIExchangeRecord) error {
+			attempts++
+			return errors.New("db down")
+		},
+	}}
+	api := newStubAzureRIExchange()
+	result := &exchange.AutoExchangeResult{Mode: "auto"}
+
+	halt, err := app.executeAzureRIExchangeReshape(ctx, azureTestConfig("auto"), azureTestClients(api), result)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, halt, "a ledger failure after money moved must halt")
+	testutil.AssertEqual(t, azureExchangeLedgerAttempts, attempts)
+	testutil.AssertEqual(t, 1, len(result.Failed))
+	testutil.AssertContains(t, result.Failed[0].Error, "ledger save failed")
+}
+
+// mockConfigStoreForAzureExchange adds the Azure cloud account listing to
+// mockConfigStoreForExchange.
+type mockConfigStoreForAzureExchange struct {
+	mockConfigStoreForExchange
+	accounts []config.CloudAccount
+	filter   config.CloudAccountFilter
+}
+
+func (m *mockConfigStoreForAzureExchange) ListCloudAccounts(_ context.Context, filter config.CloudAccountFilter) ([]config.CloudAccount, error) {
+	m.filter = filter
+	return m.accounts, nil
+}
+
+func TestRunAzureRIExchangeReshape_SkipsAccountsThatFailToBuild(t *testing.T) {
+	ctx := testutil.TestContext(t)
+
+	store := &mockConfigStoreForAzureExchange{
+		accounts: []config.CloudAccount{{ID: "a-1", ExternalID: "sub-broken"}, {ID: "a-2", ExternalID: "sub-1"}},
+	}
+	api := newStubAzureRIExchange()
+	app := &Application{
+		Config: store,
+		azureRIExchangeClientsFactory: func(_ context.Context, acct *config.CloudAccount) (azureRIExchangeClients, error) {
+			if acct.ExternalID == "sub-broken" {
+				return azureRIExchangeClients{}, errors.New("no credentials")
+			}
+			return azureTestClients(api), nil
+		},
+	}
+	result := &exchange.AutoExchangeResult{Mode: "manual"}
+
+	app.runAzureRIExchangeReshape(ctx, azureTestConfig("manual"), result)
+
+	testutil.AssertEqual(t, "azure", *store.filter.Provider)
+	testutil.AssertTrue(t, *store.filter.Enabled, "only enabled accounts are reshaped")
+	testutil.AssertEqual(t, 1, len(result.Pending))
+}
+
+func TestAzurePaymentDue(t *testing.T) {
+	testutil.AssertEqual(t, "0", azurePaymentDue(-12.5))
+	testutil.AssertEqual(t, "0", azurePaymentDue(0))
+	testutil.AssertEqual(t, "12.500000", azurePaymentDue(12.5))
+}
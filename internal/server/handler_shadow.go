@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/pkg/shadow"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// shadowMaxLookbackDays caps how far back one evaluation reads usage:
+// Cost Explorer keeps a year of daily data. Days older than that which were
+// never scored, because the task did not run, are skipped.
+const shadowMaxLookbackDays = 365
+
+// ShadowEvaluateResult is the outcome of one shadow_evaluate run. Each
+// counter increments once per shadow purchase due for evaluation.
+type ShadowEvaluateResult struct {
+	// Evaluated purchases had new days scored.
+	Evaluated int `json:"evaluated"`
+	// Unchanged purchases had no new usage data to score yet.
+	Unchanged int `json:"unchanged"`
+	// Unsupported purchases have no usage source (only AWS EC2, RDS,
+	// ElastiCache and OpenSearch usage is read).
+	Unsupported int `json:"unsupported"`
+	// Failed purchases could not be scored or saved.
+	Failed    int    `json:"failed"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// shadowUsageAPI is the slice of the Cost Explorer recommendations client
+// the shadow evaluator reads on-demand usage with. Satisfied by
+// *recommendations.Client, which reads ingested CUR data instead when
+// USAGE_DATA_SOURCE=cur.
+type shadowUsageAPI interface {
+	GetScopedOnDemandSeries(ctx context.Context, service common.ServiceType, region string, scope recommendations.OnDemandSeriesScope, lookbackDays int) ([]recommendations.DailyCost, error)
+}
+
+// handleShadowEvaluate scores every shadow purchase with unscored days
+// against the on-demand usage that followed it, and adds the result to the
+// purchase's running evaluation (see pkg/shadow). Usage is read with the
+// ambient AWS credentials, which see every linked account when CUDly runs
+// in the payer account, in the purchase's region or, for ladder purchases,
+// which carry none, the deployment's own. A plan purchase is scored against
+// its own instance type and account only (see shadowUsageScope).
+func (app *Application) handleShadowEvaluate(ctx context.Context) (*ShadowEvaluateResult, error) {
+	log.Println("Evaluating shadow purchases...")
+	now := time.Now().UTC()
+	rows, err := app.Config.ListShadowPurchasesToEvaluate(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shadow purchases: %w", err)
+	}
+	if len(rows) == 0 {
+		log.Println("Shadow purchases: nothing to evaluate")
+		return &ShadowEvaluateResult{}, nil
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := recommendations.NewClient(&awsCfg)
+	client.SetUsageReader(app.usageReader())
+
+	result := evaluateShadowPurchases(ctx, app.Config, client, awsCfg.Region, rows, now)
+	log.Printf("Shadow purchases: evaluated=%d unchanged=%d unsupported=%d failed=%d",
+		result.Evaluated, result.Unchanged, result.Unsupported, result.Failed)
+	return result, nil
+}
+
+// shadowUsageKey groups the shadow purchases that draw on the same usage
+// series.
+type shadowUsageKey struct {
+	service common.ServiceType
+	region  string
+	scope   recommendations.OnDemandSeriesScope
+}
+
+// shadowUsageScope narrows the usage a shadow purchase is scored against
+// to what it could have covered: a plan's reservation covers its own
+// instance type, and any plan purchase covers its own account first. A
+// Savings Plan's resource type is its plan type, not an instance type, so
+// it spans the service. Ladder purchases are left unscoped: the ladder
+// sizes its layers (their resource type) against the region's whole
+// series, which is what they have to be scored against. accounts caches
+// the AWS account ID of each CUDly account looked up.
+func shadowUsageScope(ctx context.Context, store config.StoreInterface, p *config.ShadowPurchase, accounts map[string]string) (recommendations.OnDemandSeriesScope, error) {
+	var scope recommendations.OnDemandSeriesScope
+	if p.Source == config.ShadowSourceLadder {
+		return scope, nil
+	}
+	switch common.ServiceType(p.Service) {
+	case "", common.ServiceSavingsPlansCompute, common.ServiceSavingsPlansEC2Instance:
+	default:
+		scope.InstanceType = p.ResourceType
+	}
+	if p.CloudAccountID == nil || *p.CloudAccountID == "" {
+		return scope, nil
+	}
+	id := *p.CloudAccountID
+	external, seen := accounts[id]
+	if !seen {
+		account, err := store.GetCloudAccount(ctx, id)
+		if err != nil {
+			return scope, fmt.Errorf("failed to load cloud account %s: %w", id, err)
+		}
+		if account == nil || account.ExternalID == "" {
+			return scope, fmt.Errorf("cloud account %s no longer exists or has no AWS account ID", id)
+		}
+		external = account.ExternalID
+		accounts[id] = external
+	}
+	scope.LinkedAccount = external
+	return scope, nil
+}
+
+// shadowUsageService maps a shadow purchase's provider and service to the
+// service whose on-demand series it is scored against. Compute Savings
+// Plans and the compute ladder (no service) are scored against EC2 compute
+// usage.
+func shadowUsageService(provider, service string) (common.ServiceType, bool) {
+	if provider != string(common.ProviderAWS) {
+		return "", false
+	}
+	switch common.ServiceType(service) {
+	case "", common.ServiceSavingsPlansCompute, common.ServiceSavingsPlansEC2Instance:
+		return common.ServiceEC2, true
+	}
+	return renewalRIService(service)
+}
+
+// evaluateShadowPurchases scores rows, ordered oldest purchase first, at
+// now. Each group of purchases drawing on the same usage (service, region
+// and scope) is scored in one pkg/shadow.Evaluate call over one usage
+// read, so overlapping purchases are stacked oldest first rather than each
+// credited with the same usage, and none is credited with more than its
+// own commitment.
+// A usage read the client rejects, such as an empty or all-zero series, is
+// recorded as the evaluation error of the group's purchases rather than
+// read as zero usage.
+func evaluateShadowPurchases(ctx context.Context, store config.StoreInterface, client shadowUsageAPI, defaultRegion string, rows []config.ShadowPurchase, now time.Time) *ShadowEvaluateResult {
+	result := &ShadowEvaluateResult{}
+	var keys []shadowUsageKey
+	groups := make(map[shadowUsageKey][]*config.ShadowPurchase)
+	accounts := make(map[string]string)
+	for i := range rows {
+		p := &rows[i]
+		service, ok := shadowUsageService(p.Provider, p.Service)
+		if !ok {
+			if saveShadowEvaluation(ctx, store, p, now, fmt.Sprintf("no usage source for %s %q", p.Provider, p.Service), result) {
+				result.Unsupported++
+			}
+			continue
+		}
+		scope, err := shadowUsageScope(ctx, store, p, accounts)
+		if err != nil {
+			log.Printf("Warning: shadow purchase %s: %v", p.ID, err)
+			if saveShadowEvaluation(ctx, store, p, now, err.Error(), result) {
+				result.Failed++
+				result.LastError = err.Error()
+			}
+			continue
+		}
+		key := shadowUsageKey{service: service, region: p.Region, scope: scope}
+		if key.region == "" {
+			key.region = defaultRegion
+		}
+		if _, seen := groups[key]; !seen {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+	for _, key := range keys {
+		evaluateShadowGroup(ctx, store, client, key, groups[key], now, result)
+	}
+	return result
+}
+
+// evaluateShadowGroup scores the purchases of one usage series from where
+// each was last scored.
+func evaluateShadowGroup(ctx context.Context, store config.StoreInterface, client shadowUsageAPI, key shadowUsageKey, group []*config.ShadowPurchase, now time.Time, result *ShadowEvaluateResult) {
+	purchases := make([]shadow.Purchase, len(group))
+	earliest := now
+	for i, p := range group {
+		purchases[i] = p.Purchase()
+		if p.EvaluatedThrough != nil && p.EvaluatedThrough.After(purchases[i].Start) {
+			purchases[i].Start = *p.EvaluatedThrough
+		}
+		if purchases[i].Start.Before(earliest) {
+			earliest = purchases[i].Start
+		}
+	}
+
+	usage, err := readShadowUsage(ctx, client, key, shadowLookbackDays(earliest, now))
+	var evals []shadow.Evaluation
+	if err == nil {
+		evals, err = shadow.Evaluate(purchases, usage, now)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("%s usage in %s%s: %v", key.service, key.region, describeShadowScope(key.scope), err)
+		log.Printf("Warning: shadow purchases: %s", msg)
+		for _, p := range group {
+			if saveShadowEvaluation(ctx, store, p, now, msg, result) {
+				result.Failed++
+				result.LastError = msg
+			}
+		}
+		return
+	}
+
+	// Scoring has reached the end of the last day the series covers.
+	var through time.Time
+	if len(usage) > 0 {
+		through = usage[len(usage)-1].Date.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	for i, p := range group {
+		p.Evaluation = p.Evaluation.Merge(evals[i])
+		end := through
+		if purchases[i].End.Before(end) {
+			end = purchases[i].End
+		}
+		if end.After(purchases[i].Start) {
+			p.EvaluatedThrough = &end
+		}
+		switch {
+		case !saveShadowEvaluation(ctx, store, p, now, "", result):
+		case evals[i].Days > 0:
+			result.Evaluated++
+		default:
+			result.Unchanged++
+		}
+	}
+}
+
+// shadowLookbackDays is the usage lookback reaching back to the first
+// whole day after since, capped at shadowMaxLookbackDays.
+func shadowLookbackDays(since, now time.Time) int {
+	days := int(math.Ceil(now.UTC().Truncate(24*time.Hour).Sub(since).Hours() / 24))
+	return max(1, min(days, shadowMaxLookbackDays))
+}
+
+// describeShadowScope renders scope for an evaluation error, "" when it
+// is empty.
+func describeShadowScope(scope recommendations.OnDemandSeriesScope) string {
+	var out string
+	if scope.InstanceType != "" {
+		out += " for " + scope.InstanceType
+	}
+	if scope.LinkedAccount != "" {
+		out += " in account " + scope.LinkedAccount
+	}
+	return out
+}
+
+// readShadowUsage reads the daily on-demand series of key.
+func readShadowUsage(ctx context.Context, client shadowUsageAPI, key shadowUsageKey, lookbackDays int) ([]ladder.DailyPoint, error) {
+	series, err := client.GetScopedOnDemandSeries(ctx, key.service, key.region, key.scope, lookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]ladder.DailyPoint, len(series))
+	for i, c := range series {
+		points[i] = ladder.DailyPoint{Date: c.Date, USDPerHour: c.USDPerHour}
+	}
+	return points, nil
+}
+
+// saveShadowEvaluation stamps p as evaluated at now with evalErr and saves
+// it. A save failure is counted as Failed and logged, does not stop the
+// run, and makes it return false.
+func saveShadowEvaluation(ctx context.Context, store config.StoreInterface, p *config.ShadowPurchase, now time.Time, evalErr string, result *ShadowEvaluateResult) bool {
+	p.EvaluatedAt = &now
+	p.EvaluationError = evalErr
+	if err := store.SaveShadowEvaluation(ctx, p); err != nil {
+		log.Printf("Warning: shadow purchase %s: failed to save evaluation: %v", p.ID, err)
+		result.Failed++
+		result.LastError = err.Error()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/testutil"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/shadow"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// fakeShadowUsage serves fixed daily series keyed by service, or by scope
+// for a scoped read, and records the lookbacks and scopes it was asked for.
+type fakeShadowUsage struct {
+	series    map[common.ServiceType][]recommendations.DailyCost
+	scoped    map[recommendations.OnDemandSeriesScope][]recommendations.DailyCost
+	err       error
+	lookbacks []int
+	scopes    []recommendations.OnDemandSeriesScope
+}
+
+func (f *fakeShadowUsage) GetScopedOnDemandSeries(_ context.Context, service common.ServiceType, _ string, scope recommendations.OnDemandSeriesScope, lookbackDays int) ([]recommendations.DailyCost, error) {
+	f.lookbacks = append(f.lookbacks, lookbackDays)
+	f.scopes = append(f.scopes, scope)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if scope != (recommendations.OnDemandSeriesScope{}) {
+		return f.scoped[scope], nil
+	}
+	return f.series[service], nil
+}
+
+// shadowEvalTestStore records every saved evaluation and serves accounts.
+type shadowEvalTestStore struct {
+	mockConfigStoreForHealth
+	accounts map[string]*config.CloudAccount
+	saved    []config.ShadowPurchase
+	saveErr  error
+}
+
+func (s *shadowEvalTestStore) GetCloudAccount(_ context.Context, id string) (*config.CloudAccount, error) {
+	return s.accounts[id], nil
+}
+
+func (s *shadowEvalTestStore) SaveShadowEvaluation(_ context.Context, p *config.ShadowPurchase) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.saved = append(s.saved, *p)
+	return nil
+}
+
+// flatSeries is usdHr per day for the days days from start.
+func flatSeries(start time.Time, days int, usdHr float64) []recommendations.DailyCost {
+	out := make([]recommendations.DailyCost, days)
+	for i := range out {
+		out[i] = recommendations.DailyCost{Date: start.AddDate(0, 0, i), USDPerHour: usdHr}
+	}
+	return out
+}
+
+func TestEvaluateShadowPurchases_StacksOverlappingPurchases(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 10)
+	usage := &fakeShadowUsage{series: map[common.ServiceType][]recommendations.DailyCost{
+		common.ServiceEC2: flatSeries(start, 10, 15),
+	}}
+	rows := []config.ShadowPurchase{
+		{ID: "first", Provider: "aws", Service: "ec2", Region: "us-east-1", TermYears: 1,
+			CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start},
+		{ID: "second", Provider: "aws", Service: "savings-plans-compute", Region: "us-east-1", TermYears: 1,
+			CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start},
+	}
+	store := &shadowEvalTestStore{}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "eu-west-1", rows, now)
+	assert.Equal(t, 2, result.Evaluated)
+	assert.Equal(t, 0, result.Failed)
+	assert.Len(t, usage.lookbacks, 1, "purchases drawing on one series share one read")
+
+	require.Len(t, store.saved, 2)
+	first, second := store.saved[0], store.saved[1]
+	require.NotNil(t, first.Evaluation.UtilizationPct)
+	require.NotNil(t, second.Evaluation.UtilizationPct)
+	assert.InDelta(t, 100, *first.Evaluation.UtilizationPct, 1e-9)
+	assert.InDelta(t, 50, *second.Evaluation.UtilizationPct, 1e-9, "the later purchase only covers what is left")
+	assert.Equal(t, now, *first.EvaluatedThrough)
+	assert.Equal(t, now, *first.EvaluatedAt)
+	assert.Empty(t, first.EvaluationError)
+}
+
+func TestEvaluateShadowPurchases_MatchesUsageToEachPurchase(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 10)
+	acct := "acct-prod"
+	m5 := recommendations.OnDemandSeriesScope{InstanceType: "m5.large", LinkedAccount: "111111111111"}
+	c5 := recommendations.OnDemandSeriesScope{InstanceType: "c5.xlarge", LinkedAccount: "111111111111"}
+	usage := &fakeShadowUsage{
+		// The region runs far more than either reservation could cover.
+		series: map[common.ServiceType][]recommendations.DailyCost{common.ServiceEC2: flatSeries(start, 10, 500)},
+		scoped: map[recommendations.OnDemandSeriesScope][]recommendations.DailyCost{
+			m5: flatSeries(start, 10, 30),
+			c5: flatSeries(start, 10, 4),
+		},
+	}
+	rows := []config.ShadowPurchase{
+		{ID: "m5", Source: config.ShadowSourcePlan, CloudAccountID: &acct, Provider: "aws", Service: "ec2",
+			Region: "us-east-1", ResourceType: "m5.large", TermYears: 1, CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start},
+		{ID: "c5", Source: config.ShadowSourcePlan, CloudAccountID: &acct, Provider: "aws", Service: "ec2",
+			Region: "us-east-1", ResourceType: "c5.xlarge", TermYears: 1, CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start},
+	}
+	store := &shadowEvalTestStore{accounts: map[string]*config.CloudAccount{
+		acct: {ID: acct, Provider: "aws", ExternalID: "111111111111"},
+	}}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "us-east-1", rows, now)
+	assert.Equal(t, 2, result.Evaluated)
+	assert.ElementsMatch(t, []recommendations.OnDemandSeriesScope{m5, c5}, usage.scopes,
+		"each purchase reads its own instance type in its own account")
+
+	require.Len(t, store.saved, 2)
+	m5Eval, c5Eval := store.saved[0].Evaluation, store.saved[1].Evaluation
+	require.NotNil(t, m5Eval.UtilizationPct)
+	require.NotNil(t, c5Eval.UtilizationPct)
+	assert.InDelta(t, 100, *m5Eval.UtilizationPct, 1e-9, "usage beyond the commitment is not credited")
+	assert.InDelta(t, 10*10*24, m5Eval.CoveredUSD, 1e-9)
+	assert.InDelta(t, 40, *c5Eval.UtilizationPct, 1e-9, "the c5 reservation only sees c5 usage")
+	assert.InDelta(t, (4-6)*10*24, c5Eval.SavingsUSD, 1e-9)
+}
+
+func TestShadowUsageScope(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	acct, gone := "acct-prod", "acct-gone"
+	store := &shadowEvalTestStore{accounts: map[string]*config.CloudAccount{
+		acct: {ID: acct, Provider: "aws", ExternalID: "111111111111"},
+	}}
+	accounts := map[string]string{}
+
+	scope, err := shadowUsageScope(ctx, store, &config.ShadowPurchase{
+		Source: config.ShadowSourcePlan, CloudAccountID: &acct, Service: "savings-plans-compute", ResourceType: "ComputeSavingsPlans",
+	}, accounts)
+	require.NoError(t, err)
+	assert.Equal(t, recommendations.OnDemandSeriesScope{LinkedAccount: "111111111111"}, scope, "a Savings Plan spans instance types")
+
+	scope, err = shadowUsageScope(ctx, store, &config.ShadowPurchase{
+		Source: config.ShadowSourceLadder, CloudAccountID: &acct, Service: "rds", ResourceType: "baseline",
+	}, accounts)
+	require.NoError(t, err)
+	assert.Zero(t, scope, "ladder layers are scored against the series they were sized on")
+
+	_, err = shadowUsageScope(ctx, store, &config.ShadowPurchase{
+		Source: config.ShadowSourcePlan, CloudAccountID: &gone, Service: "ec2", ResourceType: "m5.large",
+	}, accounts)
+	require.Error(t, err)
+}
+
+func TestEvaluateShadowPurchases_ResumesWhereLastRunStopped(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	through := start.AddDate(0, 0, 5)
+	now := start.AddDate(0, 0, 8)
+	usage := &fakeShadowUsage{series: map[common.ServiceType][]recommendations.DailyCost{
+		common.ServiceRDS: flatSeries(start, 8, 10),
+	}}
+	rows := []config.ShadowPurchase{{
+		ID: "rds", Provider: "aws", Service: "rds", Region: "us-east-1", TermYears: 1,
+		CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start, EvaluatedThrough: &through,
+		Evaluation: evalOfDays(5, 10, 6),
+	}}
+	store := &shadowEvalTestStore{}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "", rows, now)
+	assert.Equal(t, 1, result.Evaluated)
+	assert.Equal(t, []int{3}, usage.lookbacks, "only the unscored days are read")
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, 8, store.saved[0].Evaluation.Days, "new days add to the earlier evaluation")
+	assert.Equal(t, now, *store.saved[0].EvaluatedThrough)
+}
+
+// evalOfDays is the evaluation of a fully utilised commitment of commit
+// USD/h costing cost USD/h over days days.
+func evalOfDays(days int, commit, cost float64) (e shadow.Evaluation) {
+	util := 100.0
+	hours := float64(days) * 24
+	e.UtilizationPct = &util
+	e.CoveredUSD = commit * hours
+	e.CommittedUSD = commit * hours
+	e.CostUSD = cost * hours
+	e.SavingsUSD = e.CoveredUSD - e.CostUSD
+	e.Days = days
+	return e
+}
+
+func TestEvaluateShadowPurchases_NoNewDataIsUnchanged(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	usage := &fakeShadowUsage{series: map[common.ServiceType][]recommendations.DailyCost{
+		common.ServiceEC2: flatSeries(start.AddDate(0, 0, -3), 2, 10),
+	}}
+	rows := []config.ShadowPurchase{{
+		ID: "p", Provider: "aws", Service: "ec2", TermYears: 1,
+		CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start,
+	}}
+	store := &shadowEvalTestStore{}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "us-east-1", rows, start.AddDate(0, 0, 1))
+	assert.Equal(t, 1, result.Unchanged)
+	require.Len(t, store.saved, 1)
+	assert.Nil(t, store.saved[0].EvaluatedThrough, "usage that ends before the purchase scores nothing")
+}
+
+func TestEvaluateShadowPurchases_UnsupportedAndFailures(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	now := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)
+	usage := &fakeShadowUsage{err: errors.New("no on-demand usage")}
+	rows := []config.ShadowPurchase{
+		{ID: "azure", Provider: "azure", Service: "vm", TermYears: 1, CommitUSDPerHour: 1, PurchaseAt: now.AddDate(0, 0, -5)},
+		{ID: "ec2", Provider: "aws", Service: "ec2", TermYears: 1, CommitUSDPerHour: 1, PurchaseAt: now.AddDate(0, 0, -5)},
+	}
+	store := &shadowEvalTestStore{}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "us-east-1", rows, now)
+	assert.Equal(t, 1, result.Unsupported)
+	assert.Equal(t, 1, result.Failed)
+	assert.Contains(t, result.LastError, "no on-demand usage")
+	require.Len(t, store.saved, 2)
+	assert.Contains(t, store.saved[0].EvaluationError, "no usage source")
+	assert.Contains(t, store.saved[1].EvaluationError, "no on-demand usage")
+	assert.Nil(t, store.saved[1].EvaluatedThrough, "a failed read must not advance the evaluation")
+}
+
+func TestEvaluateShadowPurchases_SaveFailureCountsOnce(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	usage := &fakeShadowUsage{series: map[common.ServiceType][]recommendations.DailyCost{
+		common.ServiceEC2: flatSeries(start, 3, 10),
+	}}
+	rows := []config.ShadowPurchase{{
+		ID: "p", Provider: "aws", Service: "ec2", TermYears: 1,
+		CommitUSDPerHour: 10, CostUSDPerHour: 6, PurchaseAt: start,
+	}}
+	store := &shadowEvalTestStore{saveErr: errors.New("db down")}
+
+	result := evaluateShadowPurchases(ctx, store, usage, "us-east-1", rows, start.AddDate(0, 0, 3))
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 0, result.Evaluated)
+	assert.Equal(t, "db down", result.LastError)
+}
+
+func TestShadowUsageService(t *testing.T) {
+	tests := []struct {
+		provider, service string
+		want              common.ServiceType
+		ok                bool
+	}{
+		{"aws", "", common.ServiceEC2, true},
+		{"aws", "ec2", common.ServiceEC2, true},
+		{"aws", "savings-plans-compute", common.ServiceEC2, true},
+		{"aws", "rds", common.ServiceRDS, true},
+		{"aws", "savings-plans-sagemaker", "", false},
+		{"gcp", "compute", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.service, func(t *testing.T) {
+			got, ok := shadowUsageService(tt.provider, tt.service)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestShadowLookbackDays(t *testing.T) {
+	now := time.Date(2026, 6, 10, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, 9, shadowLookbackDays(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), now))
+	assert.Equal(t, 9, shadowLookbackDays(time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC), now), "a part day rounds up")
+	assert.Equal(t, 1, shadowLookbackDays(now, now))
+	assert.Equal(t, shadowMaxLookbackDays, shadowLookbackDays(now.AddDate(-2, 0, 0), now))
+}
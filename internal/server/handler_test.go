@@ -557,6 +557,21 @@ func TestParseScheduledEvent(t *testing.T) {
 			rawEvent:     `{"action": "finalize_revocations"}`,
 			expectedTask: TaskFinalizeRevocations,
 		},
+		{
+			name:         "inventory_sync event",
+			rawEvent:     `{"action": "inventory_sync"}`,
+			expectedTask: TaskSyncCommitmentInventory,
+		},
+		{
+			name:         "renewal_plan event",
+			rawEvent:     `{"action": "renewal_plan"}`,
+			expectedTask: TaskPlanRenewals,
+		},
+		{
+			name:         "shadow_evaluate event",
+			rawEvent:     `{"action": "shadow_evaluate"}`,
+			expectedTask: TaskEvaluateShadowPurchases,
+		},
 		{
 			name:        "unknown action returns error",
 			rawEvent:    `{"action": "unknown"}`,
@@ -18,6 +18,10 @@ type SchedulerInterface interface {
 	// exists but would be dropped by the override filter. Returns nil, nil,
 	// nil when absent or fully suppressed.
 	GetRecommendationByID(ctx context.Context, id string) (rec *config.RecommendationRecord, hiddenBy []string, err error)
+	// SyncCommitmentInventory upserts every enabled account's provider-side
+	// commitments into commitments_inventory. Wired into the
+	// "inventory_sync" scheduled task.
+	SyncCommitmentInventory(ctx context.Context) (*scheduler.InventorySyncResult, error)
 }
 
 // PurchaseManagerInterface defines the methods required for the purchase manager component.
@@ -27,6 +31,9 @@ type PurchaseManagerInterface interface {
 	ProcessMessage(ctx context.Context, body string) error
 	ApproveExecution(ctx context.Context, execID, token, actor string) error
 	ApproveAndExecute(ctx context.Context, execID, actor string, transitionedBy *string) error
+	EnforceApprovalPolicy(ctx context.Context, execID, actor string, actorUserID *string) error
+	CheckManualFreeze(ctx context.Context, execID string) error
+	StartApprovalChain(ctx context.Context, execID string) (*config.ExecutionApprovalChain, error)
 	CancelExecution(ctx context.Context, execID, token, actor string) error
 	// ReapStuckExecutions sweeps purchase_executions stuck in
 	// approved/running longer than reapAfter and flips them to "failed"
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+	pkgcommon "github.com/LeanerCloud/CUDly/pkg/common"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/google/uuid"
+)
+
+// ladderShadowDiscountEnv names the fallback discount, in percent of
+// on-demand, for a shadow-mode ladder tranche no collected recommendation
+// prices (see ladderShadowPricer). Unset, such a tranche is priced at no
+// discount, which keeps its savings a conservative lower bound, as the
+// backtest does for a layer without a discount.
+const ladderShadowDiscountEnv = "LADDER_SHADOW_DISCOUNT_PCT"
+
+// isShadowLadder reports whether dbCfg runs in shadow mode.
+func isShadowLadder(dbCfg *config.LadderConfigDB) bool {
+	return dbCfg.Mode == string(pkgladder.ModeShadow)
+}
+
+// ladderShadowDiscountPct reads ladderShadowDiscountEnv, which must be a
+// percentage in [0, 100) when set; set is false when it is not.
+func ladderShadowDiscountPct() (pct float64, set bool, err error) {
+	raw := os.Getenv(ladderShadowDiscountEnv)
+	if raw == "" {
+		return 0, false, nil
+	}
+	pct, err = strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(pct) || pct < 0 || pct >= 100 {
+		return 0, false, fmt.Errorf("%s=%q must be a percentage in [0, 100)", ladderShadowDiscountEnv, raw)
+	}
+	return pct, true, nil
+}
+
+// ladderLayerRecService is the service of the recommendations a layer's
+// PurchaseLayer buys (see each provider's ladder package); ok is false for
+// a layer CUDly cannot buy.
+func ladderLayerRecService(layer pkgladder.LayerType) (pkgcommon.ServiceType, bool) {
+	switch layer {
+	case pkgladder.LayerConvertibleRI:
+		return pkgcommon.ServiceEC2, true
+	case pkgladder.LayerEC2InstanceSP:
+		return pkgcommon.ServiceSavingsPlansEC2Instance, true
+	case pkgladder.LayerComputeSP:
+		return pkgcommon.ServiceSavingsPlansCompute, true
+	case pkgladder.LayerRDSRI:
+		return pkgcommon.ServiceRDS, true
+	case pkgladder.LayerElastiCacheRI:
+		return pkgcommon.ServiceElastiCache, true
+	case pkgladder.LayerOpenSearchRI:
+		return pkgcommon.ServiceOpenSearch, true
+	case pkgladder.LayerAzureReservation, pkgladder.LayerGCPResourceCUD:
+		return pkgcommon.ServiceCompute, true
+	case pkgladder.LayerAzureSavingsPlan:
+		return pkgcommon.ServiceSavingsPlansAll, true
+	}
+	return "", false
+}
+
+// ladderPaymentMatches reports whether a recommendation's payment option
+// is opt. Azure spells no-upfront and all-upfront "monthly" and "upfront".
+func ladderPaymentMatches(recPayment string, opt pkgladder.PaymentOption) bool {
+	switch recPayment {
+	case "monthly":
+		recPayment = string(pkgladder.PaymentNoUpfront)
+	case "upfront":
+		recPayment = string(pkgladder.PaymentAllUpfront)
+	}
+	return recPayment == string(opt)
+}
+
+// ladderPriceKey is what a tranche's price depends on.
+type ladderPriceKey struct {
+	layer   pkgladder.LayerType
+	years   int
+	payment pkgladder.PaymentOption
+}
+
+// ladderShadowPricer prices the tranches of one shadow-mode run from the
+// collected recommendations of the config's account that each would buy:
+// those for the layer's service, term and payment option. Their cost per
+// USD/h of on-demand covered, weighted by on-demand, is the tranche's.
+// With none to go by, it falls back to ladderShadowDiscountEnv.
+type ladderShadowPricer struct {
+	store  config.StoreInterface
+	dbCfg  *config.LadderConfigDB
+	recs   map[pkgcommon.ServiceType][]config.RecommendationRecord
+	ratios map[ladderPriceKey]float64
+}
+
+func newLadderShadowPricer(store config.StoreInterface, dbCfg *config.LadderConfigDB) *ladderShadowPricer {
+	return &ladderShadowPricer{
+		store:  store,
+		dbCfg:  dbCfg,
+		recs:   make(map[pkgcommon.ServiceType][]config.RecommendationRecord),
+		ratios: make(map[ladderPriceKey]float64),
+	}
+}
+
+// costRatio is what one USD/h of on-demand-equivalent commitment costs per
+// hour for key.
+func (p *ladderShadowPricer) costRatio(ctx context.Context, key ladderPriceKey) (float64, error) {
+	if ratio, ok := p.ratios[key]; ok {
+		return ratio, nil
+	}
+	ratio, ok, err := p.recRatio(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		pct, set, err := ladderShadowDiscountPct()
+		if err != nil {
+			return 0, err
+		}
+		ratio = 1 - pct/100
+		if !set {
+			log.Printf("ladder_run: config %s: no %s recommendation prices a %d-year %s %s tranche and %s is unset; recording it at no discount",
+				p.dbCfg.ID, p.dbCfg.Provider, key.years, key.payment, key.layer, ladderShadowDiscountEnv)
+		}
+	}
+	p.ratios[key] = ratio
+	return ratio, nil
+}
+
+// recRatio is costRatio from the recommendations key's layer would buy;
+// ok is false when none matches.
+func (p *ladderShadowPricer) recRatio(ctx context.Context, key ladderPriceKey) (ratio float64, ok bool, err error) {
+	service, buyable := ladderLayerRecService(key.layer)
+	if !buyable {
+		return 0, false, nil
+	}
+	recs, loaded := p.recs[service]
+	if !loaded {
+		recs, err = p.store.ListStoredRecommendations(ctx, config.RecommendationFilter{
+			Provider:   p.dbCfg.Provider,
+			Service:    string(service),
+			AccountIDs: []string{p.dbCfg.CloudAccountID},
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to load %s recommendations to price shadow tranches: %w", service, err)
+		}
+		p.recs[service] = recs
+	}
+	var onDemand, cost float64
+	for i := range recs {
+		if recs[i].Term != key.years || !ladderPaymentMatches(recs[i].Payment, key.payment) {
+			continue
+		}
+		od, c := purchase.RecHourlyPricing(recs[i])
+		if od <= 0 || c < 0 {
+			continue
+		}
+		onDemand += od
+		cost += c
+	}
+	if onDemand <= 0 {
+		return 0, false, nil
+	}
+	return cost / onDemand, true, nil
+}
+
+// ladderInFlightUSDHr is the commitment already en route for dbCfg, which
+// Allocate nets out of the gap: its scheduled tranches (see
+// GetInFlightLadderCommitUSDHr) and, for a shadow-mode config, its shadow
+// purchases whose term has not ended at now. Shadow purchases never become
+// commitments the provider reports, so without them a shadow ladder would
+// re-plan the same gap every run and record it over and over.
+func (app *Application) ladderInFlightUSDHr(ctx context.Context, dbCfg *config.LadderConfigDB, now time.Time) (*float64, error) {
+	inFlight, err := app.Config.GetInFlightLadderCommitUSDHr(ctx, dbCfg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetInFlightLadderCommitUSDHr: %w", err)
+	}
+	if inFlight == nil {
+		// The store must return a non-nil value; nil signals an impossible
+		// query state (e.g. driver bug). Treat as fail-loud.
+		return nil, fmt.Errorf("GetInFlightLadderCommitUSDHr returned nil for config %s", dbCfg.ID)
+	}
+	if !isShadowLadder(dbCfg) {
+		return inFlight, nil
+	}
+	shadowUSDHr, err := app.Config.GetShadowLadderCommitUSDHr(ctx, dbCfg.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("GetShadowLadderCommitUSDHr: %w", err)
+	}
+	total := *inFlight + shadowUSDHr
+	return &total, nil
+}
+
+// shadowBuyNowRows returns a tranche row due at now for each buy-now
+// purchase of a shadow-mode run. A live run only reports buy-now purchases
+// in its plan; a shadow run has to record them, since they are the bulk of
+// what it would have bought.
+func shadowBuyNowRows(buyNow []pkgladder.PlannedAction, runID string, configID *string, now time.Time) []config.LadderTrancheDB {
+	rows := make([]config.LadderTrancheDB, 0, len(buyNow))
+	for _, a := range buyNow {
+		amount := ratToFloat64Ptr(a.AmountUSDPerHour)
+		if a.Action != pkgladder.ActionPurchase || amount == nil {
+			continue
+		}
+		runIDCopy := runID
+		rows = append(rows, config.LadderTrancheDB{
+			ID:            uuid.New().String(),
+			ConfigID:      configID,
+			RunID:         &runIDCopy,
+			LayerType:     a.Layer,
+			Term:          a.Term,
+			PaymentOption: a.PaymentOption,
+			Status:        pkgladder.TrancheStatusScheduled,
+			AmountUSDHr:   *amount,
+			ScheduledDate: now,
+		})
+	}
+	return rows
+}
+
+// saveShadowLadderRun persists a shadow-mode run: the run row as usual, and
+// a shadow purchase dated at its fire time for each tranche (buy-now rows
+// included) a change freeze has not cancelled, in place of the tranche
+// rows, priced by ladderShadowPricer. The ladder plans
+// against the deployment's own account and region, so the purchases carry
+// no region and the evaluator reads them in its own.
+func (app *Application) saveShadowLadderRun(ctx context.Context, dbCfg *config.LadderConfigDB, run *config.LadderRunDB, tranches []config.LadderTrancheDB) (*config.LadderRunDB, error) {
+	pricer := newLadderShadowPricer(app.Config, dbCfg)
+	ps := make([]config.ShadowPurchase, 0, len(tranches))
+	for i := range tranches {
+		tr := &tranches[i]
+		if tr.Status != pkgladder.TrancheStatusScheduled {
+			continue
+		}
+		years, err := ladderTermYears(tr.Term)
+		if err != nil {
+			return nil, fmt.Errorf("tranche %s: %w", tr.ID, err)
+		}
+		ratio, err := pricer.costRatio(ctx, ladderPriceKey{layer: tr.LayerType, years: years, payment: tr.PaymentOption})
+		if err != nil {
+			return nil, fmt.Errorf("tranche %s: %w", tr.ID, err)
+		}
+		cfgID, runID, accountID := dbCfg.ID, run.ID, dbCfg.CloudAccountID
+		ps = append(ps, config.ShadowPurchase{
+			Source:           config.ShadowSourceLadder,
+			LadderConfigID:   &cfgID,
+			LadderRunID:      &runID,
+			CloudAccountID:   &accountID,
+			Provider:         dbCfg.Provider,
+			Service:          dbCfg.Service,
+			ResourceType:     string(tr.LayerType),
+			TermYears:        years,
+			Payment:          string(tr.PaymentOption),
+			CommitUSDPerHour: tr.AmountUSDHr,
+			CostUSDPerHour:   tr.AmountUSDHr * ratio,
+			PurchaseAt:       tr.ScheduledDate,
+		})
+	}
+	return app.Config.SaveShadowLadderRun(ctx, run, ps)
+}
+
+// ladderTermYears is the inverse of ladderTermFromYears.
+func ladderTermYears(term pkgladder.Term) (int, error) {
+	switch term {
+	case pkgladder.Term1Year:
+		return 1, nil
+	case pkgladder.Term3Year:
+		return 3, nil
+	default:
+		return 0, fmt.Errorf("unsupported term %q", term)
+	}
+}
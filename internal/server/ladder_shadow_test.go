@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/testutil"
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// shadowLadderTestStore is a ladderTestStore that also serves the shadow
+// purchase calls of a shadow-mode run.
+type shadowLadderTestStore struct {
+	ladderTestStore
+	shadowUSDHr     float64
+	shadowPurchases []config.ShadowPurchase
+	recs            []config.RecommendationRecord
+	recsErr         error
+	recFilters      []config.RecommendationFilter
+}
+
+func (s *shadowLadderTestStore) ListStoredRecommendations(_ context.Context, f config.RecommendationFilter) ([]config.RecommendationRecord, error) {
+	s.recFilters = append(s.recFilters, f)
+	var out []config.RecommendationRecord
+	for _, rec := range s.recs {
+		if rec.Service == f.Service {
+			out = append(out, rec)
+		}
+	}
+	return out, s.recsErr
+}
+
+func (s *shadowLadderTestStore) GetShadowLadderCommitUSDHr(_ context.Context, _ string, _ time.Time) (float64, error) {
+	return s.shadowUSDHr, nil
+}
+
+func (s *shadowLadderTestStore) SaveShadowLadderRun(_ context.Context, run *config.LadderRunDB, ps []config.ShadowPurchase) (*config.LadderRunDB, error) {
+	s.savedRun = run
+	s.savedRuns = append(s.savedRuns, run)
+	s.shadowPurchases = ps
+	return run, nil
+}
+
+func shadowTestDBConfig(id string) config.LadderConfigDB {
+	dbCfg := validTestDBConfig(id)
+	dbCfg.Mode = string(pkgladder.ModeShadow)
+	dbCfg.RampSchedule, _ = json.Marshal(pkgladder.RampSchedule{
+		Steps: []pkgladder.RampStep{{AfterDays: 0, Fraction: 0.5}, {AfterDays: 7, Fraction: 0.5}},
+	})
+	return dbCfg
+}
+
+// ladderTestRec is a recommendation covering onDemand USD/h at cost USD/h,
+// charged monthly.
+func ladderTestRec(service string, term int, payment string, onDemand, cost float64) config.RecommendationRecord {
+	od, monthly := onDemand*budget.HoursPerMonth, cost*budget.HoursPerMonth
+	return config.RecommendationRecord{
+		Provider: "aws", Service: service, Term: term, Payment: payment,
+		OnDemandCost: &od, MonthlyCost: &monthly,
+	}
+}
+
+func TestExecuteLadderRun_ShadowRecordsPurchasesInsteadOfTranches(t *testing.T) {
+	t.Setenv(ladderShadowDiscountEnv, "30")
+	ctx := testutil.TestContext(t)
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	dbCfg := shadowTestDBConfig("cfg-shadow")
+	store := &shadowLadderTestStore{}
+	app := &Application{Config: store}
+
+	err := app.executeLadderRun(ctx, &dbCfg, &fakeLadderCapability{t: t, baseline: testBaseline()},
+		"123456789012", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, now)
+	require.NoError(t, err)
+
+	require.NotNil(t, store.savedRun)
+	assert.Equal(t, "shadow", *store.savedRun.Mode)
+	assert.Nil(t, store.savedTranches, "a shadow run must not schedule tranches")
+
+	var buyNow, later int
+	var commit float64
+	for _, p := range store.shadowPurchases {
+		assert.Equal(t, config.ShadowSourceLadder, p.Source)
+		assert.Equal(t, "cfg-shadow", *p.LadderConfigID)
+		assert.Equal(t, store.savedRun.ID, *p.LadderRunID)
+		assert.Equal(t, 1, p.TermYears)
+		assert.InDelta(t, p.CommitUSDPerHour*0.7, p.CostUSDPerHour, 1e-9)
+		commit += p.CommitUSDPerHour
+		if p.PurchaseAt.Equal(now) {
+			buyNow++
+		} else {
+			assert.Equal(t, now.AddDate(0, 0, 7), p.PurchaseAt)
+			later++
+		}
+	}
+	assert.Positive(t, buyNow, "the buy-now step must be recorded")
+	assert.Positive(t, later, "the delayed step must be recorded at its fire time")
+	assert.InDelta(t, store.savedRun.TotalHourlyCommit, commit, 1e-9)
+}
+
+func TestExecuteLadderRun_ShadowNetsLiveShadowPurchases(t *testing.T) {
+	t.Setenv(ladderShadowDiscountEnv, "30")
+	ctx := testutil.TestContext(t)
+	dbCfg := shadowTestDBConfig("cfg-shadow")
+	store := &shadowLadderTestStore{shadowUSDHr: 100}
+	app := &Application{Config: store}
+
+	err := app.executeLadderRun(ctx, &dbCfg, &fakeLadderCapability{t: t, baseline: testBaseline()},
+		"123456789012", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now().UTC())
+	require.NoError(t, err)
+	require.NotNil(t, store.savedRun)
+	assert.Empty(t, store.shadowPurchases, "shadow purchases already covering the target leave no gap")
+}
+
+func TestExecuteLadderRun_ShadowPricesFromRecommendations(t *testing.T) {
+	t.Setenv(ladderShadowDiscountEnv, "")
+	ctx := testutil.TestContext(t)
+	dbCfg := shadowTestDBConfig("cfg-shadow")
+	store := &shadowLadderTestStore{recs: []config.RecommendationRecord{
+		// Convertible RIs: 1-year no-upfront at 40% and 20% off, weighted 3:1.
+		ladderTestRec("ec2", 1, "no-upfront", 3, 1.8),
+		ladderTestRec("ec2", 1, "no-upfront", 1, 0.8),
+		// Not what the layer buys at this term and payment.
+		ladderTestRec("ec2", 3, "no-upfront", 1, 0.1),
+		ladderTestRec("ec2", 1, "all-upfront", 1, 0.1),
+		ladderTestRec("savings-plans-ec2instance", 1, "no-upfront", 1, 0.1),
+	}}
+	app := &Application{Config: store}
+
+	err := app.executeLadderRun(ctx, &dbCfg, &fakeLadderCapability{t: t, baseline: testBaseline()},
+		"123456789012", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now().UTC())
+	require.NoError(t, err)
+	require.Len(t, store.shadowPurchases, 2, "one tranche per ramp step")
+	for _, p := range store.shadowPurchases {
+		assert.Equal(t, string(pkgladder.LayerConvertibleRI), p.ResourceType)
+		assert.InDelta(t, p.CommitUSDPerHour*2.6/4, p.CostUSDPerHour, 1e-9)
+	}
+	require.Len(t, store.recFilters, 1, "a layer's recommendations are loaded once per run")
+	assert.Equal(t, config.RecommendationFilter{Provider: "aws", Service: "ec2", AccountIDs: []string{"cloud-acct-uuid"}}, store.recFilters[0])
+}
+
+func TestExecuteLadderRun_ShadowUnpricedTrancheAtNoDiscount(t *testing.T) {
+	t.Setenv(ladderShadowDiscountEnv, "")
+	ctx := testutil.TestContext(t)
+	dbCfg := shadowTestDBConfig("cfg-shadow")
+	store := &shadowLadderTestStore{}
+	app := &Application{Config: store}
+
+	err := app.executeLadderRun(ctx, &dbCfg, &fakeLadderCapability{t: t, baseline: testBaseline()},
+		"123456789012", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now().UTC())
+	require.NoError(t, err)
+	require.NotEmpty(t, store.shadowPurchases)
+	for _, p := range store.shadowPurchases {
+		assert.InDelta(t, p.CommitUSDPerHour, p.CostUSDPerHour, 1e-9)
+	}
+}
+
+func TestExecuteLadderRun_ShadowRecommendationLoadError(t *testing.T) {
+	ctx := testutil.TestContext(t)
+	dbCfg := shadowTestDBConfig("cfg-shadow")
+	store := &shadowLadderTestStore{recsErr: errors.New("db down")}
+	app := &Application{Config: store}
+
+	err := app.executeLadderRun(ctx, &dbCfg, &fakeLadderCapability{t: t, baseline: testBaseline()},
+		"123456789012", pkgladder.Term1Year, pkgladder.PaymentNoUpfront, time.Now().UTC())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db down")
+	assert.Nil(t, store.savedRun, "nothing may be persisted without a price")
+}
+
+func TestLadderPaymentMatches(t *testing.T) {
+	assert.True(t, ladderPaymentMatches("no-upfront", pkgladder.PaymentNoUpfront))
+	assert.True(t, ladderPaymentMatches("monthly", pkgladder.PaymentNoUpfront))
+	assert.True(t, ladderPaymentMatches("upfront", pkgladder.PaymentAllUpfront))
+	assert.False(t, ladderPaymentMatches("partial-upfront", pkgladder.PaymentAllUpfront))
+}
+
+func TestLadderShadowDiscountPct(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    float64
+		wantErr bool
+	}{
+		{"25", 25, false},
+		{"0", 0, false},
+		{"", 0, false},
+		{"abc", 0, true},
+		{"-1", 0, true},
+		{"100", 0, true},
+		{"NaN", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			t.Setenv(ladderShadowDiscountEnv, tt.raw)
+			got, set, err := ladderShadowDiscountPct()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.raw != "", set)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/oauth2"
 
+	"github.com/LeanerCloud/CUDly/internal/config"
+	"github.com/LeanerCloud/CUDly/internal/purchase"
+	pkgcommon "github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/exchange"
 	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
 	awsprovider "github.com/LeanerCloud/CUDly/providers/aws"
 	awsladder "github.com/LeanerCloud/CUDly/providers/aws/ladder"
 	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+	azureladder "github.com/LeanerCloud/CUDly/providers/azure/ladder"
+	gcpladder "github.com/LeanerCloud/CUDly/providers/gcp/ladder"
 )
 
 // exchangeRunnerAdapter bridges internal/server wiring (exchange store, EC2 exchange
@@ -86,12 +93,37 @@ func (a *exchangeRunnerAdapter) RunAutoExchange(ctx context.Context, cfg exchang
 	})
 }
 
-// buildAndWireCapability constructs a LadderCapability via the factory and wires its
-// write side. Extracted from processOneLadderConfig to keep that function's cyclomatic
-// complexity below the project threshold (10). The returned error carries no config
-// ID: the sole caller already prefixes its log line with the config ID, so repeating
-// it here would duplicate it in the output.
-func (app *Application) buildAndWireCapability(ctx context.Context, region, accountID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+// buildAndWireCapability constructs a LadderCapability via the provider's factory
+// and wires its write side. Extracted from processOneLadderConfig to keep that
+// function's cyclomatic complexity below the project threshold (10). The returned
+// error carries no config ID: the sole caller already prefixes its log line with
+// the config ID, so repeating it here would duplicate it in the output.
+//
+// service is the config's service scope: "" builds the provider's compute
+// ladder, anything else the AWS reserved-capacity ladder for that service
+// (LadderConfigDB.Validate already restricts services to aws).
+func (app *Application) buildAndWireCapability(ctx context.Context, cloudAcct *config.CloudAccount, service, region, accountID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+	capability, err := app.buildAndWireProviderCapability(ctx, cloudAcct, service, region, accountID, executionEnabled)
+	if err != nil || !executionEnabled || app.Config == nil {
+		return capability, err
+	}
+	// Layer purchases reserve the organisation-wide commitment budget
+	// before reaching the provider, like every other purchase path.
+	return pkgladder.WithBudget(capability, purchase.NewBudgetReserver(app.Config), cloudAcct.ID), nil
+}
+
+// buildAndWireProviderCapability is buildAndWireCapability without the
+// commitment budget guard.
+func (app *Application) buildAndWireProviderCapability(ctx context.Context, cloudAcct *config.CloudAccount, service, region, accountID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+	switch pkgcommon.ProviderType(cloudAcct.Provider) {
+	case pkgcommon.ProviderAzure:
+		return app.buildAndWireAzureCapability(ctx, cloudAcct, accountID, executionEnabled)
+	case pkgcommon.ProviderGCP:
+		return app.buildAndWireGCPCapability(ctx, cloudAcct, accountID, executionEnabled)
+	}
+	if service != "" {
+		return app.buildAndWireReservedCapacityCapability(ctx, pkgcommon.ServiceType(service), region, accountID, executionEnabled)
+	}
 	if app.LadderCapabilityFactory == nil {
 		return nil, errors.New("LadderCapabilityFactory is nil (not wired)")
 	}
@@ -102,6 +134,138 @@ func (app *Application) buildAndWireCapability(ctx context.Context, region, acco
 	return app.wireLadderWriteSide(ctx, executionEnabled, region, accountID, capability)
 }
 
+// buildAndWireReservedCapacityCapability constructs the AWS reserved-capacity
+// LadderCapability for service and wires its write side.
+func (app *Application) buildAndWireReservedCapacityCapability(ctx context.Context, service pkgcommon.ServiceType, region, accountID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+	if app.AWSReservedCapacityLadderFactory == nil {
+		return nil, errors.New("AWSReservedCapacityLadderFactory is nil (not wired)")
+	}
+	capability, err := app.AWSReservedCapacityLadderFactory(ctx, service, region, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s ladder capability: %w", service, err)
+	}
+	return wireReservedCapacityWriteSide(ctx, executionEnabled, region, capability)
+}
+
+// wireReservedCapacityWriteSide is the reserved-capacity counterpart of
+// wireLadderWriteSide: it wires the service purchaser only when capability is
+// a *awsladder.ReservedCapacityLadder and returns test fakes unchanged.
+func wireReservedCapacityWriteSide(ctx context.Context, executionEnabled bool, region string, capability pkgladder.LadderCapability) (pkgladder.LadderCapability, error) {
+	l, ok := capability.(*awsladder.ReservedCapacityLadder)
+	if !ok {
+		return capability, nil
+	}
+	if !executionEnabled {
+		wired, err := awsladder.WireReservedCapacityWriteSideDisabled(l)
+		if err != nil {
+			return nil, fmt.Errorf("wireReservedCapacityWriteSide: disabled: %w", err)
+		}
+		return wired, nil
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("wireReservedCapacityWriteSide: load AWS config: %w", err)
+	}
+	wired, err := awsladder.WireReservedCapacityWriteSide(l, awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("wireReservedCapacityWriteSide: %w", err)
+	}
+	return wired, nil
+}
+
+// buildAndWireAzureCapability resolves the subscription's token credential,
+// constructs the Azure LadderCapability and wires its write side with the same
+// credential. The credential is resolved once per config run so purchases and
+// exchanges use exactly the identity the read side planned with.
+func (app *Application) buildAndWireAzureCapability(ctx context.Context, cloudAcct *config.CloudAccount, subscriptionID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+	if app.AzureLadderCapabilityFactory == nil {
+		return nil, errors.New("AzureLadderCapabilityFactory is nil (not wired)")
+	}
+	if app.AzureLadderCredentialResolver == nil {
+		return nil, errors.New("AzureLadderCredentialResolver is nil (credential store not connected)")
+	}
+	cred, err := app.AzureLadderCredentialResolver(ctx, cloudAcct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Azure credential for subscription %s: %w", subscriptionID, err)
+	}
+	capability, err := app.AzureLadderCapabilityFactory(ctx, cred, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Azure ladder capability: %w", err)
+	}
+	return wireAzureLadderWriteSide(executionEnabled, cred, capability)
+}
+
+// wireAzureLadderWriteSide is the Azure counterpart of wireLadderWriteSide: it
+// wires the write side only when capability is a *azureladder.AzureLadder and
+// returns test fakes unchanged. executionEnabled=false wires the disabled
+// implementations so PurchaseLayer / ReshapeBuffer return
+// azureladder.ErrLadderExecutionDisabled without touching any Azure API.
+func wireAzureLadderWriteSide(executionEnabled bool, cred azcore.TokenCredential, capability pkgladder.LadderCapability) (pkgladder.LadderCapability, error) {
+	l, ok := capability.(*azureladder.AzureLadder)
+	if !ok {
+		return capability, nil
+	}
+	var (
+		wired *azureladder.AzureLadder
+		err   error
+	)
+	if executionEnabled {
+		wired, err = azureladder.WireWriteSide(l, cred)
+	} else {
+		wired, err = azureladder.WireWriteSideDisabled(l)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wireAzureLadderWriteSide: %w", err)
+	}
+	return wired, nil
+}
+
+// buildAndWireGCPCapability is the GCP counterpart of
+// buildAndWireAzureCapability: it resolves the project's token source once
+// (nil = Application Default Credentials), constructs the GCP
+// LadderCapability and wires its write side with the same token source.
+func (app *Application) buildAndWireGCPCapability(ctx context.Context, cloudAcct *config.CloudAccount, projectID string, executionEnabled bool) (pkgladder.LadderCapability, error) {
+	if app.GCPLadderCapabilityFactory == nil {
+		return nil, errors.New("GCPLadderCapabilityFactory is nil (not wired)")
+	}
+	if app.GCPLadderTokenSourceResolver == nil {
+		return nil, errors.New("GCPLadderTokenSourceResolver is nil (credential store not connected)")
+	}
+	ts, err := app.GCPLadderTokenSourceResolver(ctx, cloudAcct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve GCP credential for project %s: %w", projectID, err)
+	}
+	capability, err := app.GCPLadderCapabilityFactory(ctx, ts, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GCP ladder capability: %w", err)
+	}
+	return wireGCPLadderWriteSide(executionEnabled, ts, capability)
+}
+
+// wireGCPLadderWriteSide wires the write side only when capability is a
+// *gcpladder.GCPLadder and returns test fakes unchanged. executionEnabled=false
+// wires the disabled purchaser so PurchaseLayer returns
+// gcpladder.ErrLadderExecutionDisabled without touching any GCP API.
+func wireGCPLadderWriteSide(executionEnabled bool, ts oauth2.TokenSource, capability pkgladder.LadderCapability) (pkgladder.LadderCapability, error) {
+	l, ok := capability.(*gcpladder.GCPLadder)
+	if !ok {
+		return capability, nil
+	}
+	var (
+		wired *gcpladder.GCPLadder
+		err   error
+	)
+	if executionEnabled {
+		wired, err = gcpladder.WireWriteSide(l, ts)
+	} else {
+		wired, err = gcpladder.WireWriteSideDisabled(l)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wireGCPLadderWriteSide: %w", err)
+	}
+	return wired, nil
+}
+
 // wireLadderWriteSide wires the write side of a LadderCapability if and only if
 // cap is a *awsladder.AWSLadder. For test fakes (non-AWSLadder implementations)
 // it returns cap unchanged so existing handler tests continue to work without
@@ -7,6 +7,7 @@ import (
 	"github.com/LeanerCloud/CUDly/internal/config"
 	"github.com/LeanerCloud/CUDly/internal/database"
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/ladder"
 	"github.com/jackc/pgx/v5"
 )
@@ -136,6 +137,9 @@ func (m *mockConfigStoreForHealth) ListPendingExecutionIDsForAccount(ctx context
 func (m *mockConfigStoreForHealth) TransitionExecutionStatus(ctx context.Context, executionID string, fromStatuses []string, toStatus string, actor *string) (*config.PurchaseExecution, error) {
 	return nil, nil
 }
+func (m *mockConfigStoreForHealth) DeferExecutionAtomic(ctx context.Context, executionID string, fromStatuses []string, until time.Time) (*config.PurchaseExecution, error) {
+	return nil, nil
+}
 
 func (m *mockConfigStoreForHealth) SetCancelledBy(_ context.Context, _ string, _ string) error {
 	return nil
@@ -193,6 +197,224 @@ func (m *mockConfigStoreForHealth) CancelPendingExchangesByOrigin(_ context.Cont
 func (m *mockConfigStoreForHealth) GetStaleProcessingExchanges(ctx context.Context, olderThan time.Duration) ([]config.RIExchangeRecord, error) {
 	return nil, nil
 }
+func (m *mockConfigStoreForHealth) SaveRIModificationRecord(_ context.Context, _ *config.RIModificationRecord) error {
+	return nil
+}
+func (m *mockConfigStoreForHealth) GetRIModificationRecord(_ context.Context, _ string) (*config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStoreForHealth) GetRIModificationHistory(_ context.Context, _ time.Time, _ int) ([]config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStoreForHealth) TransitionRIModificationStatus(_ context.Context, _, _, _ string, _ *string) (*config.RIModificationRecord, error) {
+	return nil, nil
+}
+func (m *mockConfigStoreForHealth) CompleteRIModification(_ context.Context, _, _, _ string) error {
+	return nil
+}
+func (m *mockConfigStoreForHealth) FailRIModification(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) SaveAzureRefund(_ context.Context, _ *config.AzureRefundRecord) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) SumAzureRefunds(_ context.Context, _, _ string, _ time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockConfigStoreForHealth) UpsertCommitmentInventory(_ context.Context, _ string, _ []config.CommitmentInventoryRecord, _ []string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) GetActiveCommitmentInventory(_ context.Context, _ time.Time, _ []string, _ map[string][]string) ([]config.CommitmentInventoryRecord, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) CreateCommitmentRenewal(_ context.Context, _ *config.CommitmentRenewal) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ListCommitmentRenewals(_ context.Context, _ config.CommitmentRenewalFilter) ([]config.CommitmentRenewal, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) UpdateCommitmentRenewalStatus(_ context.Context, _, _ string, _ *string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) CreatePurchasePolicy(_ context.Context, _ *config.PurchasePolicy) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) GetLatestPurchasePolicy(_ context.Context) (*config.PurchasePolicy, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) GetPurchasePolicy(_ context.Context, _ int) (*config.PurchasePolicy, error) {
+	return nil, config.ErrNotFound
+}
+
+func (m *mockConfigStoreForHealth) ListPurchasePolicies(_ context.Context, _ int) ([]config.PurchasePolicy, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) RecordPolicyEvaluation(_ context.Context, _ *config.PolicyEvaluation) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ListPolicyEvaluations(_ context.Context, _ string) ([]config.PolicyEvaluation, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) RecordExecutionApproval(_ context.Context, _ *config.ExecutionApproval) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ListExecutionApprovals(_ context.Context, _ string) ([]config.ExecutionApproval, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) ListAccountGroups(_ context.Context) ([]config.AccountGroup, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) CreateAccountGroup(_ context.Context, _ *config.AccountGroup) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) UpdateAccountGroup(_ context.Context, _ *config.AccountGroup) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) DeleteAccountGroup(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ListApprovalChains(_ context.Context) ([]config.ApprovalChain, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) CreateApprovalChain(_ context.Context, _ *config.ApprovalChain) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) UpdateApprovalChain(_ context.Context, _ *config.ApprovalChain) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) DeleteApprovalChain(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) StartExecutionApprovalChain(_ context.Context, _ *config.ExecutionApprovalChain) (bool, error) {
+	return false, nil
+}
+
+func (m *mockConfigStoreForHealth) GetExecutionApprovalChain(_ context.Context, _ string) (*config.ExecutionApprovalChain, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) AdvanceExecutionApprovalChain(_ context.Context, _ string, _ int) (bool, error) {
+	return false, nil
+}
+
+func (m *mockConfigStoreForHealth) RecordApprovalStageDecision(_ context.Context, _ *config.ApprovalStageDecision) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ListApprovalStageDecisions(_ context.Context, _ string) ([]config.ApprovalStageDecision, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) ListCommitmentBudgets(_ context.Context) ([]config.CommitmentBudget, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) CreateCommitmentBudget(_ context.Context, _ *config.CommitmentBudget) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) UpdateCommitmentBudget(_ context.Context, _ *config.CommitmentBudget) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) DeleteCommitmentBudget(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ReserveCommitmentBudget(_ context.Context, _ *config.BudgetReservation) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) CommitBudgetReservation(_ context.Context, _ string, _, _ float64) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) ReleaseBudgetReservation(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) CreditCommitmentBudget(_ context.Context, _ *config.BudgetReservation) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) GetCommitmentBudgetUsage(_ context.Context, _ time.Time) ([]config.CommitmentBudgetUsage, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) ListFreezeWindows(_ context.Context) ([]config.FreezeWindow, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) CreateFreezeWindow(_ context.Context, _ *config.FreezeWindow) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) UpdateFreezeWindow(_ context.Context, _ *config.FreezeWindow) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) DeleteFreezeWindow(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) RecordFreezeOverride(_ context.Context, _ *config.FreezeOverride) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) GetLatestFreezeOverride(_ context.Context, _ string) (*config.FreezeOverride, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) ListFreezeOverrides(_ context.Context, _ int) ([]config.FreezeOverride, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) RecordPlanShadowPurchases(_ context.Context, _ string, _ []config.ShadowPurchase) error {
+	return nil
+}
+
+func (m *mockConfigStoreForHealth) SaveShadowLadderRun(_ context.Context, run *config.LadderRunDB, _ []config.ShadowPurchase) (*config.LadderRunDB, error) {
+	return run, nil
+}
+
+func (m *mockConfigStoreForHealth) GetShadowLadderCommitUSDHr(_ context.Context, _ string, _ time.Time) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockConfigStoreForHealth) ListShadowPurchases(_ context.Context, _ config.ShadowPurchaseFilter) ([]config.ShadowPurchase, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) ListShadowPurchasesToEvaluate(_ context.Context, _ time.Time) ([]config.ShadowPurchase, error) {
+	return nil, nil
+}
+
+func (m *mockConfigStoreForHealth) SaveShadowEvaluation(_ context.Context, _ *config.ShadowPurchase) error {
+	return nil
+}
 
 func (m *mockConfigStoreForHealth) CreateCloudAccount(ctx context.Context, account *config.CloudAccount) error {
 	return nil
@@ -290,6 +512,21 @@ func (m *mockConfigStoreForHealth) GetRIUtilizationCache(_ context.Context, _ st
 func (m *mockConfigStoreForHealth) UpsertRIUtilizationCache(_ context.Context, _ string, _ int, _ []byte, _ time.Time) error {
 	return nil
 }
+func (m *mockConfigStoreForHealth) CURObjectETags(_ context.Context, _ string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (m *mockConfigStoreForHealth) ReplaceCURObject(_ context.Context, _, _, _ string, _ []cur.PoolHour, _ []cur.CommitmentHour) error {
+	return nil
+}
+func (m *mockConfigStoreForHealth) DeleteCURObjects(_ context.Context, _ string, _ []string) error {
+	return nil
+}
+func (m *mockConfigStoreForHealth) CURPoolHours(_ context.Context, _ cur.Query) ([]cur.PoolHour, error) {
+	return nil, nil
+}
+func (m *mockConfigStoreForHealth) CURCommitmentHours(_ context.Context, _ cur.Query) ([]cur.CommitmentHour, error) {
+	return nil, nil
+}
 
 // ── Purchase suppressions (Commit 2 of bulk-purchase-with-grace).
 func (m *mockConfigStoreForHealth) CreateSuppression(_ context.Context, _ *config.PurchaseSuppression) error {
@@ -344,7 +581,7 @@ func (m *mockConfigStoreForHealth) GetScheduledExecutionsDue(_ context.Context)
 func (m *mockConfigStoreForHealth) GetLadderConfigs(_ context.Context) ([]config.LadderConfigDB, error) {
 	return nil, nil
 }
-func (m *mockConfigStoreForHealth) GetLadderConfig(_ context.Context, _, _ string) (*config.LadderConfigDB, error) {
+func (m *mockConfigStoreForHealth) GetLadderConfig(_ context.Context, _, _, _ string) (*config.LadderConfigDB, error) {
 	return nil, nil
 }
 func (m *mockConfigStoreForHealth) UpsertLadderConfig(_ context.Context, cfg *config.LadderConfigDB) (*config.LadderConfigDB, error) {
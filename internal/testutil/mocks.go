@@ -11,9 +11,10 @@ import (
 
 // MockScheduler is a mock implementation of server.SchedulerInterface.
 type MockScheduler struct {
-	CollectRecommendationsFunc func(ctx context.Context, ownerToken string) (*scheduler.CollectResult, error)
-	ListRecommendationsFunc    func(ctx context.Context, filter config.RecommendationFilter) ([]config.RecommendationRecord, error)
-	GetRecommendationByIDFunc  func(ctx context.Context, id string) (*config.RecommendationRecord, []string, error)
+	CollectRecommendationsFunc  func(ctx context.Context, ownerToken string) (*scheduler.CollectResult, error)
+	ListRecommendationsFunc     func(ctx context.Context, filter config.RecommendationFilter) ([]config.RecommendationRecord, error)
+	GetRecommendationByIDFunc   func(ctx context.Context, id string) (*config.RecommendationRecord, []string, error)
+	SyncCommitmentInventoryFunc func(ctx context.Context) (*scheduler.InventorySyncResult, error)
 }
 
 func (m *MockScheduler) CollectRecommendations(ctx context.Context, ownerToken string) (*scheduler.CollectResult, error) {
@@ -37,6 +38,13 @@ func (m *MockScheduler) GetRecommendationByID(ctx context.Context, id string) (*
 	return nil, nil, nil
 }
 
+func (m *MockScheduler) SyncCommitmentInventory(ctx context.Context) (*scheduler.InventorySyncResult, error) {
+	if m.SyncCommitmentInventoryFunc != nil {
+		return m.SyncCommitmentInventoryFunc(ctx)
+	}
+	return &scheduler.InventorySyncResult{}, nil
+}
+
 // MockPurchaseManager is a mock implementation of server.PurchaseManagerInterface.
 type MockPurchaseManager struct {
 	ProcessScheduledPurchasesFunc         func(ctx context.Context) (*purchase.ProcessResult, error)
@@ -44,6 +52,9 @@ type MockPurchaseManager struct {
 	ProcessMessageFunc                    func(ctx context.Context, body string) error
 	ApproveExecutionFunc                  func(ctx context.Context, execID, token, actor string) error
 	ApproveAndExecuteFunc                 func(ctx context.Context, execID, actor string, transitionedBy *string) error
+	EnforceApprovalPolicyFunc             func(ctx context.Context, execID, actor string, actorUserID *string) error
+	CheckManualFreezeFunc                 func(ctx context.Context, execID string) error
+	StartApprovalChainFunc                func(ctx context.Context, execID string) (*config.ExecutionApprovalChain, error)
 	CancelExecutionFunc                   func(ctx context.Context, execID, token, actor string) error
 	ReapStuckExecutionsFunc               func(ctx context.Context, reapAfter time.Duration) (*purchase.ReapResult, error)
 	FireScheduledDelayedPurchasesFunc     func(ctx context.Context) (*purchase.FireResult, error)
@@ -85,6 +96,27 @@ func (m *MockPurchaseManager) ApproveAndExecute(ctx context.Context, execID, act
 	return nil
 }
 
+func (m *MockPurchaseManager) EnforceApprovalPolicy(ctx context.Context, execID, actor string, actorUserID *string) error {
+	if m.EnforceApprovalPolicyFunc != nil {
+		return m.EnforceApprovalPolicyFunc(ctx, execID, actor, actorUserID)
+	}
+	return nil
+}
+
+func (m *MockPurchaseManager) CheckManualFreeze(ctx context.Context, execID string) error {
+	if m.CheckManualFreezeFunc != nil {
+		return m.CheckManualFreezeFunc(ctx, execID)
+	}
+	return nil
+}
+
+func (m *MockPurchaseManager) StartApprovalChain(ctx context.Context, execID string) (*config.ExecutionApprovalChain, error) {
+	if m.StartApprovalChainFunc != nil {
+		return m.StartApprovalChainFunc(ctx, execID)
+	}
+	return nil, nil
+}
+
 func (m *MockPurchaseManager) CancelExecution(ctx context.Context, execID, token, actor string) error {
 	if m.CancelExecutionFunc != nil {
 		return m.CancelExecutionFunc(ctx, execID, token, actor)
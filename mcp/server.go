@@ -27,6 +27,7 @@ func registrations() []tools.Registration {
 		tools.NewAWSOpenSearchRIPurchaseTool(),
 		tools.NewAWSRedshiftRIPurchaseTool(),
 		tools.NewAWSMemoryDBRIPurchaseTool(),
+		tools.NewAWSDynamoDBReservedCapacityPurchaseTool(),
 		tools.NewAWSRDSRIPurchaseTool(),
 		tools.NewAWSElastiCacheRIPurchaseTool(),
 		tools.NewAWSSavingsPlansPurchaseTool(),
@@ -96,6 +96,7 @@ func (t *awsEC2RIPurchaseTool) handle(ctx context.Context, _ *mcp.CallToolReques
 		DryRun:          dryRun,
 		Confirm:         confirm,
 		ResolveClient:   t.resolveClient(args, region),
+		ResolveAccount:  resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:           args.IdempotencyNonce,
 		CredentialScope: CredentialScope(args.AWSProfile, "AWS_PROFILE"),
 	})
@@ -200,7 +201,7 @@ func ec2RecommendationFromArgs(args ec2RIPurchaseArgs) (rec common.Recommendatio
 // resolves the provider/service client against a raw, un-trimmed value.
 func (t *awsEC2RIPurchaseTool) resolveClient(args ec2RIPurchaseArgs, region string) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderAWS), cfg)
 		if err != nil {
 			return nil, err
@@ -208,3 +209,10 @@ func (t *awsEC2RIPurchaseTool) resolveClient(args ec2RIPurchaseArgs, region stri
 		return prov.GetServiceClient(ctx, common.ServiceEC2, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *awsEC2RIPurchaseTool) providerConfig(args ec2RIPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+}
@@ -88,6 +88,7 @@ func (t *awsElastiCacheRIPurchaseTool) handle(ctx context.Context, _ *mcp.CallTo
 		DryRun:          dryRun,
 		Confirm:         confirm,
 		ResolveClient:   t.resolveClient(args, region),
+		ResolveAccount:  resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:           args.IdempotencyNonce,
 		CredentialScope: CredentialScope(args.AWSProfile, "AWS_PROFILE"),
 	})
@@ -151,7 +152,7 @@ func elasticacheRecommendationFromArgs(args elasticacheRIPurchaseArgs) (rec comm
 // client against a raw, un-trimmed value.
 func (t *awsElastiCacheRIPurchaseTool) resolveClient(args elasticacheRIPurchaseArgs, region string) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderAWS), cfg)
 		if err != nil {
 			return nil, err
@@ -159,3 +160,10 @@ func (t *awsElastiCacheRIPurchaseTool) resolveClient(args elasticacheRIPurchaseA
 		return prov.GetServiceClient(ctx, common.ServiceElastiCache, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *awsElastiCacheRIPurchaseTool) providerConfig(args elasticacheRIPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+}
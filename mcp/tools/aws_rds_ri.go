@@ -91,6 +91,7 @@ func (t *awsRDSRIPurchaseTool) handle(ctx context.Context, _ *mcp.CallToolReques
 		DryRun:          dryRun,
 		Confirm:         confirm,
 		ResolveClient:   t.resolveClient(args, region),
+		ResolveAccount:  resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:           args.IdempotencyNonce,
 		CredentialScope: CredentialScope(args.AWSProfile, "AWS_PROFILE"),
 	})
@@ -159,7 +160,7 @@ func rdsRecommendationFromArgs(args rdsRIPurchaseArgs) (rec common.Recommendatio
 // raw, un-trimmed value.
 func (t *awsRDSRIPurchaseTool) resolveClient(args rdsRIPurchaseArgs, region string) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderAWS), cfg)
 		if err != nil {
 			return nil, err
@@ -167,3 +168,10 @@ func (t *awsRDSRIPurchaseTool) resolveClient(args rdsRIPurchaseArgs, region stri
 		return prov.GetServiceClient(ctx, common.ServiceRDS, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *awsRDSRIPurchaseTool) providerConfig(args rdsRIPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+}
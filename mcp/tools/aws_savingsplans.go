@@ -106,6 +106,7 @@ func (t *awsSavingsPlansPurchaseTool) handle(ctx context.Context, _ *mcp.CallToo
 		DryRun:          dryRun,
 		Confirm:         confirm,
 		ResolveClient:   t.resolveClient(args, region, rec.Service),
+		ResolveAccount:  resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:           args.IdempotencyNonce,
 		CredentialScope: CredentialScope(args.AWSProfile, "AWS_PROFILE"),
 	})
@@ -309,7 +310,7 @@ func validateDatabaseSPConstraints(spType SPType, term TermYears, paymentOption
 
 func (t *awsSavingsPlansPurchaseTool) resolveClient(args savingsPlansPurchaseArgs, region string, service common.ServiceType) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderAWS), cfg)
 		if err != nil {
 			return nil, err
@@ -317,3 +318,10 @@ func (t *awsSavingsPlansPurchaseTool) resolveClient(args savingsPlansPurchaseArg
 		return prov.GetServiceClient(ctx, service, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *awsSavingsPlansPurchaseTool) providerConfig(args savingsPlansPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+}
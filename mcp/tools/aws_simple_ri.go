@@ -12,11 +12,12 @@ import (
 
 // simpleAWSRIPurchaseSpec configures a region+resource_type+count+term+
 // payment_option AWS RI purchase tool -- the shape shared by OpenSearch,
-// Redshift, and MemoryDB. None of their PurchaseCommitment implementations
-// read rec.Details (providers/aws/services/{opensearch,redshift,memorydb}/
+// Redshift, MemoryDB and DynamoDB reserved capacity. None of their
+// PurchaseCommitment implementations read rec.Details
+// (providers/aws/services/{opensearch,redshift,memorydb,dynamodb}/
 // client.go), unlike EC2 (ComputeDetails) or RDS/ElastiCache (Database/
-// CacheDetails), so one generic tool type serves all three rather than
-// three near-identical copies.
+// CacheDetails), so one generic tool type serves all four rather than
+// near-identical copies.
 type simpleAWSRIPurchaseSpec struct {
 	name             string
 	product          string
@@ -24,6 +25,33 @@ type simpleAWSRIPurchaseSpec struct {
 	service          common.ServiceType
 	resourceTypeDesc string // jsonschema description for the resource_type field
 	examplePrompts   []string
+	// commitmentName and commitmentType default to "Reserved Instances"
+	// and CommitmentReservedInstance.
+	commitmentName string
+	commitmentType common.CommitmentType
+	// paymentOptions restricts payment_option; empty allows all three.
+	paymentOptions []PaymentOption
+}
+
+// commitment returns the spec's commitment name and type, applying the
+// Reserved Instance defaults.
+func (s simpleAWSRIPurchaseSpec) commitment() (string, common.CommitmentType) {
+	name, typ := s.commitmentName, s.commitmentType
+	if name == "" {
+		name = "Reserved Instances"
+	}
+	if typ == "" {
+		typ = common.CommitmentReservedInstance
+	}
+	return name, typ
+}
+
+// allowedPaymentOptions returns the payment options the spec accepts.
+func (s simpleAWSRIPurchaseSpec) allowedPaymentOptions() []PaymentOption {
+	if len(s.paymentOptions) > 0 {
+		return s.paymentOptions
+	}
+	return []PaymentOption{PaymentOptionAllUpfront, PaymentOptionPartialUpfront, PaymentOptionNoUpfront}
 }
 
 // simpleAWSRIPurchaseArgs is the input schema shared by every
@@ -93,17 +121,37 @@ func NewAWSMemoryDBRIPurchaseTool() Registration {
 	})
 }
 
+// NewAWSDynamoDBReservedCapacityPurchaseTool builds
+// cudly_aws_dynamodb_ri_purchase. DynamoDB reserved capacity is sold in
+// blocks of 100 read or write capacity units, partial-upfront only.
+func NewAWSDynamoDBReservedCapacityPurchaseTool() Registration {
+	return newSimpleAWSRIPurchaseTool(simpleAWSRIPurchaseSpec{
+		name:             "cudly_aws_dynamodb_ri_purchase",
+		product:          "dynamodb",
+		displayName:      "DynamoDB",
+		service:          common.ServiceDynamoDB,
+		resourceTypeDesc: "DynamoDB capacity unit type, ReadCapacityUnits or WriteCapacityUnits; count is in blocks of 100 units",
+		examplePrompts: []string{
+			"Preview reserving 500 DynamoDB write capacity units in us-east-1 for 1 year",
+		},
+		commitmentName: "reserved capacity",
+		commitmentType: common.CommitmentReservedCapacity,
+		paymentOptions: []PaymentOption{PaymentOptionPartialUpfront},
+	})
+}
+
 func (t *simpleAWSRIPurchaseTool) Descriptor() Descriptor {
+	commitmentName, _ := t.spec.commitment()
 	return Descriptor{
 		Name:     t.spec.name,
 		Provider: "aws",
 		Product:  t.spec.product,
 		Action:   "ri_purchase",
 		Description: fmt.Sprintf(
-			"Purchase AWS %s Reserved Instances. THIS SPENDS REAL MONEY when dry_run=false and confirm=true. "+
+			"Purchase AWS %s %s. THIS SPENDS REAL MONEY when dry_run=false and confirm=true. "+
 				"Always call with dry_run=true first (the default) to validate your parameters before "+
 				"committing; a dry_run response never contacts AWS and never spends money.",
-			t.spec.displayName),
+			t.spec.displayName, commitmentName),
 		RealPurchaseEnabled: true,
 		ExamplePrompts:      t.spec.examplePrompts,
 	}
@@ -113,7 +161,7 @@ func (t *simpleAWSRIPurchaseTool) Register(s *mcp.Server) error {
 	desc := t.Descriptor().Description
 	schema, err := BuildInputSchema[simpleAWSRIPurchaseArgs](map[string]FieldOverride{
 		"term_years":     {Enum: []any{int(TermOneYear), int(TermThreeYear)}},
-		"payment_option": {Enum: []any{string(PaymentOptionAllUpfront), string(PaymentOptionPartialUpfront), string(PaymentOptionNoUpfront)}},
+		"payment_option": {Enum: paymentOptionEnum(t.spec.allowedPaymentOptions())},
 		"dry_run":        {Default: true},
 		"confirm":        {Default: false},
 	})
@@ -145,6 +193,7 @@ func (t *simpleAWSRIPurchaseTool) handle(ctx context.Context, _ *mcp.CallToolReq
 		DryRun:          dryRun,
 		Confirm:         confirm,
 		ResolveClient:   t.resolveClient(args, region),
+		ResolveAccount:  resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:           args.IdempotencyNonce,
 		CredentialScope: CredentialScope(args.AWSProfile, "AWS_PROFILE"),
 	})
@@ -173,18 +222,19 @@ func (t *simpleAWSRIPurchaseTool) recommendationFromArgs(args simpleAWSRIPurchas
 	if err != nil {
 		return common.Recommendation{}, "", false, false, err
 	}
-	paymentOption, err := ValidatePaymentOption(args.PaymentOption)
+	paymentOption, err := t.validatePaymentOption(args.PaymentOption)
 	if err != nil {
 		return common.Recommendation{}, "", false, false, err
 	}
 
+	_, commitmentType := t.spec.commitment()
 	rec = common.Recommendation{
 		Provider:       common.ProviderAWS,
 		Service:        t.spec.service,
 		Region:         region,
 		ResourceType:   resourceType,
 		Count:          args.Count,
-		CommitmentType: common.CommitmentReservedInstance,
+		CommitmentType: commitmentType,
 		Term:           term.RecommendationTerm(),
 		PaymentOption:  string(paymentOption),
 	}
@@ -193,6 +243,31 @@ func (t *simpleAWSRIPurchaseTool) recommendationFromArgs(args simpleAWSRIPurchas
 	return rec, region, dryRun, confirm, nil
 }
 
+// validatePaymentOption validates s and, for a spec with a restricted set,
+// rejects options the product does not sell.
+func (t *simpleAWSRIPurchaseTool) validatePaymentOption(s string) (PaymentOption, error) {
+	paymentOption, err := ValidatePaymentOption(s)
+	if err != nil {
+		return "", err
+	}
+	allowed := t.spec.allowedPaymentOptions()
+	for _, p := range allowed {
+		if p == paymentOption {
+			return paymentOption, nil
+		}
+	}
+	return "", fmt.Errorf("invalid payment_option %q: AWS %s supports only %v", s, t.spec.displayName, allowed)
+}
+
+// paymentOptionEnum converts payment options to a JSON schema enum.
+func paymentOptionEnum(options []PaymentOption) []any {
+	out := make([]any, len(options))
+	for i, p := range options {
+		out[i] = string(p)
+	}
+	return out
+}
+
 // resolveClient returns the ResolveClientFunc that ExecutePurchase invokes
 // only for a real purchase. region is the effective, already-validated-and-
 // trimmed region returned by recommendationFromArgs -- not args.Region --
@@ -200,7 +275,7 @@ func (t *simpleAWSRIPurchaseTool) recommendationFromArgs(args simpleAWSRIPurchas
 // raw, un-trimmed value.
 func (t *simpleAWSRIPurchaseTool) resolveClient(args simpleAWSRIPurchaseArgs, region string) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderAWS), cfg)
 		if err != nil {
 			return nil, err
@@ -208,3 +283,10 @@ func (t *simpleAWSRIPurchaseTool) resolveClient(args simpleAWSRIPurchaseArgs, re
 		return prov.GetServiceClient(ctx, t.spec.service, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *simpleAWSRIPurchaseTool) providerConfig(args simpleAWSRIPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderAWS), AWSProfile: CredentialScope(args.AWSProfile), Region: region}
+}
@@ -209,3 +209,28 @@ func TestSimpleAWSRIPurchaseHandleRealPurchaseCallsCorrectService(t *testing.T)
 		})
 	}
 }
+
+func TestAWSDynamoDBReservedCapacityPurchaseTool(t *testing.T) {
+	t.Parallel()
+	tool := NewAWSDynamoDBReservedCapacityPurchaseTool().(*simpleAWSRIPurchaseTool)
+
+	d := tool.Descriptor()
+	assert.Equal(t, "cudly_aws_dynamodb_ri_purchase", d.Name)
+	assert.Contains(t, d.Description, "Purchase AWS DynamoDB reserved capacity.")
+	assert.True(t, d.RealPurchaseEnabled)
+
+	args := validSimpleArgs()
+	args.ResourceType = "WriteCapacityUnits"
+	args.Count = 5
+	_, _, _, _, err := tool.recommendationFromArgs(args)
+	require.Error(t, err, "all-upfront is not sold for DynamoDB reserved capacity")
+	assert.Contains(t, err.Error(), "supports only [partial-upfront]")
+
+	args.PaymentOption = "partial-upfront"
+	rec, _, _, _, err := tool.recommendationFromArgs(args)
+	require.NoError(t, err)
+	assert.Equal(t, common.ServiceDynamoDB, rec.Service)
+	assert.Equal(t, common.CommitmentReservedCapacity, rec.CommitmentType)
+	assert.Equal(t, "WriteCapacityUnits", rec.ResourceType)
+	assert.Equal(t, 5, rec.Count)
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// CloudAccountLookup maps the provider-side identifier of an account (AWS
+// account number, Azure subscription ID, GCP project ID) to the ID of the
+// CUDly cloud account registered for it. It returns "" and no error when
+// no CUDly cloud account is registered for it.
+type CloudAccountLookup func(ctx context.Context, providerType common.ProviderType, externalID string) (string, error)
+
+// cloudAccountLookup attributes a real purchase to the CUDly cloud account
+// it lands in, so account-group-scoped commitment budgets count it only
+// when that account is in their group. Nil (a server run without the CUDly
+// database) leaves every purchase unattributed; see budget.Request for why
+// an unattributed purchase counts against every budget.
+var cloudAccountLookup CloudAccountLookup
+
+// SetCloudAccountLookup installs the lookup real purchases are attributed
+// with. cmd/cudly-mcp calls it alongside SetBudgetReserver whenever the
+// server is configured with the CUDly database; see cloudAccountLookup.
+func SetCloudAccountLookup(l CloudAccountLookup) {
+	cloudAccountLookup = l
+}
+
+// ResolveAccountFunc lazily resolves the provider-side identifier of the
+// account a real purchase lands in. Like ResolveClientFunc it is only
+// invoked for a real purchase, never for a preview.
+type ResolveAccountFunc func(ctx context.Context) (string, error)
+
+// resolveDefaultAccount returns the ResolveAccountFunc for a tool whose
+// purchases authenticate with cfg: the account the provider reports as its
+// default, which is the one PurchaseCommitment buys into (the STS caller's
+// AWS account, the configured Azure subscription, the configured GCP
+// project).
+func resolveDefaultAccount(createProvider func(name string, cfg *provider.ProviderConfig) (provider.Provider, error), cfg *provider.ProviderConfig) ResolveAccountFunc {
+	return func(ctx context.Context) (string, error) {
+		prov, err := createProvider(cfg.Name, cfg)
+		if err != nil {
+			return "", err
+		}
+		accounts, err := prov.GetAccounts(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, a := range accounts {
+			if a.IsDefault {
+				return a.ID, nil
+			}
+		}
+		return "", fmt.Errorf("none of the %d %s accounts visible to these credentials is the default", len(accounts), cfg.Name)
+	}
+}
+
+// budgetAccountIDs returns the CUDly cloud account IDs req's purchase is
+// attributed to in the commitment budget. It is empty, so the purchase
+// counts against every budget, when the server has no cloud account lookup,
+// the tool supplies no account resolver, or the account is not registered
+// in CUDly. An account that cannot be resolved is an error rather than an
+// unattributed purchase: the same credentials are about to buy with it.
+func budgetAccountIDs(ctx context.Context, req PurchaseRequest) ([]string, error) {
+	if cloudAccountLookup == nil || req.ResolveAccount == nil {
+		return nil, nil
+	}
+	rec := req.Recommendation
+	externalID, err := req.ResolveAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolve the %s account for the commitment budget: %w", rec.Provider, err)
+	}
+	id, err := cloudAccountLookup(ctx, rec.Provider, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("look up the CUDly cloud account for %s account %s: %w", rec.Provider, externalID, err)
+	}
+	if id == "" {
+		log.Printf("mcp purchase: %s account %s is not registered in CUDly; the commitment budget counts it against every budget",
+			rec.Provider, externalID)
+		return nil, nil
+	}
+	return []string{id}, nil
+}
+
+// budgetRequest builds the commitment budget reservation for buying
+// pr.Recommendation through client, attributed per budgetAccountIDs. The
+// purchase tools build the recommendation from the caller's typed
+// arguments, so an RI or CUD carries no price (only a Savings Plan carries
+// its hourly commitment), and budget.Check lets a zero amount through every
+// cap. An unpriced recommendation is therefore priced from the provider's
+// offering, and a purchase that cannot be priced is refused rather than
+// counted as free.
+func budgetRequest(ctx context.Context, pr PurchaseRequest, client provider.ServiceClient, token string) (budget.Request, error) {
+	accountIDs, err := budgetAccountIDs(ctx, pr)
+	if err != nil {
+		return budget.Request{}, err
+	}
+	rec := pr.Recommendation
+	req := budget.FromRecommendation(budget.SourceMCP, token, accountIDs, rec)
+	if req.UpfrontUSD > 0 || req.HourlyUSD > 0 {
+		return req, nil
+	}
+	offering, err := client.GetOfferingDetails(ctx, rec)
+	if err != nil {
+		return budget.Request{}, fmt.Errorf("refusing real purchase: cannot price %s %s for the commitment budget: %w",
+			rec.Service, rec.ResourceType, err)
+	}
+	if offering == nil {
+		return budget.Request{}, fmt.Errorf("refusing real purchase: no offering found to price %s %s for the commitment budget",
+			rec.Service, rec.ResourceType)
+	}
+	if offering.Currency != "" && !strings.EqualFold(offering.Currency, "USD") {
+		return budget.Request{}, fmt.Errorf("refusing real purchase: the %s %s offering is priced in %q; commitment budgets are in USD",
+			rec.Service, rec.ResourceType, offering.Currency)
+	}
+	req.UpfrontUSD, req.HourlyUSD = offeringBudgetAmounts(offering, rec)
+	if req.UpfrontUSD <= 0 && req.HourlyUSD <= 0 {
+		return budget.Request{}, fmt.Errorf("refusing real purchase: the %s %s offering reports no price, so the commitment budget cannot be applied",
+			rec.Service, rec.ResourceType)
+	}
+	return req, nil
+}
+
+// offeringBudgetAmounts is the upfront payment and new hourly commitment of
+// buying rec.Count units of offering. OfferingDetails is per unit; the
+// hourly amount is its effective hourly rate when the provider reports one,
+// and otherwise the upfront payment spread over the term plus the per-hour
+// recurring charge.
+func offeringBudgetAmounts(offering *common.OfferingDetails, rec common.Recommendation) (upfrontUSD, hourlyUSD float64) {
+	count := float64(rec.Count)
+	if count <= 0 {
+		count = 1
+	}
+	upfrontUSD = offering.UpfrontCost * count
+	if offering.EffectiveHourlyRate > 0 {
+		return upfrontUSD, offering.EffectiveHourlyRate * count
+	}
+	monthly := offering.RecurringCost * count * budget.HoursPerMonth
+	return upfrontUSD, budget.HourlyCommitment(upfrontUSD, monthly, termYearsFromRecommendationTerm(rec.Term))
+}
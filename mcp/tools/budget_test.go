@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+type recordingReserver struct {
+	refuse   bool
+	reserved []budget.Request
+	released []string
+	commits  []string
+}
+
+func (r *recordingReserver) Reserve(_ context.Context, req budget.Request) (string, error) {
+	if r.refuse {
+		return "", &budget.ExceededError{Budget: "org", Period: budget.PeriodMonth, Limit: budget.LimitUpfront, Cap: 100, Used: 90, Requested: 600}
+	}
+	r.reserved = append(r.reserved, req)
+	return "res-1", nil
+}
+
+func (r *recordingReserver) Commit(_ context.Context, id string, _, _ float64) error {
+	r.commits = append(r.commits, id)
+	return nil
+}
+
+func (r *recordingReserver) Release(_ context.Context, id, _ string) error {
+	r.released = append(r.released, id)
+	return nil
+}
+
+// admitAllReserver reserves every request; TestMain installs it so tests
+// not about the budget clear its gate.
+type admitAllReserver struct{}
+
+func (admitAllReserver) Reserve(context.Context, budget.Request) (string, error) { return "res", nil }
+
+func (admitAllReserver) Commit(context.Context, string, float64, float64) error { return nil }
+
+func (admitAllReserver) Release(context.Context, string, string) error { return nil }
+
+// setBudgetReserver installs r for the duration of t. Tests using it must
+// not call t.Parallel: the reserver is process-wide, and parallel tests in
+// this package only resume once every sequential test has finished.
+func setBudgetReserver(t *testing.T, r budget.Reserver) {
+	t.Helper()
+	prev := budgetReserver
+	SetBudgetReserver(r)
+	t.Cleanup(func() { SetBudgetReserver(prev) })
+}
+
+func TestExecutePurchaseCommitmentBudget(t *testing.T) {
+	purchase := func(fake *fakeServiceClient) (*PurchaseResponse, error) {
+		return ExecutePurchase(context.Background(), PurchaseRequest{
+			Region:         "us-east-1",
+			Recommendation: testRecommendationWithCost(),
+			Confirm:        true, CredentialScope: "test-scope",
+			ResolveClient: func(context.Context) (provider.ServiceClient, error) { return fake, nil },
+		})
+	}
+
+	t.Run("reserves under the idempotency token and commits", func(t *testing.T) {
+		r := &recordingReserver{}
+		setBudgetReserver(t, r)
+		fake := &fakeServiceClient{purchaseResult: common.PurchaseResult{Success: true, CommitmentID: "ri-1"}}
+
+		resp, err := purchase(fake)
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+		require.Len(t, r.reserved, 1)
+		assert.Equal(t, budget.SourceMCP, r.reserved[0].Source)
+		assert.Equal(t, fake.lastOpts.IdempotencyToken, r.reserved[0].Reference)
+		assert.Equal(t, 600.0, r.reserved[0].UpfrontUSD)
+		assert.Empty(t, r.reserved[0].AccountIDs, "unattributed, so account-group budgets apply too")
+		assert.Equal(t, []string{"res-1"}, r.commits)
+	})
+
+	t.Run("refused purchase never reaches the provider", func(t *testing.T) {
+		setBudgetReserver(t, &recordingReserver{refuse: true})
+		fake := &fakeServiceClient{}
+
+		_, err := purchase(fake)
+		var exceeded *budget.ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.Contains(t, err.Error(), "remaining $10.00")
+		assert.Zero(t, fake.purchaseCalls)
+	})
+
+	t.Run("no reserver refuses before resolving the provider", func(t *testing.T) {
+		setBudgetReserver(t, nil)
+		resolved := false
+		_, err := ExecutePurchase(context.Background(), PurchaseRequest{
+			Region:         "us-east-1",
+			Recommendation: testRecommendationWithCost(),
+			Confirm:        true, CredentialScope: "test-scope",
+			ResolveClient: func(context.Context) (provider.ServiceClient, error) {
+				resolved = true
+				return &fakeServiceClient{}, nil
+			},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "commitment budget cannot be enforced")
+		assert.False(t, resolved, "an unguarded server must not touch the provider")
+	})
+
+	t.Run("unsuccessful purchase releases its reservation", func(t *testing.T) {
+		r := &recordingReserver{}
+		setBudgetReserver(t, r)
+
+		_, err := purchase(&fakeServiceClient{purchaseResult: common.PurchaseResult{Success: false}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"res-1"}, r.released)
+		assert.Empty(t, r.commits)
+	})
+}
+
+// cappedReserver enforces cap with budget.Check, as the store does, so a
+// purchase priced at $0 slips under it and a priced one does not.
+type cappedReserver struct {
+	recordingReserver
+	cap budget.Cap
+}
+
+func (r *cappedReserver) Reserve(ctx context.Context, req budget.Request) (string, error) {
+	if err := budget.Check(r.cap, budget.Usage{}, req, time.Now()); err != nil {
+		return "", err
+	}
+	return r.recordingReserver.Reserve(ctx, req)
+}
+
+func TestExecutePurchasePricesToolBuiltRecommendations(t *testing.T) {
+	args := validRDSArgs()
+	args.PaymentOption = "all-upfront"
+	rec, region, _, _, err := rdsRecommendationFromArgs(args)
+	require.NoError(t, err)
+	unpriced := budget.FromRecommendation(budget.SourceMCP, "tok", nil, rec)
+	require.Zero(t, unpriced.UpfrontUSD, "a tool-built recommendation carries no price of its own")
+	require.Zero(t, unpriced.HourlyUSD)
+
+	purchase := func(fake *fakeServiceClient) (*PurchaseResponse, error) {
+		return ExecutePurchase(context.Background(), PurchaseRequest{
+			Region:         region,
+			Recommendation: rec,
+			Confirm:        true, CredentialScope: "test-scope",
+			ResolveClient: func(context.Context) (provider.ServiceClient, error) { return fake, nil },
+		})
+	}
+	rdsOffering := &common.OfferingDetails{UpfrontCost: 1000, Currency: "USD"}
+
+	t.Run("a capped budget refuses the priced purchase", func(t *testing.T) {
+		capUSD := 500.0
+		setBudgetReserver(t, &cappedReserver{cap: budget.Cap{Name: "org", Period: budget.PeriodMonth, MaxUpfrontUSD: &capUSD}})
+		fake := &fakeServiceClient{offering: rdsOffering}
+
+		_, err := purchase(fake)
+		var exceeded *budget.ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.InDelta(t, 2000, exceeded.Requested, 1e-9, "two instances at the offering's $1000 upfront")
+		assert.Zero(t, fake.purchaseCalls)
+	})
+
+	t.Run("reserves the offering's price", func(t *testing.T) {
+		r := &recordingReserver{}
+		setBudgetReserver(t, r)
+		fake := &fakeServiceClient{offering: rdsOffering, purchaseResult: common.PurchaseResult{Success: true}}
+
+		_, err := purchase(fake)
+		require.NoError(t, err)
+		require.Len(t, r.reserved, 1)
+		assert.InDelta(t, 2000, r.reserved[0].UpfrontUSD, 1e-9)
+		assert.InDelta(t, 2000/(3*budget.HoursPerYear), r.reserved[0].HourlyUSD, 1e-9)
+	})
+
+	t.Run("a purchase that cannot be priced is refused", func(t *testing.T) {
+		r := &recordingReserver{}
+		setBudgetReserver(t, r)
+		for name, fake := range map[string]*fakeServiceClient{
+			"lookup fails":    {offeringErr: errors.New("DescribeReservedDBInstancesOfferings: throttled")},
+			"no price":        {offering: &common.OfferingDetails{Currency: "USD"}},
+			"not priced in $": {offering: &common.OfferingDetails{UpfrontCost: 1000, Currency: "EUR"}},
+		} {
+			_, err := purchase(fake)
+			require.Error(t, err, name)
+			assert.Contains(t, err.Error(), "refusing real purchase", name)
+			assert.Zero(t, fake.purchaseCalls, name)
+		}
+		assert.Empty(t, r.reserved)
+	})
+}
+
+func TestExecutePurchaseAttributesTheCloudAccount(t *testing.T) {
+	prev := cloudAccountLookup
+	t.Cleanup(func() { SetCloudAccountLookup(prev) })
+	SetCloudAccountLookup(func(_ context.Context, p common.ProviderType, externalID string) (string, error) {
+		if p == common.ProviderAWS && externalID == "111111111111" {
+			return "cloud-acct-1", nil
+		}
+		return "", nil
+	})
+	tool := &awsRDSRIPurchaseTool{}
+	purchase := func(t *testing.T, accounts []common.Account) ([]budget.Request, error) {
+		r := &recordingReserver{}
+		setBudgetReserver(t, r)
+		fp := &fakeProvider{name: "aws", accounts: accounts}
+		tool.createProvider = func(string, *provider.ProviderConfig) (provider.Provider, error) { return fp, nil }
+		_, err := ExecutePurchase(context.Background(), PurchaseRequest{
+			Region:         "us-east-1",
+			Recommendation: testRecommendationWithCost(),
+			Confirm:        true, CredentialScope: "test-scope",
+			ResolveClient: func(context.Context) (provider.ServiceClient, error) {
+				return &fakeServiceClient{purchaseResult: common.PurchaseResult{Success: true}}, nil
+			},
+			ResolveAccount: resolveDefaultAccount(tool.createProvider, tool.providerConfig(validRDSArgs(), "us-east-1")),
+		})
+		return r.reserved, err
+	}
+
+	t.Run("a registered account scopes the reservation to it", func(t *testing.T) {
+		reserved, err := purchase(t, []common.Account{
+			{Provider: common.ProviderAWS, ID: "222222222222"},
+			{Provider: common.ProviderAWS, ID: "111111111111", IsDefault: true},
+		})
+		require.NoError(t, err)
+		require.Len(t, reserved, 1)
+		assert.Equal(t, []string{"cloud-acct-1"}, reserved[0].AccountIDs)
+	})
+
+	t.Run("an unregistered account stays unattributed", func(t *testing.T) {
+		reserved, err := purchase(t, []common.Account{{Provider: common.ProviderAWS, ID: "333333333333", IsDefault: true}})
+		require.NoError(t, err)
+		require.Len(t, reserved, 1)
+		assert.Empty(t, reserved[0].AccountIDs)
+	})
+
+	t.Run("an unresolvable account refuses the purchase", func(t *testing.T) {
+		reserved, err := purchase(t, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "resolve the aws account")
+		assert.Empty(t, reserved)
+	})
+}
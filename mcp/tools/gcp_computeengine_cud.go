@@ -96,6 +96,7 @@ func (t *gcpComputeEngineCUDPurchaseTool) handle(ctx context.Context, _ *mcp.Cal
 		DryRun:         dryRun,
 		Confirm:        confirm,
 		ResolveClient:  t.resolveClient(args, region),
+		ResolveAccount: resolveDefaultAccount(t.createProvider, t.providerConfig(args, region)),
 		Nonce:          args.IdempotencyNonce,
 		// No ambient environment fallback here, deliberately, and unlike the
 		// AWS ("AWS_PROFILE") and Azure ("AZURE_SUBSCRIPTION_ID") tools. Do
@@ -190,7 +191,7 @@ func gcpComputeEngineRecommendationFromArgs(args gcpComputeEngineCUDPurchaseArgs
 // client against a raw, un-trimmed value.
 func (t *gcpComputeEngineCUDPurchaseTool) resolveClient(args gcpComputeEngineCUDPurchaseArgs, region string) ResolveClientFunc {
 	return func(ctx context.Context) (provider.ServiceClient, error) {
-		cfg := &provider.ProviderConfig{Name: string(common.ProviderGCP), GCPProjectID: CredentialScope(args.GCPProjectID), Region: region}
+		cfg := t.providerConfig(args, region)
 		prov, err := t.createProvider(string(common.ProviderGCP), cfg)
 		if err != nil {
 			return nil, err
@@ -198,3 +199,10 @@ func (t *gcpComputeEngineCUDPurchaseTool) resolveClient(args gcpComputeEngineCUD
 		return prov.GetServiceClient(ctx, common.ServiceCompute, region)
 	}
 }
+
+// providerConfig is the provider configuration a real purchase authenticates
+// with, shared by resolveClient and the account the commitment budget
+// attributes the purchase to.
+func (t *gcpComputeEngineCUDPurchaseTool) providerConfig(args gcpComputeEngineCUDPurchaseArgs, region string) *provider.ProviderConfig {
+	return &provider.ProviderConfig{Name: string(common.ProviderGCP), GCPProjectID: CredentialScope(args.GCPProjectID), Region: region}
+}
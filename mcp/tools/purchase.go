@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/LeanerCloud/CUDly/pkg/budget"
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
 )
@@ -48,6 +49,22 @@ func realPurchasesEnabled() bool {
 	return v == "1" || v == "true"
 }
 
+// budgetReserver guards every real purchase with the organisation-wide
+// commitment budget: the purchase's amounts are reserved before
+// PurchaseCommitment is called, and a purchase the budget refuses never
+// reaches the provider. Nil (the default, for a server run without the
+// CUDly database) refuses every real purchase instead: the budgets live in
+// that database, so without it the server cannot tell whether a purchase
+// would exceed one.
+var budgetReserver budget.Reserver
+
+// SetBudgetReserver installs the commitment budget guard for real
+// purchases. cmd/cudly-mcp calls it at startup whenever the server is
+// configured with the CUDly database; see budgetReserver.
+func SetBudgetReserver(r budget.Reserver) {
+	budgetReserver = r
+}
+
 // decidePurchaseMode applies the safety rail from the design doc (§7): a
 // real purchase requires confirm=true AND dry_run=false. dry_run=true always
 // wins and returns a preview, regardless of confirm, so a caller previewing
@@ -83,6 +100,11 @@ type PurchaseRequest struct {
 	Confirm        bool
 	ResolveClient  ResolveClientFunc
 
+	// ResolveAccount is optional. When set, and the server has a
+	// CloudAccountLookup, a real purchase is attributed in the commitment
+	// budget to the CUDly cloud account it lands in. See budgetAccountIDs.
+	ResolveAccount ResolveAccountFunc
+
 	// Nonce is optional. When non-empty, this call is treated as a
 	// DISTINCT purchase from an otherwise-identical one (authorizes a
 	// deliberate repeat, e.g. "buy 3 more RIs" on top of an earlier "buy 3
@@ -565,9 +587,9 @@ func logPurchaseOutcome(rec common.Recommendation, token, commitmentID string, s
 // Gathered into one function rather than inlined so ExecutePurchase stays
 // under the repo's gocyclo:10 pre-commit gate as gates accumulate, and so
 // "what must be true before this server spends money" has a single place to
-// read. Order matters: both the operator opt-in and the credential-scope
-// check run before ResolveClient, so a refusal never resolves credentials or
-// contacts a provider.
+// read. Order matters: the operator opt-in, the credential-scope check and
+// the commitment budget check all run before ResolveClient, so a refusal
+// never resolves credentials or contacts a provider.
 func authorizeRealPurchase(req PurchaseRequest, rec common.Recommendation) error {
 	// Operator authorization. See EnvEnableRealPurchases for why the
 	// model-supplied confirm flag alone is not enough.
@@ -580,6 +602,12 @@ func authorizeRealPurchase(req PurchaseRequest, rec common.Recommendation) error
 	if err := requireCredentialScope(rec.Provider, req.CredentialScope); err != nil {
 		return err
 	}
+	// Commitment budget. See budgetReserver for why an unguarded server
+	// refuses rather than spending past budgets it cannot see.
+	if budgetReserver == nil {
+		return fmt.Errorf("real purchases are disabled: the commitment budget cannot be enforced without the CUDly database; " +
+			"start the server with the DB_* variables the deployed service uses")
+	}
 	if req.ResolveClient == nil {
 		return fmt.Errorf("internal error: no ResolveClient configured for real purchase")
 	}
@@ -588,9 +616,12 @@ func authorizeRealPurchase(req PurchaseRequest, rec common.Recommendation) error
 
 // ExecutePurchase runs the shared dry_run/confirm safety gate, then every
 // gate in authorizeRealPurchase (operator opt-in via EnvEnableRealPurchases,
-// and a determinable target account), and for a real purchase that clears
-// them all, resolves the service client and calls PurchaseCommitment with
-// PurchaseSourceMCP and a derived idempotency token. It never calls
+// a determinable target account and a commitment budget guard), and for a
+// real purchase that clears
+// them all, resolves the service client, prices and attributes the purchase
+// for the commitment budget (see budgetRequest) and calls
+// PurchaseCommitment under it with PurchaseSourceMCP and a derived
+// idempotency token. It never calls
 // ResolveClient in preview mode, so a preview makes zero provider/SDK calls;
 // it also never calls ResolveClient when any authorizeRealPurchase gate
 // refuses, so a disabled server -- or one that cannot tell which account a
@@ -635,7 +666,17 @@ func ExecutePurchase(ctx context.Context, req PurchaseRequest) (*PurchaseRespons
 
 	logPurchaseAttempt(req, rec, token)
 
-	result, err := client.PurchaseCommitment(ctx, rec, opts)
+	// The reservation is keyed by the idempotency token, so a retried
+	// purchase reuses its reservation instead of counting twice, and is
+	// attributed to the CUDly cloud account the purchase lands in.
+	budgetReq, err := budgetRequest(ctx, req, client, token)
+	if err != nil {
+		return nil, err
+	}
+	result, err := budget.Guard(ctx, budgetReserver, budgetReq,
+		func(ctx context.Context) (common.PurchaseResult, error) {
+			return client.PurchaseCommitment(ctx, rec, opts)
+		})
 	if err != nil {
 		logPurchaseOutcome(rec, token, "", false, err)
 		// Full provider error text surfaces to the caller (feedback:
@@ -28,10 +28,14 @@ import (
 // TestExecutePurchaseRealPurchaseGate is the one test that deliberately
 // overrides this default, and it does so non-parallel (see its doc comment)
 // so no parallel test in this package ever observes a transient override.
+//
+// The commitment budget gate is cleared the same way, with a reserver that
+// admits everything; budget_test.go covers the gate itself.
 func TestMain(m *testing.M) {
 	if err := os.Setenv(EnvEnableRealPurchases, "1"); err != nil {
 		panic(err)
 	}
+	SetBudgetReserver(admitAllReserver{})
 	os.Exit(m.Run())
 }
 
@@ -43,8 +47,17 @@ type fakeServiceClient struct {
 	purchaseResult common.PurchaseResult
 	purchaseErr    error
 	lastOpts       common.PurchaseOptions
+	// offering prices a purchase for the commitment budget; nil serves
+	// testOffering, so tests not about pricing clear the budget's price
+	// check.
+	offering    *common.OfferingDetails
+	offeringErr error
 }
 
+// testOffering is the per-unit offering fakeServiceClient prices a
+// purchase with by default.
+var testOffering = common.OfferingDetails{UpfrontCost: 100, RecurringCost: 0.01, Currency: "USD"}
+
 func (f *fakeServiceClient) GetServiceType() common.ServiceType { return common.ServiceEC2 }
 func (f *fakeServiceClient) GetRegion() string                  { return "us-east-1" }
 func (f *fakeServiceClient) GetRecommendations(_ context.Context, _ *common.RecommendationParams) ([]common.Recommendation, error) {
@@ -63,7 +76,14 @@ func (f *fakeServiceClient) ValidateOffering(_ context.Context, _ common.Recomme
 	return nil
 }
 func (f *fakeServiceClient) GetOfferingDetails(_ context.Context, _ common.Recommendation) (*common.OfferingDetails, error) {
-	return nil, nil
+	if f.offeringErr != nil {
+		return nil, f.offeringErr
+	}
+	if f.offering != nil {
+		return f.offering, nil
+	}
+	offering := testOffering
+	return &offering, nil
 }
 func (f *fakeServiceClient) GetValidResourceTypes(_ context.Context) ([]string, error) {
 	return nil, nil
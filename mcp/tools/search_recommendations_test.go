@@ -66,6 +66,7 @@ type fakeProvider struct {
 	services  []common.ServiceType
 	recClient provider.RecommendationsClient
 	recErr    error
+	accounts  []common.Account
 }
 
 func (f *fakeProvider) Name() string        { return f.name }
@@ -76,7 +77,7 @@ func (f *fakeProvider) GetCredentials() (provider.Credentials, error) {
 }
 func (f *fakeProvider) ValidateCredentials(_ context.Context) error { return nil }
 func (f *fakeProvider) GetAccounts(_ context.Context) ([]common.Account, error) {
-	return nil, nil
+	return f.accounts, nil
 }
 func (f *fakeProvider) GetRegions(_ context.Context) ([]common.Region, error) {
 	return nil, nil
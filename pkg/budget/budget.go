@@ -0,0 +1,276 @@
+// Package budget implements the organisation-wide commitment budget: caps
+// on the upfront spend and on the new hourly commitment CUDly may take on
+// per calendar month or quarter, optionally scoped to an account group.
+//
+// Every purchase path reserves its amounts through a Reserver before the
+// cloud is called, commits the reservation with what was actually bought,
+// and releases it when the purchase fails. The caps themselves are stored
+// and enforced by the Reserver implementation; this package holds the
+// period arithmetic, the cap check and the reserve-call-settle sequence so
+// every path applies them the same way.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+)
+
+// Period is the calendar window a budget's caps apply to.
+type Period string
+
+const (
+	// PeriodMonth caps each calendar month.
+	PeriodMonth Period = "month"
+	// PeriodQuarter caps each calendar quarter (Jan-Mar, Apr-Jun, ...).
+	PeriodQuarter Period = "quarter"
+)
+
+// Valid reports whether p is a supported period.
+func (p Period) Valid() bool {
+	return p == PeriodMonth || p == PeriodQuarter
+}
+
+// Window returns the calendar period of kind p containing t as the
+// half-open UTC interval [start, end). An unsupported p is treated as a
+// month.
+func Window(p Period, t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	month := t.Month()
+	span := 1
+	if p == PeriodQuarter {
+		month = time.Month((int(month)-1)/3*3 + 1)
+		span = 3
+	}
+	start = time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, span, 0)
+}
+
+// Sources of a reservation: the purchase path that made it.
+const (
+	// SourceExecution is a purchase execution run by the purchase manager,
+	// scheduled, approved or direct-executed.
+	SourceExecution = "execution"
+	// SourceLadder is a ladder layer purchase.
+	SourceLadder = "ladder"
+	// SourceMCP is a purchase made through the MCP server's tools.
+	SourceMCP = "mcp"
+	// SourceRevocation is a credit for a purchase that was revoked or
+	// returned after it committed.
+	SourceRevocation = "revocation"
+)
+
+// HoursPerMonth and HoursPerYear convert recurring and term-long amounts
+// to an hourly commitment (730 = 8760 / 12, the factor AWS uses).
+const (
+	HoursPerMonth = 730.0
+	HoursPerYear  = 8760.0
+)
+
+// Request is an amount a purchase path asks to reserve.
+type Request struct {
+	// Source is the purchase path, one of the Source* constants.
+	Source string
+	// Reference identifies the purchase within Source (an execution ID, an
+	// idempotency token). A Source and Reference pair holds at most one
+	// reservation, so a retried purchase reuses its reservation instead of
+	// counting twice.
+	Reference string
+	// Provider is the cloud provider the purchase lands in.
+	Provider string
+	// AccountIDs are the CUDly cloud account IDs the purchase lands in;
+	// empty when they cannot be resolved. An unattributed purchase cannot
+	// be shown to fall outside any account group, so it counts against
+	// every budget, scoped or not.
+	AccountIDs []string
+	// UpfrontUSD is the upfront payment.
+	UpfrontUSD float64
+	// HourlyUSD is the new hourly commitment, see HourlyCommitment.
+	HourlyUSD float64
+}
+
+// Reserver reserves commitment budget ahead of a purchase.
+type Reserver interface {
+	// Reserve atomically checks req against every budget that applies to
+	// it and records the reservation, returning its ID. It returns an
+	// *ExceededError when a cap would be exceeded; nothing is reserved
+	// then.
+	Reserve(ctx context.Context, req Request) (string, error)
+	// Commit settles a reservation at the amounts actually bought, which
+	// may be less than reserved after a partial purchase.
+	Commit(ctx context.Context, id string, upfrontUSD, hourlyUSD float64) error
+	// Release gives a reservation's amounts back, for a purchase that
+	// failed or was revoked.
+	Release(ctx context.Context, id, reason string) error
+}
+
+// Cap is a budget's limits. A nil limit is not capped.
+type Cap struct {
+	Name          string
+	Period        Period
+	MaxUpfrontUSD *float64
+	MaxHourlyUSD  *float64
+}
+
+// Usage is what a budget's period has used so far: committed purchases
+// plus reservations still in flight.
+type Usage struct {
+	UpfrontUSD float64
+	HourlyUSD  float64
+}
+
+// Limit names the cap an ExceededError tripped.
+const (
+	LimitUpfront = "upfront"
+	LimitHourly  = "hourly"
+)
+
+// ExceededError is returned when a purchase would take a budget past one
+// of its caps in the current period.
+type ExceededError struct {
+	Budget      string
+	Period      Period
+	PeriodStart time.Time
+	Limit       string
+	Cap         float64
+	Used        float64
+	Requested   float64
+}
+
+// Error implements the error interface.
+func (e *ExceededError) Error() string {
+	unit := ""
+	if e.Limit == LimitHourly {
+		unit = "/h"
+	}
+	return fmt.Sprintf("commitment budget %q: %s cap of $%.2f%s for the %s starting %s would be exceeded (used $%.2f%s, requested $%.2f%s, remaining $%.2f%s)",
+		e.Budget, e.Limit, e.Cap, unit, e.Period, e.PeriodStart.Format("2006-01-02"),
+		e.Used, unit, e.Requested, unit, Remaining(e.Cap, e.Used), unit)
+}
+
+// Check returns an *ExceededError when adding req to used would take c
+// past either of its caps in the period containing now.
+func Check(c Cap, used Usage, req Request, now time.Time) error {
+	start, _ := Window(c.Period, now)
+	exceeded := func(limit string, capUSD *float64, usedUSD, requested float64) error {
+		if capUSD == nil || requested <= 0 || usedUSD+requested <= *capUSD {
+			return nil
+		}
+		return &ExceededError{
+			Budget: c.Name, Period: c.Period, PeriodStart: start, Limit: limit,
+			Cap: *capUSD, Used: usedUSD, Requested: requested,
+		}
+	}
+	if err := exceeded(LimitUpfront, c.MaxUpfrontUSD, used.UpfrontUSD, req.UpfrontUSD); err != nil {
+		return err
+	}
+	return exceeded(LimitHourly, c.MaxHourlyUSD, used.HourlyUSD, req.HourlyUSD)
+}
+
+// Remaining is what is left of capUSD after used, floored at zero.
+func Remaining(capUSD, used float64) float64 {
+	if used >= capUSD {
+		return 0
+	}
+	return capUSD - used
+}
+
+// HourlyCommitment is the hourly rate a commitment locks in: its upfront
+// payment spread over the term plus its recurring monthly charge. A
+// non-positive term counts the upfront payment as one year's worth.
+func HourlyCommitment(upfrontUSD, monthlyUSD float64, termYears int) float64 {
+	if termYears <= 0 {
+		termYears = 1
+	}
+	return upfrontUSD/(float64(termYears)*HoursPerYear) + monthlyUSD/HoursPerMonth
+}
+
+// FromRecommendation builds the request for buying rec into the CUDly
+// cloud accounts accountIDs. The upfront amount is rec.CommitmentCost; the
+// hourly amount is a Savings Plan's own hourly commitment, or
+// HourlyCommitment of the upfront and recurring charges for everything
+// else.
+func FromRecommendation(source, reference string, accountIDs []string, rec common.Recommendation) Request {
+	req := Request{
+		Source:     source,
+		Reference:  reference,
+		Provider:   string(rec.Provider),
+		AccountIDs: accountIDs,
+		UpfrontUSD: rec.CommitmentCost,
+	}
+	if sp, ok := rec.Details.(*common.SavingsPlanDetails); ok && sp != nil && sp.HourlyCommitment > 0 {
+		req.HourlyUSD = sp.HourlyCommitment
+		return req
+	}
+	monthly := 0.0
+	if rec.RecurringMonthlyCost != nil {
+		monthly = *rec.RecurringMonthlyCost
+	}
+	req.HourlyUSD = HourlyCommitment(rec.CommitmentCost, monthly, termYears(rec.Term))
+	return req
+}
+
+// termYears parses a "<N>yr" term, returning 0 when it does not parse.
+func termYears(term string) int {
+	years, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(term), "yr"))
+	if err != nil {
+		return 0
+	}
+	return years
+}
+
+// Guard runs purchase under a reservation of req: it reserves, calls
+// purchase, then commits the reservation at what was bought (see
+// boughtAmounts) when the purchase succeeded and releases it otherwise. A nil r runs purchase unguarded. A purchase the
+// budget refuses is never called; the *ExceededError is returned. A
+// reservation that cannot be settled is logged rather than failing a
+// purchase that already happened: a commit failure leaves it counted at
+// the amounts reserved, a release failure leaves it counted until an
+// operator releases it.
+func Guard(ctx context.Context, r Reserver, req Request, purchase func(ctx context.Context) (common.PurchaseResult, error)) (common.PurchaseResult, error) {
+	if r == nil {
+		return purchase(ctx)
+	}
+	id, err := r.Reserve(ctx, req)
+	if err != nil {
+		return common.PurchaseResult{}, err
+	}
+	result, err := purchase(ctx)
+	if err != nil || !result.Success {
+		reason := "purchase did not succeed"
+		if err != nil {
+			reason = err.Error()
+		}
+		if relErr := r.Release(ctx, id, reason); relErr != nil {
+			logging.Errorf("budget: failed to release reservation %s (%s/%s): %v", id, req.Source, req.Reference, relErr)
+		}
+		return result, err
+	}
+	upfront, hourly := boughtAmounts(req, result)
+	if commitErr := r.Commit(ctx, id, upfront, hourly); commitErr != nil {
+		logging.Errorf("budget: failed to commit reservation %s (%s/%s): %v", id, req.Source, req.Reference, commitErr)
+	}
+	return result, nil
+}
+
+// boughtAmounts is what a successful purchase of req actually committed.
+// result.Cost, when the provider reports it, is the upfront payment
+// charged; the hourly commitment is scaled by the same ratio to the
+// upfront reserved, so a partial purchase or a price change between the
+// reservation and the purchase settles at the price paid. A result with
+// no cost, or a request with no upfront to scale from, settles at the
+// amounts reserved.
+func boughtAmounts(req Request, result common.PurchaseResult) (upfrontUSD, hourlyUSD float64) {
+	if result.Cost <= 0 {
+		return req.UpfrontUSD, req.HourlyUSD
+	}
+	if req.UpfrontUSD <= 0 {
+		return result.Cost, req.HourlyUSD
+	}
+	return result.Cost, req.HourlyUSD * result.Cost / req.UpfrontUSD
+}
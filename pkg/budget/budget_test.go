@@ -0,0 +1,155 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestWindow(t *testing.T) {
+	at := time.Date(2026, 8, 17, 13, 0, 0, 0, time.UTC)
+
+	start, end := Window(PeriodMonth, at)
+	assert.Equal(t, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), end)
+
+	start, end = Window(PeriodQuarter, at)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), end)
+
+	start, end = Window(PeriodQuarter, time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	c := Cap{Name: "org", Period: PeriodQuarter, MaxUpfrontUSD: ptr(1000), MaxHourlyUSD: ptr(2)}
+
+	require.NoError(t, Check(c, Usage{UpfrontUSD: 400}, Request{UpfrontUSD: 600}, now))
+
+	err := Check(c, Usage{UpfrontUSD: 400}, Request{UpfrontUSD: 601}, now)
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, LimitUpfront, exceeded.Limit)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), exceeded.PeriodStart)
+	assert.Contains(t, err.Error(), "remaining $600.00")
+
+	err = Check(c, Usage{HourlyUSD: 1.5}, Request{HourlyUSD: 1}, now)
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, LimitHourly, exceeded.Limit)
+
+	// An uncapped limit and a zero request never trip.
+	require.NoError(t, Check(Cap{Name: "hourly-only", Period: PeriodMonth, MaxHourlyUSD: ptr(1)}, Usage{UpfrontUSD: 1e9}, Request{UpfrontUSD: 1e9}, now))
+	require.NoError(t, Check(c, Usage{UpfrontUSD: 5000}, Request{}, now))
+}
+
+func TestFromRecommendation(t *testing.T) {
+	monthly := 73.0
+	req := FromRecommendation(SourceMCP, "tok", nil, common.Recommendation{
+		Provider: common.ProviderAWS, Term: "1yr", CommitmentCost: 8760, RecurringMonthlyCost: &monthly,
+	})
+	assert.Equal(t, "aws", req.Provider)
+	assert.Equal(t, 8760.0, req.UpfrontUSD)
+	assert.InDelta(t, 1.1, req.HourlyUSD, 1e-9)
+	assert.Empty(t, req.AccountIDs)
+
+	req = FromRecommendation(SourceLadder, "tok", []string{"acct-1"}, common.Recommendation{
+		Provider: common.ProviderAWS, Term: "3yr", Details: &common.SavingsPlanDetails{HourlyCommitment: 4.5},
+	})
+	assert.Equal(t, 4.5, req.HourlyUSD)
+	assert.Equal(t, []string{"acct-1"}, req.AccountIDs)
+}
+
+type fakeReserver struct {
+	reserveErr error
+	reserved   []Request
+	committed  []string
+	amounts    [][2]float64
+	released   []string
+}
+
+func (f *fakeReserver) Reserve(_ context.Context, req Request) (string, error) {
+	if f.reserveErr != nil {
+		return "", f.reserveErr
+	}
+	f.reserved = append(f.reserved, req)
+	return "res-1", nil
+}
+
+func (f *fakeReserver) Commit(_ context.Context, id string, upfrontUSD, hourlyUSD float64) error {
+	f.committed = append(f.committed, id)
+	f.amounts = append(f.amounts, [2]float64{upfrontUSD, hourlyUSD})
+	return nil
+}
+
+func (f *fakeReserver) Release(_ context.Context, id, _ string) error {
+	f.released = append(f.released, id)
+	return nil
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	req := Request{Source: SourceMCP, Reference: "tok", UpfrontUSD: 10, HourlyUSD: 0.5}
+
+	t.Run("commits a successful purchase", func(t *testing.T) {
+		r := &fakeReserver{}
+		res, err := Guard(ctx, r, req, func(context.Context) (common.PurchaseResult, error) {
+			return common.PurchaseResult{Success: true, CommitmentID: "c-1"}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "c-1", res.CommitmentID)
+		assert.Equal(t, []string{"res-1"}, r.committed)
+		assert.Equal(t, [][2]float64{{10, 0.5}}, r.amounts, "no reported cost settles at the amounts reserved")
+		assert.Empty(t, r.released)
+	})
+
+	t.Run("commits at the cost the provider charged", func(t *testing.T) {
+		r := &fakeReserver{}
+		_, err := Guard(ctx, r, req, func(context.Context) (common.PurchaseResult, error) {
+			return common.PurchaseResult{Success: true, CommitmentID: "c-1", Cost: 4}, nil
+		})
+		require.NoError(t, err)
+		require.Len(t, r.amounts, 1)
+		assert.InDelta(t, 4, r.amounts[0][0], 1e-9)
+		assert.InDelta(t, 0.2, r.amounts[0][1], 1e-9, "the hourly commitment scales with the upfront paid")
+	})
+
+	t.Run("releases a failed purchase", func(t *testing.T) {
+		r := &fakeReserver{}
+		_, err := Guard(ctx, r, req, func(context.Context) (common.PurchaseResult, error) {
+			return common.PurchaseResult{}, errors.New("boom")
+		})
+		require.EqualError(t, err, "boom")
+		assert.Equal(t, []string{"res-1"}, r.released)
+		assert.Empty(t, r.committed)
+	})
+
+	t.Run("never calls the cloud past the cap", func(t *testing.T) {
+		r := &fakeReserver{reserveErr: &ExceededError{Budget: "org", Limit: LimitUpfront}}
+		called := false
+		_, err := Guard(ctx, r, req, func(context.Context) (common.PurchaseResult, error) {
+			called = true
+			return common.PurchaseResult{Success: true}, nil
+		})
+		var exceeded *ExceededError
+		require.ErrorAs(t, err, &exceeded)
+		assert.False(t, called)
+	})
+
+	t.Run("nil reserver runs unguarded", func(t *testing.T) {
+		res, err := Guard(ctx, nil, req, func(context.Context) (common.PurchaseResult, error) {
+			return common.PurchaseResult{Success: true}, nil
+		})
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+	})
+}
@@ -70,7 +70,8 @@ type ServiceType string
 
 const (
 	// Compute
-	ServiceCompute ServiceType = "compute" // EC2, VM, Compute Engine
+	ServiceCompute    ServiceType = "compute"     // EC2, VM, Compute Engine
+	ServiceAppService ServiceType = "app-service" // Azure App Service (Isolated, Premium v3)
 
 	// Database
 	ServiceRelationalDB ServiceType = "relational-db" // RDS, Azure SQL, Cloud SQL
@@ -86,7 +87,8 @@ const (
 	ServiceDataWarehouse ServiceType = "data-warehouse" // Redshift, Synapse, BigQuery
 
 	// Storage
-	ServiceStorage ServiceType = "storage" // S3, Blob Storage, Cloud Storage
+	ServiceStorage      ServiceType = "storage"       // S3, Blob Storage, Cloud Storage
+	ServiceBlockStorage ServiceType = "block-storage" // EBS, Managed Disks, Persistent Disk
 
 	// Savings/Commitments
 	//
@@ -132,6 +134,7 @@ const (
 	ServiceElasticsearch             = ServiceOpenSearch
 	ServiceRedshift      ServiceType = "redshift"
 	ServiceMemoryDB      ServiceType = "memorydb"
+	ServiceDynamoDB      ServiceType = "dynamodb"
 )
 
 // String returns the string representation of the service type
@@ -178,7 +181,7 @@ const (
 	CommitmentReservedInstance CommitmentType = "reserved-instance" // AWS RI, Azure RI
 	CommitmentSavingsPlan      CommitmentType = "savings-plan"      // AWS Savings Plans
 	CommitmentCUD              CommitmentType = "committed-use"     // GCP CUD
-	CommitmentReservedCapacity CommitmentType = "reserved-capacity" // Azure/GCP storage
+	CommitmentReservedCapacity CommitmentType = "reserved-capacity" // Azure/GCP storage, DynamoDB
 )
 
 // String returns the string representation of the commitment type
@@ -221,7 +224,8 @@ type Recommendation struct {
 	// Service-specific details (polymorphic)
 	Details ServiceDetails `json:"details,omitempty" csv:"-"`
 
-	// Metadata
+	// Metadata. SourceRecommendation is empty for provider-API recommendations
+	// and RecommendationSourceNative for pkg/recommender output.
 	SourceRecommendation string    `json:"source_recommendation,omitempty" csv:"SourceRecommendation"`
 	Timestamp            time.Time `json:"timestamp,omitempty" csv:"Timestamp"`
 
@@ -271,6 +275,12 @@ type Recommendation struct {
 	UsageHistory []float64 `json:"usage_history,omitempty" csv:"-"`
 }
 
+// RecommendationSourceNative is the SourceRecommendation value carried by
+// recommendations computed by pkg/recommender from CUDly's own usage analysis,
+// as opposed to a provider recommendation API (Cost Explorer, Azure Advisor,
+// GCP Recommender), which leave SourceRecommendation empty.
+const RecommendationSourceNative = "cudly-native"
+
 // ServiceDetails is an interface for service-specific details
 type ServiceDetails interface {
 	GetServiceType() ServiceType
@@ -339,6 +349,10 @@ type PurchaseResult struct {
 	Cost           float64        `json:"cost"`
 	DryRun         bool           `json:"dry_run"`
 	Timestamp      time.Time      `json:"timestamp"`
+	// EndDate is set when the commitment does not end Term after Timestamp:
+	// an AWS Reserved Instance Marketplace purchase runs for the seller's
+	// remaining term. Nil means the full Term applies.
+	EndDate *time.Time `json:"end_date,omitempty"`
 }
 
 // Source values for PurchaseOptions.Source. Kept lowercase so they can be used
@@ -395,6 +409,12 @@ type PurchaseOptions struct {
 	// defaults to "convertible" to preserve pre-694 behaviour.
 	// Only meaningful for EC2 RI purchases; ignored by other providers.
 	OfferingClass string
+	// StartTime, when set and in the future, asks for the commitment to
+	// start then rather than at purchase: a renewal timed to the expiry of
+	// the commitment it replaces. Only AWS Savings Plans support a queued
+	// start; every other client ignores it, and CUDly defers those purchases
+	// until StartTime instead.
+	StartTime *time.Time
 }
 
 // NormalizeSource lowercases s and returns it when it matches an allowed
@@ -437,7 +457,9 @@ type Commitment struct {
 	StartDate      time.Time      `json:"start_date"`
 	EndDate        time.Time      `json:"end_date"`
 	State          string         `json:"state"`
-	Cost           float64        `json:"cost"`
+	// Cost is the amortized hourly cost in USD for the whole commitment
+	// (all units), where the provider reports pricing; 0 when unknown.
+	Cost float64 `json:"cost"`
 }
 
 // OfferingDetails represents cloud provider offering details
@@ -500,6 +522,11 @@ type ComputeDetails struct {
 	Scope        string  `json:"scope"`               // regional, zonal
 	VCPU         int     `json:"vcpu,omitempty"`      // 0 = unknown
 	MemoryGB     float64 `json:"memory_gb,omitempty"` // 0 = unknown
+	// MarketplaceOfferingID, when set, makes the AWS EC2 client buy this
+	// exact Reserved Instance Marketplace offering (a third-party listing
+	// with whatever term the seller has left) instead of looking up an
+	// AWS-sold offering from the rec's term and payment option.
+	MarketplaceOfferingID string `json:"marketplace_offering_id,omitempty"`
 }
 
 func (d ComputeDetails) GetServiceType() ServiceType {
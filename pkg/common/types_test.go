@@ -39,6 +39,8 @@ func TestServiceType_String(t *testing.T) {
 		{ServiceSearch, "search"},
 		{ServiceDataWarehouse, "data-warehouse"},
 		{ServiceStorage, "storage"},
+		{ServiceBlockStorage, "block-storage"},
+		{ServiceAppService, "app-service"},
 		// Regression guard for issue #85: the frontend persists "savingsplans"
 		// (no hyphen) and the Go constant must match so direct comparisons of
 		// rec.Service == ServiceSavingsPlansAll don't silently miss rows. If you
@@ -56,6 +58,7 @@ func TestServiceType_String(t *testing.T) {
 		{ServiceOpenSearch, "opensearch"},
 		{ServiceRedshift, "redshift"},
 		{ServiceMemoryDB, "memorydb"},
+		{ServiceDynamoDB, "dynamodb"},
 	}
 
 	for _, tt := range tests {
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,6 +24,8 @@ func newFlags() *pflag.FlagSet {
 	fs.Float64("min-savings-pct", 0, "")
 	fs.Int("max-break-even-months", 0, "")
 	fs.Int("min-count", 0, "")
+	fs.Float64("discount-rate", 8, "")
+	fs.String("sort-by", "", "")
 	fs.String("idempotency-window", "24h", "")
 	return fs
 }
@@ -265,3 +268,30 @@ gcp:
 	assert.Equal(t, []string{"proj-a"}, cfg.GCP.Projects)
 	assert.Equal(t, []string{"us-central1"}, cfg.GCP.Regions)
 }
+
+func TestLoad_ScorerFinance(t *testing.T) {
+	path := writeYAML(t, "scorer:\n  sort_by: npv\n  discount_rate_pct: 0\n  usage_drop_pct: 50\n  max_worst_case_loss: 200\n")
+	cfg, err := Load(path, newFlags())
+	require.NoError(t, err)
+	assert.Equal(t, "npv", cfg.Scorer.SortBy)
+	assert.Equal(t, 0.0, cfg.Scorer.Finance.DiscountRatePct, "an explicit 0 overrides the default rate")
+	assert.Equal(t, 50.0, cfg.Scorer.Finance.UsageDropPct)
+	assert.Equal(t, 200.0, cfg.Scorer.MaxWorstCaseLoss)
+
+	t.Setenv("CUDLY_DISCOUNT_RATE_PCT", "12")
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--sort-by", "irr"}))
+	cfg, err = Load(path, flags)
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, cfg.Scorer.Finance.DiscountRatePct)
+	assert.Equal(t, "irr", cfg.Scorer.SortBy)
+
+	_, err = Load(writeYAML(t, "scorer:\n  sort_by: roi\n"), newFlags())
+	assert.ErrorContains(t, err, "invalid sort key")
+}
+
+func TestLoad_ScorerFinanceDefaults(t *testing.T) {
+	cfg, err := Load(writeYAML(t, "dry_run: true\n"), newFlags())
+	require.NoError(t, err)
+	assert.Equal(t, finance.DefaultParams(), cfg.Scorer.Finance)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/LeanerCloud/CUDly/pkg/scorer"
 	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v3"
@@ -32,7 +33,8 @@ func defaults() Config {
 			Listen:    ":8080",
 			APIKeyEnv: "CUDLY_API_KEY", // #nosec G101 -- name of the env var to look up the API key, not a credential value
 		},
-		Azure: AzureConfig{Scope: "shared"},
+		Azure:  AzureConfig{Scope: "shared"},
+		Scorer: DefaultScorerConfig(),
 	}
 }
 
@@ -150,6 +152,27 @@ func applyYAMLScorer(cfg *Config, yc yamlConfig) {
 	if len(yc.Scorer.EnabledServices) > 0 {
 		cfg.Scorer.EnabledServices = yc.Scorer.EnabledServices
 	}
+	if yc.Scorer.SortBy != "" {
+		cfg.Scorer.SortBy = yc.Scorer.SortBy
+	}
+	if yc.Scorer.MinNPV != 0 {
+		cfg.Scorer.MinNPV = yc.Scorer.MinNPV
+	}
+	if yc.Scorer.MinIRRPct != 0 {
+		cfg.Scorer.MinIRRPct = yc.Scorer.MinIRRPct
+	}
+	if yc.Scorer.MaxDiscountedPaybackMonths != 0 {
+		cfg.Scorer.MaxDiscountedPaybackMonths = yc.Scorer.MaxDiscountedPaybackMonths
+	}
+	if yc.Scorer.MaxWorstCaseLoss != 0 {
+		cfg.Scorer.MaxWorstCaseLoss = yc.Scorer.MaxWorstCaseLoss
+	}
+	if yc.Scorer.DiscountRatePct != nil {
+		cfg.Scorer.Finance.DiscountRatePct = *yc.Scorer.DiscountRatePct
+	}
+	if yc.Scorer.UsageDropPct != nil {
+		cfg.Scorer.Finance.UsageDropPct = *yc.Scorer.UsageDropPct
+	}
 }
 
 // applyYAMLCloud merges cloud-specific YAML fields into cfg.
@@ -262,6 +285,13 @@ func applyEnvScorer(cfg *Config) error {
 		}
 		cfg.Scorer.MinCount = n
 	}
+	if v := os.Getenv("CUDLY_DISCOUNT_RATE_PCT"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("CUDLY_DISCOUNT_RATE_PCT: invalid value %q: %w", v, err)
+		}
+		cfg.Scorer.Finance.DiscountRatePct = f
+	}
 	return nil
 }
 
@@ -336,6 +366,20 @@ func applyFlagsScorer(cfg *Config, flags *pflag.FlagSet) error {
 		}
 		cfg.Scorer.MinCount = v
 	}
+	if flags.Changed("discount-rate") {
+		v, err := flags.GetFloat64("discount-rate")
+		if err != nil {
+			return fmt.Errorf("--discount-rate: %w", err)
+		}
+		cfg.Scorer.Finance.DiscountRatePct = v
+	}
+	if flags.Changed("sort-by") {
+		v, err := flags.GetString("sort-by")
+		if err != nil {
+			return fmt.Errorf("--sort-by: %w", err)
+		}
+		cfg.Scorer.SortBy = v
+	}
 	return nil
 }
 
@@ -377,6 +421,9 @@ func validate(cfg Config) error {
 	if cfg.Scorer.MinSavingsPct < 0 {
 		return fmt.Errorf("min_savings_pct must be ≥ 0")
 	}
+	if err := cfg.Scorer.Validate(); err != nil {
+		return fmt.Errorf("scorer: %w", err)
+	}
 	return nil
 }
 
@@ -394,6 +441,7 @@ func splitComma(s string) []string {
 
 // DefaultScorerConfig returns a scorer.Config that can be embedded in a config.Config.
 // Exposed so callers can construct a default Config without importing the scorer package directly.
+// Financial assumptions default to finance.DefaultParams.
 func DefaultScorerConfig() scorer.Config {
-	return scorer.Config{}
+	return scorer.Config{Finance: finance.DefaultParams()}
 }
@@ -67,6 +67,14 @@ type yamlScorer struct {
 	MaxBreakEvenMonths int      `yaml:"max_break_even_months"`
 	MinCount           int      `yaml:"min_count"`
 	EnabledServices    []string `yaml:"enabled_services"`
+
+	SortBy                     string   `yaml:"sort_by"`
+	MinNPV                     float64  `yaml:"min_npv"`
+	MinIRRPct                  float64  `yaml:"min_irr_pct"`
+	MaxDiscountedPaybackMonths float64  `yaml:"max_discounted_payback_months"`
+	MaxWorstCaseLoss           float64  `yaml:"max_worst_case_loss"`
+	DiscountRatePct            *float64 `yaml:"discount_rate_pct"` // pointer: 0 is a valid rate
+	UsageDropPct               *float64 `yaml:"usage_drop_pct"`
 }
 
 type yamlAWS struct {
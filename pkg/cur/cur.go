@@ -0,0 +1,349 @@
+// Package cur normalises AWS Cost and Usage Report line items (CUR 2.0 Data
+// Exports and the legacy CUR) into hourly per-pool usage and per-commitment
+// coverage. It is a pure package: the ingestion job in internal/cur fetches
+// export objects and persists the aggregates, and the Cost Explorer
+// replacements in providers/aws/recommendations read them back through
+// Reader.
+package cur
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Line item types (line_item_line_item_type) the aggregator consumes. Every
+// other type (Tax, Credit, Refund, Fee, SavingsPlanNegation, ...) is skipped.
+const (
+	LineItemUsage                   = "Usage"
+	LineItemDiscountedUsage         = "DiscountedUsage"
+	LineItemSavingsPlanCoveredUsage = "SavingsPlanCoveredUsage"
+	LineItemRIFee                   = "RIFee"
+)
+
+// Commitment types recorded on CommitmentHour.
+const (
+	CommitmentReservation = "reservation"
+	CommitmentSavingsPlan = "savings_plan"
+)
+
+// Product codes (line_item_product_code) of the services CUDly buys
+// reserved capacity for.
+const (
+	ProductEC2         = "AmazonEC2"
+	ProductRDS         = "AmazonRDS"
+	ProductElastiCache = "AmazonElastiCache"
+	ProductOpenSearch  = "AmazonES"
+	ProductRedshift    = "AmazonRedshift"
+	ProductMemoryDB    = "AmazonMemoryDB"
+)
+
+// LineItem is the subset of a CUR row the aggregator needs. Field comments
+// name the CUR 2.0 column; legacy CUR headers map onto the same names (see
+// NormalizeColumn).
+type LineItem struct {
+	UsageStart time.Time // line_item_usage_start_date
+	UsageEnd   time.Time // line_item_usage_end_date
+	AccountID  string    // line_item_usage_account_id
+	Type       string    // line_item_line_item_type
+	// ProductCode is line_item_product_code, e.g. "AmazonEC2".
+	ProductCode string
+	UsageType   string // line_item_usage_type
+	PricingUnit string // pricing_unit
+	Region      string // product_region_code
+	// ResourceType is product_instance_type.
+	ResourceType string
+	// Platform is the operating system for EC2 or the database engine for
+	// RDS (product['operating_system'] / product['database_engine']).
+	Platform string
+	// DeploymentOption is product['deployment_option'] (RDS Single-AZ or
+	// Multi-AZ).
+	DeploymentOption string
+	UsageAmount      float64 // line_item_usage_amount
+	UnblendedCost    float64 // line_item_unblended_cost
+	// ReservationARN and SavingsPlanARN identify the commitment that
+	// covered (DiscountedUsage, SavingsPlanCoveredUsage) or billed (RIFee)
+	// the line.
+	ReservationARN string
+	SavingsPlanARN string
+	// UnusedQuantity is reservation_unused_quantity on RIFee lines: the
+	// reserved hours nothing used.
+	UnusedQuantity float64
+	// EffectiveCost is reservation_effective_cost or
+	// savings_plan_savings_plan_effective_cost on covered lines.
+	EffectiveCost float64
+}
+
+// PoolKey identifies one commitment pool. The same instance type under a
+// different platform or deployment option is a different pool, because a
+// reservation for one does not cover the other.
+type PoolKey struct {
+	AccountID        string
+	ProductCode      string
+	Region           string
+	ResourceType     string
+	Platform         string
+	DeploymentOption string
+}
+
+// PoolHour is one pool's usage in one UTC hour. UsageHours is
+// OnDemandHours + ReservedHours + SavingsPlanHours; OnDemandCost is the
+// unblended cost of the on-demand share.
+type PoolHour struct {
+	PoolKey
+	Hour             time.Time
+	UsageHours       float64
+	OnDemandHours    float64
+	ReservedHours    float64
+	SavingsPlanHours float64
+	OnDemandCost     float64
+}
+
+// CommitmentKey identifies the pool one commitment covered.
+type CommitmentKey struct {
+	AccountID      string
+	CommitmentARN  string
+	CommitmentType string
+	ProductCode    string
+	Region         string
+	ResourceType   string
+}
+
+// CommitmentHour is one commitment's coverage of one pool in one UTC hour.
+// UnusedHours comes from RIFee lines and is only set for reservations.
+type CommitmentHour struct {
+	CommitmentKey
+	Hour          time.Time
+	CoveredHours  float64
+	UnusedHours   float64
+	EffectiveCost float64
+}
+
+// Query scopes a Reader call. Empty ProductCodes / Regions match all; the
+// hour range is [From, To).
+type Query struct {
+	ProductCodes []string
+	Regions      []string
+	From         time.Time
+	To           time.Time
+}
+
+// Reader serves the persisted aggregates. internal/config's PostgresStore
+// stores them (CURPoolHours / CURCommitmentHours); internal/server adapts it
+// to this interface.
+type Reader interface {
+	PoolHours(ctx context.Context, q Query) ([]PoolHour, error)
+	CommitmentHours(ctx context.Context, q Query) ([]CommitmentHour, error)
+}
+
+type poolHourKey struct {
+	PoolKey
+	hour int64
+}
+
+type commitmentHourKey struct {
+	CommitmentKey
+	hour int64
+}
+
+// Aggregator accumulates line items into PoolHours and CommitmentHours.
+// Line items spanning several hours (daily or monthly granularity exports)
+// are spread evenly across the hours they cover. It is not safe for
+// concurrent use.
+type Aggregator struct {
+	pools       map[poolHourKey]*PoolHour
+	commitments map[commitmentHourKey]*CommitmentHour
+}
+
+// NewAggregator returns an empty Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		pools:       make(map[poolHourKey]*PoolHour),
+		commitments: make(map[commitmentHourKey]*CommitmentHour),
+	}
+}
+
+// Add folds one line item into the aggregates and reports whether it was
+// used. Line items that are not instance-hour usage of a pool (storage,
+// data transfer, Spot, taxes, ...) are skipped; malformed ones are an error.
+func (a *Aggregator) Add(li LineItem) (bool, error) {
+	if !isInstanceHours(li) {
+		return false, nil
+	}
+	for _, v := range []float64{li.UsageAmount, li.UnblendedCost, li.UnusedQuantity, li.EffectiveCost} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false, fmt.Errorf("cur: non-finite amount on %s line for %s", li.Type, li.ResourceType)
+		}
+	}
+	if li.UsageStart.IsZero() {
+		return false, fmt.Errorf("cur: %s line for %s has no usage start", li.Type, li.ResourceType)
+	}
+	if li.Type == LineItemRIFee {
+		if li.ReservationARN == "" || li.UnusedQuantity <= 0 {
+			return false, nil
+		}
+		spreadHours(li.UsageStart, li.UsageEnd, func(hour time.Time, share float64) {
+			a.commitment(li, li.ReservationARN, CommitmentReservation, hour).UnusedHours += li.UnusedQuantity * share
+		})
+		return true, nil
+	}
+	if li.UsageAmount <= 0 {
+		return false, nil
+	}
+
+	spreadHours(li.UsageStart, li.UsageEnd, func(hour time.Time, share float64) {
+		hours := li.UsageAmount * share
+		p := a.pool(li, hour)
+		p.UsageHours += hours
+		switch li.Type {
+		case LineItemUsage:
+			p.OnDemandHours += hours
+			p.OnDemandCost += li.UnblendedCost * share
+		case LineItemDiscountedUsage:
+			p.ReservedHours += hours
+			if li.ReservationARN != "" {
+				c := a.commitment(li, li.ReservationARN, CommitmentReservation, hour)
+				c.CoveredHours += hours
+				c.EffectiveCost += li.EffectiveCost * share
+			}
+		case LineItemSavingsPlanCoveredUsage:
+			p.SavingsPlanHours += hours
+			if li.SavingsPlanARN != "" {
+				c := a.commitment(li, li.SavingsPlanARN, CommitmentSavingsPlan, hour)
+				c.CoveredHours += hours
+				c.EffectiveCost += li.EffectiveCost * share
+			}
+		}
+	})
+	return true, nil
+}
+
+// PoolHours returns the pool aggregates ordered by hour, then pool.
+func (a *Aggregator) PoolHours() []PoolHour {
+	out := make([]PoolHour, 0, len(a.pools))
+	for _, p := range a.pools {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Hour.Equal(out[j].Hour) {
+			return out[i].Hour.Before(out[j].Hour)
+		}
+		return poolKeyString(out[i].PoolKey) < poolKeyString(out[j].PoolKey)
+	})
+	return out
+}
+
+// CommitmentHours returns the commitment aggregates ordered by hour, then
+// commitment.
+func (a *Aggregator) CommitmentHours() []CommitmentHour {
+	out := make([]CommitmentHour, 0, len(a.commitments))
+	for _, c := range a.commitments {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Hour.Equal(out[j].Hour) {
+			return out[i].Hour.Before(out[j].Hour)
+		}
+		return commitmentKeyString(out[i].CommitmentKey) < commitmentKeyString(out[j].CommitmentKey)
+	})
+	return out
+}
+
+func (a *Aggregator) pool(li LineItem, hour time.Time) *PoolHour {
+	key := poolHourKey{
+		PoolKey: PoolKey{
+			AccountID:        li.AccountID,
+			ProductCode:      li.ProductCode,
+			Region:           li.Region,
+			ResourceType:     li.ResourceType,
+			Platform:         li.Platform,
+			DeploymentOption: li.DeploymentOption,
+		},
+		hour: hour.Unix(),
+	}
+	p, ok := a.pools[key]
+	if !ok {
+		p = &PoolHour{PoolKey: key.PoolKey, Hour: hour}
+		a.pools[key] = p
+	}
+	return p
+}
+
+func (a *Aggregator) commitment(li LineItem, arn, commitmentType string, hour time.Time) *CommitmentHour {
+	key := commitmentHourKey{
+		CommitmentKey: CommitmentKey{
+			AccountID:      li.AccountID,
+			CommitmentARN:  arn,
+			CommitmentType: commitmentType,
+			ProductCode:    li.ProductCode,
+			Region:         li.Region,
+			ResourceType:   li.ResourceType,
+		},
+		hour: hour.Unix(),
+	}
+	c, ok := a.commitments[key]
+	if !ok {
+		c = &CommitmentHour{CommitmentKey: key.CommitmentKey, Hour: hour}
+		a.commitments[key] = c
+	}
+	return c
+}
+
+// isInstanceHours reports whether li is a line the aggregator consumes:
+// one of the four line item types, for a pool with an instance type, priced
+// in hours, and not Spot.
+func isInstanceHours(li LineItem) bool {
+	switch li.Type {
+	case LineItemUsage, LineItemDiscountedUsage, LineItemSavingsPlanCoveredUsage, LineItemRIFee:
+	default:
+		return false
+	}
+	if li.ResourceType == "" || li.Region == "" {
+		return false
+	}
+	if strings.Contains(li.UsageType, "SpotUsage") {
+		return false
+	}
+	// Older exports leave pricing_unit empty on some lines; only a unit
+	// that is present and not hours disqualifies.
+	switch strings.ToLower(li.PricingUnit) {
+	case "", "hrs", "hours", "hour":
+		return true
+	default:
+		return false
+	}
+}
+
+// spreadHours calls fn for every UTC hour [start, end) overlaps with the
+// fraction of the span that falls in it. An empty or inverted span is
+// attributed wholly to start's hour.
+func spreadHours(start, end time.Time, fn func(hour time.Time, share float64)) {
+	start, end = start.UTC(), end.UTC()
+	first := start.Truncate(time.Hour)
+	if !end.After(start) {
+		fn(first, 1)
+		return
+	}
+	total := end.Sub(start).Seconds()
+	for h := first; h.Before(end); h = h.Add(time.Hour) {
+		lo, hi := h, h.Add(time.Hour)
+		if lo.Before(start) {
+			lo = start
+		}
+		if hi.After(end) {
+			hi = end
+		}
+		fn(h, hi.Sub(lo).Seconds()/total)
+	}
+}
+
+func poolKeyString(k PoolKey) string {
+	return strings.Join([]string{k.AccountID, k.ProductCode, k.Region, k.ResourceType, k.Platform, k.DeploymentOption}, "|")
+}
+
+func commitmentKeyString(k CommitmentKey) string {
+	return strings.Join([]string{k.AccountID, k.CommitmentARN, k.CommitmentType, k.ProductCode, k.Region, k.ResourceType}, "|")
+}
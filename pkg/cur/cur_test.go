@@ -0,0 +1,167 @@
+package cur
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var hour0 = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func ec2Line(lineType string, amount, cost float64) LineItem {
+	return LineItem{
+		UsageStart:    hour0,
+		UsageEnd:      hour0.Add(time.Hour),
+		AccountID:     "111111111111",
+		Type:          lineType,
+		ProductCode:   ProductEC2,
+		UsageType:     "USE1-BoxUsage:m5.large",
+		PricingUnit:   "Hrs",
+		Region:        "us-east-1",
+		ResourceType:  "m5.large",
+		Platform:      "Linux",
+		UsageAmount:   amount,
+		UnblendedCost: cost,
+	}
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestAggregator_SplitsUsageByCoverage(t *testing.T) {
+	a := NewAggregator()
+	reserved := ec2Line(LineItemDiscountedUsage, 2, 0)
+	reserved.ReservationARN = "arn:ri-1"
+	reserved.EffectiveCost = 0.12
+	sp := ec2Line(LineItemSavingsPlanCoveredUsage, 1, 0)
+	sp.SavingsPlanARN = "arn:sp-1"
+	sp.EffectiveCost = 0.07
+	fee := ec2Line(LineItemRIFee, 0, 0)
+	fee.ReservationARN = "arn:ri-1"
+	fee.UnusedQuantity = 0.5
+
+	for _, li := range []LineItem{ec2Line(LineItemUsage, 3, 0.288), reserved, sp, fee} {
+		used, err := a.Add(li)
+		if err != nil || !used {
+			t.Fatalf("Add(%s) = %v, %v; want used", li.Type, used, err)
+		}
+	}
+
+	pools := a.PoolHours()
+	if len(pools) != 1 {
+		t.Fatalf("got %d pool hours, want 1", len(pools))
+	}
+	p := pools[0]
+	if !p.Hour.Equal(hour0) || p.ResourceType != "m5.large" || p.Platform != "Linux" {
+		t.Fatalf("unexpected pool hour %+v", p)
+	}
+	if !approx(p.UsageHours, 6) || !approx(p.OnDemandHours, 3) || !approx(p.ReservedHours, 2) || !approx(p.SavingsPlanHours, 1) {
+		t.Fatalf("usage/od/ri/sp = %v/%v/%v/%v, want 6/3/2/1", p.UsageHours, p.OnDemandHours, p.ReservedHours, p.SavingsPlanHours)
+	}
+	if !approx(p.OnDemandCost, 0.288) {
+		t.Fatalf("on-demand cost = %v, want 0.288", p.OnDemandCost)
+	}
+
+	commitments := a.CommitmentHours()
+	if len(commitments) != 2 {
+		t.Fatalf("got %d commitment hours, want 2", len(commitments))
+	}
+	byARN := map[string]CommitmentHour{}
+	for _, c := range commitments {
+		byARN[c.CommitmentARN] = c
+	}
+	ri := byARN["arn:ri-1"]
+	if ri.CommitmentType != CommitmentReservation || !approx(ri.CoveredHours, 2) || !approx(ri.UnusedHours, 0.5) || !approx(ri.EffectiveCost, 0.12) {
+		t.Fatalf("unexpected reservation hour %+v", ri)
+	}
+	spHour := byARN["arn:sp-1"]
+	if spHour.CommitmentType != CommitmentSavingsPlan || !approx(spHour.CoveredHours, 1) || spHour.UnusedHours != 0 {
+		t.Fatalf("unexpected savings plan hour %+v", spHour)
+	}
+}
+
+func TestAggregator_SpreadsDailyLinesAcrossHours(t *testing.T) {
+	a := NewAggregator()
+	li := ec2Line(LineItemUsage, 48, 4.8)
+	li.UsageStart = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	li.UsageEnd = li.UsageStart.AddDate(0, 0, 1)
+	if _, err := a.Add(li); err != nil {
+		t.Fatal(err)
+	}
+
+	pools := a.PoolHours()
+	if len(pools) != 24 {
+		t.Fatalf("got %d hours, want 24", len(pools))
+	}
+	for i, p := range pools {
+		if !p.Hour.Equal(li.UsageStart.Add(time.Duration(i) * time.Hour)) {
+			t.Fatalf("hour %d = %v, out of order", i, p.Hour)
+		}
+		if !approx(p.UsageHours, 2) || !approx(p.OnDemandCost, 0.2) {
+			t.Fatalf("hour %d = %v hours / %v USD, want 2 / 0.2", i, p.UsageHours, p.OnDemandCost)
+		}
+	}
+}
+
+func TestAggregator_SkipsNonInstanceHours(t *testing.T) {
+	spot := ec2Line(LineItemUsage, 1, 0.03)
+	spot.UsageType = "USE1-SpotUsage:m5.large"
+	storage := ec2Line(LineItemUsage, 100, 10)
+	storage.PricingUnit = "GB-Mo"
+	storage.ResourceType = ""
+	gbHours := ec2Line(LineItemUsage, 1, 0.1)
+	gbHours.PricingUnit = "GB-Hours"
+	tax := ec2Line("Tax", 1, 1)
+	negation := ec2Line("SavingsPlanNegation", 1, -0.1)
+	fullyUsedRI := ec2Line(LineItemRIFee, 0, 10)
+	fullyUsedRI.ReservationARN = "arn:ri-1"
+
+	a := NewAggregator()
+	for _, li := range []LineItem{spot, storage, gbHours, tax, negation, fullyUsedRI} {
+		used, err := a.Add(li)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used {
+			t.Fatalf("%s line (%s, %s) should be skipped", li.Type, li.UsageType, li.PricingUnit)
+		}
+	}
+	if len(a.PoolHours()) != 0 || len(a.CommitmentHours()) != 0 {
+		t.Fatal("skipped lines must not create aggregates")
+	}
+}
+
+func TestAggregator_RejectsMalformedLines(t *testing.T) {
+	nan := ec2Line(LineItemUsage, math.NaN(), 0)
+	if _, err := NewAggregator().Add(nan); err == nil {
+		t.Fatal("expected an error for a NaN usage amount")
+	}
+	noStart := ec2Line(LineItemUsage, 1, 0.1)
+	noStart.UsageStart = time.Time{}
+	if _, err := NewAggregator().Add(noStart); err == nil {
+		t.Fatal("expected an error for a line without a usage start")
+	}
+}
+
+func TestSpreadHours_PartialHours(t *testing.T) {
+	start := hour0.Add(30 * time.Minute)
+	var hours []time.Time
+	var shares []float64
+	spreadHours(start, start.Add(time.Hour), func(h time.Time, share float64) {
+		hours = append(hours, h)
+		shares = append(shares, share)
+	})
+	if len(hours) != 2 || !hours[0].Equal(hour0) || !approx(shares[0], 0.5) || !approx(shares[1], 0.5) {
+		t.Fatalf("got hours %v shares %v, want two half hours from %v", hours, shares, hour0)
+	}
+
+	var n int
+	spreadHours(start, start, func(h time.Time, share float64) {
+		n++
+		if !h.Equal(hour0) || share != 1 {
+			t.Fatalf("empty span attributed %v to %v", share, h)
+		}
+	})
+	if n != 1 {
+		t.Fatalf("empty span called fn %d times, want 1", n)
+	}
+}
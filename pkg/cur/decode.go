@@ -0,0 +1,278 @@
+package cur
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Decoder streams the line items of one export object to fn. r is already
+// decompressed. A Decoder reports a row it cannot parse as an error;
+// returning fn's error stops decoding.
+type Decoder func(r io.Reader, fn func(LineItem) error) error
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{".csv": DecodeCSV, ".parquet": DecodeParquet}
+)
+
+// RegisterDecoder installs a Decoder for a file extension such as
+// ".json". CSV and Parquet are built in; a decoder for another format maps
+// each row to a column map and calls LineItemFromRecord, so column handling
+// stays identical across formats. Registering an extension twice replaces
+// the earlier decoder.
+func RegisterDecoder(ext string, d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(ext)] = d
+}
+
+// Supported reports whether Decode can read an object with this name,
+// so listings can skip manifests and other non-data files.
+func Supported(name string) bool {
+	_, ok := decoderFor(name)
+	return ok
+}
+
+// Decode picks a Decoder by name (".csv", ".csv.gz", ".snappy.parquet", ...),
+// decompresses gzip objects, and streams their line items to fn.
+func Decode(name string, r io.Reader, fn func(LineItem) error) error {
+	d, ok := decoderFor(name)
+	if !ok {
+		return fmt.Errorf("cur: no decoder for %q (built in: .csv, .csv.gz, .parquet; register others with RegisterDecoder)", name)
+	}
+	if isGzip(name) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("cur: %s: %w", name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	if err := d(r, fn); err != nil {
+		return fmt.Errorf("cur: %s: %w", name, err)
+	}
+	return nil
+}
+
+func decoderFor(name string) (Decoder, bool) {
+	base := strings.ToLower(name)
+	if isGzip(base) {
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".gz"), ".gzip")
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[path.Ext(base)]
+	return d, ok
+}
+
+func isGzip(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".gzip")
+}
+
+// DecodeCSV is the built-in Decoder for CUR CSV exports, with either CUR 2.0
+// (line_item_usage_start_date) or legacy (lineItem/UsageStartDate) headers.
+func DecodeCSV(r io.Reader, fn func(LineItem) error) error {
+	return ForEachCSVRecord(r, NormalizeColumn, func(row int, rec map[string]string) error {
+		li, err := LineItemFromRecord(rec)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		return fn(li)
+	})
+}
+
+// ForEachCSVRecord streams the rows of a CSV with a header line to fn, keyed
+// by column(header). row is the 1-based line number for error messages. The
+// map is reused between calls. An empty input has no rows.
+func ForEachCSVRecord(r io.Reader, column func(string) string, fn func(row int, rec map[string]string) error) error {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("reading header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = column(h)
+	}
+	rec := make(map[string]string, len(columns))
+	for row := 2; ; row++ {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		clear(rec)
+		for i, v := range fields {
+			if i < len(columns) {
+				rec[columns[i]] = v
+			}
+		}
+		if err := fn(row, rec); err != nil {
+			return err
+		}
+	}
+}
+
+// NormalizeColumn maps a legacy CUR header onto its CUR 2.0 column name:
+// "lineItem/UsageStartDate" becomes "line_item_usage_start_date" and
+// "reservation/ReservationARN" becomes "reservation_reservation_a_r_n",
+// which is how Data Exports spells it. CUR 2.0 names pass through.
+func NormalizeColumn(h string) string {
+	h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	var b strings.Builder
+	for i, r := range h {
+		switch {
+		case r == '/':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 && h[i-1] != '/' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// columnAliases lists, per LineItem field, the CUR 2.0 column first and then
+// the legacy spellings NormalizeColumn produces for it.
+var columnAliases = map[string][]string{
+	"region":            {"product_region_code", "product_region"},
+	"operating_system":  {"product_operating_system"},
+	"database_engine":   {"product_database_engine"},
+	"deployment_option": {"product_deployment_option"},
+}
+
+// productMapKeys are the CUR 2.0 "product" map entries that replaced the
+// legacy flattened columns.
+var productMapKeys = map[string][]string{
+	"operating_system":  {"operating_system"},
+	"database_engine":   {"database_engine"},
+	"deployment_option": {"deployment_option"},
+	"region":            {"region_code"},
+}
+
+// LineItemFromRecord builds a LineItem from one row keyed by CUR 2.0 column
+// names (see NormalizeColumn). Missing columns read as empty or zero; a
+// present but unparseable date or amount is an error.
+func LineItemFromRecord(rec map[string]string) (LineItem, error) {
+	product := productMap(rec["product"])
+	pick := func(field string) string {
+		for _, col := range columnAliases[field] {
+			if v := rec[col]; v != "" {
+				return v
+			}
+		}
+		for _, k := range productMapKeys[field] {
+			if v := product[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	li := LineItem{
+		AccountID:        rec["line_item_usage_account_id"],
+		Type:             rec["line_item_line_item_type"],
+		ProductCode:      rec["line_item_product_code"],
+		UsageType:        rec["line_item_usage_type"],
+		PricingUnit:      rec["pricing_unit"],
+		Region:           pick("region"),
+		ResourceType:     rec["product_instance_type"],
+		Platform:         pick("operating_system"),
+		DeploymentOption: pick("deployment_option"),
+		ReservationARN:   rec["reservation_reservation_a_r_n"],
+		SavingsPlanARN:   rec["savings_plan_savings_plan_a_r_n"],
+	}
+	if li.ProductCode == ProductRDS {
+		li.Platform = pick("database_engine")
+	}
+	var err error
+	if li.UsageStart, err = parseTime(rec["line_item_usage_start_date"]); err != nil {
+		return LineItem{}, fmt.Errorf("line_item_usage_start_date: %w", err)
+	}
+	if li.UsageEnd, err = parseTime(rec["line_item_usage_end_date"]); err != nil {
+		return LineItem{}, fmt.Errorf("line_item_usage_end_date: %w", err)
+	}
+	for _, f := range []struct {
+		col string
+		dst *float64
+	}{
+		{"line_item_usage_amount", &li.UsageAmount},
+		{"line_item_unblended_cost", &li.UnblendedCost},
+		{"reservation_unused_quantity", &li.UnusedQuantity},
+	} {
+		if *f.dst, err = parseAmount(rec[f.col]); err != nil {
+			return LineItem{}, fmt.Errorf("%s: %w", f.col, err)
+		}
+	}
+	// Both effective-cost columns exist on every row; only the one matching
+	// the line's commitment is meaningful.
+	effectiveCol := "reservation_effective_cost"
+	if li.Type == LineItemSavingsPlanCoveredUsage {
+		effectiveCol = "savings_plan_savings_plan_effective_cost"
+	}
+	if li.EffectiveCost, err = parseAmount(rec[effectiveCol]); err != nil {
+		return LineItem{}, fmt.Errorf("%s: %w", effectiveCol, err)
+	}
+	return li, nil
+}
+
+// productMap decodes the CUR 2.0 "product" column, which CSV exports carry
+// as a JSON object. Anything else (absent, legacy, malformed) reads as empty:
+// the flattened columns are the primary source and the map only fills gaps.
+func productMap(s string) map[string]string {
+	if s == "" || s[0] != '{' {
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// timeLayouts are the timestamp spellings seen in CUR exports: RFC 3339 with
+// or without fractional seconds, and the space-separated form Athena and
+// some CSV writers emit.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", s)
+}
+
+func parseAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
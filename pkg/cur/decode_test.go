@@ -0,0 +1,138 @@
+package cur
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cur2CSV = `line_item_usage_start_date,line_item_usage_end_date,line_item_usage_account_id,line_item_line_item_type,line_item_product_code,line_item_usage_type,pricing_unit,product_region_code,product_instance_type,product,line_item_usage_amount,line_item_unblended_cost,reservation_reservation_a_r_n,reservation_effective_cost,savings_plan_savings_plan_a_r_n,savings_plan_savings_plan_effective_cost
+2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,111111111111,Usage,AmazonEC2,USE1-BoxUsage:m5.large,Hrs,us-east-1,m5.large,"{""operating_system"":""Linux"",""tenancy"":""Shared""}",1.5,0.144,,0,,0
+2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,111111111111,SavingsPlanCoveredUsage,AmazonEC2,USE1-BoxUsage:m5.large,Hrs,us-east-1,m5.large,"{""operating_system"":""Linux""}",1,0.096,,0.5,arn:sp-1,0.061
+`
+
+const legacyCSV = "\ufeff" + `identity/LineItemId,lineItem/UsageStartDate,lineItem/UsageEndDate,lineItem/UsageAccountId,lineItem/LineItemType,lineItem/ProductCode,lineItem/UsageType,pricing/unit,product/region,product/instanceType,product/databaseEngine,product/deploymentOption,lineItem/UsageAmount,lineItem/UnblendedCost,reservation/ReservationARN,reservation/EffectiveCost
+x1,2026-03-01 10:00:00,2026-03-01 11:00:00,222222222222,DiscountedUsage,AmazonRDS,USE1-Multi-AZUsage:db.r5.large,Hrs,us-east-1,db.r5.large,PostgreSQL,Multi-AZ,1,0,arn:ri-rds,0.21
+`
+
+func collect(t *testing.T, name string, r io.Reader) []LineItem {
+	t.Helper()
+	var out []LineItem
+	if err := Decode(name, r, func(li LineItem) error {
+		out = append(out, li)
+		return nil
+	}); err != nil {
+		t.Fatalf("Decode(%s): %v", name, err)
+	}
+	return out
+}
+
+func TestDecode_CUR2CSV(t *testing.T) {
+	items := collect(t, "export-00001.csv", strings.NewReader(cur2CSV))
+	if len(items) != 2 {
+		t.Fatalf("got %d line items, want 2", len(items))
+	}
+	od := items[0]
+	wantStart := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if !od.UsageStart.Equal(wantStart) || od.Type != LineItemUsage || od.ProductCode != ProductEC2 {
+		t.Fatalf("unexpected on-demand line %+v", od)
+	}
+	if od.Region != "us-east-1" || od.ResourceType != "m5.large" || od.Platform != "Linux" {
+		t.Fatalf("pool columns = %q/%q/%q", od.Region, od.ResourceType, od.Platform)
+	}
+	if od.UsageAmount != 1.5 || od.UnblendedCost != 0.144 {
+		t.Fatalf("amounts = %v/%v", od.UsageAmount, od.UnblendedCost)
+	}
+
+	sp := items[1]
+	if sp.SavingsPlanARN != "arn:sp-1" || sp.EffectiveCost != 0.061 {
+		t.Fatalf("savings plan line read effective cost %v for %q; want the savings plan column", sp.EffectiveCost, sp.SavingsPlanARN)
+	}
+}
+
+func TestDecode_LegacyGzipCSV(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(legacyCSV)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	items := collect(t, "cur-1.csv.gz", &buf)
+	if len(items) != 1 {
+		t.Fatalf("got %d line items, want 1", len(items))
+	}
+	li := items[0]
+	if li.AccountID != "222222222222" || li.Type != LineItemDiscountedUsage || li.ProductCode != ProductRDS {
+		t.Fatalf("unexpected line %+v", li)
+	}
+	if li.Platform != "PostgreSQL" || li.DeploymentOption != "Multi-AZ" || li.Region != "us-east-1" {
+		t.Fatalf("pool columns = %q/%q/%q", li.Platform, li.DeploymentOption, li.Region)
+	}
+	if li.ReservationARN != "arn:ri-rds" || li.EffectiveCost != 0.21 {
+		t.Fatalf("reservation = %q/%v", li.ReservationARN, li.EffectiveCost)
+	}
+	if !li.UsageEnd.Equal(time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("usage end = %v", li.UsageEnd)
+	}
+}
+
+func TestDecode_RejectsBadRows(t *testing.T) {
+	bad := "line_item_usage_start_date,line_item_usage_amount\nyesterday,1\n"
+	err := Decode("x.csv", strings.NewReader(bad), func(LineItem) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Fatalf("got %v, want a row 2 error", err)
+	}
+
+	stop := errors.New("stop")
+	err = Decode("x.csv", strings.NewReader(cur2CSV), func(LineItem) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Fatalf("got %v, want the callback's error", err)
+	}
+}
+
+func TestDecode_UnknownFormatAndRegister(t *testing.T) {
+	if !Supported("export.snappy.parquet") {
+		t.Fatal("parquet is built in")
+	}
+	if Supported("manifest.json") {
+		t.Fatal("manifests are not data files")
+	}
+	err := Decode("export.orc", strings.NewReader(""), func(LineItem) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "RegisterDecoder") {
+		t.Fatalf("got %v, want a pointer to RegisterDecoder", err)
+	}
+
+	RegisterDecoder(".orc", func(_ io.Reader, fn func(LineItem) error) error {
+		return fn(LineItem{Type: LineItemUsage})
+	})
+	t.Cleanup(func() {
+		decodersMu.Lock()
+		delete(decoders, ".orc")
+		decodersMu.Unlock()
+	})
+	items := collect(t, "export.orc", strings.NewReader(""))
+	if len(items) != 1 || items[0].Type != LineItemUsage {
+		t.Fatalf("registered decoder not used: %+v", items)
+	}
+}
+
+func TestNormalizeColumn(t *testing.T) {
+	for in, want := range map[string]string{
+		"lineItem/UsageStartDate":    "line_item_usage_start_date",
+		"reservation/ReservationARN": "reservation_reservation_a_r_n",
+		"pricing/unit":               "pricing_unit",
+		"line_item_usage_amount":     "line_item_usage_amount",
+		"\ufeffidentity/LineItemId":  "identity_line_item_id",
+	} {
+		if got := NormalizeColumn(in); got != want {
+			t.Errorf("NormalizeColumn(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package cur
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetBatchSize is how many rows ForEachParquetRecord reads per call.
+const parquetBatchSize = 256
+
+// DecodeParquet is the built-in Decoder for CUR Parquet exports
+// (".parquet", including Data Exports' ".snappy.parquet"). Rows go through
+// LineItemFromRecord exactly as CSV rows do.
+func DecodeParquet(r io.Reader, fn func(LineItem) error) error {
+	return ForEachParquetRecord(r, NormalizeColumn, func(row int, rec map[string]string) error {
+		li, err := LineItemFromRecord(rec)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		return fn(li)
+	})
+}
+
+// ForEachParquetRecord is ForEachCSVRecord for a Parquet file: it streams
+// each row to fn as the string map a CSV export of the same data would
+// produce, keyed by column(name). Timestamps read as RFC 3339, decimals at
+// their scale, and a MAP column (CUR 2.0's "product") as a JSON object. row
+// is 1-based. Parquet needs random access, so r is spooled to a temporary
+// file first rather than held in memory.
+func ForEachParquetRecord(r io.Reader, column func(string) string, fn func(row int, rec map[string]string) error) error {
+	f, err := os.CreateTemp("", "cudly-*.parquet")
+	if err != nil {
+		return fmt.Errorf("spooling parquet: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("spooling parquet: %w", err)
+	}
+	if size == 0 {
+		return nil
+	}
+	pf, err := parquet.OpenFile(f, size)
+	if err != nil {
+		return fmt.Errorf("opening parquet: %w", err)
+	}
+
+	cols := parquetColumns(pf.Schema(), column)
+	rec := make(map[string]string, len(cols))
+	maps := map[string]*parquetMap{}
+	rows := make([]parquet.Row, parquetBatchSize)
+	row := 0
+	for _, rg := range pf.RowGroups() {
+		if err := forEachParquetRow(rg, rows, func(values parquet.Row) error {
+			row++
+			clear(rec)
+			clear(maps)
+			for _, v := range values {
+				c := cols[v.Column()]
+				switch c.mapRole {
+				case "key", "value":
+					m := maps[c.name]
+					if m == nil {
+						m = &parquetMap{}
+						maps[c.name] = m
+					}
+					m.add(c.mapRole, v, c.convert)
+				default:
+					// A repeated non-map column keeps its first value.
+					if _, seen := rec[c.name]; !seen {
+						rec[c.name] = c.convert(v)
+					}
+				}
+			}
+			for name, m := range maps {
+				s, err := m.json()
+				if err != nil {
+					return fmt.Errorf("row %d: %s: %w", row, name, err)
+				}
+				rec[name] = s
+			}
+			return fn(row, rec)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func forEachParquetRow(rg parquet.RowGroup, buf []parquet.Row, fn func(parquet.Row) error) error {
+	rows := rg.Rows()
+	defer rows.Close()
+	for {
+		n, err := rows.ReadRows(buf)
+		for _, r := range buf[:n] {
+			if ferr := fn(r); ferr != nil {
+				return ferr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading parquet rows: %w", err)
+		}
+	}
+}
+
+// parquetColumn is how one leaf column of the file lands in the record.
+type parquetColumn struct {
+	name    string
+	mapRole string // "key" or "value" for the leaves of a MAP column
+	convert func(parquet.Value) string
+}
+
+// parquetColumns plans each leaf column of s, indexed like Value.Column.
+// A leaf under a top-level MAP field is named after that field; any other
+// nested leaf is named by its dotted path.
+func parquetColumns(s *parquet.Schema, column func(string) string) []parquetColumn {
+	paths := s.Columns()
+	cols := make([]parquetColumn, len(paths))
+	for i, p := range paths {
+		leaf, _ := s.Lookup(p...)
+		c := parquetColumn{name: column(strings.Join(p, ".")), convert: parquetConverter(leaf.Node.Type())}
+		if len(p) == 3 && isParquetMap(s, p[0]) && (p[2] == "key" || p[2] == "value") {
+			c.name, c.mapRole = column(p[0]), p[2]
+		}
+		cols[i] = c
+	}
+	return cols
+}
+
+func isParquetMap(s *parquet.Schema, field string) bool {
+	for _, f := range s.Fields() {
+		if f.Name() != field {
+			continue
+		}
+		t := f.Type()
+		if lt := t.LogicalType(); lt != nil {
+			if _, ok := lt.Value.(*format.MapType); ok {
+				return true
+			}
+		}
+		if ct := t.ConvertedType(); ct != nil {
+			return *ct == deprecated.Map || *ct == deprecated.MapKeyValue
+		}
+		return false
+	}
+	return false
+}
+
+// parquetMap gathers the key and value leaves of one MAP column in a row.
+type parquetMap struct {
+	keys, values []parquet.Value
+	convert      func(parquet.Value) string
+}
+
+func (m *parquetMap) add(role string, v parquet.Value, convert func(parquet.Value) string) {
+	if role == "key" {
+		m.keys = append(m.keys, v)
+		return
+	}
+	m.values = append(m.values, v)
+	m.convert = convert
+}
+
+// json renders the map as a JSON object; a null or empty map is "".
+func (m *parquetMap) json() (string, error) {
+	out := make(map[string]string, len(m.keys))
+	for i, k := range m.keys {
+		if k.IsNull() {
+			continue
+		}
+		var v string
+		if i < len(m.values) && m.convert != nil {
+			v = m.convert(m.values[i])
+		}
+		out[string(k.ByteArray())] = v
+	}
+	if len(out) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(out)
+	return string(b), err
+}
+
+// parquetConverter returns how to render values of type t as CSV would.
+func parquetConverter(t parquet.Type) func(parquet.Value) string {
+	var conv func(parquet.Value) string
+	switch {
+	case parquetTimeUnit(t) > 0:
+		unit := parquetTimeUnit(t)
+		conv = func(v parquet.Value) string {
+			return time.Unix(0, v.Int64()*int64(unit)).UTC().Format(time.RFC3339Nano)
+		}
+	case t.Kind() == parquet.Int96:
+		conv = func(v parquet.Value) string {
+			return int96Time(v.Int96()).Format(time.RFC3339Nano)
+		}
+	case parquetDecimalScale(t) >= 0:
+		scale := parquetDecimalScale(t)
+		conv = func(v parquet.Value) string { return decimalString(v, scale) }
+	default:
+		conv = parquetString
+	}
+	return func(v parquet.Value) string {
+		if v.IsNull() {
+			return ""
+		}
+		return conv(v)
+	}
+}
+
+// parquetTimeUnit is the unit of a TIMESTAMP column, or 0 for any other.
+func parquetTimeUnit(t parquet.Type) time.Duration {
+	if t.Kind() != parquet.Int64 {
+		return 0
+	}
+	if lt := t.LogicalType(); lt != nil {
+		if ts, ok := lt.Value.(*format.TimestampType); ok && ts.Unit.Value != nil {
+			return ts.Unit.Value.Duration()
+		}
+	}
+	if ct := t.ConvertedType(); ct != nil {
+		switch *ct {
+		case deprecated.TimestampMillis:
+			return time.Millisecond
+		case deprecated.TimestampMicros:
+			return time.Microsecond
+		}
+	}
+	return 0
+}
+
+// parquetDecimalScale is the scale of a DECIMAL column, or -1 for any other.
+func parquetDecimalScale(t parquet.Type) int {
+	if lt := t.LogicalType(); lt != nil {
+		if d, ok := lt.Value.(*format.DecimalType); ok {
+			return int(d.Scale)
+		}
+	}
+	return -1
+}
+
+// julianUnixEpoch is the Julian day number of 1970-01-01.
+const julianUnixEpoch = 2440588
+
+// int96Time decodes the legacy INT96 timestamp: nanoseconds within the day
+// in the low 64 bits and the Julian day in the high 32.
+func int96Time(i deprecated.Int96) time.Time {
+	nanos := int64(uint64(i[1])<<32 | uint64(i[0]))
+	days := int64(i[2]) - julianUnixEpoch
+	return time.Unix(days*86400, nanos).UTC()
+}
+
+func decimalString(v parquet.Value, scale int) string {
+	var unscaled *big.Int
+	switch v.Kind() {
+	case parquet.Int32:
+		unscaled = big.NewInt(int64(v.Int32()))
+	case parquet.Int64:
+		unscaled = big.NewInt(v.Int64())
+	default:
+		// Big-endian two's complement, per the DECIMAL spec.
+		b := v.ByteArray()
+		unscaled = new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+		}
+	}
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)).FloatString(scale)
+}
+
+func parquetString(v parquet.Value) string {
+	switch v.Kind() {
+	case parquet.Boolean:
+		return strconv.FormatBool(v.Boolean())
+	case parquet.Int32:
+		return strconv.FormatInt(int64(v.Int32()), 10)
+	case parquet.Int64:
+		return strconv.FormatInt(v.Int64(), 10)
+	case parquet.Float:
+		return formatFloat(float64(v.Float()), 32)
+	case parquet.Double:
+		return formatFloat(v.Double(), 64)
+	default:
+		return string(v.ByteArray())
+	}
+}
+
+func formatFloat(f float64, bits int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, bits)
+}
@@ -0,0 +1,87 @@
+package cur
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// cur2ParquetRow mirrors the CUR 2.0 Parquet schema for the columns the
+// decoder reads: timestamps in milliseconds, "product" as a MAP, and one
+// amount stored as a DECIMAL to cover the scaled path.
+type cur2ParquetRow struct {
+	UsageStart     int64             `parquet:"line_item_usage_start_date,timestamp(millisecond)"`
+	UsageEnd       int64             `parquet:"line_item_usage_end_date,timestamp(millisecond)"`
+	AccountID      string            `parquet:"line_item_usage_account_id"`
+	LineItemType   string            `parquet:"line_item_line_item_type"`
+	ProductCode    string            `parquet:"line_item_product_code"`
+	UsageType      string            `parquet:"line_item_usage_type"`
+	RegionCode     string            `parquet:"product_region_code"`
+	InstanceType   string            `parquet:"product_instance_type"`
+	Product        map[string]string `parquet:"product"`
+	UsageAmount    float64           `parquet:"line_item_usage_amount"`
+	UnblendedCost  int64             `parquet:"line_item_unblended_cost,decimal(3:18)"`
+	ReservationARN *string           `parquet:"reservation_reservation_a_r_n,optional"`
+}
+
+func TestDecode_CUR2Parquet(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	arn := "arn:ri-1"
+	rows := []cur2ParquetRow{
+		{
+			UsageStart: start.UnixMilli(), UsageEnd: start.Add(time.Hour).UnixMilli(),
+			AccountID: "111111111111", LineItemType: LineItemUsage, ProductCode: ProductEC2,
+			UsageType: "USE1-BoxUsage:m5.large", RegionCode: "us-east-1", InstanceType: "m5.large",
+			Product:     map[string]string{"operating_system": "Linux", "tenancy": "Shared"},
+			UsageAmount: 1.5, UnblendedCost: 144,
+		},
+		{
+			UsageStart: start.UnixMilli(), UsageEnd: start.Add(time.Hour).UnixMilli(),
+			AccountID: "111111111111", LineItemType: LineItemDiscountedUsage, ProductCode: ProductEC2,
+			InstanceType: "m5.large", Product: map[string]string{"region_code": "eu-west-1"},
+			UsageAmount: 1, ReservationARN: &arn,
+		},
+	}
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	items := collect(t, "data/export-00001.snappy.parquet", &buf)
+	if len(items) != 2 {
+		t.Fatalf("got %d line items, want 2", len(items))
+	}
+	od := items[0]
+	if !od.UsageStart.Equal(start) || !od.UsageEnd.Equal(start.Add(time.Hour)) {
+		t.Fatalf("usage window = %v..%v", od.UsageStart, od.UsageEnd)
+	}
+	if od.Type != LineItemUsage || od.Region != "us-east-1" || od.ResourceType != "m5.large" || od.Platform != "Linux" {
+		t.Fatalf("unexpected on-demand line %+v", od)
+	}
+	if od.UsageAmount != 1.5 || od.UnblendedCost != 0.144 || od.ReservationARN != "" {
+		t.Fatalf("amounts = %v/%v, reservation %q", od.UsageAmount, od.UnblendedCost, od.ReservationARN)
+	}
+
+	ri := items[1]
+	if ri.ReservationARN != arn || ri.Region != "eu-west-1" || ri.Platform != "" {
+		t.Fatalf("region and reservation = %q/%q, platform %q", ri.Region, ri.ReservationARN, ri.Platform)
+	}
+}
+
+func TestForEachParquetRecord_Empty(t *testing.T) {
+	err := ForEachParquetRecord(strings.NewReader(""), NormalizeColumn, func(int, map[string]string) error {
+		t.Fatal("an empty object has no rows")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ForEachParquetRecord(strings.NewReader("not parquet"), NormalizeColumn, func(int, map[string]string) error {
+		return nil
+	}); err == nil {
+		t.Fatal("want an error for a file that is not parquet")
+	}
+}
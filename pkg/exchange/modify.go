@@ -0,0 +1,760 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scope values a Reserved Instance configuration can carry. They match the
+// EC2 API's Scope strings so the provider layer passes them through as-is.
+const (
+	ScopeAvailabilityZone = "Availability Zone"
+	ScopeRegion           = "Region"
+)
+
+// ModificationKind classifies a ModificationPlan by its primary change.
+type ModificationKind string
+
+const (
+	// ModificationSplit turns the footprint into more, smaller instances.
+	ModificationSplit ModificationKind = "split"
+	// ModificationMerge combines several RIs, or turns the footprint into
+	// fewer, larger instances.
+	ModificationMerge ModificationKind = "merge"
+	// ModificationResize changes sizes without changing the instance count.
+	ModificationResize ModificationKind = "resize"
+	// ModificationMoveAZ moves a zonal RI to another Availability Zone.
+	ModificationMoveAZ ModificationKind = "move-az"
+	// ModificationRegionalScope turns a zonal RI into a regional one.
+	ModificationRegionalScope ModificationKind = "regional-scope"
+)
+
+// ModifiableRI describes an active Reserved Instance (standard or
+// convertible) for modification planning. Unlike RIInfo it carries the
+// placement fields ModifyReservedInstances can change.
+type ModifiableRI struct {
+	ID                 string    `json:"reserved_instance_id"`
+	InstanceType       string    `json:"instance_type"`
+	AvailabilityZone   string    `json:"availability_zone,omitempty"`
+	Scope              string    `json:"scope"`
+	ProductDescription string    `json:"product_description"`
+	InstanceTenancy    string    `json:"instance_tenancy"`
+	OfferingClass      string    `json:"offering_class"`
+	End                time.Time `json:"end"`
+	// NormalizationFactor is the AWS normalization factor for the instance
+	// size. Zero falls back to the standard table.
+	NormalizationFactor float64 `json:"normalization_factor"`
+	InstanceCount       int32   `json:"instance_count"`
+}
+
+// InstanceUsage is the number of running instances of one type in one
+// Availability Zone, i.e. the demand Reserved Instances can cover.
+type InstanceUsage struct {
+	InstanceType       string  `json:"instance_type"`
+	AvailabilityZone   string  `json:"availability_zone"`
+	ProductDescription string  `json:"product_description"`
+	InstanceTenancy    string  `json:"instance_tenancy"`
+	Count              float64 `json:"count"`
+}
+
+// ModificationTarget is one ReservedInstancesConfiguration of a
+// modification: an instance type and count placed either in an
+// Availability Zone or regionally.
+type ModificationTarget struct {
+	InstanceType     string `json:"instance_type"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	Scope            string `json:"scope"`
+	InstanceCount    int32  `json:"instance_count"`
+}
+
+// ModificationPlan is a suggested ModifyReservedInstances call. Targets
+// always carry the same normalized footprint as the sources; modifications
+// are free and keep the term, payment option and end date.
+//
+// CurrentCoveredUnits and ProjectedCoveredUnits are the normalized units of
+// running instances the sources cover today and the targets would cover,
+// estimated from the instance snapshot after every other RI has taken its
+// share. UtilizationPercent is the observed (Cost Explorer) utilization of
+// the sources, weighted by footprint.
+type ModificationPlan struct {
+	Kind                  ModificationKind     `json:"kind"`
+	Reason                string               `json:"reason"`
+	SourceRIIDs           []string             `json:"source_ri_ids"`
+	Sources               []ModificationTarget `json:"sources"`
+	Targets               []ModificationTarget `json:"targets"`
+	NormalizedUnits       float64              `json:"normalized_units"`
+	UtilizationPercent    float64              `json:"utilization_percent"`
+	CurrentCoveredUnits   float64              `json:"current_covered_units"`
+	ProjectedCoveredUnits float64              `json:"projected_covered_units"`
+}
+
+// unitsEpsilon absorbs float rounding when comparing normalized units.
+const unitsEpsilon = 1e-9
+
+// demandKey identifies one bucket of running instances.
+type demandKey struct {
+	instanceType string
+	az           string
+	platform     string
+	tenancy      string
+}
+
+// demandPool is the running-instance count per bucket not yet covered by
+// an RI. Coverage functions draw it down as RIs are placed.
+type demandPool map[demandKey]float64
+
+func (p demandPool) clone() demandPool {
+	out := make(demandPool, len(p))
+	for k, v := range p {
+		out[k] = v
+	}
+	return out
+}
+
+// sortedKeys returns the pool's keys in a stable order so coverage (and
+// therefore every plan) is deterministic.
+func (p demandPool) sortedKeys() []demandKey {
+	keys := make([]demandKey, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.az != b.az {
+			return a.az < b.az
+		}
+		if a.instanceType != b.instanceType {
+			return a.instanceType < b.instanceType
+		}
+		if a.platform != b.platform {
+			return a.platform < b.platform
+		}
+		return a.tenancy < b.tenancy
+	})
+	return keys
+}
+
+// normalizePlatform folds RI product descriptions and instance platform
+// details onto one key: "Linux/UNIX (Amazon VPC)" and "Linux/UNIX" match.
+func normalizePlatform(s string) string {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "(Amazon VPC)"))
+	return strings.ToLower(s)
+}
+
+// normalizeTenancy maps the empty tenancy to "default".
+func normalizeTenancy(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+
+// sizeFlexible reports whether AWS allows instance size changes (and
+// applies regional size flexibility) for the platform and tenancy: only
+// Linux/UNIX with default tenancy qualifies.
+func sizeFlexible(platform, tenancy string) bool {
+	return platform == "linux/unix" && tenancy == "default"
+}
+
+// instanceTypeNF returns the normalization factor of a full instance type.
+func instanceTypeNF(instanceType string) float64 {
+	_, size := parseInstanceType(instanceType)
+	return normalizationFactors[size]
+}
+
+func instanceFamily(instanceType string) string {
+	family, _ := parseInstanceType(instanceType)
+	return family
+}
+
+func buildDemandPool(usage []InstanceUsage) demandPool {
+	pool := make(demandPool, len(usage))
+	for _, u := range usage {
+		if u.Count <= 0 || instanceTypeNF(u.InstanceType) == 0 {
+			continue
+		}
+		k := demandKey{
+			instanceType: u.InstanceType,
+			az:           u.AvailabilityZone,
+			platform:     normalizePlatform(u.ProductDescription),
+			tenancy:      normalizeTenancy(u.InstanceTenancy),
+		}
+		pool[k] += u.Count
+	}
+	return pool
+}
+
+// coverTarget draws the demand one configuration covers out of pool and
+// returns the normalized units covered. A zonal configuration only matches
+// its exact instance type in its Availability Zone. A regional one matches
+// any Availability Zone, and, when size flexible, any size of the family
+// (partially, by normalized units, as AWS applies it).
+func coverTarget(pool demandPool, t ModificationTarget, platform, tenancy string) float64 {
+	nf := instanceTypeNF(t.InstanceType)
+	if nf == 0 || t.InstanceCount <= 0 {
+		return 0
+	}
+	if t.Scope != ScopeRegion {
+		k := demandKey{instanceType: t.InstanceType, az: t.AvailabilityZone, platform: platform, tenancy: tenancy}
+		n := math.Min(float64(t.InstanceCount), pool[k])
+		pool[k] -= n
+		return n * nf
+	}
+
+	flexible := sizeFlexible(platform, tenancy)
+	family := instanceFamily(t.InstanceType)
+	remaining := float64(t.InstanceCount) * nf
+	covered := 0.0
+	for _, k := range pool.sortedKeys() {
+		if remaining <= unitsEpsilon {
+			break
+		}
+		if k.platform != platform || k.tenancy != tenancy || pool[k] <= 0 {
+			continue
+		}
+		if flexible && instanceFamily(k.instanceType) != family {
+			continue
+		}
+		if !flexible && k.instanceType != t.InstanceType {
+			continue
+		}
+		kNF := instanceTypeNF(k.instanceType)
+		take := math.Min(remaining, pool[k]*kNF)
+		pool[k] -= take / kNF
+		remaining -= take
+		covered += take
+	}
+	return covered
+}
+
+func coverTargets(pool demandPool, targets []ModificationTarget, platform, tenancy string) float64 {
+	covered := 0.0
+	for _, t := range targets {
+		covered += coverTarget(pool, t, platform, tenancy)
+	}
+	return covered
+}
+
+// configurationOf returns the ModificationTarget describing an RI as it is.
+func configurationOf(ri ModifiableRI) ModificationTarget {
+	t := ModificationTarget{InstanceType: ri.InstanceType, InstanceCount: ri.InstanceCount, Scope: ri.Scope}
+	if ri.Scope == ScopeRegion {
+		return t
+	}
+	t.Scope = ScopeAvailabilityZone
+	t.AvailabilityZone = ri.AvailabilityZone
+	return t
+}
+
+// footprint is the normalized units of a set of configurations.
+func footprint(targets []ModificationTarget) float64 {
+	total := 0.0
+	for _, t := range targets {
+		total += instanceTypeNF(t.InstanceType) * float64(t.InstanceCount)
+	}
+	return total
+}
+
+// candidateGroup is a set of underutilized RIs AWS lets us modify in one
+// request: same family, platform, tenancy, placement, offering class and
+// end hour.
+type candidateGroup struct {
+	key      string
+	platform string
+	tenancy  string
+	family   string
+	az       string
+	regional bool
+	ris      []ModifiableRI
+}
+
+func groupKey(ri ModifiableRI) string {
+	return strings.Join([]string{
+		instanceFamily(ri.InstanceType),
+		normalizePlatform(ri.ProductDescription),
+		normalizeTenancy(ri.InstanceTenancy),
+		ri.Scope,
+		ri.AvailabilityZone,
+		strings.ToLower(ri.OfferingClass),
+		ri.End.UTC().Truncate(time.Hour).Format(time.RFC3339),
+	}, "|")
+}
+
+func groupCandidates(candidates []ModifiableRI) []*candidateGroup {
+	byKey := make(map[string]*candidateGroup)
+	var groups []*candidateGroup
+	for _, ri := range candidates {
+		key := groupKey(ri)
+		g, ok := byKey[key]
+		if !ok {
+			g = &candidateGroup{
+				key:      key,
+				platform: normalizePlatform(ri.ProductDescription),
+				tenancy:  normalizeTenancy(ri.InstanceTenancy),
+				family:   instanceFamily(ri.InstanceType),
+				az:       ri.AvailabilityZone,
+				regional: ri.Scope == ScopeRegion,
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.ris = append(g.ris, ri)
+	}
+	// Zonal groups first: AWS applies zonal RIs before regional ones.
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].regional != groups[j].regional {
+			return !groups[i].regional
+		}
+		return groups[i].key < groups[j].key
+	})
+	return groups
+}
+
+// PlanModifications finds Reserved Instances whose utilization is below
+// threshold (percent, 0–100) and a free ModifyReservedInstances call that
+// would let them cover more of the running instances in usage.
+//
+// RIs at or above the threshold, or without utilization data, keep their
+// placement and take their share of the running instances first (zonal
+// before regional, as AWS applies them), so candidates are only matched
+// against demand nothing else covers. Each underutilized zonal group is
+// then offered, in order of preference:
+//
+//   - the same Availability Zone with its footprint re-split to the sizes
+//     running there (Linux/UNIX default-tenancy only: AWS forbids size
+//     changes elsewhere), which keeps the capacity reservation;
+//   - another Availability Zone, re-split the same way when sizes may
+//     change and as-is otherwise;
+//   - regional scope, which gives up the capacity reservation but matches
+//     any Availability Zone and, for Linux/UNIX, any size in the family.
+//
+// The first option that strictly raises covered units wins. Regional RIs
+// already match every zone and size they can, so they are never planned.
+func PlanModifications(ris []ModifiableRI, usage []InstanceUsage, utilization []UtilizationInfo, threshold float64) []ModificationPlan {
+	utilMap := make(map[string]float64, len(utilization))
+	for _, u := range utilization {
+		utilMap[u.RIID] = u.UtilizationPercent
+	}
+
+	sorted := make([]ModifiableRI, 0, len(ris))
+	for _, ri := range ris {
+		if instanceTypeNF(ri.InstanceType) == 0 || ri.InstanceCount <= 0 {
+			continue
+		}
+		sorted = append(sorted, ri)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		zi, zj := sorted[i].Scope != ScopeRegion, sorted[j].Scope != ScopeRegion
+		if zi != zj {
+			return zi
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	pool := buildDemandPool(usage)
+	var candidates []ModifiableRI
+	for _, ri := range sorted {
+		if util, ok := utilMap[ri.ID]; ok && util < threshold {
+			candidates = append(candidates, ri)
+			continue
+		}
+		coverTarget(pool, configurationOf(ri), normalizePlatform(ri.ProductDescription), normalizeTenancy(ri.InstanceTenancy))
+	}
+
+	var plans []ModificationPlan
+	for _, g := range groupCandidates(candidates) {
+		if plan := planGroup(pool, g, utilMap); plan != nil {
+			plans = append(plans, *plan)
+		}
+	}
+	return plans
+}
+
+// planGroup picks the best option for one candidate group, draws the
+// chosen configuration's coverage out of pool, and returns the plan (nil
+// when no option beats the current placement).
+func planGroup(pool demandPool, g *candidateGroup, utilMap map[string]float64) *ModificationPlan {
+	sources := make([]ModificationTarget, 0, len(g.ris))
+	for _, ri := range g.ris {
+		sources = append(sources, configurationOf(ri))
+	}
+	if g.regional {
+		coverTargets(pool, sources, g.platform, g.tenancy)
+		return nil
+	}
+
+	current := coverTargets(pool.clone(), sources, g.platform, g.tenancy)
+	best, bestCovered := []ModificationTarget(nil), current
+	for _, option := range zonalOptions(pool, g, sources) {
+		if covered := coverTargets(pool.clone(), option, g.platform, g.tenancy); covered > bestCovered+unitsEpsilon {
+			best, bestCovered = option, covered
+		}
+	}
+	if best == nil {
+		coverTargets(pool, sources, g.platform, g.tenancy)
+		return nil
+	}
+	coverTargets(pool, best, g.platform, g.tenancy)
+
+	units := footprint(sources)
+	weightedUtil := 0.0
+	ids := make([]string, 0, len(g.ris))
+	for i, ri := range g.ris {
+		ids = append(ids, ri.ID)
+		weightedUtil += utilMap[ri.ID] * footprint(sources[i:i+1])
+	}
+	util := weightedUtil / units
+
+	return &ModificationPlan{
+		Kind:                  classifyModification(sources, best),
+		SourceRIIDs:           ids,
+		Sources:               sources,
+		Targets:               best,
+		NormalizedUnits:       units,
+		UtilizationPercent:    util,
+		CurrentCoveredUnits:   current,
+		ProjectedCoveredUnits: bestCovered,
+		Reason: fmt.Sprintf(
+			"%s at %.0f%% utilization covers %.1f of %.1f normalized units of running instances; %s would cover %.1f. Modifications are free and keep the term, payment option and end date.",
+			describeConfigurations(sources), util, current, units, describeConfigurations(best), bestCovered,
+		),
+	}
+}
+
+// zonalOptions lists the candidate target configurations for a zonal
+// group in preference order (see PlanModifications).
+func zonalOptions(pool demandPool, g *candidateGroup, sources []ModificationTarget) [][]ModificationTarget {
+	var options [][]ModificationTarget
+	flexible := sizeFlexible(g.platform, g.tenancy)
+
+	if flexible {
+		if fit, ok := fitFootprint(pool, g, sources, g.az); ok {
+			options = append(options, fit)
+		}
+	}
+	for _, az := range demandZones(pool, g) {
+		if az == g.az {
+			continue
+		}
+		if flexible {
+			if fit, ok := fitFootprint(pool, g, sources, az); ok {
+				options = append(options, fit)
+				continue
+			}
+		}
+		moved := make([]ModificationTarget, len(sources))
+		for i, s := range sources {
+			s.AvailabilityZone = az
+			moved[i] = s
+		}
+		options = append(options, moved)
+	}
+
+	regional := make([]ModificationTarget, len(sources))
+	for i, s := range sources {
+		s.Scope = ScopeRegion
+		s.AvailabilityZone = ""
+		regional[i] = s
+	}
+	return append(options, regional)
+}
+
+// demandZones returns, sorted, the Availability Zones with uncovered demand
+// in the group's family, platform and tenancy.
+func demandZones(pool demandPool, g *candidateGroup) []string {
+	seen := make(map[string]bool)
+	var zones []string
+	for _, k := range pool.sortedKeys() {
+		if pool[k] <= 0 || k.platform != g.platform || k.tenancy != g.tenancy || instanceFamily(k.instanceType) != g.family {
+			continue
+		}
+		if !seen[k.az] {
+			seen[k.az] = true
+			zones = append(zones, k.az)
+		}
+	}
+	return zones
+}
+
+// fitFootprint re-splits the group's footprint into the instance sizes
+// running in az (largest first), and expresses whatever demand cannot
+// absorb in the largest sizes that divide it exactly, drawn from the
+// sources and the running sizes. Returns false when the remainder cannot
+// be expressed exactly or the result is the current configuration.
+func fitFootprint(pool demandPool, g *candidateGroup, sources []ModificationTarget, az string) ([]ModificationTarget, bool) {
+	demand := zoneDemand(pool, g, az)
+	allowed := make(map[string]float64, len(sources)+len(demand))
+	for _, s := range sources {
+		allowed[s.InstanceType] = instanceTypeNF(s.InstanceType)
+	}
+	for _, d := range demand {
+		allowed[d.instanceType] = d.nf
+	}
+
+	counts := make(map[string]int32)
+	remaining := footprint(sources)
+	for _, d := range demand {
+		n := math.Min(math.Floor(d.count+unitsEpsilon), math.Floor((remaining+unitsEpsilon)/d.nf))
+		if n <= 0 {
+			continue
+		}
+		counts[d.instanceType] += int32(n)
+		remaining -= n * d.nf
+	}
+	if remaining = fillRemainder(counts, allowed, remaining); remaining > unitsEpsilon {
+		return nil, false
+	}
+
+	targets := make([]ModificationTarget, 0, len(counts))
+	for it, n := range counts {
+		targets = append(targets, ModificationTarget{InstanceType: it, InstanceCount: n, AvailabilityZone: az, Scope: ScopeAvailabilityZone})
+	}
+	sortConfigurations(targets)
+	if sameConfigurations(targets, sources) {
+		return nil, false
+	}
+	return targets, true
+}
+
+// sizedDemand is the uncovered running count of one instance size.
+type sizedDemand struct {
+	instanceType string
+	nf           float64
+	count        float64
+}
+
+// zoneDemand returns the group's uncovered demand in az, largest size
+// first.
+func zoneDemand(pool demandPool, g *candidateGroup, az string) []sizedDemand {
+	var demand []sizedDemand
+	for _, k := range pool.sortedKeys() {
+		if k.az != az || k.platform != g.platform || k.tenancy != g.tenancy || pool[k] <= 0 {
+			continue
+		}
+		if instanceFamily(k.instanceType) != g.family {
+			continue
+		}
+		demand = append(demand, sizedDemand{instanceType: k.instanceType, nf: instanceTypeNF(k.instanceType), count: pool[k]})
+	}
+	sort.SliceStable(demand, func(i, j int) bool { return demand[i].nf > demand[j].nf })
+	return demand
+}
+
+// fillRemainder adds the largest allowed sizes that fit into remaining
+// normalized units to counts and returns what is left over.
+func fillRemainder(counts map[string]int32, allowed map[string]float64, remaining float64) float64 {
+	fill := make([]sizedDemand, 0, len(allowed))
+	for it, nf := range allowed {
+		fill = append(fill, sizedDemand{instanceType: it, nf: nf})
+	}
+	sort.Slice(fill, func(i, j int) bool {
+		if fill[i].nf != fill[j].nf {
+			return fill[i].nf > fill[j].nf
+		}
+		return fill[i].instanceType < fill[j].instanceType
+	})
+	for _, f := range fill {
+		if remaining <= unitsEpsilon {
+			break
+		}
+		if n := math.Floor((remaining + unitsEpsilon) / f.nf); n > 0 {
+			counts[f.instanceType] += int32(n)
+			remaining -= n * f.nf
+		}
+	}
+	return remaining
+}
+
+// sortConfigurations orders configurations largest size first, then by
+// type and zone.
+func sortConfigurations(ts []ModificationTarget) {
+	sort.Slice(ts, func(i, j int) bool {
+		ni, nj := instanceTypeNF(ts[i].InstanceType), instanceTypeNF(ts[j].InstanceType)
+		if ni != nj {
+			return ni > nj
+		}
+		if ts[i].InstanceType != ts[j].InstanceType {
+			return ts[i].InstanceType < ts[j].InstanceType
+		}
+		return ts[i].AvailabilityZone < ts[j].AvailabilityZone
+	})
+}
+
+// configurationCounts sums instance counts per (type, scope, zone).
+func configurationCounts(ts []ModificationTarget) map[ModificationTarget]int32 {
+	out := make(map[ModificationTarget]int32, len(ts))
+	for _, t := range ts {
+		key := t
+		key.InstanceCount = 0
+		out[key] += t.InstanceCount
+	}
+	return out
+}
+
+func sameConfigurations(a, b []ModificationTarget) bool {
+	ca, cb := configurationCounts(a), configurationCounts(b)
+	if len(ca) != len(cb) {
+		return false
+	}
+	for k, v := range ca {
+		if cb[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceCounts sums instance counts per type, ignoring placement.
+func instanceCounts(ts []ModificationTarget) map[string]int32 {
+	out := make(map[string]int32, len(ts))
+	for _, t := range ts {
+		out[t.InstanceType] += t.InstanceCount
+	}
+	return out
+}
+
+// classifyModification names a plan by its primary change: scope, then
+// zone, then sizes.
+func classifyModification(sources, targets []ModificationTarget) ModificationKind {
+	for _, t := range targets {
+		if t.Scope == ScopeRegion {
+			return ModificationRegionalScope
+		}
+	}
+	zones := make(map[string]bool)
+	for _, s := range sources {
+		zones[s.AvailabilityZone] = true
+	}
+	for _, t := range targets {
+		if !zones[t.AvailabilityZone] {
+			return ModificationMoveAZ
+		}
+	}
+	var before, after int32
+	for _, s := range sources {
+		before += s.InstanceCount
+	}
+	for _, t := range targets {
+		after += t.InstanceCount
+	}
+	switch {
+	case after > before:
+		return ModificationSplit
+	case after < before || len(sources) > len(targets):
+		return ModificationMerge
+	default:
+		return ModificationResize
+	}
+}
+
+// describeConfigurations renders configurations for plan reasons, e.g.
+// "2x m5.large (us-east-1a) + 1x m5.xlarge (regional)".
+func describeConfigurations(ts []ModificationTarget) string {
+	parts := make([]string, 0, len(ts))
+	for _, t := range ts {
+		where := t.AvailabilityZone
+		if t.Scope == ScopeRegion {
+			where = "regional"
+		}
+		parts = append(parts, fmt.Sprintf("%dx %s (%s)", t.InstanceCount, t.InstanceType, where))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// ValidateModification checks a modification request against the AWS
+// ModifyReservedInstances rules before it is sent: the sources share an
+// instance family, platform, tenancy and end hour; every target stays in
+// that family and in region; the normalized footprint is conserved; and
+// sizes only change for Linux/UNIX default-tenancy RIs.
+func ValidateModification(region string, sources []ModifiableRI, targets []ModificationTarget) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("at least one source reserved instance is required")
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("at least one target configuration is required")
+	}
+
+	first := sources[0]
+	family := instanceFamily(first.InstanceType)
+	platform := normalizePlatform(first.ProductDescription)
+	tenancy := normalizeTenancy(first.InstanceTenancy)
+	current := make([]ModificationTarget, 0, len(sources))
+	for _, s := range sources {
+		if err := validateModificationSource(first, s); err != nil {
+			return err
+		}
+		current = append(current, configurationOf(s))
+	}
+
+	for i, t := range targets {
+		if err := validateModificationTarget(region, family, t); err != nil {
+			return fmt.Errorf("targets[%d]: %w", i, err)
+		}
+	}
+
+	if have, want := footprint(targets), footprint(current); math.Abs(have-want) > unitsEpsilon {
+		return fmt.Errorf("target configurations total %.2f normalized units but the sources total %.2f; a modification must conserve the footprint", have, want)
+	}
+
+	before, after := instanceCounts(current), instanceCounts(targets)
+	sizesChange := len(before) != len(after)
+	for it, n := range after {
+		if before[it] != n {
+			sizesChange = true
+		}
+	}
+	if sizesChange && !sizeFlexible(platform, tenancy) {
+		return fmt.Errorf("instance sizes can only be changed for Linux/UNIX reserved instances with default tenancy (got %q, %q)", first.ProductDescription, tenancy)
+	}
+	return nil
+}
+
+// validateModificationSource checks s can be modified together with first.
+func validateModificationSource(first, s ModifiableRI) error {
+	family := instanceFamily(first.InstanceType)
+	switch {
+	case instanceTypeNF(s.InstanceType) == 0:
+		return fmt.Errorf("reserved instance %s has unknown instance size %q", s.ID, s.InstanceType)
+	case instanceFamily(s.InstanceType) != family:
+		return fmt.Errorf("reserved instances must share an instance family: %s is %s, expected %s", s.ID, instanceFamily(s.InstanceType), family)
+	case normalizePlatform(s.ProductDescription) != normalizePlatform(first.ProductDescription):
+		return fmt.Errorf("reserved instances must share a platform: %s is %q", s.ID, s.ProductDescription)
+	case normalizeTenancy(s.InstanceTenancy) != normalizeTenancy(first.InstanceTenancy):
+		return fmt.Errorf("reserved instances must share a tenancy: %s is %q", s.ID, s.InstanceTenancy)
+	case !s.End.UTC().Truncate(time.Hour).Equal(first.End.UTC().Truncate(time.Hour)):
+		return fmt.Errorf("reserved instances must end in the same hour: %s ends %s", s.ID, s.End.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func validateModificationTarget(region, family string, t ModificationTarget) error {
+	if t.InstanceCount < 1 {
+		return fmt.Errorf("instance_count must be >= 1, got %d", t.InstanceCount)
+	}
+	if instanceTypeNF(t.InstanceType) == 0 {
+		return fmt.Errorf("unknown instance size %q", t.InstanceType)
+	}
+	if instanceFamily(t.InstanceType) != family {
+		return fmt.Errorf("instance type %s is outside the %s family", t.InstanceType, family)
+	}
+	switch t.Scope {
+	case ScopeRegion:
+		if t.AvailabilityZone != "" {
+			return fmt.Errorf("a regional configuration cannot name an availability zone")
+		}
+	case ScopeAvailabilityZone:
+		if t.AvailabilityZone == "" {
+			return fmt.Errorf("availability_zone is required for a zonal configuration")
+		}
+		if region != "" && !strings.HasPrefix(t.AvailabilityZone, region) {
+			return fmt.Errorf("availability zone %s is not in region %s", t.AvailabilityZone, region)
+		}
+	default:
+		return fmt.Errorf("scope must be %q or %q, got %q", ScopeAvailabilityZone, ScopeRegion, t.Scope)
+	}
+	return nil
+}
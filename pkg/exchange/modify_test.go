@@ -0,0 +1,200 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var modifyTestEnd = time.Date(2027, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func zonalRI(id, instanceType, az string, count int32) ModifiableRI {
+	return ModifiableRI{
+		ID: id, InstanceType: instanceType, InstanceCount: count,
+		AvailabilityZone: az, Scope: ScopeAvailabilityZone,
+		ProductDescription: "Linux/UNIX (Amazon VPC)", InstanceTenancy: "default",
+		OfferingClass: "standard", End: modifyTestEnd,
+	}
+}
+
+func running(instanceType, az string, count float64) InstanceUsage {
+	return InstanceUsage{InstanceType: instanceType, AvailabilityZone: az, ProductDescription: "Linux/UNIX", InstanceTenancy: "default", Count: count}
+}
+
+func TestPlanModifications(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		ris         []ModifiableRI
+		usage       []InstanceUsage
+		utilization []UtilizationInfo
+		wantKinds   []ModificationKind
+		check       func(t *testing.T, plans []ModificationPlan)
+	}{
+		{
+			name:        "healthy RI is left alone",
+			ris:         []ModifiableRI{zonalRI("ri-1", "m5.xlarge", "us-east-1a", 1)},
+			usage:       []InstanceUsage{running("m5.xlarge", "us-east-1a", 1)},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 100}},
+		},
+		{
+			name:  "RI without utilization data is left alone",
+			ris:   []ModifiableRI{zonalRI("ri-1", "m5.xlarge", "us-east-1a", 1)},
+			usage: []InstanceUsage{running("m5.large", "us-east-1a", 2)},
+		},
+		{
+			name:        "xlarge split into two running larges in the same AZ",
+			ris:         []ModifiableRI{zonalRI("ri-1", "m5.xlarge", "us-east-1a", 1)},
+			usage:       []InstanceUsage{running("m5.large", "us-east-1a", 2)},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}},
+			wantKinds:   []ModificationKind{ModificationSplit},
+			check: func(t *testing.T, plans []ModificationPlan) {
+				p := plans[0]
+				assert.Equal(t, []string{"ri-1"}, p.SourceRIIDs)
+				assert.Equal(t, []ModificationTarget{{InstanceType: "m5.large", InstanceCount: 2, AvailabilityZone: "us-east-1a", Scope: ScopeAvailabilityZone}}, p.Targets)
+				assert.Equal(t, 8.0, p.NormalizedUnits)
+				assert.Equal(t, 0.0, p.CurrentCoveredUnits)
+				assert.Equal(t, 8.0, p.ProjectedCoveredUnits)
+				assert.Contains(t, p.Reason, "Modifications are free")
+			},
+		},
+		{
+			name: "two larges merged into a running xlarge",
+			ris: []ModifiableRI{
+				zonalRI("ri-1", "m5.large", "us-east-1a", 1),
+				zonalRI("ri-2", "m5.large", "us-east-1a", 1),
+			},
+			usage: []InstanceUsage{running("m5.xlarge", "us-east-1a", 1)},
+			utilization: []UtilizationInfo{
+				{RIID: "ri-1", UtilizationPercent: 0},
+				{RIID: "ri-2", UtilizationPercent: 0},
+			},
+			wantKinds: []ModificationKind{ModificationMerge},
+			check: func(t *testing.T, plans []ModificationPlan) {
+				assert.ElementsMatch(t, []string{"ri-1", "ri-2"}, plans[0].SourceRIIDs)
+				assert.Equal(t, "m5.xlarge", plans[0].Targets[0].InstanceType)
+				assert.Equal(t, int32(1), plans[0].Targets[0].InstanceCount)
+			},
+		},
+		{
+			name:        "unabsorbed remainder keeps source sizes",
+			ris:         []ModifiableRI{zonalRI("ri-1", "m5.2xlarge", "us-east-1a", 1)},
+			usage:       []InstanceUsage{running("m5.large", "us-east-1a", 1)},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}},
+			wantKinds:   []ModificationKind{ModificationSplit},
+			check: func(t *testing.T, plans []ModificationPlan) {
+				assert.Equal(t, 16.0, footprint(plans[0].Targets))
+				assert.Equal(t, 4.0, plans[0].ProjectedCoveredUnits)
+			},
+		},
+		{
+			name:        "Windows RI moves AZ without resizing",
+			ris:         []ModifiableRI{{ID: "ri-1", InstanceType: "m5.large", InstanceCount: 1, AvailabilityZone: "us-east-1a", Scope: ScopeAvailabilityZone, ProductDescription: "Windows", End: modifyTestEnd}},
+			usage:       []InstanceUsage{{InstanceType: "m5.large", AvailabilityZone: "us-east-1b", ProductDescription: "Windows", Count: 1}},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}},
+			wantKinds:   []ModificationKind{ModificationMoveAZ},
+			check: func(t *testing.T, plans []ModificationPlan) {
+				assert.Equal(t, []ModificationTarget{{InstanceType: "m5.large", InstanceCount: 1, AvailabilityZone: "us-east-1b", Scope: ScopeAvailabilityZone}}, plans[0].Targets)
+			},
+		},
+		{
+			name:        "Windows RI with only other sizes running goes regional",
+			ris:         []ModifiableRI{{ID: "ri-1", InstanceType: "m5.large", InstanceCount: 1, AvailabilityZone: "us-east-1a", Scope: ScopeAvailabilityZone, ProductDescription: "Windows", End: modifyTestEnd}},
+			usage:       []InstanceUsage{{InstanceType: "m5.xlarge", AvailabilityZone: "us-east-1a", ProductDescription: "Windows", Count: 1}},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}},
+		},
+		{
+			name:        "demand already covered by a healthy RI is not double counted",
+			ris:         []ModifiableRI{zonalRI("ri-ok", "m5.large", "us-east-1a", 2), zonalRI("ri-1", "m5.xlarge", "us-east-1a", 1)},
+			usage:       []InstanceUsage{running("m5.large", "us-east-1a", 2)},
+			utilization: []UtilizationInfo{{RIID: "ri-ok", UtilizationPercent: 100}, {RIID: "ri-1", UtilizationPercent: 0}},
+		},
+		{
+			name:        "regional RI is never planned",
+			ris:         []ModifiableRI{{ID: "ri-1", InstanceType: "m5.xlarge", InstanceCount: 1, Scope: ScopeRegion, ProductDescription: "Linux/UNIX", End: modifyTestEnd}},
+			usage:       []InstanceUsage{running("c5.large", "us-east-1a", 2)},
+			utilization: []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			plans := PlanModifications(tt.ris, tt.usage, tt.utilization, 95)
+			kinds := make([]ModificationKind, 0, len(plans))
+			for _, p := range plans {
+				kinds = append(kinds, p.Kind)
+				assert.InDelta(t, footprint(p.Sources), footprint(p.Targets), 1e-9, "footprint must be conserved")
+			}
+			if len(tt.wantKinds) == 0 {
+				assert.Empty(t, plans)
+				return
+			}
+			require.Equal(t, tt.wantKinds, kinds)
+			if tt.check != nil {
+				tt.check(t, plans)
+			}
+		})
+	}
+}
+
+func TestPlanModifications_RegionalScopeFallback(t *testing.T) {
+	t.Parallel()
+	// Two Windows m5.large RIs with Windows m5.large demand split across two
+	// other zones: moving to either zone covers one instance, regional scope
+	// covers both.
+	ris := []ModifiableRI{{ID: "ri-1", InstanceType: "m5.large", InstanceCount: 2, AvailabilityZone: "us-east-1a", Scope: ScopeAvailabilityZone, ProductDescription: "Windows", End: modifyTestEnd}}
+	usage := []InstanceUsage{
+		{InstanceType: "m5.large", AvailabilityZone: "us-east-1b", ProductDescription: "Windows", Count: 1},
+		{InstanceType: "m5.large", AvailabilityZone: "us-east-1c", ProductDescription: "Windows", Count: 1},
+	}
+	plans := PlanModifications(ris, usage, []UtilizationInfo{{RIID: "ri-1", UtilizationPercent: 0}}, 95)
+	require.Len(t, plans, 1)
+	assert.Equal(t, ModificationRegionalScope, plans[0].Kind)
+	assert.Equal(t, 8.0, plans[0].ProjectedCoveredUnits, "regional scope covers both zones")
+}
+
+func TestValidateModification(t *testing.T) {
+	t.Parallel()
+	src := []ModifiableRI{zonalRI("ri-1", "m5.xlarge", "us-east-1a", 1)}
+	zonal := func(it, az string, n int32) ModificationTarget {
+		return ModificationTarget{InstanceType: it, InstanceCount: n, AvailabilityZone: az, Scope: ScopeAvailabilityZone}
+	}
+	windows := zonalRI("ri-w", "m5.xlarge", "us-east-1a", 1)
+	windows.ProductDescription = "Windows"
+	otherEnd := zonalRI("ri-2", "m5.xlarge", "us-east-1a", 1)
+	otherEnd.End = modifyTestEnd.Add(48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		sources []ModifiableRI
+		targets []ModificationTarget
+		wantErr string
+	}{
+		{name: "valid split", sources: src, targets: []ModificationTarget{zonal("m5.large", "us-east-1b", 2)}},
+		{name: "valid regional", sources: src, targets: []ModificationTarget{{InstanceType: "m5.xlarge", InstanceCount: 1, Scope: ScopeRegion}}},
+		{name: "no sources", targets: []ModificationTarget{zonal("m5.large", "us-east-1a", 2)}, wantErr: "source reserved instance"},
+		{name: "no targets", sources: src, wantErr: "target configuration"},
+		{name: "footprint changes", sources: src, targets: []ModificationTarget{zonal("m5.large", "us-east-1a", 3)}, wantErr: "conserve the footprint"},
+		{name: "family changes", sources: src, targets: []ModificationTarget{zonal("c5.xlarge", "us-east-1a", 1)}, wantErr: "outside the m5 family"},
+		{name: "zone outside region", sources: src, targets: []ModificationTarget{zonal("m5.xlarge", "us-west-2a", 1)}, wantErr: "not in region"},
+		{name: "zero count", sources: src, targets: []ModificationTarget{zonal("m5.xlarge", "us-east-1a", 0)}, wantErr: "instance_count"},
+		{name: "regional with zone", sources: src, targets: []ModificationTarget{{InstanceType: "m5.xlarge", InstanceCount: 1, Scope: ScopeRegion, AvailabilityZone: "us-east-1a"}}, wantErr: "regional configuration"},
+		{name: "bad scope", sources: src, targets: []ModificationTarget{{InstanceType: "m5.xlarge", InstanceCount: 1, Scope: "Zone"}}, wantErr: "scope must be"},
+		{name: "windows resize", sources: []ModifiableRI{windows}, targets: []ModificationTarget{zonal("m5.large", "us-east-1a", 2)}, wantErr: "Linux/UNIX"},
+		{name: "windows move", sources: []ModifiableRI{windows}, targets: []ModificationTarget{zonal("m5.xlarge", "us-east-1b", 1)}},
+		{name: "different end hour", sources: []ModifiableRI{src[0], otherEnd}, targets: []ModificationTarget{zonal("m5.2xlarge", "us-east-1a", 1)}, wantErr: "same hour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateModification("us-east-1", tt.sources, tt.targets)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
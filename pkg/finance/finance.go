@@ -0,0 +1,209 @@
+// Package finance evaluates commitment recommendations as investments.
+// SavingsPercentage and BreakEvenMonths ignore the time value of money, so
+// they cannot tell whether paying all-upfront beats no-upfront, or a 3-year
+// term a 1-year one, for a company with a given cost of capital. Evaluate
+// discounts the monthly cash flows of one recommendation (one term and
+// payment variant) and reports NPV, IRR, discounted payback and the
+// worst-case loss if usage drops.
+//
+// Like pkg/scorer it is a pure function package and must not import
+// pkg/config.
+package finance
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// DefaultDiscountRatePct is the annual cost of capital used when none is
+// configured.
+const DefaultDiscountRatePct = 8.0
+
+// DefaultUsageDropPct is the usage drop the worst case assumes when none
+// is configured.
+const DefaultUsageDropPct = 30.0
+
+// Params holds the financial assumptions. Both fields are percentages.
+type Params struct {
+	// DiscountRatePct is the annual cost of capital the cash flows are
+	// discounted at. 0 means money has no time value.
+	DiscountRatePct float64
+	// UsageDropPct is how far usage falls in the worst case: the
+	// commitment's fees stay, the on-demand spend it avoids shrinks.
+	UsageDropPct float64
+}
+
+// DefaultParams returns the default assumptions.
+func DefaultParams() Params {
+	return Params{DiscountRatePct: DefaultDiscountRatePct, UsageDropPct: DefaultUsageDropPct}
+}
+
+// Validate rejects rates outside [0, 100].
+func (p Params) Validate() error {
+	if math.IsNaN(p.DiscountRatePct) || p.DiscountRatePct < 0 || p.DiscountRatePct > 100 {
+		return fmt.Errorf("discount rate must be between 0 and 100 percent, got: %v", p.DiscountRatePct)
+	}
+	if math.IsNaN(p.UsageDropPct) || p.UsageDropPct < 0 || p.UsageDropPct > 100 {
+		return fmt.Errorf("usage drop must be between 0 and 100 percent, got: %v", p.UsageDropPct)
+	}
+	return nil
+}
+
+// Evaluation is the financial view of one recommendation variant.
+type Evaluation struct {
+	Term          string  `json:"term"`
+	PaymentOption string  `json:"payment_option"`
+	Upfront       float64 `json:"upfront"`
+	// MonthlyBenefit is the on-demand spend avoided each month minus the
+	// commitment's recurring fee: the cash flow the upfront payment buys.
+	MonthlyBenefit float64 `json:"monthly_benefit"`
+	NPV            float64 `json:"npv"`
+	// IRRPct is the annualised internal rate of return. nil when there is
+	// no upfront outlay (the return is unbounded) or no positive benefit.
+	IRRPct *float64 `json:"irr_pct,omitempty"`
+	// DiscountedPaybackMonths is when the discounted benefits first repay
+	// the upfront payment. nil when that never happens within the term.
+	DiscountedPaybackMonths *float64 `json:"discounted_payback_months,omitempty"`
+	// WorstCaseNPV is the NPV if usage drops by UsageDropPct for the whole
+	// term; WorstCaseLoss is its shortfall below zero (0 when the
+	// commitment still pays off).
+	WorstCaseNPV    float64 `json:"worst_case_npv"`
+	WorstCaseLoss   float64 `json:"worst_case_loss"`
+	DiscountRatePct float64 `json:"discount_rate_pct"`
+	UsageDropPct    float64 `json:"usage_drop_pct"`
+}
+
+// Evaluate computes the Evaluation of rec under p.
+//
+// The upfront payment is made at month 0 and the benefit arrives at the
+// end of each month of the term. The benefit is OnDemandCost minus
+// RecurringMonthlyCost when the provider reported both; otherwise it is
+// EstimatedSavings plus the amortised upfront, since providers report
+// savings net of the amortised upfront. The worst case scales the avoided
+// on-demand spend by (1 - UsageDropPct/100); without an on-demand baseline
+// that spend is taken to be the benefit plus the recurring fee.
+func Evaluate(rec common.Recommendation, p Params) (Evaluation, error) {
+	if err := p.Validate(); err != nil {
+		return Evaluation{}, err
+	}
+	months, err := termMonths(rec.Term)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	upfront := rec.CommitmentCost
+	var recurring float64
+	if rec.RecurringMonthlyCost != nil {
+		recurring = *rec.RecurringMonthlyCost
+	}
+	onDemand := rec.OnDemandCost
+	var benefit float64
+	if onDemand > 0 && rec.RecurringMonthlyCost != nil {
+		benefit = onDemand - recurring
+	} else {
+		benefit = rec.EstimatedSavings + upfront/float64(months)
+		if onDemand <= 0 {
+			onDemand = benefit + recurring
+		}
+	}
+
+	rate := monthlyRate(p.DiscountRatePct)
+	ev := Evaluation{
+		Term:            rec.Term,
+		PaymentOption:   rec.PaymentOption,
+		Upfront:         upfront,
+		MonthlyBenefit:  benefit,
+		NPV:             npv(upfront, benefit, months, rate),
+		IRRPct:          irr(upfront, benefit, months),
+		DiscountRatePct: p.DiscountRatePct,
+		UsageDropPct:    p.UsageDropPct,
+	}
+	if payback, ok := discountedPayback(upfront, benefit, months, rate); ok {
+		ev.DiscountedPaybackMonths = &payback
+	}
+	worstBenefit := benefit - onDemand*p.UsageDropPct/100
+	ev.WorstCaseNPV = npv(upfront, worstBenefit, months, rate)
+	ev.WorstCaseLoss = math.Max(0, -ev.WorstCaseNPV)
+	return ev, nil
+}
+
+// monthlyRate converts an annual percentage rate to the equivalent
+// monthly compound rate.
+func monthlyRate(annualPct float64) float64 {
+	return math.Pow(1+annualPct/100, 1.0/12) - 1
+}
+
+// npv is -upfront plus benefit received at the end of each of months
+// months, discounted at the monthly rate.
+func npv(upfront, benefit float64, months int, rate float64) float64 {
+	return -upfront + benefit*annuity(months, rate)
+}
+
+// annuity is the present value of 1 paid at the end of each of n periods.
+func annuity(n int, rate float64) float64 {
+	if rate == 0 {
+		return float64(n)
+	}
+	// expm1/log1p keep precision for rates near zero, where irr's
+	// bisection converges when the commitment only breaks even.
+	return -math.Expm1(-float64(n)*math.Log1p(rate)) / rate
+}
+
+// irr finds the monthly rate at which npv is zero by bisection and
+// annualises it. npv falls monotonically in the rate for one outlay
+// followed by equal positive inflows, so the root is unique.
+func irr(upfront, benefit float64, months int) *float64 {
+	if upfront <= 0 || benefit <= 0 {
+		return nil
+	}
+	lo, hi := -0.9999, 1.0
+	for npv(upfront, benefit, months, hi) > 0 {
+		hi *= 2
+		if hi > 1e6 {
+			return nil
+		}
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if npv(upfront, benefit, months, mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	annual := (math.Pow(1+(lo+hi)/2, 12) - 1) * 100
+	return &annual
+}
+
+// discountedPayback returns the fractional month at which the discounted
+// benefits first add up to the upfront payment.
+func discountedPayback(upfront, benefit float64, months int, rate float64) (float64, bool) {
+	if upfront <= 0 {
+		return 0, true
+	}
+	var cum float64
+	for k := 1; k <= months; k++ {
+		step := benefit / math.Pow(1+rate, float64(k))
+		if step <= 0 {
+			return 0, false
+		}
+		if cum+step >= upfront {
+			return float64(k-1) + (upfront-cum)/step, true
+		}
+		cum += step
+	}
+	return 0, false
+}
+
+// termMonths parses a "1yr" / "3yr" term.
+func termMonths(term string) (int, error) {
+	years, err := strconv.Atoi(strings.TrimSuffix(term, "yr"))
+	if err != nil || years <= 0 {
+		return 0, fmt.Errorf("invalid term %q", term)
+	}
+	return years * 12, nil
+}
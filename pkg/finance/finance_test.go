@@ -0,0 +1,114 @@
+package finance
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func rec(term string, upfront, onDemand float64, recurring *float64, savings float64) common.Recommendation {
+	return common.Recommendation{
+		Service: common.ServiceEC2, Term: term, PaymentOption: "partial-upfront",
+		CommitmentCost: upfront, OnDemandCost: onDemand, RecurringMonthlyCost: recurring, EstimatedSavings: savings,
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func near(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6 {
+		t.Fatalf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestEvaluate_ZeroRateBreaksEvenAtTermEnd(t *testing.T) {
+	ev, err := Evaluate(rec("1yr", 1200, 100, ptr(0), 0), Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near(t, "benefit", ev.MonthlyBenefit, 100)
+	near(t, "npv", ev.NPV, 0)
+	if ev.IRRPct == nil || ev.DiscountedPaybackMonths == nil {
+		t.Fatalf("evaluation = %+v", ev)
+	}
+	near(t, "irr", *ev.IRRPct, 0)
+	near(t, "payback", *ev.DiscountedPaybackMonths, 12)
+}
+
+func TestEvaluate_IRRMatchesKnownAnnuity(t *testing.T) {
+	// 1000 upfront repaid by 12 equal payments at 1% a month.
+	benefit := 1000 / annuity(12, 0.01)
+	ev, err := Evaluate(rec("1yr", 1000, benefit, ptr(0), 0), Params{DiscountRatePct: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near(t, "irr", *ev.IRRPct, (math.Pow(1.01, 12)-1)*100)
+	if ev.NPV <= 0 {
+		t.Fatalf("a 12.7%% return discounted at 8%% must have a positive NPV, got %v", ev.NPV)
+	}
+}
+
+func TestEvaluate_NoUpfrontHasNoIRRAndPaysBackAtOnce(t *testing.T) {
+	ev, err := Evaluate(rec("3yr", 0, 100, ptr(70), 30), Params{DiscountRatePct: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near(t, "npv", ev.NPV, 30*annuity(36, monthlyRate(12)))
+	if ev.IRRPct != nil || ev.DiscountedPaybackMonths == nil || *ev.DiscountedPaybackMonths != 0 {
+		t.Fatalf("evaluation = %+v", ev)
+	}
+}
+
+func TestEvaluate_HigherRateFavoursLessUpfront(t *testing.T) {
+	// All-upfront saves more in total, no-upfront keeps the cash: at a
+	// high enough cost of capital no-upfront wins.
+	allUpfront := rec("3yr", 2700, 100, ptr(0), 0)
+	noUpfront := rec("3yr", 0, 100, ptr(82), 0)
+	for _, tc := range []struct {
+		rate       float64
+		allUpfront bool
+	}{{0, true}, {30, false}} {
+		a, _ := Evaluate(allUpfront, Params{DiscountRatePct: tc.rate})
+		n, _ := Evaluate(noUpfront, Params{DiscountRatePct: tc.rate})
+		if (a.NPV > n.NPV) != tc.allUpfront {
+			t.Fatalf("rate %v%%: all-upfront NPV %v, no-upfront NPV %v", tc.rate, a.NPV, n.NPV)
+		}
+	}
+}
+
+func TestEvaluate_WorstCaseLoss(t *testing.T) {
+	ev, err := Evaluate(rec("1yr", 700, 100, ptr(0), 0), Params{UsageDropPct: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near(t, "worst npv", ev.WorstCaseNPV, -100)
+	near(t, "worst loss", ev.WorstCaseLoss, 100)
+	if ev.DiscountedPaybackMonths == nil {
+		t.Fatal("the expected case pays back")
+	}
+}
+
+func TestEvaluate_FallsBackToSavingsWithoutBaseline(t *testing.T) {
+	ev, err := Evaluate(rec("1yr", 240, 0, nil, 40), Params{UsageDropPct: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near(t, "benefit", ev.MonthlyBenefit, 60)
+	// All usage gone: the whole upfront is lost.
+	near(t, "worst loss", ev.WorstCaseLoss, 240)
+}
+
+func TestEvaluate_Rejects(t *testing.T) {
+	if _, err := Evaluate(rec("", 0, 100, ptr(70), 30), DefaultParams()); err == nil || !strings.Contains(err.Error(), "invalid term") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := Evaluate(rec("1yr", 0, 100, ptr(70), 30), Params{DiscountRatePct: -1}); err == nil {
+		t.Fatal("negative discount rate accepted")
+	}
+	if _, err := Evaluate(rec("1yr", 0, 100, ptr(70), 30), Params{UsageDropPct: 101}); err == nil {
+		t.Fatal("usage drop above 100% accepted")
+	}
+}
@@ -0,0 +1,316 @@
+package focus
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// BillingCurrency is the currency every CUDly amount is recorded in.
+const BillingCurrency = "USD"
+
+// Row is one exported FOCUS row. Fields map 1:1 onto Columns; the x_
+// fields are CUDly extensions.
+type Row struct {
+	BillingAccountID           string
+	SubAccountID               string
+	ProviderName               string
+	BillingPeriodStart         time.Time
+	BillingPeriodEnd           time.Time
+	ChargePeriodStart          time.Time
+	ChargePeriodEnd            time.Time
+	ChargeCategory             string
+	ChargeFrequency            string
+	ChargeDescription          string
+	PricingCategory            string
+	BilledCost                 float64
+	EffectiveCost              float64
+	ListCost                   float64
+	ContractedCost             float64
+	RegionID                   string
+	ServiceName                string
+	ServiceCategory            string
+	ResourceType               string
+	CommitmentDiscountID       string
+	CommitmentDiscountCategory string
+	CommitmentDiscountType     string
+	CommitmentDiscountStatus   string
+	CommitmentDiscountQuantity float64
+	CommitmentDiscountUnit     string
+	XTermYears                 int
+	XPaymentOption             string
+	XMonthlySavings            float64
+}
+
+// Columns is the exported header, in Row field order.
+var Columns = []string{
+	ColBillingAccountID, ColSubAccountID, ColProviderName, ColPublisherName, ColInvoiceIssuerName,
+	ColBillingCurrency, ColBillingPeriodFrom, ColBillingPeriodTo, ColChargePeriodStart, ColChargePeriodEnd,
+	ColChargeCategory, ColChargeFrequency, ColChargeDescription, ColPricingCategory,
+	ColBilledCost, ColEffectiveCost, ColListCost, ColContractedCost,
+	ColRegionID, ColServiceName, ColServiceCategory, ColResourceType,
+	ColCommitmentDiscountID, ColCommitmentDiscountName, ColCommitmentDiscountCategory, ColCommitmentDiscountType,
+	ColCommitmentDiscountStatus, ColCommitmentDiscountQuantity, ColCommitmentDiscountUnit,
+	"x_TermYears", "x_PaymentOption", "x_MonthlySavings",
+}
+
+// Record renders r in Columns order. Datetimes are ISO 8601 UTC; a zero
+// time, an empty string and a zero optional quantity are written empty
+// (FOCUS null).
+func (r Row) Record() []string {
+	ts := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	optional := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return amount(v)
+	}
+	term := ""
+	if r.XTermYears > 0 {
+		term = strconv.Itoa(r.XTermYears)
+	}
+	return []string{
+		r.BillingAccountID, r.SubAccountID, r.ProviderName, r.ProviderName, r.ProviderName,
+		BillingCurrency, ts(r.BillingPeriodStart), ts(r.BillingPeriodEnd), ts(r.ChargePeriodStart), ts(r.ChargePeriodEnd),
+		r.ChargeCategory, r.ChargeFrequency, r.ChargeDescription, r.PricingCategory,
+		amount(r.BilledCost), amount(r.EffectiveCost), amount(r.ListCost), amount(r.ContractedCost),
+		r.RegionID, r.ServiceName, r.ServiceCategory, r.ResourceType,
+		r.CommitmentDiscountID, r.CommitmentDiscountID, r.CommitmentDiscountCategory, r.CommitmentDiscountType,
+		r.CommitmentDiscountStatus, optional(r.CommitmentDiscountQuantity), r.CommitmentDiscountUnit,
+		term, r.XPaymentOption, optional(r.XMonthlySavings),
+	}
+}
+
+// WriteCSV writes the header and rows as a FOCUS CSV dataset.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return fmt.Errorf("focus: writing header: %w", err)
+	}
+	for i := range rows {
+		if err := cw.Write(rows[i].Record()); err != nil {
+			return fmt.Errorf("focus: writing row %d: %w", i+1, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Commitment is a purchased commitment as CUDly records it in
+// purchase_history.
+type Commitment struct {
+	Provider common.ProviderType
+	// AccountID is the provider account, subscription or project.
+	AccountID string
+	// ID is the provider's commitment ID (reservation, savings plan or
+	// CUD), which becomes CommitmentDiscountId.
+	ID            string
+	Service       common.ServiceType
+	Region        string
+	ResourceType  string
+	Count         int
+	TermYears     int
+	PaymentOption string
+	Start         time.Time
+	End           time.Time
+	UpfrontCost   float64
+	// MonthlyCost is nil when the provider reported no recurring charge.
+	MonthlyCost             *float64
+	EstimatedMonthlySavings float64
+}
+
+// PurchaseRow renders the purchase itself: a one-time Purchase charge
+// billing the upfront amount (zero for no-upfront commitments) in the
+// billing period the commitment started. Its EffectiveCost is zero because
+// FOCUS amortises commitment purchases onto the usage they cover.
+func PurchaseRow(c Commitment) Row {
+	r := commitmentRow(c)
+	periodStart, periodEnd := billingPeriod(c.Start)
+	r.BillingPeriodStart, r.BillingPeriodEnd = periodStart, periodEnd
+	r.ChargePeriodStart, r.ChargePeriodEnd = c.Start, periodEnd
+	r.ChargeFrequency = FrequencyOneTime
+	r.ChargeDescription = "Commitment purchase"
+	r.BilledCost, r.ListCost, r.ContractedCost = c.UpfrontCost, c.UpfrontCost, c.UpfrontCost
+	return r
+}
+
+// InventoryRow renders an active commitment's recurring Purchase charge for
+// the billing period containing asOf, clipped to the commitment's term.
+// BilledCost is the monthly fee, prorated when the term starts or ends
+// inside the period. ok is false when the commitment is not active at any
+// point in the period.
+func InventoryRow(c Commitment, asOf time.Time) (row Row, ok bool) {
+	periodStart, periodEnd := billingPeriod(asOf)
+	from, to := periodStart, periodEnd
+	if c.Start.After(from) {
+		from = c.Start
+	}
+	if !c.End.IsZero() && c.End.Before(to) {
+		to = c.End
+	}
+	if !to.After(from) {
+		return Row{}, false
+	}
+	r := commitmentRow(c)
+	r.BillingPeriodStart, r.BillingPeriodEnd = periodStart, periodEnd
+	r.ChargePeriodStart, r.ChargePeriodEnd = from, to
+	r.ChargeFrequency = FrequencyRecurring
+	r.ChargeDescription = "Commitment recurring fee"
+	if c.MonthlyCost != nil {
+		fee := *c.MonthlyCost * to.Sub(from).Hours() / periodEnd.Sub(periodStart).Hours()
+		r.BilledCost, r.ListCost, r.ContractedCost = fee, fee, fee
+	}
+	return r, true
+}
+
+// commitmentRow fills the columns every commitment row shares.
+func commitmentRow(c Commitment) Row {
+	category, typ := CommitmentDiscount(c.Provider, c.Service)
+	r := Row{
+		BillingAccountID:           c.AccountID,
+		SubAccountID:               c.AccountID,
+		ProviderName:               ProviderName(c.Provider),
+		ChargeCategory:             ChargePurchase,
+		RegionID:                   c.Region,
+		ServiceName:                string(c.Service),
+		ServiceCategory:            ServiceCategory(c.Service),
+		ResourceType:               c.ResourceType,
+		CommitmentDiscountID:       c.ID,
+		CommitmentDiscountCategory: category,
+		CommitmentDiscountType:     typ,
+		XTermYears:                 c.TermYears,
+		XPaymentOption:             c.PaymentOption,
+		XMonthlySavings:            c.EstimatedMonthlySavings,
+	}
+	if category == CategoryUsage && c.Count > 0 {
+		r.CommitmentDiscountQuantity = float64(c.Count)
+		r.CommitmentDiscountUnit = "Instances"
+	}
+	return r
+}
+
+// SavingsRunRate is one savings snapshot: monthly run-rates of the active
+// commitments in one account, service and region at a point in time.
+type SavingsRunRate struct {
+	Provider  common.ProviderType
+	AccountID string
+	Service   common.ServiceType
+	Region    string
+	// CommitmentType is "RI" or "SavingsPlan".
+	CommitmentType string
+	Timestamp      time.Time
+	// AmortizedUpfront is the upfront spend amortised to a month.
+	AmortizedUpfront float64
+	// Recurring is the monthly recurring fee, nil when unknown.
+	Recurring *float64
+	Savings   float64
+}
+
+// SavingsRow renders a snapshot as a committed Usage row over its billing
+// period: EffectiveCost is the amortised commitment cost, ListCost adds
+// back the savings (what the covered usage would have cost on demand), and
+// BilledCost is zero because the fees are billed on the Purchase rows. A
+// snapshot aggregates every commitment in its scope, so
+// CommitmentDiscountId is empty while the category and type are set.
+func SavingsRow(s SavingsRunRate) Row {
+	service := s.Service
+	if s.CommitmentType == "SavingsPlan" {
+		service = common.ServiceSavingsPlansAll
+	}
+	category, typ := CommitmentDiscount(s.Provider, service)
+	effective := s.AmortizedUpfront
+	if s.Recurring != nil {
+		effective += *s.Recurring
+	}
+	periodStart, periodEnd := billingPeriod(s.Timestamp)
+	return Row{
+		BillingAccountID:           s.AccountID,
+		SubAccountID:               s.AccountID,
+		ProviderName:               ProviderName(s.Provider),
+		BillingPeriodStart:         periodStart,
+		BillingPeriodEnd:           periodEnd,
+		ChargePeriodStart:          periodStart,
+		ChargePeriodEnd:            periodEnd,
+		ChargeCategory:             ChargeUsage,
+		ChargeFrequency:            FrequencyUsageBased,
+		ChargeDescription:          "Commitment-covered usage (monthly run-rate)",
+		PricingCategory:            PricingCommitted,
+		EffectiveCost:              effective,
+		ListCost:                   effective + s.Savings,
+		ContractedCost:             effective,
+		RegionID:                   s.Region,
+		ServiceName:                string(s.Service),
+		ServiceCategory:            ServiceCategory(s.Service),
+		CommitmentDiscountCategory: category,
+		CommitmentDiscountType:     typ,
+		CommitmentDiscountStatus:   StatusUsed,
+		XMonthlySavings:            s.Savings,
+	}
+}
+
+// ProviderName returns the FOCUS ProviderName for a CUDly provider.
+func ProviderName(p common.ProviderType) string {
+	switch p {
+	case common.ProviderAWS:
+		return "AWS"
+	case common.ProviderAzure:
+		return "Microsoft"
+	case common.ProviderGCP:
+		return "Google Cloud"
+	default:
+		return string(p)
+	}
+}
+
+// CommitmentDiscount returns the CommitmentDiscountCategory and
+// CommitmentDiscountType of a commitment bought for service.
+func CommitmentDiscount(p common.ProviderType, service common.ServiceType) (category, typ string) {
+	if common.IsSavingsPlan(service) {
+		return CategorySpend, "Savings Plan"
+	}
+	switch p {
+	case common.ProviderAzure:
+		return CategoryUsage, "Reservation"
+	case common.ProviderGCP:
+		return CategoryUsage, "Committed Use Discount"
+	default:
+		return CategoryUsage, "Reserved Instance"
+	}
+}
+
+// ServiceCategory maps a CUDly service onto the FOCUS ServiceCategory
+// enumeration.
+func ServiceCategory(s common.ServiceType) string {
+	switch s {
+	case common.ServiceCompute, common.ServiceEC2, common.ServiceAppService:
+		return "Compute"
+	case common.ServiceRelationalDB, common.ServiceNoSQL, common.ServiceCache, common.ServiceRDS,
+		common.ServiceElastiCache, common.ServiceMemoryDB, common.ServiceDynamoDB:
+		return "Databases"
+	case common.ServiceDataWarehouse, common.ServiceRedshift, common.ServiceSearch, common.ServiceOpenSearch:
+		return "Analytics"
+	case common.ServiceStorage, common.ServiceBlockStorage:
+		return "Storage"
+	}
+	if common.IsSavingsPlan(s) {
+		return "Compute"
+	}
+	return "Other"
+}
+
+// billingPeriod returns the calendar month (UTC) containing t.
+func billingPeriod(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,105 @@
+package focus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func monthly(v float64) *float64 { return &v }
+
+func TestPurchaseAndInventoryRows(t *testing.T) {
+	c := Commitment{
+		Provider: common.ProviderAWS, AccountID: "111111111111", ID: "ri-0abc",
+		Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large",
+		Count: 4, TermYears: 1, PaymentOption: "partial-upfront",
+		Start:       time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2027, 3, 16, 0, 0, 0, 0, time.UTC),
+		UpfrontCost: 1200, MonthlyCost: monthly(62),
+	}
+
+	p := PurchaseRow(c)
+	if p.ChargeCategory != ChargePurchase || p.ChargeFrequency != FrequencyOneTime || p.BilledCost != 1200 || p.EffectiveCost != 0 {
+		t.Fatalf("purchase row = %+v", p)
+	}
+	if p.CommitmentDiscountID != "ri-0abc" || p.CommitmentDiscountCategory != CategoryUsage ||
+		p.CommitmentDiscountType != "Reserved Instance" || p.CommitmentDiscountQuantity != 4 {
+		t.Fatalf("commitment columns = %+v", p)
+	}
+
+	// March: the term starts on the 16th, so the fee covers 16 of 31 days.
+	inv, ok := InventoryRow(c, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC))
+	if !ok || inv.ChargeFrequency != FrequencyRecurring || !inv.ChargePeriodStart.Equal(c.Start) {
+		t.Fatalf("inventory row = %+v, ok=%v", inv, ok)
+	}
+	if want := 62.0 * 16 / 31; inv.BilledCost < want-1e-9 || inv.BilledCost > want+1e-9 {
+		t.Fatalf("prorated fee = %v, want %v", inv.BilledCost, want)
+	}
+	if _, ok := InventoryRow(c, time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("an expired commitment must not produce an inventory row")
+	}
+}
+
+func TestSavingsRow(t *testing.T) {
+	r := SavingsRow(SavingsRunRate{
+		Provider: common.ProviderAWS, AccountID: "111111111111", Service: common.ServiceEC2,
+		Region: "us-east-1", CommitmentType: "SavingsPlan",
+		Timestamp:        time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC),
+		AmortizedUpfront: 100, Recurring: monthly(50), Savings: 40,
+	})
+	if r.ChargeCategory != ChargeUsage || r.PricingCategory != PricingCommitted || r.CommitmentDiscountStatus != StatusUsed {
+		t.Fatalf("savings row = %+v", r)
+	}
+	if r.EffectiveCost != 150 || r.ListCost != 190 || r.BilledCost != 0 || r.CommitmentDiscountCategory != CategorySpend {
+		t.Fatalf("savings amounts = %+v", r)
+	}
+	if !r.ChargePeriodStart.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("charge period start = %v", r.ChargePeriodStart)
+	}
+}
+
+func TestWriteCSV_RoundTripsThroughImport(t *testing.T) {
+	c := Commitment{
+		Provider: common.ProviderAzure, AccountID: "sub-1", ID: "r1", Service: common.ServiceCompute,
+		Region: "eastus", ResourceType: "Standard_D2s_v3", Count: 1, TermYears: 3,
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []Row{PurchaseRow(c)}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != strings.Join(Columns, ",") {
+		t.Fatalf("csv = %q", buf.String())
+	}
+	if got := len(strings.Split(lines[1], ",")); got != len(Columns) {
+		t.Fatalf("row has %d fields, want %d", got, len(Columns))
+	}
+
+	items := decodeAll(t, "export.csv", buf.String())
+	if len(items) != 1 || items[0].Type != "" || items[0].AccountID != "sub-1" || items[0].Region != "eastus" {
+		t.Fatalf("re-imported purchase = %+v; purchases are not usage", items)
+	}
+}
+
+func TestServiceCategoryAndProviderName(t *testing.T) {
+	cases := map[common.ServiceType]string{
+		common.ServiceEC2: "Compute", common.ServiceRDS: "Databases", common.ServiceRedshift: "Analytics",
+		common.ServiceSavingsPlansCompute: "Compute", common.ServiceStorage: "Storage", common.ServiceOther: "Other",
+		common.ServiceBlockStorage: "Storage", common.ServiceAppService: "Compute",
+	}
+	for s, want := range cases {
+		if got := ServiceCategory(s); got != want {
+			t.Errorf("ServiceCategory(%s) = %q, want %q", s, got, want)
+		}
+	}
+	if ProviderName(common.ProviderGCP) != "Google Cloud" {
+		t.Fatal("GCP provider name")
+	}
+	if cat, typ := CommitmentDiscount(common.ProviderGCP, common.ServiceCompute); cat != CategoryUsage || typ != "Committed Use Discount" {
+		t.Fatalf("GCP discount = %s/%s", cat, typ)
+	}
+}
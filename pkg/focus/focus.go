@@ -0,0 +1,99 @@
+// Package focus reads and writes FinOps Open Cost and Usage Specification
+// (FOCUS 1.x) datasets. Import maps billing-export rows from any provider
+// onto pkg/cur line items, so the CUR aggregator and usage store serve AWS,
+// Azure and GCP alike; export renders CUDly's purchases, commitment
+// inventory and savings snapshots as FOCUS rows for other FinOps tools.
+package focus
+
+// FOCUS column names read or written by this package. Columns prefixed x_
+// are provider or CUDly extensions, as the specification requires.
+const (
+	ColBillingAccountID  = "BillingAccountId"
+	ColSubAccountID      = "SubAccountId"
+	ColProviderName      = "ProviderName"
+	ColServiceProvider   = "ServiceProviderName" // FOCUS 1.2 successor of ProviderName
+	ColPublisherName     = "PublisherName"
+	ColInvoiceIssuerName = "InvoiceIssuerName"
+	ColBillingCurrency   = "BillingCurrency"
+	ColBillingPeriodFrom = "BillingPeriodStart"
+	ColBillingPeriodTo   = "BillingPeriodEnd"
+	ColChargePeriodStart = "ChargePeriodStart"
+	ColChargePeriodEnd   = "ChargePeriodEnd"
+	ColChargeCategory    = "ChargeCategory"
+	ColChargeClass       = "ChargeClass"
+	ColChargeFrequency   = "ChargeFrequency"
+	ColChargeDescription = "ChargeDescription"
+	ColPricingCategory   = "PricingCategory"
+	ColBilledCost        = "BilledCost"
+	ColEffectiveCost     = "EffectiveCost"
+	ColListCost          = "ListCost"
+	ColContractedCost    = "ContractedCost"
+	ColConsumedQuantity  = "ConsumedQuantity"
+	ColConsumedUnit      = "ConsumedUnit"
+	ColPricingQuantity   = "PricingQuantity"
+	ColPricingUnit       = "PricingUnit"
+	ColRegionID          = "RegionId"
+	ColRegion            = "Region" // pre-1.0 spelling of RegionId
+	ColServiceName       = "ServiceName"
+	ColServiceCategory   = "ServiceCategory"
+	ColResourceID        = "ResourceId"
+	ColResourceType      = "ResourceType"
+	ColSkuID             = "SkuId"
+	ColSkuPriceDetails   = "SkuPriceDetails"
+
+	ColCommitmentDiscountID       = "CommitmentDiscountId"
+	ColCommitmentDiscountName     = "CommitmentDiscountName"
+	ColCommitmentDiscountCategory = "CommitmentDiscountCategory"
+	ColCommitmentDiscountType     = "CommitmentDiscountType"
+	ColCommitmentDiscountStatus   = "CommitmentDiscountStatus"
+	ColCommitmentDiscountQuantity = "CommitmentDiscountQuantity"
+	ColCommitmentDiscountUnit     = "CommitmentDiscountUnit"
+
+	// AWS FOCUS exports carry the CUR product code and usage type here.
+	ColAWSServiceCode = "x_ServiceCode"
+	ColAWSUsageType   = "x_UsageType"
+	// Azure FOCUS exports carry the meter's SKU details (including the VM
+	// size) as a JSON object here.
+	ColAzureSkuDetails = "x_SkuDetails"
+)
+
+// ChargeCategory values.
+const (
+	ChargeUsage      = "Usage"
+	ChargePurchase   = "Purchase"
+	ChargeTax        = "Tax"
+	ChargeCredit     = "Credit"
+	ChargeAdjustment = "Adjustment"
+)
+
+// ChargeClass marks rows that correct an earlier billing period.
+const ChargeClassCorrection = "Correction"
+
+// ChargeFrequency values.
+const (
+	FrequencyOneTime    = "One-Time"
+	FrequencyRecurring  = "Recurring"
+	FrequencyUsageBased = "Usage-Based"
+)
+
+// PricingCategory values.
+const (
+	PricingStandard  = "Standard"
+	PricingDynamic   = "Dynamic"
+	PricingCommitted = "Committed"
+	PricingOther     = "Other"
+)
+
+// CommitmentDiscountCategory values: Spend commitments are dollar
+// denominated (Savings Plans, Azure savings plans, GCP flexible CUDs); Usage
+// commitments cover a resource quantity (reservations, resource CUDs).
+const (
+	CategorySpend = "Spend"
+	CategoryUsage = "Usage"
+)
+
+// CommitmentDiscountStatus values.
+const (
+	StatusUsed   = "Used"
+	StatusUnused = "Unused"
+)
@@ -0,0 +1,244 @@
+package focus
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]cur.Decoder{".csv": DecodeCSV, ".parquet": DecodeParquet}
+)
+
+// RegisterDecoder installs a Decoder for a file extension such as ".json".
+// As in pkg/cur, CSV and Parquet are built in; a decoder for another format
+// maps each row to a column map and calls LineItemFromRecord.
+func RegisterDecoder(ext string, d cur.Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(ext)] = d
+}
+
+// Supported reports whether Decode can read an object with this name.
+func Supported(name string) bool {
+	_, ok := decoderFor(name)
+	return ok
+}
+
+// Decode picks a Decoder by name (".csv", ".csv.gz", ".parquet", ...),
+// decompresses gzip objects, and streams their rows to fn as line items.
+func Decode(name string, r io.Reader, fn func(cur.LineItem) error) error {
+	d, ok := decoderFor(name)
+	if !ok {
+		return fmt.Errorf("focus: no decoder for %q (built in: .csv, .csv.gz, .parquet; register others with RegisterDecoder)", name)
+	}
+	if isGzip(name) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("focus: %s: %w", name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	if err := d(r, fn); err != nil {
+		return fmt.Errorf("focus: %s: %w", name, err)
+	}
+	return nil
+}
+
+func decoderFor(name string) (cur.Decoder, bool) {
+	base := strings.ToLower(name)
+	if isGzip(base) {
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".gz"), ".gzip")
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[path.Ext(base)]
+	return d, ok
+}
+
+func isGzip(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".gzip")
+}
+
+// DecodeCSV is the built-in Decoder for FOCUS CSV datasets.
+func DecodeCSV(r io.Reader, fn func(cur.LineItem) error) error {
+	return cur.ForEachCSVRecord(r, column, lineItems(fn))
+}
+
+// DecodeParquet is the built-in Decoder for FOCUS Parquet datasets, read
+// through pkg/cur's Parquet reader so values render exactly as in CSV.
+func DecodeParquet(r io.Reader, fn func(cur.LineItem) error) error {
+	return cur.ForEachParquetRecord(r, column, lineItems(fn))
+}
+
+// column is a FOCUS column name as written, minus a CSV byte-order mark.
+func column(h string) string { return strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")) }
+
+// lineItems adapts fn to the record callbacks of the pkg/cur readers.
+func lineItems(fn func(cur.LineItem) error) func(row int, rec map[string]string) error {
+	return func(row int, rec map[string]string) error {
+		li, err := LineItemFromRecord(rec)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		return fn(li)
+	}
+}
+
+// LineItemFromRecord maps one FOCUS row, keyed by column name, onto a
+// pkg/cur line item:
+//
+//   - Usage rows covered by a commitment become DiscountedUsage (Usage
+//     category) or SavingsPlanCoveredUsage (Spend category), with
+//     CommitmentDiscountId as the commitment ARN;
+//   - Unused rows of a Usage commitment become RIFee lines carrying the
+//     unused quantity; unused Spend commitments are dropped, as in CUR;
+//   - other Usage rows at Standard pricing are on-demand Usage.
+//
+// Purchase, tax, credit and adjustment rows, corrections, and Dynamic (spot)
+// pricing come back with an empty Type, which the aggregator skips.
+//
+// FOCUS has no instance-type column, so ResourceType is taken from the
+// first of: the SKU detail JSON (SkuPriceDetails, Azure's x_SkuDetails),
+// the instance type suffix of AWS's x_UsageType ("BoxUsage:m5.large"), and
+// finally SkuId, which still separates pools when no instance type is
+// exported. ProductCode is AWS's x_ServiceCode when present, so AWS FOCUS
+// data lands in the same pools as CUR data, and ServiceName otherwise.
+func LineItemFromRecord(rec map[string]string) (cur.LineItem, error) {
+	li := cur.LineItem{
+		AccountID:    first(rec, ColSubAccountID, ColBillingAccountID),
+		ProductCode:  first(rec, ColAWSServiceCode, ColServiceName),
+		UsageType:    rec[ColAWSUsageType],
+		PricingUnit:  normaliseUnit(rec[ColConsumedUnit]),
+		Region:       first(rec, ColRegionID, ColRegion),
+		ResourceType: resourceType(rec),
+	}
+	var err error
+	if li.UsageStart, err = parseTime(rec[ColChargePeriodStart]); err != nil {
+		return cur.LineItem{}, fmt.Errorf("%s: %w", ColChargePeriodStart, err)
+	}
+	if li.UsageEnd, err = parseTime(rec[ColChargePeriodEnd]); err != nil {
+		return cur.LineItem{}, fmt.Errorf("%s: %w", ColChargePeriodEnd, err)
+	}
+	amounts := map[string]float64{}
+	for _, col := range []string{ColConsumedQuantity, ColPricingQuantity, ColBilledCost, ColEffectiveCost} {
+		if amounts[col], err = parseAmount(rec[col]); err != nil {
+			return cur.LineItem{}, fmt.Errorf("%s: %w", col, err)
+		}
+	}
+	li.UsageAmount = amounts[ColConsumedQuantity]
+	li.UnblendedCost = amounts[ColBilledCost]
+	li.EffectiveCost = amounts[ColEffectiveCost]
+
+	if rec[ColChargeCategory] != ChargeUsage || rec[ColChargeClass] == ChargeClassCorrection ||
+		rec[ColPricingCategory] == PricingDynamic {
+		return li, nil
+	}
+	commitment := rec[ColCommitmentDiscountID]
+	spend := rec[ColCommitmentDiscountCategory] == CategorySpend
+	switch {
+	case commitment == "":
+		li.Type = cur.LineItemUsage
+	case rec[ColCommitmentDiscountStatus] == StatusUnused:
+		if !spend {
+			li.Type = cur.LineItemRIFee
+			li.ReservationARN = commitment
+			// Unused rows consume nothing; the unused hours are the
+			// pricing quantity.
+			li.UnusedQuantity = amounts[ColPricingQuantity]
+			if li.UnusedQuantity == 0 {
+				li.UnusedQuantity = li.UsageAmount
+			}
+		}
+	case spend:
+		li.Type = cur.LineItemSavingsPlanCoveredUsage
+		li.SavingsPlanARN = commitment
+	default:
+		li.Type = cur.LineItemDiscountedUsage
+		li.ReservationARN = commitment
+	}
+	return li, nil
+}
+
+// skuDetailKeys are the SKU detail entries known to carry the instance or
+// VM size, in preference order. Azure's x_SkuDetails names the VM size
+// ServiceType.
+var skuDetailKeys = []string{"InstanceType", "x_InstanceType", "VMSize", "MachineType", "ServiceType"}
+
+func resourceType(rec map[string]string) string {
+	for _, col := range []string{ColSkuPriceDetails, ColAzureSkuDetails} {
+		details := jsonObject(rec[col])
+		for _, k := range skuDetailKeys {
+			if v, ok := details[k].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	if ut := rec[ColAWSUsageType]; strings.Contains(ut, ":") {
+		return ut[strings.LastIndex(ut, ":")+1:]
+	}
+	return rec[ColSkuID]
+}
+
+// normaliseUnit maps FOCUS unit spellings ("Hours", "1 Hour", "Hrs") onto
+// the lower-case forms pkg/cur treats as instance hours.
+func normaliseUnit(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	return strings.TrimSpace(strings.TrimPrefix(u, "1 "))
+}
+
+func first(rec map[string]string, cols ...string) string {
+	for _, c := range cols {
+		if v := rec[c]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// jsonObject decodes a JSON object column; anything else reads as empty.
+func jsonObject(s string) map[string]any {
+	if s == "" || s[0] != '{' {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// timeLayouts are the FOCUS datetime spellings seen in provider exports:
+// ISO 8601 in UTC with or without the zone designator, and the
+// space-separated form BigQuery and some CSV writers emit.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05.999999999 MST"}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised datetime %q", s)
+}
+
+func parseAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
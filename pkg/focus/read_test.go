@@ -0,0 +1,182 @@
+package focus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+// azureCSV mixes the row kinds an Azure FOCUS export carries for one VM
+// size: on-demand, reservation-covered, unused reservation, spot, and a
+// purchase.
+const azureCSV = "\ufeff" + `BillingAccountId,SubAccountId,ChargePeriodStart,ChargePeriodEnd,ChargeCategory,ChargeClass,PricingCategory,ServiceName,RegionId,SkuId,x_SkuDetails,ConsumedQuantity,ConsumedUnit,PricingQuantity,BilledCost,EffectiveCost,CommitmentDiscountId,CommitmentDiscountCategory,CommitmentDiscountStatus
+ba,sub-1,2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,Usage,,Standard,Virtual Machines,eastus,DZH318Z0BQ4B,"{""ServiceType"":""Standard_D2s_v3""}",2,Hours,2,0.192,0.192,,,
+ba,sub-1,2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,Usage,,Committed,Virtual Machines,eastus,DZH318Z0BQ4B,"{""ServiceType"":""Standard_D2s_v3""}",1,Hours,1,0,0.06,/providers/Microsoft.Capacity/reservationOrders/o1/reservations/r1,Usage,Used
+ba,sub-1,2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,Usage,,Committed,Virtual Machines,eastus,DZH318Z0BQ4B,"{""ServiceType"":""Standard_D2s_v3""}",,Hours,0.5,0,0.03,/providers/Microsoft.Capacity/reservationOrders/o1/reservations/r1,Usage,Unused
+ba,sub-1,2026-03-01T10:00:00Z,2026-03-01T11:00:00Z,Usage,,Dynamic,Virtual Machines,eastus,DZH318Z0BQ4B,"{""ServiceType"":""Standard_D2s_v3""}",3,Hours,3,0.05,0.05,,,
+ba,sub-1,2026-03-01T00:00:00Z,2026-04-01T00:00:00Z,Purchase,,Committed,Virtual Machines,eastus,DZH318Z0BQ4B,,,,1,500,0,/providers/Microsoft.Capacity/reservationOrders/o1/reservations/r1,Usage,
+`
+
+func decodeAll(t *testing.T, name, data string) []cur.LineItem {
+	t.Helper()
+	var out []cur.LineItem
+	if err := Decode(name, strings.NewReader(data), func(li cur.LineItem) error {
+		out = append(out, li)
+		return nil
+	}); err != nil {
+		t.Fatalf("Decode(%s): %v", name, err)
+	}
+	return out
+}
+
+func TestDecode_AzureRowsMapOntoLineItems(t *testing.T) {
+	items := decodeAll(t, "focus-2026-03.csv", azureCSV)
+	if len(items) != 5 {
+		t.Fatalf("got %d rows, want 5", len(items))
+	}
+	od, covered, unused, spot, purchase := items[0], items[1], items[2], items[3], items[4]
+
+	if od.Type != cur.LineItemUsage || od.AccountID != "sub-1" || od.ProductCode != "Virtual Machines" ||
+		od.Region != "eastus" || od.ResourceType != "Standard_D2s_v3" || od.PricingUnit != "hours" {
+		t.Fatalf("on-demand row = %+v", od)
+	}
+	if od.UsageAmount != 2 || od.UnblendedCost != 0.192 || !od.UsageStart.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("on-demand amounts = %+v", od)
+	}
+	if covered.Type != cur.LineItemDiscountedUsage || covered.ReservationARN == "" || covered.EffectiveCost != 0.06 {
+		t.Fatalf("covered row = %+v", covered)
+	}
+	if unused.Type != cur.LineItemRIFee || unused.UnusedQuantity != 0.5 {
+		t.Fatalf("unused row = %+v, want RIFee with 0.5 unused hours", unused)
+	}
+	if spot.Type != "" || purchase.Type != "" {
+		t.Fatalf("spot/purchase types = %q/%q, want skipped", spot.Type, purchase.Type)
+	}
+
+	// Through the aggregator the rows become one pool-hour and one
+	// commitment-hour, exactly as CUR rows would.
+	agg := cur.NewAggregator()
+	for _, li := range items {
+		if _, err := agg.Add(li); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pools, comms := agg.PoolHours(), agg.CommitmentHours()
+	if len(pools) != 1 || pools[0].UsageHours != 3 || pools[0].ReservedHours != 1 {
+		t.Fatalf("pools = %+v", pools)
+	}
+	if len(comms) != 1 || comms[0].CoveredHours != 1 || comms[0].UnusedHours != 0.5 {
+		t.Fatalf("commitments = %+v", comms)
+	}
+}
+
+func TestLineItemFromRecord_AWSAndGCPSpellings(t *testing.T) {
+	aws, err := LineItemFromRecord(map[string]string{
+		ColChargeCategory: ChargeUsage, ColPricingCategory: PricingCommitted,
+		ColAWSServiceCode: "AmazonEC2", ColServiceName: "Amazon Elastic Compute Cloud",
+		ColAWSUsageType: "USE1-BoxUsage:m5.large", ColConsumedUnit: "Hrs", ColRegionID: "us-east-1",
+		ColConsumedQuantity: "1", ColCommitmentDiscountID: "arn:aws:savingsplans::1:savingsplan/sp-1",
+		ColCommitmentDiscountCategory: CategorySpend, ColCommitmentDiscountStatus: StatusUsed,
+		ColChargePeriodStart: "2026-03-01T10:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ProductCode != cur.ProductEC2 || aws.ResourceType != "m5.large" ||
+		aws.Type != cur.LineItemSavingsPlanCoveredUsage || aws.SavingsPlanARN == "" {
+		t.Fatalf("aws row = %+v", aws)
+	}
+
+	gcp, err := LineItemFromRecord(map[string]string{
+		ColChargeCategory: ChargeUsage, ColPricingCategory: PricingStandard,
+		ColServiceName: "Compute Engine", ColRegionID: "europe-west1", ColSkuID: "CP-COMPUTEENGINE-VMIMAGE-N2-STANDARD-4",
+		ColSkuPriceDetails: `{"MachineType":"n2-standard-4"}`, ColConsumedUnit: "1 Hour",
+		ColConsumedQuantity: "4", ColChargeClass: "", ColChargePeriodStart: "2026-03-01 10:00:00 UTC",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gcp.ResourceType != "n2-standard-4" || gcp.PricingUnit != "hour" || gcp.UsageStart.IsZero() {
+		t.Fatalf("gcp row = %+v", gcp)
+	}
+
+	correction, err := LineItemFromRecord(map[string]string{ColChargeCategory: ChargeUsage, ColChargeClass: ChargeClassCorrection})
+	if err != nil || correction.Type != "" {
+		t.Fatalf("correction = %+v, %v; want skipped", correction, err)
+	}
+}
+
+func TestDecode_RejectsBadRowsAndUnknownFormats(t *testing.T) {
+	bad := "ChargePeriodStart,BilledCost\nyesterday,1\n"
+	err := Decode("x.csv", strings.NewReader(bad), func(cur.LineItem) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "row 2") || !strings.Contains(err.Error(), ColChargePeriodStart) {
+		t.Fatalf("err = %v, want row 2 ChargePeriodStart error", err)
+	}
+	if Supported("focus.orc") {
+		t.Fatal("orc must not be supported until a decoder is registered")
+	}
+	if !Supported("part-0.snappy.parquet") {
+		t.Fatal("parquet must be supported")
+	}
+	if !Supported("part-0.csv.gz") {
+		t.Fatal("gzipped CSV must be supported")
+	}
+}
+
+// focusParquetRow is the subset of FOCUS columns a reservation-covered
+// Azure row needs, with ChargePeriodStart as a TIMESTAMP as BigQuery and
+// Azure write it and x_SkuDetails as a MAP.
+type focusParquetRow struct {
+	ChargePeriodStart          int64             `parquet:"ChargePeriodStart,timestamp(microsecond)"`
+	ChargePeriodEnd            int64             `parquet:"ChargePeriodEnd,timestamp(microsecond)"`
+	SubAccountID               string            `parquet:"SubAccountId"`
+	ChargeCategory             string            `parquet:"ChargeCategory"`
+	PricingCategory            string            `parquet:"PricingCategory"`
+	ServiceName                string            `parquet:"ServiceName"`
+	RegionID                   string            `parquet:"RegionId"`
+	SkuDetails                 map[string]string `parquet:"x_SkuDetails"`
+	ConsumedQuantity           float64           `parquet:"ConsumedQuantity"`
+	ConsumedUnit               string            `parquet:"ConsumedUnit"`
+	EffectiveCost              float64           `parquet:"EffectiveCost"`
+	CommitmentDiscountID       *string           `parquet:"CommitmentDiscountId,optional"`
+	CommitmentDiscountCategory *string           `parquet:"CommitmentDiscountCategory,optional"`
+}
+
+func TestDecode_Parquet(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	ri, category := "/providers/Microsoft.Capacity/reservationOrders/o1/reservations/r1", CategoryUsage
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, []focusParquetRow{{
+		ChargePeriodStart: start.UnixMicro(), ChargePeriodEnd: start.Add(time.Hour).UnixMicro(),
+		SubAccountID: "sub-1", ChargeCategory: ChargeUsage, PricingCategory: "Committed",
+		ServiceName: "Virtual Machines", RegionID: "eastus",
+		SkuDetails:       map[string]string{"ServiceType": "Standard_D2s_v3"},
+		ConsumedQuantity: 1, ConsumedUnit: "Hours", EffectiveCost: 0.06,
+		CommitmentDiscountID: &ri, CommitmentDiscountCategory: &category,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []cur.LineItem
+	if err := Decode("focus/part-0.snappy.parquet", &buf, func(li cur.LineItem) error {
+		items = append(items, li)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d line items, want 1", len(items))
+	}
+	li := items[0]
+	if li.Type != cur.LineItemDiscountedUsage || li.ReservationARN != ri || li.ResourceType != "Standard_D2s_v3" {
+		t.Fatalf("unexpected line %+v", li)
+	}
+	if !li.UsageStart.Equal(start) || li.Region != "eastus" || li.PricingUnit != "hours" || li.EffectiveCost != 0.06 {
+		t.Fatalf("unexpected line %+v", li)
+	}
+}
@@ -0,0 +1,270 @@
+// Package freeze implements change-freeze (blackout) calendars: windows
+// such as a fiscal year-end close, a reorganisation or a migration during
+// which CUDly must not buy commitments.
+//
+// A window is one-off or recurs weekly, monthly or yearly, and applies to
+// every purchase or only to those landing in a given provider or set of
+// accounts. Scheduled purchases that come due inside a window are deferred
+// to the first time no window is in force, or skipped when the window says
+// so; manual purchases need an explicit override. The windows themselves
+// are stored by the caller; this package holds the calendar arithmetic so
+// every purchase path reads them the same way.
+package freeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Recurrence is how often a window repeats.
+type Recurrence string
+
+const (
+	// RecurrenceNone is a one-off window.
+	RecurrenceNone Recurrence = "none"
+	// RecurrenceWeekly repeats the window every 7 days.
+	RecurrenceWeekly Recurrence = "weekly"
+	// RecurrenceMonthly repeats the window on the same day every month.
+	RecurrenceMonthly Recurrence = "monthly"
+	// RecurrenceYearly repeats the window on the same date every year.
+	RecurrenceYearly Recurrence = "yearly"
+)
+
+// Valid reports whether r is a supported recurrence.
+func (r Recurrence) Valid() bool {
+	switch r {
+	case RecurrenceNone, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+		return true
+	}
+	return false
+}
+
+// period returns the shortest gap between two occurrences of r, or 0 for a
+// one-off window.
+func (r Recurrence) period() time.Duration {
+	switch r {
+	case RecurrenceWeekly:
+		return 7 * 24 * time.Hour
+	case RecurrenceMonthly:
+		return 28 * 24 * time.Hour
+	case RecurrenceYearly:
+		return 365 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Action is what happens to a scheduled purchase that comes due inside a
+// window.
+type Action string
+
+const (
+	// ActionDefer holds the purchase until no window is in force.
+	ActionDefer Action = "defer"
+	// ActionSkip cancels the purchase.
+	ActionSkip Action = "skip"
+)
+
+// Valid reports whether a is a supported action.
+func (a Action) Valid() bool {
+	return a == ActionDefer || a == ActionSkip
+}
+
+// Window is a change-freeze window. Start and End bound its first
+// occurrence as the half-open interval [Start, End); a recurring window
+// repeats that interval every Recurrence. An empty Provider applies it to
+// every provider and empty AccountIDs to every account.
+type Window struct {
+	ID         string
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Recurrence Recurrence
+	Provider   string
+	AccountIDs []string
+	Action     Action
+}
+
+// Validate checks w is well formed: a name, an end after its start, a
+// known recurrence and action, and a recurring window shorter than the
+// gap between its occurrences, so it is never in force forever. A monthly
+// window must start by the 28th so it falls on the same day every month.
+func (w *Window) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if w.Start.IsZero() || !w.End.After(w.Start) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if !w.Recurrence.Valid() {
+		return fmt.Errorf("recurrence must be one of %q, %q, %q or %q",
+			RecurrenceNone, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly)
+	}
+	if !w.Action.Valid() {
+		return fmt.Errorf("action must be %q or %q", ActionDefer, ActionSkip)
+	}
+	if p := w.Recurrence.period(); p > 0 && w.End.Sub(w.Start) >= p {
+		return fmt.Errorf("a %s window must be shorter than its recurrence", w.Recurrence)
+	}
+	if w.Recurrence == RecurrenceMonthly && w.Start.UTC().Day() > 28 {
+		return fmt.Errorf("a monthly window must start on or before the 28th")
+	}
+	return nil
+}
+
+// occurrence returns the k-th occurrence of w (k = 0 is the first).
+func (w *Window) occurrence(k int) (start, end time.Time) {
+	switch w.Recurrence {
+	case RecurrenceWeekly:
+		return w.Start.AddDate(0, 0, 7*k), w.End.AddDate(0, 0, 7*k)
+	case RecurrenceMonthly:
+		return w.Start.AddDate(0, k, 0), w.End.AddDate(0, k, 0)
+	case RecurrenceYearly:
+		return w.Start.AddDate(k, 0, 0), w.End.AddDate(k, 0, 0)
+	}
+	return w.Start, w.End
+}
+
+// Occurrence returns the occurrence of w in force at t, if any.
+func (w *Window) Occurrence(t time.Time) (start, end time.Time, ok bool) {
+	if t.Before(w.Start) {
+		return time.Time{}, time.Time{}, false
+	}
+	if w.Recurrence == RecurrenceNone || !w.Recurrence.Valid() {
+		if !t.Before(w.End) {
+			return time.Time{}, time.Time{}, false
+		}
+		return w.Start, w.End, true
+	}
+	// Estimate the latest occurrence starting by t, then look one either
+	// side: calendar months and years are not all the same length.
+	var k int
+	s, u := w.Start.UTC(), t.UTC()
+	switch w.Recurrence {
+	case RecurrenceWeekly:
+		k = int(u.Sub(s) / (7 * 24 * time.Hour))
+	case RecurrenceMonthly:
+		k = (u.Year()-s.Year())*12 + int(u.Month()) - int(s.Month())
+	case RecurrenceYearly:
+		k = u.Year() - s.Year()
+	}
+	for i := k + 1; i >= k-1 && i >= 0; i-- {
+		start, end = w.occurrence(i)
+		if !t.Before(start) && t.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// Scope is where a purchase lands: the providers and the CUDly cloud
+// account IDs it buys in.
+type Scope struct {
+	Providers  []string
+	AccountIDs []string
+}
+
+// AppliesTo reports whether w covers a purchase landing in s. A window
+// scoped to a provider or to accounts covers a purchase touching any of
+// them.
+func (w *Window) AppliesTo(s Scope) bool {
+	if w.Provider != "" && !contains(s.Providers, w.Provider) {
+		return false
+	}
+	if len(w.AccountIDs) == 0 {
+		return true
+	}
+	for _, id := range s.AccountIDs {
+		if contains(w.AccountIDs, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Decision is the freeze in force over a purchase.
+type Decision struct {
+	// Window is the window in force. When several are, a skip window wins
+	// over a defer window.
+	Window Window
+	// Start and End bound Window's occurrence in force.
+	Start time.Time
+	End   time.Time
+	// Until is the first time no window covering the purchase is in force:
+	// End, or later when another window takes over when it ends.
+	Until time.Time
+}
+
+// Skip reports whether the purchase is to be skipped rather than deferred.
+func (d *Decision) Skip() bool {
+	return d.Window.Action == ActionSkip
+}
+
+// Check returns the freeze in force at t over a purchase landing in s, or
+// nil when none is.
+func Check(windows []Window, s Scope, t time.Time) *Decision {
+	var d *Decision
+	for i := range windows {
+		w := &windows[i]
+		if !w.AppliesTo(s) {
+			continue
+		}
+		start, end, ok := w.Occurrence(t)
+		if !ok {
+			continue
+		}
+		if d == nil || (w.Action == ActionSkip && !d.Skip()) {
+			d = &Decision{Window: *w, Start: start, End: end}
+		}
+	}
+	if d == nil {
+		return nil
+	}
+	d.Until = NextAllowed(windows, s, t)
+	return d
+}
+
+// maxHops bounds NextAllowed's walk across back-to-back windows.
+const maxHops = 1000
+
+// NextAllowed returns the first time at or after t at which no window
+// covering a purchase landing in s is in force.
+func NextAllowed(windows []Window, s Scope, t time.Time) time.Time {
+	for hop := 0; hop < maxHops; hop++ {
+		moved := false
+		for i := range windows {
+			w := &windows[i]
+			if !w.AppliesTo(s) {
+				continue
+			}
+			if _, end, ok := w.Occurrence(t); ok {
+				t, moved = end, true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return t
+}
+
+// FrozenError is returned when a purchase is refused because a freeze is
+// in force over it.
+type FrozenError struct {
+	Decision
+}
+
+// Error implements the error interface.
+func (e *FrozenError) Error() string {
+	return fmt.Sprintf("change freeze %q is in force until %s; an administrator must override it to purchase now",
+		e.Window.Name, e.Until.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,96 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestWindow_Occurrence(t *testing.T) {
+	tests := []struct {
+		name      string
+		w         Window
+		at        time.Time
+		wantOK    bool
+		wantStart time.Time
+	}{
+		{"one-off inside", Window{Start: day(2026, 3, 1), End: day(2026, 3, 5), Recurrence: RecurrenceNone}, day(2026, 3, 4), true, day(2026, 3, 1)},
+		{"one-off end is exclusive", Window{Start: day(2026, 3, 1), End: day(2026, 3, 5), Recurrence: RecurrenceNone}, day(2026, 3, 5), false, time.Time{}},
+		{"before first occurrence", Window{Start: day(2026, 3, 1), End: day(2026, 3, 5), Recurrence: RecurrenceYearly}, day(2025, 3, 2), false, time.Time{}},
+		{"weekly", Window{Start: day(2026, 1, 3), End: day(2026, 1, 5), Recurrence: RecurrenceWeekly}, day(2026, 2, 1), true, day(2026, 1, 31)},
+		{"weekly gap", Window{Start: day(2026, 1, 3), End: day(2026, 1, 5), Recurrence: RecurrenceWeekly}, day(2026, 2, 2), false, time.Time{}},
+		{"monthly", Window{Start: day(2026, 1, 25), End: day(2026, 2, 3), Recurrence: RecurrenceMonthly}, day(2026, 7, 1), true, day(2026, 6, 25)},
+		{"yearly across the new year", Window{Start: day(2025, 12, 15), End: day(2026, 1, 5), Recurrence: RecurrenceYearly}, day(2028, 1, 2), true, day(2027, 12, 15)},
+		{"yearly outside", Window{Start: day(2025, 12, 15), End: day(2026, 1, 5), Recurrence: RecurrenceYearly}, day(2028, 1, 5), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, _, ok := tt.w.Occurrence(tt.at)
+			require.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStart, start)
+		})
+	}
+}
+
+func TestWindow_Validate(t *testing.T) {
+	valid := Window{Name: "fy-close", Start: day(2026, 12, 15), End: day(2027, 1, 5), Recurrence: RecurrenceYearly, Action: ActionDefer}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		mutate func(w *Window)
+		want   string
+	}{
+		{"no name", func(w *Window) { w.Name = " " }, "name"},
+		{"end before start", func(w *Window) { w.End = w.Start }, "ends_at"},
+		{"bad recurrence", func(w *Window) { w.Recurrence = "daily" }, "recurrence"},
+		{"bad action", func(w *Window) { w.Action = "" }, "action"},
+		{"longer than its recurrence", func(w *Window) { w.Recurrence = RecurrenceWeekly }, "shorter"},
+		{"monthly on the 29th", func(w *Window) {
+			w.Recurrence, w.Start, w.End = RecurrenceMonthly, day(2026, 1, 29), day(2026, 2, 2)
+		}, "28th"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := valid
+			tt.mutate(&w)
+			assert.ErrorContains(t, w.Validate(), tt.want)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fyClose := Window{Name: "fy-close", Start: day(2026, 12, 20), End: day(2027, 1, 3), Recurrence: RecurrenceNone, Action: ActionDefer}
+	migration := Window{Name: "migration", Start: day(2027, 1, 3), End: day(2027, 1, 6), Recurrence: RecurrenceNone,
+		Provider: "azure", Action: ActionDefer}
+	reorg := Window{Name: "reorg", Start: day(2026, 12, 28), End: day(2026, 12, 30), Recurrence: RecurrenceNone,
+		AccountIDs: []string{"acct-1"}, Action: ActionSkip}
+	windows := []Window{fyClose, migration, reorg}
+
+	assert.Nil(t, Check(windows, Scope{Providers: []string{"aws"}}, day(2026, 12, 19)))
+
+	d := Check(windows, Scope{Providers: []string{"aws"}}, day(2026, 12, 24))
+	require.NotNil(t, d)
+	assert.Equal(t, "fy-close", d.Window.Name)
+	assert.False(t, d.Skip())
+	assert.Equal(t, day(2027, 1, 3), d.Until, "the azure-only window does not cover an aws purchase")
+
+	d = Check(windows, Scope{Providers: []string{"azure"}}, day(2026, 12, 24))
+	require.NotNil(t, d)
+	assert.Equal(t, day(2027, 1, 6), d.Until, "back-to-back windows chain")
+
+	d = Check(windows, Scope{Providers: []string{"aws"}, AccountIDs: []string{"acct-1"}}, day(2026, 12, 29))
+	require.NotNil(t, d)
+	assert.Equal(t, "reorg", d.Window.Name, "a skip window wins over a defer window")
+	assert.True(t, d.Skip())
+
+	err := &FrozenError{Decision: *d}
+	assert.Contains(t, err.Error(), `"reorg"`)
+	assert.Contains(t, err.Error(), "2027-01-03T00:00:00Z")
+}
@@ -0,0 +1,515 @@
+package ladder
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DataSourceBacktest is the DataSources entry stamped on every action the
+// backtest produces, so a replayed decision is never mistaken for a live one.
+const DataSourceBacktest = "backtest"
+
+// BacktestInput is the input to Backtest.
+//
+// All amounts are on-demand-equivalent USD/h, the same unit Allocate sizes
+// commitments in: one USD/h of commitment covers one USD/h of on-demand
+// usage. DiscountPct converts a layer's committed amount into what it
+// actually costs.
+type BacktestInput struct {
+	// DiscountPct is the per-layer discount of a commitment versus on-demand,
+	// in [0, 100). A layer without an entry is priced at 0% discount, which
+	// makes SavingsUSD a conservative lower bound rather than a guess.
+	DiscountPct map[LayerType]float64
+	// Series is the historical daily on-demand series to replay, oldest
+	// first, one point per consecutive UTC day. The first Config.LookbackDays
+	// points only seed the first baseline; replay starts on the day after.
+	Series []DailyPoint
+	// Layers is the provider's layer set (see ProviderLayers).
+	Layers []LayerSpec
+	// Term and PaymentOption are stamped on every simulated purchase. Term
+	// also sets how long a simulated commitment lives.
+	Term          Term
+	PaymentOption PaymentOption
+	// Config is the ladder config under test.
+	Config LadderConfig
+}
+
+// BacktestDay is one replayed day of the BacktestResult timeline. USD/h
+// fields are daily averages; USD fields are the whole day's totals.
+type BacktestDay struct {
+	Date                time.Time `json:"date"`
+	UsageUSDPerHour     float64   `json:"usage_usd_per_hour"`
+	CommittedUSDPerHour float64   `json:"committed_usd_per_hour"`
+	CoveredUSDPerHour   float64   `json:"covered_usd_per_hour"`
+	WasteUSD            float64   `json:"waste_usd"`
+	SpendUSD            float64   `json:"spend_usd"`
+}
+
+// ExpiryBucket aggregates the commitments bought during a backtest by the
+// calendar month (YYYY-MM, UTC) in which they expire.
+type ExpiryBucket struct {
+	Month      string  `json:"month"`
+	USDPerHour float64 `json:"usd_per_hour"`
+	Count      int     `json:"count"`
+}
+
+// BacktestResult reports how a LadderConfig would have behaved over the
+// replayed series.
+//
+// CoveragePct is covered usage over total usage; UtilizationPct is used
+// commitment over total commitment (nil when nothing was ever committed,
+// never 0-coerced). SpendUSD is commitment cost plus uncovered on-demand
+// spend; OnDemandOnlySpendUSD is what the same usage costs with no ladder,
+// and SavingsUSD is the difference (negative when the ladder over-commits).
+// WasteUSD is the cost of committed-but-unused capacity.
+type BacktestResult struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+
+	UtilizationPct *float64 `json:"utilization_pct,omitempty"`
+
+	Daily              []BacktestDay  `json:"daily"`
+	ExpiryDistribution []ExpiryBucket `json:"expiry_distribution"`
+
+	CoveragePct          float64 `json:"coverage_pct"`
+	SpendUSD             float64 `json:"spend_usd"`
+	CommitmentUSD        float64 `json:"commitment_usd"`
+	OnDemandUSD          float64 `json:"on_demand_usd"`
+	OnDemandOnlySpendUSD float64 `json:"on_demand_only_spend_usd"`
+	SavingsUSD           float64 `json:"savings_usd"`
+	WasteUSD             float64 `json:"waste_usd"`
+	// EndCommittedUSDPerHour is the active commitment on the last replayed
+	// day; PendingUSDPerHour is scheduled tranche volume that had not fired
+	// by then.
+	EndCommittedUSDPerHour float64 `json:"end_committed_usd_per_hour"`
+	PendingUSDPerHour      float64 `json:"pending_usd_per_hour"`
+
+	Days              int `json:"days"`
+	Runs              int `json:"runs"`
+	Purchases         int `json:"purchases"`
+	TranchesScheduled int `json:"tranches_scheduled"`
+	TrancheFirings    int `json:"tranche_firings"`
+	PendingTranches   int `json:"pending_tranches"`
+	Expiries          int `json:"expiries"`
+	Reshapes          int `json:"reshapes"`
+	Holds             int `json:"holds"`
+}
+
+// simCommitment is one simulated active commitment.
+type simCommitment struct {
+	end        time.Time
+	layer      LayerType
+	usdPerHour float64
+}
+
+// simTranche is one scheduled, not yet fired, simulated tranche.
+type simTranche struct {
+	fireAfter  time.Time
+	layer      LayerType
+	usdPerHour float64
+}
+
+// backtestSim is the mutable state of one Backtest replay.
+type backtestSim struct {
+	in          *BacktestInput
+	termDays    int
+	cadenceDays int
+	active      []simCommitment
+	pending     []simTranche
+	bought      []simCommitment
+	// lastUtil and lastCoverage hold the previous day's per-layer metrics;
+	// nil before the first replayed day, matching a live run with no
+	// utilization data yet.
+	lastUtil     map[LayerType]float64
+	lastCoverage map[LayerType]float64
+	nextID       int
+	result       BacktestResult
+	committedUSD float64
+	coveredUSD   float64
+	usageUSD     float64
+}
+
+// Backtest replays a historical daily on-demand series through Allocate and
+// BuildTranches day by day, exactly as the scheduled ladder run would have
+// seen it, and reports realised coverage, waste, spend, and the expiry
+// distribution of the commitments it would have bought.
+//
+// Each replayed day, in order:
+//  1. scheduled tranches whose FireAfter has arrived become active
+//     commitments, and commitments whose term has ended expire;
+//  2. on cadence days (every day for CadenceDaily, every 7th for
+//     CadenceWeekly) a run is simulated: layer states from the active
+//     commitments and the previous day's utilization, a baseline from the
+//     preceding LookbackDays of the series via BaselineFromDailySeries, and
+//     in-flight volume from the unfired tranches. Buy-now actions become
+//     active the same day; future tranches are scheduled;
+//  3. the day's usage is covered by the active commitments, most specific
+//     layer first (buffer, base, then flex), and spend and waste accrue.
+//
+// Reshapes are counted but do not change coverage: an aggregate series
+// carries no instance shape, so an exchange is modelled as value-preserving.
+//
+// Backtest performs no I/O and never calls time.Now; it is deterministic for
+// a given input.
+func Backtest(in *BacktestInput) (*BacktestResult, error) {
+	if err := validateBacktestInput(in); err != nil {
+		return nil, err
+	}
+	sim := &backtestSim{
+		in:          in,
+		termDays:    termDays(in.Term),
+		cadenceDays: cadenceDays(in.Config.Cadence),
+	}
+	for i := in.Config.LookbackDays; i < len(in.Series); i++ {
+		if err := sim.replayDay(i); err != nil {
+			return nil, err
+		}
+	}
+	return sim.finish(), nil
+}
+
+// validateBacktestInput checks the config, term, payment option, discounts,
+// and that the series is a gap-free daily series long enough to replay at
+// least one day after the lookback warm-up. Layers are validated by Allocate
+// on the first simulated run.
+func validateBacktestInput(in *BacktestInput) error {
+	if in == nil {
+		return fmt.Errorf("backtest input must not be nil")
+	}
+	if err := in.Config.Validate(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := in.Term.Validate(); err != nil {
+		return fmt.Errorf("term: %w", err)
+	}
+	if err := in.PaymentOption.Validate(); err != nil {
+		return fmt.Errorf("payment_option: %w", err)
+	}
+	for layer, d := range in.DiscountPct {
+		if err := layer.Validate(); err != nil {
+			return fmt.Errorf("discount_pct: %w", err)
+		}
+		if math.IsNaN(d) || d < 0 || d >= 100 {
+			return fmt.Errorf("discount_pct[%s] %g must be in [0, 100)", layer, d)
+		}
+	}
+	if len(in.Series) <= in.Config.LookbackDays {
+		return fmt.Errorf(
+			"series has %d days; at least %d are needed (lookback_days %d of warm-up plus one replayed day)",
+			len(in.Series), in.Config.LookbackDays+1, in.Config.LookbackDays)
+	}
+	return validateBacktestSeries(in.Series)
+}
+
+// validateBacktestSeries requires consecutive UTC days with finite,
+// non-negative values. A gap would silently shift every later run's
+// baseline window, so it is rejected rather than interpolated.
+func validateBacktestSeries(series []DailyPoint) error {
+	for i, p := range series {
+		if math.IsNaN(p.USDPerHour) || math.IsInf(p.USDPerHour, 0) || p.USDPerHour < 0 {
+			return fmt.Errorf("series[%d] (%s): usd_per_hour %g must be finite and >= 0", i, p.Date.Format("2006-01-02"), p.USDPerHour)
+		}
+		if i == 0 {
+			continue
+		}
+		want := utcDay(series[i-1].Date).AddDate(0, 0, 1)
+		if got := utcDay(p.Date); !got.Equal(want) {
+			return fmt.Errorf("series[%d] is %s, expected %s: the series must hold one point per consecutive UTC day",
+				i, got.Format("2006-01-02"), want.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// termDays is the simulated lifetime of a commitment bought with term t.
+func termDays(t Term) int {
+	if t == Term3Year {
+		return 3 * 365
+	}
+	return 365
+}
+
+// cadenceDays is the simulated interval between runs for cadence c.
+func cadenceDays(c LadderCadence) int {
+	if c == CadenceWeekly {
+		return 7
+	}
+	return 1
+}
+
+// replayDay advances the simulation through series[i].
+func (s *backtestSim) replayDay(i int) error {
+	day := utcDay(s.in.Series[i].Date)
+	s.fireTranches(day)
+	s.expireCommitments(day)
+	if (i-s.in.Config.LookbackDays)%s.cadenceDays == 0 {
+		if err := s.runLadder(i, day); err != nil {
+			return fmt.Errorf("run on %s: %w", day.Format("2006-01-02"), err)
+		}
+	}
+	s.accountDay(day, s.in.Series[i].USDPerHour)
+	return nil
+}
+
+// fireTranches activates every pending tranche whose FireAfter is on or
+// before day.
+func (s *backtestSim) fireTranches(day time.Time) {
+	kept := s.pending[:0]
+	for _, tr := range s.pending {
+		if tr.fireAfter.After(day) {
+			kept = append(kept, tr)
+			continue
+		}
+		s.activate(tr.layer, tr.usdPerHour, day)
+		s.result.TrancheFirings++
+	}
+	s.pending = kept
+}
+
+// expireCommitments drops every active commitment whose term has ended.
+func (s *backtestSim) expireCommitments(day time.Time) {
+	kept := s.active[:0]
+	for _, c := range s.active {
+		if c.end.After(day) {
+			kept = append(kept, c)
+			continue
+		}
+		s.result.Expiries++
+	}
+	s.active = kept
+}
+
+// activate starts a commitment of amount USD/h on layer from day.
+func (s *backtestSim) activate(layer LayerType, amount float64, day time.Time) {
+	c := simCommitment{layer: layer, usdPerHour: amount, end: day.AddDate(0, 0, s.termDays)}
+	s.active = append(s.active, c)
+	s.bought = append(s.bought, c)
+}
+
+// runLadder simulates one scheduled ladder run on day, using series[i-
+// LookbackDays:i] (the days strictly before day) for the baseline.
+func (s *backtestSim) runLadder(i int, day time.Time) error {
+	cfg := s.in.Config
+	baseline, err := BaselineFromDailySeries(s.in.Series[i-cfg.LookbackDays:i], cfg.LookbackDays, cfg.BaselinePercentile, day)
+	if err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	inFlight := 0.0
+	for _, tr := range s.pending {
+		inFlight += tr.usdPerHour
+	}
+
+	alloc, err := Allocate(&AllocationInput{
+		Now:                day,
+		LayerStates:        s.layerStates(day),
+		Layers:             s.in.Layers,
+		DataSources:        []string{DataSourceBacktest},
+		Baseline:           baseline,
+		Config:             cfg,
+		InFlightUSDPerHour: &inFlight,
+	})
+	if err != nil {
+		return fmt.Errorf("allocate: %w", err)
+	}
+	s.result.Runs++
+	s.result.Reshapes += len(alloc.Reshapes)
+	s.result.Holds += len(alloc.Holds)
+	if len(alloc.Allocations) == 0 {
+		return nil
+	}
+
+	tranches, err := BuildTranches(&TrancheInput{
+		Config:        &cfg,
+		RunID:         "backtest-" + day.Format("2006-01-02"),
+		Term:          s.in.Term,
+		PaymentOption: s.in.PaymentOption,
+		NewID:         s.newID,
+		Now:           day,
+		Allocations:   alloc.Allocations,
+	})
+	if err != nil {
+		return fmt.Errorf("build tranches: %w", err)
+	}
+	for _, a := range tranches.BuyNow {
+		s.activate(a.Layer, ratToFloat(a.AmountUSDPerHour), day)
+		s.result.Purchases++
+	}
+	for _, tr := range tranches.Tranches {
+		amount, ok := new(big.Rat).SetString(tr.AmountUSDPerHour)
+		if !ok {
+			return fmt.Errorf("tranche %s: amount %q is not a rational", tr.ID, tr.AmountUSDPerHour)
+		}
+		s.pending = append(s.pending, simTranche{layer: tr.Layer, usdPerHour: ratToFloat(amount), fireAfter: tr.FireAfter})
+		s.result.TranchesScheduled++
+	}
+	return nil
+}
+
+// newID is the deterministic tranche ID generator injected into
+// BuildTranches.
+func (s *backtestSim) newID() string {
+	s.nextID++
+	return "backtest-tranche-" + strconv.Itoa(s.nextID)
+}
+
+// layerStates snapshots the simulated commitments the way a provider's
+// GetLayerStates would: explicit zeros for empty layers, the share expiring
+// before the next run, and the previous day's utilization and coverage (nil
+// when the layer held no commitment that day).
+func (s *backtestSim) layerStates(day time.Time) map[LayerType]LayerState {
+	horizon := day.AddDate(0, 0, s.cadenceDays)
+	states := make(map[LayerType]LayerState, len(s.in.Layers))
+	for _, ls := range s.in.Layers {
+		existing, expiring := 0.0, 0.0
+		for _, c := range s.active {
+			if c.layer != ls.Type {
+				continue
+			}
+			existing += c.usdPerHour
+			if !c.end.After(horizon) {
+				expiring += c.usdPerHour
+			}
+		}
+		st := LayerState{Layer: ls.Type, ExistingUSDPerHour: &existing, ExpiringUSDPerHour: &expiring}
+		if u, ok := s.lastUtil[ls.Type]; ok {
+			st.UtilizationPct = &u
+		}
+		if c, ok := s.lastCoverage[ls.Type]; ok {
+			st.CoveragePct = &c
+		}
+		states[ls.Type] = st
+	}
+	return states
+}
+
+// coverageOrder is the order usage is applied to layers: the most specific
+// commitment first, as the providers' billing engines do (RIs before
+// instance-family SPs before compute-wide SPs).
+func (s *backtestSim) coverageOrder() []LayerType {
+	rank := func(ls LayerSpec) int {
+		switch {
+		case slices.Contains(ls.Roles, RoleBuffer):
+			return 0
+		case slices.Contains(ls.Roles, RoleBase):
+			return 1
+		default:
+			return 2
+		}
+	}
+	specs := slices.Clone(s.in.Layers)
+	sort.SliceStable(specs, func(a, b int) bool { return rank(specs[a]) < rank(specs[b]) })
+	order := make([]LayerType, len(specs))
+	for i, ls := range specs {
+		order[i] = ls.Type
+	}
+	return order
+}
+
+// accountDay covers usage (USD/h) with the active commitments and accrues
+// the day's spend, waste, and per-layer metrics.
+func (s *backtestSim) accountDay(day time.Time, usage float64) {
+	committed := make(map[LayerType]float64, len(s.in.Layers))
+	for _, c := range s.active {
+		committed[c.layer] += c.usdPerHour
+	}
+
+	util := make(map[LayerType]float64, len(committed))
+	coverage := make(map[LayerType]float64, len(committed))
+	remaining := usage
+	var totalCommitted, covered, commitCostPerHour, wastePerHour float64
+	for _, layer := range s.coverageOrder() {
+		amount := committed[layer]
+		if amount <= 0 {
+			continue
+		}
+		used := math.Min(remaining, amount)
+		remaining -= used
+		rate := 1 - s.in.DiscountPct[layer]/100
+		totalCommitted += amount
+		covered += used
+		commitCostPerHour += amount * rate
+		wastePerHour += (amount - used) * rate
+		util[layer] = used / amount * 100
+		if usage > 0 {
+			coverage[layer] = used / usage * 100
+		}
+	}
+	s.lastUtil, s.lastCoverage = util, coverage
+
+	onDemandPerHour := usage - covered
+	d := BacktestDay{
+		Date:                day,
+		UsageUSDPerHour:     usage,
+		CommittedUSDPerHour: totalCommitted,
+		CoveredUSDPerHour:   covered,
+		WasteUSD:            wastePerHour * 24,
+		SpendUSD:            (commitCostPerHour + onDemandPerHour) * 24,
+	}
+	s.result.Daily = append(s.result.Daily, d)
+	s.result.WasteUSD += d.WasteUSD
+	s.result.SpendUSD += d.SpendUSD
+	s.result.CommitmentUSD += commitCostPerHour * 24
+	s.result.OnDemandUSD += onDemandPerHour * 24
+	s.result.OnDemandOnlySpendUSD += usage * 24
+	s.usageUSD += usage
+	s.coveredUSD += covered
+	s.committedUSD += totalCommitted
+}
+
+// finish computes the aggregate ratios, the pending-tranche summary, and
+// the expiry distribution.
+func (s *backtestSim) finish() *BacktestResult {
+	r := &s.result
+	r.Days = len(r.Daily)
+	r.StartDate = r.Daily[0].Date
+	r.EndDate = r.Daily[len(r.Daily)-1].Date
+	r.EndCommittedUSDPerHour = r.Daily[len(r.Daily)-1].CommittedUSDPerHour
+	r.SavingsUSD = r.OnDemandOnlySpendUSD - r.SpendUSD
+	if s.usageUSD > 0 {
+		r.CoveragePct = s.coveredUSD / s.usageUSD * 100
+	}
+	if s.committedUSD > 0 {
+		u := s.coveredUSD / s.committedUSD * 100
+		r.UtilizationPct = &u
+	}
+	r.PendingTranches = len(s.pending)
+	for _, tr := range s.pending {
+		r.PendingUSDPerHour += tr.usdPerHour
+	}
+	r.ExpiryDistribution = expiryDistribution(s.bought)
+	return r
+}
+
+// expiryDistribution buckets commitments by expiry month, oldest first.
+func expiryDistribution(commitments []simCommitment) []ExpiryBucket {
+	byMonth := make(map[string]*ExpiryBucket)
+	for _, c := range commitments {
+		month := c.end.Format("2006-01")
+		b, ok := byMonth[month]
+		if !ok {
+			b = &ExpiryBucket{Month: month}
+			byMonth[month] = b
+		}
+		b.USDPerHour += c.usdPerHour
+		b.Count++
+	}
+	out := make([]ExpiryBucket, 0, len(byMonth))
+	for _, b := range byMonth {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month < out[j].Month })
+	return out
+}
+
+// ratToFloat converts an engine amount to float64 for accounting. The
+// replay only sums and compares amounts, so float precision is sufficient
+// here; exactness matters in the money path, not in a report.
+func ratToFloat(r *big.Rat) float64 {
+	f, _ := r.Float64()
+	return f
+}
@@ -0,0 +1,77 @@
+package ladder
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seriesDateLayout is the calendar-day format of series fixtures.
+const seriesDateLayout = "2006-01-02"
+
+// SeriesPoint is the wire form of a DailyPoint in JSON series fixtures and
+// API payloads: {"date": "2026-01-31", "usd_per_hour": 12.5}.
+type SeriesPoint struct {
+	Date       string  `json:"date"`
+	USDPerHour float64 `json:"usd_per_hour"`
+}
+
+// DailyPointsFromSeries parses wire-form points into DailyPoints. Every date
+// must be a YYYY-MM-DD calendar day.
+func DailyPointsFromSeries(points []SeriesPoint) ([]DailyPoint, error) {
+	out := make([]DailyPoint, len(points))
+	for i, p := range points {
+		d, err := time.Parse(seriesDateLayout, p.Date)
+		if err != nil {
+			return nil, fmt.Errorf("series[%d]: date %q is not YYYY-MM-DD", i, p.Date)
+		}
+		out[i] = DailyPoint{Date: d, USDPerHour: p.USDPerHour}
+	}
+	return out, nil
+}
+
+// ParseDailySeriesJSON parses a JSON array of SeriesPoint.
+func ParseDailySeriesJSON(data []byte) ([]DailyPoint, error) {
+	var points []SeriesPoint
+	if err := json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("series JSON: %w", err)
+	}
+	return DailyPointsFromSeries(points)
+}
+
+// ParseDailySeriesCSV parses a two-column CSV of date (YYYY-MM-DD) and
+// usd_per_hour. A first row whose first cell is not a date is treated as a
+// header and skipped.
+func ParseDailySeriesCSV(r io.Reader) ([]DailyPoint, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+
+	var out []DailyPoint
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("series CSV: %w", err)
+		}
+		d, err := time.Parse(seriesDateLayout, strings.TrimSpace(rec[0]))
+		if err != nil {
+			if row == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("series CSV row %d: date %q is not YYYY-MM-DD", row, rec[0])
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("series CSV row %d: usd_per_hour %q: %w", row, rec[1], err)
+		}
+		out = append(out, DailyPoint{Date: d, USDPerHour: v})
+	}
+}
@@ -0,0 +1,263 @@
+package ladder
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// backtestConfig returns a valid daily-cadence AWS config with a 30-day
+// lookback and the given ramp.
+func backtestConfig(ramp RampSchedule) LadderConfig {
+	cfg := validConfigAWS()
+	cfg.Cadence = CadenceDaily
+	cfg.Ramp = ramp
+	return cfg
+}
+
+// backtestInput replays n days of flat usage after the 30-day warm-up.
+func backtestInput(cfg LadderConfig, usage func(i int) float64, replayDays int) *BacktestInput {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := cfg.LookbackDays + replayDays
+	return &BacktestInput{
+		Config:        cfg,
+		Layers:        awsLayers(),
+		Series:        dailySeries(start.AddDate(0, 0, n-1), n, usage),
+		Term:          Term1Year,
+		PaymentOption: PaymentNoUpfront,
+	}
+}
+
+func flat(v float64) func(int) float64 { return func(int) float64 { return v } }
+
+func TestBacktest_FlatUsageIsFullyCoveredWithoutWaste(t *testing.T) {
+	t.Parallel()
+	in := backtestInput(backtestConfig(singleStepRamp()), flat(10), 60)
+	in.DiscountPct = map[LayerType]float64{LayerEC2InstanceSP: 30, LayerComputeSP: 30, LayerConvertibleRI: 30}
+
+	got, err := Backtest(in)
+	if err != nil {
+		t.Fatalf("Backtest: %v", err)
+	}
+	if got.Days != 60 || got.Runs != 60 {
+		t.Fatalf("days/runs = %d/%d, want 60/60", got.Days, got.Runs)
+	}
+	if got.Purchases == 0 {
+		t.Fatal("expected the first run to buy")
+	}
+	if math.Abs(got.CoveragePct-100) > 1e-9 {
+		t.Errorf("CoveragePct = %g, want 100", got.CoveragePct)
+	}
+	if got.UtilizationPct == nil || math.Abs(*got.UtilizationPct-100) > 1e-9 {
+		t.Errorf("UtilizationPct = %v, want 100", got.UtilizationPct)
+	}
+	if got.WasteUSD > 1e-9 {
+		t.Errorf("WasteUSD = %g, want 0", got.WasteUSD)
+	}
+	wantSavings := 0.30 * 10 * 24 * 60
+	if math.Abs(got.SavingsUSD-wantSavings) > 1e-6 {
+		t.Errorf("SavingsUSD = %g, want %g", got.SavingsUSD, wantSavings)
+	}
+	if got.StartDate.Format("2006-01-02") != "2026-01-31" {
+		t.Errorf("StartDate = %s, want the day after the 30-day warm-up", got.StartDate.Format("2006-01-02"))
+	}
+}
+
+func TestBacktest_UsageDropProducesWaste(t *testing.T) {
+	t.Parallel()
+	// 10 USD/h through the warm-up and first 10 replayed days, then 4.
+	in := backtestInput(backtestConfig(singleStepRamp()), func(i int) float64 {
+		if i < 40 {
+			return 10
+		}
+		return 4
+	}, 30)
+
+	got, err := Backtest(in)
+	if err != nil {
+		t.Fatalf("Backtest: %v", err)
+	}
+	// 6 USD/h idle for 20 days at 0% discount.
+	if want := 6.0 * 24 * 20; math.Abs(got.WasteUSD-want) > 1e-6 {
+		t.Errorf("WasteUSD = %g, want %g", got.WasteUSD, want)
+	}
+	if got.SavingsUSD >= 0 {
+		t.Errorf("SavingsUSD = %g; an undiscounted over-commitment must cost more than on-demand", got.SavingsUSD)
+	}
+	last := got.Daily[len(got.Daily)-1]
+	if last.CoveredUSDPerHour != 4 || last.CommittedUSDPerHour != 10 {
+		t.Errorf("last day covered/committed = %g/%g, want 4/10", last.CoveredUSDPerHour, last.CommittedUSDPerHour)
+	}
+}
+
+func TestBacktest_TranchesFireAndCountAsInFlight(t *testing.T) {
+	t.Parallel()
+	in := backtestInput(backtestConfig(threeStepRamp()), flat(10), 90)
+
+	got, err := Backtest(in)
+	if err != nil {
+		t.Fatalf("Backtest: %v", err)
+	}
+	// The first run buys 40% of the flex and buffer allocations now and
+	// schedules 30% of each at +30d and +60d; in-flight accounting keeps every
+	// later run from re-buying the scheduled volume.
+	if got.Purchases != 2 || got.TranchesScheduled != 4 || got.TrancheFirings != 4 {
+		t.Fatalf("purchases/scheduled/fired = %d/%d/%d, want 2/4/4", got.Purchases, got.TranchesScheduled, got.TrancheFirings)
+	}
+	if got.PendingTranches != 0 || got.PendingUSDPerHour != 0 {
+		t.Errorf("pending = %d (%g USD/h), want none", got.PendingTranches, got.PendingUSDPerHour)
+	}
+	if math.Abs(got.EndCommittedUSDPerHour-10) > 1e-9 {
+		t.Errorf("EndCommittedUSDPerHour = %g, want 10", got.EndCommittedUSDPerHour)
+	}
+	if got.CoveragePct >= 100 || got.CoveragePct <= 40 {
+		t.Errorf("CoveragePct = %g, want between the 40%% first step and 100%%", got.CoveragePct)
+	}
+
+	// Every purchase expires one year after it activates: one bucket per step.
+	if len(got.ExpiryDistribution) != 3 {
+		t.Fatalf("ExpiryDistribution = %+v, want 3 monthly buckets", got.ExpiryDistribution)
+	}
+	if got.ExpiryDistribution[0].Month != "2027-01" || got.ExpiryDistribution[2].Month != "2027-04" {
+		t.Errorf("ExpiryDistribution months = %+v", got.ExpiryDistribution)
+	}
+}
+
+func TestBacktest_WeeklyCadenceRunsEverySeventhDay(t *testing.T) {
+	t.Parallel()
+	cfg := backtestConfig(singleStepRamp())
+	cfg.Cadence = CadenceWeekly
+
+	got, err := Backtest(backtestInput(cfg, flat(5), 15))
+	if err != nil {
+		t.Fatalf("Backtest: %v", err)
+	}
+	if got.Runs != 3 {
+		t.Errorf("Runs = %d, want 3 (days 0, 7, 14)", got.Runs)
+	}
+}
+
+func TestBacktest_RejectsBadInput(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		mutate func(*BacktestInput)
+		want   string
+	}{
+		{"nil series", func(in *BacktestInput) { in.Series = nil }, "at least 31"},
+		{"gap", func(in *BacktestInput) { in.Series = append(in.Series[:10], in.Series[11:]...) }, "consecutive"},
+		{"negative", func(in *BacktestInput) { in.Series[3].USDPerHour = -1 }, "finite and >= 0"},
+		{"term", func(in *BacktestInput) { in.Term = "5yr" }, "term"},
+		{"discount", func(in *BacktestInput) { in.DiscountPct = map[LayerType]float64{LayerComputeSP: 100} }, "discount_pct"},
+		{"config", func(in *BacktestInput) { in.Config.LookbackDays = 0 }, "config"},
+		{"layers", func(in *BacktestInput) { in.Layers = nil }, "allocate"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			in := backtestInput(backtestConfig(singleStepRamp()), flat(10), 5)
+			tc.mutate(in)
+			_, err := Backtest(in)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestProviderLayers(t *testing.T) {
+	t.Parallel()
+	for _, p := range []common.ProviderType{common.ProviderAWS, common.ProviderAzure, common.ProviderGCP} {
+		layers, err := ProviderLayers(p)
+		if err != nil {
+			t.Fatalf("ProviderLayers(%s): %v", p, err)
+		}
+		if err := validateLayers(layers); err != nil {
+			t.Errorf("ProviderLayers(%s) fails engine validation: %v", p, err)
+		}
+	}
+	if _, err := ProviderLayers("oci"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestScopeLayers(t *testing.T) {
+	t.Parallel()
+	for _, svc := range []common.ServiceType{common.ServiceRDS, common.ServiceElastiCache, common.ServiceOpenSearch} {
+		layers, err := ScopeLayers(Scope{Provider: common.ProviderAWS, AccountID: "123456789012", Service: svc})
+		if err != nil {
+			t.Fatalf("ScopeLayers(%s): %v", svc, err)
+		}
+		if len(layers) != 1 {
+			t.Fatalf("ScopeLayers(%s) = %+v, want a single layer", svc, layers)
+		}
+		if err := validateLayers(layers); err != nil {
+			t.Errorf("ScopeLayers(%s) fails engine validation: %v", svc, err)
+		}
+	}
+	if _, err := ReservedCapacityLayers(common.ServiceRedshift); err == nil {
+		t.Error("expected an error for a service without a reserved-capacity layer")
+	}
+	layers, err := ScopeLayers(Scope{Provider: common.ProviderAWS, AccountID: "123456789012"})
+	if err != nil || len(layers) != 3 {
+		t.Errorf("ScopeLayers(compute) = %+v, %v; want the AWS compute layers", layers, err)
+	}
+}
+
+// TestBacktest_RDSLadderStaggersExpiries pins that a service-scoped ladder
+// tranches its single RI layer like compute: three ramp steps buy three
+// cohorts that expire in three different months.
+func TestBacktest_RDSLadderStaggersExpiries(t *testing.T) {
+	t.Parallel()
+	cfg := backtestConfig(threeStepRamp())
+	cfg.Scope.Service = common.ServiceRDS
+	cfg.BufferFraction = 0
+	in := backtestInput(cfg, flat(10), 90)
+	in.Layers, _ = ReservedCapacityLayers(common.ServiceRDS)
+
+	got, err := Backtest(in)
+	if err != nil {
+		t.Fatalf("Backtest: %v", err)
+	}
+	if got.Purchases != 1 || got.TrancheFirings != 2 {
+		t.Fatalf("purchases/fired = %d/%d, want 1/2", got.Purchases, got.TrancheFirings)
+	}
+	if len(got.ExpiryDistribution) != 3 {
+		t.Errorf("ExpiryDistribution = %+v, want 3 monthly buckets", got.ExpiryDistribution)
+	}
+
+	cfg.BufferFraction = 0.1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a service-scoped config with a buffer fraction to fail validation")
+	}
+}
+
+func TestParseDailySeries(t *testing.T) {
+	t.Parallel()
+	csvPoints, err := ParseDailySeriesCSV(strings.NewReader("date,usd_per_hour\n2026-01-01, 1.5\n2026-01-02,2\n"))
+	if err != nil {
+		t.Fatalf("ParseDailySeriesCSV: %v", err)
+	}
+	jsonPoints, err := ParseDailySeriesJSON([]byte(`[{"date":"2026-01-01","usd_per_hour":1.5},{"date":"2026-01-02","usd_per_hour":2}]`))
+	if err != nil {
+		t.Fatalf("ParseDailySeriesJSON: %v", err)
+	}
+	for _, got := range [][]DailyPoint{csvPoints, jsonPoints} {
+		if len(got) != 2 || got[0].USDPerHour != 1.5 || !got[1].Date.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("parsed %+v", got)
+		}
+	}
+
+	if _, err := ParseDailySeriesCSV(strings.NewReader("2026-01-01,1\nnot-a-date,2\n")); err == nil {
+		t.Error("expected an error for a bad date after the first row")
+	}
+	if _, err := ParseDailySeriesCSV(strings.NewReader("2026-01-01,abc\n")); err == nil {
+		t.Error("expected an error for a bad value")
+	}
+	if _, err := ParseDailySeriesJSON([]byte(`[{"date":"01/02/2026","usd_per_hour":1}]`)); err == nil {
+		t.Error("expected an error for a non-ISO date")
+	}
+}
@@ -0,0 +1,171 @@
+package ladder
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MinBaselineSeriesDays is the shortest daily series BaselineFromDailySeries
+// accepts. Fewer days cannot produce a statistically meaningful low-water
+// mark; callers should configure LookbackDays >= MinBaselineSeriesDays.
+const MinBaselineSeriesDays = 7
+
+// MaxMissingBaselineDays is the number of calendar days that may be absent
+// from the series inside the lookback window and still yield a trustworthy
+// baseline. Provider billing feeds (AWS CE, Azure consumption, GCP billing
+// export) all lag 24-48 hours, so a 30-day window typically returns 28-29
+// points; one extra day of tolerance is provided.
+const MaxMissingBaselineDays = 3
+
+// MaxBaselineSeriesAgeDays is the maximum age in calendar days of the most
+// recent point before the series is considered stale. A point older than this
+// means the billing feed has stalled or the requested date range is wrong.
+const MaxBaselineSeriesAgeDays = 3
+
+// DailyPoint is a single calendar-day entry in an on-demand cost series.
+// Date is the UTC calendar day (time-of-day is ignored; every comparison
+// truncates to midnight UTC). USDPerHour is the day's on-demand spend divided
+// by 24.
+type DailyPoint struct {
+	// Date is the UTC calendar day this data point covers.
+	Date time.Time
+	// USDPerHour is the on-demand-equivalent spend averaged over the day.
+	USDPerHour float64
+}
+
+// BaselineFromDailySeries turns a provider's daily on-demand series into a
+// UsageBaseline. It is the provider-neutral half of GetUsageBaseline: each
+// LadderCapability fetches the series from its own billing source and
+// delegates the validation and percentile math here, so every provider
+// rejects the same malformed inputs with the same errors.
+//
+// Validation, in order (all hard errors):
+//   - lookbackDays must be > 0 and percentile in (0, 100];
+//   - the series must hold at least MinBaselineSeriesDays points;
+//   - at most MaxMissingBaselineDays days may be missing INSIDE the window
+//     [today-lookbackDays, today] (points outside the window do not count);
+//   - the series must be strictly increasing by UTC day and its newest point
+//     no older than MaxBaselineSeriesAgeDays;
+//   - every value must be finite and >= 0.
+//
+// LowWaterUSDPerHour is the nearest-rank percentile of the series.
+// StableUSDPerHour is left nil: no provider has a stable-usage estimator yet,
+// and nil triggers the engine's documented "route all core gap to flex"
+// degradation. Aliasing it to LowWater would over-commit the base layer.
+func BaselineFromDailySeries(points []DailyPoint, lookbackDays int, percentile float64, now time.Time) (UsageBaseline, error) {
+	if lookbackDays <= 0 {
+		return UsageBaseline{}, fmt.Errorf("lookbackDays %d must be > 0", lookbackDays)
+	}
+	if math.IsNaN(percentile) || !(percentile > 0 && percentile <= 100) {
+		return UsageBaseline{}, fmt.Errorf("percentile %g must be in (0, 100]", percentile)
+	}
+	if len(points) == 0 {
+		return UsageBaseline{}, fmt.Errorf("on-demand series is empty (series source returned no data)")
+	}
+	if len(points) < MinBaselineSeriesDays {
+		return UsageBaseline{}, fmt.Errorf(
+			"series length %d is below minimum %d days; extend the lookback window or check the on-demand series source",
+			len(points), MinBaselineSeriesDays)
+	}
+
+	today := utcDay(now)
+	if err := validateInWindowCoverage(points, lookbackDays, today); err != nil {
+		return UsageBaseline{}, err
+	}
+	if err := validateSeriesChronology(points); err != nil {
+		return UsageBaseline{}, err
+	}
+	latest := utcDay(points[len(points)-1].Date)
+	if ageDays := int(today.Sub(latest).Hours() / 24); ageDays > MaxBaselineSeriesAgeDays {
+		return UsageBaseline{}, fmt.Errorf(
+			"on-demand series is stale: most recent data point is %s (%d days old, maximum %d)",
+			latest.Format("2006-01-02"), ageDays, MaxBaselineSeriesAgeDays)
+	}
+
+	series := make([]float64, len(points))
+	for i, p := range points {
+		if math.IsNaN(p.USDPerHour) || math.IsInf(p.USDPerHour, 0) {
+			return UsageBaseline{}, fmt.Errorf("series element at index %d is not finite (%g)", i, p.USDPerHour)
+		}
+		if p.USDPerHour < 0 {
+			return UsageBaseline{}, fmt.Errorf("series element at index %d is negative (%g); on-demand cost values must be >= 0", i, p.USDPerHour)
+		}
+		series[i] = p.USDPerHour
+	}
+
+	lowWater, err := NearestRankPercentile(series, percentile)
+	if err != nil {
+		return UsageBaseline{}, err
+	}
+	return UsageBaseline{
+		LowWaterUSDPerHour: &lowWater,
+		Series:             series,
+		LookbackDays:       lookbackDays,
+		Percentile:         percentile,
+	}, nil
+}
+
+// NearestRankPercentile returns the p-th percentile of data using the
+// nearest-rank method: rank = ceil(p/100 * N), clamped to [1, N], over the
+// sorted values. The result is always a member of data. data is not
+// modified. Callers must reject NaN values first; the sort order is
+// undefined with them.
+func NearestRankPercentile(data []float64, p float64) (float64, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("NearestRankPercentile: empty data slice")
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100.0 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1], nil
+}
+
+// utcDay normalizes t to midnight UTC of its calendar day.
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// validateInWindowCoverage rejects a series without enough points inside
+// [today-lookbackDays, today]. Counting total points is not enough: N points
+// smeared over more than N days would pass a count check while leaving the
+// requested window sparsely covered.
+func validateInWindowCoverage(points []DailyPoint, lookbackDays int, today time.Time) error {
+	windowStart := today.AddDate(0, 0, -lookbackDays)
+	inWindow := 0
+	for _, p := range points {
+		d := utcDay(p.Date)
+		if !d.Before(windowStart) && !d.After(today) {
+			inWindow++
+		}
+	}
+	if minRequired := lookbackDays - MaxMissingBaselineDays; inWindow < minRequired {
+		return fmt.Errorf(
+			"on-demand series has %d points inside the %d-day lookback window (minimum %d); the series source may have gaps or a misaligned date range",
+			inWindow, lookbackDays, minRequired)
+	}
+	return nil
+}
+
+// validateSeriesChronology verifies the series is strictly increasing by UTC
+// calendar day. The freshness check trusts the last element to be the newest,
+// so an unsorted or duplicate-day series must fail before it is consulted.
+func validateSeriesChronology(points []DailyPoint) error {
+	for i := 1; i < len(points); i++ {
+		if !utcDay(points[i].Date).After(utcDay(points[i-1].Date)) {
+			return fmt.Errorf(
+				"on-demand series is not in strictly increasing date order: point %d (%s) does not come after point %d (%s)",
+				i, points[i].Date.Format("2006-01-02"), i-1, points[i-1].Date.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package ladder
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dailySeries builds n consecutive daily points ending at end (inclusive),
+// oldest first, with values produced by val(i).
+func dailySeries(end time.Time, n int, val func(i int) float64) []DailyPoint {
+	out := make([]DailyPoint, n)
+	start := utcDay(end).AddDate(0, 0, -(n - 1))
+	for i := 0; i < n; i++ {
+		out[i] = DailyPoint{Date: start.AddDate(0, 0, i), USDPerHour: val(i)}
+	}
+	return out
+}
+
+func TestBaselineFromDailySeries_HappyPath(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+	points := dailySeries(now.AddDate(0, 0, -1), 30, func(i int) float64 { return float64(i + 1) })
+
+	got, err := BaselineFromDailySeries(points, 30, 10, now)
+	if err != nil {
+		t.Fatalf("BaselineFromDailySeries: %v", err)
+	}
+	// rank = ceil(0.10 * 30) = 3 -> third-smallest value.
+	if got.LowWaterUSDPerHour == nil || *got.LowWaterUSDPerHour != 3 {
+		t.Errorf("LowWaterUSDPerHour = %v, want 3", got.LowWaterUSDPerHour)
+	}
+	if got.StableUSDPerHour != nil {
+		t.Errorf("StableUSDPerHour = %v, want nil", *got.StableUSDPerHour)
+	}
+	if len(got.Series) != 30 || got.LookbackDays != 30 || got.Percentile != 10 {
+		t.Errorf("unexpected echo fields: len=%d lookback=%d pct=%g", len(got.Series), got.LookbackDays, got.Percentile)
+	}
+}
+
+func TestBaselineFromDailySeries_Rejections(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	fresh := func(n int) []DailyPoint {
+		return dailySeries(now.AddDate(0, 0, -1), n, func(int) float64 { return 1 })
+	}
+
+	cases := []struct {
+		name     string
+		points   []DailyPoint
+		lookback int
+		pct      float64
+		wantSub  string
+	}{
+		{"zero lookback", fresh(30), 0, 10, "lookbackDays"},
+		{"percentile zero", fresh(30), 30, 0, "percentile"},
+		{"percentile NaN", fresh(30), 30, math.NaN(), "percentile"},
+		{"empty", nil, 30, 10, "empty"},
+		{"too short", fresh(5), 5, 10, "below minimum"},
+		{"sparse window", fresh(20), 30, 10, "inside the 30-day lookback window"},
+		{
+			"stale",
+			dailySeries(now.AddDate(0, 0, -4), 31, func(int) float64 { return 1 }),
+			30, 10, "stale",
+		},
+		{
+			"unsorted",
+			func() []DailyPoint {
+				p := fresh(30)
+				p[3], p[4] = p[4], p[3]
+				return p
+			}(),
+			30, 10, "strictly increasing",
+		},
+		{
+			"negative",
+			func() []DailyPoint {
+				p := fresh(30)
+				p[7].USDPerHour = -1
+				return p
+			}(),
+			30, 10, "index 7 is negative",
+		},
+		{
+			"infinite",
+			func() []DailyPoint {
+				p := fresh(30)
+				p[2].USDPerHour = math.Inf(1)
+				return p
+			}(),
+			30, 10, "index 2 is not finite",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := BaselineFromDailySeries(tc.points, tc.lookback, tc.pct, now)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tc.wantSub)
+			}
+			if !strings.Contains(err.Error(), tc.wantSub) {
+				t.Errorf("error = %q, want substring %q", err, tc.wantSub)
+			}
+		})
+	}
+}
+
+func TestNearestRankPercentile(t *testing.T) {
+	t.Parallel()
+	data := []float64{5, 1, 4, 2, 3}
+	cases := []struct {
+		p    float64
+		want float64
+	}{
+		{0.001, 1},
+		{20, 1},
+		{40, 2},
+		{50, 3},
+		{100, 5},
+	}
+	for _, tc := range cases {
+		got, err := NearestRankPercentile(data, tc.p)
+		if err != nil {
+			t.Fatalf("p=%g: %v", tc.p, err)
+		}
+		if got != tc.want {
+			t.Errorf("p=%g: got %g, want %g", tc.p, got, tc.want)
+		}
+	}
+	if data[0] != 5 {
+		t.Errorf("input slice was mutated: %v", data)
+	}
+	if _, err := NearestRankPercentile(nil, 50); err == nil {
+		t.Error("expected error for empty data")
+	}
+}
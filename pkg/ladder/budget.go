@@ -0,0 +1,52 @@
+package ladder
+
+import (
+	"context"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// budgetedCapability is a LadderCapability whose PurchaseLayer reserves
+// the organisation-wide commitment budget before the wrapped capability
+// buys anything. Every other method passes straight through.
+type budgetedCapability struct {
+	LadderCapability
+	reserver   budget.Reserver
+	accountIDs []string
+}
+
+// WithBudget returns capability with its layer purchases guarded by
+// reserver (see budget.Guard): a purchase the budget refuses never reaches
+// the provider and fails with a *budget.ExceededError. cloudAccountID is
+// the CUDly cloud account the capability buys into, so budgets scoped to
+// an account group holding it apply. A nil reserver returns capability
+// unchanged.
+func WithBudget(capability LadderCapability, reserver budget.Reserver, cloudAccountID string) LadderCapability {
+	if reserver == nil {
+		return capability
+	}
+	var accountIDs []string
+	if cloudAccountID != "" {
+		accountIDs = []string{cloudAccountID}
+	}
+	return &budgetedCapability{LadderCapability: capability, reserver: reserver, accountIDs: accountIDs}
+}
+
+// PurchaseLayer implements LadderCapability. The reservation is keyed by
+// opts.IdempotencyToken so a retried tranche reuses its reservation; a
+// purchase without a token gets a one-off reference.
+func (c *budgetedCapability) PurchaseLayer(ctx context.Context, layer LayerType, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	reference := opts.IdempotencyToken
+	if reference == "" {
+		token, err := common.GenerateApprovalToken()
+		if err != nil {
+			return common.PurchaseResult{}, err
+		}
+		reference = token
+	}
+	req := budget.FromRecommendation(budget.SourceLadder, reference, c.accountIDs, rec)
+	return budget.Guard(ctx, c.reserver, req, func(ctx context.Context) (common.PurchaseResult, error) {
+		return c.LadderCapability.PurchaseLayer(ctx, layer, rec, opts)
+	})
+}
@@ -0,0 +1,85 @@
+package ladder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/budget"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// purchaseRecorder is a LadderCapability whose only working method is
+// PurchaseLayer; the embedded nil interface panics if anything else is
+// called.
+type purchaseRecorder struct {
+	LadderCapability
+	calls int
+}
+
+func (p *purchaseRecorder) PurchaseLayer(_ context.Context, _ LayerType, _ common.Recommendation, _ common.PurchaseOptions) (common.PurchaseResult, error) {
+	p.calls++
+	return common.PurchaseResult{Success: true, CommitmentID: "sp-1"}, nil
+}
+
+type capReserver struct {
+	refuse   bool
+	reserved []budget.Request
+	commits  int
+}
+
+func (r *capReserver) Reserve(_ context.Context, req budget.Request) (string, error) {
+	if r.refuse {
+		return "", &budget.ExceededError{Budget: "org", Limit: budget.LimitHourly}
+	}
+	r.reserved = append(r.reserved, req)
+	return "res-1", nil
+}
+
+func (r *capReserver) Commit(context.Context, string, float64, float64) error {
+	r.commits++
+	return nil
+}
+
+func (r *capReserver) Release(context.Context, string, string) error { return nil }
+
+func TestWithBudget(t *testing.T) {
+	t.Parallel()
+	rec := common.Recommendation{Provider: common.ProviderAWS, Term: "1yr", Details: &common.SavingsPlanDetails{HourlyCommitment: 2}}
+	opts := common.PurchaseOptions{IdempotencyToken: "tok-1"}
+
+	t.Run("reserves then buys", func(t *testing.T) {
+		t.Parallel()
+		inner, r := &purchaseRecorder{}, &capReserver{}
+		res, err := WithBudget(inner, r, "acct-1").PurchaseLayer(context.Background(), LayerComputeSP, rec, opts)
+		if err != nil || !res.Success {
+			t.Fatalf("PurchaseLayer = %+v, %v; want success", res, err)
+		}
+		if inner.calls != 1 || r.commits != 1 {
+			t.Fatalf("calls=%d commits=%d, want 1 and 1", inner.calls, r.commits)
+		}
+		if got := r.reserved[0]; got.Source != budget.SourceLadder || got.Reference != "tok-1" || got.HourlyUSD != 2 ||
+			len(got.AccountIDs) != 1 || got.AccountIDs[0] != "acct-1" {
+			t.Fatalf("reserved %+v, want ladder/tok-1 at $2/h in acct-1", got)
+		}
+	})
+
+	t.Run("refused purchase never reaches the provider", func(t *testing.T) {
+		t.Parallel()
+		inner := &purchaseRecorder{}
+		_, err := WithBudget(inner, &capReserver{refuse: true}, "acct-1").PurchaseLayer(context.Background(), LayerComputeSP, rec, opts)
+		if err == nil {
+			t.Fatal("PurchaseLayer succeeded past the budget")
+		}
+		if inner.calls != 0 {
+			t.Fatalf("provider called %d times, want 0", inner.calls)
+		}
+	})
+
+	t.Run("nil reserver is a no-op", func(t *testing.T) {
+		t.Parallel()
+		inner := &purchaseRecorder{}
+		if got := WithBudget(inner, nil, "acct-1"); got != LadderCapability(inner) {
+			t.Fatalf("WithBudget(nil) wrapped the capability")
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package ladder
+
+import (
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// AWSLayers returns the AWS ladder layer set: EC2 Instance Savings Plans as
+// the base, Compute Savings Plans as the flex layer, and convertible RIs as
+// the exchangeable buffer.
+func AWSLayers() []LayerSpec {
+	return []LayerSpec{
+		{Type: LayerEC2InstanceSP, Roles: []LayerRole{RoleBase}},
+		{Type: LayerComputeSP, Roles: []LayerRole{RoleFlex}},
+		{Type: LayerConvertibleRI, Roles: []LayerRole{RoleBuffer}},
+	}
+}
+
+// AzureLayers returns the Azure ladder layer set: VM reservations carry both
+// the base and buffer roles (they are exchangeable), Savings Plans the flex
+// role.
+func AzureLayers() []LayerSpec {
+	return []LayerSpec{
+		{Type: LayerAzureReservation, Roles: []LayerRole{RoleBase, RoleBuffer}},
+		{Type: LayerAzureSavingsPlan, Roles: []LayerRole{RoleFlex}},
+	}
+}
+
+// GCPLayers returns the GCP ladder layer set: resource-based CUDs as the
+// base, spend-based flexible CUDs as the flex layer. GCP has no buffer layer.
+func GCPLayers() []LayerSpec {
+	return []LayerSpec{
+		{Type: LayerGCPResourceCUD, Roles: []LayerRole{RoleBase}},
+		{Type: LayerGCPFlexCUD, Roles: []LayerRole{RoleFlex}},
+	}
+}
+
+// ReservedCapacityLayerType returns the ladder layer for an AWS
+// reserved-capacity service: RDS, ElastiCache or OpenSearch.
+func ReservedCapacityLayerType(service common.ServiceType) (LayerType, error) {
+	switch service {
+	case common.ServiceRDS:
+		return LayerRDSRI, nil
+	case common.ServiceElastiCache:
+		return LayerElastiCacheRI, nil
+	case common.ServiceOpenSearch:
+		return LayerOpenSearchRI, nil
+	default:
+		return "", fmt.Errorf("service %q has no reserved-capacity ladder layer (allowed: %s, %s, %s)",
+			service, common.ServiceRDS, common.ServiceElastiCache, common.ServiceOpenSearch)
+	}
+}
+
+// ReservedCapacityLayers returns the layer set of a service-scoped AWS
+// ladder: the service's reserved-instance layer alone, carrying the flex
+// role. Database, cache and search RIs are not exchangeable and have no
+// cheaper family-locked tier, so there is no base or buffer layer and the
+// config's BufferFraction must be 0.
+func ReservedCapacityLayers(service common.ServiceType) ([]LayerSpec, error) {
+	layer, err := ReservedCapacityLayerType(service)
+	if err != nil {
+		return nil, err
+	}
+	return []LayerSpec{{Type: layer, Roles: []LayerRole{RoleFlex}}}, nil
+}
+
+// ScopeLayers returns the layer set for scope: the service's
+// reserved-capacity layer when Scope.Service is set, the provider's compute
+// layers (ProviderLayers) otherwise.
+func ScopeLayers(scope Scope) ([]LayerSpec, error) {
+	if scope.Service != "" {
+		return ReservedCapacityLayers(scope.Service)
+	}
+	return ProviderLayers(scope.Provider)
+}
+
+// ProviderLayers returns the layer set a provider's LadderCapability reports
+// from SupportedLayers, for callers (e.g. the backtest) that size a ladder
+// without constructing a cloud-backed capability. Each call returns a fresh
+// slice.
+func ProviderLayers(provider common.ProviderType) ([]LayerSpec, error) {
+	switch provider {
+	case common.ProviderAWS:
+		return AWSLayers(), nil
+	case common.ProviderAzure:
+		return AzureLayers(), nil
+	case common.ProviderGCP:
+		return GCPLayers(), nil
+	default:
+		return nil, fmt.Errorf("no ladder layers defined for provider %q", provider)
+	}
+}
@@ -20,13 +20,28 @@ const (
 	LayerConvertibleRI    LayerType = "convertible-ri"
 	LayerAzureReservation LayerType = "azure-reservation"
 	LayerAzureSavingsPlan LayerType = "azure-savings-plan"
+	// LayerGCPResourceCUD is a regional resource-based Compute Engine CUD
+	// (vCPU + memory), bought through the Compute API.
+	LayerGCPResourceCUD LayerType = "gcp-resource-cud"
+	// LayerGCPFlexCUD is a spend-based flexible CUD. GCP exposes no purchase
+	// API for it, so the layer is advisory-only: plans can allocate to it but
+	// PurchaseLayer returns common.ErrCommitmentPurchaseNotSupported.
+	LayerGCPFlexCUD LayerType = "gcp-flex-cud"
+	// LayerRDSRI, LayerElastiCacheRI and LayerOpenSearchRI are AWS
+	// reserved-capacity layers. Each forms a single-layer ladder scoped to
+	// one service (Scope.Service) rather than joining the compute layer set.
+	LayerRDSRI         LayerType = "rds-ri"
+	LayerElastiCacheRI LayerType = "elasticache-ri"
+	LayerOpenSearchRI  LayerType = "opensearch-ri"
 )
 
 // Validate returns an error when l is not a recognized LayerType.
 func (l LayerType) Validate() error {
 	switch l {
 	case LayerEC2InstanceSP, LayerComputeSP, LayerConvertibleRI,
-		LayerAzureReservation, LayerAzureSavingsPlan:
+		LayerAzureReservation, LayerAzureSavingsPlan,
+		LayerGCPResourceCUD, LayerGCPFlexCUD,
+		LayerRDSRI, LayerElastiCacheRI, LayerOpenSearchRI:
 		return nil
 	}
 	return fmt.Errorf("unknown layer type %q", l)
@@ -100,7 +115,8 @@ func ParseActionType(s string) (ActionType, error) {
 }
 
 // LadderMode controls whether ladder runs require human approval before
-// executing purchases.
+// executing purchases, or only record what they would have bought
+// (ModeShadow).
 //
 //nolint:revive // Ladder* prefix is the spec-mandated public name (issue #1334); matches pkg/exchange's Exchange* convention.
 type LadderMode string
@@ -108,12 +124,16 @@ type LadderMode string
 const (
 	ModeEmailApproval LadderMode = "email_approval"
 	ModeAutoApprove   LadderMode = "auto_approve"
+	// ModeShadow plans exactly as the other modes do but never schedules a
+	// tranche: each one is recorded as a shadow purchase to be scored
+	// against later usage (see pkg/shadow).
+	ModeShadow LadderMode = "shadow"
 )
 
 // Validate returns an error when m is not a recognized LadderMode.
 func (m LadderMode) Validate() error {
 	switch m {
-	case ModeEmailApproval, ModeAutoApprove:
+	case ModeEmailApproval, ModeAutoApprove, ModeShadow:
 		return nil
 	}
 	return fmt.Errorf("unknown ladder mode %q", m)
@@ -223,11 +243,14 @@ type LayerSpec struct {
 // Scope identifies the ladder scope: a specific provider account or
 // subscription that the ladder engine operates on.
 //
-// Note: GCP scopes validate here, but no GCP LayerType exists yet, so every
-// GCP run fails loud at layer validation until GCP layers land.
+// Service narrows an AWS scope to one reserved-capacity service
+// (common.ServiceRDS, ServiceElastiCache, ServiceOpenSearch), whose usage,
+// commitments and layer are independent of the compute ladder. Empty means
+// the provider's compute ladder.
 type Scope struct {
 	Provider  common.ProviderType
 	AccountID string
+	Service   common.ServiceType
 }
 
 // Validate checks that the scope names a known provider and a non-empty
@@ -244,6 +267,15 @@ func (s Scope) Validate() error {
 	if s.AccountID == "" {
 		return fmt.Errorf("account ID is required")
 	}
+	if s.Service == "" {
+		return nil
+	}
+	if s.Provider != common.ProviderAWS {
+		return fmt.Errorf("service %q: service-scoped ladders are only supported on %s", s.Service, common.ProviderAWS)
+	}
+	if _, err := ReservedCapacityLayerType(s.Service); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -382,6 +414,11 @@ func (c *LadderConfig) Validate() error {
 	if err := c.validateBaselineBounds(); err != nil {
 		return err
 	}
+	// A service-scoped ladder is a single flex layer (ReservedCapacityLayers);
+	// Allocate would reject a buffer share with nowhere to go, so fail here.
+	if c.Scope.Service != "" && c.BufferFraction != 0 {
+		return fmt.Errorf("buffer_fraction %g must be 0 for a %s ladder (no buffer layer)", c.BufferFraction, c.Scope.Service)
+	}
 	if err := c.Mode.Validate(); err != nil {
 		return fmt.Errorf("mode: %w", err)
 	}
@@ -59,6 +59,11 @@ func TestLayerTypeValidate(t *testing.T) {
 		{LayerConvertibleRI, false},
 		{LayerAzureReservation, false},
 		{LayerAzureSavingsPlan, false},
+		{LayerGCPResourceCUD, false},
+		{LayerGCPFlexCUD, false},
+		{LayerRDSRI, false},
+		{LayerElastiCacheRI, false},
+		{LayerOpenSearchRI, false},
 		{"unknown-layer", true},
 		{"", true},
 	}
@@ -127,6 +132,7 @@ func TestLadderModeValidate(t *testing.T) {
 	}{
 		{ModeEmailApproval, false},
 		{ModeAutoApprove, false},
+		{ModeShadow, false},
 		{"unknown-mode", true},
 		{"", true},
 	}
@@ -228,6 +234,8 @@ func TestParseLayerType(t *testing.T) {
 		{"convertible-ri", LayerConvertibleRI, false},
 		{"azure-reservation", LayerAzureReservation, false},
 		{"azure-savings-plan", LayerAzureSavingsPlan, false},
+		{"gcp-resource-cud", LayerGCPResourceCUD, false},
+		{"gcp-flex-cud", LayerGCPFlexCUD, false},
 		{"bogus", "", true},
 		{"", "", true},
 	}
@@ -320,6 +328,7 @@ func TestParseLadderMode(t *testing.T) {
 	}{
 		{"email_approval", ModeEmailApproval, false},
 		{"auto_approve", ModeAutoApprove, false},
+		{"shadow", ModeShadow, false},
 		{"bogus", "", true},
 		{"", "", true},
 	}
@@ -560,6 +569,9 @@ func TestScopeValidate(t *testing.T) {
 		{"empty provider", Scope{Provider: "", AccountID: "x"}, true},
 		{"empty account", Scope{Provider: common.ProviderAWS, AccountID: ""}, true},
 		{"both empty", Scope{}, true},
+		{"aws rds", Scope{Provider: common.ProviderAWS, AccountID: "123456789012", Service: common.ServiceRDS}, false},
+		{"aws unsupported service", Scope{Provider: common.ProviderAWS, AccountID: "123456789012", Service: common.ServiceRedshift}, true},
+		{"service on azure", Scope{Provider: common.ProviderAzure, AccountID: "sub-id", Service: common.ServiceRDS}, true},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
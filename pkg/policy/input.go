@@ -0,0 +1,127 @@
+package policy
+
+// Input describes one purchase for evaluation. Field names in the doc
+// comments are the keys rule expressions use.
+type Input struct {
+	// Stage is StageApproval or StageExecution.
+	Stage           string           `json:"stage"`
+	Execution       Execution        `json:"execution"`
+	Recommendations []Recommendation `json:"recommendations"`
+	Actor           Actor            `json:"actor"`
+	// Accounts are the cloud accounts the purchase targets: its own
+	// account, the accounts named on its recommendations, or its plan's
+	// accounts. Empty when none can be resolved.
+	Accounts []Account `json:"accounts"`
+}
+
+// Execution is the purchase being evaluated.
+type Execution struct {
+	ID               string  `json:"id"`
+	PlanID           string  `json:"plan_id"`
+	Source           string  `json:"source"`
+	Status           string  `json:"status"`
+	StepNumber       int     `json:"step_number"`
+	TotalUpfrontCost float64 `json:"total_upfront_cost"`
+	EstimatedSavings float64 `json:"estimated_savings"`
+}
+
+// Recommendation is one commitment the purchase would buy.
+type Recommendation struct {
+	Provider     string `json:"provider"`
+	Service      string `json:"service"`
+	Region       string `json:"region"`
+	ResourceType string `json:"resource_type"`
+	Engine       string `json:"engine"`
+	Payment      string `json:"payment"`
+	// Term is in years.
+	Term        int     `json:"term"`
+	Count       int     `json:"count"`
+	UpfrontCost float64 `json:"upfront_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
+	Savings     float64 `json:"savings"`
+	// AccountID is the CUDly cloud account ID, when the recommendation
+	// names one.
+	AccountID string `json:"account_id"`
+}
+
+// Commitment is the recommendation's total spend over its term: the
+// upfront payment plus every monthly payment.
+func (r Recommendation) Commitment() float64 {
+	return r.UpfrontCost + r.MonthlyCost*12*float64(r.Term)
+}
+
+// Actor kinds.
+const (
+	ActorUser   = "user"
+	ActorSystem = "system"
+)
+
+// Actor is who is approving or executing the purchase. Kind is ActorSystem
+// for the scheduler and other unattended paths.
+type Actor struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Kind   string `json:"kind"`
+}
+
+// Account is a CUDly cloud account.
+type Account struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Provider   string `json:"provider"`
+	ExternalID string `json:"external_id"`
+}
+
+// activation converts in to the CEL variables documented on the package.
+// Numbers are int64 and float64 so CEL sees int and double.
+func (in Input) activation() map[string]any {
+	recs := make([]map[string]any, 0, len(in.Recommendations))
+	var totalCommitment float64
+	for _, r := range in.Recommendations {
+		totalCommitment += r.Commitment()
+		recs = append(recs, map[string]any{
+			"provider":      r.Provider,
+			"service":       r.Service,
+			"region":        r.Region,
+			"resource_type": r.ResourceType,
+			"engine":        r.Engine,
+			"payment":       r.Payment,
+			"term":          int64(r.Term),
+			"count":         int64(r.Count),
+			"upfront_cost":  r.UpfrontCost,
+			"monthly_cost":  r.MonthlyCost,
+			"savings":       r.Savings,
+			"commitment":    r.Commitment(),
+			"account_id":    r.AccountID,
+		})
+	}
+	accounts := make([]map[string]any, 0, len(in.Accounts))
+	for _, a := range in.Accounts {
+		accounts = append(accounts, map[string]any{
+			"id":          a.ID,
+			"name":        a.Name,
+			"provider":    a.Provider,
+			"external_id": a.ExternalID,
+		})
+	}
+	return map[string]any{
+		"stage": in.Stage,
+		"execution": map[string]any{
+			"id":                 in.Execution.ID,
+			"plan_id":            in.Execution.PlanID,
+			"source":             in.Execution.Source,
+			"status":             in.Execution.Status,
+			"step_number":        int64(in.Execution.StepNumber),
+			"total_upfront_cost": in.Execution.TotalUpfrontCost,
+			"estimated_savings":  in.Execution.EstimatedSavings,
+			"total_commitment":   totalCommitment,
+		},
+		"recommendations": recs,
+		"actor": map[string]any{
+			"user_id": in.Actor.UserID,
+			"email":   in.Actor.Email,
+			"kind":    in.Actor.Kind,
+		},
+		"accounts": accounts,
+	}
+}
@@ -0,0 +1,335 @@
+// Package policy evaluates admin-authored purchase guardrails written in CEL
+// (https://cel.dev) against a purchase before it is approved or executed.
+// It is pure: callers describe the purchase as an Input and get back a
+// Result saying whether the rules allow it, deny it, want more approvers, or
+// only warn. Loading versioned policies, recording decisions and enforcing
+// them live in internal/.
+//
+// A rule's expression sees these variables:
+//
+//	stage            string: "approval" or "execution"
+//	execution        map: id, plan_id, source, status, step_number,
+//	                 total_upfront_cost, estimated_savings, total_commitment
+//	recommendations  list of maps: provider, service, region, resource_type,
+//	                 engine, payment, term (years), count, upfront_cost,
+//	                 monthly_cost, savings, commitment, account_id
+//	actor            map: user_id, email, kind ("user" or "system")
+//	accounts         list of maps: id, name, provider, external_id
+//
+// and may call sum() on a list of numbers, e.g.
+//
+//	recommendations.filter(r, r.service == "rds").map(r, r.upfront_cost).sum() > 50000.0
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+)
+
+// Effect is what a rule does to a purchase its expression matches.
+type Effect string
+
+const (
+	// EffectDeny blocks the purchase.
+	EffectDeny Effect = "deny"
+	// EffectRequireApprovals holds the purchase until Rule.Approvals
+	// distinct approvers have signed it.
+	EffectRequireApprovals Effect = "require_approvals"
+	// EffectWarn lets the purchase through and records the rule's message.
+	EffectWarn Effect = "warn"
+)
+
+// Outcome summarizes a Result. Outcomes are ordered: deny beats
+// require_approvals, which beats warn, which beats allow.
+type Outcome string
+
+const (
+	OutcomeAllow            Outcome = "allow"
+	OutcomeWarn             Outcome = "warn"
+	OutcomeRequireApprovals Outcome = "require_approvals"
+	OutcomeDeny             Outcome = "deny"
+)
+
+// Evaluation stages, the value of the stage variable. Approval runs when
+// someone approves the purchase; execution runs just before the cloud is
+// called, including for scheduled purchases nobody approved.
+const (
+	StageApproval  = "approval"
+	StageExecution = "execution"
+)
+
+const (
+	// maxApprovals caps Rule.Approvals.
+	maxApprovals = 10
+	// maxRules and maxExpressionLength bound what one policy version may
+	// hold.
+	maxRules            = 100
+	maxExpressionLength = 4096
+	// evalCostLimit is the CEL runtime cost budget per rule evaluation.
+	evalCostLimit = 100_000
+	ruleIDPattern = `^[a-z0-9][a-z0-9_-]{0,63}$`
+)
+
+var ruleIDRe = regexp.MustCompile(ruleIDPattern)
+
+// Rule is one admin-authored guardrail.
+type Rule struct {
+	// ID names the rule in decisions and audit rows: lowercase letters,
+	// digits, '-' and '_', unique within a policy.
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// Expression is a CEL expression that evaluates to true when the rule
+	// applies to the purchase.
+	Expression string `json:"expression"`
+	Effect     Effect `json:"effect"`
+	// Approvals is how many distinct approvers a require_approvals rule
+	// wants, 2 to 10. Must be zero for the other effects.
+	Approvals int `json:"approvals,omitempty"`
+	// Message is shown to the requester and approvers when the rule fires.
+	Message string `json:"message,omitempty"`
+}
+
+// Validate checks r's fields. It does not compile the expression; Compile
+// does.
+func (r Rule) Validate() error {
+	if !ruleIDRe.MatchString(r.ID) {
+		return fmt.Errorf("rule id %q must match %s", r.ID, ruleIDPattern)
+	}
+	if r.Expression == "" {
+		return fmt.Errorf("rule %s: expression must not be empty", r.ID)
+	}
+	if len(r.Expression) > maxExpressionLength {
+		return fmt.Errorf("rule %s: expression is longer than %d characters", r.ID, maxExpressionLength)
+	}
+	switch r.Effect {
+	case EffectRequireApprovals:
+		if r.Approvals < 2 || r.Approvals > maxApprovals {
+			return fmt.Errorf("rule %s: approvals must be between 2 and %d, got %d", r.ID, maxApprovals, r.Approvals)
+		}
+	case EffectDeny, EffectWarn:
+		if r.Approvals != 0 {
+			return fmt.Errorf("rule %s: approvals is only valid with effect %q", r.ID, EffectRequireApprovals)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown effect %q", r.ID, r.Effect)
+	}
+	return nil
+}
+
+// Decision is one rule that fired, or failed to evaluate.
+type Decision struct {
+	RuleID    string `json:"rule_id"`
+	Effect    Effect `json:"effect"`
+	Approvals int    `json:"approvals,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// Error is set when the expression failed at runtime or did not yield
+	// a bool. Such rules are recorded as denials: a broken guardrail must
+	// not wave purchases through.
+	Error string `json:"error,omitempty"`
+}
+
+// Result is the outcome of evaluating every rule against one Input.
+type Result struct {
+	Outcome Outcome `json:"outcome"`
+	// RequiredApprovals is the largest Approvals among the
+	// require_approvals rules that fired, or 0.
+	RequiredApprovals int        `json:"required_approvals,omitempty"`
+	Decisions         []Decision `json:"decisions"`
+}
+
+// Denied reports whether a deny rule fired or a rule failed to evaluate.
+func (r Result) Denied() bool {
+	return r.Outcome == OutcomeDeny
+}
+
+// DenyReason joins the messages of the denying decisions, for error
+// messages and notifications.
+func (r Result) DenyReason() string {
+	var reason string
+	for _, d := range r.Decisions {
+		if d.Effect != EffectDeny {
+			continue
+		}
+		msg := d.Message
+		if d.Error != "" {
+			msg = "rule failed to evaluate: " + d.Error
+		}
+		if msg == "" {
+			msg = "denied"
+		}
+		if reason != "" {
+			reason += "; "
+		}
+		reason += d.RuleID + ": " + msg
+	}
+	return reason
+}
+
+// Engine is a compiled set of rules. It is safe for concurrent use.
+type Engine struct {
+	rules    []Rule
+	programs []cel.Program
+}
+
+// newEnv declares the variables and functions rule expressions may use.
+func newEnv() (*cel.Env, error) {
+	record := cel.MapType(cel.StringType, cel.DynType)
+	return cel.NewEnv(
+		cel.Variable("stage", cel.StringType),
+		cel.Variable("execution", record),
+		cel.Variable("recommendations", cel.ListType(record)),
+		cel.Variable("actor", record),
+		cel.Variable("accounts", cel.ListType(record)),
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+		cel.Function("sum",
+			cel.MemberOverload("list_sum", []*cel.Type{cel.ListType(cel.DynType)}, cel.DoubleType,
+				cel.UnaryBinding(sumNumbers))),
+	)
+}
+
+// sumNumbers adds up a list of ints and doubles.
+func sumNumbers(v ref.Val) ref.Val {
+	list, ok := v.(traits.Lister)
+	if !ok {
+		return types.NewErr("sum() needs a list, got %s", v.Type().TypeName())
+	}
+	var total float64
+	it := list.Iterator()
+	for it.HasNext() == types.True {
+		switch n := it.Next().(type) {
+		case types.Double:
+			total += float64(n)
+		case types.Int:
+			total += float64(n)
+		case types.Uint:
+			total += float64(n)
+		default:
+			return types.NewErr("sum() needs numbers, got %s", n.Type().TypeName())
+		}
+	}
+	return types.Double(total)
+}
+
+// Compile validates and compiles rules. The error names the first rule
+// that is invalid or does not type-check as a bool.
+func Compile(rules []Rule) (*Engine, error) {
+	if len(rules) > maxRules {
+		return nil, fmt.Errorf("a policy may have at most %d rules, got %d", maxRules, len(rules))
+	}
+	env, err := newEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+	engine := &Engine{rules: rules, programs: make([]cel.Program, 0, len(rules))}
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if validateErr := rule.Validate(); validateErr != nil {
+			return nil, validateErr
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("rule id %s is used more than once", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		prg, compileErr := compileRule(env, rule)
+		if compileErr != nil {
+			return nil, compileErr
+		}
+		engine.programs = append(engine.programs, prg)
+	}
+	return engine, nil
+}
+
+// compileRule type-checks one rule's expression and plans it with a cost
+// limit, so a pathological expression cannot stall a purchase.
+func compileRule(env *cel.Env, rule Rule) (cel.Program, error) {
+	ast, iss := env.Compile(rule.Expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("rule %s: %w", rule.ID, iss.Err())
+	}
+	if out := ast.OutputType(); !out.IsExactType(cel.BoolType) && !out.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("rule %s: expression must evaluate to a bool, not %s", rule.ID, out)
+	}
+	prg, err := env.Program(ast, cel.CostLimit(evalCostLimit))
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+	}
+	return prg, nil
+}
+
+// Rules returns the rules e was compiled from.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Evaluate runs every rule against in. It never fails: a rule that errors
+// is recorded as a denial (see Decision.Error).
+func (e *Engine) Evaluate(ctx context.Context, in Input) Result {
+	res := Result{Outcome: OutcomeAllow, Decisions: []Decision{}}
+	activation := in.activation()
+	for i, prg := range e.programs {
+		rule := e.rules[i]
+		matched, evalErr := evalRule(ctx, prg, activation)
+		if evalErr != nil {
+			res.add(Decision{RuleID: rule.ID, Effect: EffectDeny, Message: rule.Message, Error: evalErr.Error()})
+			continue
+		}
+		if matched {
+			res.add(Decision{RuleID: rule.ID, Effect: rule.Effect, Approvals: rule.Approvals, Message: rule.Message})
+		}
+	}
+	return res
+}
+
+// evalRule runs one program and insists on a bool result.
+func evalRule(ctx context.Context, prg cel.Program, activation map[string]any) (bool, error) {
+	out, _, err := prg.ContextEval(ctx, activation)
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %s, not a bool", out.Type().TypeName())
+	}
+	return matched, nil
+}
+
+// add records d and raises the outcome to match it.
+func (r *Result) add(d Decision) {
+	r.Decisions = append(r.Decisions, d)
+	var outcome Outcome
+	switch d.Effect {
+	case EffectDeny:
+		outcome = OutcomeDeny
+	case EffectRequireApprovals:
+		outcome = OutcomeRequireApprovals
+		if d.Approvals > r.RequiredApprovals {
+			r.RequiredApprovals = d.Approvals
+		}
+	default:
+		outcome = OutcomeWarn
+	}
+	if outcomeRank(outcome) > outcomeRank(r.Outcome) {
+		r.Outcome = outcome
+	}
+}
+
+func outcomeRank(o Outcome) int {
+	switch o {
+	case OutcomeDeny:
+		return 3
+	case OutcomeRequireApprovals:
+		return 2
+	case OutcomeWarn:
+		return 1
+	}
+	return 0
+}
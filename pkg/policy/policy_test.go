@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sandboxInput() Input {
+	return Input{
+		Stage:     StageApproval,
+		Execution: Execution{ID: "exec-1", TotalUpfrontCost: 60000},
+		Recommendations: []Recommendation{
+			{Provider: "aws", Service: "rds", Term: 3, Payment: "all-upfront", Count: 2, UpfrontCost: 40000},
+			{Provider: "aws", Service: "rds", Term: 1, Payment: "no-upfront", Count: 1, UpfrontCost: 20000, MonthlyCost: 100},
+			{Provider: "aws", Service: "ec2", Term: 1, Payment: "no-upfront", Count: 4, MonthlyCost: 500},
+		},
+		Actor:    Actor{UserID: "u-1", Email: "buyer@example.com", Kind: ActorUser},
+		Accounts: []Account{{ID: "acct-1", Name: "team-sandbox", Provider: "aws", ExternalID: "123456789012"}},
+	}
+}
+
+func TestEvaluate_ExampleRules(t *testing.T) {
+	engine, err := Compile([]Rule{
+		{
+			ID:         "no-3yr-all-upfront-in-sandbox",
+			Expression: `accounts.exists(a, a.name.contains("sandbox")) && recommendations.exists(r, r.term == 3 && r.payment == "all-upfront")`,
+			Effect:     EffectDeny,
+			Message:    "no 3-year all-upfront commitments in sandbox accounts",
+		},
+		{
+			ID:         "large-rds",
+			Expression: `recommendations.filter(r, r.service == "rds").map(r, r.upfront_cost).sum() > 50000`,
+			Effect:     EffectRequireApprovals,
+			Approvals:  2,
+			Message:    "RDS purchases over $50k need two approvers",
+		},
+		{ID: "ec2-heads-up", Expression: `recommendations.exists(r, r.service == "ec2")`, Effect: EffectWarn},
+		{ID: "never", Expression: `execution.total_upfront_cost > 1000000.0`, Effect: EffectDeny},
+	})
+	require.NoError(t, err)
+
+	res := engine.Evaluate(context.Background(), sandboxInput())
+	assert.Equal(t, OutcomeDeny, res.Outcome)
+	assert.True(t, res.Denied())
+	assert.Equal(t, 2, res.RequiredApprovals)
+	require.Len(t, res.Decisions, 3)
+	assert.Equal(t, "no-3yr-all-upfront-in-sandbox", res.Decisions[0].RuleID)
+	assert.Equal(t, "large-rds", res.Decisions[1].RuleID)
+	assert.Equal(t, "ec2-heads-up", res.Decisions[2].RuleID)
+	assert.Equal(t, "no-3yr-all-upfront-in-sandbox: no 3-year all-upfront commitments in sandbox accounts", res.DenyReason())
+
+	prod := sandboxInput()
+	prod.Accounts[0].Name = "prod"
+	res = engine.Evaluate(context.Background(), prod)
+	assert.Equal(t, OutcomeRequireApprovals, res.Outcome)
+	assert.False(t, res.Denied())
+	assert.Equal(t, 2, res.RequiredApprovals)
+}
+
+func TestEvaluate_Variables(t *testing.T) {
+	cases := map[string]string{
+		"stage":            `stage == "approval"`,
+		"actor":            `actor.kind == "user" && actor.email.endsWith("@example.com")`,
+		"execution":        `execution.id == "exec-1" && execution.total_upfront_cost == 60000.0`,
+		"total commitment": `execution.total_commitment == 60000.0 + 100.0 * 12.0 + 500.0 * 12.0`,
+		"rec commitment":   `recommendations[1].commitment == 21200.0`,
+		"int vs double":    `recommendations.all(r, r.term >= 1.0)`,
+		"account":          `accounts[0].external_id == "123456789012"`,
+	}
+	for name, expr := range cases {
+		t.Run(name, func(t *testing.T) {
+			engine, err := Compile([]Rule{{ID: "r", Expression: expr, Effect: EffectWarn}})
+			require.NoError(t, err)
+			res := engine.Evaluate(context.Background(), sandboxInput())
+			assert.Equal(t, OutcomeWarn, res.Outcome, expr)
+		})
+	}
+}
+
+func TestEvaluate_AllowsWhenNothingMatches(t *testing.T) {
+	engine, err := Compile([]Rule{{ID: "gcp-only", Expression: `recommendations.exists(r, r.provider == "gcp")`, Effect: EffectDeny}})
+	require.NoError(t, err)
+	res := engine.Evaluate(context.Background(), sandboxInput())
+	assert.Equal(t, OutcomeAllow, res.Outcome)
+	assert.Empty(t, res.Decisions)
+
+	empty, err := Compile(nil)
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeAllow, empty.Evaluate(context.Background(), Input{}).Outcome)
+}
+
+func TestEvaluate_RuntimeErrorsDeny(t *testing.T) {
+	engine, err := Compile([]Rule{
+		{ID: "missing-key", Expression: `execution.no_such_field == "x"`, Effect: EffectWarn, Message: "typo"},
+		{ID: "not-bool", Expression: `execution.id`, Effect: EffectWarn},
+	})
+	require.NoError(t, err)
+
+	res := engine.Evaluate(context.Background(), sandboxInput())
+	assert.Equal(t, OutcomeDeny, res.Outcome)
+	require.Len(t, res.Decisions, 2)
+	for _, d := range res.Decisions {
+		assert.Equal(t, EffectDeny, d.Effect)
+		assert.NotEmpty(t, d.Error)
+	}
+	assert.Contains(t, res.DenyReason(), "missing-key: rule failed to evaluate")
+}
+
+func TestCompile_Rejects(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []Rule
+		want  string
+	}{
+		{"bad id", []Rule{{ID: "Bad ID", Expression: "true", Effect: EffectDeny}}, "must match"},
+		{"empty expression", []Rule{{ID: "a", Effect: EffectDeny}}, "expression must not be empty"},
+		{"unknown effect", []Rule{{ID: "a", Expression: "true", Effect: "block"}}, `unknown effect "block"`},
+		{"one approver", []Rule{{ID: "a", Expression: "true", Effect: EffectRequireApprovals, Approvals: 1}}, "approvals must be between 2 and 10"},
+		{"approvals on deny", []Rule{{ID: "a", Expression: "true", Effect: EffectDeny, Approvals: 2}}, "approvals is only valid"},
+		{"duplicate id", []Rule{{ID: "a", Expression: "true", Effect: EffectWarn}, {ID: "a", Expression: "false", Effect: EffectWarn}}, "used more than once"},
+		{"syntax error", []Rule{{ID: "a", Expression: "execution.id ==", Effect: EffectDeny}}, "rule a:"},
+		{"unknown variable", []Rule{{ID: "a", Expression: `account.name == "x"`, Effect: EffectDeny}}, "undeclared reference"},
+		{"non-bool", []Rule{{ID: "a", Expression: `stage + "x"`, Effect: EffectDeny}}, "must evaluate to a bool"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile(tc.rules)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package portfolio
+
+import (
+	"math"
+)
+
+// eps is the tolerance for pivots, reduced costs and integrality.
+const eps = 1e-9
+
+// problem is a bounded mixed-integer program in the one shape the
+// portfolio produces:
+//
+//	maximise c·x  subject to  A·x ≤ b,  lo ≤ x ≤ hi,  x_j integral where integer[j]
+//
+// with A ≥ 0 and b ≥ 0. Every coefficient is a cost, a usage quantity or a
+// risk weight, so the origin is always feasible and no phase-one simplex is
+// needed; a branch that raises a lower bound past what b allows is
+// infeasible outright.
+type problem struct {
+	c       []float64
+	a       [][]float64
+	b       []float64
+	lo, hi  []float64
+	integer []bool
+}
+
+// solution is the best point found by solve. optimal is false when the
+// node limit stopped branch and bound before it proved the incumbent
+// optimal.
+type solution struct {
+	x       []float64
+	value   float64
+	optimal bool
+	nodes   int
+}
+
+// solve runs depth-first branch and bound over LP relaxations, branching on
+// the most fractional integer variable and exploring the rounded-up branch
+// first so a good incumbent is found early. At most maxNodes relaxations
+// are solved.
+func (p problem) solve(maxNodes int) solution {
+	best := solution{x: make([]float64, len(p.c)), value: 0, optimal: true}
+	copy(best.x, p.lo)
+	best.value = dot(p.c, p.lo)
+
+	type node struct{ lo, hi []float64 }
+	stack := []node{{lo: append([]float64(nil), p.lo...), hi: append([]float64(nil), p.hi...)}}
+	for len(stack) > 0 {
+		if best.nodes >= maxNodes {
+			best.optimal = false
+			break
+		}
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		best.nodes++
+
+		x, value, ok := p.relax(n.lo, n.hi)
+		if !ok || value <= best.value+eps {
+			continue
+		}
+		branch, frac := -1, 0.0
+		for j, isInt := range p.integer {
+			if !isInt {
+				continue
+			}
+			f := x[j] - math.Floor(x[j])
+			if d := math.Min(f, 1-f); d > 1e-6 && d > frac {
+				branch, frac = j, d
+			}
+		}
+		if branch < 0 {
+			for j, isInt := range p.integer {
+				if isInt {
+					x[j] = math.Round(x[j])
+				}
+			}
+			best.x, best.value = x, dot(p.c, x)
+			continue
+		}
+		down := node{lo: n.lo, hi: append([]float64(nil), n.hi...)}
+		down.hi[branch] = math.Floor(x[branch])
+		up := node{lo: append([]float64(nil), n.lo...), hi: n.hi}
+		up.lo[branch] = math.Ceil(x[branch])
+		stack = append(stack, down, up)
+	}
+	return best
+}
+
+// relax solves the LP relaxation with bounds lo ≤ x ≤ hi by substituting
+// x = lo + y, so that y ≥ 0 and the upper bounds become ordinary rows.
+func (p problem) relax(lo, hi []float64) ([]float64, float64, bool) {
+	n := len(p.c)
+	rows := make([][]float64, 0, len(p.a)+n)
+	rhs := make([]float64, 0, len(p.a)+n)
+	for i, row := range p.a {
+		r := p.b[i] - dot(row, lo)
+		if r < -eps {
+			return nil, 0, false
+		}
+		rows = append(rows, row)
+		rhs = append(rhs, math.Max(r, 0))
+	}
+	for j := 0; j < n; j++ {
+		span := hi[j] - lo[j]
+		if span < -eps {
+			return nil, 0, false
+		}
+		row := make([]float64, n)
+		row[j] = 1
+		rows = append(rows, row)
+		rhs = append(rhs, math.Max(span, 0))
+	}
+	y := simplex(p.c, rows, rhs)
+	x := make([]float64, n)
+	for j := range x {
+		x[j] = lo[j] + y[j]
+	}
+	return x, dot(p.c, x), true
+}
+
+// simplex maximises c·y subject to A·y ≤ b, y ≥ 0, for b ≥ 0, with a dense
+// tableau and Bland's rule (which cannot cycle). The slack basis is the
+// feasible starting point. The portfolio bounds every variable, so the
+// program is never unbounded.
+func simplex(c []float64, a [][]float64, b []float64) []float64 {
+	m, n := len(a), len(c)
+	width := n + m + 1
+	t := make([][]float64, m+1)
+	for i := 0; i < m; i++ {
+		t[i] = make([]float64, width)
+		copy(t[i], a[i])
+		t[i][n+i] = 1
+		t[i][width-1] = b[i]
+	}
+	t[m] = make([]float64, width)
+	for j := 0; j < n; j++ {
+		t[m][j] = -c[j]
+	}
+	basis := make([]int, m)
+	for i := range basis {
+		basis[i] = n + i
+	}
+
+	for {
+		enter := enteringColumn(t[m])
+		if enter < 0 {
+			break
+		}
+		leave := leavingRow(t[:m], basis, enter)
+		if leave < 0 {
+			break // unbounded; unreachable for bounded programs
+		}
+		pivot(t, leave, enter)
+		basis[leave] = enter
+	}
+
+	y := make([]float64, n)
+	for i, v := range basis {
+		if v < n {
+			y[v] = t[i][width-1]
+		}
+	}
+	return y
+}
+
+// enteringColumn returns the first column with a negative reduced cost in
+// the objective row, or -1 at optimality (Bland's rule).
+func enteringColumn(objective []float64) int {
+	for j := 0; j < len(objective)-1; j++ {
+		if objective[j] < -eps {
+			return j
+		}
+	}
+	return -1
+}
+
+// leavingRow returns the constraint row passing the minimum-ratio test for
+// column enter, breaking ties on the lowest basic variable (Bland's rule),
+// or -1 if the column is unbounded.
+func leavingRow(rows [][]float64, basis []int, enter int) int {
+	leave := -1
+	var ratio float64
+	for i, row := range rows {
+		if row[enter] <= eps {
+			continue
+		}
+		r := row[len(row)-1] / row[enter]
+		if leave < 0 || r < ratio-eps || (r <= ratio+eps && basis[i] < basis[leave]) {
+			leave, ratio = i, r
+		}
+	}
+	return leave
+}
+
+func pivot(t [][]float64, row, col int) {
+	p := t[row][col]
+	for j := range t[row] {
+		t[row][j] /= p
+	}
+	for i := range t {
+		if i == row || t[i][col] == 0 {
+			continue
+		}
+		f := t[i][col]
+		for j := range t[i] {
+			t[i][j] -= f * t[row][j]
+		}
+	}
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
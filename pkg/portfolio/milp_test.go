@@ -0,0 +1,60 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolve_IntegerKnapsackBeatsGreedy(t *testing.T) {
+	// Greedy by value density takes item 0 (6/4) and then nothing fits;
+	// the optimum is items 1 and 2 (5+5 with weight 3+3).
+	p := problem{
+		c:       []float64{6, 5, 5},
+		a:       [][]float64{{4, 3, 3}},
+		b:       []float64{6},
+		lo:      []float64{0, 0, 0},
+		hi:      []float64{1, 1, 1},
+		integer: []bool{true, true, true},
+	}
+	sol := p.solve(DefaultMaxNodes)
+	if !sol.optimal || sol.value != 10 || sol.x[0] != 0 || sol.x[1] != 1 || sol.x[2] != 1 {
+		t.Fatalf("solution = %+v", sol)
+	}
+}
+
+func TestSolve_MixedIntegerAndContinuous(t *testing.T) {
+	// x0 integral in [0,3], x1 continuous in [0,1]; 2*x0 + 4*x1 <= 7.
+	p := problem{
+		c:       []float64{3, 5},
+		a:       [][]float64{{2, 4}},
+		b:       []float64{7},
+		lo:      []float64{0, 0},
+		hi:      []float64{3, 1},
+		integer: []bool{true, false},
+	}
+	sol := p.solve(DefaultMaxNodes)
+	// x0=3 leaves 1 for x1=0.25: 9 + 1.25.
+	if !sol.optimal || math.Abs(sol.value-10.25) > 1e-9 || sol.x[0] != 3 || math.Abs(sol.x[1]-0.25) > 1e-9 {
+		t.Fatalf("solution = %+v", sol)
+	}
+}
+
+func TestSolve_NodeLimitKeepsFeasibleIncumbent(t *testing.T) {
+	p := problem{
+		c:       []float64{5, 4, 3},
+		a:       [][]float64{{2, 3, 1}},
+		b:       []float64{5},
+		lo:      []float64{0, 0, 0},
+		hi:      []float64{1, 1, 1},
+		integer: []bool{true, true, true},
+	}
+	// The root relaxation is fractional (x1 = 2/3), so one node only
+	// branches.
+	sol := p.solve(1)
+	if sol.optimal {
+		t.Fatal("one node cannot prove optimality")
+	}
+	if dot(p.a[0], sol.x) > p.b[0]+eps {
+		t.Fatalf("incumbent %v is infeasible", sol.x)
+	}
+}
@@ -0,0 +1,130 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// Overlap is the share of one count-denominated recommendation's usage
+// that Savings Plan recommendations would already cover if both were
+// bought.
+type Overlap struct {
+	// Index is the position of the overlapped recommendation in the input.
+	Index int
+	// CoveredPct is the share (0-100) of its on-demand usage the plans absorb.
+	CoveredPct float64
+	// AdjustedCount is the count left once the covered share is removed.
+	AdjustedCount int
+	// CoveredBy lists the input positions of the overlapping Savings Plans.
+	CoveredBy []int
+}
+
+// AnalyzeOverlap estimates how much of every RI-style recommendation's
+// on-demand usage the Savings Plan recommendations in recs cover. AWS
+// generates both against the same usage, so buying both leaves the second
+// purchase under-utilised.
+//
+// A plan covers the domain it draws from (see Domains): an EC2 Instance
+// Savings Plan its family in one region, a Compute Savings Plan every EC2
+// pool of the account. Family-scoped plans are applied first, then each
+// plan spreads the on-demand spend it was sized against over the
+// recommendations in its domain in proportion to their still-uncovered
+// on-demand cost. Recommendations without an on-demand baseline cannot be
+// measured and are skipped. The result is ordered by Index and only holds
+// recommendations with some overlap.
+func AnalyzeOverlap(recs []common.Recommendation) []Overlap {
+	plans, remaining := overlapParticipants(recs)
+
+	covered := make([]float64, len(recs))
+	coveredBy := make([][]int, len(recs))
+	for _, p := range plans {
+		domain := Domains(recs[p])[0].Key
+		var members []int
+		var uncovered float64
+		for i := range recs {
+			if remaining[i] > eps && drawsFrom(recs[i], domain) {
+				members = append(members, i)
+				uncovered += remaining[i]
+			}
+		}
+		capacity := planCapacity(recs[p])
+		if len(members) == 0 || capacity <= eps {
+			continue
+		}
+		share := math.Min(1, capacity/uncovered)
+		for _, i := range members {
+			take := remaining[i] * share
+			remaining[i] -= take
+			covered[i] += take
+			coveredBy[i] = append(coveredBy[i], p)
+		}
+	}
+
+	var out []Overlap
+	for i := range recs {
+		if covered[i] <= eps {
+			continue
+		}
+		frac := math.Min(1, covered[i]/recs[i].OnDemandCost)
+		out = append(out, Overlap{
+			Index:         i,
+			CoveredPct:    100 * frac,
+			AdjustedCount: int(math.Floor(float64(recs[i].Count)*(1-frac) + 1e-6)),
+			CoveredBy:     coveredBy[i],
+		})
+	}
+	return out
+}
+
+// overlapParticipants returns the positions of the AWS Savings Plan
+// recommendations, family-scoped first, and the on-demand cost of every
+// measurable RI-style recommendation.
+func overlapParticipants(recs []common.Recommendation) (plans []int, remaining []float64) {
+	remaining = make([]float64, len(recs))
+	for i := range recs {
+		switch {
+		case common.IsSavingsPlan(recs[i].Service):
+			if recs[i].Provider == common.ProviderAWS {
+				plans = append(plans, i)
+			}
+		case recs[i].Count > 0 && recs[i].OnDemandCost > 0:
+			remaining[i] = recs[i].OnDemandCost
+		}
+	}
+	sort.SliceStable(plans, func(a, b int) bool {
+		return familyScoped(recs[plans[a]]) && !familyScoped(recs[plans[b]])
+	})
+	return plans, remaining
+}
+
+// planCapacity is the monthly on-demand spend a Savings Plan
+// recommendation was sized to absorb: the provider's baseline when
+// reported, else commitment plus savings.
+func planCapacity(rec common.Recommendation) float64 {
+	if rec.OnDemandCost > 0 {
+		return rec.OnDemandCost
+	}
+	var recurring float64
+	if rec.RecurringMonthlyCost != nil {
+		recurring = *rec.RecurringMonthlyCost
+	} else if d, ok := rec.Details.(*common.SavingsPlanDetails); ok {
+		recurring = d.HourlyCommitment * HoursPerMonth
+	}
+	return recurring + rec.EstimatedSavings
+}
+
+func familyScoped(rec common.Recommendation) bool {
+	return strings.HasPrefix(Domains(rec)[0].Key, "ec2-family|")
+}
+
+func drawsFrom(rec common.Recommendation, key string) bool {
+	for _, d := range Domains(rec) {
+		if d.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestAnalyzeOverlap_ComputeSPCoversPoolsProportionally(t *testing.T) {
+	recs := []common.Recommendation{
+		ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 280, 120),
+		ri("c5.large", "1yr", "no-upfront", 10, 600, 0, 420, 180),
+		computeSP(500, 375, 125),
+	}
+	got := AnalyzeOverlap(recs)
+	if len(got) != 2 {
+		t.Fatalf("overlaps = %+v", got)
+	}
+	// 500 of the 1000 uncovered on-demand: half of each pool.
+	for _, o := range got {
+		if math.Abs(o.CoveredPct-50) > 1e-9 || len(o.CoveredBy) != 1 || o.CoveredBy[0] != 2 {
+			t.Fatalf("overlap = %+v", o)
+		}
+	}
+	if got[0].AdjustedCount != 2 || got[1].AdjustedCount != 5 {
+		t.Fatalf("adjusted counts = %d, %d", got[0].AdjustedCount, got[1].AdjustedCount)
+	}
+}
+
+func TestAnalyzeOverlap_FamilyPlanFirstAndFullCoverage(t *testing.T) {
+	ec2SP := common.Recommendation{
+		Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceSavingsPlansEC2Instance,
+		CommitmentType: common.CommitmentSavingsPlan, Term: "1yr", Count: 1, OnDemandCost: 300,
+		Details: &common.SavingsPlanDetails{PlanType: "EC2Instance", InstanceFamily: "m5", Region: "us-east-1"},
+	}
+	recs := []common.Recommendation{
+		computeSP(1000, 750, 250),
+		ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 280, 120),
+		ri("c5.large", "1yr", "no-upfront", 3, 300, 0, 210, 90),
+		ec2SP,
+	}
+	got := AnalyzeOverlap(recs)
+	if len(got) != 2 {
+		t.Fatalf("overlaps = %+v", got)
+	}
+	m5, c5 := got[0], got[1]
+	// The family plan takes 300 of m5 first; the Compute SP's 1000 then
+	// covers the remaining 100 of m5 and all of c5.
+	if m5.Index != 1 || m5.CoveredPct != 100 || m5.AdjustedCount != 0 || len(m5.CoveredBy) != 2 || m5.CoveredBy[0] != 3 {
+		t.Fatalf("m5 overlap = %+v", m5)
+	}
+	if c5.Index != 2 || c5.CoveredPct != 100 || c5.AdjustedCount != 0 {
+		t.Fatalf("c5 overlap = %+v", c5)
+	}
+}
+
+func TestAnalyzeOverlap_IgnoresOtherAccountsAndMissingBaseline(t *testing.T) {
+	other := ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 280, 120)
+	other.Account = "222222222222"
+	noBaseline := ri("c5.large", "1yr", "no-upfront", 4, 0, 0, 280, 120)
+	if got := AnalyzeOverlap([]common.Recommendation{other, noBaseline, computeSP(400, 300, 100)}); len(got) != 0 {
+		t.Fatalf("overlaps = %+v", got)
+	}
+}
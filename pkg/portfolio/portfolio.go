@@ -0,0 +1,621 @@
+// Package portfolio chooses which commitment recommendations to buy
+// together. pkg/scorer judges each recommendation on its own; Savings Plan
+// and RI recommendations for the same usage overlap, so buying every
+// passing recommendation over-commits. Optimize picks the mix of RIs and
+// Savings Plans, including the term and payment variant and the quantity
+// of each, that maximises expected monthly savings subject to an upfront
+// budget, a commitment-per-hour cap and a usage-volatility risk bound,
+// by solving a mixed-integer program in pure Go. AnalyzeOverlap is the
+// lighter check for callers that keep every recommendation: it estimates
+// how much of each RI's usage the Savings Plans already cover.
+//
+// Like pkg/scorer it is a pure function package and must not import
+// pkg/config.
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// HoursPerMonth converts monthly amounts to hourly ones (8760 / 12), the
+// factor the provider recommendation APIs use.
+const HoursPerMonth = 730
+
+// DefaultMaxNodes bounds branch and bound when Config.MaxNodes is zero.
+const DefaultMaxNodes = 20000
+
+// Config holds the portfolio constraints. Zero values mean "no limit" for
+// the three bounds, as in scorer.Config.
+type Config struct {
+	// MaxUpfront caps the summed upfront cost of the portfolio.
+	MaxUpfront float64
+	// MaxHourlyCommitment caps the portfolio's new commitment per hour:
+	// recurring fees plus upfront amortised over the term, divided by
+	// HoursPerMonth.
+	MaxHourlyCommitment float64
+	// MaxAtRiskHourly bounds usage-volatility risk: the sum over chosen
+	// items of hourly commitment times the volatility of the usage it
+	// covers, i.e. the commitment per hour that would sit idle if every
+	// pool dropped by one standard deviation.
+	MaxAtRiskHourly float64
+
+	// Volatility overrides the usage volatility (coefficient of variation,
+	// 0-1) by PoolKey. Pools without an override use the coefficient of
+	// variation of the recommendation's UsageHistory, then
+	// DefaultVolatility.
+	Volatility        map[string]float64
+	DefaultVolatility float64
+
+	// Demand overrides the uncovered on-demand spend per month of a usage
+	// domain (see Domain.Key), e.g. from current coverage data. Domains
+	// without an override are estimated from the candidates.
+	Demand map[string]float64
+
+	// MaxNodes bounds the branch-and-bound search. 0 = DefaultMaxNodes.
+	MaxNodes int
+}
+
+// Validate rejects negative limits and volatilities.
+func (c Config) Validate() error {
+	for name, v := range map[string]float64{
+		"MaxUpfront": c.MaxUpfront, "MaxHourlyCommitment": c.MaxHourlyCommitment,
+		"MaxAtRiskHourly": c.MaxAtRiskHourly, "DefaultVolatility": c.DefaultVolatility,
+	} {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("portfolio: %s must not be negative, got %v", name, v)
+		}
+	}
+	for k, v := range c.Volatility {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("portfolio: volatility for %s must not be negative, got %v", k, v)
+		}
+	}
+	for k, v := range c.Demand {
+		if v < 0 || math.IsNaN(v) {
+			return fmt.Errorf("portfolio: demand for %s must not be negative, got %v", k, v)
+		}
+	}
+	if c.MaxNodes < 0 {
+		return fmt.Errorf("portfolio: MaxNodes must not be negative, got %d", c.MaxNodes)
+	}
+	return nil
+}
+
+// Domain is a body of usage that several commitments can cover. An EC2 RI
+// covers its pool, which is part of its instance family's usage in the
+// region, which is part of the account's compute usage; an EC2 Instance
+// Savings Plan covers the family and a Compute Savings Plan the whole
+// compute domain. Selected commitments may not cover more on-demand spend
+// in a domain than the domain has uncovered.
+type Domain struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// Decision is the optimizer's verdict on one recommendation.
+type Decision struct {
+	Recommendation common.Recommendation `json:"recommendation"`
+	Chosen         bool                  `json:"chosen"`
+	// Quantity is the chosen instance count for count-denominated
+	// commitments, or the chosen fraction (0-1) of a Savings Plan's hourly
+	// commitment.
+	Quantity float64 `json:"quantity"`
+	// MonthlySavings, Upfront, HourlyCommitment and AtRiskHourly are the
+	// chosen quantity's contribution to the portfolio totals.
+	MonthlySavings   float64 `json:"monthly_savings"`
+	Upfront          float64 `json:"upfront"`
+	HourlyCommitment float64 `json:"hourly_commitment"`
+	AtRiskHourly     float64 `json:"at_risk_hourly"`
+	Volatility       float64 `json:"volatility"`
+	// Reason explains why the item was chosen, sized down or dropped.
+	Reason string `json:"reason"`
+}
+
+// Result is the optimised portfolio.
+type Result struct {
+	// Decisions holds one entry per input recommendation, in input order.
+	Decisions []Decision `json:"decisions"`
+	// Selected holds the chosen recommendations with Count and costs scaled
+	// to the chosen quantity, ready to become purchase plan items.
+	Selected []common.Recommendation `json:"selected"`
+
+	MonthlySavings   float64 `json:"monthly_savings"`
+	Upfront          float64 `json:"upfront"`
+	HourlyCommitment float64 `json:"hourly_commitment"`
+	AtRiskHourly     float64 `json:"at_risk_hourly"`
+	// Optimal is false when MaxNodes stopped the search before the
+	// portfolio was proven optimal; it is still feasible.
+	Optimal bool `json:"optimal"`
+}
+
+// candidate is one recommendation with its per-unit coefficients.
+type candidate struct {
+	rec        common.Recommendation
+	units      float64 // upper bound: Count, or 1 for a Savings Plan fraction
+	integer    bool
+	savings    float64 // monthly, per unit
+	upfront    float64 // per unit
+	hourly     float64 // per unit
+	onDemand   float64 // covered on-demand spend per month, per unit
+	demandCap  float64 // this candidate's estimate of its narrowest domain
+	volatility float64
+	domains    []Domain // narrowest first
+	skip       string   // non-empty: not eligible, with the reason
+}
+
+// Optimize chooses the portfolio. Every recommendation gets a Decision;
+// ineligible ones (no savings, unparseable term) are dropped with the
+// reason rather than failing the run.
+func Optimize(recs []common.Recommendation, cfg Config) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	cands := make([]candidate, len(recs))
+	for i := range recs {
+		cands[i] = newCandidate(recs[i], cfg)
+	}
+
+	var live []int
+	for i := range cands {
+		if cands[i].skip == "" {
+			live = append(live, i)
+		}
+	}
+	rows := buildRows(cands, live, cfg)
+	p := problem{
+		c:       make([]float64, len(live)),
+		lo:      make([]float64, len(live)),
+		hi:      make([]float64, len(live)),
+		integer: make([]bool, len(live)),
+	}
+	for j, i := range live {
+		p.c[j], p.hi[j], p.integer[j] = cands[i].savings, cands[i].units, cands[i].integer
+	}
+	for _, r := range rows {
+		p.a = append(p.a, r.coef)
+		p.b = append(p.b, r.limit)
+	}
+	maxNodes := cfg.MaxNodes
+	if maxNodes == 0 {
+		maxNodes = DefaultMaxNodes
+	}
+	sol := p.solve(maxNodes)
+
+	res := Result{Decisions: make([]Decision, len(recs)), Optimal: sol.optimal}
+	quantity := make([]float64, len(cands))
+	for j, i := range live {
+		quantity[i] = sol.x[j]
+		if quantity[i] < 1e-6 {
+			quantity[i] = 0
+		}
+	}
+	for i := range cands {
+		c := cands[i]
+		q := quantity[i]
+		d := Decision{
+			Recommendation:   c.rec,
+			Chosen:           q > 0,
+			Quantity:         q,
+			MonthlySavings:   c.savings * q,
+			Upfront:          c.upfront * q,
+			HourlyCommitment: c.hourly * q,
+			AtRiskHourly:     c.hourly * c.volatility * q,
+			Volatility:       c.volatility,
+		}
+		d.Reason = explain(cands, live, rows, sol.x, quantity, i)
+		res.Decisions[i] = d
+		if d.Chosen {
+			res.Selected = append(res.Selected, scaled(c, q))
+			res.MonthlySavings += d.MonthlySavings
+			res.Upfront += d.Upfront
+			res.HourlyCommitment += d.HourlyCommitment
+			res.AtRiskHourly += d.AtRiskHourly
+		}
+	}
+	return res, nil
+}
+
+func newCandidate(rec common.Recommendation, cfg Config) candidate {
+	c := candidate{rec: rec, domains: Domains(rec)}
+	years, err := termYears(rec.Term)
+	if err != nil {
+		c.skip = err.Error()
+		return c
+	}
+	if rec.EstimatedSavings <= 0 {
+		c.skip = fmt.Sprintf("no net savings (%.2f/month)", rec.EstimatedSavings)
+		return c
+	}
+	monthly := rec.CommitmentCost / float64(12*years)
+	if rec.RecurringMonthlyCost != nil {
+		monthly += *rec.RecurringMonthlyCost
+	} else if rec.OnDemandCost > 0 {
+		monthly = math.Max(rec.OnDemandCost-rec.EstimatedSavings, monthly)
+	}
+	onDemand := rec.OnDemandCost
+	if onDemand <= 0 {
+		onDemand = monthly + rec.EstimatedSavings
+	}
+
+	c.units, c.integer = 1, false
+	if rec.CommitmentType != common.CommitmentSavingsPlan && !common.IsSavingsPlan(rec.Service) && rec.Count > 0 {
+		c.units, c.integer = float64(rec.Count), true
+	}
+	c.savings = rec.EstimatedSavings / c.units
+	c.upfront = rec.CommitmentCost / c.units
+	c.hourly = monthly / HoursPerMonth / c.units
+	c.onDemand = onDemand / c.units
+
+	// Current coverage, when the collector attached it, bounds the pool at
+	// its uncovered usage rather than at what this recommendation proposes.
+	c.demandCap = onDemand
+	if c.integer && rec.ExistingCoverageKnown && rec.AverageInstancesUsedPerHour > 0 {
+		uncovered := rec.AverageInstancesUsedPerHour * (1 - rec.ExistingCoveragePct/100)
+		c.demandCap = math.Max(uncovered, 0) * c.onDemand
+	}
+
+	c.volatility = cfg.DefaultVolatility
+	if v, ok := cfg.Volatility[PoolKey(rec)]; ok {
+		c.volatility = v
+	} else if cv, ok := Volatility(rec.UsageHistory); ok {
+		c.volatility = cv
+	}
+	return c
+}
+
+// row is one ≤ constraint over the live candidates.
+type row struct {
+	kind   string // "upfront", "hourly", "risk" or "domain"
+	domain Domain
+	coef   []float64
+	limit  float64
+}
+
+func buildRows(cands []candidate, live []int, cfg Config) []row {
+	n := len(live)
+	var rows []row
+	add := func(kind string, limit float64, coef func(c candidate) float64) {
+		r := row{kind: kind, limit: limit, coef: make([]float64, n)}
+		for j, i := range live {
+			r.coef[j] = coef(cands[i])
+		}
+		rows = append(rows, r)
+	}
+	if cfg.MaxUpfront > 0 {
+		add("upfront", cfg.MaxUpfront, func(c candidate) float64 { return c.upfront })
+	}
+	if cfg.MaxHourlyCommitment > 0 {
+		add("hourly", cfg.MaxHourlyCommitment, func(c candidate) float64 { return c.hourly })
+	}
+	if cfg.MaxAtRiskHourly > 0 {
+		add("risk", cfg.MaxAtRiskHourly, func(c candidate) float64 { return c.hourly * c.volatility })
+	}
+
+	caps := domainCaps(cands, live, cfg.Demand)
+	for _, d := range orderedDomains(cands, live) {
+		d := d
+		add("domain", caps[d.Key], func(c candidate) float64 {
+			for _, cd := range c.domains {
+				if cd.Key == d.Key {
+					return c.onDemand
+				}
+			}
+			return 0
+		})
+		rows[len(rows)-1].domain = d
+	}
+	return rows
+}
+
+// orderedDomains lists the live candidates' domains in first-seen order so
+// the program, and therefore the solution, is deterministic.
+func orderedDomains(cands []candidate, live []int) []Domain {
+	seen := map[string]bool{}
+	var out []Domain
+	for _, i := range live {
+		for _, d := range cands[i].domains {
+			if !seen[d.Key] {
+				seen[d.Key] = true
+				out = append(out, d)
+			}
+		}
+	}
+	return out
+}
+
+// domainCaps estimates each domain's uncovered on-demand spend per month.
+// A domain holds at least what its largest direct candidate was sized
+// against, and at least the sum of its sub-domains: a Compute Savings Plan
+// and the EC2 RI pools inside it each saw part of the same usage.
+// Config.Demand overrides the estimate.
+func domainCaps(cands []candidate, live []int, demand map[string]float64) map[string]float64 {
+	own := map[string]float64{}
+	children := map[string]map[string]bool{}
+	for _, i := range live {
+		c := cands[i]
+		if len(c.domains) == 0 {
+			continue
+		}
+		if k := c.domains[0].Key; c.demandCap > own[k] {
+			own[k] = c.demandCap
+		}
+		for l := 1; l < len(c.domains); l++ {
+			parent := c.domains[l].Key
+			if children[parent] == nil {
+				children[parent] = map[string]bool{}
+			}
+			children[parent][c.domains[l-1].Key] = true
+		}
+	}
+	caps := map[string]float64{}
+	var capOf func(k string) float64
+	capOf = func(k string) float64 {
+		if v, ok := caps[k]; ok {
+			return v
+		}
+		if v, ok := demand[k]; ok {
+			caps[k] = v
+			return v
+		}
+		sum := 0.0
+		for child := range children[k] {
+			sum += capOf(child)
+		}
+		caps[k] = math.Max(own[k], sum)
+		return caps[k]
+	}
+	for _, i := range live {
+		for _, d := range cands[i].domains {
+			capOf(d.Key)
+		}
+	}
+	return caps
+}
+
+// explain renders the Decision reason for candidate i from the solution and
+// the constraints that bind on it.
+func explain(cands []candidate, live []int, rows []row, x, quantity []float64, i int) string {
+	c := cands[i]
+	if c.skip != "" {
+		return "dropped: " + c.skip
+	}
+	j := -1
+	for k, idx := range live {
+		if idx == i {
+			j = k
+		}
+	}
+	return verdict(c, quantity[i], bindingReasons(cands, live, rows, x, i, j))
+}
+
+// bindingReasons describes the rows that block candidate i (column j of
+// x). A row blocks the candidate when it cannot take another unit (or, for
+// a Savings Plan fraction, any more at all). Budget, cap and risk rows are
+// reported first, then the narrowest blocking usage domain.
+func bindingReasons(cands []candidate, live []int, rows []row, x []float64, i, j int) []string {
+	c := cands[i]
+	blocks := func(r row) bool {
+		slack := r.limit - dot(r.coef, x)
+		tol := 1e-6 * math.Max(1, r.limit)
+		if c.integer {
+			return slack < r.coef[j]-tol
+		}
+		return slack <= tol
+	}
+	var binding []string
+	for _, r := range rows {
+		if r.kind != "domain" && r.coef[j] > 0 && blocks(r) {
+			binding = append(binding, bindingReason(cands, live, r, x, i))
+		}
+	}
+domains:
+	for _, d := range c.domains {
+		for _, r := range rows {
+			if r.kind == "domain" && r.domain.Key == d.Key && blocks(r) {
+				binding = append(binding, bindingReason(cands, live, r, x, i))
+				break domains
+			}
+		}
+	}
+	return binding
+}
+
+// verdict renders the explanation for candidate c bought at quantity q.
+func verdict(c candidate, q float64, binding []string) string {
+	switch {
+	case q >= c.units-1e-6:
+		return fmt.Sprintf("chosen: %s saves %.2f/month for %.2f upfront and %.4f/hour committed",
+			quantityLabel(c, q), c.savings*q, c.upfront*q, c.hourly*q)
+	case q > 0 && len(binding) > 0:
+		return fmt.Sprintf("sized down to %s: %s", quantityLabel(c, q), strings.Join(binding, "; "))
+	case q > 0:
+		return fmt.Sprintf("sized down to %s: whole units only", quantityLabel(c, q))
+	case len(binding) > 0:
+		return "dropped: " + strings.Join(binding, "; ")
+	default:
+		return fmt.Sprintf("dropped: %.2f/month savings per unit is worth less than the capacity it would take from the chosen items", c.savings)
+	}
+}
+
+func bindingReason(cands []candidate, live []int, r row, x []float64, self int) string {
+	switch r.kind {
+	case "upfront":
+		return fmt.Sprintf("upfront budget %.2f is spent", r.limit)
+	case "hourly":
+		return fmt.Sprintf("commitment cap %.4f/hour is reached", r.limit)
+	case "risk":
+		return fmt.Sprintf("volatility risk bound %.4f/hour is reached", r.limit)
+	}
+	var others []string
+	for j, i := range live {
+		if i != self && x[j] > 1e-6 && r.coef[j] > 0 {
+			others = append(others, Label(cands[i].rec))
+		}
+	}
+	if len(others) == 0 {
+		return fmt.Sprintf("%s has only %.2f/month of uncovered on-demand usage", r.domain.Label, r.limit)
+	}
+	if len(others) > 3 {
+		others = append(others[:3], fmt.Sprintf("%d more", len(others)-3))
+	}
+	return fmt.Sprintf("%s is already covered by %s", r.domain.Label, strings.Join(others, ", "))
+}
+
+func quantityLabel(c candidate, q float64) string {
+	if c.integer {
+		return fmt.Sprintf("%d of %d units", int(math.Round(q)), int(c.units))
+	}
+	return fmt.Sprintf("%.0f%% of the commitment", q*100)
+}
+
+// scaled returns the recommendation sized to the chosen quantity, with
+// Count and every money field in step (common.ScaleRecommendationCosts).
+func scaled(c candidate, q float64) common.Recommendation {
+	ratio := q / c.units
+	if ratio >= 1-1e-9 {
+		return c.rec
+	}
+	rec := common.ScaleRecommendationCosts(c.rec, ratio)
+	if c.integer {
+		rec.Count = int(math.Round(q))
+	}
+	return rec
+}
+
+// Volatility returns the coefficient of variation (standard deviation over
+// mean, capped at 1) of a usage series such as Recommendation.UsageHistory.
+// ok is false when the series has fewer than two points.
+func Volatility(series []float64) (cv float64, ok bool) {
+	if len(series) < 2 {
+		return 0, false
+	}
+	var sum float64
+	for _, v := range series {
+		sum += v
+	}
+	mean := sum / float64(len(series))
+	if mean <= 0 {
+		return 1, true
+	}
+	var ss float64
+	for _, v := range series {
+		ss += (v - mean) * (v - mean)
+	}
+	cv = math.Sqrt(ss/float64(len(series))) / mean
+	return math.Min(cv, 1), true
+}
+
+// CoverageDemand is Config.Demand from the current coverage recs carry
+// (ExistingCoverageKnown): for each count-denominated pool, the on-demand
+// spend per month its usage leaves uncovered. Variants of a pool share its
+// usage, so the pool takes the largest of their figures. Pools without
+// coverage are left out, and Optimize estimates them from the candidates.
+func CoverageDemand(recs []common.Recommendation) map[string]float64 {
+	demand := map[string]float64{}
+	for i := range recs {
+		rec := recs[i]
+		if !rec.ExistingCoverageKnown || rec.AverageInstancesUsedPerHour <= 0 {
+			continue
+		}
+		c := newCandidate(rec, Config{})
+		if c.skip != "" || !c.integer {
+			continue
+		}
+		key := PoolKey(rec)
+		demand[key] = math.Max(demand[key], c.demandCap)
+	}
+	return demand
+}
+
+// PoolKey identifies the usage pool a recommendation covers, independent of
+// term and payment: variants of the same pool share it.
+func PoolKey(rec common.Recommendation) string {
+	return strings.Join([]string{"pool", string(rec.Provider), rec.Account, string(rec.Service), rec.Region, rec.ResourceType, engine(rec)}, "|")
+}
+
+// Domains lists the usage domains rec draws from, narrowest first.
+// Count-denominated commitments start at their pool; AWS EC2 and RDS pools
+// sit inside the domains the matching Savings Plans cover.
+func Domains(rec common.Recommendation) []Domain {
+	acct := string(rec.Provider) + "|" + rec.Account
+	compute := Domain{Key: "compute|" + acct, Label: "compute usage of account " + rec.Account}
+	database := Domain{Key: "database|" + acct, Label: "database usage of account " + rec.Account}
+	family := func(region, fam string) Domain {
+		return Domain{
+			Key:   strings.Join([]string{"ec2-family", acct, region, fam}, "|"),
+			Label: fmt.Sprintf("%s family usage in %s", fam, region),
+		}
+	}
+
+	if rec.Provider == common.ProviderAWS && common.IsSavingsPlan(rec.Service) {
+		details, _ := rec.Details.(*common.SavingsPlanDetails)
+		switch rec.Service {
+		case common.ServiceSavingsPlansCompute:
+			return []Domain{compute}
+		case common.ServiceSavingsPlansEC2Instance:
+			if details != nil && details.InstanceFamily != "" {
+				return []Domain{family(details.Region, details.InstanceFamily), compute}
+			}
+			return []Domain{compute}
+		case common.ServiceSavingsPlansDatabase:
+			return []Domain{database}
+		default:
+			return []Domain{{Key: string(rec.Service) + "|" + acct, Label: string(rec.Service) + " usage of account " + rec.Account}}
+		}
+	}
+
+	pool := Domain{Key: PoolKey(rec), Label: fmt.Sprintf("%s %s usage in %s", rec.Service, rec.ResourceType, rec.Region)}
+	if rec.Provider == common.ProviderAWS {
+		switch rec.Service {
+		case common.ServiceEC2:
+			if fam, _, ok := strings.Cut(rec.ResourceType, "."); ok {
+				return []Domain{pool, family(rec.Region, fam), compute}
+			}
+			return []Domain{pool, compute}
+		case common.ServiceRDS:
+			return []Domain{pool, database}
+		}
+	}
+	return []Domain{pool}
+}
+
+// Label is a short human description of a recommendation variant.
+func Label(rec common.Recommendation) string {
+	parts := []string{string(rec.Service)}
+	if rec.ResourceType != "" {
+		parts = append(parts, rec.ResourceType)
+	}
+	if rec.Region != "" {
+		parts = append(parts, rec.Region)
+	}
+	parts = append(parts, rec.Term, rec.PaymentOption)
+	return strings.Join(parts, " ")
+}
+
+func engine(rec common.Recommendation) string {
+	switch d := rec.Details.(type) {
+	case *common.DatabaseDetails:
+		if d != nil {
+			return d.Engine
+		}
+	case *common.CacheDetails:
+		if d != nil {
+			return d.Engine
+		}
+	}
+	return ""
+}
+
+// termYears parses "1yr", "3yr" or a bare year count.
+func termYears(term string) (int, error) {
+	years, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(term), "yr"))
+	if err != nil || years <= 0 {
+		return 0, fmt.Errorf("invalid term %q", term)
+	}
+	return years, nil
+}
@@ -0,0 +1,227 @@
+package portfolio
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func ri(resourceType, term, payment string, count int, onDemand, upfront, recurring, savings float64) common.Recommendation {
+	return common.Recommendation{
+		Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceEC2,
+		Region: "us-east-1", ResourceType: resourceType, Count: count,
+		CommitmentType: common.CommitmentReservedInstance, Term: term, PaymentOption: payment,
+		OnDemandCost: onDemand, CommitmentCost: upfront, RecurringMonthlyCost: &recurring, EstimatedSavings: savings,
+	}
+}
+
+func computeSP(onDemand, recurring, savings float64) common.Recommendation {
+	return common.Recommendation{
+		Provider: common.ProviderAWS, Account: "111111111111", Service: common.ServiceSavingsPlansCompute,
+		CommitmentType: common.CommitmentSavingsPlan, Term: "1yr", PaymentOption: "no-upfront", Count: 1,
+		OnDemandCost: onDemand, RecurringMonthlyCost: &recurring, EstimatedSavings: savings,
+		Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: recurring / HoursPerMonth},
+	}
+}
+
+func optimize(t *testing.T, recs []common.Recommendation, cfg Config) Result {
+	t.Helper()
+	res, err := Optimize(recs, cfg)
+	if err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+	if !res.Optimal {
+		t.Fatal("search stopped before proving optimality")
+	}
+	return res
+}
+
+func TestOptimize_OverlappingSavingsPlanAndRI(t *testing.T) {
+	recs := []common.Recommendation{
+		ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 280, 120),
+		computeSP(400, 300, 100),
+	}
+	res := optimize(t, recs, Config{})
+
+	if !res.Decisions[0].Chosen || res.Decisions[0].Quantity != 4 {
+		t.Fatalf("RI decision = %+v, want all 4 units", res.Decisions[0])
+	}
+	sp := res.Decisions[1]
+	if sp.Chosen {
+		t.Fatalf("SP chosen on top of the RI covering the same usage: %+v", sp)
+	}
+	if !strings.Contains(sp.Reason, "compute usage of account 111111111111 is already covered by ec2 m5.large") {
+		t.Fatalf("SP reason = %q", sp.Reason)
+	}
+	if res.MonthlySavings != 120 || len(res.Selected) != 1 {
+		t.Fatalf("portfolio = %+v", res)
+	}
+}
+
+func TestOptimize_SavingsPlanFillsDemandBeyondRIPools(t *testing.T) {
+	// The SP was sized against 1000/month of compute usage; the RI pool is
+	// 400 of it. The optimum takes the RI and 60% of the SP.
+	recs := []common.Recommendation{
+		ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 280, 120),
+		computeSP(1000, 750, 250),
+	}
+	res := optimize(t, recs, Config{})
+	sp := res.Decisions[1]
+	if !res.Decisions[0].Chosen || math.Abs(sp.Quantity-0.6) > 1e-6 {
+		t.Fatalf("decisions = %+v", res.Decisions)
+	}
+	if !strings.HasPrefix(sp.Reason, "sized down to 60% of the commitment") {
+		t.Fatalf("SP reason = %q", sp.Reason)
+	}
+	scaled := res.Selected[1]
+	if d := scaled.Details.(*common.SavingsPlanDetails); math.Abs(d.HourlyCommitment-0.6*750/HoursPerMonth) > 1e-9 {
+		t.Fatalf("scaled hourly commitment = %v", d.HourlyCommitment)
+	}
+	if math.Abs(res.MonthlySavings-(120+150)) > 1e-6 {
+		t.Fatalf("savings = %v", res.MonthlySavings)
+	}
+}
+
+func TestOptimize_PicksTermAndPaymentUnderBudget(t *testing.T) {
+	recs := []common.Recommendation{
+		ri("m5.large", "1yr", "no-upfront", 4, 400, 0, 300, 100),
+		ri("m5.large", "3yr", "all-upfront", 4, 400, 7200, 0, 200),
+	}
+	unconstrained := optimize(t, recs, Config{})
+	if unconstrained.Decisions[0].Chosen || !unconstrained.Decisions[1].Chosen {
+		t.Fatalf("without a budget the 3yr variant must win: %+v", unconstrained.Decisions)
+	}
+	if !strings.Contains(unconstrained.Decisions[0].Reason, "already covered by ec2 m5.large us-east-1 3yr all-upfront") {
+		t.Fatalf("1yr reason = %q", unconstrained.Decisions[0].Reason)
+	}
+
+	// 3600 buys two 3yr units; the rest of the pool goes 1yr.
+	budget := optimize(t, recs, Config{MaxUpfront: 3600})
+	oneYear, threeYear := budget.Decisions[0], budget.Decisions[1]
+	if threeYear.Quantity != 2 || oneYear.Quantity != 2 {
+		t.Fatalf("decisions = %+v", budget.Decisions)
+	}
+	if !strings.Contains(threeYear.Reason, "sized down to 2 of 4 units") || !strings.Contains(threeYear.Reason, "upfront budget 3600.00 is spent") {
+		t.Fatalf("3yr reason = %q", threeYear.Reason)
+	}
+	if budget.Selected[1].Count != 2 || budget.Selected[1].CommitmentCost != 3600 {
+		t.Fatalf("selected 3yr = %+v", budget.Selected[1])
+	}
+	if budget.Upfront != 3600 || budget.MonthlySavings != 2*25+2*50 {
+		t.Fatalf("totals = %+v", budget)
+	}
+}
+
+func TestOptimize_HourlyCapPrefersSavingsPerCommittedDollar(t *testing.T) {
+	recs := []common.Recommendation{
+		ri("m5.large", "1yr", "no-upfront", 1, 100, 0, 73, 27),
+		ri("c5.large", "1yr", "no-upfront", 1, 100, 0, 36.5, 20),
+	}
+	res := optimize(t, recs, Config{MaxHourlyCommitment: 0.06})
+	if res.Decisions[0].Chosen || !res.Decisions[1].Chosen {
+		t.Fatalf("decisions = %+v", res.Decisions)
+	}
+	if !strings.Contains(res.Decisions[0].Reason, "commitment cap 0.0600/hour") {
+		t.Fatalf("reason = %q", res.Decisions[0].Reason)
+	}
+}
+
+func TestOptimize_RiskBoundDropsVolatilePools(t *testing.T) {
+	steady := ri("m5.large", "1yr", "no-upfront", 1, 146, 0, 73, 73)
+	steady.UsageHistory = []float64{100, 100, 100, 100}
+	spiky := ri("c5.large", "1yr", "no-upfront", 1, 146, 0, 73, 73)
+	spiky.UsageHistory = []float64{0, 100, 0, 100}
+
+	res := optimize(t, []common.Recommendation{steady, spiky}, Config{MaxAtRiskHourly: 0.05})
+	if !res.Decisions[0].Chosen || res.Decisions[1].Chosen {
+		t.Fatalf("decisions = %+v", res.Decisions)
+	}
+	if res.Decisions[1].Volatility != 1 || !strings.Contains(res.Decisions[1].Reason, "volatility risk bound") {
+		t.Fatalf("spiky decision = %+v", res.Decisions[1])
+	}
+	if res.AtRiskHourly != 0 {
+		t.Fatalf("at-risk = %v", res.AtRiskHourly)
+	}
+}
+
+func TestOptimize_CoverageCapsPoolDemand(t *testing.T) {
+	// 10 instances run on average, 70% are already covered: 3 uncovered.
+	rec := ri("m5.large", "1yr", "no-upfront", 5, 500, 0, 350, 150)
+	rec.AverageInstancesUsedPerHour = 10
+	rec.ExistingCoveragePct = 70
+	rec.ExistingCoverageKnown = true
+
+	res := optimize(t, []common.Recommendation{rec}, Config{})
+	if res.Decisions[0].Quantity != 3 || !strings.Contains(res.Decisions[0].Reason, "ec2 m5.large usage in us-east-1 has only 300.00/month of uncovered on-demand usage") {
+		t.Fatalf("decision = %+v", res.Decisions[0])
+	}
+	if res.Selected[0].Count != 3 || math.Abs(res.Selected[0].EstimatedSavings-90) > 1e-9 {
+		t.Fatalf("selected = %+v", res.Selected[0])
+	}
+}
+
+func TestCoverageDemand(t *testing.T) {
+	// Two variants of a pool 70% covered, one unknown pool and a Savings
+	// Plan: only the covered pool gets a figure, 3 uncovered instances at
+	// 100/month each.
+	oneYear := ri("m5.large", "1yr", "no-upfront", 5, 500, 0, 350, 150)
+	threeYear := ri("m5.large", "3yr", "all-upfront", 5, 500, 9000, 0, 250)
+	for _, rec := range []*common.Recommendation{&oneYear, &threeYear} {
+		rec.AverageInstancesUsedPerHour = 10
+		rec.ExistingCoveragePct = 70
+		rec.ExistingCoverageKnown = true
+	}
+	unknown := ri("c5.large", "1yr", "no-upfront", 2, 200, 0, 140, 60)
+	unknown.AverageInstancesUsedPerHour = 4
+
+	demand := CoverageDemand([]common.Recommendation{oneYear, threeYear, unknown, computeSP(500, 350, 150)})
+	if len(demand) != 1 || math.Abs(demand[PoolKey(oneYear)]-300) > 1e-9 {
+		t.Fatalf("demand = %v", demand)
+	}
+}
+
+func TestOptimize_DropsIneligibleAndRejectsBadConfig(t *testing.T) {
+	bad := ri("m5.large", "", "no-upfront", 1, 100, 0, 70, 30)
+	loss := ri("c5.large", "1yr", "no-upfront", 1, 100, 0, 110, -10)
+	res := optimize(t, []common.Recommendation{bad, loss}, Config{})
+	if res.Decisions[0].Reason != `dropped: invalid term ""` || !strings.HasPrefix(res.Decisions[1].Reason, "dropped: no net savings") {
+		t.Fatalf("decisions = %+v", res.Decisions)
+	}
+	if len(res.Selected) != 0 {
+		t.Fatalf("selected = %+v", res.Selected)
+	}
+	if _, err := Optimize(nil, Config{MaxUpfront: -1}); err == nil || !strings.Contains(err.Error(), "MaxUpfront") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDomains(t *testing.T) {
+	rds := common.Recommendation{Provider: common.ProviderAWS, Account: "1", Service: common.ServiceRDS,
+		Region: "eu-west-1", ResourceType: "db.r6g.large", Details: &common.DatabaseDetails{Engine: "postgres"}}
+	if d := Domains(rds); len(d) != 2 || d[1].Key != "database|aws|1" || !strings.HasSuffix(d[0].Key, "|postgres") {
+		t.Fatalf("rds domains = %+v", d)
+	}
+	ec2SP := common.Recommendation{Provider: common.ProviderAWS, Account: "1", Service: common.ServiceSavingsPlansEC2Instance,
+		Details: &common.SavingsPlanDetails{InstanceFamily: "m5", Region: "us-east-1"}}
+	if d := Domains(ec2SP); len(d) != 2 || d[0].Key != "ec2-family|aws|1|us-east-1|m5" {
+		t.Fatalf("ec2 instance SP domains = %+v", d)
+	}
+	azure := common.Recommendation{Provider: common.ProviderAzure, Account: "sub", Service: common.ServiceCompute, ResourceType: "Standard_D2s_v3"}
+	if d := Domains(azure); len(d) != 1 {
+		t.Fatalf("azure domains = %+v", d)
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	if _, ok := Volatility([]float64{50}); ok {
+		t.Fatal("one point has no volatility")
+	}
+	if cv, _ := Volatility([]float64{50, 50, 50}); cv != 0 {
+		t.Fatalf("flat cv = %v", cv)
+	}
+	if cv, _ := Volatility([]float64{40, 60}); math.Abs(cv-0.2) > 1e-9 {
+		t.Fatalf("cv = %v, want 0.2", cv)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
 	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
@@ -65,6 +66,15 @@ type RecommendationsClient interface {
 	GetAllRecommendations(ctx context.Context) ([]common.Recommendation, error)
 }
 
+// NativeRecommendationsSource is implemented by providers that can compute
+// recommendations with pkg/recommender from their own usage, coverage and
+// pricing data instead of the provider recommendation API. It is optional:
+// callers type-assert a Provider and fall back to RecommendationsClient when
+// it is absent.
+type NativeRecommendationsSource interface {
+	GetNativeRecommendations(ctx context.Context, cfg recommender.Config) ([]common.Recommendation, error)
+}
+
 // Credentials represents cloud provider credentials
 type Credentials interface {
 	IsValid() bool
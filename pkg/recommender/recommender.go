@@ -0,0 +1,352 @@
+// Package recommender computes commitment purchase recommendations from
+// per-pool usage instead of a provider recommendation API. It is a pure
+// function package: providers fetch the usage series, existing coverage and
+// offering prices from their own billing and pricing APIs and hand them in as
+// PoolInputs; Recommend does the sizing and economics identically for every
+// provider and tags its output with common.RecommendationSourceNative.
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// HoursPerMonth converts hourly rates into the monthly figures
+// common.Recommendation carries (OnDemandCost, EstimatedSavings,
+// RecurringMonthlyCost). 730 = 8760 / 12, the same factor the AWS Savings
+// Plans parser uses.
+const HoursPerMonth = 730.0
+
+// termHours is the length of each supported term, used to amortise an
+// offering's upfront price when it does not quote an effective hourly rate.
+var termHours = map[ladder.Term]float64{
+	ladder.Term1Year: 8760,
+	ladder.Term3Year: 3 * 8760,
+}
+
+// Config controls the engine. Unlike the provider APIs, whose lookbacks are
+// fixed enums, every knob is free-form within its validated range.
+type Config struct {
+	// Term and PaymentOption are copied onto every recommendation and select
+	// the offering the caller priced. Term must be "1yr" or "3yr".
+	Term          string
+	PaymentOption string
+	// LookbackDays is how much usage history the sizing looks at, counted
+	// back from the newest sample.
+	LookbackDays int
+	// Percentile in (0, 100] picks the demand level the commitment is sized
+	// to: the nearest-rank percentile of uncovered usage over the lookback.
+	// Low values size to the trough and keep utilization near 100%.
+	Percentile float64
+	// MaxBreakEvenMonths drops pools whose upfront payment takes longer than
+	// this to recoup. 0 means no cap.
+	MaxBreakEvenMonths float64
+}
+
+// Validate reports the first invalid field.
+func (c Config) Validate() error {
+	if _, err := ladder.ParseTerm(c.Term); err != nil {
+		return fmt.Errorf("recommender: %w", err)
+	}
+	if c.PaymentOption == "" {
+		return fmt.Errorf("recommender: PaymentOption must not be empty")
+	}
+	if c.LookbackDays <= 0 {
+		return fmt.Errorf("recommender: LookbackDays %d must be > 0", c.LookbackDays)
+	}
+	if math.IsNaN(c.Percentile) || !(c.Percentile > 0 && c.Percentile <= 100) {
+		return fmt.Errorf("recommender: Percentile %g must be in (0, 100]", c.Percentile)
+	}
+	if math.IsNaN(c.MaxBreakEvenMonths) || c.MaxBreakEvenMonths < 0 {
+		return fmt.Errorf("recommender: MaxBreakEvenMonths %g must be >= 0", c.MaxBreakEvenMonths)
+	}
+	return nil
+}
+
+// Pool identifies one purchasable commitment pool: the tuple a single
+// offering covers. Details is copied onto the recommendation so the purchase
+// path can resolve the offering exactly as it would for a provider rec.
+type Pool struct {
+	Details        common.ServiceDetails
+	Provider       common.ProviderType
+	Account        string
+	Service        common.ServiceType
+	Region         string
+	ResourceType   string
+	CommitmentType common.CommitmentType
+}
+
+// String renders the pool for skip reasons and logs.
+func (p Pool) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", p.Provider, p.Service, p.Region, p.ResourceType)
+}
+
+// dollarDenominated reports whether the pool's units are on-demand USD per
+// hour (Savings Plans) rather than a count of instances or nodes.
+func (p Pool) dollarDenominated() bool {
+	return p.CommitmentType == common.CommitmentSavingsPlan
+}
+
+// UsagePoint is one sample of pool demand. Units is the average demand per
+// hour over the sample: instances or nodes for count-denominated pools,
+// on-demand-equivalent USD/h for Savings Plans pools. Samples may be hourly
+// or daily averages; the engine only requires a fixed, increasing cadence.
+type UsagePoint struct {
+	Time  time.Time
+	Units float64
+}
+
+// PoolInput is everything the engine needs for one pool.
+type PoolInput struct {
+	Pool Pool
+	// Offering prices ONE unit of the pool for Config.Term and
+	// Config.PaymentOption. UpfrontCost is paid once per unit; RecurringCost
+	// is per unit-hour. EffectiveHourlyRate, when > 0, is the all-in hourly
+	// rate and takes precedence over amortising the other two.
+	Offering common.OfferingDetails
+	// Usage is total demand (covered and uncovered), oldest first.
+	Usage []UsagePoint
+	// ExistingUnits is the demand already covered by active commitments.
+	ExistingUnits float64
+	// OnDemandUSDPerUnitHour is the on-demand price of one unit for one hour
+	// (1 for Savings Plans pools, whose units are already dollars).
+	OnDemandUSDPerUnitHour float64
+}
+
+// SkippedPool records a pool that produced no recommendation and why.
+// Skips are expected (no uncovered demand, unprofitable offering) and are
+// returned rather than logged so callers can surface them.
+type SkippedPool struct {
+	Pool   Pool
+	Reason string
+}
+
+// Result holds the engine output.
+type Result struct {
+	Recommendations []common.Recommendation
+	Skipped         []SkippedPool
+}
+
+// Recommend sizes and prices a commitment for each pool. An invalid cfg is
+// an error; a pool that cannot or should not be bought is a SkippedPool.
+// Recommendations are sorted by EstimatedSavings descending, then pool
+// identity, for deterministic output.
+//
+// Per pool:
+//   - the usage window is the last LookbackDays of samples; the series must
+//     reach back that far and its newest sample may be at most
+//     ladder.MaxBaselineSeriesAgeDays days older than now;
+//   - uncovered demand per sample is max(usage - ExistingUnits, 0), and the
+//     commitment quantity is its Percentile-th value (floored to a whole unit
+//     for count-denominated pools);
+//   - on-demand cost avoided is the mean of min(uncovered, quantity) priced
+//     at OnDemandUSDPerUnitHour, so idle commitment hours count against the
+//     savings instead of being assumed away;
+//   - break-even is the upfront payment divided by the monthly on-demand
+//     cost avoided net of the recurring charge.
+func Recommend(inputs []PoolInput, cfg Config, now time.Time) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for i := range inputs {
+		rec, reason := recommendPool(&inputs[i], cfg, now)
+		if reason != "" {
+			res.Skipped = append(res.Skipped, SkippedPool{Pool: inputs[i].Pool, Reason: reason})
+			continue
+		}
+		res.Recommendations = append(res.Recommendations, rec)
+	}
+	sort.SliceStable(res.Recommendations, func(i, j int) bool {
+		a, b := res.Recommendations[i], res.Recommendations[j]
+		if a.EstimatedSavings != b.EstimatedSavings {
+			return a.EstimatedSavings > b.EstimatedSavings
+		}
+		return string(a.Service)+"|"+a.Region+"|"+a.ResourceType < string(b.Service)+"|"+b.Region+"|"+b.ResourceType
+	})
+	return res, nil
+}
+
+// recommendPool returns the recommendation for one pool, or a non-empty
+// skip reason.
+func recommendPool(in *PoolInput, cfg Config, now time.Time) (common.Recommendation, string) {
+	window, reason := usageWindow(in.Usage, cfg.LookbackDays, now)
+	if reason != "" {
+		return common.Recommendation{}, reason
+	}
+	if !finiteNonNegative(in.ExistingUnits) || !finiteNonNegative(in.OnDemandUSDPerUnitHour) {
+		return common.Recommendation{}, "existing units and on-demand rate must be finite and >= 0"
+	}
+	if in.OnDemandUSDPerUnitHour == 0 {
+		return common.Recommendation{}, "no on-demand rate for the pool"
+	}
+	rate, reason := hourlyRate(in.Offering, cfg.Term)
+	if reason != "" {
+		return common.Recommendation{}, reason
+	}
+
+	quantity, meanUsed, meanTotal, reason := sizeCommitment(in, window, cfg.Percentile)
+	if reason != "" {
+		return common.Recommendation{}, reason
+	}
+
+	onDemandMonthly := meanUsed * in.OnDemandUSDPerUnitHour * HoursPerMonth
+	savings := onDemandMonthly - quantity*rate*HoursPerMonth
+	utilization := meanUsed / quantity * 100
+	if savings <= 0 {
+		return common.Recommendation{}, fmt.Sprintf("not profitable at %.1f%% projected utilization", utilization)
+	}
+
+	upfront := in.Offering.UpfrontCost * quantity
+	var breakEven float64
+	if upfront > 0 {
+		// savings > 0 and amortised upfront > 0 imply this is positive.
+		breakEven = upfront / (onDemandMonthly - quantity*in.Offering.RecurringCost*HoursPerMonth)
+	}
+	if cfg.MaxBreakEvenMonths > 0 && breakEven > cfg.MaxBreakEvenMonths {
+		return common.Recommendation{}, fmt.Sprintf("break-even %.1f months exceeds maximum %g", breakEven, cfg.MaxBreakEvenMonths)
+	}
+
+	rec := common.Recommendation{
+		Provider:             in.Pool.Provider,
+		Account:              in.Pool.Account,
+		Service:              in.Pool.Service,
+		Region:               in.Pool.Region,
+		ResourceType:         in.Pool.ResourceType,
+		CommitmentType:       in.Pool.CommitmentType,
+		Term:                 cfg.Term,
+		PaymentOption:        cfg.PaymentOption,
+		OnDemandCost:         onDemandMonthly,
+		CommitmentCost:       upfront,
+		EstimatedSavings:     savings,
+		SavingsPercentage:    savings / onDemandMonthly * 100,
+		BreakEvenMonths:      breakEven,
+		Details:              recDetails(in.Pool, quantity*rate),
+		SourceRecommendation: common.RecommendationSourceNative,
+		Timestamp:            now,
+
+		RecommendedUtilization: utilization,
+		ProjectedUtilization:   utilization,
+	}
+	if in.Offering.UpfrontCost > 0 || in.Offering.RecurringCost > 0 {
+		monthly := quantity * in.Offering.RecurringCost * HoursPerMonth
+		rec.RecurringMonthlyCost = &monthly
+	}
+	if in.Pool.dollarDenominated() {
+		rec.Count = 1
+	} else {
+		rec.Count = int(quantity)
+		rec.RecommendedCount = rec.Count
+		rec.AverageInstancesUsedPerHour = meanTotal
+	}
+	if meanTotal > 0 {
+		rec.ExistingCoveragePct = math.Min(in.ExistingUnits/meanTotal*100, 100)
+		rec.ExistingCoverageKnown = true
+		rec.ProjectedCoverage = math.Min((in.ExistingUnits+meanUsed)/meanTotal*100, 100)
+	}
+	return rec, ""
+}
+
+// sizeCommitment picks the commitment quantity at percentile of the demand
+// existing commitments leave uncovered, and returns the mean hourly units
+// it would be used for and the mean hourly units of total demand. A
+// non-empty reason means there is nothing to buy.
+func sizeCommitment(in *PoolInput, window []float64, percentile float64) (quantity, meanUsed, meanTotal float64, reason string) {
+	uncovered := make([]float64, len(window))
+	var totalUnits float64
+	for i, u := range window {
+		totalUnits += u
+		uncovered[i] = math.Max(u-in.ExistingUnits, 0)
+	}
+	quantity, err := ladder.NearestRankPercentile(uncovered, percentile)
+	if err != nil {
+		return 0, 0, 0, err.Error()
+	}
+	if !in.Pool.dollarDenominated() {
+		// The epsilon keeps 2.9999999 (a float sum of whole instances) at 3.
+		quantity = math.Floor(quantity + 1e-9)
+	}
+	if quantity <= 0 {
+		return 0, 0, 0, fmt.Sprintf("no uncovered demand at the p%g level", percentile)
+	}
+
+	var usedUnits float64
+	for _, u := range uncovered {
+		usedUnits += math.Min(u, quantity)
+	}
+	n := float64(len(window))
+	return quantity, usedUnits / n, totalUnits / n, ""
+}
+
+// usageWindow validates the series and returns the units inside the
+// lookback window, or a skip reason.
+func usageWindow(usage []UsagePoint, lookbackDays int, now time.Time) ([]float64, string) {
+	if len(usage) == 0 {
+		return nil, "no usage history"
+	}
+	for i, p := range usage {
+		if !finiteNonNegative(p.Units) {
+			return nil, fmt.Sprintf("usage sample %d is not finite and >= 0 (%g)", i, p.Units)
+		}
+		if i > 0 && !p.Time.After(usage[i-1].Time) {
+			return nil, fmt.Sprintf("usage samples are not strictly increasing at index %d", i)
+		}
+	}
+	newest := usage[len(usage)-1].Time
+	if age := now.Sub(newest); age > time.Duration(ladder.MaxBaselineSeriesAgeDays)*24*time.Hour {
+		return nil, fmt.Sprintf("usage history is stale: newest sample %s", newest.UTC().Format(time.RFC3339))
+	}
+
+	start := newest.Add(-time.Duration(lookbackDays) * 24 * time.Hour)
+	// The oldest in-window sample of a complete series sits at most one day
+	// after start, whatever the cadence.
+	if usage[0].Time.After(start.Add(24 * time.Hour)) {
+		return nil, fmt.Sprintf("usage history starts %s, shorter than the %d-day lookback",
+			usage[0].Time.UTC().Format("2006-01-02"), lookbackDays)
+	}
+	var window []float64
+	for _, p := range usage {
+		if p.Time.After(start) {
+			window = append(window, p.Units)
+		}
+	}
+	return window, ""
+}
+
+// hourlyRate returns the all-in hourly price of one unit of the offering.
+func hourlyRate(o common.OfferingDetails, term string) (float64, string) {
+	for _, v := range []float64{o.EffectiveHourlyRate, o.UpfrontCost, o.RecurringCost} {
+		if !finiteNonNegative(v) {
+			return 0, "offering prices must be finite and >= 0"
+		}
+	}
+	rate := o.EffectiveHourlyRate
+	if rate == 0 {
+		rate = o.RecurringCost + o.UpfrontCost/termHours[ladder.Term(term)]
+	}
+	if rate == 0 {
+		return 0, "offering has no price"
+	}
+	return rate, ""
+}
+
+// recDetails copies the pool's Details for the recommendation. A Savings
+// Plans pool gets its HourlyCommitment set from the sized quantity; the copy
+// keeps the caller's Pool untouched.
+func recDetails(p Pool, hourlyCommitment float64) common.ServiceDetails {
+	if sp, ok := p.Details.(*common.SavingsPlanDetails); ok && sp != nil {
+		cp := *sp
+		cp.HourlyCommitment = hourlyCommitment
+		return &cp
+	}
+	return p.Details
+}
+
+func finiteNonNegative(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && v >= 0
+}
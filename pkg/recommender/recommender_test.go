@@ -0,0 +1,242 @@
+package recommender
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testConfig() Config {
+	return Config{Term: "1yr", PaymentOption: "no-upfront", LookbackDays: 30, Percentile: 10}
+}
+
+// dailyUsage returns n daily samples ending yesterday.
+func dailyUsage(n int, units func(i int) float64) []UsagePoint {
+	end := testNow.Truncate(24*time.Hour).AddDate(0, 0, -1)
+	out := make([]UsagePoint, n)
+	for i := range out {
+		out[i] = UsagePoint{Time: end.AddDate(0, 0, i-n+1), Units: units(i)}
+	}
+	return out
+}
+
+func ec2Pool() Pool {
+	return Pool{
+		Provider:       common.ProviderAWS,
+		Service:        common.ServiceEC2,
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+		CommitmentType: common.CommitmentReservedInstance,
+		Details:        &common.ComputeDetails{Platform: "Linux/UNIX", Tenancy: "default", Scope: "Region"},
+	}
+}
+
+// ec2Input is a pool running 10 instances, 4 of them already reserved, at
+// 0.10 USD/h on demand against a 0.06 USD/h no-upfront RI.
+func ec2Input() PoolInput {
+	return PoolInput{
+		Pool:                   ec2Pool(),
+		Usage:                  dailyUsage(30, func(int) float64 { return 10 }),
+		ExistingUnits:          4,
+		OnDemandUSDPerUnitHour: 0.10,
+		Offering:               common.OfferingDetails{RecurringCost: 0.06},
+	}
+}
+
+func TestRecommend_SizesToUncoveredPercentile(t *testing.T) {
+	t.Parallel()
+	in := ec2Input()
+	// Usage dips to 7 on three days; p10 of uncovered demand is 3.
+	in.Usage = dailyUsage(30, func(i int) float64 {
+		if i%10 == 0 {
+			return 7
+		}
+		return 10
+	})
+
+	res, err := Recommend([]PoolInput{in}, testConfig(), testNow)
+	if err != nil {
+		t.Fatalf("Recommend: %v", err)
+	}
+	if len(res.Recommendations) != 1 {
+		t.Fatalf("got %d recs, skipped %+v", len(res.Recommendations), res.Skipped)
+	}
+	rec := res.Recommendations[0]
+	if rec.Count != 3 || rec.RecommendedCount != 3 {
+		t.Errorf("Count = %d, want 3", rec.Count)
+	}
+	if rec.SourceRecommendation != common.RecommendationSourceNative {
+		t.Errorf("SourceRecommendation = %q", rec.SourceRecommendation)
+	}
+	// Fully used: 3 x (0.10 - 0.06) x 730.
+	if want := 3 * 0.04 * HoursPerMonth; math.Abs(rec.EstimatedSavings-want) > 1e-9 {
+		t.Errorf("EstimatedSavings = %g, want %g", rec.EstimatedSavings, want)
+	}
+	if math.Abs(rec.SavingsPercentage-40) > 1e-9 || math.Abs(rec.RecommendedUtilization-100) > 1e-9 {
+		t.Errorf("savings%%/utilization = %g/%g, want 40/100", rec.SavingsPercentage, rec.RecommendedUtilization)
+	}
+	if rec.BreakEvenMonths != 0 || rec.CommitmentCost != 0 {
+		t.Errorf("no-upfront rec has break-even %g and upfront %g", rec.BreakEvenMonths, rec.CommitmentCost)
+	}
+	if rec.RecurringMonthlyCost == nil || math.Abs(*rec.RecurringMonthlyCost-3*0.06*HoursPerMonth) > 1e-9 {
+		t.Errorf("RecurringMonthlyCost = %v", rec.RecurringMonthlyCost)
+	}
+	if !rec.ExistingCoverageKnown || rec.ExistingCoveragePct <= 0 {
+		t.Errorf("existing coverage not reported: %+v", rec)
+	}
+	if rec.Details.(*common.ComputeDetails).Platform != "Linux/UNIX" {
+		t.Error("pool Details not carried onto the rec")
+	}
+}
+
+func TestRecommend_IdleHoursReduceSavings(t *testing.T) {
+	t.Parallel()
+	in := ec2Input()
+	in.ExistingUnits = 0
+	// Half the days at 10, half at 0: p60 sizes to 10 and the commitment is
+	// idle half the time, which at a 40% discount is a loss.
+	in.Usage = dailyUsage(30, func(i int) float64 { return float64(10 * (i % 2)) })
+	cfg := testConfig()
+	cfg.Percentile = 60
+
+	res, err := Recommend([]PoolInput{in}, cfg, testNow)
+	if err != nil {
+		t.Fatalf("Recommend: %v", err)
+	}
+	if len(res.Recommendations) != 0 || len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0].Reason, "not profitable at 50.0%") {
+		t.Fatalf("got %+v, want an unprofitable skip", res)
+	}
+}
+
+func TestRecommend_BreakEvenCap(t *testing.T) {
+	t.Parallel()
+	in := ec2Input()
+	in.Offering = common.OfferingDetails{UpfrontCost: 300, RecurringCost: 0.02}
+	cfg := testConfig()
+	cfg.PaymentOption = "partial-upfront"
+
+	res, err := Recommend([]PoolInput{in}, cfg, testNow)
+	if err != nil || len(res.Recommendations) != 1 {
+		t.Fatalf("Recommend = %+v, %v", res, err)
+	}
+	rec := res.Recommendations[0]
+	// 6 units: 1800 upfront over 6 x (0.10 - 0.02) x 730 per month.
+	wantBE := 1800 / (6 * 0.08 * HoursPerMonth)
+	if rec.CommitmentCost != 1800 || math.Abs(rec.BreakEvenMonths-wantBE) > 1e-9 {
+		t.Errorf("upfront/break-even = %g/%g, want 1800/%g", rec.CommitmentCost, rec.BreakEvenMonths, wantBE)
+	}
+
+	cfg.MaxBreakEvenMonths = 3
+	res, err = Recommend([]PoolInput{in}, cfg, testNow)
+	if err != nil || len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0].Reason, "break-even") {
+		t.Fatalf("Recommend = %+v, %v; want a break-even skip", res, err)
+	}
+}
+
+func TestRecommend_SavingsPlanPoolIsDollarDenominated(t *testing.T) {
+	t.Parallel()
+	in := PoolInput{
+		Pool: Pool{
+			Provider:       common.ProviderAWS,
+			Service:        common.ServiceSavingsPlansCompute,
+			CommitmentType: common.CommitmentSavingsPlan,
+			Details:        &common.SavingsPlanDetails{PlanType: "Compute"},
+		},
+		Usage:                  dailyUsage(30, func(int) float64 { return 12.5 }),
+		OnDemandUSDPerUnitHour: 1,
+		Offering:               common.OfferingDetails{EffectiveHourlyRate: 0.7},
+	}
+	res, err := Recommend([]PoolInput{in}, testConfig(), testNow)
+	if err != nil || len(res.Recommendations) != 1 {
+		t.Fatalf("Recommend = %+v, %v", res, err)
+	}
+	rec := res.Recommendations[0]
+	if rec.Count != 1 || rec.AverageInstancesUsedPerHour != 0 {
+		t.Errorf("SP rec Count/avg = %d/%g, want 1/0", rec.Count, rec.AverageInstancesUsedPerHour)
+	}
+	if got := rec.Details.(*common.SavingsPlanDetails).HourlyCommitment; math.Abs(got-12.5*0.7) > 1e-9 {
+		t.Errorf("HourlyCommitment = %g, want %g", got, 12.5*0.7)
+	}
+	if in.Pool.Details.(*common.SavingsPlanDetails).HourlyCommitment != 0 {
+		t.Error("the caller's pool Details was mutated")
+	}
+}
+
+func TestRecommend_SkipsUnusablePools(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		mutate func(*PoolInput)
+		want   string
+	}{
+		{"fully covered", func(in *PoolInput) { in.ExistingUnits = 10 }, "no uncovered demand"},
+		{"short history", func(in *PoolInput) { in.Usage = in.Usage[10:] }, "shorter than the 30-day lookback"},
+		{"stale", func(in *PoolInput) { in.Usage = dailyUsage(40, func(int) float64 { return 10 })[:33] }, "stale"},
+		{"unordered", func(in *PoolInput) { in.Usage[3], in.Usage[4] = in.Usage[4], in.Usage[3] }, "strictly increasing"},
+		{"negative", func(in *PoolInput) { in.Usage[2].Units = -1 }, "finite"},
+		{"no on-demand rate", func(in *PoolInput) { in.OnDemandUSDPerUnitHour = 0 }, "on-demand rate"},
+		{"unpriced offering", func(in *PoolInput) { in.Offering = common.OfferingDetails{} }, "no price"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			in := ec2Input()
+			tc.mutate(&in)
+			res, err := Recommend([]PoolInput{in}, testConfig(), testNow)
+			if err != nil {
+				t.Fatalf("Recommend: %v", err)
+			}
+			if len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0].Reason, tc.want) {
+				t.Fatalf("skipped = %+v, want a reason mentioning %q", res.Skipped, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecommend_HourlySamplesAndLookbackWindow(t *testing.T) {
+	t.Parallel()
+	// 60 days of hourly samples: 2 units for the first 30 days, 8 after.
+	// A 30-day lookback must only see the 8s.
+	end := testNow.Truncate(time.Hour).Add(-time.Hour)
+	usage := make([]UsagePoint, 60*24)
+	for i := range usage {
+		units := 2.0
+		if i >= 30*24 {
+			units = 8
+		}
+		usage[i] = UsagePoint{Time: end.Add(time.Duration(i-len(usage)+1) * time.Hour), Units: units}
+	}
+	in := ec2Input()
+	in.Usage, in.ExistingUnits = usage, 0
+
+	res, err := Recommend([]PoolInput{in}, testConfig(), testNow)
+	if err != nil || len(res.Recommendations) != 1 {
+		t.Fatalf("Recommend = %+v, %v", res, err)
+	}
+	if got := res.Recommendations[0].Count; got != 8 {
+		t.Errorf("Count = %d, want 8 (the lookback must exclude the older 2-unit month)", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	for _, mutate := range []func(*Config){
+		func(c *Config) { c.Term = "5yr" },
+		func(c *Config) { c.PaymentOption = "" },
+		func(c *Config) { c.LookbackDays = 0 },
+		func(c *Config) { c.Percentile = 0 },
+		func(c *Config) { c.Percentile = math.NaN() },
+		func(c *Config) { c.MaxBreakEvenMonths = -1 },
+	} {
+		cfg := testConfig()
+		mutate(&cfg)
+		if _, err := Recommend(nil, cfg, testNow); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
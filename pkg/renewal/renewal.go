@@ -0,0 +1,291 @@
+// Package renewal plans replacements for commitments nearing expiry. It is
+// pure: callers supply the inventory and the utilization they measured, and
+// get back one Proposal per expiring commitment saying whether to renew it
+// unchanged, resize it, convert it to a Savings Plan, or let it lapse. Turning
+// proposals into purchases, notifying approvers and recording lineage live
+// in internal/.
+package renewal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// Action is what a Proposal recommends doing with an expiring commitment.
+type Action string
+
+const (
+	// ActionLikeForLike renews the commitment with the same shape and size.
+	ActionLikeForLike Action = "like_for_like"
+	// ActionResize renews the commitment scaled down to its measured
+	// utilization.
+	ActionResize Action = "resize"
+	// ActionConvertToSavingsPlan replaces the commitment with an AWS Compute
+	// Savings Plan covering the spend it actually absorbed.
+	ActionConvertToSavingsPlan Action = "convert_to_savings_plan"
+	// ActionLetExpire buys nothing: the commitment was idle, or there is not
+	// enough information to size a replacement.
+	ActionLetExpire Action = "let_expire"
+)
+
+// Validate returns an error when a is not a recognized Action.
+func (a Action) Validate() error {
+	switch a {
+	case ActionLikeForLike, ActionResize, ActionConvertToSavingsPlan, ActionLetExpire:
+		return nil
+	}
+	return fmt.Errorf("unknown renewal action %q", a)
+}
+
+// Savings Plan types as reported by the AWS Savings Plans API and stored as
+// the inventory ResourceType of a Savings Plan commitment.
+const (
+	PlanTypeCompute     = "Compute"
+	PlanTypeEC2Instance = "EC2Instance"
+)
+
+// Config tunes the planner.
+type Config struct {
+	// LookaheadDays is how far ahead of expiry a commitment is planned for.
+	LookaheadDays int `json:"lookahead_days"`
+	// ResizeBelowPct is the utilization under which a commitment is renewed
+	// at its used size instead of like-for-like.
+	ResizeBelowPct float64 `json:"resize_below_pct"`
+	// ConvertBelowPct is the utilization under which an AWS EC2 Reserved
+	// Instance is replaced by a Compute Savings Plan rather than resized: a
+	// reservation that idles that much is the wrong shape, and a Savings Plan
+	// follows the workload across families and regions. Must not exceed
+	// ResizeBelowPct.
+	ConvertBelowPct float64 `json:"convert_below_pct"`
+}
+
+// DefaultConfig returns the planner defaults: a 30-day lookahead, resize
+// below 80% utilization and convert below 50%.
+func DefaultConfig() Config {
+	return Config{LookaheadDays: 30, ResizeBelowPct: 80, ConvertBelowPct: 50}
+}
+
+// Validate returns an error when c is out of range.
+func (c Config) Validate() error {
+	if c.LookaheadDays <= 0 {
+		return fmt.Errorf("lookahead days must be positive, got %d", c.LookaheadDays)
+	}
+	if c.ResizeBelowPct < 0 || c.ResizeBelowPct > 100 {
+		return fmt.Errorf("resize threshold must be between 0 and 100, got %g", c.ResizeBelowPct)
+	}
+	if c.ConvertBelowPct < 0 || c.ConvertBelowPct > c.ResizeBelowPct {
+		return fmt.Errorf("convert threshold must be between 0 and the resize threshold (%g), got %g", c.ResizeBelowPct, c.ConvertBelowPct)
+	}
+	return nil
+}
+
+// Replacement describes the commitment a Proposal would buy.
+type Replacement struct {
+	CommitmentType common.CommitmentType `json:"commitment_type"`
+	Service        common.ServiceType    `json:"service"`
+	Region         string                `json:"region"`
+	ResourceType   string                `json:"resource_type"`
+	Engine         string                `json:"engine,omitempty"`
+	Count          int                   `json:"count"`
+	// PlanType and HourlyCommitment are set for Savings Plan replacements
+	// only.
+	PlanType         string  `json:"plan_type,omitempty"`
+	HourlyCommitment float64 `json:"hourly_commitment,omitempty"`
+}
+
+// Proposal is the planner's recommendation for one expiring commitment.
+type Proposal struct {
+	Source common.Commitment `json:"source"`
+	Action Action            `json:"action"`
+	// UtilizationPct is the measured utilization the decision was based on;
+	// nil when it could not be measured, in which case the commitment is
+	// renewed like-for-like.
+	UtilizationPct *float64 `json:"utilization_pct,omitempty"`
+	// Replacement is nil for ActionLetExpire.
+	Replacement *Replacement `json:"replacement,omitempty"`
+	// StartAt is when the replacement should take effect: the source's
+	// expiry, so coverage continues without a gap or an overlap.
+	StartAt time.Time `json:"start_at"`
+	// TermYears is the replacement term, matched to the source's term.
+	TermYears int    `json:"term_years"`
+	Reason    string `json:"reason"`
+}
+
+// Expiring returns the commitments whose end date falls after now and no
+// later than lookaheadDays from now, soonest first. Commitments without an
+// end date are skipped.
+func Expiring(commitments []common.Commitment, now time.Time, lookaheadDays int) []common.Commitment {
+	horizon := now.AddDate(0, 0, lookaheadDays)
+	out := make([]common.Commitment, 0)
+	for _, c := range commitments {
+		if c.EndDate.IsZero() || !c.EndDate.After(now) || c.EndDate.After(horizon) {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EndDate.Before(out[j].EndDate) })
+	return out
+}
+
+// Propose decides how to renew c given its measured utilization, which may
+// be nil when unknown:
+//
+//   - unknown, or at least cfg.ResizeBelowPct: like-for-like;
+//   - an AWS EC2 Reserved Instance under cfg.ConvertBelowPct with a known
+//     hourly cost: a Compute Savings Plan committing to the hourly spend the
+//     reservation actually absorbed;
+//   - otherwise under cfg.ResizeBelowPct: the same shape scaled down to the
+//     used size (fewer units, or a smaller hourly commitment);
+//   - idle, or scaled down to nothing: let it expire.
+//
+// An EC2 Instance Savings Plan is always renewed as a Compute Savings Plan:
+// the inventory does not record the instance family the plan was bought
+// for, and the EC2 Instance offering cannot be resolved without it.
+func Propose(c common.Commitment, utilizationPct *float64, cfg Config) Proposal {
+	p := Proposal{
+		Source:         c,
+		UtilizationPct: utilizationPct,
+		StartAt:        c.EndDate,
+		TermYears:      termYears(c.StartDate, c.EndDate),
+	}
+	if c.CommitmentType == common.CommitmentSavingsPlan {
+		return proposeSavingsPlan(p, cfg)
+	}
+	return proposeReservation(p, cfg)
+}
+
+// proposeReservation handles RIs, reservations and CUDs, which are sized in
+// units.
+func proposeReservation(p Proposal, cfg Config) Proposal {
+	c := p.Source
+	util := p.UtilizationPct
+	if util == nil || *util >= cfg.ResizeBelowPct {
+		p.Action = ActionLikeForLike
+		p.Replacement = reservationReplacement(c, c.Count)
+		p.Reason = likeForLikeReason(util)
+		return p
+	}
+	if *util <= 0 {
+		return letExpire(p, "the commitment was unused over the lookback window")
+	}
+	if convertibleToSavingsPlan(c) && *util < cfg.ConvertBelowPct && c.Cost > 0 {
+		p.Action = ActionConvertToSavingsPlan
+		p.Replacement = savingsPlanReplacement(c, PlanTypeCompute, scaledHourly(c.Cost, *util))
+		p.Reason = fmt.Sprintf("utilization %.1f%% is below %.0f%%: a Compute Savings Plan covers the used spend without pinning an instance family", *util, cfg.ConvertBelowPct)
+		return p
+	}
+	count := int(math.Floor(float64(c.Count) * *util / 100))
+	if count <= 0 {
+		return letExpire(p, fmt.Sprintf("utilization %.1f%% rounds to less than one of %d units", *util, c.Count))
+	}
+	p.Action = ActionResize
+	p.Replacement = reservationReplacement(c, count)
+	p.Reason = fmt.Sprintf("utilization %.1f%% is below %.0f%%: renew %d of %d units", *util, cfg.ResizeBelowPct, count, c.Count)
+	return p
+}
+
+// proposeSavingsPlan handles Savings Plans, which are sized by their hourly
+// commitment (c.Cost).
+func proposeSavingsPlan(p Proposal, cfg Config) Proposal {
+	c := p.Source
+	util := p.UtilizationPct
+	if c.Cost <= 0 {
+		return letExpire(p, "the plan's hourly commitment is unknown; renew it manually")
+	}
+	planType := c.ResourceType
+	converted := planType == PlanTypeEC2Instance
+	if converted {
+		planType = PlanTypeCompute
+	}
+
+	hourly := c.Cost
+	switch {
+	case util == nil || *util >= cfg.ResizeBelowPct:
+		p.Action = ActionLikeForLike
+		p.Reason = likeForLikeReason(util)
+	case *util <= 0:
+		return letExpire(p, "the plan was unused over the lookback window")
+	default:
+		hourly = scaledHourly(c.Cost, *util)
+		p.Action = ActionResize
+		p.Reason = fmt.Sprintf("utilization %.1f%% is below %.0f%%: commit $%.2f/h instead of $%.2f/h", *util, cfg.ResizeBelowPct, hourly, c.Cost)
+	}
+	if converted {
+		p.Action = ActionConvertToSavingsPlan
+		p.Reason += "; renewed as a Compute Savings Plan because the EC2 Instance plan's family is not recorded"
+	}
+	p.Replacement = savingsPlanReplacement(c, planType, hourly)
+	return p
+}
+
+func letExpire(p Proposal, reason string) Proposal {
+	p.Action = ActionLetExpire
+	p.Replacement = nil
+	p.Reason = reason
+	return p
+}
+
+func likeForLikeReason(util *float64) string {
+	if util == nil {
+		return "utilization unknown: renew unchanged"
+	}
+	return fmt.Sprintf("utilization %.1f%%: renew unchanged", *util)
+}
+
+// convertibleToSavingsPlan reports whether c is an AWS EC2 Reserved
+// Instance, the only reservation a Compute Savings Plan can stand in for.
+func convertibleToSavingsPlan(c common.Commitment) bool {
+	return c.Provider == common.ProviderAWS &&
+		c.CommitmentType == common.CommitmentReservedInstance &&
+		(c.Service == common.ServiceEC2 || c.Service == common.ServiceCompute)
+}
+
+func reservationReplacement(c common.Commitment, count int) *Replacement {
+	return &Replacement{
+		CommitmentType: c.CommitmentType,
+		Service:        c.Service,
+		Region:         c.Region,
+		ResourceType:   c.ResourceType,
+		Engine:         c.Engine,
+		Count:          count,
+	}
+}
+
+func savingsPlanReplacement(c common.Commitment, planType string, hourly float64) *Replacement {
+	service := c.Service
+	if c.CommitmentType != common.CommitmentSavingsPlan || planType != c.ResourceType {
+		service = common.ServiceSavingsPlansCompute
+	}
+	return &Replacement{
+		CommitmentType:   common.CommitmentSavingsPlan,
+		Service:          service,
+		Region:           c.Region,
+		ResourceType:     planType,
+		Count:            1,
+		PlanType:         planType,
+		HourlyCommitment: hourly,
+	}
+}
+
+// scaledHourly scales an hourly amount by a utilization percentage, rounded
+// to the cent the Savings Plans API accepts, never below one cent.
+func scaledHourly(hourly, utilPct float64) float64 {
+	return math.Max(0.01, math.Round(hourly*utilPct)/100)
+}
+
+// termYears returns the term of a commitment running from start to end:
+// 3 when it is closer to three years than one, else 1. An unknown start
+// defaults to 1.
+func termYears(start, end time.Time) int {
+	if start.IsZero() || !end.After(start) {
+		return 1
+	}
+	if years := end.Sub(start).Hours() / (24 * 365); years >= 2 {
+		return 3
+	}
+	return 1
+}
@@ -0,0 +1,159 @@
+package renewal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+var testNow = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func pct(v float64) *float64 { return &v }
+
+func ec2RI(count int, hourly float64) common.Commitment {
+	return common.Commitment{
+		Provider:       common.ProviderAWS,
+		CommitmentID:   "ri-1",
+		CommitmentType: common.CommitmentReservedInstance,
+		Service:        common.ServiceEC2,
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+		Count:          count,
+		StartDate:      testNow.AddDate(-3, 0, 10),
+		EndDate:        testNow.AddDate(0, 0, 10),
+		Cost:           hourly,
+	}
+}
+
+func savingsPlan(planType string, hourly float64) common.Commitment {
+	return common.Commitment{
+		Provider:       common.ProviderAWS,
+		CommitmentID:   "sp-1",
+		CommitmentType: common.CommitmentSavingsPlan,
+		Service:        common.ServiceSavingsPlansEC2Instance,
+		Region:         "us-east-1",
+		ResourceType:   planType,
+		Count:          1,
+		StartDate:      testNow.AddDate(-1, 0, 5),
+		EndDate:        testNow.AddDate(0, 0, 5),
+		Cost:           hourly,
+	}
+}
+
+func TestExpiring(t *testing.T) {
+	late := ec2RI(1, 0)
+	late.CommitmentID, late.EndDate = "late", testNow.AddDate(0, 0, 20)
+	soon := ec2RI(1, 0)
+	soon.CommitmentID, soon.EndDate = "soon", testNow.AddDate(0, 0, 2)
+	past := ec2RI(1, 0)
+	past.CommitmentID, past.EndDate = "past", testNow.AddDate(0, 0, -1)
+	far := ec2RI(1, 0)
+	far.CommitmentID, far.EndDate = "far", testNow.AddDate(0, 0, 31)
+	undated := ec2RI(1, 0)
+	undated.CommitmentID, undated.EndDate = "undated", time.Time{}
+
+	got := Expiring([]common.Commitment{late, past, far, soon, undated}, testNow, 30)
+	require.Len(t, got, 2)
+	assert.Equal(t, "soon", got[0].CommitmentID)
+	assert.Equal(t, "late", got[1].CommitmentID)
+}
+
+func TestPropose_Reservation(t *testing.T) {
+	cfg := DefaultConfig()
+	cases := []struct {
+		name       string
+		c          common.Commitment
+		util       *float64
+		wantAction Action
+		wantCount  int
+		wantHourly float64
+	}{
+		{"unknown utilization renews unchanged", ec2RI(4, 0.4), nil, ActionLikeForLike, 4, 0},
+		{"well used renews unchanged", ec2RI(4, 0.4), pct(95), ActionLikeForLike, 4, 0},
+		{"under-used resizes", ec2RI(4, 0.4), pct(60), ActionResize, 2, 0},
+		{"idle EC2 RI converts to a Compute SP", ec2RI(4, 0.4), pct(30), ActionConvertToSavingsPlan, 1, 0.12},
+		{"idle EC2 RI with unknown cost resizes", ec2RI(4, 0), pct(30), ActionResize, 1, 0},
+		{"resized to nothing lapses", ec2RI(1, 0), pct(60), ActionLetExpire, 0, 0},
+		{"unused lapses", ec2RI(4, 0.4), pct(0), ActionLetExpire, 0, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Propose(tc.c, tc.util, cfg)
+			assert.Equal(t, tc.wantAction, p.Action)
+			assert.Equal(t, tc.c.EndDate, p.StartAt, "the replacement starts at expiry")
+			assert.Equal(t, 3, p.TermYears)
+			assert.NotEmpty(t, p.Reason)
+			if tc.wantAction == ActionLetExpire {
+				assert.Nil(t, p.Replacement)
+				return
+			}
+			require.NotNil(t, p.Replacement)
+			assert.Equal(t, tc.wantCount, p.Replacement.Count)
+			assert.InDelta(t, tc.wantHourly, p.Replacement.HourlyCommitment, 1e-9)
+		})
+	}
+}
+
+func TestPropose_ConvertedReservationIsComputeSP(t *testing.T) {
+	p := Propose(ec2RI(4, 0.4), pct(30), DefaultConfig())
+	require.NotNil(t, p.Replacement)
+	assert.Equal(t, common.CommitmentSavingsPlan, p.Replacement.CommitmentType)
+	assert.Equal(t, common.ServiceSavingsPlansCompute, p.Replacement.Service)
+	assert.Equal(t, PlanTypeCompute, p.Replacement.PlanType)
+}
+
+func TestPropose_NonEC2ReservationNeverConverts(t *testing.T) {
+	c := ec2RI(10, 1)
+	c.Service, c.ResourceType = common.ServiceRDS, "db.r5.large"
+	p := Propose(c, pct(30), DefaultConfig())
+	assert.Equal(t, ActionResize, p.Action)
+	assert.Equal(t, 3, p.Replacement.Count)
+}
+
+func TestPropose_SavingsPlan(t *testing.T) {
+	cfg := DefaultConfig()
+
+	p := Propose(savingsPlan(PlanTypeCompute, 2), pct(90), cfg)
+	assert.Equal(t, ActionLikeForLike, p.Action)
+	assert.Equal(t, 2.0, p.Replacement.HourlyCommitment)
+	assert.Equal(t, 1, p.TermYears)
+
+	p = Propose(savingsPlan(PlanTypeCompute, 2), pct(40), cfg)
+	assert.Equal(t, ActionResize, p.Action)
+	assert.InDelta(t, 0.8, p.Replacement.HourlyCommitment, 1e-9)
+
+	p = Propose(savingsPlan(PlanTypeCompute, 2), pct(0.1), cfg)
+	assert.InDelta(t, 0.01, p.Replacement.HourlyCommitment, 1e-9, "never below one cent")
+
+	p = Propose(savingsPlan(PlanTypeCompute, 0), pct(90), cfg)
+	assert.Equal(t, ActionLetExpire, p.Action, "an unknown hourly commitment cannot be renewed")
+}
+
+func TestPropose_EC2InstanceSPRenewsAsCompute(t *testing.T) {
+	p := Propose(savingsPlan(PlanTypeEC2Instance, 2), nil, DefaultConfig())
+	assert.Equal(t, ActionConvertToSavingsPlan, p.Action)
+	assert.Equal(t, PlanTypeCompute, p.Replacement.PlanType)
+	assert.Equal(t, common.ServiceSavingsPlansCompute, p.Replacement.Service)
+	assert.Equal(t, 2.0, p.Replacement.HourlyCommitment)
+}
+
+func TestTermYears(t *testing.T) {
+	end := testNow
+	assert.Equal(t, 1, termYears(time.Time{}, end), "unknown start")
+	assert.Equal(t, 1, termYears(end.AddDate(-1, 0, 0), end))
+	assert.Equal(t, 3, termYears(end.AddDate(-3, 0, 0), end))
+	assert.Equal(t, 1, termYears(end, end.AddDate(0, 0, -1)), "end before start")
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig().Validate())
+	assert.Error(t, Config{LookaheadDays: 0, ResizeBelowPct: 80, ConvertBelowPct: 50}.Validate())
+	assert.Error(t, Config{LookaheadDays: 30, ResizeBelowPct: 120, ConvertBelowPct: 50}.Validate())
+	assert.Error(t, Config{LookaheadDays: 30, ResizeBelowPct: 40, ConvertBelowPct: 50}.Validate())
+	assert.Error(t, Action("renew").Validate())
+	assert.NoError(t, ActionResize.Validate())
+}
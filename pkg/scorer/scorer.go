@@ -8,15 +8,53 @@ import (
 	"strings"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 )
 
+// Sort keys accepted by Config.SortBy.
+const (
+	SortSavingsPct        = "savings-pct"        // SavingsPercentage, highest first (the default)
+	SortNPV               = "npv"                // net present value, highest first
+	SortIRR               = "irr"                // internal rate of return, highest first
+	SortDiscountedPayback = "discounted-payback" // discounted payback, soonest first
+)
+
+// SortKeys lists the valid Config.SortBy values.
+var SortKeys = []string{SortSavingsPct, SortNPV, SortIRR, SortDiscountedPayback}
+
 // Config controls which recommendations are allowed through the scorer.
 // Zero values mean "no filter" for numeric thresholds; empty slice means "all services".
+//
+// The financial keys (the NPV, IRR, discounted-payback and worst-case-loss
+// filters and sort keys) are computed by finance.Evaluate under Finance.
 type Config struct {
 	MinSavingsPct      float64  // Minimum savings percentage. 0 = no filter.
 	MaxBreakEvenMonths int      // Maximum break-even months. 0 = no filter.
 	MinCount           int      // Minimum count per recommendation. 0 = no filter.
 	EnabledServices    []string // Empty = all services. E.g. ["ec2", "rds"].
+
+	MinNPV                     float64 // Minimum net present value. 0 = no filter.
+	MinIRRPct                  float64 // Minimum annualised IRR percentage. 0 = no filter.
+	MaxDiscountedPaybackMonths float64 // Maximum discounted payback months. 0 = no filter.
+	MaxWorstCaseLoss           float64 // Maximum loss if usage drops by Finance.UsageDropPct. 0 = no filter.
+	SortBy                     string  // One of SortKeys. Empty = SortSavingsPct.
+	Finance                    finance.Params
+}
+
+// Validate rejects an unknown SortBy and invalid financial assumptions.
+func (c Config) Validate() error {
+	switch c.SortBy {
+	case "", SortSavingsPct, SortNPV, SortIRR, SortDiscountedPayback:
+	default:
+		return fmt.Errorf("invalid sort key %q (valid: %s)", c.SortBy, strings.Join(SortKeys, ", "))
+	}
+	return c.Finance.Validate()
+}
+
+// usesFinance reports whether any filter or the sort needs finance.Evaluate.
+func (c Config) usesFinance() bool {
+	return c.MinNPV != 0 || c.MinIRRPct > 0 || c.MaxDiscountedPaybackMonths > 0 || c.MaxWorstCaseLoss > 0 ||
+		(c.SortBy != "" && c.SortBy != SortSavingsPct)
 }
 
 // FilteredRecommendation holds a recommendation that did not pass a filter, with the reason.
@@ -32,8 +70,9 @@ type ScoredResult struct {
 }
 
 // Score applies cfg filters to recs and returns passed and filtered recommendations.
-// Passed recommendations are sorted by SavingsPercentage (desc), then EstimatedSavings (desc),
-// then a stable tie-breaker of Service+Region+ResourceType (asc) for deterministic output.
+// Passed recommendations are sorted by cfg.SortBy (SavingsPercentage desc by default),
+// then SavingsPercentage (desc), then EstimatedSavings (desc), then a stable tie-breaker
+// of Service+Region+ResourceType (asc) for deterministic output.
 func Score(recs []common.Recommendation, cfg Config) ScoredResult {
 	result := ScoredResult{
 		Passed:   make([]common.Recommendation, 0, len(recs)),
@@ -41,32 +80,115 @@ func Score(recs []common.Recommendation, cfg Config) ScoredResult {
 	}
 
 	enabledSet := buildServiceSet(cfg.EnabledServices)
+	var evals []finance.Evaluation
 
 	for _, rec := range recs {
-		if reason := filterReason(rec, cfg, enabledSet); reason != "" {
+		reason := filterReason(rec, cfg, enabledSet)
+		var ev finance.Evaluation
+		if reason == "" && cfg.usesFinance() {
+			ev, reason = financeFilterReason(rec, cfg)
+		}
+		if reason != "" {
 			result.Filtered = append(result.Filtered, FilteredRecommendation{
 				Recommendation: rec,
 				FilterReason:   reason,
 			})
 		} else {
 			result.Passed = append(result.Passed, rec)
+			evals = append(evals, ev)
 		}
 	}
 
-	sort.Slice(result.Passed, func(i, j int) bool {
-		a, b := result.Passed[i], result.Passed[j]
-		if a.SavingsPercentage != b.SavingsPercentage {
-			return a.SavingsPercentage > b.SavingsPercentage
+	sort.Sort(byScore{recs: result.Passed, evals: evals, key: cfg.SortBy})
+
+	return result
+}
+
+// byScore sorts passed recommendations together with their evaluations.
+type byScore struct {
+	recs  []common.Recommendation
+	evals []finance.Evaluation
+	key   string
+}
+
+func (s byScore) Len() int { return len(s.recs) }
+
+func (s byScore) Swap(i, j int) {
+	s.recs[i], s.recs[j] = s.recs[j], s.recs[i]
+	s.evals[i], s.evals[j] = s.evals[j], s.evals[i]
+}
+
+func (s byScore) Less(i, j int) bool {
+	if less, decided := compareFinance(s.evals[i], s.evals[j], s.key); decided {
+		return less
+	}
+	a, b := s.recs[i], s.recs[j]
+	if a.SavingsPercentage != b.SavingsPercentage {
+		return a.SavingsPercentage > b.SavingsPercentage
+	}
+	if a.EstimatedSavings != b.EstimatedSavings {
+		return a.EstimatedSavings > b.EstimatedSavings
+	}
+	keyA := string(a.Service) + "|" + a.Region + "|" + a.ResourceType
+	keyB := string(b.Service) + "|" + b.Region + "|" + b.ResourceType
+	return keyA < keyB
+}
+
+// compareFinance orders a before b on the financial sort key. decided is
+// false for the default key and for ties. A missing IRR (no upfront
+// outlay) ranks above any finite one; a missing discounted payback (never
+// pays back) ranks last.
+func compareFinance(a, b finance.Evaluation, key string) (less, decided bool) {
+	switch key {
+	case SortNPV:
+		if a.NPV != b.NPV {
+			return a.NPV > b.NPV, true
+		}
+	case SortIRR:
+		switch {
+		case a.IRRPct == nil && b.IRRPct == nil:
+		case a.IRRPct == nil || b.IRRPct == nil:
+			return a.IRRPct == nil, true
+		case *a.IRRPct != *b.IRRPct:
+			return *a.IRRPct > *b.IRRPct, true
 		}
-		if a.EstimatedSavings != b.EstimatedSavings {
-			return a.EstimatedSavings > b.EstimatedSavings
+	case SortDiscountedPayback:
+		switch {
+		case a.DiscountedPaybackMonths == nil && b.DiscountedPaybackMonths == nil:
+		case a.DiscountedPaybackMonths == nil || b.DiscountedPaybackMonths == nil:
+			return b.DiscountedPaybackMonths == nil, true
+		case *a.DiscountedPaybackMonths != *b.DiscountedPaybackMonths:
+			return *a.DiscountedPaybackMonths < *b.DiscountedPaybackMonths, true
 		}
-		keyA := string(a.Service) + "|" + a.Region + "|" + a.ResourceType
-		keyB := string(b.Service) + "|" + b.Region + "|" + b.ResourceType
-		return keyA < keyB
-	})
+	}
+	return false, false
+}
 
-	return result
+// financeFilterReason evaluates rec and returns a non-empty reason when it
+// fails one of the financial filters.
+func financeFilterReason(rec common.Recommendation, cfg Config) (finance.Evaluation, string) {
+	ev, err := finance.Evaluate(rec, cfg.Finance)
+	if err != nil {
+		return ev, fmt.Sprintf("financial evaluation failed: %v", err)
+	}
+	if cfg.MinNPV != 0 && ev.NPV < cfg.MinNPV {
+		return ev, fmt.Sprintf("NPV %.2f below minimum %.2f", ev.NPV, cfg.MinNPV)
+	}
+	if cfg.MinIRRPct > 0 && ev.IRRPct != nil && *ev.IRRPct < cfg.MinIRRPct {
+		return ev, fmt.Sprintf("IRR %.1f%% below minimum %.1f%%", *ev.IRRPct, cfg.MinIRRPct)
+	}
+	if cfg.MaxDiscountedPaybackMonths > 0 {
+		if ev.DiscountedPaybackMonths == nil {
+			return ev, "does not pay back within its term at the configured discount rate"
+		}
+		if *ev.DiscountedPaybackMonths > cfg.MaxDiscountedPaybackMonths {
+			return ev, fmt.Sprintf("discounted payback %.1f months exceeds maximum %.1f months", *ev.DiscountedPaybackMonths, cfg.MaxDiscountedPaybackMonths)
+		}
+	}
+	if cfg.MaxWorstCaseLoss > 0 && ev.WorstCaseLoss > cfg.MaxWorstCaseLoss {
+		return ev, fmt.Sprintf("worst-case loss %.2f at a %.0f%% usage drop exceeds maximum %.2f", ev.WorstCaseLoss, cfg.Finance.UsageDropPct, cfg.MaxWorstCaseLoss)
+	}
+	return ev, ""
 }
 
 // filterReason returns a non-empty string describing why rec was filtered, or "" if it passes.
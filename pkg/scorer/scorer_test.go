@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/finance"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -140,3 +141,82 @@ func TestScore_CombinedFilters(t *testing.T) {
 	assert.Equal(t, "c5.large", result.Passed[0].ResourceType)
 	assert.Len(t, result.Filtered, 2)
 }
+
+func finRec(resourceType, term string, upfront, onDemand, recurring float64) common.Recommendation {
+	return common.Recommendation{
+		Service: common.ServiceEC2, Region: "us-east-1", ResourceType: resourceType, Term: term,
+		CommitmentCost: upfront, OnDemandCost: onDemand, RecurringMonthlyCost: &recurring,
+		// Savings percentage deliberately inverse to NPV so the sort key matters.
+		SavingsPercentage: upfront / 100,
+	}
+}
+
+func TestScore_SortByNPV(t *testing.T) {
+	t.Parallel()
+	recs := []common.Recommendation{
+		finRec("all-upfront", "3yr", 2700, 100, 0), // saves more in total, ties up cash
+		finRec("no-upfront", "3yr", 0, 100, 82),
+	}
+	atZero := Score(recs, Config{SortBy: SortNPV})
+	assert.Equal(t, "all-upfront", atZero.Passed[0].ResourceType)
+
+	atThirty := Score(recs, Config{SortBy: SortNPV, Finance: finance.Params{DiscountRatePct: 30}})
+	assert.Equal(t, "no-upfront", atThirty.Passed[0].ResourceType, "a high cost of capital favours keeping the cash")
+}
+
+func TestScore_SortByIRRAndDiscountedPayback(t *testing.T) {
+	t.Parallel()
+	recs := []common.Recommendation{
+		finRec("slow", "1yr", 1100, 100, 0), // pays back in 11 months
+		finRec("fast", "1yr", 600, 100, 0),  // pays back in 6 months
+		finRec("never", "1yr", 1300, 100, 0),
+	}
+	byIRR := Score(recs, Config{SortBy: SortIRR})
+	assert.Equal(t, []string{"fast", "slow", "never"}, resourceTypes(byIRR.Passed))
+
+	byPayback := Score(recs, Config{SortBy: SortDiscountedPayback})
+	assert.Equal(t, []string{"fast", "slow", "never"}, resourceTypes(byPayback.Passed), "never paying back sorts last")
+}
+
+func TestScore_FinanceFilters(t *testing.T) {
+	t.Parallel()
+	recs := []common.Recommendation{
+		finRec("fast", "1yr", 600, 100, 0),
+		finRec("never", "1yr", 1300, 100, 0),
+	}
+	result := Score(recs, Config{MaxDiscountedPaybackMonths: 12})
+	assert.Equal(t, []string{"fast"}, resourceTypes(result.Passed))
+	assert.Contains(t, result.Filtered[0].FilterReason, "does not pay back")
+
+	result = Score(recs, Config{MinNPV: 1})
+	assert.Equal(t, []string{"fast"}, resourceTypes(result.Passed))
+	assert.Contains(t, result.Filtered[0].FilterReason, "NPV")
+
+	// A 50% usage drop halves the 1200 of avoided spend: fast loses nothing, never loses 700.
+	result = Score(recs, Config{MaxWorstCaseLoss: 100, Finance: finance.Params{UsageDropPct: 50}})
+	assert.Equal(t, []string{"fast"}, resourceTypes(result.Passed))
+	assert.Contains(t, result.Filtered[0].FilterReason, "worst-case loss 700.00")
+}
+
+func TestScore_FinanceFilterRejectsBadTerm(t *testing.T) {
+	t.Parallel()
+	result := Score([]common.Recommendation{finRec("m5.large", "", 0, 100, 50)}, Config{MinNPV: 1})
+	assert.Empty(t, result.Passed)
+	assert.Contains(t, result.Filtered[0].FilterReason, "invalid term")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, Config{}.Validate())
+	assert.NoError(t, Config{SortBy: SortIRR, Finance: finance.DefaultParams()}.Validate())
+	assert.ErrorContains(t, Config{SortBy: "roi"}.Validate(), "invalid sort key")
+	assert.Error(t, Config{Finance: finance.Params{DiscountRatePct: -1}}.Validate())
+}
+
+func resourceTypes(recs []common.Recommendation) []string {
+	out := make([]string, len(recs))
+	for i := range recs {
+		out[i] = recs[i].ResourceType
+	}
+	return out
+}
@@ -0,0 +1,253 @@
+// Package shadow scores hypothetical purchases. A purchase plan or ladder
+// config in shadow mode runs its selection and sizing as usual but records
+// what it would have bought instead of buying it; once usage for the
+// following days is known, Evaluate replays each recorded purchase against
+// it and reports the savings or waste it would have produced. Summarize
+// rolls the evaluations up into a go/no-go verdict, so a configuration can
+// earn trust on evidence before it is allowed to spend.
+//
+// Like pkg/ladder it is a pure function package: callers load the recorded
+// purchases and the usage series and persist the results.
+package shadow
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+const day = 24 * time.Hour
+
+// Purchase is one commitment a shadow-mode configuration would have bought.
+// Start and End bound the time it is scored over: from its purchase, or
+// from where an earlier evaluation stopped, to the end of its term.
+//
+// Amounts are USD/h. CommitUSDPerHour is in the unit of the usage series:
+// the on-demand spend per hour the commitment would cover at full
+// utilization. CostUSDPerHour is what the commitment would have cost per
+// hour, upfront payment amortised over the term plus any recurring charge;
+// it is below CommitUSDPerHour by the commitment's discount.
+type Purchase struct {
+	Start time.Time
+	End   time.Time
+
+	CommitUSDPerHour float64
+	CostUSDPerHour   float64
+}
+
+// Validate reports whether p can be evaluated.
+func (p Purchase) Validate() error {
+	if !p.End.After(p.Start) {
+		return fmt.Errorf("end %s is not after start %s", p.End.Format(time.RFC3339), p.Start.Format(time.RFC3339))
+	}
+	if math.IsNaN(p.CommitUSDPerHour) || p.CommitUSDPerHour <= 0 {
+		return fmt.Errorf("commitment %g USD/h must be positive", p.CommitUSDPerHour)
+	}
+	if math.IsNaN(p.CostUSDPerHour) || p.CostUSDPerHour < 0 {
+		return fmt.Errorf("cost %g USD/h must not be negative", p.CostUSDPerHour)
+	}
+	return nil
+}
+
+// Evaluation is how one Purchase would have performed. USD fields are
+// totals over Days whole UTC days.
+//
+// CoveredUSD is the on-demand spend the commitment would have replaced and
+// CommittedUSD what it could have replaced at full utilization. CostUSD is
+// what it would have cost; SavingsUSD is CoveredUSD minus CostUSD, negative
+// when the commitment would have cost more than the usage it covered.
+// WasteUSD is the share of CostUSD paid for commitment that would have sat
+// unused. UtilizationPct is CoveredUSD over CommittedUSD, nil when Days is
+// zero (never 0-coerced).
+type Evaluation struct {
+	UtilizationPct *float64 `json:"utilization_pct,omitempty"`
+
+	CoveredUSD   float64 `json:"covered_usd"`
+	CommittedUSD float64 `json:"committed_usd"`
+	CostUSD      float64 `json:"cost_usd"`
+	SavingsUSD   float64 `json:"savings_usd"`
+	WasteUSD     float64 `json:"waste_usd"`
+
+	Days int `json:"days"`
+}
+
+// Evaluate scores purchases that draw on the same usage, such as every
+// shadow purchase for one service in one region, against usage: the daily
+// on-demand series for that usage, one point per UTC day. The result is
+// index-aligned with purchases.
+//
+// Purchases are stacked in the order given, which callers make the order
+// they would have been bought in: each day, the first active purchase
+// covers as much of the day's usage as its commitment allows and later ones
+// cover what is left, so two purchases are never credited with the same
+// usage. A purchase is active on the whole UTC days from the first midnight
+// at or after its Start until its End. Only days that have ended by now are
+// scored, and days missing from usage are skipped rather than read as zero
+// usage, so a gap in the data never shows up as waste.
+//
+// Evaluate performs no I/O; it returns an error only for an invalid
+// Purchase.
+func Evaluate(purchases []Purchase, usage []ladder.DailyPoint, now time.Time) ([]Evaluation, error) {
+	for i, p := range purchases {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("purchase %d: %w", i, err)
+		}
+	}
+	points := make([]ladder.DailyPoint, 0, len(usage))
+	for _, pt := range usage {
+		pt.Date = pt.Date.UTC().Truncate(day)
+		points = append(points, pt)
+	}
+	sort.SliceStable(points, func(a, b int) bool { return points[a].Date.Before(points[b].Date) })
+
+	evals := make([]Evaluation, len(purchases))
+	for _, pt := range points {
+		if pt.Date.Add(day).After(now) {
+			continue
+		}
+		remaining := math.Max(pt.USDPerHour, 0)
+		for i, p := range purchases {
+			if pt.Date.Before(firstDay(p.Start)) || pt.Date.Add(day).After(p.End) {
+				continue
+			}
+			covered := math.Min(remaining, p.CommitUSDPerHour)
+			remaining -= covered
+			evals[i].add(p, covered)
+		}
+	}
+	for i := range evals {
+		evals[i].finish()
+	}
+	return evals, nil
+}
+
+// firstDay is the first UTC midnight at or after t.
+func firstDay(t time.Time) time.Time {
+	d := t.UTC().Truncate(day)
+	if d.Before(t) {
+		d = d.Add(day)
+	}
+	return d
+}
+
+// add accrues one day on which p covered covered USD/h of usage.
+func (e *Evaluation) add(p Purchase, covered float64) {
+	hours := day.Hours()
+	e.Days++
+	e.CoveredUSD += covered * hours
+	e.CommittedUSD += p.CommitUSDPerHour * hours
+	e.CostUSD += p.CostUSDPerHour * hours
+	e.WasteUSD += (1 - covered/p.CommitUSDPerHour) * p.CostUSDPerHour * hours
+}
+
+// Merge returns e with o's days added, for accumulating an evaluation run
+// by run.
+func (e Evaluation) Merge(o Evaluation) Evaluation {
+	e.Days += o.Days
+	e.CoveredUSD += o.CoveredUSD
+	e.CommittedUSD += o.CommittedUSD
+	e.CostUSD += o.CostUSD
+	e.WasteUSD += o.WasteUSD
+	e.finish()
+	return e
+}
+
+// finish derives SavingsUSD and UtilizationPct from the accrued totals.
+func (e *Evaluation) finish() {
+	e.SavingsUSD = e.CoveredUSD - e.CostUSD
+	if e.CommittedUSD > 0 {
+		pct := e.CoveredUSD / e.CommittedUSD * 100
+		e.UtilizationPct = &pct
+	}
+}
+
+// Verdict is the go/no-go call Summarize makes.
+type Verdict string
+
+const (
+	// VerdictGo means the shadow purchases would have saved money at
+	// acceptable utilization.
+	VerdictGo Verdict = "go"
+	// VerdictNoGo means they would have lost money or sat too idle.
+	VerdictNoGo Verdict = "no_go"
+	// VerdictInsufficientData means too little usage has been observed to
+	// decide either way.
+	VerdictInsufficientData Verdict = "insufficient_data"
+)
+
+// Criteria are the thresholds Summarize decides against.
+type Criteria struct {
+	// MinDays is the number of evaluated purchase-days (the sum of every
+	// Evaluation's Days) below which the verdict is insufficient data.
+	MinDays int
+	// MinUtilizationPct is the utilization the purchases must reach as a
+	// whole for a go, in [0, 100].
+	MinUtilizationPct float64
+}
+
+// DefaultCriteria is the bar a configuration has to clear by default: a
+// month of evidence and the utilization a commitment typically needs to
+// beat its discount comfortably.
+var DefaultCriteria = Criteria{MinDays: 30, MinUtilizationPct: 80}
+
+// Summary rolls evaluations up into totals and a verdict. Purchases counts
+// every evaluation passed in and Evaluated those with at least one scored
+// day; USD fields and Days are sums over all of them.
+type Summary struct {
+	UtilizationPct *float64 `json:"utilization_pct,omitempty"`
+
+	Verdict Verdict `json:"verdict"`
+	Reason  string  `json:"reason"`
+
+	CoveredUSD   float64 `json:"covered_usd"`
+	CommittedUSD float64 `json:"committed_usd"`
+	CostUSD      float64 `json:"cost_usd"`
+	SavingsUSD   float64 `json:"savings_usd"`
+	WasteUSD     float64 `json:"waste_usd"`
+
+	Purchases int `json:"purchases"`
+	Evaluated int `json:"evaluated"`
+	Days      int `json:"days"`
+}
+
+// Summarize totals evals and decides: insufficient data below c.MinDays
+// evaluated purchase-days, otherwise go when the purchases would have
+// saved money overall at c.MinUtilizationPct or better, and no-go when
+// they would not.
+func Summarize(evals []Evaluation, c Criteria) Summary {
+	s := Summary{Purchases: len(evals)}
+	for _, e := range evals {
+		if e.Days > 0 {
+			s.Evaluated++
+		}
+		s.Days += e.Days
+		s.CoveredUSD += e.CoveredUSD
+		s.CommittedUSD += e.CommittedUSD
+		s.CostUSD += e.CostUSD
+		s.SavingsUSD += e.SavingsUSD
+		s.WasteUSD += e.WasteUSD
+	}
+	if s.CommittedUSD > 0 {
+		pct := s.CoveredUSD / s.CommittedUSD * 100
+		s.UtilizationPct = &pct
+	}
+
+	switch {
+	case s.Days == 0 || s.Days < c.MinDays:
+		s.Verdict = VerdictInsufficientData
+		s.Reason = fmt.Sprintf("%d purchase-days evaluated, %d needed", s.Days, c.MinDays)
+	case s.SavingsUSD <= 0:
+		s.Verdict = VerdictNoGo
+		s.Reason = fmt.Sprintf("the purchases would have lost $%.2f, with $%.2f of unused commitment", -s.SavingsUSD, s.WasteUSD)
+	case *s.UtilizationPct < c.MinUtilizationPct:
+		s.Verdict = VerdictNoGo
+		s.Reason = fmt.Sprintf("utilization %.1f%% is below the %.1f%% minimum", *s.UtilizationPct, c.MinUtilizationPct)
+	default:
+		s.Verdict = VerdictGo
+		s.Reason = fmt.Sprintf("the purchases would have saved $%.2f at %.1f%% utilization", s.SavingsUSD, *s.UtilizationPct)
+	}
+	return s
+}
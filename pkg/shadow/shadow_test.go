@@ -0,0 +1,161 @@
+package shadow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// flatUsage is n consecutive days of usage USD/h starting at start.
+func flatUsage(start time.Time, n int, usdPerHour float64) []ladder.DailyPoint {
+	points := make([]ladder.DailyPoint, n)
+	for i := range points {
+		points[i] = ladder.DailyPoint{Date: start.AddDate(0, 0, i), USDPerHour: usdPerHour}
+	}
+	return points
+}
+
+func TestEvaluate_FullyUtilized(t *testing.T) {
+	p := Purchase{Start: date(3, 1), End: date(3, 1).AddDate(1, 0, 0), CommitUSDPerHour: 10, CostUSDPerHour: 7}
+
+	evals, err := Evaluate([]Purchase{p}, flatUsage(date(3, 1), 10, 12), date(3, 11))
+	require.NoError(t, err)
+	require.Len(t, evals, 1)
+	e := evals[0]
+	assert.Equal(t, 10, e.Days)
+	assert.InDelta(t, 2400, e.CoveredUSD, 1e-9)
+	assert.InDelta(t, 1680, e.CostUSD, 1e-9)
+	assert.InDelta(t, 720, e.SavingsUSD, 1e-9)
+	assert.InDelta(t, 0, e.WasteUSD, 1e-9)
+	require.NotNil(t, e.UtilizationPct)
+	assert.InDelta(t, 100, *e.UtilizationPct, 1e-9)
+}
+
+func TestEvaluate_UnderUtilizedWastes(t *testing.T) {
+	p := Purchase{Start: date(3, 1), End: date(3, 1).AddDate(1, 0, 0), CommitUSDPerHour: 10, CostUSDPerHour: 7}
+
+	evals, err := Evaluate([]Purchase{p}, flatUsage(date(3, 1), 2, 5), date(3, 3))
+	require.NoError(t, err)
+	e := evals[0]
+	assert.InDelta(t, 240, e.CoveredUSD, 1e-9)
+	assert.InDelta(t, 336, e.CostUSD, 1e-9)
+	assert.InDelta(t, -96, e.SavingsUSD, 1e-9)
+	assert.InDelta(t, 168, e.WasteUSD, 1e-9, "half the commitment idle wastes half its cost")
+	assert.InDelta(t, 50, *e.UtilizationPct, 1e-9)
+}
+
+func TestEvaluate_StacksPurchasesInOrder(t *testing.T) {
+	first := Purchase{Start: date(3, 1), End: date(4, 1), CommitUSDPerHour: 10, CostUSDPerHour: 7}
+	second := Purchase{Start: date(3, 2), End: date(4, 1), CommitUSDPerHour: 10, CostUSDPerHour: 7}
+
+	evals, err := Evaluate([]Purchase{first, second}, flatUsage(date(3, 2), 1, 15), date(3, 3))
+	require.NoError(t, err)
+	assert.InDelta(t, 10*24, evals[0].CoveredUSD, 1e-9)
+	assert.InDelta(t, 5*24, evals[1].CoveredUSD, 1e-9, "the second purchase gets only what the first one leaves")
+}
+
+func TestEvaluation_Merge(t *testing.T) {
+	p := Purchase{Start: date(3, 1), End: date(4, 1), CommitUSDPerHour: 10, CostUSDPerHour: 7}
+	usage := append(flatUsage(date(3, 1), 2, 10), flatUsage(date(3, 3), 2, 5)...)
+
+	whole, err := Evaluate([]Purchase{p}, usage, date(3, 5))
+	require.NoError(t, err)
+	before, err := Evaluate([]Purchase{p}, usage, date(3, 3))
+	require.NoError(t, err)
+	p.Start = date(3, 3)
+	after, err := Evaluate([]Purchase{p}, usage, date(3, 5))
+	require.NoError(t, err)
+
+	merged := before[0].Merge(after[0])
+	assert.Equal(t, whole[0].Days, merged.Days)
+	assert.InDelta(t, whole[0].SavingsUSD, merged.SavingsUSD, 1e-9)
+	assert.InDelta(t, whole[0].WasteUSD, merged.WasteUSD, 1e-9)
+	assert.InDelta(t, *whole[0].UtilizationPct, *merged.UtilizationPct, 1e-9)
+}
+
+func TestEvaluate_ScoresOnlyCompleteObservedDaysInTerm(t *testing.T) {
+	// Bought mid-day on 1 March: 1 March does not count. 3 March is missing
+	// from the series, 6 March is after the term and 7 March has not ended.
+	p := Purchase{Start: date(3, 1).Add(14 * time.Hour), End: date(3, 6), CommitUSDPerHour: 1, CostUSDPerHour: 1}
+	usage := append(flatUsage(date(3, 1), 2, 1), flatUsage(date(3, 4), 4, 1)...)
+
+	evals, err := Evaluate([]Purchase{p}, usage, date(3, 7).Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, evals[0].Days, "2, 4 and 5 March")
+}
+
+func TestEvaluate_NoObservedDays(t *testing.T) {
+	p := Purchase{Start: date(3, 1), End: date(4, 1), CommitUSDPerHour: 1, CostUSDPerHour: 1}
+
+	evals, err := Evaluate([]Purchase{p}, nil, date(3, 10))
+	require.NoError(t, err)
+	assert.Zero(t, evals[0].Days)
+	assert.Nil(t, evals[0].UtilizationPct)
+}
+
+func TestPurchase_Validate(t *testing.T) {
+	valid := Purchase{Start: date(3, 1), End: date(4, 1), CommitUSDPerHour: 1, CostUSDPerHour: 0.7}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		mutate func(p *Purchase)
+		want   string
+	}{
+		{"end not after start", func(p *Purchase) { p.End = p.Start }, "end"},
+		{"no commitment", func(p *Purchase) { p.CommitUSDPerHour = 0 }, "commitment"},
+		{"negative cost", func(p *Purchase) { p.CostUSDPerHour = -1 }, "cost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			err := p.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+
+			_, err = Evaluate([]Purchase{valid, p}, nil, date(5, 1))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "purchase 1")
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	pct := func(f float64) *float64 { return &f }
+	good := Evaluation{Days: 20, CoveredUSD: 2400, CommittedUSD: 2400, CostUSD: 1680, SavingsUSD: 720, UtilizationPct: pct(100)}
+	idle := Evaluation{Days: 20, CoveredUSD: 1200, CommittedUSD: 2400, CostUSD: 1000, SavingsUSD: 200, WasteUSD: 500, UtilizationPct: pct(50)}
+	losing := Evaluation{Days: 40, CoveredUSD: 240, CommittedUSD: 480, CostUSD: 336, SavingsUSD: -96, WasteUSD: 168, UtilizationPct: pct(50)}
+
+	tests := []struct {
+		name  string
+		evals []Evaluation
+		want  Verdict
+	}{
+		{"nothing evaluated", []Evaluation{{}}, VerdictInsufficientData},
+		{"too few days", []Evaluation{good}, VerdictInsufficientData},
+		{"saves at high utilization", []Evaluation{good, good}, VerdictGo},
+		{"saves but mostly idle", []Evaluation{good, idle, idle}, VerdictNoGo},
+		{"loses money", []Evaluation{losing}, VerdictNoGo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Summarize(tt.evals, DefaultCriteria)
+			assert.Equal(t, tt.want, s.Verdict, s.Reason)
+			assert.NotEmpty(t, s.Reason)
+		})
+	}
+
+	s := Summarize([]Evaluation{good, {}}, Criteria{MinDays: 1, MinUtilizationPct: 80})
+	assert.Equal(t, 2, s.Purchases)
+	assert.Equal(t, 1, s.Evaluated)
+	assert.InDelta(t, 720, s.SavingsUSD, 1e-9)
+}
@@ -0,0 +1,38 @@
+// Package reservedcost computes the amortized hourly cost of an AWS
+// reservation from the pricing fields the Describe Reserved* APIs return.
+//
+// RDS, ElastiCache and OpenSearch report a reservation's price the same
+// way (an upfront FixedPrice, an hourly UsagePrice and a list of recurring
+// charges, all per node), but each SDK has its own RecurringCharge struct.
+// This package takes SDK-agnostic Charge values and each service converts
+// with a small adapter, as tagging does for purchase tags.
+package reservedcost
+
+// ChargeFrequencyHourly is the RecurringChargeFrequency of a charge billed
+// per hour. Other frequencies are not part of the hourly cost.
+const ChargeFrequencyHourly = "Hourly"
+
+// Charge is one recurring charge of a reservation, independent of any AWS
+// SDK type.
+type Charge struct {
+	Frequency string
+	Amount    float64
+}
+
+// HourlyCost returns the amortized hourly cost of a reservation: the
+// per-node hourly recurring charges plus usage price plus the upfront fixed
+// price spread over the term, times the node count. Describe pricing
+// fields are per node, matching the EC2 convention. Upfront amortization is
+// skipped when the duration is unknown (zero).
+func HourlyCost(fixed, usage float64, recurring []Charge, durationSeconds, count int32) float64 {
+	perNode := usage
+	for _, rc := range recurring {
+		if rc.Frequency == ChargeFrequencyHourly {
+			perNode += rc.Amount
+		}
+	}
+	if durationSeconds > 0 {
+		perNode += fixed / (float64(durationSeconds) / 3600.0)
+	}
+	return perNode * float64(count)
+}
@@ -0,0 +1,20 @@
+package reservedcost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHourlyCost pins the amortization the ladder reads from
+// Commitment.Cost: hourly recurring + usage + upfront over the term, per node.
+func TestHourlyCost(t *testing.T) {
+	const oneYearSeconds = 365 * 24 * 3600
+	recurring := []Charge{
+		{Frequency: ChargeFrequencyHourly, Amount: 0.05},
+		{Frequency: "Monthly", Amount: 99},
+	}
+	// 8760 upfront over 8760 hours = 1/h, plus 0.05 recurring, times 2 nodes.
+	assert.InDelta(t, 2.1, HourlyCost(8760, 0, recurring, oneYearSeconds, 2), 1e-9)
+	assert.InDelta(t, 0.1, HourlyCost(8760, 0.1, nil, 0, 1), 1e-9, "unknown duration skips amortization")
+}
@@ -75,16 +75,24 @@ func (a *AWSLadder) GetUsageBaseline(ctx context.Context, scope ladder.Scope, lo
 	if err := a.validateScope(scope); err != nil {
 		return ladder.UsageBaseline{}, err
 	}
+	return usageBaseline(ctx, a.onDemand, a.cfg.Region, lookbackDays, percentile)
+}
+
+// usageBaseline is the body of GetUsageBaseline shared by AWSLadder and
+// ReservedCapacityLadder: it fetches the series from src and applies every
+// check documented on GetUsageBaseline. Scope validation stays with the
+// caller because the two ladders accept different scopes.
+func usageBaseline(ctx context.Context, src onDemandSeriesSource, region string, lookbackDays int, percentile float64) (ladder.UsageBaseline, error) {
 	if err := validateBaselineArgs(lookbackDays, percentile); err != nil {
 		return ladder.UsageBaseline{}, err
 	}
 
-	points, err := a.onDemand.GetOnDemandSeries(ctx, a.cfg.Region, lookbackDays)
+	points, err := src.GetOnDemandSeries(ctx, region, lookbackDays)
 	if err != nil {
 		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: on-demand series fetch failed: %w", err)
 	}
 	if len(points) == 0 {
-		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: on-demand series is empty for region %s (series source returned no data)", a.cfg.Region)
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: on-demand series is empty for region %s (series source returned no data)", region)
 	}
 	if len(points) < minBaselineSeriesDays {
 		return ladder.UsageBaseline{}, fmt.Errorf(
@@ -47,6 +47,11 @@ func (a *AWSLadder) validateScope(scope ladder.Scope) error {
 		return fmt.Errorf("AWSLadder: scope account %s does not match configured account %s",
 			scope.AccountID, a.cfg.AccountID)
 	}
+	// A service-scoped scope belongs to a ReservedCapacityLadder; answering it
+	// with compute commitments would mix two ladders' state.
+	if scope.Service != "" {
+		return fmt.Errorf("AWSLadder: scope service %s is not served by the compute ladder", scope.Service)
+	}
 	return nil
 }
 
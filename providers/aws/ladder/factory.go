@@ -10,10 +10,14 @@ import (
 	sptypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/exchange"
 	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
 	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
 	ec2svc "github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+	elasticachesvc "github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
+	opensearchsvc "github.com/LeanerCloud/CUDly/providers/aws/services/opensearch"
+	rdssvc "github.com/LeanerCloud/CUDly/providers/aws/services/rds"
 	savingsplansvc "github.com/LeanerCloud/CUDly/providers/aws/services/savingsplans"
 )
 
@@ -43,6 +47,20 @@ import (
 //
 //	app.LadderCapabilityFactory = awsladder.NewFromAWSConfig
 func NewFromAWSConfig(ctx context.Context, region, accountID string) (pkgladder.LadderCapability, error) {
+	return newFromAWSConfig(ctx, region, accountID, nil)
+}
+
+// NewFromAWSConfigWithUsage returns a LadderCapabilityFactory whose RI
+// coverage, on-demand series and RI utilization come from ingested CUR
+// usage (usage) instead of Cost Explorer. Savings Plans coverage and
+// utilization stay on Cost Explorer.
+func NewFromAWSConfigWithUsage(usage cur.Reader) func(ctx context.Context, region, accountID string) (pkgladder.LadderCapability, error) {
+	return func(ctx context.Context, region, accountID string) (pkgladder.LadderCapability, error) {
+		return newFromAWSConfig(ctx, region, accountID, usage)
+	}
+}
+
+func newFromAWSConfig(ctx context.Context, region, accountID string, usage cur.Reader) (pkgladder.LadderCapability, error) {
 	if region == "" {
 		return nil, fmt.Errorf("awsladder.NewFromAWSConfig: region must not be empty")
 	}
@@ -65,6 +83,9 @@ func NewFromAWSConfig(ctx context.Context, region, accountID string) (pkgladder.
 	// directly, and is the underlying client for the on-demand series and SP
 	// coverage/utilization adapters.
 	recoClient := recommendations.NewClient(&awsCfg)
+	if usage != nil {
+		recoClient.SetUsageReader(usage)
+	}
 
 	// ec2svc.Client satisfies riLister (ListConvertibleReservedInstances).
 	ec2Client := ec2svc.NewClient(awsCfg)
@@ -133,3 +154,99 @@ func WireWriteSide(l *AWSLadder, awsCfg aws.Config, ex exchangeRunner) (*AWSLadd
 	spP := savingsplansvc.NewClient(awsCfg, sptypes.SavingsPlanType(""))
 	return l.WithWriteSide(riP, spP, ex)
 }
+
+// reservedCapacityClient is what a reserved-capacity service client offers
+// the ladder: the reservation listing and the purchase.
+type reservedCapacityClient interface {
+	commitmentLister
+	reservedCapacityPurchaser
+}
+
+// newReservedCapacityClient returns the service client for a
+// reserved-capacity service.
+func newReservedCapacityClient(service common.ServiceType, awsCfg aws.Config) (reservedCapacityClient, error) {
+	switch service {
+	case common.ServiceRDS:
+		return rdssvc.NewClient(awsCfg), nil
+	case common.ServiceElastiCache:
+		return elasticachesvc.NewClient(awsCfg), nil
+	case common.ServiceOpenSearch:
+		return opensearchsvc.NewClient(awsCfg), nil
+	default:
+		return nil, fmt.Errorf("service %s has no reserved-capacity ladder", service)
+	}
+}
+
+// NewReservedCapacityFromAWSConfig constructs a read-side ReservedCapacityLadder
+// for one of RDS, ElastiCache, or OpenSearch in the given region and account:
+//
+//   - commitments : the service client's GetExistingCommitments
+//   - onDemand    : serviceOnDemandSeriesAdapter (CE GetCostAndUsage filtered to the service)
+//   - utilization : serviceUtilizationAdapter (CE GetReservationUtilization filtered to the service)
+//
+// The write side stays unwired; see WireReservedCapacityWriteSide.
+//
+// It matches the AWSReservedCapacityLadderFactory type on Application:
+//
+//	app.AWSReservedCapacityLadderFactory = awsladder.NewReservedCapacityFromAWSConfig
+func NewReservedCapacityFromAWSConfig(ctx context.Context, service common.ServiceType, region, accountID string) (pkgladder.LadderCapability, error) {
+	return newReservedCapacityFromAWSConfig(ctx, service, region, accountID, nil)
+}
+
+// NewReservedCapacityFromAWSConfigWithUsage is the NewFromAWSConfigWithUsage
+// counterpart for reserved-capacity ladders: the on-demand series and
+// utilization come from ingested CUR usage.
+func NewReservedCapacityFromAWSConfigWithUsage(usage cur.Reader) func(ctx context.Context, service common.ServiceType, region, accountID string) (pkgladder.LadderCapability, error) {
+	return func(ctx context.Context, service common.ServiceType, region, accountID string) (pkgladder.LadderCapability, error) {
+		return newReservedCapacityFromAWSConfig(ctx, service, region, accountID, usage)
+	}
+}
+
+func newReservedCapacityFromAWSConfig(ctx context.Context, service common.ServiceType, region, accountID string, usage cur.Reader) (pkgladder.LadderCapability, error) {
+	if region == "" {
+		return nil, fmt.Errorf("awsladder.NewReservedCapacityFromAWSConfig: region must not be empty")
+	}
+	if accountID == "" {
+		return nil, fmt.Errorf("awsladder.NewReservedCapacityFromAWSConfig: accountID must not be empty")
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("awsladder.NewReservedCapacityFromAWSConfig: load AWS config: %w", err)
+	}
+	svcClient, err := newReservedCapacityClient(service, awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("awsladder.NewReservedCapacityFromAWSConfig: %w", err)
+	}
+	recoClient := recommendations.NewClient(&awsCfg)
+	if usage != nil {
+		recoClient.SetUsageReader(usage)
+	}
+
+	l, err := NewReservedCapacity(
+		Config{Region: region, AccountID: accountID},
+		service,
+		svcClient,
+		&serviceOnDemandSeriesAdapter{client: recoClient, service: service},
+		&serviceUtilizationAdapter{client: recoClient, service: service},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("awsladder.NewReservedCapacityFromAWSConfig: %w", err)
+	}
+	return l, nil
+}
+
+// WireReservedCapacityWriteSide wires l's purchaser with the real service
+// client. Use this when ladder_execution_enabled=true in global_config.
+func WireReservedCapacityWriteSide(l *ReservedCapacityLadder, awsCfg aws.Config) (*ReservedCapacityLadder, error) {
+	p, err := newReservedCapacityClient(l.service, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+	return l.WithWriteSide(p)
+}
+
+// WireReservedCapacityWriteSideDisabled is the WireWriteSideDisabled
+// counterpart for a ReservedCapacityLadder.
+func WireReservedCapacityWriteSideDisabled(l *ReservedCapacityLadder) (*ReservedCapacityLadder, error) {
+	return l.WithWriteSide(disabledPurchaser{})
+}
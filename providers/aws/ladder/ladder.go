@@ -156,11 +156,7 @@ func (a *AWSLadder) Provider() common.ProviderType {
 // Role-cardinality contract: exactly one RoleFlex (ComputeSP), one RoleBase
 // (EC2InstanceSP), one RoleBuffer (ConvertibleRI). No multi-role merges on AWS.
 func (a *AWSLadder) SupportedLayers() []ladder.LayerSpec {
-	return []ladder.LayerSpec{
-		{Type: ladder.LayerEC2InstanceSP, Roles: []ladder.LayerRole{ladder.RoleBase}},
-		{Type: ladder.LayerComputeSP, Roles: []ladder.LayerRole{ladder.RoleFlex}},
-		{Type: ladder.LayerConvertibleRI, Roles: []ladder.LayerRole{ladder.RoleBuffer}},
-	}
+	return ladder.AWSLayers()
 }
 
 // WithWriteSide wires the write-side dependencies and returns the same
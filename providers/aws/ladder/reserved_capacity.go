@@ -0,0 +1,318 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// commitmentLister is the narrow interface for listing the active reserved
+// capacity of one service. The concrete implementations are the
+// GetExistingCommitments methods of the rds, elasticache, and opensearch
+// service clients, which report Commitment.Cost as the whole reservation's
+// amortized hourly cost.
+type commitmentLister interface {
+	GetExistingCommitments(ctx context.Context) ([]common.Commitment, error)
+}
+
+// reservedCapacityPurchaser is the narrow interface for buying one service's
+// reserved capacity. The concrete implementations are the PurchaseCommitment
+// methods of the rds, elasticache, and opensearch service clients, which derive
+// the native reservation ID from opts.IdempotencyToken so a re-driven purchase
+// short-circuits instead of double-buying.
+type reservedCapacityPurchaser interface {
+	PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error)
+}
+
+// ReservedCapacityLadder implements ladder.LadderCapability for a single AWS
+// reserved-capacity service (RDS, ElastiCache, or OpenSearch). Each service
+// ladder has exactly one layer, the service's RI, which carries RoleFlex: the
+// engine tranches it like compute, so successive purchases land in different
+// months and renewals stagger instead of expiring as one block.
+//
+// There is no buffer layer, so the config's buffer_fraction must be 0 and
+// ReshapeBuffer always fails (the engine never calls it without a buffer).
+//
+// Fields are ordered to minimize the GC pointer-scan range (fieldalignment).
+type ReservedCapacityLadder struct {
+	commitments commitmentLister
+	onDemand    onDemandSeriesSource
+	utilization utilizationSource
+	purchase    reservedCapacityPurchaser // write side; nil until WithWriteSide is called
+	layers      []ladder.LayerSpec
+	service     common.ServiceType
+	layer       ladder.LayerType
+	cfg         Config
+}
+
+// NewReservedCapacity constructs a ReservedCapacityLadder for service, which
+// must have a reserved-capacity layer (see ladder.ReservedCapacityLayers).
+// The three read-side interfaces must be non-nil; odSeries and util must be
+// scoped to service (see serviceOnDemandSeriesAdapter and
+// serviceUtilizationAdapter).
+func NewReservedCapacity(
+	cfg Config,
+	service common.ServiceType,
+	commitments commitmentLister,
+	odSeries onDemandSeriesSource,
+	util utilizationSource,
+) (*ReservedCapacityLadder, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("ReservedCapacityLadder: Config.Region must not be empty")
+	}
+	if cfg.AccountID == "" {
+		return nil, fmt.Errorf("ReservedCapacityLadder: Config.AccountID must not be empty")
+	}
+	layers, err := ladder.ReservedCapacityLayers(service)
+	if err != nil {
+		return nil, fmt.Errorf("ReservedCapacityLadder: %w", err)
+	}
+	if commitments == nil {
+		return nil, fmt.Errorf("ReservedCapacityLadder: commitmentLister must not be nil")
+	}
+	if odSeries == nil {
+		return nil, fmt.Errorf("ReservedCapacityLadder: onDemandSeriesSource must not be nil")
+	}
+	if util == nil {
+		return nil, fmt.Errorf("ReservedCapacityLadder: utilizationSource must not be nil")
+	}
+	return &ReservedCapacityLadder{
+		commitments: commitments,
+		onDemand:    odSeries,
+		utilization: util,
+		layers:      layers,
+		service:     service,
+		layer:       layers[0].Type,
+		cfg:         cfg,
+	}, nil
+}
+
+// WithWriteSide wires the service purchaser and returns the same instance for
+// chaining. There is no exchange runner: reserved capacity has no buffer.
+func (r *ReservedCapacityLadder) WithWriteSide(p reservedCapacityPurchaser) (*ReservedCapacityLadder, error) {
+	if p == nil {
+		return nil, fmt.Errorf("ReservedCapacityLadder.WithWriteSide: reservedCapacityPurchaser must not be nil")
+	}
+	r.purchase = p
+	return r, nil
+}
+
+// Provider returns common.ProviderAWS to identify this implementation.
+func (r *ReservedCapacityLadder) Provider() common.ProviderType {
+	return common.ProviderAWS
+}
+
+// SupportedLayers returns the service's single RI layer with RoleFlex.
+func (r *ReservedCapacityLadder) SupportedLayers() []ladder.LayerSpec {
+	return r.layers
+}
+
+// validateScope returns an error when scope targets a provider, account, or
+// service that does not match this ladder instance.
+func (r *ReservedCapacityLadder) validateScope(scope ladder.Scope) error {
+	if scope.Provider != common.ProviderAWS {
+		return fmt.Errorf("ReservedCapacityLadder: expected provider %s, got %s", common.ProviderAWS, scope.Provider)
+	}
+	if scope.AccountID != r.cfg.AccountID {
+		return fmt.Errorf("ReservedCapacityLadder: scope account %s does not match configured account %s",
+			scope.AccountID, r.cfg.AccountID)
+	}
+	if scope.Service != r.service {
+		return fmt.Errorf("ReservedCapacityLadder: scope service %q does not match configured service %q",
+			scope.Service, r.service)
+	}
+	return nil
+}
+
+// ListCommitments returns the service's active reservations in the region,
+// stamped with the configured account.
+func (r *ReservedCapacityLadder) ListCommitments(ctx context.Context, scope ladder.Scope) ([]common.Commitment, error) {
+	if err := r.validateScope(scope); err != nil {
+		return nil, err
+	}
+	commitments, err := r.commitments.GetExistingCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListCommitments: %s reservation listing failed: %w", r.service, err)
+	}
+	for i := range commitments {
+		commitments[i].Account = r.cfg.AccountID
+	}
+	return commitments, nil
+}
+
+// GetLayerStates returns the snapshot for the service's single RI layer:
+//
+//   - ExistingUSDPerHour / ExpiringUSDPerHour: the summed Commitment.Cost of
+//     all and of the soon-expiring reservations. A reservation whose cost is
+//     not positive fails the snapshot: the service client could not price
+//     it, and counting it as free would make the engine re-buy its capacity.
+//   - CoveragePct: nil (no per-service RI coverage source is wired; the
+//     engine only needs coverage for buffer reshapes, which this ladder has
+//     none of).
+//   - UtilizationPct: from CE reservation utilization scoped to the service
+//     and region; nil (with a WARNING log) when the source fails.
+func (r *ReservedCapacityLadder) GetLayerStates(ctx context.Context, scope ladder.Scope) (map[ladder.LayerType]ladder.LayerState, error) {
+	if err := r.validateScope(scope); err != nil {
+		return nil, err
+	}
+	commitments, err := r.commitments.GetExistingCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetLayerStates: %s reservation listing failed: %w", r.service, err)
+	}
+
+	horizon := time.Now().Add(time.Duration(r.cfg.horizonDays()) * 24 * time.Hour)
+	var existing, expiring float64
+	for i := range commitments {
+		c := &commitments[i]
+		if !(c.Cost > 0) {
+			return nil, fmt.Errorf("GetLayerStates: %s reservation %s has no hourly cost (pricing unknown); refusing to count it as free capacity",
+				r.service, c.CommitmentID)
+		}
+		existing += c.Cost
+		if !c.EndDate.After(horizon) {
+			expiring += c.Cost
+		}
+	}
+
+	state := ladder.LayerState{
+		Layer:              r.layer,
+		ExistingUSDPerHour: ptr(existing),
+		ExpiringUSDPerHour: ptr(expiring),
+	}
+	utils, err := r.utilization.GetRIUtilization(ctx, r.cfg.lookbackDays(), r.cfg.Region)
+	if err != nil {
+		log.Printf("WARNING: ReservedCapacityLadder GetLayerStates: RI utilization degraded to nil (layer=%s, source=GetRIUtilization, region=%s): %v",
+			r.layer, r.cfg.Region, err)
+	} else {
+		// The source is already scoped to this service and region, so every
+		// entry belongs to this layer; the CE subscription IDs do not match
+		// the service reservation IDs, so there is nothing to intersect on.
+		state.UtilizationPct = computeRIUtilizationPct(utils)
+	}
+
+	return map[ladder.LayerType]ladder.LayerState{r.layer: state}, nil
+}
+
+// GetUsageBaseline computes the low-water-mark of the service's daily
+// on-demand spend in the region. See AWSLadder.GetUsageBaseline for the series
+// checks; StableUSDPerHour is nil for the same reason, which routes the whole
+// core gap to the single flex layer.
+func (r *ReservedCapacityLadder) GetUsageBaseline(ctx context.Context, scope ladder.Scope, lookbackDays int, percentile float64) (ladder.UsageBaseline, error) {
+	if err := r.validateScope(scope); err != nil {
+		return ladder.UsageBaseline{}, err
+	}
+	return usageBaseline(ctx, r.onDemand, r.cfg.Region, lookbackDays, percentile)
+}
+
+// PurchaseLayer buys reserved capacity for the service's layer. The same
+// boundary rules as AWSLadder.PurchaseLayer apply: the write side must be
+// wired, the layer must be this ladder's, opts.IdempotencyToken must be
+// non-empty, and rec must carry the service's details type, a positive count,
+// and a term and payment option, all before any AWS call.
+func (r *ReservedCapacityLadder) PurchaseLayer(ctx context.Context, layer ladder.LayerType, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	if r.purchase == nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: %w", errWriteNotWired)
+	}
+	if layer != r.layer {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: layer %q is not supported by the %s ladder (want %s)", layer, r.service, r.layer)
+	}
+	if opts.IdempotencyToken == "" {
+		return common.PurchaseResult{}, fmt.Errorf(
+			"PurchaseLayer(%s): opts.IdempotencyToken must not be empty: idempotency is mandatory on the ladder purchase path so re-driven executions cannot double-buy",
+			layer)
+	}
+	if err := validateReservedCapacityRec(r.service, &rec); err != nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer(%s): %w", layer, err)
+	}
+	result, err := r.purchase.PurchaseCommitment(ctx, rec, opts)
+	if err != nil {
+		return result, fmt.Errorf("PurchaseLayer(%s): %s reservation purchase failed: %w", layer, r.service, err)
+	}
+	return result, nil
+}
+
+// ReshapeBuffer always fails: a reserved-capacity ladder has no buffer layer,
+// and its config validation pins buffer_fraction to 0.
+func (r *ReservedCapacityLadder) ReshapeBuffer(_ context.Context, scope ladder.Scope, _ ladder.BufferReshapeConfig) (ladder.ReshapeSummary, error) {
+	if err := r.validateScope(scope); err != nil {
+		return ladder.ReshapeSummary{}, err
+	}
+	return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: the %s ladder has no buffer layer to reshape", r.service)
+}
+
+// validateReservedCapacityRec checks that rec carries the details type the
+// service client's offering lookup asserts on, with the fields it matches on
+// set, plus a positive count and the term/payment option strings.
+func validateReservedCapacityRec(service common.ServiceType, rec *common.Recommendation) error {
+	switch service {
+	case common.ServiceRDS:
+		details, ok := rec.Details.(*common.DatabaseDetails)
+		if !ok || details == nil {
+			return fmt.Errorf("recommendation Details must be *common.DatabaseDetails for an RDS reservation, got %T", rec.Details)
+		}
+		if details.InstanceClass == "" || details.Engine == "" {
+			return fmt.Errorf("DatabaseDetails.InstanceClass and Engine must not be empty for an RDS reservation")
+		}
+		if details.AZConfig == "" {
+			return fmt.Errorf("DatabaseDetails.AZConfig must not be empty for an RDS reservation (single-az and multi-az RIs do not cover each other)")
+		}
+	case common.ServiceElastiCache:
+		details, ok := rec.Details.(*common.CacheDetails)
+		if !ok || details == nil {
+			return fmt.Errorf("recommendation Details must be *common.CacheDetails for an ElastiCache reservation, got %T", rec.Details)
+		}
+		if details.NodeType == "" || details.Engine == "" {
+			return fmt.Errorf("CacheDetails.NodeType and Engine must not be empty for an ElastiCache reservation")
+		}
+	case common.ServiceOpenSearch:
+		if rec.ResourceType == "" {
+			return fmt.Errorf("recommendation ResourceType (instance type) must not be empty for an OpenSearch reservation")
+		}
+	default:
+		return fmt.Errorf("service %s has no reserved-capacity purchase path", service)
+	}
+	if rec.Count <= 0 {
+		return fmt.Errorf("recommendation Count must be > 0 for a %s reservation, got %d", service, rec.Count)
+	}
+	return validateTermAndPayment(rec)
+}
+
+// serviceOnDemandSeriesAdapter implements onDemandSeriesSource for one
+// reserved-capacity service by wrapping
+// recommendations.Client.GetServiceOnDemandSeries. See onDemandSeriesAdapter
+// for why the DailyCost -> DailyPoint mapping lives here.
+type serviceOnDemandSeriesAdapter struct {
+	client  *recommendations.Client
+	service common.ServiceType
+}
+
+// GetOnDemandSeries fetches the service's daily on-demand cost series.
+func (a *serviceOnDemandSeriesAdapter) GetOnDemandSeries(ctx context.Context, region string, lookbackDays int) ([]DailyPoint, error) {
+	costs, err := a.client.GetServiceOnDemandSeries(ctx, a.service, region, lookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]DailyPoint, len(costs))
+	for i, c := range costs {
+		points[i] = DailyPoint{Date: c.Date, USDPerHour: c.USDPerHour}
+	}
+	return points, nil
+}
+
+// serviceUtilizationAdapter implements utilizationSource for one
+// reserved-capacity service by wrapping
+// recommendations.Client.GetServiceRIUtilization.
+type serviceUtilizationAdapter struct {
+	client  *recommendations.Client
+	service common.ServiceType
+}
+
+// GetRIUtilization fetches the service's per-reservation utilization.
+func (a *serviceUtilizationAdapter) GetRIUtilization(ctx context.Context, lookbackDays int, region string) ([]recommendations.RIUtilization, error) {
+	return a.client.GetServiceRIUtilization(ctx, a.service, lookbackDays, region)
+}
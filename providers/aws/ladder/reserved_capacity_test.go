@@ -0,0 +1,203 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+)
+
+// fakeCommitmentLister: err field before commitments for fieldalignment.
+type fakeCommitmentLister struct {
+	err         error
+	commitments []common.Commitment
+}
+
+func (f *fakeCommitmentLister) GetExistingCommitments(_ context.Context) ([]common.Commitment, error) {
+	return f.commitments, f.err
+}
+
+// fakeSeriesSource is a single-purpose onDemandSeriesSource.
+type fakeSeriesSource struct {
+	err    error
+	points []DailyPoint
+}
+
+func (f *fakeSeriesSource) GetOnDemandSeries(_ context.Context, _ string, _ int) ([]DailyPoint, error) {
+	return f.points, f.err
+}
+
+func rdsScope() ladder.Scope {
+	return ladder.Scope{Provider: common.ProviderAWS, AccountID: "123456789012", Service: common.ServiceRDS}
+}
+
+func newTestRDSLadder(t *testing.T, lister commitmentLister, series onDemandSeriesSource, util utilizationSource) *ReservedCapacityLadder {
+	t.Helper()
+	r, err := NewReservedCapacity(
+		Config{Region: "us-east-1", AccountID: "123456789012", HorizonDays: 30, LookbackDays: 30},
+		common.ServiceRDS, lister, series, util,
+	)
+	require.NoError(t, err)
+	return r
+}
+
+func validRDSRec() common.Recommendation {
+	return common.Recommendation{
+		Service:       common.ServiceRDS,
+		ResourceType:  "db.r6g.large",
+		Count:         1,
+		Term:          "1yr",
+		PaymentOption: "no-upfront",
+		Details: &common.DatabaseDetails{
+			Engine:        "postgres",
+			InstanceClass: "db.r6g.large",
+			AZConfig:      "multi-az",
+		},
+	}
+}
+
+func TestNewReservedCapacity_Validation(t *testing.T) {
+	cfg := Config{Region: "us-east-1", AccountID: "123456789012"}
+	lister, series, util := &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{}
+
+	_, err := NewReservedCapacity(cfg, common.ServiceRedshift, lister, series, util)
+	require.Error(t, err)
+	_, err = NewReservedCapacity(cfg, common.ServiceRDS, nil, series, util)
+	require.ErrorContains(t, err, "commitmentLister must not be nil")
+	_, err = NewReservedCapacity(Config{AccountID: "1"}, common.ServiceRDS, lister, series, util)
+	require.ErrorContains(t, err, "Region must not be empty")
+
+	for _, svc := range []common.ServiceType{common.ServiceRDS, common.ServiceElastiCache, common.ServiceOpenSearch} {
+		r, err := NewReservedCapacity(cfg, svc, lister, series, util)
+		require.NoError(t, err, svc)
+		layers := r.SupportedLayers()
+		require.Len(t, layers, 1)
+		assert.Equal(t, []ladder.LayerRole{ladder.RoleFlex}, layers[0].Roles)
+	}
+}
+
+func TestReservedCapacity_ScopeMustMatchService(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{})
+	_, err := r.ListCommitments(context.Background(), testScope())
+	require.ErrorContains(t, err, "does not match configured service")
+
+	// And the compute ladder refuses a service scope.
+	a := newTestLadder(t, &fakeRILister{}, &fakeSPLister{}, &fakeCoverageSource{}, &fakeUtilizationSource{})
+	_, err = a.ListCommitments(context.Background(), rdsScope())
+	require.ErrorContains(t, err, "not served by the compute ladder")
+}
+
+func TestReservedCapacity_GetLayerStates_SumsCostAndExpiring(t *testing.T) {
+	now := time.Now()
+	lister := &fakeCommitmentLister{commitments: []common.Commitment{
+		{CommitmentID: "ri-a", Cost: 0.5, EndDate: now.Add(10 * 24 * time.Hour)},
+		{CommitmentID: "ri-b", Cost: 1.5, EndDate: now.Add(200 * 24 * time.Hour)},
+	}}
+	util := &fakeUtilizationSource{utils: []recommendations.RIUtilization{
+		{ReservedInstanceID: "sub-1", PurchasedHours: 100, TotalActualHours: 80},
+	}}
+	r := newTestRDSLadder(t, lister, &fakeSeriesSource{}, util)
+
+	states, err := r.GetLayerStates(context.Background(), rdsScope())
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	s := states[ladder.LayerRDSRI]
+	assert.InDelta(t, 2.0, *s.ExistingUSDPerHour, 1e-9)
+	assert.InDelta(t, 0.5, *s.ExpiringUSDPerHour, 1e-9)
+	assert.Nil(t, s.CoveragePct)
+	require.NotNil(t, s.UtilizationPct)
+	assert.InDelta(t, 80, *s.UtilizationPct, 1e-9)
+}
+
+func TestReservedCapacity_GetLayerStates_UnpricedReservationFailsLoud(t *testing.T) {
+	lister := &fakeCommitmentLister{commitments: []common.Commitment{{CommitmentID: "ri-free", EndDate: time.Now().Add(time.Hour)}}}
+	r := newTestRDSLadder(t, lister, &fakeSeriesSource{}, &fakeUtilizationSource{})
+
+	_, err := r.GetLayerStates(context.Background(), rdsScope())
+	require.ErrorContains(t, err, "ri-free")
+}
+
+func TestReservedCapacity_GetLayerStates_UtilizationErrorDegradesToNil(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{err: errors.New("ce down")})
+
+	states, err := r.GetLayerStates(context.Background(), rdsScope())
+	require.NoError(t, err)
+	s := states[ladder.LayerRDSRI]
+	assert.Nil(t, s.UtilizationPct)
+	assert.Zero(t, *s.ExistingUSDPerHour)
+}
+
+func TestReservedCapacity_GetUsageBaseline_UsesServiceSeries(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{points: makeConstantPoints(30, 4)}, &fakeUtilizationSource{})
+
+	b, err := r.GetUsageBaseline(context.Background(), rdsScope(), 30, 5)
+	require.NoError(t, err)
+	assert.InDelta(t, 4, *b.LowWaterUSDPerHour, 1e-9)
+	assert.Nil(t, b.StableUSDPerHour)
+
+	empty := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{})
+	_, err = empty.GetUsageBaseline(context.Background(), rdsScope(), 30, 5)
+	require.ErrorContains(t, err, "empty")
+}
+
+func TestReservedCapacity_PurchaseLayer(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{})
+	_, err := r.PurchaseLayer(context.Background(), ladder.LayerRDSRI, validRDSRec(), validPurchaseOpts())
+	require.ErrorIs(t, err, errWriteNotWired)
+
+	p := &fakePurchaser{result: common.PurchaseResult{Success: true, CommitmentID: "rds-id-1"}}
+	r, err = r.WithWriteSide(p)
+	require.NoError(t, err)
+
+	res, err := r.PurchaseLayer(context.Background(), ladder.LayerRDSRI, validRDSRec(), validPurchaseOpts())
+	require.NoError(t, err)
+	assert.Equal(t, "rds-id-1", res.CommitmentID)
+	assert.Equal(t, 1, p.calls)
+	assert.Equal(t, "ladder-tok-1", p.gotOpts.IdempotencyToken)
+
+	bad := []struct {
+		name   string
+		layer  ladder.LayerType
+		mutate func(*common.Recommendation, *common.PurchaseOptions)
+		want   string
+	}{
+		{"wrong layer", ladder.LayerConvertibleRI, func(*common.Recommendation, *common.PurchaseOptions) {}, "not supported"},
+		{"no token", ladder.LayerRDSRI, func(_ *common.Recommendation, o *common.PurchaseOptions) { o.IdempotencyToken = "" }, "IdempotencyToken"},
+		{"cache details", ladder.LayerRDSRI, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Details = &common.CacheDetails{} }, "DatabaseDetails"},
+		{"no az config", ladder.LayerRDSRI, func(r *common.Recommendation, _ *common.PurchaseOptions) {
+			r.Details.(*common.DatabaseDetails).AZConfig = ""
+		}, "AZConfig"},
+		{"zero count", ladder.LayerRDSRI, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Count = 0 }, "Count"},
+		{"no term", ladder.LayerRDSRI, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Term = "" }, "Term"},
+	}
+	for _, tc := range bad {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, opts := validRDSRec(), validPurchaseOpts()
+			tc.mutate(&rec, &opts)
+			_, err := r.PurchaseLayer(context.Background(), tc.layer, rec, opts)
+			require.ErrorContains(t, err, tc.want)
+		})
+	}
+	assert.Equal(t, 1, p.calls, "no invalid purchase may reach the client")
+}
+
+func TestReservedCapacity_ReshapeBufferAlwaysFails(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{})
+	_, err := r.ReshapeBuffer(context.Background(), rdsScope(), ladder.BufferReshapeConfig{})
+	require.ErrorContains(t, err, "no buffer layer")
+}
+
+func TestReservedCapacity_DisabledWriteSide(t *testing.T) {
+	r := newTestRDSLadder(t, &fakeCommitmentLister{}, &fakeSeriesSource{}, &fakeUtilizationSource{})
+	r, err := WireReservedCapacityWriteSideDisabled(r)
+	require.NoError(t, err)
+	_, err = r.PurchaseLayer(context.Background(), ladder.LayerRDSRI, validRDSRec(), validPurchaseOpts())
+	require.ErrorIs(t, err, ErrLadderExecutionDisabled)
+}
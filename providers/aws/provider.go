@@ -16,8 +16,11 @@ import (
 	"github.com/aws/smithy-go"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/savingsplans"
 )
 
@@ -91,6 +94,7 @@ type AWSProvider struct {
 	ec2Client           EC2Client
 	orgPaginator        OrganizationsPaginator
 	credentialsProvider aws.CredentialsProvider // optional override for per-account execution
+	usageReader         cur.Reader              // optional: CUR aggregates instead of Cost Explorer usage reads
 }
 
 // NewAWSProvider creates a new AWS provider instance.
@@ -129,6 +133,16 @@ func (p *AWSProvider) SetSTSClient(client STSClient) {
 	p.stsClient = client
 }
 
+// SetUsageReader serves the usage and coverage reads of the recommendations
+// clients this provider builds (existing coverage on vendor
+// recommendations, and the native engine's pools and demand) from ingested
+// CUR data instead of Cost Explorer; see recommendations.Client.SetUsageReader.
+// The vendor recommendations themselves still come from Cost Explorer,
+// which is where AWS publishes them.
+func (p *AWSProvider) SetUsageReader(r cur.Reader) {
+	p.usageReader = r
+}
+
 // SetEC2Client sets the EC2 client (for testing)
 func (p *AWSProvider) SetEC2Client(client EC2Client) {
 	p.ec2Client = client
@@ -429,6 +443,7 @@ func (p *AWSProvider) GetSupportedServices() []common.ServiceType {
 		common.ServiceCache,
 		common.ServiceSearch,
 		common.ServiceDataWarehouse,
+		common.ServiceNoSQL,
 		common.ServiceSavingsPlansCompute,
 		common.ServiceSavingsPlansEC2Instance,
 		common.ServiceSavingsPlansSageMaker,
@@ -443,6 +458,7 @@ func (p *AWSProvider) GetSupportedServices() []common.ServiceType {
 		common.ServiceOpenSearch,
 		common.ServiceRedshift,
 		common.ServiceMemoryDB,
+		common.ServiceDynamoDB,
 	}
 }
 
@@ -469,6 +485,8 @@ func (p *AWSProvider) GetServiceClient(ctx context.Context, service common.Servi
 		return NewRedshiftClient(regionalCfg), nil
 	case common.ServiceMemoryDB:
 		return NewMemoryDBClient(regionalCfg), nil
+	case common.ServiceNoSQL, common.ServiceDynamoDB:
+		return NewDynamoDBClient(regionalCfg), nil
 	case common.ServiceSavingsPlansCompute,
 		common.ServiceSavingsPlansEC2Instance,
 		common.ServiceSavingsPlansSageMaker,
@@ -497,7 +515,38 @@ func (p *AWSProvider) GetRecommendationsClient(ctx context.Context) (provider.Re
 		return nil, fmt.Errorf("AWS is not configured")
 	}
 
-	return NewRecommendationsClient(p.cfg), nil
+	client := NewRecommendationsClientDirect(p.cfg)
+	client.SetUsageReader(p.usageReader)
+	return client, nil
+}
+
+// GetNativeRecommendations implements provider.NativeRecommendationsSource:
+// EC2 RI recommendations computed by pkg/recommender across every enabled
+// region from CUR pool hours when a usage reader is set (SetUsageReader),
+// and from Cost Explorer coverage and on-demand spend otherwise, priced with
+// the regional EC2 client's offering lookup.
+func (p *AWSProvider) GetNativeRecommendations(ctx context.Context, cfg recommender.Config) ([]common.Recommendation, error) {
+	if !p.IsConfigured() {
+		return nil, fmt.Errorf("AWS is not configured")
+	}
+	regions, err := p.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regionIDs := make([]string, len(regions))
+	for i, r := range regions {
+		regionIDs[i] = r.ID
+	}
+	client := recommendations.NewClient(&p.cfg)
+	client.SetUsageReader(p.usageReader)
+	src, err := recommendations.NewNativeSource(client,
+		func(ctx context.Context, region string) (recommendations.OfferingPricer, error) {
+			return p.GetServiceClient(ctx, common.ServiceEC2, region)
+		})
+	if err != nil {
+		return nil, err
+	}
+	return src.GetNativeRecommendations(ctx, cfg, regionIDs)
 }
 
 // Register the AWS provider with the global registry
@@ -3,7 +3,9 @@ package aws
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -17,7 +19,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
 )
 
 // mockConfigLoader implements ConfigLoader for testing
@@ -247,6 +251,7 @@ func TestAWSProvider_GetSupportedServices(t *testing.T) {
 	assert.Contains(t, services, common.ServiceCache)
 	assert.Contains(t, services, common.ServiceSearch)
 	assert.Contains(t, services, common.ServiceDataWarehouse)
+	assert.Contains(t, services, common.ServiceNoSQL)
 	assert.Contains(t, services, common.ServiceSavingsPlansCompute)
 	assert.Contains(t, services, common.ServiceSavingsPlansEC2Instance)
 	assert.Contains(t, services, common.ServiceSavingsPlansSageMaker)
@@ -260,6 +265,7 @@ func TestAWSProvider_GetSupportedServices(t *testing.T) {
 	assert.Contains(t, services, common.ServiceOpenSearch)
 	assert.Contains(t, services, common.ServiceRedshift)
 	assert.Contains(t, services, common.ServiceMemoryDB)
+	assert.Contains(t, services, common.ServiceDynamoDB)
 }
 
 func TestAWSProvider_IsConfigured(t *testing.T) {
@@ -319,6 +325,8 @@ func TestAWSProvider_GetServiceClient_AllServiceTypes(t *testing.T) {
 		{common.ServiceDataWarehouse, common.ServiceDataWarehouse},
 		{common.ServiceRedshift, common.ServiceDataWarehouse},
 		{common.ServiceMemoryDB, common.ServiceCache},
+		{common.ServiceNoSQL, common.ServiceNoSQL},
+		{common.ServiceDynamoDB, common.ServiceNoSQL},
 		{common.ServiceSavingsPlansCompute, common.ServiceSavingsPlansCompute},
 		{common.ServiceSavingsPlansEC2Instance, common.ServiceSavingsPlansEC2Instance},
 		{common.ServiceSavingsPlansSageMaker, common.ServiceSavingsPlansSageMaker},
@@ -980,3 +988,77 @@ func TestGetCredentials_SourceMapping(t *testing.T) {
 		})
 	}
 }
+
+// recordingHTTPClient fails every request and records its host, so a test
+// can prove which AWS endpoints a code path reached.
+type recordingHTTPClient struct {
+	hosts []string
+}
+
+func (c *recordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.hosts = append(c.hosts, req.URL.Host)
+	return nil, errors.New("unexpected AWS call")
+}
+
+// fakeUsageReader serves canned CUR pool hours and counts the reads.
+type fakeUsageReader struct {
+	pools []cur.PoolHour
+	reads int
+}
+
+func (f *fakeUsageReader) PoolHours(context.Context, cur.Query) ([]cur.PoolHour, error) {
+	f.reads++
+	return f.pools, nil
+}
+
+func (f *fakeUsageReader) CommitmentHours(context.Context, cur.Query) ([]cur.CommitmentHour, error) {
+	f.reads++
+	return nil, nil
+}
+
+func TestAWSProvider_UsageReaderKeepsCostExplorerOut(t *testing.T) {
+	httpClient := &recordingHTTPClient{}
+	p := &AWSProvider{cfg: aws.Config{
+		Region:     "us-east-1",
+		HTTPClient: httpClient,
+		Retryer:    func() aws.Retryer { return aws.NopRetryer{} },
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}, nil
+		}),
+	}}
+	p.cfgOnce.Do(func() {})
+	p.SetEC2Client(&mockEC2Client{
+		describeRegionsFunc: func(context.Context, *ec2.DescribeRegionsInput, ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+			return &ec2.DescribeRegionsOutput{Regions: []ec2types.Region{{RegionName: aws.String("us-east-1")}}}, nil
+		},
+	})
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	reader := &fakeUsageReader{pools: []cur.PoolHour{{
+		// An operating system with no RI product description: the native
+		// engine reads the pool and skips it without pricing.
+		PoolKey:       cur.PoolKey{AccountID: "111111111111", ProductCode: cur.ProductEC2, Region: "us-east-1", ResourceType: "m5.large", Platform: "Ubuntu Pro"},
+		Hour:          yesterday,
+		UsageHours:    2,
+		OnDemandHours: 1,
+		ReservedHours: 1,
+		OnDemandCost:  0.1,
+	}}}
+	p.SetUsageReader(reader)
+	ctx := context.Background()
+
+	client, err := p.GetRecommendationsClient(ctx)
+	require.NoError(t, err)
+	adapter, ok := client.(*RecommendationsClientAdapter)
+	require.True(t, ok)
+	recs := []common.Recommendation{{Provider: common.ProviderAWS, Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large"}}
+	require.NoError(t, adapter.ApplyExistingCoverage(ctx, recs, 30))
+	assert.True(t, recs[0].ExistingCoverageKnown)
+	assert.InDelta(t, 50, recs[0].ExistingCoveragePct, 1e-9)
+
+	native, err := p.GetNativeRecommendations(ctx, recommender.Config{Term: "1yr", PaymentOption: "no-upfront", LookbackDays: 30, Percentile: 10})
+	require.NoError(t, err)
+	assert.Empty(t, native)
+
+	assert.Equal(t, 2, reader.reads, "coverage and the native pools both come from CUR")
+	assert.Empty(t, httpClient.hosts, "no Cost Explorer (or any other AWS) call with a usage reader set")
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/concurrency"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 )
 
@@ -71,6 +72,10 @@ type Client struct {
 	// recLookbackPeriod is forwarded to GetReservationPurchaseRecommendation
 	// as LookbackPeriodInDays. Defaults to "7d" when empty.
 	recLookbackPeriod string
+
+	// usageReader, when set via SetUsageReader, serves coverage, on-demand
+	// series and utilization from ingested CUR data instead of Cost Explorer.
+	usageReader cur.Reader
 }
 
 // NewClient creates a new recommendations client.
@@ -63,9 +63,15 @@ const rdsServiceFilter = "Amazon Relational Database Service"
 // the window — sizing then falls back to rec.AverageInstancesUsedPerHour
 // from the rec parser (per-account signal from
 // GetReservationPurchaseRecommendation).
+//
+// OnDemandUSDPerHour is the pool's on-demand price per instance-hour
+// (CoverageCost.OnDemandCost / CoverageHours.OnDemandHours), which the
+// native recommendation source needs to price the hours a commitment would
+// displace. Zero when CE reported no on-demand hours for the pool.
 type PoolCoverage struct {
 	Pct                 float64
 	AvgInstancesPerHour float64
+	OnDemandUSDPerHour  float64
 }
 
 // PoolCoverageMap maps a pool key to the (pct, avg) pair for that pool.
@@ -175,10 +181,16 @@ func normaliseDeployment(deployment string) string {
 // rec.ExistingCoveragePct at zero for those recs, which the sizing path
 // treats as "no signal" and falls back to the no-existing-commitments
 // formula.
+//
+// With a usage reader set (SetUsageReader) the map is built from CUR pool
+// hours instead; see curCoverageMap.
 func (c *Client) GetRICoverageMap(ctx context.Context, lookbackDays int, regions []string) (PoolCoverageMap, error) {
 	if lookbackDays <= 0 {
 		lookbackDays = 30
 	}
+	if c.usageReader != nil {
+		return c.curCoverageMap(ctx, lookbackDays, regions)
+	}
 	end := time.Now().UTC()
 	start := end.AddDate(0, 0, -lookbackDays)
 	startStr := start.Format("2006-01-02")
@@ -220,7 +232,7 @@ func (c *Client) fetchCoverageForRDSEngineRegion(ctx context.Context, startStr,
 			{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionDeploymentOption))},
 		},
 		Filter:  rdsEngineRegionFilter(engine, region),
-		Metrics: []string{"Hour"},
+		Metrics: []string{"Hour", "Cost"},
 	}
 	return c.fetchCoveragePaged(ctx, input, func(instType, deployment string, cov PoolCoverage) {
 		out[rdsPoolKey(region, instType, engine, deployment)] = cov
@@ -237,6 +249,7 @@ func (c *Client) fetchCoverageForRDSEngineRegion(ctx context.Context, startStr,
 // we actually parse. HoursPercentage isn't a valid Metrics value (CE
 // rejects it with ValidationException) — Metrics names the block, the
 // percentage / hours fields are computed and included automatically.
+// "Cost" likewise adds the CoverageCost block for OnDemandUSDPerHour.
 func (c *Client) fetchCoverageForServiceRegion(ctx context.Context, startStr, endStr string, windowHours float64, service, region string, out PoolCoverageMap) error {
 	input := &costexplorer.GetReservationCoverageInput{
 		TimePeriod: &types.DateInterval{Start: aws.String(startStr), End: aws.String(endStr)},
@@ -244,7 +257,7 @@ func (c *Client) fetchCoverageForServiceRegion(ctx context.Context, startStr, en
 			{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionInstanceType))},
 		},
 		Filter:  serviceRegionFilter(service, region),
-		Metrics: []string{"Hour"},
+		Metrics: []string{"Hour", "Cost"},
 	}
 	return c.fetchCoveragePaged(ctx, input, func(instType, _ string, cov PoolCoverage) {
 		out[poolKey(region, instType)] = cov
@@ -253,13 +266,14 @@ func (c *Client) fetchCoverageForServiceRegion(ctx context.Context, startStr, en
 
 // fetchCoveragePaged runs the paginated GetReservationCoverage loop and
 // invokes record on each group with a non-empty INSTANCE_TYPE and a
-// valid Coverage block. The keyed-write logic is callsite-specific
+// valid Coverage block. variant is the second GroupBy dimension, if any
+// (see extractGroupAttributes). The keyed-write logic is callsite-specific
 // (RDS keys carry engine + deployment, non-RDS keys don't), so record
 // closes over the key shape the caller wants.
 func (c *Client) fetchCoveragePaged(
 	ctx context.Context,
 	input *costexplorer.GetReservationCoverageInput,
-	record func(instType, deployment string, cov PoolCoverage),
+	record func(instType, variant string, cov PoolCoverage),
 	windowHours float64,
 ) error {
 	var token *string
@@ -274,7 +288,7 @@ func (c *Client) fetchCoveragePaged(
 		}
 		for _, period := range result.CoveragesByTime {
 			for _, group := range period.Groups {
-				instType, deployment := extractGroupAttributes(group.Attributes)
+				instType, variant := extractGroupAttributes(group.Attributes)
 				if instType == "" {
 					continue
 				}
@@ -282,7 +296,7 @@ func (c *Client) fetchCoveragePaged(
 				if !ok {
 					continue
 				}
-				record(instType, deployment, cov)
+				record(instType, variant, cov)
 			}
 		}
 		if result.NextPageToken == nil || *result.NextPageToken == "" {
@@ -359,26 +373,35 @@ func poolCoverageFromGroup(group types.ReservationCoverageGroup, windowHours flo
 	if windowHours > 0 && group.Coverage.CoverageHours.TotalRunningHours != nil {
 		avg = parseFloat(aws.ToString(group.Coverage.CoverageHours.TotalRunningHours)) / windowHours
 	}
-	return PoolCoverage{Pct: pct, AvgInstancesPerHour: avg}, true
+	cov := PoolCoverage{Pct: pct, AvgInstancesPerHour: avg}
+	if cost := group.Coverage.CoverageCost; cost != nil && cost.OnDemandCost != nil &&
+		group.Coverage.CoverageHours.OnDemandHours != nil {
+		if hours := parseFloat(aws.ToString(group.Coverage.CoverageHours.OnDemandHours)); hours > 0 {
+			cov.OnDemandUSDPerHour = parseFloat(aws.ToString(cost.OnDemandCost)) / hours
+		}
+	}
+	return cov, true
 }
 
-// extractGroupAttributes reads INSTANCE_TYPE and DEPLOYMENT_OPTION values
-// from CE's Attributes map. CE sends keys in camelCase ("instanceType",
-// "deploymentOption") even though the GroupBy input expects
+// extractGroupAttributes reads the INSTANCE_TYPE value and the second
+// grouping dimension from CE's Attributes map. CE sends keys in camelCase
+// ("instanceType", "deploymentOption") even though the GroupBy input expects
 // SCREAMING_SNAKE_CASE ("INSTANCE_TYPE", "DEPLOYMENT_OPTION"); normalise
 // both sides by stripping underscores and lower-casing before comparing.
-// Returns empty strings for absent dimensions — the deployment slot is
-// optional (non-RDS callers don't group by it and won't pass it through).
-func extractGroupAttributes(attrs map[string]string) (instanceType, deployment string) {
+// variant is DEPLOYMENT_OPTION (RDS coverage) or PLATFORM (the native EC2
+// source); no caller groups by both. Returns empty strings for absent
+// dimensions — the variant slot is optional (callers that group by
+// INSTANCE_TYPE alone won't pass it through).
+func extractGroupAttributes(attrs map[string]string) (instanceType, variant string) {
 	for k, v := range attrs {
 		switch strings.ToLower(strings.ReplaceAll(k, "_", "")) {
 		case "instancetype":
 			instanceType = strings.ToLower(v)
-		case "deploymentoption":
-			deployment = v
+		case "deploymentoption", "platform":
+			variant = v
 		}
 	}
-	return instanceType, deployment
+	return instanceType, variant
 }
 
 // ApplyCoverageMapToRecommendations sets ExistingCoveragePct on each rec
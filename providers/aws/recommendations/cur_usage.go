@@ -0,0 +1,187 @@
+package recommendations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+// curProductCodes maps the CE SERVICE dimension values the coverage,
+// on-demand and utilization paths query onto CUR line_item_product_code
+// values. Both spellings of MemoryDB appear because coverageServiceFilters
+// and getServiceStringForCostExplorer disagree on it.
+var curProductCodes = map[string]string{
+	ec2ComputeService:           cur.ProductEC2,
+	rdsServiceFilter:            cur.ProductRDS,
+	"Amazon ElastiCache":        cur.ProductElastiCache,
+	"Amazon OpenSearch Service": cur.ProductOpenSearch,
+	"Amazon Redshift":           cur.ProductRedshift,
+	"Amazon MemoryDB":           cur.ProductMemoryDB,
+	"Amazon MemoryDB Service":   cur.ProductMemoryDB,
+}
+
+// SetUsageReader switches GetRICoverageMap, GetOnDemandSeries,
+// GetServiceOnDemandSeries, GetRIUtilization and GetServiceRIUtilization
+// from Cost Explorer to CUR aggregates ingested by internal/cur. Results
+// keep their CE shapes, so callers (the ladder baseline, coverage apply,
+// layer utilization) are unaffected. A nil reader restores Cost Explorer.
+// Must be called before the client is shared.
+func (c *Client) SetUsageReader(r cur.Reader) {
+	c.usageReader = r
+}
+
+// curProductCode resolves a CE service string to its CUR product code.
+func curProductCode(ceService string) (string, error) {
+	code, ok := curProductCodes[ceService]
+	if !ok {
+		return "", fmt.Errorf("no CUR product code for CE service %q", ceService)
+	}
+	return code, nil
+}
+
+// curWindow is the [from, to) hour range matching the CE date window for
+// lookbackDays: whole UTC days ending at midnight today.
+func curWindow(lookbackDays int) (from, to time.Time) {
+	to = time.Now().UTC().Truncate(24 * time.Hour)
+	return to.AddDate(0, 0, -lookbackDays), to
+}
+
+// curCoverageMap is GetRICoverageMap over CUR pool hours. Accounts are
+// summed (the CE view is org-wide) and so are EC2 platforms (CE groups
+// non-RDS coverage by INSTANCE_TYPE alone). Pct is reservation coverage
+// only, like GetReservationCoverage: Savings Plan hours count toward usage
+// but not toward Pct.
+func (c *Client) curCoverageMap(ctx context.Context, lookbackDays int, regions []string) (PoolCoverageMap, error) {
+	from, to := curWindow(lookbackDays)
+	products := []string{cur.ProductRDS}
+	for _, service := range coverageServiceFilters {
+		code, err := curProductCode(service)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, code)
+	}
+	hours, err := c.usageReader.PoolHours(ctx, cur.Query{ProductCodes: products, Regions: regions, From: from, To: to})
+	if err != nil {
+		return nil, fmt.Errorf("reading CUR pool hours: %w", err)
+	}
+
+	type acc struct{ usage, reserved, odHours, odCost float64 }
+	byKey := make(map[string]*acc)
+	for _, h := range hours {
+		key := poolKey(h.Region, h.ResourceType)
+		if h.ProductCode == cur.ProductRDS {
+			key = rdsPoolKey(h.Region, h.ResourceType, h.Platform, h.DeploymentOption)
+		}
+		a, ok := byKey[key]
+		if !ok {
+			a = &acc{}
+			byKey[key] = a
+		}
+		a.usage += h.UsageHours
+		a.reserved += h.ReservedHours
+		a.odHours += h.OnDemandHours
+		a.odCost += h.OnDemandCost
+	}
+
+	windowHours := float64(lookbackDays * 24)
+	out := make(PoolCoverageMap, len(byKey))
+	for key, a := range byKey {
+		if a.usage <= 0 {
+			continue
+		}
+		cov := PoolCoverage{Pct: a.reserved / a.usage * 100, AvgInstancesPerHour: a.usage / windowHours}
+		if a.odHours > 0 {
+			cov.OnDemandUSDPerHour = a.odCost / a.odHours
+		}
+		out[key] = cov
+	}
+	return out, nil
+}
+
+// curOnDemandSeries is getOnDemandSeries over CUR pool hours: each day's
+// on-demand cost for the service in region, divided by 24, from the pools
+// scope matches. Days with usage but no on-demand cost appear as $0, like
+// CE's rows; days without any usage are absent. The empty and all-zero
+// checks of buildDailySeries apply unchanged.
+func (c *Client) curOnDemandSeries(ctx context.Context, ceService, region string, scope OnDemandSeriesScope, lookbackDays int) ([]DailyCost, error) {
+	code, err := curProductCode(ceService)
+	if err != nil {
+		return nil, fmt.Errorf("GetOnDemandSeries: %w", err)
+	}
+	from, to := curWindow(lookbackDays)
+	hours, err := c.usageReader.PoolHours(ctx, cur.Query{ProductCodes: []string{code}, Regions: []string{region}, From: from, To: to})
+	if err != nil {
+		return nil, fmt.Errorf("GetOnDemandSeries: reading CUR pool hours: %w", err)
+	}
+	byDate := make(map[string]float64)
+	for _, h := range hours {
+		if (scope.InstanceType != "" && h.ResourceType != scope.InstanceType) ||
+			(scope.LinkedAccount != "" && h.AccountID != scope.LinkedAccount) {
+			continue
+		}
+		byDate[h.Hour.UTC().Format(ceDateLayout)] += h.OnDemandCost / 24.0
+	}
+	series, err := buildDailySeries(byDate, ceService, region, lookbackDays)
+	if err != nil {
+		return nil, fmt.Errorf("GetOnDemandSeries (CUR): %w", err)
+	}
+	return series, nil
+}
+
+// curRIUtilization is getRIUtilization over CUR commitment hours: per
+// reservation, the hours it covered against the hours it covered plus the
+// hours its RIFee lines reported unused. ReservedInstanceID is the last
+// segment of the reservation ARN, which is the ID Describe*Reserved* and
+// CE's SUBSCRIPTION_ID report.
+func (c *Client) curRIUtilization(ctx context.Context, ceService string, lookbackDays int, region string) ([]RIUtilization, error) {
+	if lookbackDays <= 0 {
+		lookbackDays = 30
+	}
+	code, err := curProductCode(ceService)
+	if err != nil {
+		return nil, fmt.Errorf("utilization: %w", err)
+	}
+	q := cur.Query{ProductCodes: []string{code}}
+	q.From, q.To = curWindow(lookbackDays)
+	if region != "" {
+		q.Regions = []string{region}
+	}
+	hours, err := c.usageReader.CommitmentHours(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("utilization: reading CUR commitment hours: %w", err)
+	}
+
+	agg := make(map[string]*riAccumulator)
+	for _, h := range hours {
+		if h.CommitmentType != cur.CommitmentReservation {
+			continue
+		}
+		id := reservationID(h.CommitmentARN)
+		a, ok := agg[id]
+		if !ok {
+			a = &riAccumulator{}
+			agg[id] = a
+		}
+		a.totalActualHours += h.CoveredHours
+		a.unusedHours += h.UnusedHours
+		a.purchasedHours += h.CoveredHours + h.UnusedHours
+	}
+	out := buildUtilizations(agg)
+	sort.Slice(out, func(i, j int) bool { return out[i].ReservedInstanceID < out[j].ReservedInstanceID })
+	return out, nil
+}
+
+// reservationID returns the ID segment of a reservation ARN:
+// "arn:aws:ec2:us-east-1:123:reserved-instances/abc" yields "abc" and
+// "arn:aws:rds:us-east-1:123:ri:my-ri" yields "my-ri".
+func reservationID(arn string) string {
+	if i := strings.LastIndexAny(arn, "/:"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
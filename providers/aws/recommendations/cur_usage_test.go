@@ -0,0 +1,159 @@
+package recommendations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+)
+
+// fakeCURReader serves canned aggregates and records the queries.
+type fakeCURReader struct {
+	pools       []cur.PoolHour
+	commitments []cur.CommitmentHour
+	queries     []cur.Query
+}
+
+func (f *fakeCURReader) PoolHours(_ context.Context, q cur.Query) ([]cur.PoolHour, error) {
+	f.queries = append(f.queries, q)
+	return f.pools, nil
+}
+
+func (f *fakeCURReader) CommitmentHours(_ context.Context, q cur.Query) ([]cur.CommitmentHour, error) {
+	f.queries = append(f.queries, q)
+	return f.commitments, nil
+}
+
+func curPoolHour(product, instType, platform, deployment string, hour time.Time, od, reserved float64) cur.PoolHour {
+	return cur.PoolHour{
+		PoolKey: cur.PoolKey{
+			AccountID:        "111111111111",
+			ProductCode:      product,
+			Region:           "us-east-1",
+			ResourceType:     instType,
+			Platform:         platform,
+			DeploymentOption: deployment,
+		},
+		Hour:          hour,
+		UsageHours:    od + reserved,
+		OnDemandHours: od,
+		ReservedHours: reserved,
+		OnDemandCost:  od * 0.1,
+	}
+}
+
+// newCURClient returns a client whose Cost Explorer mock fails every call,
+// so a test passing proves the CUR path answered.
+func newCURClient(r cur.Reader) *Client {
+	c := NewClientWithAPI(&mockNativeCE{}, "us-east-1")
+	c.SetUsageReader(r)
+	return c
+}
+
+func TestCURCoverageMap_SumsAccountsAndPlatforms(t *testing.T) {
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	r := &fakeCURReader{pools: []cur.PoolHour{
+		curPoolHour(cur.ProductEC2, "m5.large", "Linux", "", yesterday, 3, 1),
+		curPoolHour(cur.ProductEC2, "m5.large", "Windows", "", yesterday, 0, 4),
+		curPoolHour(cur.ProductRDS, "db.r5.large", "Aurora PostgreSQL", "Multi-AZ", yesterday, 2, 2),
+	}}
+
+	got, err := newCURClient(r).GetRICoverageMap(context.Background(), 1, []string{"us-east-1"})
+	require.NoError(t, err)
+
+	ec2 := got[poolKey("us-east-1", "m5.large")]
+	assert.InDelta(t, 62.5, ec2.Pct, 1e-9)
+	assert.InDelta(t, 8.0/24, ec2.AvgInstancesPerHour, 1e-9)
+	assert.InDelta(t, 0.1, ec2.OnDemandUSDPerHour, 1e-9)
+
+	rds, ok := got[rdsPoolKey("us-east-1", "db.r5.large", "aurora-postgresql", "multi-az")]
+	require.True(t, ok, "RDS pools are keyed by engine and deployment")
+	assert.InDelta(t, 50, rds.Pct, 1e-9)
+
+	require.Len(t, r.queries, 1)
+	q := r.queries[0]
+	assert.Contains(t, q.ProductCodes, cur.ProductRDS)
+	assert.Contains(t, q.ProductCodes, cur.ProductOpenSearch)
+	assert.Equal(t, 24*time.Hour, q.To.Sub(q.From))
+}
+
+func TestCUROnDemandSeries_DailyUSDPerHour(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	d1, d2 := today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)
+	r := &fakeCURReader{pools: []cur.PoolHour{
+		curPoolHour(cur.ProductElastiCache, "cache.r6g.large", "", "", d1, 24, 0),
+		curPoolHour(cur.ProductElastiCache, "cache.r6g.large", "", "", d1.Add(time.Hour), 24, 0),
+		curPoolHour(cur.ProductElastiCache, "cache.r6g.large", "", "", d2, 0, 5),
+	}}
+
+	series, err := newCURClient(r).GetServiceOnDemandSeries(context.Background(), common.ServiceElastiCache, "us-east-1", 2)
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.Equal(t, d1, series[0].Date)
+	assert.InDelta(t, 4.8/24, series[0].USDPerHour, 1e-9)
+	assert.Zero(t, series[1].USDPerHour, "a fully reserved day is a $0 row, not a gap")
+	assert.Equal(t, []string{cur.ProductElastiCache}, r.queries[0].ProductCodes)
+	assert.Equal(t, []string{"us-east-1"}, r.queries[0].Regions)
+}
+
+func TestCUROnDemandSeries_Scoped(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	d1 := today.AddDate(0, 0, -1)
+	other := curPoolHour(cur.ProductEC2, "m5.large", "Linux", "", d1, 48, 0)
+	other.AccountID = "222222222222"
+	r := &fakeCURReader{pools: []cur.PoolHour{
+		curPoolHour(cur.ProductEC2, "m5.large", "Linux", "", d1, 24, 0),
+		curPoolHour(cur.ProductEC2, "c5.xlarge", "Linux", "", d1, 96, 0),
+		other,
+	}}
+
+	scope := OnDemandSeriesScope{InstanceType: "m5.large", LinkedAccount: "111111111111"}
+	series, err := newCURClient(r).GetScopedOnDemandSeries(context.Background(), common.ServiceEC2, "us-east-1", scope, 1)
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.InDelta(t, 2.4/24, series[0].USDPerHour, 1e-9, "only the scoped instance type and account count")
+}
+
+func TestCUROnDemandSeries_EmptyFailsLoud(t *testing.T) {
+	_, err := newCURClient(&fakeCURReader{}).GetOnDemandSeries(context.Background(), "us-east-1", 7)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CUR")
+}
+
+func TestCURRIUtilization_FromCommitmentHours(t *testing.T) {
+	hour := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	key := cur.CommitmentKey{
+		CommitmentARN:  "arn:aws:ec2:us-east-1:111111111111:reserved-instances/ri-1",
+		CommitmentType: cur.CommitmentReservation,
+		ProductCode:    cur.ProductEC2,
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+	}
+	sp := key
+	sp.CommitmentARN = "arn:aws:savingsplans::111111111111:savingsplan/sp-1"
+	sp.CommitmentType = cur.CommitmentSavingsPlan
+	r := &fakeCURReader{commitments: []cur.CommitmentHour{
+		{CommitmentKey: key, Hour: hour, CoveredHours: 9},
+		{CommitmentKey: key, Hour: hour.Add(time.Hour), CoveredHours: 6, UnusedHours: 5},
+		{CommitmentKey: sp, Hour: hour, CoveredHours: 3},
+	}}
+
+	utils, err := newCURClient(r).GetRIUtilization(context.Background(), 7, "")
+	require.NoError(t, err)
+	require.Len(t, utils, 1, "Savings Plans are not reservations")
+	assert.Equal(t, "ri-1", utils[0].ReservedInstanceID)
+	assert.InDelta(t, 20, utils[0].PurchasedHours, 1e-9)
+	assert.InDelta(t, 75, utils[0].UtilizationPercent, 1e-9)
+	assert.Nil(t, r.queries[0].Regions, "an empty region must not filter")
+}
+
+func TestReservationID(t *testing.T) {
+	assert.Equal(t, "abc", reservationID("arn:aws:ec2:us-east-1:1:reserved-instances/abc"))
+	assert.Equal(t, "my-ri", reservationID("arn:aws:rds:us-east-1:1:ri:my-ri"))
+	assert.Equal(t, "plain", reservationID("plain"))
+}
@@ -0,0 +1,343 @@
+package recommendations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+	"github.com/LeanerCloud/CUDly/pkg/logging"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
+)
+
+// OfferingPricer is the slice of a regional service client the native
+// source needs: the RI offering price for a probe recommendation.
+type OfferingPricer interface {
+	GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error)
+}
+
+// NativeSource builds pkg/recommender inputs for EC2 RI pools from the data
+// CUDly already collects instead of GetReservationPurchaseRecommendation.
+// Offering prices come from the EC2 client's GetOfferingDetails, and pools
+// are priced as shared-tenancy regional RIs. Pool demand comes from one of:
+//
+//   - CUR, when the client reads usage from it (Client.SetUsageReader): each
+//     (instance type, operating system) pool's own hourly usage, so the
+//     engine's percentile is a percentile of that pool's demand;
+//   - Cost Explorer otherwise: pools, existing coverage and on-demand rates
+//     from GetReservationCoverage grouped by INSTANCE_TYPE and PLATFORM, and
+//     a spend-shaped approximation of each pool's demand (see
+//     spendShapedUsage), because CE has no per-pool usage series at this
+//     granularity without hourly opt-in.
+type NativeSource struct {
+	client *Client
+	pricer func(ctx context.Context, region string) (OfferingPricer, error)
+	now    func() time.Time
+}
+
+// NewNativeSource wires a NativeSource. pricer returns the EC2 offering
+// client for a region.
+func NewNativeSource(client *Client, pricer func(ctx context.Context, region string) (OfferingPricer, error)) (*NativeSource, error) {
+	if client == nil {
+		return nil, fmt.Errorf("NewNativeSource: client must not be nil")
+	}
+	if pricer == nil {
+		return nil, fmt.Errorf("NewNativeSource: pricer must not be nil")
+	}
+	return &NativeSource{client: client, pricer: pricer, now: time.Now}, nil
+}
+
+// Recommend runs the engine over every EC2 pool in regions. Coverage and
+// pricing API errors are returned; a region without an on-demand series and
+// pools the engine declines are reported in Result.Skipped.
+func (n *NativeSource) Recommend(ctx context.Context, cfg recommender.Config, regions []string) (recommender.Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return recommender.Result{}, err
+	}
+	var res recommender.Result
+	for _, region := range regions {
+		inputs, skipped, err := n.regionInputs(ctx, cfg, region)
+		if err != nil {
+			return recommender.Result{}, fmt.Errorf("native recommendations for %s: %w", region, err)
+		}
+		res.Skipped = append(res.Skipped, skipped...)
+		if len(inputs) == 0 {
+			continue
+		}
+		regionRes, err := recommender.Recommend(inputs, cfg, n.now())
+		if err != nil {
+			return recommender.Result{}, err
+		}
+		res.Recommendations = append(res.Recommendations, regionRes.Recommendations...)
+		res.Skipped = append(res.Skipped, regionRes.Skipped...)
+	}
+	return res, nil
+}
+
+// GetNativeRecommendations is Recommend without the skip report; skips are
+// logged.
+func (n *NativeSource) GetNativeRecommendations(ctx context.Context, cfg recommender.Config, regions []string) ([]common.Recommendation, error) {
+	res, err := n.Recommend(ctx, cfg, regions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range res.Skipped {
+		logging.Debugf("native recommendations: skipped %s: %s", s.Pool, s.Reason)
+	}
+	return res.Recommendations, nil
+}
+
+// nativePool is one (instance type, platform) pool of a region and the
+// demand the engine sizes it against.
+type nativePool struct {
+	instanceType string
+	platform     string
+	// coverage is the pool's Cost Explorer coverage row; unset for CUR.
+	coverage           PoolCoverage
+	usage              []recommender.UsagePoint
+	existing           float64
+	onDemandUSDPerHour float64
+}
+
+// regionInputs builds the engine inputs for one region.
+func (n *NativeSource) regionInputs(ctx context.Context, cfg recommender.Config, region string) ([]recommender.PoolInput, []recommender.SkippedPool, error) {
+	regionPools := n.ceRegionPools
+	if n.client.usageReader != nil {
+		regionPools = n.curRegionPools
+	}
+	pools, skipped, err := regionPools(ctx, cfg, region)
+	if err != nil || len(pools) == 0 {
+		return nil, skipped, err
+	}
+
+	pricer, err := n.pricer(ctx, region)
+	if err != nil {
+		return nil, nil, fmt.Errorf("offering client: %w", err)
+	}
+	inputs := make([]recommender.PoolInput, 0, len(pools))
+	for _, p := range pools {
+		pool := ec2Pool(region, p)
+		offering, err := pricer.GetOfferingDetails(ctx, probeRec(pool, cfg))
+		if err != nil {
+			return nil, nil, fmt.Errorf("offering for %s: %w", pool, err)
+		}
+		inputs = append(inputs, recommender.PoolInput{
+			Pool:                   pool,
+			Usage:                  p.usage,
+			ExistingUnits:          p.existing,
+			OnDemandUSDPerUnitHour: p.onDemandUSDPerHour,
+			Offering:               *offering,
+		})
+	}
+	return inputs, skipped, nil
+}
+
+// ceRegionPools builds one region's pools from Cost Explorer coverage, with
+// each pool's demand from spendShapedUsage.
+func (n *NativeSource) ceRegionPools(ctx context.Context, cfg recommender.Config, region string) ([]nativePool, []recommender.SkippedPool, error) {
+	pools, err := n.fetchEC2Pools(ctx, cfg.LookbackDays, region)
+	if err != nil || len(pools) == 0 {
+		return nil, nil, err
+	}
+
+	series, err := n.client.GetOnDemandSeries(ctx, region, cfg.LookbackDays)
+	if err != nil {
+		// GetOnDemandSeries errors on an empty or all-zero series. For the
+		// native source that means the region has no on-demand demand to shape
+		// pools with, so its pools are skipped rather than failing the run.
+		skipped := make([]recommender.SkippedPool, len(pools))
+		for i, p := range pools {
+			skipped[i] = recommender.SkippedPool{Pool: ec2Pool(region, p), Reason: fmt.Sprintf("no on-demand series: %v", err)}
+		}
+		return nil, skipped, nil
+	}
+	for i := range pools {
+		p := &pools[i]
+		p.existing = p.coverage.AvgInstancesPerHour * p.coverage.Pct / 100
+		p.usage = spendShapedUsage(p.existing, p.coverage.AvgInstancesPerHour-p.existing, series)
+		p.onDemandUSDPerHour = p.coverage.OnDemandUSDPerHour
+	}
+	return pools, nil, nil
+}
+
+// spendShapedUsage approximates a pool's daily demand when only Cost
+// Explorer is available: the pool's covered average plus its uncovered
+// average scaled by the region's daily on-demand spend relative to that
+// spend's mean, usage[d] = existing + uncovered * s[d]/mean(s).
+//
+// It is not the pool's usage. Every pool in the region gets the same shape,
+// so the engine's percentile picks the region's relative spend trough
+// applied to the pool's average, not a percentile of the pool's own demand;
+// a pool that is flat while others swing is under-sized, and one that
+// swings while the region is flat is sized to its average. Configure CUR
+// (Client.SetUsageReader) for real per-pool sizing.
+func spendShapedUsage(existing, uncovered float64, series []DailyCost) []recommender.UsagePoint {
+	var mean float64
+	for _, p := range series {
+		mean += p.USDPerHour
+	}
+	mean /= float64(len(series))
+	usage := make([]recommender.UsagePoint, len(series))
+	for i, pt := range series {
+		usage[i] = recommender.UsagePoint{Time: pt.Date, Units: existing + uncovered*pt.USDPerHour/mean}
+	}
+	return usage
+}
+
+// curRIProductDescriptions maps the CUR EC2 operating system
+// (product['operating_system']) onto the RI product description the
+// offering lookup takes. CUR pools do not separate pre-installed software,
+// so Windows and RHEL usage with SQL Server is priced as the base operating
+// system. A pool whose operating system is not listed is skipped.
+var curRIProductDescriptions = map[string]string{
+	"Linux":                            "Linux/UNIX",
+	"Windows":                          "Windows",
+	"RHEL":                             "Red Hat Enterprise Linux",
+	"Red Hat Enterprise Linux with HA": "Red Hat Enterprise Linux with HA",
+	"SUSE":                             "SUSE Linux",
+}
+
+// curRegionPools builds one region's pools from CUR pool hours: each
+// (instance type, operating system) pool's usage in every hour of the
+// lookback, summed across accounts, with the hours it did not run as zero.
+// Existing coverage is the pool's average reserved hours per hour and the
+// on-demand rate its on-demand cost per on-demand hour, as in
+// GetRICoverageMap's CUR path.
+func (n *NativeSource) curRegionPools(ctx context.Context, cfg recommender.Config, region string) ([]nativePool, []recommender.SkippedPool, error) {
+	from, to := curWindow(cfg.LookbackDays)
+	hours, err := n.client.usageReader.PoolHours(ctx, cur.Query{
+		ProductCodes: []string{cur.ProductEC2}, Regions: []string{region}, From: from, To: to,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("EC2 pool hours from CUR: %w", err)
+	}
+
+	type poolKey struct{ instanceType, os string }
+	type acc struct {
+		usage                   []float64
+		reserved, odHours, cost float64
+	}
+	windowHours := int(to.Sub(from) / time.Hour)
+	byPool := make(map[poolKey]*acc)
+	for _, h := range hours {
+		i := int(h.Hour.Sub(from) / time.Hour)
+		if i < 0 || i >= windowHours || h.ResourceType == "" {
+			continue
+		}
+		k := poolKey{h.ResourceType, h.Platform}
+		a, ok := byPool[k]
+		if !ok {
+			a = &acc{usage: make([]float64, windowHours)}
+			byPool[k] = a
+		}
+		a.usage[i] += h.UsageHours
+		a.reserved += h.ReservedHours
+		a.odHours += h.OnDemandHours
+		a.cost += h.OnDemandCost
+	}
+
+	keys := make([]poolKey, 0, len(byPool))
+	for k := range byPool {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].instanceType != keys[j].instanceType {
+			return keys[i].instanceType < keys[j].instanceType
+		}
+		return keys[i].os < keys[j].os
+	})
+
+	var pools []nativePool
+	var skipped []recommender.SkippedPool
+	for _, k := range keys {
+		a := byPool[k]
+		p := nativePool{instanceType: k.instanceType, platform: curRIProductDescriptions[k.os]}
+		if p.platform == "" {
+			p.platform = k.os
+			skipped = append(skipped, recommender.SkippedPool{
+				Pool:   ec2Pool(region, p),
+				Reason: fmt.Sprintf("no RI product description for CUR operating system %q", k.os),
+			})
+			continue
+		}
+		p.existing = a.reserved / float64(windowHours)
+		if a.odHours > 0 {
+			p.onDemandUSDPerHour = a.cost / a.odHours
+		}
+		p.usage = make([]recommender.UsagePoint, windowHours)
+		for i, u := range a.usage {
+			p.usage[i] = recommender.UsagePoint{Time: from.Add(time.Duration(i) * time.Hour), Units: u}
+		}
+		pools = append(pools, p)
+	}
+	return pools, skipped, nil
+}
+
+// fetchEC2Pools returns the region's EC2 coverage rows grouped by instance
+// type and platform.
+func (n *NativeSource) fetchEC2Pools(ctx context.Context, lookbackDays int, region string) ([]nativePool, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -lookbackDays)
+	input := &costexplorer.GetReservationCoverageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(start.Format(ceDateLayout)),
+			End:   aws.String(end.Format(ceDateLayout)),
+		},
+		GroupBy: []types.GroupDefinition{
+			{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionInstanceType))},
+			{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionPlatform))},
+		},
+		Filter:  serviceRegionFilter(ec2ComputeService, region),
+		Metrics: []string{"Hour", "Cost"},
+	}
+	var pools []nativePool
+	err := n.client.fetchCoveragePaged(ctx, input, func(instType, platform string, cov PoolCoverage) {
+		if platform == "" || cov.AvgInstancesPerHour <= 0 {
+			return
+		}
+		pools = append(pools, nativePool{instanceType: instType, platform: platform, coverage: cov})
+	}, float64(lookbackDays*24))
+	if err != nil {
+		return nil, fmt.Errorf("EC2 coverage: %w", err)
+	}
+	return pools, nil
+}
+
+// ec2Pool describes a coverage row as an engine pool.
+func ec2Pool(region string, p nativePool) recommender.Pool {
+	return recommender.Pool{
+		Provider:       common.ProviderAWS,
+		Service:        common.ServiceEC2,
+		Region:         region,
+		ResourceType:   p.instanceType,
+		CommitmentType: common.CommitmentReservedInstance,
+		Details: &common.ComputeDetails{
+			InstanceType: p.instanceType,
+			Platform:     p.platform,
+			Tenancy:      "default",
+			Scope:        "Region",
+		},
+	}
+}
+
+// probeRec is the one-instance recommendation GetOfferingDetails resolves
+// the pool's offering from.
+func probeRec(pool recommender.Pool, cfg recommender.Config) common.Recommendation {
+	return common.Recommendation{
+		Provider:       pool.Provider,
+		Service:        pool.Service,
+		Region:         pool.Region,
+		ResourceType:   pool.ResourceType,
+		CommitmentType: pool.CommitmentType,
+		Count:          1,
+		Term:           cfg.Term,
+		PaymentOption:  cfg.PaymentOption,
+		Details:        pool.Details,
+	}
+}
@@ -0,0 +1,213 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
+	"github.com/LeanerCloud/CUDly/pkg/recommender"
+)
+
+// mockNativeCE serves both halves of the native source: the on-demand series
+// (GetCostAndUsage via the embedded mockOnDemandCE) and the platform-grouped
+// coverage rows.
+type mockNativeCE struct {
+	mockOnDemandCE
+	coverageOutput *costexplorer.GetReservationCoverageOutput
+	coverageInputs []*costexplorer.GetReservationCoverageInput
+}
+
+func (m *mockNativeCE) GetReservationCoverage(_ context.Context, params *costexplorer.GetReservationCoverageInput, _ ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	m.coverageInputs = append(m.coverageInputs, params)
+	return m.coverageOutput, nil
+}
+
+// fakePricer returns a fixed offering and records the probe recs.
+type fakePricer struct {
+	offering common.OfferingDetails
+	probes   []common.Recommendation
+	err      error
+}
+
+func (f *fakePricer) GetOfferingDetails(_ context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	f.probes = append(f.probes, rec)
+	if f.err != nil {
+		return nil, f.err
+	}
+	o := f.offering
+	return &o, nil
+}
+
+// coverageGroup builds one INSTANCE_TYPE x PLATFORM row over a 30-day window.
+func coverageGroup(instType, platform, pct string, runningHours, onDemandHours, onDemandCost string) types.ReservationCoverageGroup {
+	return types.ReservationCoverageGroup{
+		Attributes: map[string]string{"instanceType": instType, "platform": platform},
+		Coverage: &types.Coverage{
+			CoverageHours: &types.CoverageHours{
+				CoverageHoursPercentage: aws.String(pct),
+				TotalRunningHours:       aws.String(runningHours),
+				OnDemandHours:           aws.String(onDemandHours),
+			},
+			CoverageCost: &types.CoverageCost{OnDemandCost: aws.String(onDemandCost)},
+		},
+	}
+}
+
+// flatSeriesPage returns 30 days of constant on-demand spend ending yesterday.
+func flatSeriesPage() *costexplorer.GetCostAndUsageOutput {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	out := &costexplorer.GetCostAndUsageOutput{}
+	for d := 30; d >= 1; d-- {
+		out.ResultsByTime = append(out.ResultsByTime, dailyResult(end.AddDate(0, 0, -d).Format(ceDateLayout), 240))
+	}
+	return out
+}
+
+func nativeConfig() recommender.Config {
+	return recommender.Config{Term: "1yr", PaymentOption: "no-upfront", LookbackDays: 30, Percentile: 10}
+}
+
+func TestNativeSource_RecommendsUncoveredEC2Pools(t *testing.T) {
+	mock := &mockNativeCE{
+		mockOnDemandCE: mockOnDemandCE{pages: []*costexplorer.GetCostAndUsageOutput{flatSeriesPage()}},
+		coverageOutput: &costexplorer.GetReservationCoverageOutput{
+			CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+				// 10 instances on average, half reserved; 3600 on-demand hours
+				// at 0.10 USD/h.
+				coverageGroup("m5.large", "Linux/UNIX", "50", "7200", "3600", "360"),
+				// Fully reserved: no on-demand hours to displace.
+				coverageGroup("c5.xlarge", "Windows", "100", "720", "0", "0"),
+			}}},
+		},
+	}
+	pricer := &fakePricer{offering: common.OfferingDetails{RecurringCost: 0.06}}
+	src, err := NewNativeSource(NewClientWithAPI(mock, "us-east-1"), func(_ context.Context, region string) (OfferingPricer, error) {
+		assert.Equal(t, "us-east-1", region)
+		return pricer, nil
+	})
+	require.NoError(t, err)
+
+	res, err := src.Recommend(context.Background(), nativeConfig(), []string{"us-east-1"})
+	require.NoError(t, err)
+
+	require.Len(t, mock.coverageInputs, 1)
+	groupBy := mock.coverageInputs[0].GroupBy
+	require.Len(t, groupBy, 2)
+	assert.Equal(t, string(types.DimensionPlatform), aws.ToString(groupBy[1].Key))
+
+	require.Len(t, res.Recommendations, 1)
+	rec := res.Recommendations[0]
+	assert.Equal(t, common.RecommendationSourceNative, rec.SourceRecommendation)
+	assert.Equal(t, "m5.large", rec.ResourceType)
+	assert.Equal(t, 5, rec.Count)
+	assert.InDelta(t, 5*0.04*recommender.HoursPerMonth, rec.EstimatedSavings, 1e-6)
+	details, ok := rec.Details.(*common.ComputeDetails)
+	require.True(t, ok)
+	assert.Equal(t, "Linux/UNIX", details.Platform)
+
+	require.Len(t, res.Skipped, 1)
+	assert.Equal(t, "c5.xlarge", res.Skipped[0].Pool.ResourceType)
+
+	require.Len(t, pricer.probes, 2)
+	assert.Equal(t, 1, pricer.probes[0].Count)
+	assert.Equal(t, "1yr", pricer.probes[0].Term)
+}
+
+func TestNativeSource_NoSeriesSkipsRegion(t *testing.T) {
+	mock := &mockNativeCE{
+		mockOnDemandCE: mockOnDemandCE{pages: []*costexplorer.GetCostAndUsageOutput{{}}},
+		coverageOutput: &costexplorer.GetReservationCoverageOutput{
+			CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+				coverageGroup("m5.large", "Linux/UNIX", "100", "720", "0", "0"),
+			}}},
+		},
+	}
+	src, err := NewNativeSource(NewClientWithAPI(mock, "us-east-1"), func(context.Context, string) (OfferingPricer, error) {
+		t.Fatal("a region without an on-demand series must not be priced")
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	res, err := src.Recommend(context.Background(), nativeConfig(), []string{"us-east-1"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Recommendations)
+	require.Len(t, res.Skipped, 1)
+	assert.Contains(t, res.Skipped[0].Reason, "no on-demand series")
+}
+
+func TestNativeSource_PricingErrorFailsLoud(t *testing.T) {
+	mock := &mockNativeCE{
+		mockOnDemandCE: mockOnDemandCE{pages: []*costexplorer.GetCostAndUsageOutput{flatSeriesPage()}},
+		coverageOutput: &costexplorer.GetReservationCoverageOutput{
+			CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+				coverageGroup("m5.large", "Linux/UNIX", "50", "7200", "3600", "360"),
+			}}},
+		},
+	}
+	pricer := &fakePricer{err: errors.New("no offering")}
+	src, err := NewNativeSource(NewClientWithAPI(mock, "us-east-1"), func(context.Context, string) (OfferingPricer, error) {
+		return pricer, nil
+	})
+	require.NoError(t, err)
+
+	_, err = src.Recommend(context.Background(), nativeConfig(), []string{"us-east-1"})
+	require.ErrorContains(t, err, "no offering")
+}
+
+func TestNewNativeSource_RejectsNil(t *testing.T) {
+	_, err := NewNativeSource(nil, func(context.Context, string) (OfferingPricer, error) { return nil, nil })
+	assert.ErrorContains(t, err, "client must not be nil")
+	_, err = NewNativeSource(NewClientWithAPI(&mockNativeCE{}, "us-east-1"), nil)
+	assert.ErrorContains(t, err, "pricer must not be nil")
+}
+
+func TestNativeSource_CURSizesPoolsFromTheirOwnHours(t *testing.T) {
+	from, to := curWindow(30)
+	r := &fakeCURReader{}
+	for h := from; h.Before(to); h = h.Add(time.Hour) {
+		// m5.large runs 2 instances in the first half of the window and 6 in
+		// the second, across two accounts, with 1 reserved throughout.
+		od := 1.0
+		if h.Sub(from) >= to.Sub(from)/2 {
+			od = 5
+		}
+		a := curPoolHour(cur.ProductEC2, "m5.large", "Linux", "", h, od-1, 1)
+		b := curPoolHour(cur.ProductEC2, "m5.large", "Linux", "", h, 1, 0)
+		b.AccountID = "222222222222"
+		r.pools = append(r.pools, a, b)
+	}
+	r.pools = append(r.pools, curPoolHour(cur.ProductEC2, "c5.large", "Ubuntu Pro", "", from, 1, 0))
+	pricer := &fakePricer{offering: common.OfferingDetails{RecurringCost: 0.06}}
+	src, err := NewNativeSource(newCURClient(r), func(context.Context, string) (OfferingPricer, error) {
+		return pricer, nil
+	})
+	require.NoError(t, err)
+
+	res, err := src.Recommend(context.Background(), nativeConfig(), []string{"us-east-1"})
+	require.NoError(t, err)
+
+	require.Len(t, r.queries, 1)
+	assert.Equal(t, []string{cur.ProductEC2}, r.queries[0].ProductCodes)
+	assert.Equal(t, []string{"us-east-1"}, r.queries[0].Regions)
+
+	require.Len(t, res.Recommendations, 1)
+	rec := res.Recommendations[0]
+	assert.Equal(t, "m5.large", rec.ResourceType)
+	assert.Equal(t, 1, rec.Count, "the 10th percentile of 2 and 6 instances is 2, one of them already reserved")
+	details, ok := rec.Details.(*common.ComputeDetails)
+	require.True(t, ok)
+	assert.Equal(t, "Linux/UNIX", details.Platform)
+
+	require.Len(t, res.Skipped, 1)
+	assert.Contains(t, res.Skipped[0].Reason, "Ubuntu Pro")
+	require.Len(t, pricer.probes, 1, "a skipped pool is not priced")
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
 )
 
 // ec2ComputeService is the CE SERVICE dimension value for EC2 compute.
@@ -101,9 +103,93 @@ type DailyCost struct {
 //     spend for the whole lookback has nothing to ladder, so erroring is
 //     correct there too.
 func (c *Client) GetOnDemandSeries(ctx context.Context, region string, lookbackDays int) ([]DailyCost, error) {
+	return c.getOnDemandSeries(ctx, ec2ComputeService, region, OnDemandSeriesScope{}, lookbackDays)
+}
+
+// GetServiceOnDemandSeries is GetOnDemandSeries for one reserved-capacity
+// service: the SERVICE clause of the filter is the service's CE dimension
+// value instead of EC2 compute, everything else (window, metric, fail-loud
+// conditions) is identical. It backs the per-service RDS, ElastiCache and
+// OpenSearch ladders; any other service is an error rather than a silently
+// unfiltered query.
+func (c *Client) GetServiceOnDemandSeries(ctx context.Context, service common.ServiceType, region string, lookbackDays int) ([]DailyCost, error) {
+	ceService, err := reservedCapacityCEService(service)
+	if err != nil {
+		return nil, fmt.Errorf("GetServiceOnDemandSeries: %w", err)
+	}
+	return c.getOnDemandSeries(ctx, ceService, region, OnDemandSeriesScope{}, lookbackDays)
+}
+
+// OnDemandSeriesScope narrows an on-demand series below its service and
+// region. An empty field matches everything.
+type OnDemandSeriesScope struct {
+	// InstanceType is the CE INSTANCE_TYPE value: "m5.large",
+	// "db.r5.large", "cache.r6g.large" or "r6g.large.search".
+	InstanceType string
+	// LinkedAccount is the 12-digit AWS account ID (CE LINKED_ACCOUNT).
+	LinkedAccount string
+}
+
+// clauses returns the filter clauses for the set fields of s.
+func (s OnDemandSeriesScope) clauses() []types.Expression {
+	var out []types.Expression
+	if s.InstanceType != "" {
+		out = append(out, types.Expression{Dimensions: &types.DimensionValues{
+			Key:    types.DimensionInstanceType,
+			Values: []string{s.InstanceType},
+		}})
+	}
+	if s.LinkedAccount != "" {
+		out = append(out, types.Expression{Dimensions: &types.DimensionValues{
+			Key:    types.DimensionLinkedAccount,
+			Values: []string{s.LinkedAccount},
+		}})
+	}
+	return out
+}
+
+// GetScopedOnDemandSeries is the on-demand series of EC2 compute or one
+// reserved-capacity service (see GetServiceOnDemandSeries) narrowed to
+// scope, for scoring a single commitment against the usage it could have
+// covered rather than the whole region's. The window, metric and
+// fail-loud conditions are those of GetOnDemandSeries, so an instance type
+// or account with no on-demand spend over the window is an error.
+func (c *Client) GetScopedOnDemandSeries(ctx context.Context, service common.ServiceType, region string, scope OnDemandSeriesScope, lookbackDays int) ([]DailyCost, error) {
+	ceService := ec2ComputeService
+	if service != common.ServiceEC2 {
+		var err error
+		if ceService, err = reservedCapacityCEService(service); err != nil {
+			return nil, fmt.Errorf("GetScopedOnDemandSeries: %w", err)
+		}
+	}
+	return c.getOnDemandSeries(ctx, ceService, region, scope, lookbackDays)
+}
+
+// reservedCapacityCEService maps a reserved-capacity service to its CE
+// SERVICE dimension value. Unlike getServiceStringForCostExplorer it has no
+// pass-through default: an unmapped service would filter on a value CE does
+// not know and return an empty series.
+func reservedCapacityCEService(service common.ServiceType) (string, error) {
+	switch service {
+	case common.ServiceRDS, common.ServiceElastiCache, common.ServiceOpenSearch:
+		return getServiceStringForCostExplorer(service), nil
+	default:
+		return "", fmt.Errorf("service %q is not a reserved-capacity service (want %s, %s or %s)",
+			service, common.ServiceRDS, common.ServiceElastiCache, common.ServiceOpenSearch)
+	}
+}
+
+// getOnDemandSeries is the shared implementation of GetOnDemandSeries,
+// GetServiceOnDemandSeries and GetScopedOnDemandSeries; ceService is the
+// SERVICE dimension value. With a usage reader set the series comes from
+// CUR (curOnDemandSeries).
+func (c *Client) getOnDemandSeries(ctx context.Context, ceService, region string, scope OnDemandSeriesScope, lookbackDays int) ([]DailyCost, error) {
 	if err := validateOnDemandSeriesArgs(region, lookbackDays); err != nil {
 		return nil, err
 	}
+	if c.usageReader != nil {
+		return c.curOnDemandSeries(ctx, ceService, region, scope, lookbackDays)
+	}
 
 	end := time.Now().UTC().Truncate(24 * time.Hour) // midnight today (exclusive end for CE)
 	start := end.AddDate(0, 0, -lookbackDays)
@@ -115,7 +201,7 @@ func (c *Client) GetOnDemandSeries(ctx context.Context, region string, lookbackD
 		},
 		Granularity: types.GranularityDaily,
 		Metrics:     []string{onDemandMetric},
-		Filter:      onDemandSeriesFilter(region),
+		Filter:      onDemandSeriesFilter(ceService, region, scope),
 	}
 
 	byDate := make(map[string]float64)
@@ -149,7 +235,7 @@ func (c *Client) GetOnDemandSeries(ctx context.Context, region string, lookbackD
 		nextToken = out.NextPageToken
 	}
 
-	series, err := buildDailySeries(byDate, region, lookbackDays)
+	series, err := buildDailySeries(byDate, ceService, region, lookbackDays)
 	if err != nil {
 		return nil, fmt.Errorf("GetOnDemandSeries: %w", err)
 	}
@@ -190,23 +276,27 @@ func (c *Client) fetchOnDemandPage(ctx context.Context, input *costexplorer.GetC
 }
 
 // onDemandSeriesFilter builds the three-clause AND filter for
-// GetCostAndUsage: EC2 compute service, on-demand purchase type, and the
+// GetCostAndUsage: the given CE service, on-demand purchase type, and the
 // given region. All three clauses are required:
-//   - SERVICE scopes to EC2 compute (excludes RDS, ElastiCache, etc.).
+//   - SERVICE scopes to one service (EC2 compute excludes RDS, ElastiCache,
+//     etc., and vice versa).
 //   - PURCHASE_TYPE scopes to on-demand charges (excludes RI-covered,
 //     SP-covered, and Spot; those are accounted for via GetLayerStates).
 //   - REGION scopes to the ladder's configured region.
 //
+// scope appends an INSTANCE_TYPE and/or LINKED_ACCOUNT clause.
+//
 // Per feedback_verify_api_filter_contracts: GetCostAndUsage supports SERVICE,
-// PURCHASE_TYPE, and REGION as valid filter dimensions (CE API reference,
-// GetCostAndUsageInput.Filter). SAVINGS_PLANS_TYPE is NOT supported here
-// (that is a GetSavingsPlansCoverage/Utilization dimension only).
-func onDemandSeriesFilter(region string) *types.Expression {
+// PURCHASE_TYPE, REGION, INSTANCE_TYPE and LINKED_ACCOUNT as valid filter
+// dimensions (CE API reference, GetCostAndUsageInput.Filter).
+// SAVINGS_PLANS_TYPE is NOT supported here (that is a
+// GetSavingsPlansCoverage/Utilization dimension only).
+func onDemandSeriesFilter(ceService, region string, scope OnDemandSeriesScope) *types.Expression {
 	return &types.Expression{
-		And: []types.Expression{
+		And: append([]types.Expression{
 			{Dimensions: &types.DimensionValues{
 				Key:    types.DimensionService,
-				Values: []string{ec2ComputeService},
+				Values: []string{ceService},
 			}},
 			{Dimensions: &types.DimensionValues{
 				Key:    types.DimensionPurchaseType,
@@ -216,7 +306,7 @@ func onDemandSeriesFilter(region string) *types.Expression {
 				Key:    types.DimensionRegion,
 				Values: []string{region},
 			}},
-		},
+		}, scope.clauses()...),
 	}
 }
 
@@ -278,12 +368,12 @@ func accumulateDailyResults(byDate map[string]float64, out *costexplorer.GetCost
 //   - An all-zero series fails loud: a wrong filter or metric name makes CE
 //     return a complete series of $0 rows that would pass every downstream
 //     validation and let the engine size purchases from fabricated data. An
-//     account with genuinely zero on-demand EC2 spend over the whole window
-//     has nothing to ladder, so erroring is correct in that case too.
-func buildDailySeries(byDate map[string]float64, region string, lookbackDays int) ([]DailyCost, error) {
+//     account with genuinely zero on-demand spend for the service over the
+//     whole window has nothing to ladder, so erroring is correct there too.
+func buildDailySeries(byDate map[string]float64, ceService, region string, lookbackDays int) ([]DailyCost, error) {
 	if len(byDate) == 0 {
-		return nil, fmt.Errorf("CE returned no on-demand data for region %q over the past %d days (account may have no on-demand EC2 spend, or CE data not yet available)",
-			region, lookbackDays)
+		return nil, fmt.Errorf("CE returned no on-demand data for %s in region %q over the past %d days (account may have no on-demand spend for it, or CE data not yet available)",
+			ceService, region, lookbackDays)
 	}
 
 	dates := make([]string, 0, len(byDate))
@@ -305,8 +395,8 @@ func buildDailySeries(byDate map[string]float64, region string, lookbackDays int
 		series[i] = DailyCost{Date: day, USDPerHour: byDate[d]}
 	}
 	if allZero {
-		return nil, fmt.Errorf("CE returned an all-zero on-demand series for region %q over the past %d days; either the account has no on-demand EC2 spend to ladder, or the CE filter/metric vocabulary is wrong (a bad filter yields complete $0 rows, not an empty result)",
-			region, lookbackDays)
+		return nil, fmt.Errorf("CE returned an all-zero on-demand series for %s in region %q over the past %d days; either the account has no on-demand spend for it to ladder, or the CE filter/metric vocabulary is wrong (a bad filter yields complete $0 rows, not an empty result)",
+			ceService, region, lookbackDays)
 	}
 	return series, nil
 }
@@ -12,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
 )
 
 // mockOnDemandCE is a hermetic mock for GetCostAndUsage.  Only GetCostAndUsage
@@ -340,6 +342,55 @@ func TestGetOnDemandSeries_CorrectFilterParams(t *testing.T) {
 	assert.Equal(t, []string{"eu-west-1"}, dimVals[types.DimensionRegion])
 }
 
+// TestGetServiceOnDemandSeries_FiltersOnService verifies the per-service
+// series swaps only the SERVICE clause, and that an unmapped service fails
+// before any CE call instead of querying an unknown dimension value.
+func TestGetServiceOnDemandSeries_FiltersOnService(t *testing.T) {
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -7)
+	mock := &mockOnDemandCE{pages: generate30DayPage(start, 24.0)}
+	client := newOnDemandClient(mock)
+
+	series, err := client.GetServiceOnDemandSeries(context.Background(), common.ServiceRDS, "eu-west-1", 7)
+	require.NoError(t, err)
+	require.NotEmpty(t, series)
+	require.Len(t, mock.gotInputs, 1)
+	require.Len(t, mock.gotInputs[0].Filter.And, 3)
+	assert.Equal(t, []string{"Amazon Relational Database Service"}, mock.gotInputs[0].Filter.And[0].Dimensions.Values)
+	assert.Equal(t, []string{purchaseTypeOnDemand}, mock.gotInputs[0].Filter.And[1].Dimensions.Values)
+
+	_, err = client.GetServiceOnDemandSeries(context.Background(), common.ServiceEC2, "eu-west-1", 7)
+	require.Error(t, err)
+	assert.Len(t, mock.gotInputs, 1, "an unmapped service must not reach CE")
+}
+
+// TestGetScopedOnDemandSeries_AddsScopeClauses verifies the scope's
+// INSTANCE_TYPE and LINKED_ACCOUNT clauses follow the three base clauses,
+// and that an empty scope leaves the base filter alone.
+func TestGetScopedOnDemandSeries_AddsScopeClauses(t *testing.T) {
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -7)
+	mock := &mockOnDemandCE{pages: generate30DayPage(start, 24.0)}
+	client := newOnDemandClient(mock)
+
+	scope := OnDemandSeriesScope{InstanceType: "m5.large", LinkedAccount: "111111111111"}
+	_, err := client.GetScopedOnDemandSeries(context.Background(), common.ServiceEC2, "eu-west-1", scope, 7)
+	require.NoError(t, err)
+	_, err = client.GetScopedOnDemandSeries(context.Background(), common.ServiceRDS, "eu-west-1", OnDemandSeriesScope{}, 7)
+	require.NoError(t, err)
+
+	require.Len(t, mock.gotInputs, 2)
+	scoped := mock.gotInputs[0].Filter.And
+	require.Len(t, scoped, 5)
+	assert.Equal(t, []string{ec2ComputeService}, scoped[0].Dimensions.Values)
+	assert.Equal(t, types.DimensionInstanceType, scoped[3].Dimensions.Key)
+	assert.Equal(t, []string{"m5.large"}, scoped[3].Dimensions.Values)
+	assert.Equal(t, types.DimensionLinkedAccount, scoped[4].Dimensions.Key)
+	assert.Equal(t, []string{"111111111111"}, scoped[4].Dimensions.Values)
+
+	unscoped := mock.gotInputs[1].Filter.And
+	require.Len(t, unscoped, 3)
+	assert.Equal(t, []string{"Amazon Relational Database Service"}, unscoped[0].Dimensions.Values)
+}
+
 // TestGetOnDemandSeries_ContextCancelled verifies that a cancelled context is
 // propagated before the first CE call (ctx-cancel-is-terminal rule).
 func TestGetOnDemandSeries_ContextCancelled(t *testing.T) {
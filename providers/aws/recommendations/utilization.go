@@ -40,8 +40,33 @@ type riAccumulator struct {
 // ConvertibleRI layer) would then trigger real reshape/exchange
 // decisions off an unrelated RI's utilization (PR #1361). region
 // is optional: an empty string omits the REGION dimension, matching
-// callers that haven't resolved a specific region.
+// callers that haven't resolved a specific region. With a usage reader set
+// the figures come from CUR (curRIUtilization).
 func (c *Client) GetRIUtilization(ctx context.Context, lookbackDays int, region string) ([]RIUtilization, error) {
+	if c.usageReader != nil {
+		return c.curRIUtilization(ctx, ec2ComputeService, lookbackDays, region)
+	}
+	return c.getRIUtilization(ctx, lookbackDays, serviceUtilizationFilter(ec2ComputeService, region))
+}
+
+// GetServiceRIUtilization is GetRIUtilization for one reserved-capacity
+// service (RDS, ElastiCache, OpenSearch): per-reservation utilization scoped
+// to that service's RIs in region. The per-service ladders read it for their
+// layer's UtilizationPct.
+func (c *Client) GetServiceRIUtilization(ctx context.Context, service common.ServiceType, lookbackDays int, region string) ([]RIUtilization, error) {
+	ceService, err := reservedCapacityCEService(service)
+	if err != nil {
+		return nil, fmt.Errorf("GetServiceRIUtilization: %w", err)
+	}
+	if c.usageReader != nil {
+		return c.curRIUtilization(ctx, ceService, lookbackDays, region)
+	}
+	return c.getRIUtilization(ctx, lookbackDays, serviceUtilizationFilter(ceService, region))
+}
+
+// getRIUtilization is the shared implementation of GetRIUtilization and
+// GetServiceRIUtilization; filter scopes the query to one service.
+func (c *Client) getRIUtilization(ctx context.Context, lookbackDays int, filter *types.Expression) ([]RIUtilization, error) {
 	if lookbackDays <= 0 {
 		lookbackDays = 30
 	}
@@ -60,7 +85,7 @@ func (c *Client) GetRIUtilization(ctx context.Context, lookbackDays int, region
 				Key:  aws.String("SUBSCRIPTION_ID"),
 			},
 		},
-		Filter: ec2UtilizationFilter(region),
+		Filter: filter,
 	}
 
 	agg := make(map[string]*riAccumulator)
@@ -92,12 +117,12 @@ func (c *Client) GetRIUtilization(ctx context.Context, lookbackDays int, region
 	return buildUtilizations(agg), nil
 }
 
-// ec2UtilizationFilter builds the CE Filter expression scoping
-// GetReservationUtilization to EC2 RIs, optionally narrowed to one
-// region. Mirrors serviceRegionFilter in coverage.go so both CE
+// serviceUtilizationFilter builds the CE Filter expression scoping
+// GetReservationUtilization to one service's RIs, optionally narrowed to
+// one region. Mirrors serviceRegionFilter in coverage.go so both CE
 // query paths agree on how a (service, region) scope is expressed.
-func ec2UtilizationFilter(region string) *types.Expression {
-	svc := types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: []string{ec2ComputeService}}}
+func serviceUtilizationFilter(ceService, region string) *types.Expression {
+	svc := types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: []string{ceService}}}
 	if region == "" {
 		return &svc
 	}
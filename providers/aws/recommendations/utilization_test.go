@@ -9,6 +9,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
 )
 
 // mockUtilizationCE extends the test mock with a configurable
@@ -114,3 +116,22 @@ func TestGetRIUtilization_EmptyRegionOmitsRegionFilter(t *testing.T) {
 	assert.Equal(t, types.DimensionService, mock.lastFilter.Dimensions.Key)
 	assert.Equal(t, []string{ec2ComputeService}, mock.lastFilter.Dimensions.Values)
 }
+
+// TestGetServiceRIUtilization_ScopesToService pins the SERVICE clause of the
+// per-service variant the RDS/ElastiCache/OpenSearch ladders use, and that an
+// unmapped service fails before any CE call.
+func TestGetServiceRIUtilization_ScopesToService(t *testing.T) {
+	mock := &mockUtilizationCE{utilizationOutput: &costexplorer.GetReservationUtilizationOutput{}}
+	client := NewClientWithAPI(mock, "us-east-1")
+
+	_, err := client.GetServiceRIUtilization(context.Background(), common.ServiceElastiCache, 30, "eu-west-1")
+	require.NoError(t, err)
+	require.NotNil(t, mock.lastFilter)
+	require.Len(t, mock.lastFilter.And, 2)
+	assert.Equal(t, []string{"Amazon ElastiCache"}, mock.lastFilter.And[0].Dimensions.Values)
+	assert.Equal(t, []string{"eu-west-1"}, mock.lastFilter.And[1].Dimensions.Values)
+
+	_, err = client.GetServiceRIUtilization(context.Background(), common.ServiceRedshift, 30, "eu-west-1")
+	require.Error(t, err)
+	assert.Equal(t, 1, mock.calls, "an unmapped service must not reach CE")
+}
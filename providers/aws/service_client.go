@@ -9,9 +9,11 @@ import (
 	sptypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/cur"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
 
 	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/dynamodb"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/memorydb"
@@ -51,6 +53,11 @@ func NewMemoryDBClient(cfg aws.Config) provider.ServiceClient {
 	return memorydb.NewClient(cfg)
 }
 
+// NewDynamoDBClient creates a new DynamoDB reserved capacity service client
+func NewDynamoDBClient(cfg aws.Config) provider.ServiceClient {
+	return dynamodb.NewClient(cfg)
+}
+
 // NewSavingsPlansClient creates a Savings Plans service client scoped to one
 // AWS plan type. The four per-plan-type slugs (Compute, EC2Instance,
 // SageMaker, Database) each get their own client instance via the AWS
@@ -62,12 +69,24 @@ func NewSavingsPlansClient(cfg aws.Config, planType sptypes.SavingsPlanType) pro
 // RecommendationsClientAdapter adapts the recommendations client to the provider interface
 type RecommendationsClientAdapter struct {
 	client *recommendations.Client
+
+	// dynamoDB builds the regional DynamoDB client that answers
+	// ServiceDynamoDB / ServiceNoSQL requests: Cost Explorer has no reservation
+	// recommendations for DynamoDB reserved capacity.
+	dynamoDB func(region string) provider.ServiceClient
 }
 
 // NewRecommendationsClient creates a new recommendations client
 func NewRecommendationsClient(cfg aws.Config) provider.RecommendationsClient {
-	return &RecommendationsClientAdapter{
-		client: recommendations.NewClient(&cfg),
+	return NewRecommendationsClientDirect(cfg)
+}
+
+// regionalDynamoDB returns the adapter's DynamoDB client factory for cfg.
+func regionalDynamoDB(cfg aws.Config) func(region string) provider.ServiceClient {
+	return func(region string) provider.ServiceClient {
+		regionalCfg := cfg.Copy()
+		regionalCfg.Region = region
+		return NewDynamoDBClient(regionalCfg)
 	}
 }
 
@@ -76,6 +95,9 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	if params == nil {
 		return nil, fmt.Errorf("params cannot be nil")
 	}
+	if params.Service == common.ServiceDynamoDB || params.Service == common.ServiceNoSQL {
+		return r.getDynamoDBRecommendations(ctx, params)
+	}
 	recs, err := r.client.GetRecommendations(ctx, params)
 	if err != nil {
 		return nil, err
@@ -85,6 +107,23 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	return recs, nil
 }
 
+// getDynamoDBRecommendations answers a ServiceDynamoDB / ServiceNoSQL request from the
+// regional DynamoDB client. Its recommendations are derived per region, so
+// params.Region is required.
+func (r *RecommendationsClientAdapter) getDynamoDBRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error) {
+	if params.Region == "" {
+		return nil, fmt.Errorf("DynamoDB reserved capacity recommendations are regional: region must not be empty")
+	}
+	if r.dynamoDB == nil {
+		return nil, fmt.Errorf("DynamoDB recommendations are not configured")
+	}
+	recs, err := r.dynamoDB(params.Region).GetRecommendations(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return applyRecommendationFilters(recs, *params), nil
+}
+
 // applyRecommendationFilters applies account and region filters to recommendations.
 //
 // GetReservationPurchaseRecommendation and GetSavingsPlansPurchaseRecommendation
@@ -294,17 +333,50 @@ func (r *RecommendationsClientAdapter) GetRICoverageMap(ctx context.Context, loo
 	return r.client.GetRICoverageMap(ctx, lookbackDays, regions)
 }
 
+// ApplyExistingCoverage attaches to each rec its pool's current RI coverage
+// and average instances in use per hour, read with GetRICoverageMap over
+// the recs' regions (see recommendations.ApplyCoverageMapToRecommendations).
+// Recs whose pool has no coverage data are left unchanged.
+func (r *RecommendationsClientAdapter) ApplyExistingCoverage(ctx context.Context, recs []common.Recommendation, lookbackDays int) error {
+	seen := make(map[string]bool)
+	var regions []string
+	for i := range recs {
+		region := recs[i].Region
+		if region == "" || seen[region] || common.IsSavingsPlan(recs[i].Service) {
+			continue
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+	coverage, err := r.client.GetRICoverageMap(ctx, lookbackDays, regions)
+	if err != nil {
+		return err
+	}
+	recommendations.ApplyCoverageMapToRecommendations(recs, coverage)
+	return nil
+}
+
 // SetRecLookbackPeriod configures the LookbackPeriodInDays forwarded to
 // GetReservationPurchaseRecommendation. Valid values: "7d", "30d", "60d".
 func (r *RecommendationsClientAdapter) SetRecLookbackPeriod(period string) {
 	r.client.SetRecLookbackPeriod(period)
 }
 
+// SetUsageReader switches the adapter's coverage, on-demand series and
+// utilization reads to CUR; see recommendations.Client.SetUsageReader.
+func (r *RecommendationsClientAdapter) SetUsageReader(reader cur.Reader) {
+	r.client.SetUsageReader(reader)
+}
+
 // NewRecommendationsClientDirect creates a new recommendations client returning the concrete type
 // (needed for GetRIUtilization which is not part of the generic provider interface).
 func NewRecommendationsClientDirect(cfg aws.Config) *RecommendationsClientAdapter {
 	return &RecommendationsClientAdapter{
-		client: recommendations.NewClient(&cfg),
+		client:   recommendations.NewClient(&cfg),
+		dynamoDB: regionalDynamoDB(cfg),
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	sptypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -581,3 +582,53 @@ func TestApplyRecommendationFilters_RegionlessEC2InstanceSPNotExempt(t *testing.
 		assert.Empty(t, dropped, "a non-matching region must still be dropped")
 	})
 }
+
+// coverageCostExplorerClient reports m5.large EC2 coverage in every region
+// it is asked about, and records those regions.
+type coverageCostExplorerClient struct {
+	mockCostExplorerClient
+	regions []string
+}
+
+func (m *coverageCostExplorerClient) GetReservationCoverage(_ context.Context, params *costexplorer.GetReservationCoverageInput, _ ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	var service, region string
+	for _, e := range params.Filter.And {
+		switch e.Dimensions.Key {
+		case cetypes.DimensionService:
+			service = e.Dimensions.Values[0]
+		case cetypes.DimensionRegion:
+			region = e.Dimensions.Values[0]
+		}
+	}
+	if service != "Amazon Elastic Compute Cloud - Compute" {
+		return &costexplorer.GetReservationCoverageOutput{}, nil
+	}
+	m.regions = append(m.regions, region)
+	return &costexplorer.GetReservationCoverageOutput{
+		CoveragesByTime: []cetypes.CoverageByTime{{Groups: []cetypes.ReservationCoverageGroup{{
+			Attributes: map[string]string{"instanceType": "m5.large"},
+			Coverage: &cetypes.Coverage{CoverageHours: &cetypes.CoverageHours{
+				CoverageHoursPercentage: aws.String("75"),
+				TotalRunningHours:       aws.String("1680"), // 10 instances over 7 days
+			}},
+		}}}},
+	}, nil
+}
+
+func TestRecommendationsClientAdapter_ApplyExistingCoverage(t *testing.T) {
+	ce := &coverageCostExplorerClient{}
+	adapter := &RecommendationsClientAdapter{client: recommendations.NewClientWithAPI(ce, "us-east-1")}
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 4, AverageInstancesUsedPerHour: 5},
+		{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "c5.large", Count: 2},
+		{Service: common.ServiceSavingsPlansCompute, Region: "eu-west-1"},
+	}
+
+	require.NoError(t, adapter.ApplyExistingCoverage(context.Background(), recs, 7))
+
+	assert.Equal(t, []string{"us-east-1"}, ce.regions, "coverage is read once per RI region; Savings Plan regions are skipped")
+	assert.True(t, recs[0].ExistingCoverageKnown)
+	assert.InDelta(t, 75, recs[0].ExistingCoveragePct, 1e-9)
+	assert.InDelta(t, 10, recs[0].AverageInstancesUsedPerHour, 1e-9)
+	assert.False(t, recs[1].ExistingCoverageKnown, "a pool without coverage data stays unknown")
+}
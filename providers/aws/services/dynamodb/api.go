@@ -0,0 +1,224 @@
+package dynamodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// jsonTargetPrefix is the X-Amz-Target prefix of every DynamoDB JSON
+// protocol action.
+const jsonTargetPrefix = "DynamoDB_20120810."
+
+// maxResponseBytes caps how much of a response body is read; reserved
+// capacity pages are a few KB.
+const maxResponseBytes = 10 << 20
+
+// ReservedCapacityOffering is one entry of DescribeReservedCapacityOfferings.
+// Prices are per block of CapacityUnits units.
+type ReservedCapacityOffering struct {
+	ReservedCapacityOfferingID string  `json:"ReservedCapacityOfferingId"`
+	CapacityUnitType           string  `json:"CapacityUnitType"`
+	CapacityUnits              int64   `json:"CapacityUnits"`
+	Duration                   int64   `json:"Duration"` // seconds
+	FixedPrice                 float64 `json:"FixedPrice"`
+	UsagePrice                 float64 `json:"UsagePrice"` // hourly
+	CurrencyCode               string  `json:"CurrencyCode"`
+}
+
+// ReservedCapacityDescription is one purchased reservation. CapacityUnits
+// is the total across all blocks, FixedPrice the total upfront paid.
+type ReservedCapacityDescription struct {
+	ReservedCapacityID         string  `json:"ReservedCapacityId"`
+	ReservedCapacityOfferingID string  `json:"ReservedCapacityOfferingId"`
+	CapacityUnitType           string  `json:"CapacityUnitType"`
+	CapacityUnits              int64   `json:"CapacityUnits"`
+	Duration                   int64   `json:"Duration"` // seconds
+	FixedPrice                 float64 `json:"FixedPrice"`
+	UsagePrice                 float64 `json:"UsagePrice"`
+	StartTime                  float64 `json:"StartTime"` // epoch seconds, the JSON protocol's timestamp encoding
+	State                      string  `json:"State"`
+}
+
+// DescribeReservedCapacityInput filters DescribeReservedCapacity.
+type DescribeReservedCapacityInput struct {
+	ReservedCapacityID string `json:"ReservedCapacityId,omitempty"`
+	NextToken          string `json:"NextToken,omitempty"`
+}
+
+// DescribeReservedCapacityOutput is one page of reservations.
+type DescribeReservedCapacityOutput struct {
+	ReservedCapacities []ReservedCapacityDescription `json:"ReservedCapacities"`
+	NextToken          string                        `json:"NextToken"`
+}
+
+// DescribeReservedCapacityOfferingsInput filters
+// DescribeReservedCapacityOfferings.
+type DescribeReservedCapacityOfferingsInput struct {
+	ReservedCapacityOfferingID string `json:"ReservedCapacityOfferingId,omitempty"`
+	CapacityUnitType           string `json:"CapacityUnitType,omitempty"`
+	Duration                   int64  `json:"Duration,omitempty"`
+	NextToken                  string `json:"NextToken,omitempty"`
+}
+
+// DescribeReservedCapacityOfferingsOutput is one page of offerings.
+type DescribeReservedCapacityOfferingsOutput struct {
+	ReservedCapacityOfferings []ReservedCapacityOffering `json:"ReservedCapacityOfferings"`
+	NextToken                 string                     `json:"NextToken"`
+}
+
+// PurchaseReservedCapacityOfferingsInput buys Quantity blocks of one
+// offering. ClientToken makes a repeated request return the original
+// reservation instead of buying again.
+type PurchaseReservedCapacityOfferingsInput struct {
+	ReservedCapacityOfferingID string `json:"ReservedCapacityOfferingId"`
+	Quantity                   int64  `json:"Quantity"`
+	ClientToken                string `json:"ClientToken,omitempty"`
+}
+
+// PurchaseReservedCapacityOfferingsOutput describes the new reservation.
+type PurchaseReservedCapacityOfferingsOutput struct {
+	ReservedCapacity *ReservedCapacityDescription `json:"ReservedCapacity"`
+}
+
+// APIError is a non-2xx DynamoDB response. Code is the exception name
+// without its namespace, e.g. "ValidationException".
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("DynamoDB %s (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// jsonAPI implements ReservedCapacityAPI over the DynamoDB JSON protocol:
+// a SigV4-signed POST per action, named by the X-Amz-Target header.
+type jsonAPI struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	client      aws.HTTPClient
+}
+
+// newJSONAPI builds a jsonAPI from cfg. cfg.BaseEndpoint overrides the
+// regional endpoint.
+func newJSONAPI(cfg aws.Config) *jsonAPI {
+	endpoint := "https://dynamodb." + cfg.Region + ".amazonaws.com"
+	if cfg.BaseEndpoint != nil && *cfg.BaseEndpoint != "" {
+		endpoint = strings.TrimRight(*cfg.BaseEndpoint, "/")
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	return &jsonAPI{
+		endpoint:    endpoint,
+		region:      cfg.Region,
+		credentials: cfg.Credentials,
+		signer:      v4.NewSigner(),
+		client:      client,
+	}
+}
+
+func (a *jsonAPI) DescribeReservedCapacity(ctx context.Context, in *DescribeReservedCapacityInput) (*DescribeReservedCapacityOutput, error) {
+	out := &DescribeReservedCapacityOutput{}
+	if err := a.call(ctx, "DescribeReservedCapacity", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (a *jsonAPI) DescribeReservedCapacityOfferings(ctx context.Context, in *DescribeReservedCapacityOfferingsInput) (*DescribeReservedCapacityOfferingsOutput, error) {
+	out := &DescribeReservedCapacityOfferingsOutput{}
+	if err := a.call(ctx, "DescribeReservedCapacityOfferings", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (a *jsonAPI) PurchaseReservedCapacityOfferings(ctx context.Context, in *PurchaseReservedCapacityOfferingsInput) (*PurchaseReservedCapacityOfferingsOutput, error) {
+	out := &PurchaseReservedCapacityOfferingsOutput{}
+	if err := a.call(ctx, "PurchaseReservedCapacityOfferings", in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// call signs and sends one action and decodes its response into out.
+func (a *jsonAPI) call(ctx context.Context, action string, in, out any) error {
+	if a.credentials == nil {
+		return fmt.Errorf("%s: credentials must not be nil", action)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("%s: encoding request: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", jsonTargetPrefix+action)
+
+	creds, err := a.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: retrieving credentials: %w", action, err)
+	}
+	sum := sha256.Sum256(body)
+	if err := a.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "dynamodb", a.region, time.Now()); err != nil {
+		return fmt.Errorf("%s: signing request: %w", action, err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("%s: reading response: %w", action, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return decodeAPIError(resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", action, err)
+	}
+	return nil
+}
+
+// decodeAPIError parses a JSON protocol error body. The exception name
+// arrives as "__type", namespaced as "com.amazonaws.dynamodb.v20120810#Name".
+func decodeAPIError(status int, data []byte) error {
+	var body struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(data, &body)
+	code := body.Type
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	if code == "" {
+		code = http.StatusText(status)
+	}
+	msg := body.Message
+	if msg == "" {
+		msg = body.MessageUpper
+	}
+	return &APIError{StatusCode: status, Code: code, Message: msg}
+}
@@ -0,0 +1,72 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJSONAPI(t *testing.T, handler http.HandlerFunc) *jsonAPI {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return newJSONAPI(aws.Config{
+		Region:       "us-east-2",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+		}),
+	})
+}
+
+func TestJSONAPI_SignsAndDecodes(t *testing.T) {
+	api := newTestJSONAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "DynamoDB_20120810.PurchaseReservedCapacityOfferings", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "application/x-amz-json-1.0", r.Header.Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-2/dynamodb/aws4_request")
+
+		var in PurchaseReservedCapacityOfferingsInput
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, PurchaseReservedCapacityOfferingsInput{ReservedCapacityOfferingID: "rco-1", Quantity: 2, ClientToken: "tok"}, in)
+
+		_, _ = w.Write([]byte(`{"ReservedCapacity":{"ReservedCapacityId":"rc-1","CapacityUnits":200,"FixedPrice":60}}`))
+	})
+
+	out, err := api.PurchaseReservedCapacityOfferings(context.Background(), &PurchaseReservedCapacityOfferingsInput{
+		ReservedCapacityOfferingID: "rco-1", Quantity: 2, ClientToken: "tok",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, out.ReservedCapacity)
+	assert.Equal(t, "rc-1", out.ReservedCapacity.ReservedCapacityID)
+	assert.Equal(t, int64(200), out.ReservedCapacity.CapacityUnits)
+}
+
+func TestJSONAPI_DecodesErrors(t *testing.T) {
+	api := newTestJSONAPI(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"bad offering"}`))
+	})
+
+	_, err := api.DescribeReservedCapacityOfferings(context.Background(), &DescribeReservedCapacityOfferingsInput{})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "ValidationException", apiErr.Code)
+	assert.Equal(t, "bad offering", apiErr.Message)
+}
+
+func TestJSONAPI_RequiresCredentials(t *testing.T) {
+	api := newJSONAPI(aws.Config{Region: "us-east-2"})
+	_, err := api.DescribeReservedCapacity(context.Background(), &DescribeReservedCapacityInput{})
+	assert.ErrorContains(t, err, "credentials must not be nil")
+}
@@ -0,0 +1,608 @@
+// Package dynamodb provides the AWS DynamoDB reserved capacity client.
+//
+// DynamoDB reserved capacity is bought in blocks of 100 read or write
+// capacity units for a 1- or 3-year term and covers provisioned-mode
+// tables in one region. Every offering charges an upfront fee plus an
+// hourly rate, so partial-upfront is the only payment option.
+//
+// The reserved capacity actions (DescribeReservedCapacity,
+// DescribeReservedCapacityOfferings, PurchaseReservedCapacityOfferings)
+// are not modelled by the public SDKs, so ReservedCapacityAPI speaks the
+// DynamoDB JSON protocol directly over SigV4-signed HTTP. Cost Explorer
+// has no reservation recommendations for DynamoDB either, so
+// GetRecommendations derives them from the provisioned capacity billed at
+// on-demand rates (see GetRecommendations).
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/aws/internal/purchasecfg"
+)
+
+const (
+	// ResourceTypeRead and ResourceTypeWrite are the recommendation
+	// ResourceType values, equal to the API's CapacityUnitType.
+	ResourceTypeRead  = "ReadCapacityUnits"
+	ResourceTypeWrite = "WriteCapacityUnits"
+
+	// blockSize is the number of capacity units in one reserved block.
+	// Recommendation and commitment Count are in blocks.
+	blockSize = 100
+
+	// ceService is the Cost Explorer SERVICE dimension value for DynamoDB.
+	ceService = "Amazon DynamoDB"
+
+	// purchaseTypeOnDemand is the CE PURCHASE_TYPE value for usage billed
+	// at on-demand rates, i.e. not already covered by reserved capacity.
+	purchaseTypeOnDemand = "On Demand Instances"
+
+	hoursPerMonth = 730
+	ceDateLayout  = "2006-01-02"
+
+	// maxOfferingPages caps the DescribeReservedCapacityOfferings walk,
+	// mirroring the other AWS reservation clients (issue #688).
+	maxOfferingPages = 5
+
+	// maxCostPages caps the GetCostAndUsage walk; a daily series grouped by
+	// usage type fits in one or two pages.
+	maxCostPages = 20
+)
+
+// ReservedCapacityAPI defines the reserved capacity operations (enables
+// mocking).
+type ReservedCapacityAPI interface {
+	DescribeReservedCapacity(ctx context.Context, in *DescribeReservedCapacityInput) (*DescribeReservedCapacityOutput, error)
+	DescribeReservedCapacityOfferings(ctx context.Context, in *DescribeReservedCapacityOfferingsInput) (*DescribeReservedCapacityOfferingsOutput, error)
+	PurchaseReservedCapacityOfferings(ctx context.Context, in *PurchaseReservedCapacityOfferingsInput) (*PurchaseReservedCapacityOfferingsOutput, error)
+}
+
+// CostExplorerAPI is the Cost Explorer subset GetRecommendations reads
+// provisioned capacity usage from.
+type CostExplorerAPI interface {
+	GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error)
+}
+
+// Client handles AWS DynamoDB reserved capacity
+type Client struct {
+	client ReservedCapacityAPI
+	ce     CostExplorerAPI
+	region string
+}
+
+// NewClient creates a new DynamoDB client with purchase-path retry/timeout
+// settings. See purchasecfg for rationale.
+func NewClient(cfg aws.Config) *Client {
+	pcfg := purchasecfg.NewConfig(cfg)
+	// Cost Explorer is only served from us-east-1.
+	ceConfig := cfg.Copy()
+	ceConfig.Region = "us-east-1"
+	ceConfig.BaseEndpoint = aws.String("https://ce.us-east-1.amazonaws.com")
+	return &Client{
+		client: newJSONAPI(pcfg),
+		ce:     costexplorer.NewFromConfig(ceConfig),
+		region: cfg.Region,
+	}
+}
+
+// SetReservedCapacityAPI sets a custom reserved capacity API client (for testing)
+func (c *Client) SetReservedCapacityAPI(api ReservedCapacityAPI) {
+	c.client = api
+}
+
+// SetCostExplorerAPI sets a custom Cost Explorer client (for testing)
+func (c *Client) SetCostExplorerAPI(api CostExplorerAPI) {
+	c.ce = api
+}
+
+// GetServiceType returns the service type
+func (c *Client) GetServiceType() common.ServiceType {
+	return common.ServiceNoSQL
+}
+
+// GetRegion returns the region
+func (c *Client) GetRegion() string {
+	return c.region
+}
+
+// GetRecommendations recommends reserved capacity blocks for the region.
+//
+// For each capacity unit type it reads the provisioned capacity billed at
+// on-demand rates over the lookback period from Cost Explorer, takes the
+// lowest daily average as the steady baseline, and recommends as many
+// whole blocks as that baseline fills. Usage already covered by reserved
+// capacity is billed as reserved, so it is not counted again. The
+// on-demand rate is the period's cost divided by its unit-hours.
+func (c *Client) GetRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	term := params.Term
+	if term == "" {
+		term = "1yr"
+	}
+	duration, err := durationSeconds(term)
+	if err != nil {
+		return nil, err
+	}
+	days, err := lookbackDays(params.LookbackPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	baselines, err := c.onDemandBaselines(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]common.Recommendation, 0, 2)
+	for _, unitType := range []string{ResourceTypeRead, ResourceTypeWrite} {
+		b := baselines[unitType]
+		blocks := int(b.minUnits / blockSize)
+		if blocks == 0 || b.rate <= 0 {
+			continue
+		}
+		offering, err := c.findOffering(ctx, unitType, duration, "")
+		if err != nil {
+			return nil, err
+		}
+		rec := c.buildRecommendation(unitType, term, blocks, b, offering)
+		if rec.EstimatedSavings <= 0 {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// baseline is the on-demand provisioned capacity of one unit type.
+type baseline struct {
+	minUnits float64 // lowest daily average units
+	rate     float64 // on-demand cost per unit-hour
+}
+
+// onDemandBaselines reads the last days days of on-demand provisioned
+// capacity per unit type.
+func (c *Client) onDemandBaselines(ctx context.Context, days int) (map[string]baseline, error) {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(end.AddDate(0, 0, -days).Format(ceDateLayout)),
+			End:   aws.String(end.Format(ceDateLayout)),
+		},
+		Granularity: cetypes.GranularityDaily,
+		Metrics:     []string{"UsageQuantity", "UnblendedCost"},
+		Filter:      c.costFilter(),
+		GroupBy:     []cetypes.GroupDefinition{{Type: cetypes.GroupDefinitionTypeDimension, Key: aws.String(string(cetypes.DimensionUsageType))}},
+	}
+
+	totals := newUsageTotals()
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if page > maxCostPages {
+			return nil, fmt.Errorf("GetCostAndUsage: exceeded %d page cap for DynamoDB %s", maxCostPages, c.region)
+		}
+		out, err := c.ce.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DynamoDB usage: %w", err)
+		}
+		for _, day := range out.ResultsByTime {
+			totals.add(day)
+		}
+		if aws.ToString(out.NextPageToken) == "" {
+			break
+		}
+		input.NextPageToken = out.NextPageToken
+	}
+	return totals.baselines(), nil
+}
+
+// usageTotals accumulates provisioned capacity usage per unit type across
+// GetCostAndUsage pages.
+type usageTotals struct {
+	// Keyed by day: a day's groups can be split across pages.
+	dailyUnits map[string]map[string]float64
+	hours      map[string]float64
+	cost       map[string]float64
+}
+
+func newUsageTotals() *usageTotals {
+	return &usageTotals{
+		dailyUnits: make(map[string]map[string]float64),
+		hours:      make(map[string]float64),
+		cost:       make(map[string]float64),
+	}
+}
+
+func (t *usageTotals) add(day cetypes.ResultByTime) {
+	key := ""
+	if day.TimePeriod != nil {
+		key = aws.ToString(day.TimePeriod.Start)
+	}
+	units, ok := t.dailyUnits[key]
+	if !ok {
+		units = make(map[string]float64)
+		t.dailyUnits[key] = units
+	}
+	for _, g := range day.Groups {
+		if len(g.Keys) == 0 {
+			continue
+		}
+		unitType, ok := unitTypeForUsageType(g.Keys[0])
+		if !ok {
+			continue
+		}
+		q := metricAmount(g.Metrics, "UsageQuantity")
+		units[unitType] += q / 24
+		t.hours[unitType] += q
+		t.cost[unitType] += metricAmount(g.Metrics, "UnblendedCost")
+	}
+}
+
+// baselines returns each unit type's lowest daily average units and its
+// average on-demand rate.
+func (t *usageTotals) baselines() map[string]baseline {
+	baselines := make(map[string]baseline)
+	if len(t.dailyUnits) == 0 {
+		return baselines
+	}
+	for _, unitType := range []string{ResourceTypeRead, ResourceTypeWrite} {
+		if t.hours[unitType] <= 0 {
+			continue
+		}
+		minUnits := math.Inf(1)
+		for _, units := range t.dailyUnits {
+			minUnits = math.Min(minUnits, units[unitType])
+		}
+		baselines[unitType] = baseline{minUnits: minUnits, rate: t.cost[unitType] / t.hours[unitType]}
+	}
+	return baselines
+}
+
+// costFilter scopes GetCostAndUsage to DynamoDB usage in the client's
+// region billed at on-demand rates.
+func (c *Client) costFilter() *cetypes.Expression {
+	return &cetypes.Expression{And: []cetypes.Expression{
+		{Dimensions: &cetypes.DimensionValues{Key: cetypes.DimensionService, Values: []string{ceService}}},
+		{Dimensions: &cetypes.DimensionValues{Key: cetypes.DimensionPurchaseType, Values: []string{purchaseTypeOnDemand}}},
+		{Dimensions: &cetypes.DimensionValues{Key: cetypes.DimensionRegion, Values: []string{c.region}}},
+	}}
+}
+
+// unitTypeForUsageType maps a provisioned-capacity usage type such as
+// "USE2-ReadCapacityUnit-Hrs" to its capacity unit type. Only the plain
+// (optionally region-prefixed) types qualify: replicated write units
+// ("ReplWriteCapacityUnit-Hrs") and Standard-IA tables ("IA-...") are not
+// covered by reserved capacity.
+func unitTypeForUsageType(usageType string) (string, bool) {
+	for suffix, unitType := range map[string]string{
+		"ReadCapacityUnit-Hrs":  ResourceTypeRead,
+		"WriteCapacityUnit-Hrs": ResourceTypeWrite,
+	} {
+		prefix, ok := strings.CutSuffix(usageType, suffix)
+		if !ok {
+			continue
+		}
+		if prefix == "" {
+			return unitType, true
+		}
+		region, ok := strings.CutSuffix(prefix, "-")
+		if ok && region != "" && region != "IA" && !strings.Contains(region, "-") {
+			return unitType, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// metricAmount parses one Cost Explorer metric, treating a missing or
+// malformed amount as 0.
+func metricAmount(metrics map[string]cetypes.MetricValue, name string) float64 {
+	m, ok := metrics[name]
+	if !ok {
+		return 0
+	}
+	v, err := strconv.ParseFloat(aws.ToString(m.Amount), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// buildRecommendation prices blocks blocks of offering against the
+// on-demand baseline. Costs are monthly, EstimatedSavings is net of the
+// amortised upfront.
+func (c *Client) buildRecommendation(unitType, term string, blocks int, b baseline, o ReservedCapacityOffering) common.Recommendation {
+	months := 12
+	if term == "3yr" {
+		months = 36
+	}
+	n := float64(blocks)
+	upfront := o.FixedPrice * n
+	recurring := o.UsagePrice * hoursPerMonth * n
+	onDemand := b.rate * blockSize * hoursPerMonth * n
+	savings := onDemand - recurring - upfront/float64(months)
+
+	rec := common.Recommendation{
+		Provider:                    common.ProviderAWS,
+		Service:                     common.ServiceDynamoDB,
+		Region:                      c.region,
+		ResourceType:                unitType,
+		Count:                       blocks,
+		RecommendedCount:            blocks,
+		CommitmentType:              common.CommitmentReservedCapacity,
+		Term:                        term,
+		PaymentOption:               "partial-upfront",
+		OnDemandCost:                onDemand,
+		CommitmentCost:              upfront,
+		RecurringMonthlyCost:        &recurring,
+		EstimatedSavings:            savings,
+		AverageInstancesUsedPerHour: b.minUnits / blockSize,
+		Details: &common.NoSQLDetails{
+			Engine:          "dynamodb",
+			ThroughputUnits: blocks * blockSize,
+		},
+		Timestamp: time.Now(),
+	}
+	if onDemand > 0 {
+		rec.SavingsPercentage = savings / onDemand * 100
+	}
+	if monthly := onDemand - recurring; monthly > 0 {
+		rec.BreakEvenMonths = upfront / monthly
+	}
+	return rec
+}
+
+// GetExistingCommitments retrieves existing DynamoDB reserved capacity
+func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	commitments := make([]common.Commitment, 0)
+	input := &DescribeReservedCapacityInput{}
+
+	for {
+		response, err := c.client.DescribeReservedCapacity(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe reserved capacity: %w", err)
+		}
+
+		for _, rc := range response.ReservedCapacities {
+			if !isLiveState(rc.State) {
+				continue
+			}
+			start := time.Unix(int64(rc.StartTime), 0).UTC()
+			commitments = append(commitments, common.Commitment{
+				Provider:       common.ProviderAWS,
+				CommitmentID:   rc.ReservedCapacityID,
+				CommitmentType: common.CommitmentReservedCapacity,
+				Service:        common.ServiceDynamoDB,
+				Region:         c.region,
+				ResourceType:   rc.CapacityUnitType,
+				Count:          int(rc.CapacityUnits / blockSize),
+				State:          rc.State,
+				StartDate:      start,
+				EndDate:        start.AddDate(0, getTermMonthsFromDuration(rc.Duration), 0),
+			})
+		}
+
+		if response.NextToken == "" {
+			break
+		}
+		input.NextToken = response.NextToken
+	}
+
+	return commitments, nil
+}
+
+// isLiveState reports whether a reservation still covers usage (same
+// filter as the other AWS reservation clients).
+func isLiveState(state string) bool {
+	return state == "active" || state == "payment-pending"
+}
+
+// PurchaseCommitment purchases DynamoDB reserved capacity. rec.Count is
+// the number of 100-unit blocks.
+//
+// The action takes no tags and no caller-chosen reservation ID, so the
+// idempotency token (issue #641) is sent as ClientToken: a re-drive with
+// the same token returns the original reservation instead of buying again.
+func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	result := common.PurchaseResult{
+		Recommendation: rec,
+		DryRun:         false,
+		Success:        false,
+		Timestamp:      time.Now(),
+	}
+
+	if rec.Count <= 0 {
+		result.Error = fmt.Errorf("DynamoDB reserved capacity count must be positive, got %d", rec.Count)
+		return result, result.Error
+	}
+
+	offering, err := c.findOfferingForRec(ctx, rec, opts.ExecutionID)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to find offering: %w", err)
+		return result, result.Error
+	}
+
+	response, err := c.client.PurchaseReservedCapacityOfferings(ctx, &PurchaseReservedCapacityOfferingsInput{
+		ReservedCapacityOfferingID: offering.ReservedCapacityOfferingID,
+		Quantity:                   int64(rec.Count),
+		ClientToken:                opts.IdempotencyToken,
+	})
+	if err != nil {
+		result.Error = fmt.Errorf("failed to purchase DynamoDB reserved capacity: %w", err)
+		return result, result.Error
+	}
+	if response.ReservedCapacity == nil {
+		result.Error = fmt.Errorf("purchase response was empty")
+		return result, result.Error
+	}
+
+	result.Success = true
+	result.CommitmentID = response.ReservedCapacity.ReservedCapacityID
+	result.Cost = response.ReservedCapacity.FixedPrice
+	return result, nil
+}
+
+// convertPaymentOption rejects every payment option but partial-upfront,
+// the only shape reserved capacity is sold in.
+func convertPaymentOption(option string) error {
+	if option != "partial-upfront" {
+		return fmt.Errorf("unsupported DynamoDB payment option: %s (reserved capacity is partial-upfront only)", option)
+	}
+	return nil
+}
+
+// findOfferingForRec validates rec's unit type, term and payment option
+// and finds the matching offering.
+func (c *Client) findOfferingForRec(ctx context.Context, rec common.Recommendation, execID string) (ReservedCapacityOffering, error) {
+	if err := convertPaymentOption(rec.PaymentOption); err != nil {
+		return ReservedCapacityOffering{}, err
+	}
+	if rec.ResourceType != ResourceTypeRead && rec.ResourceType != ResourceTypeWrite {
+		return ReservedCapacityOffering{}, fmt.Errorf("unsupported DynamoDB capacity unit type %q: must be %s or %s",
+			rec.ResourceType, ResourceTypeRead, ResourceTypeWrite)
+	}
+	duration, err := durationSeconds(rec.Term)
+	if err != nil {
+		return ReservedCapacityOffering{}, err
+	}
+	return c.findOffering(ctx, rec.ResourceType, duration, execID)
+}
+
+// findOffering finds the reserved capacity offering for a unit type and
+// term. Both filters are set on the request; a page entry that does not
+// match them is an API filter mismatch and fails loud.
+//
+// execID is the purchase execution UUID for log correlation; pass "" when
+// calling outside of a purchase flow.
+func (c *Client) findOffering(ctx context.Context, unitType string, duration int64, execID string) (ReservedCapacityOffering, error) {
+	tag := purchasecfg.ResolveTag(execID)
+	t0 := time.Now()
+	log.Printf("purchase[%s]: DynamoDB findOffering starting (unitType=%s duration=%d)", tag, unitType, duration)
+
+	input := &DescribeReservedCapacityOfferingsInput{
+		CapacityUnitType: unitType,
+		Duration:         duration,
+	}
+	page := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return ReservedCapacityOffering{}, err
+		}
+		page++
+		if page > maxOfferingPages {
+			return ReservedCapacityOffering{}, fmt.Errorf("pagination cap reached after %d pages for DynamoDB %s (issue #688)",
+				maxOfferingPages, unitType)
+		}
+
+		result, err := c.client.DescribeReservedCapacityOfferings(ctx, input)
+		if err != nil {
+			return ReservedCapacityOffering{}, fmt.Errorf("failed to describe offerings: %w", err)
+		}
+		for _, o := range result.ReservedCapacityOfferings {
+			if o.CapacityUnitType != unitType || o.Duration != duration {
+				return ReservedCapacityOffering{}, fmt.Errorf("DynamoDB offering %s is %s/%ds, want %s/%ds -- API filter mismatch",
+					o.ReservedCapacityOfferingID, o.CapacityUnitType, o.Duration, unitType, duration)
+			}
+			if o.CapacityUnits != 0 && o.CapacityUnits != blockSize {
+				continue
+			}
+			log.Printf("purchase[%s]: DynamoDB findOffering found match on page %d after %s total", tag, page, time.Since(t0))
+			return o, nil
+		}
+
+		if result.NextToken == "" {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	return ReservedCapacityOffering{}, fmt.Errorf("no offerings found for DynamoDB %s with duration %ds after %d page(s)",
+		unitType, duration, page)
+}
+
+// durationSeconds converts a term to the offering Duration in seconds.
+func durationSeconds(term string) (int64, error) {
+	switch term {
+	case "1yr", "1", "12":
+		return 365 * 24 * 3600, nil
+	case "3yr", "3", "36":
+		return 3 * 365 * 24 * 3600, nil
+	default:
+		return 0, fmt.Errorf("unsupported DynamoDB reservation term %q: must be one of 1yr, 1, 12, 3yr, 3, 36", term)
+	}
+}
+
+// lookbackDays parses a "7d" / "30d" / "60d" lookback period; empty means
+// 30 days.
+func lookbackDays(period string) (int, error) {
+	if period == "" {
+		return 30, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+	if err != nil || days <= 0 {
+		return 0, fmt.Errorf("invalid lookback period %q", period)
+	}
+	return days, nil
+}
+
+// ValidateOffering checks if an offering exists without purchasing
+func (c *Client) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	_, err := c.findOfferingForRec(ctx, rec, "")
+	return err
+}
+
+// GetOfferingDetails retrieves offering details. Costs are per 100-unit
+// block.
+func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	o, err := c.findOfferingForRec(ctx, rec, "")
+	if err != nil {
+		return nil, err
+	}
+
+	hours := float64(o.Duration) / 3600
+	currency := o.CurrencyCode
+	if currency == "" {
+		currency = "USD"
+	}
+	details := &common.OfferingDetails{
+		OfferingID:    o.ReservedCapacityOfferingID,
+		ResourceType:  o.CapacityUnitType,
+		Term:          fmt.Sprintf("%d", o.Duration),
+		PaymentOption: "partial-upfront",
+		UpfrontCost:   o.FixedPrice,
+		RecurringCost: o.UsagePrice,
+		TotalCost:     o.FixedPrice + o.UsagePrice*hours,
+		Currency:      currency,
+	}
+	if hours > 0 {
+		details.EffectiveHourlyRate = details.TotalCost / hours
+	}
+	return details, nil
+}
+
+// GetValidResourceTypes returns the two capacity unit types
+func (c *Client) GetValidResourceTypes(_ context.Context) ([]string, error) {
+	return []string{ResourceTypeRead, ResourceTypeWrite}, nil
+}
+
+// getTermMonthsFromDuration converts duration in seconds to months
+func getTermMonthsFromDuration(duration int64) int {
+	if duration/2592000 >= 30 {
+		return 36
+	}
+	return 12
+}
@@ -0,0 +1,351 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// MockReservedCapacityAPI implements ReservedCapacityAPI for testing
+type MockReservedCapacityAPI struct {
+	mock.Mock
+}
+
+func (m *MockReservedCapacityAPI) DescribeReservedCapacity(ctx context.Context, in *DescribeReservedCapacityInput) (*DescribeReservedCapacityOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DescribeReservedCapacityOutput), args.Error(1)
+}
+
+func (m *MockReservedCapacityAPI) DescribeReservedCapacityOfferings(ctx context.Context, in *DescribeReservedCapacityOfferingsInput) (*DescribeReservedCapacityOfferingsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DescribeReservedCapacityOfferingsOutput), args.Error(1)
+}
+
+func (m *MockReservedCapacityAPI) PurchaseReservedCapacityOfferings(ctx context.Context, in *PurchaseReservedCapacityOfferingsInput) (*PurchaseReservedCapacityOfferingsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PurchaseReservedCapacityOfferingsOutput), args.Error(1)
+}
+
+// MockCostExplorer implements CostExplorerAPI for testing
+type MockCostExplorer struct {
+	mock.Mock
+}
+
+func (m *MockCostExplorer) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, _ ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*costexplorer.GetCostAndUsageOutput), args.Error(1)
+}
+
+const oneYear = 365 * 24 * 3600
+
+func newTestClient() (*Client, *MockReservedCapacityAPI, *MockCostExplorer) {
+	api := &MockReservedCapacityAPI{}
+	ce := &MockCostExplorer{}
+	c := &Client{region: "us-east-2"}
+	c.SetReservedCapacityAPI(api)
+	c.SetCostExplorerAPI(ce)
+	return c, api, ce
+}
+
+// usageDay is one CE day: usage type -> (unit-hours, cost).
+func usageDay(date string, usage map[string][2]string) cetypes.ResultByTime {
+	day := cetypes.ResultByTime{TimePeriod: &cetypes.DateInterval{Start: aws.String(date)}}
+	for usageType, v := range usage {
+		day.Groups = append(day.Groups, cetypes.Group{
+			Keys: []string{usageType},
+			Metrics: map[string]cetypes.MetricValue{
+				"UsageQuantity": {Amount: aws.String(v[0])},
+				"UnblendedCost": {Amount: aws.String(v[1])},
+			},
+		})
+	}
+	return day
+}
+
+func readOffering() ReservedCapacityOffering {
+	return ReservedCapacityOffering{
+		ReservedCapacityOfferingID: "rco-read-1yr",
+		CapacityUnitType:           ResourceTypeRead,
+		CapacityUnits:              100,
+		Duration:                   oneYear,
+		FixedPrice:                 30,
+		UsagePrice:                 0.0058,
+		CurrencyCode:               "USD",
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(aws.Config{Region: "us-east-1"})
+
+	assert.NotNil(t, client)
+	assert.NotNil(t, client.client)
+	assert.NotNil(t, client.ce)
+	assert.Equal(t, "us-east-1", client.GetRegion())
+	assert.Equal(t, common.ServiceNoSQL, client.GetServiceType())
+}
+
+func TestUnitTypeForUsageType(t *testing.T) {
+	tests := []struct {
+		usageType string
+		want      string
+		ok        bool
+	}{
+		{"ReadCapacityUnit-Hrs", ResourceTypeRead, true},
+		{"USE2-ReadCapacityUnit-Hrs", ResourceTypeRead, true},
+		{"EU-WriteCapacityUnit-Hrs", ResourceTypeWrite, true},
+		{"USE2-ReplWriteCapacityUnit-Hrs", "", false},
+		{"USE2-IA-ReadCapacityUnit-Hrs", "", false},
+		{"IA-WriteCapacityUnit-Hrs", "", false},
+		{"USE2-TimedStorage-ByteHrs", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.usageType, func(t *testing.T) {
+			got, ok := unitTypeForUsageType(tt.usageType)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_GetRecommendations(t *testing.T) {
+	c, api, ce := newTestClient()
+	ctx := context.Background()
+
+	// Reads: 600 units on day one, 450 on day two -> baseline 450 -> 4
+	// blocks. Writes stay under one block. Replicated writes are ignored.
+	ce.On("GetCostAndUsage", ctx, mock.MatchedBy(func(in *costexplorer.GetCostAndUsageInput) bool {
+		return in.Granularity == cetypes.GranularityDaily && in.NextPageToken == nil
+	})).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []cetypes.ResultByTime{
+			usageDay("2026-10-01", map[string][2]string{
+				"USE2-ReadCapacityUnit-Hrs":      {"14400", "1.872"},
+				"USE2-WriteCapacityUnit-Hrs":     {"1200", "0.78"},
+				"USE2-ReplWriteCapacityUnit-Hrs": {"96000", "90"},
+			}),
+		},
+		NextPageToken: aws.String("page-2"),
+	}, nil).Once()
+	ce.On("GetCostAndUsage", ctx, mock.MatchedBy(func(in *costexplorer.GetCostAndUsageInput) bool {
+		return aws.ToString(in.NextPageToken) == "page-2"
+	})).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []cetypes.ResultByTime{
+			usageDay("2026-10-02", map[string][2]string{
+				"USE2-ReadCapacityUnit-Hrs":  {"10800", "1.404"},
+				"USE2-WriteCapacityUnit-Hrs": {"1200", "0.78"},
+			}),
+		},
+	}, nil).Once()
+	api.On("DescribeReservedCapacityOfferings", ctx, &DescribeReservedCapacityOfferingsInput{
+		CapacityUnitType: ResourceTypeRead,
+		Duration:         oneYear,
+	}).Return(&DescribeReservedCapacityOfferingsOutput{
+		ReservedCapacityOfferings: []ReservedCapacityOffering{readOffering()},
+	}, nil)
+
+	recs, err := c.GetRecommendations(ctx, &common.RecommendationParams{Term: "1yr", LookbackPeriod: "7d"})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+
+	rec := recs[0]
+	assert.Equal(t, common.ServiceDynamoDB, rec.Service)
+	assert.Equal(t, common.CommitmentReservedCapacity, rec.CommitmentType)
+	assert.Equal(t, "us-east-2", rec.Region)
+	assert.Equal(t, ResourceTypeRead, rec.ResourceType)
+	assert.Equal(t, 4, rec.Count)
+	assert.Equal(t, "partial-upfront", rec.PaymentOption)
+	assert.InDelta(t, 4.5, rec.AverageInstancesUsedPerHour, 1e-9)
+	// On-demand rate 0.00013/unit-hour: 4 blocks * 100 * 730 * 0.00013.
+	assert.InDelta(t, 37.96, rec.OnDemandCost, 1e-6)
+	assert.InDelta(t, 120, rec.CommitmentCost, 1e-9)
+	require.NotNil(t, rec.RecurringMonthlyCost)
+	assert.InDelta(t, 16.936, *rec.RecurringMonthlyCost, 1e-6)
+	assert.InDelta(t, 37.96-16.936-10, rec.EstimatedSavings, 1e-6)
+	details, ok := rec.Details.(*common.NoSQLDetails)
+	require.True(t, ok)
+	assert.Equal(t, "dynamodb", details.Engine)
+	assert.Equal(t, 400, details.ThroughputUnits)
+	ce.AssertExpectations(t)
+}
+
+func TestClient_GetRecommendations_NoSavingsDropped(t *testing.T) {
+	c, api, ce := newTestClient()
+	ctx := context.Background()
+
+	// A rate far below the reserved price: no recommendation.
+	ce.On("GetCostAndUsage", ctx, mock.Anything).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []cetypes.ResultByTime{
+			usageDay("2026-10-01", map[string][2]string{"ReadCapacityUnit-Hrs": {"24000", "0.024"}}),
+		},
+	}, nil)
+	api.On("DescribeReservedCapacityOfferings", ctx, mock.Anything).Return(&DescribeReservedCapacityOfferingsOutput{
+		ReservedCapacityOfferings: []ReservedCapacityOffering{readOffering()},
+	}, nil)
+
+	recs, err := c.GetRecommendations(ctx, &common.RecommendationParams{Term: "1yr"})
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+}
+
+func TestClient_GetRecommendations_Errors(t *testing.T) {
+	c, _, ce := newTestClient()
+	ctx := context.Background()
+
+	_, err := c.GetRecommendations(ctx, nil)
+	assert.Error(t, err)
+
+	_, err = c.GetRecommendations(ctx, &common.RecommendationParams{Term: "5yr"})
+	assert.ErrorContains(t, err, "unsupported DynamoDB reservation term")
+
+	_, err = c.GetRecommendations(ctx, &common.RecommendationParams{LookbackPeriod: "weekly"})
+	assert.ErrorContains(t, err, "invalid lookback period")
+
+	ce.On("GetCostAndUsage", ctx, mock.Anything).Return(nil, errors.New("throttled"))
+	_, err = c.GetRecommendations(ctx, &common.RecommendationParams{})
+	assert.ErrorContains(t, err, "throttled")
+}
+
+func TestClient_GetExistingCommitments(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	api.On("DescribeReservedCapacity", ctx, &DescribeReservedCapacityInput{}).Return(&DescribeReservedCapacityOutput{
+		ReservedCapacities: []ReservedCapacityDescription{
+			{ReservedCapacityID: "rc-1", CapacityUnitType: ResourceTypeRead, CapacityUnits: 500, Duration: oneYear, StartTime: 1767225600, State: "active"},
+			{ReservedCapacityID: "rc-old", CapacityUnitType: ResourceTypeRead, CapacityUnits: 100, Duration: oneYear, State: "retired"},
+		},
+		NextToken: "next",
+	}, nil).Once()
+	api.On("DescribeReservedCapacity", ctx, &DescribeReservedCapacityInput{NextToken: "next"}).Return(&DescribeReservedCapacityOutput{
+		ReservedCapacities: []ReservedCapacityDescription{
+			{ReservedCapacityID: "rc-2", CapacityUnitType: ResourceTypeWrite, CapacityUnits: 200, Duration: 3 * oneYear, StartTime: 1767225600, State: "payment-pending"},
+		},
+	}, nil).Once()
+
+	commitments, err := c.GetExistingCommitments(ctx)
+	require.NoError(t, err)
+	require.Len(t, commitments, 2)
+	assert.Equal(t, "rc-1", commitments[0].CommitmentID)
+	assert.Equal(t, 5, commitments[0].Count)
+	assert.Equal(t, common.ServiceDynamoDB, commitments[0].Service)
+	assert.Equal(t, commitments[0].StartDate.AddDate(1, 0, 0), commitments[0].EndDate)
+	assert.Equal(t, ResourceTypeWrite, commitments[1].ResourceType)
+	assert.Equal(t, commitments[1].StartDate.AddDate(3, 0, 0), commitments[1].EndDate)
+}
+
+func TestClient_PurchaseCommitment(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	api.On("DescribeReservedCapacityOfferings", ctx, mock.Anything).Return(&DescribeReservedCapacityOfferingsOutput{
+		ReservedCapacityOfferings: []ReservedCapacityOffering{readOffering()},
+	}, nil)
+	api.On("PurchaseReservedCapacityOfferings", ctx, &PurchaseReservedCapacityOfferingsInput{
+		ReservedCapacityOfferingID: "rco-read-1yr",
+		Quantity:                   3,
+		ClientToken:                "token-1",
+	}).Return(&PurchaseReservedCapacityOfferingsOutput{
+		ReservedCapacity: &ReservedCapacityDescription{ReservedCapacityID: "rc-new", FixedPrice: 90},
+	}, nil)
+
+	rec := common.Recommendation{ResourceType: ResourceTypeRead, Count: 3, Term: "1yr", PaymentOption: "partial-upfront"}
+	result, err := c.PurchaseCommitment(ctx, rec, common.PurchaseOptions{IdempotencyToken: "token-1"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "rc-new", result.CommitmentID)
+	assert.InDelta(t, 90, result.Cost, 1e-9)
+}
+
+func TestClient_PurchaseCommitment_Rejects(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		rec  common.Recommendation
+		want string
+	}{
+		{"payment", common.Recommendation{ResourceType: ResourceTypeRead, Count: 1, Term: "1yr", PaymentOption: "all-upfront"}, "partial-upfront only"},
+		{"unit type", common.Recommendation{ResourceType: "db.r6g.large", Count: 1, Term: "1yr", PaymentOption: "partial-upfront"}, "unsupported DynamoDB capacity unit type"},
+		{"count", common.Recommendation{ResourceType: ResourceTypeRead, Count: 0, Term: "1yr", PaymentOption: "partial-upfront"}, "count must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.PurchaseCommitment(ctx, tt.rec, common.PurchaseOptions{})
+			assert.ErrorContains(t, err, tt.want)
+			assert.False(t, result.Success)
+		})
+	}
+	api.AssertNumberOfCalls(t, "PurchaseReservedCapacityOfferings", 0)
+}
+
+func TestFindOffering_FilterMismatchRejected(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	wrong := readOffering()
+	wrong.CapacityUnitType = ResourceTypeWrite
+	api.On("DescribeReservedCapacityOfferings", ctx, mock.Anything).Return(&DescribeReservedCapacityOfferingsOutput{
+		ReservedCapacityOfferings: []ReservedCapacityOffering{wrong},
+	}, nil)
+
+	_, err := c.findOffering(ctx, ResourceTypeRead, oneYear, "")
+	assert.ErrorContains(t, err, "API filter mismatch")
+}
+
+func TestFindOffering_PaginationCapFires(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	api.On("DescribeReservedCapacityOfferings", ctx, mock.Anything).Return(&DescribeReservedCapacityOfferingsOutput{
+		NextToken: "more",
+	}, nil)
+
+	_, err := c.findOffering(ctx, ResourceTypeRead, oneYear, "")
+	assert.ErrorContains(t, err, "pagination cap reached")
+	api.AssertNumberOfCalls(t, "DescribeReservedCapacityOfferings", maxOfferingPages)
+}
+
+func TestClient_GetOfferingDetails(t *testing.T) {
+	c, api, _ := newTestClient()
+	ctx := context.Background()
+
+	api.On("DescribeReservedCapacityOfferings", ctx, mock.Anything).Return(&DescribeReservedCapacityOfferingsOutput{
+		ReservedCapacityOfferings: []ReservedCapacityOffering{readOffering()},
+	}, nil)
+
+	details, err := c.GetOfferingDetails(ctx, common.Recommendation{ResourceType: ResourceTypeRead, Term: "1yr", PaymentOption: "partial-upfront"})
+	require.NoError(t, err)
+	assert.Equal(t, "rco-read-1yr", details.OfferingID)
+	assert.InDelta(t, 30, details.UpfrontCost, 1e-9)
+	assert.InDelta(t, 30+0.0058*8760, details.TotalCost, 1e-6)
+	assert.InDelta(t, details.TotalCost/8760, details.EffectiveHourlyRate, 1e-9)
+	assert.Equal(t, "USD", details.Currency)
+}
+
+func TestClient_GetValidResourceTypes(t *testing.T) {
+	c, _, _ := newTestClient()
+	types, err := c.GetValidResourceTypes(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{ResourceTypeRead, ResourceTypeWrite}, types)
+}
@@ -31,6 +31,8 @@ type EC2API interface {
 	CreateReservedInstancesListing(ctx context.Context, params *ec2.CreateReservedInstancesListingInput, optFns ...func(*ec2.Options)) (*ec2.CreateReservedInstancesListingOutput, error)
 	DescribeReservedInstancesListings(ctx context.Context, params *ec2.DescribeReservedInstancesListingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesListingsOutput, error)
 	CancelReservedInstancesListing(ctx context.Context, params *ec2.CancelReservedInstancesListingInput, optFns ...func(*ec2.Options)) (*ec2.CancelReservedInstancesListingOutput, error)
+	ModifyReservedInstances(ctx context.Context, params *ec2.ModifyReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.ModifyReservedInstancesOutput, error)
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
 }
 
 // Client handles AWS EC2 Reserved Instances
@@ -127,7 +129,7 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	// already succeeded, so short-circuit and return the existing RI rather
 	// than buying a second one.
 	if opts.IdempotencyToken != "" {
-		existingID, found, lookupErr := c.findRIByIdempotencyToken(ctx, opts.IdempotencyToken)
+		existing, found, lookupErr := c.findRIByIdempotencyToken(ctx, opts.IdempotencyToken)
 		if lookupErr != nil {
 			// A failed lookup must NOT fall through to a purchase: doing so
 			// would defeat the guard and risk a double-buy on a re-drive. Fail
@@ -137,26 +139,28 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 			return result, result.Error
 		}
 		if found {
+			existingID := aws.ToString(existing.ReservedInstancesId)
 			log.Printf("EC2 RI for idempotency token %s already exists (%s); skipping purchase (issue #636 re-drive)", common.MaskToken(opts.IdempotencyToken), existingID)
 			result.Success = true
 			result.CommitmentID = existingID
+			// The re-drive reports the RI's own end date, exactly as the
+			// purchase that created it did: a Marketplace RI ends before
+			// the full term.
+			if existing.End != nil {
+				end := aws.ToTime(existing.End)
+				result.EndDate = &end
+			}
 			return result, nil
 		}
 	}
 
-	// Find the offering ID
-	offeringID, err := c.findOfferingID(ctx, rec, opts.ExecutionID, opts.OfferingClass)
+	// Find the offering and create the purchase request
+	input, endDate, err := c.purchaseInput(ctx, rec, opts)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to find offering: %w", err)
+		result.Error = err
 		return result, result.Error
 	}
 
-	// Create the purchase request
-	input := &ec2.PurchaseReservedInstancesOfferingInput{
-		ReservedInstancesOfferingId: aws.String(offeringID),
-		InstanceCount:               aws.Int32(int32(rec.Count)), // #nosec G115 -- Count from CE recommendation; AWS RI purchase limits keep this far below math.MaxInt32
-	}
-
 	// Execute the purchase
 	response, err := c.client.PurchaseReservedInstancesOffering(ctx, input)
 	if err != nil {
@@ -172,6 +176,9 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
 	}
+	if !endDate.IsZero() {
+		result.EndDate = &endDate
+	}
 
 	// PurchaseReservedInstancesOfferingInput has no TagSpecifications — tag the
 	// commitment post-purchase. Failure is logged but does NOT fail the
@@ -193,13 +200,32 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	return result, nil
 }
 
+// purchaseInput builds the PurchaseReservedInstancesOffering request for rec:
+// the Reserved Instance Marketplace listing named in its ComputeDetails when
+// there is one, else the AWS-sold offering findOfferingID resolves. The
+// returned end date is zero for AWS-sold offerings, which run for the full
+// term.
+func (c *Client) purchaseInput(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (*ec2.PurchaseReservedInstancesOfferingInput, time.Time, error) {
+	if id := marketplaceOfferingID(rec); id != "" {
+		return c.marketplacePurchaseInput(ctx, rec, id)
+	}
+	offeringID, err := c.findOfferingID(ctx, rec, opts.ExecutionID, opts.OfferingClass)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to find offering: %w", err)
+	}
+	return &ec2.PurchaseReservedInstancesOfferingInput{
+		ReservedInstancesOfferingId: aws.String(offeringID),
+		InstanceCount:               aws.Int32(int32(rec.Count)), // #nosec G115 -- Count from CE recommendation; AWS RI purchase limits keep this far below math.MaxInt32
+	}, time.Time{}, nil
+}
+
 // findRIByIdempotencyToken looks for an active or payment-pending Reserved
 // Instance tagged with the given idempotency token (issue #636). It returns the
-// RI ID and true when exactly such an RI exists, so a re-driven purchase can
+// RI and true when exactly such an RI exists, so a re-driven purchase can
 // short-circuit instead of buying a second commitment. Retired/cancelled RIs are
 // excluded (they carry the same state filter as GetExistingCommitments) so a
 // returned or expired commitment does not suppress a legitimate fresh purchase.
-func (c *Client) findRIByIdempotencyToken(ctx context.Context, token string) (string, bool, error) {
+func (c *Client) findRIByIdempotencyToken(ctx context.Context, token string) (types.ReservedInstances, bool, error) {
 	input := &ec2.DescribeReservedInstancesInput{
 		Filters: []types.Filter{
 			{
@@ -215,14 +241,14 @@ func (c *Client) findRIByIdempotencyToken(ctx context.Context, token string) (st
 
 	response, err := c.client.DescribeReservedInstances(ctx, input)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to describe reserved instances for idempotency check: %w", err)
+		return types.ReservedInstances{}, false, fmt.Errorf("failed to describe reserved instances for idempotency check: %w", err)
 	}
 	for _, ri := range response.ReservedInstances {
 		if ri.ReservedInstancesId != nil {
-			return aws.ToString(ri.ReservedInstancesId), true, nil
+			return ri, true, nil
 		}
 	}
-	return "", false, nil
+	return types.ReservedInstances{}, false, nil
 }
 
 // tagReservedInstance applies the standard CUDly tag set (including the
@@ -571,8 +597,14 @@ func scanEC2OfferingPage(offerings []types.ReservedInstancesOffering, wantType t
 
 // ValidateOffering checks if an offering exists without purchasing.
 // Uses the convertible class (empty = convertible default) since the
-// validation path has no GlobalConfig context.
+// validation path has no GlobalConfig context. A rec naming a marketplace
+// offering is checked the way PurchaseCommitment would check it: still
+// listed, in the quantity and within the price approved.
 func (c *Client) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	if id := marketplaceOfferingID(rec); id != "" {
+		_, _, err := c.marketplacePurchaseInput(ctx, rec, id)
+		return err
+	}
 	_, err := c.findOfferingID(ctx, rec, "", "")
 	return err
 }
@@ -600,29 +632,41 @@ func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendati
 	}
 
 	offering := result.ReservedInstancesOfferings[0]
-
-	// Extract fixed price from pricing details
-	var fixedPrice float64
-	for _, pricing := range offering.PricingDetails {
-		if pricing.Price != nil {
-			fixedPrice = *pricing.Price
-			break
-		}
-	}
+	upfront, hourly := offeringPrices(offering)
 
 	details := &common.OfferingDetails{
 		OfferingID:    aws.ToString(offering.ReservedInstancesOfferingId),
 		ResourceType:  string(offering.InstanceType),
 		Term:          rec.Term,
 		PaymentOption: string(offering.OfferingType),
-		UpfrontCost:   fixedPrice,
-		RecurringCost: float64(aws.ToFloat32(offering.UsagePrice)),
+		UpfrontCost:   upfront,
+		RecurringCost: hourly,
 		Currency:      string(offering.CurrencyCode),
 	}
+	if seconds := aws.ToInt64(offering.Duration); seconds > 0 {
+		details.EffectiveHourlyRate = hourly + upfront/(float64(seconds)/3600)
+	}
 
 	return details, nil
 }
 
+// offeringPrices returns the per-instance upfront price and hourly charge of
+// an RI offering. The upfront price is FixedPrice; PricingDetails only lists
+// Marketplace seller tiers, which the lookup excludes. The hourly charge is
+// UsagePrice plus any hourly RecurringCharges: current offerings report
+// UsagePrice 0 and put the partial- and no-upfront hourly charge in
+// RecurringCharges, so reading UsagePrice alone prices them as free.
+func offeringPrices(o types.ReservedInstancesOffering) (upfront, hourly float64) {
+	upfront = float64(aws.ToFloat32(o.FixedPrice))
+	hourly = float64(aws.ToFloat32(o.UsagePrice))
+	for _, rc := range o.RecurringCharges {
+		if rc.Frequency == types.RecurringChargeFrequencyHourly {
+			hourly += aws.ToFloat64(rc.Amount)
+		}
+	}
+	return upfront, hourly
+}
+
 // GetValidResourceTypes returns valid EC2 instance types
 func (c *Client) GetValidResourceTypes(ctx context.Context) ([]string, error) {
 	instanceTypesMap := make(map[string]bool)
@@ -1125,3 +1169,167 @@ func (c *Client) CancelMarketplaceListing(ctx context.Context, listingID string)
 	}
 	return MarketplaceListingResult{ListingID: resolvedID, State: state}, nil
 }
+
+// ListModifiableReservedInstances returns every active RI in the region,
+// standard and convertible, in the shape the modification planner expects.
+func (c *Client) ListModifiableReservedInstances(ctx context.Context) ([]exchange.ModifiableRI, error) {
+	resp, err := c.client.DescribeReservedInstances(ctx, &ec2.DescribeReservedInstancesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{"active"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe reserved instances: %w", err)
+	}
+
+	result := make([]exchange.ModifiableRI, 0, len(resp.ReservedInstances))
+	for _, ri := range resp.ReservedInstances {
+		instanceType := string(ri.InstanceType)
+		result = append(result, exchange.ModifiableRI{
+			ID:                  aws.ToString(ri.ReservedInstancesId),
+			InstanceType:        instanceType,
+			InstanceCount:       aws.ToInt32(ri.InstanceCount),
+			AvailabilityZone:    aws.ToString(ri.AvailabilityZone),
+			Scope:               string(ri.Scope),
+			ProductDescription:  string(ri.ProductDescription),
+			InstanceTenancy:     string(ri.InstanceTenancy),
+			OfferingClass:       string(ri.OfferingClass),
+			End:                 aws.ToTime(ri.End),
+			NormalizationFactor: normalizationFactorForInstanceType(instanceType),
+		})
+	}
+	return result, nil
+}
+
+// maxDescribeInstancesPages bounds ListRunningInstanceUsage so a very large
+// fleet cannot pin a request handler: 50 pages of 1000 instances.
+const maxDescribeInstancesPages = 50
+
+// ListRunningInstanceUsage counts running on-demand instances in the region
+// by instance type, Availability Zone, platform and tenancy. Spot and
+// Capacity Block instances are skipped because Reserved Instances never
+// apply to them.
+func (c *Client) ListRunningInstanceUsage(ctx context.Context) ([]exchange.InstanceUsage, error) {
+	counts := make(map[exchange.InstanceUsage]float64)
+	var order []exchange.InstanceUsage
+	var nextToken *string
+
+	for page := 0; page < maxDescribeInstancesPages; page++ {
+		result, err := c.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("instance-state-name"),
+					Values: []string{string(types.InstanceStateNameRunning)},
+				},
+			},
+			MaxResults: aws.Int32(1000),
+			NextToken:  nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe EC2 instances: %w", err)
+		}
+
+		for _, reservation := range result.Reservations {
+			for i := range reservation.Instances {
+				key, ok := instanceUsageKey(&reservation.Instances[i])
+				if !ok {
+					continue
+				}
+				if _, seen := counts[key]; !seen {
+					order = append(order, key)
+				}
+				counts[key]++
+			}
+		}
+
+		if isLastEC2Page(result.NextToken) {
+			break
+		}
+		nextToken = result.NextToken
+	}
+
+	usage := make([]exchange.InstanceUsage, 0, len(order))
+	for _, key := range order {
+		key.Count = counts[key]
+		usage = append(usage, key)
+	}
+	return usage, nil
+}
+
+// instanceUsageKey returns the usage bucket of a running instance (Count
+// left zero), or false when no Reserved Instance could cover it.
+func instanceUsageKey(inst *types.Instance) (exchange.InstanceUsage, bool) {
+	if inst.InstanceLifecycle != "" || inst.Placement == nil {
+		return exchange.InstanceUsage{}, false
+	}
+	platform := aws.ToString(inst.PlatformDetails)
+	if platform == "" {
+		platform = "Linux/UNIX"
+	}
+	return exchange.InstanceUsage{
+		InstanceType:       string(inst.InstanceType),
+		AvailabilityZone:   aws.ToString(inst.Placement.AvailabilityZone),
+		ProductDescription: platform,
+		InstanceTenancy:    string(inst.Placement.Tenancy),
+	}, true
+}
+
+// ModifyReservedInstancesRequest carries the parameters for
+// ModifyReservedInstances.
+type ModifyReservedInstancesRequest struct {
+	// ReservedInstancesIDs are the RIs to modify. AWS requires them to share
+	// an instance family, platform, tenancy and end hour.
+	ReservedInstancesIDs []string
+	// Targets are the configurations the RIs become. Their normalized
+	// footprint must equal the sources'.
+	Targets []exchange.ModificationTarget
+	// ClientToken is a caller-supplied idempotency token so a retried
+	// approval cannot submit the same modification twice.
+	ClientToken string
+}
+
+// ModifyReservedInstances calls ec2:ModifyReservedInstances and returns the
+// ReservedInstancesModificationId. The modification is asynchronous: AWS
+// retires the source RIs and activates the targets once it is fulfilled.
+func (c *Client) ModifyReservedInstances(ctx context.Context, req ModifyReservedInstancesRequest) (string, error) {
+	if len(req.ReservedInstancesIDs) == 0 {
+		return "", fmt.Errorf("at least one reserved instances ID is required")
+	}
+	if len(req.Targets) == 0 {
+		return "", fmt.Errorf("at least one target configuration is required")
+	}
+
+	configs := make([]types.ReservedInstancesConfiguration, 0, len(req.Targets))
+	for _, t := range req.Targets {
+		cfg := types.ReservedInstancesConfiguration{
+			InstanceCount: aws.Int32(t.InstanceCount),
+			InstanceType:  types.InstanceType(t.InstanceType),
+			Scope:         types.Scope(t.Scope),
+		}
+		if t.AvailabilityZone != "" {
+			cfg.AvailabilityZone = aws.String(t.AvailabilityZone)
+		}
+		configs = append(configs, cfg)
+	}
+
+	input := &ec2.ModifyReservedInstancesInput{
+		ReservedInstancesIds: req.ReservedInstancesIDs,
+		TargetConfigurations: configs,
+	}
+	if req.ClientToken != "" {
+		input.ClientToken = aws.String(req.ClientToken)
+	}
+
+	out, err := c.client.ModifyReservedInstances(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("ModifyReservedInstances failed: %w", err)
+	}
+	modificationID := aws.ToString(out.ReservedInstancesModificationId)
+	if modificationID == "" {
+		return "", fmt.Errorf("ModifyReservedInstances returned an empty modification ID")
+	}
+	return modificationID, nil
+}
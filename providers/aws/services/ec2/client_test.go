@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/exchange"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -116,6 +117,22 @@ func (m *MockEC2Client) CancelReservedInstancesListing(ctx context.Context, para
 	return args.Get(0).(*ec2.CancelReservedInstancesListingOutput), args.Error(1)
 }
 
+func (m *MockEC2Client) ModifyReservedInstances(ctx context.Context, params *ec2.ModifyReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.ModifyReservedInstancesOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.ModifyReservedInstancesOutput), args.Error(1)
+}
+
+func (m *MockEC2Client) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeInstancesOutput), args.Error(1)
+}
+
 func TestNewClient(t *testing.T) {
 	t.Parallel()
 	cfg := aws.Config{
@@ -509,9 +526,29 @@ func TestClient_GetOfferingDetails(t *testing.T) {
 	assert.NotNil(t, details)
 	assert.Equal(t, "offering-123", details.OfferingID)
 	assert.Equal(t, "t3.micro", details.ResourceType)
+	assert.InDelta(t, 100.0, details.UpfrontCost, 1e-6)
+	assert.InDelta(t, 0.05, details.RecurringCost, 1e-6)
+	assert.InDelta(t, 0.05+100.0/(94608000.0/3600), details.EffectiveHourlyRate, 1e-6)
 	mockEC2.AssertExpectations(t)
 }
 
+// TestOfferingPrices_IncludesHourlyRecurringCharges pins that a no-upfront
+// offering, which AWS reports with UsagePrice 0 and the hourly charge in
+// RecurringCharges, is not priced as free.
+func TestOfferingPrices_IncludesHourlyRecurringCharges(t *testing.T) {
+	t.Parallel()
+	upfront, hourly := offeringPrices(types.ReservedInstancesOffering{
+		FixedPrice: aws.Float32(0),
+		UsagePrice: aws.Float32(0),
+		RecurringCharges: []types.RecurringCharge{
+			{Amount: aws.Float64(0.042), Frequency: types.RecurringChargeFrequencyHourly},
+		},
+		PricingDetails: []types.PricingDetail{{Price: aws.Float64(999)}},
+	})
+	assert.Zero(t, upfront, "Marketplace PricingDetails must not be read as the upfront price")
+	assert.InDelta(t, 0.042, hourly, 1e-9)
+}
+
 func TestClient_GetDurationValue(t *testing.T) {
 	t.Parallel()
 	client := &Client{}
@@ -970,6 +1007,39 @@ func TestPurchaseCommitment_IdempotencySkipLogMasked(t *testing.T) {
 	assert.NotEqual(t, token, masked, "masked token must not equal raw token")
 }
 
+// TestPurchaseCommitment_IdempotentRedriveReportsEndDate asserts that a
+// re-drive which finds the RI already bought reports that RI's end date,
+// as the original purchase did, rather than leaving it empty.
+func TestPurchaseCommitment_IdempotentRedriveReportsEndDate(t *testing.T) {
+	mockEC2 := &MockEC2Client{}
+	t.Cleanup(func() { mockEC2.AssertExpectations(t) })
+	client := &Client{client: mockEC2, region: "us-east-1"}
+	token := common.DeriveIdempotencyToken("exec-redrive-end", 0)
+	end := time.Date(2027, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockEC2.On("DescribeReservedInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeReservedInstancesOutput{
+		ReservedInstances: []types.ReservedInstances{
+			{ReservedInstancesId: aws.String("ri-marketplace-redrive"), End: aws.Time(end)},
+		},
+	}, nil).Once()
+
+	rec := common.Recommendation{
+		ResourceType:  "m5.large",
+		Count:         1,
+		PaymentOption: "all-upfront",
+		Term:          "1yr",
+		Details:       &common.ComputeDetails{Platform: "Linux/UNIX", Tenancy: "default", Scope: "Region"},
+	}
+	result, err := client.PurchaseCommitment(context.Background(), rec, common.PurchaseOptions{IdempotencyToken: token})
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "ri-marketplace-redrive", result.CommitmentID)
+	require.NotNil(t, result.EndDate)
+	assert.True(t, result.EndDate.Equal(end))
+	mockEC2.AssertNotCalled(t, "PurchaseReservedInstancesOffering", mock.Anything, mock.Anything)
+}
+
 // --- RI Marketplace listing tests (issue #292) ---
 
 func TestClient_CreateMarketplaceListing_HappyPathMultiCount(t *testing.T) {
@@ -1337,3 +1407,124 @@ func TestFindOfferingID_EmptyStringTokenEndsPagination(t *testing.T) {
 	}
 	mockEC2.AssertNumberOfCalls(t, "DescribeReservedInstancesOfferings", 1)
 }
+
+func TestClient_ListModifiableReservedInstances(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+	end := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mockEC2.On("DescribeReservedInstances", mock.Anything,
+		mock.MatchedBy(func(in *ec2.DescribeReservedInstancesInput) bool {
+			return len(in.Filters) == 1 && aws.ToString(in.Filters[0].Name) == "state"
+		})).
+		Return(&ec2.DescribeReservedInstancesOutput{
+			ReservedInstances: []types.ReservedInstances{{
+				ReservedInstancesId: aws.String("ri-1"),
+				InstanceType:        types.InstanceTypeM5Xlarge,
+				InstanceCount:       aws.Int32(2),
+				AvailabilityZone:    aws.String("us-east-1a"),
+				Scope:               types.ScopeAvailabilityZone,
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+				InstanceTenancy:     types.TenancyDefault,
+				OfferingClass:       types.OfferingClassTypeStandard,
+				End:                 aws.Time(end),
+			}},
+		}, nil)
+
+	ris, err := client.ListModifiableReservedInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, ris, 1)
+	assert.Equal(t, "ri-1", ris[0].ID)
+	assert.Equal(t, "Availability Zone", ris[0].Scope)
+	assert.Equal(t, "standard", ris[0].OfferingClass)
+	assert.Equal(t, int32(2), ris[0].InstanceCount)
+	assert.Equal(t, 8.0, ris[0].NormalizationFactor)
+	assert.Equal(t, end, ris[0].End)
+}
+
+func TestClient_ListRunningInstanceUsage(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+	placement := &types.Placement{AvailabilityZone: aws.String("us-east-1a"), Tenancy: types.TenancyDefault}
+
+	mockEC2.On("DescribeInstances", mock.Anything,
+		mock.MatchedBy(func(in *ec2.DescribeInstancesInput) bool { return in.NextToken == nil })).
+		Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{{Instances: []types.Instance{
+				{InstanceType: types.InstanceTypeM5Large, Placement: placement, PlatformDetails: aws.String("Linux/UNIX")},
+				{InstanceType: types.InstanceTypeM5Large, Placement: placement, InstanceLifecycle: types.InstanceLifecycleTypeSpot},
+			}}},
+			NextToken: aws.String("page-2"),
+		}, nil)
+	mockEC2.On("DescribeInstances", mock.Anything,
+		mock.MatchedBy(func(in *ec2.DescribeInstancesInput) bool { return aws.ToString(in.NextToken) == "page-2" })).
+		Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{{Instances: []types.Instance{
+				{InstanceType: types.InstanceTypeM5Large, Placement: placement},
+			}}},
+		}, nil)
+
+	usage, err := client.ListRunningInstanceUsage(context.Background())
+	require.NoError(t, err)
+	require.Len(t, usage, 1, "spot instances are skipped and same-bucket instances merge")
+	assert.Equal(t, "m5.large", usage[0].InstanceType)
+	assert.Equal(t, "Linux/UNIX", usage[0].ProductDescription)
+	assert.Equal(t, 2.0, usage[0].Count)
+	mockEC2.AssertExpectations(t)
+}
+
+func TestClient_ModifyReservedInstances(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+
+	mockEC2.On("ModifyReservedInstances", mock.Anything,
+		mock.MatchedBy(func(in *ec2.ModifyReservedInstancesInput) bool {
+			if len(in.TargetConfigurations) != 2 || aws.ToString(in.ClientToken) != "token-1" {
+				return false
+			}
+			zonal, regional := in.TargetConfigurations[0], in.TargetConfigurations[1]
+			return in.ReservedInstancesIds[0] == "ri-1" &&
+				zonal.InstanceType == types.InstanceTypeM5Large &&
+				aws.ToInt32(zonal.InstanceCount) == 2 &&
+				aws.ToString(zonal.AvailabilityZone) == "us-east-1b" &&
+				zonal.Scope == types.ScopeAvailabilityZone &&
+				regional.AvailabilityZone == nil &&
+				regional.Scope == types.ScopeRegional
+		})).
+		Return(&ec2.ModifyReservedInstancesOutput{ReservedInstancesModificationId: aws.String("rimod-1")}, nil)
+
+	id, err := client.ModifyReservedInstances(context.Background(), ModifyReservedInstancesRequest{
+		ReservedInstancesIDs: []string{"ri-1"},
+		Targets: []exchange.ModificationTarget{
+			{InstanceType: "m5.large", InstanceCount: 2, AvailabilityZone: "us-east-1b", Scope: exchange.ScopeAvailabilityZone},
+			{InstanceType: "m5.xlarge", InstanceCount: 1, Scope: exchange.ScopeRegion},
+		},
+		ClientToken: "token-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rimod-1", id)
+	mockEC2.AssertExpectations(t)
+}
+
+func TestClient_ModifyReservedInstances_Errors(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+	target := []exchange.ModificationTarget{{InstanceType: "m5.large", InstanceCount: 2, Scope: exchange.ScopeRegion}}
+
+	_, err := client.ModifyReservedInstances(context.Background(), ModifyReservedInstancesRequest{Targets: target})
+	assert.ErrorContains(t, err, "reserved instances ID is required")
+	_, err = client.ModifyReservedInstances(context.Background(), ModifyReservedInstancesRequest{ReservedInstancesIDs: []string{"ri-1"}})
+	assert.ErrorContains(t, err, "target configuration is required")
+
+	mockEC2.On("ModifyReservedInstances", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("boom")).Once()
+	_, err = client.ModifyReservedInstances(context.Background(), ModifyReservedInstancesRequest{ReservedInstancesIDs: []string{"ri-1"}, Targets: target})
+	assert.ErrorContains(t, err, "ModifyReservedInstances failed")
+
+	mockEC2.On("ModifyReservedInstances", mock.Anything, mock.Anything).Return(&ec2.ModifyReservedInstancesOutput{}, nil).Once()
+	_, err = client.ModifyReservedInstances(context.Background(), ModifyReservedInstancesRequest{ReservedInstancesIDs: []string{"ri-1"}, Targets: target})
+	assert.ErrorContains(t, err, "empty modification ID")
+}
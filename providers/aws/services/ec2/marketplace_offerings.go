@@ -0,0 +1,259 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// MarketplaceOffering is one third-party Reserved Instance listing on the
+// AWS Reserved Instance Marketplace. Duration is the seller's remaining term
+// in seconds, usually far shorter than a fresh 1-year RI.
+type MarketplaceOffering struct {
+	OfferingID         string  `json:"offering_id"`
+	InstanceType       string  `json:"instance_type"`
+	ProductDescription string  `json:"product_description"`
+	InstanceTenancy    string  `json:"instance_tenancy"`
+	Scope              string  `json:"scope"`
+	AvailabilityZone   string  `json:"availability_zone,omitempty"`
+	OfferingType       string  `json:"offering_type"`
+	Duration           int64   `json:"duration"`
+	FixedPrice         float64 `json:"fixed_price"`
+	HourlyPrice        float64 `json:"hourly_price"`
+	CurrencyCode       string  `json:"currency_code"`
+	// AvailableCount is the number of instances the seller is offering at
+	// this price.
+	AvailableCount int32 `json:"available_count"`
+	// EffectiveHourlyRate spreads FixedPrice over the remaining term and
+	// adds the hourly charge, so listings with different remaining terms
+	// compare directly against each other and against on-demand.
+	EffectiveHourlyRate float64 `json:"effective_hourly_rate"`
+}
+
+// SearchMarketplaceOfferingsParams narrows a marketplace search. InstanceType
+// is required; ProductDescription and Tenancy default to Linux/UNIX and
+// default tenancy. MinDuration / MaxDuration (seconds of remaining term)
+// are optional bounds; zero leaves that side open.
+type SearchMarketplaceOfferingsParams struct {
+	InstanceType       string
+	ProductDescription string
+	Tenancy            string
+	MinDuration        int64
+	MaxDuration        int64
+}
+
+// maxMarketplaceOfferingPages caps the pagination walk for
+// SearchMarketplaceOfferings, mirroring maxTargetOfferingPages.
+const maxMarketplaceOfferingPages = 10
+
+// SearchMarketplaceOfferings lists the Reserved Instance Marketplace
+// listings for an instance type, ranked by effective hourly rate (cheapest
+// first; equal rates prefer the shorter remaining term, the one that commits
+// less). AWS-sold offerings are excluded with the marketplace filter so only
+// third-party listings are returned.
+func (c *Client) SearchMarketplaceOfferings(ctx context.Context, params SearchMarketplaceOfferingsParams) ([]MarketplaceOffering, error) {
+	if params.InstanceType == "" {
+		return nil, fmt.Errorf("instance type is required to search the Reserved Instance Marketplace")
+	}
+	input := marketplaceSearchInput(params)
+
+	var out []MarketplaceOffering
+	for page := 1; page <= maxMarketplaceOfferingPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := c.client.DescribeReservedInstancesOfferings(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("describe marketplace offerings: %w", err)
+		}
+		out = appendMarketplaceOfferings(out, result.ReservedInstancesOfferings)
+		if isLastEC2Page(result.NextToken) {
+			break
+		}
+		input.NextToken = result.NextToken
+		if page == maxMarketplaceOfferingPages {
+			log.Printf("SearchMarketplaceOfferings: pagination cap (%d pages) reached, returning %d offerings",
+				maxMarketplaceOfferingPages, len(out))
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].EffectiveHourlyRate != out[j].EffectiveHourlyRate {
+			return out[i].EffectiveHourlyRate < out[j].EffectiveHourlyRate
+		}
+		return out[i].Duration < out[j].Duration
+	})
+	return out, nil
+}
+
+// marketplaceSearchInput builds the typed DescribeReservedInstancesOfferings
+// request for a marketplace search. OfferingClass is left unset: only
+// Standard RIs can be listed on the marketplace.
+func marketplaceSearchInput(p SearchMarketplaceOfferingsParams) *ec2.DescribeReservedInstancesOfferingsInput {
+	tenancy := canonicalizeEC2Tenancy(p.Tenancy)
+	if tenancy == "" {
+		tenancy = string(types.TenancyDefault)
+	}
+	productDesc := p.ProductDescription
+	if productDesc == "" {
+		productDesc = defaultEC2Platform
+	}
+	input := &ec2.DescribeReservedInstancesOfferingsInput{
+		InstanceType:       types.InstanceType(p.InstanceType),
+		ProductDescription: types.RIProductDescription(productDesc),
+		InstanceTenancy:    types.Tenancy(tenancy),
+		IncludeMarketplace: aws.Bool(true),
+		MaxResults:         aws.Int32(100),
+		Filters:            []types.Filter{{Name: aws.String("marketplace"), Values: []string{"true"}}},
+	}
+	if p.MinDuration > 0 {
+		input.MinDuration = aws.Int64(p.MinDuration)
+	}
+	if p.MaxDuration > 0 {
+		input.MaxDuration = aws.Int64(p.MaxDuration)
+	}
+	return input
+}
+
+// appendMarketplaceOfferings maps one result page into MarketplaceOffering
+// values. AWS-sold offerings and listings with no remaining term are skipped
+// defensively even though the request filters them out.
+func appendMarketplaceOfferings(out []MarketplaceOffering, offerings []types.ReservedInstancesOffering) []MarketplaceOffering {
+	for _, o := range offerings {
+		id := aws.ToString(o.ReservedInstancesOfferingId)
+		duration := aws.ToInt64(o.Duration)
+		if id == "" || !aws.ToBool(o.Marketplace) || duration <= 0 {
+			continue
+		}
+		upfront, hourly := marketplaceOfferingPrices(o)
+		out = append(out, MarketplaceOffering{
+			OfferingID:          id,
+			InstanceType:        string(o.InstanceType),
+			ProductDescription:  string(o.ProductDescription),
+			InstanceTenancy:     string(o.InstanceTenancy),
+			Scope:               string(o.Scope),
+			AvailabilityZone:    aws.ToString(o.AvailabilityZone),
+			OfferingType:        string(o.OfferingType),
+			Duration:            duration,
+			FixedPrice:          upfront,
+			HourlyPrice:         hourly,
+			CurrencyCode:        string(o.CurrencyCode),
+			AvailableCount:      marketplaceAvailableCount(o),
+			EffectiveHourlyRate: hourly + upfront/(float64(duration)/3600),
+		})
+	}
+	return out
+}
+
+// marketplaceOfferingPrices returns the per-instance upfront price and hourly
+// charge of a marketplace listing. offeringPrices reads FixedPrice; a seller
+// listing can leave it unset and carry its asking price only in
+// PricingDetails, in which case the cheapest tier is used.
+func marketplaceOfferingPrices(o types.ReservedInstancesOffering) (upfront, hourly float64) {
+	upfront, hourly = offeringPrices(o)
+	if upfront > 0 {
+		return upfront, hourly
+	}
+	for i, pd := range o.PricingDetails {
+		if price := aws.ToFloat64(pd.Price); i == 0 || price < upfront {
+			upfront = price
+		}
+	}
+	return upfront, hourly
+}
+
+// marketplaceAvailableCount sums the instance counts across a listing's
+// price tiers.
+func marketplaceAvailableCount(o types.ReservedInstancesOffering) int32 {
+	var n int32
+	for _, pd := range o.PricingDetails {
+		n += aws.ToInt32(pd.Count)
+	}
+	return n
+}
+
+// marketplaceOfferingID returns the marketplace offering a rec asks to buy,
+// or "" for an ordinary AWS-sold purchase.
+func marketplaceOfferingID(rec common.Recommendation) string {
+	details, ok := rec.Details.(*common.ComputeDetails)
+	if !ok || details == nil {
+		return ""
+	}
+	return details.MarketplaceOfferingID
+}
+
+// marketplacePurchaseInput re-reads the marketplace offering a rec names and
+// builds the purchase request for it, returning the end of the seller's
+// remaining term alongside. The listing is re-checked at purchase time
+// because it can change or sell out between approval and execution:
+//
+//   - it must still exist, be a marketplace listing, match the rec's
+//     instance type, and offer at least rec.Count instances;
+//   - its total upfront price must not exceed rec.CommitmentCost, the
+//     upfront amount that was approved. The same amount is sent as
+//     LimitPrice so AWS itself refuses to fill the order above it.
+//
+// A rec without a positive CommitmentCost is refused: without it there is
+// no approved amount to bound LimitPrice with.
+func (c *Client) marketplacePurchaseInput(ctx context.Context, rec common.Recommendation, offeringID string) (*ec2.PurchaseReservedInstancesOfferingInput, time.Time, error) {
+	if rec.CommitmentCost <= 0 {
+		return nil, time.Time{}, fmt.Errorf("marketplace purchase of %s requires a positive approved upfront cost to bound the order price", offeringID)
+	}
+	result, err := c.client.DescribeReservedInstancesOfferings(ctx, &ec2.DescribeReservedInstancesOfferingsInput{
+		ReservedInstancesOfferingIds: []string{offeringID},
+		IncludeMarketplace:           aws.Bool(true),
+	})
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to describe marketplace offering %s: %w", offeringID, err)
+	}
+	if len(result.ReservedInstancesOfferings) == 0 {
+		return nil, time.Time{}, fmt.Errorf("marketplace offering %s is no longer available", offeringID)
+	}
+	o := result.ReservedInstancesOfferings[0]
+	if err := checkMarketplaceOffering(o, rec); err != nil {
+		return nil, time.Time{}, fmt.Errorf("marketplace offering %s: %w", offeringID, err)
+	}
+
+	input := &ec2.PurchaseReservedInstancesOfferingInput{
+		ReservedInstancesOfferingId: aws.String(offeringID),
+		InstanceCount:               aws.Int32(int32(rec.Count)), // #nosec G115 -- checkMarketplaceOffering bounds Count by the listing's int32 available count
+		LimitPrice: &types.ReservedInstanceLimitPrice{
+			Amount:       aws.Float64(rec.CommitmentCost),
+			CurrencyCode: types.CurrencyCodeValuesUsd,
+		},
+	}
+	endDate := time.Now().Add(time.Duration(aws.ToInt64(o.Duration)) * time.Second)
+	return input, endDate, nil
+}
+
+// checkMarketplaceOffering validates a freshly described marketplace listing
+// against the rec it is bought for. See marketplacePurchaseInput.
+func checkMarketplaceOffering(o types.ReservedInstancesOffering, rec common.Recommendation) error {
+	switch {
+	case !aws.ToBool(o.Marketplace):
+		return fmt.Errorf("not a Reserved Instance Marketplace listing")
+	case !strings.EqualFold(string(o.InstanceType), rec.ResourceType):
+		return fmt.Errorf("instance type %s does not match the approved %s", o.InstanceType, rec.ResourceType)
+	case aws.ToInt64(o.Duration) <= 0:
+		return fmt.Errorf("listing has no remaining term")
+	case o.CurrencyCode != "" && o.CurrencyCode != types.CurrencyCodeValuesUsd:
+		return fmt.Errorf("listing is priced in %s, not USD", o.CurrencyCode)
+	}
+	if available := marketplaceAvailableCount(o); int64(rec.Count) > int64(available) {
+		return fmt.Errorf("only %d instances are still listed, %d approved", available, rec.Count)
+	}
+	upfront, _ := marketplaceOfferingPrices(o)
+	if total := upfront * float64(rec.Count); total > rec.CommitmentCost {
+		return fmt.Errorf("current upfront price $%.2f exceeds the approved $%.2f", total, rec.CommitmentCost)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package ec2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sixMonthsSeconds = OneYearSeconds / 2
+	marketplaceOffer = "11111111-2222-3333-4444-555555555555"
+)
+
+func marketplaceListing(id string, duration int64, fixed float32, count int32) types.ReservedInstancesOffering {
+	return types.ReservedInstancesOffering{
+		ReservedInstancesOfferingId: aws.String(id),
+		InstanceType:                types.InstanceTypeM5Large,
+		ProductDescription:          types.RIProductDescriptionLinuxUnix,
+		InstanceTenancy:             types.TenancyDefault,
+		Scope:                       types.ScopeRegional,
+		OfferingType:                types.OfferingTypeValuesAllUpfront,
+		CurrencyCode:                types.CurrencyCodeValuesUsd,
+		Marketplace:                 aws.Bool(true),
+		Duration:                    aws.Int64(duration),
+		FixedPrice:                  aws.Float32(fixed),
+		PricingDetails:              []types.PricingDetail{{Count: aws.Int32(count), Price: aws.Float64(float64(fixed))}},
+	}
+}
+
+func TestClient_SearchMarketplaceOfferings_RanksByEffectiveHourlyRate(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+
+	awsSold := marketplaceListing("aws-sold", OneYearSeconds, 100, 10)
+	awsSold.Marketplace = aws.Bool(false)
+	mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeReservedInstancesOfferingsInput) bool {
+		return aws.ToBool(in.IncludeMarketplace) &&
+			in.InstanceType == types.InstanceTypeM5Large &&
+			in.ProductDescription == types.RIProductDescriptionLinuxUnix &&
+			aws.ToInt64(in.MaxDuration) == sixMonthsSeconds &&
+			in.MinDuration == nil &&
+			len(in.Filters) == 1 && aws.ToString(in.Filters[0].Name) == "marketplace"
+	})).Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+		ReservedInstancesOfferings: []types.ReservedInstancesOffering{
+			// $438/half-year = $0.10/h.
+			marketplaceListing("pricey", sixMonthsSeconds, 438, 3),
+			// $219/half-year = $0.05/h.
+			marketplaceListing("cheap-long", sixMonthsSeconds, 219, 1),
+			// $109.5/quarter = $0.05/h, same rate over a shorter term.
+			marketplaceListing("cheap-short", sixMonthsSeconds/2, 109.5, 2),
+			awsSold,
+		},
+	}, nil).Once()
+
+	offerings, err := client.SearchMarketplaceOfferings(context.Background(), SearchMarketplaceOfferingsParams{
+		InstanceType: "m5.large",
+		MaxDuration:  sixMonthsSeconds,
+	})
+	require.NoError(t, err)
+	require.Len(t, offerings, 3, "AWS-sold offerings are excluded")
+	assert.Equal(t, "cheap-short", offerings[0].OfferingID)
+	assert.Equal(t, "cheap-long", offerings[1].OfferingID)
+	assert.Equal(t, "pricey", offerings[2].OfferingID)
+	assert.InDelta(t, 0.05, offerings[0].EffectiveHourlyRate, 1e-6)
+	assert.Equal(t, int32(2), offerings[0].AvailableCount)
+	mockEC2.AssertExpectations(t)
+}
+
+func TestClient_SearchMarketplaceOfferings_RequiresInstanceType(t *testing.T) {
+	t.Parallel()
+	client := &Client{client: &MockEC2Client{}, region: "us-east-1"}
+	_, err := client.SearchMarketplaceOfferings(context.Background(), SearchMarketplaceOfferingsParams{})
+	assert.ErrorContains(t, err, "instance type is required")
+}
+
+func marketplaceRec(count int, approved float64) common.Recommendation {
+	return common.Recommendation{
+		Service:        common.ServiceCompute,
+		ResourceType:   "m5.large",
+		Count:          count,
+		PaymentOption:  "all-upfront",
+		Term:           "1yr",
+		CommitmentCost: approved,
+		Details: &common.ComputeDetails{
+			Platform:              "Linux/UNIX",
+			MarketplaceOfferingID: marketplaceOffer,
+		},
+	}
+}
+
+func TestClient_PurchaseCommitment_MarketplaceOffering(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+
+	mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeReservedInstancesOfferingsInput) bool {
+		return len(in.ReservedInstancesOfferingIds) == 1 && in.ReservedInstancesOfferingIds[0] == marketplaceOffer &&
+			aws.ToBool(in.IncludeMarketplace)
+	})).Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+		ReservedInstancesOfferings: []types.ReservedInstancesOffering{marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)},
+	}, nil).Once()
+
+	var purchase *ec2.PurchaseReservedInstancesOfferingInput
+	mockEC2.On("PurchaseReservedInstancesOffering", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { purchase = args.Get(1).(*ec2.PurchaseReservedInstancesOfferingInput) }).
+		Return(&ec2.PurchaseReservedInstancesOfferingOutput{ReservedInstancesId: aws.String("ri-market")}, nil).Once()
+	mockEC2.On("CreateTags", mock.Anything, mock.Anything).Return(&ec2.CreateTagsOutput{}, nil)
+
+	before := time.Now()
+	result, err := client.PurchaseCommitment(context.Background(), marketplaceRec(2, 450), common.PurchaseOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "ri-market", result.CommitmentID)
+
+	require.NotNil(t, purchase)
+	assert.Equal(t, marketplaceOffer, aws.ToString(purchase.ReservedInstancesOfferingId))
+	assert.Equal(t, int32(2), aws.ToInt32(purchase.InstanceCount))
+	require.NotNil(t, purchase.LimitPrice, "marketplace orders must carry LimitPrice")
+	assert.Equal(t, 450.0, aws.ToFloat64(purchase.LimitPrice.Amount))
+	assert.Equal(t, types.CurrencyCodeValuesUsd, purchase.LimitPrice.CurrencyCode)
+
+	require.NotNil(t, result.EndDate, "marketplace purchases record the seller's remaining term")
+	assert.WithinDuration(t, before.Add(sixMonthsSeconds*time.Second), *result.EndDate, time.Minute)
+	mockEC2.AssertExpectations(t)
+}
+
+func TestClient_PurchaseCommitment_MarketplaceOfferingRefused(t *testing.T) {
+	t.Parallel()
+	wrongType := marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)
+	wrongType.InstanceType = types.InstanceTypeM5Xlarge
+	notMarketplace := marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)
+	notMarketplace.Marketplace = aws.Bool(false)
+
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		offering *types.ReservedInstancesOffering
+		wantErr  string
+	}{
+		{"no approved amount", marketplaceRec(1, 0), nil, "requires a positive approved upfront cost"},
+		{"sold out", marketplaceRec(1, 500), nil, "no longer available"},
+		{"price rose", marketplaceRec(2, 399), ptr(marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)), "current upfront price $400.00 exceeds the approved $399.00"},
+		{"not enough listed", marketplaceRec(6, 5000), ptr(marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)), "only 5 instances are still listed, 6 approved"},
+		{"instance type changed", marketplaceRec(1, 500), &wrongType, "does not match the approved m5.large"},
+		{"AWS-sold offering", marketplaceRec(1, 500), &notMarketplace, "not a Reserved Instance Marketplace listing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mockEC2 := &MockEC2Client{}
+			client := &Client{client: mockEC2, region: "us-east-1"}
+			out := &ec2.DescribeReservedInstancesOfferingsOutput{}
+			if tt.offering != nil {
+				out.ReservedInstancesOfferings = []types.ReservedInstancesOffering{*tt.offering}
+			}
+			mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.Anything).Return(out, nil).Maybe()
+
+			result, err := client.PurchaseCommitment(context.Background(), tt.rec, common.PurchaseOptions{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.False(t, result.Success)
+			mockEC2.AssertNotCalled(t, "PurchaseReservedInstancesOffering", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestClient_ValidateOffering_MarketplaceOffering(t *testing.T) {
+	t.Parallel()
+	mockEC2 := &MockEC2Client{}
+	client := &Client{client: mockEC2, region: "us-east-1"}
+	mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeReservedInstancesOfferingsInput) bool {
+		return len(in.ReservedInstancesOfferingIds) == 1 && in.ReservedInstancesOfferingIds[0] == marketplaceOffer
+	})).Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+		ReservedInstancesOfferings: []types.ReservedInstancesOffering{marketplaceListing(marketplaceOffer, sixMonthsSeconds, 200, 5)},
+	}, nil).Twice()
+
+	assert.NoError(t, client.ValidateOffering(context.Background(), marketplaceRec(2, 400)))
+	assert.ErrorContains(t, client.ValidateOffering(context.Background(), marketplaceRec(3, 400)), "exceeds the approved")
+	mockEC2.AssertExpectations(t)
+}
+
+func ptr[T any](v T) *T { return &v }
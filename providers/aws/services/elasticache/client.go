@@ -15,6 +15,7 @@ import (
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/providers/aws/internal/purchasecfg"
+	"github.com/LeanerCloud/CUDly/providers/aws/internal/reservedcost"
 	"github.com/LeanerCloud/CUDly/providers/aws/internal/tagging"
 )
 
@@ -100,6 +101,8 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 				State:          state,
 				StartDate:      aws.ToTime(node.StartTime),
 				EndDate:        aws.ToTime(node.StartTime).AddDate(0, termMonths, 0),
+				Cost: reservedcost.HourlyCost(aws.ToFloat64(node.FixedPrice), aws.ToFloat64(node.UsagePrice),
+					recurringCharges(node.RecurringCharges), duration, aws.ToInt32(node.CacheNodeCount)),
 			}
 
 			commitments = append(commitments, commitment)
@@ -114,6 +117,19 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 	return commitments, nil
 }
 
+// recurringCharges converts the SDK's recurring charges for
+// reservedcost.HourlyCost.
+func recurringCharges(rcs []types.RecurringCharge) []reservedcost.Charge {
+	charges := make([]reservedcost.Charge, 0, len(rcs))
+	for _, rc := range rcs {
+		charges = append(charges, reservedcost.Charge{
+			Frequency: aws.ToString(rc.RecurringChargeFrequency),
+			Amount:    aws.ToFloat64(rc.RecurringChargeAmount),
+		})
+	}
+	return charges
+}
+
 // PurchaseCommitment purchases an ElastiCache Reserved Cache Node
 func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
 	result := common.PurchaseResult{
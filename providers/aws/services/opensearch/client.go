@@ -18,6 +18,7 @@ import (
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/retry"
 	"github.com/LeanerCloud/CUDly/providers/aws/internal/purchasecfg"
+	"github.com/LeanerCloud/CUDly/providers/aws/internal/reservedcost"
 )
 
 // API defines the interface for OpenSearch operations (enables mocking).
@@ -115,6 +116,8 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 				State:          state,
 				StartDate:      aws.ToTime(ri.StartTime),
 				EndDate:        aws.ToTime(ri.StartTime).AddDate(0, termMonths, 0),
+				Cost: reservedcost.HourlyCost(aws.ToFloat64(ri.FixedPrice), aws.ToFloat64(ri.UsagePrice),
+					recurringCharges(ri.RecurringCharges), ri.Duration, ri.InstanceCount),
 			}
 
 			commitments = append(commitments, commitment)
@@ -129,6 +132,19 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 	return commitments, nil
 }
 
+// recurringCharges converts the SDK's recurring charges for
+// reservedcost.HourlyCost.
+func recurringCharges(rcs []types.RecurringCharge) []reservedcost.Charge {
+	charges := make([]reservedcost.Charge, 0, len(rcs))
+	for _, rc := range rcs {
+		charges = append(charges, reservedcost.Charge{
+			Frequency: aws.ToString(rc.RecurringChargeFrequency),
+			Amount:    aws.ToFloat64(rc.RecurringChargeAmount),
+		})
+	}
+	return charges
+}
+
 // PurchaseCommitment purchases an OpenSearch Reserved Instance.
 //
 // PurchaseReservedInstanceOfferingInput has no Tags field -- tagging happens
@@ -17,6 +17,7 @@ import (
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/providers/aws/internal/purchasecfg"
+	"github.com/LeanerCloud/CUDly/providers/aws/internal/reservedcost"
 	"github.com/LeanerCloud/CUDly/providers/aws/internal/tagging"
 )
 
@@ -113,6 +114,8 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 				State:          state,
 				StartDate:      aws.ToTime(instance.StartTime),
 				EndDate:        aws.ToTime(instance.StartTime).AddDate(0, termMonths, 0),
+				Cost: reservedcost.HourlyCost(aws.ToFloat64(instance.FixedPrice), aws.ToFloat64(instance.UsagePrice),
+					recurringCharges(instance.RecurringCharges), duration, aws.ToInt32(instance.DBInstanceCount)),
 			}
 
 			commitments = append(commitments, commitment)
@@ -127,6 +130,19 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 	return commitments, nil
 }
 
+// recurringCharges converts the SDK's recurring charges for
+// reservedcost.HourlyCost.
+func recurringCharges(rcs []types.RecurringCharge) []reservedcost.Charge {
+	charges := make([]reservedcost.Charge, 0, len(rcs))
+	for _, rc := range rcs {
+		charges = append(charges, reservedcost.Charge{
+			Frequency: aws.ToString(rc.RecurringChargeFrequency),
+			Amount:    aws.ToFloat64(rc.RecurringChargeAmount),
+		})
+	}
+	return charges
+}
+
 // PurchaseCommitment purchases an RDS Reserved Instance
 func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
 	result := common.PurchaseResult{
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -202,6 +203,11 @@ func (c *Client) toCommitment(sp types.SavingsPlan, service common.ServiceType)
 			commitment.EndDate = endTime
 		}
 	}
+	// A Savings Plan's commitment is its hourly spend, which is what
+	// Commitment.Cost carries; the renewal planner sizes replacements from it.
+	if hourly, err := strconv.ParseFloat(aws.ToString(sp.Commitment), 64); err == nil {
+		commitment.Cost = hourly
+	}
 
 	return commitment, true
 }
@@ -231,7 +237,7 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		SavingsPlanOfferingId: aws.String(offeringID),
 		Commitment:            aws.String(fmt.Sprintf("%.2f", spDetails.HourlyCommitment)),
 		UpfrontPaymentAmount:  nil, // AWS calculates this based on payment option
-		PurchaseTime:          aws.Time(time.Now()),
+		PurchaseTime:          aws.Time(purchaseTime(opts.StartTime)),
 		Tags:                  buildSavingsPlanTags(opts.Source),
 	}
 
@@ -514,6 +520,17 @@ func (c *Client) lookupEC2OfferingIDStrict(ctx context.Context, input *savingspl
 	return ids[0], nil
 }
 
+// purchaseTime returns when a new Savings Plan should start: the requested
+// start when it is in the future, so AWS queues the plan (a renewal timed to
+// the expiry of the plan it replaces), otherwise now.
+func purchaseTime(start *time.Time) time.Time {
+	now := time.Now()
+	if start != nil && start.After(now) {
+		return *start
+	}
+	return now
+}
+
 // convertPlanType converts a plan type string to AWS SDK type
 func convertPlanType(planType string) (types.SavingsPlanType, error) {
 	switch planType {
@@ -257,6 +257,35 @@ func TestClient_GetExistingCommitments_UmbrellaMode(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+// TestClient_GetExistingCommitments_HourlyCommitment verifies a plan's
+// hourly commitment is reported as Commitment.Cost, and an unparsable one
+// as 0.
+func TestClient_GetExistingCommitments_HourlyCommitment(t *testing.T) {
+	mockClient := &MockSavingsPlansClient{}
+	mockClient.On("DescribeSavingsPlans", mock.Anything, mock.Anything).
+		Return(&savingsplans.DescribeSavingsPlansOutput{
+			SavingsPlans: []types.SavingsPlan{
+				{SavingsPlanId: aws.String("sp-1"), SavingsPlanType: types.SavingsPlanTypeCompute, Commitment: aws.String("2.75")},
+				{SavingsPlanId: aws.String("sp-2"), SavingsPlanType: types.SavingsPlanTypeCompute},
+			},
+		}, nil).Once()
+
+	client := &Client{client: mockClient, region: "us-east-1"}
+	result, err := client.GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, 2.75, result[0].Cost)
+	assert.Equal(t, 0.0, result[1].Cost)
+}
+
+func TestPurchaseTime(t *testing.T) {
+	future := time.Now().Add(48 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+	assert.Equal(t, future, purchaseTime(&future), "a future start queues the plan")
+	assert.WithinDuration(t, time.Now(), purchaseTime(&past), time.Minute)
+	assert.WithinDuration(t, time.Now(), purchaseTime(nil), time.Minute)
+}
+
 // TestClient_GetExistingCommitments_Pagination verifies that
 // GetExistingCommitments drives DescribeSavingsPlans across all pages and
 // accumulates every item. This is the regression test for issue #1019: the
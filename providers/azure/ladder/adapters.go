@@ -0,0 +1,205 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/billingbenefits/armbillingbenefits"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/savingsplans"
+)
+
+// spListerAdapter implements spLister over the tenant-wide Savings Plan
+// listing, keeping only plans billed to the ladder's subscription. The
+// savingsplans client's GetExistingCommitments is not reused because it
+// drops the billing scope, which is the only ownership signal on the
+// tenant-wide listing.
+type spListerAdapter struct {
+	newPager       func() (savingsplans.SavingsPlanListAllPager, error)
+	subscriptionID string
+}
+
+// ListActiveSPs lists the Savings Plans in provisioning state Succeeded that
+// are billed to the subscription. Commitment fields are validated at the
+// boundary and fail loud: a plan with a missing or non-hourly commitment, or
+// one denominated in a currency other than USD, would otherwise be counted
+// at a wrong USD/hour value.
+func (a *spListerAdapter) ListActiveSPs(ctx context.Context) ([]ActiveSP, error) {
+	pager, err := a.newPager()
+	if err != nil {
+		return nil, fmt.Errorf("ListActiveSPs: %w", err)
+	}
+	want := "/subscriptions/" + a.subscriptionID
+	var out []ActiveSP
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ListActiveSPs: failed to list savings plans: %w", err)
+		}
+		for _, sp := range page.Value {
+			if sp == nil || sp.ID == nil || sp.Properties == nil {
+				continue
+			}
+			props := sp.Properties
+			if props.BillingScopeID == nil || !strings.EqualFold(*props.BillingScopeID, want) {
+				continue
+			}
+			if props.ProvisioningState == nil || *props.ProvisioningState != armbillingbenefits.ProvisioningStateSucceeded {
+				continue
+			}
+			active, err := toActiveSP(sp)
+			if err != nil {
+				return nil, fmt.Errorf("ListActiveSPs: %w", err)
+			}
+			out = append(out, active)
+		}
+	}
+	return out, nil
+}
+
+// toActiveSP maps one owned, succeeded savings plan to the AzureLadder view.
+func toActiveSP(sp *armbillingbenefits.SavingsPlanModel) (ActiveSP, error) {
+	props := sp.Properties
+	c := props.Commitment
+	if c == nil || c.Amount == nil {
+		return ActiveSP{}, fmt.Errorf("savings plan %s has no commitment amount", *sp.ID)
+	}
+	if c.Grain == nil || *c.Grain != armbillingbenefits.CommitmentGrainHourly {
+		return ActiveSP{}, fmt.Errorf("savings plan %s has a non-hourly commitment grain", *sp.ID)
+	}
+	if c.CurrencyCode == nil || !strings.EqualFold(*c.CurrencyCode, "USD") {
+		return ActiveSP{}, fmt.Errorf("savings plan %s commitment is not denominated in USD", *sp.ID)
+	}
+	if math.IsNaN(*c.Amount) || math.IsInf(*c.Amount, 0) || *c.Amount < 0 {
+		return ActiveSP{}, fmt.Errorf("savings plan %s has an invalid commitment amount %g", *sp.ID, *c.Amount)
+	}
+
+	active := ActiveSP{
+		PlanID:              *sp.ID,
+		PlanType:            spPlanTypeCompute,
+		State:               string(*props.ProvisioningState),
+		HourlyCommitmentUSD: *c.Amount,
+	}
+	if sp.SKU != nil && sp.SKU.Name != nil {
+		active.PlanType = *sp.SKU.Name
+	}
+	if props.EffectiveDateTime != nil {
+		active.StartDate = *props.EffectiveDateTime
+	}
+	if props.ExpiryDateTime != nil {
+		active.EndDate = *props.ExpiryDateTime
+	}
+	return active, nil
+}
+
+// offeringDetailer is the slice of compute.ComputeClient the pricer needs.
+type offeringDetailer interface {
+	GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error)
+}
+
+// reservationPricerAdapter implements reservationPricer from the Retail
+// Prices API via compute.ComputeClient.GetOfferingDetails. The compute client
+// prices in the region it was created for, so one client is built per region.
+// Rates are cached per (sku, region, term) for the adapter's lifetime, which
+// is one ladder run.
+type reservationPricerAdapter struct {
+	newClient func(region string) offeringDetailer
+	cache     map[string]float64
+	mu        sync.Mutex
+}
+
+// HourlyRate returns the amortized per-instance USD/hour of a reservation.
+// term is the ISO form carried by compute.ExchangeableReservation ("P1Y",
+// "P3Y"). A non-USD price or a non-positive rate is an error.
+func (a *reservationPricerAdapter) HourlyRate(ctx context.Context, sku, region, term string) (float64, error) {
+	key := strings.ToLower(sku + "|" + region + "|" + term)
+	a.mu.Lock()
+	if rate, ok := a.cache[key]; ok {
+		a.mu.Unlock()
+		return rate, nil
+	}
+	a.mu.Unlock()
+
+	recTerm, err := retailTerm(term)
+	if err != nil {
+		return 0, err
+	}
+	details, err := a.newClient(region).GetOfferingDetails(ctx, common.Recommendation{
+		ResourceType:  sku,
+		Region:        region,
+		Term:          recTerm,
+		PaymentOption: "upfront",
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !strings.EqualFold(details.Currency, "USD") {
+		return 0, fmt.Errorf("retail price for %s in %s is in %q; only USD is supported", sku, region, details.Currency)
+	}
+	rate := details.EffectiveHourlyRate
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return 0, fmt.Errorf("retail price for %s in %s yields an invalid hourly rate %g", sku, region, rate)
+	}
+
+	a.mu.Lock()
+	a.cache[key] = rate
+	a.mu.Unlock()
+	return rate, nil
+}
+
+// retailTerm maps a reservation's ISO term to the vocabulary the compute
+// client's offering lookup accepts.
+func retailTerm(term string) (string, error) {
+	switch strings.ToUpper(term) {
+	case "P1Y":
+		return "1yr", nil
+	case "P3Y":
+		return "3yr", nil
+	default:
+		return "", fmt.Errorf("unsupported reservation term %q for VM pricing", term)
+	}
+}
+
+// onDemandSeriesAPI is the slice of azure.RecommendationsClientAdapter the
+// series adapter needs.
+type onDemandSeriesAPI interface {
+	GetOnDemandSeries(ctx context.Context, lookbackDays int) ([]azure.DailyCost, error)
+}
+
+// onDemandSeriesAdapter maps the Azure DailyCost series onto ladder.DailyPoint.
+type onDemandSeriesAdapter struct {
+	client onDemandSeriesAPI
+}
+
+func (a *onDemandSeriesAdapter) GetOnDemandSeries(ctx context.Context, lookbackDays int) ([]ladder.DailyPoint, error) {
+	costs, err := a.client.GetOnDemandSeries(ctx, lookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]ladder.DailyPoint, len(costs))
+	for i, c := range costs {
+		points[i] = ladder.DailyPoint{Date: c.Date, USDPerHour: c.USDPerHour}
+	}
+	return points, nil
+}
+
+// regionalReservationPurchaser routes each VM reservation purchase to a
+// purchaser for the recommendation's region: the compute client sends its own
+// region as the reservation location, so a single client would buy every
+// reservation in one region.
+type regionalReservationPurchaser struct {
+	newClient func(region string) reservationPurchaser
+}
+
+func (p *regionalReservationPurchaser) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	if rec.Region == "" {
+		return common.PurchaseResult{}, fmt.Errorf("VM reservation purchase requires a region")
+	}
+	return p.newClient(rec.Region).PurchaseCommitment(ctx, rec, opts)
+}
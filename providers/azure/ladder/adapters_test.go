@@ -0,0 +1,138 @@
+package ladder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/billingbenefits/armbillingbenefits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/savingsplans"
+)
+
+type fakeSPPager struct {
+	pages []armbillingbenefits.SavingsPlanClientListAllResponse
+	next  int
+}
+
+func (p *fakeSPPager) More() bool { return p.next < len(p.pages) }
+
+func (p *fakeSPPager) NextPage(context.Context) (armbillingbenefits.SavingsPlanClientListAllResponse, error) {
+	page := p.pages[p.next]
+	p.next++
+	return page, nil
+}
+
+func savingsPlan(id, billingScope string, state armbillingbenefits.ProvisioningState, amount float64, currency string) *armbillingbenefits.SavingsPlanModel {
+	return &armbillingbenefits.SavingsPlanModel{
+		ID:  to.Ptr(id),
+		SKU: &armbillingbenefits.SKU{Name: to.Ptr(spPlanTypeCompute)},
+		Properties: &armbillingbenefits.SavingsPlanModelProperties{
+			BillingScopeID:    to.Ptr(billingScope),
+			ProvisioningState: to.Ptr(state),
+			ExpiryDateTime:    to.Ptr(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)),
+			Commitment: &armbillingbenefits.Commitment{
+				Amount:       to.Ptr(amount),
+				CurrencyCode: to.Ptr(currency),
+				Grain:        to.Ptr(armbillingbenefits.CommitmentGrainHourly),
+			},
+		},
+	}
+}
+
+func spAdapter(plans ...*armbillingbenefits.SavingsPlanModel) *spListerAdapter {
+	page := armbillingbenefits.SavingsPlanClientListAllResponse{}
+	page.Value = plans
+	return &spListerAdapter{
+		subscriptionID: testSub,
+		newPager: func() (savingsplans.SavingsPlanListAllPager, error) {
+			return &fakeSPPager{pages: []armbillingbenefits.SavingsPlanClientListAllResponse{page}}, nil
+		},
+	}
+}
+
+func TestSPListerAdapter_KeepsOwnedSucceededPlans(t *testing.T) {
+	owned := "/subscriptions/" + testSub
+	a := spAdapter(
+		savingsPlan("sp-owned", owned, armbillingbenefits.ProvisioningStateSucceeded, 2.5, "USD"),
+		savingsPlan("sp-foreign", "/subscriptions/other", armbillingbenefits.ProvisioningStateSucceeded, 9, "USD"),
+		savingsPlan("sp-failed", owned, armbillingbenefits.ProvisioningStateFailed, 9, "USD"),
+	)
+
+	got, err := a.ListActiveSPs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "sp-owned", got[0].PlanID)
+	assert.Equal(t, spPlanTypeCompute, got[0].PlanType)
+	assert.InDelta(t, 2.5, got[0].HourlyCommitmentUSD, 1e-9)
+	assert.False(t, got[0].EndDate.IsZero())
+}
+
+func TestSPListerAdapter_NonUSDFailsLoud(t *testing.T) {
+	a := spAdapter(savingsPlan("sp-eur", "/subscriptions/"+testSub, armbillingbenefits.ProvisioningStateSucceeded, 2, "EUR"))
+	_, err := a.ListActiveSPs(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "USD")
+}
+
+type fakeOfferingDetailer struct {
+	details *common.OfferingDetails
+	gotRec  common.Recommendation
+	calls   *int
+}
+
+func (f *fakeOfferingDetailer) GetOfferingDetails(_ context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	*f.calls++
+	f.gotRec = rec
+	return f.details, nil
+}
+
+func TestReservationPricerAdapter_CachesAndMapsTerm(t *testing.T) {
+	calls := 0
+	detailer := &fakeOfferingDetailer{details: &common.OfferingDetails{EffectiveHourlyRate: 0.1, Currency: "USD"}, calls: &calls}
+	var regions []string
+	p := &reservationPricerAdapter{
+		newClient: func(region string) offeringDetailer {
+			regions = append(regions, region)
+			return detailer
+		},
+		cache: make(map[string]float64),
+	}
+
+	for i := 0; i < 2; i++ {
+		rate, err := p.HourlyRate(context.Background(), "Standard_D2s_v3", "eastus", "P3Y")
+		require.NoError(t, err)
+		assert.InDelta(t, 0.1, rate, 1e-12)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"eastus"}, regions)
+	assert.Equal(t, "3yr", detailer.gotRec.Term)
+
+	_, err := p.HourlyRate(context.Background(), "Standard_D2s_v3", "eastus", "P5Y")
+	require.Error(t, err)
+
+	detailer.details = &common.OfferingDetails{EffectiveHourlyRate: 0.1, Currency: "EUR"}
+	_, err = p.HourlyRate(context.Background(), "Standard_D4s_v3", "eastus", "P1Y")
+	require.Error(t, err)
+}
+
+type fakeAzureSeries struct{ costs []azure.DailyCost }
+
+func (f *fakeAzureSeries) GetOnDemandSeries(context.Context, int) ([]azure.DailyCost, error) {
+	return f.costs, nil
+}
+
+func TestOnDemandSeriesAdapter_MapsPoints(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	a := &onDemandSeriesAdapter{client: &fakeAzureSeries{costs: []azure.DailyCost{{Date: day, USDPerHour: 4}}}}
+	got, err := a.GetOnDemandSeries(context.Background(), 30)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, day, got[0].Date)
+	assert.InDelta(t, 4.0, got[0].USDPerHour, 1e-12)
+}
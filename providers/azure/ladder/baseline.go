@@ -0,0 +1,36 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// GetUsageBaseline computes a statistical low-water mark from the
+// subscription's daily on-demand virtual machine spend, returned by the
+// injected onDemandSeriesSource (the Consumption Usage Details API).
+//
+// Validation and the percentile math are delegated to
+// ladder.BaselineFromDailySeries so Azure rejects exactly the malformed
+// series AWS does (empty, too short, sparse, stale, unsorted, non-finite or
+// negative). StableUSDPerHour is nil; see that function for why.
+func (a *AzureLadder) GetUsageBaseline(ctx context.Context, scope ladder.Scope, lookbackDays int, percentile float64) (ladder.UsageBaseline, error) {
+	if err := a.validateScope(scope); err != nil {
+		return ladder.UsageBaseline{}, err
+	}
+	if lookbackDays <= 0 {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: lookbackDays %d must be > 0", lookbackDays)
+	}
+
+	points, err := a.onDemand.GetOnDemandSeries(ctx, lookbackDays)
+	if err != nil {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: on-demand series fetch failed: %w", err)
+	}
+	baseline, err := ladder.BaselineFromDailySeries(points, lookbackDays, percentile, time.Now())
+	if err != nil {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: subscription %s: %w", a.cfg.SubscriptionID, err)
+	}
+	return baseline, nil
+}
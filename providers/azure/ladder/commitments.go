@@ -0,0 +1,147 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// pricedReservation pairs an owned reservation with its reservation-total
+// amortized USD/hour cost.
+type pricedReservation struct {
+	res       compute.ExchangeableReservation
+	hourlyUSD float64
+}
+
+// ListCommitments returns all active commitments for the given scope by merging:
+//   - VM reservations billed to the subscription (reservationLister, priced
+//     via reservationPricer)
+//   - Savings Plans billed to the subscription (spLister.ListActiveSPs)
+//
+// The scope's AccountID must match Config.SubscriptionID.
+func (a *AzureLadder) ListCommitments(ctx context.Context, scope ladder.Scope) ([]common.Commitment, error) {
+	if err := a.validateScope(scope); err != nil {
+		return nil, err
+	}
+
+	reservations, err := a.listPricedReservations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListCommitments: reservation listing failed: %w", err)
+	}
+
+	sps, err := a.sps.ListActiveSPs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListCommitments: SP listing failed: %w", err)
+	}
+
+	result := make([]common.Commitment, 0, len(reservations)+len(sps))
+	for i := range reservations {
+		result = append(result, reservationToCommitment(&reservations[i], a.cfg.SubscriptionID))
+	}
+	for i := range sps {
+		result = append(result, spToCommitment(&sps[i], a.cfg.SubscriptionID))
+	}
+	return result, nil
+}
+
+// validateScope returns an error when scope targets a provider or
+// subscription that does not match this AzureLadder instance.
+func (a *AzureLadder) validateScope(scope ladder.Scope) error {
+	if scope.Provider != common.ProviderAzure {
+		return fmt.Errorf("AzureLadder: expected provider %s, got %s", common.ProviderAzure, scope.Provider)
+	}
+	if !strings.EqualFold(scope.AccountID, a.cfg.SubscriptionID) {
+		return fmt.Errorf("AzureLadder: scope subscription %s does not match configured subscription %s",
+			scope.AccountID, a.cfg.SubscriptionID)
+	}
+	return nil
+}
+
+// listOwnedReservations returns the exchangeable VM reservations billed to
+// this subscription. The underlying listing is tenant-wide; BillingScopeID is
+// the only ownership signal on it (see compute.ExchangeableReservation), so a
+// reservation with an empty or foreign billing scope is excluded rather than
+// counted against this subscription's ladder.
+//
+// Limitation: the listing only returns reservations with instance size
+// flexibility On (the default for VM reservations). A reservation purchased
+// with flexibility Off is not counted as existing commitment; the engine may
+// then see a larger gap than really exists.
+func (a *AzureLadder) listOwnedReservations(ctx context.Context) ([]compute.ExchangeableReservation, error) {
+	all, err := a.reservations.ListExchangeableReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	want := a.cfg.billingScopeID()
+	owned := make([]compute.ExchangeableReservation, 0, len(all))
+	for i := range all {
+		if strings.EqualFold(all[i].BillingScopeID, want) {
+			owned = append(owned, all[i])
+		}
+	}
+	return owned, nil
+}
+
+// listPricedReservations lists the owned reservations and prices each one.
+// A pricing failure is a hard error: an unpriced reservation would count as
+// $0 of existing commitment and the engine would buy coverage that already
+// exists.
+func (a *AzureLadder) listPricedReservations(ctx context.Context) ([]pricedReservation, error) {
+	owned, err := a.listOwnedReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]pricedReservation, 0, len(owned))
+	for i := range owned {
+		r := &owned[i]
+		if r.Region == "" {
+			return nil, fmt.Errorf("reservation %s (%s) reports no region; cannot price it", r.ReservationID, r.SKU)
+		}
+		rate, err := a.pricer.HourlyRate(ctx, r.SKU, r.Region, r.Term)
+		if err != nil {
+			return nil, fmt.Errorf("pricing reservation %s (%s in %s, %s): %w", r.ReservationID, r.SKU, r.Region, r.Term, err)
+		}
+		out = append(out, pricedReservation{res: *r, hourlyUSD: rate * float64(r.Quantity)})
+	}
+	return out, nil
+}
+
+// reservationToCommitment converts a priced reservation to a
+// common.Commitment. Cost is the reservation-total amortized USD/hour.
+func reservationToCommitment(p *pricedReservation, subscriptionID string) common.Commitment {
+	return common.Commitment{
+		Provider:       common.ProviderAzure,
+		Account:        subscriptionID,
+		CommitmentID:   p.res.ReservationID,
+		CommitmentType: common.CommitmentReservedInstance,
+		Service:        common.ServiceCompute,
+		Region:         p.res.Region,
+		ResourceType:   p.res.SKU,
+		Count:          int(p.res.Quantity),
+		State:          "active",
+		EndDate:        p.res.ExpiryDate,
+		Cost:           p.hourlyUSD,
+	}
+}
+
+// spToCommitment converts an ActiveSP to a common.Commitment. Cost is the
+// hourly commitment. Azure Savings Plans are not regional, so Region is empty.
+func spToCommitment(sp *ActiveSP, subscriptionID string) common.Commitment {
+	return common.Commitment{
+		Provider:       common.ProviderAzure,
+		Account:        subscriptionID,
+		CommitmentID:   sp.PlanID,
+		CommitmentType: common.CommitmentSavingsPlan,
+		Service:        common.ServiceSavingsPlansAll,
+		ResourceType:   sp.PlanType,
+		Count:          1,
+		State:          sp.State,
+		StartDate:      sp.StartDate,
+		EndDate:        sp.EndDate,
+		Cost:           sp.HourlyCommitmentUSD,
+	}
+}
@@ -0,0 +1,122 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/billingbenefits/armbillingbenefits"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/savingsplans"
+)
+
+// NewFromTokenCredential constructs a fully wired read-side AzureLadder for
+// one subscription. Client wiring:
+//
+//   - reservationLister    : compute.ComputeClient.ListExchangeableReservations
+//   - spLister             : spListerAdapter over armbillingbenefits ListAll
+//   - reservationPricer    : reservationPricerAdapter (Retail Prices API via
+//     compute.ComputeClient.GetOfferingDetails, one client per region)
+//   - onDemandSeriesSource : onDemandSeriesAdapter wrapping
+//     azure.RecommendationsClientAdapter.GetOnDemandSeries (Usage Details)
+//   - utilizationSource    : azure.RecommendationsClientAdapter.GetRIUtilization
+//
+// The write side stays unwired; callers wire it with WireWriteSide or
+// WireWriteSideDisabled. The ctx parameter matches the AWS factory's shape
+// and is reserved for clients that need it at construction.
+func NewFromTokenCredential(_ context.Context, cred azcore.TokenCredential, subscriptionID string) (pkgladder.LadderCapability, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("azureladder.NewFromTokenCredential: credential must not be nil")
+	}
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("azureladder.NewFromTokenCredential: subscriptionID must not be empty")
+	}
+
+	// ListExchangeableReservations is tenant-wide and region-independent;
+	// the region argument only matters for pricing and purchases.
+	lister := compute.NewClient(cred, subscriptionID, "")
+	recoAdapter, err := azure.NewRecommendationsClientAdapter(cred, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("azureladder.NewFromTokenCredential: %w", err)
+	}
+
+	l, err := New(
+		Config{SubscriptionID: subscriptionID},
+		lister,
+		&spListerAdapter{
+			subscriptionID: subscriptionID,
+			newPager: func() (savingsplans.SavingsPlanListAllPager, error) {
+				client, err := armbillingbenefits.NewSavingsPlanClient(nil, cred, nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create savings plan client: %w", err)
+				}
+				return client.NewListAllPager(nil), nil
+			},
+		},
+		&reservationPricerAdapter{
+			newClient: func(region string) offeringDetailer { return compute.NewClient(cred, subscriptionID, region) },
+			cache:     make(map[string]float64),
+		},
+		&onDemandSeriesAdapter{client: recoAdapter},
+		recoAdapter,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("azureladder.NewFromTokenCredential: %w", err)
+	}
+	return l, nil
+}
+
+// disabledPurchaser is the reservationPurchaser / spPurchaser implementation
+// used when ladder_execution_enabled=false. Every call returns
+// ErrLadderExecutionDisabled without touching any Azure API.
+type disabledPurchaser struct{}
+
+func (disabledPurchaser) PurchaseCommitment(_ context.Context, _ common.Recommendation, _ common.PurchaseOptions) (common.PurchaseResult, error) {
+	return common.PurchaseResult{}, fmt.Errorf("%w: purchase blocked by kill-switch", ErrLadderExecutionDisabled)
+}
+
+// disabledExchangeClient is the exchangeClient / targetSource implementation
+// used when ladder_execution_enabled=false. Every call returns
+// ErrLadderExecutionDisabled without touching any Azure API.
+type disabledExchangeClient struct{}
+
+func (disabledExchangeClient) CalculateExchange(_ context.Context, _ []compute.ExchangeableReservation, _ []compute.ExchangeTarget) (*compute.ExchangePreview, []compute.CompatibleOffering, error) {
+	return nil, nil, fmt.Errorf("%w: exchange blocked by kill-switch", ErrLadderExecutionDisabled)
+}
+
+func (disabledExchangeClient) ExecuteExchange(_ context.Context, _ string) (*compute.ExchangeResult, error) {
+	return nil, fmt.Errorf("%w: exchange blocked by kill-switch", ErrLadderExecutionDisabled)
+}
+
+func (disabledExchangeClient) GetRecommendations(_ context.Context, _ *common.RecommendationParams) ([]common.Recommendation, error) {
+	return nil, fmt.Errorf("%w: exchange blocked by kill-switch", ErrLadderExecutionDisabled)
+}
+
+// WireWriteSideDisabled wires the ladder's write side with disabled
+// implementations that return ErrLadderExecutionDisabled on every call.
+// Use this when ladder_execution_enabled=false in global_config.
+func WireWriteSideDisabled(l *AzureLadder) (*AzureLadder, error) {
+	return l.WithWriteSide(disabledPurchaser{}, disabledPurchaser{}, disabledExchangeClient{}, disabledExchangeClient{})
+}
+
+// WireWriteSide wires the ladder's write side with real Azure clients for
+// the ladder's subscription. Use this when ladder_execution_enabled=true in
+// global_config. Reservation purchases are routed to a compute client for
+// the recommendation's region; the exchange and recommendation calls are
+// region-independent and share one compute client.
+func WireWriteSide(l *AzureLadder, cred azcore.TokenCredential) (*AzureLadder, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("azureladder.WireWriteSide: credential must not be nil")
+	}
+	sub := l.cfg.SubscriptionID
+	resP := &regionalReservationPurchaser{
+		newClient: func(region string) reservationPurchaser { return compute.NewClient(cred, sub, region) },
+	}
+	spP := savingsplans.NewClient(cred, sub, "")
+	ex := compute.NewClient(cred, sub, "")
+	return l.WithWriteSide(resP, spP, ex, ex)
+}
@@ -0,0 +1,115 @@
+// Package ladder implements ladder.LadderCapability for Azure: the read side
+// (commitment listing, layer states, usage baseline) and the write side
+// (layer purchases, buffer reshaping via reservation exchange). Write-side
+// methods require the write dependencies to be wired via
+// AzureLadder.WithWriteSide; until then they return an explicit not-wired
+// error.
+//
+// Azure has two ladder layers. VM reservations (instance-size flexible,
+// exchangeable) serve both the base and the buffer role; compute Savings
+// Plans serve the flex role.
+package ladder
+
+import (
+	"context"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// spPlanTypeCompute is the Azure Savings Plan SKU name for compute Savings
+// Plans, the only Azure plan type today and the one the savingsplans client
+// passes verbatim as the order alias SKU.
+const spPlanTypeCompute = "Compute_Savings_Plan"
+
+// reservationLister is the narrow interface for listing exchangeable VM
+// reservations. The concrete implementation is
+// compute.ComputeClient.ListExchangeableReservations, which enumerates the
+// whole tenant; AzureLadder filters the result to reservations billed to
+// its own subscription.
+type reservationLister interface {
+	ListExchangeableReservations(ctx context.Context) ([]compute.ExchangeableReservation, error)
+}
+
+// ActiveSP is a minimal view of an active Azure Savings Plan billed to the
+// ladder's subscription.
+//
+// Fields are ordered to minimize the GC pointer-scan range (fieldalignment).
+type ActiveSP struct {
+	// PlanID is the full ARM resource ID of the savings plan.
+	PlanID string
+	// PlanType is the savings plan SKU name (e.g. "Compute_Savings_Plan").
+	PlanType string
+	// State mirrors the provisioning state ("Succeeded").
+	State string
+	// StartDate is the SP effective time.
+	StartDate time.Time
+	// EndDate is the SP expiry time.
+	EndDate time.Time
+	// HourlyCommitmentUSD is the committed spend in USD per hour.
+	HourlyCommitmentUSD float64
+}
+
+// spLister is the narrow interface for listing the subscription's active
+// Savings Plans. The concrete implementation is spListerAdapter.
+type spLister interface {
+	ListActiveSPs(ctx context.Context) ([]ActiveSP, error)
+}
+
+// reservationPricer returns the amortized per-instance USD/hour rate of a VM
+// reservation. Azure's reservation listing carries no price, so the layer
+// state prices each reservation from the Retail Prices API. The concrete
+// implementation is reservationPricerAdapter.
+type reservationPricer interface {
+	HourlyRate(ctx context.Context, sku, region, term string) (float64, error)
+}
+
+// utilizationSource is the narrow interface for reservation utilization.
+// The concrete implementation is azure.RecommendationsClientAdapter.
+// GetRIUtilization, which keys each entry by the reservation GUID (the last
+// segment of the reservation's ARM ID).
+type utilizationSource interface {
+	GetRIUtilization(ctx context.Context, lookbackDays int) ([]common.RIUtilization, error)
+}
+
+// onDemandSeriesSource is the narrow interface for the daily on-demand VM
+// spend series consumed by GetUsageBaseline, ordered oldest-to-newest. The
+// concrete implementation is onDemandSeriesAdapter.
+type onDemandSeriesSource interface {
+	GetOnDemandSeries(ctx context.Context, lookbackDays int) ([]ladder.DailyPoint, error)
+}
+
+// reservationPurchaser is the narrow interface for purchasing VM
+// reservations. The concrete implementation is regionalReservationPurchaser,
+// which routes each purchase to a compute.ComputeClient for the
+// recommendation's region (the Azure purchase body carries the client's
+// region as the reservation location).
+type reservationPurchaser interface {
+	PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error)
+}
+
+// spPurchaser is the narrow interface for purchasing Savings Plans. The
+// concrete implementation is savingsplans.Client.PurchaseCommitment, which
+// derives the order alias name from opts.IdempotencyToken so a re-driven
+// purchase re-PUTs the same alias instead of double-buying.
+type spPurchaser interface {
+	PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error)
+}
+
+// exchangeClient is the narrow interface for the two-step reservation
+// exchange backing ReshapeBuffer. The concrete implementation is
+// compute.ComputeClient.
+type exchangeClient interface {
+	CalculateExchange(ctx context.Context, sources []compute.ExchangeableReservation, targets []compute.ExchangeTarget) (*compute.ExchangePreview, []compute.CompatibleOffering, error)
+	ExecuteExchange(ctx context.Context, sessionID string) (*compute.ExchangeResult, error)
+}
+
+// targetSource supplies the candidate SKUs an under-utilized reservation can
+// be exchanged into. The concrete implementation is
+// compute.ComputeClient.GetRecommendations (Azure's own VM reservation
+// recommendations for the subscription).
+type targetSource interface {
+	GetRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error)
+}
@@ -0,0 +1,173 @@
+package ladder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// DefaultHorizonDays is the number of days ahead used to classify a
+// commitment as "expiring soon" in GetLayerStates.ExpiringUSDPerHour.
+// Callers that need a different window pass it via Config.HorizonDays.
+const DefaultHorizonDays = 30
+
+// DefaultLookbackDays is the number of days used for utilization queries
+// when Config.LookbackDays is zero.
+const DefaultLookbackDays = 30
+
+// errWriteNotWired is the sentinel returned by PurchaseLayer and ReshapeBuffer
+// when the write-side dependencies have not been wired via WithWriteSide.
+// It is distinct from common.ErrCommitmentPurchaseNotSupported: the
+// capability exists, the instance is just missing its write wiring.
+var errWriteNotWired = errors.New("write side not wired: wire reservationPurchaser, spPurchaser, exchangeClient, and targetSource via WithWriteSide before calling PurchaseLayer or ReshapeBuffer")
+
+// ErrLadderExecutionDisabled is returned by PurchaseLayer and ReshapeBuffer when
+// the ladder has been wired with a disabled write side (ladder_execution_enabled=false
+// in global_config). Use errors.Is(err, ErrLadderExecutionDisabled) to distinguish
+// this from errWriteNotWired (missing wiring = programming error at the call site).
+var ErrLadderExecutionDisabled = errors.New("ladder write side disabled: set ladder_execution_enabled=true in global_config to enable purchases and reshapes")
+
+// Config holds construction-time parameters for AzureLadder.
+type Config struct {
+	// SubscriptionID is the Azure subscription this ladder instance is
+	// scoped to. It is the ladder Scope.AccountID.
+	SubscriptionID string
+	// HorizonDays is the look-ahead window (in days) used to classify a
+	// commitment as expiring soon in ExpiringUSDPerHour. When zero,
+	// DefaultHorizonDays is applied.
+	HorizonDays int
+	// LookbackDays is the history window (in days) for utilization
+	// queries. When zero, DefaultLookbackDays is applied.
+	LookbackDays int
+}
+
+// horizonDays returns the effective horizon, applying the default when unset.
+func (c Config) horizonDays() int {
+	if c.HorizonDays > 0 {
+		return c.HorizonDays
+	}
+	return DefaultHorizonDays
+}
+
+// lookbackDays returns the effective lookback, applying the default when unset.
+func (c Config) lookbackDays() int {
+	if c.LookbackDays > 0 {
+		return c.LookbackDays
+	}
+	return DefaultLookbackDays
+}
+
+// billingScopeID is the ARM scope reservations and Savings Plans owned by
+// this subscription are billed to.
+func (c Config) billingScopeID() string {
+	return "/subscriptions/" + c.SubscriptionID
+}
+
+// AzureLadder implements ladder.LadderCapability for one Azure subscription:
+// the read side (ListCommitments, GetLayerStates, GetUsageBaseline) and the
+// write side (PurchaseLayer, ReshapeBuffer).
+//
+// Unlike AWSLadder, an Azure ladder is not region-scoped: reservations and
+// Savings Plans are listed per billing subscription and the on-demand series
+// covers every region of the subscription.
+//
+// The write-side dependencies are wired via WithWriteSide; until then
+// PurchaseLayer and ReshapeBuffer fail loud with errWriteNotWired.
+//
+// Fields are ordered to minimize the GC pointer-scan range (fieldalignment):
+// interface fields (all-pointer) come before Config.
+type AzureLadder struct {
+	reservations reservationLister
+	sps          spLister
+	pricer       reservationPricer
+	onDemand     onDemandSeriesSource
+	utilization  utilizationSource
+	resPurchase  reservationPurchaser // write side; nil until WithWriteSide is called
+	spPurchase   spPurchaser          // write side; nil until WithWriteSide is called
+	exchange     exchangeClient       // write side; nil until WithWriteSide is called
+	targets      targetSource         // write side; nil until WithWriteSide is called
+	cfg          Config
+}
+
+// New constructs an AzureLadder. All five read-side interfaces must be
+// non-nil.
+func New(
+	cfg Config,
+	reservations reservationLister,
+	sps spLister,
+	pricer reservationPricer,
+	odSeries onDemandSeriesSource,
+	util utilizationSource,
+) (*AzureLadder, error) {
+	if cfg.SubscriptionID == "" {
+		return nil, fmt.Errorf("AzureLadder: Config.SubscriptionID must not be empty")
+	}
+	if reservations == nil {
+		return nil, fmt.Errorf("AzureLadder: reservationLister must not be nil")
+	}
+	if sps == nil {
+		return nil, fmt.Errorf("AzureLadder: spLister must not be nil")
+	}
+	if pricer == nil {
+		return nil, fmt.Errorf("AzureLadder: reservationPricer must not be nil")
+	}
+	if odSeries == nil {
+		return nil, fmt.Errorf("AzureLadder: onDemandSeriesSource must not be nil")
+	}
+	if util == nil {
+		return nil, fmt.Errorf("AzureLadder: utilizationSource must not be nil")
+	}
+	return &AzureLadder{
+		cfg:          cfg,
+		reservations: reservations,
+		sps:          sps,
+		pricer:       pricer,
+		onDemand:     odSeries,
+		utilization:  util,
+	}, nil
+}
+
+// Provider returns common.ProviderAzure to identify this implementation.
+func (a *AzureLadder) Provider() common.ProviderType {
+	return common.ProviderAzure
+}
+
+// SupportedLayers returns the two Azure ladder layers:
+//   - LayerAzureReservation carries RoleBase and RoleBuffer: an
+//     instance-size-flexible VM reservation is both the stable base and,
+//     because Azure lets it be exchanged, the reshapeable buffer.
+//   - LayerAzureSavingsPlan carries RoleFlex (compute Savings Plans).
+//
+// The base+buffer merge is the one multi-role layer the engine permits.
+func (a *AzureLadder) SupportedLayers() []ladder.LayerSpec {
+	return ladder.AzureLayers()
+}
+
+// WithWriteSide wires the write-side dependencies and returns the same
+// instance for chaining. All four must be non-nil: a partially wired write
+// side would let one write method work while its sibling fails at call time.
+//
+// resP purchases VM reservations (LayerAzureReservation); spP purchases
+// Savings Plans (LayerAzureSavingsPlan); ex prices and executes reservation
+// exchanges and targets supplies exchange destinations for ReshapeBuffer.
+func (a *AzureLadder) WithWriteSide(resP reservationPurchaser, spP spPurchaser, ex exchangeClient, targets targetSource) (*AzureLadder, error) {
+	if resP == nil {
+		return nil, fmt.Errorf("AzureLadder.WithWriteSide: reservationPurchaser must not be nil")
+	}
+	if spP == nil {
+		return nil, fmt.Errorf("AzureLadder.WithWriteSide: spPurchaser must not be nil")
+	}
+	if ex == nil {
+		return nil, fmt.Errorf("AzureLadder.WithWriteSide: exchangeClient must not be nil")
+	}
+	if targets == nil {
+		return nil, fmt.Errorf("AzureLadder.WithWriteSide: targetSource must not be nil")
+	}
+	a.resPurchase = resP
+	a.spPurchase = spP
+	a.exchange = ex
+	a.targets = targets
+	return a, nil
+}
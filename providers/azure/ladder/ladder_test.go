@@ -0,0 +1,277 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+const testSub = "sub-123"
+
+var testScope = ladder.Scope{Provider: common.ProviderAzure, AccountID: testSub}
+
+// --- fakes ---
+
+type fakeReservationLister struct {
+	res []compute.ExchangeableReservation
+	err error
+}
+
+func (f *fakeReservationLister) ListExchangeableReservations(context.Context) ([]compute.ExchangeableReservation, error) {
+	return f.res, f.err
+}
+
+type fakeSPLister struct {
+	sps []ActiveSP
+	err error
+}
+
+func (f *fakeSPLister) ListActiveSPs(context.Context) ([]ActiveSP, error) { return f.sps, f.err }
+
+type fakePricer struct {
+	rate  float64
+	err   error
+	calls int
+}
+
+func (f *fakePricer) HourlyRate(context.Context, string, string, string) (float64, error) {
+	f.calls++
+	return f.rate, f.err
+}
+
+type fakeUtilization struct {
+	utils []common.RIUtilization
+	err   error
+}
+
+func (f *fakeUtilization) GetRIUtilization(context.Context, int) ([]common.RIUtilization, error) {
+	return f.utils, f.err
+}
+
+type fakeSeries struct {
+	points []ladder.DailyPoint
+	err    error
+}
+
+func (f *fakeSeries) GetOnDemandSeries(context.Context, int) ([]ladder.DailyPoint, error) {
+	return f.points, f.err
+}
+
+type fakes struct {
+	res    *fakeReservationLister
+	sps    *fakeSPLister
+	pricer *fakePricer
+	series *fakeSeries
+	util   *fakeUtilization
+}
+
+func newFakes() *fakes {
+	return &fakes{
+		res:    &fakeReservationLister{},
+		sps:    &fakeSPLister{},
+		pricer: &fakePricer{rate: 0.5},
+		series: &fakeSeries{},
+		util:   &fakeUtilization{},
+	}
+}
+
+func newTestLadder(t *testing.T, f *fakes) *AzureLadder {
+	t.Helper()
+	l, err := New(Config{SubscriptionID: testSub}, f.res, f.sps, f.pricer, f.series, f.util)
+	require.NoError(t, err)
+	return l
+}
+
+func ownedReservation(guid, sku string, qty int32, expiry time.Time) compute.ExchangeableReservation {
+	return compute.ExchangeableReservation{
+		ReservationOrderID: "order-" + guid,
+		ReservationID:      "/providers/Microsoft.Capacity/reservationOrders/order-" + guid + "/reservations/" + guid,
+		BillingScopeID:     "/subscriptions/" + testSub,
+		SKU:                sku,
+		Quantity:           qty,
+		Region:             "eastus",
+		Term:               "P1Y",
+		ExpiryDate:         expiry,
+	}
+}
+
+// --- construction ---
+
+func TestNew_RejectsMissingDependencies(t *testing.T) {
+	f := newFakes()
+	cases := []struct {
+		name string
+		call func() (*AzureLadder, error)
+		want string
+	}{
+		{"subscription", func() (*AzureLadder, error) { return New(Config{}, f.res, f.sps, f.pricer, f.series, f.util) }, "SubscriptionID"},
+		{"lister", func() (*AzureLadder, error) {
+			return New(Config{SubscriptionID: testSub}, nil, f.sps, f.pricer, f.series, f.util)
+		}, "reservationLister"},
+		{"sps", func() (*AzureLadder, error) {
+			return New(Config{SubscriptionID: testSub}, f.res, nil, f.pricer, f.series, f.util)
+		}, "spLister"},
+		{"pricer", func() (*AzureLadder, error) {
+			return New(Config{SubscriptionID: testSub}, f.res, f.sps, nil, f.series, f.util)
+		}, "reservationPricer"},
+		{"series", func() (*AzureLadder, error) {
+			return New(Config{SubscriptionID: testSub}, f.res, f.sps, f.pricer, nil, f.util)
+		}, "onDemandSeriesSource"},
+		{"util", func() (*AzureLadder, error) {
+			return New(Config{SubscriptionID: testSub}, f.res, f.sps, f.pricer, f.series, nil)
+		}, "utilizationSource"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.call()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestSupportedLayers_MergesBaseAndBufferOnReservations(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	assert.Equal(t, common.ProviderAzure, l.Provider())
+	assert.Equal(t, []ladder.LayerSpec{
+		{Type: ladder.LayerAzureReservation, Roles: []ladder.LayerRole{ladder.RoleBase, ladder.RoleBuffer}},
+		{Type: ladder.LayerAzureSavingsPlan, Roles: []ladder.LayerRole{ladder.RoleFlex}},
+	}, l.SupportedLayers())
+}
+
+func TestValidateScope(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	_, err := l.ListCommitments(context.Background(), ladder.Scope{Provider: common.ProviderAWS, AccountID: testSub})
+	require.Error(t, err)
+	_, err = l.ListCommitments(context.Background(), ladder.Scope{Provider: common.ProviderAzure, AccountID: "other"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+}
+
+// --- ListCommitments ---
+
+func TestListCommitments_OwnedReservationsAndSPs(t *testing.T) {
+	f := newFakes()
+	foreign := ownedReservation("g2", "Standard_D4s_v3", 1, time.Time{})
+	foreign.BillingScopeID = "/subscriptions/other"
+	unowned := ownedReservation("g3", "Standard_D4s_v3", 1, time.Time{})
+	unowned.BillingScopeID = ""
+	f.res.res = []compute.ExchangeableReservation{ownedReservation("g1", "Standard_D2s_v3", 4, time.Time{}), foreign, unowned}
+	f.sps.sps = []ActiveSP{{PlanID: "sp-1", PlanType: spPlanTypeCompute, State: "Succeeded", HourlyCommitmentUSD: 3}}
+	l := newTestLadder(t, f)
+
+	got, err := l.ListCommitments(context.Background(), testScope)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "Standard_D2s_v3", got[0].ResourceType)
+	assert.InDelta(t, 2.0, got[0].Cost, 1e-9) // 0.5/hr x 4
+	assert.Equal(t, common.CommitmentReservedInstance, got[0].CommitmentType)
+	assert.Equal(t, common.CommitmentSavingsPlan, got[1].CommitmentType)
+	assert.InDelta(t, 3.0, got[1].Cost, 1e-9)
+	assert.Equal(t, 1, f.pricer.calls, "foreign and unowned reservations must not be priced")
+}
+
+func TestListCommitments_PricingFailureFailsLoud(t *testing.T) {
+	f := newFakes()
+	f.res.res = []compute.ExchangeableReservation{ownedReservation("g1", "Standard_D2s_v3", 1, time.Time{})}
+	f.pricer.err = errors.New("no price")
+	l := newTestLadder(t, f)
+
+	_, err := l.ListCommitments(context.Background(), testScope)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no price")
+}
+
+// --- GetLayerStates ---
+
+func TestGetLayerStates(t *testing.T) {
+	f := newFakes()
+	soon := time.Now().Add(10 * 24 * time.Hour)
+	later := time.Now().Add(200 * 24 * time.Hour)
+	f.res.res = []compute.ExchangeableReservation{
+		ownedReservation("g1", "Standard_D2s_v3", 2, soon),
+		ownedReservation("g2", "Standard_D2s_v3", 2, later),
+	}
+	f.sps.sps = []ActiveSP{
+		{PlanID: "sp-1", HourlyCommitmentUSD: 5, EndDate: soon},
+		{PlanID: "sp-2", HourlyCommitmentUSD: 7, EndDate: later},
+	}
+	f.util.utils = []common.RIUtilization{
+		{ReservedInstanceID: "G1", PurchasedHours: 100, TotalActualHours: 50},
+		{ReservedInstanceID: "g2", PurchasedHours: 100, TotalActualHours: 100},
+		// Not owned by this ladder: must not dilute the aggregate.
+		{ReservedInstanceID: "other", PurchasedHours: 1000, TotalActualHours: 0},
+	}
+	l := newTestLadder(t, f)
+
+	states, err := l.GetLayerStates(context.Background(), testScope)
+	require.NoError(t, err)
+
+	res := states[ladder.LayerAzureReservation]
+	require.NotNil(t, res.ExistingUSDPerHour)
+	assert.InDelta(t, 2.0, *res.ExistingUSDPerHour, 1e-9)
+	assert.InDelta(t, 1.0, *res.ExpiringUSDPerHour, 1e-9)
+	require.NotNil(t, res.UtilizationPct)
+	assert.InDelta(t, 75.0, *res.UtilizationPct, 1e-9)
+	assert.Nil(t, res.CoveragePct)
+
+	sp := states[ladder.LayerAzureSavingsPlan]
+	assert.InDelta(t, 12.0, *sp.ExistingUSDPerHour, 1e-9)
+	assert.InDelta(t, 5.0, *sp.ExpiringUSDPerHour, 1e-9)
+	assert.Nil(t, sp.UtilizationPct)
+}
+
+func TestGetLayerStates_EmptyLayersAreExplicitZeros(t *testing.T) {
+	f := newFakes()
+	f.util.err = errors.New("consumption throttled")
+	l := newTestLadder(t, f)
+
+	states, err := l.GetLayerStates(context.Background(), testScope)
+	require.NoError(t, err, "a utilization failure degrades to nil rather than failing the snapshot")
+	for _, layer := range []ladder.LayerType{ladder.LayerAzureReservation, ladder.LayerAzureSavingsPlan} {
+		s := states[layer]
+		require.NotNil(t, s.ExistingUSDPerHour, layer)
+		require.NotNil(t, s.ExpiringUSDPerHour, layer)
+		assert.Zero(t, *s.ExistingUSDPerHour, layer)
+		assert.Nil(t, s.UtilizationPct, layer)
+	}
+}
+
+// --- GetUsageBaseline ---
+
+func TestGetUsageBaseline(t *testing.T) {
+	f := newFakes()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := 30; i >= 1; i-- {
+		f.series.points = append(f.series.points, ladder.DailyPoint{Date: today.AddDate(0, 0, -i), USDPerHour: float64(31 - i)})
+	}
+	l := newTestLadder(t, f)
+
+	got, err := l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.NoError(t, err)
+	require.NotNil(t, got.LowWaterUSDPerHour)
+	assert.InDelta(t, 3.0, *got.LowWaterUSDPerHour, 1e-9)
+	assert.Nil(t, got.StableUSDPerHour)
+}
+
+func TestGetUsageBaseline_SeriesErrors(t *testing.T) {
+	f := newFakes()
+	f.series.err = errors.New("usage details unavailable")
+	l := newTestLadder(t, f)
+	_, err := l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "usage details unavailable")
+
+	f.series.err = nil
+	_, err = l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "empty")
+}
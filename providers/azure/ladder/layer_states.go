@@ -0,0 +1,151 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// ptr wraps a float64 as a non-nil pointer. Used to convert derived metrics
+// into the pointer form required by LayerState.
+func ptr(v float64) *float64 { return &v }
+
+// GetLayerStates returns a point-in-time snapshot for both Azure ladder
+// layers:
+//
+//   - ExistingUSDPerHour: explicit zero when the layer has no commitments;
+//     the summed hourly cost otherwise (reservations are priced from the
+//     Retail Prices API, Savings Plans carry their hourly commitment).
+//   - ExpiringUSDPerHour: the share expiring within Config.HorizonDays.
+//   - UtilizationPct (reservation layer): from the Consumption reservation
+//     summaries, restricted to the reservations this ladder owns. Nil when
+//     the source fails (logged) or reports no hours.
+//   - CoveragePct: nil for both layers. Azure exposes no per-subscription
+//     coverage figure equivalent to the CE coverage APIs; the engine treats
+//     nil as unmeasured.
+//   - UtilizationPct (Savings Plan layer): nil, not yet measured.
+//
+// The scope must match Config.SubscriptionID and common.ProviderAzure.
+func (a *AzureLadder) GetLayerStates(ctx context.Context, scope ladder.Scope) (map[ladder.LayerType]ladder.LayerState, error) {
+	if err := a.validateScope(scope); err != nil {
+		return nil, err
+	}
+
+	reservations, err := a.listPricedReservations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetLayerStates: reservation listing failed: %w", err)
+	}
+
+	sps, err := a.sps.ListActiveSPs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetLayerStates: SP listing failed: %w", err)
+	}
+
+	utils, utilErr := a.utilization.GetRIUtilization(ctx, a.cfg.lookbackDays())
+	// utilErr degrades UtilizationPct to nil rather than failing the snapshot.
+
+	horizon := time.Now().Add(time.Duration(a.cfg.horizonDays()) * 24 * time.Hour)
+
+	states := make(map[ladder.LayerType]ladder.LayerState, 2)
+	states[ladder.LayerAzureReservation] = a.reservationLayerState(reservations, horizon, utils, utilErr)
+	states[ladder.LayerAzureSavingsPlan] = spLayerState(sps, horizon)
+	return states, nil
+}
+
+// reservationLayerState builds the LayerState for the reservation
+// (base+buffer) layer.
+func (a *AzureLadder) reservationLayerState(
+	reservations []pricedReservation,
+	horizon time.Time,
+	utils []common.RIUtilization,
+	utilErr error,
+) ladder.LayerState {
+	var existing, expiring float64
+	for i := range reservations {
+		r := &reservations[i]
+		existing += r.hourlyUSD
+		if !r.res.ExpiryDate.IsZero() && !r.res.ExpiryDate.After(horizon) {
+			expiring += r.hourlyUSD
+		}
+	}
+
+	state := ladder.LayerState{
+		Layer:              ladder.LayerAzureReservation,
+		ExistingUSDPerHour: ptr(existing),
+		ExpiringUSDPerHour: ptr(expiring),
+	}
+
+	if utilErr != nil {
+		// Log so a persistently failing Consumption call is visible: silent
+		// degradation would quietly disable reshape triggering downstream.
+		log.Printf("WARNING: AzureLadder GetLayerStates: reservation utilization degraded to nil (layer=%s, source=GetRIUtilization, subscription=%s): %v",
+			ladder.LayerAzureReservation, a.cfg.SubscriptionID, utilErr)
+		return state
+	}
+	state.UtilizationPct = computeUtilizationPct(utilsForReservations(utils, reservations))
+	return state
+}
+
+// spLayerState builds the LayerState for the Savings Plan (flex) layer.
+func spLayerState(sps []ActiveSP, horizon time.Time) ladder.LayerState {
+	var existing, expiring float64
+	for i := range sps {
+		existing += sps[i].HourlyCommitmentUSD
+		if !sps[i].EndDate.IsZero() && !sps[i].EndDate.After(horizon) {
+			expiring += sps[i].HourlyCommitmentUSD
+		}
+	}
+	return ladder.LayerState{
+		Layer:              ladder.LayerAzureSavingsPlan,
+		ExistingUSDPerHour: ptr(existing),
+		ExpiringUSDPerHour: ptr(expiring),
+	}
+}
+
+// reservationGUID returns the last path segment of a reservation ARM ID
+// (".../reservationOrders/{order}/reservations/{guid}"), the identifier the
+// Consumption reservation summaries key utilization by.
+func reservationGUID(reservationID string) string {
+	if i := strings.LastIndex(reservationID, "/"); i >= 0 {
+		return reservationID[i+1:]
+	}
+	return reservationID
+}
+
+// utilsForReservations filters utilization entries down to the reservations
+// this ladder owns. The summaries are subscription-scoped but still include
+// non-flexible reservations and reservations for other resource types, which
+// this layer does not track.
+func utilsForReservations(utils []common.RIUtilization, reservations []pricedReservation) []common.RIUtilization {
+	ids := make(map[string]struct{}, len(reservations))
+	for i := range reservations {
+		ids[strings.ToLower(reservationGUID(reservations[i].res.ReservationID))] = struct{}{}
+	}
+	filtered := make([]common.RIUtilization, 0, len(utils))
+	for i := range utils {
+		if _, ok := ids[strings.ToLower(utils[i].ReservedInstanceID)]; ok {
+			filtered = append(filtered, utils[i])
+		}
+	}
+	return filtered
+}
+
+// computeUtilizationPct aggregates per-reservation utilization as
+// sum(used hours) / sum(reserved hours) * 100. Returns nil when no reserved
+// hours were reported (an empty layer is genuinely unmeasured).
+func computeUtilizationPct(utils []common.RIUtilization) *float64 {
+	var purchased, actual float64
+	for i := range utils {
+		purchased += utils[i].PurchasedHours
+		actual += utils[i].TotalActualHours
+	}
+	if purchased == 0 {
+		return nil
+	}
+	return ptr((actual / purchased) * 100.0)
+}
@@ -0,0 +1,117 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// PurchaseLayer buys a commitment for the given layer by dispatching to the
+// injected purchase client:
+//
+//   - LayerAzureReservation -> reservationPurchaser (VM reservation via the
+//     two-step calculatePrice/purchase flow, bought in rec.Region)
+//   - LayerAzureSavingsPlan -> spPurchaser (compute Savings Plan order alias)
+//
+// Boundary validation happens BEFORE any client call (this is a money path;
+// nothing is bought on malformed input):
+//
+//   - layer must be one of the two Azure layers;
+//   - opts.IdempotencyToken must be non-empty: both clients derive their
+//     re-drive dedupe (reservation tag lookup, order alias name) from it;
+//   - rec must carry what the target client needs (see
+//     validateReservationPurchaseRec / validateSPPurchaseRec).
+//
+// Client errors are wrapped with layer context via %w, and the client's
+// PurchaseResult is returned alongside the error.
+func (a *AzureLadder) PurchaseLayer(ctx context.Context, layer ladder.LayerType, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	if a.resPurchase == nil || a.spPurchase == nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: %w", errWriteNotWired)
+	}
+	if layer != ladder.LayerAzureReservation && layer != ladder.LayerAzureSavingsPlan {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: layer %q is not a supported Azure ladder layer (want %s or %s)",
+			layer, ladder.LayerAzureReservation, ladder.LayerAzureSavingsPlan)
+	}
+	if opts.IdempotencyToken == "" {
+		return common.PurchaseResult{}, fmt.Errorf(
+			"PurchaseLayer(%s): opts.IdempotencyToken must not be empty: idempotency is mandatory on the ladder purchase path so re-driven executions cannot double-buy",
+			layer)
+	}
+
+	if layer == ladder.LayerAzureReservation {
+		if err := validateReservationPurchaseRec(&rec, opts); err != nil {
+			return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer(%s): %w", layer, err)
+		}
+		result, err := a.resPurchase.PurchaseCommitment(ctx, rec, opts)
+		if err != nil {
+			return result, fmt.Errorf("PurchaseLayer(%s): VM reservation purchase failed: %w", layer, err)
+		}
+		return result, nil
+	}
+
+	if err := validateSPPurchaseRec(&rec); err != nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer(%s): %w", layer, err)
+	}
+	result, err := a.spPurchase.PurchaseCommitment(ctx, rec, opts)
+	if err != nil {
+		return result, fmt.Errorf("PurchaseLayer(%s): Savings Plan purchase failed: %w", layer, err)
+	}
+	return result, nil
+}
+
+// validateReservationPurchaseRec checks that rec carries everything the
+// compute client's PurchaseCommitment needs. The client builds the purchase
+// body from ResourceType (SKU), Count (quantity), Term and PaymentOption
+// (billing plan), and the reservation location from the region its client
+// was created for, which the purchaser routes from rec.Region. opts.Source
+// is required by the client for attribution; checking it here keeps the
+// failure ahead of any client construction.
+func validateReservationPurchaseRec(rec *common.Recommendation, opts common.PurchaseOptions) error {
+	if rec.ResourceType == "" {
+		return fmt.Errorf("recommendation ResourceType (VM size) must not be empty for a VM reservation purchase")
+	}
+	if rec.Region == "" {
+		return fmt.Errorf("recommendation Region must not be empty for a VM reservation purchase (it is the reservation location)")
+	}
+	if rec.Count <= 0 {
+		return fmt.Errorf("recommendation Count must be > 0 for a VM reservation purchase, got %d", rec.Count)
+	}
+	if opts.Source == "" {
+		return fmt.Errorf("opts.Source must not be empty for a VM reservation purchase (it tags the reservation for attribution)")
+	}
+	return validateTermAndPayment(rec)
+}
+
+// validateSPPurchaseRec checks that rec carries everything the Savings
+// Plans client needs: *SavingsPlanDetails (the client type-asserts the
+// pointer form) with the compute plan type and a positive, finite
+// HourlyCommitment, plus the term and payment option.
+func validateSPPurchaseRec(rec *common.Recommendation) error {
+	details, ok := rec.Details.(*common.SavingsPlanDetails)
+	if !ok || details == nil {
+		return fmt.Errorf("recommendation Details must be *common.SavingsPlanDetails for a Savings Plan purchase, got %T", rec.Details)
+	}
+	if details.PlanType != spPlanTypeCompute {
+		return fmt.Errorf("recommendation plan type %q is not the Azure compute Savings Plan type %q", details.PlanType, spPlanTypeCompute)
+	}
+	if math.IsNaN(details.HourlyCommitment) || math.IsInf(details.HourlyCommitment, 0) || details.HourlyCommitment <= 0 {
+		return fmt.Errorf("SavingsPlanDetails.HourlyCommitment must be a positive finite value, got %g", details.HourlyCommitment)
+	}
+	return validateTermAndPayment(rec)
+}
+
+// validateTermAndPayment checks the two fields shared by both purchase
+// paths. An empty Term would be silently read as one year by the Savings
+// Plans client's term mapping, so it is rejected here.
+func validateTermAndPayment(rec *common.Recommendation) error {
+	if rec.Term == "" {
+		return fmt.Errorf("recommendation Term must not be empty")
+	}
+	if rec.PaymentOption == "" {
+		return fmt.Errorf("recommendation PaymentOption must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+type fakePurchaser struct {
+	err   error
+	calls []common.Recommendation
+}
+
+func (f *fakePurchaser) PurchaseCommitment(_ context.Context, rec common.Recommendation, _ common.PurchaseOptions) (common.PurchaseResult, error) {
+	f.calls = append(f.calls, rec)
+	return common.PurchaseResult{Recommendation: rec, Success: f.err == nil}, f.err
+}
+
+func wiredLadder(t *testing.T, f *fakes, resP, spP *fakePurchaser, ex *fakeExchange) *AzureLadder {
+	t.Helper()
+	l, err := newTestLadder(t, f).WithWriteSide(resP, spP, ex, ex)
+	require.NoError(t, err)
+	return l
+}
+
+func validReservationRec() common.Recommendation {
+	return common.Recommendation{
+		ResourceType:  "Standard_D2s_v3",
+		Region:        "eastus",
+		Count:         2,
+		Term:          "1yr",
+		PaymentOption: "upfront",
+	}
+}
+
+func validSPRec() common.Recommendation {
+	return common.Recommendation{
+		Term:          "1yr",
+		PaymentOption: "monthly",
+		Details:       &common.SavingsPlanDetails{PlanType: spPlanTypeCompute, HourlyCommitment: 1.5},
+	}
+}
+
+var purchaseOpts = common.PurchaseOptions{Source: "cudly-ladder", IdempotencyToken: "tok-1"}
+
+func TestPurchaseLayer_NotWired(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	_, err := l.PurchaseLayer(context.Background(), ladder.LayerAzureReservation, validReservationRec(), purchaseOpts)
+	require.ErrorIs(t, err, errWriteNotWired)
+}
+
+func TestPurchaseLayer_Dispatch(t *testing.T) {
+	resP, spP := &fakePurchaser{}, &fakePurchaser{}
+	l := wiredLadder(t, newFakes(), resP, spP, &fakeExchange{})
+
+	_, err := l.PurchaseLayer(context.Background(), ladder.LayerAzureReservation, validReservationRec(), purchaseOpts)
+	require.NoError(t, err)
+	_, err = l.PurchaseLayer(context.Background(), ladder.LayerAzureSavingsPlan, validSPRec(), purchaseOpts)
+	require.NoError(t, err)
+
+	assert.Len(t, resP.calls, 1)
+	assert.Len(t, spP.calls, 1)
+}
+
+func TestPurchaseLayer_RejectsBeforeAnyClientCall(t *testing.T) {
+	cases := []struct {
+		name  string
+		layer ladder.LayerType
+		rec   func() common.Recommendation
+		opts  common.PurchaseOptions
+		want  string
+	}{
+		{"unknown layer", ladder.LayerComputeSP, validReservationRec, purchaseOpts, "not a supported Azure ladder layer"},
+		{"no idempotency token", ladder.LayerAzureReservation, validReservationRec, common.PurchaseOptions{Source: "x"}, "IdempotencyToken"},
+		{"no source", ladder.LayerAzureReservation, validReservationRec, common.PurchaseOptions{IdempotencyToken: "t"}, "opts.Source"},
+		{"no region", ladder.LayerAzureReservation, func() common.Recommendation {
+			r := validReservationRec()
+			r.Region = ""
+			return r
+		}, purchaseOpts, "Region"},
+		{"zero count", ladder.LayerAzureReservation, func() common.Recommendation {
+			r := validReservationRec()
+			r.Count = 0
+			return r
+		}, purchaseOpts, "Count"},
+		{"no term", ladder.LayerAzureReservation, func() common.Recommendation {
+			r := validReservationRec()
+			r.Term = ""
+			return r
+		}, purchaseOpts, "Term"},
+		{"sp wrong details", ladder.LayerAzureSavingsPlan, validReservationRec, purchaseOpts, "*common.SavingsPlanDetails"},
+		{"sp wrong plan type", ladder.LayerAzureSavingsPlan, func() common.Recommendation {
+			r := validSPRec()
+			r.Details = &common.SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 1}
+			return r
+		}, purchaseOpts, "plan type"},
+		{"sp zero commitment", ladder.LayerAzureSavingsPlan, func() common.Recommendation {
+			r := validSPRec()
+			r.Details = &common.SavingsPlanDetails{PlanType: spPlanTypeCompute}
+			return r
+		}, purchaseOpts, "HourlyCommitment"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resP, spP := &fakePurchaser{}, &fakePurchaser{}
+			l := wiredLadder(t, newFakes(), resP, spP, &fakeExchange{})
+			_, err := l.PurchaseLayer(context.Background(), tc.layer, tc.rec(), tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+			assert.Empty(t, resP.calls)
+			assert.Empty(t, spP.calls)
+		})
+	}
+}
+
+func TestPurchaseLayer_WrapsClientError(t *testing.T) {
+	resP := &fakePurchaser{err: common.ErrCommitmentPurchaseNotSupported}
+	l := wiredLadder(t, newFakes(), resP, &fakePurchaser{}, &fakeExchange{})
+	_, err := l.PurchaseLayer(context.Background(), ladder.LayerAzureReservation, validReservationRec(), purchaseOpts)
+	require.ErrorIs(t, err, common.ErrCommitmentPurchaseNotSupported)
+}
+
+func TestWireWriteSideDisabled(t *testing.T) {
+	l, err := WireWriteSideDisabled(newTestLadder(t, newFakes()))
+	require.NoError(t, err)
+
+	_, err = l.PurchaseLayer(context.Background(), ladder.LayerAzureReservation, validReservationRec(), purchaseOpts)
+	require.ErrorIs(t, err, ErrLadderExecutionDisabled)
+	assert.False(t, errors.Is(err, errWriteNotWired))
+}
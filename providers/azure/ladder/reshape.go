@@ -0,0 +1,319 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// unlimitedCapUSD is the "no cap" value a nil BufferReshapeConfig cap maps
+// to. Zero cannot serve: a zero cap would block every exchange.
+const unlimitedCapUSD = math.MaxFloat64
+
+// reshapeRun carries the validated configuration and running state of one
+// ReshapeBuffer call.
+type reshapeRun struct {
+	summary        ladder.ReshapeSummary
+	perExchangeCap float64
+	dailyCap       float64
+	capsSet        bool
+	spentUSD       float64
+	failed         int
+	firstFailure   string
+	usedTargets    map[string]struct{}
+}
+
+// ReshapeBuffer exchanges under-utilized VM reservations owned by the
+// subscription into the SKUs Azure currently recommends for it. For each
+// owned reservation whose utilization over cfg.LookbackDays is below
+// cfg.UtilizationThresholdPct:
+//
+//  1. pick the unused recommendation with the highest estimated savings on
+//     the same term, excluding the reservation's own SKU and region;
+//  2. price the swap with CalculateExchange (the reservation is refunded,
+//     the recommended quantity is purchased, billed to this subscription);
+//  3. apply the money guardrails and, unless cfg.DryRun, ExecuteExchange
+//     the priced session.
+//
+// Guardrails (a tripped guardrail is a skip, never a failure):
+//
+//   - Azure policy errors on the preview: Azure would reject the exchange
+//     (e.g. the purchase total is below the refund).
+//   - NetPayable nil: an absent amount is not "free".
+//   - NetPayableCurrency other than USD while a cap is set: the caps are
+//     USD and no FX conversion is performed.
+//   - NetPayable above MaxPaymentPerExchangeUSD.
+//   - Cumulative NetPayable above MaxPaymentDailyUSD. The daily cap is
+//     call-scoped: it sums the exchanges made by this call only, so
+//     exchanges executed elsewhere the same day (e.g. the manual Azure
+//     exchange endpoint) do not count against it.
+//
+// Cap mapping matches AWSLadder: nil means no cap; a set cap must be finite
+// and > 0. UtilizationThresholdPct must be in (0, 100] and LookbackDays > 0.
+//
+// Outcome mapping: Analyzed counts the owned reservations inspected;
+// Reshaped counts executed exchanges (and simulated ones under DryRun);
+// Skipped counts under-utilized reservations left alone by a guardrail or for
+// lack of a target. Failed calculate/execute calls are neither: they appear
+// in Details and the populated summary is returned together with a non-nil
+// error. Context cancellation aborts the run immediately.
+func (a *AzureLadder) ReshapeBuffer(ctx context.Context, scope ladder.Scope, cfg ladder.BufferReshapeConfig) (ladder.ReshapeSummary, error) {
+	if a.exchange == nil || a.targets == nil {
+		return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: %w", errWriteNotWired)
+	}
+	if err := a.validateScope(scope); err != nil {
+		return ladder.ReshapeSummary{}, err
+	}
+	run, err := newReshapeRun(cfg)
+	if err != nil {
+		return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: %w", err)
+	}
+
+	owned, err := a.listOwnedReservations(ctx)
+	if err != nil {
+		return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: reservation listing failed: %w", err)
+	}
+	utils, err := a.utilization.GetRIUtilization(ctx, cfg.LookbackDays)
+	if err != nil {
+		return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: reservation utilization failed: %w", err)
+	}
+	candidates, err := a.targets.GetRecommendations(ctx, &common.RecommendationParams{Service: common.ServiceCompute})
+	if err != nil {
+		return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: exchange target lookup failed: %w", err)
+	}
+
+	utilByGUID := make(map[string]common.RIUtilization, len(utils))
+	for i := range utils {
+		utilByGUID[strings.ToLower(utils[i].ReservedInstanceID)] = utils[i]
+	}
+
+	run.summary.Analyzed = len(owned)
+	for i := range owned {
+		r := &owned[i]
+		u, ok := utilByGUID[strings.ToLower(reservationGUID(r.ReservationID))]
+		if !ok || u.PurchasedHours == 0 {
+			// No measurement is not evidence of under-use; leave it alone.
+			continue
+		}
+		if u.UtilizationPercent >= cfg.UtilizationThresholdPct {
+			continue
+		}
+		if err := a.reshapeOne(ctx, run, r, u.UtilizationPercent, candidates, cfg.DryRun); err != nil {
+			return run.summary, fmt.Errorf("ReshapeBuffer: %w", err)
+		}
+	}
+
+	if run.failed > 0 {
+		return run.summary, fmt.Errorf(
+			"ReshapeBuffer: %d exchange attempt(s) failed (first: %s); see summary details for the full list",
+			run.failed, run.firstFailure)
+	}
+	return run.summary, nil
+}
+
+// newReshapeRun validates cfg at the boundary and initializes the run state.
+func newReshapeRun(cfg ladder.BufferReshapeConfig) (*reshapeRun, error) {
+	perExchangeCap, err := capOrUnlimited("MaxPaymentPerExchangeUSD", cfg.MaxPaymentPerExchangeUSD)
+	if err != nil {
+		return nil, err
+	}
+	dailyCap, err := capOrUnlimited("MaxPaymentDailyUSD", cfg.MaxPaymentDailyUSD)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(cfg.UtilizationThresholdPct) || cfg.UtilizationThresholdPct <= 0 || cfg.UtilizationThresholdPct > 100 {
+		return nil, fmt.Errorf("UtilizationThresholdPct must be in (0, 100], got %g", cfg.UtilizationThresholdPct)
+	}
+	if cfg.LookbackDays <= 0 {
+		return nil, fmt.Errorf("LookbackDays must be > 0, got %d", cfg.LookbackDays)
+	}
+	return &reshapeRun{
+		perExchangeCap: perExchangeCap,
+		dailyCap:       dailyCap,
+		capsSet:        cfg.MaxPaymentPerExchangeUSD != nil || cfg.MaxPaymentDailyUSD != nil,
+		usedTargets:    make(map[string]struct{}),
+	}, nil
+}
+
+// reshapeOne prices and (unless dryRun) executes the exchange for a single
+// under-utilized reservation, recording the outcome on run. It returns an
+// error only for context cancellation, which aborts the whole run.
+func (a *AzureLadder) reshapeOne(ctx context.Context, run *reshapeRun, r *compute.ExchangeableReservation, utilPct float64, candidates []common.Recommendation, dryRun bool) error {
+	label := fmt.Sprintf("%s (%s x%d, %.1f%% utilized)", r.ReservationID, r.SKU, r.Quantity, utilPct)
+
+	target, key, ok := PickExchangeTarget(candidates, r, run.usedTargets)
+	if !ok {
+		run.skip(label, "no recommended exchange target on the same term")
+		return nil
+	}
+	target.BillingScopeID = a.cfg.billingScopeID()
+
+	preview, _, err := a.exchange.CalculateExchange(ctx, []compute.ExchangeableReservation{*r}, []compute.ExchangeTarget{target})
+	if err != nil {
+		if isCtxErr(err) {
+			return err
+		}
+		run.fail(label, fmt.Sprintf("calculate exchange into %s x%d: %v", target.SKU, target.Quantity, err))
+		return nil
+	}
+	if reason := run.guardrailReason(preview); reason != "" {
+		run.skip(label, reason)
+		return nil
+	}
+
+	net := *preview.NetPayable
+	if dryRun {
+		run.reshaped(key, net, fmt.Sprintf("simulated (dry run): %s -> %s in %s x%d, net payable %.2f %s",
+			label, target.SKU, target.Location, target.Quantity, net, preview.NetPayableCurrency))
+		return nil
+	}
+
+	result, err := a.exchange.ExecuteExchange(ctx, preview.SessionID)
+	if err != nil {
+		if isCtxErr(err) {
+			return err
+		}
+		run.fail(label, fmt.Sprintf("execute exchange into %s x%d: %v", target.SKU, target.Quantity, err))
+		return nil
+	}
+	run.reshaped(key, net, fmt.Sprintf("reshaped: %s -> %s in %s x%d, net payable %.2f %s, status %s",
+		label, target.SKU, target.Location, target.Quantity, net, preview.NetPayableCurrency, result.Status))
+	return nil
+}
+
+// guardrailReason returns a non-empty skip reason when preview must not be
+// executed. See ReshapeBuffer's godoc for the rules.
+func (run *reshapeRun) guardrailReason(preview *compute.ExchangePreview) string {
+	switch {
+	case preview == nil:
+		return "Azure returned no exchange preview"
+	case len(preview.PolicyErrors) > 0:
+		return "Azure exchange policy rejected the exchange: " + strings.Join(preview.PolicyErrors, "; ")
+	case preview.NetPayable == nil:
+		return "Azure did not report a net payable amount"
+	case run.capsSet && !strings.EqualFold(preview.NetPayableCurrency, "USD"):
+		return fmt.Sprintf("net payable is in %q; payment caps are USD", preview.NetPayableCurrency)
+	}
+	net := *preview.NetPayable
+	if net > run.perExchangeCap {
+		return fmt.Sprintf("net payable %.2f exceeds the per-exchange cap %.2f", net, run.perExchangeCap)
+	}
+	if net > 0 && run.spentUSD+net > run.dailyCap {
+		return fmt.Sprintf("net payable %.2f would exceed the daily cap %.2f (%.2f already committed this run)", net, run.dailyCap, run.spentUSD)
+	}
+	return ""
+}
+
+func (run *reshapeRun) skip(label, reason string) {
+	run.summary.Skipped++
+	run.summary.Details = append(run.summary.Details, fmt.Sprintf("skipped: %s: %s", label, reason))
+}
+
+func (run *reshapeRun) fail(label, reason string) {
+	if run.failed == 0 {
+		run.firstFailure = label + ": " + reason
+	}
+	run.failed++
+	run.summary.Details = append(run.summary.Details, fmt.Sprintf("failed: %s: %s", label, reason))
+}
+
+func (run *reshapeRun) reshaped(targetKey string, net float64, detail string) {
+	run.summary.Reshaped++
+	if net > 0 {
+		run.spentUSD += net
+	}
+	run.usedTargets[targetKey] = struct{}{}
+	run.summary.Details = append(run.summary.Details, detail)
+}
+
+// PickExchangeTarget returns the recommendation with the highest estimated
+// savings that is on r's term, names a different SKU or region, has a
+// positive count, and whose key is not in used (the targets taken by earlier
+// exchanges in the same run). Recommendations are expanded per payment
+// option, so targets are keyed by SKU and region rather than by index; the
+// returned key is the one to add to used once the exchange goes ahead.
+//
+// The returned target has no BillingScopeID: the caller sets it to the
+// subscription it is exchanging for. Shared by ReshapeBuffer and the
+// standalone ri_exchange_reshape task.
+func PickExchangeTarget(candidates []common.Recommendation, r *compute.ExchangeableReservation, used map[string]struct{}) (compute.ExchangeTarget, string, bool) {
+	best := -1
+	for i := range candidates {
+		c := &candidates[i]
+		if c.ResourceType == "" || c.Region == "" || c.Count <= 0 || c.Count > math.MaxInt32 {
+			continue
+		}
+		if strings.EqualFold(c.ResourceType, r.SKU) && strings.EqualFold(c.Region, r.Region) {
+			continue
+		}
+		if term, err := reservationTerm(c.Term); err != nil || string(term) != r.Term {
+			continue
+		}
+		if _, taken := used[targetKey(c)]; taken {
+			continue
+		}
+		if best < 0 || c.EstimatedSavings > candidates[best].EstimatedSavings {
+			best = i
+		}
+	}
+	if best < 0 {
+		return compute.ExchangeTarget{}, "", false
+	}
+	c := &candidates[best]
+	term, _ := reservationTerm(c.Term)
+	return compute.ExchangeTarget{
+		SKU:      c.ResourceType,
+		Location: c.Region,
+		Term:     term,
+		Quantity: int32(c.Count),
+	}, targetKey(c), true
+}
+
+func targetKey(c *common.Recommendation) string {
+	return strings.ToLower(c.ResourceType + "|" + c.Region)
+}
+
+// reservationTerm maps a recommendation term ("1yr", "3yr", "5yr", or the
+// ISO forms) to the armreservations term enum.
+func reservationTerm(term string) (armreservations.ReservationTerm, error) {
+	switch strings.ToLower(strings.TrimSpace(term)) {
+	case "1yr", "1", "p1y":
+		return armreservations.ReservationTermP1Y, nil
+	case "3yr", "3", "p3y":
+		return armreservations.ReservationTermP3Y, nil
+	case "5yr", "5", "p5y":
+		return armreservations.ReservationTermP5Y, nil
+	default:
+		return "", fmt.Errorf("unsupported reservation term %q", term)
+	}
+}
+
+// capOrUnlimited maps an optional money cap: nil -> unlimitedCapUSD (no cap);
+// non-nil values must be finite and > 0.
+func capOrUnlimited(name string, capUSD *float64) (float64, error) {
+	if capUSD == nil {
+		return unlimitedCapUSD, nil
+	}
+	v := *capUSD
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s must be finite, got %g", name, v)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("%s must be > 0 when set (a zero cap would block every exchange; use nil for no cap), got %g", name, v)
+	}
+	return v, nil
+}
+
+// isCtxErr reports whether err is a context cancellation or deadline expiry,
+// which must abort the run rather than be recorded as one failed exchange.
+func isCtxErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
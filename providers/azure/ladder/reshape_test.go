@@ -0,0 +1,196 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
+)
+
+// fakeExchange implements exchangeClient and targetSource.
+type fakeExchange struct {
+	recs       []common.Recommendation
+	preview    *compute.ExchangePreview
+	calcErr    error
+	execErr    error
+	calcCalls  [][]compute.ExchangeTarget
+	execCalls  []string
+	recsCalled bool
+}
+
+func (f *fakeExchange) CalculateExchange(_ context.Context, _ []compute.ExchangeableReservation, targets []compute.ExchangeTarget) (*compute.ExchangePreview, []compute.CompatibleOffering, error) {
+	f.calcCalls = append(f.calcCalls, targets)
+	if f.calcErr != nil {
+		return nil, nil, f.calcErr
+	}
+	return f.preview, nil, nil
+}
+
+func (f *fakeExchange) ExecuteExchange(_ context.Context, sessionID string) (*compute.ExchangeResult, error) {
+	f.execCalls = append(f.execCalls, sessionID)
+	if f.execErr != nil {
+		return nil, f.execErr
+	}
+	return &compute.ExchangeResult{SessionID: sessionID, Status: "Succeeded"}, nil
+}
+
+func (f *fakeExchange) GetRecommendations(context.Context, *common.RecommendationParams) ([]common.Recommendation, error) {
+	f.recsCalled = true
+	return f.recs, nil
+}
+
+func preview(net float64) *compute.ExchangePreview {
+	return &compute.ExchangePreview{SessionID: "session-1", NetPayable: to.Ptr(net), NetPayableCurrency: "USD"}
+}
+
+// reshapeFixture has one under-utilized (40%) and one well-utilized (95%)
+// reservation, and two recommended targets.
+func reshapeFixture() (*fakes, *fakeExchange) {
+	f := newFakes()
+	f.res.res = []compute.ExchangeableReservation{
+		ownedReservation("g1", "Standard_D2s_v3", 2, time.Time{}),
+		ownedReservation("g2", "Standard_D8s_v3", 1, time.Time{}),
+	}
+	f.util.utils = []common.RIUtilization{
+		{ReservedInstanceID: "g1", UtilizationPercent: 40, PurchasedHours: 100, TotalActualHours: 40},
+		{ReservedInstanceID: "g2", UtilizationPercent: 95, PurchasedHours: 100, TotalActualHours: 95},
+	}
+	ex := &fakeExchange{
+		recs: []common.Recommendation{
+			{ResourceType: "Standard_E2s_v5", Region: "westeurope", Count: 3, Term: "1yr", EstimatedSavings: 10},
+			{ResourceType: "Standard_F4s_v2", Region: "eastus", Count: 2, Term: "1yr", EstimatedSavings: 50},
+			// Wrong term: never picked for a P1Y source.
+			{ResourceType: "Standard_M8ms", Region: "eastus", Count: 1, Term: "3yr", EstimatedSavings: 999},
+		},
+		preview: preview(12.5),
+	}
+	return f, ex
+}
+
+func reshapeCfg() ladder.BufferReshapeConfig {
+	return ladder.BufferReshapeConfig{UtilizationThresholdPct: 80, LookbackDays: 30}
+}
+
+func TestReshapeBuffer_NotWired(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	_, err := l.ReshapeBuffer(context.Background(), testScope, reshapeCfg())
+	require.ErrorIs(t, err, errWriteNotWired)
+}
+
+func TestReshapeBuffer_ExchangesUnderUtilizedIntoBestTarget(t *testing.T) {
+	f, ex := reshapeFixture()
+	l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+
+	summary, err := l.ReshapeBuffer(context.Background(), testScope, reshapeCfg())
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Analyzed)
+	assert.Equal(t, 1, summary.Reshaped)
+	assert.Equal(t, 0, summary.Skipped)
+
+	require.Len(t, ex.calcCalls, 1)
+	target := ex.calcCalls[0][0]
+	assert.Equal(t, "Standard_F4s_v2", target.SKU)
+	assert.Equal(t, armreservations.ReservationTermP1Y, target.Term)
+	assert.Equal(t, int32(2), target.Quantity)
+	assert.Equal(t, "/subscriptions/"+testSub, target.BillingScopeID)
+	assert.Equal(t, []string{"session-1"}, ex.execCalls)
+}
+
+func TestReshapeBuffer_DryRunDoesNotExecute(t *testing.T) {
+	f, ex := reshapeFixture()
+	l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+	cfg := reshapeCfg()
+	cfg.DryRun = true
+
+	summary, err := l.ReshapeBuffer(context.Background(), testScope, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Reshaped)
+	assert.Empty(t, ex.execCalls)
+	assert.Contains(t, summary.Details[0], "simulated")
+}
+
+func TestReshapeBuffer_GuardrailsSkip(t *testing.T) {
+	cases := []struct {
+		name    string
+		preview *compute.ExchangePreview
+		cfg     func(*ladder.BufferReshapeConfig)
+		want    string
+	}{
+		{"policy error", &compute.ExchangePreview{SessionID: "s", NetPayable: to.Ptr(1.0), NetPayableCurrency: "USD", PolicyErrors: []string{"purchase below refund"}}, nil, "purchase below refund"},
+		{"nil net payable", &compute.ExchangePreview{SessionID: "s", NetPayableCurrency: "USD"}, nil, "net payable"},
+		{"per-exchange cap", preview(500), func(c *ladder.BufferReshapeConfig) { c.MaxPaymentPerExchangeUSD = to.Ptr(100.0) }, "per-exchange cap"},
+		{"daily cap", preview(500), func(c *ladder.BufferReshapeConfig) { c.MaxPaymentDailyUSD = to.Ptr(100.0) }, "daily cap"},
+		{"non-USD with cap", &compute.ExchangePreview{SessionID: "s", NetPayable: to.Ptr(1.0), NetPayableCurrency: "EUR"}, func(c *ladder.BufferReshapeConfig) { c.MaxPaymentDailyUSD = to.Ptr(100.0) }, "EUR"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, ex := reshapeFixture()
+			ex.preview = tc.preview
+			l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+			cfg := reshapeCfg()
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+
+			summary, err := l.ReshapeBuffer(context.Background(), testScope, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, 1, summary.Skipped)
+			assert.Equal(t, 0, summary.Reshaped)
+			assert.Empty(t, ex.execCalls)
+			require.Len(t, summary.Details, 1)
+			assert.Contains(t, summary.Details[0], tc.want)
+		})
+	}
+}
+
+func TestReshapeBuffer_FailureReturnsSummaryAndError(t *testing.T) {
+	f, ex := reshapeFixture()
+	ex.execErr = errors.New("exchange rejected")
+	l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+
+	summary, err := l.ReshapeBuffer(context.Background(), testScope, reshapeCfg())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exchange rejected")
+	assert.Equal(t, 0, summary.Reshaped)
+	require.Len(t, summary.Details, 1)
+	assert.Contains(t, summary.Details[0], "failed")
+}
+
+func TestReshapeBuffer_ContextCancellationAborts(t *testing.T) {
+	f, ex := reshapeFixture()
+	ex.calcErr = context.Canceled
+	l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+
+	_, err := l.ReshapeBuffer(context.Background(), testScope, reshapeCfg())
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReshapeBuffer_RejectsInvalidConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  ladder.BufferReshapeConfig
+	}{
+		{"zero threshold", ladder.BufferReshapeConfig{LookbackDays: 30}},
+		{"threshold above 100", ladder.BufferReshapeConfig{UtilizationThresholdPct: 101, LookbackDays: 30}},
+		{"zero lookback", ladder.BufferReshapeConfig{UtilizationThresholdPct: 80}},
+		{"zero cap", ladder.BufferReshapeConfig{UtilizationThresholdPct: 80, LookbackDays: 30, MaxPaymentDailyUSD: to.Ptr(0.0)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, ex := reshapeFixture()
+			l := wiredLadder(t, f, &fakePurchaser{}, &fakePurchaser{}, ex)
+			_, err := l.ReshapeBuffer(context.Background(), testScope, tc.cfg)
+			require.Error(t, err)
+			assert.False(t, ex.recsCalled, "config must be validated before any Azure call")
+		})
+	}
+}
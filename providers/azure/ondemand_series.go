@@ -0,0 +1,200 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+)
+
+// vmMeterCategory is the Azure meter category for virtual machine compute
+// usage. Disk, bandwidth and licence meters emitted by Microsoft.Compute
+// carry other categories and are excluded from the on-demand series.
+const vmMeterCategory = "Virtual Machines"
+
+// usageDetailsDateLayout is the date format the Consumption API accepts in
+// usageStart / usageEnd filter clauses.
+const usageDetailsDateLayout = "2006-01-02"
+
+// DailyCost is one calendar day of on-demand VM spend, the Azure analogue of
+// the AWS recommendations.DailyCost returned by GetOnDemandSeries.
+type DailyCost struct {
+	// Date is the UTC calendar day (midnight UTC) this entry covers.
+	Date time.Time
+	// USDPerHour is the on-demand spend averaged over the day.
+	USDPerHour float64
+}
+
+// usageDetailsPager is the page-iterator interface returned by
+// armconsumption.UsageDetailsClient.NewListPager. Extracted as an interface so
+// tests can inject a stub without a real Azure connection.
+type usageDetailsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.UsageDetailsClientListResponse, error)
+}
+
+// usageDetailsAPI is the narrow slice of armconsumption.UsageDetailsClient
+// that GetOnDemandSeries needs.
+type usageDetailsAPI interface {
+	newListPager(scope, filter string) usageDetailsPager
+}
+
+// realUsageDetailsAPI wraps the concrete Azure SDK client.
+type realUsageDetailsAPI struct {
+	client *armconsumption.UsageDetailsClient
+}
+
+func (r *realUsageDetailsAPI) newListPager(scope, filter string) usageDetailsPager {
+	return r.client.NewListPager(scope, &armconsumption.UsageDetailsClientListOptions{
+		Filter: &filter,
+		Expand: to.Ptr("properties/meterDetails"),
+		Metric: to.Ptr(armconsumption.MetrictypeActualCostMetricType),
+	})
+}
+
+// GetOnDemandSeries fetches daily on-demand virtual machine spend for the
+// subscription over the past lookbackDays days from the Consumption Usage
+// Details API and returns one DailyCost per calendar day, ordered
+// oldest-to-newest. It is the Azure counterpart of the AWS Cost Explorer
+// series the ladder baseline is computed from.
+//
+// Usage Details only supports filtering on the usage date range server-side,
+// so the pricing-model (On Demand) and meter-category (Virtual Machines)
+// filters are applied to each row client-side. Each day's total is divided by
+// 24 to yield USD/hour.
+//
+// Fail-loud conditions, mirroring the AWS series:
+//   - A qualifying row billed in a currency other than USD: error. Ladder
+//     amounts are USD and no FX conversion is performed.
+//   - No qualifying rows, or an all-zero series: error. A wrong filter would
+//     otherwise hand the engine a fresh, complete series of fabricated zeros.
+func (r *RecommendationsClientAdapter) GetOnDemandSeries(ctx context.Context, lookbackDays int) ([]DailyCost, error) {
+	if lookbackDays <= 0 {
+		return nil, fmt.Errorf("azure on-demand series: lookbackDays must be > 0, got %d", lookbackDays)
+	}
+	client, err := armconsumption.NewUsageDetailsClient(r.cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure on-demand series: failed to create usage details client: %w", err)
+	}
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -lookbackDays)
+	return r.getOnDemandSeriesViaAPI(ctx, &realUsageDetailsAPI{client: client}, start, end)
+}
+
+// getOnDemandSeriesViaAPI performs the pager loop. It is separate from
+// GetOnDemandSeries so tests can inject a fake usageDetailsAPI.
+func (r *RecommendationsClientAdapter) getOnDemandSeriesViaAPI(ctx context.Context, api usageDetailsAPI, start, end time.Time) ([]DailyCost, error) {
+	scope := fmt.Sprintf("/subscriptions/%s", r.subscriptionID)
+	// end is exclusive: today's partial day would drag the newest point down.
+	filter := fmt.Sprintf("properties/usageStart ge '%s' and properties/usageEnd lt '%s'",
+		start.Format(usageDetailsDateLayout), end.Format(usageDetailsDateLayout))
+
+	byDay := make(map[time.Time]float64)
+	pager := api.newListPager(scope, filter)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("azure on-demand series: failed to fetch usage details page: %w", err)
+		}
+		for _, item := range page.Value {
+			if err := accumulateOnDemandUsage(byDay, item); err != nil {
+				return nil, fmt.Errorf("azure on-demand series: %w", err)
+			}
+		}
+	}
+	return buildOnDemandSeries(byDay)
+}
+
+// onDemandUsageRow is the subset of a legacy or modern usage detail row that
+// the series needs, normalized across the two shapes.
+type onDemandUsageRow struct {
+	date          *time.Time
+	cost          *float64
+	currency      string
+	pricingModel  string
+	meterCategory string
+}
+
+// accumulateOnDemandUsage adds one usage detail row into byDay when it is an
+// on-demand virtual machine charge. Rows of any other kind are ignored.
+func accumulateOnDemandUsage(byDay map[time.Time]float64, item armconsumption.UsageDetailClassification) error {
+	row, ok := normalizeUsageRow(item)
+	if !ok {
+		return nil
+	}
+	if row.pricingModel != string(armconsumption.PricingModelTypeOnDemand) || !strings.EqualFold(row.meterCategory, vmMeterCategory) {
+		return nil
+	}
+	if row.date == nil || row.cost == nil {
+		return fmt.Errorf("on-demand usage row is missing its date or cost")
+	}
+	if !strings.EqualFold(row.currency, "USD") {
+		return fmt.Errorf("on-demand usage row is billed in %q; only USD billing is supported", row.currency)
+	}
+	byDay[row.date.UTC().Truncate(24*time.Hour)] += *row.cost
+	return nil
+}
+
+// normalizeUsageRow extracts the fields the series needs from either usage
+// detail shape. EA billing accounts return legacy rows; MCA accounts return
+// modern rows with the cost already converted to USD.
+func normalizeUsageRow(item armconsumption.UsageDetailClassification) (onDemandUsageRow, bool) {
+	switch d := item.(type) {
+	case *armconsumption.LegacyUsageDetail:
+		if d.Properties == nil {
+			return onDemandUsageRow{}, false
+		}
+		p := d.Properties
+		row := onDemandUsageRow{date: p.Date, cost: p.Cost}
+		if p.BillingCurrency != nil {
+			row.currency = *p.BillingCurrency
+		}
+		if p.PricingModel != nil {
+			row.pricingModel = string(*p.PricingModel)
+		}
+		if p.MeterDetails != nil && p.MeterDetails.MeterCategory != nil {
+			row.meterCategory = *p.MeterDetails.MeterCategory
+		}
+		return row, true
+	case *armconsumption.ModernUsageDetail:
+		if d.Properties == nil {
+			return onDemandUsageRow{}, false
+		}
+		p := d.Properties
+		row := onDemandUsageRow{date: p.Date, cost: p.CostInUSD, currency: "USD"}
+		if p.PricingModel != nil {
+			row.pricingModel = string(*p.PricingModel)
+		}
+		if p.MeterCategory != nil {
+			row.meterCategory = *p.MeterCategory
+		}
+		return row, true
+	default:
+		return onDemandUsageRow{}, false
+	}
+}
+
+// buildOnDemandSeries converts the per-day totals into a chronological series
+// and rejects the empty and all-zero cases.
+func buildOnDemandSeries(byDay map[time.Time]float64) ([]DailyCost, error) {
+	if len(byDay) == 0 {
+		return nil, fmt.Errorf("azure on-demand series: no on-demand virtual machine usage in the lookback window")
+	}
+	series := make([]DailyCost, 0, len(byDay))
+	allZero := true
+	for day, total := range byDay {
+		if total != 0 {
+			allZero = false
+		}
+		series = append(series, DailyCost{Date: day, USDPerHour: total / 24.0})
+	}
+	if allZero {
+		return nil, fmt.Errorf("azure on-demand series: every day in the lookback window reports $0 on-demand virtual machine spend")
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Date.Before(series[j].Date) })
+	return series, nil
+}
@@ -0,0 +1,148 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsageDetailsPager returns each configured page once, in order.
+type fakeUsageDetailsPager struct {
+	pages []armconsumption.UsageDetailsClientListResponse
+	err   error
+	next  int
+}
+
+func (p *fakeUsageDetailsPager) More() bool {
+	return p.next < len(p.pages) || (p.err != nil && p.next == 0)
+}
+
+func (p *fakeUsageDetailsPager) NextPage(context.Context) (armconsumption.UsageDetailsClientListResponse, error) {
+	if p.err != nil {
+		p.next++
+		return armconsumption.UsageDetailsClientListResponse{}, p.err
+	}
+	page := p.pages[p.next]
+	p.next++
+	return page, nil
+}
+
+// fakeUsageDetailsAPI captures the scope and filter passed to newListPager.
+type fakeUsageDetailsAPI struct {
+	pager          *fakeUsageDetailsPager
+	capturedScope  string
+	capturedFilter string
+}
+
+func (f *fakeUsageDetailsAPI) newListPager(scope, filter string) usageDetailsPager {
+	f.capturedScope = scope
+	f.capturedFilter = filter
+	return f.pager
+}
+
+func legacyUsageRow(day time.Time, cost float64, pricing armconsumption.PricingModelType, category, currency string) armconsumption.UsageDetailClassification {
+	return &armconsumption.LegacyUsageDetail{
+		Properties: &armconsumption.LegacyUsageDetailProperties{
+			Date:            to.Ptr(day),
+			Cost:            to.Ptr(cost),
+			PricingModel:    to.Ptr(pricing),
+			BillingCurrency: to.Ptr(currency),
+			MeterDetails:    &armconsumption.MeterDetailsResponse{MeterCategory: to.Ptr(category)},
+		},
+	}
+}
+
+func usagePage(rows ...armconsumption.UsageDetailClassification) armconsumption.UsageDetailsClientListResponse {
+	return armconsumption.UsageDetailsClientListResponse{
+		UsageDetailsListResult: armconsumption.UsageDetailsListResult{Value: rows},
+	}
+}
+
+func TestGetOnDemandSeries_AggregatesOnDemandVMRowsPerDay(t *testing.T) {
+	d1 := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 0, 1)
+	api := &fakeUsageDetailsAPI{pager: &fakeUsageDetailsPager{pages: []armconsumption.UsageDetailsClientListResponse{
+		usagePage(
+			legacyUsageRow(d2, 48, armconsumption.PricingModelTypeOnDemand, vmMeterCategory, "USD"),
+			legacyUsageRow(d1, 24, armconsumption.PricingModelTypeOnDemand, vmMeterCategory, "USD"),
+			// Reservation-covered and non-VM rows are ignored.
+			legacyUsageRow(d1, 999, armconsumption.PricingModelTypeReservation, vmMeterCategory, "USD"),
+			legacyUsageRow(d1, 999, armconsumption.PricingModelTypeOnDemand, "Storage", "USD"),
+		),
+		usagePage(&armconsumption.ModernUsageDetail{
+			Properties: &armconsumption.ModernUsageDetailProperties{
+				Date:          to.Ptr(d1.Add(5 * time.Hour)),
+				CostInUSD:     to.Ptr(24.0),
+				PricingModel:  to.Ptr(armconsumption.PricingModelTypeOnDemand),
+				MeterCategory: to.Ptr(vmMeterCategory),
+			},
+		}),
+	}}}
+	adapter := &RecommendationsClientAdapter{subscriptionID: "sub-123"}
+
+	series, err := adapter.getOnDemandSeriesViaAPI(context.Background(), api, d1, d1.AddDate(0, 0, 30))
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.Equal(t, d1, series[0].Date)
+	assert.InDelta(t, 2.0, series[0].USDPerHour, 1e-9) // (24 + 24) / 24
+	assert.Equal(t, d2, series[1].Date)
+	assert.InDelta(t, 2.0, series[1].USDPerHour, 1e-9)
+
+	assert.Equal(t, "/subscriptions/sub-123", api.capturedScope)
+	assert.Equal(t, "properties/usageStart ge '2026-05-01' and properties/usageEnd lt '2026-05-31'", api.capturedFilter)
+}
+
+func TestGetOnDemandSeries_FailLoud(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		pager   *fakeUsageDetailsPager
+		wantErr string
+	}{
+		{
+			name:    "page error",
+			pager:   &fakeUsageDetailsPager{err: errors.New("throttled")},
+			wantErr: "throttled",
+		},
+		{
+			name:    "no qualifying rows",
+			pager:   &fakeUsageDetailsPager{pages: []armconsumption.UsageDetailsClientListResponse{usagePage()}},
+			wantErr: "no on-demand virtual machine usage",
+		},
+		{
+			name: "all zero",
+			pager: &fakeUsageDetailsPager{pages: []armconsumption.UsageDetailsClientListResponse{usagePage(
+				legacyUsageRow(day, 0, armconsumption.PricingModelTypeOnDemand, vmMeterCategory, "USD"),
+			)}},
+			wantErr: "$0",
+		},
+		{
+			name: "non-USD billing",
+			pager: &fakeUsageDetailsPager{pages: []armconsumption.UsageDetailsClientListResponse{usagePage(
+				legacyUsageRow(day, 10, armconsumption.PricingModelTypeOnDemand, vmMeterCategory, "EUR"),
+			)}},
+			wantErr: `billed in "EUR"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := &RecommendationsClientAdapter{subscriptionID: "sub-123"}
+			_, err := adapter.getOnDemandSeriesViaAPI(context.Background(), &fakeUsageDetailsAPI{pager: tc.pager}, day, day.AddDate(0, 0, 30))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestGetOnDemandSeries_RejectsNonPositiveLookback(t *testing.T) {
+	adapter := &RecommendationsClientAdapter{subscriptionID: "sub-123"}
+	_, err := adapter.GetOnDemandSeries(context.Background(), 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lookbackDays must be > 0")
+}
@@ -523,6 +523,9 @@ func (p *AzureProvider) GetSupportedServices() []common.ServiceType {
 		common.ServiceSavingsPlansAll,
 		common.ServiceSearch,
 		common.ServiceDataWarehouse,
+		common.ServiceStorage,
+		common.ServiceBlockStorage,
+		common.ServiceAppService,
 	}
 }
 
@@ -595,6 +598,12 @@ func (p *AzureProvider) newServiceClientForSubscription(service common.ServiceTy
 		return NewSearchClient(cred, subscriptionID, region), nil
 	case common.ServiceDataWarehouse:
 		return NewSynapseClient(cred, subscriptionID, region), nil
+	case common.ServiceStorage:
+		return NewBlobStorageClient(cred, subscriptionID, region), nil
+	case common.ServiceBlockStorage:
+		return NewManagedDiskClient(cred, subscriptionID, region), nil
+	case common.ServiceAppService:
+		return NewAppServiceClient(cred, subscriptionID, region), nil
 	default:
 		return nil, fmt.Errorf("unsupported service: %s", service)
 	}
@@ -272,6 +272,9 @@ func TestAzureProvider_GetSupportedServices(t *testing.T) {
 	assert.Contains(t, services, common.ServiceSavingsPlansAll)
 	assert.Contains(t, services, common.ServiceSearch)
 	assert.Contains(t, services, common.ServiceDataWarehouse)
+	assert.Contains(t, services, common.ServiceStorage)
+	assert.Contains(t, services, common.ServiceBlockStorage)
+	assert.Contains(t, services, common.ServiceAppService)
 }
 
 func TestAzureProvider_IsConfigured(t *testing.T) {
@@ -434,6 +437,9 @@ func TestAzureProvider_GetServiceClient_AllServiceTypes(t *testing.T) {
 		{common.ServiceSavingsPlansAll},
 		{common.ServiceSearch},
 		{common.ServiceDataWarehouse},
+		{common.ServiceStorage},
+		{common.ServiceBlockStorage},
+		{common.ServiceAppService},
 	}
 
 	for _, tc := range testCases {
@@ -1201,6 +1207,9 @@ func TestAzureProvider_GetServiceClientForAccount(t *testing.T) {
 			common.ServiceSavingsPlansAll,
 			common.ServiceSearch,
 			common.ServiceDataWarehouse,
+			common.ServiceStorage,
+			common.ServiceBlockStorage,
+			common.ServiceAppService,
 		}
 		for _, svc := range services {
 			t.Run(string(svc), func(t *testing.T) {
@@ -15,15 +15,18 @@ import (
 	"github.com/LeanerCloud/CUDly/pkg/concurrency"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 	azrecs "github.com/LeanerCloud/CUDly/providers/azure/internal/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/appservice"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/blobstorage"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/cache"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/cosmosdb"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/database"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/manageddisk"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/savingsplans"
 )
 
 // serviceRecsGetter is the narrow interface satisfied by each per-service
-// client (compute, database, cache, cosmosdb). The interface exists solely to
+// client (compute, database, cache, cosmosdb, blob, disk, appservice). The interface exists solely to
 // allow tests to substitute fake implementations; production code uses the
 // concrete types via the newXxxClientFn variables below.
 type serviceRecsGetter interface {
@@ -31,7 +34,8 @@ type serviceRecsGetter interface {
 }
 
 // newComputeClientFn, newDatabaseClientFn, newCacheClientFn,
-// newCosmosDBClientFn, and newSavingsPlansClientFn default to the real
+// newCosmosDBClientFn, newBlobStorageClientFn, newManagedDiskClientFn,
+// newAppServiceClientFn, and newSavingsPlansClientFn default to the real
 // constructors and are overridden in tests to inject fakes. The variables are
 // package-level (not fields on the adapter) so that the constructor signature
 // stays unchanged and the injection is limited to the test package that owns
@@ -54,6 +58,15 @@ var (
 	newCosmosDBClientFn func(azcore.TokenCredential, string, string) serviceRecsGetter = func(cred azcore.TokenCredential, sub, region string) serviceRecsGetter {
 		return cosmosdb.NewClient(cred, sub, region)
 	}
+	newBlobStorageClientFn func(azcore.TokenCredential, string, string) serviceRecsGetter = func(cred azcore.TokenCredential, sub, region string) serviceRecsGetter {
+		return blobstorage.NewClient(cred, sub, region)
+	}
+	newManagedDiskClientFn func(azcore.TokenCredential, string, string) serviceRecsGetter = func(cred azcore.TokenCredential, sub, region string) serviceRecsGetter {
+		return manageddisk.NewClient(cred, sub, region)
+	}
+	newAppServiceClientFn func(azcore.TokenCredential, string, string) serviceRecsGetter = func(cred azcore.TokenCredential, sub, region string) serviceRecsGetter {
+		return appservice.NewClient(cred, sub, region)
+	}
 	newSavingsPlansClientFn func(azcore.TokenCredential, string, string) serviceRecsGetter = func(cred azcore.TokenCredential, sub, region string) serviceRecsGetter {
 		return savingsplans.NewClient(cred, sub, region)
 	}
@@ -104,10 +117,11 @@ func NewRecommendationsClientAdapter(cred azcore.TokenCredential, subscriptionID
 // (see known_issues/10_azure_provider.md CRITICAL "Recommendation converters
 // ignore the API response entirely" for the matching converter work).
 //
-// All six service calls run concurrently under errgroup. Each goroutine captures
+// All nine service calls run concurrently under errgroup. Each goroutine captures
 // its own error and returns nil to the group so that a single service failure
 // does not cancel sibling calls. Results are appended in a deterministic order
-// (compute → database → cache → cosmosdb → savingsplans → advisor) after all goroutines finish.
+// (compute → database → cache → cosmosdb → blob → disk → appservice →
+// savingsplans → advisor) after all goroutines finish.
 func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error) {
 	if params == nil {
 		return nil, fmt.Errorf("params cannot be nil")
@@ -115,6 +129,8 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	var (
 		computeRecs, dbRecs, cacheRecs, cosmosRecs, advisorRecs, spRecs []common.Recommendation
 		computeErr, dbErr, cacheErr, cosmosErr, advisorErr, spErr       error
+		blobRecs, diskRecs, appRecs                                     []common.Recommendation
+		blobErr, diskErr, appErr                                        error
 	)
 
 	g, gctx := errgroup.WithContext(ctx)
@@ -150,6 +166,9 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	includeDB := shouldIncludeService(*params, common.ServiceRelationalDB)
 	includeCache := shouldIncludeService(*params, common.ServiceCache)
 	includeCosmos := shouldIncludeService(*params, common.ServiceNoSQL)
+	includeBlob := shouldIncludeService(*params, common.ServiceStorage)
+	includeDisk := shouldIncludeService(*params, common.ServiceBlockStorage)
+	includeApp := shouldIncludeService(*params, common.ServiceAppService)
 	includeSP := shouldIncludeService(*params, common.ServiceSavingsPlansAll)
 
 	// Compute (VM) recommendations — subscription-wide.
@@ -184,6 +203,20 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 		})
 	}
 
+	// Blob Storage, Managed Disk and App Service reserved capacity —
+	// subscription-wide, same Consumption API as the services above.
+	// goIfIncluded keeps the include checks out of this function's
+	// cyclomatic count.
+	goIfIncluded(includeBlob, goService, &blobErr, func() {
+		blobRecs, blobErr = newBlobStorageClientFn(r.cred, r.subscriptionID, "").GetRecommendations(gctx, params)
+	})
+	goIfIncluded(includeDisk, goService, &diskErr, func() {
+		diskRecs, diskErr = newManagedDiskClientFn(r.cred, r.subscriptionID, "").GetRecommendations(gctx, params)
+	})
+	goIfIncluded(includeApp, goService, &appErr, func() {
+		appRecs, appErr = newAppServiceClientFn(r.cred, r.subscriptionID, "").GetRecommendations(gctx, params)
+	})
+
 	// Savings Plans — Azure has no stable public API for SP purchase
 	// recommendations (Benefits Recommendations API is still in preview).
 	// The call returns an empty slice so the service appears in the fan-out
@@ -226,6 +259,9 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 		serviceResult{"database", dbRecs, dbErr, includeDB},
 		serviceResult{"cache", cacheRecs, cacheErr, includeCache},
 		serviceResult{"cosmosdb", cosmosRecs, cosmosErr, includeCosmos},
+		serviceResult{"blob", blobRecs, blobErr, includeBlob},
+		serviceResult{"disk", diskRecs, diskErr, includeDisk},
+		serviceResult{"appservice", appRecs, appErr, includeApp},
 		// The savingsplans client is a stub that unconditionally returns
 		// ([], nil) until the Benefits Recommendations API stabilizes (see
 		// services/savingsplans Client.GetRecommendations). Counting its
@@ -246,6 +282,13 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 		serviceResult{"advisor", advisorRecs, advisorErr, false})
 }
 
+// goIfIncluded launches fn through goService only when include is true.
+func goIfIncluded(include bool, goService func(*error, func()), errOut *error, fn func()) {
+	if include {
+		goService(errOut, fn)
+	}
+}
+
 // serviceResult bundles a per-service collection outcome for the deterministic
 // merge in mergeServiceResults. Extracted into a helper so GetRecommendations
 // stays under the cyclomatic-complexity gate after the post-Wait ctx.Err()
@@ -265,8 +308,8 @@ type serviceResult struct {
 // mergeServiceResults logs per-service errors (matches the previous sequential
 // behaviour where each error was logged inline via logging.Warnf) and appends
 // successful results in the order the slice is passed — callers must preserve
-// the canonical compute → database → cache → cosmosdb → savingsplans → advisor
-// order so that order-sensitive consumers remain stable. The advisor entry's
+// the canonical compute → database → cache → cosmosdb → blob → disk →
+// appservice → savingsplans → advisor order so that order-sensitive consumers remain stable. The advisor entry's
 // error is logged via logging.Errorf to match the pre-parallelisation severity.
 //
 // Partial failure is tolerated: as long as at least one attempted service
@@ -219,6 +219,25 @@ func overrideServiceClientFns(t *testing.T, compute, database, cache, cosmos, sp
 	newCacheClientFn = newFakeFn(cache)
 	newCosmosDBClientFn = newFakeFn(cosmos)
 	newSavingsPlansClientFn = newFakeFn(sp)
+	overrideReservedCapacityClientFns(t, &fakeServiceClient{}, &fakeServiceClient{}, &fakeServiceClient{})
+}
+
+// overrideReservedCapacityClientFns swaps the Blob Storage, Managed Disk and
+// App Service client constructors for the given fakes and restores the
+// originals on cleanup.
+func overrideReservedCapacityClientFns(t *testing.T, blob, disk, app serviceRecsGetter) {
+	t.Helper()
+	origBlob := newBlobStorageClientFn
+	origDisk := newManagedDiskClientFn
+	origApp := newAppServiceClientFn
+	t.Cleanup(func() {
+		newBlobStorageClientFn = origBlob
+		newManagedDiskClientFn = origDisk
+		newAppServiceClientFn = origApp
+	})
+	newBlobStorageClientFn = newFakeFn(blob)
+	newManagedDiskClientFn = newFakeFn(disk)
+	newAppServiceClientFn = newFakeFn(app)
 }
 
 func TestRecommendationsClientAdapter_GetRecommendationsForService(t *testing.T) {
@@ -699,6 +718,7 @@ func TestGetRecommendations_Parallelism(t *testing.T) {
 	newCacheClientFn = newFakeFn(&fakeServiceClient{sleepDur: fakeServiceSleep, recs: []common.Recommendation{rec(common.ServiceCache)}})
 	newCosmosDBClientFn = newFakeFn(&fakeServiceClient{sleepDur: fakeServiceSleep, recs: []common.Recommendation{rec(common.ServiceNoSQL)}})
 	newSavingsPlansClientFn = noopFake
+	overrideReservedCapacityClientFns(t, &fakeServiceClient{}, &fakeServiceClient{}, &fakeServiceClient{})
 
 	adapter := &RecommendationsClientAdapter{
 		cred:             &mockAzureTokenCredential{},
@@ -752,6 +772,7 @@ func TestGetRecommendations_OrderPreservation(t *testing.T) {
 	newCacheClientFn = newFakeFn(&fakeServiceClient{sleepDur: 20 * time.Millisecond, recs: []common.Recommendation{makeRec(common.ServiceCache)}})
 	newCosmosDBClientFn = newFakeFn(&fakeServiceClient{sleepDur: 10 * time.Millisecond, recs: []common.Recommendation{makeRec(common.ServiceNoSQL)}})
 	newSavingsPlansClientFn = newFakeFn(&fakeServiceClient{})
+	overrideReservedCapacityClientFns(t, &fakeServiceClient{}, &fakeServiceClient{}, &fakeServiceClient{})
 
 	adapter := &RecommendationsClientAdapter{
 		cred:             &mockAzureTokenCredential{},
@@ -801,6 +822,7 @@ func TestGetRecommendations_ErrorIsolation(t *testing.T) {
 	newCacheClientFn = newFakeFn(&fakeServiceClient{sleepDur: fakeServiceSleep, recs: []common.Recommendation{makeRec(common.ServiceCache)}})
 	newCosmosDBClientFn = newFakeFn(&fakeServiceClient{sleepDur: fakeServiceSleep, recs: []common.Recommendation{makeRec(common.ServiceNoSQL)}})
 	newSavingsPlansClientFn = newFakeFn(&fakeServiceClient{})
+	overrideReservedCapacityClientFns(t, &fakeServiceClient{}, &fakeServiceClient{}, &fakeServiceClient{})
 
 	adapter := &RecommendationsClientAdapter{
 		cred:             &mockAzureTokenCredential{},
@@ -821,3 +843,38 @@ func TestGetRecommendations_ErrorIsolation(t *testing.T) {
 	assert.Contains(t, services, common.ServiceNoSQL, "cosmosdb recs must be present despite db error")
 	assert.NotContains(t, services, common.ServiceRelationalDB, "db recs must be absent when db errors")
 }
+
+// TestGetRecommendations_ReservedCapacityServices pins that the Blob Storage,
+// Managed Disk and App Service clients take part in the fan-out, honour the
+// service filter, and merge after cosmosdb in canonical order.
+func TestGetRecommendations_ReservedCapacityServices(t *testing.T) {
+	rec := func(svc common.ServiceType) common.Recommendation {
+		return common.Recommendation{Provider: common.ProviderAzure, Service: svc}
+	}
+	overrideServiceClientFns(t,
+		&fakeServiceClient{}, &fakeServiceClient{}, &fakeServiceClient{},
+		&fakeServiceClient{recs: []common.Recommendation{rec(common.ServiceNoSQL)}},
+		&fakeServiceClient{})
+	overrideReservedCapacityClientFns(t,
+		&fakeServiceClient{sleepDur: 20 * time.Millisecond, recs: []common.Recommendation{rec(common.ServiceStorage)}},
+		&fakeServiceClient{sleepDur: 10 * time.Millisecond, recs: []common.Recommendation{rec(common.ServiceBlockStorage)}},
+		&fakeServiceClient{recs: []common.Recommendation{rec(common.ServiceAppService)}})
+
+	adapter := &RecommendationsClientAdapter{
+		cred:             &mockAzureTokenCredential{},
+		subscriptionID:   "sub-reserved",
+		getAdvisorRecsFn: noopAdvisorFn,
+	}
+
+	recs, err := adapter.GetRecommendations(context.Background(), &common.RecommendationParams{})
+	require.NoError(t, err)
+	require.Len(t, recs, 4)
+	assert.Equal(t, common.ServiceNoSQL, recs[0].Service)
+	assert.Equal(t, common.ServiceStorage, recs[1].Service)
+	assert.Equal(t, common.ServiceBlockStorage, recs[2].Service)
+	assert.Equal(t, common.ServiceAppService, recs[3].Service)
+
+	recs, err = adapter.GetRecommendationsForService(context.Background(), common.ServiceBlockStorage)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Recommendation{rec(common.ServiceBlockStorage)}, recs)
+}
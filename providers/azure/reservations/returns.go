@@ -0,0 +1,282 @@
+// Package reservations wraps the tenant-level Azure Reservations
+// (Microsoft.Capacity) operations that are not tied to one service.
+//
+// This file implements reservation returns. A return is two calls:
+//  1. CalculateRefund -- prices returning a quantity of one reservation
+//     and reports the refund policy that applies to it: the rolling
+//     12-month refund limit of the billing profile (or EA enrollment), how
+//     much of it is already consumed, and any policy errors. Nothing is
+//     committed; the response carries a session ID.
+//  2. Return -- commits the return priced by that session ID.
+//
+// Money-path note: like the exchange operations in services/compute, this
+// file only prices and executes what it is told. The caller (the revoke
+// handler in internal/api) decides whether a return is allowed and keeps
+// the audit trail.
+package reservations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+)
+
+// DefaultRefundLimitUSD is Azure's rolling 12-month refund limit per
+// billing profile or EA enrollment, used when a CalculateRefund response
+// does not report the limit itself.
+// https://learn.microsoft.com/azure/cost-management-billing/reservations/exchange-and-refund-azure-reservations
+const DefaultRefundLimitUSD = 50000.0
+
+// refundScope is the CalculateRefund/Return scope for returning part or
+// all of a single reservation (as opposed to a whole reservation order).
+const refundScope = "Reservation"
+
+// CalculateRefundAPI is the narrow interface over
+// armreservations.CalculateRefundClient. Satisfied by the SDK client; a
+// stub can be injected via NewClientWithAPIs.
+type CalculateRefundAPI interface {
+	Post(ctx context.Context, reservationOrderID string, body armreservations.CalculateRefundRequest, options *armreservations.CalculateRefundClientPostOptions) (armreservations.CalculateRefundClientPostResponse, error)
+}
+
+// ReturnAPI is the narrow interface over armreservations.ReturnClient.
+type ReturnAPI interface {
+	Post(ctx context.Context, reservationOrderID string, body armreservations.RefundRequest, options *armreservations.ReturnClientPostOptions) (armreservations.ReturnClientPostResponse, error)
+}
+
+// Client returns Azure reservations for a refund.
+type Client struct {
+	calculate CalculateRefundAPI
+	ret       ReturnAPI
+}
+
+// NewClient creates a Client backed by the Azure SDK.
+func NewClient(cred azcore.TokenCredential) (*Client, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("credential must not be nil")
+	}
+	calculate, err := armreservations.NewCalculateRefundClient(cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure: create CalculateRefund client: %w", err)
+	}
+	ret, err := armreservations.NewReturnClient(cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azure: create Return client: %w", err)
+	}
+	return NewClientWithAPIs(calculate, ret), nil
+}
+
+// NewClientWithAPIs creates a Client over injected API implementations.
+// ret may be nil for a Client that only quotes refunds.
+func NewClientWithAPIs(calculate CalculateRefundAPI, ret ReturnAPI) *Client {
+	return &Client{calculate: calculate, ret: ret}
+}
+
+// ReturnTarget identifies what to return.
+type ReturnTarget struct {
+	// ReservationOrderID is the GUID of the parent reservation order.
+	ReservationOrderID string
+
+	// ReservationID is the reservation item within the order.
+	ReservationID string
+
+	// Quantity is how many of the reservation's instances to return.
+	Quantity int32
+}
+
+func (t ReturnTarget) validate(op string) error {
+	if t.ReservationOrderID == "" || t.ReservationID == "" {
+		return fmt.Errorf("azure: %s: reservation order ID and reservation ID are required", op)
+	}
+	if t.Quantity < 1 {
+		return fmt.Errorf("azure: %s: quantity must be >= 1, got %d", op, t.Quantity)
+	}
+	return nil
+}
+
+func (t ReturnTarget) toReturn() *armreservations.ReservationToReturn {
+	return &armreservations.ReservationToReturn{
+		ReservationID: to.Ptr(t.ReservationID),
+		Quantity:      to.Ptr(t.Quantity),
+	}
+}
+
+// RefundQuote is the priced result of a CalculateRefund call.
+type RefundQuote struct {
+	// SessionID must be passed verbatim to Return to commit this quote.
+	SessionID string `json:"session_id"`
+
+	// Amount is the refund in the billing currency. Nil when Azure did
+	// not report one -- never a fabricated 0.
+	Amount *float64 `json:"amount"`
+
+	// Currency is the ISO 4217 code for Amount.
+	Currency string `json:"currency,omitempty"`
+
+	// MaxRefundLimit is the billing profile's rolling 12-month refund
+	// limit as Azure reported it. Nil when not reported.
+	MaxRefundLimit *float64 `json:"max_refund_limit,omitempty"`
+
+	// ConsumedRefundsTotal is how much of MaxRefundLimit the billing
+	// profile has already used in the rolling window. Nil when not
+	// reported.
+	ConsumedRefundsTotal *float64 `json:"consumed_refunds_total,omitempty"`
+
+	// LimitCurrency is the ISO 4217 code for MaxRefundLimit and
+	// ConsumedRefundsTotal.
+	LimitCurrency string `json:"limit_currency,omitempty"`
+
+	// PolicyErrors is non-empty when Azure's refund policy blocks the
+	// return (for example, the refund limit is exhausted). Callers must
+	// not call Return when it is non-empty.
+	PolicyErrors []string `json:"policy_errors,omitempty"`
+}
+
+// RefundResult is the outcome of a committed return.
+type RefundResult struct {
+	// Amount is the refund Azure committed to, in the billing currency.
+	// Nil when Azure did not report one.
+	Amount *float64 `json:"amount"`
+
+	// Currency is the ISO 4217 code for Amount.
+	Currency string `json:"currency,omitempty"`
+}
+
+// CalculateRefund prices returning target without committing anything.
+//
+// A priced-but-policy-rejected return is a successful call whose
+// RefundQuote.PolicyErrors is non-empty. Errors from the API are wrapped
+// with %w so callers can classify *azcore.ResponseError status codes.
+func (c *Client) CalculateRefund(ctx context.Context, target ReturnTarget) (*RefundQuote, error) {
+	if err := target.validate("CalculateRefund"); err != nil {
+		return nil, err
+	}
+	resp, err := c.calculate.Post(ctx, target.ReservationOrderID, armreservations.CalculateRefundRequest{
+		Properties: &armreservations.CalculateRefundRequestProperties{
+			ReservationToReturn: target.toReturn(),
+			Scope:               to.Ptr(refundScope),
+		},
+	}, nil)
+	if err != nil {
+		if isTerminalCtxErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("azure: CalculateRefund: %w", err)
+	}
+	return extractRefundQuote(resp.Properties), nil
+}
+
+// Return commits the return priced by sessionID. reason is recorded by
+// Azure as the return reason.
+func (c *Client) Return(ctx context.Context, target ReturnTarget, sessionID, reason string) (*RefundResult, error) {
+	if err := target.validate("Return"); err != nil {
+		return nil, err
+	}
+	if sessionID == "" {
+		return nil, fmt.Errorf("azure: Return: session_id is required (obtain from CalculateRefund)")
+	}
+	if c.ret == nil {
+		return nil, fmt.Errorf("azure: Return: client was created without a Return API")
+	}
+	resp, err := c.ret.Post(ctx, target.ReservationOrderID, armreservations.RefundRequest{
+		Properties: &armreservations.RefundRequestProperties{
+			ReservationToReturn: target.toReturn(),
+			SessionID:           to.Ptr(sessionID),
+			ReturnReason:        to.Ptr(reason),
+			Scope:               to.Ptr(refundScope),
+		},
+	}, nil)
+	if err != nil {
+		if isTerminalCtxErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("azure: Return: %w", err)
+	}
+	result := &RefundResult{}
+	if resp.Properties != nil {
+		result.Amount, result.Currency = extractPrice(resp.Properties.BillingRefundAmount)
+	}
+	return result, nil
+}
+
+// Headroom returns how much more the billing profile may be refunded in
+// the rolling window according to Azure, and whether Azure reported
+// enough to tell.
+func (q *RefundQuote) Headroom() (float64, bool) {
+	if q == nil || q.MaxRefundLimit == nil || q.ConsumedRefundsTotal == nil {
+		return 0, false
+	}
+	return *q.MaxRefundLimit - *q.ConsumedRefundsTotal, true
+}
+
+func extractRefundQuote(props *armreservations.RefundResponseProperties) *RefundQuote {
+	quote := &RefundQuote{}
+	if props == nil {
+		return quote
+	}
+	if props.SessionID != nil {
+		quote.SessionID = *props.SessionID
+	}
+	quote.Amount, quote.Currency = extractPrice(props.BillingRefundAmount)
+	if props.PolicyResult == nil || props.PolicyResult.Properties == nil {
+		return quote
+	}
+	policy := props.PolicyResult.Properties
+	var consumedCurrency string
+	quote.MaxRefundLimit, quote.LimitCurrency = extractPrice(policy.MaxRefundLimit)
+	quote.ConsumedRefundsTotal, consumedCurrency = extractPrice(policy.ConsumedRefundsTotal)
+	if quote.LimitCurrency == "" {
+		quote.LimitCurrency = consumedCurrency
+	}
+	for _, e := range policy.PolicyErrors {
+		quote.PolicyErrors = append(quote.PolicyErrors, refundPolicyErrorMessage(e))
+	}
+	return quote
+}
+
+// refundPolicyErrorMessage renders one refund policy violation as a
+// non-empty string, so that a Message-less entry still blocks the return.
+func refundPolicyErrorMessage(e *armreservations.RefundPolicyError) string {
+	if e == nil {
+		return "azure reported an unspecified refund policy violation"
+	}
+	var code string
+	if e.Code != nil {
+		code = string(*e.Code)
+	}
+	switch {
+	case e.Message != nil && *e.Message != "" && code != "":
+		return fmt.Sprintf("%s: %s", code, *e.Message)
+	case e.Message != nil && *e.Message != "":
+		return *e.Message
+	case code != "":
+		return code
+	default:
+		return "azure reported an unspecified refund policy violation"
+	}
+}
+
+// extractPrice reads an optional armreservations.Price, returning a nil
+// amount when Azure did not report one.
+func extractPrice(p *armreservations.Price) (amount *float64, currency string) {
+	if p == nil {
+		return nil, ""
+	}
+	if p.Amount != nil {
+		v := *p.Amount
+		amount = &v
+	}
+	if p.CurrencyCode != nil {
+		currency = *p.CurrencyCode
+	}
+	return amount, currency
+}
+
+// isTerminalCtxErr reports whether err is a context cancellation or
+// deadline expiry, which is propagated as-is.
+func isTerminalCtxErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
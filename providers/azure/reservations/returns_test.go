@@ -0,0 +1,168 @@
+package reservations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubCalculateRefund struct {
+	resp    armreservations.CalculateRefundClientPostResponse
+	err     error
+	orderID string
+	body    armreservations.CalculateRefundRequest
+}
+
+func (s *stubCalculateRefund) Post(_ context.Context, orderID string, body armreservations.CalculateRefundRequest, _ *armreservations.CalculateRefundClientPostOptions) (armreservations.CalculateRefundClientPostResponse, error) {
+	s.orderID, s.body = orderID, body
+	return s.resp, s.err
+}
+
+type stubReturn struct {
+	resp  armreservations.ReturnClientPostResponse
+	err   error
+	body  armreservations.RefundRequest
+	calls int
+}
+
+func (s *stubReturn) Post(_ context.Context, _ string, body armreservations.RefundRequest, _ *armreservations.ReturnClientPostOptions) (armreservations.ReturnClientPostResponse, error) {
+	s.calls++
+	s.body = body
+	return s.resp, s.err
+}
+
+func price(amount float64, currency string) *armreservations.Price {
+	return &armreservations.Price{Amount: to.Ptr(amount), CurrencyCode: to.Ptr(currency)}
+}
+
+var target = ReturnTarget{ReservationOrderID: "order-1", ReservationID: "res-1", Quantity: 2}
+
+func TestCalculateRefund(t *testing.T) {
+	calc := &stubCalculateRefund{resp: armreservations.CalculateRefundClientPostResponse{
+		CalculateRefundResponse: armreservations.CalculateRefundResponse{
+			Properties: &armreservations.RefundResponseProperties{
+				SessionID:           to.Ptr("session-1"),
+				BillingRefundAmount: price(1200, "USD"),
+				PolicyResult: &armreservations.RefundPolicyResult{
+					Properties: &armreservations.RefundPolicyResultProperty{
+						MaxRefundLimit:       price(50000, "USD"),
+						ConsumedRefundsTotal: price(48000, "USD"),
+					},
+				},
+			},
+		},
+	}}
+	c := NewClientWithAPIs(calc, nil)
+
+	quote, err := c.CalculateRefund(context.Background(), target)
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", calc.orderID)
+	assert.Equal(t, "res-1", *calc.body.Properties.ReservationToReturn.ReservationID)
+	assert.Equal(t, int32(2), *calc.body.Properties.ReservationToReturn.Quantity)
+	assert.Equal(t, "Reservation", *calc.body.Properties.Scope)
+
+	assert.Equal(t, "session-1", quote.SessionID)
+	require.NotNil(t, quote.Amount)
+	assert.Equal(t, 1200.0, *quote.Amount)
+	assert.Equal(t, "USD", quote.Currency)
+	assert.Equal(t, "USD", quote.LimitCurrency)
+	assert.Empty(t, quote.PolicyErrors)
+	headroom, ok := quote.Headroom()
+	assert.True(t, ok)
+	assert.Equal(t, 2000.0, headroom)
+}
+
+func TestCalculateRefund_PolicyErrors(t *testing.T) {
+	code := armreservations.ErrorResponseCode("RefundLimitExceeded")
+	calc := &stubCalculateRefund{resp: armreservations.CalculateRefundClientPostResponse{
+		CalculateRefundResponse: armreservations.CalculateRefundResponse{
+			Properties: &armreservations.RefundResponseProperties{
+				SessionID: to.Ptr("session-1"),
+				PolicyResult: &armreservations.RefundPolicyResult{
+					Properties: &armreservations.RefundPolicyResultProperty{
+						PolicyErrors: []*armreservations.RefundPolicyError{
+							{Code: &code, Message: to.Ptr("limit reached")},
+							{Code: &code},
+							{},
+							nil,
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	quote, err := NewClientWithAPIs(calc, nil).CalculateRefund(context.Background(), target)
+	require.NoError(t, err)
+	assert.Nil(t, quote.Amount, "a missing amount is never reported as 0")
+	assert.Equal(t, []string{
+		"RefundLimitExceeded: limit reached",
+		"RefundLimitExceeded",
+		"azure reported an unspecified refund policy violation",
+		"azure reported an unspecified refund policy violation",
+	}, quote.PolicyErrors)
+	_, ok := quote.Headroom()
+	assert.False(t, ok)
+}
+
+func TestCalculateRefund_Errors(t *testing.T) {
+	apiErr := errors.New("boom")
+	c := NewClientWithAPIs(&stubCalculateRefund{err: apiErr}, nil)
+
+	_, err := c.CalculateRefund(context.Background(), target)
+	assert.ErrorIs(t, err, apiErr)
+	assert.ErrorContains(t, err, "azure: CalculateRefund")
+
+	c = NewClientWithAPIs(&stubCalculateRefund{err: context.Canceled}, nil)
+	_, err = c.CalculateRefund(context.Background(), target)
+	assert.Equal(t, context.Canceled, err, "context errors are propagated unwrapped")
+
+	_, err = c.CalculateRefund(context.Background(), ReturnTarget{ReservationOrderID: "order-1", Quantity: 1})
+	assert.ErrorContains(t, err, "reservation ID are required")
+	_, err = c.CalculateRefund(context.Background(), ReturnTarget{ReservationOrderID: "order-1", ReservationID: "res-1"})
+	assert.ErrorContains(t, err, "quantity must be >= 1")
+}
+
+func TestReturn(t *testing.T) {
+	ret := &stubReturn{resp: armreservations.ReturnClientPostResponse{
+		RefundResponse: armreservations.RefundResponse{
+			Properties: &armreservations.RefundResponseProperties{BillingRefundAmount: price(1200, "EUR")},
+		},
+	}}
+	c := NewClientWithAPIs(&stubCalculateRefund{}, ret)
+
+	result, err := c.Return(context.Background(), target, "session-1", "no longer needed")
+	require.NoError(t, err)
+	require.NotNil(t, result.Amount)
+	assert.Equal(t, 1200.0, *result.Amount)
+	assert.Equal(t, "EUR", result.Currency)
+	assert.Equal(t, "session-1", *ret.body.Properties.SessionID)
+	assert.Equal(t, "no longer needed", *ret.body.Properties.ReturnReason)
+	assert.Equal(t, "Reservation", *ret.body.Properties.Scope)
+}
+
+func TestReturn_Errors(t *testing.T) {
+	ret := &stubReturn{}
+	c := NewClientWithAPIs(&stubCalculateRefund{}, ret)
+
+	_, err := c.Return(context.Background(), target, "", "reason")
+	assert.ErrorContains(t, err, "session_id is required")
+	assert.Zero(t, ret.calls)
+
+	_, err = NewClientWithAPIs(&stubCalculateRefund{}, nil).Return(context.Background(), target, "session-1", "reason")
+	assert.ErrorContains(t, err, "without a Return API")
+
+	apiErr := errors.New("boom")
+	_, err = NewClientWithAPIs(&stubCalculateRefund{}, &stubReturn{err: apiErr}).Return(context.Background(), target, "session-1", "reason")
+	assert.ErrorIs(t, err, apiErr)
+}
+
+func TestNewClient_RequiresCredential(t *testing.T) {
+	_, err := NewClient(nil)
+	assert.ErrorContains(t, err, "credential must not be nil")
+}
@@ -4,10 +4,13 @@ package azure
 import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/appservice"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/blobstorage"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/cache"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/compute"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/cosmosdb"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/database"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/manageddisk"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/managedredis"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/savingsplans"
 	"github.com/LeanerCloud/CUDly/providers/azure/services/search"
@@ -55,6 +58,21 @@ func NewSynapseClient(cred azcore.TokenCredential, subscriptionID, region string
 	return synapse.NewClient(cred, subscriptionID, region)
 }
 
+// NewBlobStorageClient creates a new Azure Blob Storage reserved capacity client
+func NewBlobStorageClient(cred azcore.TokenCredential, subscriptionID, region string) provider.ServiceClient {
+	return blobstorage.NewClient(cred, subscriptionID, region)
+}
+
+// NewManagedDiskClient creates a new Azure Managed Disk reservation client
+func NewManagedDiskClient(cred azcore.TokenCredential, subscriptionID, region string) provider.ServiceClient {
+	return manageddisk.NewClient(cred, subscriptionID, region)
+}
+
+// NewAppServiceClient creates a new Azure App Service reservation client
+func NewAppServiceClient(cred azcore.TokenCredential, subscriptionID, region string) provider.ServiceClient {
+	return appservice.NewClient(cred, subscriptionID, region)
+}
+
 // NewRecommendationsClient creates a new Azure recommendations client.
 //
 // Returns an error when subscriptionID is empty — the adapter's downstream
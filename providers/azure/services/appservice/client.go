@@ -0,0 +1,352 @@
+// Package appservice provides the Azure App Service reservation client.
+// Azure sells reservations for the Premium v3 and Isolated v2 App Service
+// plan tiers; a reservation covers one plan instance of the reserved SKU
+// (e.g. "P1v3") in the reserved region for the term.
+package appservice
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/httpclient"
+	azrecs "github.com/LeanerCloud/CUDly/providers/azure/internal/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/internal/reservations"
+)
+
+// reservationResourceTypeAppService is the resourceType value for App
+// Service in the Consumption ReservationRecommendations API $filter.
+const reservationResourceTypeAppService = "AppService"
+
+// maxRecsPages caps Consumption API recommendation pagination.
+const maxRecsPages = 10
+
+// maxReservationsPages caps reservation-detail pagination.
+const maxReservationsPages = 50
+
+// reservableSKUs are the App Service plan SKUs Azure sells reservations for.
+// Older tiers (Basic, Standard, Premium v2, Isolated v1) are not reservable.
+var reservableSKUs = []string{
+	// Premium v3
+	"P0v3", "P1v3", "P2v3", "P3v3",
+	"P1mv3", "P2mv3", "P3mv3", "P4mv3", "P5mv3",
+	// Isolated v2
+	"I1v2", "I2v2", "I3v2", "I4v2", "I5v2", "I6v2",
+	"I1mv2", "I2mv2", "I3mv2", "I4mv2", "I5mv2",
+}
+
+// HTTPClient interface for HTTP operations (enables mocking)
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RecommendationsPager interface for recommendations pager (enables mocking)
+type RecommendationsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationRecommendationsClientListResponse, error)
+}
+
+// ReservationsDetailsPager interface for reservations details pager (enables mocking)
+type ReservationsDetailsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationsDetailsClientListResponse, error)
+}
+
+// AppServiceClient handles Azure App Service reservations
+type AppServiceClient struct {
+	cred                 azcore.TokenCredential
+	subscriptionID       string
+	region               string
+	httpClient           HTTPClient
+	recommendationsPager RecommendationsPager
+	reservationsPager    ReservationsDetailsPager
+}
+
+// NewClient creates a new Azure App Service reservation client
+func NewClient(cred azcore.TokenCredential, subscriptionID, region string) *AppServiceClient {
+	return &AppServiceClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpclient.New(),
+	}
+}
+
+// NewClientWithHTTP creates a new Azure App Service reservation client with a custom HTTP client (for testing)
+func NewClientWithHTTP(cred azcore.TokenCredential, subscriptionID, region string, httpClient HTTPClient) *AppServiceClient {
+	return &AppServiceClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpClient,
+	}
+}
+
+// SetRecommendationsPager sets the recommendations pager (for testing)
+func (c *AppServiceClient) SetRecommendationsPager(pager RecommendationsPager) {
+	c.recommendationsPager = pager
+}
+
+// SetReservationsPager sets the reservations pager (for testing)
+func (c *AppServiceClient) SetReservationsPager(pager ReservationsDetailsPager) {
+	c.reservationsPager = pager
+}
+
+// GetServiceType returns the service type
+func (c *AppServiceClient) GetServiceType() common.ServiceType {
+	return common.ServiceAppService
+}
+
+// GetRegion returns the region
+func (c *AppServiceClient) GetRegion() string {
+	return c.region
+}
+
+// GetRecommendations gets App Service reservation recommendations from the
+// Azure Consumption API
+func (c *AppServiceClient) GetRecommendations(ctx context.Context, _ *common.RecommendationParams) ([]common.Recommendation, error) {
+	recommendations := make([]common.Recommendation, 0)
+
+	var pager RecommendationsPager
+	if c.recommendationsPager != nil {
+		pager = c.recommendationsPager
+	} else {
+		client, err := armconsumption.NewReservationRecommendationsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumption client: %w", err)
+		}
+		scope := fmt.Sprintf("/subscriptions/%s", c.subscriptionID)
+		filter := "properties/scope eq 'Shared' and properties/resourceType eq '" + reservationResourceTypeAppService + "'"
+		pager = client.NewListPager(scope, &armconsumption.ReservationRecommendationsClientListOptions{Filter: &filter})
+	}
+
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxRecsPages {
+			return nil, fmt.Errorf("appservice: GetRecommendations pagination cap (%d pages) reached", maxRecsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get App Service recommendations: %w", err)
+		}
+
+		for _, rec := range page.Value {
+			if converted := c.convertRecommendation(rec); converted != nil {
+				recommendations = append(recommendations, azrecs.ExpandPaymentVariants(*converted)...)
+			}
+		}
+	}
+
+	return recommendations, nil
+}
+
+// convertRecommendation converts an Azure App Service reservation recommendation to common format
+func (c *AppServiceClient) convertRecommendation(azureRec armconsumption.ReservationRecommendationClassification) *common.Recommendation {
+	extracted := azrecs.Extract(azureRec)
+	if extracted == nil {
+		return nil
+	}
+
+	rec := &common.Recommendation{
+		Provider:             common.ProviderAzure,
+		Service:              common.ServiceAppService,
+		Account:              c.subscriptionID,
+		CommitmentType:       common.CommitmentReservedInstance,
+		Timestamp:            time.Now(),
+		Region:               extracted.Region,
+		ResourceType:         extracted.ResourceType,
+		Count:                extracted.Count,
+		OnDemandCost:         extracted.OnDemandCost,
+		CommitmentCost:       extracted.CommitmentCost,
+		EstimatedSavings:     extracted.EstimatedSavings,
+		Term:                 extracted.Term,
+		RecurringMonthlyCost: extracted.RecurringMonthlyCost,
+		PaymentOption:        "upfront", // Default, will be expanded by ExpandPaymentVariants
+	}
+	if rec.Region == "" {
+		rec.Region = c.region
+	}
+	return rec
+}
+
+// GetExistingCommitments retrieves existing App Service reservations
+func (c *AppServiceClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	pager := c.reservationsPager
+	if pager == nil {
+		client, err := armconsumption.NewReservationsDetailsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reservations details client: %w", err)
+		}
+		scope := fmt.Sprintf("subscriptions/%s", c.subscriptionID)
+		pager = client.NewListPager(scope, &armconsumption.ReservationsDetailsClientListOptions{})
+	}
+
+	commitments := make([]common.Commitment, 0)
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxReservationsPages {
+			return nil, fmt.Errorf("appservice: GetExistingCommitments pagination cap (%d pages) reached", maxReservationsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("appservice: list reservations: %w", err)
+		}
+		for _, detail := range page.Value {
+			if commitment := c.convertReservation(detail); commitment != nil {
+				commitments = append(commitments, *commitment)
+			}
+		}
+	}
+
+	return commitments, nil
+}
+
+// convertReservation converts a reservation detail to a commitment if it is
+// a App Service reservation
+func (c *AppServiceClient) convertReservation(detail *armconsumption.ReservationDetail) *common.Commitment {
+	if detail == nil || detail.Properties == nil || detail.Properties.SKUName == nil {
+		return nil
+	}
+	props := detail.Properties
+	if !isAppServiceSKU(*props.SKUName) {
+		return nil
+	}
+
+	commitment := &common.Commitment{
+		Provider:       common.ProviderAzure,
+		Account:        c.subscriptionID,
+		CommitmentType: common.CommitmentReservedInstance,
+		Service:        common.ServiceAppService,
+		Region:         c.region,
+		ResourceType:   *props.SKUName,
+		State:          "active",
+	}
+	if props.ReservationID != nil {
+		commitment.CommitmentID = *props.ReservationID
+	}
+	return commitment
+}
+
+// isAppServiceSKU reports whether a reservation SKU name is an App Service SKU
+func isAppServiceSKU(sku string) bool {
+	lower := strings.ToLower(sku)
+	if strings.Contains(lower, "app_service") || strings.Contains(lower, "app service") {
+		return true
+	}
+	_, ok := canonicalSKU(sku)
+	return ok
+}
+
+// PurchaseCommitment purchases a App Service reservation using the shared
+// two-step calculatePrice->purchase flow
+func (c *AppServiceClient) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	return reservations.Purchase(ctx, c.cred, c.httpClient, reservations.PurchaseSpec{
+		SubscriptionID:       c.subscriptionID,
+		Region:               c.region,
+		ReservedResourceType: armreservations.ReservedResourceTypeAppService,
+		DisplayService:       "appsvc",
+	}, rec, opts)
+}
+
+// ValidateOffering validates that the recommendation names a reservable plan SKU
+func (c *AppServiceClient) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	validSKUs, err := c.GetValidResourceTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get valid SKUs: %w", err)
+	}
+
+	resourceType := strings.TrimSpace(rec.ResourceType)
+	for _, sku := range validSKUs {
+		if strings.EqualFold(sku, resourceType) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid Azure App Service reservation SKU: %s", rec.ResourceType)
+}
+
+// GetOfferingDetails retrieves App Service reservation offering details from
+// the Azure Retail Prices API
+func (c *AppServiceClient) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	termYears, err := reservations.ParseTermYears(rec.Term)
+	if err != nil {
+		return nil, fmt.Errorf("invalid term: %w", err)
+	}
+
+	sku, ok := canonicalSKU(rec.ResourceType)
+	if !ok {
+		return nil, fmt.Errorf("invalid Azure App Service reservation SKU: %s", rec.ResourceType)
+	}
+	filter := fmt.Sprintf("serviceName eq 'Azure App Service' and armRegionName eq '%s' and skuName eq '%s'",
+		reservations.ODataQuote(c.region), retailSKUName(sku))
+	p, err := reservations.LookupRetailPricing(ctx, c.httpClient, filter, termYears, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+
+	var upfrontCost, recurringCost float64
+	totalCost := p.ReservationPrice
+
+	switch rec.PaymentOption {
+	case "all-upfront", "upfront":
+		upfrontCost = totalCost
+	case "monthly", "no-upfront":
+		recurringCost = totalCost / (float64(termYears) * 12)
+	default:
+		// Fail loud on an unrecognised payment option rather than silently
+		// billing it as all-upfront (owner policy: no silent fallbacks on
+		// money-affecting fields).
+		return nil, fmt.Errorf("unsupported payment option for Azure App Service offering details: %q", rec.PaymentOption)
+	}
+
+	return &common.OfferingDetails{
+		OfferingID:          fmt.Sprintf("azure-appservice-%s-%s-%s", sku, c.region, rec.Term),
+		ResourceType:        rec.ResourceType,
+		Term:                rec.Term,
+		PaymentOption:       rec.PaymentOption,
+		UpfrontCost:         upfrontCost,
+		RecurringCost:       recurringCost,
+		TotalCost:           totalCost,
+		EffectiveHourlyRate: totalCost / (8760.0 * float64(termYears)),
+		Currency:            p.Currency,
+	}, nil
+}
+
+// canonicalSKU returns the reservableSKUs spelling of sku, ignoring case
+// and surrounding whitespace.
+func canonicalSKU(sku string) (string, bool) {
+	sku = strings.TrimSpace(sku)
+	for _, s := range reservableSKUs {
+		if strings.EqualFold(s, sku) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// retailSKUName converts a plan SKU to the Retail Prices API skuName, which
+// separates the generation suffix with a space ("P1v3" -> "P1 v3").
+func retailSKUName(sku string) string {
+	if i := strings.LastIndex(sku, "v"); i > 0 {
+		return sku[:i] + " " + sku[i:]
+	}
+	return sku
+}
+
+// GetValidResourceTypes returns the reservable App Service plan SKUs
+func (c *AppServiceClient) GetValidResourceTypes(_ context.Context) ([]string, error) {
+	skus := make([]string, len(reservableSKUs))
+	copy(skus, reservableSKUs)
+	return skus, nil
+}
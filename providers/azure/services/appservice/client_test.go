@@ -0,0 +1,210 @@
+package appservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure/mocks"
+)
+
+// MockTokenCredential for testing PurchaseCommitment
+type MockTokenCredential struct {
+	token string
+	err   error
+}
+
+func (m *MockTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if m.err != nil {
+		return azcore.AccessToken{}, m.err
+	}
+	return azcore.AccessToken{Token: m.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// errPager fails every NextPage call.
+type errPager struct{ err error }
+
+func (p *errPager) More() bool { return true }
+func (p *errPager) NextPage(context.Context) (armconsumption.ReservationRecommendationsClientListResponse, error) {
+	return armconsumption.ReservationRecommendationsClientListResponse{}, p.err
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(nil, "sub-1", "eastus")
+	assert.Equal(t, common.ServiceAppService, client.GetServiceType())
+	assert.Equal(t, "eastus", client.GetRegion())
+	assert.NotNil(t, client.httpClient)
+}
+
+func TestAppServiceClient_GetRecommendations(t *testing.T) {
+	client := NewClient(nil, "sub-1", "eastus")
+	client.SetRecommendationsPager(&mocks.MockRecommendationsPager{
+		HasMore: true,
+		Results: []armconsumption.ReservationRecommendationClassification{
+			mocks.BuildLegacyReservationRecommendation(
+				mocks.WithRegion("eastus"),
+				mocks.WithTerm("P3Y"),
+				mocks.WithQuantity(4),
+				mocks.WithNormalizedSize("P1v3"),
+				mocks.WithCosts(9000, 6000, 3000),
+			),
+		},
+	})
+
+	recs, err := client.GetRecommendations(context.Background(), &common.RecommendationParams{})
+	require.NoError(t, err)
+	require.Len(t, recs, 2, "upfront and monthly variants")
+	for _, rec := range recs {
+		assert.Equal(t, common.ServiceAppService, rec.Service)
+		assert.Equal(t, common.ProviderAzure, rec.Provider)
+		assert.Equal(t, "sub-1", rec.Account)
+		assert.Equal(t, "P1v3", rec.ResourceType)
+		assert.Equal(t, 4, rec.Count)
+		assert.Equal(t, "3yr", rec.Term)
+	}
+	assert.Equal(t, "upfront", recs[0].PaymentOption)
+	assert.Equal(t, "monthly", recs[1].PaymentOption)
+}
+
+func TestAppServiceClient_GetRecommendations_PagerError(t *testing.T) {
+	client := NewClient(nil, "sub-1", "eastus")
+	client.SetRecommendationsPager(&errPager{err: errors.New("API error")})
+
+	_, err := client.GetRecommendations(context.Background(), nil)
+	assert.ErrorContains(t, err, "failed to get App Service recommendations")
+}
+
+func TestAppServiceClient_GetExistingCommitments(t *testing.T) {
+	client := NewClient(nil, "sub-1", "eastus")
+	client.SetReservationsPager(&mocks.MockReservationsDetailsPager{
+		HasMore: true,
+		Results: []*armconsumption.ReservationDetail{
+			{Properties: &armconsumption.ReservationDetailProperties{
+				ReservationID: mocks.StringPtr("res-appsvc"),
+				SKUName:       mocks.StringPtr("I2v2"),
+			}},
+			{Properties: &armconsumption.ReservationDetailProperties{
+				ReservationID: mocks.StringPtr("res-vm"),
+				SKUName:       mocks.StringPtr("Standard_D2s_v3"),
+			}},
+			{Properties: nil},
+		},
+	})
+
+	commitments, err := client.GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, commitments, 1)
+	assert.Equal(t, "res-appsvc", commitments[0].CommitmentID)
+	assert.Equal(t, common.ServiceAppService, commitments[0].Service)
+	assert.Equal(t, "I2v2", commitments[0].ResourceType)
+}
+
+func TestAppServiceClient_PurchaseCommitment(t *testing.T) {
+	mockHTTP := &mocks.MockHTTPClient{}
+	client := NewClientWithHTTP(&MockTokenCredential{token: "test-token"}, "sub-1", "eastus", mockHTTP)
+
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		if r.URL.Path != "/providers/Microsoft.Capacity/calculatePrice" {
+			return false
+		}
+		var body struct {
+			SKU        map[string]string      `json:"sku"`
+			Properties map[string]interface{} `json:"properties"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		return json.Unmarshal(b, &body) == nil &&
+			body.SKU["name"] == "P1v3" &&
+			body.Properties["reservedResourceType"] == "AppService"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{"properties":{"reservationOrderId":"appsvc-order-1"}}`), nil).Once()
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.Path == "/providers/Microsoft.Capacity/reservationOrders/appsvc-order-1/purchase"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{}`), nil).Once()
+
+	rec := common.Recommendation{ResourceType: "P1v3", Term: "1yr", Count: 2, PaymentOption: "upfront", CommitmentCost: 800}
+	result, err := client.PurchaseCommitment(context.Background(), rec, common.PurchaseOptions{Source: common.PurchaseSourceCLI})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "appsvc-order-1", result.CommitmentID)
+	assert.Equal(t, 800.0, result.Cost)
+	mockHTTP.AssertExpectations(t)
+}
+
+func TestAppServiceClient_PurchaseCommitment_RequiresSource(t *testing.T) {
+	client := NewClientWithHTTP(&MockTokenCredential{token: "t"}, "sub-1", "eastus", &mocks.MockHTTPClient{})
+	rec := common.Recommendation{ResourceType: "P1v3", Term: "1yr", Count: 1, PaymentOption: "upfront"}
+
+	result, err := client.PurchaseCommitment(context.Background(), rec, common.PurchaseOptions{})
+	assert.ErrorContains(t, err, "purchase source is required")
+	assert.False(t, result.Success)
+}
+
+func TestAppServiceClient_GetOfferingDetails(t *testing.T) {
+	mockHTTP := &mocks.MockHTTPClient{}
+	client := NewClientWithHTTP(nil, "sub-1", "eastus", mockHTTP)
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.Host == "prices.azure.com" &&
+			r.URL.Query().Get("$filter") == "serviceName eq 'Azure App Service' and armRegionName eq 'eastus' and skuName eq 'P1 v3'"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{"Items":[`+
+		`{"type":"Consumption","unitPrice":135.17,"currencyCode":"USD"},`+
+		`{"type":"Reservation","reservationTerm":"1 Year","retailPrice":1460,"currencyCode":"USD"}]}`), nil)
+
+	details, err := client.GetOfferingDetails(context.Background(), common.Recommendation{
+		ResourceType: "P1v3", Term: "1yr", PaymentOption: "monthly",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1460.0, details.TotalCost)
+	assert.Equal(t, 0.0, details.UpfrontCost)
+	assert.InDelta(t, 1460.0/12, details.RecurringCost, 1e-9)
+	assert.InDelta(t, 1460.0/8760, details.EffectiveHourlyRate, 1e-9)
+	assert.Equal(t, "azure-appservice-P1v3-eastus-1yr", details.OfferingID)
+
+	// The payment option is checked after pricing, so the lookup must succeed.
+	client = NewClientWithHTTP(nil, "sub-1", "eastus", pricedHTTP())
+	_, err = client.GetOfferingDetails(context.Background(), common.Recommendation{
+		ResourceType: "P1v3", Term: "1yr", PaymentOption: "partial-upfront",
+	})
+	assert.ErrorContains(t, err, "unsupported payment option")
+}
+
+func pricedHTTP() *mocks.MockHTTPClient {
+	m := &mocks.MockHTTPClient{}
+	m.On("Do", mock.Anything).Return(mocks.CreateMockHTTPResponse(http.StatusOK,
+		`{"Items":[{"type":"Reservation","reservationTerm":"1 Year","retailPrice":1460}]}`), nil).Once()
+	return m
+}
+
+func TestAppServiceClient_GetOfferingDetails_NoReservationPrice(t *testing.T) {
+	mockHTTP := &mocks.MockHTTPClient{}
+	client := NewClientWithHTTP(nil, "sub-1", "eastus", mockHTTP)
+	mockHTTP.On("Do", mock.Anything).Return(mocks.CreateMockHTTPResponse(http.StatusOK,
+		`{"Items":[{"type":"Consumption","unitPrice":135.17}]}`), nil)
+
+	_, err := client.GetOfferingDetails(context.Background(), common.Recommendation{
+		ResourceType: "P1v3", Term: "3yr", PaymentOption: "upfront",
+	})
+	assert.ErrorContains(t, err, "no 3 Years reservation price found")
+}
+
+func TestAppServiceClient_ValidateOffering(t *testing.T) {
+	client := NewClient(nil, "sub-1", "eastus")
+	assert.NoError(t, client.ValidateOffering(context.Background(), common.Recommendation{ResourceType: "i6v2"}))
+	assert.ErrorContains(t, client.ValidateOffering(context.Background(), common.Recommendation{ResourceType: "S1"}),
+		"invalid Azure App Service reservation SKU")
+}
+
+func TestRetailSKUName(t *testing.T) {
+	assert.Equal(t, "P1 v3", retailSKUName("P1v3"))
+	assert.Equal(t, "I3m v2", retailSKUName("I3mv2"))
+}
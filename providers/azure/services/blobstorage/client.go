@@ -0,0 +1,375 @@
+// Package blobstorage provides the Azure Blob Storage reserved capacity client.
+// Blob reserved capacity is sold in 100 TB and 1 PB monthly units per access
+// tier (Hot, Cool, Archive) and redundancy (LRS, ZRS, GRS, RA-GRS) in a
+// region; CUDly names a unit as "<Tier>_<Redundancy>_<Size>", e.g.
+// "Hot_LRS_100TB".
+package blobstorage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/httpclient"
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/pricing"
+	azrecs "github.com/LeanerCloud/CUDly/providers/azure/internal/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/internal/reservations"
+)
+
+// reservationResourceTypeBlockBlob is the resourceType value for Blob
+// Storage reserved capacity in the Consumption ReservationRecommendations
+// API $filter.
+const reservationResourceTypeBlockBlob = "BlockBlob"
+
+// maxRecsPages caps Consumption API recommendation pagination.
+const maxRecsPages = 10
+
+// maxReservationsPages caps reservation-detail pagination.
+const maxReservationsPages = 50
+
+var (
+	accessTiers  = []string{"Hot", "Cool", "Archive"}
+	redundancies = []string{"LRS", "ZRS", "GRS", "RA-GRS"}
+	unitSizes    = []string{"100TB", "1PB"}
+)
+
+// HTTPClient interface for HTTP operations (enables mocking)
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RecommendationsPager interface for recommendations pager (enables mocking)
+type RecommendationsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationRecommendationsClientListResponse, error)
+}
+
+// ReservationsDetailsPager interface for reservations details pager (enables mocking)
+type ReservationsDetailsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationsDetailsClientListResponse, error)
+}
+
+// BlobStorageClient handles Azure Blob Storage reserved capacity
+type BlobStorageClient struct {
+	cred                 azcore.TokenCredential
+	subscriptionID       string
+	region               string
+	httpClient           HTTPClient
+	recommendationsPager RecommendationsPager
+	reservationsPager    ReservationsDetailsPager
+}
+
+// NewClient creates a new Azure Blob Storage reserved capacity client
+func NewClient(cred azcore.TokenCredential, subscriptionID, region string) *BlobStorageClient {
+	return &BlobStorageClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpclient.New(),
+	}
+}
+
+// NewClientWithHTTP creates a new Azure Blob Storage reserved capacity client with a custom HTTP client (for testing)
+func NewClientWithHTTP(cred azcore.TokenCredential, subscriptionID, region string, httpClient HTTPClient) *BlobStorageClient {
+	return &BlobStorageClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpClient,
+	}
+}
+
+// SetRecommendationsPager sets the recommendations pager (for testing)
+func (c *BlobStorageClient) SetRecommendationsPager(pager RecommendationsPager) {
+	c.recommendationsPager = pager
+}
+
+// SetReservationsPager sets the reservations pager (for testing)
+func (c *BlobStorageClient) SetReservationsPager(pager ReservationsDetailsPager) {
+	c.reservationsPager = pager
+}
+
+// GetServiceType returns the service type
+func (c *BlobStorageClient) GetServiceType() common.ServiceType {
+	return common.ServiceStorage
+}
+
+// GetRegion returns the region
+func (c *BlobStorageClient) GetRegion() string {
+	return c.region
+}
+
+// GetRecommendations gets Blob Storage reserved capacity recommendations
+// from the Azure Consumption API
+func (c *BlobStorageClient) GetRecommendations(ctx context.Context, _ *common.RecommendationParams) ([]common.Recommendation, error) {
+	recommendations := make([]common.Recommendation, 0)
+
+	var pager RecommendationsPager
+	if c.recommendationsPager != nil {
+		pager = c.recommendationsPager
+	} else {
+		client, err := armconsumption.NewReservationRecommendationsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumption client: %w", err)
+		}
+		scope := fmt.Sprintf("/subscriptions/%s", c.subscriptionID)
+		filter := "properties/scope eq 'Shared' and properties/resourceType eq '" + reservationResourceTypeBlockBlob + "'"
+		pager = client.NewListPager(scope, &armconsumption.ReservationRecommendationsClientListOptions{Filter: &filter})
+	}
+
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxRecsPages {
+			return nil, fmt.Errorf("blobstorage: GetRecommendations pagination cap (%d pages) reached", maxRecsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Blob Storage recommendations: %w", err)
+		}
+
+		for _, rec := range page.Value {
+			if converted := c.convertRecommendation(rec); converted != nil {
+				recommendations = append(recommendations, azrecs.ExpandPaymentVariants(*converted)...)
+			}
+		}
+	}
+
+	return recommendations, nil
+}
+
+// convertRecommendation converts an Azure Blob Storage reservation recommendation to common format
+func (c *BlobStorageClient) convertRecommendation(azureRec armconsumption.ReservationRecommendationClassification) *common.Recommendation {
+	extracted := azrecs.Extract(azureRec)
+	if extracted == nil {
+		return nil
+	}
+
+	rec := &common.Recommendation{
+		Provider:             common.ProviderAzure,
+		Service:              common.ServiceStorage,
+		Account:              c.subscriptionID,
+		CommitmentType:       common.CommitmentReservedInstance,
+		Timestamp:            time.Now(),
+		Region:               extracted.Region,
+		ResourceType:         extracted.ResourceType,
+		Count:                extracted.Count,
+		OnDemandCost:         extracted.OnDemandCost,
+		CommitmentCost:       extracted.CommitmentCost,
+		EstimatedSavings:     extracted.EstimatedSavings,
+		Term:                 extracted.Term,
+		RecurringMonthlyCost: extracted.RecurringMonthlyCost,
+		PaymentOption:        "upfront", // Default, will be expanded by ExpandPaymentVariants
+	}
+	if rec.Region == "" {
+		rec.Region = c.region
+	}
+	return rec
+}
+
+// GetExistingCommitments retrieves existing Blob Storage reservations
+func (c *BlobStorageClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	pager := c.reservationsPager
+	if pager == nil {
+		client, err := armconsumption.NewReservationsDetailsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reservations details client: %w", err)
+		}
+		scope := fmt.Sprintf("subscriptions/%s", c.subscriptionID)
+		pager = client.NewListPager(scope, &armconsumption.ReservationsDetailsClientListOptions{})
+	}
+
+	commitments := make([]common.Commitment, 0)
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxReservationsPages {
+			return nil, fmt.Errorf("blobstorage: GetExistingCommitments pagination cap (%d pages) reached", maxReservationsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("blobstorage: list reservations: %w", err)
+		}
+		for _, detail := range page.Value {
+			if commitment := c.convertReservation(detail); commitment != nil {
+				commitments = append(commitments, *commitment)
+			}
+		}
+	}
+
+	return commitments, nil
+}
+
+// convertReservation converts a reservation detail to a commitment if it is
+// a Blob Storage reservation
+func (c *BlobStorageClient) convertReservation(detail *armconsumption.ReservationDetail) *common.Commitment {
+	if detail == nil || detail.Properties == nil || detail.Properties.SKUName == nil {
+		return nil
+	}
+	props := detail.Properties
+	if !isBlobSKU(*props.SKUName) {
+		return nil
+	}
+
+	commitment := &common.Commitment{
+		Provider:       common.ProviderAzure,
+		Account:        c.subscriptionID,
+		CommitmentType: common.CommitmentReservedInstance,
+		Service:        common.ServiceStorage,
+		Region:         c.region,
+		ResourceType:   *props.SKUName,
+		State:          "active",
+	}
+	if props.ReservationID != nil {
+		commitment.CommitmentID = *props.ReservationID
+	}
+	return commitment
+}
+
+// isBlobSKU reports whether a reservation SKU name is a Blob Storage SKU
+func isBlobSKU(sku string) bool {
+	if strings.Contains(strings.ToLower(sku), "blob") {
+		return true
+	}
+	_, err := parseSKU(sku)
+	return err == nil
+}
+
+// PurchaseCommitment purchases Blob Storage reserved capacity using the
+// shared two-step calculatePrice->purchase flow
+func (c *BlobStorageClient) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	return reservations.Purchase(ctx, c.cred, c.httpClient, reservations.PurchaseSpec{
+		SubscriptionID:       c.subscriptionID,
+		Region:               c.region,
+		ReservedResourceType: armreservations.ReservedResourceTypeBlockBlob,
+		DisplayService:       "blob",
+	}, rec, opts)
+}
+
+// ValidateOffering validates that the recommendation names a Blob reserved capacity unit
+func (c *BlobStorageClient) ValidateOffering(_ context.Context, rec common.Recommendation) error {
+	if _, err := parseSKU(rec.ResourceType); err != nil {
+		return fmt.Errorf("invalid Azure Blob Storage reservation SKU: %w", err)
+	}
+	return nil
+}
+
+// GetOfferingDetails retrieves Blob Storage reservation offering details from
+// the Azure Retail Prices API
+func (c *BlobStorageClient) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	termYears, err := reservations.ParseTermYears(rec.Term)
+	if err != nil {
+		return nil, fmt.Errorf("invalid term: %w", err)
+	}
+	sku, err := parseSKU(rec.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := fmt.Sprintf("serviceName eq 'Storage' and productName eq 'Blob Storage' and armRegionName eq '%s' and skuName eq '%s %s'",
+		reservations.ODataQuote(c.region), sku.tier, sku.redundancy)
+	p, err := reservations.LookupRetailPricing(ctx, c.httpClient, filter, termYears, sku.matchesPriceItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+
+	var upfrontCost, recurringCost float64
+	totalCost := p.ReservationPrice
+
+	switch rec.PaymentOption {
+	case "all-upfront", "upfront":
+		upfrontCost = totalCost
+	case "monthly", "no-upfront":
+		recurringCost = totalCost / (float64(termYears) * 12)
+	default:
+		// Fail loud on an unrecognised payment option rather than silently
+		// billing it as all-upfront (owner policy: no silent fallbacks on
+		// money-affecting fields).
+		return nil, fmt.Errorf("unsupported payment option for Azure Blob Storage offering details: %q", rec.PaymentOption)
+	}
+
+	return &common.OfferingDetails{
+		OfferingID:          fmt.Sprintf("azure-blob-%s-%s-%s", rec.ResourceType, c.region, rec.Term),
+		ResourceType:        rec.ResourceType,
+		Term:                rec.Term,
+		PaymentOption:       rec.PaymentOption,
+		UpfrontCost:         upfrontCost,
+		RecurringCost:       recurringCost,
+		TotalCost:           totalCost,
+		EffectiveHourlyRate: totalCost / (8760.0 * float64(termYears)),
+		Currency:            p.Currency,
+	}, nil
+}
+
+// GetValidResourceTypes returns every Blob reserved capacity unit CUDly can purchase
+func (c *BlobStorageClient) GetValidResourceTypes(_ context.Context) ([]string, error) {
+	skus := make([]string, 0, len(accessTiers)*len(redundancies)*len(unitSizes))
+	for _, tier := range accessTiers {
+		for _, redundancy := range redundancies {
+			for _, size := range unitSizes {
+				skus = append(skus, tier+"_"+redundancy+"_"+size)
+			}
+		}
+	}
+	return skus, nil
+}
+
+// blobSKU is a parsed "<Tier>_<Redundancy>_<Size>" reservation unit.
+type blobSKU struct {
+	tier       string
+	redundancy string
+	size       string
+}
+
+// parseSKU parses a reservation unit name case-insensitively into its
+// canonical spelling.
+func parseSKU(s string) (blobSKU, error) {
+	parts := strings.Split(strings.TrimSpace(s), "_")
+	if len(parts) != 3 {
+		return blobSKU{}, fmt.Errorf("%q is not of the form <Tier>_<Redundancy>_<Size>", s)
+	}
+	tier, ok := canonical(accessTiers, parts[0])
+	if !ok {
+		return blobSKU{}, fmt.Errorf("unknown access tier %q in %q", parts[0], s)
+	}
+	redundancy, ok := canonical(redundancies, parts[1])
+	if !ok {
+		return blobSKU{}, fmt.Errorf("unknown redundancy %q in %q", parts[1], s)
+	}
+	size, ok := canonical(unitSizes, parts[2])
+	if !ok {
+		return blobSKU{}, fmt.Errorf("unknown unit size %q in %q", parts[2], s)
+	}
+	return blobSKU{tier: tier, redundancy: redundancy, size: size}, nil
+}
+
+// canonical returns the entry of values equal to s ignoring case.
+func canonical(values []string, s string) (string, bool) {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// matchesPriceItem keeps the reservation items priced in this SKU's unit
+// size ("100 TB/Month" vs "1 PB/Month") and the data-stored consumption
+// meter, dropping the per-operation meters that share the skuName.
+func (s blobSKU) matchesPriceItem(item pricing.RetailPriceItem) bool {
+	if strings.EqualFold(item.Type, "Reservation") {
+		unit := strings.ReplaceAll(item.UnitOfMeasure, " ", "")
+		return strings.HasPrefix(strings.ToUpper(unit), strings.ToUpper(s.size))
+	}
+	return strings.Contains(item.MeterName, "Data Stored")
+}
@@ -0,0 +1,171 @@
+package blobstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure/mocks"
+)
+
+// MockTokenCredential for testing PurchaseCommitment
+type MockTokenCredential struct {
+	token string
+	err   error
+}
+
+func (m *MockTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if m.err != nil {
+		return azcore.AccessToken{}, m.err
+	}
+	return azcore.AccessToken{Token: m.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// errDetailsPager fails every NextPage call.
+type errDetailsPager struct{ err error }
+
+func (p *errDetailsPager) More() bool { return true }
+func (p *errDetailsPager) NextPage(context.Context) (armconsumption.ReservationsDetailsClientListResponse, error) {
+	return armconsumption.ReservationsDetailsClientListResponse{}, p.err
+}
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	assert.Equal(t, common.ServiceStorage, client.GetServiceType())
+	assert.Equal(t, "westeurope", client.GetRegion())
+	assert.NotNil(t, client.httpClient)
+}
+
+func TestBlobStorageClient_GetRecommendations(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	client.SetRecommendationsPager(&mocks.MockRecommendationsPager{
+		HasMore: true,
+		Results: []armconsumption.ReservationRecommendationClassification{
+			mocks.BuildLegacyReservationRecommendation(
+				mocks.WithRegion(""),
+				mocks.WithQuantity(2),
+				mocks.WithSKU("Hot_LRS_100TB"),
+				mocks.WithCosts(50000, 40000, 10000),
+			),
+		},
+	})
+
+	recs, err := client.GetRecommendations(context.Background(), &common.RecommendationParams{})
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	for _, rec := range recs {
+		assert.Equal(t, common.ServiceStorage, rec.Service)
+		assert.Equal(t, "Hot_LRS_100TB", rec.ResourceType)
+		assert.Equal(t, 2, rec.Count)
+		assert.Equal(t, "westeurope", rec.Region, "falls back to the client region")
+	}
+}
+
+func TestBlobStorageClient_GetExistingCommitments(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	client.SetReservationsPager(&mocks.MockReservationsDetailsPager{
+		HasMore: true,
+		Results: []*armconsumption.ReservationDetail{
+			{Properties: &armconsumption.ReservationDetailProperties{
+				ReservationID: mocks.StringPtr("res-blob"),
+				SKUName:       mocks.StringPtr("Cool_GRS_1PB"),
+			}},
+			{Properties: &armconsumption.ReservationDetailProperties{
+				ReservationID: mocks.StringPtr("res-vm"),
+				SKUName:       mocks.StringPtr("Standard_D2s_v3"),
+			}},
+		},
+	})
+
+	commitments, err := client.GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, commitments, 1)
+	assert.Equal(t, "res-blob", commitments[0].CommitmentID)
+	assert.Equal(t, common.ServiceStorage, commitments[0].Service)
+}
+
+func TestBlobStorageClient_GetExistingCommitments_PagerError(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	client.SetReservationsPager(&errDetailsPager{err: errors.New("API error")})
+
+	commitments, err := client.GetExistingCommitments(context.Background())
+	assert.ErrorContains(t, err, "blobstorage: list reservations")
+	assert.Nil(t, commitments)
+}
+
+func TestBlobStorageClient_PurchaseCommitment(t *testing.T) {
+	mockHTTP := &mocks.MockHTTPClient{}
+	client := NewClientWithHTTP(&MockTokenCredential{token: "test-token"}, "sub-1", "westeurope", mockHTTP)
+
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		if r.URL.Path != "/providers/Microsoft.Capacity/calculatePrice" {
+			return false
+		}
+		var body struct {
+			Location   string                 `json:"location"`
+			Properties map[string]interface{} `json:"properties"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		return json.Unmarshal(b, &body) == nil &&
+			body.Location == "westeurope" &&
+			body.Properties["reservedResourceType"] == "BlockBlob" &&
+			body.Properties["term"] == "P3Y"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{"properties":{"reservationOrderId":"blob-order-1"}}`), nil).Once()
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.Path == "/providers/Microsoft.Capacity/reservationOrders/blob-order-1/purchase"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{}`), nil).Once()
+
+	rec := common.Recommendation{ResourceType: "Hot_LRS_100TB", Term: "3yr", Count: 1, PaymentOption: "monthly", CommitmentCost: 60000}
+	result, err := client.PurchaseCommitment(context.Background(), rec, common.PurchaseOptions{Source: common.PurchaseSourceCLI})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "blob-order-1", result.CommitmentID)
+	mockHTTP.AssertExpectations(t)
+}
+
+func TestBlobStorageClient_GetOfferingDetails(t *testing.T) {
+	mockHTTP := &mocks.MockHTTPClient{}
+	client := NewClientWithHTTP(nil, "sub-1", "westeurope", mockHTTP)
+	mockHTTP.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.Query().Get("$filter") == "serviceName eq 'Storage' and productName eq 'Blob Storage' and armRegionName eq 'westeurope' and skuName eq 'Hot RA-GRS'"
+	})).Return(mocks.CreateMockHTTPResponse(http.StatusOK, `{"Items":[`+
+		`{"type":"Consumption","meterName":"Write Operations","unitPrice":0.1},`+
+		`{"type":"Consumption","meterName":"Hot RA-GRS Data Stored","unitPrice":0.046},`+
+		`{"type":"Reservation","reservationTerm":"1 Year","unitOfMeasure":"100 TB/Month","retailPrice":30000},`+
+		`{"type":"Reservation","reservationTerm":"1 Year","unitOfMeasure":"1 PB/Month","retailPrice":280000}]}`), nil)
+
+	details, err := client.GetOfferingDetails(context.Background(), common.Recommendation{
+		ResourceType: "hot_ra-grs_1pb", Term: "1yr", PaymentOption: "upfront",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 280000.0, details.TotalCost)
+	assert.Equal(t, 280000.0, details.UpfrontCost)
+	assert.Equal(t, 0.0, details.RecurringCost)
+}
+
+func TestBlobStorageClient_ValidateOffering(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	assert.NoError(t, client.ValidateOffering(context.Background(), common.Recommendation{ResourceType: "Archive_ZRS_100TB"}))
+	assert.ErrorContains(t, client.ValidateOffering(context.Background(), common.Recommendation{ResourceType: "Premium_LRS_100TB"}), "unknown access tier")
+	assert.ErrorContains(t, client.ValidateOffering(context.Background(), common.Recommendation{ResourceType: "Hot_LRS"}), "not of the form")
+}
+
+func TestBlobStorageClient_GetValidResourceTypes(t *testing.T) {
+	client := NewClient(nil, "sub-1", "westeurope")
+	skus, err := client.GetValidResourceTypes(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, skus, 24)
+	assert.Contains(t, skus, "Cool_RA-GRS_1PB")
+}
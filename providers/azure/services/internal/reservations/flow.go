@@ -0,0 +1,149 @@
+package reservations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// PurchaseSpec carries the service-specific inputs of a reservation
+// purchase. Everything else in the request body (billing plan, term,
+// quantity, display name, Shared scope, tags) is derived from the
+// recommendation and options the same way for every service, so newer
+// service clients describe only what differs and call Purchase rather than
+// re-implementing the body construction.
+type PurchaseSpec struct {
+	// SubscriptionID becomes the billingScopeId of the reservation.
+	SubscriptionID string
+
+	// Region is the reservation's location.
+	Region string
+
+	// ReservedResourceType is the armreservations enum value for the
+	// service (e.g. ReservedResourceTypeManagedDisk).
+	ReservedResourceType armreservations.ReservedResourceType
+
+	// DisplayService is the short service identifier passed to
+	// BuildDisplayName (e.g. "disk").
+	DisplayService string
+
+	// ExtraProperties are merged into the body's properties map, for
+	// services whose reservation carries extra fields. They cannot
+	// override the fields Purchase sets itself.
+	ExtraProperties map[string]interface{}
+}
+
+// Purchase validates rec/opts, builds the reservation request body from
+// spec, stamps the attribution and idempotency tags, and runs it through
+// DoIdempotentPurchaseTwoStep. The returned PurchaseResult mirrors what the
+// per-service PurchaseCommitment implementations return: Error is set and
+// also returned on any failure.
+func Purchase(ctx context.Context, cred azcore.TokenCredential, httpClient HTTPClient, spec PurchaseSpec, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	result := common.PurchaseResult{
+		Recommendation: rec,
+		Timestamp:      time.Now(),
+	}
+
+	bodyBytes, err := buildPurchaseBody(spec, rec, opts)
+	if err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+
+	if cred == nil {
+		result.Error = fmt.Errorf("credential must not be nil")
+		return result, result.Error
+	}
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{BaseURL + "/.default"},
+	})
+	if err != nil {
+		result.Error = fmt.Errorf("failed to get access token: %w", err)
+		return result, result.Error
+	}
+
+	reservationOrderID, err := DoIdempotentPurchaseTwoStep(ctx, httpClient, CalculatePriceURL(), bodyBytes, token.Token, opts.IdempotencyToken)
+	if err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+
+	result.Success = true
+	result.CommitmentID = reservationOrderID
+	result.Cost = rec.CommitmentCost
+	return result, nil
+}
+
+// buildPurchaseBody validates the inputs and marshals the calculatePrice /
+// purchase request body. Split from Purchase so the body shape can be
+// asserted without a credential or HTTP round trip.
+func buildPurchaseBody(spec PurchaseSpec, rec common.Recommendation, opts common.PurchaseOptions) ([]byte, error) {
+	// Source is required so the resulting reservation is attributable to
+	// CUDly in the portal via the purchase-automation tag (see
+	// ApplyPurchaseTags); the dedupe key is opts.IdempotencyToken.
+	if opts.Source == "" {
+		return nil, fmt.Errorf("purchase source is required for Azure reservation purchases")
+	}
+	if spec.ReservedResourceType == "" {
+		return nil, fmt.Errorf("reserved resource type must not be empty")
+	}
+	if strings.TrimSpace(rec.ResourceType) == "" {
+		return nil, fmt.Errorf("resource type is required")
+	}
+	if rec.Count <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than zero, got %d", rec.Count)
+	}
+
+	termYears, err := ParseTermYears(rec.Term)
+	if err != nil {
+		return nil, err
+	}
+	billingPlan, err := BillingPlanForPaymentOption(rec.PaymentOption)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]interface{}, len(spec.ExtraProperties)+8)
+	for k, v := range spec.ExtraProperties {
+		properties[k] = v
+	}
+	properties["reservedResourceType"] = string(spec.ReservedResourceType)
+	properties["billingScopeId"] = fmt.Sprintf("/subscriptions/%s", spec.SubscriptionID)
+	properties["billingPlan"] = string(billingPlan)
+	properties["term"] = fmt.Sprintf("P%dY", termYears)
+	properties["quantity"] = rec.Count
+	properties["displayName"] = BuildDisplayName(DisplayNameFields{
+		Service:      spec.DisplayService,
+		Region:       spec.Region,
+		ResourceType: rec.ResourceType,
+		Count:        rec.Count,
+		Term:         rec.Term,
+		Payment:      rec.PaymentOption,
+		Now:          time.Now(),
+	})
+	properties["appliedScopeType"] = "Shared"
+	properties["renew"] = false
+
+	requestBody := map[string]interface{}{
+		"sku": map[string]string{
+			"name": rec.ResourceType,
+		},
+		"location":   spec.Region,
+		"properties": properties,
+	}
+	ApplyPurchaseTags(requestBody, opts.Source, opts.IdempotencyToken)
+
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return bodyBytes, nil
+}
@@ -0,0 +1,135 @@
+package reservations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// fakeCredential implements azcore.TokenCredential for tests.
+type fakeCredential struct{ err error }
+
+func (f *fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if f.err != nil {
+		return azcore.AccessToken{}, f.err
+	}
+	return azcore.AccessToken{Token: "test-token"}, nil
+}
+
+func diskSpec() PurchaseSpec {
+	return PurchaseSpec{
+		SubscriptionID:       "sub-1",
+		Region:               "eastus",
+		ReservedResourceType: armreservations.ReservedResourceTypeManagedDisk,
+		DisplayService:       "disk",
+		ExtraProperties:      map[string]interface{}{"reservedResourceType": "ignored", "extra": "x"},
+	}
+}
+
+func diskRec() common.Recommendation {
+	return common.Recommendation{ResourceType: "P30", Count: 2, Term: "3yr", PaymentOption: "monthly", CommitmentCost: 1234}
+}
+
+func TestBuildPurchaseBody(t *testing.T) {
+	bodyBytes, err := buildPurchaseBody(diskSpec(), diskRec(), common.PurchaseOptions{Source: "cudly-cli", IdempotencyToken: "tok"})
+	require.NoError(t, err)
+
+	var body struct {
+		SKU        map[string]string      `json:"sku"`
+		Location   string                 `json:"location"`
+		Properties map[string]interface{} `json:"properties"`
+		Tags       map[string]string      `json:"tags"`
+	}
+	require.NoError(t, json.Unmarshal(bodyBytes, &body))
+	assert.Equal(t, "P30", body.SKU["name"])
+	assert.Equal(t, "eastus", body.Location)
+	assert.Equal(t, "ManagedDisk", body.Properties["reservedResourceType"])
+	assert.Equal(t, "/subscriptions/sub-1", body.Properties["billingScopeId"])
+	assert.Equal(t, "Monthly", body.Properties["billingPlan"])
+	assert.Equal(t, "P3Y", body.Properties["term"])
+	assert.Equal(t, float64(2), body.Properties["quantity"])
+	assert.Equal(t, "Shared", body.Properties["appliedScopeType"])
+	assert.Equal(t, false, body.Properties["renew"])
+	assert.Equal(t, "x", body.Properties["extra"])
+	assert.Contains(t, body.Properties["displayName"], "disk")
+	assert.Equal(t, "cudly-cli", body.Tags[common.PurchaseTagKey])
+	assert.Equal(t, "tok", body.Tags[common.IdempotencyTagKey])
+}
+
+func TestBuildPurchaseBody_Validation(t *testing.T) {
+	opts := common.PurchaseOptions{Source: "cudly-cli"}
+	tests := []struct {
+		name    string
+		spec    func(*PurchaseSpec)
+		rec     func(*common.Recommendation)
+		opts    common.PurchaseOptions
+		wantErr string
+	}{
+		{name: "missing source", opts: common.PurchaseOptions{}, wantErr: "purchase source is required"},
+		{name: "missing reserved type", spec: func(s *PurchaseSpec) { s.ReservedResourceType = "" }, opts: opts, wantErr: "reserved resource type must not be empty"},
+		{name: "missing resource type", rec: func(r *common.Recommendation) { r.ResourceType = " " }, opts: opts, wantErr: "resource type is required"},
+		{name: "zero count", rec: func(r *common.Recommendation) { r.Count = 0 }, opts: opts, wantErr: "quantity must be greater than zero"},
+		{name: "bad term", rec: func(r *common.Recommendation) { r.Term = "5yr" }, opts: opts, wantErr: "unsupported reservation term"},
+		{name: "partial upfront", rec: func(r *common.Recommendation) { r.PaymentOption = "partial-upfront" }, opts: opts, wantErr: "partial-upfront"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, rec := diskSpec(), diskRec()
+			if tt.spec != nil {
+				tt.spec(&spec)
+			}
+			if tt.rec != nil {
+				tt.rec(&rec)
+			}
+			_, err := buildPurchaseBody(spec, rec, tt.opts)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPurchase_HappyPath(t *testing.T) {
+	m := &mockHTTPClient{}
+	m.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.String() == calcURL && r.Header.Get("Authorization") == "Bearer test-token"
+	})).Return(fakeResp(http.StatusOK, `{"properties":{"reservationOrderId":"order-disk"}}`), nil).Once()
+	m.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		if r.URL.Path != "/providers/Microsoft.Capacity/reservationOrders/order-disk/purchase" {
+			return false
+		}
+		b, _ := io.ReadAll(r.Body)
+		return json.Valid(b)
+	})).Return(fakeResp(http.StatusOK, `{}`), nil).Once()
+
+	result, err := Purchase(context.Background(), &fakeCredential{}, m, diskSpec(), diskRec(), common.PurchaseOptions{Source: "cudly-cli"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "order-disk", result.CommitmentID)
+	assert.Equal(t, 1234.0, result.Cost)
+	m.AssertExpectations(t)
+}
+
+func TestPurchase_TokenError(t *testing.T) {
+	m := &mockHTTPClient{}
+	result, err := Purchase(context.Background(), &fakeCredential{err: errors.New("no token")}, m, diskSpec(), diskRec(), common.PurchaseOptions{Source: "cudly-cli"})
+	require.ErrorContains(t, err, "failed to get access token")
+	assert.False(t, result.Success)
+	assert.Equal(t, err, result.Error)
+	m.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestPurchase_NilCredential(t *testing.T) {
+	_, err := Purchase(context.Background(), nil, &mockHTTPClient{}, diskSpec(), diskRec(), common.PurchaseOptions{Source: "cudly-cli"})
+	assert.ErrorContains(t, err, "credential must not be nil")
+}
@@ -1,6 +1,7 @@
 // Package reservations provides the shared two-step calculatePrice->purchase
 // flow for all Azure reservation-based service clients (compute, database,
-// cache, search, cosmosdb, managedredis, synapse).
+// cache, search, cosmosdb, managedredis, synapse, blobstorage, manageddisk,
+// appservice).
 //
 // Azure's Reservations API shifted away from direct-PUT for newer SKU families
 // (Burstable v2 and likely others). The previous pattern:
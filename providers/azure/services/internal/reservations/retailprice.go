@@ -0,0 +1,110 @@
+package reservations
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/pricing"
+)
+
+// retailPricesURL is the Azure Retail Prices API endpoint.
+const retailPricesURL = "https://prices.azure.com/api/retail/prices"
+
+// retailPricesAPIVersion matches the api-version every service client pins.
+const retailPricesAPIVersion = "2023-01-01-preview"
+
+// RetailPricing is the result of LookupRetailPricing.
+type RetailPricing struct {
+	// OnDemandUnitPrice is the pay-as-you-go price per unit of measure
+	// (hour, GB-month, ...) of the first matching Consumption meter. Zero
+	// when the filter matched no Consumption item; callers decide whether
+	// that is fatal.
+	OnDemandUnitPrice float64
+
+	// ReservationPrice is the total price of one reservation unit for the
+	// requested term, as published by the Retail Prices API.
+	ReservationPrice float64
+
+	// UnitOfMeasure is the reservation item's unit of measure, e.g.
+	// "1 Hour" or "100 TB/Month", so callers can tell what one unit buys.
+	UnitOfMeasure string
+
+	Currency string
+}
+
+// RetailTermString returns the Retail Prices API ReservationTerm string for
+// the given number of years: "1 Year" for one year, "N Years" otherwise.
+func RetailTermString(termYears int) string {
+	if termYears == 1 {
+		return "1 Year"
+	}
+	return fmt.Sprintf("%d Years", termYears)
+}
+
+// LookupRetailPricing queries the Retail Prices API with the given OData
+// filter and returns the reservation price for termYears plus the on-demand
+// unit price, when present. keep, when non-nil, drops items the filter
+// cannot express (e.g. a reservation unit size). A missing reservation
+// price is an error rather than an estimate (issue #1020 H4): fabricating
+// one from a discount multiplier would present a made-up TotalCost as real.
+func LookupRetailPricing(ctx context.Context, httpClient pricing.HTTPClient, filter string, termYears int, keep func(pricing.RetailPriceItem) bool) (*RetailPricing, error) {
+	params := url.Values{}
+	params.Add("$filter", filter)
+	params.Add("api-version", retailPricesAPIVersion)
+
+	items, err := pricing.FetchAll[pricing.RetailPriceItem](ctx, httpClient, retailPricesURL+"?"+params.Encode(), pricing.DefaultPageTimeout, pricing.DefaultMaxPages)
+	if err != nil {
+		return nil, err
+	}
+	if keep != nil {
+		kept := items[:0]
+		for _, item := range items {
+			if keep(item) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no pricing data found for filter %q", filter)
+	}
+
+	result := extractRetailPricing(items, termYears)
+	if result.ReservationPrice == 0 {
+		return nil, fmt.Errorf("no %s reservation price found for filter %q", RetailTermString(termYears), filter)
+	}
+	return result, nil
+}
+
+// extractRetailPricing picks the reservation item for termYears and the
+// first positive Consumption item out of a Retail Prices result set.
+func extractRetailPricing(items []pricing.RetailPriceItem, termYears int) *RetailPricing {
+	result := &RetailPricing{Currency: "USD"}
+	termStr := RetailTermString(termYears)
+
+	for _, item := range items {
+		if item.CurrencyCode != "" {
+			result.Currency = item.CurrencyCode
+		}
+		switch {
+		case strings.EqualFold(item.Type, "Reservation") && item.ReservationTerm == termStr:
+			if item.RetailPrice > 0 && result.ReservationPrice == 0 {
+				result.ReservationPrice = item.RetailPrice
+				result.UnitOfMeasure = item.UnitOfMeasure
+			}
+		case strings.EqualFold(item.Type, "Consumption"):
+			if item.UnitPrice > 0 && result.OnDemandUnitPrice == 0 {
+				result.OnDemandUnitPrice = item.UnitPrice
+			}
+		}
+	}
+	return result
+}
+
+// ODataQuote escapes a value for use inside a single-quoted OData string
+// literal by doubling embedded single quotes.
+func ODataQuote(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
@@ -0,0 +1,87 @@
+package reservations
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/pricing"
+)
+
+func TestRetailTermString(t *testing.T) {
+	assert.Equal(t, "1 Year", RetailTermString(1))
+	assert.Equal(t, "3 Years", RetailTermString(3))
+}
+
+func TestODataQuote(t *testing.T) {
+	assert.Equal(t, "O''Brien", ODataQuote("O'Brien"))
+	assert.Equal(t, "P30", ODataQuote("P30"))
+}
+
+func TestExtractRetailPricing(t *testing.T) {
+	items := []pricing.RetailPriceItem{
+		{Type: "Consumption", UnitPrice: 0.5, CurrencyCode: "EUR"},
+		{Type: "Consumption", UnitPrice: 0.7},
+		{Type: "Reservation", ReservationTerm: "1 Year", RetailPrice: 3000, UnitOfMeasure: "1 Hour"},
+		{Type: "Reservation", ReservationTerm: "3 Years", RetailPrice: 7000, UnitOfMeasure: "1 Hour"},
+	}
+
+	got := extractRetailPricing(items, 3)
+	assert.Equal(t, 0.5, got.OnDemandUnitPrice)
+	assert.Equal(t, 7000.0, got.ReservationPrice)
+	assert.Equal(t, "1 Hour", got.UnitOfMeasure)
+	assert.Equal(t, "EUR", got.Currency)
+
+	got = extractRetailPricing(items, 1)
+	assert.Equal(t, 3000.0, got.ReservationPrice)
+}
+
+func TestLookupRetailPricing(t *testing.T) {
+	m := &mockHTTPClient{}
+	m.On("Do", mock.MatchedBy(func(r *http.Request) bool {
+		return r.URL.Host == "prices.azure.com" &&
+			strings.Contains(r.URL.Query().Get("$filter"), "skuName eq 'P30'") &&
+			r.URL.Query().Get("api-version") == "2023-01-01-preview"
+	})).Return(fakeResp(http.StatusOK, `{"Items":[{"type":"Consumption","unitPrice":0.2},{"type":"Reservation","reservationTerm":"1 Year","retailPrice":1500,"currencyCode":"USD"}]}`), nil).Once()
+
+	got, err := LookupRetailPricing(context.Background(), m, "skuName eq 'P30'", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1500.0, got.ReservationPrice)
+	assert.Equal(t, 0.2, got.OnDemandUnitPrice)
+	m.AssertExpectations(t)
+}
+
+func TestLookupRetailPricing_NoReservationPrice(t *testing.T) {
+	m := &mockHTTPClient{}
+	m.On("Do", mock.Anything).Return(fakeResp(http.StatusOK, `{"Items":[{"type":"Consumption","unitPrice":0.2}]}`), nil).Once()
+
+	_, err := LookupRetailPricing(context.Background(), m, "skuName eq 'P30'", 3, nil)
+	assert.ErrorContains(t, err, "no 3 Years reservation price found")
+}
+
+func TestLookupRetailPricing_NoItems(t *testing.T) {
+	m := &mockHTTPClient{}
+	m.On("Do", mock.Anything).Return(fakeResp(http.StatusOK, `{"Items":[]}`), nil).Once()
+
+	_, err := LookupRetailPricing(context.Background(), m, "skuName eq 'P30'", 1, nil)
+	assert.ErrorContains(t, err, "no pricing data found")
+}
+
+func TestLookupRetailPricing_Keep(t *testing.T) {
+	m := &mockHTTPClient{}
+	m.On("Do", mock.Anything).Return(fakeResp(http.StatusOK, `{"Items":[`+
+		`{"type":"Reservation","reservationTerm":"1 Year","retailPrice":20000,"unitOfMeasure":"100 TB/Month"},`+
+		`{"type":"Reservation","reservationTerm":"1 Year","retailPrice":180000,"unitOfMeasure":"1 PB/Month"}]}`), nil).Once()
+
+	got, err := LookupRetailPricing(context.Background(), m, "skuName eq 'Hot LRS'", 1, func(item pricing.RetailPriceItem) bool {
+		return strings.Contains(item.UnitOfMeasure, "PB")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 180000.0, got.ReservationPrice)
+	assert.Equal(t, "1 PB/Month", got.UnitOfMeasure)
+}
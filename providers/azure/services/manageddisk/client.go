@@ -0,0 +1,333 @@
+// Package manageddisk provides the Azure Managed Disk reservation client.
+// Azure sells reservations for Premium SSD managed disks in P30 and larger
+// performance tiers; a reservation covers one disk of the reserved tier in
+// the reserved region for the term.
+package manageddisk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/azure/internal/httpclient"
+	azrecs "github.com/LeanerCloud/CUDly/providers/azure/internal/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/azure/services/internal/reservations"
+)
+
+// reservationResourceTypeManagedDisk is the resourceType value for managed
+// disks in the Consumption ReservationRecommendations API $filter.
+const reservationResourceTypeManagedDisk = "ManagedDisk"
+
+// skuPrefix is the reservation SKU prefix for Premium SSD disk tiers, e.g.
+// "Premium_SSD_Managed_Disks_P30".
+const skuPrefix = "Premium_SSD_Managed_Disks_"
+
+// maxRecsPages caps Consumption API recommendation pagination.
+const maxRecsPages = 10
+
+// maxReservationsPages caps reservation-detail pagination.
+const maxReservationsPages = 50
+
+// reservableTiers are the Premium SSD tiers Azure sells reservations for.
+// Smaller tiers (P1-P20) are not reservable.
+var reservableTiers = []string{"P30", "P40", "P50", "P60", "P70", "P80"}
+
+// HTTPClient interface for HTTP operations (enables mocking)
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RecommendationsPager interface for recommendations pager (enables mocking)
+type RecommendationsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationRecommendationsClientListResponse, error)
+}
+
+// ReservationsDetailsPager interface for reservations details pager (enables mocking)
+type ReservationsDetailsPager interface {
+	More() bool
+	NextPage(ctx context.Context) (armconsumption.ReservationsDetailsClientListResponse, error)
+}
+
+// ManagedDiskClient handles Azure Managed Disk reservations
+type ManagedDiskClient struct {
+	cred                 azcore.TokenCredential
+	subscriptionID       string
+	region               string
+	httpClient           HTTPClient
+	recommendationsPager RecommendationsPager
+	reservationsPager    ReservationsDetailsPager
+}
+
+// NewClient creates a new Azure Managed Disk reservation client
+func NewClient(cred azcore.TokenCredential, subscriptionID, region string) *ManagedDiskClient {
+	return &ManagedDiskClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpclient.New(),
+	}
+}
+
+// NewClientWithHTTP creates a new Azure Managed Disk reservation client with a custom HTTP client (for testing)
+func NewClientWithHTTP(cred azcore.TokenCredential, subscriptionID, region string, httpClient HTTPClient) *ManagedDiskClient {
+	return &ManagedDiskClient{
+		cred:           cred,
+		subscriptionID: subscriptionID,
+		region:         region,
+		httpClient:     httpClient,
+	}
+}
+
+// SetRecommendationsPager sets the recommendations pager (for testing)
+func (c *ManagedDiskClient) SetRecommendationsPager(pager RecommendationsPager) {
+	c.recommendationsPager = pager
+}
+
+// SetReservationsPager sets the reservations pager (for testing)
+func (c *ManagedDiskClient) SetReservationsPager(pager ReservationsDetailsPager) {
+	c.reservationsPager = pager
+}
+
+// GetServiceType returns the service type
+func (c *ManagedDiskClient) GetServiceType() common.ServiceType {
+	return common.ServiceBlockStorage
+}
+
+// GetRegion returns the region
+func (c *ManagedDiskClient) GetRegion() string {
+	return c.region
+}
+
+// GetRecommendations gets Managed Disk reservation recommendations from the
+// Azure Consumption API
+func (c *ManagedDiskClient) GetRecommendations(ctx context.Context, _ *common.RecommendationParams) ([]common.Recommendation, error) {
+	recommendations := make([]common.Recommendation, 0)
+
+	var pager RecommendationsPager
+	if c.recommendationsPager != nil {
+		pager = c.recommendationsPager
+	} else {
+		client, err := armconsumption.NewReservationRecommendationsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumption client: %w", err)
+		}
+		scope := fmt.Sprintf("/subscriptions/%s", c.subscriptionID)
+		filter := "properties/scope eq 'Shared' and properties/resourceType eq '" + reservationResourceTypeManagedDisk + "'"
+		pager = client.NewListPager(scope, &armconsumption.ReservationRecommendationsClientListOptions{Filter: &filter})
+	}
+
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxRecsPages {
+			return nil, fmt.Errorf("manageddisk: GetRecommendations pagination cap (%d pages) reached", maxRecsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Managed Disk recommendations: %w", err)
+		}
+
+		for _, rec := range page.Value {
+			if converted := c.convertRecommendation(rec); converted != nil {
+				recommendations = append(recommendations, azrecs.ExpandPaymentVariants(*converted)...)
+			}
+		}
+	}
+
+	return recommendations, nil
+}
+
+// convertRecommendation converts an Azure Managed Disk reservation recommendation to common format
+func (c *ManagedDiskClient) convertRecommendation(azureRec armconsumption.ReservationRecommendationClassification) *common.Recommendation {
+	extracted := azrecs.Extract(azureRec)
+	if extracted == nil {
+		return nil
+	}
+
+	rec := &common.Recommendation{
+		Provider:             common.ProviderAzure,
+		Service:              common.ServiceBlockStorage,
+		Account:              c.subscriptionID,
+		CommitmentType:       common.CommitmentReservedInstance,
+		Timestamp:            time.Now(),
+		Region:               extracted.Region,
+		ResourceType:         extracted.ResourceType,
+		Count:                extracted.Count,
+		OnDemandCost:         extracted.OnDemandCost,
+		CommitmentCost:       extracted.CommitmentCost,
+		EstimatedSavings:     extracted.EstimatedSavings,
+		Term:                 extracted.Term,
+		RecurringMonthlyCost: extracted.RecurringMonthlyCost,
+		PaymentOption:        "upfront", // Default, will be expanded by ExpandPaymentVariants
+	}
+	if rec.Region == "" {
+		rec.Region = c.region
+	}
+	return rec
+}
+
+// GetExistingCommitments retrieves existing Managed Disk reservations
+func (c *ManagedDiskClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	pager := c.reservationsPager
+	if pager == nil {
+		client, err := armconsumption.NewReservationsDetailsClient(c.cred, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reservations details client: %w", err)
+		}
+		scope := fmt.Sprintf("subscriptions/%s", c.subscriptionID)
+		pager = client.NewListPager(scope, &armconsumption.ReservationsDetailsClientListOptions{})
+	}
+
+	commitments := make([]common.Commitment, 0)
+	for pageIdx := 0; pager.More(); pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxReservationsPages {
+			return nil, fmt.Errorf("manageddisk: GetExistingCommitments pagination cap (%d pages) reached", maxReservationsPages)
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("manageddisk: list reservations: %w", err)
+		}
+		for _, detail := range page.Value {
+			if commitment := c.convertReservation(detail); commitment != nil {
+				commitments = append(commitments, *commitment)
+			}
+		}
+	}
+
+	return commitments, nil
+}
+
+// convertReservation converts a reservation detail to a commitment if it is
+// a Managed Disk reservation
+func (c *ManagedDiskClient) convertReservation(detail *armconsumption.ReservationDetail) *common.Commitment {
+	if detail == nil || detail.Properties == nil || detail.Properties.SKUName == nil {
+		return nil
+	}
+	props := detail.Properties
+	if !isManagedDiskSKU(*props.SKUName) {
+		return nil
+	}
+
+	commitment := &common.Commitment{
+		Provider:       common.ProviderAzure,
+		Account:        c.subscriptionID,
+		CommitmentType: common.CommitmentReservedInstance,
+		Service:        common.ServiceBlockStorage,
+		Region:         c.region,
+		ResourceType:   *props.SKUName,
+		State:          "active",
+	}
+	if props.ReservationID != nil {
+		commitment.CommitmentID = *props.ReservationID
+	}
+	return commitment
+}
+
+// isManagedDiskSKU reports whether a reservation SKU name is a managed disk SKU
+func isManagedDiskSKU(sku string) bool {
+	lower := strings.ToLower(sku)
+	return strings.Contains(lower, "managed_disk") || strings.Contains(lower, "managed disk")
+}
+
+// PurchaseCommitment purchases a Managed Disk reservation using the shared
+// two-step calculatePrice->purchase flow
+func (c *ManagedDiskClient) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	return reservations.Purchase(ctx, c.cred, c.httpClient, reservations.PurchaseSpec{
+		SubscriptionID:       c.subscriptionID,
+		Region:               c.region,
+		ReservedResourceType: armreservations.ReservedResourceTypeManagedDisk,
+		DisplayService:       "disk",
+	}, rec, opts)
+}
+
+// ValidateOffering validates that the recommendation names a reservable disk tier
+func (c *ManagedDiskClient) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	validSKUs, err := c.GetValidResourceTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get valid SKUs: %w", err)
+	}
+
+	resourceType := strings.TrimSpace(rec.ResourceType)
+	for _, sku := range validSKUs {
+		if strings.EqualFold(sku, resourceType) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid Azure Managed Disk reservation SKU: %s", rec.ResourceType)
+}
+
+// GetOfferingDetails retrieves Managed Disk reservation offering details from
+// the Azure Retail Prices API
+func (c *ManagedDiskClient) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	termYears, err := reservations.ParseTermYears(rec.Term)
+	if err != nil {
+		return nil, fmt.Errorf("invalid term: %w", err)
+	}
+
+	tier := diskTier(rec.ResourceType)
+	filter := fmt.Sprintf("serviceName eq 'Storage' and productName eq 'Premium SSD Managed Disks' and armRegionName eq '%s' and skuName eq '%s LRS'",
+		reservations.ODataQuote(c.region), reservations.ODataQuote(tier))
+	p, err := reservations.LookupRetailPricing(ctx, c.httpClient, filter, termYears, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+
+	var upfrontCost, recurringCost float64
+	totalCost := p.ReservationPrice
+
+	switch rec.PaymentOption {
+	case "all-upfront", "upfront":
+		upfrontCost = totalCost
+	case "monthly", "no-upfront":
+		recurringCost = totalCost / (float64(termYears) * 12)
+	default:
+		// Fail loud on an unrecognised payment option rather than silently
+		// billing it as all-upfront (owner policy: no silent fallbacks on
+		// money-affecting fields).
+		return nil, fmt.Errorf("unsupported payment option for Azure Managed Disk offering details: %q", rec.PaymentOption)
+	}
+
+	return &common.OfferingDetails{
+		OfferingID:          fmt.Sprintf("azure-disk-%s-%s-%s", tier, c.region, rec.Term),
+		ResourceType:        rec.ResourceType,
+		Term:                rec.Term,
+		PaymentOption:       rec.PaymentOption,
+		UpfrontCost:         upfrontCost,
+		RecurringCost:       recurringCost,
+		TotalCost:           totalCost,
+		EffectiveHourlyRate: totalCost / (8760.0 * float64(termYears)),
+		Currency:            p.Currency,
+	}, nil
+}
+
+// diskTier returns the performance tier ("P30") of a reservation SKU name,
+// accepting both the full "Premium_SSD_Managed_Disks_P30" form and a bare tier.
+func diskTier(sku string) string {
+	sku = strings.TrimSpace(sku)
+	if i := strings.LastIndex(sku, "_"); i >= 0 {
+		return strings.ToUpper(sku[i+1:])
+	}
+	return strings.ToUpper(sku)
+}
+
+// GetValidResourceTypes returns the reservable Managed Disk SKUs
+func (c *ManagedDiskClient) GetValidResourceTypes(_ context.Context) ([]string, error) {
+	skus := make([]string, 0, len(reservableTiers))
+	for _, tier := range reservableTiers {
+		skus = append(skus, skuPrefix+tier)
+	}
+	return skus, nil
+}
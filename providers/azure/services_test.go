@@ -49,6 +49,30 @@ func TestNewSearchClient(t *testing.T) {
 	assert.Equal(t, "eastus", client.GetRegion())
 }
 
+func TestNewBlobStorageClient(t *testing.T) {
+	client := NewBlobStorageClient(nil, "test-subscription", "eastus")
+
+	require.NotNil(t, client)
+	assert.Equal(t, common.ServiceStorage, client.GetServiceType())
+	assert.Equal(t, "eastus", client.GetRegion())
+}
+
+func TestNewManagedDiskClient(t *testing.T) {
+	client := NewManagedDiskClient(nil, "test-subscription", "eastus")
+
+	require.NotNil(t, client)
+	assert.Equal(t, common.ServiceBlockStorage, client.GetServiceType())
+	assert.Equal(t, "eastus", client.GetRegion())
+}
+
+func TestNewAppServiceClient(t *testing.T) {
+	client := NewAppServiceClient(nil, "test-subscription", "eastus")
+
+	require.NotNil(t, client)
+	assert.Equal(t, common.ServiceAppService, client.GetServiceType())
+	assert.Equal(t, "eastus", client.GetRegion())
+}
+
 func TestNewRecommendationsClient(t *testing.T) {
 	client, err := NewRecommendationsClient(nil, "test-subscription")
 	require.NoError(t, err)
@@ -0,0 +1,130 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+// regionSource is the slice of gcp.GCPProvider the commitment lister needs.
+type regionSource interface {
+	GetRegions(ctx context.Context) ([]common.Region, error)
+}
+
+// resourceCommitmentSource is the slice of computeengine.ComputeEngineClient
+// the commitment lister needs.
+type resourceCommitmentSource interface {
+	GetResourceCommitments(ctx context.Context) ([]computeengine.ResourceCommitmentInfo, error)
+}
+
+// regionalCommitmentLister implements commitmentLister by walking the
+// project's regions with one compute client per region: the commitments API
+// is regional, and a CUD missed in any region would be counted as $0 of
+// existing commitment. A failure in any region is therefore a hard error.
+type regionalCommitmentLister struct {
+	regions   regionSource
+	newClient func(ctx context.Context, region string) (resourceCommitmentSource, error)
+}
+
+func (l *regionalCommitmentLister) ListResourceCommitments(ctx context.Context) ([]RegionalCommitment, error) {
+	regions, err := l.regions.GetRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListResourceCommitments: failed to list regions: %w", err)
+	}
+	var out []RegionalCommitment
+	for _, region := range regions {
+		client, err := l.newClient(ctx, region.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ListResourceCommitments: compute client for %s: %w", region.ID, err)
+		}
+		infos, err := client.GetResourceCommitments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ListResourceCommitments: %s: %w", region.ID, err)
+		}
+		for _, info := range infos {
+			out = append(out, RegionalCommitment{Region: region.ID, ResourceCommitmentInfo: info})
+		}
+	}
+	return out, nil
+}
+
+// unitRateSource is the slice of computeengine.ComputeEngineClient the pricer
+// needs.
+type unitRateSource interface {
+	GetCommitmentUnitRates(ctx context.Context, commitmentType, plan string) (computeengine.CommitmentUnitRates, error)
+}
+
+// commitmentPricerAdapter implements commitmentPricer from the Cloud Billing
+// Catalog commitment SKUs via computeengine.ComputeEngineClient. The compute
+// client prices in the region it was created for, so one client is built per
+// region. Unit rates are cached per (region, type, plan) for the adapter's
+// lifetime, which is one ladder run.
+type commitmentPricerAdapter struct {
+	newClient func(ctx context.Context, region string) (unitRateSource, error)
+	cache     map[string]computeengine.CommitmentUnitRates
+	mu        sync.Mutex
+}
+
+// HourlyCostUSD returns the hourly USD cost of c's committed vCPUs and
+// memory. A non-USD price or a non-positive cost is an error.
+func (a *commitmentPricerAdapter) HourlyCostUSD(ctx context.Context, c RegionalCommitment) (float64, error) {
+	rates, err := a.unitRates(ctx, c.Region, c.Type, c.Plan)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.EqualFold(rates.Currency, "USD") {
+		return 0, fmt.Errorf("commitment SKUs for %s in %s are priced in %q; only USD is supported", c.Type, c.Region, rates.Currency)
+	}
+	cost := rates.HourlyCost(c.ResourceCommitmentInfo)
+	if math.IsNaN(cost) || math.IsInf(cost, 0) || cost <= 0 {
+		return 0, fmt.Errorf("commitment %s (%d vCPU, %d MB) yields an invalid hourly cost %g", c.Name, c.VCPUs, c.MemoryMB, cost)
+	}
+	return cost, nil
+}
+
+func (a *commitmentPricerAdapter) unitRates(ctx context.Context, region, commitmentType, plan string) (computeengine.CommitmentUnitRates, error) {
+	key := strings.ToLower(region + "|" + commitmentType + "|" + plan)
+	a.mu.Lock()
+	if rates, ok := a.cache[key]; ok {
+		a.mu.Unlock()
+		return rates, nil
+	}
+	a.mu.Unlock()
+
+	client, err := a.newClient(ctx, region)
+	if err != nil {
+		return computeengine.CommitmentUnitRates{}, fmt.Errorf("compute client for %s: %w", region, err)
+	}
+	rates, err := client.GetCommitmentUnitRates(ctx, commitmentType, plan)
+	if err != nil {
+		return computeengine.CommitmentUnitRates{}, err
+	}
+
+	a.mu.Lock()
+	a.cache[key] = rates
+	a.mu.Unlock()
+	return rates, nil
+}
+
+// regionalCUDPurchaser routes each CUD purchase to a compute client for the
+// recommendation's region: the compute client inserts the commitment in its
+// own region, so a single client would buy every CUD in one region.
+type regionalCUDPurchaser struct {
+	newClient func(ctx context.Context, region string) (cudPurchaser, error)
+}
+
+func (p *regionalCUDPurchaser) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	if rec.Region == "" {
+		return common.PurchaseResult{}, fmt.Errorf("resource-based CUD purchase requires a region")
+	}
+	client, err := p.newClient(ctx, rec.Region)
+	if err != nil {
+		return common.PurchaseResult{}, fmt.Errorf("compute client for %s: %w", rec.Region, err)
+	}
+	return client.PurchaseCommitment(ctx, rec, opts)
+}
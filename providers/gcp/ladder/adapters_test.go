@@ -0,0 +1,147 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+type fakeRegions struct {
+	regions []common.Region
+	err     error
+}
+
+func (f *fakeRegions) GetRegions(context.Context) ([]common.Region, error) { return f.regions, f.err }
+
+type fakeCommitmentSource struct {
+	infos []computeengine.ResourceCommitmentInfo
+	err   error
+}
+
+func (f *fakeCommitmentSource) GetResourceCommitments(context.Context) ([]computeengine.ResourceCommitmentInfo, error) {
+	return f.infos, f.err
+}
+
+type fakeUnitRates struct {
+	rates computeengine.CommitmentUnitRates
+	err   error
+	calls int
+}
+
+func (f *fakeUnitRates) GetCommitmentUnitRates(context.Context, string, string) (computeengine.CommitmentUnitRates, error) {
+	f.calls++
+	return f.rates, f.err
+}
+
+func TestRegionalCommitmentLister_TagsRegions(t *testing.T) {
+	sources := map[string]*fakeCommitmentSource{
+		"us-central1":  {infos: []computeengine.ResourceCommitmentInfo{{Name: "a"}}},
+		"europe-west1": {infos: []computeengine.ResourceCommitmentInfo{{Name: "b"}, {Name: "c"}}},
+	}
+	l := &regionalCommitmentLister{
+		regions: &fakeRegions{regions: []common.Region{{ID: "us-central1"}, {ID: "europe-west1"}}},
+		newClient: func(_ context.Context, region string) (resourceCommitmentSource, error) {
+			return sources[region], nil
+		},
+	}
+
+	got, err := l.ListResourceCommitments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, "us-central1", got[0].Region)
+	assert.Equal(t, "a", got[0].Name)
+	assert.Equal(t, "europe-west1", got[2].Region)
+	assert.Equal(t, "c", got[2].Name)
+}
+
+func TestRegionalCommitmentLister_AnyRegionFailureFailsLoud(t *testing.T) {
+	boom := errors.New("403")
+	l := &regionalCommitmentLister{
+		regions: &fakeRegions{regions: []common.Region{{ID: "us-central1"}, {ID: "europe-west1"}}},
+		newClient: func(_ context.Context, region string) (resourceCommitmentSource, error) {
+			if region == "europe-west1" {
+				return &fakeCommitmentSource{err: boom}, nil
+			}
+			return &fakeCommitmentSource{}, nil
+		},
+	}
+
+	_, err := l.ListResourceCommitments(context.Background())
+	require.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "europe-west1")
+}
+
+func TestCommitmentPricerAdapter_CachesPerRegionTypeAndPlan(t *testing.T) {
+	src := &fakeUnitRates{rates: computeengine.CommitmentUnitRates{VCPUPerHour: 0.02, MemoryGBPerHour: 0.003, Currency: "USD"}}
+	a := &commitmentPricerAdapter{
+		newClient: func(context.Context, string) (unitRateSource, error) { return src, nil },
+		cache:     make(map[string]computeengine.CommitmentUnitRates),
+	}
+	c := RegionalCommitment{
+		Region: "us-central1",
+		ResourceCommitmentInfo: computeengine.ResourceCommitmentInfo{
+			Name: "a", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", VCPUs: 8, MemoryMB: 32768,
+		},
+	}
+
+	cost, err := a.HourlyCostUSD(context.Background(), c)
+	require.NoError(t, err)
+	assert.InDelta(t, 8*0.02+32*0.003, cost, 1e-9)
+
+	_, err = a.HourlyCostUSD(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, 1, src.calls, "second lookup must be served from the cache")
+
+	c.Plan = "THIRTY_SIX_MONTH"
+	_, err = a.HourlyCostUSD(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, 2, src.calls)
+}
+
+func TestCommitmentPricerAdapter_RejectsNonUSDAndZeroCost(t *testing.T) {
+	src := &fakeUnitRates{rates: computeengine.CommitmentUnitRates{VCPUPerHour: 0.02, MemoryGBPerHour: 0.003, Currency: "EUR"}}
+	a := &commitmentPricerAdapter{
+		newClient: func(context.Context, string) (unitRateSource, error) { return src, nil },
+		cache:     make(map[string]computeengine.CommitmentUnitRates),
+	}
+	c := RegionalCommitment{
+		Region:                 "europe-west1",
+		ResourceCommitmentInfo: computeengine.ResourceCommitmentInfo{Name: "a", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", VCPUs: 8},
+	}
+
+	_, err := a.HourlyCostUSD(context.Background(), c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only USD")
+
+	src.rates.Currency = "USD"
+	a.cache = make(map[string]computeengine.CommitmentUnitRates)
+	c.VCPUs = 0
+	_, err = a.HourlyCostUSD(context.Background(), c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid hourly cost")
+}
+
+func TestRegionalCUDPurchaser_RoutesByRegion(t *testing.T) {
+	var gotRegion string
+	p := &regionalCUDPurchaser{
+		newClient: func(_ context.Context, region string) (cudPurchaser, error) {
+			gotRegion = region
+			return &fakePurchaser{}, nil
+		},
+	}
+
+	_, err := p.PurchaseCommitment(context.Background(), validCUDRec(), purchaseOpts)
+	require.NoError(t, err)
+	assert.Equal(t, "us-central1", gotRegion)
+
+	rec := validCUDRec()
+	rec.Region = ""
+	_, err = p.PurchaseCommitment(context.Background(), rec, purchaseOpts)
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// GetUsageBaseline derives the project's committable usage floor from the
+// Compute Engine CUD recommendations the Recommender already publishes:
+//
+//	floor = existing resource-based CUD USD/hour
+//	      + sum of ACTIVE CUD recommendations' commitment USD/hour
+//
+// The Recommender sizes each recommendation to the stable usage it observed
+// above the commitments already in place, so adding the existing layer back
+// yields the total stable floor in the same commitment-cost unit the layer
+// states use. At 100% target coverage the engine's gap is therefore exactly
+// the recommended amount.
+//
+// Because the Recommender's output already is a stable-usage estimate, the
+// floor is returned as both LowWaterUSDPerHour and StableUSDPerHour. This
+// differs from AWSLadder and AzureLadder, which only have a raw on-demand
+// series and leave Stable nil; here the base layer is meant to absorb the
+// recommendation.
+//
+// Limitations:
+//   - The Recommender analyzes its own fixed window and does not take a
+//     percentile, so lookbackDays and percentile are validated and echoed
+//     into the result but do not change the computation.
+//   - Recommendations whose billing-catalog pricing failed (CommitmentCost
+//     0) are skipped with a WARNING. Skipping understates the floor, which
+//     under-buys rather than over-buys.
+func (g *GCPLadder) GetUsageBaseline(ctx context.Context, scope ladder.Scope, lookbackDays int, percentile float64) (ladder.UsageBaseline, error) {
+	if err := g.validateScope(scope); err != nil {
+		return ladder.UsageBaseline{}, err
+	}
+	if lookbackDays <= 0 {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: lookbackDays must be > 0, got %d", lookbackDays)
+	}
+	if math.IsNaN(percentile) || percentile <= 0 || percentile > 100 {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: percentile must be in (0, 100], got %g", percentile)
+	}
+
+	priced, err := g.listPricedCommitments(ctx)
+	if err != nil {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: %w", err)
+	}
+	floor := 0.0
+	for i := range priced {
+		floor += priced[i].hourlyUSD
+	}
+
+	recommended, err := g.recommendedUSDPerHour(ctx)
+	if err != nil {
+		return ladder.UsageBaseline{}, fmt.Errorf("GetUsageBaseline: %w", err)
+	}
+	floor += recommended
+
+	return ladder.UsageBaseline{
+		LowWaterUSDPerHour: ptr(floor),
+		StableUSDPerHour:   ptr(floor),
+		LookbackDays:       lookbackDays,
+		Percentile:         percentile,
+	}, nil
+}
+
+// recommendedUSDPerHour sums the commitment USD/hour of the project's Compute
+// Engine CUD recommendations at Config.Term. CommitmentCost on a converted
+// recommendation is the term-total commitment cost.
+func (g *GCPLadder) recommendedUSDPerHour(ctx context.Context) (float64, error) {
+	recs, err := g.recs.GetRecommendations(ctx, &common.RecommendationParams{
+		Service: common.ServiceCompute,
+		Term:    g.cfg.term(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("recommendation fetch failed: %w", err)
+	}
+
+	total, unpriced := 0.0, 0
+	for i := range recs {
+		rec := &recs[i]
+		if rec.Service != common.ServiceCompute || rec.CommitmentType != common.CommitmentCUD {
+			continue
+		}
+		years, err := termYears(rec.Term)
+		if err != nil {
+			return 0, fmt.Errorf("recommendation for %s in %s: %w", rec.ResourceType, rec.Region, err)
+		}
+		if math.IsNaN(rec.CommitmentCost) || math.IsInf(rec.CommitmentCost, 0) || rec.CommitmentCost <= 0 {
+			unpriced++
+			continue
+		}
+		total += rec.CommitmentCost / (hoursPerYear * float64(years))
+	}
+	if unpriced > 0 {
+		log.Printf("WARNING: GCPLadder GetUsageBaseline: %d of %d CUD recommendations had no commitment price and were left out of the baseline (project=%s)",
+			unpriced, len(recs), g.cfg.ProjectID)
+	}
+	return total, nil
+}
@@ -0,0 +1,97 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// commitmentStatusActive is the lower-cased GCP status of a CUD that is
+// currently discounting usage. CREATING, NOT_YET_ACTIVE and EXPIRED
+// commitments are not existing coverage.
+const commitmentStatusActive = "active"
+
+// pricedCommitment pairs an active resource-based CUD with its hourly USD
+// cost.
+type pricedCommitment struct {
+	c         RegionalCommitment
+	hourlyUSD float64
+}
+
+// ListCommitments returns the project's active resource-based CUDs, priced
+// via commitmentPricer. Spend-based flexible CUDs are not included: GCP
+// exposes no API to list them (see GetLayerStates).
+//
+// The scope's AccountID must match Config.ProjectID.
+func (g *GCPLadder) ListCommitments(ctx context.Context, scope ladder.Scope) ([]common.Commitment, error) {
+	if err := g.validateScope(scope); err != nil {
+		return nil, err
+	}
+	priced, err := g.listPricedCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListCommitments: %w", err)
+	}
+	result := make([]common.Commitment, 0, len(priced))
+	for i := range priced {
+		result = append(result, toCommitment(&priced[i], g.cfg.ProjectID))
+	}
+	return result, nil
+}
+
+// validateScope returns an error when scope targets a provider or project
+// that does not match this GCPLadder instance.
+func (g *GCPLadder) validateScope(scope ladder.Scope) error {
+	if scope.Provider != common.ProviderGCP {
+		return fmt.Errorf("GCPLadder: expected provider %s, got %s", common.ProviderGCP, scope.Provider)
+	}
+	if scope.AccountID != g.cfg.ProjectID {
+		return fmt.Errorf("GCPLadder: scope project %s does not match configured project %s", scope.AccountID, g.cfg.ProjectID)
+	}
+	return nil
+}
+
+// listPricedCommitments lists the active resource-based CUDs and prices each
+// one. A pricing failure is a hard error: an unpriced commitment would count
+// as $0 of existing commitment and the engine would buy coverage that
+// already exists.
+func (g *GCPLadder) listPricedCommitments(ctx context.Context) ([]pricedCommitment, error) {
+	all, err := g.commitments.ListResourceCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("commitment listing failed: %w", err)
+	}
+	out := make([]pricedCommitment, 0, len(all))
+	for i := range all {
+		c := all[i]
+		if c.Status != commitmentStatusActive {
+			continue
+		}
+		cost, err := g.pricer.HourlyCostUSD(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("pricing commitment %s (%s %s in %s): %w", c.Name, c.Type, c.Plan, c.Region, err)
+		}
+		out = append(out, pricedCommitment{c: c, hourlyUSD: cost})
+	}
+	return out, nil
+}
+
+// toCommitment converts a priced CUD to a common.Commitment. ResourceType is
+// the commitment type (e.g. GENERAL_PURPOSE_N2), Count the committed vCPUs
+// and Cost the commitment-total USD/hour.
+func toCommitment(p *pricedCommitment, projectID string) common.Commitment {
+	return common.Commitment{
+		Provider:       common.ProviderGCP,
+		Account:        projectID,
+		CommitmentID:   p.c.Name,
+		CommitmentType: common.CommitmentCUD,
+		Service:        common.ServiceCompute,
+		Region:         p.c.Region,
+		ResourceType:   p.c.Type,
+		Count:          int(p.c.VCPUs),
+		StartDate:      p.c.Start,
+		EndDate:        p.c.End,
+		State:          p.c.Status,
+		Cost:           p.hourlyUSD,
+	}
+}
@@ -0,0 +1,112 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	pkgladder "github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/gcp"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+// NewFromClientOptions constructs a fully wired read-side GCPLadder for one
+// project. opts are the Google API client options the rest of the provider
+// uses (e.g. option.WithTokenSource); none means Application Default
+// Credentials. Client wiring:
+//
+//   - commitmentLister     : regionalCommitmentLister over
+//     gcp.GCPProvider.GetRegions and
+//     computeengine.ComputeEngineClient.GetResourceCommitments
+//   - commitmentPricer     : commitmentPricerAdapter (Cloud Billing Catalog
+//     commitment SKUs, one compute client per region)
+//   - recommendationSource : gcp.RecommendationsClientAdapter (Recommender,
+//     fanned out over every region of the project)
+//
+// The write side stays unwired; callers wire it with WireWriteSide or
+// WireWriteSideDisabled.
+func NewFromClientOptions(ctx context.Context, projectID string, opts ...option.ClientOption) (pkgladder.LadderCapability, error) {
+	if projectID == "" {
+		return nil, fmt.Errorf("gcpladder.NewFromClientOptions: projectID must not be empty")
+	}
+
+	provider := gcp.NewProviderWithProject(ctx, projectID, opts...)
+	recoClient, err := provider.GetRecommendationsClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcpladder.NewFromClientOptions: %w", err)
+	}
+	recs, ok := recoClient.(recommendationSource)
+	if !ok {
+		return nil, fmt.Errorf("gcpladder.NewFromClientOptions: recommendations client %T does not implement GetRecommendations", recoClient)
+	}
+
+	l, err := New(
+		Config{ProjectID: projectID},
+		&regionalCommitmentLister{
+			regions: provider,
+			newClient: func(ctx context.Context, region string) (resourceCommitmentSource, error) {
+				return computeengine.NewClient(ctx, projectID, region, opts...)
+			},
+		},
+		&commitmentPricerAdapter{
+			newClient: func(ctx context.Context, region string) (unitRateSource, error) {
+				return computeengine.NewClient(ctx, projectID, region, opts...)
+			},
+			cache: make(map[string]computeengine.CommitmentUnitRates),
+		},
+		recs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gcpladder.NewFromClientOptions: %w", err)
+	}
+	return l, nil
+}
+
+// NewFromTokenSource is NewFromClientOptions for a token source resolved from
+// the credential store. A nil ts selects Application Default Credentials,
+// matching credentials.ResolveGCPTokenSource's application_default mode.
+func NewFromTokenSource(ctx context.Context, ts oauth2.TokenSource, projectID string) (pkgladder.LadderCapability, error) {
+	return NewFromClientOptions(ctx, projectID, tokenSourceOptions(ts)...)
+}
+
+// tokenSourceOptions maps a possibly-nil token source onto client options.
+func tokenSourceOptions(ts oauth2.TokenSource) []option.ClientOption {
+	if ts == nil {
+		return nil
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}
+}
+
+// disabledPurchaser is the cudPurchaser implementation used when
+// ladder_execution_enabled=false. Every call returns
+// ErrLadderExecutionDisabled without touching any GCP API.
+type disabledPurchaser struct{}
+
+func (disabledPurchaser) PurchaseCommitment(_ context.Context, _ common.Recommendation, _ common.PurchaseOptions) (common.PurchaseResult, error) {
+	return common.PurchaseResult{}, fmt.Errorf("%w: purchase blocked by kill-switch", ErrLadderExecutionDisabled)
+}
+
+// WireWriteSideDisabled wires the ladder's write side with a disabled
+// purchaser that returns ErrLadderExecutionDisabled on every call.
+// Use this when ladder_execution_enabled=false in global_config.
+func WireWriteSideDisabled(l *GCPLadder) (*GCPLadder, error) {
+	return l.WithWriteSide(disabledPurchaser{})
+}
+
+// WireWriteSide wires the ladder's write side with real compute clients for
+// the ladder's project. Use this when ladder_execution_enabled=true in
+// global_config. Each purchase is routed to a compute client for the
+// recommendation's region. ts must be the token source the read side was
+// built with; nil selects Application Default Credentials.
+func WireWriteSide(l *GCPLadder, ts oauth2.TokenSource) (*GCPLadder, error) {
+	project := l.cfg.ProjectID
+	opts := tokenSourceOptions(ts)
+	return l.WithWriteSide(&regionalCUDPurchaser{
+		newClient: func(ctx context.Context, region string) (cudPurchaser, error) {
+			return computeengine.NewClient(ctx, project, region, opts...)
+		},
+	})
+}
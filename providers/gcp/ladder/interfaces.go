@@ -0,0 +1,57 @@
+// Package ladder implements ladder.LadderCapability for GCP: the read side
+// (commitment listing, layer states, usage baseline) and the write side
+// (layer purchases). Write-side methods require the purchaser to be wired via
+// GCPLadder.WithWriteSide; until then they return an explicit not-wired
+// error.
+//
+// GCP has two ladder layers. Regional resource-based Compute Engine CUDs
+// (vCPU + memory) serve the base role and are bought through the Compute
+// API. Spend-based flexible CUDs serve the flex role but are advisory-only:
+// GCP exposes no API to buy or list them, so PurchaseLayer returns
+// common.ErrCommitmentPurchaseNotSupported for that layer. There is no
+// buffer layer: GCP CUDs cannot be exchanged or modified after purchase.
+package ladder
+
+import (
+	"context"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+// RegionalCommitment is one resource-based CUD together with the region it
+// was listed in (ResourceCommitmentInfo itself carries no region).
+type RegionalCommitment struct {
+	Region string
+	computeengine.ResourceCommitmentInfo
+}
+
+// commitmentLister is the narrow interface for listing the project's
+// resource-based CUDs across every region. The concrete implementation is
+// regionalCommitmentLister, which walks the project's regions with one
+// computeengine.ComputeEngineClient per region.
+type commitmentLister interface {
+	ListResourceCommitments(ctx context.Context) ([]RegionalCommitment, error)
+}
+
+// commitmentPricer returns the hourly USD cost of one resource-based CUD.
+// The concrete implementation is commitmentPricerAdapter over the Cloud
+// Billing Catalog commitment SKUs.
+type commitmentPricer interface {
+	HourlyCostUSD(ctx context.Context, c RegionalCommitment) (float64, error)
+}
+
+// recommendationSource is the narrow interface for the Compute Engine CUD
+// recommendations the usage baseline is derived from. The concrete
+// implementation is gcp.RecommendationsClientAdapter, which fans out over
+// every region of the project.
+type recommendationSource interface {
+	GetRecommendations(ctx context.Context, params *common.RecommendationParams) ([]common.Recommendation, error)
+}
+
+// cudPurchaser is the narrow interface for buying a resource-based CUD. The
+// concrete implementation is regionalCUDPurchaser, which routes to a
+// computeengine.ComputeEngineClient for rec.Region.
+type cudPurchaser interface {
+	PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error)
+}
@@ -0,0 +1,148 @@
+package ladder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// DefaultHorizonDays is the number of days ahead used to classify a
+// commitment as "expiring soon" in GetLayerStates.ExpiringUSDPerHour.
+// Callers that need a different window pass it via Config.HorizonDays.
+const DefaultHorizonDays = 30
+
+// DefaultTerm is the commitment term the baseline prices recommendations at
+// when Config.Term is empty.
+const DefaultTerm = "1yr"
+
+// hoursPerYear converts a term-total commitment cost into USD/hour.
+const hoursPerYear = 8760.0
+
+// errWriteNotWired is the sentinel returned by PurchaseLayer when the
+// purchaser has not been wired via WithWriteSide. It is distinct from
+// common.ErrCommitmentPurchaseNotSupported: the capability exists, the
+// instance is just missing its write wiring.
+var errWriteNotWired = errors.New("write side not wired: wire cudPurchaser via WithWriteSide before calling PurchaseLayer")
+
+// ErrLadderExecutionDisabled is returned by PurchaseLayer when the ladder has
+// been wired with a disabled write side (ladder_execution_enabled=false in
+// global_config). Use errors.Is(err, ErrLadderExecutionDisabled) to
+// distinguish this from errWriteNotWired (missing wiring = programming error
+// at the call site).
+var ErrLadderExecutionDisabled = errors.New("ladder write side disabled: set ladder_execution_enabled=true in global_config to enable purchases")
+
+// Config holds construction-time parameters for GCPLadder.
+type Config struct {
+	// ProjectID is the GCP project this ladder instance is scoped to. It is
+	// the ladder Scope.AccountID.
+	ProjectID string
+	// Term is the commitment term ("1yr" or "3yr") recommendations are
+	// fetched and priced at for the usage baseline. When empty, DefaultTerm
+	// is applied.
+	Term string
+	// HorizonDays is the look-ahead window (in days) used to classify a
+	// commitment as expiring soon in ExpiringUSDPerHour. When zero,
+	// DefaultHorizonDays is applied.
+	HorizonDays int
+}
+
+// horizonDays returns the effective horizon, applying the default when unset.
+func (c Config) horizonDays() int {
+	if c.HorizonDays > 0 {
+		return c.HorizonDays
+	}
+	return DefaultHorizonDays
+}
+
+// term returns the effective baseline term, applying the default when unset.
+func (c Config) term() string {
+	if c.Term != "" {
+		return c.Term
+	}
+	return DefaultTerm
+}
+
+// GCPLadder implements ladder.LadderCapability for one GCP project: the read
+// side (ListCommitments, GetLayerStates, GetUsageBaseline) and the write side
+// (PurchaseLayer, ReshapeBuffer).
+//
+// Like AzureLadder and unlike AWSLadder, a GCP ladder is not region-scoped:
+// resource-based CUDs are listed in every region of the project and the
+// Recommender-derived baseline covers all of them.
+//
+// Fields are ordered to minimize the GC pointer-scan range (fieldalignment):
+// interface fields (all-pointer) come before Config.
+type GCPLadder struct {
+	commitments commitmentLister
+	pricer      commitmentPricer
+	recs        recommendationSource
+	purchaser   cudPurchaser // write side; nil until WithWriteSide is called
+	cfg         Config
+}
+
+// New constructs a GCPLadder. All three read-side interfaces must be non-nil.
+func New(cfg Config, commitments commitmentLister, pricer commitmentPricer, recs recommendationSource) (*GCPLadder, error) {
+	if cfg.ProjectID == "" {
+		return nil, fmt.Errorf("GCPLadder: Config.ProjectID must not be empty")
+	}
+	if _, err := termYears(cfg.term()); err != nil {
+		return nil, fmt.Errorf("GCPLadder: Config.Term: %w", err)
+	}
+	if commitments == nil {
+		return nil, fmt.Errorf("GCPLadder: commitmentLister must not be nil")
+	}
+	if pricer == nil {
+		return nil, fmt.Errorf("GCPLadder: commitmentPricer must not be nil")
+	}
+	if recs == nil {
+		return nil, fmt.Errorf("GCPLadder: recommendationSource must not be nil")
+	}
+	return &GCPLadder{
+		cfg:         cfg,
+		commitments: commitments,
+		pricer:      pricer,
+		recs:        recs,
+	}, nil
+}
+
+// Provider returns common.ProviderGCP to identify this implementation.
+func (g *GCPLadder) Provider() common.ProviderType {
+	return common.ProviderGCP
+}
+
+// SupportedLayers returns the two GCP ladder layers:
+//   - LayerGCPResourceCUD carries RoleBase (regional vCPU + memory CUDs).
+//   - LayerGCPFlexCUD carries RoleFlex (spend-based flexible CUDs,
+//     advisory-only).
+//
+// No layer carries RoleBuffer, so GCP ladder configs must use
+// BufferFraction 0; the engine fails loud otherwise.
+func (g *GCPLadder) SupportedLayers() []ladder.LayerSpec {
+	return ladder.GCPLayers()
+}
+
+// WithWriteSide wires the purchaser and returns the same instance for
+// chaining.
+func (g *GCPLadder) WithWriteSide(p cudPurchaser) (*GCPLadder, error) {
+	if p == nil {
+		return nil, fmt.Errorf("GCPLadder.WithWriteSide: cudPurchaser must not be nil")
+	}
+	g.purchaser = p
+	return g, nil
+}
+
+// termYears maps a ladder term label onto its length in years. Unlike the
+// computeengine client's lenient termYearsFromTerm, an unknown label is an
+// error: a mis-read term mis-prices the baseline by a factor of three.
+func termYears(term string) (int, error) {
+	switch term {
+	case "1yr":
+		return 1, nil
+	case "3yr":
+		return 3, nil
+	default:
+		return 0, fmt.Errorf("unsupported term %q (want 1yr or 3yr)", term)
+	}
+}
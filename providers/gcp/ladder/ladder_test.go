@@ -0,0 +1,291 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
+)
+
+const testProject = "proj-123"
+
+var testScope = ladder.Scope{Provider: common.ProviderGCP, AccountID: testProject}
+
+// --- fakes ---
+
+type fakeLister struct {
+	commitments []RegionalCommitment
+	err         error
+}
+
+func (f *fakeLister) ListResourceCommitments(context.Context) ([]RegionalCommitment, error) {
+	return f.commitments, f.err
+}
+
+type fakePricer struct {
+	cost  float64
+	err   error
+	calls int
+}
+
+func (f *fakePricer) HourlyCostUSD(context.Context, RegionalCommitment) (float64, error) {
+	f.calls++
+	return f.cost, f.err
+}
+
+type fakeRecs struct {
+	recs   []common.Recommendation
+	err    error
+	params *common.RecommendationParams
+}
+
+func (f *fakeRecs) GetRecommendations(_ context.Context, p *common.RecommendationParams) ([]common.Recommendation, error) {
+	f.params = p
+	return f.recs, f.err
+}
+
+type fakes struct {
+	lister *fakeLister
+	pricer *fakePricer
+	recs   *fakeRecs
+}
+
+func newFakes() *fakes {
+	return &fakes{
+		lister: &fakeLister{},
+		pricer: &fakePricer{cost: 0.5},
+		recs:   &fakeRecs{},
+	}
+}
+
+func newTestLadder(t *testing.T, f *fakes) *GCPLadder {
+	t.Helper()
+	l, err := New(Config{ProjectID: testProject}, f.lister, f.pricer, f.recs)
+	require.NoError(t, err)
+	return l
+}
+
+func activeCUD(name, region string, end time.Time) RegionalCommitment {
+	return RegionalCommitment{
+		Region: region,
+		ResourceCommitmentInfo: computeengine.ResourceCommitmentInfo{
+			Name:     name,
+			Type:     "GENERAL_PURPOSE_N2",
+			Plan:     "TWELVE_MONTH",
+			Status:   commitmentStatusActive,
+			Start:    end.AddDate(-1, 0, 0),
+			End:      end,
+			VCPUs:    8,
+			MemoryMB: 32768,
+		},
+	}
+}
+
+func cudRec(region string, commitmentCost float64) common.Recommendation {
+	return common.Recommendation{
+		Provider:       common.ProviderGCP,
+		Service:        common.ServiceCompute,
+		CommitmentType: common.CommitmentCUD,
+		Region:         region,
+		ResourceType:   "n2-standard-4",
+		Term:           "1yr",
+		CommitmentCost: commitmentCost,
+	}
+}
+
+// --- construction ---
+
+func TestNew_RejectsInvalidConfigAndDependencies(t *testing.T) {
+	f := newFakes()
+	cases := []struct {
+		name string
+		call func() (*GCPLadder, error)
+		want string
+	}{
+		{"project", func() (*GCPLadder, error) { return New(Config{}, f.lister, f.pricer, f.recs) }, "ProjectID"},
+		{"term", func() (*GCPLadder, error) {
+			return New(Config{ProjectID: testProject, Term: "5yr"}, f.lister, f.pricer, f.recs)
+		}, "Term"},
+		{"lister", func() (*GCPLadder, error) { return New(Config{ProjectID: testProject}, nil, f.pricer, f.recs) }, "commitmentLister"},
+		{"pricer", func() (*GCPLadder, error) { return New(Config{ProjectID: testProject}, f.lister, nil, f.recs) }, "commitmentPricer"},
+		{"recs", func() (*GCPLadder, error) { return New(Config{ProjectID: testProject}, f.lister, f.pricer, nil) }, "recommendationSource"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := tc.call()
+			require.Error(t, err)
+			assert.Nil(t, l)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestSupportedLayers_ValidateAgainstEngineRules(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	assert.Equal(t, common.ProviderGCP, l.Provider())
+
+	specs := l.SupportedLayers()
+	require.Len(t, specs, 2)
+	assert.Equal(t, ladder.LayerGCPResourceCUD, specs[0].Type)
+	assert.Equal(t, []ladder.LayerRole{ladder.RoleBase}, specs[0].Roles)
+	assert.Equal(t, ladder.LayerGCPFlexCUD, specs[1].Type)
+	assert.Equal(t, []ladder.LayerRole{ladder.RoleFlex}, specs[1].Roles)
+}
+
+func TestValidateScope(t *testing.T) {
+	f := newFakes()
+	l := newTestLadder(t, f)
+
+	_, err := l.ListCommitments(context.Background(), ladder.Scope{Provider: common.ProviderAWS, AccountID: testProject})
+	require.Error(t, err)
+	_, err = l.GetLayerStates(context.Background(), ladder.Scope{Provider: common.ProviderGCP, AccountID: "other"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+}
+
+// --- read side ---
+
+func TestListCommitments_ActiveOnlyAndPriced(t *testing.T) {
+	f := newFakes()
+	end := time.Now().AddDate(0, 6, 0)
+	expired := activeCUD("old", "us-east1", end)
+	expired.Status = "expired"
+	f.lister.commitments = []RegionalCommitment{activeCUD("cud-a", "us-central1", end), expired}
+	l := newTestLadder(t, f)
+
+	got, err := l.ListCommitments(context.Background(), testScope)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "cud-a", got[0].CommitmentID)
+	assert.Equal(t, "us-central1", got[0].Region)
+	assert.Equal(t, "GENERAL_PURPOSE_N2", got[0].ResourceType)
+	assert.Equal(t, 8, got[0].Count)
+	assert.Equal(t, testProject, got[0].Account)
+	assert.Equal(t, common.CommitmentCUD, got[0].CommitmentType)
+	assert.InDelta(t, 0.5, got[0].Cost, 1e-9)
+	assert.Equal(t, 1, f.pricer.calls, "inactive commitments must not be priced")
+}
+
+func TestListCommitments_PricingErrorFailsLoud(t *testing.T) {
+	f := newFakes()
+	f.lister.commitments = []RegionalCommitment{activeCUD("cud-a", "us-central1", time.Now().AddDate(1, 0, 0))}
+	f.pricer.err = errors.New("no SKU")
+	l := newTestLadder(t, f)
+
+	_, err := l.ListCommitments(context.Background(), testScope)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, f.pricer.err)
+	assert.Contains(t, err.Error(), "cud-a")
+}
+
+func TestGetLayerStates_ExistingExpiringAndFlexZeros(t *testing.T) {
+	f := newFakes()
+	f.lister.commitments = []RegionalCommitment{
+		activeCUD("soon", "us-central1", time.Now().Add(10*24*time.Hour)),
+		activeCUD("later", "europe-west1", time.Now().AddDate(0, 6, 0)),
+	}
+	l := newTestLadder(t, f)
+
+	states, err := l.GetLayerStates(context.Background(), testScope)
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+
+	base := states[ladder.LayerGCPResourceCUD]
+	assert.InDelta(t, 1.0, *base.ExistingUSDPerHour, 1e-9)
+	assert.InDelta(t, 0.5, *base.ExpiringUSDPerHour, 1e-9)
+	assert.Nil(t, base.UtilizationPct)
+	assert.Nil(t, base.CoveragePct)
+
+	flex := states[ladder.LayerGCPFlexCUD]
+	assert.Zero(t, *flex.ExistingUSDPerHour)
+	assert.Zero(t, *flex.ExpiringUSDPerHour)
+}
+
+func TestGetLayerStates_ListingErrorPropagates(t *testing.T) {
+	f := newFakes()
+	f.lister.err = errors.New("403")
+	l := newTestLadder(t, f)
+
+	_, err := l.GetLayerStates(context.Background(), testScope)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, f.lister.err)
+}
+
+func TestGetUsageBaseline_ExistingPlusRecommended(t *testing.T) {
+	f := newFakes()
+	f.lister.commitments = []RegionalCommitment{activeCUD("cud-a", "us-central1", time.Now().AddDate(1, 0, 0))}
+	f.recs.recs = []common.Recommendation{
+		cudRec("us-central1", 8760),  // 1.0 USD/h
+		cudRec("europe-west1", 4380), // 0.5 USD/h
+		cudRec("asia-east1", 0),      // unpriced: skipped
+		{Service: common.ServiceRelationalDB, CommitmentType: common.CommitmentCUD, Term: "1yr", CommitmentCost: 1e6},
+	}
+	l := newTestLadder(t, f)
+
+	b, err := l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.NoError(t, err)
+	require.NotNil(t, b.LowWaterUSDPerHour)
+	require.NotNil(t, b.StableUSDPerHour)
+	assert.InDelta(t, 2.0, *b.LowWaterUSDPerHour, 1e-9)
+	assert.InDelta(t, 2.0, *b.StableUSDPerHour, 1e-9)
+	assert.Equal(t, 30, b.LookbackDays)
+	assert.InDelta(t, 10, b.Percentile, 1e-9)
+
+	require.NotNil(t, f.recs.params)
+	assert.Equal(t, common.ServiceCompute, f.recs.params.Service)
+	assert.Equal(t, DefaultTerm, f.recs.params.Term)
+}
+
+func TestGetUsageBaseline_ThreeYearTermAmortizesOverThreeYears(t *testing.T) {
+	f := newFakes()
+	rec := cudRec("us-central1", 3*8760)
+	rec.Term = "3yr"
+	f.recs.recs = []common.Recommendation{rec}
+	l, err := New(Config{ProjectID: testProject, Term: "3yr"}, f.lister, f.pricer, f.recs)
+	require.NoError(t, err)
+
+	b, err := l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, *b.LowWaterUSDPerHour, 1e-9)
+	assert.Equal(t, "3yr", f.recs.params.Term)
+}
+
+func TestGetUsageBaseline_RejectsBadArgsAndPropagatesErrors(t *testing.T) {
+	f := newFakes()
+	l := newTestLadder(t, f)
+
+	_, err := l.GetUsageBaseline(context.Background(), testScope, 0, 10)
+	require.Error(t, err)
+	_, err = l.GetUsageBaseline(context.Background(), testScope, 30, 0)
+	require.Error(t, err)
+	_, err = l.GetUsageBaseline(context.Background(), testScope, 30, 101)
+	require.Error(t, err)
+
+	f.recs.err = errors.New("recommender down")
+	_, err = l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, f.recs.err)
+
+	f.recs.err = nil
+	bad := cudRec("us-central1", 100)
+	bad.Term = "2yr"
+	f.recs.recs = []common.Recommendation{bad}
+	_, err = l.GetUsageBaseline(context.Background(), testScope, 30, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported term")
+}
+
+func TestReshapeBuffer_NotSupported(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+
+	_, err := l.ReshapeBuffer(context.Background(), testScope, ladder.BufferReshapeConfig{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, common.ErrCommitmentPurchaseNotSupported)
+}
@@ -0,0 +1,62 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// ptr wraps a float64 as a non-nil pointer. Used to convert derived metrics
+// into the pointer form required by LayerState.
+func ptr(v float64) *float64 { return &v }
+
+// GetLayerStates returns a point-in-time snapshot for both GCP ladder layers:
+//
+//   - LayerGCPResourceCUD: ExistingUSDPerHour is the summed hourly cost of
+//     the active resource-based CUDs (explicit zero when there are none);
+//     ExpiringUSDPerHour is the share ending within Config.HorizonDays.
+//   - LayerGCPFlexCUD: explicit zeros. Spend-based CUDs are bought and held
+//     at the billing-account level and no API this client uses lists them,
+//     so an existing flexible CUD is invisible here. The layer is
+//     advisory-only, so the worst case is an over-sized hold, never a
+//     purchase.
+//
+// UtilizationPct and CoveragePct are nil for both layers: the Recommender
+// data the ladder reads carries neither, and the engine treats nil as
+// unmeasured.
+//
+// The scope must match Config.ProjectID and common.ProviderGCP.
+func (g *GCPLadder) GetLayerStates(ctx context.Context, scope ladder.Scope) (map[ladder.LayerType]ladder.LayerState, error) {
+	if err := g.validateScope(scope); err != nil {
+		return nil, err
+	}
+	priced, err := g.listPricedCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetLayerStates: %w", err)
+	}
+
+	horizon := time.Now().Add(time.Duration(g.cfg.horizonDays()) * 24 * time.Hour)
+	var existing, expiring float64
+	for i := range priced {
+		p := &priced[i]
+		existing += p.hourlyUSD
+		if !p.c.End.IsZero() && !p.c.End.After(horizon) {
+			expiring += p.hourlyUSD
+		}
+	}
+
+	return map[ladder.LayerType]ladder.LayerState{
+		ladder.LayerGCPResourceCUD: {
+			Layer:              ladder.LayerGCPResourceCUD,
+			ExistingUSDPerHour: ptr(existing),
+			ExpiringUSDPerHour: ptr(expiring),
+		},
+		ladder.LayerGCPFlexCUD: {
+			Layer:              ladder.LayerGCPFlexCUD,
+			ExistingUSDPerHour: ptr(0),
+			ExpiringUSDPerHour: ptr(0),
+		},
+	}, nil
+}
@@ -0,0 +1,88 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// PurchaseLayer buys a commitment for the given layer:
+//
+//   - LayerGCPResourceCUD -> cudPurchaser (regional resource-based CUD via
+//     the Compute commitments.insert API, bought in rec.Region)
+//   - LayerGCPFlexCUD     -> common.ErrCommitmentPurchaseNotSupported.
+//     Spend-based flexible CUDs are bought in the Cloud Console at the
+//     billing-account level; GCP exposes no API for it. The sentinel lets
+//     the engine emit a hold for the flex layer instead of failing the run.
+//
+// Boundary validation happens BEFORE any client call (this is a money path;
+// nothing is bought on malformed input):
+//
+//   - layer must be one of the two GCP layers;
+//   - opts.IdempotencyToken must be non-empty: the compute client derives
+//     the commitment name and insert request ID from it, which is what makes
+//     a re-driven purchase collide instead of buying twice;
+//   - rec must carry what the compute client needs (see
+//     validateResourceCUDPurchaseRec).
+//
+// The flex check runs before the write-side check so an unwired ladder still
+// reports the layer as unpurchasable rather than as a wiring error.
+//
+// Client errors are wrapped with layer context via %w, and the client's
+// PurchaseResult is returned alongside the error.
+func (g *GCPLadder) PurchaseLayer(ctx context.Context, layer ladder.LayerType, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	switch layer {
+	case ladder.LayerGCPResourceCUD:
+	case ladder.LayerGCPFlexCUD:
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer(%s): %w: spend-based flexible CUDs can only be bought in the Cloud Console",
+			layer, common.ErrCommitmentPurchaseNotSupported)
+	default:
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: layer %q is not a supported GCP ladder layer (want %s or %s)",
+			layer, ladder.LayerGCPResourceCUD, ladder.LayerGCPFlexCUD)
+	}
+	if g.purchaser == nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer: %w", errWriteNotWired)
+	}
+	if opts.IdempotencyToken == "" {
+		return common.PurchaseResult{}, fmt.Errorf(
+			"PurchaseLayer(%s): opts.IdempotencyToken must not be empty: idempotency is mandatory on the ladder purchase path so re-driven executions cannot double-buy",
+			layer)
+	}
+	if err := validateResourceCUDPurchaseRec(&rec); err != nil {
+		return common.PurchaseResult{}, fmt.Errorf("PurchaseLayer(%s): %w", layer, err)
+	}
+
+	result, err := g.purchaser.PurchaseCommitment(ctx, rec, opts)
+	if err != nil {
+		return result, fmt.Errorf("PurchaseLayer(%s): resource-based CUD purchase failed: %w", layer, err)
+	}
+	return result, nil
+}
+
+// validateResourceCUDPurchaseRec checks that rec carries everything the
+// compute client's PurchaseCommitment needs. The client derives the
+// commitment type from ResourceType (machine type), the vCPU amount from
+// Count, the plan from Term and the memory amount from ComputeDetails; the
+// commitment region is the one its client was created for, which the
+// purchaser routes from rec.Region. The client re-validates all of these,
+// but checking here keeps the failure ahead of any client construction.
+func validateResourceCUDPurchaseRec(rec *common.Recommendation) error {
+	if rec.ResourceType == "" {
+		return fmt.Errorf("recommendation ResourceType (machine type) must not be empty for a resource-based CUD purchase")
+	}
+	if rec.Region == "" {
+		return fmt.Errorf("recommendation Region must not be empty for a resource-based CUD purchase (CUDs are regional)")
+	}
+	if rec.Count <= 0 {
+		return fmt.Errorf("recommendation Count (vCPUs) must be > 0 for a resource-based CUD purchase, got %d", rec.Count)
+	}
+	if _, err := termYears(rec.Term); err != nil {
+		return fmt.Errorf("recommendation Term: %w", err)
+	}
+	if cd, ok := rec.Details.(common.ComputeDetails); !ok || cd.MemoryGB <= 0 {
+		return fmt.Errorf("recommendation Details must be common.ComputeDetails with MemoryGB > 0 for a resource-based CUD purchase, got %T", rec.Details)
+	}
+	return nil
+}
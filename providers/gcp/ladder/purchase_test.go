@@ -0,0 +1,116 @@
+package ladder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+type fakePurchaser struct {
+	err   error
+	calls []common.Recommendation
+}
+
+func (f *fakePurchaser) PurchaseCommitment(_ context.Context, rec common.Recommendation, _ common.PurchaseOptions) (common.PurchaseResult, error) {
+	f.calls = append(f.calls, rec)
+	return common.PurchaseResult{Recommendation: rec, Success: f.err == nil}, f.err
+}
+
+func wiredLadder(t *testing.T, p *fakePurchaser) *GCPLadder {
+	t.Helper()
+	l, err := newTestLadder(t, newFakes()).WithWriteSide(p)
+	require.NoError(t, err)
+	return l
+}
+
+func validCUDRec() common.Recommendation {
+	return common.Recommendation{
+		ResourceType: "n2-standard-4",
+		Region:       "us-central1",
+		Count:        4,
+		Term:         "1yr",
+		Details:      common.ComputeDetails{MemoryGB: 16},
+	}
+}
+
+var purchaseOpts = common.PurchaseOptions{Source: "cudly-ladder", IdempotencyToken: "tok-1"}
+
+func TestPurchaseLayer_NotWired(t *testing.T) {
+	l := newTestLadder(t, newFakes())
+	_, err := l.PurchaseLayer(context.Background(), ladder.LayerGCPResourceCUD, validCUDRec(), purchaseOpts)
+	require.ErrorIs(t, err, errWriteNotWired)
+}
+
+func TestPurchaseLayer_FlexIsNotSupported(t *testing.T) {
+	// Both unwired and wired: the flex layer is never purchasable, and the
+	// sentinel must win over the wiring check so the engine emits a hold.
+	_, err := newTestLadder(t, newFakes()).PurchaseLayer(context.Background(), ladder.LayerGCPFlexCUD, validCUDRec(), purchaseOpts)
+	require.ErrorIs(t, err, common.ErrCommitmentPurchaseNotSupported)
+
+	p := &fakePurchaser{}
+	_, err = wiredLadder(t, p).PurchaseLayer(context.Background(), ladder.LayerGCPFlexCUD, validCUDRec(), purchaseOpts)
+	require.ErrorIs(t, err, common.ErrCommitmentPurchaseNotSupported)
+	assert.Empty(t, p.calls)
+}
+
+func TestPurchaseLayer_ResourceCUDDispatch(t *testing.T) {
+	p := &fakePurchaser{}
+	l := wiredLadder(t, p)
+
+	res, err := l.PurchaseLayer(context.Background(), ladder.LayerGCPResourceCUD, validCUDRec(), purchaseOpts)
+	require.NoError(t, err)
+	assert.True(t, res.Success)
+	require.Len(t, p.calls, 1)
+	assert.Equal(t, "us-central1", p.calls[0].Region)
+}
+
+func TestPurchaseLayer_RejectsBeforeAnyClientCall(t *testing.T) {
+	cases := []struct {
+		name   string
+		layer  ladder.LayerType
+		mutate func(*common.Recommendation, *common.PurchaseOptions)
+		want   string
+	}{
+		{"unknown layer", ladder.LayerComputeSP, func(*common.Recommendation, *common.PurchaseOptions) {}, "not a supported GCP ladder layer"},
+		{"no idempotency token", ladder.LayerGCPResourceCUD, func(_ *common.Recommendation, o *common.PurchaseOptions) { o.IdempotencyToken = "" }, "IdempotencyToken"},
+		{"no machine type", ladder.LayerGCPResourceCUD, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.ResourceType = "" }, "ResourceType"},
+		{"no region", ladder.LayerGCPResourceCUD, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Region = "" }, "Region"},
+		{"zero count", ladder.LayerGCPResourceCUD, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Count = 0 }, "Count"},
+		{"bad term", ladder.LayerGCPResourceCUD, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Term = "" }, "Term"},
+		{"no memory", ladder.LayerGCPResourceCUD, func(r *common.Recommendation, _ *common.PurchaseOptions) { r.Details = nil }, "MemoryGB"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakePurchaser{}
+			l := wiredLadder(t, p)
+			rec, opts := validCUDRec(), purchaseOpts
+			tc.mutate(&rec, &opts)
+
+			_, err := l.PurchaseLayer(context.Background(), tc.layer, rec, opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+			assert.Empty(t, p.calls)
+		})
+	}
+}
+
+func TestPurchaseLayer_WrapsClientError(t *testing.T) {
+	p := &fakePurchaser{err: errors.New("quota exceeded")}
+	_, err := wiredLadder(t, p).PurchaseLayer(context.Background(), ladder.LayerGCPResourceCUD, validCUDRec(), purchaseOpts)
+	require.ErrorIs(t, err, p.err)
+	assert.Contains(t, err.Error(), string(ladder.LayerGCPResourceCUD))
+}
+
+func TestWireWriteSideDisabled(t *testing.T) {
+	l, err := WireWriteSideDisabled(newTestLadder(t, newFakes()))
+	require.NoError(t, err)
+
+	_, err = l.PurchaseLayer(context.Background(), ladder.LayerGCPResourceCUD, validCUDRec(), purchaseOpts)
+	require.ErrorIs(t, err, ErrLadderExecutionDisabled)
+}
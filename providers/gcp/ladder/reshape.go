@@ -0,0 +1,21 @@
+package ladder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/ladder"
+)
+
+// ReshapeBuffer is not supported on GCP. Resource-based CUDs cannot be
+// exchanged, converted or cancelled once bought, and the GCP ladder has no
+// buffer layer to reshape. The returned error wraps
+// common.ErrCommitmentPurchaseNotSupported so callers can tell an
+// unsupported operation from a failed one with errors.Is.
+func (g *GCPLadder) ReshapeBuffer(_ context.Context, scope ladder.Scope, _ ladder.BufferReshapeConfig) (ladder.ReshapeSummary, error) {
+	if err := g.validateScope(scope); err != nil {
+		return ladder.ReshapeSummary{}, err
+	}
+	return ladder.ReshapeSummary{}, fmt.Errorf("ReshapeBuffer: %w: GCP committed use discounts cannot be exchanged", common.ErrCommitmentPurchaseNotSupported)
+}
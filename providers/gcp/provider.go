@@ -20,6 +20,7 @@ import (
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
 	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/bigquery"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/cloudsql"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/cloudstorage"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
@@ -465,6 +466,7 @@ func (p *GCPProvider) GetSupportedServices() []common.ServiceType {
 		common.ServiceRelationalDB,
 		common.ServiceCache,
 		common.ServiceStorage,
+		common.ServiceDataWarehouse,
 	}
 }
 
@@ -479,6 +481,8 @@ func (p *GCPProvider) GetServiceClient(ctx context.Context, service common.Servi
 		return memorystore.NewClient(ctx, p.projectID, region, p.clientOpts...)
 	case common.ServiceStorage:
 		return cloudstorage.NewClient(ctx, p.projectID, region, p.clientOpts...)
+	case common.ServiceDataWarehouse:
+		return bigquery.NewClient(ctx, p.projectID, region, p.clientOpts...)
 	default:
 		return nil, fmt.Errorf("unsupported service type for GCP: %s", service)
 	}
@@ -289,6 +289,7 @@ func TestGCPProvider_GetSupportedServices(t *testing.T) {
 	require.NotEmpty(t, services)
 	assert.Contains(t, services, common.ServiceCompute)
 	assert.Contains(t, services, common.ServiceRelationalDB)
+	assert.Contains(t, services, common.ServiceDataWarehouse)
 }
 
 func TestGCPProvider_GetServiceClient_UnsupportedService(t *testing.T) {
@@ -314,6 +315,18 @@ func TestGCPProvider_GetServiceClient_ServiceCache(t *testing.T) {
 	require.NotNil(t, client)
 }
 
+func TestGCPProvider_GetServiceClient_ServiceDataWarehouse(t *testing.T) {
+	ctx := context.Background()
+	provider := NewProviderWithProject(ctx, "test-project")
+
+	// ServiceDataWarehouse is BigQuery slot capacity commitments.
+	client, err := provider.GetServiceClient(ctx, common.ServiceDataWarehouse, "US")
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	assert.Equal(t, common.ServiceDataWarehouse, client.GetServiceType())
+	assert.Equal(t, "US", client.GetRegion())
+}
+
 func TestGCPProvider_GetRecommendationsClient(t *testing.T) {
 	ctx := context.Background()
 	provider := NewProviderWithProject(ctx, "test-project")
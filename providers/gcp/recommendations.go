@@ -17,6 +17,7 @@ import (
 	"github.com/LeanerCloud/CUDly/pkg/common"
 	"github.com/LeanerCloud/CUDly/pkg/concurrency"
 	"github.com/LeanerCloud/CUDly/pkg/logging"
+	"github.com/LeanerCloud/CUDly/providers/gcp/services/bigquery"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/cloudsql"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/cloudstorage"
 	"github.com/LeanerCloud/CUDly/providers/gcp/services/computeengine"
@@ -49,9 +50,9 @@ func gcpRegionConcurrency() int {
 // and appends compute, sql, cache, storage per region so output is
 // deterministic independent of goroutine completion order.
 //
-// All four GCP service clients (computeengine, cloudsql, memorystore,
-// cloudstorage) implement GetRecommendations and are fanned out concurrently
-// when shouldIncludeService permits. Note that cache and storage purchase paths
+// All five GCP service clients (computeengine, cloudsql, memorystore,
+// cloudstorage, bigquery) implement GetRecommendations and are fanned out
+// concurrently when shouldIncludeService permits. Note that cache and storage purchase paths
 // are advisory-only (no-op PurchaseCommitment); their recommendations are still
 // surfaced so operators can see spend-optimisation signals.
 type regionResult struct {
@@ -59,6 +60,10 @@ type regionResult struct {
 	sql     []common.Recommendation
 	cache   []common.Recommendation
 	storage []common.Recommendation
+	// warehouse holds BigQuery slot commitment recommendations. Regions come
+	// from the Compute regions list, so the US/EU multi-regions are not
+	// covered here; query them through GetServiceClient directly.
+	warehouse []common.Recommendation
 	// attempted counts the service calls launched for this region (after the
 	// params service filter), failed counts how many of those errored, and
 	// lastErr keeps one representative error. mergeRegionResults aggregates
@@ -82,8 +87,8 @@ type RecommendationsClientAdapter struct {
 //   - Outer: errgroup over regions, capped at gcpRegionConcurrency()
 //     (CUDLY_GCP_REGION_PARALLELISM, default 10) to stay polite to the
 //     project-scoped Recommender API quota.
-//   - Inner: within each region's goroutine, the four service calls
-//     (compute, cloud-sql, memorystore, cloudstorage) run as concurrent
+//   - Inner: within each region's goroutine, the five service calls
+//     (compute, cloud-sql, memorystore, cloudstorage, bigquery) run as concurrent
 //     goroutines under a per-region sub-errgroup, so the per-region cost is
 //     max(service latencies) rather than their sum.
 //
@@ -164,7 +169,7 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	}
 
 	// Deterministic merge: walk regions in sorted order, append compute, sql,
-	// cache, storage per region. Output is stable regardless of GCP API
+	// cache, storage, warehouse per region. Output is stable regardless of GCP API
 	// region-list ordering or goroutine completion order.
 	sortedRegions := make([]string, 0, len(results))
 	for region := range results {
@@ -175,7 +180,7 @@ func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, p
 	return mergeRegionResults(sortedRegions, results)
 }
 
-// mergeRegionResults appends compute, sql, cache, storage per region in the
+// mergeRegionResults appends compute, sql, cache, storage, warehouse per region in the
 // (sorted) order given so output is deterministic, and ports the AWS 08-H4
 // all-failed guard from providers/aws/recommendations/client.go: when every
 // attempted (region, service) call errored (e.g. an expired credential, a
@@ -203,6 +208,7 @@ func mergeRegionResults(sortedRegions []string, results map[string]regionResult)
 		merged = append(merged, res.sql...)
 		merged = append(merged, res.cache...)
 		merged = append(merged, res.storage...)
+		merged = append(merged, res.warehouse...)
 	}
 	if failed > 0 && failed == attempted {
 		return nil, fmt.Errorf("all %d GCP recommendation service calls failed across %d regions: %w", failed, len(sortedRegions), lastErr)
@@ -264,8 +270,21 @@ func (r *RecommendationsClientAdapter) collectStorageRecs(ctx context.Context, p
 	return client.GetRecommendations(ctx, &params)
 }
 
-// collectRegion fetches recommendations for all four GCP services
-// (Compute Engine, Cloud SQL, Memorystore, Cloud Storage) for a single region
+// collectWarehouseRecs fetches BigQuery slot commitment recommendations for one region.
+func (r *RecommendationsClientAdapter) collectWarehouseRecs(ctx context.Context, params common.RecommendationParams, region string) ([]common.Recommendation, error) {
+	if err := concurrency.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer concurrency.Release(ctx)
+	client, err := bigquery.NewClient(ctx, r.projectID, region, r.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetRecommendations(ctx, &params)
+}
+
+// collectRegion fetches recommendations for all five GCP services
+// (Compute Engine, Cloud SQL, Memorystore, Cloud Storage, BigQuery) for a single region
 // concurrently. Per-service errors are logged at WARN with the region+service
 // tag and do not fail the region on their own -- the previous
 // silent-skip-on-err shape is preserved for partial failures (so a
@@ -276,7 +295,7 @@ func (r *RecommendationsClientAdapter) collectStorageRecs(ctx context.Context, p
 // The per-service fetch logic
 // (semaphore, client construction, GetRecommendations call) is delegated to
 // dedicated helpers (collectComputeRecs, collectSQLRecs, collectCacheRecs,
-// collectStorageRecs) to keep this function's cyclomatic complexity under the
+// collectStorageRecs, collectWarehouseRecs) to keep this function's cyclomatic complexity under the
 // gocyclo gate.
 //
 // Note: memorystore and cloudstorage PurchaseCommitment paths are advisory-only
@@ -284,8 +303,8 @@ func (r *RecommendationsClientAdapter) collectStorageRecs(ctx context.Context, p
 // surfaced so operators can see spend-optimisation signals (H-2 fix).
 func (r *RecommendationsClientAdapter) collectRegion(ctx context.Context, params common.RecommendationParams, region string) regionResult {
 	var (
-		computeRecs, sqlRecs, cacheRecs, storageRecs []common.Recommendation
-		computeErr, sqlErr, cacheErr, storageErr     error
+		computeRecs, sqlRecs, cacheRecs, storageRecs, warehouseRecs []common.Recommendation
+		computeErr, sqlErr, cacheErr, storageErr, warehouseErr      error
 	)
 
 	g, gctx := errgroup.WithContext(ctx)
@@ -319,6 +338,13 @@ func (r *RecommendationsClientAdapter) collectRegion(ctx context.Context, params
 			return nil
 		})
 	}
+	if shouldIncludeService(params, common.ServiceDataWarehouse) {
+		attempted++
+		g.Go(func() error {
+			warehouseRecs, warehouseErr = r.collectWarehouseRecs(gctx, params, region)
+			return nil
+		})
+	}
 	_ = g.Wait()
 
 	failed := 0
@@ -343,9 +369,14 @@ func (r *RecommendationsClientAdapter) collectRegion(ctx context.Context, params
 		failed++
 		lastErr = storageErr
 	}
+	if warehouseErr != nil {
+		logging.Warnf("GCP %s bigquery recommendations: %v", region, warehouseErr)
+		failed++
+		lastErr = warehouseErr
+	}
 
 	return regionResult{
-		compute: computeRecs, sql: sqlRecs, cache: cacheRecs, storage: storageRecs,
+		compute: computeRecs, sql: sqlRecs, cache: cacheRecs, storage: storageRecs, warehouse: warehouseRecs,
 		attempted: attempted, failed: failed, lastErr: lastErr,
 	}
 }
@@ -325,3 +325,22 @@ func TestMergeRegionResults_NoAttemptsIsNotAFailure(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, recs)
 }
+
+// TestMergeRegionResults_AppendsWarehouseLast pins the per-region merge order
+// with BigQuery slot recommendations after the four CUD services.
+func TestMergeRegionResults_AppendsWarehouseLast(t *testing.T) {
+	results := map[string]regionResult{
+		"us-central1": {
+			compute:   []common.Recommendation{{Service: common.ServiceCompute}},
+			storage:   []common.Recommendation{{Service: common.ServiceStorage}},
+			warehouse: []common.Recommendation{{Service: common.ServiceDataWarehouse}},
+			attempted: 5,
+		},
+	}
+
+	recs, err := mergeRegionResults([]string{"us-central1"}, results)
+
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	assert.Equal(t, common.ServiceDataWarehouse, recs[2].Service)
+}
@@ -0,0 +1,972 @@
+// Package bigquery provides GCP BigQuery slot capacity commitments client
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/bigqueryreservation/v1"
+	"google.golang.org/api/cloudbilling/v1"
+	"google.golang.org/api/option"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+const (
+	// slotIncrement is the granularity BigQuery editions capacity
+	// commitments are sold in.
+	slotIncrement = 50
+
+	// maxListPages caps Reservation API, billing catalog and query-result
+	// pagination.
+	maxListPages = 20
+
+	// queryTimeoutMs bounds how long a single jobs.query / getQueryResults
+	// call waits for the INFORMATION_SCHEMA query before returning.
+	queryTimeoutMs = 60000
+
+	// reservationBillingService is the Cloud Billing catalog display name of
+	// the service that owns the editions slot SKUs.
+	reservationBillingService = "BigQuery Reservation API"
+
+	// idempotentIDTokenLen is how many token characters go into a
+	// capacity commitment ID (max 64 characters including the prefix).
+	idempotentIDTokenLen = 40
+
+	hoursPerMonth = 730.0
+)
+
+// Editions that can hold capacity commitments. Standard edition is
+// pay-as-you-go only, and EDITION_UNSPECIFIED is legacy flat-rate.
+const (
+	EditionEnterprise     = "ENTERPRISE"
+	EditionEnterprisePlus = "ENTERPRISE_PLUS"
+)
+
+// Capacity commitment plan and renewal plan values.
+const (
+	planAnnual    = "ANNUAL"
+	planThreeYear = "THREE_YEAR"
+	planNone      = "NONE"
+)
+
+// locationPattern guards the region interpolated into the
+// INFORMATION_SCHEMA table qualifier, which cannot be a query parameter.
+var locationPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// ReservationService interface for Reservation API operations (enables mocking)
+type ReservationService interface {
+	ListCapacityCommitments(parent, pageToken string) (*bigqueryreservation.ListCapacityCommitmentsResponse, error)
+	CreateCapacityCommitment(parent, commitmentID string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error)
+	PatchCapacityCommitment(name, updateMask string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error)
+	ListReservations(parent, pageToken string) (*bigqueryreservation.ListReservationsResponse, error)
+}
+
+// JobsService interface for BigQuery query jobs (enables mocking)
+type JobsService interface {
+	Query(projectID string, req *bq.QueryRequest) (*bq.QueryResponse, error)
+	GetQueryResults(projectID, jobID, location, pageToken string) (*bq.GetQueryResultsResponse, error)
+}
+
+// BillingService interface for billing catalog operations (enables mocking)
+type BillingService interface {
+	ListServices(pageToken string) (*cloudbilling.ListServicesResponse, error)
+	ListSKUs(serviceName, pageToken string) (*cloudbilling.ListSkusResponse, error)
+}
+
+// BigQueryClient handles GCP BigQuery slot capacity commitments
+type BigQueryClient struct {
+	ctx                context.Context
+	projectID          string
+	region             string
+	clientOpts         []option.ClientOption
+	reservationService ReservationService
+	jobsService        JobsService
+	billingService     BillingService
+}
+
+// NewClient creates a new GCP BigQuery client. region is the BigQuery
+// location, either a region ("us-central1") or a multi-region ("US").
+func NewClient(ctx context.Context, projectID, region string, opts ...option.ClientOption) (*BigQueryClient, error) {
+	return &BigQueryClient{
+		ctx:        ctx,
+		projectID:  projectID,
+		region:     region,
+		clientOpts: opts,
+	}, nil
+}
+
+// SetReservationService sets the Reservation API service (for testing)
+func (c *BigQueryClient) SetReservationService(svc ReservationService) {
+	c.reservationService = svc
+}
+
+// SetJobsService sets the BigQuery jobs service (for testing)
+func (c *BigQueryClient) SetJobsService(svc JobsService) {
+	c.jobsService = svc
+}
+
+// SetBillingService sets the billing service (for testing)
+func (c *BigQueryClient) SetBillingService(svc BillingService) {
+	c.billingService = svc
+}
+
+// realReservationService wraps the real bigqueryreservation.Service
+type realReservationService struct {
+	service *bigqueryreservation.Service
+}
+
+func (r *realReservationService) ListCapacityCommitments(parent, pageToken string) (*bigqueryreservation.ListCapacityCommitmentsResponse, error) {
+	call := r.service.Projects.Locations.CapacityCommitments.List(parent)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+func (r *realReservationService) CreateCapacityCommitment(parent, commitmentID string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error) {
+	call := r.service.Projects.Locations.CapacityCommitments.Create(parent, commitment)
+	if commitmentID != "" {
+		call = call.CapacityCommitmentId(commitmentID)
+	}
+	return call.Do()
+}
+
+func (r *realReservationService) PatchCapacityCommitment(name, updateMask string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error) {
+	return r.service.Projects.Locations.CapacityCommitments.Patch(name, commitment).UpdateMask(updateMask).Do()
+}
+
+func (r *realReservationService) ListReservations(parent, pageToken string) (*bigqueryreservation.ListReservationsResponse, error) {
+	call := r.service.Projects.Locations.Reservations.List(parent)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+// realJobsService wraps the real bigquery.Service
+type realJobsService struct {
+	service *bq.Service
+}
+
+func (r *realJobsService) Query(projectID string, req *bq.QueryRequest) (*bq.QueryResponse, error) {
+	return r.service.Jobs.Query(projectID, req).Do()
+}
+
+func (r *realJobsService) GetQueryResults(projectID, jobID, location, pageToken string) (*bq.GetQueryResultsResponse, error) {
+	call := r.service.Jobs.GetQueryResults(projectID, jobID).Location(location).TimeoutMs(queryTimeoutMs)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+// realBillingService wraps the real cloudbilling.APIService
+type realBillingService struct {
+	service *cloudbilling.APIService
+}
+
+func (r *realBillingService) ListServices(pageToken string) (*cloudbilling.ListServicesResponse, error) {
+	call := r.service.Services.List()
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+func (r *realBillingService) ListSKUs(serviceName, pageToken string) (*cloudbilling.ListSkusResponse, error) {
+	call := r.service.Services.Skus.List(serviceName)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
+// GetServiceType returns the service type
+func (c *BigQueryClient) GetServiceType() common.ServiceType {
+	return common.ServiceDataWarehouse
+}
+
+// GetRegion returns the region
+func (c *BigQueryClient) GetRegion() string {
+	return c.region
+}
+
+// resolveReservationService returns the injected service (for testing) or
+// creates a new one from the stored options.
+func (c *BigQueryClient) resolveReservationService(ctx context.Context) (ReservationService, error) {
+	if c.reservationService != nil {
+		return c.reservationService, nil
+	}
+	service, err := bigqueryreservation.NewService(ctx, c.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery reservation service: %w", err)
+	}
+	return &realReservationService{service: service}, nil
+}
+
+// resolveJobsService returns the injected service (for testing) or creates a
+// new one from the stored options.
+func (c *BigQueryClient) resolveJobsService(ctx context.Context) (JobsService, error) {
+	if c.jobsService != nil {
+		return c.jobsService, nil
+	}
+	service, err := bq.NewService(ctx, c.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery service: %w", err)
+	}
+	return &realJobsService{service: service}, nil
+}
+
+// getOrCreateBillingService returns the billing service, creating it if needed
+func (c *BigQueryClient) getOrCreateBillingService(ctx context.Context) (BillingService, error) {
+	if c.billingService != nil {
+		return c.billingService, nil
+	}
+	service, err := cloudbilling.NewService(ctx, c.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create billing service: %w", err)
+	}
+	return &realBillingService{service: service}, nil
+}
+
+// parent returns the Reservation API parent for this project and location.
+func (c *BigQueryClient) parent() string {
+	return fmt.Sprintf("projects/%s/locations/%s", c.projectID, c.region)
+}
+
+// commitmentName expands a bare capacity commitment ID to its full resource
+// name; full names pass through unchanged.
+func (c *BigQueryClient) commitmentName(commitmentID string) string {
+	if strings.HasPrefix(commitmentID, "projects/") {
+		return commitmentID
+	}
+	return c.parent() + "/capacityCommitments/" + commitmentID
+}
+
+// GetRecommendations recommends slot capacity commitments per edition.
+//
+// Commitments only discount slots held by reservations of the same edition,
+// so a location without reservations gets no recommendation and the jobs
+// query is skipped. For each reservation the steady-state demand is the
+// larger of its baseline slots (billed whether or not they are used) and the
+// lowest daily average slot usage over the lookback window, taken from
+// INFORMATION_SCHEMA.JOBS_TIMELINE_BY_PROJECT. Active and pending
+// commitments are subtracted per edition and the remainder is rounded down
+// to the 50-slot purchase increment.
+//
+// The jobs view only sees this project's jobs, so a reservation shared with
+// other projects through assignments is sized from its baseline alone.
+func (c *BigQueryClient) GetRecommendations(ctx context.Context, p *common.RecommendationParams) ([]common.Recommendation, error) {
+	if p == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	params := *p
+	term := params.Term
+	if term == "" {
+		term = "1yr"
+	}
+	if _, err := termPlan(term); err != nil {
+		return nil, err
+	}
+	days, err := lookbackDays(params.LookbackPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := c.resolveReservationService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := c.listReservations(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) == 0 {
+		return []common.Recommendation{}, nil
+	}
+	commitments, err := c.listCapacityCommitments(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := c.dailyReservationSlots(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	demand := slotDemand(reservations, usage, days)
+	committed := committedSlots(commitments)
+
+	editions := make([]string, 0, len(demand))
+	for edition := range demand {
+		editions = append(editions, edition)
+	}
+	sort.Strings(editions)
+
+	recommendations := make([]common.Recommendation, 0)
+	for _, edition := range editions {
+		if !isCommitmentEdition(edition) {
+			continue
+		}
+		gap := demand[edition] - committed[edition]
+		slots := gap / slotIncrement * slotIncrement
+		if slots <= 0 {
+			continue
+		}
+		rec := common.Recommendation{
+			Provider:         common.ProviderGCP,
+			Account:          c.projectID,
+			Service:          common.ServiceDataWarehouse,
+			Region:           c.region,
+			ResourceType:     edition,
+			Count:            int(slots),
+			RecommendedCount: int(slots),
+			CommitmentType:   common.CommitmentCUD,
+			Term:             term,
+			PaymentOption:    "monthly",
+			Details: &common.DataWarehouseDetails{
+				NodeType:      edition,
+				NumberOfNodes: int(slots),
+			},
+			Timestamp: time.Now(),
+		}
+		c.fillSlotPricing(ctx, &rec)
+		recommendations = append(recommendations, rec)
+	}
+
+	return recommendations, nil
+}
+
+// listReservations returns every reservation in the location.
+func (c *BigQueryClient) listReservations(ctx context.Context, svc ReservationService) ([]*bigqueryreservation.Reservation, error) {
+	var (
+		out   []*bigqueryreservation.Reservation
+		token string
+	)
+	for page := 0; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if page >= maxListPages {
+			return nil, fmt.Errorf("bigquery: ListReservations page cap (%d) reached", maxListPages)
+		}
+		resp, err := svc.ListReservations(c.parent(), token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reservations: %w", err)
+		}
+		out = append(out, resp.Reservations...)
+		if resp.NextPageToken == "" {
+			return out, nil
+		}
+		token = resp.NextPageToken
+	}
+}
+
+// listCapacityCommitments returns every capacity commitment in the location.
+func (c *BigQueryClient) listCapacityCommitments(ctx context.Context, svc ReservationService) ([]*bigqueryreservation.CapacityCommitment, error) {
+	var (
+		out   []*bigqueryreservation.CapacityCommitment
+		token string
+	)
+	for page := 0; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if page >= maxListPages {
+			return nil, fmt.Errorf("bigquery: ListCapacityCommitments page cap (%d) reached", maxListPages)
+		}
+		resp, err := svc.ListCapacityCommitments(c.parent(), token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list capacity commitments: %w", err)
+		}
+		out = append(out, resp.CapacityCommitments...)
+		if resp.NextPageToken == "" {
+			return out, nil
+		}
+		token = resp.NextPageToken
+	}
+}
+
+// dailySlotsQuery averages slot usage per reservation per full day. SCRIPT
+// parent jobs are excluded because their slot time repeats their children's.
+const dailySlotsQuery = "SELECT reservation_id, DATE(period_start) AS day, " +
+	"SUM(period_slot_ms) / (1000 * 86400) AS avg_slots " +
+	"FROM `region-%s`.INFORMATION_SCHEMA.JOBS_TIMELINE_BY_PROJECT " +
+	"WHERE period_start >= TIMESTAMP(DATE_SUB(CURRENT_DATE(), INTERVAL @days DAY)) " +
+	"AND period_start < TIMESTAMP(CURRENT_DATE()) " +
+	"AND reservation_id IS NOT NULL " +
+	"AND (statement_type IS NULL OR statement_type != 'SCRIPT') " +
+	"GROUP BY reservation_id, day"
+
+// dailyReservationSlots returns the per-day average slots keyed by
+// reservation short name (the segment after the location in reservation_id,
+// e.g. "proj:US.etl" -> "etl").
+func (c *BigQueryClient) dailyReservationSlots(ctx context.Context, days int) (map[string][]float64, error) {
+	if !locationPattern.MatchString(c.region) {
+		return nil, fmt.Errorf("invalid BigQuery location %q", c.region)
+	}
+	svc, err := c.resolveJobsService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	useLegacySQL := false
+	req := &bq.QueryRequest{
+		Query:         fmt.Sprintf(dailySlotsQuery, strings.ToLower(c.region)),
+		UseLegacySql:  &useLegacySQL,
+		Location:      c.region,
+		TimeoutMs:     queryTimeoutMs,
+		ParameterMode: "NAMED",
+		QueryParameters: []*bq.QueryParameter{{
+			Name:           "days",
+			ParameterType:  &bq.QueryParameterType{Type: "INT64"},
+			ParameterValue: &bq.QueryParameterValue{Value: strconv.Itoa(days)},
+		}},
+	}
+	resp, err := svc.Query(c.projectID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job statistics: %w", err)
+	}
+
+	rows := resp.Rows
+	complete, token := resp.JobComplete, resp.PageToken
+	for page := 0; !complete || token != ""; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled while reading job statistics: %w", err)
+		}
+		if page >= maxListPages {
+			return nil, fmt.Errorf("bigquery: job statistics page cap (%d) reached", maxListPages)
+		}
+		if resp.JobReference == nil {
+			return nil, fmt.Errorf("job statistics query returned no job reference")
+		}
+		more, err := svc.GetQueryResults(c.projectID, resp.JobReference.JobId, resp.JobReference.Location, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job statistics: %w", err)
+		}
+		rows = append(rows, more.Rows...)
+		complete, token = more.JobComplete, more.PageToken
+	}
+
+	usage := make(map[string][]float64)
+	for _, row := range rows {
+		if len(row.F) < 3 {
+			continue
+		}
+		id, _ := row.F[0].V.(string)
+		raw, _ := row.F[2].V.(string)
+		slots, err := strconv.ParseFloat(raw, 64)
+		if id == "" || err != nil {
+			continue
+		}
+		name := reservationShortName(id)
+		usage[name] = append(usage[name], slots)
+	}
+	return usage, nil
+}
+
+// slotDemand sums, per edition, each reservation's steady-state slot demand:
+// max(baseline slots, lowest daily average). A reservation with fewer usage
+// days than the window had zero usage on the missing days.
+func slotDemand(reservations []*bigqueryreservation.Reservation, usage map[string][]float64, days int) map[string]int64 {
+	demand := make(map[string]int64)
+	for _, r := range reservations {
+		if r == nil {
+			continue
+		}
+		slots := r.SlotCapacity
+		if daily := usage[reservationShortName(r.Name)]; len(daily) >= days {
+			low := daily[0]
+			for _, d := range daily[1:] {
+				if d < low {
+					low = d
+				}
+			}
+			if int64(low) > slots {
+				slots = int64(low)
+			}
+		}
+		demand[normalizeEdition(r.Edition)] += slots
+	}
+	return demand
+}
+
+// committedSlots sums active and pending commitment slots per edition.
+// Pending commitments are counted so a recommendation made while a purchase
+// is provisioning does not buy the same slots twice.
+func committedSlots(commitments []*bigqueryreservation.CapacityCommitment) map[string]int64 {
+	committed := make(map[string]int64)
+	for _, cc := range commitments {
+		if cc == nil || (cc.State != "ACTIVE" && cc.State != "PENDING") {
+			continue
+		}
+		committed[normalizeEdition(cc.Edition)] += cc.SlotCount
+	}
+	return committed
+}
+
+// reservationShortName returns the trailing reservation name of either a
+// resource name ("projects/p/locations/US/reservations/etl") or a jobs view
+// reservation_id ("p:US.etl").
+func reservationShortName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func normalizeEdition(edition string) string {
+	if edition == "" {
+		return "EDITION_UNSPECIFIED"
+	}
+	return strings.ToUpper(edition)
+}
+
+func isCommitmentEdition(edition string) bool {
+	return edition == EditionEnterprise || edition == EditionEnterprisePlus
+}
+
+// GetExistingCommitments lists the capacity commitments in the location.
+func (c *BigQueryClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	svc, err := c.resolveReservationService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commitments, err := c.listCapacityCommitments(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]common.Commitment, 0, len(commitments))
+	for _, cc := range commitments {
+		if cc == nil || cc.Name == "" {
+			continue
+		}
+		out = append(out, c.convertCapacityCommitment(cc))
+	}
+	return out, nil
+}
+
+// convertCapacityCommitment maps a Reservation API commitment to the common
+// shape. Count is the slot count and ResourceType the edition.
+func (c *BigQueryClient) convertCapacityCommitment(cc *bigqueryreservation.CapacityCommitment) common.Commitment {
+	com := common.Commitment{
+		Provider:       common.ProviderGCP,
+		Account:        c.projectID,
+		CommitmentID:   cc.Name,
+		CommitmentType: common.CommitmentCUD,
+		Service:        common.ServiceDataWarehouse,
+		Region:         c.region,
+		ResourceType:   normalizeEdition(cc.Edition),
+		Count:          int(cc.SlotCount),
+		State:          strings.ToLower(cc.State),
+	}
+	if t, err := time.Parse(time.RFC3339, cc.CommitmentStartTime); err == nil {
+		com.StartDate = t
+	}
+	if t, err := time.Parse(time.RFC3339, cc.CommitmentEndTime); err == nil {
+		com.EndDate = t
+	}
+	return com
+}
+
+// PurchaseCommitment creates a capacity commitment of rec.Count slots of the
+// rec.ResourceType edition.
+//
+// A non-empty opts.IdempotencyToken becomes the capacity commitment ID.
+// Commitment IDs are unique per project+location, so a re-drive of the same
+// execution is rejected with ALREADY_EXISTS instead of buying the slots a
+// second time. CapacityCommitment has no labels, so opts.Source is not
+// recorded on the commitment.
+func (c *BigQueryClient) PurchaseCommitment(ctx context.Context, rec common.Recommendation, opts common.PurchaseOptions) (common.PurchaseResult, error) {
+	result := common.PurchaseResult{
+		Recommendation: rec,
+		DryRun:         false,
+		Success:        false,
+		Timestamp:      time.Now(),
+	}
+
+	commitment, err := buildCapacityCommitment(rec)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	svc, err := c.resolveReservationService(ctx)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	created, err := svc.CreateCapacityCommitment(c.parent(), idempotentCommitmentID(opts.IdempotencyToken), commitment)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create capacity commitment: %w", err)
+		return result, result.Error
+	}
+
+	result.Success = true
+	result.CommitmentID = created.Name
+	result.Cost = rec.CommitmentCost
+	return result, nil
+}
+
+// buildCapacityCommitment validates rec and assembles the commitment to
+// create.
+func buildCapacityCommitment(rec common.Recommendation) (*bigqueryreservation.CapacityCommitment, error) {
+	if rec.Count <= 0 || rec.Count%slotIncrement != 0 {
+		return nil, fmt.Errorf("slot count must be a positive multiple of %d (got %d)", slotIncrement, rec.Count)
+	}
+	edition := normalizeEdition(rec.ResourceType)
+	if !isCommitmentEdition(edition) {
+		return nil, fmt.Errorf("BigQuery edition %q does not support capacity commitments", rec.ResourceType)
+	}
+	plan, err := termPlan(rec.Term)
+	if err != nil {
+		return nil, err
+	}
+	return &bigqueryreservation.CapacityCommitment{
+		SlotCount: int64(rec.Count),
+		Plan:      plan,
+		Edition:   edition,
+	}, nil
+}
+
+// idempotentCommitmentID derives a capacity commitment ID from an
+// idempotency token. An empty token lets the API generate the ID (the CLI
+// path, which has no owning execution). The token is a lowercase hex digest,
+// which already satisfies the lowercase-alphanumeric ID rule.
+func idempotentCommitmentID(token string) string {
+	if token == "" {
+		return ""
+	}
+	t := strings.ToLower(token)
+	if len(t) > idempotentIDTokenLen {
+		t = t[:idempotentIDTokenLen]
+	}
+	return "cudly-" + t
+}
+
+// ConvertCommitment moves an existing commitment to the longer plan for term
+// ("1yr" or "3yr"). The Reservation API only accepts conversions to a longer
+// commitment period and rejects anything else.
+func (c *BigQueryClient) ConvertCommitment(ctx context.Context, commitmentID, term string) (common.Commitment, error) {
+	plan, err := termPlan(term)
+	if err != nil {
+		return common.Commitment{}, err
+	}
+	return c.patchCommitment(ctx, commitmentID, "plan", &bigqueryreservation.CapacityCommitment{Plan: plan})
+}
+
+// RenewCommitment sets the plan a commitment renews into when its term ends:
+// "1yr", "3yr", or "none" to let it lapse.
+func (c *BigQueryClient) RenewCommitment(ctx context.Context, commitmentID, term string) (common.Commitment, error) {
+	plan := planNone
+	if !strings.EqualFold(strings.TrimSpace(term), "none") {
+		p, err := termPlan(term)
+		if err != nil {
+			return common.Commitment{}, err
+		}
+		plan = p
+	}
+	return c.patchCommitment(ctx, commitmentID, "renewalPlan", &bigqueryreservation.CapacityCommitment{RenewalPlan: plan})
+}
+
+func (c *BigQueryClient) patchCommitment(ctx context.Context, commitmentID, mask string, patch *bigqueryreservation.CapacityCommitment) (common.Commitment, error) {
+	if commitmentID == "" {
+		return common.Commitment{}, fmt.Errorf("commitment ID must not be empty")
+	}
+	svc, err := c.resolveReservationService(ctx)
+	if err != nil {
+		return common.Commitment{}, err
+	}
+	updated, err := svc.PatchCapacityCommitment(c.commitmentName(commitmentID), mask, patch)
+	if err != nil {
+		return common.Commitment{}, fmt.Errorf("failed to update capacity commitment %s: %w", commitmentID, err)
+	}
+	return c.convertCapacityCommitment(updated), nil
+}
+
+// ValidateOffering validates the edition, slot count and term of rec
+func (c *BigQueryClient) ValidateOffering(_ context.Context, rec common.Recommendation) error {
+	_, err := buildCapacityCommitment(rec)
+	return err
+}
+
+// GetOfferingDetails prices a slot commitment from the Cloud Billing catalog.
+// Editions commitments are billed monthly, so nothing is paid upfront.
+func (c *BigQueryClient) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	if _, err := buildCapacityCommitment(rec); err != nil {
+		return nil, err
+	}
+	termYears := termYearsFromLabel(rec.Term)
+	pricing, err := c.getSlotPricing(ctx, normalizeEdition(rec.ResourceType), termYears)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+
+	slots := float64(rec.Count)
+	total := pricing.CommitmentPrice * slots
+	return &common.OfferingDetails{
+		OfferingID:          fmt.Sprintf("gcp-bigquery-%s-%s-%s", strings.ToLower(rec.ResourceType), c.region, rec.Term),
+		ResourceType:        rec.ResourceType,
+		Term:                rec.Term,
+		PaymentOption:       "monthly",
+		UpfrontCost:         0,
+		RecurringCost:       total / (float64(termYears) * 12),
+		TotalCost:           total,
+		EffectiveHourlyRate: pricing.HourlyRate * slots,
+		Currency:            pricing.Currency,
+	}, nil
+}
+
+// GetValidResourceTypes returns the editions that accept capacity commitments
+func (c *BigQueryClient) GetValidResourceTypes(_ context.Context) ([]string, error) {
+	return []string{EditionEnterprise, EditionEnterprisePlus}, nil
+}
+
+// SlotPricing contains per-slot pricing for a BigQuery edition
+type SlotPricing struct {
+	HourlyRate        float64 // committed price per slot-hour
+	CommitmentPrice   float64 // committed price per slot over the term
+	OnDemandPrice     float64 // pay-as-you-go price per slot over the term
+	Currency          string
+	SavingsPercentage float64
+}
+
+// getSlotPricing reads the pay-as-you-go and committed slot-hour prices for
+// edition from the billing catalog. Like Cloud SQL, it errors rather than
+// inventing a discount when the catalog has no commitment SKU.
+func (c *BigQueryClient) getSlotPricing(ctx context.Context, edition string, termYears int) (*SlotPricing, error) {
+	svc, err := c.getOrCreateBillingService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceName, err := findBillingService(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	skus, err := listSKUs(ctx, svc, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	onDemand, commitment, currency := extractSlotPricingFromSKUs(skus, edition, c.region, termYears)
+	if onDemand == 0 {
+		return nil, fmt.Errorf("no pay-as-you-go pricing found for BigQuery %s in %s", edition, c.region)
+	}
+	if commitment == 0 {
+		return nil, fmt.Errorf("no %d-year commitment pricing found for BigQuery %s in %s", termYears, edition, c.region)
+	}
+
+	hours := 8760.0 * float64(termYears)
+	return &SlotPricing{
+		HourlyRate:        commitment,
+		CommitmentPrice:   commitment * hours,
+		OnDemandPrice:     onDemand * hours,
+		Currency:          currency,
+		SavingsPercentage: (onDemand - commitment) / onDemand * 100,
+	}, nil
+}
+
+// findBillingService resolves the catalog service name ("services/XXXX")
+// of the BigQuery Reservation API by display name.
+func findBillingService(ctx context.Context, svc BillingService) (string, error) {
+	token := ""
+	for page := 0; page < maxListPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		resp, err := svc.ListServices(token)
+		if err != nil {
+			return "", fmt.Errorf("failed to list billing services: %w", err)
+		}
+		for _, s := range resp.Services {
+			if s != nil && s.DisplayName == reservationBillingService {
+				return s.Name, nil
+			}
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		token = resp.NextPageToken
+	}
+	return "", fmt.Errorf("billing catalog has no %q service", reservationBillingService)
+}
+
+func listSKUs(ctx context.Context, svc BillingService, serviceName string) ([]*cloudbilling.Sku, error) {
+	var (
+		out   []*cloudbilling.Sku
+		token string
+	)
+	for page := 0; page < maxListPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		resp, err := svc.ListSKUs(serviceName, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SKUs: %w", err)
+		}
+		out = append(out, resp.Skus...)
+		if resp.NextPageToken == "" {
+			return out, nil
+		}
+		token = resp.NextPageToken
+	}
+	return nil, fmt.Errorf("bigquery: ListSKUs page cap (%d) reached", maxListPages)
+}
+
+// extractSlotPricingFromSKUs picks the edition's pay-as-you-go and
+// termYears commitment slot-hour prices for region. Edition SKUs are named
+// after the edition ("Enterprise Edition", "Enterprise Plus Edition");
+// commitment SKUs additionally mention the commitment and its term.
+func extractSlotPricingFromSKUs(skus []*cloudbilling.Sku, edition, region string, termYears int) (onDemand, commitment float64, currency string) {
+	currency = "USD"
+	name := editionDisplayName(edition)
+	for _, sku := range skus {
+		if sku == nil || !skuInRegion(sku, region) {
+			continue
+		}
+		desc := strings.ToLower(sku.Description)
+		if !strings.Contains(desc, name) {
+			continue
+		}
+		price, curr := skuUnitPrice(sku)
+		if price == 0 {
+			continue
+		}
+		if !strings.Contains(desc, "commit") {
+			onDemand, currency = price, currencyOr(curr, currency)
+			continue
+		}
+		if skuTermYears(desc) == termYears {
+			commitment, currency = price, currencyOr(curr, currency)
+		}
+	}
+	return onDemand, commitment, currency
+}
+
+func editionDisplayName(edition string) string {
+	if edition == EditionEnterprisePlus {
+		return "enterprise plus edition"
+	}
+	return strings.ToLower(edition) + " edition"
+}
+
+// skuTermYears reads the commitment term from a lowercased SKU description.
+func skuTermYears(desc string) int {
+	switch {
+	case strings.Contains(desc, "3 year"), strings.Contains(desc, "3yr"), strings.Contains(desc, "three year"):
+		return 3
+	case strings.Contains(desc, "1 year"), strings.Contains(desc, "1yr"), strings.Contains(desc, "annual"):
+		return 1
+	}
+	return 0
+}
+
+func skuInRegion(sku *cloudbilling.Sku, region string) bool {
+	if len(sku.ServiceRegions) == 0 {
+		return true
+	}
+	for _, r := range sku.ServiceRegions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// skuUnitPrice extracts the first tier unit price from a SKU
+func skuUnitPrice(sku *cloudbilling.Sku) (float64, string) {
+	if len(sku.PricingInfo) == 0 {
+		return 0, ""
+	}
+	expr := sku.PricingInfo[0].PricingExpression
+	if expr == nil || len(expr.TieredRates) == 0 {
+		return 0, ""
+	}
+	// Tier 0 is often a free tier at price 0; take the first priced tier.
+	for _, rate := range expr.TieredRates {
+		if rate.UnitPrice == nil {
+			continue
+		}
+		price := float64(rate.UnitPrice.Units) + float64(rate.UnitPrice.Nanos)/1e9
+		if price > 0 {
+			return price, rate.UnitPrice.CurrencyCode
+		}
+	}
+	return 0, ""
+}
+
+func currencyOr(curr, fallback string) string {
+	if curr == "" {
+		return fallback
+	}
+	return curr
+}
+
+// fillSlotPricing prices rec from the billing catalog. Costs are term totals
+// like the other GCP clients; EstimatedSavings is monthly. Pricing failures
+// are logged and do not discard the recommendation.
+func (c *BigQueryClient) fillSlotPricing(ctx context.Context, rec *common.Recommendation) {
+	termYears := termYearsFromLabel(rec.Term)
+	pricing, err := c.getSlotPricing(ctx, rec.ResourceType, termYears)
+	if err != nil {
+		log.Printf("bigquery: pricing unavailable for %s in %s: %v", rec.ResourceType, c.region, err)
+		return
+	}
+	slots := float64(rec.Count)
+	months := float64(termYears * 12)
+	rec.CommitmentCost = pricing.CommitmentPrice * slots
+	rec.OnDemandCost = pricing.OnDemandPrice * slots
+	rec.SavingsPercentage = pricing.SavingsPercentage
+	rec.EstimatedSavings = (rec.OnDemandCost - rec.CommitmentCost) / months
+	recurring := pricing.HourlyRate * hoursPerMonth * slots
+	rec.RecurringMonthlyCost = &recurring
+}
+
+// termPlan maps a term label to a capacity commitment plan.
+//
+// Accepted forms:
+//   - 1-year: "1yr", "1", "12mo"
+//   - 3-year: "3yr", "3", "36mo"
+func termPlan(term string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(term)) {
+	case "3yr", "3", "36mo":
+		return planThreeYear, nil
+	case "1yr", "1", "12mo":
+		return planAnnual, nil
+	default:
+		return "", fmt.Errorf("unrecognized commitment term %q (accepted: 1yr/1/12mo or 3yr/3/36mo)", term)
+	}
+}
+
+// termYearsFromLabel converts a term string such as "1yr" or "3yr" to an
+// integer number of years (defaults to 1 for any unrecognized value).
+func termYearsFromLabel(term string) int {
+	if plan, _ := termPlan(term); plan == planThreeYear {
+		return 3
+	}
+	return 1
+}
+
+// lookbackDays parses a "30d"-style lookback period, defaulting to 30 days.
+func lookbackDays(period string) (int, error) {
+	if period == "" {
+		return 30, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+	if err != nil || days <= 0 {
+		return 0, fmt.Errorf("invalid lookback period %q", period)
+	}
+	return days, nil
+}
@@ -0,0 +1,512 @@
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/bigqueryreservation/v1"
+	"google.golang.org/api/cloudbilling/v1"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// MockReservationService mocks the ReservationService interface. Commitment
+// pages are served in order, one per call.
+type MockReservationService struct {
+	reservations     []*bigqueryreservation.Reservation
+	commitmentPages  [][]*bigqueryreservation.CapacityCommitment
+	err              error
+	createdParent    string
+	createdID        string
+	created          *bigqueryreservation.CapacityCommitment
+	patchedName      string
+	patchedMask      string
+	patched          *bigqueryreservation.CapacityCommitment
+	commitmentTokens []string
+}
+
+func (m *MockReservationService) ListCapacityCommitments(parent, pageToken string) (*bigqueryreservation.ListCapacityCommitmentsResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.commitmentTokens = append(m.commitmentTokens, pageToken)
+	page := len(m.commitmentTokens) - 1
+	resp := &bigqueryreservation.ListCapacityCommitmentsResponse{}
+	if page < len(m.commitmentPages) {
+		resp.CapacityCommitments = m.commitmentPages[page]
+	}
+	if page+1 < len(m.commitmentPages) {
+		resp.NextPageToken = "next"
+	}
+	return resp, nil
+}
+
+func (m *MockReservationService) CreateCapacityCommitment(parent, commitmentID string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error) {
+	m.createdParent, m.createdID, m.created = parent, commitmentID, commitment
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := *commitment
+	out.Name = parent + "/capacityCommitments/" + commitmentID
+	out.State = "PENDING"
+	return &out, nil
+}
+
+func (m *MockReservationService) PatchCapacityCommitment(name, updateMask string, commitment *bigqueryreservation.CapacityCommitment) (*bigqueryreservation.CapacityCommitment, error) {
+	m.patchedName, m.patchedMask, m.patched = name, updateMask, commitment
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := *commitment
+	out.Name = name
+	out.State = "ACTIVE"
+	return &out, nil
+}
+
+func (m *MockReservationService) ListReservations(parent, pageToken string) (*bigqueryreservation.ListReservationsResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &bigqueryreservation.ListReservationsResponse{Reservations: m.reservations}, nil
+}
+
+// MockJobsService mocks the JobsService interface
+type MockJobsService struct {
+	resp    *bq.QueryResponse
+	more    []*bq.GetQueryResultsResponse
+	err     error
+	query   *bq.QueryRequest
+	called  bool
+	fetches []string
+}
+
+func (m *MockJobsService) Query(projectID string, req *bq.QueryRequest) (*bq.QueryResponse, error) {
+	m.called, m.query = true, req
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.resp, nil
+}
+
+func (m *MockJobsService) GetQueryResults(projectID, jobID, location, pageToken string) (*bq.GetQueryResultsResponse, error) {
+	m.fetches = append(m.fetches, pageToken)
+	if len(m.fetches) > len(m.more) {
+		return nil, errors.New("unexpected getQueryResults call")
+	}
+	return m.more[len(m.fetches)-1], nil
+}
+
+// MockBillingService mocks the BillingService interface
+type MockBillingService struct {
+	skus []*cloudbilling.Sku
+	err  error
+}
+
+func (m *MockBillingService) ListServices(pageToken string) (*cloudbilling.ListServicesResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &cloudbilling.ListServicesResponse{Services: []*cloudbilling.Service{
+		{Name: "services/24E6-581D-38E5", DisplayName: "BigQuery"},
+		{Name: "services/RESV", DisplayName: reservationBillingService},
+	}}, nil
+}
+
+func (m *MockBillingService) ListSKUs(serviceName, pageToken string) (*cloudbilling.ListSkusResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if serviceName != "services/RESV" {
+		return nil, errors.New("wrong billing service " + serviceName)
+	}
+	return &cloudbilling.ListSkusResponse{Skus: m.skus}, nil
+}
+
+func slotSKU(desc, region string, price float64) *cloudbilling.Sku {
+	units := int64(price)
+	return &cloudbilling.Sku{
+		Description:    desc,
+		ServiceRegions: []string{region},
+		PricingInfo: []*cloudbilling.PricingInfo{{
+			PricingExpression: &cloudbilling.PricingExpression{
+				TieredRates: []*cloudbilling.TierRate{{
+					UnitPrice: &cloudbilling.Money{
+						CurrencyCode: "USD",
+						Units:        units,
+						Nanos:        int64(math.Round((price - float64(units)) * 1e9)),
+					},
+				}},
+			},
+		}},
+	}
+}
+
+func editionSKUs() []*cloudbilling.Sku {
+	return []*cloudbilling.Sku{
+		slotSKU("Enterprise Edition for us-central1", "us-central1", 0.06),
+		slotSKU("Enterprise Edition - 1 Year Commitment for us-central1", "us-central1", 0.048),
+		slotSKU("Enterprise Edition - 3 Year Commitment for us-central1", "us-central1", 0.036),
+		slotSKU("Enterprise Plus Edition for us-central1", "us-central1", 0.10),
+		slotSKU("Enterprise Plus Edition - 1 Year Commitment for us-central1", "us-central1", 0.08),
+		slotSKU("Enterprise Edition for europe-west1", "europe-west1", 0.066),
+	}
+}
+
+func usageRow(reservationID, day, slots string) *bq.TableRow {
+	return &bq.TableRow{F: []*bq.TableCell{{V: reservationID}, {V: day}, {V: slots}}}
+}
+
+func newTestClient(res *MockReservationService, jobs *MockJobsService) *BigQueryClient {
+	c, _ := NewClient(context.Background(), "proj", "us-central1")
+	c.SetReservationService(res)
+	c.SetJobsService(jobs)
+	c.SetBillingService(&MockBillingService{skus: editionSKUs()})
+	return c
+}
+
+func TestBigQueryClient_ServiceIdentity(t *testing.T) {
+	c, err := NewClient(context.Background(), "proj", "US")
+	require.NoError(t, err)
+	assert.Equal(t, common.ServiceDataWarehouse, c.GetServiceType())
+	assert.Equal(t, "US", c.GetRegion())
+
+	types, err := c.GetValidResourceTypes(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{EditionEnterprise, EditionEnterprisePlus}, types)
+}
+
+func TestBigQueryClient_GetRecommendations(t *testing.T) {
+	res := &MockReservationService{
+		reservations: []*bigqueryreservation.Reservation{
+			{Name: "projects/proj/locations/us-central1/reservations/etl", SlotCapacity: 100, Edition: "ENTERPRISE"},
+			{Name: "projects/proj/locations/us-central1/reservations/bi", SlotCapacity: 0, Edition: "ENTERPRISE"},
+			{Name: "projects/proj/locations/us-central1/reservations/adhoc", SlotCapacity: 500, Edition: "STANDARD"},
+		},
+		commitmentPages: [][]*bigqueryreservation.CapacityCommitment{{
+			{Name: "c1", SlotCount: 50, Edition: "ENTERPRISE", State: "ACTIVE"},
+			{Name: "c2", SlotCount: 1000, Edition: "ENTERPRISE", State: "FAILED"},
+		}},
+	}
+	jobs := &MockJobsService{resp: &bq.QueryResponse{
+		JobComplete: true,
+		Rows: []*bq.TableRow{
+			usageRow("proj:us-central1.bi", "2026-10-14", "140.5"),
+			usageRow("proj:us-central1.bi", "2026-10-15", "130.2"),
+			usageRow("proj:us-central1.etl", "2026-10-14", "20"),
+		},
+	}}
+	c := newTestClient(res, jobs)
+
+	recs, err := c.GetRecommendations(context.Background(), &common.RecommendationParams{LookbackPeriod: "2d"})
+	require.NoError(t, err)
+
+	// ENTERPRISE demand: etl baseline 100 (usage below baseline, and only
+	// one of two days) + bi lowest daily average 130 = 230, minus the 50
+	// active slots = 180, rounded down to 150. STANDARD has no commitments.
+	require.Len(t, recs, 1)
+	rec := recs[0]
+	assert.Equal(t, common.ProviderGCP, rec.Provider)
+	assert.Equal(t, common.ServiceDataWarehouse, rec.Service)
+	assert.Equal(t, common.CommitmentCUD, rec.CommitmentType)
+	assert.Equal(t, EditionEnterprise, rec.ResourceType)
+	assert.Equal(t, 150, rec.Count)
+	assert.Equal(t, "1yr", rec.Term)
+	assert.Equal(t, &common.DataWarehouseDetails{NodeType: EditionEnterprise, NumberOfNodes: 150}, rec.Details)
+
+	assert.InDelta(t, 0.048*8760*150, rec.CommitmentCost, 0.01)
+	assert.InDelta(t, 0.06*8760*150, rec.OnDemandCost, 0.01)
+	assert.InDelta(t, 20.0, rec.SavingsPercentage, 0.01)
+	assert.InDelta(t, (0.06-0.048)*8760*150/12, rec.EstimatedSavings, 0.01)
+	require.NotNil(t, rec.RecurringMonthlyCost)
+	assert.InDelta(t, 0.048*730*150, *rec.RecurringMonthlyCost, 0.01)
+
+	require.NotNil(t, jobs.query)
+	assert.Contains(t, jobs.query.Query, "`region-us-central1`.INFORMATION_SCHEMA.JOBS_TIMELINE_BY_PROJECT")
+	assert.Equal(t, "us-central1", jobs.query.Location)
+	require.Len(t, jobs.query.QueryParameters, 1)
+	assert.Equal(t, "2", jobs.query.QueryParameters[0].ParameterValue.Value)
+	require.NotNil(t, jobs.query.UseLegacySql)
+	assert.False(t, *jobs.query.UseLegacySql)
+}
+
+func TestBigQueryClient_GetRecommendations_NoReservations(t *testing.T) {
+	jobs := &MockJobsService{}
+	c := newTestClient(&MockReservationService{}, jobs)
+
+	recs, err := c.GetRecommendations(context.Background(), &common.RecommendationParams{})
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+	assert.False(t, jobs.called, "jobs query must be skipped without reservations")
+}
+
+func TestBigQueryClient_GetRecommendations_PricingUnavailable(t *testing.T) {
+	res := &MockReservationService{reservations: []*bigqueryreservation.Reservation{
+		{Name: "r", SlotCapacity: 100, Edition: "ENTERPRISE_PLUS"},
+	}}
+	c := newTestClient(res, &MockJobsService{resp: &bq.QueryResponse{JobComplete: true}})
+	c.SetBillingService(&MockBillingService{err: errors.New("billing disabled")})
+
+	recs, err := c.GetRecommendations(context.Background(), &common.RecommendationParams{Term: "3yr"})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, 100, recs[0].Count)
+	assert.Equal(t, "3yr", recs[0].Term)
+	assert.Zero(t, recs[0].CommitmentCost)
+	assert.Nil(t, recs[0].RecurringMonthlyCost)
+}
+
+func TestBigQueryClient_GetRecommendations_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *common.RecommendationParams
+		res    *MockReservationService
+		jobs   *MockJobsService
+		want   string
+	}{
+		{name: "nil params", want: "params cannot be nil"},
+		{name: "bad term", params: &common.RecommendationParams{Term: "5yr"}, want: "unrecognized commitment term"},
+		{name: "bad lookback", params: &common.RecommendationParams{LookbackPeriod: "xd"}, want: "invalid lookback period"},
+		{
+			name:   "reservation error",
+			params: &common.RecommendationParams{},
+			res:    &MockReservationService{err: errors.New("denied")},
+			want:   "failed to list reservations: denied",
+		},
+		{
+			name:   "query error",
+			params: &common.RecommendationParams{},
+			res:    &MockReservationService{reservations: []*bigqueryreservation.Reservation{{Name: "r", Edition: "ENTERPRISE"}}},
+			jobs:   &MockJobsService{err: errors.New("no access")},
+			want:   "failed to query job statistics: no access",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.res
+			if res == nil {
+				res = &MockReservationService{}
+			}
+			jobs := tt.jobs
+			if jobs == nil {
+				jobs = &MockJobsService{}
+			}
+			_, err := newTestClient(res, jobs).GetRecommendations(context.Background(), tt.params)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestBigQueryClient_DailyReservationSlots_PollsAndPages(t *testing.T) {
+	jobs := &MockJobsService{
+		resp: &bq.QueryResponse{JobComplete: false, JobReference: &bq.JobReference{JobId: "job-1", Location: "US"}},
+		more: []*bq.GetQueryResultsResponse{
+			{JobComplete: true, Rows: []*bq.TableRow{usageRow("p:US.etl", "d1", "10")}, PageToken: "p2"},
+			{JobComplete: true, Rows: []*bq.TableRow{usageRow("p:US.etl", "d2", "12"), {F: []*bq.TableCell{{V: "short"}}}}},
+		},
+	}
+	c, _ := NewClient(context.Background(), "proj", "US")
+	c.SetJobsService(jobs)
+
+	usage, err := c.dailyReservationSlots(context.Background(), 30)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float64{"etl": {10, 12}}, usage)
+	assert.Equal(t, []string{"", "p2"}, jobs.fetches)
+	assert.Contains(t, jobs.query.Query, "`region-us`")
+}
+
+func TestBigQueryClient_DailyReservationSlots_RejectsBadLocation(t *testing.T) {
+	jobs := &MockJobsService{}
+	c, _ := NewClient(context.Background(), "proj", "us`; DROP")
+	c.SetJobsService(jobs)
+
+	_, err := c.dailyReservationSlots(context.Background(), 30)
+	assert.ErrorContains(t, err, "invalid BigQuery location")
+	assert.False(t, jobs.called)
+}
+
+func TestBigQueryClient_GetExistingCommitments(t *testing.T) {
+	res := &MockReservationService{commitmentPages: [][]*bigqueryreservation.CapacityCommitment{
+		{{
+			Name:                "projects/proj/locations/us-central1/capacityCommitments/c1",
+			SlotCount:           100,
+			Plan:                "ANNUAL",
+			State:               "ACTIVE",
+			Edition:             "ENTERPRISE",
+			CommitmentStartTime: "2026-01-01T00:00:00Z",
+			CommitmentEndTime:   "2027-01-01T00:00:00Z",
+		}},
+		{{Name: ""}, {Name: "projects/proj/locations/us-central1/capacityCommitments/c2", SlotCount: 50, State: "PENDING"}},
+	}}
+	c := newTestClient(res, nil)
+
+	got, err := c.GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, []string{"", "next"}, res.commitmentTokens)
+
+	assert.Equal(t, "projects/proj/locations/us-central1/capacityCommitments/c1", got[0].CommitmentID)
+	assert.Equal(t, common.CommitmentCUD, got[0].CommitmentType)
+	assert.Equal(t, common.ServiceDataWarehouse, got[0].Service)
+	assert.Equal(t, "proj", got[0].Account)
+	assert.Equal(t, EditionEnterprise, got[0].ResourceType)
+	assert.Equal(t, 100, got[0].Count)
+	assert.Equal(t, "active", got[0].State)
+	assert.Equal(t, 2026, got[0].StartDate.Year())
+	assert.Equal(t, 2027, got[0].EndDate.Year())
+
+	assert.Equal(t, "EDITION_UNSPECIFIED", got[1].ResourceType)
+	assert.Equal(t, "pending", got[1].State)
+	assert.True(t, got[1].EndDate.IsZero())
+}
+
+func TestBigQueryClient_PurchaseCommitment(t *testing.T) {
+	res := &MockReservationService{}
+	c := newTestClient(res, nil)
+	rec := common.Recommendation{ResourceType: "ENTERPRISE", Count: 150, Term: "3yr", CommitmentCost: 42}
+
+	result, err := c.PurchaseCommitment(context.Background(), rec, common.PurchaseOptions{
+		IdempotencyToken: "ABCDEF0123456789abcdef0123456789abcdef0123456789",
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 42.0, result.Cost)
+
+	assert.Equal(t, "projects/proj/locations/us-central1", res.createdParent)
+	assert.Equal(t, "cudly-abcdef0123456789abcdef0123456789abcdef01", res.createdID)
+	assert.Equal(t, &bigqueryreservation.CapacityCommitment{SlotCount: 150, Plan: "THREE_YEAR", Edition: "ENTERPRISE"}, res.created)
+	assert.Equal(t, "projects/proj/locations/us-central1/capacityCommitments/"+res.createdID, result.CommitmentID)
+}
+
+func TestBigQueryClient_PurchaseCommitment_NoTokenLetsAPINameIt(t *testing.T) {
+	res := &MockReservationService{}
+	c := newTestClient(res, nil)
+
+	_, err := c.PurchaseCommitment(context.Background(), common.Recommendation{ResourceType: "ENTERPRISE_PLUS", Count: 50, Term: "1yr"}, common.PurchaseOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, res.createdID)
+	assert.Equal(t, "ANNUAL", res.created.Plan)
+}
+
+func TestBigQueryClient_PurchaseCommitment_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  common.Recommendation
+		want string
+	}{
+		{name: "zero slots", rec: common.Recommendation{ResourceType: "ENTERPRISE", Term: "1yr"}, want: "positive multiple of 50"},
+		{name: "odd slots", rec: common.Recommendation{ResourceType: "ENTERPRISE", Count: 75, Term: "1yr"}, want: "positive multiple of 50"},
+		{name: "standard edition", rec: common.Recommendation{ResourceType: "STANDARD", Count: 100, Term: "1yr"}, want: "does not support capacity commitments"},
+		{name: "bad term", rec: common.Recommendation{ResourceType: "ENTERPRISE", Count: 100, Term: "2yr"}, want: "unrecognized commitment term"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &MockReservationService{}
+			c := newTestClient(res, nil)
+			result, err := c.PurchaseCommitment(context.Background(), tt.rec, common.PurchaseOptions{})
+			assert.ErrorContains(t, err, tt.want)
+			assert.False(t, result.Success)
+			assert.Nil(t, res.created, "nothing may be created for an invalid recommendation")
+			assert.ErrorContains(t, c.ValidateOffering(context.Background(), tt.rec), tt.want)
+		})
+	}
+}
+
+func TestBigQueryClient_PurchaseCommitment_APIError(t *testing.T) {
+	c := newTestClient(&MockReservationService{err: errors.New("quota")}, nil)
+	result, err := c.PurchaseCommitment(context.Background(), common.Recommendation{ResourceType: "ENTERPRISE", Count: 50, Term: "1yr"}, common.PurchaseOptions{})
+	assert.ErrorContains(t, err, "failed to create capacity commitment: quota")
+	assert.Equal(t, err, result.Error)
+}
+
+func TestBigQueryClient_ConvertCommitment(t *testing.T) {
+	res := &MockReservationService{}
+	c := newTestClient(res, nil)
+
+	got, err := c.ConvertCommitment(context.Background(), "c1", "3yr")
+	require.NoError(t, err)
+	assert.Equal(t, "projects/proj/locations/us-central1/capacityCommitments/c1", res.patchedName)
+	assert.Equal(t, "plan", res.patchedMask)
+	assert.Equal(t, &bigqueryreservation.CapacityCommitment{Plan: "THREE_YEAR"}, res.patched)
+	assert.Equal(t, res.patchedName, got.CommitmentID)
+
+	_, err = c.ConvertCommitment(context.Background(), "c1", "flex")
+	assert.ErrorContains(t, err, "unrecognized commitment term")
+	_, err = c.ConvertCommitment(context.Background(), "", "1yr")
+	assert.ErrorContains(t, err, "commitment ID must not be empty")
+}
+
+func TestBigQueryClient_RenewCommitment(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{term: "1yr", want: "ANNUAL"},
+		{term: "3yr", want: "THREE_YEAR"},
+		{term: "none", want: "NONE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			res := &MockReservationService{}
+			c := newTestClient(res, nil)
+			name := "projects/proj/locations/us-central1/capacityCommitments/c9"
+
+			_, err := c.RenewCommitment(context.Background(), name, tt.term)
+			require.NoError(t, err)
+			assert.Equal(t, name, res.patchedName, "full names pass through unchanged")
+			assert.Equal(t, "renewalPlan", res.patchedMask)
+			assert.Equal(t, tt.want, res.patched.RenewalPlan)
+		})
+	}
+
+	c := newTestClient(&MockReservationService{err: errors.New("boom")}, nil)
+	_, err := c.RenewCommitment(context.Background(), "c1", "1yr")
+	assert.ErrorContains(t, err, "failed to update capacity commitment c1: boom")
+}
+
+func TestBigQueryClient_GetOfferingDetails(t *testing.T) {
+	c := newTestClient(&MockReservationService{}, nil)
+
+	got, err := c.GetOfferingDetails(context.Background(), common.Recommendation{ResourceType: "ENTERPRISE_PLUS", Count: 100, Term: "1yr"})
+	require.NoError(t, err)
+	assert.Equal(t, "gcp-bigquery-enterprise_plus-us-central1-1yr", got.OfferingID)
+	assert.Equal(t, "monthly", got.PaymentOption)
+	assert.Zero(t, got.UpfrontCost)
+	assert.InDelta(t, 0.08*8760*100, got.TotalCost, 0.01)
+	assert.InDelta(t, got.TotalCost/12, got.RecurringCost, 0.01)
+	assert.InDelta(t, 8.0, got.EffectiveHourlyRate, 0.0001)
+	assert.Equal(t, "USD", got.Currency)
+
+	// The catalog has no 3-year Enterprise Plus SKU.
+	_, err = c.GetOfferingDetails(context.Background(), common.Recommendation{ResourceType: "ENTERPRISE_PLUS", Count: 100, Term: "3yr"})
+	assert.ErrorContains(t, err, "no 3-year commitment pricing")
+}
+
+func TestExtractSlotPricingFromSKUs(t *testing.T) {
+	skus := editionSKUs()
+
+	onDemand, commit, currency := extractSlotPricingFromSKUs(skus, EditionEnterprise, "us-central1", 3)
+	assert.InDelta(t, 0.06, onDemand, 1e-9, "Enterprise Plus SKUs must not match Enterprise")
+	assert.InDelta(t, 0.036, commit, 1e-9)
+	assert.Equal(t, "USD", currency)
+
+	onDemand, commit, _ = extractSlotPricingFromSKUs(skus, EditionEnterprise, "europe-west1", 1)
+	assert.InDelta(t, 0.066, onDemand, 1e-9)
+	assert.Zero(t, commit)
+}
+
+func TestSlotDemand(t *testing.T) {
+	reservations := []*bigqueryreservation.Reservation{
+		{Name: "projects/p/locations/US/reservations/a", SlotCapacity: 100, Edition: "ENTERPRISE"},
+		{Name: "projects/p/locations/US/reservations/b", SlotCapacity: 0, Edition: "enterprise"},
+		nil,
+	}
+	usage := map[string][]float64{"a": {300, 250, 400}, "b": {80, 90}}
+
+	// a: lowest daily 250 beats baseline 100; b: only 2 of 3 days -> baseline 0.
+	assert.Equal(t, map[string]int64{"ENTERPRISE": 250}, slotDemand(reservations, usage, 3))
+}
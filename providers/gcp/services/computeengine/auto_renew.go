@@ -0,0 +1,203 @@
+package computeengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultAutoRenewMinCoveragePct is the AutoRenewPolicy.MinCoveragePct used
+// when the policy leaves it zero.
+const DefaultAutoRenewMinCoveragePct = 90.0
+
+// AutoRenewPolicy decides which commitments GCP should renew when they end.
+// A commitment renews when the usage forecast for its expiry, less what the
+// other commitments of its type still in force at that moment cover, fills at
+// least MinCoveragePct of both its vCPUs and its memory. Otherwise renewal is
+// turned off and the commitment lapses into on-demand pricing.
+type AutoRenewPolicy struct {
+	// Horizon limits decisions to commitments ending within Horizon of now;
+	// later commitments keep their current setting. Zero decides every
+	// active commitment.
+	Horizon time.Duration
+	// MinCoveragePct is in [0, 100]. Zero selects
+	// DefaultAutoRenewMinCoveragePct.
+	MinCoveragePct float64
+}
+
+// Validate rejects a negative horizon or a coverage outside [0, 100].
+func (p AutoRenewPolicy) Validate() error {
+	if p.Horizon < 0 {
+		return fmt.Errorf("auto-renew horizon must not be negative, got %s", p.Horizon)
+	}
+	if math.IsNaN(p.MinCoveragePct) || p.MinCoveragePct < 0 || p.MinCoveragePct > 100 {
+		return fmt.Errorf("auto-renew min coverage must be in [0, 100], got %g", p.MinCoveragePct)
+	}
+	return nil
+}
+
+func (p AutoRenewPolicy) minCoveragePct() float64 {
+	if p.MinCoveragePct == 0 {
+		return DefaultAutoRenewMinCoveragePct
+	}
+	return p.MinCoveragePct
+}
+
+// AutoRenewDecision is the policy's verdict on one commitment.
+type AutoRenewDecision struct {
+	Name string    `json:"name"`
+	Type string    `json:"type"`
+	End  time.Time `json:"end"`
+	// CurrentAutoRenew is the commitment's setting before the decision.
+	CurrentAutoRenew bool `json:"current_auto_renew"`
+	AutoRenew        bool `json:"auto_renew"`
+	// CoveragePct is the share of the commitment the forecast usage fills at
+	// expiry, capped at 100.
+	CoveragePct float64 `json:"coverage_pct"`
+	Reason      string  `json:"reason"`
+	// Applied is set by ApplyAutoRenewPolicy once a changed setting has been
+	// written to GCP.
+	Applied bool `json:"applied"`
+}
+
+// Changed reports whether the decision flips the commitment's setting.
+func (d AutoRenewDecision) Changed() bool {
+	return d.AutoRenew != d.CurrentAutoRenew
+}
+
+// DecideAutoRenew applies policy to the active commitments. forecast is the
+// usage expected at expiry per commitment type name, as GetCommittableUsage
+// reports it; a type missing from forecast is forecast as unused.
+//
+// Commitments are decided in expiry order, so a commitment that lapses
+// earlier and is set to renew counts as in force for the ones after it.
+// Commitments outside the horizon are not decided but still count toward
+// coverage with their current setting.
+func DecideAutoRenew(commitments []ResourceCommitmentInfo, forecast map[string]CommittableUsage, policy AutoRenewPolicy, now time.Time) []AutoRenewDecision {
+	active := make([]ResourceCommitmentInfo, 0, len(commitments))
+	for _, info := range commitments {
+		if info.Status == "active" && !info.End.IsZero() {
+			active = append(active, info)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].End.Equal(active[j].End) {
+			return active[i].End.Before(active[j].End)
+		}
+		return active[i].Name < active[j].Name
+	})
+
+	renews := make(map[string]bool, len(active))
+	for _, info := range active {
+		renews[info.Name] = info.AutoRenew
+	}
+
+	decisions := make([]AutoRenewDecision, 0, len(active))
+	for i, info := range active {
+		if policy.Horizon > 0 && info.End.After(now.Add(policy.Horizon)) {
+			break
+		}
+		inForce := usageInForceAt(active, i, renews)
+		coverage := autoRenewCoverage(info, forecast[info.Type], inForce)
+		d := AutoRenewDecision{
+			Name:             info.Name,
+			Type:             info.Type,
+			End:              info.End,
+			CurrentAutoRenew: info.AutoRenew,
+			AutoRenew:        coverage >= policy.minCoveragePct(),
+			CoveragePct:      coverage,
+		}
+		if d.AutoRenew {
+			d.Reason = fmt.Sprintf("forecast usage fills %.0f%% of the commitment at expiry", coverage)
+		} else {
+			d.Reason = fmt.Sprintf("forecast usage fills only %.0f%% of the commitment at expiry (renewal needs %.0f%%)", coverage, policy.minCoveragePct())
+		}
+		renews[info.Name] = d.AutoRenew
+		decisions = append(decisions, d)
+	}
+	return decisions
+}
+
+// usageInForceAt sums the resources of the commitments of active[idx]'s type,
+// other than active[idx] itself, that are still in force when it ends: those
+// ending later and those ending no later that renew.
+func usageInForceAt(active []ResourceCommitmentInfo, idx int, renews map[string]bool) CommittableUsage {
+	target := active[idx]
+	var sum CommittableUsage
+	for j, other := range active {
+		if j == idx || other.Type != target.Type {
+			continue
+		}
+		if other.End.After(target.End) || renews[other.Name] {
+			sum.VCPUs += other.VCPUs
+			sum.MemoryMB += other.MemoryMB
+		}
+	}
+	return sum
+}
+
+// autoRenewCoverage is the percentage of info's vCPUs and memory, whichever
+// is lower, that forecast usage leaves for it once inForce is taken off. A
+// commitment with no vCPU or memory amount is reported fully covered.
+func autoRenewCoverage(info ResourceCommitmentInfo, forecast, inForce CommittableUsage) float64 {
+	coverage := 100.0
+	if info.VCPUs > 0 {
+		coverage = math.Min(coverage, resourceCoveragePct(forecast.VCPUs-inForce.VCPUs, info.VCPUs))
+	}
+	if info.MemoryMB > 0 {
+		coverage = math.Min(coverage, resourceCoveragePct(forecast.MemoryMB-inForce.MemoryMB, info.MemoryMB))
+	}
+	return coverage
+}
+
+func resourceCoveragePct(headroom, committed int64) float64 {
+	if headroom <= 0 {
+		return 0
+	}
+	return math.Min(100, float64(headroom)/float64(committed)*100)
+}
+
+// ApplyAutoRenewPolicy decides auto-renew for the region's commitments and,
+// unless dryRun, writes every changed setting with SetAutoRenew. The forecast
+// at expiry is the committable usage of the instances running now
+// (GetCommittableUsage): a flat forecast, which errs toward keeping
+// commitments that today's fleet still fills.
+//
+// A failed update does not stop the others; the decisions are returned with
+// Applied set on the ones that were written, together with every failure.
+func (c *ComputeEngineClient) ApplyAutoRenewPolicy(ctx context.Context, policy AutoRenewPolicy, now time.Time, dryRun bool) ([]AutoRenewDecision, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("ApplyAutoRenewPolicy: %w", err)
+	}
+	commitments, err := c.GetResourceCommitments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ApplyAutoRenewPolicy: %w", err)
+	}
+	forecast, err := c.GetCommittableUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ApplyAutoRenewPolicy: %w", err)
+	}
+
+	decisions := DecideAutoRenew(commitments, forecast, policy, now)
+	if dryRun {
+		return decisions, nil
+	}
+	var errs []error
+	for i := range decisions {
+		d := &decisions[i]
+		if !d.Changed() {
+			continue
+		}
+		if setErr := c.SetAutoRenew(ctx, d.Name, d.AutoRenew); setErr != nil {
+			errs = append(errs, setErr)
+			continue
+		}
+		d.Applied = true
+		log.Printf("GCP commitment %s in %s: auto-renew set to %t (%s)", d.Name, c.region, d.AutoRenew, d.Reason)
+	}
+	return decisions, errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+package computeengine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideAutoRenew(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	n2 := "GENERAL_PURPOSE_N2"
+	commitment := func(name string, endDays int, vcpus int64, autoRenew bool) ResourceCommitmentInfo {
+		return ResourceCommitmentInfo{
+			Name: name, Type: n2, Plan: "TWELVE_MONTH", Status: "active",
+			End: now.AddDate(0, 0, endDays), VCPUs: vcpus, MemoryMB: vcpus * 4096, AutoRenew: autoRenew,
+		}
+	}
+
+	t.Run("renews what the forecast fills", func(t *testing.T) {
+		// 24 vCPUs forecast. When "first" ends, "second" and "late" are both
+		// still in force and cover all of it, so "first" lapses. "second"
+		// then has 8 vCPUs left beside "late" and renews, which leaves
+		// "late" its full 16.
+		got := DecideAutoRenew([]ResourceCommitmentInfo{
+			commitment("late", 300, 16, false),
+			commitment("second", 60, 8, false),
+			commitment("first", 30, 8, true),
+		}, map[string]CommittableUsage{n2: {VCPUs: 24, MemoryMB: 24 * 4096}}, AutoRenewPolicy{}, now)
+
+		require.Len(t, got, 3)
+		assert.Equal(t, "first", got[0].Name)
+		assert.False(t, got[0].AutoRenew)
+		assert.True(t, got[0].Changed())
+		assert.Zero(t, got[0].CoveragePct)
+
+		assert.Equal(t, "second", got[1].Name)
+		assert.True(t, got[1].AutoRenew)
+		assert.Equal(t, 100.0, got[1].CoveragePct)
+
+		assert.Equal(t, "late", got[2].Name)
+		assert.True(t, got[2].AutoRenew)
+		assert.True(t, got[2].Changed())
+	})
+
+	t.Run("memory shortfall blocks renewal", func(t *testing.T) {
+		got := DecideAutoRenew([]ResourceCommitmentInfo{commitment("a", 30, 8, true)},
+			map[string]CommittableUsage{n2: {VCPUs: 8, MemoryMB: 8 * 1024}}, AutoRenewPolicy{}, now)
+		require.Len(t, got, 1)
+		assert.False(t, got[0].AutoRenew)
+		assert.Equal(t, 25.0, got[0].CoveragePct)
+		assert.Contains(t, got[0].Reason, "needs 90%")
+	})
+
+	t.Run("horizon and inactive commitments", func(t *testing.T) {
+		expired := commitment("expired", -10, 8, false)
+		expired.Status = "expired"
+		got := DecideAutoRenew([]ResourceCommitmentInfo{
+			commitment("soon", 30, 8, false),
+			commitment("later", 200, 8, true),
+			expired,
+		}, map[string]CommittableUsage{n2: {VCPUs: 16, MemoryMB: 16 * 4096}}, AutoRenewPolicy{Horizon: 90 * 24 * time.Hour}, now)
+		require.Len(t, got, 1)
+		assert.Equal(t, "soon", got[0].Name)
+		assert.True(t, got[0].AutoRenew)
+	})
+
+	t.Run("unforecast type lapses", func(t *testing.T) {
+		got := DecideAutoRenew([]ResourceCommitmentInfo{commitment("a", 30, 8, true)}, nil, AutoRenewPolicy{MinCoveragePct: 50}, now)
+		require.Len(t, got, 1)
+		assert.False(t, got[0].AutoRenew)
+	})
+}
+
+func TestAutoRenewPolicy_Validate(t *testing.T) {
+	assert.NoError(t, AutoRenewPolicy{}.Validate())
+	assert.NoError(t, AutoRenewPolicy{Horizon: time.Hour, MinCoveragePct: 100}.Validate())
+	assert.Error(t, AutoRenewPolicy{Horizon: -time.Hour}.Validate())
+	assert.Error(t, AutoRenewPolicy{MinCoveragePct: 101}.Validate())
+}
+
+func TestApplyAutoRenewPolicy(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	n2 := computepb.Commitment_GENERAL_PURPOSE_N2.String()
+	listed := func() []*computepb.Commitment {
+		// Both cover the same 8 running vCPUs: the early one should lapse
+		// and the late one renew, the reverse of their current settings.
+		early := mergeSource("cud-early", n2, "TWELVE_MONTH", "ACTIVE", 8, 32768)
+		early.EndTimestamp = stringPtr("2026-11-01T00:00:00Z")
+		early.AutoRenew = boolPtr(true)
+		late := mergeSource("cud-late", n2, "TWELVE_MONTH", "ACTIVE", 8, 32768)
+		late.EndTimestamp = stringPtr("2026-12-01T00:00:00Z")
+		return []*computepb.Commitment{early, late}
+	}
+	newClient := func(svc *MockCommitmentsService) *ComputeEngineClient {
+		client, _ := NewClient(ctx, "test-project", "us-central1")
+		client.SetCommitmentsService(svc)
+		client.SetInstancesService(&MockInstancesService{pairs: []compute.InstancesScopedListPair{
+			{Key: "zones/us-central1-a", Value: &computepb.InstancesScopedList{Instances: []*computepb.Instance{
+				runningInstance("web-1", "us-central1-a", "n2-custom-8-32768"),
+			}}},
+		}})
+		return client
+	}
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		svc := &MockCommitmentsService{operation: &MockOperation{}, commitments: listed()}
+		decisions, err := newClient(svc).ApplyAutoRenewPolicy(ctx, AutoRenewPolicy{}, now, true)
+		require.NoError(t, err)
+		require.Len(t, decisions, 2)
+		assert.False(t, decisions[0].AutoRenew)
+		assert.True(t, decisions[1].AutoRenew)
+		assert.Empty(t, svc.updateReqs)
+	})
+
+	t.Run("applies changed settings", func(t *testing.T) {
+		svc := &MockCommitmentsService{operation: &MockOperation{}, commitments: listed()}
+		decisions, err := newClient(svc).ApplyAutoRenewPolicy(ctx, AutoRenewPolicy{}, now, false)
+		require.NoError(t, err)
+		require.Len(t, svc.updateReqs, 2)
+		assert.Equal(t, "cud-early", svc.updateReqs[0].Commitment)
+		assert.False(t, svc.updateReqs[0].GetCommitmentResource().GetAutoRenew())
+		assert.Equal(t, "cud-late", svc.updateReqs[1].Commitment)
+		assert.True(t, svc.updateReqs[1].GetCommitmentResource().GetAutoRenew())
+		assert.True(t, decisions[0].Applied)
+		assert.True(t, decisions[1].Applied)
+	})
+
+	t.Run("update failures are reported", func(t *testing.T) {
+		svc := &MockCommitmentsService{updateErr: errors.New("denied"), commitments: listed()}
+		decisions, err := newClient(svc).ApplyAutoRenewPolicy(ctx, AutoRenewPolicy{}, now, false)
+		assert.ErrorContains(t, err, "denied")
+		require.Len(t, decisions, 2)
+		assert.False(t, decisions[0].Applied)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := newClient(&MockCommitmentsService{}).ApplyAutoRenewPolicy(ctx, AutoRenewPolicy{MinCoveragePct: -1}, now, true)
+		assert.ErrorContains(t, err, "min coverage")
+	})
+}
@@ -210,6 +210,7 @@ func termYearsFromTerm(term string) int {
 type CommitmentsService interface {
 	List(ctx context.Context, req *computepb.ListRegionCommitmentsRequest) CommitmentsIterator
 	Insert(ctx context.Context, req *computepb.InsertRegionCommitmentRequest) (CommitmentsOperation, error)
+	Update(ctx context.Context, req *computepb.UpdateRegionCommitmentRequest) (CommitmentsOperation, error)
 	Close() error
 }
 
@@ -223,6 +224,17 @@ type CommitmentsOperation interface {
 	Wait(ctx context.Context, opts ...gax.CallOption) error
 }
 
+// InstancesService interface for instance listing operations (enables mocking)
+type InstancesService interface {
+	AggregatedList(ctx context.Context, req *computepb.AggregatedListInstancesRequest) InstancesPairIterator
+	Close() error
+}
+
+// InstancesPairIterator interface for aggregated instance iteration (enables mocking)
+type InstancesPairIterator interface {
+	Next() (compute.InstancesScopedListPair, error)
+}
+
 // MachineTypesService interface for machine types operations (enables mocking)
 type MachineTypesService interface {
 	List(ctx context.Context, req *computepb.ListMachineTypesRequest) MachineTypesIterator
@@ -257,6 +269,7 @@ type ComputeEngineClient struct {
 	region              string
 	clientOpts          []option.ClientOption
 	commitmentsService  CommitmentsService
+	instancesService    InstancesService
 	machineTypesService MachineTypesService
 	billingService      BillingService
 	recommenderClient   RecommenderClient
@@ -277,6 +290,11 @@ func (c *ComputeEngineClient) SetCommitmentsService(svc CommitmentsService) {
 	c.commitmentsService = svc
 }
 
+// SetInstancesService sets the instances service (for testing)
+func (c *ComputeEngineClient) SetInstancesService(svc InstancesService) {
+	c.instancesService = svc
+}
+
 // SetMachineTypesService sets the machine types service (for testing)
 func (c *ComputeEngineClient) SetMachineTypesService(svc MachineTypesService) {
 	c.machineTypesService = svc
@@ -305,10 +323,27 @@ func (r *realCommitmentsService) Insert(ctx context.Context, req *computepb.Inse
 	return r.client.Insert(ctx, req)
 }
 
+func (r *realCommitmentsService) Update(ctx context.Context, req *computepb.UpdateRegionCommitmentRequest) (CommitmentsOperation, error) {
+	return r.client.Update(ctx, req)
+}
+
 func (r *realCommitmentsService) Close() error {
 	return r.client.Close()
 }
 
+// realInstancesService wraps the real compute.InstancesClient
+type realInstancesService struct {
+	client *compute.InstancesClient
+}
+
+func (r *realInstancesService) AggregatedList(ctx context.Context, req *computepb.AggregatedListInstancesRequest) InstancesPairIterator {
+	return r.client.AggregatedList(ctx, req)
+}
+
+func (r *realInstancesService) Close() error {
+	return r.client.Close()
+}
+
 // realMachineTypesService wraps the real compute.MachineTypesClient
 type realMachineTypesService struct {
 	client *compute.MachineTypesClient
@@ -958,7 +993,7 @@ func (c *ComputeEngineClient) getComputePricing(ctx context.Context, machineType
 		return nil, err
 	}
 
-	skus, err := svc.ListSKUs("services/6F81-5844-456A")
+	skus, err := svc.ListSKUs(computeEngineBillingServiceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list SKUs: %w", err)
 	}
@@ -1430,3 +1465,7 @@ func stringPtr(s string) *string {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
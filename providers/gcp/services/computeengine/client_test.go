@@ -26,6 +26,8 @@ type MockCommitmentsService struct {
 	lastInsertReq *computepb.InsertRegionCommitmentRequest // captured for assertions
 	operation     *MockOperation
 	insertReqs    []*computepb.InsertRegionCommitmentRequest // every Insert call (re-drive assertions)
+	updateErr     error
+	updateReqs    []*computepb.UpdateRegionCommitmentRequest // every Update call
 	commitments   []*computepb.Commitment
 }
 
@@ -42,6 +44,14 @@ func (m *MockCommitmentsService) Insert(ctx context.Context, req *computepb.Inse
 	return m.operation, nil
 }
 
+func (m *MockCommitmentsService) Update(ctx context.Context, req *computepb.UpdateRegionCommitmentRequest) (CommitmentsOperation, error) {
+	m.updateReqs = append(m.updateReqs, req)
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	return m.operation, nil
+}
+
 func (m *MockCommitmentsService) Close() error {
 	return nil
 }
@@ -0,0 +1,225 @@
+package computeengine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/iterator"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// autoRenewField is the Commitment field SetAutoRenew updates. GCP takes it
+// both as the UpdateMask and as the Paths of an UpdateRegionCommitment call.
+const autoRenewField = "autoRenew"
+
+// SetAutoRenew turns automatic renewal of the named commitment on or off.
+// Only autoRenew is named in the update mask, so GCP leaves every other field
+// of the commitment untouched. Setting the value the commitment already has
+// is a no-op on GCP's side, so a re-drive is safe.
+func (c *ComputeEngineClient) SetAutoRenew(ctx context.Context, name string, autoRenew bool) error {
+	if name == "" {
+		return fmt.Errorf("SetAutoRenew: commitment name must not be empty")
+	}
+	svc, err := c.createCommitmentsService(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	op, err := svc.Update(ctx, &computepb.UpdateRegionCommitmentRequest{
+		Project:            c.projectID,
+		Region:             c.region,
+		Commitment:         name,
+		CommitmentResource: &computepb.Commitment{AutoRenew: boolPtr(autoRenew)},
+		UpdateMask:         stringPtr(autoRenewField),
+		Paths:              stringPtr(autoRenewField),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update auto-renew on commitment %s: %w", name, err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("auto-renew update on commitment %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// MergeableGroups groups the active commitments that GCP can merge into one:
+// those sharing a type and plan (a client only ever sees one region). Groups
+// of a single commitment are dropped. Groups are ordered by type then plan,
+// and each group's commitments by end date then name, so the output is
+// stable across calls.
+func MergeableGroups(infos []ResourceCommitmentInfo) [][]ResourceCommitmentInfo {
+	byKey := make(map[string][]ResourceCommitmentInfo)
+	for _, info := range infos {
+		if info.Status != "active" || info.Type == "" || info.Plan == "" {
+			continue
+		}
+		key := info.Type + "/" + info.Plan
+		byKey[key] = append(byKey[key], info)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key, group := range byKey {
+		if len(group) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	groups := make([][]ResourceCommitmentInfo, 0, len(keys))
+	for _, key := range keys {
+		group := byKey[key]
+		sort.Slice(group, func(i, j int) bool {
+			if !group[i].End.Equal(group[j].End) {
+				return group[i].End.Before(group[j].End)
+			}
+			return group[i].Name < group[j].Name
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// MergeCommitments merges the named commitments into a single new commitment
+// and returns its name. Every source must be active and share one type and
+// plan; the merged commitment carries the same type and plan and the sum of
+// the sources' resources, and GCP retires the sources once it is created.
+//
+// idempotencyToken plays the same role as PurchaseOptions.IdempotencyToken in
+// PurchaseCommitment: it fixes both the merged commitment's name and the
+// Insert RequestId, so retrying a merge with the same token cannot create a
+// second commitment. An empty token falls back to a timestamp-based name.
+func (c *ComputeEngineClient) MergeCommitments(ctx context.Context, names []string, idempotencyToken string) (string, error) {
+	if len(names) < 2 {
+		return "", fmt.Errorf("MergeCommitments: at least two commitments are required, got %d", len(names))
+	}
+	svc, err := c.createCommitmentsService(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer svc.Close()
+
+	sources, err := c.findCommitments(ctx, svc, names)
+	if err != nil {
+		return "", fmt.Errorf("MergeCommitments: %w", err)
+	}
+	insertReq, mergedName, err := c.buildMergeRequest(sources, idempotencyToken)
+	if err != nil {
+		return "", fmt.Errorf("MergeCommitments: %w", err)
+	}
+
+	op, err := svc.Insert(ctx, insertReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to create merged commitment: %w", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return "", fmt.Errorf("merged commitment creation failed: %w", err)
+	}
+	log.Printf("GCP merged %d commitments (%s) into %s in %s", len(names), strings.Join(names, ", "), mergedName, c.region)
+	return mergedName, nil
+}
+
+// findCommitments returns the listed commitments named in names, in the
+// order given. A name that is listed twice or not found is an error.
+func (c *ComputeEngineClient) findCommitments(ctx context.Context, svc CommitmentsService, names []string) ([]*computepb.Commitment, error) {
+	wanted := make(map[string]int, len(names))
+	for i, name := range names {
+		if _, dup := wanted[name]; dup {
+			return nil, fmt.Errorf("commitment %s is listed more than once", name)
+		}
+		wanted[name] = i
+	}
+
+	found := make([]*computepb.Commitment, len(names))
+	it := svc.List(ctx, &computepb.ListRegionCommitmentsRequest{
+		Project: c.projectID,
+		Region:  c.region,
+	})
+	for pageIdx := 0; ; pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxCommitmentsPages {
+			return nil, fmt.Errorf("commitment iteration cap (%d items) reached", maxCommitmentsPages)
+		}
+		commitment, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commitments: %w", err)
+		}
+		if i, ok := wanted[commitment.GetName()]; ok {
+			found[i] = commitment
+		}
+	}
+
+	for i, commitment := range found {
+		if commitment == nil {
+			return nil, fmt.Errorf("commitment %s not found in %s", names[i], c.region)
+		}
+	}
+	return found, nil
+}
+
+// buildMergeRequest assembles the RegionCommitments.Insert request that
+// merges sources. Resources are summed per resource type and, for
+// accelerators, per accelerator type, so the merged commitment covers exactly
+// what the sources did.
+func (c *ComputeEngineClient) buildMergeRequest(sources []*computepb.Commitment, idempotencyToken string) (*computepb.InsertRegionCommitmentRequest, string, error) {
+	first := sources[0]
+	sourceURLs := make([]string, 0, len(sources))
+	sourceNames := make([]string, 0, len(sources))
+	amounts := make(map[[2]string]int64)
+	var order [][2]string
+	for _, src := range sources {
+		if !strings.EqualFold(src.GetStatus(), "active") {
+			return nil, "", fmt.Errorf("commitment %s is %s; only active commitments can be merged", src.GetName(), strings.ToLower(src.GetStatus()))
+		}
+		if src.GetType() != first.GetType() || src.GetPlan() != first.GetPlan() {
+			return nil, "", fmt.Errorf("commitment %s (%s, %s) does not match %s (%s, %s); only commitments of the same type and plan can be merged",
+				src.GetName(), src.GetType(), src.GetPlan(), first.GetName(), first.GetType(), first.GetPlan())
+		}
+		for _, r := range src.GetResources() {
+			key := [2]string{r.GetType(), r.GetAcceleratorType()}
+			if _, seen := amounts[key]; !seen {
+				order = append(order, key)
+			}
+			amounts[key] += r.GetAmount()
+		}
+		sourceURLs = append(sourceURLs, fmt.Sprintf("projects/%s/regions/%s/commitments/%s", c.projectID, c.region, src.GetName()))
+		sourceNames = append(sourceNames, src.GetName())
+	}
+
+	resources := make([]*computepb.ResourceCommitment, 0, len(order))
+	for _, key := range order {
+		r := &computepb.ResourceCommitment{Type: stringPtr(key[0]), Amount: int64Ptr(amounts[key])}
+		if key[1] != "" {
+			r.AcceleratorType = stringPtr(key[1])
+		}
+		resources = append(resources, r)
+	}
+
+	mergedName := idempotentCommitmentName(idempotencyToken)
+	insertReq := &computepb.InsertRegionCommitmentRequest{
+		Project: c.projectID,
+		Region:  c.region,
+		CommitmentResource: &computepb.Commitment{
+			Name:                   stringPtr(mergedName),
+			Type:                   stringPtr(first.GetType()),
+			Plan:                   stringPtr(first.GetPlan()),
+			Description:            stringPtr("Merged from " + strings.Join(sourceNames, ", ")),
+			Resources:              resources,
+			MergeSourceCommitments: sourceURLs,
+		},
+	}
+	if requestID := common.IdempotencyGUID(idempotencyToken); requestID != "" {
+		insertReq.RequestId = stringPtr(requestID)
+	}
+	return insertReq, mergedName, nil
+}
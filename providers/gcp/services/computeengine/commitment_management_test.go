@@ -0,0 +1,151 @@
+package computeengine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeSource(name, commitType, plan, status string, vcpus, memMB int64) *computepb.Commitment {
+	return &computepb.Commitment{
+		Name:   stringPtr(name),
+		Type:   stringPtr(commitType),
+		Plan:   stringPtr(plan),
+		Status: stringPtr(status),
+		Resources: []*computepb.ResourceCommitment{
+			{Type: stringPtr(computepb.ResourceCommitment_VCPU.String()), Amount: int64Ptr(vcpus)},
+			{Type: stringPtr(computepb.ResourceCommitment_MEMORY.String()), Amount: int64Ptr(memMB)},
+		},
+	}
+}
+
+func TestSetAutoRenew_UpdatesOnlyAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	svc := &MockCommitmentsService{operation: &MockOperation{}}
+	client.SetCommitmentsService(svc)
+
+	require.NoError(t, client.SetAutoRenew(ctx, "cud-1", true))
+	require.Len(t, svc.updateReqs, 1)
+	req := svc.updateReqs[0]
+	assert.Equal(t, "test-project", req.Project)
+	assert.Equal(t, "us-central1", req.Region)
+	assert.Equal(t, "cud-1", req.Commitment)
+	assert.True(t, req.GetCommitmentResource().GetAutoRenew())
+	assert.Equal(t, "autoRenew", req.GetUpdateMask())
+	assert.Equal(t, "autoRenew", req.GetPaths())
+}
+
+func TestSetAutoRenew_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty name", func(t *testing.T) {
+		client, _ := NewClient(ctx, "test-project", "us-central1")
+		assert.ErrorContains(t, client.SetAutoRenew(ctx, "", false), "commitment name must not be empty")
+	})
+
+	t.Run("update rejected", func(t *testing.T) {
+		client, _ := NewClient(ctx, "test-project", "us-central1")
+		client.SetCommitmentsService(&MockCommitmentsService{updateErr: errors.New("permission denied")})
+		err := client.SetAutoRenew(ctx, "cud-1", false)
+		assert.ErrorContains(t, err, "failed to update auto-renew on commitment cud-1")
+	})
+
+	t.Run("operation failed", func(t *testing.T) {
+		client, _ := NewClient(ctx, "test-project", "us-central1")
+		client.SetCommitmentsService(&MockCommitmentsService{operation: &MockOperation{err: errors.New("boom")}})
+		err := client.SetAutoRenew(ctx, "cud-1", false)
+		assert.ErrorContains(t, err, "auto-renew update on commitment cud-1 failed")
+	})
+}
+
+func TestMergeableGroups(t *testing.T) {
+	jan := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	infos := []ResourceCommitmentInfo{
+		{Name: "n2-b", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", Status: "active", End: feb},
+		{Name: "n2-a", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", Status: "active", End: jan},
+		{Name: "n2-3y", Type: "GENERAL_PURPOSE_N2", Plan: "THIRTY_SIX_MONTH", Status: "active", End: jan},
+		{Name: "n2-expired", Type: "GENERAL_PURPOSE_N2", Plan: "TWELVE_MONTH", Status: "expired", End: jan},
+		{Name: "c3-a", Type: "COMPUTE_OPTIMIZED_C3", Plan: "TWELVE_MONTH", Status: "active", End: jan},
+		{Name: "c3-b", Type: "COMPUTE_OPTIMIZED_C3", Plan: "TWELVE_MONTH", Status: "active", End: jan},
+	}
+
+	groups := MergeableGroups(infos)
+	require.Len(t, groups, 2)
+	assert.Equal(t, []string{"c3-a", "c3-b"}, []string{groups[0][0].Name, groups[0][1].Name})
+	assert.Equal(t, []string{"n2-a", "n2-b"}, []string{groups[1][0].Name, groups[1][1].Name})
+}
+
+func TestMergeCommitments_InsertsSummedCommitment(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	n2, plan := computepb.Commitment_GENERAL_PURPOSE_N2.String(), computepb.Commitment_TWELVE_MONTH.String()
+	svc := &MockCommitmentsService{
+		operation: &MockOperation{},
+		commitments: []*computepb.Commitment{
+			mergeSource("cud-a", n2, plan, "ACTIVE", 8, 32768),
+			mergeSource("cud-other", n2, plan, "ACTIVE", 2, 8192),
+			mergeSource("cud-b", n2, plan, "ACTIVE", 4, 16384),
+		},
+	}
+	client.SetCommitmentsService(svc)
+
+	token := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"
+	name, err := client.MergeCommitments(ctx, []string{"cud-a", "cud-b"}, token)
+	require.NoError(t, err)
+	assert.Equal(t, idempotentCommitmentName(token), name)
+
+	require.Len(t, svc.insertReqs, 1)
+	req := svc.insertReqs[0]
+	assert.NotEmpty(t, req.GetRequestId())
+	merged := req.GetCommitmentResource()
+	assert.Equal(t, name, merged.GetName())
+	assert.Equal(t, n2, merged.GetType())
+	assert.Equal(t, plan, merged.GetPlan())
+	assert.Equal(t, []string{
+		"projects/test-project/regions/us-central1/commitments/cud-a",
+		"projects/test-project/regions/us-central1/commitments/cud-b",
+	}, merged.GetMergeSourceCommitments())
+	require.Len(t, merged.GetResources(), 2)
+	assert.Equal(t, int64(12), merged.GetResources()[0].GetAmount())
+	assert.Equal(t, int64(49152), merged.GetResources()[1].GetAmount())
+}
+
+func TestMergeCommitments_Rejects(t *testing.T) {
+	ctx := context.Background()
+	n2, c3 := computepb.Commitment_GENERAL_PURPOSE_N2.String(), computepb.Commitment_COMPUTE_OPTIMIZED_C3.String()
+	plan := computepb.Commitment_TWELVE_MONTH.String()
+	listed := []*computepb.Commitment{
+		mergeSource("cud-a", n2, plan, "ACTIVE", 8, 32768),
+		mergeSource("cud-c3", c3, plan, "ACTIVE", 8, 32768),
+		mergeSource("cud-expired", n2, plan, "EXPIRED", 8, 32768),
+	}
+
+	cases := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"single source", []string{"cud-a"}, "at least two commitments are required"},
+		{"duplicate", []string{"cud-a", "cud-a"}, "listed more than once"},
+		{"missing", []string{"cud-a", "cud-nope"}, "commitment cud-nope not found"},
+		{"type mismatch", []string{"cud-a", "cud-c3"}, "only commitments of the same type and plan can be merged"},
+		{"not active", []string{"cud-a", "cud-expired"}, "only active commitments can be merged"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := NewClient(ctx, "test-project", "us-central1")
+			svc := &MockCommitmentsService{operation: &MockOperation{}, commitments: listed}
+			client.SetCommitmentsService(svc)
+			_, err := client.MergeCommitments(ctx, tc.names, "")
+			assert.ErrorContains(t, err, tc.want)
+			assert.Empty(t, svc.insertReqs)
+		})
+	}
+}
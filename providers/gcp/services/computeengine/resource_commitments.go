@@ -0,0 +1,231 @@
+package computeengine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/cloudbilling/v1"
+	"google.golang.org/api/iterator"
+)
+
+// computeEngineBillingServiceID is the Cloud Billing Catalog service that
+// carries both the on-demand VM SKUs and the commitment SKUs.
+const computeEngineBillingServiceID = "services/6F81-5844-456A"
+
+// mbPerGiB converts a MEMORY ResourceCommitment amount (MB) into the GiB unit
+// the commitment RAM SKUs are priced in.
+const mbPerGiB = 1024.0
+
+// ResourceCommitmentInfo is the detailed view of one resource-based Compute
+// Engine CUD. GetExistingCommitments flattens commitments into
+// common.Commitment and drops the plan, lifetime and committed amounts; the
+// ladder needs those to price and age its base layer.
+type ResourceCommitmentInfo struct {
+	Name   string
+	Type   string // computepb.Commitment_Type name, e.g. "GENERAL_PURPOSE_N2"
+	Plan   string // computepb.Commitment_Plan name, e.g. "TWELVE_MONTH"
+	Status string // lower-cased, e.g. "active"
+	Start  time.Time
+	End    time.Time
+	// VCPUs and MemoryMB are the committed VCPU and MEMORY resource amounts.
+	VCPUs    int64
+	MemoryMB int64
+	// AutoRenew reports whether GCP will renew the commitment for another
+	// term when it ends.
+	AutoRenew bool
+}
+
+// CommitmentUnitRates are the hourly commitment prices for one commitment type
+// and plan in the client's region.
+type CommitmentUnitRates struct {
+	VCPUPerHour     float64
+	MemoryGBPerHour float64
+	Currency        string
+}
+
+// GetResourceCommitments lists the region's resource-based CUDs with their
+// plan, lifetime and committed vCPU/memory amounts. Timestamps GCP returns
+// unparseable fail loud: a commitment with an unknown end date cannot be
+// placed on an expiry ladder.
+func (c *ComputeEngineClient) GetResourceCommitments(ctx context.Context) ([]ResourceCommitmentInfo, error) {
+	svc, err := c.createCommitmentsService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+
+	it := svc.List(ctx, &computepb.ListRegionCommitmentsRequest{
+		Project: c.projectID,
+		Region:  c.region,
+	})
+	infos := make([]ResourceCommitmentInfo, 0)
+	for pageIdx := 0; ; pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxCommitmentsPages {
+			return nil, fmt.Errorf("computeengine: GetResourceCommitments iteration cap (%d items) reached", maxCommitmentsPages)
+		}
+		commitment, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commitments: %w", err)
+		}
+		if commitment.Name == nil {
+			continue
+		}
+		info, err := toResourceCommitmentInfo(commitment)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// toResourceCommitmentInfo converts one listed commitment. VCPU and MEMORY
+// amounts are summed across resources of the same type; other resource types
+// (ACCELERATOR, LOCAL_SSD) are not representable and are ignored here, which
+// GetCommitmentUnitRates compensates for by refusing the commitment types that
+// carry them.
+func toResourceCommitmentInfo(commitment *computepb.Commitment) (ResourceCommitmentInfo, error) {
+	info := ResourceCommitmentInfo{
+		Name:      commitment.GetName(),
+		Type:      commitment.GetType(),
+		Plan:      commitment.GetPlan(),
+		Status:    strings.ToLower(commitment.GetStatus()),
+		AutoRenew: commitment.GetAutoRenew(),
+	}
+	var err error
+	if info.Start, err = parseCommitmentTimestamp(commitment.GetStartTimestamp()); err != nil {
+		return ResourceCommitmentInfo{}, fmt.Errorf("commitment %s: start timestamp: %w", info.Name, err)
+	}
+	if info.End, err = parseCommitmentTimestamp(commitment.GetEndTimestamp()); err != nil {
+		return ResourceCommitmentInfo{}, fmt.Errorf("commitment %s: end timestamp: %w", info.Name, err)
+	}
+	for _, r := range commitment.GetResources() {
+		switch r.GetType() {
+		case computepb.ResourceCommitment_VCPU.String():
+			info.VCPUs += r.GetAmount()
+		case computepb.ResourceCommitment_MEMORY.String():
+			info.MemoryMB += r.GetAmount()
+		}
+	}
+	return info, nil
+}
+
+// parseCommitmentTimestamp parses GCP's RFC 3339 commitment timestamps. An
+// empty value (a commitment still being created) yields the zero time.
+func parseCommitmentTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// commitmentSKUFamily maps a commitment type onto the family token GCP uses
+// in commitment SKU descriptions, e.g. "Commitment v1: N2 Cpu in Americas for
+// 1 Year". N1 (GENERAL_PURPOSE) SKUs carry no family token at all. Types not
+// listed here fall back to the machine family from
+// machineFamilyCommitmentType, upper-cased.
+var commitmentSKUFamily = map[computepb.Commitment_Type]string{
+	computepb.Commitment_GENERAL_PURPOSE:     "",
+	computepb.Commitment_GENERAL_PURPOSE_N2D: "n2d amd ",
+	computepb.Commitment_COMPUTE_OPTIMIZED:   "compute optimized ",
+	computepb.Commitment_MEMORY_OPTIMIZED:    "memory-optimized ",
+}
+
+// skuFamilyToken returns the lower-cased description token that precedes
+// "cpu"/"ram" in the commitment SKUs for commitmentType.
+func skuFamilyToken(commitmentType string) (string, error) {
+	value, ok := computepb.Commitment_Type_value[commitmentType]
+	if !ok {
+		return "", fmt.Errorf("unknown commitment type %q", commitmentType)
+	}
+	ct := computepb.Commitment_Type(value)
+	if token, ok := commitmentSKUFamily[ct]; ok {
+		return token, nil
+	}
+	for family, mapped := range machineFamilyCommitmentType {
+		if mapped == ct {
+			return family + " ", nil
+		}
+	}
+	return "", fmt.Errorf("commitment type %q has no priceable VCPU/MEMORY commitment SKU (accelerator, local-SSD and size-bucketed types are not supported)", commitmentType)
+}
+
+// GetCommitmentUnitRates returns the per-vCPU-hour and per-GiB-hour
+// commitment prices for commitmentType and plan in the client's region, from
+// the Cloud Billing Catalog. It fails loud when either SKU is missing or the
+// two disagree on currency rather than pricing half a commitment.
+func (c *ComputeEngineClient) GetCommitmentUnitRates(ctx context.Context, commitmentType, plan string) (CommitmentUnitRates, error) {
+	token, err := skuFamilyToken(commitmentType)
+	if err != nil {
+		return CommitmentUnitRates{}, fmt.Errorf("GetCommitmentUnitRates: %w", err)
+	}
+	var years string
+	switch plan {
+	case computepb.Commitment_TWELVE_MONTH.String():
+		years = "1 year"
+	case computepb.Commitment_THIRTY_SIX_MONTH.String():
+		years = "3 year"
+	default:
+		return CommitmentUnitRates{}, fmt.Errorf("GetCommitmentUnitRates: unsupported commitment plan %q", plan)
+	}
+
+	svc, err := c.getOrCreateBillingService(ctx)
+	if err != nil {
+		return CommitmentUnitRates{}, err
+	}
+	skus, err := svc.ListSKUs(computeEngineBillingServiceID)
+	if err != nil {
+		return CommitmentUnitRates{}, fmt.Errorf("failed to list SKUs: %w", err)
+	}
+
+	cpuPrefix := "commitment v1: " + token + "cpu "
+	ramPrefix := "commitment v1: " + token + "ram "
+	var rates CommitmentUnitRates
+	var cpuCurrency, ramCurrency string
+	for _, sku := range skus.Skus {
+		desc := strings.ToLower(sku.Description)
+		if !strings.Contains(desc, years) || !skuInRegion(sku, c.region) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(desc, cpuPrefix):
+			rates.VCPUPerHour, cpuCurrency = extractComputePriceFromSKU(sku)
+		case strings.HasPrefix(desc, ramPrefix):
+			rates.MemoryGBPerHour, ramCurrency = extractComputePriceFromSKU(sku)
+		}
+	}
+	if rates.VCPUPerHour <= 0 || rates.MemoryGBPerHour <= 0 {
+		return CommitmentUnitRates{}, fmt.Errorf("GetCommitmentUnitRates: no %s commitment CPU and RAM SKUs for %s in region %s", years, commitmentType, c.region)
+	}
+	if cpuCurrency != ramCurrency {
+		return CommitmentUnitRates{}, fmt.Errorf("GetCommitmentUnitRates: CPU SKU currency %q differs from RAM SKU currency %q", cpuCurrency, ramCurrency)
+	}
+	rates.Currency = cpuCurrency
+	return rates, nil
+}
+
+// HourlyCost prices a commitment from its committed amounts and unit rates.
+func (r CommitmentUnitRates) HourlyCost(info ResourceCommitmentInfo) float64 {
+	return float64(info.VCPUs)*r.VCPUPerHour + float64(info.MemoryMB)/mbPerGiB*r.MemoryGBPerHour
+}
+
+// skuInRegion reports whether sku is sold in region. Unlike
+// skuMatchesMachineType, a SKU without ServiceRegions does not match: every
+// commitment SKU is regional, so a missing list means a different product.
+func skuInRegion(sku *cloudbilling.Sku, region string) bool {
+	for _, serviceRegion := range sku.ServiceRegions {
+		if strings.EqualFold(serviceRegion, region) {
+			return true
+		}
+	}
+	return false
+}
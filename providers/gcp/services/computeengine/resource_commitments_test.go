@@ -0,0 +1,111 @@
+package computeengine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudbilling/v1"
+)
+
+func TestGetResourceCommitments_ParsesPlanLifetimeAndAmounts(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	client.SetCommitmentsService(&MockCommitmentsService{
+		commitments: []*computepb.Commitment{
+			{
+				Name:           stringPtr("cud-1"),
+				Status:         stringPtr("ACTIVE"),
+				Type:           stringPtr(computepb.Commitment_GENERAL_PURPOSE_N2.String()),
+				Plan:           stringPtr(computepb.Commitment_TWELVE_MONTH.String()),
+				StartTimestamp: stringPtr("2026-01-01T00:00:00-08:00"),
+				EndTimestamp:   stringPtr("2027-01-01T00:00:00-08:00"),
+				AutoRenew:      boolPtr(true),
+				Resources: []*computepb.ResourceCommitment{
+					{Type: stringPtr(computepb.ResourceCommitment_VCPU.String()), Amount: int64Ptr(8)},
+					{Type: stringPtr(computepb.ResourceCommitment_MEMORY.String()), Amount: int64Ptr(32768)},
+				},
+			},
+			{Status: stringPtr("ACTIVE")}, // nameless entries are skipped
+		},
+	})
+
+	got, err := client.GetResourceCommitments(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "cud-1", got[0].Name)
+	assert.Equal(t, "active", got[0].Status)
+	assert.Equal(t, "GENERAL_PURPOSE_N2", got[0].Type)
+	assert.Equal(t, "TWELVE_MONTH", got[0].Plan)
+	assert.Equal(t, int64(8), got[0].VCPUs)
+	assert.Equal(t, int64(32768), got[0].MemoryMB)
+	assert.True(t, got[0].AutoRenew)
+	assert.True(t, got[0].End.Equal(time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC)))
+}
+
+func TestGetResourceCommitments_BadTimestampFailsLoud(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	client.SetCommitmentsService(&MockCommitmentsService{
+		commitments: []*computepb.Commitment{
+			{Name: stringPtr("cud-1"), EndTimestamp: stringPtr("next year")},
+		},
+	})
+
+	_, err := client.GetResourceCommitments(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cud-1")
+}
+
+func commitmentSKU(desc, region string, nanos int64, currency string) *cloudbilling.Sku {
+	return &cloudbilling.Sku{
+		Description:    desc,
+		ServiceRegions: []string{region},
+		PricingInfo: []*cloudbilling.PricingInfo{{
+			PricingExpression: &cloudbilling.PricingExpression{
+				TieredRates: []*cloudbilling.TierRate{{
+					UnitPrice: &cloudbilling.Money{Nanos: nanos, CurrencyCode: currency},
+				}},
+			},
+		}},
+	}
+}
+
+func TestGetCommitmentUnitRates(t *testing.T) {
+	skus := &cloudbilling.ListSkusResponse{Skus: []*cloudbilling.Sku{
+		commitmentSKU("Commitment v1: N2 Cpu in Americas for 1 Year", "us-central1", 20000000, "USD"),
+		commitmentSKU("Commitment v1: N2 Ram in Americas for 1 Year", "us-central1", 3000000, "USD"),
+		commitmentSKU("Commitment v1: N2 Cpu in Americas for 3 Year", "us-central1", 10000000, "USD"),
+		commitmentSKU("Commitment v1: N2D AMD Cpu in Americas for 1 Year", "us-central1", 99000000, "USD"),
+		commitmentSKU("Commitment v1: N2 Cpu in Europe for 1 Year", "europe-west1", 99000000, "USD"),
+		commitmentSKU("Commitment v1: Cpu in Americas for 1 Year", "us-central1", 25000000, "USD"),
+		commitmentSKU("Commitment v1: Ram in Americas for 1 Year", "us-central1", 4000000, "USD"),
+	}}
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	client.SetBillingService(&MockBillingService{skus: skus})
+
+	rates, err := client.GetCommitmentUnitRates(ctx, "GENERAL_PURPOSE_N2", "TWELVE_MONTH")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.02, rates.VCPUPerHour, 1e-12)
+	assert.InDelta(t, 0.003, rates.MemoryGBPerHour, 1e-12)
+	assert.Equal(t, "USD", rates.Currency)
+	// 8 vCPU x 0.02 + 32 GiB x 0.003
+	assert.InDelta(t, 0.256, rates.HourlyCost(ResourceCommitmentInfo{VCPUs: 8, MemoryMB: 32768}), 1e-12)
+
+	n1, err := client.GetCommitmentUnitRates(ctx, "GENERAL_PURPOSE", "TWELVE_MONTH")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.025, n1.VCPUPerHour, 1e-12)
+
+	// No 3-year RAM SKU: refuse rather than price half the commitment.
+	_, err = client.GetCommitmentUnitRates(ctx, "GENERAL_PURPOSE_N2", "THIRTY_SIX_MONTH")
+	require.Error(t, err)
+
+	_, err = client.GetCommitmentUnitRates(ctx, "ACCELERATOR_OPTIMIZED", "TWELVE_MONTH")
+	require.Error(t, err)
+	_, err = client.GetCommitmentUnitRates(ctx, "GENERAL_PURPOSE_N2", "SIX_MONTH")
+	require.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package computeengine
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/iterator"
+)
+
+// maxInstanceZonePages caps aggregated instance iteration. Each Next() call
+// yields one zone's instance list, and GCP has well under this many zones.
+const maxInstanceZonePages = 500
+
+// maxMachineTypeSpecItems caps one zone's machine type listing. Zones offer a
+// few hundred predefined machine types.
+const maxMachineTypeSpecItems = 2000
+
+// CommittableUsage is the vCPU and memory that running instances draw for one
+// commitment type, in the units ResourceCommitmentInfo uses.
+type CommittableUsage struct {
+	VCPUs    int64
+	MemoryMB int64
+}
+
+// machineTypeSpec is a machine type's vCPU and memory size.
+type machineTypeSpec struct {
+	vcpus    int64
+	memoryMB int64
+}
+
+// GetCommittableUsage sums the vCPUs and memory of the region's running
+// instances per commitment type name (e.g. "GENERAL_PURPOSE_N2"). Spot and
+// preemptible instances are left out because CUDs never apply to them, as
+// are machine families GCP sells no VCPU/MEMORY commitment for. This is a
+// snapshot of the usage commitments can cover right now.
+func (c *ComputeEngineClient) GetCommittableUsage(ctx context.Context) (map[string]CommittableUsage, error) {
+	svc, err := c.createInstancesService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+
+	specs := make(map[string]map[string]machineTypeSpec)
+	usage := make(map[string]CommittableUsage)
+	it := svc.AggregatedList(ctx, &computepb.AggregatedListInstancesRequest{Project: c.projectID})
+	for pageIdx := 0; ; pageIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if pageIdx >= maxInstanceZonePages {
+			return nil, fmt.Errorf("computeengine: GetCommittableUsage iteration cap (%d zones) reached", maxInstanceZonePages)
+		}
+		pair, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+		zone := strings.TrimPrefix(pair.Key, "zones/")
+		if !strings.HasPrefix(zone, c.region+"-") || pair.Value == nil {
+			continue
+		}
+		if err := c.addZoneUsage(ctx, zone, pair.Value.GetInstances(), specs, usage); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+// addZoneUsage adds one zone's committable running instances to usage,
+// resolving machine type sizes through specs (zone -> name -> size).
+func (c *ComputeEngineClient) addZoneUsage(ctx context.Context, zone string, instances []*computepb.Instance, specs map[string]map[string]machineTypeSpec, usage map[string]CommittableUsage) error {
+	for _, inst := range instances {
+		if inst.GetStatus() != computepb.Instance_RUNNING.String() || !isCommittableScheduling(inst.GetScheduling()) {
+			continue
+		}
+		machineType := path.Base(inst.GetMachineType())
+		commitType, ok := usageCommitmentType(machineType)
+		if !ok {
+			continue
+		}
+		spec, ok := parseCustomMachineType(machineType)
+		if !ok {
+			if specs[zone] == nil {
+				zoneSpecs, err := c.listMachineTypeSpecs(ctx, zone)
+				if err != nil {
+					return err
+				}
+				specs[zone] = zoneSpecs
+			}
+			if spec, ok = specs[zone][machineType]; !ok {
+				return fmt.Errorf("instance %s: machine type %s not found in zone %s", inst.GetName(), machineType, zone)
+			}
+		}
+		u := usage[commitType]
+		u.VCPUs += spec.vcpus
+		u.MemoryMB += spec.memoryMB
+		usage[commitType] = u
+	}
+	return nil
+}
+
+// isCommittableScheduling reports whether an instance with this scheduling
+// can consume a CUD. Spot and preemptible capacity never can.
+func isCommittableScheduling(s *computepb.Scheduling) bool {
+	if s == nil {
+		return true
+	}
+	return !s.GetPreemptible() && s.GetProvisioningModel() != computepb.Scheduling_SPOT.String()
+}
+
+// usageCommitmentType maps a running machine type onto the commitment type
+// whose VCPU/MEMORY resources it draws down. N1 custom machine types are
+// named "custom-<vcpus>-<mb>" with no family prefix.
+func usageCommitmentType(machineType string) (string, bool) {
+	if strings.HasPrefix(machineType, "custom-") {
+		machineType = "n1-" + machineType
+	}
+	commitType, err := commitmentTypeForMachineType(machineType)
+	if err != nil {
+		return "", false
+	}
+	return commitType.String(), true
+}
+
+// parseCustomMachineType reads the size of a custom machine type such as
+// "n2-custom-8-32768", "custom-4-15360" or "n2-custom-8-65536-ext" from its
+// name. Predefined machine types report false.
+func parseCustomMachineType(machineType string) (machineTypeSpec, bool) {
+	parts := strings.Split(strings.TrimSuffix(machineType, "-ext"), "-")
+	if len(parts) < 3 || parts[len(parts)-3] != "custom" {
+		return machineTypeSpec{}, false
+	}
+	vcpus, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+	if err != nil {
+		return machineTypeSpec{}, false
+	}
+	memoryMB, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return machineTypeSpec{}, false
+	}
+	return machineTypeSpec{vcpus: vcpus, memoryMB: memoryMB}, true
+}
+
+// listMachineTypeSpecs returns the sizes of the machine types offered in zone.
+func (c *ComputeEngineClient) listMachineTypeSpecs(ctx context.Context, zone string) (map[string]machineTypeSpec, error) {
+	svc, err := c.createMachineTypesService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+
+	specs := make(map[string]machineTypeSpec)
+	it := svc.List(ctx, &computepb.ListMachineTypesRequest{Project: c.projectID, Zone: zone})
+	for itemIdx := 0; ; itemIdx++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("context cancelled during pagination: %w", err)
+		}
+		if itemIdx >= maxMachineTypeSpecItems {
+			return nil, fmt.Errorf("computeengine: machine type iteration cap (%d items) reached in %s", maxMachineTypeSpecItems, zone)
+		}
+		mt, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list machine types in %s: %w", zone, err)
+		}
+		specs[mt.GetName()] = machineTypeSpec{vcpus: int64(mt.GetGuestCpus()), memoryMB: int64(mt.GetMemoryMb())}
+	}
+	return specs, nil
+}
+
+// createInstancesService creates an instances service client
+func (c *ComputeEngineClient) createInstancesService(ctx context.Context) (InstancesService, error) {
+	// Use injected service if available (for testing)
+	if c.instancesService != nil {
+		return c.instancesService, nil
+	}
+
+	client, err := compute.NewInstancesRESTClient(ctx, c.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instances client: %w", err)
+	}
+	return &realInstancesService{client: client}, nil
+}
+
+// createMachineTypesService creates a machine types service client
+func (c *ComputeEngineClient) createMachineTypesService(ctx context.Context) (MachineTypesService, error) {
+	// Use injected service if available (for testing)
+	if c.machineTypesService != nil {
+		return c.machineTypesService, nil
+	}
+
+	client, err := compute.NewMachineTypesRESTClient(ctx, c.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create machine types client: %w", err)
+	}
+	return &realMachineTypesService{client: client}, nil
+}
@@ -0,0 +1,116 @@
+package computeengine
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+// MockInstancesService mocks the InstancesService interface.
+type MockInstancesService struct {
+	pairs []compute.InstancesScopedListPair
+}
+
+func (m *MockInstancesService) AggregatedList(ctx context.Context, req *computepb.AggregatedListInstancesRequest) InstancesPairIterator {
+	return &MockInstancesPairIterator{pairs: m.pairs}
+}
+
+func (m *MockInstancesService) Close() error {
+	return nil
+}
+
+// MockInstancesPairIterator mocks the InstancesPairIterator interface.
+type MockInstancesPairIterator struct {
+	pairs []compute.InstancesScopedListPair
+	index int
+}
+
+func (m *MockInstancesPairIterator) Next() (compute.InstancesScopedListPair, error) {
+	if m.index >= len(m.pairs) {
+		return compute.InstancesScopedListPair{}, iterator.Done
+	}
+	p := m.pairs[m.index]
+	m.index++
+	return p, nil
+}
+
+func runningInstance(name, zone, machineType string) *computepb.Instance {
+	return &computepb.Instance{
+		Name:        stringPtr(name),
+		Status:      stringPtr(computepb.Instance_RUNNING.String()),
+		MachineType: stringPtr("https://www.googleapis.com/compute/v1/projects/test-project/zones/" + zone + "/machineTypes/" + machineType),
+	}
+}
+
+func TestGetCommittableUsage_SumsRunningInstancesPerCommitmentType(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+
+	spot := runningInstance("spot", "us-central1-a", "n2-standard-8")
+	spot.Scheduling = &computepb.Scheduling{ProvisioningModel: stringPtr(computepb.Scheduling_SPOT.String())}
+	stopped := runningInstance("stopped", "us-central1-a", "n2-standard-8")
+	stopped.Status = stringPtr(computepb.Instance_TERMINATED.String())
+
+	client.SetInstancesService(&MockInstancesService{pairs: []compute.InstancesScopedListPair{
+		{Key: "zones/us-central1-a", Value: &computepb.InstancesScopedList{Instances: []*computepb.Instance{
+			runningInstance("web-1", "us-central1-a", "n2-standard-8"),
+			runningInstance("web-2", "us-central1-b", "n2-custom-4-16384"),
+			runningInstance("legacy", "us-central1-a", "custom-2-7680"),
+			runningInstance("tiny", "us-central1-a", "f1-micro"),
+			spot,
+			stopped,
+		}}},
+		{Key: "zones/europe-west1-b", Value: &computepb.InstancesScopedList{Instances: []*computepb.Instance{
+			runningInstance("eu", "europe-west1-b", "n2-standard-8"),
+		}}},
+		{Key: "zones/us-central1-c"}, // zone with no instances
+	}})
+	client.SetMachineTypesService(&MockMachineTypesService{machineTypes: []*computepb.MachineType{
+		{Name: stringPtr("n2-standard-8"), GuestCpus: int32Ptr(8), MemoryMb: int32Ptr(32768)},
+	}})
+
+	usage, err := client.GetCommittableUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]CommittableUsage{
+		computepb.Commitment_GENERAL_PURPOSE_N2.String(): {VCPUs: 12, MemoryMB: 49152},
+		computepb.Commitment_GENERAL_PURPOSE.String():    {VCPUs: 2, MemoryMB: 7680},
+	}, usage)
+}
+
+func TestGetCommittableUsage_UnknownMachineTypeFailsLoud(t *testing.T) {
+	ctx := context.Background()
+	client, _ := NewClient(ctx, "test-project", "us-central1")
+	client.SetInstancesService(&MockInstancesService{pairs: []compute.InstancesScopedListPair{
+		{Key: "zones/us-central1-a", Value: &computepb.InstancesScopedList{Instances: []*computepb.Instance{
+			runningInstance("web-1", "us-central1-a", "n2-standard-8"),
+		}}},
+	}})
+	client.SetMachineTypesService(&MockMachineTypesService{})
+
+	_, err := client.GetCommittableUsage(ctx)
+	assert.ErrorContains(t, err, "machine type n2-standard-8 not found in zone us-central1-a")
+}
+
+func TestParseCustomMachineType(t *testing.T) {
+	cases := map[string]machineTypeSpec{
+		"n2-custom-8-32768":     {vcpus: 8, memoryMB: 32768},
+		"custom-4-15360":        {vcpus: 4, memoryMB: 15360},
+		"n2-custom-8-65536-ext": {vcpus: 8, memoryMB: 65536},
+	}
+	for name, want := range cases {
+		got, ok := parseCustomMachineType(name)
+		require.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+	_, ok := parseCustomMachineType("n2-standard-8")
+	assert.False(t, ok)
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
@@ -670,6 +670,135 @@ resource "aws_lambda_permission" "eventbridge_analytics_collect" {
   source_arn    = aws_cloudwatch_event_rule.analytics_collect[0].arn
 }
 
+# ==============================================
+# EventBridge Rule for Commitment Inventory Sync
+# ==============================================
+#
+# Periodic run of the inventory_sync task: list the existing RIs, Savings
+# Plans and CUDs of every enabled cloud account and upsert them into
+# commitments_inventory, so commitments bought outside CUDly show up in the
+# inventory, coverage and dashboard views. Advisory-lock guarded like the
+# other scheduled tasks.
+
+resource "aws_cloudwatch_event_rule" "inventory_sync" {
+  count = var.enable_inventory_sync_schedule ? 1 : 0
+
+  name                = "${var.stack_name}-inventory-sync"
+  description         = "Trigger commitment inventory sync (inventory_sync task)"
+  schedule_expression = var.inventory_sync_schedule
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "inventory_sync" {
+  count = var.enable_inventory_sync_schedule ? 1 : 0
+
+  rule      = aws_cloudwatch_event_rule.inventory_sync[0].name
+  target_id = "lambda"
+  arn       = aws_lambda_function.main.arn
+
+  input = jsonencode({
+    action = "inventory_sync"
+  })
+}
+
+resource "aws_lambda_permission" "eventbridge_inventory_sync" {
+  count = var.enable_inventory_sync_schedule ? 1 : 0
+
+  statement_id  = "AllowExecutionFromEventBridgeInventorySync"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.main.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.inventory_sync[0].arn
+}
+
+# ==============================================
+# EventBridge Rule for Commitment Renewal Planning
+# ==============================================
+#
+# Periodic run of the renewal_plan task: propose replacements for the
+# commitments in commitments_inventory that expire within
+# RENEWAL_LOOKAHEAD_DAYS, draft them as pending purchases timed to start at
+# expiry, and email the approvers. Runs after inventory_sync has had a
+# chance to refresh the inventory. Advisory-lock guarded like the other
+# scheduled tasks.
+
+resource "aws_cloudwatch_event_rule" "renewal_plan" {
+  count = var.enable_renewal_plan_schedule ? 1 : 0
+
+  name                = "${var.stack_name}-renewal-plan"
+  description         = "Trigger commitment renewal planning (renewal_plan task)"
+  schedule_expression = var.renewal_plan_schedule
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "renewal_plan" {
+  count = var.enable_renewal_plan_schedule ? 1 : 0
+
+  rule      = aws_cloudwatch_event_rule.renewal_plan[0].name
+  target_id = "lambda"
+  arn       = aws_lambda_function.main.arn
+
+  input = jsonencode({
+    action = "renewal_plan"
+  })
+}
+
+resource "aws_lambda_permission" "eventbridge_renewal_plan" {
+  count = var.enable_renewal_plan_schedule ? 1 : 0
+
+  statement_id  = "AllowExecutionFromEventBridgeRenewalPlan"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.main.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.renewal_plan[0].arn
+}
+
+# ==============================================
+# EventBridge Rule for Shadow Purchase Evaluation
+# ==============================================
+#
+# Periodic run of the shadow_evaluate task: score the purchases recorded by
+# shadow-mode purchase plans and ladder configs against the on-demand usage
+# that followed them. Each run scores only the days since the previous one,
+# so a daily schedule keeps the evidence current. Shadow ladder tranches are
+# priced from the collected recommendations of the layer they would buy;
+# LADDER_SHADOW_DISCOUNT_PCT (see additional_env_vars) optionally prices
+# those no recommendation covers, which otherwise get no discount.
+
+resource "aws_cloudwatch_event_rule" "shadow_evaluate" {
+  count = var.enable_shadow_evaluate_schedule ? 1 : 0
+
+  name                = "${var.stack_name}-shadow-evaluate"
+  description         = "Trigger shadow purchase evaluation (shadow_evaluate task)"
+  schedule_expression = var.shadow_evaluate_schedule
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "shadow_evaluate" {
+  count = var.enable_shadow_evaluate_schedule ? 1 : 0
+
+  rule      = aws_cloudwatch_event_rule.shadow_evaluate[0].name
+  target_id = "lambda"
+  arn       = aws_lambda_function.main.arn
+
+  input = jsonencode({
+    action = "shadow_evaluate"
+  })
+}
+
+resource "aws_lambda_permission" "eventbridge_shadow_evaluate" {
+  count = var.enable_shadow_evaluate_schedule ? 1 : 0
+
+  statement_id  = "AllowExecutionFromEventBridgeShadowEvaluate"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.main.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.shadow_evaluate[0].arn
+}
+
 # ==============================================
 # EventBridge Rule for Commitment-Ladder Planning Run
 # ==============================================
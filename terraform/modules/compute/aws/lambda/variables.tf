@@ -207,6 +207,42 @@ variable "analytics_collect_schedule" {
   default     = "rate(1 day)"
 }
 
+variable "enable_inventory_sync_schedule" {
+  description = "Enable the scheduled commitment inventory sync. When true, EventBridge periodically invokes the inventory_sync task, which lists every enabled account's existing commitments so externally purchased ones are counted."
+  type        = bool
+  default     = true
+}
+
+variable "inventory_sync_schedule" {
+  description = "EventBridge schedule for the inventory_sync task. Commitments change rarely, so a daily sweep keeps the inventory fresh without excess provider API calls. rate() starts from deployment time; use cron() for fixed clock times."
+  type        = string
+  default     = "rate(1 day)"
+}
+
+variable "enable_renewal_plan_schedule" {
+  description = "Enable the scheduled commitment renewal planner. When true, EventBridge periodically invokes the renewal_plan task, which drafts replacement purchases for expiring commitments and emails them for approval. Default false: opt in once the commitments inventory is populated."
+  type        = bool
+  default     = false
+}
+
+variable "renewal_plan_schedule" {
+  description = "EventBridge schedule for the renewal_plan task. A commitment is planned once per expiry, so a daily run picks up each one shortly after it enters the lookahead window. rate() starts from deployment time; use cron() for fixed clock times."
+  type        = string
+  default     = "rate(1 day)"
+}
+
+variable "enable_shadow_evaluate_schedule" {
+  description = "Enable the scheduled shadow purchase evaluation. When true, EventBridge periodically invokes the shadow_evaluate task, which scores the purchases recorded by shadow-mode plans and ladder configs against later usage. Default false: opt in once a plan or ladder config runs in shadow mode."
+  type        = bool
+  default     = false
+}
+
+variable "shadow_evaluate_schedule" {
+  description = "EventBridge schedule for the shadow_evaluate task. Usage is scored by the day, so running more than daily adds nothing. rate() starts from deployment time; use cron() for fixed clock times."
+  type        = string
+  default     = "rate(1 day)"
+}
+
 variable "enable_ladder_run_schedule" {
   description = "Enable the scheduled commitment-ladder planning run. When true, EventBridge fires the ladder_run task daily. Default false until laddering is promoted to GA."
   type        = bool